@@ -630,6 +630,12 @@ export interface DataSourceInstanceSettings<T extends DataSourceJsonData = DataS
   basicAuth?: string;
   withCredentials?: boolean;
 
+  /**
+   * The features of the data source published by its last successful health check, like the support of streaming.
+   * A missing feature is unknown, the data source then behaves as if it is supported.
+   */
+  capabilities?: Record<string, boolean>;
+
   /** When the name+uid are based on template variables, maintain access to the real values */
   rawRef?: DataSourceRef;
 }
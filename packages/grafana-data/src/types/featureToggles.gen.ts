@@ -83,4 +83,7 @@ export interface FeatureToggles {
   prometheusMetricEncyclopedia?: boolean;
   timeSeriesTable?: boolean;
   influxdbBackendMigration?: boolean;
+  dashboardQueryConversion?: boolean;
+  lokiQuerySharding?: boolean;
+  queryDeduplication?: boolean;
 }
@@ -33,6 +33,33 @@ func (hs *HTTPServer) AdminGetSettings(c *contextmodel.ReqContext) response.Resp
 	return response.JSON(http.StatusOK, settings)
 }
 
+// swagger:route POST /admin/settings/reload admin adminReloadSettings
+//
+// Reload settings.
+//
+// Reads the configuration files again and applies the changed settings that don't require a restart: the log levels
+// and outputs, the SMTP settings, the default limits of the quotas and the TTL of the query result cache. The other
+// changed settings are reported as requiring a restart.
+//
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `settings:reload`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminReloadSettingsResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminReloadSettings(c *contextmodel.ReqContext) response.Response {
+	report, err := hs.Cfg.Reload()
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to reload settings", err)
+	}
+	hs.log.Info("Reloaded settings", "applied", report.Applied, "requiresRestart", report.RequiresRestart, "user", c.Login)
+	return response.JSON(http.StatusOK, report)
+}
+
 // swagger:route GET /admin/stats admin adminGetStats
 //
 // Fetch Grafana Stats.
@@ -106,6 +133,12 @@ type GetSettingsResponse struct {
 	Body setting.SettingsBag `json:"body"`
 }
 
+// swagger:response adminReloadSettingsResponse
+type ReloadSettingsResponse struct {
+	// in:body
+	Body setting.ReloadReport `json:"body"`
+}
+
 // swagger:response adminGetStatsResponse
 type GetStatsResponse struct {
 	// in:body
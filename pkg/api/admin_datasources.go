@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/datasources/drift"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /admin/datasources/drift admin adminGetDataSourcesDrift
+//
+// Get the data sources that drifted from their settings.
+//
+// Compares the data sources of all the organizations with their live instances and with the datasource provisioning
+// files. Returns the data sources whose live instance was created from older settings than the stored settings, the
+// data sources whose stored settings differ from the provisioning files, and the data sources of the provisioning files
+// that are not stored. The values of the secure settings are not returned.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `provisioning:reload` and scope `provisioners:datasources`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminGetDataSourcesDriftResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminGetDataSourcesDrift(c *contextmodel.ReqContext) response.Response {
+	report, err := hs.dataSourceDriftService.Check(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to compare the data sources with their settings", err)
+	}
+	return response.JSON(http.StatusOK, report)
+}
+
+// swagger:route POST /admin/datasources/reload admin adminReloadDataSources
+//
+// Reload data sources.
+//
+// Recreates the live instances of data sources from their stored settings without restarting Grafana: the HTTP
+// clients of Grafana and the instances of the backend plugins. The instances are recreated the next time the data
+// sources are queried. Reloads the data sources of the organization with the given UIDs, or all the data sources of
+// the organization without UIDs. The organization is the current organization of the user without `orgId`.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `provisioning:reload` and scope `provisioners:datasources`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminReloadDataSourcesResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) AdminReloadDataSources(c *contextmodel.ReqContext) response.Response {
+	cmd := drift.ReloadCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.OrgID == 0 {
+		cmd.OrgID = c.OrgID
+	}
+
+	report, err := hs.dataSourceDriftService.Reload(c.Req.Context(), cmd)
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return response.Error(http.StatusNotFound, "Data source not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to reload data sources", err)
+	}
+	return response.JSON(http.StatusOK, report)
+}
+
+// swagger:parameters adminReloadDataSources
+type AdminReloadDataSourcesParams struct {
+	// in:body
+	// required:true
+	Body drift.ReloadCommand
+}
+
+// swagger:response adminGetDataSourcesDriftResponse
+type AdminGetDataSourcesDriftResponse struct {
+	// in: body
+	Body drift.Report `json:"body"`
+}
+
+// swagger:response adminReloadDataSourcesResponse
+type AdminReloadDataSourcesResponse struct {
+	// in: body
+	Body drift.ReloadReport `json:"body"`
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/datasources/drift"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+type fakeDataSourceDriftService struct {
+	reloaded []drift.ReloadCommand
+}
+
+func (f *fakeDataSourceDriftService) Check(ctx context.Context) (*drift.Report, error) {
+	return &drift.Report{Datasources: []drift.DatasourceDrift{}, Missing: []drift.MissingDatasource{{OrgID: 1, Name: "Tempo"}}}, nil
+}
+
+func (f *fakeDataSourceDriftService) Reload(ctx context.Context, cmd drift.ReloadCommand) (*drift.ReloadReport, error) {
+	f.reloaded = append(f.reloaded, cmd)
+	report := &drift.ReloadReport{Reloaded: []drift.ReloadedDatasource{}}
+	for _, uid := range cmd.UIDs {
+		if uid == "unknown" {
+			return nil, datasources.ErrDataSourceNotFound
+		}
+		report.Reloaded = append(report.Reloaded, drift.ReloadedDatasource{OrgID: cmd.OrgID, UID: uid})
+	}
+	return report, nil
+}
+
+func TestAPI_AdminDataSources(t *testing.T) {
+	permissions := []accesscontrol.Permission{{Action: ActionProvisioningReload, Scope: ScopeProvisionersDatasources}}
+
+	type testCase struct {
+		desc         string
+		method       string
+		url          string
+		body         string
+		permissions  []accesscontrol.Permission
+		expectedCode int
+		expectedBody string
+	}
+	tests := []testCase{
+		{
+			desc:         "should return the drift with the datasources provisioning scope",
+			method:       http.MethodGet,
+			url:          "/api/admin/datasources/drift",
+			permissions:  permissions,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"datasources":[],"missing":[{"orgId":1,"name":"Tempo"}]}`,
+		},
+		{
+			desc:         "should not return the drift without permission",
+			method:       http.MethodGet,
+			url:          "/api/admin/datasources/drift",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should reload the datasources of the current organization",
+			method:       http.MethodPost,
+			url:          "/api/admin/datasources/reload",
+			body:         `{"uids": ["prom"]}`,
+			permissions:  permissions,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"reloaded":[{"orgId":1,"uid":"prom","name":"","updated":"0001-01-01T00:00:00Z"}]}`,
+		},
+		{
+			desc:         "should fail to reload an unknown datasource",
+			method:       http.MethodPost,
+			url:          "/api/admin/datasources/reload",
+			body:         `{"orgId": 2, "uids": ["unknown"]}`,
+			permissions:  permissions,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			desc:         "should not reload the datasources without permission",
+			method:       http.MethodPost,
+			url:          "/api/admin/datasources/reload",
+			body:         `{}`,
+			permissions:  []accesscontrol.Permission{{Action: ActionProvisioningReload, Scope: ScopeProvisionersDashboards}},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			driftService := &fakeDataSourceDriftService{}
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.dataSourceDriftService = driftService
+			})
+
+			req := server.NewGetRequest(tt.url)
+			if tt.method == http.MethodPost {
+				req = server.NewPostRequest(tt.url, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+			}
+			res, err := server.Send(webtest.RequestWithSignedInUser(req, userWithPermissions(1, tt.permissions)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, tt.expectedBody, string(body))
+			}
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
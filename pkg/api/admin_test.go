@@ -97,6 +97,17 @@ func TestAPI_AdminGetSettings(t *testing.T) {
 	}
 }
 
+func TestAPI_AdminReloadSettings(t *testing.T) {
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+	})
+
+	res, err := server.Send(webtest.RequestWithSignedInUser(server.NewPostRequest("/api/admin/settings/reload", nil), userWithPermissions(1, []accesscontrol.Permission{{Action: accesscontrol.ActionSettingsRead}})))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	require.NoError(t, res.Body.Close())
+}
+
 func TestAdmin_AccessControl(t *testing.T) {
 	type testCase struct {
 		desc         string
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/middleware/cookies"
+	"github.com/grafana/grafana/pkg/services/asyncquery"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// asyncQuerySessionCookie identifies the session of the anonymous users, who share the same identity, so that they
+// only see the queries they started
+const asyncQuerySessionCookie = "grafana_async_query_session"
+
+// asyncQuerySession returns the session of the anonymous users, it is created when they start a query
+func (hs *HTTPServer) asyncQuerySession(c *contextmodel.ReqContext, create bool) (string, error) {
+	if !c.SignedInUser.IsAnonymous {
+		return "", nil
+	}
+	if cookie, err := c.Req.Cookie(asyncQuerySessionCookie); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+	if !create {
+		return "", nil
+	}
+
+	session, err := util.GetRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	// the cookie is only sent to the query endpoints, until the browser is closed
+	cookies.WriteCookie(c.Resp, asyncQuerySessionCookie, session, 0, func() cookies.CookieOptions {
+		options := cookies.NewCookieOptions()
+		options.Path = hs.Cfg.AppSubURL + "/api/ds/query"
+		return options
+	})
+	return session, nil
+}
+
+// startAsyncQuery starts the query in the background and returns its state, the client polls it until it is done
+func (hs *HTTPServer) startAsyncQuery(c *contextmodel.ReqContext, reqDTO dtos.MetricRequest) response.Response {
+	session, err := hs.asyncQuerySession(c, true)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to create the query session", err)
+	}
+	q, err := hs.asyncQueryService.Start(c.Req.Context(), c.SignedInUser, session, c.SkipCache, reqDTO)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to start the query", err)
+	}
+	return response.JSON(http.StatusAccepted, asyncQueryDTO(q)).
+		SetHeader("Location", hs.Cfg.AppSubURL+"/api/ds/query/async/"+q.UID)
+}
+
+// swagger:route GET /ds/query/async/{uid} ds getAsyncQuery
+//
+// Get the state of a query started with the async parameter of /ds/query.
+//
+// Responses:
+// 200: getAsyncQueryResponse
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetAsyncQuery(c *contextmodel.ReqContext) response.Response {
+	session, _ := hs.asyncQuerySession(c, false)
+	q, err := hs.asyncQueryService.Get(c.Req.Context(), c.SignedInUser, session, web.Params(c.Req)[":uid"], false)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get the query", err)
+	}
+	return response.JSON(http.StatusOK, asyncQueryDTO(q))
+}
+
+// swagger:route GET /ds/query/async/{uid}/result ds getAsyncQueryResult
+//
+// Get the result of a query started with the async parameter of /ds/query.
+//
+// The result is returned like the result of /ds/query once the query is done, and the state of the query is returned
+// with 202 while it is pending or running. The error of the failed queries is returned with its status code.
+//
+// Responses:
+// 200: queryMetricsWithExpressionsRespons
+// 202: getAsyncQueryResponse
+// 207: queryMetricsWithExpressionsRespons
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetAsyncQueryResult(c *contextmodel.ReqContext) response.Response {
+	session, _ := hs.asyncQuerySession(c, false)
+	q, err := hs.asyncQueryService.Get(c.Req.Context(), c.SignedInUser, session, web.Params(c.Req)[":uid"], true)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get the query", err)
+	}
+
+	switch q.Status {
+	case asyncquery.StatusDone:
+		resp := &backend.QueryDataResponse{}
+		if err := json.Unmarshal([]byte(q.Result), resp); err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to read the result of the query", err)
+		}
+		if acceptsParquet(c.Req) {
+			return hs.toParquetResponse(resp)
+		}
+		return hs.toJsonStreamingResponse(resp)
+	case asyncquery.StatusFailed:
+		return response.Error(q.ErrorStatus, q.Error, nil)
+	default:
+		return response.JSON(http.StatusAccepted, asyncQueryDTO(q))
+	}
+}
+
+// swagger:route DELETE /ds/query/async/{uid} ds deleteAsyncQuery
+//
+// Delete a query started with the async parameter of /ds/query.
+//
+// The query is canceled when it is still running, and its result is deleted.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) DeleteAsyncQuery(c *contextmodel.ReqContext) response.Response {
+	session, _ := hs.asyncQuerySession(c, false)
+	if err := hs.asyncQueryService.Delete(c.Req.Context(), c.SignedInUser, session, web.Params(c.Req)[":uid"]); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete the query", err)
+	}
+	return response.Success("Query deleted")
+}
+
+func asyncQueryDTO(q *asyncquery.AsyncQuery) dtos.AsyncQuery {
+	return dtos.AsyncQuery{
+		UID:     q.UID,
+		Status:  string(q.Status),
+		Error:   q.Error,
+		Created: time.Unix(q.Created, 0),
+		Updated: time.Unix(q.Updated, 0),
+		Expires: time.Unix(q.Expires, 0),
+	}
+}
+
+// swagger:parameters getAsyncQuery getAsyncQueryResult deleteAsyncQuery
+type AsyncQueryParams struct {
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+}
+
+// swagger:response getAsyncQueryResponse
+type GetAsyncQueryResponse struct {
+	// in: body
+	Body dtos.AsyncQuery `json:"body"`
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/asyncquery"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+type fakeAsyncQueryService struct {
+	query    *asyncquery.AsyncQuery
+	err      error
+	started  []dtos.MetricRequest
+	deleted  []string
+	sessions []string
+}
+
+func (f *fakeAsyncQueryService) Start(_ context.Context, _ *user.SignedInUser, session string, _ bool, reqDTO dtos.MetricRequest) (*asyncquery.AsyncQuery, error) {
+	f.started = append(f.started, reqDTO)
+	f.sessions = append(f.sessions, session)
+	return f.query, f.err
+}
+
+func (f *fakeAsyncQueryService) Get(_ context.Context, _ *user.SignedInUser, session string, uid string, withResult bool) (*asyncquery.AsyncQuery, error) {
+	f.sessions = append(f.sessions, session)
+	if f.err != nil {
+		return nil, f.err
+	}
+	q := *f.query
+	if !withResult {
+		q.Result = ""
+	}
+	return &q, nil
+}
+
+func (f *fakeAsyncQueryService) Delete(_ context.Context, _ *user.SignedInUser, _ string, uid string) error {
+	f.deleted = append(f.deleted, uid)
+	return f.err
+}
+
+func TestAPIEndpoint_AsyncQuery(t *testing.T) {
+	fake := &fakeAsyncQueryService{}
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.asyncQueryService = fake
+		hs.Features = featuremgmt.WithFeatures()
+		hs.QuotaService = quotatest.New(false, nil)
+	})
+	send := func(t *testing.T, req *http.Request) *http.Response {
+		webtest.RequestWithSignedInUser(req, &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleViewer})
+		resp, err := server.SendJSON(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, resp.Body.Close()) })
+		return resp
+	}
+	readState := func(t *testing.T, resp *http.Response) dtos.AsyncQuery {
+		var state dtos.AsyncQuery
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+		return state
+	}
+
+	t.Run("the async queries are started", func(t *testing.T) {
+		*fake = fakeAsyncQueryService{query: &asyncquery.AsyncQuery{UID: "q1", Status: asyncquery.StatusPending}}
+		resp := send(t, server.NewPostRequest("/api/ds/query?async=true", strings.NewReader(reqValid)))
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		assert.Equal(t, "/api/ds/query/async/q1", resp.Header.Get("Location"))
+		assert.Equal(t, "pending", readState(t, resp).Status)
+		require.Len(t, fake.started, 1)
+		require.Len(t, fake.started[0].Queries, 1)
+	})
+
+	t.Run("the state of the queries", func(t *testing.T) {
+		*fake = fakeAsyncQueryService{query: &asyncquery.AsyncQuery{UID: "q1", Status: asyncquery.StatusRunning}}
+		resp := send(t, server.NewGetRequest("/api/ds/query/async/q1"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		state := readState(t, resp)
+		assert.Equal(t, "q1", state.UID)
+		assert.Equal(t, "running", state.Status)
+	})
+
+	t.Run("the result of the unfinished queries is their state", func(t *testing.T) {
+		*fake = fakeAsyncQueryService{query: &asyncquery.AsyncQuery{UID: "q1", Status: asyncquery.StatusRunning}}
+		resp := send(t, server.NewGetRequest("/api/ds/query/async/q1/result"))
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		assert.Equal(t, "running", readState(t, resp).Status)
+	})
+
+	t.Run("the result of the done queries", func(t *testing.T) {
+		result := `{"results":{"A":{"status":200,"frames":[{"schema":{"name":"frame","fields":[{"name":"value","type":"number","typeInfo":{"frame":"int64"}}]},"data":{"values":[[1,2]]}}]}}}`
+		*fake = fakeAsyncQueryService{query: &asyncquery.AsyncQuery{UID: "q1", Status: asyncquery.StatusDone, Result: result}}
+		resp := send(t, server.NewGetRequest("/api/ds/query/async/q1/result"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, result, string(body))
+	})
+
+	t.Run("the error of the failed queries", func(t *testing.T) {
+		*fake = fakeAsyncQueryService{query: &asyncquery.AsyncQuery{UID: "q1", Status: asyncquery.StatusFailed, Error: "Access denied to data source", ErrorStatus: http.StatusForbidden}}
+		resp := send(t, server.NewGetRequest("/api/ds/query/async/q1/result"))
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "Access denied to data source", body["message"])
+	})
+
+	t.Run("the unknown queries are not found", func(t *testing.T) {
+		*fake = fakeAsyncQueryService{err: asyncquery.ErrQueryNotFound.Errorf("async query not found")}
+		resp := send(t, server.NewGetRequest("/api/ds/query/async/unknown"))
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("the queries of the anonymous users are owned by their session", func(t *testing.T) {
+		*fake = fakeAsyncQueryService{query: &asyncquery.AsyncQuery{UID: "q1", Status: asyncquery.StatusPending}}
+		sendAnonymous := func(req *http.Request) *http.Response {
+			webtest.RequestWithSignedInUser(req, &user.SignedInUser{OrgID: 1, OrgRole: org.RoleViewer, IsAnonymous: true})
+			resp, err := server.SendJSON(req)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, resp.Body.Close()) })
+			return resp
+		}
+
+		resp := sendAnonymous(server.NewPostRequest("/api/ds/query?async=true", strings.NewReader(reqValid)))
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		require.Len(t, resp.Cookies(), 1)
+		cookie := resp.Cookies()[0]
+		assert.Equal(t, asyncQuerySessionCookie, cookie.Name)
+		assert.Equal(t, "/api/ds/query", cookie.Path)
+		assert.True(t, cookie.HttpOnly)
+
+		req := server.NewGetRequest("/api/ds/query/async/q1")
+		req.AddCookie(cookie)
+		require.Equal(t, http.StatusOK, sendAnonymous(req).StatusCode)
+		require.Equal(t, http.StatusOK, sendAnonymous(server.NewGetRequest("/api/ds/query/async/q1")).StatusCode)
+		assert.Equal(t, []string{cookie.Value, cookie.Value, ""}, fake.sessions)
+		assert.NotEmpty(t, cookie.Value)
+	})
+
+	t.Run("the queries are deleted", func(t *testing.T) {
+		*fake = fakeAsyncQueryService{}
+		resp := send(t, server.NewRequest(http.MethodDelete, "/api/ds/query/async/q1", nil))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{"q1"}, fake.deleted)
+	})
+}
@@ -233,7 +233,7 @@ func (hs *HTTPServer) GetDashboard(c *contextmodel.ReqContext) response.Response
 	}
 
 	c.TimeRequest(metrics.MApiDashboardGet)
-	return response.JSON(http.StatusOK, dto)
+	return response.JSON(http.StatusOK, dto).WithETag()
 }
 
 func (hs *HTTPServer) getAnnotationPermissionsByScope(c *contextmodel.ReqContext, actions *dtos.AnnotationActions, scope string) {
@@ -418,6 +418,22 @@ func (hs *HTTPServer) postDashboard(c *contextmodel.ReqContext, cmd dashboards.S
 
 	dash := cmd.GetDashboardModel()
 	newDashboard := dash.ID == 0
+
+	if resp := hs.applyFolderSettings(c, cmd.FolderUID, dash); resp != nil {
+		return resp
+	}
+
+	var convertedQueries []dashboards.QueryConversion
+	if hs.Features.IsEnabled(featuremgmt.FlagDashboardQueryConversion) {
+		convertedQueries = dashboards.ConvertDashboardQueries(dash.Data, hs.queryHooks.QueryConverter, func(uid string) string {
+			ds, err := hs.DataSourceCache.GetDatasourceByUID(ctx, uid, c.SignedInUser, c.SkipCache)
+			if err != nil {
+				return ""
+			}
+			return ds.Type
+		})
+	}
+
 	if newDashboard {
 		limitReached, err := hs.QuotaService.QuotaReached(c, dashboards.QuotaTargetSrv)
 		if err != nil {
@@ -499,14 +515,18 @@ func (hs *HTTPServer) postDashboard(c *contextmodel.ReqContext, cmd dashboards.S
 	}
 
 	c.TimeRequest(metrics.MApiDashboardSave)
-	return response.JSON(http.StatusOK, util.DynMap{
+	result := util.DynMap{
 		"status":  "success",
 		"slug":    dashboard.Slug,
 		"version": dashboard.Version,
 		"id":      dashboard.ID,
 		"uid":     dashboard.UID,
 		"url":     dashboard.GetURL(),
-	})
+	}
+	if len(convertedQueries) > 0 {
+		result["convertedQueries"] = convertedQueries
+	}
+	return response.JSON(http.StatusOK, result)
 }
 
 // swagger:route GET /dashboards/home dashboards getHomeDashboard
@@ -771,6 +791,56 @@ func (hs *HTTPServer) GetDashboardVersion(c *contextmodel.ReqContext) response.R
 	return response.JSON(http.StatusOK, dashVersionMeta)
 }
 
+// swagger:route GET /dashboards/uid/{uid}/versions/{DashboardVersionID}/diff dashboard_versions getDashboardVersionDiffByUID
+//
+// Get the changes of a dashboard version using UID.
+//
+// Classifies the changes of the version compared to the base version, or to its parent version when the base version is not given: panels added or removed, layout, query and datasource changes.
+//
+// Responses:
+// 200: dashboardVersionDiffResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetDashboardVersionDiff(c *contextmodel.ReqContext) response.Response {
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.OrgID, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian, err := guardian.NewByDashboard(c.Req.Context(), dash, c.OrgID, c.SignedInUser)
+	if err != nil {
+		return response.Err(err)
+	}
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	version, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 32)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "version is invalid", err)
+	}
+	query := dashver.DiffDashboardVersionsQuery{
+		OrgID:        c.OrgID,
+		DashboardID:  dash.ID,
+		DashboardUID: dash.UID,
+		BaseVersion:  c.QueryInt("base"),
+		NewVersion:   int(version),
+	}
+
+	res, err := hs.dashboardVersionService.Diff(c.Req.Context(), &query)
+	if err != nil {
+		if errors.Is(err, dashver.ErrDashboardVersionNotFound) {
+			return response.Error(http.StatusNotFound, "Dashboard version not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Unable to compute diff", err)
+	}
+
+	return response.JSON(http.StatusOK, res)
+}
+
 // swagger:route POST /dashboards/validate dashboards alpha validateDashboard
 //
 // Validates a dashboard JSON against the schema.
@@ -1104,6 +1174,19 @@ type GetDashboardVersionByUIDParams struct {
 	UID string `json:"uid"`
 }
 
+// swagger:parameters getDashboardVersionDiffByUID
+type GetDashboardVersionDiffByUIDParams struct {
+	// in:path
+	DashboardVersionID int64
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+	// Version compared to, the parent version of the version when 0
+	// in:query
+	// required:false
+	Base int64 `json:"base"`
+}
+
 // swagger:parameters getDashboardVersions getDashboardVersionsByUID
 type GetDashboardVersionsParams struct {
 	// Maximum number of results to return
@@ -1225,6 +1308,10 @@ type PostDashboardResponse struct {
 		// required: true
 		// example: /d/nHz3SXiiz/my-dashboard
 		URL string `json:"url"`
+
+		// ConvertedQueries The panel queries migrated to the current schema of their datasource while saving.
+		// required: false
+		ConvertedQueries []dashboards.QueryConversion `json:"convertedQueries,omitempty"`
 	} `json:"body"`
 }
 
@@ -1276,6 +1363,12 @@ type DashboardVersionResponse struct {
 	Body *dashver.DashboardVersionMeta `json:"body"`
 }
 
+// swagger:response dashboardVersionDiffResponse
+type DashboardVersionDiffResponse struct {
+	// in: body
+	Body *dashver.DashboardVersionDiff `json:"body"`
+}
+
 // swagger:response validateDashboardResponse
 type ValidateDashboardResponse struct {
 	IsValid bool   `json:"isValid"`
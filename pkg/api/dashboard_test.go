@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/db/dbtest"
@@ -203,6 +204,15 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 					hs.callGetDashboardVersions(sc)
 					assert.Equal(t, 403, sc.resp.Code)
 				}, mockSQLStore)
+
+			loggedInUserScenarioWithRole(t, "When calling GET on", "GET", "/api/dashboards/uid/abcdefghi/versions/2/diff",
+				"/api/dashboards/uid/:uid/versions/:id/diff", role, func(sc *scenarioContext) {
+					setUp()
+					sc.sqlStore = mockSQLStore
+
+					hs.callGetDashboardVersionDiff(sc)
+					assert.Equal(t, 403, sc.resp.Code)
+				}, mockSQLStore)
 		})
 
 		t.Run("When user is an Org Editor", func(t *testing.T) {
@@ -234,6 +244,24 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 
 					assert.Equal(t, 200, sc.resp.Code)
 				}, mockSQLStore)
+
+			loggedInUserScenarioWithRole(t, "When calling GET on", "GET", "/api/dashboards/uid/abcdefghi/versions/2/diff",
+				"/api/dashboards/uid/:uid/versions/:id/diff", role, func(sc *scenarioContext) {
+					setUp()
+					fakeDashboardVersionService.ExpectedDashboardVersionDiff = &dashver.DashboardVersionDiff{
+						DashboardUID: "abcdefghi",
+						BaseVersion:  1,
+						NewVersion:   2,
+						SemanticDiff: dashdiffs.SemanticDiff{Kinds: []dashdiffs.ChangeKind{dashdiffs.ChangeKindLayout}, LayoutOnly: true},
+					}
+					hs.callGetDashboardVersionDiff(sc)
+
+					assert.Equal(t, 200, sc.resp.Code)
+					diff := dashver.DashboardVersionDiff{}
+					require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&diff))
+					assert.True(t, diff.LayoutOnly)
+					assert.Equal(t, 2, diff.NewVersion)
+				}, mockSQLStore)
 		})
 	})
 
@@ -1005,6 +1033,11 @@ func (hs *HTTPServer) callGetDashboardVersion(sc *scenarioContext) {
 	sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
 }
 
+func (hs *HTTPServer) callGetDashboardVersionDiff(sc *scenarioContext) {
+	sc.handlerFunc = hs.GetDashboardVersionDiff
+	sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+}
+
 func (hs *HTTPServer) callGetDashboardVersions(sc *scenarioContext) {
 	sc.handlerFunc = hs.GetDashboardVersions
 	sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
@@ -1224,6 +1257,10 @@ func (l *mockLibraryElementService) GetElement(c context.Context, signedInUser *
 	return model.LibraryElementDTO{}, nil
 }
 
+func (l *mockLibraryElementService) PatchElement(c context.Context, signedInUser *user.SignedInUser, cmd model.PatchLibraryElementCommand, UID string) (model.LibraryElementDTO, error) {
+	return model.LibraryElementDTO{}, nil
+}
+
 // GetElementsForDashboard gets all connected elements for a specific dashboard.
 func (l *mockLibraryElementService) GetElementsForDashboard(c context.Context, dashboardID int64) (map[string]model.LibraryElementDTO, error) {
 	return map[string]model.LibraryElementDTO{}, nil
@@ -1,6 +1,12 @@
 package api
 
-import contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasourceproxy"
+)
 
 // swagger:route GET /datasources/proxy/{id}/{datasource_proxy_route} datasources datasourceProxyGETcalls
 //
@@ -106,6 +112,32 @@ func (hs *HTTPServer) ProxyDataSourceRequestWithUID(c *contextmodel.ReqContext)
 	hs.DataProxy.ProxyDatasourceRequestWithUID(c, "")
 }
 
+// swagger:route GET /datasources/proxy-usage datasources getDataSourceProxyUsage
+//
+// Get the calls of the data source proxy.
+//
+// Returns the calls of the data source proxy of the organization since Grafana started, by data source, route and
+// caller, to migrate them to the backend of the data sources before making their types backend-only with the
+// `backend_only_types` option of the `[dataproxy]` section of the configuration.
+//
+// If you are running Grafana Enterprise and have Fine-grained access control enabled
+// you need to have a permission with action: `datasources:read` and scope: `datasources:*`.
+//
+// Responses:
+// 200: getDataSourceProxyUsageResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) GetDataSourceProxyUsage(c *contextmodel.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, hs.DataProxy.UsageReport(c.OrgID))
+}
+
+// swagger:response getDataSourceProxyUsageResponse
+type GetDataSourceProxyUsageResponse struct {
+	// in: body
+	Body datasourceproxy.ProxyUsageReport `json:"body"`
+}
+
 // swagger:parameters datasourceProxyDELETEcalls
 type DatasourceProxyDELETEcallsParams struct {
 	// in:path
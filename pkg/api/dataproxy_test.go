@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/datasourceproxy"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestAPIEndpoint_GetDataSourceProxyUsage(t *testing.T) {
+	tests := []struct {
+		desc         string
+		permissions  []accesscontrol.Permission
+		expectedCode int
+	}{
+		{
+			desc:         "should return the proxy usage of the organization",
+			permissions:  []accesscontrol.Permission{{Action: datasources.ActionRead, Scope: datasources.ScopeAll}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should not return the proxy usage without permission on all the datasources",
+			permissions:  []accesscontrol.Permission{{Action: datasources.ActionRead, Scope: datasources.ScopeProvider.GetResourceScopeUID("prom")}},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.Cfg.DataProxyBackendOnlyTypes = map[string]bool{"loki": true, "prometheus": true}
+				hs.DataProxy = datasourceproxy.ProvideService(nil, nil, nil, hs.Cfg, nil, nil, nil, nil, nil, nil, nil)
+			})
+
+			res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/datasources/proxy-usage"), userWithPermissions(1, tt.permissions)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			if tt.expectedCode == http.StatusOK {
+				var report datasourceproxy.ProxyUsageReport
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&report))
+				assert.Equal(t, []string{"loki", "prometheus"}, report.BackendOnlyTypes)
+				assert.Empty(t, report.Calls)
+			}
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
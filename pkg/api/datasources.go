@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,9 +18,12 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	dscapabilities "github.com/grafana/grafana/pkg/services/datasources/capabilities"
 	"github.com/grafana/grafana/pkg/services/datasources/permissions"
+	dsusage "github.com/grafana/grafana/pkg/services/datasources/usage"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/adapters"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
@@ -70,7 +74,7 @@ func (hs *HTTPServer) GetDataSources(c *contextmodel.ReqContext) response.Respon
 			User:      ds.User,
 			BasicAuth: ds.BasicAuth,
 			IsDefault: ds.IsDefault,
-			JsonData:  ds.JsonData,
+			JsonData:  hs.jsonDataForUser(c, ds),
 			ReadOnly:  ds.ReadOnly,
 		}
 
@@ -86,7 +90,7 @@ func (hs *HTTPServer) GetDataSources(c *contextmodel.ReqContext) response.Respon
 
 	sort.Sort(result)
 
-	return response.JSON(http.StatusOK, &result)
+	return response.JSON(http.StatusOK, &result).WithETag()
 }
 
 // swagger:route GET /datasources/{id} datasources getDataSourceByID
@@ -129,11 +133,12 @@ func (hs *HTTPServer) GetDataSourceById(c *contextmodel.ReqContext) response.Res
 	}
 
 	dto := hs.convertModelToDtos(c.Req.Context(), dataSource)
+	dto.JsonData = hs.jsonDataForUser(c, dataSource)
 
 	// Add accesscontrol metadata
 	dto.AccessControl = hs.getAccessControlMetadata(c, c.OrgID, datasources.ScopePrefix, dto.UID)
 
-	return response.JSON(http.StatusOK, &dto)
+	return response.JSON(http.StatusOK, &dto).WithETag()
 }
 
 // swagger:route DELETE /datasources/{id} datasources deleteDataSourceByID
@@ -215,11 +220,115 @@ func (hs *HTTPServer) GetDataSourceByUID(c *contextmodel.ReqContext) response.Re
 	}
 
 	dto := hs.convertModelToDtos(c.Req.Context(), ds)
+	dto.JsonData = hs.jsonDataForUser(c, ds)
 
 	// Add accesscontrol metadata
 	dto.AccessControl = hs.getAccessControlMetadata(c, c.OrgID, datasources.ScopePrefix, dto.UID)
 
-	return response.JSON(http.StatusOK, &dto)
+	return response.JSON(http.StatusOK, &dto).WithETag()
+}
+
+// swagger:route GET /datasources/uid/{uid}/usage datasources getDataSourceUsageByUID
+//
+// Get where a data source is used.
+//
+// Returns the dashboards and panels, alert rules, correlations and recorded queries of the organization that query or
+// reference the data source, to check what must be updated before deleting or replacing it. The dashboards are
+// scanned when the request is made, which can take a while in organizations with many dashboards.
+//
+// If you are running Grafana Enterprise and have Fine-grained access control enabled
+// you need to have a permission with action: `datasources:read` and scopes: `datasources:*`, `datasources:uid:*` and `datasources:uid:kLtEtcRGk` (single data source).
+//
+// Responses:
+// 200: getDataSourceUsageResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetDataSourceUsageByUID(c *contextmodel.ReqContext) response.Response {
+	ds, err := hs.getRawDataSourceByUID(c.Req.Context(), web.Params(c.Req)[":uid"], c.OrgID)
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return response.Error(http.StatusNotFound, "Data source not found", nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to query datasource", err)
+	}
+
+	report, err := hs.dataSourceUsageService.GetUsage(c.Req.Context(), c.SignedInUser, ds.UID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get data source usage", err)
+	}
+	return response.JSON(http.StatusOK, report)
+}
+
+// swagger:route POST /datasources/uid/{uid}/migrate datasources migrateDataSourceByUID
+//
+// Migrate the references of a data source to another data source.
+//
+// Rewrites the references of the data source to the target data source, of the same type, in the dashboards,
+// including their annotation queries, the library panels, the alert rules and the correlations of the organization.
+// The resources are saved as the user: the dashboards and alert rules updated get a new version, and the resources the
+// user cannot update, or that are provisioned, are skipped. With `dryRun`, only the resources that would be updated
+// are returned.
+//
+// If you are running Grafana Enterprise and have Fine-grained access control enabled
+// you need to have a permission with action: `datasources:write` and scopes: `datasources:*`, `datasources:uid:*` and `datasources:uid:kLtEtcRGk` (single data source),
+// and a permission with action `datasources:query` on the target data source.
+//
+// Responses:
+// 200: migrateDataSourceResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) MigrateDataSourceByUID(c *contextmodel.ReqContext) response.Response {
+	cmd := dtos.MigrateDataSourceCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.TargetUID == "" {
+		return response.Error(http.StatusBadRequest, "Target data source UID is required", nil)
+	}
+
+	source, err := hs.getRawDataSourceByUID(c.Req.Context(), web.Params(c.Req)[":uid"], c.OrgID)
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return response.Error(http.StatusNotFound, "Data source not found", nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to query datasource", err)
+	}
+
+	// the migrated queries are run as the users of the resources, the user must be able to query the target
+	canQuery, err := hs.AccessControl.Evaluate(c.Req.Context(), c.SignedInUser,
+		ac.EvalPermission(datasources.ActionQuery, datasources.ScopeProvider.GetResourceScopeUID(cmd.TargetUID)))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to check the permissions of the target data source", err)
+	}
+	if !canQuery {
+		return response.Error(http.StatusForbidden, "Access denied to the target data source", nil)
+	}
+	target, err := hs.getRawDataSourceByUID(c.Req.Context(), cmd.TargetUID, c.OrgID)
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return response.Error(http.StatusNotFound, "Target data source not found", nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to query datasource", err)
+	}
+
+	report, err := hs.dataSourceUsageService.Migrate(c.Req.Context(), dsusage.MigrateCommand{
+		User:   c.SignedInUser,
+		Source: source,
+		Target: target,
+		DryRun: cmd.DryRun,
+	})
+	if err != nil {
+		if errors.Is(err, dsusage.ErrMigrateToSameDatasource) || errors.Is(err, dsusage.ErrMigrateToOtherType) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to migrate data source", err)
+	}
+	return response.JSON(http.StatusOK, report)
 }
 
 // swagger:route DELETE /datasources/uid/{uid} datasources deleteDataSourceByUID
@@ -351,6 +460,53 @@ func validateJSONData(jsonData *simplejson.Json, cfg *setting.Cfg) error {
 	return nil
 }
 
+// tlsFileKeys are the options of the data sources reading TLS files from the disk of the server
+var tlsFileKeys = []string{"tlsCACertFile", "tlsClientCertFile", "tlsClientKeyFile"}
+
+// validateTLSFiles prevents the user from reading any file of the server through the TLS file options of a data
+// source. The files must be in one of the tls_file_dirs of the [datasources] section, only the provisioned data
+// sources can read the other files.
+func validateTLSFiles(jsonData *simplejson.Json, cfg *setting.Cfg) error {
+	if jsonData == nil {
+		return nil
+	}
+
+	for _, key := range tlsFileKeys {
+		path := jsonData.Get(key).MustString()
+		if path == "" {
+			continue
+		}
+		if !isAllowedTLSFile(path, cfg.DataSourceTLSFileDirs) {
+			datasourcesLogger.Error("Forbidden to add a data source TLS file outside of the allowed directories", "option", key, "path", path)
+			return fmt.Errorf("validation error, %s must be in one of the tls_file_dirs of the [datasources] section", key)
+		}
+	}
+	return nil
+}
+
+// isAllowedTLSFile returns true for an absolute path without .. elements in one of the directories
+func isAllowedTLSFile(path string, dirs []string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(path), "/") {
+		if elem == ".." {
+			return false
+		}
+	}
+
+	path = filepath.Clean(path)
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+		if strings.HasPrefix(path, strings.TrimSuffix(filepath.Clean(dir), string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
 // swagger:route POST /datasources datasources addDataSource
 //
 // Create a data source.
@@ -385,6 +541,9 @@ func (hs *HTTPServer) AddDataSource(c *contextmodel.ReqContext) response.Respons
 	if err := validateJSONData(cmd.JsonData, hs.Cfg); err != nil {
 		return response.Error(http.StatusBadRequest, "Failed to add datasource", err)
 	}
+	if err := validateTLSFiles(cmd.JsonData, hs.Cfg); err != nil {
+		return response.Error(http.StatusBadRequest, "Failed to add datasource", err)
+	}
 
 	dataSource, err := hs.DataSourcesService.AddDataSource(c.Req.Context(), &cmd)
 	if err != nil {
@@ -452,6 +611,9 @@ func (hs *HTTPServer) UpdateDataSourceByID(c *contextmodel.ReqContext) response.
 	if err := validateJSONData(cmd.JsonData, hs.Cfg); err != nil {
 		return response.Error(http.StatusBadRequest, "Failed to update datasource", err)
 	}
+	if err := validateTLSFiles(cmd.JsonData, hs.Cfg); err != nil {
+		return response.Error(http.StatusBadRequest, "Failed to update datasource", err)
+	}
 
 	ds, err := hs.getRawDataSourceById(c.Req.Context(), cmd.ID, cmd.OrgID)
 	if err != nil {
@@ -492,6 +654,9 @@ func (hs *HTTPServer) UpdateDataSourceByUID(c *contextmodel.ReqContext) response
 	if err := validateJSONData(cmd.JsonData, hs.Cfg); err != nil {
 		return response.Error(http.StatusBadRequest, "Failed to update datasource", err)
 	}
+	if err := validateTLSFiles(cmd.JsonData, hs.Cfg); err != nil {
+		return response.Error(http.StatusBadRequest, "Failed to update datasource", err)
+	}
 
 	ds, err := hs.getRawDataSourceByUID(c.Req.Context(), web.Params(c.Req)[":uid"], c.OrgID)
 	if err != nil {
@@ -598,7 +763,8 @@ func (hs *HTTPServer) GetDataSourceByName(c *contextmodel.ReqContext) response.R
 	}
 
 	dto := hs.convertModelToDtos(c.Req.Context(), dataSource)
-	return response.JSON(http.StatusOK, &dto)
+	dto.JsonData = hs.jsonDataForUser(c, dataSource)
+	return response.JSON(http.StatusOK, &dto).WithETag()
 }
 
 // swagger:route GET /datasources/id/{name} datasources getDataSourceIdByName
@@ -709,6 +875,20 @@ func (hs *HTTPServer) CallDatasourceResourceWithUID(c *contextmodel.ReqContext)
 	hs.callPluginResourceWithDataSource(c, plugin.ID, ds)
 }
 
+// jsonDataForUser returns the jsondata of the datasource, without the team overrides for the users who can't edit it
+// so that the members of a team can't read the overrides of the other teams
+func (hs *HTTPServer) jsonDataForUser(c *contextmodel.ReqContext, ds *datasources.DataSource) *simplejson.Json {
+	withoutOverrides := datasources.WithoutTeamOverrides(ds.JsonData)
+	if withoutOverrides == ds.JsonData {
+		return ds.JsonData
+	}
+	canWrite, err := hs.AccessControl.Evaluate(c.Req.Context(), c.SignedInUser, ac.EvalPermission(datasources.ActionWrite, datasources.ScopeProvider.GetResourceScopeUID(ds.UID)))
+	if err == nil && canWrite {
+		return ds.JsonData
+	}
+	return withoutOverrides
+}
+
 func (hs *HTTPServer) convertModelToDtos(ctx context.Context, ds *datasources.DataSource) dtos.DataSource {
 	dto := dtos.DataSource{
 		Id:               ds.ID,
@@ -841,8 +1021,8 @@ func (hs *HTTPServer) checkDatasourceHealth(c *contextmodel.ReqContext, ds *data
 	}
 
 	// Unmarshal JSONDetails if it's not empty.
+	var jsonDetails map[string]interface{}
 	if len(resp.JSONDetails) > 0 {
-		var jsonDetails map[string]interface{}
 		err = json.Unmarshal(resp.JSONDetails, &jsonDetails)
 		if err != nil {
 			return response.Error(http.StatusInternalServerError, "Failed to unmarshal detailed response from backend plugin", err)
@@ -855,9 +1035,24 @@ func (hs *HTTPServer) checkDatasourceHealth(c *contextmodel.ReqContext, ds *data
 		return response.JSON(http.StatusBadRequest, payload)
 	}
 
+	hs.saveDatasourceCapabilities(c, ds, jsonDetails)
 	return response.JSON(http.StatusOK, payload)
 }
 
+// saveDatasourceCapabilities stores the capabilities published by the health check of the datasource, which the
+// frontend settings deliver to the frontend. The health check still succeeds when they can't be stored.
+func (hs *HTTPServer) saveDatasourceCapabilities(c *contextmodel.ReqContext, ds *datasources.DataSource, details map[string]interface{}) {
+	var err error
+	if capabilities, ok := dscapabilities.FromHealthDetails(details); ok {
+		err = hs.dsCapabilitiesService.Save(c.Req.Context(), ds.OrgID, ds.UID, capabilities)
+	} else {
+		err = hs.dsCapabilitiesService.Delete(c.Req.Context(), ds.OrgID, ds.UID)
+	}
+	if err != nil {
+		c.Logger.Warn("Failed to save the capabilities of the datasource", "datasource", ds.UID, "error", err)
+	}
+}
+
 func (hs *HTTPServer) decryptSecureJsonDataFn(ctx context.Context) func(ds *datasources.DataSource) (map[string]string, error) {
 	return func(ds *datasources.DataSource) (map[string]string, error) {
 		return hs.DataSourcesService.DecryptedValues(ctx, ds)
@@ -937,6 +1132,23 @@ type GetDataSourceByUIDParams struct {
 	DatasourceUID string `json:"uid"`
 }
 
+// swagger:parameters getDataSourceUsageByUID
+type GetDataSourceUsageByUIDParams struct {
+	// in:path
+	// required:true
+	DatasourceUID string `json:"uid"`
+}
+
+// swagger:parameters migrateDataSourceByUID
+type MigrateDataSourceByUIDParams struct {
+	// in:body
+	// required:true
+	Body dtos.MigrateDataSourceCommand
+	// in:path
+	// required:true
+	DatasourceUID string `json:"uid"`
+}
+
 // swagger:parameters getDataSourceByName
 type GetDataSourceByNameParams struct {
 	// in:path
@@ -999,6 +1211,18 @@ type GetDataSourceResponse struct {
 	Body dtos.DataSource `json:"body"`
 }
 
+// swagger:response getDataSourceUsageResponse
+type GetDataSourceUsageResponse struct {
+	// in: body
+	Body dsusage.Report `json:"body"`
+}
+
+// swagger:response migrateDataSourceResponse
+type MigrateDataSourceResponse struct {
+	// in: body
+	Body dsusage.MigrationReport `json:"body"`
+}
+
 // swagger:response createOrUpdateDatasourceResponse
 type CreateOrUpdateDatasourceResponse struct {
 	// The response message
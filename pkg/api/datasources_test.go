@@ -5,24 +5,34 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db/dbtest"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	dscapabilities "github.com/grafana/grafana/pkg/services/datasources/capabilities"
 	"github.com/grafana/grafana/pkg/services/datasources/permissions"
+	dsusage "github.com/grafana/grafana/pkg/services/datasources/usage"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
 	"github.com/grafana/grafana/pkg/web/webtest"
 )
 
@@ -165,6 +175,43 @@ func TestAddDataSource_InvalidJSONData(t *testing.T) {
 	assert.Equal(t, 400, sc.resp.Code)
 }
 
+// Data sources reading TLS files outside of the allowed directories should fail
+func TestAddDataSource_TLSFiles(t *testing.T) {
+	for path, expected := range map[string]int{
+		"/etc/grafana/tls/tempo/ca.crt":       200,
+		"/etc/grafana/tls/../grafana.key":     400,
+		"/etc/grafana/grafana.key":            400,
+		"tls/tempo/ca.crt":                    400,
+		"/etc/grafana/tls-other/tempo/ca.crt": 400,
+	} {
+		hs := &HTTPServer{
+			DataSourcesService:   &dataSourcesServiceMock{expectedDatasource: &datasources.DataSource{}},
+			Cfg:                  setting.NewCfg(),
+			AccessControl:        acimpl.ProvideAccessControl(setting.NewCfg()),
+			accesscontrolService: actest.FakeService{},
+		}
+		hs.Cfg.DataSourceTLSFileDirs = []string{"/etc/grafana/tls/"}
+		sc := setupScenarioContext(t, "/api/datasources")
+
+		jsonData := simplejson.New()
+		jsonData.Set("tlsCACertFile", path)
+		sc.m.Post(sc.url, routing.Wrap(func(c *contextmodel.ReqContext) response.Response {
+			c.Req.Body = mockRequestBody(datasources.AddDataSourceCommand{
+				Name:     "Test",
+				URL:      "http://localhost:3200",
+				Access:   "proxy",
+				Type:     "tempo",
+				JsonData: jsonData,
+			})
+			return hs.AddDataSource(c)
+		}))
+
+		sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+		assert.Equal(t, expected, sc.resp.Code, path)
+	}
+}
+
 // Updating data sources with invalid URLs should lead to an error.
 func TestUpdateDataSource_InvalidURL(t *testing.T) {
 	hs := &HTTPServer{
@@ -295,6 +342,53 @@ func TestAPI_datasources_AccessControl(t *testing.T) {
 			permission:   []ac.Permission{},
 			expectedCode: http.StatusForbidden,
 		},
+		{
+			desc:   "should be able to fetch datasource usage with correct permission",
+			urls:   []string{"/api/datasources/uid/1/usage"},
+			method: http.MethodGet,
+			permission: []ac.Permission{
+				{Action: datasources.ActionRead, Scope: datasources.ScopeProvider.GetResourceScopeUID("1")},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should not be able to fetch datasource usage without correct permission",
+			urls:         []string{"/api/datasources/uid/1/usage"},
+			method:       http.MethodGet,
+			permission:   []ac.Permission{},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:   "should be able to migrate datasource with correct permission",
+			urls:   []string{"/api/datasources/uid/1/migrate"},
+			method: http.MethodPost,
+			body:   `{"targetUid": "2", "dryRun": true}`,
+			permission: []ac.Permission{
+				{Action: datasources.ActionWrite, Scope: datasources.ScopeProvider.GetResourceScopeUID("1")},
+				{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID("2")},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:   "should not be able to migrate datasource without query permission on the target",
+			urls:   []string{"/api/datasources/uid/1/migrate"},
+			method: http.MethodPost,
+			body:   `{"targetUid": "2", "dryRun": true}`,
+			permission: []ac.Permission{
+				{Action: datasources.ActionWrite, Scope: datasources.ScopeProvider.GetResourceScopeUID("1")},
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:   "should not be able to migrate datasource with read permission",
+			urls:   []string{"/api/datasources/uid/1/migrate"},
+			method: http.MethodPost,
+			body:   `{"targetUid": "2", "dryRun": true}`,
+			permission: []ac.Permission{
+				{Action: datasources.ActionRead, Scope: datasources.ScopeProvider.GetResourceScopeUID("1")},
+			},
+			expectedCode: http.StatusForbidden,
+		},
 		{
 			desc:         "should be able to create datasource with correct permission",
 			urls:         []string{"/api/datasources"},
@@ -336,6 +430,7 @@ func TestAPI_datasources_AccessControl(t *testing.T) {
 				hs.DataSourcesService = &dataSourcesServiceMock{expectedDatasource: &datasources.DataSource{}}
 				hs.accesscontrolService = actest.FakeService{}
 				hs.Live = newTestLive(t, hs.SQLStore)
+				hs.dataSourceUsageService = &fakeDataSourceUsageService{}
 			})
 
 			for _, url := range tt.urls {
@@ -353,6 +448,51 @@ func TestAPI_datasources_AccessControl(t *testing.T) {
 	}
 }
 
+func TestAPI_datasources_TeamOverrides(t *testing.T) {
+	getJSONData := func(t *testing.T, permissions []ac.Permission) map[string]interface{} {
+		t.Helper()
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.Cfg = setting.NewCfg()
+			hs.DataSourcesService = &dataSourcesServiceMock{expectedDatasource: &datasources.DataSource{
+				UID:      "1",
+				JsonData: simplejson.NewFromAny(map[string]interface{}{"httpMethod": "POST", "teamOverrides": []interface{}{map[string]interface{}{"teamId": 1}}}),
+			}}
+			hs.accesscontrolService = actest.FakeService{}
+		})
+
+		res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/datasources/uid/1"), userWithPermissions(1, permissions)))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var dto struct {
+			JsonData map[string]interface{} `json:"jsonData"`
+		}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&dto))
+		require.NoError(t, res.Body.Close())
+		return dto.JsonData
+	}
+	read := ac.Permission{Action: datasources.ActionRead, Scope: datasources.ScopeProvider.GetResourceScopeUID("1")}
+
+	t.Run("should hide the team overrides from the users who can't edit the datasource", func(t *testing.T) {
+		assert.Equal(t, map[string]interface{}{"httpMethod": "POST"}, getJSONData(t, []ac.Permission{read}))
+	})
+
+	t.Run("should return the team overrides to the users who can edit the datasource", func(t *testing.T) {
+		jsonData := getJSONData(t, []ac.Permission{read, {Action: datasources.ActionWrite, Scope: datasources.ScopeProvider.GetResourceScopeUID("1")}})
+		assert.Equal(t, "POST", jsonData["httpMethod"])
+		assert.Contains(t, jsonData, "teamOverrides")
+	})
+}
+
+type fakeDataSourceUsageService struct{}
+
+func (f *fakeDataSourceUsageService) GetUsage(ctx context.Context, user *user.SignedInUser, datasourceUID string) (*dsusage.Report, error) {
+	return &dsusage.Report{DatasourceUID: datasourceUID}, nil
+}
+
+func (f *fakeDataSourceUsageService) Migrate(ctx context.Context, cmd dsusage.MigrateCommand) (*dsusage.MigrationReport, error) {
+	return &dsusage.MigrationReport{SourceUID: cmd.Source.UID, TargetUID: cmd.Target.UID, DryRun: cmd.DryRun}, nil
+}
+
 type dataSourcesServiceMock struct {
 	datasources.DataSourceService
 
@@ -393,3 +533,62 @@ func (m *dataSourcesServiceMock) DecryptedValues(ctx context.Context, ds *dataso
 	decryptedValues := make(map[string]string)
 	return decryptedValues, m.expectedError
 }
+
+type fakeHealthCheckPluginClient struct {
+	plugins.Client
+
+	jsonDetails []byte
+}
+
+func (c *fakeHealthCheckPluginClient) CheckHealth(_ context.Context, _ *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "Data source is working", JSONDetails: c.jsonDetails}, nil
+}
+
+func TestDatasourceCapabilities(t *testing.T) {
+	ds := &datasources.DataSource{ID: 1, UID: "tempo", OrgID: 1, Type: "tempo", URL: "http://localhost:3200"}
+	pluginClient := &fakeHealthCheckPluginClient{}
+	permissionService := permissions.NewMockDatasourcePermissionService()
+	permissionService.DsResult = []*datasources.DataSource{ds}
+	hs := &HTTPServer{
+		Cfg:                          setting.NewCfg(),
+		DataSourcesService:           &dataSourcesServiceMock{expectedDatasources: []*datasources.DataSource{ds}},
+		DatasourcePermissionsService: permissionService,
+		PluginRequestValidator:       &fakePluginRequestValidator{},
+		pluginClient:                 pluginClient,
+		pluginStore:                  plugins.FakePluginStore{PluginList: []plugins.PluginDTO{{JSONData: plugins.JSONData{ID: "tempo", Type: plugins.DataSource}}}},
+		dsCapabilitiesService:        dscapabilities.ProvideService(kvstore.NewFakeKVStore(), bus.ProvideBus(tracing.InitializeTracerForTest())),
+	}
+	availablePlugins := AvailablePlugins{plugins.DataSource: {"tempo": &availablePluginDTO{Plugin: plugins.PluginDTO{JSONData: plugins.JSONData{ID: "tempo", Type: plugins.DataSource}}}}}
+	newReqContext := func() *contextmodel.ReqContext {
+		return &contextmodel.ReqContext{
+			Context:      &web.Context{Req: httptest.NewRequest(http.MethodGet, "/api/datasources/uid/tempo/health", nil)},
+			SignedInUser: &user.SignedInUser{UserID: 1, OrgID: 1},
+			Logger:       log.New("test"),
+		}
+	}
+	checkHealth := func(t *testing.T, jsonDetails string) {
+		pluginClient.jsonDetails = []byte(jsonDetails)
+		resp := hs.checkDatasourceHealth(newReqContext(), ds)
+		require.Equal(t, http.StatusOK, resp.Status())
+	}
+	frontendCapabilities := func(t *testing.T) map[string]bool {
+		dataSources, err := hs.getFSDataSources(newReqContext(), availablePlugins)
+		require.NoError(t, err)
+		require.Contains(t, dataSources, ds.Name)
+		return dataSources[ds.Name].Capabilities
+	}
+
+	t.Run("the datasources without published capabilities have none", func(t *testing.T) {
+		assert.Nil(t, frontendCapabilities(t))
+	})
+
+	t.Run("the capabilities published by the health check are in the frontend settings", func(t *testing.T) {
+		checkHealth(t, `{"capabilities":{"streaming":true,"metricsQueries":false,"invalid":"yes"}}`)
+		assert.Equal(t, map[string]bool{"streaming": true, "metricsQueries": false}, frontendCapabilities(t))
+	})
+
+	t.Run("the capabilities are removed when the health check no longer publishes them", func(t *testing.T) {
+		checkHealth(t, `{"verboseMessage":"ok"}`)
+		assert.Nil(t, frontendCapabilities(t))
+	})
+}
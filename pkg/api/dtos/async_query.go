@@ -0,0 +1,16 @@
+package dtos
+
+import "time"
+
+// AsyncQuery is the state of a query started with the async parameter of /api/ds/query
+type AsyncQuery struct {
+	UID string `json:"uid"`
+	// Status is pending, running, done or failed
+	Status string `json:"status"`
+	// Error is the error of the failed queries
+	Error   string    `json:"error,omitempty"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+	// Expires is when the query and its result are deleted
+	Expires time.Time `json:"expires"`
+}
@@ -61,3 +61,12 @@ func (slice DataSourceList) Less(i, j int) bool {
 func (slice DataSourceList) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
+
+// MigrateDataSourceCommand is the body of the request migrating the references of a data source to another one
+type MigrateDataSourceCommand struct {
+	// UID of the data source replacing the migrated one
+	// required: true
+	TargetUID string `json:"targetUid"`
+	// DryRun only reports the resources that would be updated
+	DryRun bool `json:"dryRun"`
+}
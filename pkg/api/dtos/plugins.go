@@ -63,4 +63,6 @@ func (slice PluginList) Swap(i, j int) {
 
 type InstallPluginCommand struct {
 	Version string `json:"version"`
+	// Checksum is the expected SHA256 checksum of the plugin archive.
+	Checksum string `json:"checksum"`
 }
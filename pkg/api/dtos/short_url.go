@@ -1,10 +1,49 @@
 package dtos
 
+import "time"
+
 type ShortURL struct {
 	UID string `json:"uid"`
 	URL string `json:"url"`
+	// ExpiresAt is not set when the short URL doesn't expire
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
 }
 
 type CreateShortURLCmd struct {
 	Path string `json:"path"`
+	// TTL is the time to live of the short URL, for example 7d. The default time to live is used when it's empty.
+	TTL string `json:"ttl,omitempty"`
+}
+
+type ShortURLDetails struct {
+	UID            string     `json:"uid"`
+	URL            string     `json:"url"`
+	Path           string     `json:"path"`
+	CreatedBy      int64      `json:"createdBy"`
+	CreatedByLogin string     `json:"createdByLogin"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	LastSeenAt     *time.Time `json:"lastSeenAt,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	HitCount       int64      `json:"hitCount"`
+}
+
+type SearchShortURLsResult struct {
+	TotalCount int64             `json:"totalCount"`
+	ShortURLs  []ShortURLDetails `json:"shortUrls"`
+	Page       int               `json:"page"`
+	PerPage    int               `json:"perPage"`
+}
+
+// PurgeShortURLsCmd deletes the short URLs matching all the set filters, at least one filter is required
+type PurgeShortURLsCmd struct {
+	// Expired deletes the expired short URLs
+	Expired bool `json:"expired"`
+	// NotSeenFor deletes the short URLs that were not visited for this duration, for example 30d
+	NotSeenFor string `json:"notSeenFor,omitempty"`
+	// CreatedBy deletes the short URLs created by the user
+	CreatedBy int64 `json:"createdBy,omitempty"`
+}
+
+type PurgeShortURLsResult struct {
+	Deleted int64 `json:"deleted"`
 }
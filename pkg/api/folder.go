@@ -73,7 +73,7 @@ func (hs *HTTPServer) GetFolders(c *contextmodel.ReqContext) response.Response {
 		}
 	}
 
-	return response.JSON(http.StatusOK, result)
+	return response.JSON(http.StatusOK, result).WithETag()
 }
 
 // swagger:route GET /folders/{folder_uid} folders getFolderByUID
@@ -98,7 +98,7 @@ func (hs *HTTPServer) GetFolderByUID(c *contextmodel.ReqContext) response.Respon
 		return response.Err(err)
 	}
 
-	return response.JSON(http.StatusOK, hs.newToFolderDto(c, g, folder))
+	return response.JSON(http.StatusOK, hs.newToFolderDto(c, g, folder)).WithETag()
 }
 
 // swagger:route GET /folders/id/{folder_id} folders getFolderByID
@@ -127,7 +127,7 @@ func (hs *HTTPServer) GetFolderByID(c *contextmodel.ReqContext) response.Respons
 	if err != nil {
 		return response.Err(err)
 	}
-	return response.JSON(http.StatusOK, hs.newToFolderDto(c, g, folder))
+	return response.JSON(http.StatusOK, hs.newToFolderDto(c, g, folder)).WithETag()
 }
 
 // swagger:route POST /folders folders createFolder
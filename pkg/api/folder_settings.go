@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/apierrors"
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/foldersettings"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /folders/{folder_uid}/settings folders getFolderSettings
+//
+// Get the settings of a folder.
+//
+// Returns the settings of the folder and the settings inherited by its dashboards, including the ones of its parent folders.
+//
+// Responses:
+// 200: getFolderSettingsResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetFolderSettings(c *contextmodel.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+	if _, err := hs.folderService.Get(c.Req.Context(), &folder.GetFolderQuery{OrgID: c.OrgID, UID: &uid, SignedInUser: c.SignedInUser}); err != nil {
+		return apierrors.ToFolderErrorResponse(err)
+	}
+
+	settings, err := hs.folderSettingsService.GetFolderSettings(c.Req.Context(), c.OrgID, uid)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get folder settings", err)
+	}
+	inherited, err := hs.folderSettingsService.GetInheritedSettings(c.Req.Context(), c.OrgID, uid)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get folder settings", err)
+	}
+	return response.JSON(http.StatusOK, foldersettings.FolderSettingsDTO{Settings: settings, Inherited: inherited})
+}
+
+// swagger:route PUT /folders/{folder_uid}/settings folders updateFolderSettings
+//
+// Update the settings of a folder.
+//
+// The settings are inherited by the dashboards of the folder and of its subfolders. Omitting a key will remove the setting.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) UpdateFolderSettings(c *contextmodel.ReqContext) response.Response {
+	cmd := foldersettings.UpdateFolderSettingsCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	cmd.FolderUID = web.Params(c.Req)[":uid"]
+
+	if _, err := hs.folderService.Get(c.Req.Context(), &folder.GetFolderQuery{OrgID: c.OrgID, UID: &cmd.FolderUID, SignedInUser: c.SignedInUser}); err != nil {
+		return apierrors.ToFolderErrorResponse(err)
+	}
+
+	if cmd.DefaultDatasourceUID != "" {
+		if _, err := hs.DataSourceCache.GetDatasourceByUID(c.Req.Context(), cmd.DefaultDatasourceUID, c.SignedInUser, false); err != nil {
+			return response.Error(http.StatusBadRequest, "Data source not found", err)
+		}
+	}
+
+	if err := hs.folderSettingsService.UpdateFolderSettings(c.Req.Context(), &cmd); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update folder settings", err)
+	}
+	return response.Success("Folder settings updated")
+}
+
+// applyFolderSettings sets the datasource of the panels without datasource of the dashboard to the default
+// datasource inherited from its folder. It returns an error response when the settings of the folder can't be read.
+func (hs *HTTPServer) applyFolderSettings(c *contextmodel.ReqContext, folderUID string, dash *dashboards.Dashboard) response.Response {
+	if hs.folderSettingsService == nil || dash.IsFolder || (folderUID == "" && dash.FolderID == 0) {
+		return nil
+	}
+
+	if folderUID == "" {
+		f, err := hs.folderService.Get(c.Req.Context(), &folder.GetFolderQuery{OrgID: c.OrgID, ID: &dash.FolderID, SignedInUser: c.SignedInUser})
+		if err != nil {
+			if errors.Is(err, dashboards.ErrFolderNotFound) {
+				return response.Error(http.StatusBadRequest, "Folder not found", err)
+			}
+			return response.Error(http.StatusInternalServerError, "Error while checking folder ID", err)
+		}
+		folderUID = f.UID
+	}
+
+	settings, err := hs.folderSettingsService.GetInheritedSettings(c.Req.Context(), c.OrgID, folderUID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get folder settings", err)
+	}
+	if changed := dashboards.ApplyDefaultDatasource(dash.Data, settings.DefaultDatasourceUID); changed > 0 {
+		hs.log.Debug("Set the default datasource of the folder to the dashboard panels", "folderUid", folderUID, "panels", changed)
+	}
+	return nil
+}
+
+// swagger:parameters getFolderSettings
+type GetFolderSettingsParams struct {
+	// in:path
+	// required:true
+	FolderUID string `json:"folder_uid"`
+}
+
+// swagger:parameters updateFolderSettings
+type UpdateFolderSettingsParams struct {
+	// in:path
+	// required:true
+	FolderUID string `json:"folder_uid"`
+	// in:body
+	// required:true
+	Body foldersettings.Settings `json:"body"`
+}
+
+// swagger:response getFolderSettingsResponse
+type GetFolderSettingsResponse struct {
+	// in:body
+	Body foldersettings.FolderSettingsDTO `json:"body"`
+}
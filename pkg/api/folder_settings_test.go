@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/folder/foldertest"
+	"github.com/grafana/grafana/pkg/services/foldersettings"
+	"github.com/grafana/grafana/pkg/services/foldersettings/foldersettingstest"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestHTTPServer_FolderSettings(t *testing.T) {
+	setUpRBACGuardian(t)
+	settingsService := &foldersettingstest.FakeService{
+		ExpectedSettings:          foldersettings.Settings{QueryTimeoutSeconds: 10, LabelMatchers: []string{}},
+		ExpectedInheritedSettings: foldersettings.Settings{QueryTimeoutSeconds: 10, LabelMatchers: []string{`team="payments"`}},
+	}
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = &setting.Cfg{
+			RBACEnabled: true,
+		}
+		hs.folderService = &foldertest.FakeService{ExpectedFolder: &folder.Folder{UID: "uid", Title: "Folder"}}
+		hs.folderSettingsService = settingsService
+	})
+
+	t.Run("get the settings and the inherited settings", func(t *testing.T) {
+		req := webtest.RequestWithSignedInUser(server.NewGetRequest("/api/folders/uid/settings"), userWithPermissions(1, []accesscontrol.Permission{
+			{Action: dashboards.ActionFoldersRead, Scope: dashboards.ScopeFoldersAll},
+		}))
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, res.Body.Close()) }()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		body := foldersettings.FolderSettingsDTO{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, int64(10), body.QueryTimeoutSeconds)
+		assert.Equal(t, []string{`team="payments"`}, body.Inherited.LabelMatchers)
+	})
+
+	t.Run("update the settings", func(t *testing.T) {
+		req := server.NewRequest(http.MethodPut, "/api/folders/uid/settings", strings.NewReader(`{"queryTimeoutSeconds": 30, "labelMatchers": ["env!=\"dev\""]}`))
+		req = webtest.RequestWithSignedInUser(req, userWithPermissions(1, []accesscontrol.Permission{
+			{Action: dashboards.ActionFoldersWrite, Scope: dashboards.ScopeFoldersAll},
+		}))
+		res, err := server.SendJSON(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		require.NotNil(t, settingsService.UpdatedSettings)
+		assert.Equal(t, "uid", settingsService.UpdatedSettings.FolderUID)
+		assert.Equal(t, int64(1), settingsService.UpdatedSettings.OrgID)
+		assert.Equal(t, int64(30), settingsService.UpdatedSettings.QueryTimeoutSeconds)
+		assert.Equal(t, []string{`env!="dev"`}, settingsService.UpdatedSettings.LabelMatchers)
+	})
+
+	t.Run("updating the settings requires the folder write permission", func(t *testing.T) {
+		req := server.NewRequest(http.MethodPut, "/api/folders/uid/settings", strings.NewReader(`{"queryTimeoutSeconds": 30}`))
+		req = webtest.RequestWithSignedInUser(req, userWithPermissions(1, []accesscontrol.Permission{
+			{Action: dashboards.ActionFoldersRead, Scope: dashboards.ScopeFoldersAll},
+		}))
+		res, err := server.SendJSON(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+}
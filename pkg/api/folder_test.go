@@ -408,3 +408,43 @@ func TestFolderMoveAPIEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPServer_GetFolderByUID_ETag(t *testing.T) {
+	setUpRBACGuardian(t)
+	folderService := &foldertest.FakeService{
+		ExpectedFolder: &folder.Folder{UID: "uid", Title: "Folder"},
+	}
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = &setting.Cfg{
+			RBACEnabled: true,
+		}
+		hs.folderService = folderService
+	})
+
+	get := func(ifNoneMatch string) *http.Response {
+		req := server.NewGetRequest("/api/folders/uid")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		webtest.RequestWithSignedInUser(req, userWithPermissions(1, []accesscontrol.Permission{
+			{Action: dashboards.ActionFoldersRead, Scope: dashboards.ScopeFoldersAll},
+		}))
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		return res
+	}
+
+	res := get("")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	etag := res.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	res = get(etag)
+	require.Equal(t, http.StatusNotModified, res.StatusCode)
+
+	folderService.ExpectedFolder = &folder.Folder{UID: "uid", Title: "Renamed folder"}
+	res = get(etag)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.NotEqual(t, etag, res.Header.Get("ETag"))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	dscapabilities "github.com/grafana/grafana/pkg/services/datasources/capabilities"
 	"github.com/grafana/grafana/pkg/services/licensing"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginsettings"
 	"github.com/grafana/grafana/pkg/services/secrets/kvstore"
@@ -199,7 +200,7 @@ func (hs *HTTPServer) getFrontendSettings(c *contextmodel.ReqContext) (*dtos.Fro
 			Enabled: hs.Cfg.SectionWithEnvOverrides("caching").Key("enabled").MustBool(true),
 		},
 		RecordedQueries: dtos.FrontendSettingsRecordedQueriesDTO{
-			Enabled: hs.Cfg.SectionWithEnvOverrides("recorded_queries").Key("enabled").MustBool(true),
+			Enabled: hs.Cfg.RecordedQueries.Enabled,
 		},
 		Reporting: dtos.FrontendSettingsReportingDTO{
 			Enabled: hs.Cfg.SectionWithEnvOverrides("reporting").Key("enabled").MustBool(true),
@@ -275,6 +276,17 @@ func (hs *HTTPServer) getFSDataSources(c *contextmodel.ReqContext, availablePlug
 
 	dataSources := make(map[string]plugins.DataSourceDTO)
 
+	// the datasources without capabilities, and all of them when they can't be read, are used like before their
+	// capabilities were published
+	var capabilities map[string]dscapabilities.Capabilities
+	if len(orgDataSources) > 0 {
+		var err error
+		capabilities, err = hs.dsCapabilitiesService.GetAll(c.Req.Context(), c.OrgID)
+		if err != nil {
+			c.Logger.Warn("Failed to get the capabilities of the datasources", "error", err)
+		}
+	}
+
 	for _, ds := range orgDataSources {
 		url := ds.URL
 
@@ -292,6 +304,7 @@ func (hs *HTTPServer) getFSDataSources(c *contextmodel.ReqContext, availablePlug
 			Access:    string(ds.Access),
 			ReadOnly:  ds.ReadOnly,
 		}
+		dsDTO.Capabilities = capabilities[ds.UID]
 
 		ap, exists := availablePlugins.Get(plugins.DataSource, ds.Type)
 		if !exists {
@@ -311,7 +324,8 @@ func (hs *HTTPServer) getFSDataSources(c *contextmodel.ReqContext, availablePlug
 		if ds.JsonData == nil {
 			dsDTO.JSONData = make(map[string]interface{})
 		} else {
-			dsDTO.JSONData = ds.JsonData.MustMap()
+			// the frontend doesn't need the team overrides, they are only sent to the users who can edit the datasource
+			dsDTO.JSONData = datasources.WithoutTeamOverrides(ds.JsonData).MustMap()
 		}
 
 		if ds.Access == datasources.DS_ACCESS_DIRECT {
@@ -38,7 +38,9 @@ import (
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/asyncquery"
 	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/auth/identitytoken"
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/cleanup"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
@@ -48,10 +50,14 @@ import (
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	dscapabilities "github.com/grafana/grafana/pkg/services/datasources/capabilities"
+	dsdrift "github.com/grafana/grafana/pkg/services/datasources/drift"
 	"github.com/grafana/grafana/pkg/services/datasources/permissions"
+	dsusage "github.com/grafana/grafana/pkg/services/datasources/usage"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/foldersettings"
 	"github.com/grafana/grafana/pkg/services/hooks"
 	"github.com/grafana/grafana/pkg/services/libraryelements"
 	"github.com/grafana/grafana/pkg/services/librarypanels"
@@ -73,6 +79,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/provisioning"
 	publicdashboardsApi "github.com/grafana/grafana/pkg/services/publicdashboards/api"
 	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/querycapture"
 	"github.com/grafana/grafana/pkg/services/queryhistory"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
@@ -207,6 +214,15 @@ type HTTPServer struct {
 	statsService           stats.Service
 	authnService           authn.Service
 	starApi                *starApi.API
+	dataSourceUsageService dsusage.Service
+	dataSourceDriftService dsdrift.Service
+	asyncQueryService      asyncquery.Service
+	dsCapabilitiesService  dscapabilities.Service
+	folderSettingsService  foldersettings.Service
+	queryCaptureService    querycapture.Service
+	identityTokens         *identitytoken.Service
+	// queryHooks convert the queries of the dashboards saved
+	queryHooks plugins.QueryHookRegistry
 }
 
 type ServerOptions struct {
@@ -249,7 +265,10 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 	accesscontrolService accesscontrol.Service, dashboardThumbsService thumbs.DashboardThumbService, navTreeService navtree.Service,
 	annotationRepo annotations.Repository, tagService tag.Service, searchv2HTTPService searchV2.SearchHTTPService, oauthTokenService oauthtoken.OAuthTokenService,
 	statsService stats.Service, authnService authn.Service, pluginsCDNService *pluginscdn.Service,
-	starApi *starApi.API,
+	starApi *starApi.API, dataSourceUsageService dsusage.Service, dataSourceDriftService dsdrift.Service,
+	asyncQueryService asyncquery.Service, dsCapabilitiesService dscapabilities.Service,
+	folderSettingsService foldersettings.Service, queryCaptureService querycapture.Service,
+	identityTokens *identitytoken.Service, queryHooks plugins.QueryHookRegistry,
 ) (*HTTPServer, error) {
 	web.Env = cfg.Env
 	m := web.New()
@@ -353,6 +372,14 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 		authnService:                 authnService,
 		pluginsCDNService:            pluginsCDNService,
 		starApi:                      starApi,
+		dataSourceUsageService:       dataSourceUsageService,
+		dataSourceDriftService:       dataSourceDriftService,
+		asyncQueryService:            asyncQueryService,
+		dsCapabilitiesService:        dsCapabilitiesService,
+		folderSettingsService:        folderSettingsService,
+		queryCaptureService:          queryCaptureService,
+		identityTokens:               identityTokens,
+		queryHooks:                   queryHooks,
 	}
 	if hs.Listener != nil {
 		hs.log.Debug("Using provided listener")
@@ -518,6 +545,11 @@ func (hs *HTTPServer) configureHttps() error {
 		},
 	}
 
+	if hs.Cfg.RequestClientCertificate {
+		// the certificates are not verified, the tokens bound to a certificate are only accepted with it
+		tlsCfg.ClientAuth = tls.RequestClientCert
+	}
+
 	hs.httpSrv.TLSConfig = tlsCfg
 	hs.httpSrv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
 
@@ -557,6 +589,10 @@ func (hs *HTTPServer) configureHttp2() error {
 		NextProtos: []string{"h2", "http/1.1"},
 	}
 
+	if hs.Cfg.RequestClientCertificate {
+		tlsCfg.ClientAuth = tls.RequestClientCert
+	}
+
 	hs.httpSrv.TLSConfig = tlsCfg
 
 	return nil
@@ -615,6 +651,8 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 	m.Use(hs.frontendLogEndpoints())
 
 	m.UseMiddleware(hs.ContextHandler.Middleware)
+	// needs to be after context handler, before the handlers accessible to the tokens limited to querying data sources
+	m.Use(middleware.RestrictQueryOnlyTokens)
 	m.Use(middleware.OrgRedirect(hs.Cfg, hs.userService))
 	if !hs.Features.IsEnabled(featuremgmt.FlagAuthnService) {
 		m.Use(accesscontrol.LoadPermissionsMiddleware(hs.accesscontrolService))
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+)
+
+// swagger:route GET /identity-token/jwks datasources getIdentityTokenKeys
+//
+// Get the public keys verifying the identity tokens sent to the data sources.
+//
+// The keys are returned as a JSON Web Key Set, so that the gateways in front of the data sources can verify the
+// tokens. It doesn't require authentication.
+//
+// Responses:
+// 200: getIdentityTokenKeysResponse
+// 404: notFoundError
+func (hs *HTTPServer) GetIdentityTokenKeys(c *contextmodel.ReqContext) response.Response {
+	if hs.identityTokens == nil {
+		return response.Error(http.StatusNotFound, "Identity tokens are not enabled", nil)
+	}
+	return response.JSON(http.StatusOK, hs.identityTokens.Keys())
+}
+
+// swagger:response getIdentityTokenKeysResponse
+type GetIdentityTokenKeysResponse struct {
+	// in: body
+	Body struct {
+		// The public keys in the JWK format
+		Keys []map[string]interface{} `json:"keys"`
+	} `json:"body"`
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jose "github.com/go-jose/go-jose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/auth/identitytoken"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestAPI_GetIdentityTokenKeys(t *testing.T) {
+	t.Run("should publish the public key without authentication", func(t *testing.T) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		keyFile := filepath.Join(t.TempDir(), "key.pem")
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+		cfg := setting.NewCfg()
+		cfg.DataProxyIdentityToken = setting.IdentityTokenSettings{SigningKeyFile: keyFile, KeyID: "key-1"}
+		identityTokens, err := identitytoken.ProvideService(cfg)
+		require.NoError(t, err)
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.identityTokens = identityTokens
+		})
+
+		res, err := server.Send(server.NewGetRequest("/api/identity-token/jwks"))
+		require.NoError(t, err)
+		defer func() { require.NoError(t, res.Body.Close()) }()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var keys jose.JSONWebKeySet
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&keys))
+		require.Len(t, keys.Keys, 1)
+		assert.Equal(t, "key-1", keys.Keys[0].KeyID)
+		assert.Equal(t, "EdDSA", keys.Keys[0].Algorithm)
+		assert.Equal(t, key.Public(), keys.Keys[0].Key)
+	})
+
+	t.Run("should return 404 when the identity tokens are not enabled", func(t *testing.T) {
+		server := SetupAPITestServer(t)
+
+		res, err := server.Send(server.NewGetRequest("/api/identity-token/jwks"))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	})
+}
@@ -1,17 +1,27 @@
 package api
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/frameparquet"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/querycapture"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -23,6 +33,15 @@ func (hs *HTTPServer) handleQueryMetricsError(err error) *response.NormalRespons
 		return response.Error(http.StatusNotFound, "Data source not found", err)
 	}
 
+	var quotaReached query.QuotaReachedError
+	if errors.As(err, &quotaReached) {
+		rsp := response.Err(err)
+		for k, v := range quotaReached.Headers() {
+			rsp.SetHeader(k, v)
+		}
+		return rsp
+	}
+
 	var secretsPlugin datasources.ErrDatasourceSecretsPluginUserFriendly
 	if errors.As(err, &secretsPlugin) {
 		return response.Error(http.StatusInternalServerError, fmt.Sprint("Secrets Plugin error: ", err.Error()), err)
@@ -39,8 +58,19 @@ func (hs *HTTPServer) handleQueryMetricsError(err error) *response.NormalRespons
 // If you are running Grafana Enterprise and have Fine-grained access control enabled
 // you need to have a permission with action: `datasources:query`.
 //
+// The frames are encoded as Parquet files when the request accepts `application/vnd.apache.parquet`,
+// in a `multipart/mixed` body when the queries return several frames, and 204 is returned without frames.
+//
+// With the `async` parameter, the queries run in the background and 202 is returned with the state of the query,
+// to poll with /ds/query/async/{uid} until it is done. The result is then fetched with /ds/query/async/{uid}/result.
+//
+// With the `X-Grafana-Capture: true` header, the requests sent by the data sources to their downstream services are
+// recorded with their responses. The UID of the capture is returned in the `X-Grafana-Capture-Uid` header, to fetch
+// it with /query-captures/{uid}. Capturing the requests requires the `datasources:explore` permission.
+//
 // Responses:
 // 200: queryMetricsWithExpressionsRespons
+// 202: getAsyncQueryResponse
 // 207: queryMetricsWithExpressionsRespons
 // 401: unauthorisedError
 // 400: badRequestError
@@ -51,14 +81,106 @@ func (hs *HTTPServer) QueryMetricsV2(c *contextmodel.ReqContext) response.Respon
 	if err := web.Bind(c.Req, &reqDTO); err != nil {
 		return response.Error(http.StatusBadRequest, "bad request data", err)
 	}
+	if c.QueryBool("async") {
+		return hs.startAsyncQuery(c, reqDTO)
+	}
 
-	resp, err := hs.queryDataService.QueryData(c.Req.Context(), c.SignedInUser, c.SkipCache, reqDTO)
+	ctx := c.Req.Context()
+	if hs.queryCaptureService != nil && strings.EqualFold(c.Req.Header.Get(querycapture.HeaderCapture), "true") {
+		canCapture, err := hs.AccessControl.Evaluate(ctx, c.SignedInUser, ac.EvalPermission(ac.ActionDatasourcesExplore))
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to check the permissions of the user", err)
+		}
+		if !canCapture {
+			return response.Error(http.StatusForbidden, "Capturing the data source requests requires the permission to explore the data sources", nil)
+		}
+		recorder, err := hs.queryCaptureService.Start(c.SignedInUser)
+		if err != nil {
+			return response.Error(http.StatusForbidden, err.Error(), err)
+		}
+		ctx = querycapture.WithRecorder(ctx, recorder)
+		c.Resp.Header().Set(querycapture.HeaderCaptureUID, recorder.UID())
+	}
+
+	resp, err := hs.queryDataService.QueryData(ctx, c.SignedInUser, c.SkipCache, reqDTO)
 	if err != nil {
 		return hs.handleQueryMetricsError(err)
 	}
+	if acceptsParquet(c.Req) {
+		return hs.toParquetResponse(resp)
+	}
 	return hs.toJsonStreamingResponse(resp)
 }
 
+// acceptsParquet checks if the client asks for the frames as Parquet files in the Accept header of the request
+func acceptsParquet(req *http.Request) bool {
+	for _, value := range req.Header.Values("Accept") {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && mediaType == frameparquet.ContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toParquetResponse returns the frame of a response with a single frame as a Parquet file, and the frames of the
+// other responses as the Parquet files of a multipart/mixed response, in the order of their refId. The responses
+// with an error are returned as JSON, as the errors can't be written to the files.
+func (hs *HTTPServer) toParquetResponse(qdr *backend.QueryDataResponse) response.Response {
+	refIDs := make([]string, 0, len(qdr.Responses))
+	for refID, res := range qdr.Responses {
+		if res.Error != nil {
+			return hs.toJsonStreamingResponse(qdr)
+		}
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+
+	type part struct {
+		refID string
+		index int
+		body  []byte
+	}
+	var parts []part
+	for _, refID := range refIDs {
+		for i, frame := range qdr.Responses[refID].Frames {
+			body, err := frameparquet.MarshalFrame(frame)
+			if err != nil {
+				return response.Error(http.StatusInternalServerError, "Failed to encode the frame to Parquet", err)
+			}
+			parts = append(parts, part{refID: refID, index: i, body: body})
+		}
+	}
+
+	switch len(parts) {
+	case 0:
+		return response.Empty(http.StatusNoContent)
+	case 1:
+		return response.Respond(http.StatusOK, parts[0].body).SetHeader("Content-Type", frameparquet.ContentType)
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", frameparquet.ContentType)
+		header.Set("Content-Disposition", fmt.Sprintf(`attachment; name=%q; filename="%s-%d.parquet"`, p.refID, p.refID, p.index))
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to write the Parquet response", err)
+		}
+		if _, err := pw.Write(p.body); err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to write the Parquet response", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to write the Parquet response", err)
+	}
+	return response.Respond(http.StatusOK, buf.Bytes()).SetHeader("Content-Type", "multipart/mixed; boundary="+w.Boundary())
+}
+
 func (hs *HTTPServer) toJsonStreamingResponse(qdr *backend.QueryDataResponse) response.Response {
 	statusWhenError := http.StatusBadRequest
 	if hs.Features.IsEnabled(featuremgmt.FlagDatasourceQueryMultiStatus) {
@@ -80,6 +202,10 @@ type QueryMetricsWithExpressionsBodyParams struct {
 	// in:body
 	// required:true
 	Body dtos.MetricRequest `json:"body"`
+	// Run the queries in the background
+	// in:query
+	// required:false
+	Async bool `json:"async"`
 }
 
 // swagger:response queryMetricsWithExpressionsRespons
@@ -7,22 +7,32 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"strings"
 	"testing"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/frameparquet"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/config"
 	pluginClient "github.com/grafana/grafana/pkg/plugins/manager/client"
 	"github.com/grafana/grafana/pkg/plugins/manager/registry"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/querycapture"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
@@ -61,6 +71,10 @@ func TestAPIEndpoint_Metrics_QueryMetricsV2(t *testing.T) {
 				return &backend.QueryDataResponse{Responses: resp}, nil
 			},
 		},
+		nil,
+		nil,
+		nil,
+		nil,
 	)
 	serverFeatureEnabled := SetupAPITestServer(t, func(hs *HTTPServer) {
 		hs.queryDataService = qds
@@ -92,6 +106,198 @@ func TestAPIEndpoint_Metrics_QueryMetricsV2(t *testing.T) {
 	})
 }
 
+func TestAPIEndpoint_Metrics_QueryMetricsV2Parquet(t *testing.T) {
+	frames := data.Frames{}
+	queryErr := error(nil)
+	qds := query.ProvideService(
+		setting.NewCfg(),
+		nil,
+		nil,
+		&fakePluginRequestValidator{},
+		&fakeDatasources.FakeDataSourceService{},
+		&fakePluginClient{
+			QueryDataHandlerFunc: func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+				return &backend.QueryDataResponse{Responses: backend.Responses{
+					"A": backend.DataResponse{Frames: frames, Error: queryErr},
+				}}, nil
+			},
+		},
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.queryDataService = qds
+		hs.Features = featuremgmt.WithFeatures()
+		hs.QuotaService = quotatest.New(false, nil)
+	})
+	send := func(t *testing.T) *http.Response {
+		req := server.NewPostRequest("/api/ds/query", strings.NewReader(reqValid))
+		req.Header.Set("Accept", "application/json;q=0.5, application/vnd.apache.parquet")
+		webtest.RequestWithSignedInUser(req, &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleViewer})
+		resp, err := server.SendJSON(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, resp.Body.Close()) })
+		return resp
+	}
+
+	t.Run("a single frame is returned as a Parquet file", func(t *testing.T) {
+		frames, queryErr = data.Frames{data.NewFrame("requests", data.NewField("value", nil, []float64{1, 2}))}, nil
+		resp := send(t)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, frameparquet.ContentType, resp.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		frame, err := frameparquet.UnmarshalFrame(body)
+		require.NoError(t, err)
+		require.Equal(t, "requests", frame.Name)
+		require.Equal(t, 2.0, frame.Fields[0].At(1))
+	})
+
+	t.Run("several frames are returned as the parts of a multipart response", func(t *testing.T) {
+		frames, queryErr = data.Frames{
+			data.NewFrame("first", data.NewField("value", nil, []float64{1})),
+			data.NewFrame("second", data.NewField("value", nil, []float64{2})),
+		}, nil
+		resp := send(t)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		require.Equal(t, "multipart/mixed", mediaType)
+
+		r := multipart.NewReader(resp.Body, params["boundary"])
+		for i, name := range []string{"first", "second"} {
+			part, err := r.NextPart()
+			require.NoError(t, err)
+			require.Equal(t, frameparquet.ContentType, part.Header.Get("Content-Type"))
+			_, disposition, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			require.NoError(t, err)
+			require.Equal(t, "A", disposition["name"])
+			require.Equal(t, fmt.Sprintf("A-%d.parquet", i), part.FileName())
+			body, err := io.ReadAll(part)
+			require.NoError(t, err)
+			frame, err := frameparquet.UnmarshalFrame(body)
+			require.NoError(t, err)
+			require.Equal(t, name, frame.Name)
+		}
+		_, err = r.NextPart()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("a response without frames has no content", func(t *testing.T) {
+		frames, queryErr = nil, nil
+		resp := send(t)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("the errors are returned as JSON", func(t *testing.T) {
+		frames, queryErr = nil, errors.New("query failed")
+		resp := send(t)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+	})
+}
+
+type fakeQueryQuotaService struct {
+	*quotatest.FakeQuotaService
+	concurrent int64
+}
+
+func (f *fakeQueryQuotaService) GetLimits(ctx context.Context, target quota.TargetSrv, params *quota.ScopeParameters) (*quota.Map, error) {
+	tag, err := quota.NewTag(query.QuotaTargetSrv, query.QuotaTargetConcurrentQueries, quota.OrgScope)
+	if err != nil {
+		return nil, err
+	}
+	limits := &quota.Map{}
+	limits.Set(tag, f.concurrent)
+	return limits, nil
+}
+
+func TestAPIEndpoint_Metrics_QueryQuotaReached(t *testing.T) {
+	qds := query.ProvideService(
+		setting.NewCfg(),
+		nil,
+		nil,
+		&fakePluginRequestValidator{},
+		&fakeDatasources.FakeDataSourceService{},
+		&fakePluginClient{},
+		&fakeQueryQuotaService{FakeQuotaService: quotatest.New(false, nil), concurrent: 0},
+		nil,
+		nil,
+		nil,
+	)
+	httpServer := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.queryDataService = qds
+		hs.QuotaService = quotatest.New(false, nil)
+	})
+
+	t.Run("Status code is 429 with rate limit headers when the org reached its query quota", func(t *testing.T) {
+		req := httpServer.NewPostRequest("/api/ds/query", strings.NewReader(reqValid))
+		webtest.RequestWithSignedInUser(req, &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleViewer})
+		resp, err := httpServer.SendJSON(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		require.Equal(t, "1", resp.Header.Get("Retry-After"))
+		require.Equal(t, "0", resp.Header.Get("X-RateLimit-Limit"))
+		require.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+	})
+}
+
+type fakeCapturedQueryService struct {
+	query.Service
+	recorder *querycapture.Recorder
+}
+
+func (f *fakeCapturedQueryService) QueryData(ctx context.Context, _ *user.SignedInUser, _ bool, _ dtos.MetricRequest) (*backend.QueryDataResponse, error) {
+	f.recorder = querycapture.RecorderFromContext(ctx)
+	return backend.NewQueryDataResponse(), nil
+}
+
+func TestAPIEndpoint_Metrics_QueryCapture(t *testing.T) {
+	qds := &fakeCapturedQueryService{}
+	httpServer := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.queryDataService = qds
+		hs.queryCaptureService = querycapture.ProvideService(routing.NewRouteRegister())
+	})
+
+	canExplore := []accesscontrol.Permission{{Action: accesscontrol.ActionDatasourcesExplore}}
+	send := func(permissions []accesscontrol.Permission, capture bool) *http.Response {
+		req := httpServer.NewPostRequest("/api/ds/query", strings.NewReader(reqValid))
+		if capture {
+			req.Header.Set(querycapture.HeaderCapture, "true")
+		}
+		signedInUser := userWithPermissions(1, append(permissions, accesscontrol.Permission{Action: datasources.ActionQuery}))
+		signedInUser.UserID = 1
+		webtest.RequestWithSignedInUser(req, signedInUser)
+		resp, err := httpServer.SendJSON(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp
+	}
+
+	t.Run("the data source requests are captured with the capture header", func(t *testing.T) {
+		resp := send(canExplore, true)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NotNil(t, qds.recorder)
+		require.Equal(t, qds.recorder.UID(), resp.Header.Get(querycapture.HeaderCaptureUID))
+	})
+
+	t.Run("the data source requests are not captured without the capture header", func(t *testing.T) {
+		resp := send(canExplore, false)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Nil(t, qds.recorder)
+		require.Empty(t, resp.Header.Get(querycapture.HeaderCaptureUID))
+	})
+
+	t.Run("Status code is 403 when a user who can't explore the data sources captures their requests", func(t *testing.T) {
+		resp := send(nil, true)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
+
 func TestAPIEndpoint_Metrics_PluginDecryptionFailure(t *testing.T) {
 	qds := query.ProvideService(
 		setting.NewCfg(),
@@ -109,6 +315,10 @@ func TestAPIEndpoint_Metrics_PluginDecryptionFailure(t *testing.T) {
 				return &backend.QueryDataResponse{Responses: resp}, nil
 			},
 		},
+		nil,
+		nil,
+		nil,
+		nil,
 	)
 	httpServer := SetupAPITestServer(t, func(hs *HTTPServer) {
 		hs.queryDataService = qds
@@ -262,6 +472,10 @@ func TestDataSourceQueryError(t *testing.T) {
 					&fakePluginRequestValidator{},
 					&fakeDatasources.FakeDataSourceService{},
 					pluginClient.ProvideService(r, &config.Cfg{}),
+					nil,
+					nil,
+					nil,
+					nil,
 				)
 				hs.QuotaService = quotatest.New(false, nil)
 			})
@@ -18,9 +18,11 @@ import (
 	glog "github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/auth/identitytoken"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/proxyutil"
@@ -43,6 +45,8 @@ type DataSourceProxy struct {
 	oAuthTokenService  oauthtoken.OAuthTokenService
 	dataSourcesService datasources.DataSourceService
 	tracer             tracing.Tracer
+	teamOverrides      datasources.RequestOverrides
+	identityTokens     *identitytoken.Service
 }
 
 type httpClient interface {
@@ -53,11 +57,19 @@ type httpClient interface {
 func NewDataSourceProxy(ds *datasources.DataSource, pluginRoutes []*plugins.Route, ctx *contextmodel.ReqContext,
 	proxyPath string, cfg *setting.Cfg, clientProvider httpclient.Provider,
 	oAuthTokenService oauthtoken.OAuthTokenService, dsService datasources.DataSourceService,
-	tracer tracing.Tracer) (*DataSourceProxy, error) {
+	tracer tracing.Tracer, identityTokens *identitytoken.Service) (*DataSourceProxy, error) {
 	targetURL, err := datasource.ValidateURL(ds.Type, ds.URL)
 	if err != nil {
 		return nil, err
 	}
+	teamOverrides, err := ds.TeamOverrides()
+	if err != nil {
+		return nil, err
+	}
+	var teams []int64
+	if ctx.SignedInUser != nil {
+		teams = ctx.Teams
+	}
 
 	return &DataSourceProxy{
 		ds:                 ds,
@@ -70,6 +82,8 @@ func NewDataSourceProxy(ds *datasources.DataSource, pluginRoutes []*plugins.Rout
 		oAuthTokenService:  oAuthTokenService,
 		dataSourcesService: dsService,
 		tracer:             tracer,
+		teamOverrides:      teamOverrides.ForTeams(teams).Without(identityTokens.HeaderName()),
+		identityTokens:     identityTokens,
 	}, nil
 }
 
@@ -85,6 +99,16 @@ func (proxy *DataSourceProxy) HandleRequest() {
 		proxy.ctx.JsonApiErr(403, err.Error(), nil)
 		return
 	}
+	// the label matchers and the TraceQL filters of the teams are added to the queries of the request before it is
+	// proxied, the queries that can't be parsed are rejected, and so are the requests the overrides don't apply to
+	if err := proxy.teamOverrides.EnforceRequest(proxy.ds.Type, proxy.ctx.Req); err != nil {
+		if errors.Is(err, datasources.ErrTeamOverridesNotEnforced) {
+			proxy.ctx.JsonApiErr(http.StatusForbidden, err.Error(), err)
+			return
+		}
+		proxy.ctx.JsonApiErr(http.StatusBadRequest, "Failed to add the team overrides to the request", err)
+		return
+	}
 
 	proxyErrorLogger := logger.New(
 		"userId", proxy.ctx.UserID,
@@ -139,7 +163,8 @@ func (proxy *DataSourceProxy) HandleRequest() {
 	proxy.ctx.Req = proxy.ctx.Req.WithContext(ctx)
 
 	span.SetAttributes("datasource_name", proxy.ds.Name, attribute.Key("datasource_name").String(proxy.ds.Name))
-	span.SetAttributes("datasource_type", proxy.ds.Type, attribute.Key("datasource_type").String(proxy.ds.Type))
+	span.SetAttributes("datasource_type", proxy.ds.Type, tracing.DatasourceTypeAttributeKey.String(proxy.ds.Type))
+	span.SetAttributes("datasource_uid", proxy.ds.UID, tracing.DatasourceUIDAttributeKey.String(proxy.ds.UID))
 	span.SetAttributes("user", proxy.ctx.SignedInUser.Login, attribute.Key("user").String(proxy.ctx.SignedInUser.Login))
 	span.SetAttributes("org_id", proxy.ctx.SignedInUser.OrgID, attribute.Key("org_id").Int64(proxy.ctx.SignedInUser.OrgID))
 
@@ -224,6 +249,16 @@ func (proxy *DataSourceProxy) director(req *http.Request) {
 
 	proxyutil.ApplyUserHeader(proxy.cfg.SendUserHeader, req, proxy.ctx.SignedInUser)
 
+	if proxy.cfg.SendBaggage {
+		members := proxyutil.UserBaggageMembers(proxy.ctx.SignedInUser)
+		members[proxyutil.BaggageDatasourceUIDKey] = proxy.ds.UID
+		if proxy.ctx.Context != nil && proxy.ctx.Req != nil {
+			members[proxyutil.BaggageDashboardUIDKey] = proxy.ctx.Req.Header.Get(query.HeaderDashboardUID)
+			members[proxyutil.BaggagePanelIDKey] = proxy.ctx.Req.Header.Get(query.HeaderPanelID)
+		}
+		proxyutil.ApplyBaggageHeader(req, members, proxy.cfg.BaggageRedactedKeys)
+	}
+
 	proxyutil.ClearCookieHeader(req, proxy.ds.AllowedCookies(), []string{proxy.cfg.LoginCookieName})
 	req.Header.Set("User-Agent", proxy.cfg.DataProxyUserAgent)
 
@@ -261,6 +296,22 @@ func (proxy *DataSourceProxy) director(req *http.Request) {
 			}
 		}
 	}
+
+	if proxy.identityTokens.IsEnabled(proxy.ds) {
+		// the token of another user is never forwarded
+		req.Header.Del(proxy.identityTokens.HeaderName())
+		token, err := proxy.identityTokens.Token(proxy.ctx.SignedInUser, proxy.ds)
+		if err != nil {
+			ctxLogger.Error("Failed to sign the identity token", "error", err)
+			return
+		}
+		if token != "" {
+			req.Header.Set(proxy.identityTokens.HeaderName(), token)
+		}
+	}
+
+	// set last so the team overrides replace the headers and query parameters of the user and of the routes
+	proxy.teamOverrides.Apply(req)
 }
 
 func (proxy *DataSourceProxy) validateRequest() error {
@@ -3,7 +3,11 @@ package pluginproxy
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
@@ -11,12 +15,15 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
 	"golang.org/x/oauth2"
 
 	"github.com/grafana/grafana/pkg/api/datasource"
@@ -27,6 +34,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
 	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/auth/identitytoken"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	datasourceservice "github.com/grafana/grafana/pkg/services/datasources/service"
@@ -41,6 +49,7 @@ import (
 	secretsmng "github.com/grafana/grafana/pkg/services/secrets/manager"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/proxyutil"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -145,7 +154,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 			dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 			require.NoError(t, err)
 			proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/v4/some/method", cfg, httpClientProvider,
-				&oauthtoken.Service{}, dsService, tracer)
+				&oauthtoken.Service{}, dsService, tracer, nil)
 			require.NoError(t, err)
 			proxy.matchedRoute = routes[0]
 			ApplyRoute(proxy.ctx.Req.Context(), req, proxy.proxyPath, proxy.matchedRoute, dsInfo, cfg)
@@ -159,7 +168,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 			quotaService := quotatest.New(false, nil)
 			dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 			require.NoError(t, err)
-			proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/common/some/method", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+			proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/common/some/method", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 			require.NoError(t, err)
 			proxy.matchedRoute = routes[3]
 			ApplyRoute(proxy.ctx.Req.Context(), req, proxy.proxyPath, proxy.matchedRoute, dsInfo, cfg)
@@ -173,7 +182,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 			quotaService := quotatest.New(false, nil)
 			dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 			require.NoError(t, err)
-			proxy, err := NewDataSourceProxy(ds, routes, ctx, "", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+			proxy, err := NewDataSourceProxy(ds, routes, ctx, "", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 			require.NoError(t, err)
 			proxy.matchedRoute = routes[4]
 			ApplyRoute(proxy.ctx.Req.Context(), req, proxy.proxyPath, proxy.matchedRoute, dsInfo, cfg)
@@ -186,7 +195,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 			quotaService := quotatest.New(false, nil)
 			dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 			require.NoError(t, err)
-			proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/body", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+			proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/body", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 			require.NoError(t, err)
 			proxy.matchedRoute = routes[5]
 			ApplyRoute(proxy.ctx.Req.Context(), req, proxy.proxyPath, proxy.matchedRoute, dsInfo, cfg)
@@ -202,7 +211,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 				quotaService := quotatest.New(false, nil)
 				dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 				require.NoError(t, err)
-				proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/v4/some/method", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+				proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/v4/some/method", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 				require.NoError(t, err)
 				err = proxy.validateRequest()
 				require.NoError(t, err)
@@ -213,7 +222,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 				quotaService := quotatest.New(false, nil)
 				dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 				require.NoError(t, err)
-				proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/admin", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+				proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/admin", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 				require.NoError(t, err)
 				err = proxy.validateRequest()
 				require.Error(t, err)
@@ -225,7 +234,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 				quotaService := quotatest.New(false, nil)
 				dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 				require.NoError(t, err)
-				proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/admin", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+				proxy, err := NewDataSourceProxy(ds, routes, ctx, "api/admin", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 				require.NoError(t, err)
 				err = proxy.validateRequest()
 				require.NoError(t, err)
@@ -318,7 +327,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 				quotaService := quotatest.New(false, nil)
 				dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 				require.NoError(t, err)
-				proxy, err := NewDataSourceProxy(ds, routes, ctx, "pathwithtoken1", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+				proxy, err := NewDataSourceProxy(ds, routes, ctx, "pathwithtoken1", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 				require.NoError(t, err)
 				ApplyRoute(proxy.ctx.Req.Context(), req, proxy.proxyPath, routes[0], dsInfo, cfg)
 
@@ -336,7 +345,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 					quotaService := quotatest.New(false, nil)
 					dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 					require.NoError(t, err)
-					proxy, err := NewDataSourceProxy(ds, routes, ctx, "pathwithtoken2", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+					proxy, err := NewDataSourceProxy(ds, routes, ctx, "pathwithtoken2", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 					require.NoError(t, err)
 					ApplyRoute(proxy.ctx.Req.Context(), req, proxy.proxyPath, routes[1], dsInfo, cfg)
 
@@ -355,7 +364,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 						quotaService := quotatest.New(false, nil)
 						dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 						require.NoError(t, err)
-						proxy, err := NewDataSourceProxy(ds, routes, ctx, "pathwithtoken1", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+						proxy, err := NewDataSourceProxy(ds, routes, ctx, "pathwithtoken1", cfg, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 						require.NoError(t, err)
 						ApplyRoute(proxy.ctx.Req.Context(), req, proxy.proxyPath, routes[0], dsInfo, cfg)
 
@@ -381,7 +390,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{BuildVersion: "5.3.0"}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{BuildVersion: "5.3.0"}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 		req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
 		require.NoError(t, err)
@@ -410,7 +419,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 
 		req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
@@ -438,7 +447,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 
 		requestURL, err := url.Parse("http://grafana.com/sub")
@@ -470,7 +479,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, pluginRoutes, ctx, "", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, pluginRoutes, ctx, "", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 
 		requestURL, err := url.Parse("http://grafana.com/sub")
@@ -497,7 +506,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/path/to/folder/", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/path/to/folder/", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 		req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
 		req.Header.Set("Origin", "grafana.com")
@@ -550,7 +559,7 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/path/to/folder/", &setting.Cfg{}, httpClientProvider, &mockAuthToken, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/path/to/folder/", &setting.Cfg{}, httpClientProvider, &mockAuthToken, dsService, tracer, nil)
 		require.NoError(t, err)
 		req, err = http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
 		require.NoError(t, err)
@@ -600,6 +609,46 @@ func TestDataSourceProxy_routeRule(t *testing.T) {
 		assert.Empty(t, req.Header.Get("X-Grafana-User"))
 	})
 
+	t.Run("When SendBaggage config is enabled", func(t *testing.T) {
+		incoming, err := http.NewRequest(http.MethodGet, "/api/datasources/proxy/uid/ds/query", nil)
+		require.NoError(t, err)
+		incoming.Header.Set("X-Dashboard-Uid", "dash-uid")
+		incoming.Header.Set("X-Panel-Id", "2")
+
+		req := getDatasourceProxiedRequest(
+			t,
+			&contextmodel.ReqContext{
+				Context: &web.Context{Req: incoming},
+				SignedInUser: &user.SignedInUser{
+					UserID: 3,
+					OrgID:  1,
+					Login:  "test_user",
+				},
+			},
+			&setting.Cfg{SendBaggage: true, BaggageRedactedKeys: map[string]bool{proxyutil.BaggageUserLoginKey: true}},
+		)
+		b, err := baggage.Parse(req.Header.Get(proxyutil.BaggageHeaderName))
+		require.NoError(t, err)
+		assert.Equal(t, "3", b.Member(proxyutil.BaggageUserIDKey).Value())
+		assert.Equal(t, "1", b.Member(proxyutil.BaggageOrgIDKey).Value())
+		assert.Equal(t, "dash-uid", b.Member(proxyutil.BaggageDashboardUIDKey).Value())
+		assert.Equal(t, "2", b.Member(proxyutil.BaggagePanelIDKey).Value())
+		assert.NotEqual(t, "test_user", b.Member(proxyutil.BaggageUserLoginKey).Value())
+	})
+
+	t.Run("When SendBaggage config is disabled", func(t *testing.T) {
+		req := getDatasourceProxiedRequest(
+			t,
+			&contextmodel.ReqContext{
+				SignedInUser: &user.SignedInUser{
+					Login: "test_user",
+				},
+			},
+			&setting.Cfg{},
+		)
+		assert.Empty(t, req.Header.Get(proxyutil.BaggageHeaderName))
+	})
+
 	t.Run("When proxying data source proxy should handle authentication", func(t *testing.T) {
 		sqlStore := db.InitTestDB(t)
 		secretsService := secretsmng.SetupTestService(t, fakes.NewFakeSecretsStore())
@@ -689,7 +738,7 @@ func TestDataSourceProxy_requestHandling(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 
 		proxy.HandleRequest()
@@ -711,7 +760,7 @@ func TestDataSourceProxy_requestHandling(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 
 		proxy.HandleRequest()
@@ -729,7 +778,7 @@ func TestDataSourceProxy_requestHandling(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 
 		proxy.HandleRequest()
@@ -755,7 +804,7 @@ func TestDataSourceProxy_requestHandling(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/render", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 
 		proxy.HandleRequest()
@@ -784,7 +833,7 @@ func TestDataSourceProxy_requestHandling(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/path/%2Ftest%2Ftest%2F", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/path/%2Ftest%2Ftest%2F", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 
 		proxy.HandleRequest()
@@ -812,7 +861,7 @@ func TestDataSourceProxy_requestHandling(t *testing.T) {
 		quotaService := quotatest.New(false, nil)
 		dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 		require.NoError(t, err)
-		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/path/%2Ftest%2Ftest%2F", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer)
+		proxy, err := NewDataSourceProxy(ds, routes, ctx, "/path/%2Ftest%2Ftest%2F", &setting.Cfg{}, httpClientProvider, &oauthtoken.Service{}, dsService, tracer, nil)
 		require.NoError(t, err)
 
 		proxy.HandleRequest()
@@ -841,7 +890,7 @@ func TestNewDataSourceProxy_InvalidURL(t *testing.T) {
 	var err error
 	dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 	require.NoError(t, err)
-	_, err = NewDataSourceProxy(&ds, routes, &ctx, "api/method", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer)
+	_, err = NewDataSourceProxy(&ds, routes, &ctx, "api/method", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer, nil)
 	require.Error(t, err)
 	assert.True(t, strings.HasPrefix(err.Error(), `validation of data source URL "://host/root" failed`))
 }
@@ -865,7 +914,7 @@ func TestNewDataSourceProxy_ProtocolLessURL(t *testing.T) {
 	quotaService := quotatest.New(false, nil)
 	dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 	require.NoError(t, err)
-	_, err = NewDataSourceProxy(&ds, routes, &ctx, "api/method", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer)
+	_, err = NewDataSourceProxy(&ds, routes, &ctx, "api/method", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer, nil)
 
 	require.NoError(t, err)
 }
@@ -911,7 +960,7 @@ func TestNewDataSourceProxy_MSSQL(t *testing.T) {
 			quotaService := quotatest.New(false, nil)
 			dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 			require.NoError(t, err)
-			p, err := NewDataSourceProxy(&ds, routes, &ctx, "api/method", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer)
+			p, err := NewDataSourceProxy(&ds, routes, &ctx, "api/method", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer, nil)
 			if tc.err == nil {
 				require.NoError(t, err)
 				assert.Equal(t, &url.URL{
@@ -926,6 +975,184 @@ func TestNewDataSourceProxy_MSSQL(t *testing.T) {
 	}
 }
 
+func TestDataSourceProxy_teamOverrides(t *testing.T) {
+	tracer := tracing.InitializeTracerForTest()
+	cfg := &setting.Cfg{}
+	sqlStore := db.InitTestDB(t)
+	secretsService := secretsmng.SetupTestService(t, fakes.NewFakeSecretsStore())
+	secretsStore := secretskvs.NewSQLSecretsKVStore(sqlStore, secretsService, log.New("test.logger"))
+	quotaService := quotatest.New(false, nil)
+	dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
+	require.NoError(t, err)
+
+	newProxy := func(teams []int64, jsonData string) (*DataSourceProxy, error) {
+		json, err := simplejson.NewJson([]byte(jsonData))
+		require.NoError(t, err)
+		ctx := &contextmodel.ReqContext{
+			Context:      &web.Context{},
+			SignedInUser: &user.SignedInUser{OrgRole: org.RoleViewer, Teams: teams},
+		}
+		ds := &datasources.DataSource{Type: "prometheus", URL: "http://prometheus:9090", JsonData: json}
+		return NewDataSourceProxy(ds, nil, ctx, "api/v1/query", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer, nil)
+	}
+	const jsonData = `{"teamOverrides": {
+		"1": {"headers": [{"name": "X-Prom-Label-Policy", "value": "a"}], "queryParams": [{"name": "namespace", "value": "a"}]},
+		"2": {"headers": [{"name": "X-Scope-OrgID", "value": "team-b"}]}
+	}}`
+
+	t.Run("replaces the headers and query parameters of the user with the overrides of their teams", func(t *testing.T) {
+		proxy, err := newProxy([]int64{1, 2}, jsonData)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub?query=up&namespace=b", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Prom-Label-Policy", "b")
+		proxy.director(req)
+
+		assert.Equal(t, "a", req.Header.Get("X-Prom-Label-Policy"))
+		assert.Equal(t, "team-b", req.Header.Get("X-Scope-OrgID"))
+		assert.Equal(t, []string{"a"}, req.URL.Query()["namespace"])
+		assert.Equal(t, "up", req.URL.Query().Get("query"))
+	})
+
+	t.Run("does not change the requests of users without overrides", func(t *testing.T) {
+		proxy, err := newProxy([]int64{3}, jsonData)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub?query=up&namespace=b", nil)
+		require.NoError(t, err)
+		proxy.director(req)
+
+		assert.Empty(t, req.Header.Get("X-Prom-Label-Policy"))
+		assert.Equal(t, "b", req.URL.Query().Get("namespace"))
+	})
+
+	t.Run("fails with invalid overrides", func(t *testing.T) {
+		_, err := newProxy([]int64{1}, `{"teamOverrides": {"1": {"queryParams": [{"value": "a"}]}}}`)
+		require.Error(t, err)
+	})
+
+	t.Run("adds the label matchers and the TraceQL filters of the teams to the queries", func(t *testing.T) {
+		var received []string
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = append(received, r.URL.Query().Get("query")+r.URL.Query().Get("q"))
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(backend.Close)
+		proxyRequest := func(t *testing.T, dsType string, path string) int {
+			t.Helper()
+			json, err := simplejson.NewJson([]byte(`{"teamOverrides": {"1": {
+				"labelMatchers": ["namespace=\"a\""],
+				"traceQLFilters": [{"scope": "resource", "tag": "namespace", "operator": "=", "value": "a"}]
+			}}}`))
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			ctx := &contextmodel.ReqContext{
+				Context: &web.Context{
+					Req:  httptest.NewRequest(http.MethodGet, "/api/datasources/proxy/uid/ds/"+path, nil),
+					Resp: web.NewResponseWriter(http.MethodGet, recorder),
+				},
+				SignedInUser: &user.SignedInUser{OrgRole: org.RoleViewer, Teams: []int64{1}},
+				Logger:       log.New("test"),
+			}
+			ds := &datasources.DataSource{Type: dsType, URL: backend.URL, JsonData: json}
+			proxy, err := NewDataSourceProxy(ds, nil, ctx, strings.Split(path, "?")[0], cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer, nil)
+			require.NoError(t, err)
+			proxy.HandleRequest()
+			return recorder.Code
+		}
+
+		assert.Equal(t, http.StatusOK, proxyRequest(t, datasources.DS_PROMETHEUS, "api/v1/query?query=sum(up)"))
+		assert.Equal(t, http.StatusOK, proxyRequest(t, datasources.DS_TEMPO, "api/search?q=%7B%7D"))
+		assert.Equal(t, http.StatusOK, proxyRequest(t, datasources.DS_TEMPO, "api/search?tags=service.name%3Dshop"))
+		assert.Equal(t, []string{
+			`sum(up{namespace="a"})`,
+			`{ resource.namespace = "a" }`,
+			`{ resource.namespace = "a" }`,
+		}, received)
+
+		assert.Equal(t, http.StatusBadRequest, proxyRequest(t, datasources.DS_PROMETHEUS, "api/v1/query?query=sum("))
+		assert.Equal(t, http.StatusForbidden, proxyRequest(t, datasources.DS_TEMPO, "api/traces/abc123"))
+		assert.Equal(t, http.StatusForbidden, proxyRequest(t, datasources.DS_LOKI, "loki/api/v1/query_range?query=%7Bapp%3D%22shop%22%7D"))
+		assert.Len(t, received, 3)
+	})
+}
+
+func TestDataSourceProxy_identityToken(t *testing.T) {
+	tracer := tracing.InitializeTracerForTest()
+	sqlStore := db.InitTestDB(t)
+	secretsService := secretsmng.SetupTestService(t, fakes.NewFakeSecretsStore())
+	secretsStore := secretskvs.NewSQLSecretsKVStore(sqlStore, secretsService, log.New("test.logger"))
+	quotaService := quotatest.New(false, nil)
+	dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, &setting.Cfg{}, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	cfg := setting.NewCfg()
+	cfg.AppURL = "https://grafana.example.com/"
+	cfg.DataProxyIdentityToken = setting.IdentityTokenSettings{SigningKeyFile: keyFile, HeaderName: "X-Grafana-Id"}
+	identityTokens, err := identitytoken.ProvideService(cfg)
+	require.NoError(t, err)
+
+	newProxy := func(jsonData string) *DataSourceProxy {
+		json, err := simplejson.NewJson([]byte(jsonData))
+		require.NoError(t, err)
+		ctx := &contextmodel.ReqContext{
+			Context:      &web.Context{},
+			SignedInUser: &user.SignedInUser{UserID: 4, OrgID: 1, OrgRole: org.RoleViewer, Teams: []int64{2}},
+		}
+		ds := &datasources.DataSource{UID: "loki", OrgID: 1, Type: "loki", URL: "http://loki-gateway:3100", JsonData: json}
+		proxy, err := NewDataSourceProxy(ds, nil, ctx, "loki/api/v1/query", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer, identityTokens)
+		require.NoError(t, err)
+		return proxy
+	}
+
+	t.Run("replaces the identity token of the request with the token of the user", func(t *testing.T) {
+		proxy := newProxy(`{"forwardIdentityToken": true}`)
+		req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Grafana-Id", "forged")
+		proxy.director(req)
+
+		parsed, err := jwt.ParseSigned(req.Header.Get("X-Grafana-Id"))
+		require.NoError(t, err)
+		var claims identitytoken.Claims
+		require.NoError(t, parsed.Claims(&key.PublicKey, &claims))
+		assert.Equal(t, "user:4", claims.Subject)
+		assert.Equal(t, jwt.Audience{"https://grafana.example.com/orgs/1/datasources/loki"}, claims.Audience)
+		assert.Equal(t, []int64{2}, claims.Teams)
+	})
+
+	t.Run("does not let the team overrides replace the identity token", func(t *testing.T) {
+		proxy := newProxy(`{"forwardIdentityToken": true, "teamOverrides": {"2": {"headers": [
+			{"name": "x-grafana-id", "value": "forged"},
+			{"name": "X-Scope-OrgID", "value": "team-2"}
+		]}}}`)
+		req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
+		require.NoError(t, err)
+		proxy.director(req)
+
+		parsed, err := jwt.ParseSigned(req.Header.Get("X-Grafana-Id"))
+		require.NoError(t, err)
+		var claims identitytoken.Claims
+		require.NoError(t, parsed.Claims(&key.PublicKey, &claims))
+		assert.Equal(t, "user:4", claims.Subject)
+		assert.Equal(t, "team-2", req.Header.Get("X-Scope-OrgID"))
+	})
+
+	t.Run("does not send identity tokens to the other datasources", func(t *testing.T) {
+		proxy := newProxy(`{}`)
+		req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
+		require.NoError(t, err)
+		proxy.director(req)
+
+		assert.Empty(t, req.Header.Get("X-Grafana-Id"))
+	})
+}
+
 // getDatasourceProxiedRequest is a helper for easier setup of tests based on global config and ReqContext.
 func getDatasourceProxiedRequest(t *testing.T, ctx *contextmodel.ReqContext, cfg *setting.Cfg) *http.Request {
 	ds := &datasources.DataSource{
@@ -941,7 +1168,7 @@ func getDatasourceProxiedRequest(t *testing.T, ctx *contextmodel.ReqContext, cfg
 	quotaService := quotatest.New(false, nil)
 	dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 	require.NoError(t, err)
-	proxy, err := NewDataSourceProxy(ds, routes, ctx, "", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer)
+	proxy, err := NewDataSourceProxy(ds, routes, ctx, "", cfg, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer, nil)
 	require.NoError(t, err)
 	req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
 	require.NoError(t, err)
@@ -1060,7 +1287,7 @@ func runDatasourceAuthTest(t *testing.T, secretsService secrets.Service, secrets
 	quotaService := quotatest.New(false, nil)
 	dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 	require.NoError(t, err)
-	proxy, err := NewDataSourceProxy(test.datasource, routes, ctx, "", &setting.Cfg{}, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer)
+	proxy, err := NewDataSourceProxy(test.datasource, routes, ctx, "", &setting.Cfg{}, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer, nil)
 	require.NoError(t, err)
 
 	req, err := http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
@@ -1106,7 +1333,7 @@ func Test_PathCheck(t *testing.T) {
 	quotaService := quotatest.New(false, nil)
 	dsService, err := datasourceservice.ProvideService(nil, secretsService, secretsStore, cfg, featuremgmt.WithFeatures(), acmock.New(), acmock.NewMockedPermissionsService(), quotaService)
 	require.NoError(t, err)
-	proxy, err := NewDataSourceProxy(&datasources.DataSource{}, routes, ctx, "b", &setting.Cfg{}, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer)
+	proxy, err := NewDataSourceProxy(&datasources.DataSource{}, routes, ctx, "b", &setting.Cfg{}, httpclient.NewProvider(), &oauthtoken.Service{}, dsService, tracer, nil)
 	require.NoError(t, err)
 
 	require.Nil(t, proxy.validateRequest())
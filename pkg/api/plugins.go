@@ -467,12 +467,17 @@ func (hs *HTTPServer) InstallPlugin(c *contextmodel.ReqContext) response.Respons
 		GrafanaVersion: hs.Cfg.BuildVersion,
 		OS:             runtime.GOOS,
 		Arch:           runtime.GOARCH,
+		Checksum:       dto.Checksum,
 	})
 	if err != nil {
 		var dupeErr plugins.DuplicateError
 		if errors.As(err, &dupeErr) {
 			return response.Error(http.StatusConflict, "Plugin already installed", err)
 		}
+		var versionPinnedErr plugins.VersionPinnedError
+		if errors.As(err, &versionPinnedErr) {
+			return response.Error(http.StatusConflict, "Plugin version is pinned", err)
+		}
 		var versionUnsupportedErr repo.ErrVersionUnsupported
 		if errors.As(err, &versionUnsupportedErr) {
 			return response.Error(http.StatusConflict, "Plugin version not supported", err)
@@ -488,6 +493,12 @@ func (hs *HTTPServer) InstallPlugin(c *contextmodel.ReqContext) response.Respons
 		if errors.Is(err, plugins.ErrInstallCorePlugin) {
 			return response.Error(http.StatusForbidden, "Cannot install or change a Core plugin", err)
 		}
+		if errors.Is(err, repo.ErrChecksumMismatch) {
+			return response.Error(http.StatusBadRequest, "Plugin archive checksum mismatch", err)
+		}
+		if errors.Is(err, plugins.ErrInstallInvalidSignature) {
+			return response.Error(http.StatusForbidden, "Plugin does not have a valid signature", err)
+		}
 
 		return response.Error(http.StatusInternalServerError, "Failed to install plugin", err)
 	}
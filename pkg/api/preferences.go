@@ -235,6 +235,64 @@ func (hs *HTTPServer) patchPreferencesFor(ctx context.Context, orgID, userID, te
 	return response.Success("Preferences updated")
 }
 
+// swagger:route GET /user/preferences/query-defaults user_preferences getUserQueryDefaults
+//
+// Get the query defaults of the user.
+//
+// The query defaults are applied by the query service to the queries of the user that don't set them.
+//
+// Responses:
+// 200: getQueryDefaultsResponse
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) GetUserQueryDefaults(c *contextmodel.ReqContext) response.Response {
+	preference, err := hs.preferenceService.Get(c.Req.Context(), &pref.GetPreferenceQuery{OrgID: c.OrgID, UserID: c.UserID})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get preferences", err)
+	}
+
+	defaults := pref.QueryDefaultsPreference{}
+	if preference.JSONData != nil && preference.JSONData.QueryDefaults != nil {
+		defaults = *preference.JSONData.QueryDefaults
+	}
+	return response.JSON(http.StatusOK, defaults)
+}
+
+// swagger:route PUT /user/preferences/query-defaults user_preferences updateUserQueryDefaults
+//
+// Update the query defaults of the user.
+//
+// Omitting a key will remove the default, empty defaults are removed.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) UpdateUserQueryDefaults(c *contextmodel.ReqContext) response.Response {
+	defaults := pref.QueryDefaultsPreference{}
+	if err := web.Bind(c.Req, &defaults); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if defaults.DatasourceUID != "" {
+		if _, err := hs.DataSourceCache.GetDatasourceByUID(c.Req.Context(), defaults.DatasourceUID, c.SignedInUser, false); err != nil {
+			return response.Error(http.StatusBadRequest, "Data source not found", err)
+		}
+	}
+
+	patchCmd := pref.PatchPreferenceCommand{
+		UserID:        c.UserID,
+		OrgID:         c.OrgID,
+		QueryDefaults: &defaults,
+	}
+	if err := hs.preferenceService.Patch(c.Req.Context(), &patchCmd); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to save preferences", err)
+	}
+
+	return response.Success("Query defaults updated")
+}
+
 // swagger:route GET /org/preferences org_preferences getOrgPreferences
 //
 // Get Current Org Prefs.
@@ -305,6 +363,19 @@ type GetPreferencesResponse struct {
 	Body preferences.Preferences `json:"body"`
 }
 
+// swagger:parameters updateUserQueryDefaults
+type UpdateUserQueryDefaultsParams struct {
+	// in:body
+	// required:true
+	Body pref.QueryDefaultsPreference `json:"body"`
+}
+
+// swagger:response getQueryDefaultsResponse
+type GetQueryDefaultsResponse struct {
+	// in:body
+	Body pref.QueryDefaultsPreference `json:"body"`
+}
+
 // swagger:parameters patchUserPreferences
 type PatchUserPreferencesParams struct {
 	// in:body
@@ -13,6 +13,8 @@ import (
 
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/org"
 	pref "github.com/grafana/grafana/pkg/services/preference"
 	"github.com/grafana/grafana/pkg/services/preference/preftest"
@@ -26,6 +28,7 @@ var (
 	putOrgPreferencesURL    = "/api/org/preferences/"
 	patchOrgPreferencesUrl  = "/api/org/preferences/"
 	patchUserPreferencesUrl = "/api/user/preferences/"
+	userQueryDefaultsURL    = "/api/user/preferences/query-defaults"
 
 	testUpdateOrgPreferencesCmd                     = `{ "theme": "light", "homeDashboardId": 1 }`
 	testPatchOrgPreferencesCmd                      = `{"navbar":{"savedItems":[{"id":"snapshots","text":"Snapshots","icon":"camera","url":"/dashboard/snapshots"}]}}`
@@ -238,6 +241,48 @@ func TestAPIEndpoint_PatchUserPreferences(t *testing.T) {
 	})
 }
 
+func TestAPIEndpoint_UserQueryDefaults(t *testing.T) {
+	prefService := preftest.NewPreferenceServiceFake()
+	prefService.ExpectedPreference = &pref.Preference{JSONData: &pref.PreferenceJSONData{
+		QueryDefaults: &pref.QueryDefaultsPreference{MaxDataPoints: 500, DatasourceUID: "prom"},
+	}}
+
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.preferenceService = prefService
+		hs.DataSourceCache = &fakeDatasources.FakeCacheService{DataSources: []*datasources.DataSource{{UID: "prom"}}}
+	})
+	signedInUser := &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleViewer}
+
+	t.Run("Returns the query defaults of the user", func(t *testing.T) {
+		req := webtest.RequestWithSignedInUser(server.NewGetRequest(userQueryDefaultsURL), signedInUser)
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		b, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"maxDataPoints": 500, "datasourceUid": "prom"}`, string(b))
+		require.NoError(t, res.Body.Close())
+	})
+
+	for _, tc := range []struct {
+		desc           string
+		body           string
+		expectedStatus int
+	}{
+		{desc: "Returns 200 on success", body: `{"maxDataPoints": 1000, "timeoutSeconds": 30, "datasourceUid": "prom"}`, expectedStatus: http.StatusOK},
+		{desc: "Returns 400 with an unknown datasource", body: `{"datasourceUid": "unknown"}`, expectedStatus: http.StatusBadRequest},
+		{desc: "Returns 400 with bad data", body: `this is not json`, expectedStatus: http.StatusBadRequest},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := webtest.RequestWithSignedInUser(server.NewRequest(http.MethodPut, userQueryDefaultsURL, strings.NewReader(tc.body)), signedInUser)
+			res, err := server.SendJSON(req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
 func TestAPIEndpoint_PatchOrgPreferences(t *testing.T) {
 	cfg := setting.NewCfg()
 	cfg.RBACEnabled = false
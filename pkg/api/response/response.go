@@ -2,11 +2,14 @@ package response
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 
 	jsoniter "github.com/json-iterator/go"
 	"gopkg.in/yaml.v3"
@@ -17,6 +20,9 @@ import (
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
+// etagLength is the number of hexadecimal characters of the hash of the body used as ETag.
+const etagLength = 32
+
 // Response is an HTTP response interface.
 type Response interface {
 	// WriteTo writes to a context.
@@ -101,6 +107,11 @@ func (r *NormalResponse) WriteTo(ctx *contextmodel.ReqContext) {
 	for k, v := range r.header {
 		header[k] = v
 	}
+	if r.notModified(ctx.Req) {
+		header.Del("Content-Type")
+		ctx.Resp.WriteHeader(http.StatusNotModified)
+		return
+	}
 	ctx.Resp.WriteHeader(r.status)
 	if _, err := ctx.Resp.Write(r.body.Bytes()); err != nil {
 		ctx.Logger.Error("Error writing to response", "err", err)
@@ -112,6 +123,33 @@ func (r *NormalResponse) SetHeader(key, value string) *NormalResponse {
 	return r
 }
 
+// WithETag sets the ETag of the response to a hash of its body. A successful response with an ETag is written as a
+// 304 Not Modified without body when the If-None-Match header of the request matches the ETag.
+func (r *NormalResponse) WithETag() *NormalResponse {
+	sum := sha256.Sum256(r.body.Bytes())
+	return r.SetHeader("ETag", fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:])[:etagLength]))
+}
+
+// notModified returns whether the If-None-Match header of the request matches the ETag of the response, using the
+// weak comparison of RFC 7232.
+func (r *NormalResponse) notModified(req *http.Request) bool {
+	etag := r.header.Get("ETag")
+	if etag == "" || r.status != http.StatusOK || req == nil {
+		return false
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	for _, v := range strings.Split(req.Header.Get("If-None-Match"), ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
 // StreamingResponse is a response that streams itself back to the client.
 type StreamingResponse struct {
 	body   interface{}
@@ -3,12 +3,16 @@ package response
 import (
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/infra/log"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 func TestErrors(t *testing.T) {
@@ -125,3 +129,50 @@ func TestErrors(t *testing.T) {
 		)
 	}
 }
+
+func TestETag(t *testing.T) {
+	write := func(rsp *NormalResponse, method string, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/dashboards/uid/abc", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		recorder := httptest.NewRecorder()
+		rsp.WriteTo(&contextmodel.ReqContext{
+			Context: &web.Context{Req: req, Resp: web.NewResponseWriter(method, recorder)},
+			Logger:  log.New("test"),
+		})
+		return recorder
+	}
+
+	body := map[string]string{"uid": "abc"}
+	etag := JSON(http.StatusOK, body).WithETag().Header().Get("ETag")
+	require.Len(t, etag, etagLength+2)
+	require.Equal(t, etag, JSON(http.StatusOK, body).WithETag().Header().Get("ETag"), "the ETag is stable")
+	require.NotEqual(t, etag, JSON(http.StatusOK, map[string]string{"uid": "def"}).WithETag().Header().Get("ETag"))
+
+	t.Run("the body is written without a matching If-None-Match header", func(t *testing.T) {
+		for _, ifNoneMatch := range []string{"", `"other"`} {
+			recorder := write(JSON(http.StatusOK, body).WithETag(), http.MethodGet, ifNoneMatch)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.Equal(t, etag, recorder.Header().Get("ETag"))
+			require.JSONEq(t, `{"uid":"abc"}`, recorder.Body.String())
+		}
+	})
+
+	t.Run("not modified with a matching If-None-Match header", func(t *testing.T) {
+		for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+			recorder := write(JSON(http.StatusOK, body).WithETag(), http.MethodGet, ifNoneMatch)
+			require.Equal(t, http.StatusNotModified, recorder.Code, ifNoneMatch)
+			require.Equal(t, etag, recorder.Header().Get("ETag"))
+			require.Empty(t, recorder.Body.Bytes())
+		}
+	})
+
+	t.Run("only successful GET requests are conditional", func(t *testing.T) {
+		recorder := write(JSON(http.StatusOK, body).WithETag(), http.MethodPost, etag)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		recorder = write(JSON(http.StatusAccepted, body).WithETag(), http.MethodGet, etag)
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+	})
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
@@ -21,22 +24,119 @@ func (hs *HTTPServer) createShortURL(c *contextmodel.ReqContext) response.Respon
 		return response.Err(shorturls.ErrShortURLBadRequest.Errorf("bad request data: %w", err))
 	}
 	hs.log.Debug("Received request to create short URL", "path", cmd.Path)
-	shortURL, err := hs.ShortURLService.CreateShortURL(c.Req.Context(), c.SignedInUser, cmd.Path)
+	var ttl time.Duration
+	if cmd.TTL != "" {
+		var err error
+		if ttl, err = gtime.ParseDuration(cmd.TTL); err != nil {
+			return response.Err(shorturls.ErrShortURLInvalidTTL.Errorf("invalid time to live %q: %w", cmd.TTL, err))
+		}
+	}
+	shortURL, err := hs.ShortURLService.CreateShortURL(c.Req.Context(), c.SignedInUser, cmd.Path, ttl)
 	if err != nil {
 		return response.Err(err)
 	}
 
-	url := fmt.Sprintf("%s/goto/%s?orgId=%d", strings.TrimSuffix(setting.AppUrl, "/"), shortURL.Uid, c.OrgID)
+	url := shortURLFor(shortURL.Uid, c.OrgID)
 	c.Logger.Debug("Created short URL", "url", url)
 
 	dto := dtos.ShortURL{
-		UID: shortURL.Uid,
-		URL: url,
+		UID:       shortURL.Uid,
+		URL:       url,
+		ExpiresAt: unixTime(shortURL.ExpiresAt),
+	}
+
+	return response.JSON(http.StatusOK, dto)
+}
+
+// searchShortURLs handles requests to list the short URLs of the organization.
+func (hs *HTTPServer) searchShortURLs(c *contextmodel.ReqContext) response.Response {
+	query := shorturls.SearchShortURLsQuery{
+		OrgID:     c.OrgID,
+		CreatedBy: c.QueryInt64("createdBy"),
+		Expired:   c.QueryBool("expired"),
+		Page:      c.QueryInt("page"),
+		Limit:     c.QueryInt("perpage"),
+	}
+	result, err := hs.ShortURLService.SearchShortURLs(c.Req.Context(), &query)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to search short URLs", err)
 	}
 
+	dto := dtos.SearchShortURLsResult{
+		TotalCount: result.TotalCount,
+		ShortURLs:  make([]dtos.ShortURLDetails, 0, len(result.ShortURLs)),
+		Page:       query.Page,
+		PerPage:    query.Limit,
+	}
+	for _, shortURL := range result.ShortURLs {
+		dto.ShortURLs = append(dto.ShortURLs, dtos.ShortURLDetails{
+			UID:            shortURL.Uid,
+			URL:            shortURLFor(shortURL.Uid, shortURL.OrgId),
+			Path:           shortURL.Path,
+			CreatedBy:      shortURL.CreatedBy,
+			CreatedByLogin: shortURL.CreatedByLogin,
+			CreatedAt:      time.Unix(shortURL.CreatedAt, 0).UTC(),
+			LastSeenAt:     unixTime(shortURL.LastSeenAt),
+			ExpiresAt:      unixTime(shortURL.ExpiresAt),
+			HitCount:       shortURL.HitCount,
+		})
+	}
 	return response.JSON(http.StatusOK, dto)
 }
 
+// deleteShortURL handles requests to delete a short URL of the organization.
+func (hs *HTTPServer) deleteShortURL(c *contextmodel.ReqContext) response.Response {
+	cmd := shorturls.DeleteShortURLsCommand{OrgID: c.OrgID, UID: web.Params(c.Req)[":uid"]}
+	if err := hs.ShortURLService.DeleteShortURLs(c.Req.Context(), &cmd); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete short URL", err)
+	}
+	if cmd.NumDeleted == 0 {
+		return response.Err(shorturls.ErrShortURLNotFound.Errorf("short URL not found"))
+	}
+	return response.Success("Short URL deleted")
+}
+
+// purgeShortURLs handles requests to delete the short URLs of the organization matching filters.
+func (hs *HTTPServer) purgeShortURLs(c *contextmodel.ReqContext) response.Response {
+	body := dtos.PurgeShortURLsCmd{}
+	if err := web.Bind(c.Req, &body); err != nil {
+		return response.Err(shorturls.ErrShortURLBadRequest.Errorf("bad request data: %w", err))
+	}
+	if !body.Expired && body.NotSeenFor == "" && body.CreatedBy == 0 {
+		return response.Err(shorturls.ErrShortURLBadRequest.Errorf("at least one of the expired, notSeenFor and createdBy filters is required"))
+	}
+
+	now := time.Now()
+	cmd := shorturls.DeleteShortURLsCommand{OrgID: c.OrgID, CreatedBy: body.CreatedBy}
+	if body.Expired {
+		cmd.ExpiredAt = now
+	}
+	if body.NotSeenFor != "" {
+		notSeenFor, err := gtime.ParseDuration(body.NotSeenFor)
+		if err != nil || notSeenFor <= 0 {
+			return response.Err(shorturls.ErrShortURLBadRequest.Errorf("invalid duration %q", body.NotSeenFor))
+		}
+		cmd.NotSeenSince = now.Add(-notSeenFor)
+	}
+	if err := hs.ShortURLService.DeleteShortURLs(c.Req.Context(), &cmd); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete short URLs", err)
+	}
+	return response.JSON(http.StatusOK, dtos.PurgeShortURLsResult{Deleted: cmd.NumDeleted})
+}
+
+func shortURLFor(uid string, orgID int64) string {
+	return fmt.Sprintf("%s/goto/%s?orgId=%d", strings.TrimSuffix(setting.AppUrl, "/"), uid, orgID)
+}
+
+// unixTime returns the time of the unix timestamp, nil when it's 0
+func unixTime(sec int64) *time.Time {
+	if sec == 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0).UTC()
+	return &t
+}
+
 func (hs *HTTPServer) redirectFromShortURL(c *contextmodel.ReqContext) {
 	shortURLUID := web.Params(c.Req)[":uid"]
 
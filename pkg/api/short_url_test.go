@@ -4,8 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -13,9 +18,11 @@ import (
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/log"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
 )
 
 func TestShortURLAPIEndpoint(t *testing.T) {
@@ -31,7 +38,7 @@ func TestShortURLAPIEndpoint(t *testing.T) {
 			Path:  cmd.Path,
 		}
 		service := &fakeShortURLService{
-			createShortURLFunc: func(ctx context.Context, user *user.SignedInUser, path string) (*shorturls.ShortUrl, error) {
+			createShortURLFunc: func(ctx context.Context, user *user.SignedInUser, path string, ttl time.Duration) (*shorturls.ShortUrl, error) {
 				return createResp, nil
 			},
 		}
@@ -49,6 +56,113 @@ func TestShortURLAPIEndpoint(t *testing.T) {
 	})
 }
 
+func TestShortURLManagementAPIEndpoints(t *testing.T) {
+	var (
+		deleted *shorturls.DeleteShortURLsCommand
+		ttl     time.Duration
+	)
+	service := &fakeShortURLService{
+		createShortURLFunc: func(ctx context.Context, user *user.SignedInUser, path string, t time.Duration) (*shorturls.ShortUrl, error) {
+			ttl = t
+			return &shorturls.ShortUrl{Uid: "N1u6L4eGz", OrgId: user.OrgID, Path: path, ExpiresAt: time.Date(2023, 1, 8, 0, 0, 0, 0, time.UTC).Unix()}, nil
+		},
+		searchShortURLsFunc: func(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error) {
+			return &shorturls.SearchShortURLsResult{TotalCount: 1, ShortURLs: []*shorturls.ShortURLWithOwner{{
+				ShortUrl:       shorturls.ShortUrl{Uid: "N1u6L4eGz", OrgId: 1, Path: "explore", CreatedBy: 2, CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), HitCount: 3},
+				CreatedByLogin: "editor",
+			}}}, nil
+		},
+		deleteShortURLsFunc: func(ctx context.Context, cmd *shorturls.DeleteShortURLsCommand) error {
+			deleted = cmd
+			if cmd.UID == "unknown" {
+				return nil
+			}
+			cmd.NumDeleted = 2
+			return nil
+		},
+	}
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.ShortURLService = service
+		hs.log = log.New("test")
+	})
+	admin := &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleAdmin}
+	viewer := &user.SignedInUser{UserID: 2, OrgID: 1, OrgRole: org.RoleViewer}
+
+	t.Run("short URLs are created with a time to live", func(t *testing.T) {
+		req := webtest.RequestWithSignedInUser(server.NewPostRequest("/api/short-urls", strings.NewReader(`{"path": "explore", "ttl": "7d"}`)), viewer)
+		res, err := server.SendJSON(req)
+		require.NoError(t, err)
+		b, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 7*24*time.Hour, ttl)
+		assert.Contains(t, string(b), `"expiresAt":"2023-01-08T00:00:00Z"`)
+
+		req = webtest.RequestWithSignedInUser(server.NewPostRequest("/api/short-urls", strings.NewReader(`{"path": "explore", "ttl": "soon"}`)), viewer)
+		res, err = server.SendJSON(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("org admins can list the short URLs", func(t *testing.T) {
+		res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/short-urls"), viewer))
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		res, err = server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/short-urls?expired=true"), admin))
+		require.NoError(t, err)
+		result := dtos.SearchShortURLsResult{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Len(t, result.ShortURLs, 1)
+		assert.Equal(t, "editor", result.ShortURLs[0].CreatedByLogin)
+		assert.Equal(t, int64(3), result.ShortURLs[0].HitCount)
+		assert.Nil(t, result.ShortURLs[0].LastSeenAt)
+	})
+
+	t.Run("org admins can delete a short URL", func(t *testing.T) {
+		res, err := server.Send(webtest.RequestWithSignedInUser(server.NewRequest(http.MethodDelete, "/api/short-urls/N1u6L4eGz", nil), admin))
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, shorturls.DeleteShortURLsCommand{OrgID: 1, UID: "N1u6L4eGz", NumDeleted: 2}, *deleted)
+
+		res, err = server.Send(webtest.RequestWithSignedInUser(server.NewRequest(http.MethodDelete, "/api/short-urls/unknown", nil), admin))
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("org admins can purge the short URLs", func(t *testing.T) {
+		req := webtest.RequestWithSignedInUser(server.NewPostRequest("/api/short-urls/purge", strings.NewReader(`{"expired": true, "notSeenFor": "30d"}`)), admin)
+		res, err := server.SendJSON(req)
+		require.NoError(t, err)
+		result := dtos.PurgeShortURLsResult{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, int64(2), result.Deleted)
+		assert.False(t, deleted.ExpiredAt.IsZero())
+		assert.WithinDuration(t, time.Now().Add(-30*24*time.Hour), deleted.NotSeenSince, time.Minute)
+	})
+
+	t.Run("purging the short URLs requires a filter", func(t *testing.T) {
+		deleted = nil
+		for _, body := range []string{`{}`, `{"expired": false, "notSeenFor": "", "createdBy": 0}`} {
+			req := webtest.RequestWithSignedInUser(server.NewPostRequest("/api/short-urls/purge", strings.NewReader(body)), admin)
+			res, err := server.SendJSON(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+		}
+		assert.Nil(t, deleted)
+	})
+}
+
 func callCreateShortURL(sc *scenarioContext) {
 	sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
 }
@@ -78,16 +192,18 @@ func createShortURLScenario(t *testing.T, desc string, url string, routePattern
 }
 
 type fakeShortURLService struct {
-	createShortURLFunc func(ctx context.Context, user *user.SignedInUser, path string) (*shorturls.ShortUrl, error)
+	createShortURLFunc  func(ctx context.Context, user *user.SignedInUser, path string, ttl time.Duration) (*shorturls.ShortUrl, error)
+	searchShortURLsFunc func(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error)
+	deleteShortURLsFunc func(ctx context.Context, cmd *shorturls.DeleteShortURLsCommand) error
 }
 
 func (s *fakeShortURLService) GetShortURLByUID(ctx context.Context, user *user.SignedInUser, uid string) (*shorturls.ShortUrl, error) {
 	return nil, nil
 }
 
-func (s *fakeShortURLService) CreateShortURL(ctx context.Context, user *user.SignedInUser, path string) (*shorturls.ShortUrl, error) {
+func (s *fakeShortURLService) CreateShortURL(ctx context.Context, user *user.SignedInUser, path string, ttl time.Duration) (*shorturls.ShortUrl, error) {
 	if s.createShortURLFunc != nil {
-		return s.createShortURLFunc(ctx, user, path)
+		return s.createShortURLFunc(ctx, user, path, ttl)
 	}
 
 	return nil, nil
@@ -100,3 +216,19 @@ func (s *fakeShortURLService) UpdateLastSeenAt(ctx context.Context, shortURL *sh
 func (s *fakeShortURLService) DeleteStaleShortURLs(ctx context.Context, cmd *shorturls.DeleteShortUrlCommand) error {
 	return nil
 }
+
+func (s *fakeShortURLService) SearchShortURLs(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error) {
+	if s.searchShortURLsFunc != nil {
+		return s.searchShortURLsFunc(ctx, query)
+	}
+
+	return &shorturls.SearchShortURLsResult{}, nil
+}
+
+func (s *fakeShortURLService) DeleteShortURLs(ctx context.Context, cmd *shorturls.DeleteShortURLsCommand) error {
+	if s.deleteShortURLsFunc != nil {
+		return s.deleteShortURLsFunc(ctx, cmd)
+	}
+
+	return nil
+}
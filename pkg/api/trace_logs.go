@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// traceLogsQueryPath is the resource of the tracing data sources returning the query of the logs of a trace, in the
+// shape of the body of the query API
+const traceLogsQueryPath = "trace-logs-query"
+
+// GetTraceLogs returns the logs of a trace.
+// swagger:route GET /datasources/uid/{uid}/traces/{traceId}/logs datasources getTraceLogs
+//
+// Get the logs of a trace of a tracing data source.
+//
+// The query of the logs of the trace is returned by the trace-logs-query resource of the tracing data source, like
+// Tempo, from its trace to logs settings. The query is then run in the logs data source as the signed in user.
+//
+// If you are running Grafana Enterprise and have Fine-grained access control enabled
+// you need to have a permission with action: `datasources:query`.
+//
+// Responses:
+// 200: queryMetricsWithExpressionsRespons
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetTraceLogs(c *contextmodel.ReqContext) response.Response {
+	ds, err := hs.DataSourceCache.GetDatasourceByUID(c.Req.Context(), web.Params(c.Req)[":uid"], c.SignedInUser, c.SkipCache)
+	if err != nil {
+		return hs.handleQueryMetricsError(err)
+	}
+	pCtx, found, err := hs.PluginContextProvider.GetWithDataSource(c.Req.Context(), ds.Type, c.SignedInUser, ds)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get plugin settings", err)
+	}
+	if !found {
+		return response.Error(http.StatusNotFound, "Plugin not found", nil)
+	}
+
+	params := url.Values{}
+	params.Set("traceId", web.Params(c.Req)[":traceId"])
+	// without time range the trace is looked up over the whole retention of the data source
+	if from, to := c.Query("from"), c.Query("to"); from != "" && to != "" {
+		timeRange := legacydata.NewDataTimeRange(from, to)
+		params.Set("from", strconv.FormatInt(timeRange.GetFromAsMsEpoch(), 10))
+		params.Set("to", strconv.FormatInt(timeRange.GetToAsMsEpoch(), 10))
+	}
+	if limit := c.Query("limit"); limit != "" {
+		params.Set("limit", limit)
+	}
+
+	var res *backend.CallResourceResponse
+	err = hs.pluginClient.CallResource(c.Req.Context(), &backend.CallResourceRequest{
+		PluginContext: pCtx,
+		Path:          traceLogsQueryPath,
+		Method:        http.MethodGet,
+		URL:           traceLogsQueryPath + "?" + params.Encode(),
+	}, callResourceResponseSenderFunc(func(r *backend.CallResourceResponse) error {
+		res = r
+		return nil
+	}))
+	if errors.Is(err, backendplugin.ErrMethodNotImplemented) {
+		return response.Error(http.StatusBadRequest, "The data source doesn't return the logs of its traces", err)
+	}
+	if err != nil || res == nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get the logs query of the trace", err)
+	}
+	if res.Status != http.StatusOK {
+		var body struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(res.Body, &body); err != nil || body.Message == "" {
+			body.Message = "Failed to get the logs query of the trace"
+		}
+		return response.Error(res.Status, body.Message, nil)
+	}
+
+	var reqDTO dtos.MetricRequest
+	if err := json.Unmarshal(res.Body, &reqDTO); err != nil {
+		return response.Error(http.StatusInternalServerError, "Invalid logs query of the trace", err)
+	}
+	resp, err := hs.queryDataService.QueryData(c.Req.Context(), c.SignedInUser, c.SkipCache, reqDTO)
+	if err != nil {
+		return hs.handleQueryMetricsError(err)
+	}
+	return hs.toJsonStreamingResponse(resp)
+}
+
+type callResourceResponseSenderFunc func(res *backend.CallResourceResponse) error
+
+func (fn callResourceResponseSenderFunc) Send(res *backend.CallResourceResponse) error {
+	return fn(res)
+}
+
+// swagger:parameters getTraceLogs
+type GetTraceLogsParams struct {
+	// in:path
+	// required:true
+	DatasourceUID string `json:"uid"`
+	// in:path
+	// required:true
+	TraceID string `json:"traceId"`
+	// The start of the time range of the trace lookup
+	// in:query
+	// required:false
+	From string `json:"from"`
+	// The end of the time range of the trace lookup
+	// in:query
+	// required:false
+	To string `json:"to"`
+	// The maximum number of log lines
+	// in:query
+	// required:false
+	Limit int64 `json:"limit"`
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginsettings"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+// fakeTraceLogsQueryService returns an empty logs frame to the logs queries
+type fakeTraceLogsQueryService struct {
+	requests []dtos.MetricRequest
+}
+
+func (f *fakeTraceLogsQueryService) Run(context.Context) error {
+	return nil
+}
+
+func (f *fakeTraceLogsQueryService) QueryData(_ context.Context, _ *user.SignedInUser, _ bool, reqDTO dtos.MetricRequest) (*backend.QueryDataResponse, error) {
+	f.requests = append(f.requests, reqDTO)
+	refID := reqDTO.Queries[0].Get("refId").MustString()
+	return &backend.QueryDataResponse{Responses: backend.Responses{refID: {Frames: data.Frames{data.NewFrame("logs")}}}}, nil
+}
+
+// fakeTraceLogsPluginClient returns the response of the trace-logs-query resource
+type fakeTraceLogsPluginClient struct {
+	plugins.Client
+
+	res      *backend.CallResourceResponse
+	err      error
+	requests []*backend.CallResourceRequest
+}
+
+func (c *fakeTraceLogsPluginClient) CallResource(_ context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	c.requests = append(c.requests, req)
+	if c.err != nil {
+		return c.err
+	}
+	return sender.Send(c.res)
+}
+
+func TestAPIEndpoint_GetTraceLogs(t *testing.T) {
+	tempo := &datasources.DataSource{UID: "tempo", Type: datasources.DS_TEMPO}
+	logsQuery := `{"from": "1682935140000", "to": "1682935261500", "queries": [{"refId": "logs", "datasource": {"uid": "loki"},
+		"queryType": "range", "expr": "{cluster=\"eu\"}", "maxLines": 100}]}`
+
+	getLogs := func(t *testing.T, client *fakeTraceLogsPluginClient, url string) (*http.Response, *fakeTraceLogsQueryService) {
+		qs := &fakeTraceLogsQueryService{}
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.DataSourceCache = &fakeDatasources.FakeCacheService{DataSources: []*datasources.DataSource{tempo}}
+			hs.PluginContextProvider = plugincontext.ProvideService(localcache.ProvideService(),
+				plugins.FakePluginStore{PluginList: []plugins.PluginDTO{{JSONData: plugins.JSONData{ID: datasources.DS_TEMPO, Type: plugins.DataSource}}}},
+				&fakeDatasources.FakeCacheService{}, &fakeDatasources.FakeDataSourceService{}, &pluginsettings.FakePluginSettings{})
+			hs.pluginClient = client
+			hs.queryDataService = qs
+			hs.Features = featuremgmt.WithFeatures()
+		})
+		req := webtest.RequestWithSignedInUser(server.NewGetRequest(url), &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleViewer})
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			if res != nil && res.Body != nil {
+				require.NoError(t, res.Body.Close())
+			}
+		})
+		return res, qs
+	}
+
+	t.Run("the logs query of the data source is run", func(t *testing.T) {
+		client := &fakeTraceLogsPluginClient{res: &backend.CallResourceResponse{Status: http.StatusOK, Body: []byte(logsQuery)}}
+		res, qs := getLogs(t, client, "/api/datasources/uid/tempo/traces/abc/logs?limit=100&from=1682935000000&to=1682936000000")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		require.Len(t, client.requests, 1)
+		assert.Equal(t, "tempo", client.requests[0].PluginContext.DataSourceInstanceSettings.UID)
+		assert.Equal(t, "trace-logs-query", client.requests[0].Path)
+		u, err := url.Parse(client.requests[0].URL)
+		require.NoError(t, err)
+		assert.Equal(t, url.Values{"traceId": {"abc"}, "limit": {"100"}, "from": {"1682935000000"}, "to": {"1682936000000"}}, u.Query())
+
+		require.Len(t, qs.requests, 1)
+		assert.Equal(t, "1682935140000", qs.requests[0].From)
+		assert.Equal(t, "1682935261500", qs.requests[0].To)
+		assert.Equal(t, "loki", qs.requests[0].Queries[0].GetPath("datasource", "uid").MustString())
+		assert.Equal(t, `{cluster="eu"}`, qs.requests[0].Queries[0].Get("expr").MustString())
+	})
+
+	t.Run("the trace is looked up without time range by default", func(t *testing.T) {
+		client := &fakeTraceLogsPluginClient{res: &backend.CallResourceResponse{Status: http.StatusOK, Body: []byte(logsQuery)}}
+		res, _ := getLogs(t, client, "/api/datasources/uid/tempo/traces/abc/logs")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "trace-logs-query?traceId=abc", client.requests[0].URL)
+	})
+
+	t.Run("the failures of the data source are returned with their status", func(t *testing.T) {
+		client := &fakeTraceLogsPluginClient{res: &backend.CallResourceResponse{Status: http.StatusNotFound, Body: []byte(`{"message": "trace not found"}`)}}
+		res, qs := getLogs(t, client, "/api/datasources/uid/tempo/traces/abc/logs")
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Empty(t, qs.requests)
+	})
+
+	t.Run("the data sources without logs query are rejected", func(t *testing.T) {
+		client := &fakeTraceLogsPluginClient{err: backendplugin.ErrMethodNotImplemented}
+		res, qs := getLogs(t, client, "/api/datasources/uid/tempo/traces/abc/logs")
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Empty(t, qs.requests)
+	})
+
+	t.Run("the unknown data sources are not found", func(t *testing.T) {
+		client := &fakeTraceLogsPluginClient{}
+		res, qs := getLogs(t, client, "/api/datasources/uid/loki/traces/abc/logs")
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Empty(t, client.requests)
+		assert.Empty(t, qs.requests)
+	})
+}
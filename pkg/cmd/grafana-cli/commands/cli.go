@@ -52,6 +52,12 @@ func CLICommand(version string) *cli.Command {
 				Value:   "",
 				EnvVars: []string{"GF_PLUGIN_URL"},
 			},
+			&cli.StringFlag{
+				Name:    "registryToken",
+				Usage:   "Bearer token authenticating the requests to the OCI registries of the plugins",
+				Value:   "",
+				EnvVars: []string{"GF_PLUGIN_REGISTRY_TOKEN"},
+			},
 			&cli.BoolFlag{
 				Name:  "insecure",
 				Usage: "Skip TLS verification (insecure)",
@@ -103,8 +103,12 @@ var cmd Command = Command{
 var pluginCommands = []*cli.Command{
 	{
 		Name:   "install",
-		Usage:  "install <plugin id> <plugin version (optional)>",
+		Usage:  "install <plugin id> <plugin version (optional)> or install <oci reference>",
 		Action: runPluginCommand(cmd.installCommand),
+	}, {
+		Name:   "push",
+		Usage:  "push <oci reference> <plugin archive>...",
+		Action: runPluginCommand(cmd.pushCommand),
 	}, {
 		Name:   "list-remote",
 		Usage:  "list remote available plugins",
@@ -115,7 +119,7 @@ var pluginCommands = []*cli.Command{
 		Action: runPluginCommand(cmd.listVersionsCommand),
 	}, {
 		Name:    "update",
-		Usage:   "update <plugin id>",
+		Usage:   "update <plugin id> or update <oci reference>",
 		Aliases: []string{"upgrade"},
 		Action:  runPluginCommand(cmd.upgradeCommand),
 	}, {
@@ -62,8 +62,8 @@ func (cmd Command) installCommand(c utils.CommandLine) error {
 	return err
 }
 
-// installPlugin downloads the plugin code as a zip file from the Grafana.com API
-// and then extracts the zip into the plugin's directory.
+// installPlugin downloads the plugin code as a zip file from the Grafana.com API, or from an OCI registry if
+// `pluginID` is an OCI reference, and then extracts the zip into the plugin's directory.
 func installPlugin(ctx context.Context, pluginID, version string, c utils.CommandLine) error {
 	skipTLSVerify := c.Bool("insecure")
 	repository := repo.New(skipTLSVerify, c.PluginRepoURL(), services.Logger)
@@ -73,7 +73,22 @@ func installPlugin(ctx context.Context, pluginID, version string, c utils.Comman
 	var archive *repo.PluginArchive
 	var err error
 	pluginZipURL := c.PluginURL()
-	if pluginZipURL != "" {
+	if repo.IsOCIReference(pluginID) {
+		if version != "" {
+			return errors.New("the version of a plugin pulled from an OCI registry is the tag or digest of its reference")
+		}
+		ref, err := repo.ParseOCIReference(pluginID)
+		if err != nil {
+			return err
+		}
+		registry := repo.NewOCIRegistry(skipTLSVerify, c.String("registryToken"), services.Logger)
+		artifact, err := registry.GetPluginArtifact(ctx, ref, compatOpts)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Pulled %s v%s from %s (digest %s)\n", artifact.PluginID, artifact.Version, ref, artifact.Digest)
+		pluginID, archive = artifact.PluginID, artifact.PluginArchive
+	} else if pluginZipURL != "" {
 		if archive, err = repository.GetPluginArchiveByURL(ctx, pluginZipURL, compatOpts); err != nil {
 			return err
 		}
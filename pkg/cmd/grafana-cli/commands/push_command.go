@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/plugins/repo"
+)
+
+// rePlatformArchive matches the names of the archives of the plugins built for an OS and architecture, like
+// my-plugin-1.0.0.linux-amd64.zip.
+var rePlatformArchive = regexp.MustCompile(`\.([a-z0-9]+-[a-z0-9]+)\.zip$`)
+
+// pushCommand pushes the archives of a plugin to an OCI registry, as an artifact with a layer per OS and architecture.
+func (cmd Command) pushCommand(c utils.CommandLine) error {
+	args := c.Args().Slice()
+	if len(args) < 2 {
+		return errors.New("please specify the OCI reference and the archives of the plugin to push")
+	}
+
+	ref, err := repo.ParseOCIReference(args[0])
+	if err != nil {
+		return err
+	}
+
+	var pluginID, version string
+	archives := make([]repo.PluginArtifactArchive, 0, len(args)-1)
+	for _, archive := range args[1:] {
+		id, v, err := readArchivePlugin(archive)
+		if err != nil {
+			return err
+		}
+		if pluginID == "" {
+			pluginID, version = id, v
+		} else if id != pluginID || v != version {
+			return fmt.Errorf("the archive %s is %s v%s, other archives are %s v%s", archive, id, v, pluginID, version)
+		}
+		archives = append(archives, repo.PluginArtifactArchive{
+			Platform: archivePlatform(archive),
+			Path:     archive,
+		})
+	}
+
+	registry := repo.NewOCIRegistry(c.Bool("insecure"), c.String("registryToken"), services.Logger)
+	compatOpts := repo.NewCompatOpts(services.GrafanaVersion, runtime.GOOS, runtime.GOARCH)
+	digest, err := registry.PushPluginArtifact(context.Background(), ref, pluginID, version, archives, compatOpts)
+	if err != nil {
+		return err
+	}
+
+	ref.Digest = digest
+	logger.Infof("%s Pushed %s v%s, install it with: grafana-cli plugins install %s\n", color.GreenString("✔"), pluginID, version, ref)
+	return nil
+}
+
+// archivePlatform returns the OS and architecture of the plugin archive from its name, or any.
+func archivePlatform(archive string) string {
+	if m := rePlatformArchive.FindStringSubmatch(filepath.Base(archive)); m != nil {
+		return m[1]
+	}
+	return "any"
+}
+
+// readArchivePlugin returns the ID and version of the plugin of the archive, from its plugin.json closest to the
+// root of the archive.
+func readArchivePlugin(archive string) (string, string, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open plugin archive %s: %w", archive, err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			logger.Warnf("Failed to close plugin archive: %v\n", err)
+		}
+	}()
+
+	var pluginJSON *zip.File
+	for _, f := range r.File {
+		if path.Base(f.Name) != "plugin.json" {
+			continue
+		}
+		if pluginJSON == nil || strings.Count(f.Name, "/") < strings.Count(pluginJSON.Name, "/") {
+			pluginJSON = f
+		}
+	}
+	if pluginJSON == nil {
+		return "", "", fmt.Errorf("could not find plugin.json in plugin archive %s", archive)
+	}
+
+	rc, err := pluginJSON.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer func() {
+		if err := rc.Close(); err != nil {
+			logger.Warnf("Failed to close plugin.json: %v\n", err)
+		}
+	}()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", "", err
+	}
+
+	var plugin struct {
+		ID   string `json:"id"`
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(data, &plugin); err != nil {
+		return "", "", fmt.Errorf("failed to read plugin.json in plugin archive %s: %w", archive, err)
+	}
+	if plugin.ID == "" {
+		return "", "", fmt.Errorf("the plugin.json in plugin archive %s has no ID", archive)
+	}
+	return plugin.ID, plugin.Info.Version, nil
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchivePlatform(t *testing.T) {
+	require.Equal(t, "linux-amd64", archivePlatform("dist/test-datasource-1.0.0.linux-amd64.zip"))
+	require.Equal(t, "darwin-arm64", archivePlatform("test-datasource-1.0.0.darwin-arm64.zip"))
+	require.Equal(t, "any", archivePlatform("test-datasource-1.0.0.zip"))
+}
+
+func TestReadArchivePlugin(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "test-datasource-1.0.0.zip")
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"test-datasource/plugin.json":        `{"id": "test-datasource", "info": {"version": "1.0.0"}}`,
+		"test-datasource/nested/plugin.json": `{"id": "test-nested-panel", "info": {"version": "2.0.0"}}`,
+	} {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	id, version, err := readArchivePlugin(archive)
+	require.NoError(t, err)
+	require.Equal(t, "test-datasource", id)
+	require.Equal(t, "1.0.0", version)
+}
@@ -9,12 +9,22 @@ import (
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/plugins/repo"
 )
 
 func (cmd Command) upgradeCommand(c utils.CommandLine) error {
 	pluginsDir := c.PluginDirectory()
 	pluginName := c.Args().First()
 
+	// the artifacts pulled from OCI registries replace the installed version of the plugin
+	if repo.IsOCIReference(pluginName) {
+		err := installPlugin(context.Background(), pluginName, "", c)
+		if err == nil {
+			logRestartNotice()
+		}
+		return err
+	}
+
 	localPlugin, err := services.ReadPlugin(pluginsDir, pluginName)
 
 	if err != nil {
@@ -0,0 +1,403 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// ChangeKind classifies the changes between two versions of a dashboard.
+type ChangeKind string
+
+const (
+	// ChangeKindLayout is a change of the position, size or order of the panels, or of the collapsed rows.
+	ChangeKindLayout ChangeKind = "layout"
+	// ChangeKindPanel is a panel added or removed.
+	ChangeKindPanel ChangeKind = "panel"
+	// ChangeKindQuery is a change of the queries, query options or transformations of a panel.
+	ChangeKindQuery ChangeKind = "query"
+	// ChangeKindDatasource is a change of the datasource of a panel or of one of its queries.
+	ChangeKindDatasource ChangeKind = "datasource"
+	// ChangeKindVisualization is a change of the other properties of a panel, such as its type, title or options.
+	ChangeKindVisualization ChangeKind = "visualization"
+	// ChangeKindVariables is a variable added, removed or modified.
+	ChangeKindVariables ChangeKind = "variables"
+	// ChangeKindSettings is a change of the other properties of the dashboard, such as its title or time range.
+	ChangeKindSettings ChangeKind = "settings"
+)
+
+// ElementChange is how an element of a dashboard changed between two versions.
+type ElementChange string
+
+const (
+	ElementAdded    ElementChange = "added"
+	ElementRemoved  ElementChange = "removed"
+	ElementModified ElementChange = "modified"
+)
+
+// SemanticDiff is the structured diff of two versions of a dashboard.
+type SemanticDiff struct {
+	// Kinds are the kinds of all the changes, sorted.
+	Kinds []ChangeKind `json:"kinds"`
+	// LayoutOnly is true when only the layout of the panels changed.
+	LayoutOnly bool           `json:"layoutOnly"`
+	Panels     []PanelDiff    `json:"panels"`
+	Variables  []VariableDiff `json:"variables"`
+	// Settings are the properties of the dashboard that changed, other than the panels and the variables.
+	Settings []string `json:"settings"`
+}
+
+// PanelDiff is a panel that changed, the panels are matched by ID.
+type PanelDiff struct {
+	ID     int64         `json:"id"`
+	Title  string        `json:"title"`
+	Change ElementChange `json:"change"`
+	Kinds  []ChangeKind  `json:"kinds"`
+	// Queries are the queries that changed, matched by refId.
+	Queries []QueryDiff `json:"queries,omitempty"`
+}
+
+// QueryDiff is a query of a panel that changed.
+type QueryDiff struct {
+	RefID  string        `json:"refId"`
+	Change ElementChange `json:"change"`
+	// Before and After are the text of the query, or its JSON when the text is unknown.
+	Before            string `json:"before,omitempty"`
+	After             string `json:"after,omitempty"`
+	DatasourceChanged bool   `json:"datasourceChanged,omitempty"`
+
+	// queryChanged is true when the query changed other than its datasource
+	queryChanged bool
+}
+
+// VariableDiff is a variable that changed, the variables are matched by name.
+type VariableDiff struct {
+	Name   string        `json:"name"`
+	Change ElementChange `json:"change"`
+}
+
+var (
+	// ignoredDashboardProperties change with every version
+	ignoredDashboardProperties = map[string]bool{"id": true, "version": true, "iteration": true}
+	// layoutPanelProperties are the properties of the panels that only change their layout
+	layoutPanelProperties = map[string]bool{"gridPos": true, "collapsed": true}
+	// queryPanelProperties are the properties of the panels that change the data they show
+	queryPanelProperties = map[string]bool{
+		"targets": true, "transformations": true, "interval": true, "maxDataPoints": true, "timeFrom": true,
+		"timeShift": true, "hideTimeOverride": true, "cacheTimeout": true, "queryCachingTTL": true,
+	}
+	// queryTextProperties are the properties of the queries of the core datasources holding the text of the query
+	queryTextProperties = []string{"expr", "query", "rawSql", "target", "queryText"}
+)
+
+// CalculateSemanticDiff classifies the changes between two versions of a dashboard.
+func CalculateSemanticDiff(baseData, newData *simplejson.Json) *SemanticDiff {
+	base, updated := dashboardMap(baseData), dashboardMap(newData)
+	result := &SemanticDiff{Panels: []PanelDiff{}, Variables: []VariableDiff{}, Settings: []string{}}
+	kinds := map[ChangeKind]bool{}
+
+	basePanels, baseOrder := flattenPanels(base["panels"])
+	newPanels, newOrder := flattenPanels(updated["panels"])
+	// the panels are moved when their order changed among the panels of both versions, so that adding or removing a
+	// panel does not move the others
+	baseIndex, newIndex := commonIndex(baseOrder, newPanels), commonIndex(newOrder, basePanels)
+	for _, id := range newOrder {
+		p := newPanels[id]
+		old, ok := basePanels[id]
+		if !ok {
+			result.Panels = append(result.Panels, PanelDiff{ID: id, Title: p.title(), Change: ElementAdded, Kinds: []ChangeKind{ChangeKindPanel}})
+			kinds[ChangeKindPanel] = true
+			continue
+		}
+		diff := diffPanel(id, old, p, baseIndex[id] != newIndex[id])
+		if len(diff.Kinds) > 0 {
+			result.Panels = append(result.Panels, diff)
+			for _, k := range diff.Kinds {
+				kinds[k] = true
+			}
+		}
+	}
+	for _, id := range baseOrder {
+		if _, ok := newPanels[id]; !ok {
+			p := basePanels[id]
+			result.Panels = append(result.Panels, PanelDiff{ID: id, Title: p.title(), Change: ElementRemoved, Kinds: []ChangeKind{ChangeKindPanel}})
+			kinds[ChangeKindPanel] = true
+		}
+	}
+
+	result.Variables = diffVariables(base["templating"], updated["templating"])
+	if len(result.Variables) > 0 {
+		kinds[ChangeKindVariables] = true
+	}
+
+	for _, key := range changedKeys(base, updated) {
+		if key == "panels" || key == "templating" || ignoredDashboardProperties[key] {
+			continue
+		}
+		result.Settings = append(result.Settings, key)
+		kinds[ChangeKindSettings] = true
+	}
+
+	result.Kinds = sortedKinds(kinds)
+	result.LayoutOnly = len(result.Kinds) == 1 && result.Kinds[0] == ChangeKindLayout
+	return result
+}
+
+type panel struct {
+	props map[string]interface{}
+}
+
+func (p panel) title() string {
+	title, _ := p.props["title"].(string)
+	return title
+}
+
+func dashboardMap(data *simplejson.Json) map[string]interface{} {
+	if data == nil {
+		return map[string]interface{}{}
+	}
+	m, err := data.Map()
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
+
+// flattenPanels returns the panels by ID, including the panels of the collapsed rows, and their IDs in the order of
+// the dashboard
+func flattenPanels(value interface{}) (map[int64]panel, []int64) {
+	panels := map[int64]panel{}
+	var order []int64
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		list, _ := value.([]interface{})
+		for _, v := range list {
+			props, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, ok := toInt64(props["id"])
+			if !ok {
+				continue
+			}
+			if _, ok := panels[id]; !ok {
+				panels[id] = panel{props: props}
+				order = append(order, id)
+			}
+			walk(props["panels"])
+		}
+	}
+	walk(value)
+	return panels, order
+}
+
+// commonIndex returns the index of the panels in the order that are also in the other panels
+func commonIndex(order []int64, other map[int64]panel) map[int64]int {
+	index := map[int64]int{}
+	for _, id := range order {
+		if _, ok := other[id]; ok {
+			index[id] = len(index)
+		}
+	}
+	return index
+}
+
+func diffPanel(id int64, base, updated panel, moved bool) PanelDiff {
+	diff := PanelDiff{ID: id, Title: updated.title(), Change: ElementModified}
+	kinds := map[ChangeKind]bool{}
+	if moved {
+		kinds[ChangeKindLayout] = true
+	}
+	for _, key := range changedKeys(base.props, updated.props) {
+		switch {
+		// the panels of the collapsed rows are compared as the other panels
+		case key == "panels":
+		case layoutPanelProperties[key]:
+			kinds[ChangeKindLayout] = true
+		case key == "datasource":
+			kinds[ChangeKindDatasource] = true
+		case key == "targets":
+			diff.Queries = diffQueries(base.props["targets"], updated.props["targets"])
+			for _, q := range diff.Queries {
+				if q.DatasourceChanged {
+					kinds[ChangeKindDatasource] = true
+				}
+				if q.Change != ElementModified || q.queryChanged {
+					kinds[ChangeKindQuery] = true
+				}
+			}
+		case queryPanelProperties[key]:
+			kinds[ChangeKindQuery] = true
+		default:
+			kinds[ChangeKindVisualization] = true
+		}
+	}
+	diff.Kinds = sortedKinds(kinds)
+	return diff
+}
+
+func diffQueries(baseValue, newValue interface{}) []QueryDiff {
+	base, baseOrder := queriesByRefID(baseValue)
+	updated, newOrder := queriesByRefID(newValue)
+	var diffs []QueryDiff
+	for _, refID := range newOrder {
+		q := updated[refID]
+		old, ok := base[refID]
+		if !ok {
+			diffs = append(diffs, QueryDiff{RefID: refID, Change: ElementAdded, After: queryText(q)})
+			continue
+		}
+		if reflect.DeepEqual(old, q) {
+			continue
+		}
+		diffs = append(diffs, QueryDiff{
+			RefID:             refID,
+			Change:            ElementModified,
+			Before:            queryText(old),
+			After:             queryText(q),
+			DatasourceChanged: !reflect.DeepEqual(old["datasource"], q["datasource"]),
+			queryChanged:      !reflect.DeepEqual(without(old, "datasource"), without(q, "datasource")),
+		})
+	}
+	for _, refID := range baseOrder {
+		if _, ok := updated[refID]; !ok {
+			diffs = append(diffs, QueryDiff{RefID: refID, Change: ElementRemoved, Before: queryText(base[refID])})
+		}
+	}
+	return diffs
+}
+
+func queriesByRefID(value interface{}) (map[string]map[string]interface{}, []string) {
+	queries := map[string]map[string]interface{}{}
+	var order []string
+	list, _ := value.([]interface{})
+	for i, v := range list {
+		q, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		refID, _ := q["refId"].(string)
+		if refID == "" {
+			refID = fmt.Sprintf("#%d", i)
+		}
+		if _, ok := queries[refID]; !ok {
+			queries[refID] = q
+			order = append(order, refID)
+		}
+	}
+	return queries, order
+}
+
+// queryText returns the text of the query, or the JSON of the query without its refId and datasource
+func queryText(q map[string]interface{}) string {
+	for _, key := range queryTextProperties {
+		if text, ok := q[key].(string); ok && text != "" {
+			return text
+		}
+	}
+	encoded, err := json.Marshal(without(q, "refId", "datasource"))
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+func diffVariables(baseValue, newValue interface{}) []VariableDiff {
+	base, baseOrder := variablesByName(baseValue)
+	updated, newOrder := variablesByName(newValue)
+	diffs := []VariableDiff{}
+	for _, name := range newOrder {
+		old, ok := base[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, VariableDiff{Name: name, Change: ElementAdded})
+		// the selected value and the options of a variable are saved with the dashboard but are not a change of the
+		// variable
+		case !reflect.DeepEqual(without(old, "current", "options"), without(updated[name], "current", "options")):
+			diffs = append(diffs, VariableDiff{Name: name, Change: ElementModified})
+		}
+	}
+	for _, name := range baseOrder {
+		if _, ok := updated[name]; !ok {
+			diffs = append(diffs, VariableDiff{Name: name, Change: ElementRemoved})
+		}
+	}
+	return diffs
+}
+
+func variablesByName(value interface{}) (map[string]map[string]interface{}, []string) {
+	variables := map[string]map[string]interface{}{}
+	var order []string
+	templating, _ := value.(map[string]interface{})
+	list, _ := templating["list"].([]interface{})
+	for _, v := range list {
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := variable["name"].(string)
+		if _, ok := variables[name]; !ok {
+			variables[name] = variable
+			order = append(order, name)
+		}
+	}
+	return variables, order
+}
+
+// without returns a copy of the map without the keys
+func without(m map[string]interface{}, keys ...string) map[string]interface{} {
+	rest := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		rest[k] = v
+	}
+	for _, k := range keys {
+		delete(rest, k)
+	}
+	return rest
+}
+
+// changedKeys returns the sorted keys whose values are different in the two maps
+func changedKeys(base, updated map[string]interface{}) []string {
+	var keys []string
+	for k, v := range updated {
+		if old, ok := base[k]; !ok || !reflect.DeepEqual(old, v) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range base {
+		if _, ok := updated[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedKinds(kinds map[ChangeKind]bool) []ChangeKind {
+	sorted := make([]ChangeKind, 0, len(kinds))
+	for k := range kinds {
+		sorted = append(sorted, k)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,132 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const semanticBaseDashboard = `{
+	"id": 1,
+	"version": 1,
+	"title": "Services",
+	"panels": [
+		{"id": 1, "type": "timeseries", "title": "Requests", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+			"datasource": {"type": "prometheus", "uid": "prom"},
+			"targets": [{"refId": "A", "expr": "rate(requests[5m])", "datasource": {"type": "prometheus", "uid": "prom"}}]},
+		{"id": 2, "type": "stat", "title": "Errors", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+			"targets": [{"refId": "A", "expr": "rate(errors[5m])"}]},
+		{"id": 3, "type": "row", "title": "Details", "collapsed": true, "panels": [
+			{"id": 4, "type": "table", "title": "Slow requests", "targets": [{"refId": "A", "rawSql": "SELECT 1"}]}
+		]}
+	],
+	"templating": {"list": [
+		{"name": "env", "type": "custom", "query": "dev,prod", "current": {"value": "dev"}}
+	]}
+}`
+
+func semanticDiff(t *testing.T, base, updated string) *SemanticDiff {
+	t.Helper()
+	baseData, err := simplejson.NewJson([]byte(base))
+	require.NoError(t, err)
+	newData, err := simplejson.NewJson([]byte(updated))
+	require.NoError(t, err)
+	return CalculateSemanticDiff(baseData, newData)
+}
+
+func TestCalculateSemanticDiff(t *testing.T) {
+	t.Run("no changes other than the version", func(t *testing.T) {
+		diff := semanticDiff(t, semanticBaseDashboard, `{"version": 2, "id": 1, "title": "Services", "panels": [
+			{"id": 1, "type": "timeseries", "title": "Requests", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+				"datasource": {"type": "prometheus", "uid": "prom"},
+				"targets": [{"refId": "A", "expr": "rate(requests[5m])", "datasource": {"type": "prometheus", "uid": "prom"}}]},
+			{"id": 2, "type": "stat", "title": "Errors", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+				"targets": [{"refId": "A", "expr": "rate(errors[5m])"}]},
+			{"id": 3, "type": "row", "title": "Details", "collapsed": true, "panels": [
+				{"id": 4, "type": "table", "title": "Slow requests", "targets": [{"refId": "A", "rawSql": "SELECT 1"}]}
+			]}
+		], "templating": {"list": [{"name": "env", "type": "custom", "query": "dev,prod", "current": {"value": "prod"}}]}}`)
+		assert.Empty(t, diff.Kinds)
+		assert.Empty(t, diff.Panels)
+		assert.Empty(t, diff.Variables)
+		assert.Empty(t, diff.Settings)
+		assert.False(t, diff.LayoutOnly)
+	})
+
+	t.Run("panels moved and resized are layout only changes", func(t *testing.T) {
+		diff := semanticDiff(t, semanticBaseDashboard, `{"title": "Services", "panels": [
+			{"id": 2, "type": "stat", "title": "Errors", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+				"targets": [{"refId": "A", "expr": "rate(errors[5m])"}]},
+			{"id": 1, "type": "timeseries", "title": "Requests", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 10},
+				"datasource": {"type": "prometheus", "uid": "prom"},
+				"targets": [{"refId": "A", "expr": "rate(requests[5m])", "datasource": {"type": "prometheus", "uid": "prom"}}]},
+			{"id": 3, "type": "row", "title": "Details", "collapsed": false, "panels": []},
+			{"id": 4, "type": "table", "title": "Slow requests", "targets": [{"refId": "A", "rawSql": "SELECT 1"}]}
+		], "templating": {"list": [{"name": "env", "type": "custom", "query": "dev,prod"}]}}`)
+		assert.Equal(t, []ChangeKind{ChangeKindLayout}, diff.Kinds)
+		assert.True(t, diff.LayoutOnly)
+		require.Len(t, diff.Panels, 3)
+		assert.Equal(t, []int64{2, 1, 3}, []int64{diff.Panels[0].ID, diff.Panels[1].ID, diff.Panels[2].ID})
+	})
+
+	t.Run("query and datasource changes", func(t *testing.T) {
+		diff := semanticDiff(t, semanticBaseDashboard, `{"title": "Services", "panels": [
+			{"id": 1, "type": "timeseries", "title": "Requests", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+				"datasource": {"type": "prometheus", "uid": "prom-2"},
+				"targets": [{"refId": "A", "expr": "rate(requests[5m])", "datasource": {"type": "prometheus", "uid": "prom-2"}}]},
+			{"id": 2, "type": "stat", "title": "Errors", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+				"targets": [{"refId": "A", "expr": "rate(errors[1m])"}, {"refId": "B", "expr": "rate(timeouts[1m])"}]},
+			{"id": 3, "type": "row", "title": "Details", "collapsed": true, "panels": [
+				{"id": 4, "type": "table", "title": "Slow requests", "targets": [{"refId": "A", "rawSql": "SELECT 2"}]}
+			]}
+		], "templating": {"list": [{"name": "env", "type": "custom", "query": "dev,prod"}]}}`)
+		assert.Equal(t, []ChangeKind{ChangeKindDatasource, ChangeKindQuery}, diff.Kinds)
+		assert.False(t, diff.LayoutOnly)
+		require.Len(t, diff.Panels, 3)
+
+		assert.Equal(t, PanelDiff{ID: 1, Title: "Requests", Change: ElementModified, Kinds: []ChangeKind{ChangeKindDatasource}, Queries: []QueryDiff{
+			{RefID: "A", Change: ElementModified, Before: "rate(requests[5m])", After: "rate(requests[5m])", DatasourceChanged: true},
+		}}, diff.Panels[0])
+		assert.Equal(t, []ChangeKind{ChangeKindQuery}, diff.Panels[1].Kinds)
+		assert.Equal(t, []QueryDiff{
+			{RefID: "A", Change: ElementModified, Before: "rate(errors[5m])", After: "rate(errors[1m])", queryChanged: true},
+			{RefID: "B", Change: ElementAdded, After: "rate(timeouts[1m])"},
+		}, diff.Panels[1].Queries)
+		// the panels of the collapsed rows are compared
+		assert.Equal(t, int64(4), diff.Panels[2].ID)
+		assert.Equal(t, "SELECT 2", diff.Panels[2].Queries[0].After)
+	})
+
+	t.Run("panels added and removed, variables and settings", func(t *testing.T) {
+		diff := semanticDiff(t, semanticBaseDashboard, `{"title": "All services", "panels": [
+			{"id": 5, "type": "text", "title": "Notes"},
+			{"id": 1, "type": "barchart", "title": "Requests", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+				"datasource": {"type": "prometheus", "uid": "prom"},
+				"targets": [{"refId": "A", "expr": "rate(requests[5m])", "datasource": {"type": "prometheus", "uid": "prom"}}]},
+			{"id": 2, "type": "stat", "title": "Errors", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+				"targets": [{"refId": "A", "expr": "rate(errors[5m])"}]}
+		], "templating": {"list": [
+			{"name": "env", "type": "custom", "query": "dev,staging,prod"},
+			{"name": "region", "type": "custom", "query": "eu,us"}
+		]}}`)
+		assert.Equal(t, []ChangeKind{ChangeKindPanel, ChangeKindSettings, ChangeKindVariables, ChangeKindVisualization}, diff.Kinds)
+		assert.Equal(t, []PanelDiff{
+			{ID: 5, Title: "Notes", Change: ElementAdded, Kinds: []ChangeKind{ChangeKindPanel}},
+			{ID: 1, Title: "Requests", Change: ElementModified, Kinds: []ChangeKind{ChangeKindVisualization}},
+			{ID: 3, Title: "Details", Change: ElementRemoved, Kinds: []ChangeKind{ChangeKindPanel}},
+			{ID: 4, Title: "Slow requests", Change: ElementRemoved, Kinds: []ChangeKind{ChangeKindPanel}},
+		}, diff.Panels)
+		assert.Equal(t, []VariableDiff{{Name: "env", Change: ElementModified}, {Name: "region", Change: ElementAdded}}, diff.Variables)
+		assert.Equal(t, []string{"title"}, diff.Settings)
+	})
+
+	t.Run("first version", func(t *testing.T) {
+		diff := semanticDiff(t, `{}`, semanticBaseDashboard)
+		assert.Equal(t, []ChangeKind{ChangeKindPanel, ChangeKindSettings, ChangeKindVariables}, diff.Kinds)
+		assert.Len(t, diff.Panels, 4)
+		assert.Equal(t, []string{"title"}, diff.Settings)
+	})
+}
@@ -0,0 +1,443 @@
+// Package frameparquet encodes data frames to Parquet files, and decodes Parquet files to data frames.
+//
+// Every field of a frame is a column of the file, with the name of the field, or a unique name when several fields
+// of the frame have the same name. The name, refId and metadata of the frame, and the original name, type, labels
+// and config of the fields are stored in the metadata of the Arrow schema embedded in the file, so the frames encoded
+// by Grafana are decoded as they were. The files written by other tools are decoded from their column types.
+package frameparquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/apache/arrow/go/v12/parquet"
+	"github.com/apache/arrow/go/v12/parquet/compress"
+	"github.com/apache/arrow/go/v12/parquet/file"
+	"github.com/apache/arrow/go/v12/parquet/metadata"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// ContentType is the media type of the Parquet files
+const ContentType = "application/vnd.apache.parquet"
+
+const (
+	metaFrameName   = "grafana.frame.name"
+	metaFrameRefID  = "grafana.frame.refId"
+	metaFrameMeta   = "grafana.frame.meta"
+	metaFieldName   = "grafana.field.name"
+	metaFieldType   = "grafana.field.type"
+	metaFieldLabels = "grafana.field.labels"
+	metaFieldConfig = "grafana.field.config"
+)
+
+// MarshalFrame encodes the frame to a Parquet file
+func MarshalFrame(frame *data.Frame) ([]byte, error) {
+	rows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	if len(frame.Fields) == 0 {
+		return nil, fmt.Errorf("frame %q has no fields", frame.Name)
+	}
+
+	mem := memory.NewGoAllocator()
+	fields := make([]arrow.Field, 0, len(frame.Fields))
+	columns := make([]arrow.Array, 0, len(frame.Fields))
+	defer func() {
+		for _, column := range columns {
+			column.Release()
+		}
+	}()
+
+	names := columnNames(frame)
+	for i, field := range frame.Fields {
+		dt, err := arrowType(field.Type())
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		metadata, err := fieldMetadata(field)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		column, err := arrowArray(mem, dt, field)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		columns = append(columns, column)
+		fields = append(fields, arrow.Field{Name: names[i], Type: dt, Nullable: field.Nullable(), Metadata: metadata})
+	}
+
+	metadata, err := frameMetadata(frame)
+	if err != nil {
+		return nil, err
+	}
+	schema := arrow.NewSchema(fields, &metadata)
+	record := array.NewRecord(schema, columns, int64(rows))
+	defer record.Release()
+	table := array.NewTableFromRecords(schema, []arrow.Record{record})
+	defer table.Release()
+
+	var buf bytes.Buffer
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy), parquet.WithAllocator(mem))
+	chunkSize := int64(rows)
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+	if err := pqarrow.WriteTable(table, &buf, chunkSize, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema())); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalFrame decodes a Parquet file to a frame
+func UnmarshalFrame(b []byte) (*data.Frame, error) {
+	mem := memory.NewGoAllocator()
+	rdr, err := file.NewParquetReader(bytes.NewReader(b), file.WithReadProps(parquet.NewReaderProperties(mem)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rdr.Close() }()
+	fr, err := pqarrow.NewFileReader(rdr, pqarrow.ArrowReadProperties{}, mem)
+	if err != nil {
+		return nil, err
+	}
+	table, err := fr.ReadTable(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer table.Release()
+
+	frame := data.NewFrame("")
+	// the metadata of the arrow schema is written to the key value metadata of the file
+	if err := applyFrameMetadata(frame, rdr.MetaData().KeyValueMetadata()); err != nil {
+		return nil, err
+	}
+	for i := 0; i < int(table.NumCols()); i++ {
+		column := table.Column(i)
+		field, err := fieldFromColumn(column)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", column.Name(), err)
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+	return frame, nil
+}
+
+// columnNames returns unique names of the columns of the fields, the names of the parquet columns must be unique
+func columnNames(frame *data.Frame) []string {
+	names := make([]string, len(frame.Fields))
+	seen := map[string]int{}
+	for _, field := range frame.Fields {
+		seen[field.Name]++
+	}
+	used := map[string]bool{}
+	for i, field := range frame.Fields {
+		name := field.Name
+		if name == "" {
+			name = fmt.Sprintf("Field %d", i+1)
+		} else if seen[field.Name] > 1 && len(field.Labels) > 0 {
+			name = field.Name + " " + field.Labels.String()
+		}
+		for unique, n := name, 2; ; n++ {
+			if !used[unique] {
+				name = unique
+				break
+			}
+			unique = fmt.Sprintf("%s %d", name, n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+func frameMetadata(frame *data.Frame) (arrow.Metadata, error) {
+	keys := []string{metaFrameName, metaFrameRefID}
+	values := []string{frame.Name, frame.RefID}
+	if frame.Meta != nil {
+		meta, err := json.Marshal(frame.Meta)
+		if err != nil {
+			return arrow.Metadata{}, err
+		}
+		keys, values = append(keys, metaFrameMeta), append(values, string(meta))
+	}
+	return arrow.NewMetadata(keys, values), nil
+}
+
+func applyFrameMetadata(frame *data.Frame, kv metadata.KeyValueMetadata) error {
+	value := func(key string) string {
+		if v := kv.FindValue(key); v != nil {
+			return *v
+		}
+		return ""
+	}
+	frame.Name = value(metaFrameName)
+	frame.RefID = value(metaFrameRefID)
+	if meta := value(metaFrameMeta); meta != "" {
+		frame.Meta = &data.FrameMeta{}
+		if err := json.Unmarshal([]byte(meta), frame.Meta); err != nil {
+			return fmt.Errorf("invalid frame metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+func fieldMetadata(field *data.Field) (arrow.Metadata, error) {
+	keys := []string{metaFieldName, metaFieldType}
+	values := []string{field.Name, field.Type().ItemTypeString()}
+	if len(field.Labels) > 0 {
+		labels, err := json.Marshal(field.Labels)
+		if err != nil {
+			return arrow.Metadata{}, err
+		}
+		keys, values = append(keys, metaFieldLabels), append(values, string(labels))
+	}
+	if field.Config != nil {
+		config, err := json.Marshal(field.Config)
+		if err != nil {
+			return arrow.Metadata{}, err
+		}
+		keys, values = append(keys, metaFieldConfig), append(values, string(config))
+	}
+	return arrow.NewMetadata(keys, values), nil
+}
+
+func metadataValue(metadata arrow.Metadata, key string) string {
+	if i := metadata.FindKey(key); i >= 0 {
+		return metadata.Values()[i]
+	}
+	return ""
+}
+
+func arrowType(t data.FieldType) (arrow.DataType, error) {
+	switch t.NonNullableType() {
+	case data.FieldTypeInt8:
+		return arrow.PrimitiveTypes.Int8, nil
+	case data.FieldTypeInt16:
+		return arrow.PrimitiveTypes.Int16, nil
+	case data.FieldTypeInt32:
+		return arrow.PrimitiveTypes.Int32, nil
+	case data.FieldTypeInt64:
+		return arrow.PrimitiveTypes.Int64, nil
+	case data.FieldTypeUint8:
+		return arrow.PrimitiveTypes.Uint8, nil
+	case data.FieldTypeUint16, data.FieldTypeEnum:
+		return arrow.PrimitiveTypes.Uint16, nil
+	case data.FieldTypeUint32:
+		return arrow.PrimitiveTypes.Uint32, nil
+	case data.FieldTypeUint64:
+		return arrow.PrimitiveTypes.Uint64, nil
+	case data.FieldTypeFloat32:
+		return arrow.PrimitiveTypes.Float32, nil
+	case data.FieldTypeFloat64:
+		return arrow.PrimitiveTypes.Float64, nil
+	case data.FieldTypeString, data.FieldTypeJSON:
+		return arrow.BinaryTypes.String, nil
+	case data.FieldTypeBool:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case data.FieldTypeTime:
+		return &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported field type %s", t.ItemTypeString())
+	}
+}
+
+// arrowArray builds the column of the field, the null values of the nullable fields are the nulls of the column
+//
+//nolint:gocyclo
+func arrowArray(mem memory.Allocator, dt arrow.DataType, field *data.Field) (arrow.Array, error) {
+	builder := array.NewBuilder(mem, dt)
+	defer builder.Release()
+	builder.Reserve(field.Len())
+
+	for i := 0; i < field.Len(); i++ {
+		v, ok := field.ConcreteAt(i)
+		if !ok {
+			builder.AppendNull()
+			continue
+		}
+		switch b := builder.(type) {
+		case *array.Int8Builder:
+			b.Append(v.(int8))
+		case *array.Int16Builder:
+			b.Append(v.(int16))
+		case *array.Int32Builder:
+			b.Append(v.(int32))
+		case *array.Int64Builder:
+			b.Append(v.(int64))
+		case *array.Uint8Builder:
+			b.Append(v.(uint8))
+		case *array.Uint16Builder:
+			if e, ok := v.(data.EnumItemIndex); ok {
+				b.Append(uint16(e))
+			} else {
+				b.Append(v.(uint16))
+			}
+		case *array.Uint32Builder:
+			b.Append(v.(uint32))
+		case *array.Uint64Builder:
+			b.Append(v.(uint64))
+		case *array.Float32Builder:
+			b.Append(v.(float32))
+		case *array.Float64Builder:
+			b.Append(v.(float64))
+		case *array.StringBuilder:
+			if raw, ok := v.(json.RawMessage); ok {
+				b.Append(string(raw))
+			} else {
+				b.Append(v.(string))
+			}
+		case *array.BooleanBuilder:
+			b.Append(v.(bool))
+		case *array.TimestampBuilder:
+			b.Append(arrow.Timestamp(v.(time.Time).UnixNano()))
+		default:
+			return nil, fmt.Errorf("unsupported column type %s", dt)
+		}
+	}
+	return builder.NewArray(), nil
+}
+
+// fieldFromColumn converts the column to a field, of the type stored in the metadata of the column or, for the
+// files written by other tools, of the type of the column
+func fieldFromColumn(column *arrow.Column) (*data.Field, error) {
+	metadata := column.Field().Metadata
+	name := column.Name()
+	if metadata.FindKey(metaFieldName) >= 0 {
+		name = metadataValue(metadata, metaFieldName)
+	}
+
+	fieldType, ok := data.FieldTypeFromItemTypeString(metadataValue(metadata, metaFieldType))
+	if !ok {
+		var err error
+		fieldType, err = fieldTypeOf(column.DataType(), column.Field().Nullable)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	field := data.NewFieldFromFieldType(fieldType, column.Len())
+	field.Name = name
+	if labels := metadataValue(metadata, metaFieldLabels); labels != "" {
+		if err := json.Unmarshal([]byte(labels), &field.Labels); err != nil {
+			return nil, fmt.Errorf("invalid labels: %w", err)
+		}
+	}
+	if config := metadataValue(metadata, metaFieldConfig); config != "" {
+		field.Config = &data.FieldConfig{}
+		if err := json.Unmarshal([]byte(config), field.Config); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	idx := 0
+	for _, chunk := range column.Data().Chunks() {
+		for i := 0; i < chunk.Len(); i, idx = i+1, idx+1 {
+			if chunk.IsNull(i) {
+				if !field.Nullable() {
+					return nil, fmt.Errorf("null value at row %d of a field of type %s", idx, fieldType.ItemTypeString())
+				}
+				continue
+			}
+			v, err := columnValue(chunk, i, fieldType)
+			if err != nil {
+				return nil, err
+			}
+			field.SetConcrete(idx, v)
+		}
+	}
+	return field, nil
+}
+
+// fieldTypeOf returns the type of the field of a column without Grafana metadata
+func fieldTypeOf(dt arrow.DataType, nullable bool) (data.FieldType, error) {
+	var t data.FieldType
+	switch dt.ID() {
+	case arrow.INT8:
+		t = data.FieldTypeInt8
+	case arrow.INT16:
+		t = data.FieldTypeInt16
+	case arrow.INT32:
+		t = data.FieldTypeInt32
+	case arrow.INT64:
+		t = data.FieldTypeInt64
+	case arrow.UINT8:
+		t = data.FieldTypeUint8
+	case arrow.UINT16:
+		t = data.FieldTypeUint16
+	case arrow.UINT32:
+		t = data.FieldTypeUint32
+	case arrow.UINT64:
+		t = data.FieldTypeUint64
+	case arrow.FLOAT32:
+		t = data.FieldTypeFloat32
+	case arrow.FLOAT64:
+		t = data.FieldTypeFloat64
+	case arrow.STRING, arrow.LARGE_STRING:
+		t = data.FieldTypeString
+	case arrow.BOOL:
+		t = data.FieldTypeBool
+	case arrow.TIMESTAMP, arrow.DATE32, arrow.DATE64:
+		t = data.FieldTypeTime
+	default:
+		return data.FieldTypeUnknown, fmt.Errorf("unsupported column type %s", dt)
+	}
+	if nullable {
+		return t.NullableType(), nil
+	}
+	return t, nil
+}
+
+//nolint:gocyclo
+func columnValue(chunk arrow.Array, i int, fieldType data.FieldType) (interface{}, error) {
+	switch a := chunk.(type) {
+	case *array.Int8:
+		return a.Value(i), nil
+	case *array.Int16:
+		return a.Value(i), nil
+	case *array.Int32:
+		return a.Value(i), nil
+	case *array.Int64:
+		return a.Value(i), nil
+	case *array.Uint8:
+		return a.Value(i), nil
+	case *array.Uint16:
+		if fieldType.NonNullableType() == data.FieldTypeEnum {
+			return data.EnumItemIndex(a.Value(i)), nil
+		}
+		return a.Value(i), nil
+	case *array.Uint32:
+		return a.Value(i), nil
+	case *array.Uint64:
+		return a.Value(i), nil
+	case *array.Float32:
+		return a.Value(i), nil
+	case *array.Float64:
+		return a.Value(i), nil
+	case *array.String:
+		if fieldType.NonNullableType() == data.FieldTypeJSON {
+			return json.RawMessage(a.Value(i)), nil
+		}
+		return a.Value(i), nil
+	case *array.LargeString:
+		return a.Value(i), nil
+	case *array.Boolean:
+		return a.Value(i), nil
+	case *array.Timestamp:
+		unit := a.DataType().(*arrow.TimestampType).Unit
+		return a.Value(i).ToTime(unit).UTC(), nil
+	case *array.Date32:
+		return a.Value(i).ToTime().UTC(), nil
+	case *array.Date64:
+		return a.Value(i).ToTime().UTC(), nil
+	default:
+		return nil, fmt.Errorf("unsupported column type %s", chunk.DataType())
+	}
+}
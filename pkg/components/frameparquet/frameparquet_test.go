@@ -0,0 +1,142 @@
+package frameparquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/apache/arrow/go/v12/parquet"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalFrame(t *testing.T) {
+	t.Run("frames are decoded as they were encoded", func(t *testing.T) {
+		t0 := time.Date(2023, 3, 4, 12, 0, 0, 123456789, time.UTC)
+		frame := data.NewFrame("requests",
+			data.NewField("time", nil, []time.Time{t0, t0.Add(time.Minute)}),
+			data.NewField("value", data.Labels{"job": "api"}, []*float64{ptr(1.5), nil}).SetConfig(&data.FieldConfig{Unit: "reqps"}),
+			data.NewField("value", data.Labels{"job": "web"}, []float64{2, 3}),
+			data.NewField("count", nil, []int64{1, 2}),
+			data.NewField("small", nil, []*int8{nil, ptr(int8(-1))}),
+			data.NewField("bytes", nil, []uint32{7, 8}),
+			data.NewField("ok", nil, []*bool{ptr(true), nil}),
+			data.NewField("line", nil, []string{"a", "b"}),
+			data.NewField("attributes", nil, []json.RawMessage{json.RawMessage(`{"a":1}`), json.RawMessage(`[]`)}),
+			data.NewField("", nil, []*time.Time{nil, &t0}),
+			data.NewField("level", nil, []data.EnumItemIndex{0, 1}),
+		).SetMeta(&data.FrameMeta{ExecutedQueryString: "sum(rate(requests[5m]))", PreferredVisualization: data.VisTypeGraph})
+		frame.RefID = "A"
+
+		b, err := MarshalFrame(frame)
+		require.NoError(t, err)
+		decoded, err := UnmarshalFrame(b)
+		require.NoError(t, err)
+
+		if diff := cmpFrames(t, frame, decoded); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("the columns of the fields have unique names", func(t *testing.T) {
+		frame := data.NewFrame("",
+			data.NewField("value", data.Labels{"job": "api"}, []float64{1}),
+			data.NewField("value", data.Labels{"job": "web"}, []float64{2}),
+			data.NewField("value", data.Labels{"job": "web"}, []float64{3}),
+			data.NewField("", nil, []float64{4}),
+		)
+		b, err := MarshalFrame(frame)
+		require.NoError(t, err)
+
+		table, err := pqarrow.ReadTable(context.Background(), bytes.NewReader(b), parquet.NewReaderProperties(nil), pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+		require.NoError(t, err)
+		defer table.Release()
+		var names []string
+		for _, f := range table.Schema().Fields() {
+			names = append(names, f.Name)
+		}
+		assert.Equal(t, []string{"value job=api", "value job=web", "value job=web 2", "Field 4"}, names)
+	})
+
+	t.Run("frames without rows are encoded", func(t *testing.T) {
+		frame := data.NewFrame("empty", data.NewField("value", nil, []float64{}))
+		b, err := MarshalFrame(frame)
+		require.NoError(t, err)
+		decoded, err := UnmarshalFrame(b)
+		require.NoError(t, err)
+		require.Len(t, decoded.Fields, 1)
+		assert.Equal(t, 0, decoded.Fields[0].Len())
+	})
+
+	t.Run("frames without fields are not encoded", func(t *testing.T) {
+		_, err := MarshalFrame(data.NewFrame("empty"))
+		require.Error(t, err)
+	})
+}
+
+func TestUnmarshalFrame(t *testing.T) {
+	t.Run("files written by other tools are decoded from their column types", func(t *testing.T) {
+		mem := memory.NewGoAllocator()
+		schema := arrow.NewSchema([]arrow.Field{
+			{Name: "ts", Type: &arrow.TimestampType{Unit: arrow.Millisecond}},
+			{Name: "host", Type: arrow.BinaryTypes.String, Nullable: true},
+			{Name: "cpu", Type: arrow.PrimitiveTypes.Float64},
+		}, nil)
+		b := array.NewRecordBuilder(mem, schema)
+		defer b.Release()
+		b.Field(0).(*array.TimestampBuilder).AppendValues([]arrow.Timestamp{1000, 2000}, nil)
+		b.Field(1).(*array.StringBuilder).AppendValues([]string{"a", ""}, []bool{true, false})
+		b.Field(2).(*array.Float64Builder).AppendValues([]float64{0.5, 0.25}, nil)
+		record := b.NewRecord()
+		defer record.Release()
+		table := array.NewTableFromRecords(schema, []arrow.Record{record})
+		defer table.Release()
+
+		var buf bytes.Buffer
+		require.NoError(t, pqarrow.WriteTable(table, &buf, 2, nil, pqarrow.DefaultWriterProps()))
+
+		frame, err := UnmarshalFrame(buf.Bytes())
+		require.NoError(t, err)
+		require.Len(t, frame.Fields, 3)
+		assert.Equal(t, data.FieldTypeTime, frame.Fields[0].Type())
+		assert.Equal(t, time.UnixMilli(2000).UTC(), frame.Fields[0].At(1))
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		assert.Equal(t, ptr("a"), frame.Fields[1].At(0))
+		assert.Nil(t, frame.Fields[1].At(1))
+		assert.Equal(t, "cpu", frame.Fields[2].Name)
+		assert.Equal(t, 0.25, frame.Fields[2].At(1))
+	})
+
+	t.Run("invalid files return an error", func(t *testing.T) {
+		_, err := UnmarshalFrame([]byte("not parquet"))
+		require.Error(t, err)
+	})
+}
+
+func cmpFrames(t *testing.T, expected, actual *data.Frame) string {
+	t.Helper()
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+	if !assert.JSONEq(t, string(expectedJSON), string(actualJSON)) {
+		return "the decoded frame is different"
+	}
+	for i := range expected.Fields {
+		if expected.Fields[i].Type() != actual.Fields[i].Type() {
+			return "field " + expected.Fields[i].Name + " has type " + actual.Fields[i].Type().ItemTypeString()
+		}
+	}
+	return ""
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
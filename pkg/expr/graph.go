@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"gonum.org/v1/gonum/graph/simple"
 	"gonum.org/v1/gonum/graph/topo"
 
 	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
 )
 
 // NodeType is the type of a DPNode. Currently either a expression command or datasource query.
@@ -45,19 +48,73 @@ type Node interface {
 // DataPipeline is an ordered set of nodes returned from DPGraph processing.
 type DataPipeline []Node
 
+// NodeStats are the execution statistics of a node of the pipeline.
+type NodeStats struct {
+	Duration time.Duration
+	// InputSeries is the number of series of the nodes the node depends on
+	InputSeries int
+	// OutputSeries is the number of series returned by the node
+	OutputSeries int
+}
+
 // execute runs all the command/datasource requests in the pipeline return a
-// map of the refId of the of each command
-func (dp *DataPipeline) execute(c context.Context, now time.Time, s *Service) (mathexp.Vars, error) {
+// map of the refId of the of each command and the statistics of each node
+func (dp *DataPipeline) execute(c context.Context, now time.Time, s *Service) (mathexp.Vars, map[string]NodeStats, error) {
 	vars := make(mathexp.Vars)
+	stats := make(map[string]NodeStats, len(*dp))
 	for _, node := range *dp {
-		res, err := node.Execute(c, now, vars, s)
+		res, nodeStats, err := executeNode(c, now, node, vars, s)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		vars[node.RefID()] = res
+		stats[node.RefID()] = nodeStats
+	}
+	return vars, stats, nil
+}
+
+// executeNode runs a node in its own span, the span records the volume of the series going in and
+// out of the node
+func executeNode(c context.Context, now time.Time, node Node, vars mathexp.Vars, s *Service) (mathexp.Results, NodeStats, error) {
+	c, span := s.tracer.Start(c, "SSE.ExecuteNode")
+	defer span.End()
+
+	span.SetAttributes("node.refId", node.RefID(), attribute.Key("node.refId").String(node.RefID()))
+	span.SetAttributes("node.type", node.NodeType().String(), attribute.Key("node.type").String(node.NodeType().String()))
+
+	stats := NodeStats{}
+	if cmdNode, ok := node.(*CMDNode); ok {
+		span.SetAttributes("node.command", cmdNode.CMDType.String(), attribute.Key("node.command").String(cmdNode.CMDType.String()))
+		for _, refID := range cmdNode.Command.NeedsVars() {
+			stats.InputSeries += countSeries(vars[refID].Values)
+		}
+		span.SetAttributes("node.input_series", stats.InputSeries, attribute.Key("node.input_series").Int(stats.InputSeries))
+	}
+
+	start := time.Now()
+	res, err := node.Execute(c, now, vars, s)
+	stats.Duration = time.Since(start)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return res, stats, err
+	}
+
+	stats.OutputSeries = countSeries(res.Values)
+	span.SetAttributes("node.output_series", stats.OutputSeries, attribute.Key("node.output_series").Int(stats.OutputSeries))
+	return res, stats, nil
+}
+
+// countSeries returns the number of values that are not a no data response
+func countSeries(values mathexp.Values) int {
+	count := 0
+	for _, v := range values {
+		if v.Type() != parse.TypeNoData {
+			count++
+		}
 	}
-	return vars, nil
+	return count
 }
 
 // BuildPipeline builds a graph of the nodes, and returns the nodes in an
@@ -135,6 +135,10 @@ const (
 	defaultMaxDP      = int64(5000)
 )
 
+// FromExpressionHeaderName is the name of the header added to the requests of the queries of the expressions, the
+// datasources can't return partial results their expressions would be computed from
+const FromExpressionHeaderName = "FromExpression"
+
 // DSNode is a DPNode that holds a datasource request.
 type DSNode struct {
 	baseNode
@@ -225,8 +229,12 @@ func (dn *DSNode) Execute(ctx context.Context, now time.Time, _ mathexp.Vars, s
 				QueryType:     dn.queryType,
 			},
 		},
-		Headers: dn.request.Headers,
+		Headers: make(map[string]string, len(dn.request.Headers)+1),
+	}
+	for k, v := range dn.request.Headers {
+		req.Headers[k] = v
 	}
+	req.Headers[FromExpressionHeaderName] = "true"
 
 	responseType := "unknown"
 	defer func() {
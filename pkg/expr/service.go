@@ -5,8 +5,10 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/setting"
@@ -40,13 +42,15 @@ type Service struct {
 	cfg               *setting.Cfg
 	dataService       backend.QueryDataHandler
 	dataSourceService datasources.DataSourceService
+	tracer            tracing.Tracer
 }
 
-func ProvideService(cfg *setting.Cfg, pluginClient plugins.Client, dataSourceService datasources.DataSourceService) *Service {
+func ProvideService(cfg *setting.Cfg, pluginClient plugins.Client, dataSourceService datasources.DataSourceService, tracer tracing.Tracer) *Service {
 	return &Service{
 		cfg:               cfg,
 		dataService:       pluginClient,
 		dataSourceService: dataSourceService,
+		tracer:            tracer,
 	}
 }
 
@@ -63,20 +67,46 @@ func (s *Service) BuildPipeline(req *Request) (DataPipeline, error) {
 }
 
 // ExecutePipeline executes an expression pipeline and returns all the results.
+// The statistics of the execution of each node are added to the meta of the first frame of the node.
 func (s *Service) ExecutePipeline(ctx context.Context, now time.Time, pipeline DataPipeline) (*backend.QueryDataResponse, error) {
 	res := backend.NewQueryDataResponse()
-	vars, err := pipeline.execute(ctx, now, s)
+	vars, stats, err := pipeline.execute(ctx, now, s)
 	if err != nil {
 		return nil, err
 	}
 	for refID, val := range vars {
+		frames := val.Values.AsDataFrames(refID)
+		addNodeStats(frames, stats[refID])
 		res.Responses[refID] = backend.DataResponse{
-			Frames: val.Values.AsDataFrames(refID),
+			Frames: frames,
 		}
 	}
 	return res, nil
 }
 
+// addNodeStats adds the statistics of a node to the meta of its first frame, the query inspector shows
+// the statistics of all the frames of a query.
+func addNodeStats(frames []*data.Frame, stats NodeStats) {
+	if len(frames) == 0 {
+		return
+	}
+	rows := 0
+	for _, frame := range frames {
+		rows += frame.Rows()
+	}
+
+	frame := frames[0]
+	if frame.Meta == nil {
+		frame.Meta = &data.FrameMeta{}
+	}
+	frame.Meta.Stats = append(frame.Meta.Stats,
+		data.QueryStat{FieldConfig: data.FieldConfig{DisplayName: "Expression execution time", Unit: "ms"}, Value: float64(stats.Duration.Microseconds()) / 1000},
+		data.QueryStat{FieldConfig: data.FieldConfig{DisplayName: "Expression input series"}, Value: float64(stats.InputSeries)},
+		data.QueryStat{FieldConfig: data.FieldConfig{DisplayName: "Expression output series"}, Value: float64(stats.OutputSeries)},
+		data.QueryStat{FieldConfig: data.FieldConfig{DisplayName: "Expression output rows"}, Value: float64(rows)},
+	)
+}
+
 func DataSourceModel() *datasources.DataSource {
 	return &datasources.DataSource{
 		ID:             DatasourceID,
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	datafakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/setting"
@@ -32,6 +33,7 @@ func TestService(t *testing.T) {
 		cfg:               cfg,
 		dataService:       me,
 		dataSourceService: &datafakes.FakeDataSourceService{},
+		tracer:            tracing.InitializeTracerForTest(),
 	}
 
 	queries := []Query{
@@ -55,7 +57,7 @@ func TestService(t *testing.T) {
 		},
 	}
 
-	req := &Request{Queries: queries}
+	req := &Request{Queries: queries, Headers: map[string]string{"X-Cache-Skip": "true"}}
 
 	pl, err := s.BuildPipeline(req)
 	require.NoError(t, err)
@@ -63,6 +65,22 @@ func TestService(t *testing.T) {
 	res, err := s.ExecutePipeline(context.Background(), time.Now(), pl)
 	require.NoError(t, err)
 
+	// the datasources know their results are used by an expression
+	require.Equal(t, map[string]string{"X-Cache-Skip": "true", FromExpressionHeaderName: "true"}, me.req.Headers)
+	require.Equal(t, map[string]string{"X-Cache-Skip": "true"}, req.Headers)
+
+	// the execution time of the nodes changes between runs
+	stats := popNodeStats(res)
+	require.Len(t, stats["A"], 4)
+	require.Len(t, stats["B"], 4)
+	require.Equal(t, "Expression execution time", stats["B"][0].DisplayName)
+	require.Equal(t, "ms", stats["B"][0].Unit)
+	require.Equal(t, []data.QueryStat{
+		{FieldConfig: data.FieldConfig{DisplayName: "Expression input series"}, Value: 1},
+		{FieldConfig: data.FieldConfig{DisplayName: "Expression output series"}, Value: 1},
+		{FieldConfig: data.FieldConfig{DisplayName: "Expression output rows"}, Value: 1},
+	}, stats["B"][1:])
+
 	bDF := data.NewFrame("",
 		data.NewField("Time", nil, []time.Time{time.Unix(1, 0)}),
 		data.NewField("B", nil, []*float64{fp(4)}))
@@ -93,15 +111,36 @@ func TestService(t *testing.T) {
 	}
 }
 
+// popNodeStats removes the statistics of the nodes from the frames of the response and returns them by refId
+func popNodeStats(res *backend.QueryDataResponse) map[string][]data.QueryStat {
+	stats := map[string][]data.QueryStat{}
+	for refID, dr := range res.Responses {
+		for _, frame := range dr.Frames {
+			if frame.Meta == nil {
+				continue
+			}
+			stats[refID] = append(stats[refID], frame.Meta.Stats...)
+			frame.Meta.Stats = nil
+			if cmp.Equal(frame.Meta, &data.FrameMeta{}) {
+				frame.Meta = nil
+			}
+		}
+	}
+	return stats
+}
+
 func fp(f float64) *float64 {
 	return &f
 }
 
 type mockEndpoint struct {
 	Frames data.Frames
+	// req is the last request sent to the datasource
+	req *backend.QueryDataRequest
 }
 
 func (me *mockEndpoint) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	me.req = req
 	resp := backend.NewQueryDataResponse()
 	resp.Responses["A"] = backend.DataResponse{
 		Frames: me.Frames,
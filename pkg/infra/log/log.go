@@ -27,6 +27,8 @@ import (
 )
 
 var (
+	// handlersMu guards the handlers replaced by ReadLoggingConfig when the settings are reloaded
+	handlersMu      sync.Mutex
 	loggersToClose  []DisposableHandler
 	loggersToReload []ReloadableHandler
 	root            *logManager
@@ -369,19 +371,30 @@ func getLogFormat(format string) Formatedlogger {
 
 // this is for file logger only
 func Close() error {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	err := closeHandlers(loggersToClose)
+	loggersToClose = make([]DisposableHandler, 0)
+
+	return err
+}
+
+func closeHandlers(handlers []DisposableHandler) error {
 	var err error
-	for _, logger := range loggersToClose {
+	for _, logger := range handlers {
 		if e := logger.Close(); e != nil && err == nil {
 			err = e
 		}
 	}
-	loggersToClose = make([]DisposableHandler, 0)
-
 	return err
 }
 
 // Reload reloads all loggers.
 func Reload() error {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
 	for _, logger := range loggersToReload {
 		if err := logger.Reload(); err != nil {
 			return err
@@ -397,16 +410,24 @@ type logWithFilters struct {
 	maxLevel level.Option
 }
 
+// ReadLoggingConfig configures the loggers, it is called again when the log settings are reloaded. The handlers of
+// the previous configuration are closed once the loggers use the new ones, they are kept when the configuration is
+// invalid.
 func ReadLoggingConfig(modes []string, logsPath string, cfg *ini.File) error {
-	if err := Close(); err != nil {
-		return err
-	}
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
 
 	logEnabled := cfg.Section("log").Key("enabled").MustBool(true)
 	if !logEnabled {
-		return nil
+		err := closeHandlers(loggersToClose)
+		loggersToClose = make([]DisposableHandler, 0)
+		loggersToReload = make([]ReloadableHandler, 0)
+		return err
 	}
 
+	toClose := make([]DisposableHandler, 0)
+	toReload := make([]ReloadableHandler, 0)
+
 	defaultLevelName, _ := getLogLevelFromConfig("log", "info", cfg)
 	defaultFilters := getFilters(util.SplitString(cfg.Section("log").Key("filters").String()))
 
@@ -416,6 +437,7 @@ func ReadLoggingConfig(modes []string, logsPath string, cfg *ini.File) error {
 		sec, err := cfg.GetSection("log." + mode)
 		if err != nil {
 			_ = level.Error(root).Log("Unknown log mode", "mode", mode)
+			_ = closeHandlers(toClose)
 			return fmt.Errorf("failed to get config section log. %s: %w", mode, err)
 		}
 
@@ -450,12 +472,12 @@ func ReadLoggingConfig(modes []string, logsPath string, cfg *ini.File) error {
 				continue
 			}
 
-			loggersToClose = append(loggersToClose, fileHandler)
-			loggersToReload = append(loggersToReload, fileHandler)
+			toClose = append(toClose, fileHandler)
+			toReload = append(toReload, fileHandler)
 			handler.val = fileHandler
 		case "syslog":
 			sysLogHandler := NewSyslog(sec, format)
-			loggersToClose = append(loggersToClose, sysLogHandler)
+			toClose = append(toClose, sysLogHandler)
 			handler.val = sysLogHandler.logger
 		}
 		if handler.val == nil {
@@ -473,9 +495,12 @@ func ReadLoggingConfig(modes []string, logsPath string, cfg *ini.File) error {
 		handler.maxLevel = leveloption
 		configLoggers = append(configLoggers, handler)
 	}
-	if len(configLoggers) > 0 {
-		root.initialize(configLoggers)
+	if len(configLoggers) == 0 {
+		return nil
 	}
 
-	return nil
+	root.initialize(configLoggers)
+	previous := loggersToClose
+	loggersToClose, loggersToReload = toClose, toReload
+	return closeHandlers(previous)
 }
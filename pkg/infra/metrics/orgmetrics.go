@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OrgLabelOther is the org label of the organizations beyond the maximum number of organizations with their own label
+const OrgLabelOther = "other"
+
+// Per-org metrics, only recorded when enabled in the settings
+var (
+	// MOrgDataQueries is a metric counter for the data queries executed, by organization
+	MOrgDataQueries *prometheus.CounterVec
+
+	// MOrgAlertRuleEvaluations is a metric counter for the alert rule evaluations, by organization
+	MOrgAlertRuleEvaluations *prometheus.CounterVec
+
+	// MOrgStatActiveUsers is a metric number of active users, by organization
+	MOrgStatActiveUsers *prometheus.GaugeVec
+
+	orgLabels = &orgLabelLimiter{}
+)
+
+// orgLabelLimiter caps the cardinality of the org label: the first maxOrgs organizations seen get their own label,
+// the organizations seen after them share the OrgLabelOther label.
+type orgLabelLimiter struct {
+	mu      sync.Mutex
+	enabled bool
+	maxOrgs int
+	orgs    map[int64]string
+}
+
+func (l *orgLabelLimiter) configure(enabled bool, maxOrgs int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enabled = enabled
+	l.maxOrgs = maxOrgs
+	l.orgs = map[int64]string{}
+}
+
+func (l *orgLabelLimiter) label(orgID int64) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.enabled {
+		return "", false
+	}
+	if label, ok := l.orgs[orgID]; ok {
+		return label, true
+	}
+	if l.maxOrgs > 0 && len(l.orgs) >= l.maxOrgs {
+		return OrgLabelOther, true
+	}
+	label := strconv.FormatInt(orgID, 10)
+	l.orgs[orgID] = label
+	return label, true
+}
+
+func (l *orgLabelLimiter) isEnabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enabled
+}
+
+// OrgLabel returns the org label of the per-org metrics for the organization, it returns false when the per-org
+// metrics are disabled
+func OrgLabel(orgID int64) (string, bool) {
+	return orgLabels.label(orgID)
+}
+
+// PerOrgMetricsEnabled returns true when the per-org metrics are recorded
+func PerOrgMetricsEnabled() bool {
+	return orgLabels.isEnabled()
+}
+
+func initOrgMetrics() {
+	MOrgDataQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "org_data_queries_total",
+		Help:      "counter for the data queries executed, by organization",
+		Namespace: ExporterName,
+	}, []string{"org"})
+
+	MOrgAlertRuleEvaluations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "org_alert_rule_evaluations_total",
+		Help:      "counter for the alert rule evaluations, by organization",
+		Namespace: ExporterName,
+	}, []string{"org"})
+
+	MOrgStatActiveUsers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "org_stat_active_users",
+		Help:      "number of active users, by organization",
+		Namespace: ExporterName,
+	}, []string{"org"})
+
+	prometheus.MustRegister(
+		MOrgDataQueries,
+		MOrgAlertRuleEvaluations,
+		MOrgStatActiveUsers,
+	)
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgLabelLimiter(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		l := &orgLabelLimiter{}
+		_, ok := l.label(1)
+		require.False(t, ok)
+	})
+
+	t.Run("caps the number of organizations", func(t *testing.T) {
+		l := &orgLabelLimiter{}
+		l.configure(true, 2)
+
+		for orgID, expected := range map[int64]string{1: "1", 2: "2"} {
+			label, ok := l.label(orgID)
+			require.True(t, ok)
+			assert.Equal(t, expected, label)
+		}
+		label, _ := l.label(3)
+		assert.Equal(t, OrgLabelOther, label)
+		// the organizations seen first keep their label
+		label, _ = l.label(1)
+		assert.Equal(t, "1", label)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		l := &orgLabelLimiter{}
+		l.configure(true, 0)
+		for orgID := int64(1); orgID <= 1000; orgID++ {
+			label, _ := l.label(orgID)
+			assert.NotEqual(t, OrgLabelOther, label)
+		}
+	})
+}
@@ -22,6 +22,7 @@ func (lw *logWrapper) Println(v ...interface{}) {
 func init() {
 	initMetricVars()
 	initFrontendMetrics()
+	initOrgMetrics()
 }
 
 func ProvideService(cfg *setting.Cfg) (*InternalMetricsService, error) {
@@ -18,6 +18,7 @@ func (im *InternalMetricsService) readSettings() error {
 	}
 
 	im.intervalSeconds = section.Key("interval_seconds").MustInt64(10)
+	orgLabels.configure(im.Cfg.MetricsPerOrgEnabled, im.Cfg.MetricsPerOrgMaxOrgs)
 
 	if err := im.parseGraphiteSettings(); err != nil {
 		return fmt.Errorf("unable to parse metrics graphite section: %w", err)
@@ -42,6 +42,13 @@ type Opentelemetry struct {
 	customAttribs []attribute.KeyValue
 	log           log.Logger
 
+	samplerRatio                 float64
+	routeSamplingRules           []samplingRule
+	datasourceSamplingRules      []samplingRule
+	tailSampling                 bool
+	tailSamplingLatencyThreshold time.Duration
+	tailSamplingMaxTraces        int
+
 	tracerProvider tracerProvider
 	tracer         trace.Tracer
 
@@ -88,6 +95,25 @@ func (ots *Opentelemetry) parseSettingsOpentelemetry() error {
 		return err
 	}
 
+	ots.samplerRatio = section.Key("sampler_ratio").MustFloat64(1)
+	if ots.samplerRatio < 0 || ots.samplerRatio > 1 {
+		return fmt.Errorf("sampler_ratio must be between 0 and 1: %v", ots.samplerRatio)
+	}
+	ots.routeSamplingRules, err = parseSamplingRules(section.Key("route_sampling_rules").MustString(""))
+	if err != nil {
+		return err
+	}
+	ots.datasourceSamplingRules, err = parseSamplingRules(section.Key("datasource_sampling_rules").MustString(""))
+	if err != nil {
+		return err
+	}
+	ots.tailSampling = section.Key("tail_sampling_enabled").MustBool(false)
+	ots.tailSamplingLatencyThreshold = section.Key("tail_sampling_latency_threshold").MustDuration(time.Second)
+	ots.tailSamplingMaxTraces = section.Key("tail_sampling_max_traces").MustInt(10000)
+	if len(ots.datasourceSamplingRules) > 0 && !ots.tailSampling {
+		ots.log.Warn("The data source sampling rules are only applied with tail sampling enabled")
+	}
+
 	section, err = ots.Cfg.Raw.GetSection("tracing.opentelemetry.jaeger")
 	if err != nil {
 		return err
@@ -152,8 +178,7 @@ func (ots *Opentelemetry) initJaegerTracerProvider() (*tracesdk.TracerProvider,
 	}
 
 	tp := tracesdk.NewTracerProvider(
-		tracesdk.WithBatcher(exp),
-		tracesdk.WithResource(res),
+		ots.samplingOptions(exp, tracesdk.WithResource(res))...,
 	)
 
 	return tp, nil
@@ -181,15 +206,23 @@ func (ots *Opentelemetry) initOTLPTracerProvider() (*tracesdk.TracerProvider, er
 	}
 
 	tp := tracesdk.NewTracerProvider(
-		tracesdk.WithBatcher(exp),
-		tracesdk.WithSampler(tracesdk.ParentBased(
-			tracesdk.AlwaysSample(),
-		)),
-		tracesdk.WithResource(res),
+		ots.samplingOptions(exp, tracesdk.WithResource(res))...,
 	)
 	return tp, nil
 }
 
+// samplingOptions returns the options of the tracer provider sampling the spans and exporting them to the exporter,
+// either in batches or after the tail sampling.
+func (ots *Opentelemetry) samplingOptions(exp tracesdk.SpanExporter, opts ...tracesdk.TracerProviderOption) []tracesdk.TracerProviderOption {
+	opts = append(opts, tracesdk.WithSampler(newSampler(ots.samplerRatio, ots.routeSamplingRules, ots.tailSampling)))
+	if !ots.tailSampling {
+		return append(opts, tracesdk.WithBatcher(exp))
+	}
+	return append(opts, tracesdk.WithSpanProcessor(newTailSamplingProcessor(
+		exp, ots.tailSamplingLatencyThreshold, ots.datasourceSamplingRules, ots.tailSamplingMaxTraces, ots.log,
+	)))
+}
+
 func (ots *Opentelemetry) initNoopTracerProvider() (tracerProvider, error) {
 	return &noopTracerProvider{TracerProvider: trace.NewNoopTracerProvider()}, nil
 }
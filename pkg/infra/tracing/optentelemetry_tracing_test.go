@@ -2,10 +2,12 @@ package tracing
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -87,3 +89,37 @@ func TestOptentelemetry_ParseSettingsOpentelemetry(t *testing.T) {
 	assert.Equal(t, "somehost:4317", otel.address)
 	assert.Equal(t, otlpExporter, otel.enabled)
 }
+
+func TestOptentelemetry_ParseSettingsOpentelemetry_Sampling(t *testing.T) {
+	cfg := setting.NewCfg()
+	otel := &Opentelemetry{Cfg: cfg, log: log.NewNopLogger()}
+
+	otelsect := cfg.Raw.Section("tracing.opentelemetry")
+	cfg.Raw.Section("tracing.opentelemetry.jaeger")
+	cfg.Raw.Section("tracing.opentelemetry.otlp")
+
+	assert.NoError(t, otel.parseSettingsOpentelemetry())
+	assert.Equal(t, float64(1), otel.samplerRatio)
+	assert.Empty(t, otel.routeSamplingRules)
+	assert.False(t, otel.tailSampling)
+	assert.Equal(t, time.Second, otel.tailSamplingLatencyThreshold)
+
+	otelsect.Key("sampler_ratio").SetValue("0.1")
+	otelsect.Key("route_sampling_rules").SetValue("/api/ds/query:0.5")
+	otelsect.Key("datasource_sampling_rules").SetValue("prometheus:1")
+	otelsect.Key("tail_sampling_enabled").SetValue("true")
+	otelsect.Key("tail_sampling_latency_threshold").SetValue("5s")
+	assert.NoError(t, otel.parseSettingsOpentelemetry())
+	assert.Equal(t, 0.1, otel.samplerRatio)
+	assert.Len(t, otel.routeSamplingRules, 1)
+	assert.Len(t, otel.datasourceSamplingRules, 1)
+	assert.True(t, otel.tailSampling)
+	assert.Equal(t, 5*time.Second, otel.tailSamplingLatencyThreshold)
+
+	otelsect.Key("sampler_ratio").SetValue("2")
+	assert.Error(t, otel.parseSettingsOpentelemetry())
+
+	otelsect.Key("sampler_ratio").SetValue("1")
+	otelsect.Key("route_sampling_rules").SetValue("/api/ds/query")
+	assert.Error(t, otel.parseSettingsOpentelemetry())
+}
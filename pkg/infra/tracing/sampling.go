@@ -0,0 +1,358 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	trace "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// The keys of the span attributes, and span event attributes, identifying the data source of a span. They are matched
+// against the data source sampling rules.
+const (
+	DatasourceTypeAttributeKey = attribute.Key("datasource_type")
+	DatasourceUIDAttributeKey  = attribute.Key("datasource_uid")
+)
+
+const (
+	// maxSpansPerTrace is the maximum number of spans of a trace buffered by the tail sampling.
+	maxSpansPerTrace = 1000
+	// tailSamplingTraceTimeout is the time after which the traces whose local root span didn't end are evaluated
+	// with the spans buffered so far.
+	tailSamplingTraceTimeout = 5 * time.Minute
+	// tailSamplingExportTimeout is the timeout of an export of the spans of the traces kept by the tail sampling.
+	tailSamplingExportTimeout = 30 * time.Second
+	// tailSamplingQueueSize is the number of kept traces waiting to be exported, the traces kept when the queue is
+	// full are dropped.
+	tailSamplingQueueSize = 1000
+)
+
+// samplingRule is the ratio of the traces sampled when their route or data source matches the pattern. A pattern
+// ending with * matches the values starting with the pattern.
+type samplingRule struct {
+	pattern string
+	ratio   float64
+	sampler tracesdk.Sampler
+}
+
+func (r samplingRule) matches(value string) bool {
+	if prefix := strings.TrimSuffix(r.pattern, "*"); prefix != r.pattern {
+		return strings.HasPrefix(value, prefix)
+	}
+	return value == r.pattern
+}
+
+// parseSamplingRules parses a comma separated list of pattern:ratio sampling rules.
+func parseSamplingRules(s string) ([]samplingRule, error) {
+	rules := []samplingRule{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		i := strings.LastIndex(v, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("sampling rule malformed - must be in 'pattern:ratio' form: %q", v)
+		}
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(v[i+1:]), 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("sampling rule malformed - the ratio must be between 0 and 1: %q", v)
+		}
+		rules = append(rules, samplingRule{
+			pattern: strings.TrimSpace(v[:i]),
+			ratio:   ratio,
+			sampler: tracesdk.TraceIDRatioBased(ratio),
+		})
+	}
+	return rules, nil
+}
+
+// matchSamplingRule returns the first rule matching the value.
+func matchSamplingRule(rules []samplingRule, value string) (samplingRule, bool) {
+	for _, r := range rules {
+		if r.matches(value) {
+			return r, true
+		}
+	}
+	return samplingRule{}, false
+}
+
+// newSampler returns the sampler of the spans. The root spans are sampled by routeSampler, the other spans have the
+// sampling decision of their parent. With tail sampling, the spans which are not sampled are still recorded so that
+// the tail sampling can keep them.
+func newSampler(ratio float64, routes []samplingRule, tailSampling bool) tracesdk.Sampler {
+	root := routeSampler{
+		defaultSampler:  tracesdk.TraceIDRatioBased(ratio),
+		routes:          routes,
+		recordUnsampled: tailSampling,
+	}
+	if !tailSampling {
+		return tracesdk.ParentBased(root)
+	}
+	return tracesdk.ParentBased(root,
+		tracesdk.WithRemoteParentNotSampled(recordOnlySampler{}),
+		tracesdk.WithLocalParentNotSampled(recordOnlySampler{}),
+	)
+}
+
+// routeSampler samples the spans of the HTTP requests with the ratio of the first route rule matching their path, and
+// the other spans with the default sampler.
+type routeSampler struct {
+	defaultSampler  tracesdk.Sampler
+	routes          []samplingRule
+	recordUnsampled bool
+}
+
+func (s routeSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	sampler := s.defaultSampler
+	for _, attr := range p.Attributes {
+		if attr.Key != semconv.HTTPTargetKey {
+			continue
+		}
+		if r, ok := matchSamplingRule(s.routes, attr.Value.AsString()); ok {
+			sampler = r.sampler
+		}
+		break
+	}
+
+	res := sampler.ShouldSample(p)
+	if res.Decision == tracesdk.Drop && s.recordUnsampled {
+		res.Decision = tracesdk.RecordOnly
+	}
+	return res
+}
+
+func (s routeSampler) Description() string {
+	return fmt.Sprintf("RouteSampler{default:%s,routes:%d}", s.defaultSampler.Description(), len(s.routes))
+}
+
+// recordOnlySampler records the spans without sampling them.
+type recordOnlySampler struct{}
+
+func (recordOnlySampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	return tracesdk.SamplingResult{
+		Decision:   tracesdk.RecordOnly,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+func (recordOnlySampler) Description() string {
+	return "RecordOnly"
+}
+
+type pendingTrace struct {
+	spans    []tracesdk.ReadOnlySpan
+	received time.Time
+}
+
+// tailSamplingProcessor is a tracesdk.SpanProcessor buffering the spans of the traces until their local root span
+// ends. The trace is then exported when it was sampled, has an error, was slower than the latency threshold or
+// matches a data source sampling rule, and is dropped otherwise.
+type tailSamplingProcessor struct {
+	exporter         tracesdk.SpanExporter
+	latencyThreshold time.Duration
+	datasources      []samplingRule
+	maxTraces        int
+	log              log.Logger
+
+	mtx    sync.Mutex
+	traces map[trace.TraceID]*pendingTrace
+	closed bool
+
+	queue chan []tracesdk.ReadOnlySpan
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newTailSamplingProcessor(exporter tracesdk.SpanExporter, latencyThreshold time.Duration, datasources []samplingRule, maxTraces int, logger log.Logger) *tailSamplingProcessor {
+	p := &tailSamplingProcessor{
+		exporter:         exporter,
+		latencyThreshold: latencyThreshold,
+		datasources:      datasources,
+		maxTraces:        maxTraces,
+		log:              logger,
+		traces:           map[trace.TraceID]*pendingTrace{},
+		queue:            make(chan []tracesdk.ReadOnlySpan, tailSamplingQueueSize),
+		done:             make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run()
+	}()
+	return p
+}
+
+func (p *tailSamplingProcessor) OnStart(parent context.Context, s tracesdk.ReadWriteSpan) {}
+
+func (p *tailSamplingProcessor) OnEnd(s tracesdk.ReadOnlySpan) {
+	traceID := s.SpanContext().TraceID()
+	localRoot := !s.Parent().IsValid() || s.Parent().IsRemote()
+
+	p.mtx.Lock()
+	if p.closed {
+		p.mtx.Unlock()
+		return
+	}
+	t, exists := p.traces[traceID]
+	if !exists {
+		if !localRoot && len(p.traces) >= p.maxTraces {
+			p.mtx.Unlock()
+			p.log.Debug("Dropping span, too many pending traces", "traceID", traceID, "maxTraces", p.maxTraces)
+			return
+		}
+		t = &pendingTrace{received: time.Now()}
+	}
+	if len(t.spans) < maxSpansPerTrace {
+		t.spans = append(t.spans, s)
+	}
+	if !localRoot {
+		p.traces[traceID] = t
+		p.mtx.Unlock()
+		return
+	}
+	delete(p.traces, traceID)
+	p.mtx.Unlock()
+
+	p.evaluate(traceID, t.spans)
+}
+
+// evaluate queues the spans of the trace for export when the trace is kept.
+func (p *tailSamplingProcessor) evaluate(traceID trace.TraceID, spans []tracesdk.ReadOnlySpan) {
+	if !p.keep(traceID, spans) {
+		return
+	}
+
+	select {
+	case p.queue <- spans:
+	default:
+		p.log.Warn("Dropping trace, the export queue is full", "traceID", traceID)
+	}
+}
+
+func (p *tailSamplingProcessor) keep(traceID trace.TraceID, spans []tracesdk.ReadOnlySpan) bool {
+	for _, s := range spans {
+		if s.SpanContext().IsSampled() || s.Status().Code == codes.Error {
+			return true
+		}
+		if p.latencyThreshold > 0 && s.EndTime().Sub(s.StartTime()) >= p.latencyThreshold {
+			return true
+		}
+	}
+
+	if len(p.datasources) == 0 {
+		return false
+	}
+	for _, s := range spans {
+		attrs := s.Attributes()
+		for _, e := range s.Events() {
+			attrs = append(attrs[:len(attrs):len(attrs)], e.Attributes...)
+		}
+		for _, attr := range attrs {
+			if attr.Key != DatasourceTypeAttributeKey && attr.Key != DatasourceUIDAttributeKey {
+				continue
+			}
+			r, ok := matchSamplingRule(p.datasources, attr.Value.AsString())
+			if !ok {
+				continue
+			}
+			if r.sampler.ShouldSample(tracesdk.SamplingParameters{TraceID: traceID}).Decision == tracesdk.RecordAndSample {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *tailSamplingProcessor) run() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case spans := <-p.queue:
+			p.export(spans)
+		case <-ticker.C:
+			p.evictPendingTraces(time.Now().Add(-tailSamplingTraceTimeout))
+		case <-p.done:
+			for {
+				select {
+				case spans := <-p.queue:
+					p.export(spans)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// evictPendingTraces evaluates the traces received before the time whose local root span didn't end yet, like the
+// traces of the requests whose root span ended before some of its child spans.
+func (p *tailSamplingProcessor) evictPendingTraces(before time.Time) {
+	p.mtx.Lock()
+	evicted := map[trace.TraceID]*pendingTrace{}
+	for traceID, t := range p.traces {
+		if t.received.Before(before) {
+			evicted[traceID] = t
+			delete(p.traces, traceID)
+		}
+	}
+	p.mtx.Unlock()
+
+	for traceID, t := range evicted {
+		p.evaluate(traceID, t.spans)
+	}
+}
+
+func (p *tailSamplingProcessor) export(spans []tracesdk.ReadOnlySpan) {
+	ctx, cancel := context.WithTimeout(context.Background(), tailSamplingExportTimeout)
+	defer cancel()
+
+	if err := p.exporter.ExportSpans(ctx, spans); err != nil {
+		p.log.Error("Failed to export spans", "error", err)
+	}
+}
+
+// ForceFlush exports the kept traces waiting in the export queue.
+func (p *tailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	for {
+		select {
+		case spans := <-p.queue:
+			p.export(spans)
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			return nil
+		}
+	}
+}
+
+// Shutdown exports the kept traces waiting in the export queue and shuts the exporter down. The pending traces are
+// dropped.
+func (p *tailSamplingProcessor) Shutdown(ctx context.Context) error {
+	p.mtx.Lock()
+	if p.closed {
+		p.mtx.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.traces = map[trace.TraceID]*pendingTrace{}
+	p.mtx.Unlock()
+
+	close(p.done)
+	p.wg.Wait()
+	return p.exporter.Shutdown(ctx)
+}
@@ -0,0 +1,158 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	trace "go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestParseSamplingRules(t *testing.T) {
+	rules, err := parseSamplingRules("/api/ds/query:0.5, /api/datasources/*:1,prometheus:0")
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	r, ok := matchSamplingRule(rules, "/api/ds/query")
+	require.True(t, ok)
+	assert.Equal(t, 0.5, r.ratio)
+
+	r, ok = matchSamplingRule(rules, "/api/datasources/uid/abc/resources")
+	require.True(t, ok)
+	assert.Equal(t, float64(1), r.ratio)
+
+	_, ok = matchSamplingRule(rules, "/api/ds/query/extra")
+	assert.False(t, ok)
+
+	rules, err = parseSamplingRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for _, malformed := range []string{"/api/ds/query", ":0.5", "/api/ds/query:high", "/api/ds/query:2"} {
+		_, err := parseSamplingRules(malformed)
+		assert.Error(t, err, malformed)
+	}
+}
+
+func TestRouteSampler(t *testing.T) {
+	routes, err := parseSamplingRules("/api/ds/query:1")
+	require.NoError(t, err)
+
+	startSpan := func(tp trace.TracerProvider, path string) trace.Span {
+		_, span := tp.Tracer("test").Start(context.Background(), "HTTP POST", trace.WithAttributes(semconv.HTTPTargetKey.String(path)))
+		return span
+	}
+
+	t.Run("the routes are sampled with the ratio of their rule", func(t *testing.T) {
+		tp := tracesdk.NewTracerProvider(tracesdk.WithSampler(newSampler(0, routes, false)))
+
+		assert.True(t, startSpan(tp, "/api/ds/query").SpanContext().IsSampled())
+		span := startSpan(tp, "/api/dashboards/uid/abc")
+		assert.False(t, span.SpanContext().IsSampled())
+		assert.False(t, span.IsRecording())
+	})
+
+	t.Run("the spans not sampled are recorded with tail sampling", func(t *testing.T) {
+		tp := tracesdk.NewTracerProvider(tracesdk.WithSampler(newSampler(0, routes, true)))
+
+		span := startSpan(tp, "/api/dashboards/uid/abc")
+		assert.False(t, span.SpanContext().IsSampled())
+		assert.True(t, span.IsRecording())
+
+		_, child := tp.Tracer("test").Start(trace.ContextWithSpan(context.Background(), span), "child")
+		assert.False(t, child.SpanContext().IsSampled())
+		assert.True(t, child.IsRecording())
+	})
+}
+
+func TestTailSamplingProcessor(t *testing.T) {
+	datasources, err := parseSamplingRules("prometheus:1")
+	require.NoError(t, err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	processor := newTailSamplingProcessor(exporter, time.Hour, datasources, 10, log.New("test"))
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSampler(newSampler(0, nil, true)),
+		tracesdk.WithSpanProcessor(processor),
+	)
+	t.Cleanup(func() {
+		require.NoError(t, tp.Shutdown(context.Background()))
+	})
+	tracer := tp.Tracer("test")
+
+	exportedTraces := func() map[trace.TraceID]int {
+		traces := map[trace.TraceID]int{}
+		for _, s := range exporter.GetSpans() {
+			traces[s.SpanContext.TraceID()]++
+		}
+		return traces
+	}
+
+	t.Run("the traces with an error are kept", func(t *testing.T) {
+		ctx, root := tracer.Start(context.Background(), "root")
+		_, child := tracer.Start(ctx, "child")
+		child.RecordError(errors.New("boom"))
+		child.SetStatus(codes.Error, "boom")
+		child.End()
+		require.Empty(t, exportedTraces(), "the trace is exported once its root span ends")
+		root.End()
+
+		require.Eventually(t, func() bool {
+			return exportedTraces()[root.SpanContext().TraceID()] == 2
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("the traces matching a data source rule are kept", func(t *testing.T) {
+		ctx, root := tracer.Start(context.Background(), "root")
+		_, child := tracer.Start(ctx, "child")
+		child.AddEvent("datasource query", trace.WithAttributes(DatasourceTypeAttributeKey.String("prometheus")))
+		child.End()
+		root.End()
+
+		require.Eventually(t, func() bool {
+			return exportedTraces()[root.SpanContext().TraceID()] == 2
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("keep", func(t *testing.T) {
+		newSpans := func(opts ...trace.SpanStartOption) (trace.TraceID, []tracesdk.ReadOnlySpan) {
+			_, span := tracer.Start(context.Background(), "span", opts...)
+			span.End()
+			return span.SpanContext().TraceID(), []tracesdk.ReadOnlySpan{span.(tracesdk.ReadOnlySpan)}
+		}
+
+		traceID, spans := newSpans()
+		assert.False(t, processor.keep(traceID, spans), "fast traces without error are dropped")
+
+		traceID, spans = newSpans(trace.WithAttributes(DatasourceTypeAttributeKey.String("loki")))
+		assert.False(t, processor.keep(traceID, spans), "the data sources without rule are dropped")
+
+		traceID, spans = newSpans(trace.WithAttributes(DatasourceTypeAttributeKey.String("prometheus")))
+		assert.True(t, processor.keep(traceID, spans))
+
+		traceID, spans = newSpans(trace.WithTimestamp(time.Now().Add(-2 * time.Hour)))
+		assert.True(t, processor.keep(traceID, spans), "slow traces are kept")
+	})
+
+	t.Run("the pending traces are evaluated after the timeout", func(t *testing.T) {
+		ctx, root := tracer.Start(context.Background(), "root")
+		_, child := tracer.Start(ctx, "child")
+		child.SetStatus(codes.Error, "boom")
+		child.End()
+
+		processor.evictPendingTraces(time.Now().Add(time.Minute))
+		require.Eventually(t, func() bool {
+			return exportedTraces()[root.SpanContext().TraceID()] == 1
+		}, time.Second, 10*time.Millisecond)
+		root.End()
+	})
+}
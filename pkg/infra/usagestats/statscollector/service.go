@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/queryinsights"
 	"github.com/grafana/grafana/pkg/services/stats"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -31,6 +32,7 @@ type Service struct {
 	features           *featuremgmt.FeatureManager
 	datasources        datasources.DataSourceService
 	httpClientProvider httpclient.Provider
+	queryInsights      queryinsights.Service
 
 	log log.Logger
 
@@ -50,6 +52,7 @@ func ProvideService(
 	features *featuremgmt.FeatureManager,
 	datasourceService datasources.DataSourceService,
 	httpClientProvider httpclient.Provider,
+	queryInsights queryinsights.Service,
 ) *Service {
 	s := &Service{
 		cfg:                cfg,
@@ -60,6 +63,7 @@ func ProvideService(
 		features:           features,
 		datasources:        datasourceService,
 		httpClientProvider: httpClientProvider,
+		queryInsights:      queryInsights,
 
 		startTime: time.Now(),
 		log:       log.New("infra.usagestats.collector"),
@@ -70,6 +74,7 @@ func ProvideService(
 		s.collectConcurrentUsers,
 		s.collectDatasourceStats,
 		s.collectDatasourceAccess,
+		s.collectDatasourceQueries,
 		s.collectElasticStats,
 		s.collectAlertNotifierStats,
 		s.collectPrometheusFlavors,
@@ -236,6 +241,32 @@ func (s *Service) collectDatasourceStats(ctx context.Context) (map[string]interf
 	return m, nil
 }
 
+func (s *Service) collectDatasourceQueries(ctx context.Context) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if s.queryInsights == nil {
+		return m, nil
+	}
+
+	// the latencies of the custom data sources are not reported
+	// as the percentiles cannot be merged
+	var otherCount, otherErrors int64
+	for dsType, dsStats := range s.queryInsights.GetDatasourceTypeStats() {
+		if !s.usageStats.ShouldBeReported(ctx, dsType) {
+			otherCount += dsStats.Queries
+			otherErrors += dsStats.Errors
+			continue
+		}
+		m["stats.ds_queries."+dsType+".count"] = dsStats.Queries
+		m["stats.ds_queries."+dsType+".errors.count"] = dsStats.Errors
+		m["stats.ds_queries."+dsType+".latency_p50_ms"] = int64(dsStats.LatencyP50Ms)
+		m["stats.ds_queries."+dsType+".latency_p99_ms"] = int64(dsStats.LatencyP99Ms)
+	}
+	m["stats.ds_queries.other.count"] = otherCount
+	m["stats.ds_queries.other.errors.count"] = otherErrors
+
+	return m, nil
+}
+
 func (s *Service) collectElasticStats(ctx context.Context) (map[string]interface{}, error) {
 	m := map[string]interface{}{}
 	esDataSourcesQuery := datasources.GetDataSourcesByTypeQuery{Type: datasources.DS_ES}
@@ -333,6 +364,8 @@ func (s *Service) updateTotalStats(ctx context.Context) bool {
 
 	metrics.MStatTotalPublicDashboards.Set(float64(statsQuery.Result.PublicDashboards))
 
+	s.updateOrgStats(ctx)
+
 	dsStats := stats.GetDataSourceStatsQuery{}
 	if err := s.statsService.GetDataSourceStats(ctx, &dsStats); err != nil {
 		s.log.Error("Failed to get datasource stats", "error", err)
@@ -345,6 +378,31 @@ func (s *Service) updateTotalStats(ctx context.Context) bool {
 	return true
 }
 
+// updateOrgStats sets the per-org stats when the per-org metrics are enabled
+func (s *Service) updateOrgStats(ctx context.Context) {
+	if !metrics.PerOrgMetricsEnabled() {
+		return
+	}
+
+	query := stats.GetOrgActiveUsersStatsQuery{}
+	if err := s.statsService.GetOrgActiveUsersStats(ctx, &query); err != nil {
+		s.log.Error("Failed to get org active users stats", "error", err)
+		return
+	}
+
+	// the organizations beyond the maximum share a label
+	activeUsers := map[string]int64{}
+	for _, orgStats := range query.Result {
+		if label, ok := metrics.OrgLabel(orgStats.OrgID); ok {
+			activeUsers[label] += orgStats.Count
+		}
+	}
+	metrics.MOrgStatActiveUsers.Reset()
+	for label, count := range activeUsers {
+		metrics.MOrgStatActiveUsers.WithLabelValues(label).Set(float64(count))
+	}
+}
+
 func (s *Service) appCount(ctx context.Context) int {
 	return len(s.plugins.Plugins(ctx, plugins.App))
 }
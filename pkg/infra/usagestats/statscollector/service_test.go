@@ -12,6 +12,7 @@ import (
 
 	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 
+	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/db/dbtest"
@@ -22,6 +23,7 @@ import (
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/queryinsights"
 	"github.com/grafana/grafana/pkg/services/stats"
 	"github.com/grafana/grafana/pkg/services/stats/statstest"
 	"github.com/grafana/grafana/pkg/setting"
@@ -334,6 +336,25 @@ func TestDatasourceStats(t *testing.T) {
 	}
 }
 
+func TestDatasourceQueriesStats(t *testing.T) {
+	insights := queryinsights.ProvideService(routing.NewRouteRegister())
+	s := createService(t, &setting.Cfg{}, dbtest.NewFakeDB(), statstest.NewFakeService(), withQueryInsights(insights))
+
+	insights.Record(queryinsights.Query{OrgID: 1, DatasourceUID: "prom", DatasourceType: datasources.DS_PROMETHEUS, Duration: 20 * time.Millisecond})
+	insights.Record(queryinsights.Query{OrgID: 2, DatasourceUID: "prom", DatasourceType: datasources.DS_PROMETHEUS, Duration: 40 * time.Millisecond, Failed: true})
+	insights.Record(queryinsights.Query{OrgID: 1, DatasourceUID: "custom", DatasourceType: "unknown_ds", Failed: true})
+
+	m, err := s.collectDatasourceQueries(context.Background())
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 2, m["stats.ds_queries."+datasources.DS_PROMETHEUS+".count"])
+	assert.EqualValues(t, 1, m["stats.ds_queries."+datasources.DS_PROMETHEUS+".errors.count"])
+	assert.EqualValues(t, 25, m["stats.ds_queries."+datasources.DS_PROMETHEUS+".latency_p50_ms"])
+	assert.EqualValues(t, 1, m["stats.ds_queries.other.count"])
+	assert.EqualValues(t, 1, m["stats.ds_queries.other.errors.count"])
+	assert.NotContains(t, m, "stats.ds_queries.unknown_ds.count")
+}
+
 func TestAlertNotifiersStats(t *testing.T) {
 	sqlStore := dbtest.NewFakeDB()
 	statsService := statstest.NewFakeService()
@@ -444,11 +465,19 @@ func createService(t testing.TB, cfg *setting.Cfg, store db.DB, statsService sta
 		featuremgmt.WithFeatures("feature1", "feature2"),
 		o.datasources,
 		httpclient.NewProvider(),
+		o.queryInsights,
 	)
 }
 
 type serviceOptions struct {
-	datasources datasources.DataSourceService
+	datasources   datasources.DataSourceService
+	queryInsights queryinsights.Service
+}
+
+func withQueryInsights(insights queryinsights.Service) func(*serviceOptions) {
+	return func(options *serviceOptions) {
+		options.queryInsights = insights
+	}
 }
 
 func withDatasources(ds datasources.DataSourceService) func(*serviceOptions) {
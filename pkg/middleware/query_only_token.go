@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/expr"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+)
+
+// queryOnlyTokenPath is the only endpoint the service account tokens limited to querying data sources can call
+const queryOnlyTokenPath = "/api/ds/query"
+
+// asyncQueryPathPrefix is the prefix of the endpoints polling and deleting the queries started with the async parameter
+// of queryOnlyTokenPath, which the tokens can call since they only return the queries they started
+const asyncQueryPathPrefix = "/api/ds/query/async/"
+
+// RestrictQueryOnlyTokens rejects the requests of the service account tokens limited to querying data sources, except
+// the queries of the data sources of the token.
+func RestrictQueryOnlyTokens(c *contextmodel.ReqContext) {
+	if c.SignedInUser == nil || len(c.QueryOnlyDatasourceUIDs) == 0 {
+		return
+	}
+
+	if isAsyncQueryRequest(c.Req) {
+		return
+	}
+
+	if c.Req.Method != http.MethodPost || c.Req.URL.Path != queryOnlyTokenPath {
+		c.JsonApiErr(http.StatusForbidden, "The token can only be used to query data sources", nil)
+		return
+	}
+
+	body, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "Failed to read the request body", err)
+		return
+	}
+	c.Req.Body = io.NopCloser(bytes.NewBuffer(body))
+
+	reqDTO := dtos.MetricRequest{}
+	if err := json.Unmarshal(body, &reqDTO); err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "bad request data", err)
+		return
+	}
+
+	for _, query := range reqDTO.Queries {
+		uid := query.Get("datasource").Get("uid").MustString()
+		// before 8.3 special types could be sent as datasource (expr)
+		if uid == "" {
+			uid = query.Get("datasource").MustString()
+		}
+		if expr.IsDataSource(uid) {
+			continue
+		}
+		// the queries without a data source UID, using the data source ID or the default data source, are rejected
+		if !containsUID(c.QueryOnlyDatasourceUIDs, uid) {
+			c.JsonApiErr(http.StatusForbidden, "The token cannot query the data source", nil)
+			return
+		}
+	}
+}
+
+// isAsyncQueryRequest returns true for the requests getting the state or the result of an async query, or deleting it
+func isAsyncQueryRequest(req *http.Request) bool {
+	if !strings.HasPrefix(req.URL.Path, asyncQueryPathPrefix) {
+		return false
+	}
+	uid := strings.TrimPrefix(req.URL.Path, asyncQueryPathPrefix)
+	switch req.Method {
+	case http.MethodGet:
+		uid = strings.TrimSuffix(uid, "/result")
+	case http.MethodDelete:
+	default:
+		return false
+	}
+	return uid != "" && !strings.Contains(uid, "/")
+}
+
+func containsUID(uids []string, uid string) bool {
+	for _, u := range uids {
+		if u == uid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestRestrictQueryOnlyTokens(t *testing.T) {
+	queryOnlyToken := &user.SignedInUser{UserID: 1, IsServiceAccount: true, QueryOnlyDatasourceUIDs: []string{"prom", "loki"}}
+
+	run := func(t *testing.T, usr *user.SignedInUser, method, path, body string) (*contextmodel.ReqContext, *httptest.ResponseRecorder) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c := &contextmodel.ReqContext{
+			Context:      &web.Context{Req: httptest.NewRequest(method, path, strings.NewReader(body)), Resp: web.NewResponseWriter(method, rec)},
+			SignedInUser: usr,
+			Logger:       log.New("test"),
+		}
+		RestrictQueryOnlyTokens(c)
+		return c, rec
+	}
+
+	tests := []struct {
+		desc         string
+		usr          *user.SignedInUser
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{
+			desc:   "allows the queries of the data sources of the token",
+			usr:    queryOnlyToken,
+			method: http.MethodPost,
+			path:   "/api/ds/query",
+			body: `{"queries": [
+				{"refId": "A", "datasource": {"uid": "prom"}},
+				{"refId": "B", "datasource": {"uid": "__expr__", "type": "__expr__"}, "expression": "$A"},
+				{"refId": "C", "datasource": "loki"}
+			]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "rejects the queries of other data sources",
+			usr:          queryOnlyToken,
+			method:       http.MethodPost,
+			path:         "/api/ds/query",
+			body:         `{"queries": [{"refId": "A", "datasource": {"uid": "prom"}}, {"refId": "B", "datasource": {"uid": "mysql"}}]}`,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "rejects the queries using a data source ID",
+			usr:          queryOnlyToken,
+			method:       http.MethodPost,
+			path:         "/api/ds/query",
+			body:         `{"queries": [{"refId": "A", "datasourceId": 1}]}`,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "rejects the other endpoints",
+			usr:          queryOnlyToken,
+			method:       http.MethodGet,
+			path:         "/api/search",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "rejects invalid queries",
+			usr:          queryOnlyToken,
+			method:       http.MethodPost,
+			path:         "/api/ds/query",
+			body:         `{"queries": `,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			desc:         "allows polling the async queries",
+			usr:          queryOnlyToken,
+			method:       http.MethodGet,
+			path:         "/api/ds/query/async/q1",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "allows getting the result of the async queries",
+			usr:          queryOnlyToken,
+			method:       http.MethodGet,
+			path:         "/api/ds/query/async/q1/result",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "allows deleting the async queries",
+			usr:          queryOnlyToken,
+			method:       http.MethodDelete,
+			path:         "/api/ds/query/async/q1",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "rejects deleting the results of the async queries",
+			usr:          queryOnlyToken,
+			method:       http.MethodDelete,
+			path:         "/api/ds/query/async/q1/result",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "rejects the other async query paths",
+			usr:          queryOnlyToken,
+			method:       http.MethodGet,
+			path:         "/api/ds/query/async/q1/other",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "does not restrict the other users",
+			usr:          &user.SignedInUser{UserID: 1, IsServiceAccount: true},
+			method:       http.MethodGet,
+			path:         "/api/search",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c, rec := run(t, tt.usr, tt.method, tt.path, tt.body)
+			if tt.expectedCode == http.StatusOK {
+				require.False(t, c.Resp.Written())
+				return
+			}
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+
+	t.Run("keeps the body of the allowed queries", func(t *testing.T) {
+		body := `{"queries": [{"refId": "A", "datasource": {"uid": "prom"}}]}`
+		c, _ := run(t, queryOnlyToken, http.MethodPost, "/api/ds/query", body)
+		require.False(t, c.Resp.Written())
+
+		read, err := io.ReadAll(c.Req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(read))
+	})
+}
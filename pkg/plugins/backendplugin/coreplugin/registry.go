@@ -56,6 +56,8 @@ func init() {
 
 type Registry struct {
 	store map[string]backendplugin.PluginFactoryFunc
+	// services are the backends of the core plugins, for the hooks they implement
+	services map[string]interface{}
 }
 
 func NewRegistry(store map[string]backendplugin.PluginFactoryFunc) *Registry {
@@ -68,25 +70,33 @@ func ProvideCoreRegistry(am *azuremonitor.Service, cw *cloudwatch.CloudWatchServ
 	es *elasticsearch.Service, grap *graphite.Service, idb *influxdb.Service, lk *loki.Service, otsdb *opentsdb.Service,
 	pr *prometheus.Service, t *tempo.Service, td *testdatasource.Service, pg *postgres.Service, my *mysql.Service,
 	ms *mssql.Service, graf *grafanads.Service, phlare *phlare.Service, parca *parca.Service) *Registry {
-	return NewRegistry(map[string]backendplugin.PluginFactoryFunc{
-		CloudWatch:      asBackendPlugin(cw.Executor),
-		CloudMonitoring: asBackendPlugin(cm),
-		AzureMonitor:    asBackendPlugin(am),
-		Elasticsearch:   asBackendPlugin(es),
-		Graphite:        asBackendPlugin(grap),
-		InfluxDB:        asBackendPlugin(idb),
-		Loki:            asBackendPlugin(lk),
-		OpenTSDB:        asBackendPlugin(otsdb),
-		Prometheus:      asBackendPlugin(pr),
-		Tempo:           asBackendPlugin(t),
-		TestData:        asBackendPlugin(td),
-		PostgreSQL:      asBackendPlugin(pg),
-		MySQL:           asBackendPlugin(my),
-		MSSQL:           asBackendPlugin(ms),
-		Grafana:         asBackendPlugin(graf),
-		Phlare:          asBackendPlugin(phlare),
-		Parca:           asBackendPlugin(parca),
-	})
+	services := map[string]interface{}{
+		CloudWatch:      cw.Executor,
+		CloudMonitoring: cm,
+		AzureMonitor:    am,
+		Elasticsearch:   es,
+		Graphite:        grap,
+		InfluxDB:        idb,
+		Loki:            lk,
+		OpenTSDB:        otsdb,
+		Prometheus:      pr,
+		Tempo:           t,
+		TestData:        td,
+		PostgreSQL:      pg,
+		MySQL:           my,
+		MSSQL:           ms,
+		Grafana:         graf,
+		Phlare:          phlare,
+		Parca:           parca,
+	}
+
+	store := make(map[string]backendplugin.PluginFactoryFunc, len(services))
+	for id, svc := range services {
+		store[id] = asBackendPlugin(svc)
+	}
+	r := NewRegistry(store)
+	r.services = services
+	return r
 }
 
 func (cr *Registry) Get(pluginID string) backendplugin.PluginFactoryFunc {
@@ -103,6 +113,18 @@ func (cr *Registry) BackendFactoryProvider() func(_ context.Context, p *plugins.
 	}
 }
 
+// QueryConverter returns the query converter of the core plugin, when its backend implements one
+func (cr *Registry) QueryConverter(pluginID string) (plugins.QueryConverter, bool) {
+	converter, ok := cr.services[pluginID].(plugins.QueryConverter)
+	return converter, ok
+}
+
+// QueryValidator returns the query validator of the core plugin, when its backend implements one
+func (cr *Registry) QueryValidator(pluginID string) (plugins.QueryValidator, bool) {
+	validator, ok := cr.services[pluginID].(plugins.QueryValidator)
+	return validator, ok
+}
+
 func asBackendPlugin(svc interface{}) backendplugin.PluginFactoryFunc {
 	opts := backend.ServeOpts{}
 	if queryHandler, ok := svc.(backend.QueryDataHandler); ok {
@@ -0,0 +1,41 @@
+package coreplugin_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch"
+	"github.com/grafana/grafana/pkg/tsdb/loki"
+	"github.com/grafana/grafana/pkg/tsdb/tempo"
+)
+
+func TestRegistry_QueryConverter(t *testing.T) {
+	r := coreplugin.ProvideCoreRegistry(nil, &cloudwatch.CloudWatchService{}, nil, nil, nil, nil, &loki.Service{}, nil,
+		nil, &tempo.Service{}, nil, nil, nil, nil, nil, nil, nil)
+
+	for _, pluginID := range []string{coreplugin.Loki, coreplugin.Tempo} {
+		_, ok := r.QueryConverter(pluginID)
+		require.True(t, ok, pluginID)
+	}
+	_, ok := r.QueryConverter(coreplugin.Prometheus)
+	require.False(t, ok)
+	_, ok = r.QueryConverter("unknown")
+	require.False(t, ok)
+
+	query := map[string]interface{}{"expr": `{job="app"}`, "instant": true}
+	converter, _ := r.QueryConverter(coreplugin.Loki)
+	require.Equal(t, []string{"queryType", "instant"}, converter.ConvertQuery(query))
+}
+
+func TestRegistry_QueryValidator(t *testing.T) {
+	r := coreplugin.ProvideCoreRegistry(nil, &cloudwatch.CloudWatchService{}, nil, nil, nil, nil, &loki.Service{}, nil,
+		nil, &tempo.Service{}, nil, nil, nil, nil, nil, nil, nil)
+
+	validator, ok := r.QueryValidator(coreplugin.Loki)
+	require.True(t, ok)
+	require.NotEmpty(t, validator.ValidateQuery(map[string]interface{}{"expr": `app="foo"`}))
+	_, ok = r.QueryValidator(coreplugin.Tempo)
+	require.False(t, ok)
+}
@@ -31,6 +31,14 @@ type Cfg struct {
 	LogDatasourceRequests bool
 
 	PluginsCDNURLTemplate string
+
+	// Plugin catalog mirror settings
+	PluginCatalogMirrorType          string
+	PluginCatalogMirrorURL           string
+	PluginCatalogMirrorToken         string
+	PluginCatalogMirrorSkipTLSVerify bool
+	PluginCatalogVerifySignatures    bool
+	PluginCatalogPinnedVersions      map[string]string
 }
 
 func ProvideConfig(settingProvider setting.Provider, grafanaCfg *setting.Cfg) *Cfg {
@@ -65,6 +73,13 @@ func NewCfg(settingProvider setting.Provider, grafanaCfg *setting.Cfg) *Cfg {
 		Azure:                   grafanaCfg.Azure,
 		LogDatasourceRequests:   grafanaCfg.PluginLogBackendRequests,
 		PluginsCDNURLTemplate:   grafanaCfg.PluginsCDNURLTemplate,
+
+		PluginCatalogMirrorType:          grafanaCfg.PluginCatalogMirrorType,
+		PluginCatalogMirrorURL:           grafanaCfg.PluginCatalogMirrorURL,
+		PluginCatalogMirrorToken:         grafanaCfg.PluginCatalogMirrorToken,
+		PluginCatalogMirrorSkipTLSVerify: grafanaCfg.PluginCatalogMirrorSkipTLSVerify,
+		PluginCatalogVerifySignatures:    grafanaCfg.PluginCatalogVerifySignatures,
+		PluginCatalogPinnedVersions:      grafanaCfg.PluginCatalogPinnedVersions,
 	}
 }
 
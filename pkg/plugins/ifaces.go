@@ -34,6 +34,8 @@ type CompatOpts struct {
 	GrafanaVersion string
 	OS             string
 	Arch           string
+	// Checksum is the expected SHA256 checksum of the plugin archive. It is not verified if empty.
+	Checksum string
 }
 
 type UpdateInfo struct {
@@ -125,3 +127,21 @@ type ClientMiddlewareFunc func(next Client) Client
 func (fn ClientMiddlewareFunc) CreateClientMiddleware(next Client) Client {
 	return fn(next)
 }
+
+// QueryConverter is implemented by the backends of the plugins migrating the queries stored in the dashboards to their
+// current schema. The query is changed in place and the names of the converted fields are returned.
+type QueryConverter interface {
+	ConvertQuery(query map[string]interface{}) []string
+}
+
+// QueryValidator is implemented by the backends of the plugins checking the queries stored in the dashboards, the
+// problems found in the query are returned.
+type QueryValidator interface {
+	ValidateQuery(query map[string]interface{}) []string
+}
+
+// QueryHookRegistry returns the query hooks implemented by the backends of the plugins
+type QueryHookRegistry interface {
+	QueryConverter(pluginID string) (QueryConverter, bool)
+	QueryValidator(pluginID string) (QueryValidator, bool)
+}
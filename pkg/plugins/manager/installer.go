@@ -1,14 +1,21 @@
 package manager
 
 import (
+	"archive/zip"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"path"
+	"strings"
 
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/config"
 	"github.com/grafana/grafana/pkg/plugins/log"
 	"github.com/grafana/grafana/pkg/plugins/manager/loader"
+	"github.com/grafana/grafana/pkg/plugins/manager/loader/finder"
 	"github.com/grafana/grafana/pkg/plugins/manager/registry"
+	"github.com/grafana/grafana/pkg/plugins/manager/signature"
 	"github.com/grafana/grafana/pkg/plugins/manager/sources"
 	"github.com/grafana/grafana/pkg/plugins/repo"
 	"github.com/grafana/grafana/pkg/plugins/storage"
@@ -21,12 +28,21 @@ type PluginInstaller struct {
 	pluginStorage  storage.Manager
 	pluginRegistry registry.Service
 	pluginLoader   loader.Service
+	pluginFinder   finder.Finder
 	log            log.Logger
+
+	// pinnedVersions are the versions the plugins are pinned to, by plugin ID.
+	pinnedVersions map[string]string
+	// verifySignatures requires the installed plugins to have a valid signature.
+	verifySignatures bool
 }
 
 func ProvideInstaller(cfg *config.Cfg, pluginRegistry registry.Service, pluginLoader loader.Service,
 	pluginRepo repo.Service) *PluginInstaller {
-	return New(pluginRegistry, pluginLoader, pluginRepo, storage.FileSystem(log.NewPrettyLogger("installer.fs"), cfg.PluginsPath))
+	i := New(pluginRegistry, pluginLoader, pluginRepo, storage.FileSystem(log.NewPrettyLogger("installer.fs"), cfg.PluginsPath))
+	i.pinnedVersions = cfg.PluginCatalogPinnedVersions
+	i.verifySignatures = cfg.PluginCatalogVerifySignatures
+	return i
 }
 
 func New(pluginRegistry registry.Service, pluginLoader loader.Service, pluginRepo repo.Service,
@@ -36,12 +52,21 @@ func New(pluginRegistry registry.Service, pluginLoader loader.Service, pluginRep
 		pluginRegistry: pluginRegistry,
 		pluginRepo:     pluginRepo,
 		pluginStorage:  pluginStorage,
+		pluginFinder:   finder.NewLocalFinder(),
 		log:            log.New("plugin.installer"),
 	}
 }
 
 func (m *PluginInstaller) Add(ctx context.Context, pluginID, version string, opts plugins.CompatOpts) error {
+	version, err := m.pinnedVersion(pluginID, version)
+	if err != nil {
+		return err
+	}
+
 	compatOpts := repo.NewCompatOpts(opts.GrafanaVersion, opts.OS, opts.Arch)
+	// the checksum is only the one of the archive of the requested plugin, not of its dependencies
+	pluginCompatOpts := compatOpts
+	pluginCompatOpts.Checksum = opts.Checksum
 
 	var pluginArchive *repo.PluginArchive
 	if plugin, exists := m.plugin(ctx, pluginID); exists {
@@ -56,7 +81,7 @@ func (m *PluginInstaller) Add(ctx context.Context, pluginID, version string, opt
 		}
 
 		// get plugin update information to confirm if target update is possible
-		dlOpts, err := m.pluginRepo.GetPluginDownloadOptions(ctx, pluginID, version, compatOpts)
+		dlOpts, err := m.pluginRepo.GetPluginDownloadOptions(ctx, pluginID, version, pluginCompatOpts)
 		if err != nil {
 			return err
 		}
@@ -72,26 +97,33 @@ func (m *PluginInstaller) Add(ctx context.Context, pluginID, version string, opt
 			return fmt.Errorf("could not determine update options for %s", pluginID)
 		}
 
-		// remove existing installation of plugin
-		err = m.Remove(ctx, plugin.ID)
-		if err != nil {
-			return err
-		}
-
 		if dlOpts.PluginZipURL != "" {
-			pluginArchive, err = m.pluginRepo.GetPluginArchiveByURL(ctx, dlOpts.PluginZipURL, compatOpts)
+			pluginArchive, err = m.pluginRepo.GetPluginArchiveByURL(ctx, dlOpts.PluginZipURL, pluginCompatOpts)
 			if err != nil {
 				return err
 			}
 		} else {
-			pluginArchive, err = m.pluginRepo.GetPluginArchive(ctx, pluginID, dlOpts.Version, compatOpts)
+			pluginArchive, err = m.pluginRepo.GetPluginArchive(ctx, pluginID, dlOpts.Version, pluginCompatOpts)
 			if err != nil {
 				return err
 			}
 		}
+
+		// the signature of the new version is checked before the installed version is removed, so that the installed
+		// version is kept when the new one is refused
+		if m.verifySignatures {
+			if err := m.verifyArchiveSignature(ctx, pluginID, pluginArchive.File); err != nil {
+				return err
+			}
+		}
+
+		// remove existing installation of plugin
+		err = m.Remove(ctx, plugin.ID)
+		if err != nil {
+			return err
+		}
 	} else {
-		var err error
-		pluginArchive, err = m.pluginRepo.GetPluginArchive(ctx, pluginID, version, compatOpts)
+		pluginArchive, err = m.pluginRepo.GetPluginArchive(ctx, pluginID, version, pluginCompatOpts)
 		if err != nil {
 			return err
 		}
@@ -104,9 +136,14 @@ func (m *PluginInstaller) Add(ctx context.Context, pluginID, version string, opt
 
 	// download dependency plugins
 	pathsToScan := []string{extractedArchive.Path}
+	installed := []string{pluginID}
 	for _, dep := range extractedArchive.Dependencies {
 		m.log.Info("Fetching %s dependencies...", dep.ID)
-		d, err := m.pluginRepo.GetPluginArchive(ctx, dep.ID, dep.Version, compatOpts)
+		depVersion := dep.Version
+		if pinned, exists := m.pinnedVersions[dep.ID]; exists {
+			depVersion = pinned
+		}
+		d, err := m.pluginRepo.GetPluginArchive(ctx, dep.ID, depVersion, compatOpts)
 		if err != nil {
 			return fmt.Errorf("%v: %w", fmt.Sprintf("failed to download plugin %s from repository", dep.ID), err)
 		}
@@ -117,9 +154,17 @@ func (m *PluginInstaller) Add(ctx context.Context, pluginID, version string, opt
 		}
 
 		pathsToScan = append(pathsToScan, depArchive.Path)
+		installed = append(installed, dep.ID)
+	}
+
+	src := sources.NewLocalSource(plugins.External, pathsToScan)
+	if m.verifySignatures {
+		if err := m.verifyExtractedSignatures(ctx, src, installed); err != nil {
+			return err
+		}
 	}
 
-	_, err = m.pluginLoader.Load(ctx, sources.NewLocalSource(plugins.External, pathsToScan))
+	_, err = m.pluginLoader.Load(ctx, src)
 	if err != nil {
 		m.log.Error("Could not load plugins", "paths", pathsToScan, "err", err)
 		return err
@@ -128,6 +173,96 @@ func (m *PluginInstaller) Add(ctx context.Context, pluginID, version string, opt
 	return nil
 }
 
+// pinnedVersion returns the version of the plugin to install, which is its pinned version if it is pinned to one.
+func (m *PluginInstaller) pinnedVersion(pluginID, version string) (string, error) {
+	pinned, exists := m.pinnedVersions[pluginID]
+	if !exists || version == pinned {
+		return version, nil
+	}
+	if version == "" {
+		return pinned, nil
+	}
+	return "", plugins.VersionPinnedError{
+		PluginID:         pluginID,
+		PinnedVersion:    pinned,
+		RequestedVersion: version,
+	}
+}
+
+// verifyExtractedSignatures removes the extracted plugins if any of them does not have a valid signature. It is checked
+// before the plugins are loaded, so that a plugin without a valid signature is not initialized even if it is allowed
+// to be unsigned by allow_loading_unsigned_plugins. Without plugin_catalog_verify_signatures, the signatures are only
+// validated by the loader, which initializes the unsigned plugins that are allowed.
+func (m *PluginInstaller) verifyExtractedSignatures(ctx context.Context, src plugins.PluginSource, installed []string) error {
+	found, err := m.pluginFinder.Find(ctx, src)
+	if err != nil {
+		m.removeExtracted(ctx, installed)
+		return err
+	}
+
+	signatures := make(map[string]plugins.SignatureStatus, len(found))
+	for _, p := range found {
+		sig, err := signature.Calculate(ctx, m.log, src, p.Primary)
+		if err != nil {
+			m.log.Warn("Could not calculate plugin signature state", "pluginID", p.Primary.JSONData.ID, "err", err)
+			continue
+		}
+		signatures[p.Primary.JSONData.ID] = sig.Status
+	}
+
+	for _, pluginID := range installed {
+		if !signatures[pluginID].IsValid() {
+			m.removeExtracted(ctx, installed)
+			return fmt.Errorf("%w: %s", plugins.ErrInstallInvalidSignature, pluginID)
+		}
+	}
+	return nil
+}
+
+// verifyArchiveSignature returns an error if the plugin of the archive does not have a valid signature. The files are
+// read from the archive, without extracting them.
+func (m *PluginInstaller) verifyArchiveSignature(ctx context.Context, pluginID string, archive *zip.ReadCloser) error {
+	invalid := fmt.Errorf("%w: %s", plugins.ErrInstallInvalidSignature, pluginID)
+	if archive == nil {
+		return invalid
+	}
+	pluginFS, err := newArchiveFS(&archive.Reader)
+	if err != nil {
+		m.log.Warn("Could not find the plugin of the archive", "pluginID", pluginID, "err", err)
+		return invalid
+	}
+	f, err := pluginFS.Open("plugin.json")
+	if err != nil {
+		return invalid
+	}
+	jsonData, err := finder.ReadPluginJSON(f)
+	if closeErr := f.Close(); closeErr != nil {
+		m.log.Warn("Failed to close plugin.json of the archive", "pluginID", pluginID, "err", closeErr)
+	}
+	if err != nil || jsonData.ID != pluginID {
+		return invalid
+	}
+
+	sig, err := signature.Calculate(ctx, m.log, sources.NewLocalSource(plugins.External, nil),
+		plugins.FoundPlugin{JSONData: jsonData, FS: pluginFS})
+	if err != nil {
+		return err
+	}
+	if !sig.Status.IsValid() {
+		return invalid
+	}
+	return nil
+}
+
+// removeExtracted removes the files of the extracted plugins which were not loaded
+func (m *PluginInstaller) removeExtracted(ctx context.Context, installed []string) {
+	for _, pluginID := range installed {
+		if err := m.pluginStorage.Remove(ctx, pluginID); err != nil {
+			m.log.Error("Could not remove plugin without a valid signature", "pluginID", pluginID, "err", err)
+		}
+	}
+}
+
 func (m *PluginInstaller) Remove(ctx context.Context, pluginID string) error {
 	plugin, exists := m.plugin(ctx, pluginID)
 	if !exists {
@@ -153,3 +288,58 @@ func (m *PluginInstaller) plugin(ctx context.Context, pluginID string) (*plugins
 
 	return p, true
 }
+
+// archiveFS is the plugins.FS of the plugin of an archive, whose root is the shallowest directory with a plugin.json
+type archiveFS struct {
+	fs.FS
+	base  string
+	files []string
+}
+
+func newArchiveFS(r *zip.Reader) (*archiveFS, error) {
+	base := ""
+	for _, f := range r.File {
+		if path.Base(f.Name) != "plugin.json" {
+			continue
+		}
+		if dir := path.Dir(f.Name); base == "" || strings.Count(dir, "/") < strings.Count(base, "/") {
+			base = dir
+		}
+	}
+	if base == "" {
+		return nil, errors.New("the archive has no plugin.json")
+	}
+
+	sub, err := fs.Sub(r, base)
+	if err != nil {
+		return nil, err
+	}
+	afs := &archiveFS{FS: sub, base: base}
+	prefix := base + "/"
+	if base == "." {
+		prefix = ""
+	}
+	for _, f := range r.File {
+		if strings.HasPrefix(f.Name, prefix) && !f.FileInfo().IsDir() {
+			afs.files = append(afs.files, strings.TrimPrefix(f.Name, prefix))
+		}
+	}
+	return afs, nil
+}
+
+// Open returns plugins.ErrFileNotExist for the missing files, like the plugins.LocalFS of the extracted plugins
+func (a *archiveFS) Open(name string) (fs.File, error) {
+	f, err := a.FS.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, plugins.ErrFileNotExist
+	}
+	return f, err
+}
+
+func (a *archiveFS) Base() string {
+	return a.base
+}
+
+func (a *archiveFS) Files() []string {
+	return a.files
+}
@@ -4,6 +4,8 @@ import (
 	"archive/zip"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -191,6 +193,176 @@ func TestPluginManager_Add_Remove(t *testing.T) {
 	})
 }
 
+func TestPluginManager_Add_Catalog(t *testing.T) {
+	const pluginID = "test-panel"
+
+	setup := func(t *testing.T, loaded *plugins.Plugin) (*PluginInstaller, *fakes.FakePluginRepo, *fakes.FakePluginStorage, *[]string) {
+		var unloaded []string
+		loader := &fakes.FakeLoader{
+			LoadFunc: func(_ context.Context, _ plugins.PluginSource) ([]*plugins.Plugin, error) {
+				if loaded == nil {
+					return nil, nil
+				}
+				return []*plugins.Plugin{loaded}, nil
+			},
+			UnloadFunc: func(_ context.Context, id string) error {
+				unloaded = append(unloaded, id)
+				return nil
+			},
+		}
+		pluginRepo := &fakes.FakePluginRepo{
+			GetPluginArchiveFunc: func(_ context.Context, _, _ string, _ repo.CompatOpts) (*repo.PluginArchive, error) {
+				return &repo.PluginArchive{}, nil
+			},
+		}
+		fs := fakes.NewFakePluginStorage()
+		return New(fakes.NewFakePluginRegistry(), loader, pluginRepo, fs), pluginRepo, fs, &unloaded
+	}
+
+	t.Run("The pinned version is installed by default", func(t *testing.T) {
+		inst, pluginRepo, _, _ := setup(t, nil)
+		inst.pinnedVersions = map[string]string{pluginID: "1.2.0"}
+
+		var requested string
+		pluginRepo.GetPluginArchiveFunc = func(_ context.Context, _, version string, _ repo.CompatOpts) (*repo.PluginArchive, error) {
+			requested = version
+			return &repo.PluginArchive{}, nil
+		}
+		require.NoError(t, inst.Add(context.Background(), pluginID, "", plugins.CompatOpts{}))
+		require.Equal(t, "1.2.0", requested)
+
+		err := inst.Add(context.Background(), pluginID, "2.0.0", plugins.CompatOpts{})
+		require.Equal(t, plugins.VersionPinnedError{PluginID: pluginID, PinnedVersion: "1.2.0", RequestedVersion: "2.0.0"}, err)
+	})
+
+	t.Run("The requested checksum is only verified for the requested plugin", func(t *testing.T) {
+		inst, pluginRepo, fs, _ := setup(t, nil)
+		fs.AddFunc = func(_ context.Context, id string, _ *zip.ReadCloser) (*storage.ExtractedPluginArchive, error) {
+			if id != pluginID {
+				return &storage.ExtractedPluginArchive{}, nil
+			}
+			return &storage.ExtractedPluginArchive{Dependencies: []*storage.Dependency{{ID: "test-datasource"}}}, nil
+		}
+
+		checksums := map[string]string{}
+		pluginRepo.GetPluginArchiveFunc = func(_ context.Context, id, _ string, compatOpts repo.CompatOpts) (*repo.PluginArchive, error) {
+			checksums[id] = compatOpts.Checksum
+			return &repo.PluginArchive{}, nil
+		}
+		require.NoError(t, inst.Add(context.Background(), pluginID, "", plugins.CompatOpts{Checksum: "abcd"}))
+		require.Equal(t, map[string]string{pluginID: "abcd", "test-datasource": ""}, checksums)
+	})
+
+	extractTo := func(fs *fakes.FakePluginStorage, dirs map[string]string) {
+		fs.AddFunc = func(_ context.Context, id string, _ *zip.ReadCloser) (*storage.ExtractedPluginArchive, error) {
+			return &storage.ExtractedPluginArchive{ID: id, Path: dirs[id]}, nil
+		}
+	}
+
+	t.Run("The plugins without a valid signature are removed before they are loaded", func(t *testing.T) {
+		inst, _, fs, _ := setup(t, nil)
+		inst.verifySignatures = true
+		inst.pluginLoader.(*fakes.FakeLoader).LoadFunc = func(_ context.Context, _ plugins.PluginSource) ([]*plugins.Plugin, error) {
+			t.Fatal("the plugin should not be loaded")
+			return nil, nil
+		}
+		extractTo(fs, map[string]string{pluginID: "testdata/unsigned-panel"})
+
+		err := inst.Add(context.Background(), pluginID, "", plugins.CompatOpts{})
+		require.ErrorIs(t, err, plugins.ErrInstallInvalidSignature)
+		require.NotContains(t, fs.Store, pluginID)
+	})
+
+	t.Run("The plugins without a valid plugin.json are removed", func(t *testing.T) {
+		inst, _, fs, _ := setup(t, nil)
+		inst.verifySignatures = true
+
+		err := inst.Add(context.Background(), pluginID, "", plugins.CompatOpts{})
+		require.ErrorIs(t, err, plugins.ErrInstallInvalidSignature)
+		require.NotContains(t, fs.Store, pluginID)
+	})
+
+	t.Run("The plugins with a valid signature are installed", func(t *testing.T) {
+		const signedID = "test-datasource"
+		signed := createPlugin(t, signedID, plugins.External, true, true, func(plugin *plugins.Plugin) {
+			plugin.Signature = plugins.SignatureValid
+		})
+		inst, _, fs, unloaded := setup(t, signed)
+		inst.verifySignatures = true
+		extractTo(fs, map[string]string{signedID: "testdata/valid-v2-signature"})
+
+		require.NoError(t, inst.Add(context.Background(), signedID, "", plugins.CompatOpts{}))
+		require.Contains(t, fs.Store, signedID)
+		require.Empty(t, *unloaded)
+	})
+
+	update := func(t *testing.T, extraFiles map[string]string) ([]string, error) {
+		const signedID = "test-datasource"
+		installed := createPlugin(t, signedID, plugins.External, true, true, func(plugin *plugins.Plugin) {
+			plugin.Info.Version = "0.9.0"
+		})
+		inst, pluginRepo, fs, unloaded := setup(t, installed)
+		inst.verifySignatures = true
+		require.NoError(t, inst.pluginRegistry.Add(context.Background(), installed))
+		pluginRepo.GetPluginDownloadOptionsFunc = func(_ context.Context, _, _ string, _ repo.CompatOpts) (*repo.PluginDownloadOptions, error) {
+			return &repo.PluginDownloadOptions{Version: "1.0.0"}, nil
+		}
+		pluginRepo.GetPluginArchiveFunc = func(_ context.Context, _, _ string, _ repo.CompatOpts) (*repo.PluginArchive, error) {
+			return &repo.PluginArchive{File: zipPlugin(t, "testdata/valid-v2-signature/plugin", signedID, extraFiles)}, nil
+		}
+		extractTo(fs, map[string]string{signedID: "testdata/valid-v2-signature"})
+
+		err := inst.Add(context.Background(), signedID, "", plugins.CompatOpts{})
+		return *unloaded, err
+	}
+
+	t.Run("The installed version is kept when the update does not have a valid signature", func(t *testing.T) {
+		unloaded, err := update(t, map[string]string{"module.js": "unsigned"})
+		require.ErrorIs(t, err, plugins.ErrInstallInvalidSignature)
+		require.Empty(t, unloaded)
+	})
+
+	t.Run("The installed version is replaced by an update with a valid signature", func(t *testing.T) {
+		unloaded, err := update(t, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"test-datasource"}, unloaded)
+	})
+}
+
+// zipPlugin returns an archive with the files of the directory and the extra files in the root directory of the archive
+func zipPlugin(t *testing.T, dir, root string, extraFiles map[string]string) *zip.ReadCloser {
+	t.Helper()
+	files := map[string][]byte{}
+	for name, content := range extraFiles {
+		files[name] = []byte(content)
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		require.NoError(t, err)
+		files[e.Name()] = b
+	}
+
+	path := filepath.Join(t.TempDir(), root+".zip")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(root + "/" + name)
+		require.NoError(t, err)
+		_, err = fw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	r, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
 func createPlugin(t *testing.T, pluginID string, class plugins.Class, managed, backend bool, cbs ...func(*plugins.Plugin)) *plugins.Plugin {
 	t.Helper()
 
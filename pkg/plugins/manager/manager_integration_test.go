@@ -99,13 +99,13 @@ func TestIntegrationPluginManager(t *testing.T) {
 	lk := loki.ProvideService(hcp, features, tracer)
 	otsdb := opentsdb.ProvideService(hcp)
 	pr := prometheus.ProvideService(hcp, cfg, features, tracer)
-	tmpo := tempo.ProvideService(hcp)
+	tmpo := tempo.ProvideService(hcp, nil, nil)
 	td := testdatasource.ProvideService(cfg, features)
 	pg := postgres.ProvideService(cfg)
 	my := mysql.ProvideService(cfg, hcp)
 	ms := mssql.ProvideService(cfg)
 	sv2 := searchV2.ProvideService(cfg, db.InitTestDB(t), nil, nil, tracer, features, nil, nil, nil)
-	graf := grafanads.ProvideService(sv2, nil)
+	graf := grafanads.ProvideService(sv2, nil, nil)
 	phlare := phlare.ProvideService(hcp)
 	parca := parca.ProvideService(hcp)
 
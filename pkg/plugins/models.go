@@ -15,8 +15,22 @@ var (
 	ErrInstallCorePlugin   = errors.New("cannot install a Core plugin")
 	ErrUninstallCorePlugin = errors.New("cannot uninstall a Core plugin")
 	ErrPluginNotInstalled  = errors.New("plugin is not installed")
+	// ErrInstallInvalidSignature is returned when installing a plugin without a valid signature while the
+	// signatures of the installed plugins are verified.
+	ErrInstallInvalidSignature = errors.New("plugin does not have a valid signature")
 )
 
+// VersionPinnedError is returned when installing another version of a plugin than its pinned version.
+type VersionPinnedError struct {
+	PluginID         string
+	PinnedVersion    string
+	RequestedVersion string
+}
+
+func (e VersionPinnedError) Error() string {
+	return fmt.Sprintf("plugin '%s' is pinned to version %s, cannot install version %s", e.PluginID, e.PinnedVersion, e.RequestedVersion)
+}
+
 type NotFoundError struct {
 	PluginID string
 }
@@ -229,6 +243,9 @@ type DataSourceDTO struct {
 	// This is populated by an Enterprise hook
 	CachingConfig QueryCachingConfig `json:"cachingConfig,omitempty"`
 
+	// Capabilities are the features of the server of the datasource published by its last health check
+	Capabilities map[string]bool `json:"capabilities,omitempty"`
+
 	// InfluxDB
 	Username string `json:"username,omitempty"`
 	Password string `json:"password,omitempty"`
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/plugins/log"
@@ -22,6 +23,8 @@ type Client struct {
 	httpClient          http.Client
 	httpClientNoTimeout http.Client
 	retryCount          int
+	// token is the bearer token authenticating the requests, if not empty.
+	token string
 
 	log log.PrettyLogger
 }
@@ -142,17 +145,24 @@ func (c *Client) downloadFile(tmpFile *os.File, pluginURL, checksum string, comp
 	if err := w.Flush(); err != nil {
 		return fmt.Errorf("failed to write to %q: %w", tmpFile.Name(), err)
 	}
-	if len(checksum) > 0 && checksum != fmt.Sprintf("%x", h.Sum(nil)) {
-		return fmt.Errorf("expected SHA256 checksum does not match the downloaded archive - please contact security@grafana.com")
+	if len(checksum) > 0 && !strings.EqualFold(checksum, fmt.Sprintf("%x", h.Sum(nil))) {
+		return fmt.Errorf("%w - please contact security@grafana.com", ErrChecksumMismatch)
 	}
 	return nil
 }
 
 func (c *Client) sendReq(url *url.URL, compatOpts CompatOpts) ([]byte, error) {
+	return c.sendReqWithHeader(url, compatOpts, nil)
+}
+
+func (c *Client) sendReqWithHeader(url *url.URL, compatOpts CompatOpts, header http.Header) ([]byte, error) {
 	req, err := c.createReq(url, compatOpts)
 	if err != nil {
 		return nil, err
 	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
 
 	res, err := c.httpClient.Do(req)
 	if err != nil {
@@ -184,7 +194,11 @@ func (c *Client) sendReqNoTimeout(url *url.URL, compatOpts CompatOpts) (io.ReadC
 }
 
 func (c *Client) createReq(url *url.URL, compatOpts CompatOpts) (*http.Request, error) {
-	req, err := http.NewRequest(http.MethodGet, url.String(), nil)
+	return c.createReqWithBody(http.MethodGet, url, nil, compatOpts)
+}
+
+func (c *Client) createReqWithBody(method string, url *url.URL, body io.Reader, compatOpts CompatOpts) (*http.Request, error) {
+	req, err := http.NewRequest(method, url.String(), body)
 	if err != nil {
 		return nil, err
 	}
@@ -193,6 +207,9 @@ func (c *Client) createReq(url *url.URL, compatOpts CompatOpts) (*http.Request,
 	req.Header.Set("grafana-os", compatOpts.OS)
 	req.Header.Set("grafana-arch", compatOpts.Arch)
 	req.Header.Set("User-Agent", "grafana "+compatOpts.GrafanaVersion)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
 
 	return req, err
 }
@@ -20,6 +20,8 @@ type CompatOpts struct {
 	GrafanaVersion string
 	OS             string
 	Arch           string
+	// Checksum is the expected SHA256 checksum of the plugin archive. It is not verified if empty.
+	Checksum string
 }
 
 func NewCompatOpts(grafanaVersion, os, arch string) CompatOpts {
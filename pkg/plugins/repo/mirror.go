@@ -0,0 +1,215 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/plugins/log"
+)
+
+// MirrorType is the type of an internal mirror of the plugin catalog.
+type MirrorType string
+
+const (
+	// MirrorTypeStatic is a mirror served by a static file server. The versions of a plugin are listed in
+	// <url>/<plugin ID>/index.json, in the format of the catalog of grafana.com, with the SHA256 checksums of the
+	// archives by OS and architecture. The archives are <url>/<plugin ID>/<version>/<plugin ID>-<version>.<os>-<arch>.zip,
+	// or <url>/<plugin ID>/<version>/<plugin ID>-<version>.zip for the plugins supporting any OS and architecture.
+	MirrorTypeStatic MirrorType = "static"
+	// MirrorTypeOCI is a mirror served by an OCI registry. A plugin is the repository <url>/<plugin ID>, tagged with
+	// its versions. The layers of the manifest of a version are the archives of the version, titled like the
+	// archives of the static mirrors.
+	MirrorTypeOCI MirrorType = "oci"
+)
+
+const (
+	mirrorAnyArchitecture  = "any"
+	mirrorIndexFile        = "index.json"
+	mirrorArchiveExtension = ".zip"
+)
+
+// MirrorOpts are the options of an internal mirror of the plugin catalog.
+type MirrorOpts struct {
+	Type MirrorType
+	URL  string
+	// Token is the bearer token authenticating the requests to the mirror, if not empty.
+	Token         string
+	SkipTLSVerify bool
+}
+
+// NewMirror returns the repository of the plugins of an internal mirror of the catalog, for air-gapped
+// installations. Unlike grafana.com, the mirrors must provide the checksums of the archives. The checksums come from
+// the mirror, they detect the corrupted downloads but not the plugins the mirror should not serve, their signatures do.
+func NewMirror(opts MirrorOpts, logger log.PrettyLogger) (*Manager, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plugin catalog mirror URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid plugin catalog mirror URL %q: the scheme must be http or https", opts.URL)
+	}
+
+	client := newClient(opts.SkipTLSVerify, logger)
+	client.token = opts.Token
+
+	var source Source
+	switch opts.Type {
+	case MirrorTypeStatic:
+		source = &staticMirrorSource{client: client, baseURL: u, log: logger}
+	case MirrorTypeOCI:
+		source = &ociMirrorSource{client: client, baseURL: u, log: logger}
+	default:
+		return nil, fmt.Errorf("unknown plugin catalog mirror type %q", opts.Type)
+	}
+
+	return &Manager{
+		client: client,
+		source: source,
+		log:    logger,
+	}, nil
+}
+
+// archiveName returns the name of the archive of the version of the plugin for the OS and architecture.
+func archiveName(pluginID, version, osAndArch string) string {
+	if osAndArch == mirrorAnyArchitecture {
+		return fmt.Sprintf("%s-%s%s", pluginID, version, mirrorArchiveExtension)
+	}
+	return fmt.Sprintf("%s-%s.%s%s", pluginID, version, osAndArch, mirrorArchiveExtension)
+}
+
+// staticMirrorSource is a mirror of the catalog served by a static file server.
+type staticMirrorSource struct {
+	client  *Client
+	baseURL *url.URL
+
+	log log.PrettyLogger
+}
+
+func (s *staticMirrorSource) url(elem ...string) *url.URL {
+	u := *s.baseURL
+	u.Path = path.Join(append([]string{u.Path}, elem...)...)
+	return &u
+}
+
+func (s *staticMirrorSource) PluginMetadata(_ context.Context, pluginID string, compatOpts CompatOpts) (Plugin, error) {
+	u := s.url(pluginID, mirrorIndexFile)
+	s.log.Debugf("Fetching metadata for plugin \"%s\" from mirror %s", pluginID, u)
+
+	body, err := s.client.sendReq(u, compatOpts)
+	if err != nil {
+		return Plugin{}, err
+	}
+
+	var data Plugin
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Plugin{}, fmt.Errorf("failed to unmarshal the metadata of plugin %s from the mirror: %w", pluginID, err)
+	}
+	if data.ID == "" {
+		data.ID = pluginID
+	}
+	sortVersions(&data)
+	return data, nil
+}
+
+func (s *staticMirrorSource) DownloadOptions(_ context.Context, pluginID string, v *Version, compatOpts CompatOpts) (*PluginDownloadOptions, error) {
+	osAndArch := compatOpts.OSAndArch()
+	archMeta, exists := v.Arch[osAndArch]
+	if !exists {
+		osAndArch = mirrorAnyArchitecture
+		archMeta = v.Arch[mirrorAnyArchitecture]
+	}
+	if archMeta.SHA256 == "" {
+		return nil, fmt.Errorf("the mirror has no checksum for %s v%s (%s)", pluginID, v.Version, compatOpts.String())
+	}
+
+	return &PluginDownloadOptions{
+		Version:      v.Version,
+		Checksum:     archMeta.SHA256,
+		PluginZipURL: s.url(pluginID, v.Version, archiveName(pluginID, v.Version, osAndArch)).String(),
+	}, nil
+}
+
+// ociMirrorSource is a mirror of the catalog served by an OCI registry.
+type ociMirrorSource struct {
+	client  *Client
+	baseURL *url.URL
+
+	log log.PrettyLogger
+}
+
+// url returns the URL of the registry API of the repository of the plugin.
+func (s *ociMirrorSource) url(pluginID string, elem ...string) *url.URL {
+	u := *s.baseURL
+	u.Path = path.Join(append([]string{"/v2", u.Path, pluginID}, elem...)...)
+	return &u
+}
+
+func (s *ociMirrorSource) PluginMetadata(_ context.Context, pluginID string, compatOpts CompatOpts) (Plugin, error) {
+	u := s.url(pluginID, "tags", "list")
+	s.log.Debugf("Fetching tags of plugin \"%s\" from mirror %s", pluginID, u)
+
+	body, err := s.client.sendReq(u, compatOpts)
+	if err != nil {
+		return Plugin{}, err
+	}
+
+	var tags ociTags
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return Plugin{}, fmt.Errorf("failed to unmarshal the tags of plugin %s from the mirror: %w", pluginID, err)
+	}
+
+	// the supported architectures are only known from the manifest of a version
+	plugin := Plugin{ID: pluginID, Versions: make([]Version, 0, len(tags.Tags))}
+	for _, tag := range tags.Tags {
+		if tag == "latest" {
+			continue
+		}
+		plugin.Versions = append(plugin.Versions, Version{Version: tag})
+	}
+	sortVersions(&plugin)
+	return plugin, nil
+}
+
+func (s *ociMirrorSource) DownloadOptions(_ context.Context, pluginID string, v *Version, compatOpts CompatOpts) (*PluginDownloadOptions, error) {
+	body, err := s.client.sendReqWithHeader(s.url(pluginID, "manifests", v.Version), compatOpts, http.Header{
+		"Accept": []string{ociManifestMediaType},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the manifest of %s v%s from the mirror: %w", pluginID, v.Version, err)
+	}
+
+	layers := make(map[string]ociDescriptor, len(manifest.Layers))
+	for _, layer := range manifest.Layers {
+		layers[layer.Annotations[ociTitleAnnotation]] = layer
+	}
+	layer, exists := layers[archiveName(pluginID, v.Version, compatOpts.OSAndArch())]
+	if !exists {
+		layer, exists = layers[archiveName(pluginID, v.Version, mirrorAnyArchitecture)]
+	}
+	if !exists {
+		return nil, ErrVersionUnsupported{
+			PluginID:         pluginID,
+			RequestedVersion: v.Version,
+			SystemInfo:       compatOpts.String(),
+		}
+	}
+	if !strings.HasPrefix(layer.Digest, ociSHA256DigestPrefix) {
+		return nil, fmt.Errorf("the archive of %s v%s in the mirror does not have a SHA256 digest", pluginID, v.Version)
+	}
+
+	return &PluginDownloadOptions{
+		Version:      v.Version,
+		Checksum:     strings.TrimPrefix(layer.Digest, ociSHA256DigestPrefix),
+		PluginZipURL: s.url(pluginID, "blobs", layer.Digest).String(),
+	}, nil
+}
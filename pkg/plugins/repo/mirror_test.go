@@ -0,0 +1,174 @@
+package repo
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticMirror(t *testing.T) {
+	archive, checksum := createArchive(t)
+	compatOpts := NewCompatOpts("9.4.0", "linux", "amd64")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mirror/test-panel/index.json", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		writeJSON(t, w, Plugin{
+			ID: "test-panel",
+			Versions: []Version{
+				{Version: "1.0.0", Arch: map[string]ArchMeta{"any": {SHA256: checksum}}},
+				{Version: "1.10.0", Arch: map[string]ArchMeta{"linux-amd64": {SHA256: checksum}}},
+				{Version: "1.2.0", Arch: map[string]ArchMeta{"any": {}}},
+			},
+		})
+	})
+	mux.HandleFunc("/mirror/test-panel/1.10.0/test-panel-1.10.0.linux-amd64.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	})
+	mux.HandleFunc("/mirror/test-panel/1.0.0/test-panel-1.0.0.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	m, err := NewMirror(MirrorOpts{Type: MirrorTypeStatic, URL: srv.URL + "/mirror", Token: "token"}, &fakeLogger{})
+	require.NoError(t, err)
+
+	t.Run("the latest version is the newest semantic version", func(t *testing.T) {
+		dlOpts, err := m.GetPluginDownloadOptions(context.Background(), "test-panel", "", compatOpts)
+		require.NoError(t, err)
+		require.Equal(t, "1.10.0", dlOpts.Version)
+		require.Equal(t, checksum, dlOpts.Checksum)
+		require.Equal(t, srv.URL+"/mirror/test-panel/1.10.0/test-panel-1.10.0.linux-amd64.zip", dlOpts.PluginZipURL)
+	})
+
+	t.Run("the archives for any architecture are downloaded", func(t *testing.T) {
+		pa, err := m.GetPluginArchive(context.Background(), "test-panel", "1.0.0", compatOpts)
+		require.NoError(t, err)
+		require.Len(t, pa.File.File, 1)
+		require.NoError(t, pa.File.Close())
+	})
+
+	t.Run("the versions without checksum cannot be installed", func(t *testing.T) {
+		_, err := m.GetPluginDownloadOptions(context.Background(), "test-panel", "1.2.0", compatOpts)
+		require.Error(t, err)
+	})
+
+	t.Run("the requested checksum must match the checksum of the mirror", func(t *testing.T) {
+		opts := compatOpts
+		opts.Checksum = "0123"
+		_, err := m.GetPluginArchive(context.Background(), "test-panel", "1.0.0", opts)
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+}
+
+func TestOCIMirror(t *testing.T) {
+	archive, checksum := createArchive(t)
+	digest := ociSHA256DigestPrefix + checksum
+	compatOpts := NewCompatOpts("9.4.0", "linux", "amd64")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/plugins/test-panel/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, ociTags{Tags: []string{"latest", "1.0.0", "2.0.0"}})
+	})
+	mux.HandleFunc("/v2/plugins/test-panel/manifests/2.0.0", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, ociManifestMediaType, r.Header.Get("Accept"))
+		writeJSON(t, w, ociManifest{Layers: []ociDescriptor{{
+			MediaType:   "application/zip",
+			Digest:      digest,
+			Annotations: map[string]string{ociTitleAnnotation: "test-panel-2.0.0.linux-amd64.zip"},
+		}}})
+	})
+	mux.HandleFunc("/v2/plugins/test-panel/manifests/1.0.0", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, ociManifest{Layers: []ociDescriptor{{
+			MediaType:   "application/zip",
+			Digest:      digest,
+			Annotations: map[string]string{ociTitleAnnotation: "test-panel-1.0.0.darwin-arm64.zip"},
+		}}})
+	})
+	mux.HandleFunc("/v2/plugins/test-panel/blobs/"+digest, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	m, err := NewMirror(MirrorOpts{Type: MirrorTypeOCI, URL: srv.URL + "/plugins"}, &fakeLogger{})
+	require.NoError(t, err)
+
+	t.Run("the archive of the latest version is downloaded from the blobs", func(t *testing.T) {
+		pa, err := m.GetPluginArchive(context.Background(), "test-panel", "", compatOpts)
+		require.NoError(t, err)
+		require.Len(t, pa.File.File, 1)
+		require.NoError(t, pa.File.Close())
+	})
+
+	t.Run("the versions without a layer for the architecture are unsupported", func(t *testing.T) {
+		_, err := m.GetPluginDownloadOptions(context.Background(), "test-panel", "1.0.0", compatOpts)
+		require.ErrorAs(t, err, &ErrVersionUnsupported{})
+	})
+}
+
+func TestNewMirror(t *testing.T) {
+	_, err := NewMirror(MirrorOpts{Type: MirrorTypeStatic, URL: "ftp://mirror"}, &fakeLogger{})
+	require.Error(t, err)
+	_, err = NewMirror(MirrorOpts{Type: "unknown", URL: "https://mirror"}, &fakeLogger{})
+	require.Error(t, err)
+}
+
+func TestExpectedChecksum(t *testing.T) {
+	checksum, err := expectedChecksum("abcd", "")
+	require.NoError(t, err)
+	require.Equal(t, "abcd", checksum)
+
+	checksum, err = expectedChecksum("", "ABCD")
+	require.NoError(t, err)
+	require.Equal(t, "abcd", checksum)
+
+	checksum, err = expectedChecksum("abcd", "ABCD")
+	require.NoError(t, err)
+	require.Equal(t, "abcd", checksum)
+
+	_, err = expectedChecksum("abcd", "0123")
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestSortVersions(t *testing.T) {
+	p := createPlugin(versionArg{version: "main"}, versionArg{version: "1.9.0"}, versionArg{version: "1.10.0"}, versionArg{version: "1.0.0-beta"})
+	sortVersions(p)
+
+	versions := make([]string, 0, len(p.Versions))
+	for _, v := range p.Versions {
+		versions = append(versions, v.Version)
+	}
+	require.Equal(t, []string{"1.10.0", "1.9.0", "1.0.0-beta", "main"}, versions)
+}
+
+func createArchive(t *testing.T) ([]byte, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("test-panel/plugin.json")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(`{"id": "test-panel"}`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	sum := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), hex.EncodeToString(sum[:])
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
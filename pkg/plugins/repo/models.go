@@ -2,9 +2,13 @@ package repo
 
 import (
 	"archive/zip"
+	"errors"
 	"fmt"
 )
 
+// ErrChecksumMismatch is returned when the SHA256 checksum of a plugin archive is not the expected one.
+var ErrChecksumMismatch = errors.New("expected SHA256 checksum does not match the downloaded archive")
+
 type PluginArchive struct {
 	File *zip.ReadCloser
 }
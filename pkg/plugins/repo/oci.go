@@ -0,0 +1,441 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/plugins/log"
+)
+
+// OCIReferencePrefix is the prefix of the references to the plugins distributed as OCI artifacts.
+const OCIReferencePrefix = "oci://"
+
+const (
+	ociManifestMediaType      = "application/vnd.oci.image.manifest.v1+json"
+	ociPluginConfigMediaType  = "application/vnd.grafana.plugin.config.v1+json"
+	ociPluginArchiveMediaType = "application/vnd.grafana.plugin.archive.v1+zip"
+	ociTitleAnnotation        = "org.opencontainers.image.title"
+	ociVersionAnnotation      = "org.opencontainers.image.version"
+	ociPluginIDAnnotation     = "io.grafana.plugin.id"
+	ociPlatformAnnotation     = "io.grafana.plugin.platform"
+	ociSHA256DigestPrefix     = "sha256:"
+	ociDefaultTag             = "latest"
+)
+
+var (
+	ErrInvalidOCIReference = errors.New("invalid OCI reference")
+	ErrDigestMismatch      = errors.New("the digest of the OCI artifact does not match the pinned digest")
+
+	ociDigestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	ociTagRegexp    = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+)
+
+// OCIReference is a reference to a plugin distributed as an OCI artifact, like
+// oci://registry.example.com/grafana/my-plugin:1.0.0 or oci://registry.example.com/grafana/my-plugin@sha256:<digest>.
+type OCIReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	// Digest pins the manifest of the artifact. It takes precedence over the tag when pulling.
+	Digest string
+}
+
+// IsOCIReference returns whether the plugin reference is an OCI reference.
+func IsOCIReference(ref string) bool {
+	return strings.HasPrefix(ref, OCIReferencePrefix)
+}
+
+// ParseOCIReference parses a reference to a plugin distributed as an OCI artifact. The tag defaults to latest if the
+// reference has neither a tag nor a digest.
+func ParseOCIReference(ref string) (OCIReference, error) {
+	if !IsOCIReference(ref) {
+		return OCIReference{}, fmt.Errorf("%w %q: must start with %s", ErrInvalidOCIReference, ref, OCIReferencePrefix)
+	}
+	rest := strings.TrimPrefix(ref, OCIReferencePrefix)
+
+	var r OCIReference
+	if i := strings.Index(rest, "@"); i >= 0 {
+		rest, r.Digest = rest[:i], rest[i+1:]
+		if !ociDigestRegexp.MatchString(r.Digest) {
+			return OCIReference{}, fmt.Errorf("%w %q: the digest must be a SHA256 digest", ErrInvalidOCIReference, ref)
+		}
+	}
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		rest, r.Tag = rest[:i], rest[i+1:]
+		if !ociTagRegexp.MatchString(r.Tag) {
+			return OCIReference{}, fmt.Errorf("%w %q: invalid tag", ErrInvalidOCIReference, ref)
+		}
+	}
+	i := strings.Index(rest, "/")
+	if i <= 0 || i == len(rest)-1 {
+		return OCIReference{}, fmt.Errorf("%w %q: must include a registry and a repository", ErrInvalidOCIReference, ref)
+	}
+	r.Registry, r.Repository = rest[:i], rest[i+1:]
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = ociDefaultTag
+	}
+	return r, nil
+}
+
+func (r OCIReference) String() string {
+	s := OCIReferencePrefix + r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// reference returns the reference of the manifest of the artifact in the registry API.
+func (r OCIReference) reference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+type ociTags struct {
+	Tags []string `json:"tags"`
+}
+
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        ociDescriptor     `json:"config"`
+	Layers        []ociDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociPluginConfig struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// PluginArtifact is a plugin archive pulled from an OCI registry.
+type PluginArtifact struct {
+	*PluginArchive
+
+	PluginID string
+	Version  string
+	// Digest is the digest of the manifest of the artifact, to pin it in the references.
+	Digest string
+}
+
+// PluginArtifactArchive is an archive of a plugin pushed as a layer of an OCI artifact.
+type PluginArtifactArchive struct {
+	// Platform is the OS and architecture of the archive, like linux-amd64, or any.
+	Platform string
+	Path     string
+}
+
+// OCIRegistry pulls and pushes plugins distributed as OCI artifacts. The artifacts of a plugin have a layer per
+// archive, annotated with the OS and architecture of the archive, so that the backend plugins can be distributed
+// for several platforms under the same tag.
+type OCIRegistry struct {
+	client *Client
+
+	log log.PrettyLogger
+}
+
+// NewOCIRegistry returns a client of the OCI registries. The requests are authenticated with the bearer token if it
+// is not empty.
+func NewOCIRegistry(skipTLSVerify bool, token string, logger log.PrettyLogger) *OCIRegistry {
+	client := newClient(skipTLSVerify, logger)
+	client.token = token
+	return &OCIRegistry{
+		client: client,
+		log:    logger,
+	}
+}
+
+// url returns the URL of the registry API of the repository of the artifact.
+func (r *OCIRegistry) url(ref OCIReference, elem ...string) *url.URL {
+	return &url.URL{
+		Scheme: "https",
+		Host:   ref.Registry,
+		Path:   path.Join(append([]string{"/v2", ref.Repository}, elem...)...),
+	}
+}
+
+// GetPluginArtifact pulls the archive of the plugin for the OS and architecture from the OCI artifact. If the
+// reference has a digest, the manifest of the artifact must match it.
+func (r *OCIRegistry) GetPluginArtifact(ctx context.Context, ref OCIReference, compatOpts CompatOpts) (*PluginArtifact, error) {
+	r.log.Debugf("Fetching manifest of plugin artifact %s", ref)
+
+	body, err := r.client.sendReqWithHeader(r.url(ref, "manifests", ref.reference()), compatOpts, http.Header{
+		"Accept": []string{ociManifestMediaType},
+	})
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256Digest(body)
+	if ref.Digest != "" && digest != ref.Digest {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, ref.Digest, digest)
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the manifest of plugin artifact %s: %w", ref, err)
+	}
+
+	pluginID := manifest.Annotations[ociPluginIDAnnotation]
+	if pluginID == "" {
+		pluginID = path.Base(ref.Repository)
+	}
+	version := manifest.Annotations[ociVersionAnnotation]
+
+	layer, exists := selectArtifactLayer(manifest.Layers, compatOpts)
+	if !exists {
+		return nil, ErrVersionUnsupported{
+			PluginID:         pluginID,
+			RequestedVersion: ref.reference(),
+			SystemInfo:       compatOpts.String(),
+		}
+	}
+
+	archive, err := r.client.download(ctx, r.url(ref, "blobs", layer.Digest).String(),
+		strings.TrimPrefix(layer.Digest, ociSHA256DigestPrefix), compatOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PluginArtifact{
+		PluginArchive: archive,
+		PluginID:      pluginID,
+		Version:       version,
+		Digest:        digest,
+	}, nil
+}
+
+// selectArtifactLayer returns the archive layer for the OS and architecture, or the one supporting any OS and
+// architecture.
+func selectArtifactLayer(layers []ociDescriptor, compatOpts CompatOpts) (ociDescriptor, bool) {
+	var anyLayer *ociDescriptor
+	for i, layer := range layers {
+		if layer.MediaType != ociPluginArchiveMediaType || !strings.HasPrefix(layer.Digest, ociSHA256DigestPrefix) {
+			continue
+		}
+		switch layer.Annotations[ociPlatformAnnotation] {
+		case compatOpts.OSAndArch():
+			return layer, true
+		case mirrorAnyArchitecture, "":
+			if anyLayer == nil {
+				anyLayer = &layers[i]
+			}
+		}
+	}
+	if anyLayer == nil {
+		return ociDescriptor{}, false
+	}
+	return *anyLayer, true
+}
+
+// PushPluginArtifact pushes the archives of the version of the plugin as an OCI artifact tagged with the tag of the
+// reference. It returns the digest of the manifest of the artifact, to pin it.
+func (r *OCIRegistry) PushPluginArtifact(ctx context.Context, ref OCIReference, pluginID, version string,
+	archives []PluginArtifactArchive, compatOpts CompatOpts) (string, error) {
+	if ref.Digest != "" {
+		return "", fmt.Errorf("%w %s: cannot push to a digest", ErrInvalidOCIReference, ref)
+	}
+	if len(archives) == 0 {
+		return "", errors.New("no plugin archive to push")
+	}
+
+	config, err := json.Marshal(ociPluginConfig{ID: pluginID, Version: version})
+	if err != nil {
+		return "", err
+	}
+	configDescriptor := ociDescriptor{
+		MediaType: ociPluginConfigMediaType,
+		Digest:    sha256Digest(config),
+		Size:      int64(len(config)),
+	}
+	if err := r.pushBlob(ctx, ref, configDescriptor, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(config)), nil
+	}, compatOpts); err != nil {
+		return "", err
+	}
+
+	platforms := make(map[string]struct{}, len(archives))
+	layers := make([]ociDescriptor, 0, len(archives))
+	for _, archive := range archives {
+		if _, exists := platforms[archive.Platform]; exists {
+			return "", fmt.Errorf("several plugin archives for platform %s", archive.Platform)
+		}
+		platforms[archive.Platform] = struct{}{}
+
+		layer, err := fileDescriptor(archive.Path)
+		if err != nil {
+			return "", err
+		}
+		layer.Annotations = map[string]string{
+			ociTitleAnnotation:    filepath.Base(archive.Path),
+			ociPlatformAnnotation: archive.Platform,
+		}
+		archivePath := archive.Path
+		if err := r.pushBlob(ctx, ref, layer, func() (io.ReadCloser, error) {
+			// We can ignore the gosec G304 warning since the archives are selected by the user pushing them.
+			// nolint:gosec
+			return os.Open(archivePath)
+		}, compatOpts); err != nil {
+			return "", err
+		}
+		layers = append(layers, layer)
+	}
+
+	manifest, err := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		Config:        configDescriptor,
+		Layers:        layers,
+		Annotations: map[string]string{
+			ociPluginIDAnnotation: pluginID,
+			ociVersionAnnotation:  version,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	r.log.Debugf("Pushing manifest of plugin artifact %s", ref)
+	req, err := r.client.createReqWithBody(http.MethodPut, r.url(ref, "manifests", ref.Tag), bytes.NewReader(manifest), compatOpts)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", ociManifestMediaType)
+	if err := r.do(req.WithContext(ctx), compatOpts); err != nil {
+		return "", fmt.Errorf("failed to push the manifest of plugin artifact %s: %w", ref, err)
+	}
+
+	return sha256Digest(manifest), nil
+}
+
+// pushBlob uploads the blob to the repository of the artifact, unless the repository already has it.
+func (r *OCIRegistry) pushBlob(ctx context.Context, ref OCIReference, blob ociDescriptor, open func() (io.ReadCloser, error),
+	compatOpts CompatOpts) error {
+	req, err := r.client.createReqWithBody(http.MethodHead, r.url(ref, "blobs", blob.Digest), nil, compatOpts)
+	if err != nil {
+		return err
+	}
+	if err := r.do(req.WithContext(ctx), compatOpts); err == nil {
+		r.log.Debugf("Blob %s already exists in %s", blob.Digest, ref.Repository)
+		return nil
+	}
+
+	uploads := r.url(ref, "blobs", "uploads")
+	uploads.Path += "/"
+	req, err = r.client.createReqWithBody(http.MethodPost, uploads, nil, compatOpts)
+	if err != nil {
+		return err
+	}
+	res, err := r.client.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if err := r.closeResp(res, compatOpts); err != nil {
+		return fmt.Errorf("failed to start the upload of blob %s: %w", blob.Digest, err)
+	}
+	location, err := res.Location()
+	if err != nil {
+		return fmt.Errorf("failed to start the upload of blob %s: %w", blob.Digest, err)
+	}
+	query := location.Query()
+	query.Set("digest", blob.Digest)
+	location.RawQuery = query.Encode()
+
+	body, err := open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := body.Close(); err != nil {
+			r.log.Warn("Failed to close blob", "err", err)
+		}
+	}()
+
+	r.log.Debugf("Uploading blob %s to %s", blob.Digest, ref.Repository)
+	req, err = r.client.createReqWithBody(http.MethodPut, location, body, compatOpts)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = blob.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err = r.client.httpClientNoTimeout.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if err := r.closeResp(res, compatOpts); err != nil {
+		return fmt.Errorf("failed to upload blob %s: %w", blob.Digest, err)
+	}
+	return nil
+}
+
+func (r *OCIRegistry) do(req *http.Request, compatOpts CompatOpts) error {
+	res, err := r.client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return r.closeResp(res, compatOpts)
+}
+
+func (r *OCIRegistry) closeResp(res *http.Response, compatOpts CompatOpts) error {
+	body, err := r.client.handleResp(res, compatOpts)
+	if err != nil {
+		return err
+	}
+	if err := body.Close(); err != nil {
+		r.log.Warn("Failed to close response body", "err", err)
+	}
+	return nil
+}
+
+// fileDescriptor returns the descriptor of the archive layer of the file.
+func fileDescriptor(name string) (ociDescriptor, error) {
+	// We can ignore the gosec G304 warning since the archives are selected by the user pushing them.
+	// nolint:gosec
+	f, err := os.Open(name)
+	if err != nil {
+		return ociDescriptor{}, fmt.Errorf("failed to open plugin archive: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return ociDescriptor{}, fmt.Errorf("failed to compute SHA256 checksum: %w", err)
+	}
+	return ociDescriptor{
+		MediaType: ociPluginArchiveMediaType,
+		Digest:    ociSHA256DigestPrefix + hex.EncodeToString(h.Sum(nil)),
+		Size:      size,
+	}, nil
+}
+
+func sha256Digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return ociSHA256DigestPrefix + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package repo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	const digest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+	tcs := []struct {
+		ref      string
+		expected OCIReference
+		err      bool
+	}{
+		{
+			ref:      "oci://registry.example.com/grafana/test-panel:1.0.0",
+			expected: OCIReference{Registry: "registry.example.com", Repository: "grafana/test-panel", Tag: "1.0.0"},
+		},
+		{
+			ref:      "oci://localhost:5000/test-panel",
+			expected: OCIReference{Registry: "localhost:5000", Repository: "test-panel", Tag: "latest"},
+		},
+		{
+			ref:      "oci://localhost:5000/test-panel@" + digest,
+			expected: OCIReference{Registry: "localhost:5000", Repository: "test-panel", Digest: digest},
+		},
+		{
+			ref:      "oci://localhost:5000/test-panel:1.0.0@" + digest,
+			expected: OCIReference{Registry: "localhost:5000", Repository: "test-panel", Tag: "1.0.0", Digest: digest},
+		},
+		{ref: "registry.example.com/test-panel:1.0.0", err: true},
+		{ref: "oci://registry.example.com", err: true},
+		{ref: "oci://registry.example.com/", err: true},
+		{ref: "oci://registry.example.com/test-panel@sha256:1234", err: true},
+		{ref: "oci://registry.example.com/test-panel:-1", err: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.ref, func(t *testing.T) {
+			ref, err := ParseOCIReference(tc.ref)
+			if tc.err {
+				require.ErrorIs(t, err, ErrInvalidOCIReference)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ref)
+		})
+	}
+}
+
+func TestOCIRegistry(t *testing.T) {
+	registry := newFakeOCIRegistry()
+	srv := httptest.NewTLSServer(registry)
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	archive, _ := createArchive(t)
+	dir := t.TempDir()
+	linuxArchive := filepath.Join(dir, "test-panel-1.0.0.linux-amd64.zip")
+	require.NoError(t, os.WriteFile(linuxArchive, archive, 0600))
+	anyArchive := filepath.Join(dir, "test-panel-1.0.0.zip")
+	require.NoError(t, os.WriteFile(anyArchive, archive, 0600))
+
+	r := NewOCIRegistry(true, "token", &fakeLogger{})
+	ref, err := ParseOCIReference("oci://" + host + "/grafana/test-panel:1.0.0")
+	require.NoError(t, err)
+
+	digest, err := r.PushPluginArtifact(context.Background(), ref, "test-panel", "1.0.0", []PluginArtifactArchive{
+		{Platform: "linux-amd64", Path: linuxArchive},
+		{Platform: "any", Path: anyArchive},
+	}, CompatOpts{})
+	require.NoError(t, err)
+	// the archive and the config are only uploaded once
+	require.Len(t, registry.blobs, 2)
+
+	t.Run("the archive for the OS and architecture is pulled", func(t *testing.T) {
+		artifact, err := r.GetPluginArtifact(context.Background(), ref, NewCompatOpts("9.4.0", "linux", "amd64"))
+		require.NoError(t, err)
+		require.Equal(t, "test-panel", artifact.PluginID)
+		require.Equal(t, "1.0.0", artifact.Version)
+		require.Equal(t, digest, artifact.Digest)
+		require.Len(t, artifact.File.File, 1)
+		require.NoError(t, artifact.File.Close())
+	})
+
+	t.Run("the artifact is pulled by digest", func(t *testing.T) {
+		pinned, err := ParseOCIReference("oci://" + host + "/grafana/test-panel@" + digest)
+		require.NoError(t, err)
+		artifact, err := r.GetPluginArtifact(context.Background(), pinned, NewCompatOpts("9.4.0", "darwin", "arm64"))
+		require.NoError(t, err)
+		require.NoError(t, artifact.File.Close())
+	})
+
+	t.Run("the manifest must match the pinned digest", func(t *testing.T) {
+		pinned := ref
+		pinned.Digest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+		registry.manifests["grafana/test-panel/"+pinned.Digest] = registry.manifests["grafana/test-panel/1.0.0"]
+
+		_, err := r.GetPluginArtifact(context.Background(), pinned, NewCompatOpts("9.4.0", "linux", "amd64"))
+		require.ErrorIs(t, err, ErrDigestMismatch)
+	})
+
+	t.Run("cannot push to a digest", func(t *testing.T) {
+		pinned := ref
+		pinned.Digest = digest
+		_, err := r.PushPluginArtifact(context.Background(), pinned, "test-panel", "1.0.0", []PluginArtifactArchive{
+			{Platform: "any", Path: anyArchive},
+		}, CompatOpts{})
+		require.ErrorIs(t, err, ErrInvalidOCIReference)
+	})
+}
+
+// fakeOCIRegistry implements the parts of the OCI distribution API used to pull and push plugins.
+type fakeOCIRegistry struct {
+	mtx       sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func newFakeOCIRegistry() *fakeOCIRegistry {
+	return &fakeOCIRegistry{
+		blobs:     map[string][]byte{},
+		manifests: map[string][]byte{},
+	}
+}
+
+func (f *fakeOCIRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	p := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case strings.HasSuffix(p, "/blobs/uploads/") && r.Method == http.MethodPost:
+		w.Header().Set("Location", "/upload/"+strings.TrimSuffix(p, "/blobs/uploads/"))
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(r.URL.Path, "/upload/") && r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		digest := r.URL.Query().Get("digest")
+		if sha256Digest(body) != digest {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.blobs[digest] = body
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(p, "/blobs/"):
+		blob, exists := f.blobs[p[strings.LastIndex(p, "/")+1:]]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(blob)
+		}
+	case strings.Contains(p, "/manifests/"):
+		key := strings.Replace(p, "/manifests/", "/", 1)
+		if r.Method == http.MethodPut {
+			body, _ := io.ReadAll(r.Body)
+			f.manifests[key] = body
+			f.manifests[key[:strings.LastIndex(key, "/")+1]+sha256Digest(body)] = body
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		manifest, exists := f.manifests[key]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ociManifestMediaType)
+		_, _ = w.Write(manifest)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
@@ -2,32 +2,41 @@ package repo
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/url"
-	"path"
 	"strings"
 
+	"github.com/grafana/grafana/pkg/plugins/config"
 	"github.com/grafana/grafana/pkg/plugins/log"
 )
 
 type Manager struct {
-	client  *Client
-	baseURL string
+	client *Client
+	source Source
 
 	log log.PrettyLogger
 }
 
-func ProvideService() *Manager {
+// ProvideService returns the repository of the plugins of grafana.com, or of the internal mirror of the catalog if
+// one is configured.
+func ProvideService(cfg *config.Cfg) (*Manager, error) {
+	logger := log.NewPrettyLogger("plugin.repository")
+	if cfg.PluginCatalogMirrorType != "" {
+		return NewMirror(MirrorOpts{
+			Type:          MirrorType(cfg.PluginCatalogMirrorType),
+			URL:           cfg.PluginCatalogMirrorURL,
+			Token:         cfg.PluginCatalogMirrorToken,
+			SkipTLSVerify: cfg.PluginCatalogMirrorSkipTLSVerify,
+		}, logger)
+	}
 	defaultBaseURL := "https://grafana.com/api/plugins"
-	return New(false, defaultBaseURL, log.NewPrettyLogger("plugin.repository"))
+	return New(false, defaultBaseURL, logger), nil
 }
 
 func New(skipTLSVerify bool, baseURL string, logger log.PrettyLogger) *Manager {
+	client := newClient(skipTLSVerify, logger)
 	return &Manager{
-		client:  newClient(skipTLSVerify, logger),
-		baseURL: baseURL,
-		log:     logger,
+		client: client,
+		source: newGrafanaComSource(client, baseURL, logger),
+		log:    logger,
 	}
 }
 
@@ -38,17 +47,21 @@ func (m *Manager) GetPluginArchive(ctx context.Context, pluginID, version string
 		return nil, err
 	}
 
-	return m.client.download(ctx, dlOpts.PluginZipURL, dlOpts.Checksum, compatOpts)
+	checksum, err := expectedChecksum(dlOpts.Checksum, compatOpts.Checksum)
+	if err != nil {
+		return nil, err
+	}
+	return m.client.download(ctx, dlOpts.PluginZipURL, checksum, compatOpts)
 }
 
 // GetPluginArchiveByURL fetches the requested plugin archive from the provided `pluginZipURL`
 func (m *Manager) GetPluginArchiveByURL(ctx context.Context, pluginZipURL string, compatOpts CompatOpts) (*PluginArchive, error) {
-	return m.client.download(ctx, pluginZipURL, "", compatOpts)
+	return m.client.download(ctx, pluginZipURL, compatOpts.Checksum, compatOpts)
 }
 
 // GetPluginDownloadOptions returns the options for downloading the requested plugin (with optional `version`)
-func (m *Manager) GetPluginDownloadOptions(_ context.Context, pluginID, version string, compatOpts CompatOpts) (*PluginDownloadOptions, error) {
-	plugin, err := m.pluginMetadata(pluginID, compatOpts)
+func (m *Manager) GetPluginDownloadOptions(ctx context.Context, pluginID, version string, compatOpts CompatOpts) (*PluginDownloadOptions, error) {
+	plugin, err := m.source.PluginMetadata(ctx, pluginID, compatOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -58,45 +71,19 @@ func (m *Manager) GetPluginDownloadOptions(_ context.Context, pluginID, version
 		return nil, err
 	}
 
-	// Plugins which are downloaded just as sourcecode zipball from GitHub do not have checksum
-	var checksum string
-	if v.Arch != nil {
-		archMeta, exists := v.Arch[compatOpts.OSAndArch()]
-		if !exists {
-			archMeta = v.Arch["any"]
-		}
-		checksum = archMeta.SHA256
-	}
-
-	return &PluginDownloadOptions{
-		Version:      v.Version,
-		Checksum:     checksum,
-		PluginZipURL: fmt.Sprintf("%s/%s/versions/%s/download", m.baseURL, pluginID, v.Version),
-	}, nil
+	return m.source.DownloadOptions(ctx, pluginID, v, compatOpts)
 }
 
-func (m *Manager) pluginMetadata(pluginID string, compatOpts CompatOpts) (Plugin, error) {
-	m.log.Debugf("Fetching metadata for plugin \"%s\" from repo %s", pluginID, m.baseURL)
-
-	u, err := url.Parse(m.baseURL)
-	if err != nil {
-		return Plugin{}, err
+// expectedChecksum returns the checksum the archive must match, from the catalog or requested when installing.
+func expectedChecksum(catalogChecksum, requestedChecksum string) (string, error) {
+	requestedChecksum = strings.ToLower(requestedChecksum)
+	if catalogChecksum != "" && requestedChecksum != "" && !strings.EqualFold(catalogChecksum, requestedChecksum) {
+		return "", ErrChecksumMismatch
 	}
-	u.Path = path.Join(u.Path, "repo", pluginID)
-
-	body, err := m.client.sendReq(u, compatOpts)
-	if err != nil {
-		return Plugin{}, err
+	if requestedChecksum != "" {
+		return requestedChecksum, nil
 	}
-
-	var data Plugin
-	err = json.Unmarshal(body, &data)
-	if err != nil {
-		m.log.Error("Failed to unmarshal plugin repo response error", err)
-		return Plugin{}, err
-	}
-
-	return data, nil
+	return catalogChecksum, nil
 }
 
 // selectVersion selects the most appropriate plugin version
@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+
+	"github.com/grafana/grafana/pkg/plugins/log"
+)
+
+// Source is a catalog of plugins, such as grafana.com or an internal mirror of it.
+type Source interface {
+	// PluginMetadata returns the plugin with its versions, the newest first.
+	PluginMetadata(ctx context.Context, pluginID string, compatOpts CompatOpts) (Plugin, error)
+	// DownloadOptions returns the options for downloading the version of the plugin.
+	DownloadOptions(ctx context.Context, pluginID string, version *Version, compatOpts CompatOpts) (*PluginDownloadOptions, error)
+}
+
+// grafanaComSource is the catalog of grafana.com.
+type grafanaComSource struct {
+	client  *Client
+	baseURL string
+
+	log log.PrettyLogger
+}
+
+func newGrafanaComSource(client *Client, baseURL string, logger log.PrettyLogger) *grafanaComSource {
+	return &grafanaComSource{
+		client:  client,
+		baseURL: baseURL,
+		log:     logger,
+	}
+}
+
+func (s *grafanaComSource) PluginMetadata(_ context.Context, pluginID string, compatOpts CompatOpts) (Plugin, error) {
+	s.log.Debugf("Fetching metadata for plugin \"%s\" from repo %s", pluginID, s.baseURL)
+
+	u, err := url.Parse(s.baseURL)
+	if err != nil {
+		return Plugin{}, err
+	}
+	u.Path = path.Join(u.Path, "repo", pluginID)
+
+	body, err := s.client.sendReq(u, compatOpts)
+	if err != nil {
+		return Plugin{}, err
+	}
+
+	var data Plugin
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		s.log.Error("Failed to unmarshal plugin repo response error", err)
+		return Plugin{}, err
+	}
+
+	return data, nil
+}
+
+func (s *grafanaComSource) DownloadOptions(_ context.Context, pluginID string, v *Version, compatOpts CompatOpts) (*PluginDownloadOptions, error) {
+	// Plugins which are downloaded just as sourcecode zipball from GitHub do not have checksum
+	var checksum string
+	if v.Arch != nil {
+		archMeta, exists := v.Arch[compatOpts.OSAndArch()]
+		if !exists {
+			archMeta = v.Arch["any"]
+		}
+		checksum = archMeta.SHA256
+	}
+
+	return &PluginDownloadOptions{
+		Version:      v.Version,
+		Checksum:     checksum,
+		PluginZipURL: fmt.Sprintf("%s/%s/versions/%s/download", s.baseURL, pluginID, v.Version),
+	}, nil
+}
+
+// sortVersions sorts the versions of the plugin, the newest first. The versions that are not semantic versions are
+// sorted last.
+func sortVersions(plugin *Plugin) {
+	parsed := make(map[string]*version.Version, len(plugin.Versions))
+	for _, v := range plugin.Versions {
+		if sv, err := version.NewVersion(v.Version); err == nil {
+			parsed[v.Version] = sv
+		}
+	}
+	sort.SliceStable(plugin.Versions, func(i, j int) bool {
+		vi, vj := parsed[plugin.Versions[i].Version], parsed[plugin.Versions[j].Version]
+		if vi == nil || vj == nil {
+			return vj == nil && vi != nil
+		}
+		return vi.GreaterThan(vj)
+	})
+}
@@ -215,6 +215,20 @@ func (s *Server) Shutdown(ctx context.Context, reason string) error {
 	return err
 }
 
+// ReloadSettings reads the configuration files again and applies the changed settings that don't
+// require a restart, the other changed settings are logged.
+func (s *Server) ReloadSettings() error {
+	report, err := s.cfg.Reload()
+	if err != nil {
+		return err
+	}
+	s.log.Info("Reloaded settings", "applied", report.Applied)
+	if len(report.RequiresRestart) > 0 {
+		s.log.Warn("Changed settings require a restart", "settings", report.RequiresRestart)
+	}
+	return nil
+}
+
 // writePIDFile retrieves the current process ID and writes it to file.
 func (s *Server) writePIDFile() error {
 	if s.pidFile == "" {
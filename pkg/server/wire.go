@@ -38,6 +38,9 @@ import (
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/annotations/annotationsimpl"
 	"github.com/grafana/grafana/pkg/services/apikey/apikeyimpl"
+	"github.com/grafana/grafana/pkg/services/asyncquery"
+	"github.com/grafana/grafana/pkg/services/asyncquery/asyncqueryimpl"
+	"github.com/grafana/grafana/pkg/services/auth/identitytoken"
 	"github.com/grafana/grafana/pkg/services/auth/jwt"
 	"github.com/grafana/grafana/pkg/services/authn/authnimpl"
 	"github.com/grafana/grafana/pkg/services/cleanup"
@@ -54,12 +57,16 @@ import (
 	"github.com/grafana/grafana/pkg/services/dashboardversion/dashverimpl"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	dscapabilities "github.com/grafana/grafana/pkg/services/datasources/capabilities"
+	dsdrift "github.com/grafana/grafana/pkg/services/datasources/drift"
 	datasourceservice "github.com/grafana/grafana/pkg/services/datasources/service"
+	dsusage "github.com/grafana/grafana/pkg/services/datasources/usage"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	encryptionservice "github.com/grafana/grafana/pkg/services/encryption/service"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/folder/folderimpl"
+	"github.com/grafana/grafana/pkg/services/foldersettings"
 	"github.com/grafana/grafana/pkg/services/grpcserver"
 	grpccontext "github.com/grafana/grafana/pkg/services/grpcserver/context"
 	"github.com/grafana/grafana/pkg/services/grpcserver/interceptors"
@@ -67,6 +74,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/hooks"
 	ldapapi "github.com/grafana/grafana/pkg/services/ldap/api"
 	ldapservice "github.com/grafana/grafana/pkg/services/ldap/service"
+	"github.com/grafana/grafana/pkg/services/ldap/teamsync"
 	"github.com/grafana/grafana/pkg/services/libraryelements"
 	"github.com/grafana/grafana/pkg/services/librarypanels"
 	"github.com/grafana/grafana/pkg/services/live"
@@ -96,9 +104,15 @@ import (
 	publicdashboardsStore "github.com/grafana/grafana/pkg/services/publicdashboards/database"
 	publicdashboardsService "github.com/grafana/grafana/pkg/services/publicdashboards/service"
 	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
+	"github.com/grafana/grafana/pkg/services/querycapture"
+	"github.com/grafana/grafana/pkg/services/queryexport"
 	"github.com/grafana/grafana/pkg/services/queryhistory"
+	"github.com/grafana/grafana/pkg/services/queryinsights"
 	"github.com/grafana/grafana/pkg/services/quota/quotaimpl"
+	"github.com/grafana/grafana/pkg/services/recording"
 	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/savedsearch"
 	"github.com/grafana/grafana/pkg/services/search"
 	"github.com/grafana/grafana/pkg/services/searchV2"
 	"github.com/grafana/grafana/pkg/services/secrets"
@@ -170,6 +184,8 @@ var wireBasicSet = wire.NewSet(
 	New,
 	api.ProvideHTTPServer,
 	query.ProvideService,
+	asyncqueryimpl.ProvideService,
+	wire.Bind(new(asyncquery.Service), new(*asyncqueryimpl.Service)),
 	wire.Bind(new(query.Service), new(*query.ServiceImpl)),
 	bus.ProvideBus,
 	wire.Bind(new(bus.Bus), new(*bus.InProcBus)),
@@ -208,6 +224,25 @@ var wireBasicSet = wire.NewSet(
 	wire.Bind(new(shorturls.Service), new(*shorturlimpl.ShortURLService)),
 	queryhistory.ProvideService,
 	wire.Bind(new(queryhistory.Service), new(*queryhistory.QueryHistoryService)),
+	savedsearch.ProvideService,
+	wire.Bind(new(savedsearch.Service), new(*savedsearch.SavedSearchService)),
+	foldersettings.ProvideService,
+	wire.Bind(new(foldersettings.Service), new(*foldersettings.FolderSettingsService)),
+	queryinsights.ProvideService,
+	wire.Bind(new(queryinsights.Service), new(*queryinsights.QueryInsightsService)),
+	querycapture.ProvideService,
+	wire.Bind(new(querycapture.Service), new(*querycapture.QueryCaptureService)),
+	dsusage.ProvideService,
+	wire.Bind(new(dsusage.Service), new(*dsusage.UsageService)),
+	dsdrift.ProvideService,
+	wire.Bind(new(dsdrift.Service), new(*dsdrift.DriftService)),
+	dscapabilities.ProvideService,
+	wire.Bind(new(dscapabilities.Service), new(*dscapabilities.CapabilitiesService)),
+	recording.ProvideService,
+	queryexport.ProvideService,
+	queryaudit.ProvideService,
+	wire.Bind(new(queryaudit.Service), new(*queryaudit.QueryAuditService)),
+	identitytoken.ProvideService,
 	correlations.ProvideService,
 	wire.Bind(new(correlations.Service), new(*correlations.CorrelationsService)),
 	quotaimpl.ProvideService,
@@ -246,6 +281,7 @@ var wireBasicSet = wire.NewSet(
 	metrics.ProvideService,
 	testdatasource.ProvideService,
 	ldapapi.ProvideService,
+	teamsync.ProvideService,
 	opentsdb.ProvideService,
 	social.ProvideService,
 	influxdb.ProvideService,
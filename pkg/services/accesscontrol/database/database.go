@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
@@ -27,16 +28,19 @@ func (s *AccessControlStore) GetUserPermissions(ctx context.Context, query acces
 
 		filter, params := accesscontrol.UserRolesFilter(query.OrgID, query.UserID, query.TeamIDs, query.Roles)
 
+		notExpired, notExpiredParams := accesscontrol.PermissionNotExpiredFilter("permission.expires")
 		q := `
 		SELECT
 			permission.action,
 			permission.scope
 			FROM permission
 			INNER JOIN role ON role.id = permission.role_id
-		` + filter
+		` + filter + `
+			WHERE ` + notExpired
+		params = append(params, notExpiredParams...)
 
 		if query.RolePrefix != "" {
-			q += " WHERE role.name LIKE ?"
+			q += " AND role.name LIKE ?"
 			params = append(params, query.RolePrefix+"%")
 		}
 
@@ -66,21 +70,21 @@ func (s *AccessControlStore) SearchUsersPermissions(ctx context.Context, orgID i
 			action,
 			scope
 		FROM (
-			SELECT ur.user_id, ur.org_id, p.action, p.scope
+			SELECT ur.user_id, ur.org_id, p.action, p.scope, p.expires
 				FROM permission AS p
 				INNER JOIN user_role AS ur on ur.role_id = p.role_id
 			UNION ALL
-				SELECT tm.user_id, tr.org_id, p.action, p.scope
+				SELECT tm.user_id, tr.org_id, p.action, p.scope, p.expires
 					FROM permission AS p
 					INNER JOIN team_role AS tr ON tr.role_id = p.role_id
 					INNER JOIN team_member AS tm ON tm.team_id = tr.team_id
 			UNION ALL
-				SELECT ou.user_id, br.org_id, p.action, p.scope
+				SELECT ou.user_id, br.org_id, p.action, p.scope, p.expires
 					FROM permission AS p
 					INNER JOIN builtin_role AS br ON br.role_id = p.role_id
 					INNER JOIN org_user AS ou ON ou.role = br.role
 			UNION ALL
-				SELECT sa.user_id, br.org_id, p.action, p.scope
+				SELECT sa.user_id, br.org_id, p.action, p.scope, p.expires
 					FROM permission AS p
 					INNER JOIN builtin_role AS br ON br.role_id = p.role_id
 					INNER JOIN (
@@ -94,6 +98,10 @@ func (s *AccessControlStore) SearchUsersPermissions(ctx context.Context, orgID i
 
 		params := []interface{}{accesscontrol.RoleGrafanaAdmin, accesscontrol.GlobalOrgID, orgID}
 
+		notExpired, notExpiredParams := accesscontrol.PermissionNotExpiredFilter("expires")
+		q += ` AND ` + notExpired
+		params = append(params, notExpiredParams...)
+
 		if options.ActionPrefix != "" {
 			q += ` AND action LIKE ?`
 			params = append(params, options.ActionPrefix+"%")
@@ -231,3 +239,47 @@ func (s *AccessControlStore) DeleteUserPermissions(ctx context.Context, orgID, u
 	})
 	return err
 }
+
+const deleteExpiredBatchSize = 500
+
+// ExpiredPermission is a time-bound permission deleted once expired
+type ExpiredPermission struct {
+	ID       int64  `xorm:"id"`
+	OrgID    int64  `xorm:"org_id"`
+	RoleName string `xorm:"role_name"`
+	Action   string `xorm:"action"`
+	Scope    string `xorm:"scope"`
+	Expires  int64  `xorm:"expires"`
+}
+
+// DeleteExpiredPermissions deletes the time-bound permissions expired before now and returns them
+func (s *AccessControlStore) DeleteExpiredPermissions(ctx context.Context, now time.Time) ([]ExpiredPermission, error) {
+	var expired []ExpiredPermission
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if err := sess.SQL(`
+		SELECT p.id, r.org_id, r.name AS role_name, p.action, p.scope, p.expires
+			FROM permission AS p
+			INNER JOIN role AS r ON r.id = p.role_id
+			WHERE p.expires > 0 AND p.expires <= ?
+		`, now.Unix()).Find(&expired); err != nil {
+			return err
+		}
+
+		for start := 0; start < len(expired); start += deleteExpiredBatchSize {
+			end := start + deleteExpiredBatchSize
+			if end > len(expired) {
+				end = len(expired)
+			}
+			query := "DELETE FROM permission WHERE id IN(?" + strings.Repeat(",?", end-start-1) + ")"
+			args := []interface{}{query}
+			for _, p := range expired[start:end] {
+				args = append(args, p.ID)
+			}
+			if _, err := sess.Exec(args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return expired, err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -621,3 +622,98 @@ func TestAccessControlStore_GetUsersBasicRoles(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessControlStore_DeleteExpiredPermissions(t *testing.T) {
+	store, permissionsStore, sql, teamSvc, _ := setupTestEnv(t)
+	user, _ := createUserAndTeam(t, sql, teamSvc, 1)
+	now := time.Now()
+
+	// an expired grant, a grant expiring later and a permanent one
+	for id, expires := range map[string]time.Time{"1": now.Add(-time.Minute), "2": now.Add(time.Hour), "3": {}} {
+		_, err := permissionsStore.SetUserResourcePermission(context.Background(), 1, accesscontrol.User{ID: user.ID}, rs.SetResourcePermissionCommand{
+			Actions:           []string{"dashboards:write"},
+			Resource:          "dashboards",
+			ResourceID:        id,
+			ResourceAttribute: "uid",
+			Expires:           expires,
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	query := accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: user.ID}
+	permissions, err := store.GetUserPermissions(context.Background(), query)
+	require.NoError(t, err)
+	require.Len(t, permissions, 2)
+	for _, p := range permissions {
+		assert.NotEqual(t, "dashboards:uid:1", p.Scope)
+	}
+
+	expired, err := store.DeleteExpiredPermissions(context.Background(), now)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, int64(1), expired[0].OrgID)
+	assert.Equal(t, "dashboards:write", expired[0].Action)
+	assert.Equal(t, "dashboards:uid:1", expired[0].Scope)
+	assert.Equal(t, fmt.Sprintf("managed:users:%d:permissions", user.ID), expired[0].RoleName)
+
+	expired, err = store.DeleteExpiredPermissions(context.Background(), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, "dashboards:uid:2", expired[0].Scope)
+
+	permissions, err = store.GetUserPermissions(context.Background(), query)
+	require.NoError(t, err)
+	require.Len(t, permissions, 1)
+	assert.Equal(t, "dashboards:uid:3", permissions[0].Scope)
+}
+
+func TestAccessControlStore_TimeBoundPermissionOverPermanent(t *testing.T) {
+	store, permissionsStore, sql, teamSvc, _ := setupTestEnv(t)
+	user, _ := createUserAndTeam(t, sql, teamSvc, 1)
+	now := time.Now()
+	setPermission := func(actions []string, expires time.Time) *accesscontrol.ResourcePermission {
+		t.Helper()
+		p, err := permissionsStore.SetUserResourcePermission(context.Background(), 1, accesscontrol.User{ID: user.ID}, rs.SetResourcePermissionCommand{
+			Actions:           actions,
+			Resource:          "dashboards",
+			ResourceID:        "1",
+			ResourceAttribute: "uid",
+			Expires:           expires,
+		}, nil)
+		require.NoError(t, err)
+		return p
+	}
+	actions := func() []string {
+		t.Helper()
+		permissions, err := store.GetUserPermissions(context.Background(), accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: user.ID})
+		require.NoError(t, err)
+		var result []string
+		for _, p := range permissions {
+			result = append(result, p.Action)
+		}
+		return result
+	}
+
+	// a permanent View, then an Edit for an hour
+	setPermission([]string{"dashboards:read"}, time.Time{})
+	granted := setPermission([]string{"dashboards:read", "dashboards:write"}, now.Add(time.Hour))
+	assert.ElementsMatch(t, []string{"dashboards:read", "dashboards:write"}, granted.Actions)
+	assert.Equal(t, now.Add(time.Hour).Unix(), granted.Expires.Unix())
+	assert.ElementsMatch(t, []string{"dashboards:read", "dashboards:write"}, actions())
+
+	// the View is granted again once the Edit expired
+	expired, err := store.DeleteExpiredPermissions(context.Background(), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, "dashboards:write", expired[0].Action)
+	assert.ElementsMatch(t, []string{"dashboards:read"}, actions())
+
+	// a permanent permission replaces the time-bound one
+	setPermission([]string{"dashboards:read", "dashboards:write"}, now.Add(time.Hour))
+	permanent := setPermission([]string{"dashboards:read", "dashboards:write"}, time.Time{})
+	assert.True(t, permanent.Expires.IsZero())
+	expired, err = store.DeleteExpiredPermissions(context.Background(), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	assert.ElementsMatch(t, []string{"dashboards:read", "dashboards:write"}, actions())
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/user"
 )
@@ -126,6 +127,11 @@ func SetAcceptListForTest(list map[string]struct{}) func() {
 	}
 }
 
+// PermissionNotExpiredFilter filters out the time-bound permissions that expired, they are only deleted periodically
+func PermissionNotExpiredFilter(column string) (string, []interface{}) {
+	return "(" + column + " = 0 OR " + column + " > ?)", []interface{}{time.Now().Unix()}
+}
+
 func UserRolesFilter(orgID, userID int64, teamIDs []int64, roles []string) (string, []interface{}) {
 	var params []interface{}
 	builder := strings.Builder{}
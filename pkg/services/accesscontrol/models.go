@@ -175,6 +175,8 @@ type Permission struct {
 	RoleID int64  `json:"-" xorm:"role_id"`
 	Action string `json:"action"`
 	Scope  string `json:"scope"`
+	// Expires is the unix time at which a time-bound permission expires, 0 when it doesn't expire
+	Expires int64 `json:"expires,omitempty" xorm:"expires"`
 
 	Updated time.Time `json:"updated"`
 	Created time.Time `json:"created"`
@@ -211,8 +213,10 @@ type ResourcePermission struct {
 	BuiltInRole string
 	IsManaged   bool
 	IsInherited bool
-	Created     time.Time
-	Updated     time.Time
+	// Expires is zero when the permission doesn't expire
+	Expires time.Time
+	Created time.Time
+	Updated time.Time
 }
 
 func (p *ResourcePermission) Contains(targetActions []string) bool {
@@ -243,6 +247,8 @@ type SetResourcePermissionCommand struct {
 	TeamID      int64  `json:"teamId,omitempty"`
 	BuiltinRole string `json:"builtInRole,omitempty"`
 	Permission  string `json:"permission"`
+	// Expires is the time at which the permission is removed, the permission doesn't expire when it is not set
+	Expires time.Time `json:"expires"`
 }
 
 const (
@@ -309,7 +315,8 @@ const (
 	ActionServerStatsRead = "server.stats:read"
 
 	// Settings actions
-	ActionSettingsRead = "settings:read"
+	ActionSettingsRead   = "settings:read"
+	ActionSettingsReload = "settings:reload"
 
 	// Datasources actions
 	ActionDatasourcesExplore = "datasources:explore"
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
@@ -89,6 +90,8 @@ type resourcePermissionDTO struct {
 	BuiltInRole   string   `json:"builtInRole,omitempty"`
 	Actions       []string `json:"actions"`
 	Permission    string   `json:"permission"`
+	// Expires is only set for the time-bound permissions
+	Expires *time.Time `json:"expires,omitempty"`
 }
 
 func (a *api) getPermissions(c *contextmodel.ReqContext) response.Response {
@@ -115,6 +118,11 @@ func (a *api) getPermissions(c *contextmodel.ReqContext) response.Response {
 				teamAvatarUrl = dtos.GetGravatarUrlWithDefault(p.TeamEmail, p.Team)
 			}
 
+			var expires *time.Time
+			if !p.Expires.IsZero() {
+				expires = &p.Expires
+			}
+
 			dto = append(dto, resourcePermissionDTO{
 				ID:            p.ID,
 				RoleName:      p.RoleName,
@@ -129,6 +137,7 @@ func (a *api) getPermissions(c *contextmodel.ReqContext) response.Response {
 				Permission:    permission,
 				IsManaged:     p.IsManaged,
 				IsInherited:   p.IsInherited,
+				Expires:       expires,
 			})
 		}
 	}
@@ -138,6 +147,8 @@ func (a *api) getPermissions(c *contextmodel.ReqContext) response.Response {
 
 type setPermissionCommand struct {
 	Permission string `json:"permission"`
+	// Expires is the time at which the permission is removed, the permission doesn't expire when it is not set
+	Expires time.Time `json:"expires"`
 }
 
 type setPermissionsCommand struct {
@@ -156,7 +167,7 @@ func (a *api) setUserPermission(c *contextmodel.ReqContext) response.Response {
 		return response.Error(http.StatusBadRequest, "bad request data", err)
 	}
 
-	_, err = a.service.SetUserPermission(c.Req.Context(), c.OrgID, accesscontrol.User{ID: userID}, resourceID, cmd.Permission)
+	_, err = a.service.SetUserPermissionUntil(c.Req.Context(), c.OrgID, accesscontrol.User{ID: userID}, resourceID, cmd.Permission, cmd.Expires)
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "failed to set user permission", err)
 	}
@@ -176,7 +187,7 @@ func (a *api) setTeamPermission(c *contextmodel.ReqContext) response.Response {
 		return response.Error(http.StatusBadRequest, "bad request data", err)
 	}
 
-	_, err = a.service.SetTeamPermission(c.Req.Context(), c.OrgID, teamID, resourceID, cmd.Permission)
+	_, err = a.service.SetTeamPermissionUntil(c.Req.Context(), c.OrgID, teamID, resourceID, cmd.Permission, cmd.Expires)
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "failed to set team permission", err)
 	}
@@ -193,7 +204,7 @@ func (a *api) setBuiltinRolePermission(c *contextmodel.ReqContext) response.Resp
 		return response.Error(http.StatusBadRequest, "bad request data", err)
 	}
 
-	_, err := a.service.SetBuiltInRolePermission(c.Req.Context(), c.OrgID, builtInRole, resourceID, cmd.Permission)
+	_, err := a.service.SetBuiltInRolePermissionUntil(c.Req.Context(), c.OrgID, builtInRole, resourceID, cmd.Permission, cmd.Expires)
 	if err != nil {
 		return response.Error(http.StatusBadRequest, "failed to set role permission", err)
 	}
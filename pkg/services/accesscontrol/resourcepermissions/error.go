@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrInvalidPermission = errors.New("invalid permission")
 	ErrInvalidAssignment = errors.New("invalid assignment")
+	ErrInvalidExpiry     = errors.New("invalid expiry, the permission must expire in the future")
 )
@@ -1,6 +1,8 @@
 package resourcepermissions
 
 import (
+	"time"
+
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/user"
 )
@@ -11,6 +13,8 @@ type SetResourcePermissionCommand struct {
 	ResourceID        string
 	ResourceAttribute string
 	Permission        string
+	// Expires is zero when the permission doesn't expire
+	Expires time.Time
 }
 
 type SetResourcePermissionsCommand struct {
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/appcontext"
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/licensing"
 	"github.com/grafana/grafana/pkg/services/org"
@@ -84,6 +87,7 @@ func New(
 		service:     service,
 		teamService: teamService,
 		userService: userService,
+		auditLog:    log.New("accesscontrol.audit"),
 	}
 
 	s.api = newApi(ac, router, s)
@@ -112,6 +116,8 @@ type Service struct {
 	sqlStore    db.DB
 	teamService team.Service
 	userService user.Service
+	// auditLog records the grants of time-bound permissions, their expiry is recorded by the cleanup service
+	auditLog log.Logger
 }
 
 func (s *Service) GetPermissions(ctx context.Context, user *user.SignedInUser, resourceID string) ([]accesscontrol.ResourcePermission, error) {
@@ -137,11 +143,21 @@ func (s *Service) GetPermissions(ctx context.Context, user *user.SignedInUser, r
 }
 
 func (s *Service) SetUserPermission(ctx context.Context, orgID int64, user accesscontrol.User, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
+	return s.SetUserPermissionUntil(ctx, orgID, user, resourceID, permission, time.Time{})
+}
+
+// SetUserPermissionUntil sets a permission on the resource for a user which expires at expires, the permission
+// doesn't expire when expires is zero
+func (s *Service) SetUserPermissionUntil(ctx context.Context, orgID int64, user accesscontrol.User, resourceID, permission string, expires time.Time) (*accesscontrol.ResourcePermission, error) {
 	actions, err := s.mapPermission(permission)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := validateExpiry(permission, expires); err != nil {
+		return nil, err
+	}
+
 	if err := s.validateResource(ctx, orgID, resourceID); err != nil {
 		return nil, err
 	}
@@ -150,21 +166,38 @@ func (s *Service) SetUserPermission(ctx context.Context, orgID int64, user acces
 		return nil, err
 	}
 
-	return s.store.SetUserResourcePermission(ctx, orgID, user, SetResourcePermissionCommand{
+	p, err := s.store.SetUserResourcePermission(ctx, orgID, user, SetResourcePermissionCommand{
 		Actions:           actions,
 		Permission:        permission,
 		Resource:          s.options.Resource,
 		ResourceID:        resourceID,
 		ResourceAttribute: s.options.ResourceAttribute,
+		Expires:           expires,
 	}, s.options.OnSetUser)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logTimeBoundGrant(ctx, orgID, "userId", user.ID, resourceID, permission, expires)
+	return p, nil
 }
 
 func (s *Service) SetTeamPermission(ctx context.Context, orgID, teamID int64, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
+	return s.SetTeamPermissionUntil(ctx, orgID, teamID, resourceID, permission, time.Time{})
+}
+
+// SetTeamPermissionUntil sets a permission on the resource for a team which expires at expires, the permission
+// doesn't expire when expires is zero
+func (s *Service) SetTeamPermissionUntil(ctx context.Context, orgID, teamID int64, resourceID, permission string, expires time.Time) (*accesscontrol.ResourcePermission, error) {
 	actions, err := s.mapPermission(permission)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := validateExpiry(permission, expires); err != nil {
+		return nil, err
+	}
+
 	if err := s.validateTeam(ctx, orgID, teamID); err != nil {
 		return nil, err
 	}
@@ -173,21 +206,38 @@ func (s *Service) SetTeamPermission(ctx context.Context, orgID, teamID int64, re
 		return nil, err
 	}
 
-	return s.store.SetTeamResourcePermission(ctx, orgID, teamID, SetResourcePermissionCommand{
+	p, err := s.store.SetTeamResourcePermission(ctx, orgID, teamID, SetResourcePermissionCommand{
 		Actions:           actions,
 		Permission:        permission,
 		Resource:          s.options.Resource,
 		ResourceID:        resourceID,
 		ResourceAttribute: s.options.ResourceAttribute,
+		Expires:           expires,
 	}, s.options.OnSetTeam)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logTimeBoundGrant(ctx, orgID, "teamId", teamID, resourceID, permission, expires)
+	return p, nil
 }
 
 func (s *Service) SetBuiltInRolePermission(ctx context.Context, orgID int64, builtInRole, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
+	return s.SetBuiltInRolePermissionUntil(ctx, orgID, builtInRole, resourceID, permission, time.Time{})
+}
+
+// SetBuiltInRolePermissionUntil sets a permission on the resource for a built-in role which expires at expires, the
+// permission doesn't expire when expires is zero
+func (s *Service) SetBuiltInRolePermissionUntil(ctx context.Context, orgID int64, builtInRole, resourceID, permission string, expires time.Time) (*accesscontrol.ResourcePermission, error) {
 	actions, err := s.mapPermission(permission)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := validateExpiry(permission, expires); err != nil {
+		return nil, err
+	}
+
 	if err := s.validateBuiltinRole(ctx, builtInRole); err != nil {
 		return nil, err
 	}
@@ -196,13 +246,20 @@ func (s *Service) SetBuiltInRolePermission(ctx context.Context, orgID int64, bui
 		return nil, err
 	}
 
-	return s.store.SetBuiltInResourcePermission(ctx, orgID, builtInRole, SetResourcePermissionCommand{
+	p, err := s.store.SetBuiltInResourcePermission(ctx, orgID, builtInRole, SetResourcePermissionCommand{
 		Actions:           actions,
 		Permission:        permission,
 		Resource:          s.options.Resource,
 		ResourceID:        resourceID,
 		ResourceAttribute: s.options.ResourceAttribute,
+		Expires:           expires,
 	}, s.options.OnSetBuiltInRole)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logTimeBoundGrant(ctx, orgID, "builtInRole", builtInRole, resourceID, permission, expires)
+	return p, nil
 }
 
 func (s *Service) SetPermissions(
@@ -234,6 +291,10 @@ func (s *Service) SetPermissions(
 			return nil, err
 		}
 
+		if err := validateExpiry(cmd.Permission, cmd.Expires); err != nil {
+			return nil, err
+		}
+
 		dbCommands = append(dbCommands, SetResourcePermissionsCommand{
 			User:        accesscontrol.User{ID: cmd.UserID},
 			TeamID:      cmd.TeamID,
@@ -244,15 +305,45 @@ func (s *Service) SetPermissions(
 				ResourceID:        resourceID,
 				ResourceAttribute: s.options.ResourceAttribute,
 				Permission:        cmd.Permission,
+				Expires:           cmd.Expires,
 			},
 		})
 	}
 
-	return s.store.SetResourcePermissions(ctx, orgID, dbCommands, ResourceHooks{
+	permissions, err := s.store.SetResourcePermissions(ctx, orgID, dbCommands, ResourceHooks{
 		User:        s.options.OnSetUser,
 		Team:        s.options.OnSetTeam,
 		BuiltInRole: s.options.OnSetBuiltInRole,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cmd := range commands {
+		if cmd.UserID != 0 {
+			s.logTimeBoundGrant(ctx, orgID, "userId", cmd.UserID, resourceID, cmd.Permission, cmd.Expires)
+		} else if cmd.TeamID != 0 {
+			s.logTimeBoundGrant(ctx, orgID, "teamId", cmd.TeamID, resourceID, cmd.Permission, cmd.Expires)
+		} else {
+			s.logTimeBoundGrant(ctx, orgID, "builtInRole", cmd.BuiltinRole, resourceID, cmd.Permission, cmd.Expires)
+		}
+	}
+	return permissions, nil
+}
+
+// logTimeBoundGrant records the grant of a permission which expires, with the user granting it
+func (s *Service) logTimeBoundGrant(ctx context.Context, orgID int64, assigneeKey string, assignee interface{}, resourceID, permission string, expires time.Time) {
+	if expires.IsZero() {
+		return
+	}
+
+	var grantedBy string
+	if u, err := appcontext.User(ctx); err == nil {
+		grantedBy = u.Login
+	}
+	s.auditLog.Info("Time-bound permission granted", "orgId", orgID, assigneeKey, assignee,
+		"resource", s.options.Resource, "resourceId", resourceID, "permission", permission,
+		"expires", expires, "grantedBy", grantedBy)
 }
 
 func (s *Service) MapActions(permission accesscontrol.ResourcePermission) string {
@@ -277,6 +368,17 @@ func (s *Service) mapPermission(permission string) ([]string, error) {
 	return nil, ErrInvalidPermission
 }
 
+// validateExpiry checks that a time-bound permission expires in the future, a removed permission has no expiry
+func validateExpiry(permission string, expires time.Time) error {
+	if expires.IsZero() {
+		return nil
+	}
+	if permission == "" || !expires.After(time.Now()) {
+		return ErrInvalidExpiry
+	}
+	return nil
+}
+
 func (s *Service) validateResource(ctx context.Context, orgID int64, resourceID string) error {
 	if s.options.ResourceValidator != nil {
 		return s.options.ResourceValidator(ctx, orgID, resourceID)
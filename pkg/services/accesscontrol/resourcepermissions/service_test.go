@@ -3,6 +3,7 @@ package resourcepermissions
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -203,6 +204,60 @@ func TestService_SetPermissions(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			desc: "should set time-bound permissions",
+			options: Options{
+				Resource: "dashboards",
+				Assignments: Assignments{
+					Users:        true,
+					Teams:        true,
+					BuiltInRoles: true,
+				},
+				PermissionsToActions: map[string][]string{
+					"View": {"dashboards:read"},
+				},
+			},
+			commands: []accesscontrol.SetResourcePermissionCommand{
+				{UserID: 1, Permission: "View", Expires: time.Now().Add(time.Hour)},
+				{TeamID: 1, Permission: "View"},
+			},
+		},
+		{
+			desc: "should return error for expiry in the past",
+			options: Options{
+				Resource: "dashboards",
+				Assignments: Assignments{
+					Users:        true,
+					Teams:        true,
+					BuiltInRoles: true,
+				},
+				PermissionsToActions: map[string][]string{
+					"View": {"dashboards:read"},
+				},
+			},
+			commands: []accesscontrol.SetResourcePermissionCommand{
+				{UserID: 1, Permission: "View", Expires: time.Now().Add(-time.Hour)},
+			},
+			expectErr: true,
+		},
+		{
+			desc: "should return error for expiry of a removed permission",
+			options: Options{
+				Resource: "dashboards",
+				Assignments: Assignments{
+					Users:        true,
+					Teams:        true,
+					BuiltInRoles: true,
+				},
+				PermissionsToActions: map[string][]string{
+					"View": {"dashboards:read"},
+				},
+			},
+			commands: []accesscontrol.SetResourcePermissionCommand{
+				{UserID: 1, Permission: "", Expires: time.Now().Add(time.Hour)},
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -34,6 +34,7 @@ type flatResourcePermission struct {
 	TeamEmail   string
 	Team        string
 	BuiltInRole string
+	Expires     int64
 	Created     time.Time
 	Updated     time.Time
 }
@@ -222,12 +223,25 @@ func (s *store) setResourcePermission(
 		missing[a] = struct{}{}
 	}
 
-	var remove []int64
+	var expires int64
+	if !cmd.Expires.IsZero() {
+		expires = cmd.Expires.Unix()
+	}
+
+	// a time-bound permission is granted in its own rows, the permanent ones are kept so that the previous
+	// permission is granted again once it expires. A permanent permission replaces all the rows.
+	var remove, update []int64
 	for _, p := range current {
-		if _, ok := missing[p.Action]; ok {
+		_, ok := missing[p.Action]
+		if ok {
 			delete(missing, p.Action)
-		} else if !ok {
+		}
+		switch {
+		case expires > 0 && p.Expires == 0:
+		case !ok:
 			remove = append(remove, p.ID)
+		case p.Expires != expires:
+			update = append(update, p.ID)
 		}
 	}
 
@@ -235,7 +249,11 @@ func (s *store) setResourcePermission(
 		return nil, err
 	}
 
-	if err := s.createPermissions(sess, role.ID, cmd.Resource, cmd.ResourceID, cmd.ResourceAttribute, missing); err != nil {
+	if err := updatePermissionsExpiry(sess, update, expires); err != nil {
+		return nil, err
+	}
+
+	if err := s.createPermissions(sess, role.ID, cmd.Resource, cmd.ResourceID, cmd.ResourceAttribute, expires, missing); err != nil {
 		return nil, err
 	}
 
@@ -351,6 +369,10 @@ func (s *store) getResourcePermissions(sess *db.Session, orgID int64, query GetR
 		args = append(args, a)
 	}
 
+	notExpired, notExpiredArgs := accesscontrol.PermissionNotExpiredFilter("p.expires")
+	where += " AND " + notExpired
+	args = append(args, notExpiredArgs...)
+
 	initialLength := len(args)
 	userQuery := userSelect + userFrom + where
 	if query.EnforceAccessControl {
@@ -449,7 +471,16 @@ func flatPermissionsToResourcePermission(scope string, permissions []flatResourc
 		actions = append(actions, p.Action)
 	}
 
+	// the permission expires when some of its actions are time-bound, the permanent ones are the permission granted
+	// before
 	first := permissions[0]
+	var expires time.Time
+	for _, p := range permissions {
+		if p.Expires > 0 {
+			expires = time.Unix(p.Expires, 0)
+			break
+		}
+	}
 	return &accesscontrol.ResourcePermission{
 		ID:          first.ID,
 		RoleName:    first.RoleName,
@@ -462,6 +493,7 @@ func flatPermissionsToResourcePermission(scope string, permissions []flatResourc
 		TeamEmail:   first.TeamEmail,
 		Team:        first.Team,
 		BuiltInRole: first.BuiltInRole,
+		Expires:     expires,
 		Created:     first.Created,
 		Updated:     first.Updated,
 		IsManaged:   first.IsManaged(scope),
@@ -611,7 +643,7 @@ func (s *store) getPermissions(sess *db.Session, resource, resourceID, resourceA
 	return result, nil
 }
 
-func (s *store) createPermissions(sess *db.Session, roleID int64, resource, resourceID, resourceAttribute string, actions map[string]struct{}) error {
+func (s *store) createPermissions(sess *db.Session, roleID int64, resource, resourceID, resourceAttribute string, expires int64, actions map[string]struct{}) error {
 	if len(actions) == 0 {
 		return nil
 	}
@@ -619,6 +651,7 @@ func (s *store) createPermissions(sess *db.Session, roleID int64, resource, reso
 	for action := range actions {
 		p := managedPermission(action, resource, resourceID, resourceAttribute)
 		p.RoleID = roleID
+		p.Expires = expires
 		p.Created = time.Now()
 		p.Updated = time.Now()
 		permissions = append(permissions, p)
@@ -650,6 +683,22 @@ func deletePermissions(sess *db.Session, ids []int64) error {
 	return nil
 }
 
+func updatePermissionsExpiry(sess *db.Session, ids []int64, expires int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rawSQL := "UPDATE permission SET expires = ?, updated = ? WHERE id IN(?" + strings.Repeat(",?", len(ids)-1) + ")"
+	args := make([]interface{}, 0, len(ids)+3)
+	args = append(args, rawSQL, expires, time.Now())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	_, err := sess.Exec(args...)
+	return err
+}
+
 func managedPermission(action, resource string, resourceID, resourceAttribute string) accesscontrol.Permission {
 	return accesscontrol.Permission{
 		Action: action,
@@ -91,6 +91,18 @@ var (
 		},
 	}
 
+	settingsReloaderRole = RoleDTO{
+		Name:        "fixed:settings:reloader",
+		DisplayName: "Setting reloader",
+		Description: "Reload the Grafana instance settings that can be applied without a restart.",
+		Group:       "Settings",
+		Permissions: []Permission{
+			{
+				Action: ActionSettingsReload,
+			},
+		},
+	}
+
 	statsReaderRole = RoleDTO{
 		Name:        "fixed:stats:reader",
 		DisplayName: "Statistics reader",
@@ -195,6 +207,10 @@ func DeclareFixedRoles(service Service) error {
 		Role:   SettingsReaderRole,
 		Grants: []string{RoleGrafanaAdmin},
 	}
+	settingsReloader := RoleRegistration{
+		Role:   settingsReloaderRole,
+		Grants: []string{RoleGrafanaAdmin},
+	}
 	statsReader := RoleRegistration{
 		Role:   statsReaderRole,
 		Grants: []string{RoleGrafanaAdmin},
@@ -209,7 +225,7 @@ func DeclareFixedRoles(service Service) error {
 	}
 
 	return service.DeclareFixedRoles(ldapReader, ldapWriter, orgUsersReader, orgUsersWriter,
-		settingsReader, statsReader, usersReader, usersWriter)
+		settingsReader, settingsReloader, statsReader, usersReader, usersWriter)
 }
 
 func ConcatPermissions(permissions ...[]Permission) []Permission {
@@ -30,9 +30,10 @@ func ProvideService(db db.DB, cfg *setting.Cfg, quotaService quota.Service) (api
 	}
 
 	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
-		TargetSrv:     apikey.QuotaTargetSrv,
-		DefaultLimits: defaultLimits,
-		Reporter:      s.Usage,
+		TargetSrv:         apikey.QuotaTargetSrv,
+		DefaultLimits:     defaultLimits,
+		Reporter:          s.Usage,
+		ReadDefaultLimits: readQuotaConfig,
 	}); err != nil {
 		return s, err
 	}
@@ -95,7 +96,7 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 		return limits, err
 	}
 
-	limits.Set(globalQuotaTag, cfg.Quota.Global.ApiKey)
-	limits.Set(orgQuotaTag, cfg.Quota.Org.ApiKey)
+	limits.Set(globalQuotaTag, cfg.QuotaSettings().Global.ApiKey)
+	limits.Set(orgQuotaTag, cfg.QuotaSettings().Org.ApiKey)
 	return limits, nil
 }
@@ -114,9 +114,11 @@ func (ss *sqlxStore) AddAPIKey(ctx context.Context, cmd *apikey.AddCommand) erro
 		ServiceAccountId: nil,
 		IsRevoked:        &isRevoked,
 	}
+	t.SetQueryOnlyDatasourceUIDs(cmd.QueryDatasourceUIDs)
+	t.SetBinding(cmd.AllowedCIDRs, cmd.ClientCertFingerprints)
 
 	t.ID, err = ss.sess.ExecWithReturningId(ctx,
-		`INSERT INTO api_key (org_id, name, role, "key", created, updated, expires, service_account_id, is_revoked) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, t.OrgID, t.Name, t.Role, t.Key, t.Created, t.Updated, t.Expires, t.ServiceAccountId, t.IsRevoked)
+		`INSERT INTO api_key (org_id, name, role, "key", created, updated, expires, service_account_id, is_revoked, query_datasource_uids, allowed_cidrs, client_cert_fingerprints) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, t.OrgID, t.Name, t.Role, t.Key, t.Created, t.Updated, t.Expires, t.ServiceAccountId, t.IsRevoked, t.QueryDatasourceUIDs, t.AllowedCIDRs, t.ClientCertFingerprints)
 	cmd.Result = &t
 	return err
 }
@@ -129,6 +129,8 @@ func (ss *sqlStore) AddAPIKey(ctx context.Context, cmd *apikey.AddCommand) error
 			ServiceAccountId: cmd.ServiceAccountID,
 			IsRevoked:        &isRevoked,
 		}
+		t.SetQueryOnlyDatasourceUIDs(cmd.QueryDatasourceUIDs)
+		t.SetBinding(cmd.AllowedCIDRs, cmd.ClientCertFingerprints)
 
 		if _, err := sess.Insert(&t); err != nil {
 			return fmt.Errorf("%s: %w", "failed to insert token", err)
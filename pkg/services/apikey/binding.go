@@ -0,0 +1,127 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrBoundNetwork     = errors.New("token is not allowed from this network")
+	ErrBoundCertificate = errors.New("token is not allowed without its client certificate")
+)
+
+// CheckBinding returns an error when the token is bound to networks and the client is not in one of them, or when it
+// is bound to client certificates and the request was not made with one of them. The client address is the address of
+// the connection, or the address in the X-Forwarded-For header set by the trusted proxies.
+func (k APIKey) CheckBinding(req *http.Request, trustedProxies []*net.IPNet) error {
+	if cidrs := k.BoundCIDRs(); len(cidrs) > 0 {
+		ip := ClientIP(req, trustedProxies)
+		if ip == nil || !containsIP(parseNetworks(cidrs), ip) {
+			return ErrBoundNetwork
+		}
+	}
+
+	if fingerprints := k.BoundClientCertFingerprints(); len(fingerprints) > 0 {
+		if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+			return ErrBoundCertificate
+		}
+		fingerprint := CertificateFingerprint(req.TLS.PeerCertificates[0])
+		for _, f := range fingerprints {
+			if f == fingerprint {
+				return nil
+			}
+		}
+		return ErrBoundCertificate
+	}
+	return nil
+}
+
+// ClientIP returns the address of the client of the request. The X-Forwarded-For header is only read when the
+// connection comes from a trusted proxy, its last address that is not a trusted proxy is the client.
+func ClientIP(req *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(trustedProxies, ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			// the addresses before an invalid one can't be trusted
+			return ip
+		}
+		ip = hop
+		if !containsIP(trustedProxies, hop) {
+			return hop
+		}
+	}
+	return ip
+}
+
+// CertificateFingerprint returns the SHA-256 fingerprint of the certificate, in lowercase hexadecimal
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeCIDR returns the network of a CIDR or a single address, or false when it is not valid
+func NormalizeCIDR(cidr string) (string, bool) {
+	cidr = strings.TrimSpace(cidr)
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return "", false
+		}
+		if ip.To4() != nil {
+			return ip.String() + "/32", true
+		}
+		return ip.String() + "/128", true
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", false
+	}
+	return network.String(), true
+}
+
+// NormalizeCertFingerprint returns a SHA-256 fingerprint in lowercase hexadecimal without separators, or false when
+// it is not valid
+func NormalizeCertFingerprint(fingerprint string) (string, bool) {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+	if len(normalized) != 2*sha256.Size {
+		return "", false
+	}
+	if _, err := hex.DecodeString(normalized); err != nil {
+		return "", false
+	}
+	return normalized, true
+}
+
+func parseNetworks(cidrs []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package apikey
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey_CheckBinding(t *testing.T) {
+	cert := &x509.Certificate{Raw: []byte("client certificate")}
+	other := &x509.Certificate{Raw: []byte("other certificate")}
+
+	key := APIKey{}
+	key.SetBinding([]string{"10.0.1.0/24", "2001:db8::/32"}, []string{CertificateFingerprint(cert)})
+
+	newRequest := func(remoteAddr string, certs ...*x509.Certificate) *http.Request {
+		req := &http.Request{RemoteAddr: remoteAddr, Header: http.Header{}}
+		if len(certs) > 0 {
+			req.TLS = &tls.ConnectionState{PeerCertificates: certs}
+		}
+		return req
+	}
+
+	assert.NoError(t, key.CheckBinding(newRequest("10.0.1.5:4242", cert), nil))
+	assert.NoError(t, key.CheckBinding(newRequest("[2001:db8::5]:4242", cert), nil))
+	assert.ErrorIs(t, key.CheckBinding(newRequest("10.0.2.5:4242", cert), nil), ErrBoundNetwork)
+	assert.ErrorIs(t, key.CheckBinding(newRequest("10.0.1.5:4242"), nil), ErrBoundCertificate)
+	assert.ErrorIs(t, key.CheckBinding(newRequest("10.0.1.5:4242", other), nil), ErrBoundCertificate)
+
+	assert.NoError(t, APIKey{}.CheckBinding(newRequest("192.168.1.1:4242"), nil))
+}
+
+func TestClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	trusted := []*net.IPNet{proxies}
+
+	newRequest := func(remoteAddr string, forwardedFor ...string) *http.Request {
+		req := &http.Request{RemoteAddr: remoteAddr, Header: http.Header{}}
+		for _, f := range forwardedFor {
+			req.Header.Add("X-Forwarded-For", f)
+		}
+		return req
+	}
+
+	tests := []struct {
+		desc     string
+		req      *http.Request
+		expected string
+	}{
+		{desc: "should use the address of the connection", req: newRequest("192.168.1.1:4242"), expected: "192.168.1.1"},
+		{desc: "should ignore the header set by an untrusted client", req: newRequest("192.168.1.1:4242", "10.0.1.1"), expected: "192.168.1.1"},
+		{desc: "should use the header set by a trusted proxy", req: newRequest("10.0.0.1:4242", "192.168.1.1"), expected: "192.168.1.1"},
+		{desc: "should skip the trusted proxies", req: newRequest("10.0.0.1:4242", "172.16.0.1, 192.168.1.1, 10.0.0.2"), expected: "192.168.1.1"},
+		{desc: "should read all the headers", req: newRequest("10.0.0.1:4242", "192.168.1.1", "10.0.0.2"), expected: "192.168.1.1"},
+		{desc: "should stop at an invalid address", req: newRequest("10.0.0.1:4242", "192.168.1.1, invalid, 10.0.0.2"), expected: "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClientIP(tt.req, trusted).String())
+		})
+	}
+}
+
+func TestNormalizeCIDR(t *testing.T) {
+	for cidr, expected := range map[string]string{
+		"10.0.1.7/24":  "10.0.1.0/24",
+		" 192.168.1.1": "192.168.1.1/32",
+		"2001:db8::1":  "2001:db8::1/128",
+	} {
+		normalized, ok := NormalizeCIDR(cidr)
+		assert.True(t, ok, cidr)
+		assert.Equal(t, expected, normalized)
+	}
+
+	for _, cidr := range []string{"", "10.0.0.0/33", "example.com"} {
+		_, ok := NormalizeCIDR(cidr)
+		assert.False(t, ok, cidr)
+	}
+}
+
+func TestNormalizeCertFingerprint(t *testing.T) {
+	fingerprint := CertificateFingerprint(&x509.Certificate{Raw: []byte("client certificate")})
+	var withColons string
+	for i := 0; i < len(fingerprint); i += 2 {
+		if i > 0 {
+			withColons += ":"
+		}
+		withColons += fingerprint[i : i+2]
+	}
+
+	normalized, ok := NormalizeCertFingerprint(withColons)
+	assert.True(t, ok)
+	assert.Equal(t, fingerprint, normalized)
+
+	for _, invalid := range []string{"", "abcd", fingerprint[:62] + "zz"} {
+		_, ok := NormalizeCertFingerprint(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
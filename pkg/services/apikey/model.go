@@ -2,6 +2,7 @@ package apikey
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/services/org"
@@ -28,10 +29,71 @@ type APIKey struct {
 	Expires          *int64       `db:"expires"`
 	ServiceAccountId *int64       `db:"service_account_id"`
 	IsRevoked        *bool        `xorm:"is_revoked" db:"is_revoked"`
+	// QueryDatasourceUIDs are the comma separated UIDs of the datasources a service account token limited to querying
+	// can query
+	QueryDatasourceUIDs *string `xorm:"query_datasource_uids" db:"query_datasource_uids"`
+	// AllowedCIDRs are the comma separated networks the token can be used from, it can be used from any network when
+	// not set
+	AllowedCIDRs *string `xorm:"allowed_cidrs" db:"allowed_cidrs"`
+	// ClientCertFingerprints are the comma separated SHA-256 fingerprints of the client certificates the token can be
+	// used with, it can be used without client certificate when not set
+	ClientCertFingerprints *string `xorm:"client_cert_fingerprints" db:"client_cert_fingerprints"`
 }
 
 func (k APIKey) TableName() string { return "api_key" }
 
+// QueryOnlyDatasourceUIDs returns the datasources the token can query when it is limited to querying, or nil when
+// the token is not limited
+func (k APIKey) QueryOnlyDatasourceUIDs() []string {
+	if k.QueryDatasourceUIDs == nil || *k.QueryDatasourceUIDs == "" {
+		return nil
+	}
+	return strings.Split(*k.QueryDatasourceUIDs, ",")
+}
+
+// SetQueryOnlyDatasourceUIDs limits the token to querying the datasources, the token is not limited without
+// datasources. The datasource UIDs cannot contain commas.
+func (k *APIKey) SetQueryOnlyDatasourceUIDs(uids []string) {
+	if len(uids) == 0 {
+		k.QueryDatasourceUIDs = nil
+		return
+	}
+	joined := strings.Join(uids, ",")
+	k.QueryDatasourceUIDs = &joined
+}
+
+// BoundCIDRs returns the networks the token can be used from, or nil when the token is not bound to networks
+func (k APIKey) BoundCIDRs() []string {
+	return splitList(k.AllowedCIDRs)
+}
+
+// BoundClientCertFingerprints returns the fingerprints of the client certificates the token can be used with, or nil
+// when the token is not bound to client certificates
+func (k APIKey) BoundClientCertFingerprints() []string {
+	return splitList(k.ClientCertFingerprints)
+}
+
+// SetBinding binds the token to the networks and to the client certificates, the token is not bound without them
+func (k *APIKey) SetBinding(cidrs []string, fingerprints []string) {
+	k.AllowedCIDRs = joinList(cidrs)
+	k.ClientCertFingerprints = joinList(fingerprints)
+}
+
+func splitList(list *string) []string {
+	if list == nil || *list == "" {
+		return nil
+	}
+	return strings.Split(*list, ",")
+}
+
+func joinList(values []string) *string {
+	if len(values) == 0 {
+		return nil
+	}
+	joined := strings.Join(values, ",")
+	return &joined
+}
+
 // swagger:model
 type AddCommand struct {
 	Name             string       `json:"name" binding:"Required"`
@@ -40,6 +102,11 @@ type AddCommand struct {
 	Key              string       `json:"-"`
 	SecondsToLive    int64        `json:"secondsToLive"`
 	ServiceAccountID *int64       `json:"-"`
+	// QueryDatasourceUIDs limits a service account token to querying the datasources
+	QueryDatasourceUIDs []string `json:"-"`
+	// AllowedCIDRs and ClientCertFingerprints bind a service account token to networks and to client certificates
+	AllowedCIDRs           []string `json:"-"`
+	ClientCertFingerprints []string `json:"-"`
 
 	Result *APIKey `json:"-"`
 }
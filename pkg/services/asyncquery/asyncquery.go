@@ -0,0 +1,20 @@
+package asyncquery
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// Service runs the queries of /api/ds/query in the background and keeps their results in the database, so that the
+// queries running longer than the timeouts of the proxies in front of Grafana are polled instead of waited for.
+type Service interface {
+	// Start queues the query and returns without waiting for it, the query runs as the user. The session identifies
+	// the anonymous users, who share the same identity, it is ignored for the other users.
+	Start(ctx context.Context, user *user.SignedInUser, session string, skipCache bool, reqDTO dtos.MetricRequest) (*AsyncQuery, error)
+	// Get returns the query of the user, with its result once it is done
+	Get(ctx context.Context, user *user.SignedInUser, session string, uid string, withResult bool) (*AsyncQuery, error)
+	// Delete cancels the query of the user when it is running on this instance, and deletes it with its result
+	Delete(ctx context.Context, user *user.SignedInUser, session string, uid string) error
+}
@@ -0,0 +1,314 @@
+package asyncqueryimpl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/asyncquery"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	// cleanupInterval is how often the expired queries are deleted
+	cleanupInterval = time.Minute
+	// interruptedGracePeriod is how long after their timeout the unfinished queries are reported as failed, when the
+	// instance running them stopped before they finished
+	interruptedGracePeriod = time.Minute
+)
+
+var _ asyncquery.Service = (*Service)(nil)
+
+type Service struct {
+	cfg          *setting.Cfg
+	store        store
+	queryService query.Service
+	log          log.Logger
+	now          func() time.Time
+
+	timeout   time.Duration
+	resultTTL time.Duration
+	// resultMaxSize is the size in bytes of the largest result saved, 0 when there is no maximum
+	resultMaxSize int
+	// slots limits the queries running at the same time, it is nil when they are not limited
+	slots chan struct{}
+
+	mu sync.Mutex
+	// cancels cancels the queries running on this instance, by UID
+	cancels map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func ProvideService(cfg *setting.Cfg, db db.DB, queryService query.Service) *Service {
+	s := &Service{
+		cfg:           cfg,
+		store:         sqlStore{db: db},
+		queryService:  queryService,
+		log:           log.New("asyncquery"),
+		now:           time.Now,
+		timeout:       cfg.AsyncQueryTimeout,
+		resultTTL:     cfg.AsyncQueryResultTTL,
+		resultMaxSize: cfg.AsyncQueryResultMaxSize,
+		cancels:       map[string]context.CancelFunc{},
+	}
+	if cfg.AsyncQueryMaxConcurrent > 0 {
+		s.slots = make(chan struct{}, cfg.AsyncQueryMaxConcurrent)
+	}
+	return s
+}
+
+// Run deletes the expired queries, and cancels the running queries when Grafana stops
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			deleted, err := s.store.DeleteExpired(ctx, s.now())
+			if err != nil {
+				s.log.Error("Failed to delete the expired async queries", "error", err)
+			} else if deleted > 0 {
+				s.log.Debug("Deleted the expired async queries", "count", deleted)
+			}
+		case <-ctx.Done():
+			s.mu.Lock()
+			for _, cancel := range s.cancels {
+				cancel()
+			}
+			s.mu.Unlock()
+			s.wg.Wait()
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) Start(ctx context.Context, user *user.SignedInUser, session string, skipCache bool, reqDTO dtos.MetricRequest) (*asyncquery.AsyncQuery, error) {
+	owner, err := queryOwner(user, session)
+	if err != nil {
+		return nil, asyncquery.ErrQueryInternal.Errorf("failed to identify the owner of the async query: %w", err)
+	}
+
+	now := s.now()
+	q := &asyncquery.AsyncQuery{
+		UID:     util.GenerateShortUID(),
+		OrgID:   user.OrgID,
+		UserID:  user.UserID,
+		Owner:   owner,
+		Status:  asyncquery.StatusPending,
+		Created: now.Unix(),
+		Updated: now.Unix(),
+		// the unfinished queries are deleted too when the instance running them stops
+		Expires: now.Add(s.timeout + s.resultTTL).Unix(),
+	}
+	if err := s.store.Insert(ctx, q); err != nil {
+		return nil, asyncquery.ErrQueryInternal.Errorf("failed to save the async query: %w", err)
+	}
+
+	// the query doesn't run with the context of the request, which is canceled when the request ends. It is canceled
+	// by its timeout, when the caller deletes it or when Grafana stops.
+	runCtx, cancel := context.WithTimeout(s.detachedContext(ctx, user), s.timeout)
+	s.mu.Lock()
+	s.cancels[q.UID] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.cancels, q.UID)
+			s.mu.Unlock()
+			cancel()
+		}()
+		s.execute(runCtx, user, skipCache, reqDTO, *q)
+	}()
+	return q, nil
+}
+
+func (s *Service) execute(ctx context.Context, user *user.SignedInUser, skipCache bool, reqDTO dtos.MetricRequest, q asyncquery.AsyncQuery) {
+	logger := s.log.New("uid", q.UID, "orgId", q.OrgID)
+
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-ctx.Done():
+			s.finish(logger, &q, nil, ctx.Err())
+			return
+		}
+	}
+
+	q.Status = asyncquery.StatusRunning
+	q.Updated = s.now().Unix()
+	if ok, err := s.store.Update(ctx, &q); err != nil || !ok {
+		if err != nil {
+			logger.Error("Failed to update the async query", "error", err)
+		}
+		// the query was deleted while it was pending
+		return
+	}
+
+	resp, err := s.queryService.QueryData(ctx, user, skipCache, reqDTO)
+	s.finish(logger, &q, resp, err)
+}
+
+// finish saves the result or the error of the query, the result is kept for the result TTL
+func (s *Service) finish(logger log.Logger, q *asyncquery.AsyncQuery, resp *backend.QueryDataResponse, err error) {
+	if err == nil {
+		result, marshalErr := json.Marshal(resp)
+		switch {
+		case marshalErr != nil:
+			err = fmt.Errorf("failed to encode the result: %w", marshalErr)
+		case s.resultMaxSize > 0 && len(result) > s.resultMaxSize:
+			err = asyncquery.ErrQueryResultTooLarge.Errorf("the result of %d bytes is larger than the maximum of %d bytes", len(result), s.resultMaxSize)
+		default:
+			q.Status = asyncquery.StatusDone
+			q.Result = string(result)
+		}
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			err = fmt.Errorf("the query didn't finish within %s: %w", s.timeout, err)
+		case errors.Is(err, context.Canceled):
+			err = fmt.Errorf("the query was canceled: %w", err)
+		}
+		q.Status = asyncquery.StatusFailed
+		q.Error, q.ErrorStatus = queryError(err)
+	}
+
+	now := s.now()
+	q.Updated = now.Unix()
+	q.Expires = now.Add(s.resultTTL).Unix()
+	// the query context may be canceled, the result is still saved
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if ok, err := s.store.Update(ctx, q); err != nil {
+		logger.Error("Failed to save the result of the async query", "error", err)
+	} else if !ok {
+		logger.Debug("Async query deleted before it finished")
+	}
+}
+
+func (s *Service) Get(ctx context.Context, user *user.SignedInUser, session string, uid string, withResult bool) (*asyncquery.AsyncQuery, error) {
+	owner, err := queryOwner(user, session)
+	if err != nil {
+		return nil, asyncquery.ErrQueryNotFound.Errorf("async query not found: %w", err)
+	}
+	q, err := s.store.Get(ctx, user.OrgID, uid, withResult)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	// the queries of the other users are not found, like the expired queries not deleted yet
+	if q.Owner != owner || q.Expired(now) {
+		return nil, asyncquery.ErrQueryNotFound.Errorf("async query not found")
+	}
+	if !q.Status.Finished() && now.After(time.Unix(q.Created, 0).Add(s.timeout+interruptedGracePeriod)) {
+		q.Status = asyncquery.StatusFailed
+		q.Error = "the query was interrupted before it finished"
+		q.ErrorStatus = http.StatusInternalServerError
+	}
+	return q, nil
+}
+
+func (s *Service) Delete(ctx context.Context, user *user.SignedInUser, session string, uid string) error {
+	if _, err := s.Get(ctx, user, session, uid, false); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if cancel, ok := s.cancels[uid]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+	return s.store.Delete(ctx, user.OrgID, uid)
+}
+
+// queryOwner returns the namespaced ID of the user, the service account or the API key, which are only compared within
+// the organization of the query. The anonymous users share the same identity, their queries are owned by the hash of
+// their session.
+func queryOwner(user *user.SignedInUser, session string) (string, error) {
+	switch {
+	case user.IsAnonymous:
+		if session == "" {
+			return "", errors.New("the anonymous user has no session")
+		}
+		hash := sha256.Sum256([]byte(session))
+		return "anonymous:" + hex.EncodeToString(hash[:]), nil
+	case user.IsApiKeyUser():
+		return authn.NamespacedID(authn.NamespaceAPIKey, user.ApiKeyID), nil
+	case user.IsServiceAccountUser():
+		return authn.NamespacedID(authn.NamespaceServiceAccount, user.UserID), nil
+	case user.UserID > 0:
+		return authn.NamespacedID(authn.NamespaceUser, user.UserID), nil
+	default:
+		return "", errors.New("the user has no unique identity")
+	}
+}
+
+// queryError returns the message and the HTTP status code of the error of a query, like the synchronous queries of
+// /api/ds/query
+func queryError(err error) (string, int) {
+	var grafanaErr errutil.Error
+	switch {
+	case errors.Is(err, datasources.ErrDataSourceAccessDenied):
+		return "Access denied to data source", http.StatusForbidden
+	case errors.Is(err, datasources.ErrDataSourceNotFound):
+		return "Data source not found", http.StatusNotFound
+	case errors.As(err, &grafanaErr):
+		public := grafanaErr.Public()
+		return public.Message, public.StatusCode
+	case errors.Is(err, context.DeadlineExceeded):
+		return err.Error(), http.StatusGatewayTimeout
+	default:
+		return err.Error(), http.StatusInternalServerError
+	}
+}
+
+// detachedContext returns a context with only the identity the query needs: the caller, and the request context
+// with a copy of the request, whose headers are forwarded to the data sources. The other values of the request, such
+// as its cancellation, its tracing span or its response writer, are not kept.
+func (s *Service) detachedContext(parent context.Context, user *user.SignedInUser) context.Context {
+	ctx := contexthandler.WithAuthHTTPHeaders(appcontext.WithUser(context.Background(), user), s.cfg)
+
+	reqCtx := contexthandler.FromContext(parent)
+	if reqCtx == nil || reqCtx.Context == nil || reqCtx.Req == nil {
+		return ctx
+	}
+	req := reqCtx.Req.Clone(ctx)
+	req.Body = http.NoBody
+	detached := &contextmodel.ReqContext{
+		Context:        &web.Context{Req: req},
+		SignedInUser:   user,
+		IsSignedIn:     reqCtx.IsSignedIn,
+		AllowAnonymous: reqCtx.AllowAnonymous,
+		SkipCache:      reqCtx.SkipCache,
+		Logger:         s.log,
+	}
+	ctx = ctxkey.Set(ctx, detached)
+	detached.Req = req.WithContext(ctx)
+	return ctx
+}
@@ -0,0 +1,349 @@
+package asyncqueryimpl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/asyncquery"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type fakeQueryService struct {
+	queryData func(ctx context.Context) (*backend.QueryDataResponse, error)
+}
+
+func (f *fakeQueryService) Run(context.Context) error { return nil }
+
+func (f *fakeQueryService) QueryData(ctx context.Context, _ *user.SignedInUser, _ bool, _ dtos.MetricRequest) (*backend.QueryDataResponse, error) {
+	return f.queryData(ctx)
+}
+
+func newTestService(t *testing.T, cfg *setting.Cfg, queryData func(ctx context.Context) (*backend.QueryDataResponse, error)) *Service {
+	t.Helper()
+	if cfg.AsyncQueryTimeout == 0 {
+		cfg.AsyncQueryTimeout = time.Minute
+	}
+	if cfg.AsyncQueryResultTTL == 0 {
+		cfg.AsyncQueryResultTTL = time.Hour
+	}
+	return ProvideService(cfg, db.InitTestDB(t), &fakeQueryService{queryData: queryData})
+}
+
+// waitFinished waits for the query to be done or to fail
+func waitFinished(t *testing.T, s *Service, u *user.SignedInUser, uid string) *asyncquery.AsyncQuery {
+	t.Helper()
+	var q *asyncquery.AsyncQuery
+	require.Eventually(t, func() bool {
+		var err error
+		q, err = s.Get(context.Background(), u, "", uid, true)
+		require.NoError(t, err)
+		return q.Status.Finished()
+	}, 5*time.Second, 10*time.Millisecond)
+	return q
+}
+
+func TestAsyncQueryService(t *testing.T) {
+	signedInUser := &user.SignedInUser{UserID: 1, OrgID: 1}
+
+	t.Run("the result of the done queries is saved", func(t *testing.T) {
+		release := make(chan struct{})
+		s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			<-release
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{data.NewFrame("frame", data.NewField("value", nil, []int64{1, 2}))}}
+			return resp, nil
+		})
+
+		q, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		require.NotEmpty(t, q.UID)
+
+		running, err := s.Get(context.Background(), signedInUser, "", q.UID, true)
+		require.NoError(t, err)
+		assert.False(t, running.Status.Finished())
+		assert.Empty(t, running.Result)
+
+		close(release)
+		done := waitFinished(t, s, signedInUser, q.UID)
+		assert.Equal(t, asyncquery.StatusDone, done.Status)
+		result := &backend.QueryDataResponse{}
+		require.NoError(t, result.UnmarshalJSON([]byte(done.Result)))
+		require.Len(t, result.Responses["A"].Frames, 1)
+		assert.Equal(t, 2, result.Responses["A"].Frames[0].Rows())
+
+		// the result is only read when it is asked for
+		status, err := s.Get(context.Background(), signedInUser, "", q.UID, false)
+		require.NoError(t, err)
+		assert.Equal(t, asyncquery.StatusDone, status.Status)
+		assert.Empty(t, status.Result)
+	})
+
+	t.Run("the error of the failed queries is saved with its status code", func(t *testing.T) {
+		errs := map[string]error{
+			"denied":  datasources.ErrDataSourceAccessDenied,
+			"invalid": errutil.NewBase(errutil.StatusBadRequest, "query.invalid", errutil.WithPublicMessage("Invalid query")).Errorf("invalid"),
+			"failed":  errors.New("connection refused"),
+		}
+		expected := map[string]struct {
+			message string
+			status  int
+		}{
+			"denied":  {message: "Access denied to data source", status: 403},
+			"invalid": {message: "Invalid query", status: 400},
+			"failed":  {message: "connection refused", status: 500},
+		}
+		for name, queryErr := range errs {
+			queryErr := queryErr
+			s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+				return nil, queryErr
+			})
+			q, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+			require.NoError(t, err)
+			failed := waitFinished(t, s, signedInUser, q.UID)
+			assert.Equal(t, asyncquery.StatusFailed, failed.Status, name)
+			assert.Equal(t, expected[name].message, failed.Error, name)
+			assert.Equal(t, expected[name].status, failed.ErrorStatus, name)
+		}
+	})
+
+	t.Run("the queries time out", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.AsyncQueryTimeout = 50 * time.Millisecond
+		s := newTestService(t, cfg, func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		q, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		failed := waitFinished(t, s, signedInUser, q.UID)
+		assert.Equal(t, asyncquery.StatusFailed, failed.Status)
+		assert.Contains(t, failed.Error, "the query didn't finish within 50ms")
+		assert.Equal(t, 504, failed.ErrorStatus)
+	})
+
+	t.Run("the queries are not canceled with the request", func(t *testing.T) {
+		s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			time.Sleep(20 * time.Millisecond)
+			return backend.NewQueryDataResponse(), ctx.Err()
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		q, err := s.Start(ctx, signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		cancel()
+		assert.Equal(t, asyncquery.StatusDone, waitFinished(t, s, signedInUser, q.UID).Status)
+	})
+
+	t.Run("the queries wait for a free slot", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.AsyncQueryMaxConcurrent = 1
+		release := make(chan struct{})
+		s := newTestService(t, cfg, func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			<-release
+			return backend.NewQueryDataResponse(), nil
+		})
+
+		first, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			q, err := s.Get(context.Background(), signedInUser, "", first.UID, false)
+			require.NoError(t, err)
+			return q.Status == asyncquery.StatusRunning
+		}, 5*time.Second, 10*time.Millisecond)
+
+		second, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		q, err := s.Get(context.Background(), signedInUser, "", second.UID, false)
+		require.NoError(t, err)
+		assert.Equal(t, asyncquery.StatusPending, q.Status)
+
+		close(release)
+		assert.Equal(t, asyncquery.StatusDone, waitFinished(t, s, signedInUser, first.UID).Status)
+		assert.Equal(t, asyncquery.StatusDone, waitFinished(t, s, signedInUser, second.UID).Status)
+	})
+
+	t.Run("delete cancels the running queries", func(t *testing.T) {
+		canceled := make(chan struct{})
+		s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		})
+		q, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			running, err := s.Get(context.Background(), signedInUser, "", q.UID, false)
+			require.NoError(t, err)
+			return running.Status == asyncquery.StatusRunning
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, s.Delete(context.Background(), signedInUser, "", q.UID))
+		<-canceled
+		_, err = s.Get(context.Background(), signedInUser, "", q.UID, false)
+		require.ErrorIs(t, err, asyncquery.ErrQueryNotFound)
+		require.ErrorIs(t, s.Delete(context.Background(), signedInUser, "", q.UID), asyncquery.ErrQueryNotFound)
+	})
+
+	t.Run("the queries of the other users are not found", func(t *testing.T) {
+		s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			return backend.NewQueryDataResponse(), nil
+		})
+		q, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		waitFinished(t, s, signedInUser, q.UID)
+
+		_, err = s.Get(context.Background(), &user.SignedInUser{UserID: 2, OrgID: 1}, "", q.UID, false)
+		require.ErrorIs(t, err, asyncquery.ErrQueryNotFound)
+		_, err = s.Get(context.Background(), &user.SignedInUser{UserID: 1, OrgID: 2}, "", q.UID, false)
+		require.ErrorIs(t, err, asyncquery.ErrQueryNotFound)
+		require.ErrorIs(t, s.Delete(context.Background(), &user.SignedInUser{UserID: 2, OrgID: 1}, "", q.UID), asyncquery.ErrQueryNotFound)
+	})
+
+	t.Run("the queries are owned by the namespaced ID of the caller", func(t *testing.T) {
+		s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			return backend.NewQueryDataResponse(), nil
+		})
+		serviceAccount := &user.SignedInUser{UserID: 1, OrgID: 1, IsServiceAccount: true}
+		apiKey := &user.SignedInUser{ApiKeyID: 1, OrgID: 1}
+		q, err := s.Start(context.Background(), serviceAccount, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		waitFinished(t, s, serviceAccount, q.UID)
+
+		// the user, the service account and the API key with the same ID are different callers
+		_, err = s.Get(context.Background(), signedInUser, "", q.UID, false)
+		require.ErrorIs(t, err, asyncquery.ErrQueryNotFound)
+		_, err = s.Get(context.Background(), apiKey, "", q.UID, false)
+		require.ErrorIs(t, err, asyncquery.ErrQueryNotFound)
+	})
+
+	t.Run("the queries of the anonymous users are owned by their session", func(t *testing.T) {
+		s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			return backend.NewQueryDataResponse(), nil
+		})
+		anonymous := &user.SignedInUser{OrgID: 1, IsAnonymous: true}
+		_, err := s.Start(context.Background(), anonymous, "", false, dtos.MetricRequest{})
+		require.ErrorIs(t, err, asyncquery.ErrQueryInternal)
+
+		q, err := s.Start(context.Background(), anonymous, "session", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		assert.NotContains(t, q.Owner, "session")
+
+		require.Eventually(t, func() bool {
+			q, err := s.Get(context.Background(), anonymous, "session", q.UID, false)
+			require.NoError(t, err)
+			return q.Status.Finished()
+		}, 5*time.Second, 10*time.Millisecond)
+		_, err = s.Get(context.Background(), anonymous, "other", q.UID, false)
+		require.ErrorIs(t, err, asyncquery.ErrQueryNotFound)
+		_, err = s.Get(context.Background(), anonymous, "", q.UID, false)
+		require.ErrorIs(t, err, asyncquery.ErrQueryNotFound)
+	})
+
+	t.Run("the queries run with the identity of the caller", func(t *testing.T) {
+		identities := make(chan *user.SignedInUser, 1)
+		s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			u, err := appcontext.User(ctx)
+			require.NoError(t, err)
+			identities <- u
+			return backend.NewQueryDataResponse(), nil
+		})
+		_, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, signedInUser, <-identities)
+	})
+
+	t.Run("the queries only keep the identity of the request", func(t *testing.T) {
+		type testKey struct{}
+		contexts := make(chan context.Context, 1)
+		s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			contexts <- ctx
+			return backend.NewQueryDataResponse(), nil
+		})
+		req, err := http.NewRequest(http.MethodPost, "/api/ds/query?async=true", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Dashboard-Uid", "dashboard")
+		ctx := context.WithValue(context.Background(), testKey{}, "value")
+		ctx = ctxkey.Set(ctx, &contextmodel.ReqContext{Context: &web.Context{Req: req}, SignedInUser: signedInUser, IsSignedIn: true})
+
+		_, err = s.Start(ctx, signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		runCtx := <-contexts
+		assert.Nil(t, runCtx.Value(testKey{}))
+		assert.NotNil(t, contexthandler.AuthHTTPHeaderListFromContext(runCtx))
+		reqCtx := contexthandler.FromContext(runCtx)
+		require.NotNil(t, reqCtx)
+		assert.Equal(t, signedInUser, reqCtx.SignedInUser)
+		assert.True(t, reqCtx.IsSignedIn)
+		assert.Equal(t, "dashboard", reqCtx.Req.Header.Get("X-Dashboard-Uid"))
+		assert.Equal(t, reqCtx, contexthandler.FromContext(reqCtx.Req.Context()))
+	})
+
+	t.Run("the results larger than the maximum are not saved", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.AsyncQueryResultMaxSize = 10
+		s := newTestService(t, cfg, func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{data.NewFrame("frame")}}
+			return resp, nil
+		})
+		q, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+
+		q = waitFinished(t, s, signedInUser, q.UID)
+		assert.Equal(t, asyncquery.StatusFailed, q.Status)
+		assert.Equal(t, http.StatusBadRequest, q.ErrorStatus)
+		assert.Empty(t, q.Result)
+	})
+
+	t.Run("the expired queries are deleted", func(t *testing.T) {
+		s := newTestService(t, setting.NewCfg(), func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			return backend.NewQueryDataResponse(), nil
+		})
+		q, err := s.Start(context.Background(), signedInUser, "", false, dtos.MetricRequest{})
+		require.NoError(t, err)
+		waitFinished(t, s, signedInUser, q.UID)
+
+		later := time.Now().Add(2 * time.Hour)
+		s.now = func() time.Time { return later }
+		_, err = s.Get(context.Background(), signedInUser, "", q.UID, false)
+		require.ErrorIs(t, err, asyncquery.ErrQueryNotFound)
+
+		deleted, err := s.store.DeleteExpired(context.Background(), later)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+
+	t.Run("the queries not finished after their timeout were interrupted", func(t *testing.T) {
+		s := newTestService(t, setting.NewCfg(), nil)
+		now := time.Now()
+		q := &asyncquery.AsyncQuery{UID: "interrupted", OrgID: 1, UserID: 1, Owner: "user:1", Status: asyncquery.StatusRunning, Created: now.Unix(), Updated: now.Unix(), Expires: now.Add(2 * time.Hour).Unix()}
+		require.NoError(t, s.store.Insert(context.Background(), q))
+
+		running, err := s.Get(context.Background(), signedInUser, "", q.UID, false)
+		require.NoError(t, err)
+		assert.Equal(t, asyncquery.StatusRunning, running.Status)
+
+		s.now = func() time.Time { return now.Add(5 * time.Minute) }
+		interrupted, err := s.Get(context.Background(), signedInUser, "", q.UID, false)
+		require.NoError(t, err)
+		assert.Equal(t, asyncquery.StatusFailed, interrupted.Status)
+		assert.Equal(t, "the query was interrupted before it finished", interrupted.Error)
+	})
+}
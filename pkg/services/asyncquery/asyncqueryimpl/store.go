@@ -0,0 +1,85 @@
+package asyncqueryimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/asyncquery"
+)
+
+type store interface {
+	Insert(ctx context.Context, query *asyncquery.AsyncQuery) error
+	Get(ctx context.Context, orgID int64, uid string, withResult bool) (*asyncquery.AsyncQuery, error)
+	// Update saves the status, error and result of the query, it returns false when the query was deleted
+	Update(ctx context.Context, query *asyncquery.AsyncQuery) (bool, error)
+	Delete(ctx context.Context, orgID int64, uid string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s sqlStore) Insert(ctx context.Context, query *asyncquery.AsyncQuery) error {
+	return s.db.WithDbSession(ctx, func(session *db.Session) error {
+		_, err := session.Insert(query)
+		return err
+	})
+}
+
+func (s sqlStore) Get(ctx context.Context, orgID int64, uid string, withResult bool) (*asyncquery.AsyncQuery, error) {
+	var query asyncquery.AsyncQuery
+	err := s.db.WithDbSession(ctx, func(session *db.Session) error {
+		sess := session.Where("org_id=? AND uid=?", orgID, uid)
+		if !withResult {
+			// the results can be large, they are only read when they are fetched
+			sess = sess.Omit("result")
+		}
+		exists, err := sess.Get(&query)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return asyncquery.ErrQueryNotFound.Errorf("async query not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &query, nil
+}
+
+func (s sqlStore) Update(ctx context.Context, query *asyncquery.AsyncQuery) (bool, error) {
+	var updated int64
+	err := s.db.WithDbSession(ctx, func(session *db.Session) error {
+		var err error
+		updated, err = session.ID(query.ID).Cols("status", "error", "error_status", "result", "updated", "expires").Update(query)
+		return err
+	})
+	return updated > 0, err
+}
+
+func (s sqlStore) Delete(ctx context.Context, orgID int64, uid string) error {
+	return s.db.WithDbSession(ctx, func(session *db.Session) error {
+		deleted, err := session.Where("org_id=? AND uid=?", orgID, uid).Delete(&asyncquery.AsyncQuery{})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return asyncquery.ErrQueryNotFound.Errorf("async query not found")
+		}
+		return nil
+	})
+}
+
+func (s sqlStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := s.db.WithDbSession(ctx, func(session *db.Session) error {
+		var err error
+		deleted, err = session.Where("expires <= ?", now.Unix()).Delete(&asyncquery.AsyncQuery{})
+		return err
+	})
+	return deleted, err
+}
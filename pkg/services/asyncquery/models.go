@@ -0,0 +1,56 @@
+package asyncquery
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrQueryNotFound       = errutil.NewBase(errutil.StatusNotFound, "asyncquery.not-found", errutil.WithPublicMessage("Query not found"))
+	ErrQueryInternal       = errutil.NewBase(errutil.StatusInternal, "asyncquery.internal")
+	ErrQueryResultTooLarge = errutil.NewBase(errutil.StatusBadRequest, "asyncquery.result-too-large",
+		errutil.WithPublicMessage("The result of the query is too large to be saved, narrow the query down"))
+)
+
+// Status is the state of an asynchronous query
+type Status string
+
+const (
+	// StatusPending is the status of the queries waiting for one of the running queries to finish
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Finished returns true when the query has a result or an error
+func (s Status) Finished() bool {
+	return s == StatusDone || s == StatusFailed
+}
+
+type AsyncQuery struct {
+	ID     int64  `xorm:"pk autoincr 'id'"`
+	UID    string `xorm:"uid"`
+	OrgID  int64  `xorm:"org_id"`
+	UserID int64  `xorm:"user_id"`
+	// Owner is the namespaced ID of the user, the service account or the API key which started the query, or the hash
+	// of the session of the anonymous user
+	Owner  string `xorm:"owner"`
+	Status Status `xorm:"status"`
+	// Error is the error of the failed queries
+	Error string `xorm:"error"`
+	// ErrorStatus is the HTTP status code of the error of the failed queries
+	ErrorStatus int `xorm:"error_status"`
+	// Result is the JSON encoded backend.QueryDataResponse of the done queries
+	Result  string `xorm:"result"`
+	Created int64  `xorm:"created"`
+	Updated int64  `xorm:"updated"`
+	// Expires is the unix time after which the query and its result are deleted
+	Expires int64 `xorm:"expires"`
+}
+
+// Expired returns true when the query and its result expired at t
+func (q AsyncQuery) Expired(t time.Time) bool {
+	return q.Expires <= t.Unix()
+}
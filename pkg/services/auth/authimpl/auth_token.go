@@ -43,9 +43,10 @@ func ProvideUserAuthTokenService(sqlStore db.DB,
 	}
 
 	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
-		TargetSrv:     auth.QuotaTargetSrv,
-		DefaultLimits: defaultLimits,
-		Reporter:      s.reportActiveTokenCount,
+		TargetSrv:         auth.QuotaTargetSrv,
+		DefaultLimits:     defaultLimits,
+		Reporter:          s.reportActiveTokenCount,
+		ReadDefaultLimits: readQuotaConfig,
 	}); err != nil {
 		return s, err
 	}
@@ -521,6 +522,6 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 		return limits, err
 	}
 
-	limits.Set(globalQuotaTag, cfg.Quota.Global.Session)
+	limits.Set(globalQuotaTag, cfg.QuotaSettings().Global.Session)
 	return limits, nil
 }
@@ -0,0 +1,218 @@
+package identitytoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Claims describe the querying user to the gateways in front of the datasources. The subject is the kind of the
+// identity followed by its ID, like user:42, service-account:7 or api-key:3.
+type Claims struct {
+	jwt.Claims
+	Login   string  `json:"login,omitempty"`
+	Email   string  `json:"email,omitempty"`
+	Name    string  `json:"name,omitempty"`
+	OrgID   int64   `json:"org_id"`
+	OrgRole string  `json:"org_role,omitempty"`
+	Teams   []int64 `json:"teams"`
+}
+
+// Service signs the identity tokens sent to the datasources with forwardIdentityToken enabled. Its methods can be
+// called on a nil Service, no token is signed then.
+type Service struct {
+	signer     jose.Signer
+	keys       jose.JSONWebKeySet
+	issuer     string
+	expiration time.Duration
+	headerName string
+	now        func() time.Time
+}
+
+// ProvideService returns nil when the signing key of the identity tokens is not configured
+func ProvideService(cfg *setting.Cfg) (*Service, error) {
+	settings := cfg.DataProxyIdentityToken
+	if settings.SigningKeyFile == "" {
+		return nil, nil
+	}
+
+	key, err := readPrivateKey(settings.SigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the identity token signing key: %w", err)
+	}
+	algorithm, err := signatureAlgorithm(key)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: algorithm,
+		Key:       jose.JSONWebKey{Key: key, KeyID: settings.KeyID, Algorithm: string(algorithm)},
+	}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, err
+	}
+	// the supported private keys all implement crypto.Signer
+	publicKey := jose.JSONWebKey{
+		Key:       key.(crypto.Signer).Public(),
+		KeyID:     settings.KeyID,
+		Algorithm: string(algorithm),
+		Use:       "sig",
+	}
+
+	expiration := settings.Expiration
+	if expiration <= 0 {
+		expiration = time.Minute
+	}
+	return &Service{
+		signer:     signer,
+		keys:       jose.JSONWebKeySet{Keys: []jose.JSONWebKey{publicKey}},
+		issuer:     cfg.AppURL,
+		expiration: expiration,
+		headerName: settings.HeaderName,
+		now:        time.Now,
+	}, nil
+}
+
+// HeaderName is the header of the requests the identity tokens are sent in
+func (s *Service) HeaderName() string {
+	if s == nil {
+		return ""
+	}
+	return s.headerName
+}
+
+// Keys returns the public keys verifying the identity tokens, published for the gateways as a JWKS
+func (s *Service) Keys() jose.JSONWebKeySet {
+	if s == nil {
+		return jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	}
+	return s.keys
+}
+
+// Audience returns the audience of the identity tokens sent to the datasource. It is derived from the root URL of
+// Grafana, the organization and the UID of the datasource, so that the users who can edit a datasource cannot make
+// it receive the tokens issued for another one.
+func (s *Service) Audience(ds *datasources.DataSource) string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/orgs/%d/datasources/%s", strings.TrimSuffix(s.issuer, "/"), ds.OrgID, ds.UID)
+}
+
+// IsEnabled returns true when the identity tokens are sent to the datasource
+func (s *Service) IsEnabled(ds *datasources.DataSource) bool {
+	return s != nil && ds.JsonData != nil && ds.JsonData.Get("forwardIdentityToken").MustBool()
+}
+
+// Token returns the identity token of the user for the datasource. It returns an empty token when the identity tokens
+// are not sent to the datasource, or for the anonymous users.
+func (s *Service) Token(usr *user.SignedInUser, ds *datasources.DataSource) (string, error) {
+	if !s.IsEnabled(ds) || usr == nil {
+		return "", nil
+	}
+	subject := subject(usr)
+	if subject == "" {
+		return "", nil
+	}
+
+	// the audience lets the gateways check that the token was issued for the datasource they are in front of
+	audience := s.Audience(ds)
+	now := s.now()
+	teams := usr.Teams
+	if teams == nil {
+		teams = []int64{}
+	}
+	claims := Claims{
+		Claims: jwt.Claims{
+			Issuer:    s.issuer,
+			Subject:   subject,
+			Audience:  jwt.Audience{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Expiry:    jwt.NewNumericDate(now.Add(s.expiration)),
+		},
+		Login:   usr.Login,
+		Email:   usr.Email,
+		Name:    usr.Name,
+		OrgID:   usr.OrgID,
+		OrgRole: string(usr.OrgRole),
+		Teams:   teams,
+	}
+	return jwt.Signed(s.signer).Claims(claims).CompactSerialize()
+}
+
+func subject(usr *user.SignedInUser) string {
+	switch {
+	case usr.IsAnonymous:
+		return ""
+	case usr.IsServiceAccount:
+		return fmt.Sprintf("service-account:%d", usr.UserID)
+	case usr.ApiKeyID != 0:
+		return fmt.Sprintf("api-key:%d", usr.ApiKeyID)
+	case usr.UserID != 0:
+		return fmt.Sprintf("user:%d", usr.UserID)
+	default:
+		return ""
+	}
+}
+
+// readPrivateKey reads a PKCS #8, PKCS #1 or SEC 1 PEM private key
+func readPrivateKey(path string) (interface{}, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path comes from the Grafana configuration file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %q", block.Type)
+}
+
+func signatureAlgorithm(key interface{}) (jose.SignatureAlgorithm, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jose.RS256, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jose.ES256, nil
+		case elliptic.P384():
+			return jose.ES384, nil
+		case elliptic.P521():
+			return jose.ES512, nil
+		}
+		return "", fmt.Errorf("unsupported elliptic curve %s", k.Curve.Params().Name)
+	case ed25519.PrivateKey:
+		return jose.EdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported private key %T", key)
+	}
+}
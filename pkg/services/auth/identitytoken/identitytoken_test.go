@@ -0,0 +1,177 @@
+package identitytoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func writeKey(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	return path
+}
+
+func newService(t *testing.T, keyFile string) *Service {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.AppURL = "https://grafana.example.com/"
+	cfg.DataProxyIdentityToken = setting.IdentityTokenSettings{
+		SigningKeyFile: keyFile,
+		KeyID:          "key-1",
+		Expiration:     time.Minute,
+		HeaderName:     "X-Grafana-Id",
+	}
+	s, err := ProvideService(cfg)
+	require.NoError(t, err)
+	return s
+}
+
+func TestService_Token(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	s := newService(t, writeKey(t, "PRIVATE KEY", der))
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	ds := &datasources.DataSource{UID: "tempo", OrgID: 3, JsonData: simplejson.NewFromAny(map[string]interface{}{"forwardIdentityToken": true})}
+	usr := &user.SignedInUser{UserID: 42, OrgID: 3, OrgRole: org.RoleEditor, Login: "alice", Email: "alice@example.com", Teams: []int64{1, 5}}
+
+	parse := func(t *testing.T, token string, verifyKey crypto.PublicKey) Claims {
+		t.Helper()
+		parsed, err := jwt.ParseSigned(token)
+		require.NoError(t, err)
+		require.Len(t, parsed.Headers, 1)
+		assert.Equal(t, "key-1", parsed.Headers[0].KeyID)
+		assert.Equal(t, "ES256", parsed.Headers[0].Algorithm)
+		var claims Claims
+		require.NoError(t, parsed.Claims(verifyKey, &claims))
+		return claims
+	}
+
+	t.Run("should describe the user", func(t *testing.T) {
+		token, err := s.Token(usr, ds)
+		require.NoError(t, err)
+		claims := parse(t, token, &key.PublicKey)
+
+		assert.Equal(t, "https://grafana.example.com/", claims.Issuer)
+		assert.Equal(t, "user:42", claims.Subject)
+		assert.Equal(t, jwt.Audience{"https://grafana.example.com/orgs/3/datasources/tempo"}, claims.Audience)
+		assert.Equal(t, now.Add(time.Minute).Unix(), claims.Expiry.Time().Unix())
+		assert.Equal(t, "alice", claims.Login)
+		assert.Equal(t, "alice@example.com", claims.Email)
+		assert.Equal(t, int64(3), claims.OrgID)
+		assert.Equal(t, "Editor", claims.OrgRole)
+		assert.Equal(t, []int64{1, 5}, claims.Teams)
+		require.NoError(t, claims.Validate(jwt.Expected{Audience: jwt.Audience{"https://grafana.example.com/orgs/3/datasources/tempo"}, Time: now.Add(30 * time.Second)}))
+		require.Error(t, claims.ValidateWithLeeway(jwt.Expected{Time: now.Add(2 * time.Minute)}, 0))
+	})
+
+	t.Run("should not use an audience set in the datasource", func(t *testing.T) {
+		withAudience := &datasources.DataSource{UID: "tempo", OrgID: 3, JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"forwardIdentityToken":  true,
+			"identityTokenAudience": "https://grafana.example.com/orgs/3/datasources/loki",
+		})}
+		token, err := s.Token(usr, withAudience)
+		require.NoError(t, err)
+		assert.Equal(t, jwt.Audience{"https://grafana.example.com/orgs/3/datasources/tempo"}, parse(t, token, &key.PublicKey).Audience)
+	})
+
+	t.Run("should publish the public key", func(t *testing.T) {
+		keys := s.Keys()
+		require.Len(t, keys.Keys, 1)
+		assert.Equal(t, "key-1", keys.Keys[0].KeyID)
+		assert.Equal(t, "ES256", keys.Keys[0].Algorithm)
+		assert.True(t, keys.Keys[0].IsPublic())
+
+		token, err := s.Token(usr, ds)
+		require.NoError(t, err)
+		parse(t, token, keys.Keys[0].Key)
+	})
+
+	t.Run("should identify the service accounts and API keys", func(t *testing.T) {
+		token, err := s.Token(&user.SignedInUser{UserID: 7, OrgID: 1, IsServiceAccount: true}, ds)
+		require.NoError(t, err)
+		claims := parse(t, token, &key.PublicKey)
+		assert.Equal(t, "service-account:7", claims.Subject)
+		assert.Equal(t, []int64{}, claims.Teams)
+
+		token, err = s.Token(&user.SignedInUser{ApiKeyID: 3, OrgID: 1}, ds)
+		require.NoError(t, err)
+		assert.Equal(t, "api-key:3", parse(t, token, &key.PublicKey).Subject)
+	})
+
+	t.Run("should not sign tokens for the anonymous users", func(t *testing.T) {
+		token, err := s.Token(&user.SignedInUser{OrgID: 1, IsAnonymous: true}, ds)
+		require.NoError(t, err)
+		assert.Empty(t, token)
+	})
+
+	t.Run("should not sign tokens for the datasources without forwardIdentityToken", func(t *testing.T) {
+		token, err := s.Token(usr, &datasources.DataSource{UID: "tempo", JsonData: simplejson.New()})
+		require.NoError(t, err)
+		assert.Empty(t, token)
+	})
+}
+
+func TestProvideService(t *testing.T) {
+	t.Run("should be disabled without signing key", func(t *testing.T) {
+		s, err := ProvideService(setting.NewCfg())
+		require.NoError(t, err)
+		require.Nil(t, s)
+		assert.Empty(t, s.Keys().Keys)
+
+		ds := &datasources.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{"forwardIdentityToken": true})}
+		assert.False(t, s.IsEnabled(ds))
+		token, err := s.Token(&user.SignedInUser{UserID: 1}, ds)
+		require.NoError(t, err)
+		assert.Empty(t, token)
+	})
+
+	t.Run("should read the PKCS #1 RSA keys", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		s := newService(t, writeKey(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)))
+
+		token, err := s.Token(&user.SignedInUser{UserID: 1}, &datasources.DataSource{UID: "loki", JsonData: simplejson.NewFromAny(map[string]interface{}{"forwardIdentityToken": true})})
+		require.NoError(t, err)
+		parsed, err := jwt.ParseSigned(token)
+		require.NoError(t, err)
+		assert.Equal(t, "RS256", parsed.Headers[0].Algorithm)
+		var claims Claims
+		require.NoError(t, parsed.Claims(&key.PublicKey, &claims))
+		assert.Equal(t, "user:1", claims.Subject)
+	})
+
+	t.Run("should fail on invalid keys", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.DataProxyIdentityToken.SigningKeyFile = writeKey(t, "PRIVATE KEY", []byte("not a key"))
+		_, err := ProvideService(cfg)
+		require.Error(t, err)
+
+		cfg.DataProxyIdentityToken.SigningKeyFile = filepath.Join(t.TempDir(), "missing.pem")
+		_, err = ProvideService(cfg)
+		require.Error(t, err)
+	})
+}
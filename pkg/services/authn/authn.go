@@ -225,6 +225,9 @@ type Identity struct {
 	ClientParams ClientParams
 	// Permissions is the list of permissions the entity has.
 	Permissions map[int64]map[string][]string
+	// QueryOnlyDatasourceUIDs limits the entity to querying these datasources, set for the service account tokens
+	// limited to querying.
+	QueryOnlyDatasourceUIDs []string
 }
 
 // Role returns the role of the identity in the active organization.
@@ -261,23 +264,24 @@ func (i *Identity) SignedInUser() *user.SignedInUser {
 	}
 
 	u := &user.SignedInUser{
-		UserID:             0,
-		OrgID:              i.OrgID,
-		OrgName:            i.OrgName,
-		OrgRole:            i.Role(),
-		ExternalAuthModule: i.AuthModule,
-		ExternalAuthID:     i.AuthID,
-		Login:              i.Login,
-		Name:               i.Name,
-		Email:              i.Email,
-		OrgCount:           i.OrgCount,
-		IsGrafanaAdmin:     isGrafanaAdmin,
-		IsAnonymous:        i.IsAnonymous,
-		IsDisabled:         i.IsDisabled,
-		HelpFlags1:         i.HelpFlags1,
-		LastSeenAt:         i.LastSeenAt,
-		Teams:              i.Teams,
-		Permissions:        i.Permissions,
+		UserID:                  0,
+		OrgID:                   i.OrgID,
+		OrgName:                 i.OrgName,
+		OrgRole:                 i.Role(),
+		ExternalAuthModule:      i.AuthModule,
+		ExternalAuthID:          i.AuthID,
+		Login:                   i.Login,
+		Name:                    i.Name,
+		Email:                   i.Email,
+		OrgCount:                i.OrgCount,
+		IsGrafanaAdmin:          isGrafanaAdmin,
+		IsAnonymous:             i.IsAnonymous,
+		IsDisabled:              i.IsDisabled,
+		HelpFlags1:              i.HelpFlags1,
+		LastSeenAt:              i.LastSeenAt,
+		Teams:                   i.Teams,
+		Permissions:             i.Permissions,
+		QueryOnlyDatasourceUIDs: i.QueryOnlyDatasourceUIDs,
 	}
 
 	namespace, id := i.NamespacedID()
@@ -311,21 +315,22 @@ func (i *Identity) ExternalUserInfo() login.ExternalUserInfo {
 // IdentityFromSignedInUser creates an identity from a SignedInUser.
 func IdentityFromSignedInUser(id string, usr *user.SignedInUser, params ClientParams) *Identity {
 	return &Identity{
-		ID:             id,
-		OrgID:          usr.OrgID,
-		OrgName:        usr.OrgName,
-		OrgRoles:       map[int64]org.RoleType{usr.OrgID: usr.OrgRole},
-		Login:          usr.Login,
-		Name:           usr.Name,
-		Email:          usr.Email,
-		OrgCount:       usr.OrgCount,
-		IsGrafanaAdmin: &usr.IsGrafanaAdmin,
-		IsDisabled:     usr.IsDisabled,
-		HelpFlags1:     usr.HelpFlags1,
-		LastSeenAt:     usr.LastSeenAt,
-		Teams:          usr.Teams,
-		ClientParams:   params,
-		Permissions:    usr.Permissions,
+		ID:                      id,
+		OrgID:                   usr.OrgID,
+		OrgName:                 usr.OrgName,
+		OrgRoles:                map[int64]org.RoleType{usr.OrgID: usr.OrgRole},
+		Login:                   usr.Login,
+		Name:                    usr.Name,
+		Email:                   usr.Email,
+		OrgCount:                usr.OrgCount,
+		IsGrafanaAdmin:          &usr.IsGrafanaAdmin,
+		IsDisabled:              usr.IsDisabled,
+		HelpFlags1:              usr.HelpFlags1,
+		LastSeenAt:              usr.LastSeenAt,
+		Teams:                   usr.Teams,
+		ClientParams:            params,
+		Permissions:             usr.Permissions,
+		QueryOnlyDatasourceUIDs: usr.QueryOnlyDatasourceUIDs,
 	}
 }
 
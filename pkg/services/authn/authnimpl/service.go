@@ -78,7 +78,7 @@ func ProvideService(
 	usageStats.RegisterMetricsFunc(s.getUsageStats)
 
 	s.RegisterClient(clients.ProvideRender(userService, renderService))
-	s.RegisterClient(clients.ProvideAPIKey(apikeyService, userService))
+	s.RegisterClient(clients.ProvideAPIKey(apikeyService, userService, cfg))
 
 	if cfg.LoginCookieName != "" {
 		s.RegisterClient(clients.ProvideSession(sessionService, userService, cfg))
@@ -3,6 +3,7 @@ package clients
 import (
 	"context"
 	"errors"
+	"net"
 	"strings"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
@@ -21,23 +23,26 @@ var (
 	errAPIKeyInvalid = errutil.NewBase(errutil.StatusUnauthorized, "api-key.invalid", errutil.WithPublicMessage("Invalid API key"))
 	errAPIKeyExpired = errutil.NewBase(errutil.StatusUnauthorized, "api-key.expired", errutil.WithPublicMessage("Expired API key"))
 	errAPIKeyRevoked = errutil.NewBase(errutil.StatusUnauthorized, "api-key.revoked", errutil.WithPublicMessage("Revoked API key"))
+	errAPIKeyBound   = errutil.NewBase(errutil.StatusUnauthorized, "api-key.bound", errutil.WithPublicMessage("API key is not allowed from this client"))
 )
 
 var _ authn.HookClient = new(APIKey)
 var _ authn.ContextAwareClient = new(APIKey)
 
-func ProvideAPIKey(apiKeyService apikey.Service, userService user.Service) *APIKey {
+func ProvideAPIKey(apiKeyService apikey.Service, userService user.Service, cfg *setting.Cfg) *APIKey {
 	return &APIKey{
-		log:           log.New(authn.ClientAPIKey),
-		userService:   userService,
-		apiKeyService: apiKeyService,
+		log:            log.New(authn.ClientAPIKey),
+		userService:    userService,
+		apiKeyService:  apiKeyService,
+		trustedProxies: cfg.TokenBindingTrustedProxies,
 	}
 }
 
 type APIKey struct {
-	log           log.Logger
-	userService   user.Service
-	apiKeyService apikey.Service
+	log            log.Logger
+	userService    user.Service
+	apiKeyService  apikey.Service
+	trustedProxies []*net.IPNet
 }
 
 func (s *APIKey) Name() string {
@@ -61,6 +66,10 @@ func (s *APIKey) Authenticate(ctx context.Context, r *authn.Request) (*authn.Ide
 		return nil, errAPIKeyRevoked.Errorf("Api key is revoked")
 	}
 
+	if err := s.checkBinding(r, apiKey); err != nil {
+		return nil, errAPIKeyBound.Errorf("%w", err)
+	}
+
 	// if the api key don't belong to a service account construct the identity and return it
 	if apiKey.ServiceAccountId == nil || *apiKey.ServiceAccountId < 1 {
 		return &authn.Identity{
@@ -80,7 +89,21 @@ func (s *APIKey) Authenticate(ctx context.Context, r *authn.Request) (*authn.Ide
 		return nil, err
 	}
 
-	return authn.IdentityFromSignedInUser(authn.NamespacedID(authn.NamespaceServiceAccount, usr.UserID), usr, authn.ClientParams{SyncPermissions: true}), nil
+	identity := authn.IdentityFromSignedInUser(authn.NamespacedID(authn.NamespaceServiceAccount, usr.UserID), usr, authn.ClientParams{SyncPermissions: true})
+	identity.QueryOnlyDatasourceUIDs = apiKey.QueryOnlyDatasourceUIDs()
+	return identity, nil
+}
+
+// checkBinding rejects the tokens bound to networks or to client certificates used by another client
+func (s *APIKey) checkBinding(r *authn.Request, apiKey *apikey.APIKey) error {
+	if len(apiKey.BoundCIDRs()) == 0 && len(apiKey.BoundClientCertFingerprints()) == 0 {
+		return nil
+	}
+	// the bound tokens are only accepted over HTTP
+	if r.HTTPRequest == nil {
+		return apikey.ErrBoundNetwork
+	}
+	return apiKey.CheckBinding(r.HTTPRequest, s.trustedProxies)
 }
 
 func (s *APIKey) getAPIKey(ctx context.Context, token string) (*apikey.APIKey, error) {
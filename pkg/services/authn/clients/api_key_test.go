@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 var (
@@ -90,6 +92,41 @@ func TestAPIKey_Authenticate(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "should set the data sources of a token limited to querying data sources",
+			req: &authn.Request{HTTPRequest: &http.Request{
+				Header: map[string][]string{
+					"Authorization": {"Bearer " + secret},
+				},
+			}},
+			expectedKey: &apikey.APIKey{
+				ID:                  1,
+				OrgID:               1,
+				Key:                 hash,
+				ServiceAccountId:    intPtr(1),
+				QueryDatasourceUIDs: strPtr("prom,loki"),
+			},
+			expectedUser: &user.SignedInUser{
+				UserID:           1,
+				OrgID:            1,
+				IsServiceAccount: true,
+				OrgCount:         1,
+				OrgRole:          org.RoleViewer,
+				Name:             "test",
+			},
+			expectedIdentity: &authn.Identity{
+				ID:                      "service-account:1",
+				OrgID:                   1,
+				OrgCount:                1,
+				Name:                    "test",
+				OrgRoles:                map[int64]org.RoleType{1: org.RoleViewer},
+				IsGrafanaAdmin:          boolPtr(false),
+				QueryOnlyDatasourceUIDs: []string{"prom", "loki"},
+				ClientParams: authn.ClientParams{
+					SyncPermissions: true,
+				},
+			},
+		},
 		{
 			desc: "should fail for expired api key",
 			req:  &authn.Request{HTTPRequest: &http.Request{Header: map[string][]string{"Authorization": {"Bearer " + secret}}}},
@@ -108,6 +145,47 @@ func TestAPIKey_Authenticate(t *testing.T) {
 			},
 			expectedErr: errAPIKeyRevoked,
 		},
+		{
+			desc: "should success for api key used from one of its networks",
+			req: &authn.Request{HTTPRequest: &http.Request{
+				RemoteAddr: "10.0.1.5:51234",
+				Header:     map[string][]string{"Authorization": {"Bearer " + secret}},
+			}},
+			expectedKey: &apikey.APIKey{
+				ID:           1,
+				OrgID:        1,
+				Key:          hash,
+				Role:         org.RoleAdmin,
+				AllowedCIDRs: strPtr("10.0.1.0/24"),
+			},
+			expectedIdentity: &authn.Identity{
+				ID:           "api-key:1",
+				OrgID:        1,
+				OrgRoles:     map[int64]org.RoleType{1: org.RoleAdmin},
+				ClientParams: authn.ClientParams{SyncPermissions: true},
+			},
+		},
+		{
+			desc: "should fail for api key used from another network",
+			req: &authn.Request{HTTPRequest: &http.Request{
+				RemoteAddr: "192.168.1.5:51234",
+				Header:     map[string][]string{"Authorization": {"Bearer " + secret}, "X-Forwarded-For": {"10.0.1.5"}},
+			}},
+			expectedKey: &apikey.APIKey{
+				Key:          hash,
+				AllowedCIDRs: strPtr("10.0.1.0/24"),
+			},
+			expectedErr: errAPIKeyBound,
+		},
+		{
+			desc: "should fail for api key bound to a client certificate used without one",
+			req:  &authn.Request{HTTPRequest: &http.Request{Header: map[string][]string{"Authorization": {"Bearer " + secret}}}},
+			expectedKey: &apikey.APIKey{
+				Key:                    hash,
+				ClientCertFingerprints: strPtr(strings.Repeat("ab", 32)),
+			},
+			expectedErr: errAPIKeyBound,
+		},
 	}
 
 	for _, tt := range tests {
@@ -116,7 +194,7 @@ func TestAPIKey_Authenticate(t *testing.T) {
 				ExpectedAPIKey: tt.expectedKey,
 			}, &usertest.FakeUserService{
 				ExpectedSignedInUser: tt.expectedUser,
-			})
+			}, setting.NewCfg())
 
 			identity, err := c.Authenticate(context.Background(), tt.req)
 			if tt.expectedErr != nil {
@@ -181,7 +259,7 @@ func TestAPIKey_Test(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			c := ProvideAPIKey(&apikeytest.Service{}, usertest.NewUserServiceFake())
+			c := ProvideAPIKey(&apikeytest.Service{}, usertest.NewUserServiceFake(), setting.NewCfg())
 			assert.Equal(t, tt.expected, c.Test(context.Background(), tt.req))
 		})
 	}
@@ -16,12 +16,15 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	acdb "github.com/grafana/grafana/pkg/services/accesscontrol/database"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ngalert/image"
 	"github.com/grafana/grafana/pkg/services/queryhistory"
 	"github.com/grafana/grafana/pkg/services/shorturls"
+	"github.com/grafana/grafana/pkg/services/store/entity/sqlstash"
 	tempuser "github.com/grafana/grafana/pkg/services/temp_user"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -43,6 +46,8 @@ func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockSe
 		tempUserService:           tempUserService,
 		tracer:                    tracer,
 		annotationCleaner:         annotationCleaner,
+		accessControlStore:        acdb.ProvideService(sqlstore),
+		auditLog:                  log.New("accesscontrol.audit"),
 	}
 	return s
 }
@@ -60,6 +65,9 @@ type CleanUpService struct {
 	deleteExpiredImageService *image.DeleteExpiredService
 	tempUserService           tempuser.Service
 	annotationCleaner         annotations.Cleaner
+	accessControlStore        *acdb.AccessControlStore
+	// auditLog records the expiry of the time-bound permissions
+	auditLog log.Logger
 }
 
 type cleanUpJob struct {
@@ -102,6 +110,8 @@ func (srv *CleanUpService) clean(ctx context.Context) {
 		{"expire old user invites", srv.expireOldUserInvites},
 		{"delete stale short URLs", srv.deleteStaleShortURLs},
 		{"delete stale query history", srv.deleteStaleQueryHistory},
+		{"delete expired permissions", srv.deleteExpiredPermissions},
+		{"delete expired entity changes", srv.deleteExpiredEntityChanges},
 	}
 
 	logger := srv.log.FromContext(ctx)
@@ -237,6 +247,39 @@ func (srv *CleanUpService) expireOldUserInvites(ctx context.Context) {
 	}
 }
 
+// deleteExpiredPermissions deletes the time-bound permissions once expired, they are already ignored by the access
+// control since their expiry
+func (srv *CleanUpService) deleteExpiredPermissions(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	expired, err := srv.accessControlStore.DeleteExpiredPermissions(ctx, time.Now())
+	if err != nil {
+		logger.Error("Problem deleting expired permissions", "error", err.Error())
+		return
+	}
+
+	// a permission on a resource is made of several actions, it is recorded once
+	type grant struct {
+		orgID   int64
+		role    string
+		scope   string
+		expires int64
+	}
+	var grants []grant
+	actions := map[grant][]string{}
+	for _, p := range expired {
+		g := grant{orgID: p.OrgID, role: p.RoleName, scope: p.Scope, expires: p.Expires}
+		if _, ok := actions[g]; !ok {
+			grants = append(grants, g)
+		}
+		actions[g] = append(actions[g], p.Action)
+	}
+	for _, g := range grants {
+		srv.auditLog.Info("Time-bound permission expired", "orgId", g.orgID, "role", g.role, "scope", g.scope,
+			"actions", actions[g], "expires", time.Unix(g.expires, 0))
+	}
+	logger.Debug("Deleted expired permissions", "rows affected", len(expired))
+}
+
 func (srv *CleanUpService) deleteStaleShortURLs(ctx context.Context) {
 	logger := srv.log.FromContext(ctx)
 	cmd := shorturls.DeleteShortUrlCommand{
@@ -249,6 +292,21 @@ func (srv *CleanUpService) deleteStaleShortURLs(ctx context.Context) {
 	}
 }
 
+// deleteExpiredEntityChanges removes the old changes of the change log of the entity store, which only exists with the
+// entityStore feature toggle
+func (srv *CleanUpService) deleteExpiredEntityChanges(ctx context.Context) {
+	if srv.Cfg.IsFeatureToggleEnabled == nil || !srv.Cfg.IsFeatureToggleEnabled(featuremgmt.FlagEntityStore) {
+		return
+	}
+	logger := srv.log.FromContext(ctx)
+	deleted, err := sqlstash.DeleteExpiredChanges(ctx, srv.store.GetSqlxSession(), time.Now())
+	if err != nil {
+		logger.Error("Problem deleting expired entity changes", "error", err.Error())
+	} else {
+		logger.Debug("Deleted expired entity changes", "rows affected", deleted)
+	}
+}
+
 func (srv *CleanUpService) deleteStaleQueryHistory(ctx context.Context) {
 	logger := srv.log.FromContext(ctx)
 	// Delete query history from 14+ days ago with exception of starred queries
@@ -358,6 +358,12 @@ func (h *ContextHandler) initContextWithAPIKey(reqContext *contextmodel.ReqConte
 		return true
 	}
 
+	if err := apiKey.CheckBinding(reqContext.Req, h.Cfg.TokenBindingTrustedProxies); err != nil {
+		reqContext.Logger.Warn("Bound token used by another client", "id", apiKey.ID, "remote_addr", reqContext.Req.RemoteAddr, "error", err)
+		reqContext.JsonApiErr(http.StatusUnauthorized, "Token is not allowed from this client", nil)
+		return true
+	}
+
 	// non-blocking update api_key last used date
 	go func(id int64) {
 		defer func() {
@@ -403,6 +409,8 @@ func (h *ContextHandler) initContextWithAPIKey(reqContext *contextmodel.ReqConte
 		return true
 	}
 
+	querySignedInUserResult.QueryOnlyDatasourceUIDs = apiKey.QueryOnlyDatasourceUIDs()
+
 	reqContext.IsSignedIn = true
 	reqContext.SignedInUser = querySignedInUserResult
 
@@ -55,9 +55,10 @@ func ProvideDashboardStore(sqlStore db.DB, cfg *setting.Cfg, features featuremgm
 	}
 
 	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
-		TargetSrv:     dashboards.QuotaTargetSrv,
-		DefaultLimits: defaultLimits,
-		Reporter:      s.Count,
+		TargetSrv:         dashboards.QuotaTargetSrv,
+		DefaultLimits:     defaultLimits,
+		Reporter:          s.Count,
+		ReadDefaultLimits: readQuotaConfig,
 	}); err != nil {
 		return nil, err
 	}
@@ -734,6 +735,11 @@ func (d *dashboardStore) deleteDashboard(cmd *dashboards.DeleteDashboardCommand,
 			return err
 		}
 
+		_, err = sess.Exec("DELETE FROM folder_settings WHERE org_id = ? AND folder_uid = ?", dashboard.OrgID, dashboard.UID)
+		if err != nil {
+			return err
+		}
+
 		for _, dash := range dashIds {
 			// remove all access control permission with child dashboard scopes
 			_, err = sess.Exec("DELETE FROM permission WHERE scope = ?", ac.GetResourceScopeUID("dashboards", dash.Uid))
@@ -1046,7 +1052,7 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 		return &quota.Map{}, err
 	}
 
-	limits.Set(globalQuotaTag, cfg.Quota.Global.Dashboard)
-	limits.Set(orgQuotaTag, cfg.Quota.Org.Dashboard)
+	limits.Set(globalQuotaTag, cfg.QuotaSettings().Global.Dashboard)
+	limits.Set(orgQuotaTag, cfg.QuotaSettings().Org.Dashboard)
 	return limits, nil
 }
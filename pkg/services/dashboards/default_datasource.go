@@ -0,0 +1,48 @@
+package dashboards
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// ApplyDefaultDatasource sets the datasource of the panels whose queries and the panel itself have no datasource,
+// including the panels of collapsed rows, to the datasource with the uid. It returns the number of panels it changed.
+func ApplyDefaultDatasource(data *simplejson.Json, uid string) int {
+	if uid == "" {
+		return 0
+	}
+	return applyPanelsDefaultDatasource(data.Get("panels").MustArray(), uid)
+}
+
+func applyPanelsDefaultDatasource(panels []interface{}, uid string) int {
+	changed := 0
+	for _, panelObj := range panels {
+		panel, ok := panelObj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// collapsed rows keep their panels nested in the row
+		if panel["type"] == "row" {
+			nested, _ := panel["panels"].([]interface{})
+			changed += applyPanelsDefaultDatasource(nested, uid)
+			continue
+		}
+
+		targets, _ := panel["targets"].([]interface{})
+		if len(targets) == 0 || panel["datasource"] != nil || targetsHaveDatasource(targets) {
+			continue
+		}
+		panel["datasource"] = map[string]interface{}{"uid": uid}
+		changed++
+	}
+	return changed
+}
+
+func targetsHaveDatasource(targets []interface{}) bool {
+	for _, targetObj := range targets {
+		if target, ok := targetObj.(map[string]interface{}); ok && target["datasource"] != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package dashboards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestApplyDefaultDatasource(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{
+		"panels": [
+			{"id": 1, "targets": [{"refId": "A", "expr": "up"}]},
+			{"id": 2, "datasource": {"uid": "loki-uid"}, "targets": [{"refId": "A"}]},
+			{"id": 3, "targets": [{"refId": "A", "datasource": {"uid": "tempo-uid"}}]},
+			{"id": 4, "type": "text"},
+			{
+				"id": 5,
+				"type": "row",
+				"panels": [
+					{"id": 6, "datasource": null, "targets": [{"refId": "A"}]}
+				]
+			}
+		]
+	}`))
+	require.NoError(t, err)
+
+	require.Equal(t, 2, ApplyDefaultDatasource(data, "prom-uid"))
+
+	panels := data.Get("panels")
+	require.Equal(t, "prom-uid", panels.GetIndex(0).Get("datasource").Get("uid").MustString())
+	require.Equal(t, "loki-uid", panels.GetIndex(1).Get("datasource").Get("uid").MustString())
+	require.Nil(t, panels.GetIndex(2).Get("datasource").Interface(), "the queries with a datasource are not changed")
+	require.Nil(t, panels.GetIndex(3).Get("datasource").Interface(), "the panels without queries are not changed")
+	require.Equal(t, "prom-uid", panels.GetIndex(4).Get("panels").GetIndex(0).Get("datasource").Get("uid").MustString())
+
+	require.Equal(t, 0, ApplyDefaultDatasource(data, ""))
+}
@@ -0,0 +1,76 @@
+package dashboards
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// QueryConversion describes the fields converted in a single panel query.
+type QueryConversion struct {
+	PanelID        int64    `json:"panelId"`
+	RefID          string   `json:"refId"`
+	DatasourceType string   `json:"datasourceType"`
+	Fields         []string `json:"fields"`
+}
+
+// ConvertDashboardQueries runs the converter of the datasource plugin of each panel query, including the panels of
+// collapsed rows. Datasource references that only contain a uid are resolved with resolveType.
+func ConvertDashboardQueries(data *simplejson.Json, converter func(pluginID string) (plugins.QueryConverter, bool), resolveType func(uid string) string) []QueryConversion {
+	var conversions []QueryConversion
+	walkPanelQueries(data.Get("panels").MustArray(), resolveType, func(panel *simplejson.Json, target map[string]interface{}, dsType string) {
+		c, ok := converter(dsType)
+		if !ok {
+			return
+		}
+
+		if fields := c.ConvertQuery(target); len(fields) > 0 {
+			refID, _ := target["refId"].(string)
+			conversions = append(conversions, QueryConversion{
+				PanelID:        panel.Get("id").MustInt64(),
+				RefID:          refID,
+				DatasourceType: dsType,
+				Fields:         fields,
+			})
+		}
+	})
+	return conversions
+}
+
+// walkPanelQueries calls fn with the datasource type of each query of the panels, including the panels of collapsed rows
+func walkPanelQueries(panels []interface{}, resolveType func(uid string) string, fn func(panel *simplejson.Json, target map[string]interface{}, dsType string)) {
+	for _, panelObj := range panels {
+		panel := simplejson.NewFromAny(panelObj)
+
+		// collapsed rows keep their panels nested in the row
+		if panel.Get("type").MustString() == "row" {
+			walkPanelQueries(panel.Get("panels").MustArray(), resolveType, fn)
+			continue
+		}
+
+		panelType := datasourceType(panel.Get("datasource"), resolveType)
+		for _, targetObj := range panel.Get("targets").MustArray() {
+			target, ok := targetObj.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			dsType := panelType
+			if ds, ok := target["datasource"]; ok {
+				if t := datasourceType(simplejson.NewFromAny(ds), resolveType); t != "" {
+					dsType = t
+				}
+			}
+			fn(panel, target, dsType)
+		}
+	}
+}
+
+func datasourceType(ref *simplejson.Json, resolveType func(uid string) string) string {
+	if t := ref.Get("type").MustString(); t != "" {
+		return t
+	}
+	if uid := ref.Get("uid").MustString(); uid != "" && resolveType != nil {
+		return resolveType(uid)
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package dashboards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+type queryConverterFunc func(query map[string]interface{}) []string
+
+func (f queryConverterFunc) ConvertQuery(query map[string]interface{}) []string {
+	return f(query)
+}
+
+func TestConvertDashboardQueries(t *testing.T) {
+	converters := map[string]plugins.QueryConverter{
+		"loki": queryConverterFunc(func(query map[string]interface{}) []string {
+			if _, ok := query["instant"]; !ok {
+				return nil
+			}
+			delete(query, "instant")
+			query["queryType"] = "instant"
+			return []string{"queryType", "instant"}
+		}),
+	}
+	resolveType := func(uid string) string {
+		if uid == "loki-uid" {
+			return "loki"
+		}
+		return ""
+	}
+
+	data, err := simplejson.NewJson([]byte(`{
+		"panels": [
+			{
+				"id": 1,
+				"datasource": {"type": "loki", "uid": "loki-uid"},
+				"targets": [
+					{"refId": "A", "expr": "{job=\"a\"}", "instant": true},
+					{"refId": "B", "expr": "{job=\"b\"}", "queryType": "range"}
+				]
+			},
+			{
+				"id": 2,
+				"datasource": {"type": "datasource", "uid": "-- Mixed --"},
+				"targets": [
+					{"refId": "A", "datasource": {"uid": "loki-uid"}, "expr": "{job=\"c\"}", "instant": true},
+					{"refId": "B", "datasource": {"type": "prometheus", "uid": "prom"}, "expr": "up", "instant": true}
+				]
+			},
+			{
+				"id": 3,
+				"type": "row",
+				"collapsed": true,
+				"panels": [
+					{
+						"id": 4,
+						"datasource": {"uid": "loki-uid"},
+						"targets": [{"refId": "C", "expr": "{job=\"d\"}", "instant": true}]
+					}
+				]
+			}
+		]
+	}`))
+	require.NoError(t, err)
+
+	converter := func(pluginID string) (plugins.QueryConverter, bool) {
+		c, ok := converters[pluginID]
+		return c, ok
+	}
+	conversions := ConvertDashboardQueries(data, converter, resolveType)
+	require.Equal(t, []QueryConversion{
+		{PanelID: 1, RefID: "A", DatasourceType: "loki", Fields: []string{"queryType", "instant"}},
+		{PanelID: 2, RefID: "A", DatasourceType: "loki", Fields: []string{"queryType", "instant"}},
+		{PanelID: 4, RefID: "C", DatasourceType: "loki", Fields: []string{"queryType", "instant"}},
+	}, conversions)
+
+	panels := data.Get("panels")
+	require.Equal(t, "instant", panels.GetIndex(0).Get("targets").GetIndex(0).Get("queryType").MustString())
+	_, hasInstant := panels.GetIndex(0).Get("targets").GetIndex(0).CheckGet("instant")
+	require.False(t, hasInstant)
+	_, hasInstant = panels.GetIndex(1).Get("targets").GetIndex(1).CheckGet("instant")
+	require.True(t, hasInstant, "queries of datasources without converter should not change")
+	require.Equal(t, "instant", panels.GetIndex(2).Get("panels").GetIndex(0).Get("targets").GetIndex(0).Get("queryType").MustString())
+}
@@ -0,0 +1,37 @@
+package dashboards
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// QueryValidation describes the problems found in a single panel query.
+type QueryValidation struct {
+	PanelID        int64    `json:"panelId"`
+	RefID          string   `json:"refId"`
+	DatasourceType string   `json:"datasourceType"`
+	Issues         []string `json:"issues"`
+}
+
+// ValidateDashboardQueries runs the validator of the datasource plugin of each panel query, including the panels of
+// collapsed rows. Datasource references that only contain a uid are resolved with resolveType.
+func ValidateDashboardQueries(data *simplejson.Json, validator func(pluginID string) (plugins.QueryValidator, bool), resolveType func(uid string) string) []QueryValidation {
+	var validations []QueryValidation
+	walkPanelQueries(data.Get("panels").MustArray(), resolveType, func(panel *simplejson.Json, target map[string]interface{}, dsType string) {
+		v, ok := validator(dsType)
+		if !ok {
+			return
+		}
+
+		if issues := v.ValidateQuery(target); len(issues) > 0 {
+			refID, _ := target["refId"].(string)
+			validations = append(validations, QueryValidation{
+				PanelID:        panel.Get("id").MustInt64(),
+				RefID:          refID,
+				DatasourceType: dsType,
+				Issues:         issues,
+			})
+		}
+	})
+	return validations
+}
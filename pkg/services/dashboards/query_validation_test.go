@@ -0,0 +1,67 @@
+package dashboards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+type queryValidatorFunc func(query map[string]interface{}) []string
+
+func (f queryValidatorFunc) ValidateQuery(query map[string]interface{}) []string {
+	return f(query)
+}
+
+func TestValidateDashboardQueries(t *testing.T) {
+	validator := func(pluginID string) (plugins.QueryValidator, bool) {
+		if pluginID != "loki" {
+			return nil, false
+		}
+		return queryValidatorFunc(func(query map[string]interface{}) []string {
+			if expr, _ := query["expr"].(string); expr == "" {
+				return []string{"query is empty"}
+			}
+			return nil
+		}), true
+	}
+
+	data, err := simplejson.NewJson([]byte(`{
+		"panels": [
+			{
+				"id": 1,
+				"datasource": {"type": "loki", "uid": "loki-uid"},
+				"targets": [
+					{"refId": "A", "expr": "{job=\"a\"}"},
+					{"refId": "B", "expr": ""}
+				]
+			},
+			{
+				"id": 2,
+				"datasource": {"type": "prometheus", "uid": "prom"},
+				"targets": [{"refId": "A", "expr": ""}]
+			},
+			{
+				"id": 3,
+				"type": "row",
+				"collapsed": true,
+				"panels": [
+					{
+						"id": 4,
+						"datasource": {"uid": "loki-uid"},
+						"targets": [{"refId": "C"}]
+					}
+				]
+			}
+		]
+	}`))
+	require.NoError(t, err)
+
+	validations := ValidateDashboardQueries(data, validator, func(uid string) string { return "loki" })
+	require.Equal(t, []QueryValidation{
+		{PanelID: 1, RefID: "B", DatasourceType: "loki", Issues: []string{"query is empty"}},
+		{PanelID: 4, RefID: "C", DatasourceType: "loki", Issues: []string{"query is empty"}},
+	}, validations)
+}
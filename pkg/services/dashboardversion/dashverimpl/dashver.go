@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
@@ -127,6 +129,45 @@ func (s *Service) List(ctx context.Context, query *dashver.ListDashboardVersions
 	return dtos, nil
 }
 
+// Diff classifies the changes between two versions of a dashboard. The first
+// version of a dashboard is compared to an empty dashboard.
+func (s *Service) Diff(ctx context.Context, query *dashver.DiffDashboardVersionsQuery) (*dashver.DashboardVersionDiff, error) {
+	newVersion, err := s.Get(ctx, &dashver.GetDashboardVersionQuery{
+		DashboardID:  query.DashboardID,
+		DashboardUID: query.DashboardUID,
+		OrgID:        query.OrgID,
+		Version:      query.NewVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	baseVersion := query.BaseVersion
+	if baseVersion == 0 {
+		baseVersion = newVersion.ParentVersion
+	}
+	baseData := simplejson.New()
+	if baseVersion > 0 {
+		base, err := s.Get(ctx, &dashver.GetDashboardVersionQuery{
+			DashboardID:  newVersion.DashboardID,
+			DashboardUID: newVersion.DashboardUID,
+			OrgID:        query.OrgID,
+			Version:      baseVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+		baseData = base.Data
+	}
+
+	return &dashver.DashboardVersionDiff{
+		DashboardUID: newVersion.DashboardUID,
+		BaseVersion:  baseVersion,
+		NewVersion:   newVersion.Version,
+		SemanticDiff: *dashdiffs.CalculateSemanticDiff(baseData, newVersion.Data),
+	}, nil
+}
+
 // getDashUIDMaybeEmpty is a helper function which takes a dashboardID and
 // returns the UID. If the dashboard is not found, it will return an empty
 // string.
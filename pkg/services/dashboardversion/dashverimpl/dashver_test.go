@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
@@ -144,12 +145,61 @@ func TestListDashboardVersions(t *testing.T) {
 	})
 }
 
+func TestDiffDashboardVersions(t *testing.T) {
+	version := func(v, parent int, data string) *dashver.DashboardVersion {
+		json, err := simplejson.NewJson([]byte(data))
+		require.NoError(t, err)
+		return &dashver.DashboardVersion{DashboardID: 42, Version: v, ParentVersion: parent, Data: json}
+	}
+
+	dashboardVersionStore := newDashboardVersionStoreFake()
+	dashboardVersionStore.ExpectedDashboardVersionsByVersion = map[int]*dashver.DashboardVersion{
+		1: version(1, 0, `{"title": "Services", "panels": [{"id": 1, "gridPos": {"x": 0}}]}`),
+		2: version(2, 1, `{"title": "Services", "panels": [{"id": 1, "gridPos": {"x": 12}}]}`),
+		3: version(3, 2, `{"title": "All services", "panels": [{"id": 1, "gridPos": {"x": 12}}]}`),
+	}
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).
+		Return(&dashboards.Dashboard{ID: 42}, nil).Once()
+	dashboardVersionService := Service{store: dashboardVersionStore, dashSvc: dashboardService, log: log.NewNopLogger()}
+
+	t.Run("compares the version to its parent by default", func(t *testing.T) {
+		diff, err := dashboardVersionService.Diff(context.Background(), &dashver.DiffDashboardVersionsQuery{DashboardUID: "uid", NewVersion: 2})
+		require.NoError(t, err)
+		require.Equal(t, "uid", diff.DashboardUID)
+		require.Equal(t, 1, diff.BaseVersion)
+		require.Equal(t, 2, diff.NewVersion)
+		require.True(t, diff.LayoutOnly)
+	})
+
+	t.Run("compares the version to the base version", func(t *testing.T) {
+		diff, err := dashboardVersionService.Diff(context.Background(), &dashver.DiffDashboardVersionsQuery{DashboardID: 42, DashboardUID: "uid", BaseVersion: 1, NewVersion: 3})
+		require.NoError(t, err)
+		require.Equal(t, []dashdiffs.ChangeKind{dashdiffs.ChangeKindLayout, dashdiffs.ChangeKindSettings}, diff.Kinds)
+		require.Equal(t, []string{"title"}, diff.Settings)
+	})
+
+	t.Run("compares the first version to an empty dashboard", func(t *testing.T) {
+		diff, err := dashboardVersionService.Diff(context.Background(), &dashver.DiffDashboardVersionsQuery{DashboardID: 42, DashboardUID: "uid", NewVersion: 1})
+		require.NoError(t, err)
+		require.Equal(t, 0, diff.BaseVersion)
+		require.Equal(t, []dashdiffs.ChangeKind{dashdiffs.ChangeKindPanel, dashdiffs.ChangeKindSettings}, diff.Kinds)
+	})
+
+	t.Run("returns an error when a version is not found", func(t *testing.T) {
+		_, err := dashboardVersionService.Diff(context.Background(), &dashver.DiffDashboardVersionsQuery{DashboardID: 42, DashboardUID: "uid", BaseVersion: 5, NewVersion: 3})
+		require.ErrorIs(t, err, dashver.ErrDashboardVersionNotFound)
+	})
+}
+
 type FakeDashboardVersionStore struct {
 	ExpectedDashboardVersion *dashver.DashboardVersion
-	ExptectedDeletedVersions int64
-	ExpectedVersions         []interface{}
-	ExpectedListVersions     []*dashver.DashboardVersion
-	ExpectedError            error
+	// ExpectedDashboardVersionsByVersion are returned by Get by version, instead of ExpectedDashboardVersion
+	ExpectedDashboardVersionsByVersion map[int]*dashver.DashboardVersion
+	ExptectedDeletedVersions           int64
+	ExpectedVersions                   []interface{}
+	ExpectedListVersions               []*dashver.DashboardVersion
+	ExpectedError                      error
 }
 
 func newDashboardVersionStoreFake() *FakeDashboardVersionStore {
@@ -157,6 +207,13 @@ func newDashboardVersionStoreFake() *FakeDashboardVersionStore {
 }
 
 func (f *FakeDashboardVersionStore) Get(ctx context.Context, query *dashver.GetDashboardVersionQuery) (*dashver.DashboardVersion, error) {
+	if f.ExpectedDashboardVersionsByVersion != nil {
+		v, ok := f.ExpectedDashboardVersionsByVersion[query.Version]
+		if !ok {
+			return nil, dashver.ErrDashboardVersionNotFound
+		}
+		return v, f.ExpectedError
+	}
 	return f.ExpectedDashboardVersion, f.ExpectedError
 }
 
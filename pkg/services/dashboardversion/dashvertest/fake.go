@@ -10,6 +10,7 @@ type FakeDashboardVersionService struct {
 	ExpectedDashboardVersion     *dashver.DashboardVersionDTO
 	ExpectedDashboardVersions    []*dashver.DashboardVersionDTO
 	ExpectedListDashboarVersions []*dashver.DashboardVersionDTO
+	ExpectedDashboardVersionDiff *dashver.DashboardVersionDiff
 	counter                      int
 	ExpectedError                error
 }
@@ -33,3 +34,7 @@ func (f *FakeDashboardVersionService) DeleteExpired(ctx context.Context, cmd *da
 func (f *FakeDashboardVersionService) List(ctx context.Context, query *dashver.ListDashboardVersionsQuery) ([]*dashver.DashboardVersionDTO, error) {
 	return f.ExpectedListDashboarVersions, f.ExpectedError
 }
+
+func (f *FakeDashboardVersionService) Diff(ctx context.Context, query *dashver.DiffDashboardVersionsQuery) (*dashver.DashboardVersionDiff, error) {
+	return f.ExpectedDashboardVersionDiff, f.ExpectedError
+}
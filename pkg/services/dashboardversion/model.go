@@ -4,6 +4,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
@@ -67,6 +68,25 @@ type ListDashboardVersionsQuery struct {
 	Start        int
 }
 
+// DiffDashboardVersionsQuery is used to compare two versions of a dashboard.
+// Only one of DashboardID and DashboardUID are required. The new version is
+// compared to its parent version when BaseVersion is 0.
+type DiffDashboardVersionsQuery struct {
+	DashboardID  int64
+	DashboardUID string
+	OrgID        int64
+	BaseVersion  int
+	NewVersion   int
+}
+
+// DashboardVersionDiff is the structured diff of two versions of a dashboard.
+type DashboardVersionDiff struct {
+	DashboardUID string `json:"dashboardUid"`
+	BaseVersion  int    `json:"baseVersion"`
+	NewVersion   int    `json:"newVersion"`
+	dashdiffs.SemanticDiff
+}
+
 type DashboardVersionDTO struct {
 	ID            int64            `json:"id"`
 	DashboardID   int64            `json:"dashboardId"`
@@ -0,0 +1,220 @@
+package datasourceproxy
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+const (
+	// maxTrackedCalls limits the calls counted separately in the usage report, the others are counted together
+	maxTrackedCalls = 10000
+	otherCalls      = "other"
+	maxCallerLength = 100
+)
+
+var (
+	accessLogger = log.New("data-proxy-access")
+
+	proxyCallsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "datasource_proxy_calls_total",
+		Help:      "Number of calls of the data source proxy, by data source type and whether they were rejected because the type is backend-only",
+	}, []string{"datasource_type", "rejected"})
+
+	dashboardRefererRegexp = regexp.MustCompile(`^/d(?:-solo)?/([^/]+)`)
+	versionSegmentRegexp   = regexp.MustCompile(`^v\d+(?:\.\d+)*$`)
+	digitRegexp            = regexp.MustCompile(`\d`)
+)
+
+// ProxyCallUsage counts the calls of the data source proxy of a caller to a route of a data source
+type ProxyCallUsage struct {
+	DatasourceType string `json:"datasourceType"`
+	DatasourceUID  string `json:"datasourceUid"`
+	// Route is the plugin route matched by the calls, or their path with the IDs replaced by *
+	Route string `json:"route"`
+	// Caller is the dashboard, Grafana page, service account or user agent the calls come from
+	Caller string `json:"caller"`
+	Count  int64  `json:"count"`
+	// Rejected counts the calls rejected because the type of the data source is backend-only
+	Rejected int64     `json:"rejected"`
+	LastCall time.Time `json:"lastCall"`
+}
+
+// ProxyUsageReport lists the calls of the data source proxy of an organization since Grafana started, to migrate
+// their callers to the backend of the data sources before making their types backend-only
+type ProxyUsageReport struct {
+	Since time.Time `json:"since"`
+	// BackendOnlyTypes are the types of the data sources the data source proxy rejects the calls of
+	BackendOnlyTypes []string         `json:"backendOnlyTypes"`
+	Calls            []ProxyCallUsage `json:"calls"`
+}
+
+type proxyCallKey struct {
+	orgID          int64
+	datasourceType string
+	datasourceUID  string
+	route          string
+	caller         string
+}
+
+// proxyUsage counts the calls of the data source proxy by organization, data source, route and caller
+type proxyUsage struct {
+	since time.Time
+	mu    sync.Mutex
+	calls map[proxyCallKey]*ProxyCallUsage
+}
+
+func newProxyUsage() *proxyUsage {
+	return &proxyUsage{since: time.Now(), calls: map[proxyCallKey]*ProxyCallUsage{}}
+}
+
+func (u *proxyUsage) record(key proxyCallKey, rejected bool, at time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usage, ok := u.calls[key]
+	if !ok {
+		if len(u.calls) >= maxTrackedCalls {
+			key = proxyCallKey{orgID: key.orgID, datasourceType: key.datasourceType, datasourceUID: key.datasourceUID, route: otherCalls, caller: otherCalls}
+			usage, ok = u.calls[key]
+		}
+		if !ok {
+			usage = &ProxyCallUsage{DatasourceType: key.datasourceType, DatasourceUID: key.datasourceUID, Route: key.route, Caller: key.caller}
+			u.calls[key] = usage
+		}
+	}
+	usage.Count++
+	if rejected {
+		usage.Rejected++
+	}
+	usage.LastCall = at
+}
+
+// report returns the calls of the organization, the most frequent first
+func (u *proxyUsage) report(orgID int64) []ProxyCallUsage {
+	u.mu.Lock()
+	calls := []ProxyCallUsage{}
+	for key, usage := range u.calls {
+		if key.orgID == orgID {
+			calls = append(calls, *usage)
+		}
+	}
+	u.mu.Unlock()
+
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].Count != calls[j].Count {
+			return calls[i].Count > calls[j].Count
+		}
+		return calls[i].LastCall.After(calls[j].LastCall)
+	})
+	return calls
+}
+
+// UsageReport returns the calls of the data source proxy of the organization since Grafana started
+func (p *DataSourceProxyService) UsageReport(orgID int64) ProxyUsageReport {
+	backendOnlyTypes := make([]string, 0, len(p.Cfg.DataProxyBackendOnlyTypes))
+	for dsType := range p.Cfg.DataProxyBackendOnlyTypes {
+		backendOnlyTypes = append(backendOnlyTypes, dsType)
+	}
+	sort.Strings(backendOnlyTypes)
+	return ProxyUsageReport{Since: p.usage.since, BackendOnlyTypes: backendOnlyTypes, Calls: p.usage.report(orgID)}
+}
+
+// recordAccess counts and logs the call of the data source proxy, and returns whether it is rejected because the
+// type of the data source is backend-only
+func (p *DataSourceProxyService) recordAccess(c *contextmodel.ReqContext, ds *datasources.DataSource, routes []*plugins.Route, proxyPath string) bool {
+	rejected := p.Cfg.DataProxyBackendOnlyTypes[ds.Type]
+	route := proxyRoute(routes, c.Req.Method, proxyPath)
+	caller := proxyCaller(c, p.Cfg.AppSubURL)
+
+	p.usage.record(proxyCallKey{orgID: c.OrgID, datasourceType: ds.Type, datasourceUID: ds.UID, route: route, caller: caller}, rejected, time.Now())
+	proxyCallsTotal.WithLabelValues(ds.Type, boolLabel(rejected)).Inc()
+
+	if p.Cfg.DataProxyAccessLog {
+		accessLogger.FromContext(c.Req.Context()).Info("Data source proxy call",
+			"caller", caller,
+			"plugin", ds.Type,
+			"datasource", ds.UID,
+			"route", route,
+			"method", c.Req.Method,
+			"userId", c.UserID,
+			"orgId", c.OrgID,
+			"rejected", rejected)
+	}
+	return rejected
+}
+
+// proxyRoute returns the plugin route matched by the call, like the data source proxy matches them, or the path of
+// the call with the segments holding IDs replaced by * so that the calls of a route are counted together
+func proxyRoute(routes []*plugins.Route, method string, proxyPath string) string {
+	for _, route := range routes {
+		if route.Method != "" && route.Method != "*" && route.Method != method {
+			continue
+		}
+		if strings.HasPrefix(proxyPath, route.Path) {
+			return route.Path
+		}
+	}
+
+	segments := strings.Split(strings.Trim(proxyPath, "/"), "/")
+	for i, segment := range segments {
+		if digitRegexp.MatchString(segment) && !versionSegmentRegexp.MatchString(segment) {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// proxyCaller returns where the call comes from: the service account making it, the dashboard or the Grafana page
+// of the frontend, or the user agent of the other clients
+func proxyCaller(c *contextmodel.ReqContext, appSubURL string) string {
+	if c.SignedInUser != nil && c.SignedInUser.IsServiceAccount {
+		return "service-account:" + c.SignedInUser.Login
+	}
+	if uid := c.Req.Header.Get("X-Dashboard-Uid"); uid != "" {
+		return "dashboard:" + uid
+	}
+	if referer, err := url.Parse(c.Req.Referer()); err == nil && referer.Path != "" {
+		page := strings.TrimPrefix(referer.Path, appSubURL)
+		if m := dashboardRefererRegexp.FindStringSubmatch(page); m != nil {
+			return "dashboard:" + m[1]
+		}
+		return truncateCaller("page:" + page)
+	}
+	if userAgent := c.Req.UserAgent(); userAgent != "" {
+		return truncateCaller("user-agent:" + userAgent)
+	}
+	return "unknown"
+}
+
+func truncateCaller(caller string) string {
+	if len(caller) > maxCallerLength {
+		return caller[:maxCallerLength]
+	}
+	return caller
+}
+
+func boolLabel(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// rejectBackendOnly rejects the call of a data source whose type is backend-only
+func rejectBackendOnly(c *contextmodel.ReqContext, ds *datasources.DataSource) {
+	c.JsonApiErr(http.StatusForbidden, "The data source proxy is disabled for the "+ds.Type+" data sources, they are queried through their backend with /api/ds/query and their resources", nil)
+}
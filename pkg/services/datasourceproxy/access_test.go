@@ -0,0 +1,123 @@
+package datasourceproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/validations"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestProxyRoute(t *testing.T) {
+	routes := []*plugins.Route{
+		{Path: "api/v1/rules", Method: "POST"},
+		{Path: "api/v1/labels"},
+	}
+	testCases := []struct {
+		method    string
+		proxyPath string
+		exp       string
+	}{
+		{"GET", "api/v1/labels/job/values", "api/v1/labels"},
+		{"POST", "api/v1/rules/ns", "api/v1/rules"},
+		{"GET", "api/v1/rules/ns", "api/v1/rules/ns"},
+		{"GET", "api/v1/query_range", "api/v1/query_range"},
+		{"GET", "api/traces/3f2a9c01e5b4d6a7", "api/traces/*"},
+		{"GET", "/loki/api/v1/tail/", "loki/api/v1/tail"},
+		{"GET", "index-2023.05.04/_search", "*/_search"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.exp, proxyRoute(routes, tc.method, tc.proxyPath), tc.proxyPath)
+	}
+}
+
+func TestProxyCaller(t *testing.T) {
+	newCtx := func(headers map[string]string, signedInUser *user.SignedInUser) *contextmodel.ReqContext {
+		req := httptest.NewRequest("GET", "/api/datasources/proxy/uid/prom/api/v1/query", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return &contextmodel.ReqContext{Context: &web.Context{Req: req}, SignedInUser: signedInUser}
+	}
+	viewer := &user.SignedInUser{UserID: 1, OrgID: 1, Login: "viewer"}
+
+	assert.Equal(t, "service-account:sa-reporting", proxyCaller(newCtx(map[string]string{"X-Dashboard-Uid": "abc"}, &user.SignedInUser{Login: "sa-reporting", IsServiceAccount: true}), ""))
+	assert.Equal(t, "dashboard:abc", proxyCaller(newCtx(map[string]string{"X-Dashboard-Uid": "abc"}, viewer), ""))
+	assert.Equal(t, "dashboard:xyz", proxyCaller(newCtx(map[string]string{"Referer": "https://grafana.example.com/grafana/d/xyz/my-dashboard?orgId=1"}, viewer), "/grafana"))
+	assert.Equal(t, "page:/explore", proxyCaller(newCtx(map[string]string{"Referer": "https://grafana.example.com/explore?left=x"}, viewer), ""))
+	assert.Equal(t, "user-agent:curl/8.0.1", proxyCaller(newCtx(map[string]string{"User-Agent": "curl/8.0.1"}, viewer), ""))
+	assert.Equal(t, "unknown", proxyCaller(newCtx(map[string]string{"User-Agent": ""}, viewer), ""))
+}
+
+func TestProxyUsage(t *testing.T) {
+	usage := newProxyUsage()
+	now := time.Now()
+	key := proxyCallKey{orgID: 1, datasourceType: "prometheus", datasourceUID: "prom", route: "api/v1/query", caller: "dashboard:abc"}
+	usage.record(key, false, now.Add(-time.Minute))
+	usage.record(key, true, now)
+	usage.record(proxyCallKey{orgID: 1, datasourceType: "loki", datasourceUID: "loki", route: "loki/api/v1/labels", caller: "page:/explore"}, false, now)
+	usage.record(proxyCallKey{orgID: 2, datasourceType: "loki", datasourceUID: "loki", route: "loki/api/v1/labels", caller: "page:/explore"}, false, now)
+
+	report := usage.report(1)
+	require.Len(t, report, 2)
+	assert.Equal(t, ProxyCallUsage{DatasourceType: "prometheus", DatasourceUID: "prom", Route: "api/v1/query", Caller: "dashboard:abc", Count: 2, Rejected: 1, LastCall: now}, report[0])
+	assert.Equal(t, "loki", report[1].DatasourceType)
+	assert.Len(t, usage.report(2), 1)
+	assert.Empty(t, usage.report(3))
+
+	t.Run("the calls are counted together once too many are tracked", func(t *testing.T) {
+		usage := newProxyUsage()
+		for i := 0; i < maxTrackedCalls+5; i++ {
+			usage.record(proxyCallKey{orgID: 1, datasourceType: "loki", datasourceUID: "loki", route: "route", caller: time.Duration(i).String()}, false, now)
+		}
+		report := usage.report(1)
+		require.Len(t, report, maxTrackedCalls+1)
+		assert.Equal(t, otherCalls, report[0].Caller)
+		assert.Equal(t, int64(5), report[0].Count)
+	})
+}
+
+func TestBackendOnlyTypes(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.DataProxyBackendOnlyTypes = map[string]bool{"prometheus": true}
+	p := ProvideService(
+		&fakeDatasources.FakeCacheService{DataSources: []*datasources.DataSource{
+			{UID: "prom", OrgID: 1, Type: "prometheus", URL: "http://localhost:9090"},
+		}},
+		validations.ProvideValidator(),
+		plugins.FakePluginStore{PluginList: []plugins.PluginDTO{{JSONData: plugins.JSONData{ID: "prometheus"}}}},
+		cfg, nil, nil, nil, nil, nil, nil, nil,
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/datasources/proxy/uid/prom/api/v1/query?query=up", nil)
+	req.Header.Set("X-Dashboard-Uid", "abc")
+	c := &contextmodel.ReqContext{
+		Context:      &web.Context{Req: req, Resp: web.NewResponseWriter("GET", rec)},
+		SignedInUser: &user.SignedInUser{UserID: 1, OrgID: 1},
+		Logger:       log.New("test"),
+	}
+	p.ProxyDatasourceRequestWithUID(c, "prom")
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "The data source proxy is disabled for the prometheus data sources")
+
+	report := p.UsageReport(1)
+	assert.Equal(t, []string{"prometheus"}, report.BackendOnlyTypes)
+	require.Len(t, report.Calls, 1)
+	assert.Equal(t, "api/v1/query", report.Calls[0].Route)
+	assert.Equal(t, "dashboard:abc", report.Calls[0].Caller)
+	assert.Equal(t, int64(1), report.Calls[0].Rejected)
+}
@@ -1,11 +1,14 @@
 package datasourceproxy
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"regexp"
 	"strconv"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/datasource"
 	"github.com/grafana/grafana/pkg/api/pluginproxy"
@@ -13,9 +16,12 @@ import (
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/auth/identitytoken"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/validations"
 	"github.com/grafana/grafana/pkg/setting"
@@ -26,7 +32,8 @@ import (
 func ProvideService(dataSourceCache datasources.CacheService, plugReqValidator validations.PluginRequestValidator,
 	pluginStore plugins.Store, cfg *setting.Cfg, httpClientProvider httpclient.Provider,
 	oauthTokenService *oauthtoken.Service, dsService datasources.DataSourceService,
-	tracer tracing.Tracer, secretsService secrets.Service) *DataSourceProxyService {
+	tracer tracing.Tracer, secretsService secrets.Service, identityTokens *identitytoken.Service,
+	queryAudit queryaudit.Service) *DataSourceProxyService {
 	return &DataSourceProxyService{
 		DataSourceCache:        dataSourceCache,
 		PluginRequestValidator: plugReqValidator,
@@ -37,6 +44,9 @@ func ProvideService(dataSourceCache datasources.CacheService, plugReqValidator v
 		DataSourcesService:     dsService,
 		tracer:                 tracer,
 		secretsService:         secretsService,
+		identityTokens:         identityTokens,
+		queryAudit:             queryAudit,
+		usage:                  newProxyUsage(),
 	}
 }
 
@@ -50,6 +60,9 @@ type DataSourceProxyService struct {
 	DataSourcesService     datasources.DataSourceService
 	tracer                 tracing.Tracer
 	secretsService         secrets.Service
+	identityTokens         *identitytoken.Service
+	queryAudit             queryaudit.Service
+	usage                  *proxyUsage
 }
 
 func (p *DataSourceProxyService) ProxyDataSourceRequest(c *contextmodel.ReqContext) {
@@ -119,8 +132,14 @@ func (p *DataSourceProxyService) proxyDatasourceRequest(c *contextmodel.ReqConte
 	}
 
 	proxyPath := getProxyPath(c)
+	// the calls of Grafana itself, such as the alerting calls to the rulers, are not counted as deprecated calls
+	if isProxyAPICall(c) && p.recordAccess(c, ds, plugin.Routes, proxyPath) {
+		rejectBackendOnly(c, ds)
+		return
+	}
+
 	proxy, err := pluginproxy.NewDataSourceProxy(ds, plugin.Routes, c, proxyPath, p.Cfg, p.HTTPClientProvider,
-		p.OAuthTokenService, p.DataSourcesService, p.tracer)
+		p.OAuthTokenService, p.DataSourcesService, p.tracer, p.identityTokens)
 	if err != nil {
 		if errors.Is(err, datasource.URLValidationError{}) {
 			c.JsonApiErr(http.StatusBadRequest, fmt.Sprintf("Invalid data source URL: %q", ds.URL), err)
@@ -129,7 +148,74 @@ func (p *DataSourceProxyService) proxyDatasourceRequest(c *contextmodel.ReqConte
 		}
 		return
 	}
+
+	if p.queryAudit == nil || p.queryAudit.IsDisabled() {
+		proxy.HandleRequest()
+		return
+	}
+	if err := bufferBody(c.Req); err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "Failed to read the request body", err)
+		return
+	}
+	start := time.Now()
 	proxy.HandleRequest()
+	p.recordProxyRequest(c, ds, proxyPath, start)
+}
+
+// bufferBody reads the body of the request so it can be read again with GetBody once it is proxied
+func bufferBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if err := req.Body.Close(); err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return nil
+}
+
+// recordProxyRequest records the proxied request in the query audit, as it was sent to the datasource: the team
+// overrides replace the query string and the body of the request with the enforced ones
+func (p *DataSourceProxyService) recordProxyRequest(c *contextmodel.ReqContext, ds *datasources.DataSource, proxyPath string,
+	start time.Time) {
+	var body []byte
+	if c.Req.GetBody != nil {
+		if r, err := c.Req.GetBody(); err == nil {
+			body, _ = io.ReadAll(r)
+		}
+	}
+
+	e := queryaudit.Event{
+		Timestamp:      start,
+		Kind:           queryaudit.KindProxy,
+		OrgID:          ds.OrgID,
+		RemoteAddr:     c.RemoteAddr(),
+		DatasourceUID:  ds.UID,
+		DatasourceName: ds.Name,
+		DatasourceType: ds.Type,
+		DashboardUID:   c.Req.Header.Get(query.HeaderDashboardUID),
+		PanelID:        c.Req.Header.Get(query.HeaderPanelID),
+		Query: queryaudit.NewHTTPRequestQuery(c.Req.Method, "/"+proxyPath, c.Req.URL.Query(),
+			c.Req.Header.Get("Content-Type"), body),
+		DurationMs: time.Since(start).Milliseconds(),
+		Status:     queryaudit.StatusSuccess,
+	}
+	if c.SignedInUser != nil {
+		e.UserID = c.UserID
+		e.UserLogin = c.Login
+		e.IsServiceAccount = c.IsServiceAccount
+	}
+	if status := c.Resp.Status(); status >= http.StatusBadRequest {
+		e.Status, e.Error = queryaudit.StatusError, fmt.Sprintf("the datasource returned the status %d", status)
+	}
+	p.queryAudit.Record(e)
 }
 
 var proxyPathRegexp = regexp.MustCompile(`^\/api\/datasources\/proxy\/([\d]+|uid\/[\w-]+)\/?`)
@@ -138,6 +224,11 @@ func extractProxyPath(originalRawPath string) string {
 	return proxyPathRegexp.ReplaceAllString(originalRawPath, "")
 }
 
+// isProxyAPICall returns whether the request is a call of the /api/datasources/proxy API
+func isProxyAPICall(c *contextmodel.ReqContext) bool {
+	return proxyPathRegexp.MatchString(c.Req.URL.EscapedPath())
+}
+
 func getProxyPath(c *contextmodel.ReqContext) string {
 	return extractProxyPath(c.Req.URL.EscapedPath())
 }
@@ -1,9 +1,29 @@
 package datasourceproxy
 
 import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/plugins"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/validations"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 func TestDataProxy(t *testing.T) {
@@ -52,3 +72,75 @@ func TestDataProxy(t *testing.T) {
 		}
 	})
 }
+
+type fakeQueryAudit struct {
+	queryaudit.Service
+	events []queryaudit.Event
+}
+
+func (f *fakeQueryAudit) IsDisabled() bool {
+	return false
+}
+
+func (f *fakeQueryAudit) Record(events ...queryaudit.Event) {
+	f.events = append(f.events, events...)
+}
+
+func TestQueryAudit(t *testing.T) {
+	var received string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(backend.Close)
+
+	jsonData, err := simplejson.NewJson([]byte(`{"teamOverrides": {"1": {"labelMatchers": ["namespace=\"a\""]}}}`))
+	require.NoError(t, err)
+	audit := &fakeQueryAudit{}
+	p := ProvideService(
+		&fakeDatasources.FakeCacheService{DataSources: []*datasources.DataSource{
+			{UID: "prom", Name: "Prometheus", OrgID: 1, Type: "prometheus", URL: backend.URL, JsonData: jsonData},
+		}},
+		validations.ProvideValidator(),
+		plugins.FakePluginStore{PluginList: []plugins.PluginDTO{{JSONData: plugins.JSONData{
+			ID:     "prometheus",
+			Routes: []*plugins.Route{{Path: "api/v1/query", Method: http.MethodPost}},
+		}}}},
+		setting.NewCfg(), httpclient.NewProvider(), nil, &fakeDatasources.FakeDataSourceService{},
+		tracing.InitializeTracerForTest(), nil, nil, audit,
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/datasources/proxy/uid/prom/api/v1/query?time=10",
+		strings.NewReader("query=sum(up)"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Dashboard-Uid", "abc")
+	c := &contextmodel.ReqContext{
+		Context:      &web.Context{Req: req, Resp: web.NewResponseWriter(http.MethodPost, rec)},
+		SignedInUser: &user.SignedInUser{UserID: 5, OrgID: 1, Login: "auditor", Teams: []int64{1}},
+		Logger:       log.New("test"),
+	}
+	p.ProxyDatasourceRequestWithUID(c, "prom")
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "query=sum%28up%7Bnamespace%3D%22a%22%7D%29", received)
+
+	// the request is recorded as it was sent to the datasource, with the label matchers of the team
+	require.Len(t, audit.events, 1)
+	e := audit.events[0]
+	assert.Equal(t, queryaudit.KindProxy, e.Kind)
+	assert.Equal(t, int64(1), e.OrgID)
+	assert.Equal(t, int64(5), e.UserID)
+	assert.Equal(t, "auditor", e.UserLogin)
+	assert.Equal(t, "prom", e.DatasourceUID)
+	assert.Equal(t, "prometheus", e.DatasourceType)
+	assert.Equal(t, "abc", e.DashboardUID)
+	assert.Equal(t, queryaudit.StatusSuccess, e.Status)
+	var query queryaudit.HTTPRequest
+	require.NoError(t, json.Unmarshal(e.Query, &query))
+	assert.Equal(t, http.MethodPost, query.Method)
+	assert.Equal(t, "/api/v1/query", query.Path)
+	assert.Equal(t, "10", query.Params.Get("time"))
+	assert.JSONEq(t, `{"query": ["sum(up{namespace=\"a\"})"]}`, string(query.Body))
+}
@@ -0,0 +1,71 @@
+package capabilities
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const kvNamespace = "datasource-capabilities"
+
+type Service interface {
+	// Save stores the capabilities the datasource published in its last health check
+	Save(ctx context.Context, orgID int64, datasourceUID string, capabilities Capabilities) error
+	// Delete removes the capabilities of the datasource, when its health check no longer publishes any
+	Delete(ctx context.Context, orgID int64, datasourceUID string) error
+	// GetAll returns the capabilities of the datasources of the organization, by datasource UID
+	GetAll(ctx context.Context, orgID int64) (map[string]Capabilities, error)
+}
+
+// CapabilitiesService stores the capabilities in the key-value store, so that they are shared by the instances of
+// Grafana and kept until the next health check of the datasources
+type CapabilitiesService struct {
+	kv  kvstore.KVStore
+	log log.Logger
+}
+
+func ProvideService(kv kvstore.KVStore, bus bus.Bus) *CapabilitiesService {
+	s := &CapabilitiesService{
+		kv:  kv,
+		log: log.New("datasources.capabilities"),
+	}
+	bus.AddEventListener(s.handleDatasourceDeletion)
+	return s
+}
+
+func (s *CapabilitiesService) Save(ctx context.Context, orgID int64, datasourceUID string, capabilities Capabilities) error {
+	value, err := json.Marshal(capabilities)
+	if err != nil {
+		return err
+	}
+	return kvstore.WithNamespace(s.kv, orgID, kvNamespace).Set(ctx, datasourceUID, string(value))
+}
+
+func (s *CapabilitiesService) Delete(ctx context.Context, orgID int64, datasourceUID string) error {
+	return kvstore.WithNamespace(s.kv, orgID, kvNamespace).Del(ctx, datasourceUID)
+}
+
+func (s *CapabilitiesService) GetAll(ctx context.Context, orgID int64) (map[string]Capabilities, error) {
+	items, err := kvstore.WithNamespace(s.kv, orgID, kvNamespace).GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]Capabilities, len(items[orgID]))
+	for uid, value := range items[orgID] {
+		var capabilities Capabilities
+		if err := json.Unmarshal([]byte(value), &capabilities); err != nil {
+			s.log.Warn("Ignoring invalid datasource capabilities", "datasource", uid, "error", err)
+			continue
+		}
+		all[uid] = capabilities
+	}
+	return all, nil
+}
+
+func (s *CapabilitiesService) handleDatasourceDeletion(ctx context.Context, event *events.DataSourceDeleted) error {
+	return s.Delete(ctx, event.OrgID, event.UID)
+}
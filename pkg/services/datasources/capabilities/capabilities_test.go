@@ -0,0 +1,57 @@
+package capabilities
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestFromHealthDetails(t *testing.T) {
+	capabilities, ok := FromHealthDetails(map[string]interface{}{
+		"capabilities":   map[string]interface{}{"streaming": true, "tagsV2": false, "version": "2.2.0"},
+		"verboseMessage": "ok",
+	})
+	require.True(t, ok)
+	assert.Equal(t, Capabilities{"streaming": true, "tagsV2": false}, capabilities)
+
+	_, ok = FromHealthDetails(map[string]interface{}{"verboseMessage": "ok"})
+	assert.False(t, ok)
+	_, ok = FromHealthDetails(nil)
+	assert.False(t, ok)
+}
+
+func TestCapabilitiesService(t *testing.T) {
+	ctx := context.Background()
+	b := bus.ProvideBus(tracing.InitializeTracerForTest())
+	s := ProvideService(kvstore.ProvideService(db.InitTestDB(t)), b)
+
+	require.NoError(t, s.Save(ctx, 1, "tempo", Capabilities{"streaming": true, "metricsQueries": false}))
+	require.NoError(t, s.Save(ctx, 1, "loki", Capabilities{"labelVolumes": true}))
+	require.NoError(t, s.Save(ctx, 2, "tempo", Capabilities{"streaming": false}))
+
+	all, err := s.GetAll(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Capabilities{
+		"tempo": {"streaming": true, "metricsQueries": false},
+		"loki":  {"labelVolumes": true},
+	}, all)
+
+	t.Run("the capabilities of the deleted datasources are deleted", func(t *testing.T) {
+		require.NoError(t, b.Publish(ctx, &events.DataSourceDeleted{UID: "tempo", OrgID: 1}))
+		all, err := s.GetAll(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]Capabilities{"loki": {"labelVolumes": true}}, all)
+
+		all, err = s.GetAll(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]Capabilities{"tempo": {"streaming": false}}, all)
+	})
+}
@@ -0,0 +1,26 @@
+package capabilities
+
+// Capabilities are the features supported by the server of a datasource, such as streaming or the TraceQL metrics
+// queries of Tempo, by name. The datasources publish them in the details of their health check, under the
+// capabilities key, and the frontend reads them from the frontend settings instead of guessing them from the
+// version of the server.
+type Capabilities map[string]bool
+
+// detailsKey is the key of the capabilities in the details of the health check
+const detailsKey = "capabilities"
+
+// FromHealthDetails returns the capabilities published in the details of a health check, false when the datasource
+// publishes none. The values which are not booleans are ignored.
+func FromHealthDetails(details map[string]interface{}) (Capabilities, bool) {
+	raw, ok := details[detailsKey].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	capabilities := Capabilities{}
+	for name, value := range raw {
+		if supported, ok := value.(bool); ok {
+			capabilities[name] = supported
+		}
+	}
+	return capabilities, true
+}
@@ -0,0 +1,156 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	dsservice "github.com/grafana/grafana/pkg/services/datasources/service"
+	"github.com/grafana/grafana/pkg/services/org"
+	provisioning "github.com/grafana/grafana/pkg/services/provisioning/datasources"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type Service interface {
+	// Check compares the stored datasources with their live instances and with the provisioning files
+	Check(ctx context.Context) (*Report, error)
+	// Reload makes the live instances of the datasources be recreated from their stored settings
+	Reload(ctx context.Context, cmd ReloadCommand) (*ReloadReport, error)
+}
+
+// datasourceService reads the stored datasources and their live HTTP transports, it is implemented by
+// service.Service
+type datasourceService interface {
+	provisioning.Store
+	GetDataSources(ctx context.Context, query *datasources.GetDataSourcesQuery) ([]*datasources.DataSource, error)
+	GetAllDataSources(ctx context.Context, query *datasources.GetAllDataSourcesQuery) ([]*datasources.DataSource, error)
+	DecryptedValues(ctx context.Context, ds *datasources.DataSource) (map[string]string, error)
+	CachedTransportUpdated(id int64) (time.Time, bool)
+}
+
+// datasourceCache is the cache the datasources are read from when they are queried, it is implemented by
+// service.CacheServiceImpl
+type datasourceCache interface {
+	Invalidate(ds *datasources.DataSource)
+}
+
+type DriftService struct {
+	cfg         *setting.Cfg
+	sql         db.DB
+	datasources datasourceService
+	cache       datasourceCache
+	orgService  org.Service
+	log         log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, sql db.DB, datasourceService *dsservice.Service, cacheService *dsservice.CacheServiceImpl, orgService org.Service) *DriftService {
+	return &DriftService{
+		cfg:         cfg,
+		sql:         sql,
+		datasources: datasourceService,
+		cache:       cacheService,
+		orgService:  orgService,
+		log:         log.New("datasources.drift"),
+	}
+}
+
+func (s *DriftService) Check(ctx context.Context) (*Report, error) {
+	stored, err := s.datasources.GetAllDataSources(ctx, &datasources.GetAllDataSourcesQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	configDrifts, err := provisioning.Drift(ctx, filepath.Join(s.cfg.ProvisioningPath, "datasources"), s.datasources, s.orgService, s.datasources.DecryptedValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare the datasources with the provisioning files: %w", err)
+	}
+
+	report := &Report{Datasources: []DatasourceDrift{}, Missing: []MissingDatasource{}}
+	// the provisioning files reference the datasources by organization and name
+	provisioned := map[string][]provisioning.FieldDrift{}
+	for _, d := range configDrifts {
+		if d.Missing {
+			report.Missing = append(report.Missing, MissingDatasource{OrgID: d.OrgID, Name: d.Name, UID: d.UID})
+			continue
+		}
+		provisioned[nameKey(d.OrgID, d.Name)] = d.Fields
+	}
+
+	sort.SliceStable(stored, func(i, j int) bool {
+		return stored[i].OrgID < stored[j].OrgID
+	})
+	for _, ds := range stored {
+		drift := DatasourceDrift{
+			OrgID:        ds.OrgID,
+			UID:          ds.UID,
+			Name:         ds.Name,
+			Type:         ds.Type,
+			Updated:      ds.Updated,
+			Provisioning: provisioned[nameKey(ds.OrgID, ds.Name)],
+		}
+		if updated, ok := s.datasources.CachedTransportUpdated(ds.ID); ok && !updated.Equal(ds.Updated) {
+			drift.Instance = &InstanceDrift{Updated: updated}
+		}
+		if drift.Instance != nil || len(drift.Provisioning) > 0 {
+			report.Datasources = append(report.Datasources, drift)
+		}
+	}
+	return report, nil
+}
+
+// Reload updates the update time of the datasources. The live instances are recreated when the update time of the
+// settings of their datasource changes: the HTTP transports of Grafana and the instances of the backend plugins.
+func (s *DriftService) Reload(ctx context.Context, cmd ReloadCommand) (*ReloadReport, error) {
+	var toReload []*datasources.DataSource
+	if len(cmd.UIDs) == 0 {
+		all, err := s.datasources.GetDataSources(ctx, &datasources.GetDataSourcesQuery{OrgID: cmd.OrgID})
+		if err != nil {
+			return nil, err
+		}
+		toReload = all
+	} else {
+		for _, uid := range cmd.UIDs {
+			ds, err := s.datasources.GetDataSource(ctx, &datasources.GetDataSourceQuery{OrgID: cmd.OrgID, UID: uid})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get datasource %s: %w", uid, err)
+			}
+			toReload = append(toReload, ds)
+		}
+	}
+
+	report := &ReloadReport{Reloaded: make([]ReloadedDatasource, 0, len(toReload))}
+	for _, ds := range toReload {
+		updated, err := s.touch(ctx, ds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reload datasource %s: %w", ds.UID, err)
+		}
+		s.cache.Invalidate(ds)
+		s.log.Info("Reloaded datasource", "orgId", ds.OrgID, "uid", ds.UID, "name", ds.Name)
+		report.Reloaded = append(report.Reloaded, ReloadedDatasource{OrgID: ds.OrgID, UID: ds.UID, Name: ds.Name, Updated: updated})
+	}
+	return report, nil
+}
+
+// touch sets the update time of the datasource to now, without changing its version so that the users editing the
+// datasource can still save it. The update times are stored with a precision of a second.
+func (s *DriftService) touch(ctx context.Context, ds *datasources.DataSource) (time.Time, error) {
+	updated := time.Now().Truncate(time.Second)
+	if !updated.After(ds.Updated) {
+		updated = ds.Updated.Truncate(time.Second).Add(time.Second)
+	}
+
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Table("data_source").Where("id = ? AND org_id = ?", ds.ID, ds.OrgID).Cols("updated").Update(map[string]interface{}{"updated": updated})
+		return err
+	})
+	return updated, err
+}
+
+func nameKey(orgID int64, name string) string {
+	return fmt.Sprintf("%d/%s", orgID, name)
+}
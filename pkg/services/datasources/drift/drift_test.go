@@ -0,0 +1,139 @@
+package drift
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	dsservice "github.com/grafana/grafana/pkg/services/datasources/service"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	provisioning "github.com/grafana/grafana/pkg/services/provisioning/datasources"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeDatasourceService struct {
+	*dsservice.SqlStore
+	transports map[int64]time.Time
+}
+
+func (s *fakeDatasourceService) DecryptedValues(ctx context.Context, ds *datasources.DataSource) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (s *fakeDatasourceService) CachedTransportUpdated(id int64) (time.Time, bool) {
+	updated, ok := s.transports[id]
+	return updated, ok
+}
+
+type fakeDatasourceCache struct {
+	invalidated []string
+}
+
+func (c *fakeDatasourceCache) Invalidate(ds *datasources.DataSource) {
+	c.invalidated = append(c.invalidated, ds.UID)
+}
+
+func TestIntegrationDrift(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	sqlStore := db.InitTestDB(t)
+	updated := time.Now().Add(-time.Hour).Truncate(time.Second)
+	err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		_, err := sess.Insert(
+			&datasources.DataSource{ID: 1, OrgID: 1, UID: "prom", Name: "Prometheus", Type: "prometheus", Access: datasources.DS_ACCESS_PROXY, URL: "http://prometheus:9091", Created: updated, Updated: updated},
+			&datasources.DataSource{ID: 2, OrgID: 1, UID: "loki", Name: "Loki", Type: "loki", Access: datasources.DS_ACCESS_PROXY, Created: updated, Updated: updated},
+			&datasources.DataSource{ID: 3, OrgID: 2, UID: "loki", Name: "Loki", Type: "loki", Access: datasources.DS_ACCESS_PROXY, Created: updated, Updated: updated},
+		)
+		return err
+	})
+	require.NoError(t, err)
+
+	provisioningPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(provisioningPath, "datasources"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(provisioningPath, "datasources", "datasources.yaml"), []byte(`
+apiVersion: 1
+datasources:
+  - name: Prometheus
+    type: prometheus
+    uid: prom
+    url: http://prometheus:9090
+    editable: true
+  - name: Tempo
+    type: tempo
+    url: http://tempo:3200
+`), 0600))
+
+	dsService := &fakeDatasourceService{
+		SqlStore:   dsservice.CreateStore(sqlStore, log.New("test")),
+		transports: map[int64]time.Time{1: updated, 2: updated.Add(-time.Minute)},
+	}
+	cache := &fakeDatasourceCache{}
+	s := &DriftService{
+		cfg:         &setting.Cfg{ProvisioningPath: provisioningPath},
+		sql:         sqlStore,
+		datasources: dsService,
+		cache:       cache,
+		orgService:  &orgtest.FakeOrgService{ExpectedOrg: &org.Org{ID: 1}},
+		log:         log.New("test"),
+	}
+
+	t.Run("the drifted datasources are reported", func(t *testing.T) {
+		report, err := s.Check(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, report.Datasources, 2)
+		assert.Equal(t, "Loki", report.Datasources[0].Name)
+		assert.Equal(t, &InstanceDrift{Updated: updated.Add(-time.Minute)}, report.Datasources[0].Instance)
+		assert.Empty(t, report.Datasources[0].Provisioning)
+
+		assert.Equal(t, "Prometheus", report.Datasources[1].Name)
+		assert.Nil(t, report.Datasources[1].Instance)
+		assert.Equal(t, []provisioning.FieldDrift{
+			{Field: "url", Stored: "http://prometheus:9091", Provisioned: "http://prometheus:9090"},
+		}, report.Datasources[1].Provisioning)
+
+		assert.Equal(t, []MissingDatasource{{OrgID: 1, Name: "Tempo"}}, report.Missing)
+	})
+
+	t.Run("the reloaded datasources get a new update time", func(t *testing.T) {
+		report, err := s.Reload(context.Background(), ReloadCommand{OrgID: 1, UIDs: []string{"prom"}})
+		require.NoError(t, err)
+		require.Len(t, report.Reloaded, 1)
+		assert.Equal(t, "prom", report.Reloaded[0].UID)
+		assert.True(t, report.Reloaded[0].Updated.After(updated))
+		assert.Equal(t, []string{"prom"}, cache.invalidated)
+
+		ds, err := dsService.GetDataSource(context.Background(), &datasources.GetDataSourceQuery{OrgID: 1, UID: "prom"})
+		require.NoError(t, err)
+		assert.True(t, ds.Updated.Equal(report.Reloaded[0].Updated))
+
+		// the other datasources are not changed
+		ds, err = dsService.GetDataSource(context.Background(), &datasources.GetDataSourceQuery{OrgID: 2, UID: "loki"})
+		require.NoError(t, err)
+		assert.True(t, ds.Updated.Equal(updated))
+	})
+
+	t.Run("all the datasources of the organization are reloaded without UIDs", func(t *testing.T) {
+		report, err := s.Reload(context.Background(), ReloadCommand{OrgID: 1})
+		require.NoError(t, err)
+		require.Len(t, report.Reloaded, 2)
+		assert.Equal(t, "loki", report.Reloaded[0].UID)
+		assert.Equal(t, "prom", report.Reloaded[1].UID)
+	})
+
+	t.Run("reloading an unknown datasource fails", func(t *testing.T) {
+		_, err := s.Reload(context.Background(), ReloadCommand{OrgID: 1, UIDs: []string{"unknown"}})
+		require.ErrorIs(t, err, datasources.ErrDataSourceNotFound)
+	})
+}
@@ -0,0 +1,62 @@
+package drift
+
+import (
+	"time"
+
+	provisioning "github.com/grafana/grafana/pkg/services/provisioning/datasources"
+)
+
+// Report lists the datasources whose live instances or provisioning files differ from their stored settings
+// swagger:model
+type Report struct {
+	Datasources []DatasourceDrift `json:"datasources"`
+	// Missing lists the datasources of the provisioning files that are not stored
+	Missing []MissingDatasource `json:"missing"`
+}
+
+// DatasourceDrift is a stored datasource that has drifted
+type DatasourceDrift struct {
+	OrgID   int64     `json:"orgId"`
+	UID     string    `json:"uid"`
+	Name    string    `json:"name"`
+	Type    string    `json:"type"`
+	Updated time.Time `json:"updated"`
+	// Instance is set when the live instance of the datasource was created from older settings than the stored
+	// settings
+	Instance *InstanceDrift `json:"instance,omitempty"`
+	// Provisioning lists the settings that differ from the provisioning files
+	Provisioning []provisioning.FieldDrift `json:"provisioning,omitempty"`
+}
+
+// InstanceDrift is a live instance created from outdated settings, it is recreated the next time the datasource
+// is queried or when the datasource is reloaded
+type InstanceDrift struct {
+	Updated time.Time `json:"updated"`
+}
+
+// MissingDatasource is a datasource of the provisioning files that is not stored, it is created when the
+// datasources are provisioned again
+type MissingDatasource struct {
+	OrgID int64  `json:"orgId"`
+	Name  string `json:"name"`
+	UID   string `json:"uid,omitempty"`
+}
+
+// ReloadCommand reloads the datasources of an organization, all of them when UIDs is empty
+type ReloadCommand struct {
+	OrgID int64    `json:"orgId"`
+	UIDs  []string `json:"uids"`
+}
+
+// ReloadReport lists the reloaded datasources with their new update time
+// swagger:model
+type ReloadReport struct {
+	Reloaded []ReloadedDatasource `json:"reloaded"`
+}
+
+type ReloadedDatasource struct {
+	OrgID   int64     `json:"orgId"`
+	UID     string    `json:"uid"`
+	Name    string    `json:"name"`
+	Updated time.Time `json:"updated"`
+}
@@ -0,0 +1,87 @@
+package enforcer
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromQL(t *testing.T) {
+	matchers, err := parser.ParseMetricSelector(`{team="payments", env!="dev"}`)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		expr     string
+		expected string
+	}{
+		{expr: `up`, expected: `up{env!="dev",team="payments"}`},
+		{expr: `sum by (job) (rate(http_requests_total{code="500"}[5m])) / sum(rate(http_requests_total[5m]))`, expected: `sum by(job) (rate(http_requests_total{code="500",env!="dev",team="payments"}[5m])) / sum(rate(http_requests_total{env!="dev",team="payments"}[5m]))`},
+		{expr: `up{team="payments"}`, expected: `up{env!="dev",team="payments"}`},
+		{expr: `rate(up[$__rate_interval]) * ${__range_s}`, expected: `rate(up{env!="dev",team="payments"}[$__rate_interval]) * ${__range_s}`},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			enforced, err := PromQL(tc.expr, matchers)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, enforced)
+		})
+	}
+
+	_, err = PromQL(`sum(`, matchers)
+	require.Error(t, err)
+}
+
+func TestParseLabelMatchers(t *testing.T) {
+	matchers, err := ParseLabelMatchers([]string{`team="payments"`, `env=~"prod|staging"`})
+	require.NoError(t, err)
+	require.Len(t, matchers, 2)
+	assert.Equal(t, `env=~"prod|staging"`, matchers[1].String())
+
+	_, err = ParseLabelMatchers([]string{`team="payments",env="prod"`})
+	require.ErrorContains(t, err, `invalid label matcher "team=\"payments\",env=\"prod\""`)
+	_, err = ParseLabelMatchers([]string{`team`})
+	require.Error(t, err)
+}
+
+func TestTraceQL(t *testing.T) {
+	filters := []TraceQLFilter{
+		{Scope: "resource", Tag: "namespace", Operator: "=", Value: "team-a"},
+		{Tag: ".tier", Operator: "!=", Value: "internal"},
+	}
+	condition := `resource.namespace = "team-a" && .tier != "internal"`
+
+	for _, tc := range []struct {
+		query    string
+		expected string
+	}{
+		{query: ``, expected: `{ ` + condition + ` }`},
+		{query: `{}`, expected: `{ ` + condition + ` }`},
+		{query: `{ span.http.status_code >= 500 }`, expected: `{ (span.http.status_code >= 500) && ` + condition + ` }`},
+		{query: `{ .a = 1 || .b = 2 } >> { name = "}{" } | count() > 2`, expected: `{ (.a = 1 || .b = 2) && ` + condition + ` } >> { (name = "}{") && ` + condition + ` } | count() > 2`},
+		{query: `{ .msg = "say \"{hi}\"" }`, expected: `{ (.msg = "say \"{hi}\"") && ` + condition + ` }`},
+		{query: "{ .msg = `{`}", expected: "{ (.msg = `{`) && " + condition + " }"},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			enforced, err := TraceQL(tc.query, filters)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, enforced)
+		})
+	}
+
+	for _, query := range []string{`{ .a = 1 `, `{ .a = "1 }`, `.a = 1 }`, `{ .a = { } }`, `count() > 2`} {
+		_, err := TraceQL(query, filters)
+		assert.Error(t, err, query)
+	}
+
+	query, err := TraceQL(`{ .a = 1 }`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{ .a = 1 }`, query)
+}
+
+func TestTraceQLFilterValidate(t *testing.T) {
+	require.NoError(t, TraceQLFilter{Scope: "span", Tag: "k8s.namespace.name", Operator: "=~", Value: "team-.*"}.Validate())
+	assert.Error(t, TraceQLFilter{Scope: "event", Tag: "name", Operator: "=", Value: "a"}.Validate())
+	assert.Error(t, TraceQLFilter{Tag: "a } || { true", Operator: "=", Value: "a"}.Validate())
+	assert.Error(t, TraceQLFilter{Tag: "name", Operator: ">", Value: "a"}.Validate())
+}
@@ -0,0 +1,89 @@
+// Package enforcer adds the label matchers and the filters enforced by Grafana, such as the ones of a folder or of
+// a team, to the PromQL and TraceQL queries sent to the datasources.
+package enforcer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// ParseLabelMatchers parses the PromQL label matchers, such as team="payments"
+func ParseLabelMatchers(labelMatchers []string) ([]*labels.Matcher, error) {
+	matchers := make([]*labels.Matcher, 0, len(labelMatchers))
+	for _, m := range labelMatchers {
+		parsed, err := parser.ParseMetricSelector("{" + m + "}")
+		if err == nil && len(parsed) != 1 {
+			err = errors.New("expected a single label matcher")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid label matcher %q: %w", m, err)
+		}
+		matchers = append(matchers, parsed...)
+	}
+	return matchers, nil
+}
+
+// grafanaVariablePattern matches the variables interpolated by the Prometheus datasource in the backend, such as
+// $__rate_interval or ${__range_s}
+var grafanaVariablePattern = regexp.MustCompile(`\$(__\w+)|\$\{(__\w+)\}`)
+
+// PromQL adds the matchers to the vector selectors of the PromQL expression. The Grafana variables of the expression
+// are replaced by placeholders while the expression is parsed, and restored afterwards.
+func PromQL(expr string, matchers []*labels.Matcher) (string, error) {
+	placeholders := map[string]string{}
+	i := 0
+	sanitized := grafanaVariablePattern.ReplaceAllStringFunc(expr, func(variable string) string {
+		name := strings.Trim(variable, "${}")
+		i++
+		// the placeholders are unusual values, printed back the same way by the PromQL printer
+		var placeholder string
+		if strings.HasSuffix(name, "_ms") || strings.HasSuffix(name, "_s") {
+			placeholder = fmt.Sprint(float64(913370000 + i))
+		} else {
+			placeholder = model.Duration(time.Duration(913370000+i) * time.Millisecond).String()
+		}
+		placeholders[placeholder] = variable
+		return placeholder
+	})
+
+	parsed, err := parser.ParseExpr(sanitized)
+	if err != nil {
+		return "", err
+	}
+
+	parser.Inspect(parsed, func(node parser.Node, _ []parser.Node) error {
+		if selector, ok := node.(*parser.VectorSelector); ok {
+			selector.LabelMatchers = appendMissingMatchers(selector.LabelMatchers, matchers)
+		}
+		return nil
+	})
+
+	enforced := parsed.String()
+	for placeholder, variable := range placeholders {
+		enforced = strings.ReplaceAll(enforced, placeholder, variable)
+	}
+	return enforced, nil
+}
+
+func appendMissingMatchers(existing []*labels.Matcher, matchers []*labels.Matcher) []*labels.Matcher {
+	for _, m := range matchers {
+		found := false
+		for _, e := range existing {
+			if e.Name == m.Name && e.Type == m.Type && e.Value == m.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing = append(existing, m)
+		}
+	}
+	return existing
+}
@@ -0,0 +1,129 @@
+package enforcer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// traceQLScopes are the prefixes of the attributes of the filters by scope
+var traceQLScopes = map[string]string{
+	"":         ".",
+	"unscoped": ".",
+	"resource": "resource.",
+	"span":     "span.",
+}
+
+var (
+	traceQLOperators = map[string]bool{"=": true, "!=": true, "=~": true, "!~": true}
+	traceQLTag       = regexp.MustCompile(`^[\w.\-/:]+$`)
+)
+
+// TraceQLFilter is a condition on a string attribute of the spans, such as resource.namespace = "team-a". It has the
+// fields of the filters of the TraceQL search editor.
+type TraceQLFilter struct {
+	// Scope is resource, span or unscoped, unscoped by default
+	Scope    string `json:"scope,omitempty"`
+	Tag      string `json:"tag"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// Validate returns an error when the filter can't be added to the TraceQL queries
+func (f TraceQLFilter) Validate() error {
+	if _, ok := traceQLScopes[f.Scope]; !ok {
+		return fmt.Errorf("invalid TraceQL filter scope %q, the scopes are resource, span and unscoped", f.Scope)
+	}
+	if !traceQLTag.MatchString(strings.TrimPrefix(f.Tag, ".")) {
+		return fmt.Errorf("invalid TraceQL filter tag %q", f.Tag)
+	}
+	if !traceQLOperators[f.Operator] {
+		return fmt.Errorf("invalid TraceQL filter operator %q, the operators are =, !=, =~ and !~", f.Operator)
+	}
+	// the filters without value are ignored by the search editor
+	if f.Value == "" {
+		return fmt.Errorf("missing value for the TraceQL filter of tag %q", f.Tag)
+	}
+	return nil
+}
+
+// String returns the TraceQL condition of the filter
+func (f TraceQLFilter) String() string {
+	return fmt.Sprintf("%s%s %s %q", traceQLScopes[f.Scope], strings.TrimPrefix(f.Tag, "."), f.Operator, f.Value)
+}
+
+// TraceQL adds the filters to every spanset filter of the TraceQL query, so that only the spans matching them are
+// selected. The empty query selects the spans matching the filters. The queries that can't be scanned fail, so that
+// the filters can't be bypassed.
+func TraceQL(query string, filters []TraceQLFilter) (string, error) {
+	if len(filters) == 0 {
+		return query, nil
+	}
+	conditions := make([]string, 0, len(filters))
+	for _, f := range filters {
+		if err := f.Validate(); err != nil {
+			return "", err
+		}
+		conditions = append(conditions, f.String())
+	}
+	condition := strings.Join(conditions, " && ")
+	if strings.TrimSpace(query) == "" {
+		return "{ " + condition + " }", nil
+	}
+
+	var (
+		enforced strings.Builder
+		// quote is the delimiter of the string being scanned, 0 outside of the strings
+		quote   rune
+		escaped bool
+		// start is the position of the opening brace of the spanset filter being scanned, -1 outside of them
+		start     = -1
+		spansets  = 0
+		lastWrite = 0
+	)
+	for i, r := range query {
+		switch {
+		case quote != 0:
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\' && quote == '"':
+				escaped = true
+			case r == quote:
+				quote = 0
+			}
+		case r == '"' || r == '`':
+			quote = r
+		case r == '{':
+			if start >= 0 {
+				return "", fmt.Errorf("unexpected { at position %d in a spanset filter", i)
+			}
+			start = i
+		case r == '}':
+			if start < 0 {
+				return "", fmt.Errorf("unexpected } at position %d", i)
+			}
+			enforced.WriteString(query[lastWrite:start])
+			if inner := strings.TrimSpace(query[start+1 : i]); inner == "" {
+				enforced.WriteString("{ " + condition + " }")
+			} else {
+				enforced.WriteString("{ (" + inner + ") && " + condition + " }")
+			}
+			lastWrite = i + 1
+			start = -1
+			spansets++
+		}
+	}
+	if quote != 0 {
+		return "", errors.New("unterminated string")
+	}
+	if start >= 0 {
+		return "", errors.New("unterminated spanset filter")
+	}
+	if spansets == 0 {
+		return "", errors.New("the query has no spanset filter")
+	}
+	enforced.WriteString(query[lastWrite:])
+	return enforced.String(), nil
+}
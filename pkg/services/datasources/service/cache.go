@@ -101,6 +101,12 @@ func (dc *CacheServiceImpl) GetDatasourceByUID(
 	return ds, nil
 }
 
+// Invalidate removes the datasource from the cache, so that it is read again from the database
+func (dc *CacheServiceImpl) Invalidate(ds *datasources.DataSource) {
+	dc.CacheService.Delete(idKey(ds.ID))
+	dc.CacheService.Delete(uidKey(ds.OrgID, ds.UID))
+}
+
 func idKey(id int64) string {
 	return fmt.Sprintf("ds-%d", id)
 }
@@ -81,9 +81,10 @@ func ProvideService(
 	}
 
 	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
-		TargetSrv:     datasources.QuotaTargetSrv,
-		DefaultLimits: defaultLimits,
-		Reporter:      s.Usage,
+		TargetSrv:         datasources.QuotaTargetSrv,
+		DefaultLimits:     defaultLimits,
+		Reporter:          s.Usage,
+		ReadDefaultLimits: readQuotaConfig,
 	}); err != nil {
 		return nil, err
 	}
@@ -316,6 +317,16 @@ func (s *Service) GetHTTPTransport(ctx context.Context, ds *datasources.DataSour
 	return rt, nil
 }
 
+// CachedTransportUpdated returns the update time of the settings the cached HTTP transport of the datasource was
+// created from, false when no transport is cached.
+func (s *Service) CachedTransportUpdated(id int64) (time.Time, bool) {
+	s.ptc.Lock()
+	defer s.ptc.Unlock()
+
+	t, ok := s.ptc.cache[id]
+	return t.updated, ok
+}
+
 func (s *Service) GetTLSConfig(ctx context.Context, ds *datasources.DataSource, httpClientProvider httpclient.Provider) (*tls.Config, error) {
 	opts, err := s.httpClientOptions(ctx, ds)
 	if err != nil {
@@ -647,7 +658,7 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 		return limits, err
 	}
 
-	limits.Set(globalQuotaTag, cfg.Quota.Global.DataSource)
-	limits.Set(orgQuotaTag, cfg.Quota.Org.DataSource)
+	limits.Set(globalQuotaTag, cfg.QuotaSettings().Global.DataSource)
+	limits.Set(orgQuotaTag, cfg.QuotaSettings().Org.DataSource)
 	return limits, nil
 }
@@ -0,0 +1,397 @@
+package datasources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources/enforcer"
+)
+
+// TeamOverride is merged into the requests to the datasource made for the members of a team, for example to enforce
+// a label selector for the team on a datasource shared by several teams
+type TeamOverride struct {
+	Headers     []TeamOverrideValue `json:"headers,omitempty"`
+	QueryParams []TeamOverrideValue `json:"queryParams,omitempty"`
+	// LabelMatchers are added to the selectors of the PromQL queries of the team, such as namespace="team-a"
+	LabelMatchers []string `json:"labelMatchers,omitempty"`
+	// TraceQLFilters are added to the spanset filters of the TraceQL queries of the team
+	TraceQLFilters []enforcer.TraceQLFilter `json:"traceQLFilters,omitempty"`
+}
+
+type TeamOverrideValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// TeamOverrides are the overrides of a datasource by team ID, configured in jsondata.teamOverrides
+type TeamOverrides map[int64]TeamOverride
+
+// teamOverridesKey is the property of the jsondata holding the team overrides
+const teamOverridesKey = "teamOverrides"
+
+// tempoTraceQLQueryTypes are the types of the Tempo backend queries whose query is TraceQL, the query of the other
+// types is a trace ID
+var tempoTraceQLQueryTypes = map[string]bool{
+	"errorSummary":        true,
+	"attributeStatistics": true,
+	"serviceGraph":        true,
+	"flameGraph":          true,
+}
+
+// TeamOverrides parses the jsondata.teamOverrides of the datasource
+func (ds DataSource) TeamOverrides() (TeamOverrides, error) {
+	if ds.JsonData == nil {
+		return TeamOverrides{}, nil
+	}
+	jsonData, err := ds.JsonData.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return TeamOverridesFromJSONData(jsonData)
+}
+
+// TeamOverridesFromJSONData parses the teamOverrides of the encoded jsondata of a datasource, where the teams are the
+// keys of an object
+func TeamOverridesFromJSONData(jsonData []byte) (TeamOverrides, error) {
+	settings := struct {
+		TeamOverrides TeamOverrides `json:"teamOverrides"`
+	}{}
+	if len(jsonData) > 0 {
+		if err := json.Unmarshal(jsonData, &settings); err != nil {
+			return nil, fmt.Errorf("invalid team overrides: %w", err)
+		}
+	}
+	if settings.TeamOverrides == nil {
+		return TeamOverrides{}, nil
+	}
+	for teamID, override := range settings.TeamOverrides {
+		if !hasNames(override.Headers) || !hasNames(override.QueryParams) {
+			return nil, fmt.Errorf("invalid team overrides: missing name for team %d", teamID)
+		}
+		if _, err := enforcer.ParseLabelMatchers(override.LabelMatchers); err != nil {
+			return nil, fmt.Errorf("invalid team overrides: team %d: %w", teamID, err)
+		}
+		for _, f := range override.TraceQLFilters {
+			if err := f.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid team overrides: team %d: %w", teamID, err)
+			}
+		}
+	}
+	return settings.TeamOverrides, nil
+}
+
+func hasNames(values []TeamOverrideValue) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v.Name) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ForTeams merges the overrides of the teams of a user. The values of a header set by several teams are joined and
+// the query parameters are repeated. The label matchers and the TraceQL filters of all the teams are enforced.
+func (o TeamOverrides) ForTeams(teamIDs []int64) RequestOverrides {
+	ids := make([]int64, 0, len(teamIDs))
+	for _, id := range teamIDs {
+		if _, ok := o[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	headers := http.Header{}
+	params := url.Values{}
+	enforced := map[string][]string{}
+	var traceQLFilters []enforcer.TraceQLFilter
+	for _, id := range ids {
+		for _, h := range o[id].Headers {
+			addUnique(headers, http.CanonicalHeaderKey(h.Name), h.Value)
+		}
+		for _, p := range o[id].QueryParams {
+			addUnique(params, p.Name, p.Value)
+		}
+		for _, m := range o[id].LabelMatchers {
+			addUnique(enforced, "", m)
+		}
+		for _, f := range o[id].TraceQLFilters {
+			if !containsFilter(traceQLFilters, f) {
+				traceQLFilters = append(traceQLFilters, f)
+			}
+		}
+	}
+
+	overrides := RequestOverrides{
+		Headers:        make(map[string]string, len(headers)),
+		QueryParams:    params,
+		LabelMatchers:  enforced[""],
+		TraceQLFilters: traceQLFilters,
+	}
+	for name, values := range headers {
+		overrides.Headers[name] = strings.Join(values, ", ")
+	}
+	return overrides
+}
+
+func addUnique(values map[string][]string, key, value string) {
+	for _, v := range values[key] {
+		if v == value {
+			return
+		}
+	}
+	values[key] = append(values[key], value)
+}
+
+func containsFilter(filters []enforcer.TraceQLFilter, filter enforcer.TraceQLFilter) bool {
+	for _, f := range filters {
+		if f == filter {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestOverrides are the headers and query parameters of the teams of a user, set on the requests to a datasource,
+// and the label matchers and TraceQL filters added to their queries
+type RequestOverrides struct {
+	Headers        map[string]string
+	QueryParams    url.Values
+	LabelMatchers  []string
+	TraceQLFilters []enforcer.TraceQLFilter
+}
+
+func (o RequestOverrides) IsEmpty() bool {
+	return len(o.Headers) == 0 && len(o.QueryParams) == 0 && len(o.LabelMatchers) == 0 && len(o.TraceQLFilters) == 0
+}
+
+// Without returns the overrides without the headers set by Grafana itself, such as the identity tokens, so that a
+// team override can never replace them
+func (o RequestOverrides) Without(headers ...string) RequestOverrides {
+	result := RequestOverrides{
+		Headers:        make(map[string]string, len(o.Headers)),
+		QueryParams:    o.QueryParams,
+		LabelMatchers:  o.LabelMatchers,
+		TraceQLFilters: o.TraceQLFilters,
+	}
+	for name, value := range o.Headers {
+		result.Headers[name] = value
+	}
+	for _, name := range headers {
+		if name != "" {
+			delete(result.Headers, http.CanonicalHeaderKey(name))
+		}
+	}
+	return result
+}
+
+// Apply sets the headers and query parameters on the request, replacing the values sent by the user so the teams
+// overrides cannot be bypassed
+func (o RequestOverrides) Apply(req *http.Request) {
+	for name, value := range o.Headers {
+		req.Header.Set(name, value)
+	}
+	if len(o.QueryParams) == 0 {
+		return
+	}
+	query := req.URL.Query()
+	for name, values := range o.QueryParams {
+		query[name] = values
+	}
+	req.URL.RawQuery = query.Encode()
+}
+
+// EnforceQuery adds the label matchers or the TraceQL filters of the teams to a query of a Prometheus or a Tempo
+// datasource, the queries of the other datasources are returned unchanged
+func (o RequestOverrides) EnforceQuery(dsType, query string) (string, error) {
+	switch {
+	case dsType == DS_PROMETHEUS && len(o.LabelMatchers) > 0 && strings.TrimSpace(query) != "":
+		matchers, err := enforcer.ParseLabelMatchers(o.LabelMatchers)
+		if err != nil {
+			return "", err
+		}
+		return enforcer.PromQL(query, matchers)
+	case dsType == DS_TEMPO && len(o.TraceQLFilters) > 0:
+		return enforcer.TraceQL(query, o.TraceQLFilters)
+	default:
+		return query, nil
+	}
+}
+
+// enforceable fails with ErrTeamOverridesNotEnforced when the overrides restrict the queries but none of their
+// restrictions applies to the datasource type: the label matchers only apply to Prometheus and the TraceQL filters to
+// Tempo, the queries of the other datasources, such as Loki, are rejected instead of being sent unrestricted
+func (o RequestOverrides) enforceable(dsType string) error {
+	switch {
+	case len(o.LabelMatchers) == 0 && len(o.TraceQLFilters) == 0:
+		return nil
+	case dsType == DS_PROMETHEUS && len(o.LabelMatchers) > 0, dsType == DS_TEMPO && len(o.TraceQLFilters) > 0:
+		return nil
+	default:
+		return fmt.Errorf("%w: the label matchers and the TraceQL filters of the teams can't be added to the queries of the %s data sources", ErrTeamOverridesNotEnforced, dsType)
+	}
+}
+
+// EnforceQueryModel adds the label matchers or the TraceQL filters of the teams to the model of a backend query. The
+// filters of the Tempo queries built from the filters of the search editor are added to their filters. The Tempo
+// queries which aren't TraceQL queries, such as the lookups of a trace by its ID, are rejected with the TraceQL filters.
+func (o RequestOverrides) EnforceQueryModel(dsType, queryType string, model []byte) ([]byte, error) {
+	if err := o.enforceable(dsType); err != nil {
+		return nil, err
+	}
+	var key string
+	switch {
+	case dsType == DS_PROMETHEUS && len(o.LabelMatchers) > 0:
+		key = "expr"
+	case dsType == DS_TEMPO && len(o.TraceQLFilters) > 0:
+		if !tempoTraceQLQueryTypes[queryType] {
+			return nil, fmt.Errorf("%w: TraceQL filters can't be added to the %q queries", ErrTeamOverridesNotEnforced, queryType)
+		}
+		key = "query"
+	default:
+		return model, nil
+	}
+
+	var query map[string]interface{}
+	if err := json.Unmarshal(model, &query); err != nil {
+		return nil, err
+	}
+	text, _ := query[key].(string)
+	filters, _ := query["filters"].([]interface{})
+	if dsType == DS_TEMPO && strings.TrimSpace(text) == "" && len(filters) > 0 {
+		for i, f := range o.TraceQLFilters {
+			scope := f.Scope
+			if scope == "" {
+				scope = "unscoped"
+			}
+			filters = append(filters, map[string]interface{}{
+				"id":        fmt.Sprintf("team-override-%d", i),
+				"type":      "static",
+				"scope":     scope,
+				"tag":       f.Tag,
+				"operator":  f.Operator,
+				"value":     f.Value,
+				"valueType": "string",
+			})
+		}
+		query["filters"] = filters
+		return json.Marshal(query)
+	}
+
+	enforced, err := o.EnforceQuery(dsType, text)
+	if err != nil {
+		return nil, err
+	}
+	query[key] = enforced
+	return json.Marshal(query)
+}
+
+// EnforceRequest adds the label matchers or the TraceQL filters of the teams to the queries sent to the HTTP API of
+// Prometheus and Tempo in the parameters of the URL or of the form of the request. The searches of Tempo by tags
+// are replaced by the TraceQL search of the filters, and the lookups of a trace by its ID are rejected.
+func (o RequestOverrides) EnforceRequest(dsType string, req *http.Request) error {
+	if err := o.enforceable(dsType); err != nil {
+		return err
+	}
+	var enforce func(url.Values) error
+	switch {
+	case dsType == DS_TEMPO && len(o.TraceQLFilters) > 0 && isTempoTraceLookup(req.URL.Path):
+		return fmt.Errorf("%w: TraceQL filters can't be added to the lookups of a trace", ErrTeamOverridesNotEnforced)
+	case dsType == DS_PROMETHEUS && len(o.LabelMatchers) > 0:
+		enforce = func(values url.Values) error {
+			return o.enforceParams(dsType, values, "query", "match[]")
+		}
+	case dsType == DS_TEMPO && len(o.TraceQLFilters) > 0 && strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), "/api/search"):
+		enforce = func(values url.Values) error {
+			values.Del("tags")
+			if len(values["q"]) == 0 {
+				values.Set("q", "")
+			}
+			return o.enforceParams(dsType, values, "q")
+		}
+	default:
+		return nil
+	}
+
+	query := req.URL.Query()
+	if err := enforce(query); err != nil {
+		return err
+	}
+	req.URL.RawQuery = query.Encode()
+
+	if req.Method != http.MethodPost || req.Body == nil {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "application/x-www-form-urlencoded" {
+		return nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if err := req.Body.Close(); err != nil {
+		return err
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	if err := enforce(form); err != nil {
+		return err
+	}
+	encoded := form.Encode()
+	req.Body = io.NopCloser(strings.NewReader(encoded))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(encoded)), nil
+	}
+	req.ContentLength = int64(len(encoded))
+	req.Header.Set("Content-Length", strconv.Itoa(len(encoded)))
+	return nil
+}
+
+// isTempoTraceLookup returns true for the paths of the Tempo API returning a trace by its ID
+func isTempoTraceLookup(path string) bool {
+	return strings.Contains(path, "/api/traces/") || strings.Contains(path, "/api/v2/traces/")
+}
+
+func (o RequestOverrides) enforceParams(dsType string, values url.Values, names ...string) error {
+	for _, name := range names {
+		for i, value := range values[name] {
+			enforced, err := o.EnforceQuery(dsType, value)
+			if err != nil {
+				return fmt.Errorf("failed to add the team overrides to the %s parameter: %w", name, err)
+			}
+			values[name][i] = enforced
+		}
+	}
+	return nil
+}
+
+// WithoutTeamOverrides returns a copy of the jsondata without the team overrides, for the users who can't edit the
+// datasource
+func WithoutTeamOverrides(jsonData *simplejson.Json) *simplejson.Json {
+	if jsonData == nil {
+		return nil
+	}
+	values, err := jsonData.Map()
+	if err != nil {
+		return jsonData
+	}
+	if _, ok := values[teamOverridesKey]; !ok {
+		return jsonData
+	}
+	copied := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if k != teamOverridesKey {
+			copied[k] = v
+		}
+	}
+	return simplejson.NewFromAny(copied)
+}
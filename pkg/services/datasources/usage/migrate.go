@@ -0,0 +1,473 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/correlations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/libraryelements/model"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+var (
+	ErrMigrateToSameDatasource = errors.New("the source and target data sources must be different")
+	ErrMigrateToOtherType      = errors.New("the source and target data sources must have the same type")
+)
+
+// MigrateCommand rewrites the references of the source datasource to the target datasource, both of the organization
+// of the user
+type MigrateCommand struct {
+	User   *user.SignedInUser
+	Source *datasources.DataSource
+	Target *datasources.DataSource
+	// DryRun only reports the resources that would be updated
+	DryRun bool
+}
+
+// alertRuleStore updates the alert rules, it is implemented by the ngalert store
+type alertRuleStore interface {
+	GetProvenance(ctx context.Context, o ngmodels.Provisionable, org int64) (ngmodels.Provenance, error)
+	UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateRule) error
+}
+
+// Migrate rewrites the references of a datasource in the dashboards, the library panels, the alert rules and the
+// correlations of the organization. The resources are saved as the user, through the services owning them, in a
+// single transaction so that the migration is not left half done. The dashboards and alert rules updated get a new
+// version and the resources the user cannot update, or that are provisioned, are skipped. Like for the usage of the
+// datasource, the dashboards, library panels and alert rules the user cannot read are only counted, also for a dry
+// run.
+func (s *UsageService) Migrate(ctx context.Context, cmd MigrateCommand) (*MigrationReport, error) {
+	if cmd.Source.UID == cmd.Target.UID {
+		return nil, ErrMigrateToSameDatasource
+	}
+	if cmd.Source.Type != cmd.Target.Type {
+		return nil, ErrMigrateToOtherType
+	}
+
+	m := &migration{
+		UsageService: s,
+		ctx:          ctx,
+		cmd:          cmd,
+		orgID:        cmd.User.OrgID,
+		message:      fmt.Sprintf("Migrated data source %s to %s", cmd.Source.Name, cmd.Target.Name),
+		rewriter: datasourceRewriter{
+			sourceUID:  cmd.Source.UID,
+			sourceName: cmd.Source.Name,
+			targetUID:  cmd.Target.UID,
+			targetType: cmd.Target.Type,
+		},
+		report: &MigrationReport{
+			SourceUID:     cmd.Source.UID,
+			TargetUID:     cmd.Target.UID,
+			DryRun:        cmd.DryRun,
+			Dashboards:    []MigratedResource{},
+			LibraryPanels: []MigratedResource{},
+			AlertRules:    []MigratedResource{},
+			Correlations:  []MigratedResource{},
+			Skipped:       []SkippedResource{},
+		},
+	}
+
+	if cmd.DryRun {
+		if err := m.migrate(); err != nil {
+			return nil, err
+		}
+		return m.report, nil
+	}
+
+	// the services reuse the transaction of the context
+	err := s.sql.InTransaction(ctx, func(ctx context.Context) error {
+		m.ctx = ctx
+		return m.migrate()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.report, nil
+}
+
+func (m *migration) migrate() error {
+	if err := m.migrateDashboards(); err != nil {
+		return fmt.Errorf("failed to migrate dashboards: %w", err)
+	}
+	if err := m.migrateLibraryPanels(); err != nil {
+		return fmt.Errorf("failed to migrate library panels: %w", err)
+	}
+	if err := m.migrateAlertRules(); err != nil {
+		return fmt.Errorf("failed to migrate alert rules: %w", err)
+	}
+	if err := m.migrateCorrelations(); err != nil {
+		return fmt.Errorf("failed to migrate correlations: %w", err)
+	}
+	return nil
+}
+
+type migration struct {
+	*UsageService
+	ctx      context.Context
+	cmd      MigrateCommand
+	orgID    int64
+	message  string
+	rewriter datasourceRewriter
+	report   *MigrationReport
+}
+
+// references is a quick check of the raw JSON before parsing it, it can match values that aren't datasources
+func (m *migration) references(raw []byte) bool {
+	return bytes.Contains(raw, []byte(m.cmd.Source.UID)) || bytes.Contains(raw, []byte(m.cmd.Source.Name))
+}
+
+// skip reports a resource the user is not allowed to update, the other errors stop the migration
+func (m *migration) skip(kind, uid, title string, err error) {
+	m.report.Skipped = append(m.report.Skipped, SkippedResource{Kind: kind, UID: uid, Title: title, Reason: err.Error()})
+}
+
+// canRead returns whether the user can read a resource, the resources the user cannot read are neither listed nor
+// updated
+func (m *migration) canRead(evaluator accesscontrol.Evaluator) (bool, error) {
+	return m.accessControl.Evaluate(m.ctx, m.cmd.User, evaluator)
+}
+
+func (m *migration) migrateDashboards() error {
+	var lastID int64
+	for {
+		rows := make([]*dashboards.Dashboard, 0, dashboardBatchSize)
+		err := m.sql.WithDbSession(m.ctx, func(sess *db.Session) error {
+			return sess.Where("org_id = ? AND is_folder = ? AND id > ?", m.orgID, m.sql.GetDialect().BooleanStr(false), lastID).
+				OrderBy("id").
+				Limit(dashboardBatchSize).
+				Find(&rows)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, dash := range rows {
+			if err := m.migrateDashboard(dash); err != nil {
+				return fmt.Errorf("dashboard %s: %w", dash.UID, err)
+			}
+		}
+
+		if len(rows) < dashboardBatchSize {
+			return nil
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+func (m *migration) migrateDashboard(dash *dashboards.Dashboard) error {
+	raw, err := dash.Data.Encode()
+	if err != nil {
+		return err
+	}
+	if !m.references(raw) {
+		return nil
+	}
+	data := dash.Data.Interface()
+	if !m.rewriter.rewrite(data) {
+		return nil
+	}
+	canRead, err := m.canRead(accesscontrol.EvalPermission(dashboards.ActionDashboardsRead, dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dash.UID)))
+	if err != nil {
+		return err
+	}
+	if !canRead {
+		m.report.HiddenDashboards++
+		return nil
+	}
+	if m.cmd.DryRun {
+		m.report.Dashboards = append(m.report.Dashboards, MigratedResource{UID: dash.UID, Title: dash.Title})
+		return nil
+	}
+
+	dash.Data = simplejson.NewFromAny(data)
+	// the version of the model is checked against the stored dashboard to not overwrite a concurrent change
+	dash.Data.Set("version", dash.Version)
+	_, err = m.dashboardService.SaveDashboard(m.ctx, &dashboards.SaveDashboardDTO{
+		OrgID:     m.orgID,
+		User:      m.cmd.User,
+		Message:   m.message,
+		Dashboard: dash,
+	}, false)
+	if errors.Is(err, dashboards.ErrDashboardUpdateAccessDenied) || errors.Is(err, dashboards.ErrDashboardCannotSaveProvisionedDashboard) {
+		m.skip(KindDashboard, dash.UID, dash.Title, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.report.Dashboards = append(m.report.Dashboards, MigratedResource{UID: dash.UID, Title: dash.Title})
+	return nil
+}
+
+// libraryElementWithFolder is a library element with the UID of its folder, empty in the general folder
+type libraryElementWithFolder struct {
+	model.LibraryElement `xorm:"extends"`
+	FolderUID            string `xorm:"folder_uid"`
+}
+
+func (m *migration) migrateLibraryPanels() error {
+	rows := make([]*libraryElementWithFolder, 0)
+	err := m.sql.WithDbSession(m.ctx, func(sess *db.Session) error {
+		return sess.SQL(`SELECT le.*, COALESCE(f.uid, '') AS folder_uid
+			FROM library_element AS le
+			LEFT JOIN dashboard AS f ON f.id = le.folder_id AND f.org_id = le.org_id
+			WHERE le.org_id = ?
+			ORDER BY le.id`, m.orgID).Find(&rows)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		element := &row.LibraryElement
+		if !m.references(element.Model) {
+			continue
+		}
+		var data interface{}
+		if err := json.Unmarshal(element.Model, &data); err != nil {
+			return fmt.Errorf("library panel %s: %w", element.UID, err)
+		}
+		if !m.rewriter.rewrite(data) {
+			continue
+		}
+		folderUID := row.FolderUID
+		if folderUID == "" {
+			folderUID = accesscontrol.GeneralFolderUID
+		}
+		canRead, err := m.canRead(accesscontrol.EvalPermission(dashboards.ActionFoldersRead, dashboards.ScopeFoldersProvider.GetResourceScopeUID(folderUID)))
+		if err != nil {
+			return fmt.Errorf("library panel %s: %w", element.UID, err)
+		}
+		if !canRead {
+			m.report.HiddenLibraryPanels++
+			continue
+		}
+		if m.cmd.DryRun {
+			m.report.LibraryPanels = append(m.report.LibraryPanels, MigratedResource{UID: element.UID, Title: element.Name})
+			continue
+		}
+
+		modelJSON, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = m.libraryElementService.PatchElement(m.ctx, m.cmd.User, model.PatchLibraryElementCommand{
+			// the library panel stays in its folder
+			FolderID: -1,
+			Model:    modelJSON,
+			Kind:     element.Kind,
+			Version:  element.Version,
+		}, element.UID)
+		if errors.Is(err, dashboards.ErrFolderAccessDenied) {
+			m.skip(KindLibraryPanel, element.UID, element.Name, err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("library panel %s: %w", element.UID, err)
+		}
+		m.report.LibraryPanels = append(m.report.LibraryPanels, MigratedResource{UID: element.UID, Title: element.Name})
+	}
+	return nil
+}
+
+func (m *migration) migrateAlertRules() error {
+	rules := make([]*ngmodels.AlertRule, 0)
+	err := m.sql.WithDbSession(m.ctx, func(sess *db.Session) error {
+		return sess.Table("alert_rule").Where("org_id = ?", m.orgID).OrderBy("id").Find(&rules)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		updated := *rule
+		updated.Data = append([]ngmodels.AlertQuery(nil), rule.Data...)
+		changed, err := m.migrateAlertQueries(updated.Data)
+		if err != nil {
+			return fmt.Errorf("alert rule %s: %w", rule.UID, err)
+		}
+		if !changed {
+			continue
+		}
+		canRead, err := m.canRead(alertRuleEvaluator(accesscontrol.ActionAlertingRuleRead, rule))
+		if err != nil {
+			return fmt.Errorf("alert rule %s: %w", rule.UID, err)
+		}
+		if !canRead {
+			m.report.HiddenAlertRules++
+			continue
+		}
+		if m.cmd.DryRun {
+			m.report.AlertRules = append(m.report.AlertRules, MigratedResource{UID: rule.UID, Title: rule.Title})
+			continue
+		}
+
+		// the changes are authorized like the changes of the ruler API
+		allowed, err := m.accessControl.Evaluate(m.ctx, m.cmd.User, alertRuleEvaluator(accesscontrol.ActionAlertingRuleUpdate, &updated))
+		if err != nil {
+			return fmt.Errorf("alert rule %s: %w", rule.UID, err)
+		}
+		if !allowed {
+			m.skip(KindAlertRule, rule.UID, rule.Title, errAlertRuleAccessDenied)
+			continue
+		}
+		provenance, err := m.alertRuleStore.GetProvenance(m.ctx, rule, m.orgID)
+		if err != nil {
+			return fmt.Errorf("alert rule %s: %w", rule.UID, err)
+		}
+		if provenance != ngmodels.ProvenanceNone {
+			m.skip(KindAlertRule, rule.UID, rule.Title, errAlertRuleProvisioned)
+			continue
+		}
+
+		updated.Updated = time.Now()
+		if err := m.alertRuleStore.UpdateAlertRules(m.ctx, []ngmodels.UpdateRule{{Existing: rule, New: updated}}); err != nil {
+			return fmt.Errorf("alert rule %s: %w", rule.UID, err)
+		}
+		m.report.AlertRules = append(m.report.AlertRules, MigratedResource{UID: rule.UID, Title: rule.Title})
+	}
+	return nil
+}
+
+var (
+	errAlertRuleAccessDenied   = errors.New("access denied to the folder or to the data sources of the alert rule")
+	errAlertRuleProvisioned    = errors.New("cannot update a provisioned alert rule")
+	errCorrelationAccessDenied = errors.New("access denied to the source data source of the correlation")
+)
+
+// migrateAlertQueries rewrites the datasource of the queries and the references in their models
+func (m *migration) migrateAlertQueries(queries []ngmodels.AlertQuery) (bool, error) {
+	changed := false
+	for i := range queries {
+		q := &queries[i]
+		if q.DatasourceUID != m.cmd.Source.UID {
+			continue
+		}
+		q.DatasourceUID = m.cmd.Target.UID
+		changed = true
+
+		var model interface{}
+		if len(q.Model) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(q.Model, &model); err != nil {
+			return false, fmt.Errorf("query %s: %w", q.RefID, err)
+		}
+		if m.rewriter.rewrite(model) {
+			raw, err := json.Marshal(model)
+			if err != nil {
+				return false, err
+			}
+			q.Model = raw
+		}
+	}
+	return changed, nil
+}
+
+// migrateCorrelations moves the correlations from and to the source datasource. A correlation is owned by its
+// source datasource, the correlations to the migrated datasource owned by a datasource the user is not allowed to
+// write are skipped.
+func (m *migration) migrateCorrelations() error {
+	return m.sql.WithTransactionalDbSession(m.ctx, func(sess *db.Session) error {
+		rows := make([]correlations.Correlation, 0)
+		err := sess.Select("correlation.*").
+			Join("", "data_source AS dss", "correlation.source_uid = dss.uid and dss.org_id = ?", m.orgID).
+			Where("correlation.source_uid = ? OR correlation.target_uid = ?", m.cmd.Source.UID, m.cmd.Source.UID).
+			OrderBy("correlation.uid").
+			Find(&rows)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range rows {
+			canWrite, err := m.accessControl.Evaluate(m.ctx, m.cmd.User, accesscontrol.EvalPermission(datasources.ActionWrite, datasources.ScopeProvider.GetResourceScopeUID(c.SourceUID)))
+			if err != nil {
+				return fmt.Errorf("correlation %s: %w", c.UID, err)
+			}
+			if !canWrite {
+				m.skip(KindCorrelation, c.UID, c.Label, errCorrelationAccessDenied)
+				continue
+			}
+			m.report.Correlations = append(m.report.Correlations, MigratedResource{UID: c.UID, Title: c.Label})
+			if m.cmd.DryRun {
+				continue
+			}
+
+			columns := map[string]interface{}{}
+			if c.SourceUID == m.cmd.Source.UID {
+				columns["source_uid"] = m.cmd.Target.UID
+			}
+			if c.TargetUID != nil && *c.TargetUID == m.cmd.Source.UID {
+				columns["target_uid"] = m.cmd.Target.UID
+			}
+			if _, err := sess.Table("correlation").Where("uid = ? AND source_uid = ?", c.UID, c.SourceUID).Update(columns); err != nil {
+				return fmt.Errorf("correlation %s: %w", c.UID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// datasourceRewriter replaces the references of a datasource in the JSON models, where the datasource is either a
+// reference with a UID or, in older models, the name or the UID of the datasource
+type datasourceRewriter struct {
+	sourceUID  string
+	sourceName string
+	targetUID  string
+	targetType string
+}
+
+// rewrite replaces the datasource properties referencing the source datasource in the decoded JSON value, it returns
+// whether the value was changed
+func (r datasourceRewriter) rewrite(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key == "datasource" {
+				if ref, ok := r.rewriteRef(child); ok {
+					v[key] = ref
+					changed = true
+					continue
+				}
+			}
+			if r.rewrite(child) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if r.rewrite(child) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func (r datasourceRewriter) rewriteRef(v interface{}) (interface{}, bool) {
+	switch ref := v.(type) {
+	case string:
+		if ref == r.sourceUID || ref == r.sourceName {
+			return map[string]interface{}{"uid": r.targetUID, "type": r.targetType}, true
+		}
+	case map[string]interface{}:
+		if uid, _ := ref["uid"].(string); uid == r.sourceUID {
+			ref["uid"] = r.targetUID
+			ref["type"] = r.targetType
+			return ref, true
+		}
+	}
+	return nil, false
+}
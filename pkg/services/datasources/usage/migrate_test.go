@@ -0,0 +1,322 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/correlations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/libraryelements/model"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationMigrate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	sqlStore := db.InitTestDB(t)
+	withSession := func(f func(sess *db.Session) error) {
+		t.Helper()
+		require.NoError(t, sqlStore.WithDbSession(context.Background(), f))
+	}
+	insert := func(beans ...interface{}) {
+		t.Helper()
+		withSession(func(sess *db.Session) error {
+			_, err := sess.Insert(beans...)
+			return err
+		})
+	}
+
+	source := &datasources.DataSource{OrgID: 1, UID: "old-prom", Name: "Old Prometheus", Type: "prometheus", Created: time.Now(), Updated: time.Now()}
+	target := &datasources.DataSource{OrgID: 1, UID: "new-prom", Name: "New Prometheus", Type: "prometheus", Created: time.Now(), Updated: time.Now()}
+	loki := &datasources.DataSource{OrgID: 1, UID: "loki", Name: "Loki", Type: "loki", Created: time.Now(), Updated: time.Now()}
+	insert(source, target, loki, &datasources.DataSource{OrgID: 2, UID: "old-prom", Name: "Old Prometheus", Type: "prometheus", Created: time.Now(), Updated: time.Now()})
+
+	insertDashboard := func(orgID int64, uid, body string) {
+		t.Helper()
+		data, err := simplejson.NewJson([]byte(body))
+		require.NoError(t, err)
+		dash := dashboards.NewDashboardFromJson(data)
+		dash.OrgID = orgID
+		dash.UID = uid
+		dash.Version = 1
+		insert(dash)
+	}
+	insertDashboard(1, "by-uid", `{"title": "By UID", "version": 1, "panels": [
+		{"id": 1, "title": "CPU", "datasource": {"uid": "old-prom", "type": "prometheus"}, "targets": [{"refId": "A", "datasource": {"uid": "old-prom", "type": "prometheus"}}]}
+	], "annotations": {"list": [{"name": "Deploys", "datasource": {"uid": "old-prom", "type": "prometheus"}}]}}`)
+	insertDashboard(1, "by-name", `{"title": "By name", "version": 1, "panels": [
+		{"id": 1, "title": "Memory", "datasource": "Old Prometheus"}
+	]}`)
+	insertDashboard(1, "unrelated", `{"title": "Unrelated", "version": 1, "panels": [
+		{"id": 1, "title": "Logs", "datasource": {"uid": "loki", "type": "loki"}}
+	]}`)
+	insertDashboard(1, "hidden", `{"title": "Hidden", "version": 1, "panels": [
+		{"id": 1, "title": "CPU", "datasource": {"uid": "old-prom", "type": "prometheus"}}
+	]}`)
+	insertDashboard(2, "other-org", `{"title": "Other org", "version": 1, "panels": [
+		{"id": 1, "title": "CPU", "datasource": {"uid": "old-prom", "type": "prometheus"}}
+	]}`)
+
+	insert(&model.LibraryElement{
+		OrgID:   1,
+		UID:     "lib-cpu",
+		Name:    "CPU",
+		Kind:    1,
+		Model:   json.RawMessage(`{"title": "CPU", "datasource": {"uid": "old-prom", "type": "prometheus"}}`),
+		Version: 1,
+		Created: time.Now(),
+		Updated: time.Now(),
+	})
+
+	rule := alertRule(1, "rule-prom", "old-prom")
+	rule.Version = 1
+	rule.Data[0].Model = json.RawMessage(`{"refId": "A", "datasource": {"uid": "old-prom", "type": "prometheus"}}`)
+	insert(rule, alertRule(2, "rule-other-org", "old-prom"))
+
+	insert(
+		&correlations.Correlation{UID: "prom-to-loki", SourceUID: "old-prom", TargetUID: &loki.UID, Label: "Logs"},
+		&correlations.Correlation{UID: "loki-to-loki", SourceUID: "loki", TargetUID: &loki.UID, Label: "Context"},
+		&correlations.Correlation{UID: "loki-to-prom", SourceUID: "loki", TargetUID: &source.UID, Label: "Metrics"},
+	)
+
+	provisioned := alertRule(1, "rule-provisioned", "old-prom")
+	denied := alertRule(1, "rule-denied", "old-prom")
+	denied.NamespaceUID = "other-folder"
+	hidden := alertRule(1, "rule-hidden", "old-prom")
+	hidden.NamespaceUID = "secret-folder"
+	insert(provisioned, denied, hidden)
+
+	dashboardService := &fakeDashboardService{denied: map[string]bool{"by-name": true}}
+	libraryElementService := &fakeLibraryElementService{}
+	ruleStore := &fakeAlertRuleStore{provisioned: map[string]bool{"rule-provisioned": true}}
+	s := &UsageService{
+		sql:                   sqlStore,
+		recordings:            fakeRecordings{},
+		dashboardService:      dashboardService,
+		libraryElementService: libraryElementService,
+		alertRuleStore:        ruleStore,
+		accessControl:         acimpl.ProvideAccessControl(setting.NewCfg()),
+		log:                   log.NewNopLogger(),
+	}
+	usr := &user.SignedInUser{OrgID: 1, UserID: 10, Permissions: map[int64]map[string][]string{1: {
+		dashboards.ActionDashboardsRead:        {"dashboards:uid:by-uid", "dashboards:uid:by-name"},
+		dashboards.ActionFoldersRead:           {"folders:uid:general"},
+		accesscontrol.ActionAlertingRuleRead:   {"folders:uid:folder", "folders:uid:other-folder"},
+		accesscontrol.ActionAlertingRuleUpdate: {"folders:uid:folder"},
+		datasources.ActionQuery:                {"datasources:uid:old-prom", "datasources:uid:new-prom"},
+		datasources.ActionWrite:                {"datasources:uid:old-prom"},
+	}}}
+
+	t.Run("dry run does not update the resources", func(t *testing.T) {
+		report, err := s.Migrate(context.Background(), MigrateCommand{User: usr, Source: source, Target: target, DryRun: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, &MigrationReport{
+			SourceUID:        "old-prom",
+			TargetUID:        "new-prom",
+			DryRun:           true,
+			Dashboards:       []MigratedResource{{UID: "by-uid", Title: "By UID"}, {UID: "by-name", Title: "By name"}},
+			HiddenDashboards: 1,
+			LibraryPanels:    []MigratedResource{{UID: "lib-cpu", Title: "CPU"}},
+			AlertRules:       []MigratedResource{{UID: "rule-prom", Title: "rule-prom"}, {UID: "rule-provisioned", Title: "rule-provisioned"}, {UID: "rule-denied", Title: "rule-denied"}},
+			HiddenAlertRules: 1,
+			Correlations:     []MigratedResource{{UID: "prom-to-loki", Title: "Logs"}},
+			Skipped: []SkippedResource{
+				{Kind: KindCorrelation, UID: "loki-to-prom", Title: "Metrics", Reason: errCorrelationAccessDenied.Error()},
+			},
+		}, report)
+		assert.Empty(t, dashboardService.saved)
+		assert.Empty(t, libraryElementService.patched)
+		assert.Empty(t, ruleStore.updated)
+
+		usage, err := s.GetUsage(context.Background(), usr, "old-prom")
+		require.NoError(t, err)
+		assert.Len(t, usage.Correlations, 2)
+	})
+
+	t.Run("rolls back the resources saved before a failure", func(t *testing.T) {
+		failing := &UsageService{
+			sql:                   sqlStore,
+			recordings:            fakeRecordings{},
+			dashboardService:      &fakeDashboardService{sql: sqlStore},
+			libraryElementService: &fakeLibraryElementService{},
+			alertRuleStore:        &fakeAlertRuleStore{err: errors.New("database is down")},
+			accessControl:         acimpl.ProvideAccessControl(setting.NewCfg()),
+			log:                   log.NewNopLogger(),
+		}
+		_, err := failing.Migrate(context.Background(), MigrateCommand{User: usr, Source: source, Target: target})
+		require.ErrorContains(t, err, "database is down")
+
+		var titles []string
+		withSession(func(sess *db.Session) error {
+			return sess.Table("dashboard").Where("org_id = 1").OrderBy("id").Cols("title").Find(&titles)
+		})
+		assert.Equal(t, []string{"By UID", "By name", "Unrelated", "Hidden"}, titles)
+	})
+
+	t.Run("saves the resources as the user", func(t *testing.T) {
+		report, err := s.Migrate(context.Background(), MigrateCommand{User: usr, Source: source, Target: target})
+		require.NoError(t, err)
+		assert.Equal(t, &MigrationReport{
+			SourceUID:        "old-prom",
+			TargetUID:        "new-prom",
+			Dashboards:       []MigratedResource{{UID: "by-uid", Title: "By UID"}},
+			HiddenDashboards: 1,
+			LibraryPanels:    []MigratedResource{{UID: "lib-cpu", Title: "CPU"}},
+			AlertRules:       []MigratedResource{{UID: "rule-prom", Title: "rule-prom"}},
+			HiddenAlertRules: 1,
+			Correlations:     []MigratedResource{{UID: "prom-to-loki", Title: "Logs"}},
+			Skipped: []SkippedResource{
+				{Kind: KindDashboard, UID: "by-name", Title: "By name", Reason: dashboards.ErrDashboardUpdateAccessDenied.Error()},
+				{Kind: KindAlertRule, UID: "rule-provisioned", Title: "rule-provisioned", Reason: errAlertRuleProvisioned.Error()},
+				{Kind: KindAlertRule, UID: "rule-denied", Title: "rule-denied", Reason: errAlertRuleAccessDenied.Error()},
+				{Kind: KindCorrelation, UID: "loki-to-prom", Title: "Metrics", Reason: errCorrelationAccessDenied.Error()},
+			},
+		}, report)
+
+		require.Len(t, dashboardService.saved, 2)
+		saved := dashboardService.saved[0]
+		assert.Equal(t, usr, saved.User)
+		assert.Equal(t, int64(1), saved.OrgID)
+		assert.False(t, saved.Overwrite)
+		assert.Equal(t, "Migrated data source Old Prometheus to New Prometheus", saved.Message)
+		assert.Equal(t, "by-uid", saved.Dashboard.UID)
+		assert.Equal(t, 1, saved.Dashboard.Data.Get("version").MustInt())
+		panel := saved.Dashboard.Data.Get("panels").GetIndex(0)
+		assert.Equal(t, "new-prom", panel.GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "new-prom", panel.Get("targets").GetIndex(0).GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "new-prom", saved.Dashboard.Data.GetPath("annotations", "list").GetIndex(0).GetPath("datasource", "uid").MustString())
+
+		require.Len(t, libraryElementService.patched, 1)
+		patch := libraryElementService.patched[0]
+		assert.Equal(t, int64(-1), patch.FolderID)
+		assert.Equal(t, int64(1), patch.Version)
+		assert.JSONEq(t, `{"title": "CPU", "datasource": {"uid": "new-prom", "type": "prometheus"}}`, string(patch.Model))
+
+		require.Len(t, ruleStore.updated, 1)
+		updated := ruleStore.updated[0]
+		assert.Equal(t, "old-prom", updated.Existing.Data[0].DatasourceUID)
+		assert.Equal(t, "new-prom", updated.New.Data[0].DatasourceUID)
+		assert.JSONEq(t, `{"refId": "A", "datasource": {"uid": "new-prom", "type": "prometheus"}}`, string(updated.New.Data[0].Model))
+
+		usage, err := s.GetUsage(context.Background(), usr, "new-prom")
+		require.NoError(t, err)
+		assert.Equal(t, []CorrelationUsage{{UID: "prom-to-loki", Label: "Logs", SourceUID: "new-prom", TargetUID: "loki"}}, usage.Correlations)
+	})
+
+	t.Run("fails to migrate to the same datasource", func(t *testing.T) {
+		_, err := s.Migrate(context.Background(), MigrateCommand{User: usr, Source: source, Target: source})
+		require.ErrorIs(t, err, ErrMigrateToSameDatasource)
+	})
+
+	t.Run("fails to migrate to a datasource of another type", func(t *testing.T) {
+		loki := &datasources.DataSource{OrgID: 1, UID: "loki", Name: "Loki", Type: "loki"}
+		_, err := s.Migrate(context.Background(), MigrateCommand{User: usr, Source: source, Target: loki})
+		require.ErrorIs(t, err, ErrMigrateToOtherType)
+	})
+}
+
+type fakeDashboardService struct {
+	dashboards.DashboardService
+	denied map[string]bool
+	saved  []*dashboards.SaveDashboardDTO
+	// sql renames the saved dashboards with the session of the context when set
+	sql db.DB
+}
+
+func (f *fakeDashboardService) SaveDashboard(ctx context.Context, dto *dashboards.SaveDashboardDTO, allowUiUpdate bool) (*dashboards.Dashboard, error) {
+	f.saved = append(f.saved, dto)
+	if f.denied[dto.Dashboard.UID] {
+		return nil, dashboards.ErrDashboardUpdateAccessDenied
+	}
+	if f.sql != nil {
+		err := f.sql.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec("UPDATE dashboard SET title = ? WHERE uid = ?", "Migrated "+dto.Dashboard.UID, dto.Dashboard.UID)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dto.Dashboard, nil
+}
+
+type fakeLibraryElementService struct {
+	libraryelements.Service
+	patched []model.PatchLibraryElementCommand
+}
+
+func (f *fakeLibraryElementService) PatchElement(c context.Context, signedInUser *user.SignedInUser, cmd model.PatchLibraryElementCommand, UID string) (model.LibraryElementDTO, error) {
+	f.patched = append(f.patched, cmd)
+	return model.LibraryElementDTO{UID: UID}, nil
+}
+
+type fakeAlertRuleStore struct {
+	provisioned map[string]bool
+	updated     []ngmodels.UpdateRule
+	err         error
+}
+
+func (f *fakeAlertRuleStore) GetProvenance(ctx context.Context, o ngmodels.Provisionable, org int64) (ngmodels.Provenance, error) {
+	if f.provisioned[o.ResourceID()] {
+		return ngmodels.ProvenanceFile, nil
+	}
+	return ngmodels.ProvenanceNone, nil
+}
+
+func (f *fakeAlertRuleStore) UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateRule) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.updated = append(f.updated, rules...)
+	return nil
+}
+
+func TestDatasourceRewriter(t *testing.T) {
+	r := datasourceRewriter{sourceUID: "old", sourceName: "Old", targetUID: "new", targetType: "loki"}
+
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"datasource": "Old",
+		"panels": [
+			{"datasource": {"uid": "old", "type": "prometheus"}},
+			{"datasource": "old"},
+			{"datasource": {"uid": "other"}},
+			{"datasource": null},
+			{"title": "old"}
+		]
+	}`), &v))
+
+	assert.True(t, r.rewrite(v))
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"datasource": {"uid": "new", "type": "loki"},
+		"panels": [
+			{"datasource": {"uid": "new", "type": "loki"}},
+			{"datasource": {"uid": "new", "type": "loki"}},
+			{"datasource": {"uid": "other"}},
+			{"datasource": null},
+			{"title": "old"}
+		]
+	}`, string(raw))
+
+	assert.False(t, r.rewrite(v))
+}
@@ -0,0 +1,88 @@
+package usage
+
+// Report lists what references a datasource: the datasource can be deleted or replaced once they are updated. The
+// dashboards and alert rules the user cannot read are only counted.
+// swagger:model
+type Report struct {
+	DatasourceUID    string               `json:"datasourceUid"`
+	Dashboards       []DashboardUsage     `json:"dashboards"`
+	HiddenDashboards int                  `json:"hiddenDashboards"`
+	AlertRules       []AlertRuleUsage     `json:"alertRules"`
+	HiddenAlertRules int                  `json:"hiddenAlertRules"`
+	Correlations     []CorrelationUsage   `json:"correlations"`
+	RecordedQueries  []RecordedQueryUsage `json:"recordedQueries"`
+}
+
+// DashboardUsage is a dashboard with panels querying the datasource. A dashboard referencing the datasource only
+// outside of its panels, for example in its annotations, has no panels.
+type DashboardUsage struct {
+	UID    string       `json:"uid"`
+	Title  string       `json:"title"`
+	Panels []PanelUsage `json:"panels"`
+}
+
+type PanelUsage struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+// AlertRuleUsage is an alert rule with a query of the datasource
+type AlertRuleUsage struct {
+	UID       string `json:"uid"`
+	Title     string `json:"title"`
+	FolderUID string `json:"folderUid"`
+	RuleGroup string `json:"ruleGroup"`
+}
+
+// CorrelationUsage is a correlation from or to the datasource
+type CorrelationUsage struct {
+	UID       string `json:"uid"`
+	Label     string `json:"label"`
+	SourceUID string `json:"sourceUid"`
+	TargetUID string `json:"targetUid,omitempty"`
+}
+
+// RecordedQueryUsage is a recorded query with a query of the datasource
+type RecordedQueryUsage struct {
+	UID    string `json:"uid"`
+	Metric string `json:"metric"`
+}
+
+// MigrationReport lists the resources updated to reference the target datasource instead of the source datasource,
+// or that would be updated for a dry run, and the resources the user could not update. The dashboards, library
+// panels and alert rules the user cannot read are only counted.
+// swagger:model
+type MigrationReport struct {
+	SourceUID           string             `json:"sourceUid"`
+	TargetUID           string             `json:"targetUid"`
+	DryRun              bool               `json:"dryRun"`
+	Dashboards          []MigratedResource `json:"dashboards"`
+	HiddenDashboards    int                `json:"hiddenDashboards"`
+	LibraryPanels       []MigratedResource `json:"libraryPanels"`
+	HiddenLibraryPanels int                `json:"hiddenLibraryPanels"`
+	AlertRules          []MigratedResource `json:"alertRules"`
+	HiddenAlertRules    int                `json:"hiddenAlertRules"`
+	Correlations        []MigratedResource `json:"correlations"`
+	Skipped             []SkippedResource  `json:"skipped"`
+}
+
+type MigratedResource struct {
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+}
+
+const (
+	KindDashboard    = "dashboard"
+	KindLibraryPanel = "libraryPanel"
+	KindAlertRule    = "alertRule"
+	KindCorrelation  = "correlation"
+)
+
+// SkippedResource is a resource referencing the source datasource that the user is not allowed to update, or that
+// is provisioned
+type SkippedResource struct {
+	Kind   string `json:"kind"`
+	UID    string `json:"uid"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,271 @@
+package usage
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/correlations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	ngstore "github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/recording"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/kind/dashboard"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// dashboardBatchSize is the number of dashboards read at once when looking for the references of a datasource
+const dashboardBatchSize = 100
+
+type Service interface {
+	// GetUsage returns the dashboards, alert rules, correlations and recorded queries of the organization of the user
+	// that reference the datasource. The dashboards and alert rules the user cannot read are only counted.
+	GetUsage(ctx context.Context, user *user.SignedInUser, datasourceUID string) (*Report, error)
+	// Migrate rewrites the references of a datasource to another datasource of the organization
+	Migrate(ctx context.Context, cmd MigrateCommand) (*MigrationReport, error)
+}
+
+// recordings lists the recorded queries, it is implemented by recording.Service
+type recordings interface {
+	Recordings(orgID int64) []recording.Recording
+}
+
+type UsageService struct {
+	sql                   db.DB
+	recordings            recordings
+	dashboardService      dashboards.DashboardService
+	libraryElementService libraryelements.Service
+	alertRuleStore        alertRuleStore
+	accessControl         accesscontrol.AccessControl
+	log                   log.Logger
+}
+
+func ProvideService(sql db.DB, recordingService *recording.Service, dashboardService dashboards.DashboardService,
+	libraryElementService libraryelements.Service, ruleStore *ngstore.DBstore, accessControl accesscontrol.AccessControl) *UsageService {
+	return &UsageService{
+		sql:                   sql,
+		recordings:            recordingService,
+		dashboardService:      dashboardService,
+		libraryElementService: libraryElementService,
+		alertRuleStore:        ruleStore,
+		accessControl:         accessControl,
+		log:                   log.New("datasources.usage"),
+	}
+}
+
+func (s *UsageService) GetUsage(ctx context.Context, user *user.SignedInUser, datasourceUID string) (*Report, error) {
+	orgID := user.OrgID
+	dashboardUsages, err := s.dashboardUsage(ctx, orgID, datasourceUID)
+	if err != nil {
+		return nil, err
+	}
+	alertRuleUsages, err := s.alertRuleUsage(ctx, orgID, datasourceUID)
+	if err != nil {
+		return nil, err
+	}
+	correlations, err := s.correlationUsage(ctx, orgID, datasourceUID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		DatasourceUID:   datasourceUID,
+		Dashboards:      []DashboardUsage{},
+		AlertRules:      []AlertRuleUsage{},
+		Correlations:    correlations,
+		RecordedQueries: s.recordedQueryUsage(orgID, datasourceUID),
+	}
+	for _, d := range dashboardUsages {
+		canRead, err := s.accessControl.Evaluate(ctx, user, accesscontrol.EvalPermission(dashboards.ActionDashboardsRead, dashboards.ScopeDashboardsProvider.GetResourceScopeUID(d.UID)))
+		if err != nil {
+			return nil, err
+		}
+		if canRead {
+			report.Dashboards = append(report.Dashboards, d)
+		} else {
+			report.HiddenDashboards++
+		}
+	}
+	for _, r := range alertRuleUsages {
+		canRead, err := s.accessControl.Evaluate(ctx, user, alertRuleEvaluator(accesscontrol.ActionAlertingRuleRead, r.rule))
+		if err != nil {
+			return nil, err
+		}
+		if canRead {
+			report.AlertRules = append(report.AlertRules, r.AlertRuleUsage)
+		} else {
+			report.HiddenAlertRules++
+		}
+	}
+	return report, nil
+}
+
+// alertRuleEvaluator evaluates the permissions of the ruler API on a rule: the action on the rules of its folder and
+// the query of all its datasources
+func alertRuleEvaluator(action string, rule *ngmodels.AlertRule) accesscontrol.Evaluator {
+	evaluators := []accesscontrol.Evaluator{
+		accesscontrol.EvalPermission(action, dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID)),
+	}
+	for _, q := range rule.Data {
+		if q.QueryType == expr.DatasourceType || expr.IsDataSource(q.DatasourceUID) {
+			continue
+		}
+		evaluators = append(evaluators, accesscontrol.EvalPermission(datasources.ActionQuery, datasources.ScopeProvider.GetResourceScopeUID(q.DatasourceUID)))
+	}
+	return accesscontrol.EvalAll(evaluators...)
+}
+
+type dashboardRow struct {
+	ID    int64  `xorm:"id"`
+	UID   string `xorm:"uid"`
+	Title string `xorm:"title"`
+	Data  []byte `xorm:"data"`
+}
+
+// dashboardUsage reads the dashboards in batches and lists the ones with panels querying the datasource. The
+// datasources referenced by name, the default datasource and the datasource variables are resolved like for the
+// search index.
+func (s *UsageService) dashboardUsage(ctx context.Context, orgID int64, datasourceUID string) ([]DashboardUsage, error) {
+	lookup, err := dashboard.LoadDatasourceLookup(ctx, orgID, s.sql)
+	if err != nil {
+		return nil, err
+	}
+	builder := dashboard.NewStaticDashboardSummaryBuilder(lookup, false)
+
+	usages := []DashboardUsage{}
+	var lastID int64
+	for {
+		rows := make([]*dashboardRow, 0, dashboardBatchSize)
+		err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+			return sess.Table("dashboard").
+				Where("org_id = ? AND is_folder = ? AND id > ?", orgID, s.sql.GetDialect().BooleanStr(false), lastID).
+				Cols("id", "uid", "title", "data").
+				OrderBy("id").
+				Limit(dashboardBatchSize).
+				Find(&rows)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			summary, _, err := builder(ctx, row.UID, row.Data)
+			if err != nil {
+				s.log.Warn("Failed to read dashboard", "uid", row.UID, "error", err)
+				continue
+			}
+			if usage, ok := dashboardUsageFromSummary(row.UID, row.Title, summary, datasourceUID); ok {
+				usages = append(usages, usage)
+			}
+		}
+
+		if len(rows) < dashboardBatchSize {
+			return usages, nil
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+func dashboardUsageFromSummary(uid, title string, summary *entity.EntitySummary, datasourceUID string) (DashboardUsage, bool) {
+	usage := DashboardUsage{UID: uid, Title: title, Panels: []PanelUsage{}}
+	for _, panel := range summary.Nested {
+		if referencesDatasource(panel.References, datasourceUID) {
+			usage.Panels = append(usage.Panels, PanelUsage{ID: panelID(panel, uid), Title: panel.Name})
+		}
+	}
+	return usage, len(usage.Panels) > 0 || referencesDatasource(summary.References, datasourceUID)
+}
+
+// panelID returns the ID of the panel from the UID of its summary, made of the dashboard UID and the panel ID
+func panelID(panel *entity.EntitySummary, dashboardUID string) int64 {
+	id, _ := strconv.ParseInt(strings.TrimPrefix(panel.UID, dashboardUID+"#"), 10, 64)
+	return id
+}
+
+func referencesDatasource(refs []*entity.EntityExternalReference, datasourceUID string) bool {
+	for _, ref := range refs {
+		if ref.Family == entity.StandardKindDataSource && ref.Identifier == datasourceUID {
+			return true
+		}
+	}
+	return false
+}
+
+// alertRuleUsageWithRule keeps the rule to check the permissions of the user
+type alertRuleUsageWithRule struct {
+	AlertRuleUsage
+	rule *ngmodels.AlertRule
+}
+
+func (s *UsageService) alertRuleUsage(ctx context.Context, orgID int64, datasourceUID string) ([]alertRuleUsageWithRule, error) {
+	rules := make([]*ngmodels.AlertRule, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table("alert_rule").Where("org_id = ?", orgID).OrderBy("namespace_uid, rule_group, rule_group_idx, id").Find(&rules)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usages := []alertRuleUsageWithRule{}
+	for _, rule := range rules {
+		for _, q := range rule.Data {
+			if q.DatasourceUID == datasourceUID {
+				usages = append(usages, alertRuleUsageWithRule{
+					AlertRuleUsage: AlertRuleUsage{
+						UID:       rule.UID,
+						Title:     rule.Title,
+						FolderUID: rule.NamespaceUID,
+						RuleGroup: rule.RuleGroup,
+					},
+					rule: rule,
+				})
+				break
+			}
+		}
+	}
+	return usages, nil
+}
+
+func (s *UsageService) correlationUsage(ctx context.Context, orgID int64, datasourceUID string) ([]CorrelationUsage, error) {
+	rows := make([]correlations.Correlation, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Select("correlation.*").
+			Join("", "data_source AS dss", "correlation.source_uid = dss.uid and dss.org_id = ?", orgID).
+			Where("correlation.source_uid = ? OR correlation.target_uid = ?", datasourceUID, datasourceUID).
+			OrderBy("correlation.uid").
+			Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]CorrelationUsage, 0, len(rows))
+	for _, c := range rows {
+		usage := CorrelationUsage{UID: c.UID, Label: c.Label, SourceUID: c.SourceUID}
+		if c.TargetUID != nil {
+			usage.TargetUID = *c.TargetUID
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+func (s *UsageService) recordedQueryUsage(orgID int64, datasourceUID string) []RecordedQueryUsage {
+	usages := []RecordedQueryUsage{}
+	for _, r := range s.recordings.Recordings(orgID) {
+		for _, q := range r.Queries {
+			if q.DatasourceUID == datasourceUID {
+				usages = append(usages, RecordedQueryUsage{UID: r.UID, Metric: r.Metric})
+				break
+			}
+		}
+	}
+	return usages
+}
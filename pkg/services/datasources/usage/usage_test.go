@@ -0,0 +1,149 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/correlations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/recording"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationGetUsage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	sqlStore := db.InitTestDB(t)
+	insert := func(beans ...interface{}) {
+		t.Helper()
+		err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+			_, err := sess.Insert(beans...)
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	insert(
+		&datasources.DataSource{OrgID: 1, UID: "prom", Name: "Prometheus", Type: "prometheus", IsDefault: true, Created: time.Now(), Updated: time.Now()},
+		&datasources.DataSource{OrgID: 1, UID: "loki", Name: "Loki", Type: "loki", Created: time.Now(), Updated: time.Now()},
+		&datasources.DataSource{OrgID: 2, UID: "prom", Name: "Prometheus", Type: "prometheus", Created: time.Now(), Updated: time.Now()},
+	)
+
+	insertDashboard := func(orgID int64, uid, body string) {
+		t.Helper()
+		data, err := simplejson.NewJson([]byte(body))
+		require.NoError(t, err)
+		dash := dashboards.NewDashboardFromJson(data)
+		dash.OrgID = orgID
+		dash.UID = uid
+		insert(dash)
+	}
+	insertDashboard(1, "by-uid", `{"title": "By UID", "panels": [
+		{"id": 1, "title": "CPU", "type": "timeseries", "datasource": {"uid": "prom", "type": "prometheus"}},
+		{"id": 2, "title": "Logs", "type": "logs", "datasource": {"uid": "loki", "type": "loki"}}
+	]}`)
+	insertDashboard(1, "by-name", `{"title": "By name", "panels": [
+		{"id": 3, "title": "Memory", "type": "timeseries", "datasource": "Prometheus"}
+	]}`)
+	insertDashboard(1, "default", `{"title": "Default", "panels": [
+		{"id": 4, "title": "Disk", "type": "timeseries", "targets": [{"refId": "A"}]}
+	]}`)
+	insertDashboard(1, "logs", `{"title": "Logs only", "panels": [
+		{"id": 5, "title": "Logs", "type": "logs", "datasource": {"uid": "loki", "type": "loki"}}
+	]}`)
+	insertDashboard(2, "other-org", `{"title": "Other org", "panels": [
+		{"id": 1, "title": "CPU", "type": "timeseries", "datasource": {"uid": "prom", "type": "prometheus"}}
+	]}`)
+
+	hiddenRule := alertRule(1, "rule-hidden", "prom")
+	hiddenRule.NamespaceUID = "other-folder"
+	insert(
+		alertRule(1, "rule-prom", "prom"),
+		alertRule(1, "rule-loki", "loki"),
+		alertRule(2, "rule-other-org", "prom"),
+		hiddenRule,
+	)
+
+	lokiUID := "loki"
+	insert(
+		&correlations.Correlation{UID: "prom-to-loki", SourceUID: "prom", TargetUID: &lokiUID, Label: "Logs"},
+		&correlations.Correlation{UID: "loki-to-loki", SourceUID: "loki", TargetUID: &lokiUID, Label: "Context"},
+	)
+
+	s := &UsageService{
+		sql: sqlStore,
+		recordings: fakeRecordings{1: {
+			{UID: "rec-loki", Metric: "errors", Queries: []recording.Query{{RefID: "A", DatasourceUID: "loki"}}},
+			{UID: "rec-prom", Metric: "cpu", Queries: []recording.Query{{RefID: "A", DatasourceUID: "prom"}, {RefID: "B", DatasourceUID: "__expr__"}}},
+		}},
+		accessControl: acimpl.ProvideAccessControl(setting.NewCfg()),
+		log:           log.NewNopLogger(),
+	}
+	usr := &user.SignedInUser{OrgID: 1, UserID: 1, Permissions: map[int64]map[string][]string{1: {
+		dashboards.ActionDashboardsRead:      {"dashboards:uid:by-uid", "dashboards:uid:by-name"},
+		accesscontrol.ActionAlertingRuleRead: {"folders:uid:folder"},
+		datasources.ActionQuery:              {"datasources:uid:prom"},
+	}}}
+
+	report, err := s.GetUsage(context.Background(), usr, "prom")
+	require.NoError(t, err)
+
+	assert.Equal(t, "prom", report.DatasourceUID)
+	assert.Equal(t, []DashboardUsage{
+		{UID: "by-uid", Title: "By UID", Panels: []PanelUsage{{ID: 1, Title: "CPU"}}},
+		{UID: "by-name", Title: "By name", Panels: []PanelUsage{{ID: 3, Title: "Memory"}}},
+	}, report.Dashboards)
+	// the dashboards and alert rules the user cannot read are only counted
+	assert.Equal(t, 1, report.HiddenDashboards)
+	assert.Equal(t, []AlertRuleUsage{{UID: "rule-prom", Title: "rule-prom", FolderUID: "folder", RuleGroup: "group"}}, report.AlertRules)
+	assert.Equal(t, 1, report.HiddenAlertRules)
+	assert.Equal(t, []CorrelationUsage{{UID: "prom-to-loki", Label: "Logs", SourceUID: "prom", TargetUID: "loki"}}, report.Correlations)
+	assert.Equal(t, []RecordedQueryUsage{{UID: "rec-prom", Metric: "cpu"}}, report.RecordedQueries)
+
+	report, err = s.GetUsage(context.Background(), usr, "unused")
+	require.NoError(t, err)
+	assert.Empty(t, report.Dashboards)
+	assert.Empty(t, report.AlertRules)
+	assert.Empty(t, report.Correlations)
+	assert.Empty(t, report.RecordedQueries)
+}
+
+type fakeRecordings map[int64][]recording.Recording
+
+func (f fakeRecordings) Recordings(orgID int64) []recording.Recording {
+	return f[orgID]
+}
+
+func alertRule(orgID int64, uid, datasourceUID string) *ngmodels.AlertRule {
+	return &ngmodels.AlertRule{
+		OrgID:        orgID,
+		UID:          uid,
+		Title:        uid,
+		Condition:    "A",
+		NamespaceUID: "folder",
+		RuleGroup:    "group",
+		Data: []ngmodels.AlertQuery{
+			{RefID: "A", DatasourceUID: datasourceUID, Model: json.RawMessage(`{}`)},
+		},
+		Updated:      time.Now(),
+		NoDataState:  ngmodels.NoData,
+		ExecErrState: ngmodels.AlertingErrState,
+		Annotations:  map[string]string{},
+		Labels:       map[string]string{},
+	}
+}
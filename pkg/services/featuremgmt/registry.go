@@ -434,5 +434,23 @@ var (
 			FrontendOnly: true,
 			Owner:        grafanaObservabilityMetricsSquad,
 		},
+		{
+			Name:        "dashboardQueryConversion",
+			Description: "Migrates stored queries of supported datasources to their current schema when dashboards are saved",
+			State:       FeatureStateAlpha,
+			Owner:       grafanaPluginsPlatformSquad,
+		},
+		{
+			Name:        "lokiQuerySharding",
+			Description: "Split metric queries by stream shard and run them in parallel in the backend",
+			State:       FeatureStateAlpha,
+			Owner:       grafanaObservabilityLogsSquad,
+		},
+		{
+			Name:        "queryDeduplication",
+			Description: "Execute the identical data source queries of a user running at the same time once",
+			State:       FeatureStateAlpha,
+			Owner:       grafanaPluginsPlatformSquad,
+		},
 	}
 )
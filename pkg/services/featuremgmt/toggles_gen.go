@@ -266,4 +266,16 @@ const (
 	// FlagInfluxdbBackendMigration
 	// Query InfluxDB InfluxQL without the proxy
 	FlagInfluxdbBackendMigration = "influxdbBackendMigration"
+
+	// FlagDashboardQueryConversion
+	// Migrates stored queries of supported datasources to their current schema when dashboards are saved
+	FlagDashboardQueryConversion = "dashboardQueryConversion"
+
+	// FlagLokiQuerySharding
+	// Split metric queries by stream shard and run them in parallel in the backend
+	FlagLokiQuerySharding = "lokiQuerySharding"
+
+	// FlagQueryDeduplication
+	// Execute the identical data source queries of a user running at the same time once
+	FlagQueryDeduplication = "queryDeduplication"
 )
@@ -0,0 +1,55 @@
+package foldersettings
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+// list returns the settings of the folders with the UIDs, the folders without settings are skipped
+func (s *FolderSettingsService) list(ctx context.Context, orgID int64, folderUIDs []string) ([]FolderSettings, error) {
+	settings := []FolderSettings{}
+	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
+		return session.Where("org_id = ?", orgID).In("folder_uid", folderUIDs).Find(&settings)
+	})
+	return settings, err
+}
+
+func (s *FolderSettingsService) upsert(ctx context.Context, settings *FolderSettings) error {
+	return s.store.WithTransactionalDbSession(ctx, func(session *db.Session) error {
+		existing := FolderSettings{}
+		exists, err := session.Where("org_id = ? AND folder_uid = ?", settings.OrgID, settings.FolderUID).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			_, err = session.Insert(settings)
+			return err
+		}
+
+		settings.ID = existing.ID
+		_, err = session.ID(existing.ID).AllCols().Update(settings)
+		return err
+	})
+}
+
+func (s *FolderSettingsService) delete(ctx context.Context, orgID int64, folderUID string) error {
+	return s.store.WithDbSession(ctx, func(session *db.Session) error {
+		_, err := session.Where("org_id = ? AND folder_uid = ?", orgID, folderUID).Delete(&FolderSettings{})
+		return err
+	})
+}
+
+// dashboardFolderUID returns the UID of the folder of the dashboard, empty for the dashboards of the General folder
+// and for unknown dashboards
+func (s *FolderSettingsService) dashboardFolderUID(ctx context.Context, orgID int64, dashboardUID string) (string, error) {
+	var folderUID string
+	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
+		_, err := session.SQL(
+			"SELECT f.uid FROM dashboard AS d INNER JOIN dashboard AS f ON f.id = d.folder_id AND f.org_id = d.org_id WHERE d.org_id = ? AND d.uid = ?",
+			orgID, dashboardUID,
+		).Get(&folderUID)
+		return err
+	})
+	return folderUID, err
+}
@@ -0,0 +1,122 @@
+package foldersettings
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/folder"
+)
+
+type Service interface {
+	// GetFolderSettings returns the settings of the folder, without the settings inherited from its parents.
+	GetFolderSettings(ctx context.Context, orgID int64, folderUID string) (Settings, error)
+	UpdateFolderSettings(ctx context.Context, cmd *UpdateFolderSettingsCommand) error
+	// GetInheritedSettings returns the settings applied to the dashboards of the folder. The default datasource and
+	// the query timeout are the ones of the closest folder setting them, the label matchers of the folder and of all
+	// its parents are added.
+	GetInheritedSettings(ctx context.Context, orgID int64, folderUID string) (Settings, error)
+	// GetDashboardSettings returns the settings inherited by the dashboard from its folder.
+	GetDashboardSettings(ctx context.Context, orgID int64, dashboardUID string) (Settings, error)
+}
+
+func ProvideService(sqlStore db.DB, folderService folder.Service) *FolderSettingsService {
+	return &FolderSettingsService{
+		store:         sqlStore,
+		folderService: folderService,
+	}
+}
+
+// Gives us compile time error if the service does not adhere to the contract of the interface
+var _ Service = (*FolderSettingsService)(nil)
+
+type FolderSettingsService struct {
+	store         db.DB
+	folderService folder.Service
+}
+
+func (s *FolderSettingsService) GetFolderSettings(ctx context.Context, orgID int64, folderUID string) (Settings, error) {
+	settings, err := s.list(ctx, orgID, []string{folderUID})
+	if err != nil {
+		return Settings{}, err
+	}
+	if len(settings) == 0 {
+		return Settings{LabelMatchers: []string{}}, nil
+	}
+	return settings[0].toSettings(), nil
+}
+
+func (s *FolderSettingsService) UpdateFolderSettings(ctx context.Context, cmd *UpdateFolderSettingsCommand) error {
+	if err := cmd.Settings.validate(); err != nil {
+		return err
+	}
+
+	if cmd.Settings.IsEmpty() {
+		return s.delete(ctx, cmd.OrgID, cmd.FolderUID)
+	}
+
+	matchers := LabelMatchers(cmd.LabelMatchers)
+	return s.upsert(ctx, &FolderSettings{
+		OrgID:                cmd.OrgID,
+		FolderUID:            cmd.FolderUID,
+		DefaultDatasourceUID: cmd.DefaultDatasourceUID,
+		QueryTimeoutSeconds:  cmd.QueryTimeoutSeconds,
+		LabelMatchers:        &matchers,
+		Updated:              time.Now(),
+	})
+}
+
+func (s *FolderSettingsService) GetInheritedSettings(ctx context.Context, orgID int64, folderUID string) (Settings, error) {
+	inherited := Settings{LabelMatchers: []string{}}
+	if folderUID == "" || folderUID == folder.GeneralFolderUID {
+		return inherited, nil
+	}
+
+	// the parents are returned from the root folder, they are only returned when the nested folders are enabled
+	parents, err := s.folderService.GetParents(ctx, folder.GetParentsQuery{UID: folderUID, OrgID: orgID})
+	if err != nil {
+		return Settings{}, err
+	}
+	uids := []string{folderUID}
+	for i := len(parents) - 1; i >= 0; i-- {
+		uids = append(uids, parents[i].UID)
+	}
+
+	rows, err := s.list(ctx, orgID, uids)
+	if err != nil {
+		return Settings{}, err
+	}
+	byUID := make(map[string]Settings, len(rows))
+	for _, row := range rows {
+		byUID[row.FolderUID] = row.toSettings()
+	}
+
+	seen := map[string]bool{}
+	for _, uid := range uids {
+		settings, ok := byUID[uid]
+		if !ok {
+			continue
+		}
+		if inherited.DefaultDatasourceUID == "" {
+			inherited.DefaultDatasourceUID = settings.DefaultDatasourceUID
+		}
+		if inherited.QueryTimeoutSeconds == 0 {
+			inherited.QueryTimeoutSeconds = settings.QueryTimeoutSeconds
+		}
+		for _, m := range settings.LabelMatchers {
+			if !seen[m] {
+				seen[m] = true
+				inherited.LabelMatchers = append(inherited.LabelMatchers, m)
+			}
+		}
+	}
+	return inherited, nil
+}
+
+func (s *FolderSettingsService) GetDashboardSettings(ctx context.Context, orgID int64, dashboardUID string) (Settings, error) {
+	folderUID, err := s.dashboardFolderUID(ctx, orgID, dashboardUID)
+	if err != nil {
+		return Settings{}, err
+	}
+	return s.GetInheritedSettings(ctx, orgID, folderUID)
+}
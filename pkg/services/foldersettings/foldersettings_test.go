@@ -0,0 +1,101 @@
+package foldersettings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/folder/foldertest"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+func TestIntegrationFolderSettings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	sqlStore := db.InitTestDB(t)
+	folders := foldertest.NewFakeService()
+	s := ProvideService(sqlStore, folders)
+
+	settings, err := s.GetFolderSettings(ctx, 1, "child")
+	require.NoError(t, err)
+	require.True(t, settings.IsEmpty())
+
+	require.NoError(t, s.UpdateFolderSettings(ctx, &UpdateFolderSettingsCommand{
+		OrgID:     1,
+		FolderUID: "parent",
+		Settings: Settings{
+			DefaultDatasourceUID: "prom",
+			QueryTimeoutSeconds:  30,
+			LabelMatchers:        []string{`team="payments"`},
+		},
+	}))
+	require.NoError(t, s.UpdateFolderSettings(ctx, &UpdateFolderSettingsCommand{
+		OrgID:     1,
+		FolderUID: "child",
+		Settings:  Settings{QueryTimeoutSeconds: 10, LabelMatchers: []string{`env!="dev"`, `team="payments"`}},
+	}))
+
+	settings, err = s.GetFolderSettings(ctx, 1, "child")
+	require.NoError(t, err)
+	require.Equal(t, Settings{QueryTimeoutSeconds: 10, LabelMatchers: []string{`env!="dev"`, `team="payments"`}}, settings)
+
+	t.Run("the settings of the parent folders are inherited", func(t *testing.T) {
+		folders.ExpectedFolders = []*folder.Folder{{UID: "parent"}}
+		inherited, err := s.GetInheritedSettings(ctx, 1, "child")
+		require.NoError(t, err)
+		require.Equal(t, Settings{
+			DefaultDatasourceUID: "prom",
+			QueryTimeoutSeconds:  10,
+			LabelMatchers:        []string{`env!="dev"`, `team="payments"`},
+		}, inherited)
+
+		inherited, err = s.GetInheritedSettings(ctx, 2, "child")
+		require.NoError(t, err)
+		require.True(t, inherited.IsEmpty(), "the settings are scoped by organization")
+	})
+
+	t.Run("the settings of the dashboard folder", func(t *testing.T) {
+		folders.ExpectedFolders = nil
+		err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			if _, err := sess.Exec("INSERT INTO dashboard (id, org_id, uid, title, slug, data, version, created, updated, is_folder, folder_id) VALUES (100, 1, 'child', 'Child', 'child', '{}', 1, ?, ?, ?, 0)", "2023-01-01", "2023-01-01", true); err != nil {
+				return err
+			}
+			_, err := sess.Exec("INSERT INTO dashboard (id, org_id, uid, title, slug, data, version, created, updated, is_folder, folder_id) VALUES (101, 1, 'dash', 'Dash', 'dash', '{}', 1, ?, ?, ?, 100)", "2023-01-01", "2023-01-01", false)
+			return err
+		})
+		require.NoError(t, err)
+
+		settings, err := s.GetDashboardSettings(ctx, 1, "dash")
+		require.NoError(t, err)
+		require.Equal(t, int64(10), settings.QueryTimeoutSeconds)
+
+		settings, err = s.GetDashboardSettings(ctx, 1, "unknown")
+		require.NoError(t, err)
+		require.True(t, settings.IsEmpty())
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		for _, invalid := range []Settings{
+			{QueryTimeoutSeconds: -1},
+			{LabelMatchers: []string{`team`}},
+			{LabelMatchers: []string{`team="a",env="b"`}},
+		} {
+			err := s.UpdateFolderSettings(ctx, &UpdateFolderSettingsCommand{OrgID: 1, FolderUID: "child", Settings: invalid})
+			var grafanaErr errutil.Error
+			require.ErrorAs(t, err, &grafanaErr)
+			require.Equal(t, ErrInvalidSettings.Status(), grafanaErr.Reason.Status())
+		}
+	})
+
+	t.Run("empty settings are removed", func(t *testing.T) {
+		require.NoError(t, s.UpdateFolderSettings(ctx, &UpdateFolderSettingsCommand{OrgID: 1, FolderUID: "child"}))
+		settings, err := s.GetFolderSettings(ctx, 1, "child")
+		require.NoError(t, err)
+		require.True(t, settings.IsEmpty())
+	})
+}
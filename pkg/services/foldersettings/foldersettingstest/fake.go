@@ -0,0 +1,37 @@
+package foldersettingstest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/foldersettings"
+)
+
+type FakeService struct {
+	ExpectedSettings          foldersettings.Settings
+	ExpectedInheritedSettings foldersettings.Settings
+	ExpectedError             error
+	UpdatedSettings           *foldersettings.UpdateFolderSettingsCommand
+}
+
+func NewFakeService() *FakeService {
+	return &FakeService{}
+}
+
+var _ foldersettings.Service = (*FakeService)(nil)
+
+func (s *FakeService) GetFolderSettings(ctx context.Context, orgID int64, folderUID string) (foldersettings.Settings, error) {
+	return s.ExpectedSettings, s.ExpectedError
+}
+
+func (s *FakeService) UpdateFolderSettings(ctx context.Context, cmd *foldersettings.UpdateFolderSettingsCommand) error {
+	s.UpdatedSettings = cmd
+	return s.ExpectedError
+}
+
+func (s *FakeService) GetInheritedSettings(ctx context.Context, orgID int64, folderUID string) (foldersettings.Settings, error) {
+	return s.ExpectedInheritedSettings, s.ExpectedError
+}
+
+func (s *FakeService) GetDashboardSettings(ctx context.Context, orgID int64, dashboardUID string) (foldersettings.Settings, error) {
+	return s.ExpectedInheritedSettings, s.ExpectedError
+}
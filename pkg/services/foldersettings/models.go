@@ -0,0 +1,99 @@
+package foldersettings
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/datasources/enforcer"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var ErrInvalidSettings = errutil.NewBase(
+	errutil.StatusBadRequest,
+	"folderSettings.invalid",
+	errutil.WithPublicMessage("The folder settings are invalid, the query timeout can't be negative and the label matchers must be PromQL label matchers"),
+)
+
+// FolderSettings is the model for the settings of a folder, inherited by the dashboards of the folder and of its
+// subfolders.
+type FolderSettings struct {
+	ID                   int64          `xorm:"pk autoincr 'id'"`
+	OrgID                int64          `xorm:"org_id"`
+	FolderUID            string         `xorm:"folder_uid"`
+	DefaultDatasourceUID string         `xorm:"default_datasource_uid"`
+	QueryTimeoutSeconds  int64          `xorm:"query_timeout_seconds"`
+	LabelMatchers        *LabelMatchers `xorm:"label_matchers"`
+	Updated              time.Time
+}
+
+func (s FolderSettings) TableName() string {
+	return "folder_settings"
+}
+
+func (s FolderSettings) toSettings() Settings {
+	settings := Settings{
+		DefaultDatasourceUID: s.DefaultDatasourceUID,
+		QueryTimeoutSeconds:  s.QueryTimeoutSeconds,
+		LabelMatchers:        []string{},
+	}
+	if s.LabelMatchers != nil {
+		settings.LabelMatchers = append(settings.LabelMatchers, *s.LabelMatchers...)
+	}
+	return settings
+}
+
+// LabelMatchers is a list of PromQL label matchers, such as team="payments"
+type LabelMatchers []string
+
+func (m *LabelMatchers) FromDB(data []byte) error {
+	return json.Unmarshal(data, m)
+}
+
+func (m *LabelMatchers) ToDB() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// Settings are the settings of a folder.
+type Settings struct {
+	// DefaultDatasourceUID is the datasource of the panels and the queries of the dashboards without datasource
+	DefaultDatasourceUID string `json:"defaultDatasourceUid"`
+	// QueryTimeoutSeconds is the timeout of the queries of the dashboards, zero for no timeout
+	QueryTimeoutSeconds int64 `json:"queryTimeoutSeconds"`
+	// LabelMatchers are added to the selectors of the Prometheus queries of the dashboards
+	LabelMatchers []string `json:"labelMatchers"`
+}
+
+// IsEmpty returns true when none of the settings is set
+func (s Settings) IsEmpty() bool {
+	return s.DefaultDatasourceUID == "" && s.QueryTimeoutSeconds == 0 && len(s.LabelMatchers) == 0
+}
+
+// Timeout returns the query timeout, zero when no timeout is set
+func (s Settings) Timeout() time.Duration {
+	return time.Duration(s.QueryTimeoutSeconds) * time.Second
+}
+
+func (s Settings) validate() error {
+	if s.QueryTimeoutSeconds < 0 {
+		return ErrInvalidSettings.Errorf("invalid query timeout %d", s.QueryTimeoutSeconds)
+	}
+	if _, err := enforcer.ParseLabelMatchers(s.LabelMatchers); err != nil {
+		return ErrInvalidSettings.Errorf("%w", err)
+	}
+	return nil
+}
+
+// UpdateFolderSettingsCommand replaces the settings of a folder
+type UpdateFolderSettingsCommand struct {
+	Settings
+
+	OrgID     int64  `json:"-"`
+	FolderUID string `json:"-"`
+}
+
+// FolderSettingsDTO are the settings of a folder and the settings inherited by its dashboards
+type FolderSettingsDTO struct {
+	Settings
+	// Inherited are the settings applied to the dashboards of the folder, including the ones of its parents
+	Inherited Settings `json:"inherited"`
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ldap"
 	"github.com/grafana/grafana/pkg/services/ldap/multildap"
 	"github.com/grafana/grafana/pkg/services/ldap/service"
+	"github.com/grafana/grafana/pkg/services/ldap/teamsync"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/supportbundles"
@@ -37,12 +38,13 @@ type Service struct {
 	sessionService    auth.UserTokenService
 	log               log.Logger
 	ldapService       service.LDAP
+	teamSync          *teamsync.Service
 }
 
 func ProvideService(cfg *setting.Cfg, router routing.RouteRegister, accessControl ac.AccessControl,
 	userService user.Service, authInfoService login.AuthInfoService, ldapGroupsService ldap.Groups,
 	loginService login.Service, orgService org.Service, ldapService service.LDAP,
-	sessionService auth.UserTokenService, bundleRegistry supportbundles.Service) *Service {
+	sessionService auth.UserTokenService, bundleRegistry supportbundles.Service, teamSync *teamsync.Service) *Service {
 	s := &Service{
 		cfg:               cfg,
 		userService:       userService,
@@ -52,6 +54,7 @@ func ProvideService(cfg *setting.Cfg, router routing.RouteRegister, accessContro
 		orgService:        orgService,
 		sessionService:    sessionService,
 		ldapService:       ldapService,
+		teamSync:          teamSync,
 		log:               log.New("ldap.api"),
 	}
 
@@ -61,6 +64,7 @@ func ProvideService(cfg *setting.Cfg, router routing.RouteRegister, accessContro
 	router.Group("/api/admin", func(adminRoute routing.RouteRegister) {
 		adminRoute.Post("/ldap/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPConfigReload)), routing.Wrap(s.ReloadLDAPCfg))
 		adminRoute.Post("/ldap/sync/:id", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPUsersSync)), routing.Wrap(s.PostSyncUserWithLDAP))
+		adminRoute.Post("/ldap/teams/sync", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPUsersSync)), routing.Wrap(s.PostSyncTeamsWithLDAP))
 		adminRoute.Get("/ldap/:username", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPUsersRead)), routing.Wrap(s.GetUserFromLDAP))
 		adminRoute.Get("/ldap/status", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPStatusRead)), routing.Wrap(s.GetLDAPStatus))
 	}, middleware.ReqSignedIn)
@@ -247,6 +251,35 @@ func (s *Service) PostSyncUserWithLDAP(c *contextmodel.ReqContext) response.Resp
 	return response.Success("User synced successfully")
 }
 
+// swagger:route POST /admin/ldap/teams/sync admin_ldap postSyncTeamsWithLDAP
+//
+// Synchronizes the members of the teams in the team mappings with the groups of the LDAP users. Only the differences are applied, and only the memberships added by a previous synchronization are removed.
+// With `dryRun=true`, the changes are returned without being applied.
+//
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `ldap.user:sync`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: syncLDAPTeamsResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (s *Service) PostSyncTeamsWithLDAP(c *contextmodel.ReqContext) response.Response {
+	if !s.cfg.LDAPEnabled {
+		return response.Error(http.StatusBadRequest, "LDAP is not enabled", nil)
+	}
+
+	result, err := s.teamSync.Sync(c.Req.Context(), c.QueryBool("dryRun"))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to sync the teams with LDAP", err)
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
 // swagger:route GET /admin/ldap/{user_name} admin_ldap getUserFromLDAP
 //
 // Finds an user based on a username in LDAP. This helps illustrate how would the particular user be mapped in Grafana when synced.
@@ -73,6 +73,7 @@ func setupAPITest(t *testing.T, opts ...func(a *Service)) (*Service, *webtest.Se
 		service.NewLDAPFakeService(),
 		authtest.NewFakeUserAuthTokenService(),
 		supportbundlestest.NewFakeBundleService(),
+		nil,
 	)
 
 	for _, o := range opts {
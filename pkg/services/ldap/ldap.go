@@ -288,8 +288,8 @@ func (server *Server) Users(logins []string) (
 ) {
 	var users [][]*ldap.Entry
 	err := getUsersIteration(logins, func(previous, current int) error {
-		var err error
-		users, err = server.users(logins[previous:current])
+		entries, err := server.users(logins[previous:current])
+		users = append(users, entries...)
 		return err
 	})
 	if err != nil {
@@ -182,6 +182,39 @@ func TestServer_Users(t *testing.T) {
 		assert.Len(t, searchResult, 2)
 	})
 
+	t.Run("more users than in a request", func(t *testing.T) {
+		conn := &MockConnection{}
+		requests := 0
+		conn.setSearchFunc(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			requests++
+			return &ldap.SearchResult{Entries: []*ldap.Entry{{
+				DN: fmt.Sprintf("dn%d", requests), Attributes: []*ldap.EntryAttribute{
+					{Name: "username", Values: []string{fmt.Sprintf("user%d", requests)}},
+				}},
+			}}, nil
+		})
+
+		server := &Server{
+			cfg: setting.NewCfg(),
+			Config: &ServerConfig{
+				Attr:          AttributeMap{Username: "username"},
+				SearchBaseDNs: []string{"BaseDNHere"},
+			},
+			Connection: conn,
+			log:        log.New("test-logger"),
+		}
+
+		logins := make([]string, 2*UsersMaxRequest+1)
+		for i := range logins {
+			logins[i] = fmt.Sprintf("user%d", i)
+		}
+		searchResult, err := server.Users(logins)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, requests)
+		assert.Len(t, searchResult, 3)
+	})
+
 	t.Run("same user in multiple DNs", func(t *testing.T) {
 		conn := &MockConnection{}
 		firstDN := "dc=users1,dc=example,dc=org"
@@ -61,6 +61,16 @@ func readConfig(configFile string) (*ldap.Config, error) {
 			}
 		}
 
+		for _, teamMap := range server.Teams {
+			if teamMap.TeamId == 0 {
+				return nil, fmt.Errorf("LDAP team mapping: team id is required")
+			}
+
+			if teamMap.OrgId == 0 {
+				teamMap.OrgId = 1
+			}
+		}
+
 		// set default timeout if unspecified
 		if server.Timeout == 0 {
 			server.Timeout = defaultTimeout
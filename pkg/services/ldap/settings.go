@@ -50,6 +50,7 @@ type ServerConfig struct {
 	GroupSearchBaseDNs             []string `toml:"group_search_base_dns"`
 
 	Groups []*GroupToOrgRole `toml:"group_mappings"`
+	Teams  []*GroupToTeam    `toml:"team_mappings"`
 }
 
 // AttributeMap is a struct representation for LDAP "attributes" setting
@@ -73,6 +74,14 @@ type GroupToOrgRole struct {
 	OrgRole org.RoleType `toml:"org_role"`
 }
 
+// GroupToTeam is a struct representation of LDAP
+// config "team_mappings" setting
+type GroupToTeam struct {
+	GroupDN string `toml:"group_dn"`
+	OrgId   int64  `toml:"org_id"`
+	TeamId  int64  `toml:"team_id"`
+}
+
 // logger for all LDAP stuff
 var logger = log.New("ldap")
 
@@ -167,6 +176,16 @@ func readConfig(configFile string) (*Config, error) {
 			}
 		}
 
+		for _, teamMap := range server.Teams {
+			if teamMap.TeamId == 0 {
+				return nil, fmt.Errorf("LDAP team mapping: team id is required")
+			}
+
+			if teamMap.OrgId == 0 {
+				teamMap.OrgId = 1
+			}
+		}
+
 		// set default timeout if unspecified
 		if server.Timeout == 0 {
 			server.Timeout = defaultTimeout
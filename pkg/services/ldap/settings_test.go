@@ -16,6 +16,7 @@ func TestReadingLDAPSettings(t *testing.T) {
 	assert.EqualValues(t, uint16(tls.VersionTLS13), config.Servers[0].minTLSVersion)
 	assert.EqualValues(t, []string{"TLS_CHACHA20_POLY1305_SHA256", "TLS_AES_128_GCM_SHA256"}, config.Servers[0].TLSCiphers)
 	assert.ElementsMatch(t, []uint16{tls.TLS_CHACHA20_POLY1305_SHA256, tls.TLS_AES_128_GCM_SHA256}, config.Servers[0].tlsCiphers)
+	assert.Equal(t, []*GroupToTeam{{GroupDN: "cn=sre,ou=groups,dc=grafana,dc=org", OrgId: 1, TeamId: 3}}, config.Servers[0].Teams)
 }
 
 func TestReadingLDAPSettingsWithEnvVariable(t *testing.T) {
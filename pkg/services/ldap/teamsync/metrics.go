@@ -0,0 +1,58 @@
+package teamsync
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsSubSystem = "ldap"
+	metricsNamespace = "grafana"
+)
+
+type metrics struct {
+	runs        *prometheus.CounterVec
+	duration    prometheus.Histogram
+	changes     *prometheus.CounterVec
+	lastSuccess prometheus.Gauge
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "team_sync_runs_total",
+			Help:      "Number of syncs of the LDAP teams",
+		}, []string{"result"}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "team_sync_duration_seconds",
+			Help:      "Duration of the syncs of the LDAP teams",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 4, 8),
+		}),
+		changes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "team_sync_membership_changes_total",
+			Help:      "Number of team memberships added or removed by the syncs of the LDAP teams",
+		}, []string{"action", "result"}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "team_sync_last_success_timestamp_seconds",
+			Help:      "Time of the last successful sync of the LDAP teams",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(
+			m.runs,
+			m.duration,
+			m.changes,
+			m.lastSuccess,
+		)
+	}
+
+	return m
+}
@@ -0,0 +1,374 @@
+package teamsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/ldap/service"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// permissionMember is the team permission of the members added by the sync
+const permissionMember = "Member"
+
+// Action is the change of a team membership
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionRemove Action = "remove"
+)
+
+// Change is a team membership added or removed by a sync
+type Change struct {
+	UserID int64  `json:"userId"`
+	Login  string `json:"login"`
+	OrgID  int64  `json:"orgId"`
+	TeamID int64  `json:"teamId"`
+	Action Action `json:"action"`
+}
+
+// Result of a sync. The changes of a dry run are the ones that would have been made.
+type Result struct {
+	DryRun bool `json:"dryRun"`
+	// Users is the number of LDAP users of Grafana found in the LDAP servers
+	Users   int      `json:"users"`
+	Changes []Change `json:"changes"`
+	// Failed is the number of changes that couldn't be made
+	Failed int `json:"failed"`
+}
+
+// Service syncs the members of the teams in the team mappings of the LDAP servers with the groups of the users in the
+// background, instead of only when the users log in. Only the differences are written, and only the memberships added
+// by the sync are removed.
+type Service struct {
+	cfg             *setting.Cfg
+	ldapService     service.LDAP
+	users           user.Service
+	orgs            org.Service
+	teams           team.Service
+	teamPermissions accesscontrol.TeamPermissionsService
+	serverLock      *serverlock.ServerLockService
+	metrics         *metrics
+	log             log.Logger
+
+	// signedInUser lists the LDAP users of all the organizations
+	signedInUser *user.SignedInUser
+	// newServer creates the clients of the LDAP servers
+	newServer func(*ldap.ServerConfig, *setting.Cfg) ldap.IServer
+	// mu makes the syncs sequential
+	mu sync.Mutex
+}
+
+func ProvideService(cfg *setting.Cfg, ldapService service.LDAP, userService user.Service, orgService org.Service,
+	teamService team.Service, teamPermissions accesscontrol.TeamPermissionsService,
+	serverLock *serverlock.ServerLockService, reg prometheus.Registerer) *Service {
+	return &Service{
+		cfg:             cfg,
+		ldapService:     ldapService,
+		users:           userService,
+		orgs:            orgService,
+		teams:           teamService,
+		teamPermissions: teamPermissions,
+		serverLock:      serverLock,
+		metrics:         newMetrics(reg),
+		log:             log.New("ldap.teamsync"),
+		signedInUser: accesscontrol.BackgroundUser("ldap_team_sync", accesscontrol.GlobalOrgID, org.RoleAdmin, []accesscontrol.Permission{
+			{Action: accesscontrol.ActionUsersRead, Scope: accesscontrol.ScopeGlobalUsersAll},
+		}),
+		newServer: ldap.New,
+	}
+}
+
+// IsDisabled disables the background sync when LDAP is disabled or the sync interval is 0, the teams can still be
+// synced from the API then.
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.LDAPEnabled || s.cfg.LDAPTeamSyncInterval <= 0
+}
+
+// Run syncs the teams at the sync interval. When Grafana runs in high availability, the teams are synced by a single
+// instance.
+func (s *Service) Run(ctx context.Context) error {
+	interval := s.cfg.LDAPTeamSyncInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := s.serverLock.LockAndExecute(ctx, "ldap team sync", interval/2, func(ctx context.Context) {
+				if _, err := s.Sync(ctx, false); err != nil {
+					s.log.Error("Failed to sync the LDAP teams", "error", err)
+				}
+			})
+			if err != nil {
+				s.log.Error("Failed to lock the LDAP team sync", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Sync adds the LDAP users of Grafana to the mapped teams of their groups, and removes the members added by a previous
+// sync from the teams of the groups they left. The changes are only listed when dryRun is true.
+func (s *Service) Sync(ctx context.Context, dryRun bool) (*Result, error) {
+	if !s.cfg.LDAPEnabled {
+		return nil, service.ErrLDAPNotEnabled
+	}
+	if dryRun {
+		return s.sync(ctx, true)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	result, err := s.sync(ctx, false)
+	s.metrics.duration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		s.metrics.runs.WithLabelValues("failure").Inc()
+		return nil, err
+	}
+	s.metrics.runs.WithLabelValues("success").Inc()
+	s.metrics.lastSuccess.SetToCurrentTime()
+	s.log.Info("Synced the LDAP teams", "users", result.Users, "changes", len(result.Changes), "failed", result.Failed, "duration", time.Since(start))
+	return result, nil
+}
+
+func (s *Service) sync(ctx context.Context, dryRun bool) (*Result, error) {
+	result := &Result{DryRun: dryRun, Changes: []Change{}}
+	config := s.ldapService.Config()
+	if config == nil {
+		return nil, service.ErrUnableToCreateLDAPClient
+	}
+
+	teams, err := s.mappedTeams(ctx, config.Servers)
+	if err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 {
+		return result, nil
+	}
+
+	servers, err := s.connect(config.Servers)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAll(servers)
+
+	// the users are listed and searched in the LDAP servers a page at a time
+	for page := 1; ; page++ {
+		users, err := s.users.Search(ctx, &user.SearchUsersQuery{
+			SignedInUser: s.signedInUser,
+			AuthModule:   login.LDAPAuthModule,
+			Page:         page,
+			Limit:        ldap.UsersMaxRequest,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the LDAP users: %w", err)
+		}
+
+		found, err := lookup(config.Servers, servers, users.Users)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range users.Users {
+			ldapUser, ok := found[strings.ToLower(hit.Login)]
+			if !ok {
+				// the users missing from the LDAP servers are disabled when they log in or are synced
+				continue
+			}
+			result.Users++
+			if err := s.syncUser(ctx, hit, ldapUser, teams, result); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(users.Users) < ldap.UsersMaxRequest {
+			return result, nil
+		}
+	}
+}
+
+// mappedTeams returns the teams of the team mappings by organization, the mappings of the missing teams are ignored
+func (s *Service) mappedTeams(ctx context.Context, configs []*ldap.ServerConfig) (map[int64][]int64, error) {
+	teams := map[int64][]int64{}
+	seen := map[[2]int64]bool{}
+	for _, config := range configs {
+		for _, mapping := range config.Teams {
+			key := [2]int64{mapping.OrgId, mapping.TeamId}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if _, err := s.teams.GetTeamByID(ctx, &team.GetTeamByIDQuery{OrgID: mapping.OrgId, ID: mapping.TeamId}); err != nil {
+				if errors.Is(err, team.ErrTeamNotFound) {
+					s.log.Warn("Ignoring the LDAP team mapping of a missing team", "orgId", mapping.OrgId, "teamId", mapping.TeamId)
+					continue
+				}
+				return nil, err
+			}
+			teams[mapping.OrgId] = append(teams[mapping.OrgId], mapping.TeamId)
+		}
+	}
+	return teams, nil
+}
+
+// connect connects to all the LDAP servers. The sync fails when one of them isn't available, since the users found in
+// the next servers could belong to it.
+func (s *Service) connect(configs []*ldap.ServerConfig) ([]ldap.IServer, error) {
+	servers := make([]ldap.IServer, 0, len(configs))
+	for _, config := range configs {
+		server := s.newServer(config, s.cfg)
+		if err := server.Dial(); err != nil {
+			closeAll(servers)
+			return nil, fmt.Errorf("failed to connect to the LDAP server %s: %w", config.Host, err)
+		}
+		servers = append(servers, server)
+
+		if err := server.Bind(); err != nil {
+			closeAll(servers)
+			return nil, fmt.Errorf("failed to bind to the LDAP server %s: %w", config.Host, err)
+		}
+	}
+	return servers, nil
+}
+
+func closeAll(servers []ldap.IServer) {
+	for _, server := range servers {
+		server.Close()
+	}
+}
+
+// ldapUser is a user found in an LDAP server
+type ldapUser struct {
+	groups []string
+	config *ldap.ServerConfig
+}
+
+// isMember returns true when the user is in a group of the team in the team mappings of its server
+func (u ldapUser) isMember(orgID, teamID int64) bool {
+	for _, mapping := range u.config.Teams {
+		if mapping.OrgId == orgID && mapping.TeamId == teamID && ldap.IsMemberOf(u.groups, mapping.GroupDN) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup searches the enabled users in the LDAP servers by lowercase login. Like when the users log in, the groups of
+// a user come from the first server the user is found in.
+func lookup(configs []*ldap.ServerConfig, servers []ldap.IServer, hits []*user.UserSearchHitDTO) (map[string]ldapUser, error) {
+	found := make(map[string]ldapUser, len(hits))
+	remaining := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		if !hit.IsDisabled {
+			remaining = append(remaining, hit.Login)
+		}
+	}
+
+	for i, server := range servers {
+		if len(remaining) == 0 {
+			break
+		}
+
+		users, err := server.Users(remaining)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search the users in the LDAP server %s: %w", configs[i].Host, err)
+		}
+		for _, u := range users {
+			if _, ok := found[strings.ToLower(u.Login)]; !ok {
+				found[strings.ToLower(u.Login)] = ldapUser{groups: u.Groups, config: configs[i]}
+			}
+		}
+
+		next := remaining[:0]
+		for _, l := range remaining {
+			if _, ok := found[strings.ToLower(l)]; !ok {
+				next = append(next, l)
+			}
+		}
+		remaining = next
+	}
+	return found, nil
+}
+
+// syncUser adds the user to the mapped teams of its groups, and removes it from the other mapped teams it was added to
+// by a sync. The memberships that weren't added by a sync are kept.
+func (s *Service) syncUser(ctx context.Context, hit *user.UserSearchHitDTO, ldapUser ldapUser, teams map[int64][]int64, result *Result) error {
+	orgs, err := s.orgs.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: hit.ID})
+	if err != nil {
+		return err
+	}
+
+	for _, o := range orgs {
+		if len(teams[o.OrgID]) == 0 {
+			continue
+		}
+
+		memberships, err := s.teams.GetUserTeamMemberships(ctx, o.OrgID, hit.ID, false)
+		if err != nil {
+			return err
+		}
+		external := make(map[int64]bool, len(memberships))
+		for _, m := range memberships {
+			external[m.TeamID] = m.External
+		}
+
+		for _, teamID := range teams[o.OrgID] {
+			isExternal, isMember := external[teamID]
+			change := Change{UserID: hit.ID, Login: hit.Login, OrgID: o.OrgID, TeamID: teamID}
+			switch shouldBeMember := ldapUser.isMember(o.OrgID, teamID); {
+			case shouldBeMember && !isMember:
+				change.Action = ActionAdd
+			case !shouldBeMember && isMember && isExternal:
+				change.Action = ActionRemove
+			default:
+				continue
+			}
+			s.apply(ctx, change, result)
+		}
+	}
+	return nil
+}
+
+// apply makes the change, or only lists it on a dry run. A change that fails is logged and the sync continues.
+func (s *Service) apply(ctx context.Context, change Change, result *Result) {
+	if result.DryRun {
+		result.Changes = append(result.Changes, change)
+		return
+	}
+
+	permission := ""
+	if change.Action == ActionAdd {
+		permission = permissionMember
+	}
+	_, err := s.teamPermissions.SetUserPermission(ctx, change.OrgID, accesscontrol.User{ID: change.UserID, IsExternal: true},
+		strconv.FormatInt(change.TeamID, 10), permission)
+	if err != nil {
+		s.log.Warn("Failed to sync the LDAP team membership", "userId", change.UserID, "orgId", change.OrgID, "teamId", change.TeamID, "action", change.Action, "error", err)
+		s.metrics.changes.WithLabelValues(string(change.Action), "failure").Inc()
+		result.Failed++
+		return
+	}
+
+	s.log.Debug("Synced the LDAP team membership", "userId", change.UserID, "orgId", change.OrgID, "teamId", change.TeamID, "action", change.Action)
+	s.metrics.changes.WithLabelValues(string(change.Action), "success").Inc()
+	result.Changes = append(result.Changes, change)
+}
@@ -0,0 +1,214 @@
+package teamsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/ldap/service"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/team/teamtest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeServer struct {
+	users   []*login.ExternalUserInfo
+	dialErr error
+	closed  bool
+}
+
+func (s *fakeServer) Login(*login.LoginUserQuery) (*login.ExternalUserInfo, error) {
+	return nil, nil
+}
+
+func (s *fakeServer) Users(logins []string) ([]*login.ExternalUserInfo, error) {
+	var result []*login.ExternalUserInfo
+	for _, u := range s.users {
+		for _, l := range logins {
+			if strings.EqualFold(u.Login, l) {
+				result = append(result, u)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeServer) Bind() error                   { return nil }
+func (s *fakeServer) UserBind(string, string) error { return nil }
+func (s *fakeServer) Dial() error                   { return s.dialErr }
+func (s *fakeServer) Close()                        { s.closed = true }
+
+type fakeTeamService struct {
+	teamtest.FakeService
+	teams       map[int64]bool
+	memberships map[int64][]*team.TeamMemberDTO
+}
+
+func (s *fakeTeamService) GetTeamByID(ctx context.Context, query *team.GetTeamByIDQuery) (*team.TeamDTO, error) {
+	if !s.teams[query.ID] {
+		return nil, team.ErrTeamNotFound
+	}
+	return &team.TeamDTO{ID: query.ID, OrgID: query.OrgID}, nil
+}
+
+func (s *fakeTeamService) GetUserTeamMemberships(ctx context.Context, orgID, userID int64, external bool) ([]*team.TeamMemberDTO, error) {
+	return s.memberships[userID], nil
+}
+
+type fakeTeamPermissions struct {
+	calls []string
+}
+
+func (p *fakeTeamPermissions) GetPermissions(ctx context.Context, user *user.SignedInUser, resourceID string) ([]accesscontrol.ResourcePermission, error) {
+	return nil, nil
+}
+
+func (p *fakeTeamPermissions) SetUserPermission(ctx context.Context, orgID int64, user accesscontrol.User, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
+	p.calls = append(p.calls, fmt.Sprintf("user %d external %t team %s: %q", user.ID, user.IsExternal, resourceID, permission))
+	return &accesscontrol.ResourcePermission{}, nil
+}
+
+func setupTeamSync(t *testing.T) (*Service, map[string]*fakeServer, *fakeTeamPermissions) {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.LDAPEnabled = true
+
+	config := &ldap.Config{Servers: []*ldap.ServerConfig{
+		{
+			Host: "first",
+			Teams: []*ldap.GroupToTeam{
+				{GroupDN: "cn=sre,ou=groups", OrgId: 1, TeamId: 3},
+				{GroupDN: "cn=dev,ou=groups", OrgId: 1, TeamId: 4},
+			},
+		},
+		{
+			Host: "second",
+			Teams: []*ldap.GroupToTeam{
+				{GroupDN: "cn=ops,ou=groups", OrgId: 1, TeamId: 3},
+				{GroupDN: "cn=ops,ou=groups", OrgId: 1, TeamId: 99},
+			},
+		},
+	}}
+	servers := map[string]*fakeServer{
+		"first": {users: []*login.ExternalUserInfo{
+			{Login: "Alice", Groups: []string{"cn=sre,ou=groups"}},
+			{Login: "bob"},
+		}},
+		"second": {users: []*login.ExternalUserInfo{
+			{Login: "bob", Groups: []string{"cn=ops,ou=groups"}},
+			{Login: "carol", Groups: []string{"cn=ops,ou=groups"}},
+		}},
+	}
+
+	userService := usertest.NewUserServiceFake()
+	userService.ExpectedSearchUsers = user.SearchUserQueryResult{Users: []*user.UserSearchHitDTO{
+		{ID: 1, Login: "alice"},
+		{ID: 2, Login: "bob"},
+		{ID: 3, Login: "carol"},
+		{ID: 4, Login: "dave", IsDisabled: true},
+		{ID: 5, Login: "erin"},
+	}}
+	teamService := &fakeTeamService{
+		teams: map[int64]bool{3: true, 4: true},
+		memberships: map[int64][]*team.TeamMemberDTO{
+			2: {{TeamID: 3, External: true}, {TeamID: 4, External: false}},
+			3: {{TeamID: 4, External: true}},
+			4: {{TeamID: 3, External: true}},
+			5: {{TeamID: 3, External: true}},
+		},
+	}
+	permissions := &fakeTeamPermissions{}
+
+	s := ProvideService(cfg, &service.LDAPFakeService{ExpectedConfig: config}, userService,
+		&orgtest.FakeOrgService{ExpectedUserOrgDTO: []*org.UserOrgDTO{{OrgID: 1}}}, teamService, permissions, nil, nil)
+	s.newServer = func(config *ldap.ServerConfig, _ *setting.Cfg) ldap.IServer {
+		return servers[config.Host]
+	}
+	return s, servers, permissions
+}
+
+func TestService_Sync(t *testing.T) {
+	expected := []Change{
+		{UserID: 1, Login: "alice", OrgID: 1, TeamID: 3, Action: ActionAdd},
+		// bob is found in the first server first, the groups from the second one are ignored
+		{UserID: 2, Login: "bob", OrgID: 1, TeamID: 3, Action: ActionRemove},
+		{UserID: 3, Login: "carol", OrgID: 1, TeamID: 3, Action: ActionAdd},
+		{UserID: 3, Login: "carol", OrgID: 1, TeamID: 4, Action: ActionRemove},
+	}
+
+	t.Run("should only list the changes on a dry run", func(t *testing.T) {
+		s, servers, permissions := setupTeamSync(t)
+
+		result, err := s.Sync(context.Background(), true)
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 3, result.Users)
+		assert.Equal(t, expected, result.Changes)
+		assert.Empty(t, permissions.calls)
+		assert.True(t, servers["first"].closed)
+		assert.True(t, servers["second"].closed)
+	})
+
+	t.Run("should add and remove the external memberships", func(t *testing.T) {
+		s, _, permissions := setupTeamSync(t)
+
+		result, err := s.Sync(context.Background(), false)
+		require.NoError(t, err)
+		assert.False(t, result.DryRun)
+		assert.Equal(t, expected, result.Changes)
+		assert.Equal(t, []string{
+			`user 1 external true team 3: "Member"`,
+			`user 2 external true team 3: ""`,
+			`user 3 external true team 3: "Member"`,
+			`user 3 external true team 4: ""`,
+		}, permissions.calls)
+		assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.runs.WithLabelValues("success")))
+		assert.Equal(t, 2.0, testutil.ToFloat64(s.metrics.changes.WithLabelValues("add", "success")))
+	})
+
+	t.Run("should fail without changes when a server is not available", func(t *testing.T) {
+		s, servers, permissions := setupTeamSync(t)
+		servers["second"].dialErr = errors.New("connection refused")
+
+		_, err := s.Sync(context.Background(), false)
+		require.Error(t, err)
+		assert.Empty(t, permissions.calls)
+		assert.True(t, servers["first"].closed)
+		assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.runs.WithLabelValues("failure")))
+	})
+
+	t.Run("should not sync without team mappings", func(t *testing.T) {
+		s, _, permissions := setupTeamSync(t)
+		s.ldapService = &service.LDAPFakeService{ExpectedConfig: &ldap.Config{Servers: []*ldap.ServerConfig{{Host: "first"}}}}
+		s.newServer = func(*ldap.ServerConfig, *setting.Cfg) ldap.IServer {
+			t.Fatal("the LDAP servers should not be searched")
+			return nil
+		}
+
+		result, err := s.Sync(context.Background(), false)
+		require.NoError(t, err)
+		assert.Empty(t, result.Changes)
+		assert.Empty(t, permissions.calls)
+	})
+
+	t.Run("should fail when LDAP is disabled", func(t *testing.T) {
+		s, _, _ := setupTeamSync(t)
+		s.cfg.LDAPEnabled = false
+
+		_, err := s.Sync(context.Background(), true)
+		assert.ErrorIs(t, err, service.ErrLDAPNotEnabled)
+	})
+}
@@ -28,6 +28,7 @@ func ProvideService(cfg *setting.Cfg, sqlStore db.DB, routeRegister routing.Rout
 type Service interface {
 	CreateElement(c context.Context, signedInUser *user.SignedInUser, cmd model.CreateLibraryElementCommand) (model.LibraryElementDTO, error)
 	GetElement(c context.Context, signedInUser *user.SignedInUser, UID string) (model.LibraryElementDTO, error)
+	PatchElement(c context.Context, signedInUser *user.SignedInUser, cmd model.PatchLibraryElementCommand, UID string) (model.LibraryElementDTO, error)
 	GetElementsForDashboard(c context.Context, dashboardID int64) (map[string]model.LibraryElementDTO, error)
 	ConnectElementsToDashboard(c context.Context, signedInUser *user.SignedInUser, elementUIDs []string, dashboardID int64) error
 	DisconnectElementsFromDashboard(c context.Context, dashboardID int64) error
@@ -53,6 +54,11 @@ func (l *LibraryElementService) GetElement(c context.Context, signedInUser *user
 	return l.getLibraryElementByUid(c, signedInUser, UID)
 }
 
+// PatchElement updates a Library Element.
+func (l *LibraryElementService) PatchElement(c context.Context, signedInUser *user.SignedInUser, cmd model.PatchLibraryElementCommand, UID string) (model.LibraryElementDTO, error) {
+	return l.patchLibraryElement(c, signedInUser, cmd, UID)
+}
+
 // GetElementsForDashboard gets all connected elements for a specific dashboard.
 func (l *LibraryElementService) GetElementsForDashboard(c context.Context, dashboardID int64) (map[string]model.LibraryElementDTO, error) {
 	return l.getElementsForDashboardID(c, dashboardID)
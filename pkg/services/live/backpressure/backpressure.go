@@ -0,0 +1,90 @@
+// Package backpressure gives the streams of the plugins, started by RunStream, a signal of how fast the subscribers
+// of their channel consume the data, so a stream can send less data instead of having its subscribers disconnected.
+//
+// The signals are set in the context given to RunStream, they are only available to the plugins running in the
+// Grafana process, as the values of the context are not sent to the external plugins.
+package backpressure
+
+import (
+	"context"
+	"sync"
+)
+
+// Hint tells a stream how to reduce the data it sends to its channel
+type Hint string
+
+const (
+	// HintNone is set when the subscribers consume the data fast enough
+	HintNone Hint = ""
+	// HintDownsample asks to send less data, for example by sampling or aggregating it
+	HintDownsample Hint = "downsample"
+	// HintDrop asks to drop the data the subscribers can't consume, sending only the latest data at a low rate
+	HintDrop Hint = "drop"
+	// HintPause asks to stop sending data until the hint changes, as all the subscribers were too slow
+	HintPause Hint = "pause"
+)
+
+// Signal is the backpressure of the channel of a stream
+type Signal struct {
+	Hint Hint
+	// SlowSubscribers is the number of subscribers recently disconnected because they could not read the messages of
+	// the channel fast enough
+	SlowSubscribers int
+}
+
+// Signals holds the current backpressure signal of a stream. A nil Signals always returns HintNone.
+type Signals struct {
+	mu      sync.RWMutex
+	signal  Signal
+	changed chan struct{}
+}
+
+func NewSignals() *Signals {
+	return &Signals{changed: make(chan struct{})}
+}
+
+// Load returns the current signal
+func (s *Signals) Load() Signal {
+	if s == nil {
+		return Signal{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signal
+}
+
+// Changed returns a channel closed when the signal changes, Changed must be called again to wait for the next change
+func (s *Signals) Changed() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.changed
+}
+
+// Store sets the signal and notifies the streams waiting on Changed, it returns true when the signal changed
+func (s *Signals) Store(signal Signal) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.signal == signal {
+		return false
+	}
+	s.signal = signal
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return true
+}
+
+type signalsContextKey struct{}
+
+// WithSignals returns a context holding the signals of a stream
+func WithSignals(ctx context.Context, s *Signals) context.Context {
+	return context.WithValue(ctx, signalsContextKey{}, s)
+}
+
+// FromContext returns the signals of the stream, or nil when the context has none
+func FromContext(ctx context.Context) *Signals {
+	s, _ := ctx.Value(signalsContextKey{}).(*Signals)
+	return s
+}
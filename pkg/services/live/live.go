@@ -306,6 +306,15 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 			}
 		})
 
+		// Called when client unsubscribes from the channel. The streams of the plugins get a backpressure signal
+		// when the subscribers of their channel are disconnected because they can't read the messages fast enough.
+		client.OnUnsubscribe(func(e centrifuge.UnsubscribeEvent) {
+			if e.Disconnect != nil && e.Disconnect.Code == centrifuge.DisconnectSlow.Code {
+				logger.Debug("Slow client unsubscribed", "user", client.UserID(), "client", client.ID(), "channel", e.Channel)
+				g.runStreamManager.HandleSlowSubscriber(e.Channel)
+			}
+		})
+
 		client.OnDisconnect(func(e centrifuge.DisconnectEvent) {
 			reason := e.Disconnect.Reason
 			if e.Disconnect.Code == 3001 { // Shutdown
@@ -0,0 +1,65 @@
+package runstream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/live/backpressure"
+)
+
+const (
+	// backpressureWindow is the duration the slow subscribers are counted for, the hint of a stream goes back to none
+	// when no subscriber was too slow for this duration
+	backpressureWindow = time.Minute
+	// dropSlowSubscribers is the number of slow subscribers in the window from which the stream is asked to drop its
+	// data instead of downsampling it
+	dropSlowSubscribers = 3
+)
+
+// streamBackpressure computes the backpressure signal of a stream from the subscribers of its channel disconnected
+// because they could not read the messages fast enough
+type streamBackpressure struct {
+	mu      sync.Mutex
+	slow    []time.Time
+	signals *backpressure.Signals
+}
+
+func newStreamBackpressure() *streamBackpressure {
+	return &streamBackpressure{signals: backpressure.NewSignals()}
+}
+
+func (b *streamBackpressure) addSlowSubscriber(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.slow = append(b.slow, now)
+}
+
+// update sets the signal from the slow subscribers of the window and the current number of subscribers of the
+// channel, it returns true when the signal changed
+func (b *streamBackpressure) update(now time.Time, numSubscribers int) (backpressure.Signal, bool) {
+	b.mu.Lock()
+	i := 0
+	for i < len(b.slow) && now.Sub(b.slow[i]) >= backpressureWindow {
+		i++
+	}
+	b.slow = b.slow[i:]
+	numSlow := len(b.slow)
+	b.mu.Unlock()
+
+	signal := backpressure.Signal{Hint: backpressureHint(numSlow, numSubscribers), SlowSubscribers: numSlow}
+	return signal, b.signals.Store(signal)
+}
+
+func backpressureHint(numSlow int, numSubscribers int) backpressure.Hint {
+	switch {
+	case numSlow == 0:
+		return backpressure.HintNone
+	case numSubscribers == 0:
+		// all the subscribers were too slow, nothing is sent until they subscribe again
+		return backpressure.HintPause
+	case numSlow >= dropSlowSubscribers:
+		return backpressure.HintDrop
+	default:
+		return backpressure.HintDownsample
+	}
+}
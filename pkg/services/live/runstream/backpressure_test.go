@@ -0,0 +1,61 @@
+package runstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/backpressure"
+)
+
+func TestStreamBackpressure(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should escalate the hint with the slow subscribers", func(t *testing.T) {
+		bp := newStreamBackpressure()
+
+		signal, changed := bp.update(now, 2)
+		require.False(t, changed)
+		require.Equal(t, backpressure.HintNone, signal.Hint)
+
+		bp.addSlowSubscriber(now)
+		signal, changed = bp.update(now, 2)
+		require.True(t, changed)
+		require.Equal(t, backpressure.Signal{Hint: backpressure.HintDownsample, SlowSubscribers: 1}, signal)
+
+		bp.addSlowSubscriber(now)
+		bp.addSlowSubscriber(now)
+		signal, _ = bp.update(now, 2)
+		require.Equal(t, backpressure.Signal{Hint: backpressure.HintDrop, SlowSubscribers: 3}, signal)
+		require.Equal(t, signal, bp.signals.Load())
+	})
+
+	t.Run("should pause until the subscribers are back", func(t *testing.T) {
+		bp := newStreamBackpressure()
+		bp.addSlowSubscriber(now)
+
+		signal, _ := bp.update(now, 0)
+		require.Equal(t, backpressure.HintPause, signal.Hint)
+
+		signal, _ = bp.update(now.Add(time.Second), 1)
+		require.Equal(t, backpressure.HintDownsample, signal.Hint)
+	})
+
+	t.Run("should go back to no hint after the window", func(t *testing.T) {
+		bp := newStreamBackpressure()
+		bp.addSlowSubscriber(now)
+		changed := bp.signals.Changed()
+
+		signal, _ := bp.update(now, 1)
+		require.Equal(t, backpressure.HintDownsample, signal.Hint)
+		select {
+		case <-changed:
+		default:
+			t.Fatal("the change was not notified")
+		}
+
+		signal, _ = bp.update(now.Add(backpressureWindow), 1)
+		require.Equal(t, backpressure.Signal{}, signal)
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/backpressure"
 	"github.com/grafana/grafana/pkg/services/user"
 )
 
@@ -169,7 +170,33 @@ func (s *Manager) stopStream(sr streamRequest, cancelFn func()) {
 	close(closeCh)
 }
 
-func (s *Manager) watchStream(ctx context.Context, cancelFn func(), sr streamRequest) {
+// HandleSlowSubscriber updates the backpressure signal of the stream of the channel when a subscriber of the channel
+// was disconnected because it could not read the messages fast enough.
+func (s *Manager) HandleSlowSubscriber(channel string) {
+	s.mu.RLock()
+	streamCtx, ok := s.streams[channel]
+	s.mu.RUnlock()
+	if !ok {
+		return
+	}
+	streamCtx.backpressure.addSlowSubscriber(time.Now())
+
+	numSubscribers, err := s.presenceGetter.GetNumLocalSubscribers(channel)
+	if err != nil {
+		logger.Error("Error checking num subscribers", "channel", streamCtx.streamRequest.Channel, "path", streamCtx.streamRequest.Path, "error", err)
+		// the stream is not paused without knowing whether other subscribers are left
+		numSubscribers = 1
+	}
+	s.updateBackpressure(streamCtx.streamRequest, streamCtx.backpressure, numSubscribers)
+}
+
+func (s *Manager) updateBackpressure(sr streamRequest, bp *streamBackpressure, numSubscribers int) {
+	if signal, changed := bp.update(time.Now(), numSubscribers); changed {
+		logger.Info("Stream backpressure changed", "channel", sr.Channel, "path", sr.Path, "hint", signal.Hint, "slowSubscribers", signal.SlowSubscribers)
+	}
+}
+
+func (s *Manager) watchStream(ctx context.Context, cancelFn func(), sr streamRequest, bp *streamBackpressure) {
 	numNoSubscribersChecks := 0
 	presenceTicker := time.NewTicker(s.checkInterval)
 	defer presenceTicker.Stop()
@@ -207,6 +234,7 @@ func (s *Manager) watchStream(ctx context.Context, cancelFn func(), sr streamReq
 				logger.Error("Error checking num subscribers", "channel", sr.Channel, "path", sr.Path, "error", err)
 				continue
 			}
+			s.updateBackpressure(sr, bp, numSubscribers)
 			if numSubscribers > 0 {
 				// reset counter since channel has active subscribers.
 				numNoSubscribersChecks = 0
@@ -238,8 +266,10 @@ func getDelay(numErrors int) time.Duration {
 }
 
 // run stream until context canceled or stream finished without an error.
-func (s *Manager) runStream(ctx context.Context, cancelFn func(), sr streamRequest) {
+func (s *Manager) runStream(ctx context.Context, cancelFn func(), sr streamRequest, bp *streamBackpressure) {
 	defer func() { s.stopStream(sr, cancelFn) }()
+	// the plugins running in the Grafana process can read the backpressure signals from the context of the stream
+	ctx = backpressure.WithSignals(ctx, bp.signals)
 	var numFastErrors int
 	var delay time.Duration
 	var isReconnect bool
@@ -325,6 +355,7 @@ type streamContext struct {
 	CloseCh       chan struct{}
 	cancelFn      func()
 	streamRequest streamRequest
+	backpressure  *streamBackpressure
 }
 
 func (s *Manager) registerStream(ctx context.Context, sr submitRequest) {
@@ -337,10 +368,12 @@ func (s *Manager) registerStream(ctx context.Context, sr submitRequest) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	closeCh := make(chan struct{})
+	bp := newStreamBackpressure()
 	s.streams[sr.streamRequest.Channel] = streamContext{
 		CloseCh:       closeCh,
 		cancelFn:      cancel,
 		streamRequest: sr.streamRequest,
+		backpressure:  bp,
 	}
 	if sr.streamRequest.PluginContext.DataSourceInstanceSettings != nil {
 		dsUID := sr.streamRequest.PluginContext.DataSourceInstanceSettings.UID
@@ -352,8 +385,8 @@ func (s *Manager) registerStream(ctx context.Context, sr submitRequest) {
 	}
 	s.mu.Unlock()
 	sr.responseCh <- submitResponse{Result: submitResult{StreamExists: false, CloseNotify: closeCh}}
-	go s.watchStream(ctx, cancel, sr.streamRequest)
-	s.runStream(ctx, cancel, sr.streamRequest)
+	go s.watchStream(ctx, cancel, sr.streamRequest, bp)
+	s.runStream(ctx, cancel, sr.streamRequest, bp)
 }
 
 // Run Manager till context canceled.
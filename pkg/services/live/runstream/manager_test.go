@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/services/live/backpressure"
 	"github.com/grafana/grafana/pkg/services/user"
 )
 
@@ -431,3 +432,64 @@ func TestStreamManager_HandleDatasourceDelete(t *testing.T) {
 	require.NoError(t, err)
 	waitWithTimeout(t, result.CloseNotify, time.Second)
 }
+
+func TestStreamManager_HandleSlowSubscriber(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPacketSender := NewMockChannelLocalPublisher(mockCtrl)
+	mockNumSubscribersGetter := NewMockNumLocalSubscribersGetter(mockCtrl)
+	mockContextGetter := NewMockPluginContextGetter(mockCtrl)
+
+	manager := NewManager(mockPacketSender, mockNumSubscribersGetter, mockContextGetter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = manager.Run(ctx)
+	}()
+
+	startedCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	hintsCh := make(chan backpressure.Hint, 2)
+
+	mockNumSubscribersGetter.EXPECT().GetNumLocalSubscribers("1/test").Return(1, nil).Times(1)
+	mockNumSubscribersGetter.EXPECT().GetNumLocalSubscribers("1/test").Return(0, nil).Times(1)
+
+	mockStreamRunner := NewMockStreamRunner(mockCtrl)
+	mockStreamRunner.EXPECT().RunStream(
+		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+	).DoAndReturn(func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+		signals := backpressure.FromContext(ctx)
+		require.NotNil(t, signals)
+		require.Equal(t, backpressure.HintNone, signals.Load().Hint)
+		changed := signals.Changed()
+		close(startedCh)
+		for {
+			select {
+			case <-changed:
+				changed = signals.Changed()
+				hintsCh <- signals.Load().Hint
+			case <-ctx.Done():
+				close(doneCh)
+				return ctx.Err()
+			}
+		}
+	}).Times(1)
+
+	_, err := manager.SubmitStream(context.Background(), &user.SignedInUser{UserID: 2, OrgID: 1}, "1/test", "test", nil, backend.PluginContext{}, mockStreamRunner, false)
+	require.NoError(t, err)
+	waitWithTimeout(t, startedCh, time.Second)
+
+	// a slow subscriber with other subscribers left asks to downsample, the last one asks to pause
+	manager.HandleSlowSubscriber("1/test")
+	require.Equal(t, backpressure.HintDownsample, <-hintsCh)
+	manager.HandleSlowSubscriber("1/test")
+	require.Equal(t, backpressure.HintPause, <-hintsCh)
+
+	// the channels without streams are ignored
+	manager.HandleSlowSubscriber("1/other")
+
+	cancel()
+	waitWithTimeout(t, doneCh, time.Second)
+}
@@ -52,6 +52,10 @@ type Alertmanager interface {
 	// Receivers
 	GetReceivers(ctx context.Context) []apimodels.Receiver
 	TestReceivers(ctx context.Context, c apimodels.TestReceiversConfigBodyParams) (*notifier.TestReceiversResult, error)
+
+	// Notification deliveries
+	GetNotificationDeliveries(ctx context.Context, query models.ListNotificationDeliveriesQuery) ([]*models.NotificationDelivery, error)
+	ReplayNotificationDelivery(ctx context.Context, id int64) (*models.NotificationDelivery, error)
 }
 
 type AlertingStore interface {
@@ -78,6 +82,8 @@ type API struct {
 	ContactPointService  *provisioning.ContactPointService
 	Templates            *provisioning.TemplateService
 	MuteTimings          *provisioning.MuteTimingService
+	InhibitionRules      *provisioning.InhibitionRuleService
+	SLOs                 *provisioning.SLOService
 	AlertRules           *provisioning.AlertRuleService
 	AlertsRouter         *sender.AlertsRouter
 	EvaluatorFactory     eval.EvaluatorFactory
@@ -148,6 +154,8 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 		contactPointService: api.ContactPointService,
 		templates:           api.Templates,
 		muteTimings:         api.MuteTimings,
+		inhibitionRules:     api.InhibitionRules,
+		slos:                api.SLOs,
 		alertRules:          api.AlertRules,
 	}), m)
 
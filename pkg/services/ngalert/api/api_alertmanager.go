@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -17,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/util"
@@ -25,6 +27,8 @@ import (
 const (
 	defaultTestReceiversTimeout = 15 * time.Second
 	maxTestReceiversTimeout     = 30 * time.Second
+
+	defaultNotificationDeliveriesLimit = 100
 )
 
 type AlertmanagerSrv struct {
@@ -258,6 +262,91 @@ func (srv AlertmanagerSrv) RouteGetReceivers(c *contextmodel.ReqContext) respons
 	return response.JSON(http.StatusOK, rcvs)
 }
 
+func (srv AlertmanagerSrv) RouteGetNotificationDeliveries(c *contextmodel.ReqContext) response.Response {
+	query := ngmodels.ListNotificationDeliveriesQuery{
+		Receiver:       c.Query("receiver"),
+		IntegrationUID: c.Query("integration"),
+		Limit:          c.QueryInt("limit"),
+	}
+	for _, state := range c.QueryStrings("state") {
+		switch s := ngmodels.NotificationDeliveryState(state); s {
+		case ngmodels.NotificationDeliveryPending, ngmodels.NotificationDeliveryDelivered, ngmodels.NotificationDeliveryDead:
+			query.States = append(query.States, s)
+		default:
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("unknown notification delivery state: %s", state), "")
+		}
+	}
+	if query.Limit < 0 {
+		return ErrResp(http.StatusBadRequest, errors.New("the limit must not be negative"), "")
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultNotificationDeliveriesLimit
+	}
+
+	am, errResp := srv.AlertmanagerFor(c.OrgID)
+	if errResp != nil {
+		return errResp
+	}
+
+	deliveries, err := am.GetNotificationDeliveries(c.Req.Context(), query)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get notification deliveries")
+	}
+	result := make(apimodels.GettableNotificationDeliveries, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		result = append(result, newGettableNotificationDelivery(delivery))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (srv AlertmanagerSrv) RoutePostNotificationDeliveryReplay(c *contextmodel.ReqContext, id string) response.Response {
+	deliveryID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse the notification delivery ID")
+	}
+
+	am, errResp := srv.AlertmanagerFor(c.OrgID)
+	if errResp != nil {
+		return errResp
+	}
+
+	delivery, err := am.ReplayNotificationDelivery(c.Req.Context(), deliveryID)
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrNotificationDeliveryNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		if errors.Is(err, notifier.ErrNotificationDeliveryNotDead) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to replay the notification")
+	}
+	return response.JSON(http.StatusOK, newGettableNotificationDelivery(delivery))
+}
+
+func newGettableNotificationDelivery(d *ngmodels.NotificationDelivery) apimodels.GettableNotificationDelivery {
+	result := apimodels.GettableNotificationDelivery{
+		ID:              d.ID,
+		Receiver:        d.Receiver,
+		IntegrationUID:  d.IntegrationUID,
+		IntegrationName: d.IntegrationName,
+		IntegrationType: d.IntegrationType,
+		GroupKey:        d.GroupKey,
+		GroupLabels:     map[string]string{},
+		Alerts:          []apimodels.NotificationDeliveryAlert{},
+		State:           string(d.State),
+		Attempts:        d.Attempts,
+		Retries:         d.Retries,
+		LastError:       d.LastError,
+		NextAttemptAt:   d.NextAttemptAt,
+		CreatedAt:       d.CreatedAt,
+		UpdatedAt:       d.UpdatedAt,
+	}
+	// the receipts hold the alerts as they were sent, the fields the API does not return are ignored
+	_ = json.Unmarshal([]byte(d.GroupLabels), &result.GroupLabels)
+	_ = json.Unmarshal([]byte(d.Alerts), &result.Alerts)
+	return result
+}
+
 func (srv AlertmanagerSrv) RoutePostTestReceivers(c *contextmodel.ReqContext, body apimodels.TestReceiversConfigBodyParams) response.Response {
 	if err := srv.crypto.LoadSecureSettings(c.Req.Context(), c.OrgID, body.Receivers); err != nil {
 		var unknownReceiverError UnknownReceiverError
@@ -27,6 +27,9 @@ func (srv AlertmanagerSrv) provenanceGuard(currentConfig apimodels.GettableUserC
 	if err := checkMuteTimes(currentConfig, newConfig); err != nil {
 		return err
 	}
+	if err := checkInhibitRules(currentConfig, newConfig); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -125,6 +128,30 @@ func checkContactPoints(currReceivers []*apimodels.GettableApiReceiver, newRecei
 	return nil
 }
 
+func checkInhibitRules(currentConfig apimodels.GettableUserConfig, newConfig apimodels.PostableUserConfig) error {
+	provenance := ngmodels.Provenance(currentConfig.AlertmanagerConfig.InhibitRulesProvenance)
+	if provenance == ngmodels.ProvenanceNone {
+		return nil
+	}
+	currentRules, newRules := currentConfig.AlertmanagerConfig.InhibitRules, newConfig.AlertmanagerConfig.InhibitRules
+	if len(currentRules) == 0 && len(newRules) == 0 {
+		return nil
+	}
+	// the rules are compared serialized, as the regular expressions of the rules can't be compared
+	current, err := json.Marshal(currentRules)
+	if err != nil {
+		return err
+	}
+	posted, err := json.Marshal(newRules)
+	if err != nil {
+		return err
+	}
+	if string(current) != string(posted) {
+		return fmt.Errorf("inhibition rules were provisioned and cannot be changed through the UI")
+	}
+	return nil
+}
+
 func checkMuteTimes(currentConfig apimodels.GettableUserConfig, newConfig apimodels.PostableUserConfig) error {
 	newMTs := make(map[string]amConfig.MuteTimeInterval)
 	for _, newMuteTime := range newConfig.AlertmanagerConfig.MuteTimeIntervals {
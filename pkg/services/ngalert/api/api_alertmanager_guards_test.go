@@ -520,6 +520,72 @@ func TestCheckMuteTimes(t *testing.T) {
 	}
 }
 
+func TestCheckInhibitRules(t *testing.T) {
+	datacenterDown := amConfig.InhibitRule{
+		SourceMatch: map[string]string{"alertname": "DatacenterDown"},
+		TargetMatch: map[string]string{"severity": "warning"},
+		Equal:       model.LabelNames{"datacenter"},
+	}
+	edited := datacenterDown
+	edited.Equal = model.LabelNames{"datacenter", "rack"}
+
+	tests := []struct {
+		name       string
+		shouldErr  bool
+		provenance models.Provenance
+		current    []amConfig.InhibitRule
+		new        []amConfig.InhibitRule
+	}{
+		{
+			name:       "equal provisioned rules should not error",
+			provenance: models.ProvenanceAPI,
+			current:    []amConfig.InhibitRule{datacenterDown},
+			new:        []amConfig.InhibitRule{datacenterDown},
+		},
+		{
+			name:       "editing non provisioned rules should not fail",
+			provenance: models.ProvenanceNone,
+			current:    []amConfig.InhibitRule{datacenterDown},
+			new:        []amConfig.InhibitRule{edited},
+		},
+		{
+			name:       "editing provisioned rules should fail",
+			shouldErr:  true,
+			provenance: models.ProvenanceFile,
+			current:    []amConfig.InhibitRule{datacenterDown},
+			new:        []amConfig.InhibitRule{edited},
+		},
+		{
+			name:       "removing provisioned rules should fail",
+			shouldErr:  true,
+			provenance: models.ProvenanceAPI,
+			current:    []amConfig.InhibitRule{datacenterDown},
+			new:        nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			current := definitions.GettableUserConfig{
+				AlertmanagerConfig: definitions.GettableApiAlertingConfig{
+					InhibitRulesProvenance: definitions.Provenance(test.provenance),
+					Config:                 definitions.Config{InhibitRules: test.current},
+				},
+			}
+			posted := definitions.PostableUserConfig{
+				AlertmanagerConfig: definitions.PostableApiAlertingConfig{
+					Config: definitions.Config{InhibitRules: test.new},
+				},
+			}
+			err := checkInhibitRules(current, posted)
+			if test.shouldErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func gettableMuteIntervals(t *testing.T, muteTimeIntervals []amConfig.MuteTimeInterval, provenances map[string]definitions.Provenance) definitions.GettableUserConfig {
 	return definitions.GettableUserConfig{
 		AlertmanagerConfig: definitions.GettableApiAlertingConfig{
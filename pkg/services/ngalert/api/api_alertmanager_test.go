@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -483,6 +484,41 @@ func TestRouteCreateSilence(t *testing.T) {
 	}
 }
 
+func TestRouteNotificationDeliveries(t *testing.T) {
+	sut := createSut(t, nil)
+
+	t.Run("the deliveries are listed", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req.URL = &url.URL{RawQuery: "state=pending&state=dead"}
+
+		response := sut.RouteGetNotificationDeliveries(rc)
+
+		require.Equal(t, http.StatusOK, response.Status())
+		require.JSONEq(t, "[]", string(response.Body()))
+	})
+
+	t.Run("unknown states are rejected", func(t *testing.T) {
+		rc := createRequestCtxInOrg(1)
+		rc.Req.URL = &url.URL{RawQuery: "state=failed"}
+
+		response := sut.RouteGetNotificationDeliveries(rc)
+
+		require.Equal(t, http.StatusBadRequest, response.Status())
+	})
+
+	t.Run("replaying an unknown delivery returns 404", func(t *testing.T) {
+		response := sut.RoutePostNotificationDeliveryReplay(createRequestCtxInOrg(1), "100")
+
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("replaying a delivery with an invalid ID returns 400", func(t *testing.T) {
+		response := sut.RoutePostNotificationDeliveryReplay(createRequestCtxInOrg(1), "invalid")
+
+		require.Equal(t, http.StatusBadRequest, response.Status())
+	})
+}
+
 func createSut(t *testing.T, accessControl accesscontrol.AccessControl) AlertmanagerSrv {
 	t.Helper()
 
@@ -26,6 +26,8 @@ type ProvisioningSrv struct {
 	contactPointService ContactPointService
 	templates           TemplateService
 	muteTimings         MuteTimingService
+	inhibitionRules     InhibitionRuleService
+	slos                SLOService
 	alertRules          AlertRuleService
 }
 
@@ -55,6 +57,20 @@ type MuteTimingService interface {
 	DeleteMuteTiming(ctx context.Context, name string, orgID int64) error
 }
 
+type InhibitionRuleService interface {
+	GetInhibitionRules(ctx context.Context, orgID int64) (definitions.InhibitionRules, error)
+	UpdateInhibitionRules(ctx context.Context, orgID int64, rules definitions.InhibitionRules, p alerting_models.Provenance) error
+	ResetInhibitionRules(ctx context.Context, orgID int64) error
+}
+
+type SLOService interface {
+	GetSLOs(ctx context.Context, orgID int64) (definitions.SLOs, error)
+	GetSLO(ctx context.Context, orgID int64, uid string) (definitions.SLO, error)
+	CreateSLO(ctx context.Context, orgID int64, slo definitions.SLO, p alerting_models.Provenance, userID int64) (definitions.SLO, error)
+	UpdateSLO(ctx context.Context, orgID int64, slo definitions.SLO, p alerting_models.Provenance, userID int64) (definitions.SLO, error)
+	DeleteSLO(ctx context.Context, orgID int64, uid string, p alerting_models.Provenance, userID int64) error
+}
+
 type AlertRuleService interface {
 	GetAlertRules(ctx context.Context, orgID int64) ([]*alerting_models.AlertRule, error)
 	GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (alerting_models.AlertRule, alerting_models.Provenance, error)
@@ -254,6 +270,98 @@ func (srv *ProvisioningSrv) RouteDeleteMuteTiming(c *contextmodel.ReqContext, na
 	return response.JSON(http.StatusNoContent, nil)
 }
 
+func (srv *ProvisioningSrv) RouteGetInhibitionRules(c *contextmodel.ReqContext) response.Response {
+	rules, err := srv.inhibitionRules.GetInhibitionRules(c.Req.Context(), c.OrgID)
+	if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+		return ErrResp(http.StatusNotFound, err, "")
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, rules)
+}
+
+func (srv *ProvisioningSrv) RoutePutInhibitionRules(c *contextmodel.ReqContext, rules definitions.InhibitionRules) response.Response {
+	err := srv.inhibitionRules.UpdateInhibitionRules(c.Req.Context(), c.OrgID, rules, determineProvenance(c))
+	if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+		return ErrResp(http.StatusNotFound, err, "")
+	}
+	if errors.Is(err, provisioning.ErrValidation) {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": "inhibition rules updated"})
+}
+
+func (srv *ProvisioningSrv) RouteResetInhibitionRules(c *contextmodel.ReqContext) response.Response {
+	err := srv.inhibitionRules.ResetInhibitionRules(c.Req.Context(), c.OrgID)
+	if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+		return ErrResp(http.StatusNotFound, err, "")
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": "inhibition rules deleted"})
+}
+
+func (srv *ProvisioningSrv) RouteGetSLOs(c *contextmodel.ReqContext) response.Response {
+	slos, err := srv.slos.GetSLOs(c.Req.Context(), c.OrgID)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, slos)
+}
+
+func (srv *ProvisioningSrv) RouteGetSLO(c *contextmodel.ReqContext, UID string) response.Response {
+	slo, err := srv.slos.GetSLO(c.Req.Context(), c.OrgID, UID)
+	if errors.Is(err, provisioning.ErrNotFound) {
+		return ErrResp(http.StatusNotFound, err, "")
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, slo)
+}
+
+func (srv *ProvisioningSrv) RoutePostSLO(c *contextmodel.ReqContext, slo definitions.SLO) response.Response {
+	created, err := srv.slos.CreateSLO(c.Req.Context(), c.OrgID, slo, determineProvenance(c), c.UserID)
+	if errors.Is(err, provisioning.ErrValidation) {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusCreated, created)
+}
+
+func (srv *ProvisioningSrv) RoutePutSLO(c *contextmodel.ReqContext, slo definitions.SLO, UID string) response.Response {
+	slo.UID = UID
+	updated, err := srv.slos.UpdateSLO(c.Req.Context(), c.OrgID, slo, determineProvenance(c), c.UserID)
+	if errors.Is(err, provisioning.ErrNotFound) {
+		return ErrResp(http.StatusNotFound, err, "")
+	}
+	if errors.Is(err, provisioning.ErrValidation) {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, updated)
+}
+
+func (srv *ProvisioningSrv) RouteDeleteSLO(c *contextmodel.ReqContext, UID string) response.Response {
+	err := srv.slos.DeleteSLO(c.Req.Context(), c.OrgID, UID, determineProvenance(c), c.UserID)
+	if errors.Is(err, provisioning.ErrNotFound) {
+		return ErrResp(http.StatusNotFound, err, "")
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusNoContent, nil)
+}
+
 func (srv *ProvisioningSrv) RouteGetAlertRules(c *contextmodel.ReqContext) response.Response {
 	rules, err := srv.alertRules.GetAlertRules(c.Req.Context(), c.OrgID)
 	if err != nil {
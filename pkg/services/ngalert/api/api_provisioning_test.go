@@ -18,6 +18,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
@@ -231,6 +232,75 @@ func TestProvisioningApi(t *testing.T) {
 		})
 	})
 
+	t.Run("inhibition rules", func(t *testing.T) {
+		t.Run("successful GET returns 200", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+
+			response := sut.RouteGetInhibitionRules(&rc)
+
+			require.Equal(t, 200, response.Status())
+			require.JSONEq(t, `{"rules": []}`, string(response.Body()))
+		})
+
+		t.Run("successful PUT returns 202", func(t *testing.T) {
+			env := createTestEnv(t)
+			env.configs.(*provisioning.MockAMConfigStore).EXPECT().SaveSucceeds()
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			rc := createTestRequestCtx()
+			rules := definitions.InhibitionRules{Rules: []prometheus.InhibitRule{{
+				SourceMatch: map[string]string{"alertname": "DatacenterDown"},
+				TargetMatch: map[string]string{"severity": "warning"},
+			}}}
+
+			response := sut.RoutePutInhibitionRules(&rc, rules)
+
+			require.Equal(t, 202, response.Status())
+		})
+
+		t.Run("are invalid, PUT returns 400", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			rules := definitions.InhibitionRules{Rules: []prometheus.InhibitRule{{
+				SourceMatch: map[string]string{"alertname": "DatacenterDown"},
+			}}}
+
+			response := sut.RoutePutInhibitionRules(&rc, rules)
+
+			require.Equal(t, 400, response.Status())
+			require.Contains(t, string(response.Body()), "no target matchers")
+		})
+	})
+
+	t.Run("SLOs", func(t *testing.T) {
+		t.Run("unknown GET returns 404", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+
+			response := sut.RouteGetSLO(&rc, "unknown")
+
+			require.Equal(t, 404, response.Status())
+		})
+
+		t.Run("are invalid, POST returns 400", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			slo := definitions.SLO{
+				Title:         "Checkout availability",
+				FolderUID:     "folder-uid",
+				DatasourceUID: "prometheus",
+				ErrorQuery:    `sum(rate(http_requests_total{code=~"5.."}[5m]))`,
+				TotalQuery:    `sum(rate(http_requests_total[5m]))`,
+				Objective:     99.9,
+			}
+
+			response := sut.RoutePostSLO(&rc, slo)
+
+			require.Equal(t, 400, response.Status())
+			require.Contains(t, string(response.Body()), "$__range")
+		})
+	})
+
 	t.Run("alert rules", func(t *testing.T) {
 		t.Run("are invalid", func(t *testing.T) {
 			t.Run("POST returns 400 on wrong body params", func(t *testing.T) {
@@ -877,13 +947,16 @@ func createProvisioningSrvSut(t *testing.T) ProvisioningSrv {
 func createProvisioningSrvSutFromEnv(t *testing.T, env *testEnvironment) ProvisioningSrv {
 	t.Helper()
 
+	alertRules := provisioning.NewAlertRuleService(env.store, env.prov, env.dashboardService, env.quotas, env.xact, 60, 10, env.log)
 	return ProvisioningSrv{
 		log:                 env.log,
 		policies:            newFakeNotificationPolicyService(),
 		contactPointService: provisioning.NewContactPointService(env.configs, env.secrets, env.prov, env.xact, env.log),
 		templates:           provisioning.NewTemplateService(env.configs, env.prov, env.xact, env.log),
 		muteTimings:         provisioning.NewMuteTimingService(env.configs, env.prov, env.xact, env.log),
-		alertRules:          provisioning.NewAlertRuleService(env.store, env.prov, env.dashboardService, env.quotas, env.xact, 60, 10, env.log),
+		inhibitionRules:     provisioning.NewInhibitionRuleService(env.configs, env.prov, env.xact, env.log),
+		slos:                provisioning.NewSLOService(kvstore.NewFakeKVStore(), alertRules, env.prov, env.xact, env.log),
+		alertRules:          alertRules,
 	}
 }
 
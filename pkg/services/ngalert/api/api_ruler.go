@@ -444,22 +444,23 @@ func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64, provena
 	}
 	gettableExtendedRuleNode := apimodels.GettableExtendedRuleNode{
 		GrafanaManagedAlert: &apimodels.GettableGrafanaRule{
-			ID:              r.ID,
-			OrgID:           r.OrgID,
-			Title:           r.Title,
-			Condition:       r.Condition,
-			Data:            r.Data,
-			Updated:         r.Updated,
-			IntervalSeconds: r.IntervalSeconds,
-			Version:         r.Version,
-			UID:             r.UID,
-			NamespaceUID:    r.NamespaceUID,
-			NamespaceID:     namespaceID,
-			RuleGroup:       r.RuleGroup,
-			NoDataState:     apimodels.NoDataState(r.NoDataState),
-			ExecErrState:    apimodels.ExecutionErrorState(r.ExecErrState),
-			Provenance:      apimodels.Provenance(provenance),
-			IsPaused:        r.IsPaused,
+			ID:               r.ID,
+			OrgID:            r.OrgID,
+			Title:            r.Title,
+			Condition:        r.Condition,
+			Data:             r.Data,
+			Updated:          r.Updated,
+			IntervalSeconds:  r.IntervalSeconds,
+			Version:          r.Version,
+			UID:              r.UID,
+			NamespaceUID:     r.NamespaceUID,
+			NamespaceID:      namespaceID,
+			RuleGroup:        r.RuleGroup,
+			NoDataState:      apimodels.NoDataState(r.NoDataState),
+			ExecErrState:     apimodels.ExecutionErrorState(r.ExecErrState),
+			Provenance:       apimodels.Provenance(provenance),
+			IsPaused:         r.IsPaused,
+			PausedDimensions: r.PausedDimensions,
 		},
 	}
 	forDuration := model.Duration(r.For)
@@ -86,17 +86,22 @@ func validateRuleNode(
 		}
 	}
 
+	if _, err = ngmodels.ParsePausedDimensions(ruleNode.GrafanaManagedAlert.PausedDimensions); err != nil {
+		return nil, err
+	}
+
 	newAlertRule := ngmodels.AlertRule{
-		OrgID:           orgId,
-		Title:           ruleNode.GrafanaManagedAlert.Title,
-		Condition:       ruleNode.GrafanaManagedAlert.Condition,
-		Data:            ruleNode.GrafanaManagedAlert.Data,
-		UID:             ruleNode.GrafanaManagedAlert.UID,
-		IntervalSeconds: intervalSeconds,
-		NamespaceUID:    namespace.UID,
-		RuleGroup:       groupName,
-		NoDataState:     noDataState,
-		ExecErrState:    errorState,
+		OrgID:            orgId,
+		Title:            ruleNode.GrafanaManagedAlert.Title,
+		Condition:        ruleNode.GrafanaManagedAlert.Condition,
+		Data:             ruleNode.GrafanaManagedAlert.Data,
+		UID:              ruleNode.GrafanaManagedAlert.UID,
+		IntervalSeconds:  intervalSeconds,
+		NamespaceUID:     namespace.UID,
+		RuleGroup:        groupName,
+		NoDataState:      noDataState,
+		ExecErrState:     errorState,
+		PausedDimensions: ruleNode.GrafanaManagedAlert.PausedDimensions,
 	}
 
 	newAlertRule.For, err = validateForInterval(ruleNode)
@@ -399,6 +399,22 @@ func TestValidateRuleNodeFailures_NoUID(t *testing.T) {
 				return &r
 			},
 		},
+		{
+			name: "fail if a paused dimension selector is not valid",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				r.GrafanaManagedAlert.PausedDimensions = []string{`{instance="server-1"}`, `{instance=~"["}`}
+				return &r
+			},
+		},
+		{
+			name: "fail if a paused dimension selector has no matchers",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				r.GrafanaManagedAlert.PausedDimensions = []string{`{}`}
+				return &r
+			},
+		},
 		{
 			name: "fail if there are not data (nil)",
 			rule: func() *apimodels.PostableExtendedRuleNode {
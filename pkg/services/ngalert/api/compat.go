@@ -12,43 +12,45 @@ import (
 // AlertRuleFromProvisionedAlertRule converts definitions.ProvisionedAlertRule to models.AlertRule
 func AlertRuleFromProvisionedAlertRule(a definitions.ProvisionedAlertRule) (models.AlertRule, error) {
 	return models.AlertRule{
-		ID:           a.ID,
-		UID:          a.UID,
-		OrgID:        a.OrgID,
-		NamespaceUID: a.FolderUID,
-		RuleGroup:    a.RuleGroup,
-		Title:        a.Title,
-		Condition:    a.Condition,
-		Data:         a.Data,
-		Updated:      a.Updated,
-		NoDataState:  models.NoDataState(a.NoDataState),          // TODO there must be a validation
-		ExecErrState: models.ExecutionErrorState(a.ExecErrState), // TODO there must be a validation
-		For:          time.Duration(a.For),
-		Annotations:  a.Annotations,
-		Labels:       a.Labels,
-		IsPaused:     a.IsPaused,
+		ID:               a.ID,
+		UID:              a.UID,
+		OrgID:            a.OrgID,
+		NamespaceUID:     a.FolderUID,
+		RuleGroup:        a.RuleGroup,
+		Title:            a.Title,
+		Condition:        a.Condition,
+		Data:             a.Data,
+		Updated:          a.Updated,
+		NoDataState:      models.NoDataState(a.NoDataState),          // TODO there must be a validation
+		ExecErrState:     models.ExecutionErrorState(a.ExecErrState), // TODO there must be a validation
+		For:              time.Duration(a.For),
+		Annotations:      a.Annotations,
+		Labels:           a.Labels,
+		IsPaused:         a.IsPaused,
+		PausedDimensions: a.PausedDimensions,
 	}, nil
 }
 
 // ProvisionedAlertRuleFromAlertRule converts models.AlertRule to definitions.ProvisionedAlertRule and sets provided provenance status
 func ProvisionedAlertRuleFromAlertRule(rule models.AlertRule, provenance models.Provenance) definitions.ProvisionedAlertRule {
 	return definitions.ProvisionedAlertRule{
-		ID:           rule.ID,
-		UID:          rule.UID,
-		OrgID:        rule.OrgID,
-		FolderUID:    rule.NamespaceUID,
-		RuleGroup:    rule.RuleGroup,
-		Title:        rule.Title,
-		For:          model.Duration(rule.For),
-		Condition:    rule.Condition,
-		Data:         rule.Data,
-		Updated:      rule.Updated,
-		NoDataState:  definitions.NoDataState(rule.NoDataState),          // TODO there may be a validation
-		ExecErrState: definitions.ExecutionErrorState(rule.ExecErrState), // TODO there may be a validation
-		Annotations:  rule.Annotations,
-		Labels:       rule.Labels,
-		Provenance:   definitions.Provenance(provenance), // TODO validate enum conversion?
-		IsPaused:     rule.IsPaused,
+		ID:               rule.ID,
+		UID:              rule.UID,
+		OrgID:            rule.OrgID,
+		FolderUID:        rule.NamespaceUID,
+		RuleGroup:        rule.RuleGroup,
+		Title:            rule.Title,
+		For:              model.Duration(rule.For),
+		Condition:        rule.Condition,
+		Data:             rule.Data,
+		Updated:          rule.Updated,
+		NoDataState:      definitions.NoDataState(rule.NoDataState),          // TODO there may be a validation
+		ExecErrState:     definitions.ExecutionErrorState(rule.ExecErrState), // TODO there may be a validation
+		Annotations:      rule.Annotations,
+		Labels:           rule.Labels,
+		Provenance:       definitions.Provenance(provenance), // TODO validate enum conversion?
+		IsPaused:         rule.IsPaused,
+		PausedDimensions: rule.PausedDimensions,
 	}
 }
 
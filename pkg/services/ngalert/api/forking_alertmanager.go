@@ -181,3 +181,11 @@ func (f *AlertmanagerApiHandler) handleRouteGetGrafanaReceivers(ctx *contextmode
 func (f *AlertmanagerApiHandler) handleRoutePostTestGrafanaReceivers(ctx *contextmodel.ReqContext, conf apimodels.TestReceiversConfigBodyParams) response.Response {
 	return f.GrafanaSvc.RoutePostTestReceivers(ctx, conf)
 }
+
+func (f *AlertmanagerApiHandler) handleRouteGetGrafanaNotificationDeliveries(ctx *contextmodel.ReqContext) response.Response {
+	return f.GrafanaSvc.RouteGetNotificationDeliveries(ctx)
+}
+
+func (f *AlertmanagerApiHandler) handleRoutePostGrafanaNotificationDeliveryReplay(ctx *contextmodel.ReqContext, id string) response.Response {
+	return f.GrafanaSvc.RoutePostNotificationDeliveryReplay(ctx, id)
+}
@@ -22,6 +22,7 @@ type ProvisioningApi interface {
 	RouteDeleteAlertRule(*contextmodel.ReqContext) response.Response
 	RouteDeleteContactpoints(*contextmodel.ReqContext) response.Response
 	RouteDeleteMuteTiming(*contextmodel.ReqContext) response.Response
+	RouteDeleteSLO(*contextmodel.ReqContext) response.Response
 	RouteDeleteTemplate(*contextmodel.ReqContext) response.Response
 	RouteGetAlertRule(*contextmodel.ReqContext) response.Response
 	RouteGetAlertRuleExport(*contextmodel.ReqContext) response.Response
@@ -30,20 +31,27 @@ type ProvisioningApi interface {
 	RouteGetAlertRules(*contextmodel.ReqContext) response.Response
 	RouteGetAlertRulesExport(*contextmodel.ReqContext) response.Response
 	RouteGetContactpoints(*contextmodel.ReqContext) response.Response
+	RouteGetInhibitionRules(*contextmodel.ReqContext) response.Response
 	RouteGetMuteTiming(*contextmodel.ReqContext) response.Response
 	RouteGetMuteTimings(*contextmodel.ReqContext) response.Response
 	RouteGetPolicyTree(*contextmodel.ReqContext) response.Response
+	RouteGetSLO(*contextmodel.ReqContext) response.Response
+	RouteGetSLOs(*contextmodel.ReqContext) response.Response
 	RouteGetTemplate(*contextmodel.ReqContext) response.Response
 	RouteGetTemplates(*contextmodel.ReqContext) response.Response
 	RoutePostAlertRule(*contextmodel.ReqContext) response.Response
 	RoutePostContactpoints(*contextmodel.ReqContext) response.Response
 	RoutePostMuteTiming(*contextmodel.ReqContext) response.Response
+	RoutePostSLO(*contextmodel.ReqContext) response.Response
 	RoutePutAlertRule(*contextmodel.ReqContext) response.Response
 	RoutePutAlertRuleGroup(*contextmodel.ReqContext) response.Response
 	RoutePutContactpoint(*contextmodel.ReqContext) response.Response
+	RoutePutInhibitionRules(*contextmodel.ReqContext) response.Response
 	RoutePutMuteTiming(*contextmodel.ReqContext) response.Response
 	RoutePutPolicyTree(*contextmodel.ReqContext) response.Response
+	RoutePutSLO(*contextmodel.ReqContext) response.Response
 	RoutePutTemplate(*contextmodel.ReqContext) response.Response
+	RouteResetInhibitionRules(*contextmodel.ReqContext) response.Response
 	RouteResetPolicyTree(*contextmodel.ReqContext) response.Response
 }
 
@@ -62,6 +70,11 @@ func (f *ProvisioningApiHandler) RouteDeleteMuteTiming(ctx *contextmodel.ReqCont
 	nameParam := web.Params(ctx.Req)[":name"]
 	return f.handleRouteDeleteMuteTiming(ctx, nameParam)
 }
+func (f *ProvisioningApiHandler) RouteDeleteSLO(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	uIDParam := web.Params(ctx.Req)[":UID"]
+	return f.handleRouteDeleteSLO(ctx, uIDParam)
+}
 func (f *ProvisioningApiHandler) RouteDeleteTemplate(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -98,6 +111,9 @@ func (f *ProvisioningApiHandler) RouteGetAlertRulesExport(ctx *contextmodel.ReqC
 func (f *ProvisioningApiHandler) RouteGetContactpoints(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetContactpoints(ctx)
 }
+func (f *ProvisioningApiHandler) RouteGetInhibitionRules(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetInhibitionRules(ctx)
+}
 func (f *ProvisioningApiHandler) RouteGetMuteTiming(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -109,6 +125,14 @@ func (f *ProvisioningApiHandler) RouteGetMuteTimings(ctx *contextmodel.ReqContex
 func (f *ProvisioningApiHandler) RouteGetPolicyTree(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetPolicyTree(ctx)
 }
+func (f *ProvisioningApiHandler) RouteGetSLO(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	uIDParam := web.Params(ctx.Req)[":UID"]
+	return f.handleRouteGetSLO(ctx, uIDParam)
+}
+func (f *ProvisioningApiHandler) RouteGetSLOs(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetSLOs(ctx)
+}
 func (f *ProvisioningApiHandler) RouteGetTemplate(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -141,6 +165,14 @@ func (f *ProvisioningApiHandler) RoutePostMuteTiming(ctx *contextmodel.ReqContex
 	}
 	return f.handleRoutePostMuteTiming(ctx, conf)
 }
+func (f *ProvisioningApiHandler) RoutePostSLO(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.SLO{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostSLO(ctx, conf)
+}
 func (f *ProvisioningApiHandler) RoutePutAlertRule(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	uIDParam := web.Params(ctx.Req)[":UID"]
@@ -172,6 +204,14 @@ func (f *ProvisioningApiHandler) RoutePutContactpoint(ctx *contextmodel.ReqConte
 	}
 	return f.handleRoutePutContactpoint(ctx, conf, uIDParam)
 }
+func (f *ProvisioningApiHandler) RoutePutInhibitionRules(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.InhibitionRules{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePutInhibitionRules(ctx, conf)
+}
 func (f *ProvisioningApiHandler) RoutePutMuteTiming(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -190,6 +230,16 @@ func (f *ProvisioningApiHandler) RoutePutPolicyTree(ctx *contextmodel.ReqContext
 	}
 	return f.handleRoutePutPolicyTree(ctx, conf)
 }
+func (f *ProvisioningApiHandler) RoutePutSLO(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	uIDParam := web.Params(ctx.Req)[":UID"]
+	// Parse Request Body
+	conf := apimodels.SLO{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePutSLO(ctx, conf, uIDParam)
+}
 func (f *ProvisioningApiHandler) RoutePutTemplate(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -200,6 +250,9 @@ func (f *ProvisioningApiHandler) RoutePutTemplate(ctx *contextmodel.ReqContext)
 	}
 	return f.handleRoutePutTemplate(ctx, conf, nameParam)
 }
+func (f *ProvisioningApiHandler) RouteResetInhibitionRules(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteResetInhibitionRules(ctx)
+}
 func (f *ProvisioningApiHandler) RouteResetPolicyTree(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteResetPolicyTree(ctx)
 }
@@ -236,6 +289,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Delete(
+			toMacaronPath("/api/v1/provisioning/slos/{UID}"),
+			api.authorize(http.MethodDelete, "/api/v1/provisioning/slos/{UID}"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/v1/provisioning/slos/{UID}",
+				srv.RouteDeleteSLO,
+				m,
+			),
+		)
 		group.Delete(
 			toMacaronPath("/api/v1/provisioning/templates/{name}"),
 			api.authorize(http.MethodDelete, "/api/v1/provisioning/templates/{name}"),
@@ -316,6 +379,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/provisioning/inhibition-rules"),
+			api.authorize(http.MethodGet, "/api/v1/provisioning/inhibition-rules"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/provisioning/inhibition-rules",
+				srv.RouteGetInhibitionRules,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/provisioning/mute-timings/{name}"),
 			api.authorize(http.MethodGet, "/api/v1/provisioning/mute-timings/{name}"),
@@ -346,6 +419,26 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/provisioning/slos/{UID}"),
+			api.authorize(http.MethodGet, "/api/v1/provisioning/slos/{UID}"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/provisioning/slos/{UID}",
+				srv.RouteGetSLO,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/provisioning/slos"),
+			api.authorize(http.MethodGet, "/api/v1/provisioning/slos"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/provisioning/slos",
+				srv.RouteGetSLOs,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/provisioning/templates/{name}"),
 			api.authorize(http.MethodGet, "/api/v1/provisioning/templates/{name}"),
@@ -396,6 +489,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/provisioning/slos"),
+			api.authorize(http.MethodPost, "/api/v1/provisioning/slos"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/provisioning/slos",
+				srv.RoutePostSLO,
+				m,
+			),
+		)
 		group.Put(
 			toMacaronPath("/api/v1/provisioning/alert-rules/{UID}"),
 			api.authorize(http.MethodPut, "/api/v1/provisioning/alert-rules/{UID}"),
@@ -426,6 +529,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Put(
+			toMacaronPath("/api/v1/provisioning/inhibition-rules"),
+			api.authorize(http.MethodPut, "/api/v1/provisioning/inhibition-rules"),
+			metrics.Instrument(
+				http.MethodPut,
+				"/api/v1/provisioning/inhibition-rules",
+				srv.RoutePutInhibitionRules,
+				m,
+			),
+		)
 		group.Put(
 			toMacaronPath("/api/v1/provisioning/mute-timings/{name}"),
 			api.authorize(http.MethodPut, "/api/v1/provisioning/mute-timings/{name}"),
@@ -446,6 +559,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Put(
+			toMacaronPath("/api/v1/provisioning/slos/{UID}"),
+			api.authorize(http.MethodPut, "/api/v1/provisioning/slos/{UID}"),
+			metrics.Instrument(
+				http.MethodPut,
+				"/api/v1/provisioning/slos/{UID}",
+				srv.RoutePutSLO,
+				m,
+			),
+		)
 		group.Put(
 			toMacaronPath("/api/v1/provisioning/templates/{name}"),
 			api.authorize(http.MethodPut, "/api/v1/provisioning/templates/{name}"),
@@ -456,6 +579,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Delete(
+			toMacaronPath("/api/v1/provisioning/inhibition-rules"),
+			api.authorize(http.MethodDelete, "/api/v1/provisioning/inhibition-rules"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/v1/provisioning/inhibition-rules",
+				srv.RouteResetInhibitionRules,
+				m,
+			),
+		)
 		group.Delete(
 			toMacaronPath("/api/v1/provisioning/policies"),
 			api.authorize(http.MethodDelete, "/api/v1/provisioning/policies"),
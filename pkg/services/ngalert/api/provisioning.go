@@ -108,6 +108,38 @@ func (f *ProvisioningApiHandler) handleRouteResetPolicyTree(ctx *contextmodel.Re
 	return f.svc.RouteResetPolicyTree(ctx)
 }
 
+func (f *ProvisioningApiHandler) handleRouteGetInhibitionRules(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetInhibitionRules(ctx)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePutInhibitionRules(ctx *contextmodel.ReqContext, rules apimodels.InhibitionRules) response.Response {
+	return f.svc.RoutePutInhibitionRules(ctx, rules)
+}
+
+func (f *ProvisioningApiHandler) handleRouteResetInhibitionRules(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteResetInhibitionRules(ctx)
+}
+
+func (f *ProvisioningApiHandler) handleRouteGetSLOs(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetSLOs(ctx)
+}
+
+func (f *ProvisioningApiHandler) handleRouteGetSLO(ctx *contextmodel.ReqContext, UID string) response.Response {
+	return f.svc.RouteGetSLO(ctx, UID)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePostSLO(ctx *contextmodel.ReqContext, slo apimodels.SLO) response.Response {
+	return f.svc.RoutePostSLO(ctx, slo)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePutSLO(ctx *contextmodel.ReqContext, slo apimodels.SLO, UID string) response.Response {
+	return f.svc.RoutePutSLO(ctx, slo, UID)
+}
+
+func (f *ProvisioningApiHandler) handleRouteDeleteSLO(ctx *contextmodel.ReqContext, UID string) response.Response {
+	return f.svc.RouteDeleteSLO(ctx, UID)
+}
+
 func (f *ProvisioningApiHandler) handleRouteGetAlertRuleGroup(ctx *contextmodel.ReqContext, folder, group string) response.Response {
 	return f.svc.RouteGetAlertRuleGroup(ctx, folder, group)
 }
@@ -637,6 +637,8 @@ func (c *GettableUserConfig) GetGrafanaReceiverMap() map[string]*GettableGrafana
 type GettableApiAlertingConfig struct {
 	Config              `yaml:",inline"`
 	MuteTimeProvenances map[string]Provenance `yaml:"muteTimeProvenances,omitempty" json:"muteTimeProvenances,omitempty"`
+	// InhibitRulesProvenance is the provenance of all the inhibition rules, they are provisioned together
+	InhibitRulesProvenance Provenance `yaml:"inhibitRulesProvenance,omitempty" json:"inhibitRulesProvenance,omitempty"`
 	// Override with our superset receiver type
 	Receivers []*GettableApiReceiver `yaml:"receivers,omitempty" json:"receivers,omitempty"`
 }
@@ -0,0 +1,84 @@
+package definitions
+
+import (
+	"time"
+)
+
+// swagger:route GET /api/alertmanager/grafana/config/api/v1/deliveries alertmanager RouteGetGrafanaNotificationDeliveries
+//
+// Get the receipts of the notifications sent by the Grafana Alertmanager, the most recently updated first.
+//
+//     Responses:
+//       200: GettableNotificationDeliveries
+//       400: ValidationError
+
+// swagger:route POST /api/alertmanager/grafana/config/api/v1/deliveries/{DeliveryID}/replay alertmanager RoutePostGrafanaNotificationDeliveryReplay
+//
+// Send a dead-lettered notification again. The notification is retried with backoff if it fails again.
+//
+//     Responses:
+//       200: GettableNotificationDelivery
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:parameters RouteGetGrafanaNotificationDeliveries
+type GetNotificationDeliveriesParams struct {
+	// Filter the notifications by state: pending, delivered or dead
+	// in:query
+	// required: false
+	States []string `json:"state"`
+
+	// Filter the notifications by receiver
+	// in:query
+	// required: false
+	Receiver string `json:"receiver"`
+
+	// Filter the notifications by the UID of the integration of the receiver
+	// in:query
+	// required: false
+	IntegrationUID string `json:"integration"`
+
+	// The maximum number of notifications to return
+	// in:query
+	// required: false
+	// default: 100
+	Limit int64 `json:"limit"`
+}
+
+// swagger:parameters RoutePostGrafanaNotificationDeliveryReplay
+type NotificationDeliveryParams struct {
+	// in:path
+	DeliveryID int64
+}
+
+// swagger:model
+type GettableNotificationDeliveries []GettableNotificationDelivery
+
+// swagger:model
+type GettableNotificationDelivery struct {
+	ID              int64                       `json:"id"`
+	Receiver        string                      `json:"receiver"`
+	IntegrationUID  string                      `json:"integrationUid"`
+	IntegrationName string                      `json:"integrationName"`
+	IntegrationType string                      `json:"integrationType"`
+	GroupKey        string                      `json:"groupKey"`
+	GroupLabels     map[string]string           `json:"groupLabels"`
+	Alerts          []NotificationDeliveryAlert `json:"alerts"`
+	// enum: pending,delivered,dead
+	State         string    `json:"state"`
+	Attempts      int64     `json:"attempts"`
+	Retries       int64     `json:"retries"`
+	LastError     string    `json:"lastError,omitempty"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// swagger:model
+type NotificationDeliveryAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
@@ -151,3 +151,25 @@ func (mt *MuteTimeInterval) Validate() error {
 	}
 	return nil
 }
+
+// Validate normalizes the inhibition rules, and returns errors if a rule is invalid. The rules must match both the
+// source and the target alerts, a rule without matchers would inhibit every alert.
+func (r *InhibitionRules) Validate() error {
+	for i := range r.Rules {
+		s, err := yaml.Marshal(r.Rules[i])
+		if err != nil {
+			return err
+		}
+		if err = yaml.Unmarshal(s, &(r.Rules[i])); err != nil {
+			return fmt.Errorf("invalid inhibition rule %d: %w", i, err)
+		}
+		rule := r.Rules[i]
+		if len(rule.SourceMatch) == 0 && len(rule.SourceMatchRE) == 0 && len(rule.SourceMatchers) == 0 {
+			return fmt.Errorf("invalid inhibition rule %d: no source matchers", i)
+		}
+		if len(rule.TargetMatch) == 0 && len(rule.TargetMatchRE) == 0 && len(rule.TargetMatchers) == 0 {
+			return fmt.Errorf("invalid inhibition rule %d: no target matchers", i)
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/alertmanager/timeinterval"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
@@ -367,6 +368,66 @@ func TestValidateMuteTimeInterval(t *testing.T) {
 	})
 }
 
+func TestValidateInhibitionRules(t *testing.T) {
+	matcher := func(t *testing.T, name, value string) *labels.Matcher {
+		m, err := labels.NewMatcher(labels.MatchEqual, name, value)
+		require.NoError(t, err)
+		return m
+	}
+
+	t.Run("valid rules", func(t *testing.T) {
+		rules := InhibitionRules{Rules: []config.InhibitRule{
+			{
+				SourceMatchers: config.Matchers{matcher(t, "alertname", "DatacenterDown")},
+				TargetMatchers: config.Matchers{matcher(t, "severity", "warning")},
+				Equal:          model.LabelNames{"datacenter"},
+			},
+			{
+				SourceMatch: map[string]string{"alertname": "DatacenterDown"},
+				TargetMatch: map[string]string{"severity": "critical"},
+			},
+		}}
+
+		require.NoError(t, rules.Validate())
+		require.NoError(t, (&InhibitionRules{}).Validate())
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		cases := []struct {
+			desc   string
+			rule   config.InhibitRule
+			expMsg string
+		}{
+			{
+				desc:   "no source matchers",
+				rule:   config.InhibitRule{TargetMatchers: config.Matchers{matcher(t, "severity", "warning")}},
+				expMsg: "invalid inhibition rule 0: no source matchers",
+			},
+			{
+				desc:   "no target matchers",
+				rule:   config.InhibitRule{SourceMatchers: config.Matchers{matcher(t, "alertname", "DatacenterDown")}},
+				expMsg: "invalid inhibition rule 0: no target matchers",
+			},
+			{
+				desc: "invalid label name",
+				rule: config.InhibitRule{
+					SourceMatch:    map[string]string{"alert-name": "DatacenterDown"},
+					TargetMatchers: config.Matchers{matcher(t, "severity", "warning")},
+				},
+				expMsg: "invalid label name",
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.desc, func(t *testing.T) {
+				rules := InhibitionRules{Rules: []config.InhibitRule{c.rule}}
+
+				require.ErrorContains(t, rules.Validate(), c.expMsg)
+			})
+		}
+	})
+}
+
 func TestValidateNotificationTemplates(t *testing.T) {
 	tc := []struct {
 		name       string
@@ -375,6 +375,9 @@ type PostableGrafanaRule struct {
 	NoDataState  NoDataState         `json:"no_data_state" yaml:"no_data_state"`
 	ExecErrState ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
 	IsPaused     *bool               `json:"is_paused" yaml:"is_paused"`
+	// The label selectors of the dimensions of the rule that are paused
+	// example: ["{instance=\"server-1\"}"]
+	PausedDimensions []string `json:"paused_dimensions,omitempty" yaml:"paused_dimensions,omitempty"`
 }
 
 // swagger:model
@@ -395,4 +398,6 @@ type GettableGrafanaRule struct {
 	ExecErrState    ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
 	Provenance      Provenance          `json:"provenance,omitempty" yaml:"provenance,omitempty"`
 	IsPaused        bool                `json:"is_paused" yaml:"is_paused"`
+	// The label selectors of the dimensions of the rule that are paused
+	PausedDimensions []string `json:"paused_dimensions,omitempty" yaml:"paused_dimensions,omitempty"`
 }
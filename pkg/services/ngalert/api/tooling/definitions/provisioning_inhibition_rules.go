@@ -0,0 +1,52 @@
+package definitions
+
+import (
+	"github.com/prometheus/alertmanager/config"
+)
+
+// swagger:route GET /api/v1/provisioning/inhibition-rules provisioning stable RouteGetInhibitionRules
+//
+// Get the inhibition rules.
+//
+//     Responses:
+//       200: InhibitionRules
+
+// swagger:route PUT /api/v1/provisioning/inhibition-rules provisioning stable RoutePutInhibitionRules
+//
+// Replace the inhibition rules.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       202: Ack
+//       400: ValidationError
+
+// swagger:route DELETE /api/v1/provisioning/inhibition-rules provisioning stable RouteResetInhibitionRules
+//
+// Delete all the inhibition rules.
+//
+//     Responses:
+//       202: Ack
+
+// swagger:parameters RoutePutInhibitionRules
+type InhibitionRulesPayload struct {
+	// in:body
+	Body InhibitionRules
+}
+
+// InhibitionRules are the inhibition rules of the Alertmanager configuration. The rules are provisioned together, as
+// they have no name to identify them.
+// swagger:model
+type InhibitionRules struct {
+	Rules      []config.InhibitRule `json:"rules" yaml:"rules"`
+	Provenance Provenance           `json:"provenance,omitempty"`
+}
+
+func (r *InhibitionRules) ResourceType() string {
+	return "inhibitionRules"
+}
+
+func (r *InhibitionRules) ResourceID() string {
+	return ""
+}
@@ -0,0 +1,109 @@
+package definitions
+
+import (
+	"github.com/prometheus/common/model"
+)
+
+// swagger:route GET /api/v1/provisioning/slos provisioning stable RouteGetSLOs
+//
+// Get all the SLOs.
+//
+//     Responses:
+//       200: SLOs
+
+// swagger:route GET /api/v1/provisioning/slos/{UID} provisioning stable RouteGetSLO
+//
+// Get an SLO.
+//
+//     Responses:
+//       200: SLO
+//       404: description: Not found.
+
+// swagger:route POST /api/v1/provisioning/slos provisioning stable RoutePostSLO
+//
+// Create an SLO and its burn rate alert rules.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       201: SLO
+//       400: ValidationError
+
+// swagger:route PUT /api/v1/provisioning/slos/{UID} provisioning stable RoutePutSLO
+//
+// Replace an SLO and update its burn rate alert rules.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: SLO
+//       400: ValidationError
+//       404: description: Not found.
+
+// swagger:route DELETE /api/v1/provisioning/slos/{UID} provisioning stable RouteDeleteSLO
+//
+// Delete an SLO and its burn rate alert rules.
+//
+//     Responses:
+//       204: description: The SLO was deleted successfully.
+
+// swagger:parameters RouteGetSLO RoutePutSLO RouteDeleteSLO
+type SLOUIDReference struct {
+	// SLO UID
+	// in:path
+	UID string
+}
+
+// swagger:parameters RoutePostSLO RoutePutSLO
+type SLOPayload struct {
+	// in:body
+	Body SLO
+}
+
+// swagger:model
+type SLOs []SLO
+
+// SLO is a service level objective. Grafana generates the multi-window, multi-burn-rate alert rules of the SLO in a
+// rule group of the folder named after the SLO, and updates them when the SLO changes.
+// swagger:model
+type SLO struct {
+	// example: checkout-availability
+	UID string `json:"uid"`
+	// required: true
+	// example: Checkout availability
+	Title string `json:"title"`
+	// required: true
+	// example: project_x
+	FolderUID string `json:"folderUID"`
+	// The data source of the queries, a Prometheus compatible data source
+	// required: true
+	DatasourceUID string `json:"datasourceUid"`
+	// The query of the rate of the failed events, $__range is the window of the burn rate
+	// required: true
+	// example: sum(rate(http_requests_total{code=~"5.."}[$__range]))
+	ErrorQuery string `json:"errorQuery"`
+	// The query of the rate of all the events, $__range is the window of the burn rate
+	// required: true
+	// example: sum(rate(http_requests_total[$__range]))
+	TotalQuery string `json:"totalQuery"`
+	// The objective, in percent of good events
+	// required: true
+	// example: 99.9
+	Objective float64 `json:"objective"`
+	// The window of the objective, 30d by default
+	// example: 30d
+	Window model.Duration `json:"window,omitempty"`
+	// The labels added to the alert rules of the SLO
+	Labels     map[string]string `json:"labels,omitempty"`
+	Provenance Provenance        `json:"provenance,omitempty"`
+}
+
+func (s *SLO) ResourceType() string {
+	return "slo"
+}
+
+func (s *SLO) ResourceID() string {
+	return s.UID
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -239,6 +240,10 @@ func buildDatasourceHeaders(ctx EvaluationContext) map[string]string {
 		headers["X-Grafana-Org-Id"] = strconv.FormatInt(key.OrgID, 10)
 	}
 
+	// the evaluations don't go through the query service, they are background queries that the datasources can queue
+	// or shed under load. The data sources read it as an HTTP header, with the prefix of the SDK.
+	headers["http_"+query.HeaderQueryPriority] = string(query.PriorityBackground)
+
 	return headers
 }
 
@@ -12,10 +12,12 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	fakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
@@ -532,7 +534,7 @@ func TestValidate(t *testing.T) {
 				pluginsStore: store,
 			})
 
-			evaluator := NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, cacheService, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil), store)
+			evaluator := NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, cacheService, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, tracing.InitializeTracerForTest()), store)
 			evalCtx := Context(context.Background(), u)
 
 			err := evaluator.Validate(evalCtx, condition)
@@ -572,6 +574,19 @@ func TestEvaluateRaw(t *testing.T) {
 	})
 }
 
+func TestGetExprRequest(t *testing.T) {
+	t.Run("should send the evaluations as background queries", func(t *testing.T) {
+		ctx := models.WithRuleKey(context.Background(), models.AlertRuleKey{OrgID: 1, UID: "rule"})
+		req, err := getExprRequest(Context(ctx, &user.SignedInUser{OrgID: 1}), nil, &fakes.FakeCacheService{})
+		require.NoError(t, err)
+
+		queryReq := backend.QueryDataRequest{Headers: req.Headers}
+		require.Equal(t, string(query.PriorityBackground), queryReq.GetHTTPHeader(query.HeaderQueryPriority))
+		require.Equal(t, "rule", req.Headers["X-Rule-Uid"])
+		require.Equal(t, "true", req.Headers[models.FromAlertHeaderName])
+	})
+}
+
 type fakeExpressionService struct {
 	hook func(ctx context.Context, now time.Time, pipeline expr.DataPipeline) (*backend.QueryDataResponse, error)
 }
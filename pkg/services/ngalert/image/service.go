@@ -11,12 +11,17 @@ import (
 	"golang.org/x/sync/singleflight"
 
 	"github.com/grafana/grafana/pkg/components/imguploader"
+	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/screenshot"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -80,7 +85,7 @@ func NewScreenshotImageService(
 // NewScreenshotImageServiceFromCfg returns a new ScreenshotImageService
 // from the configuration.
 func NewScreenshotImageServiceFromCfg(cfg *setting.Cfg, db *store.DBstore, ds dashboards.DashboardService,
-	rs rendering.Service, r prometheus.Registerer) (ImageService, error) {
+	rs rendering.Service, qs query.Service, r prometheus.Registerer) (ImageService, error) {
 	var (
 		cache             CacheService                 = &NoOpCacheService{}
 		limiter           screenshot.RateLimiter       = &screenshot.NoOpRateLimiter{}
@@ -94,6 +99,11 @@ func NewScreenshotImageServiceFromCfg(cfg *setting.Cfg, db *store.DBstore, ds da
 		cache = NewInmemCacheService(screenshotCacheTTL, r)
 		limiter = screenshot.NewTokenRateLimiter(cfg.UnifiedAlerting.Screenshots.MaxConcurrentScreenshots)
 		screenshots = screenshot.NewHeadlessScreenshotService(ds, rs, r)
+		// Panels are rendered natively when the image renderer is not available
+		if cfg.UnifiedAlerting.Screenshots.NativeRendererFallback && qs != nil {
+			screenshots = screenshot.NewFallbackScreenshotService(rs, screenshots,
+				screenshot.NewNativeScreenshotService(ds, qs, cfg.ImagesDir))
+		}
 		screenshotTimeout = cfg.UnifiedAlerting.Screenshots.CaptureTimeout
 
 		// Image uploading is an optional feature
@@ -110,6 +120,27 @@ func NewScreenshotImageServiceFromCfg(cfg *setting.Cfg, db *store.DBstore, ds da
 		screenshots, screenshotTimeout, db, uploads), nil
 }
 
+// screenshotUser returns the identity the queries of the screenshots of the alert rule run as. It can only query the
+// datasources of the rule.
+func screenshotUser(r *models.AlertRule) *user.SignedInUser {
+	scopes := make([]string, 0, len(r.Data))
+	for _, q := range r.Data {
+		if !expr.IsDataSource(q.DatasourceUID) {
+			scopes = append(scopes, datasources.ScopeProvider.GetResourceScopeUID(q.DatasourceUID))
+		}
+	}
+	return &user.SignedInUser{
+		UserID:           -1,
+		IsServiceAccount: true,
+		Login:            "grafana_scheduler",
+		OrgID:            r.OrgID,
+		OrgRole:          org.RoleViewer,
+		Permissions: map[int64]map[string][]string{
+			r.OrgID: {datasources.ActionQuery: scopes},
+		},
+	}
+}
+
 // NewImage returns a screenshot of the alert rule or an error.
 //
 // The alert rule must be associated with a dashboard panel for a screenshot to be
@@ -139,6 +170,7 @@ func (s *ScreenshotImageService) NewImage(ctx context.Context, r *models.AlertRu
 		DashboardUID: dashboardUID,
 		PanelID:      panelID,
 		Timeout:      s.screenshotTimeout,
+		SignedInUser: screenshotUser(r),
 	}
 
 	// To prevent concurrent screenshots of the same dashboard panel we use singleflight,
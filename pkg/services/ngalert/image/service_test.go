@@ -12,9 +12,12 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/components/imguploader"
+	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/screenshot"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -38,7 +41,7 @@ func TestScreenshotImageService(t *testing.T) {
 
 	t.Run("image is taken, uploaded, saved to database and cached", func(t *testing.T) {
 		// assert that the cache is checked for an existing image
-		cache.EXPECT().Get(gomock.Any(), "oyh1kYgaJwM=").Return(models.Image{}, false)
+		cache.EXPECT().Get(gomock.Any(), "vezx4ISXP5U=").Return(models.Image{}, false)
 
 		// assert that a screenshot is taken
 		screenshots.EXPECT().Take(gomock.Any(), screenshot.ScreenshotOptions{
@@ -46,6 +49,7 @@ func TestScreenshotImageService(t *testing.T) {
 			DashboardUID: "foo",
 			PanelID:      1,
 			Timeout:      5 * time.Second,
+			SignedInUser: screenshotUser(&models.AlertRule{OrgID: 1}),
 		}).Return(&screenshot.Screenshot{
 			Path: "foo.png",
 		}, nil)
@@ -63,7 +67,7 @@ func TestScreenshotImageService(t *testing.T) {
 		}
 
 		// assert that the image is saved into the cache
-		cache.EXPECT().Set(gomock.Any(), "oyh1kYgaJwM=", expected).Return(nil)
+		cache.EXPECT().Set(gomock.Any(), "vezx4ISXP5U=", expected).Return(nil)
 
 		image, err := s.NewImage(ctx, &models.AlertRule{
 			OrgID:        1,
@@ -76,7 +80,7 @@ func TestScreenshotImageService(t *testing.T) {
 
 	t.Run("image is taken, upload return error, saved to database without URL and cached", func(t *testing.T) {
 		// assert that the cache is checked for an existing image
-		cache.EXPECT().Get(gomock.Any(), "yszV9tgmKAo=").Return(models.Image{}, false)
+		cache.EXPECT().Get(gomock.Any(), "hNe9pYSmHSY=").Return(models.Image{}, false)
 
 		// assert that a screenshot is taken
 		screenshots.EXPECT().Take(gomock.Any(), screenshot.ScreenshotOptions{
@@ -84,6 +88,7 @@ func TestScreenshotImageService(t *testing.T) {
 			DashboardUID: "bar",
 			PanelID:      1,
 			Timeout:      5 * time.Second,
+			SignedInUser: screenshotUser(&models.AlertRule{OrgID: 1}),
 		}).Return(&screenshot.Screenshot{
 			Path: "bar.png",
 		}, nil)
@@ -100,7 +105,7 @@ func TestScreenshotImageService(t *testing.T) {
 		}
 
 		// assert that the image is saved into the cache, but without a URL
-		cache.EXPECT().Set(gomock.Any(), "yszV9tgmKAo=", expected).Return(nil)
+		cache.EXPECT().Set(gomock.Any(), "hNe9pYSmHSY=", expected).Return(nil)
 
 		image, err := s.NewImage(ctx, &models.AlertRule{
 			OrgID:        1,
@@ -115,7 +120,7 @@ func TestScreenshotImageService(t *testing.T) {
 		expected := models.Image{Path: "baz.png", URL: "https://example.com/baz.png"}
 
 		// assert that the cache is checked for an existing image and it is returned
-		cache.EXPECT().Get(gomock.Any(), "he399rFDBPI=").Return(expected, true)
+		cache.EXPECT().Get(gomock.Any(), "pt1khBYKis4=").Return(expected, true)
 
 		image, err := s.NewImage(ctx, &models.AlertRule{
 			OrgID:        1,
@@ -128,7 +133,7 @@ func TestScreenshotImageService(t *testing.T) {
 
 	t.Run("error is returned when timeout is exceeded", func(t *testing.T) {
 		// assert that the cache is checked for an existing image
-		cache.EXPECT().Get(gomock.Any(), "TTHub8HUe2U=").Return(models.Image{}, false)
+		cache.EXPECT().Get(gomock.Any(), "yqL7L9jcclM=").Return(models.Image{}, false)
 
 		// assert that when the timeout is exceeded an error is returned
 		screenshots.EXPECT().Take(gomock.Any(), screenshot.ScreenshotOptions{
@@ -136,6 +141,7 @@ func TestScreenshotImageService(t *testing.T) {
 			DashboardUID: "qux",
 			PanelID:      1,
 			Timeout:      5 * time.Second,
+			SignedInUser: screenshotUser(&models.AlertRule{OrgID: 1}),
 		}).Return(nil, context.DeadlineExceeded)
 
 		image, err := s.NewImage(ctx, &models.AlertRule{
@@ -147,3 +153,15 @@ func TestScreenshotImageService(t *testing.T) {
 		assert.Nil(t, image)
 	})
 }
+
+func TestScreenshotUser(t *testing.T) {
+	u := screenshotUser(&models.AlertRule{OrgID: 2, Data: []models.AlertQuery{
+		{RefID: "A", DatasourceUID: "prom"},
+		{RefID: "B", DatasourceUID: expr.DatasourceUID},
+	}})
+	assert.Equal(t, int64(2), u.OrgID)
+	assert.Equal(t, org.RoleViewer, u.OrgRole)
+	assert.Equal(t, map[int64]map[string][]string{
+		2: {datasources.ActionQuery: {"datasources:uid:prom"}},
+	}, u.Permissions)
+}
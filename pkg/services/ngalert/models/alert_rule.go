@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	alertingModels "github.com/grafana/alerting/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
 
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/util/cmputil"
@@ -93,6 +95,10 @@ const (
 	// Annotations are actually a set of labels, so technically this is the label name of an annotation.
 	DashboardUIDAnnotation = "__dashboardUid__"
 	PanelIDAnnotation      = "__panelId__"
+	// LinkedPanelsAnnotation is the annotation with the dashboards and panels the state history annotations of the
+	// rule are attached to, in addition to its own panel. It is a comma separated list of dashboard UIDs, each
+	// optionally followed by a slash and a panel ID, such as "deployments/4,services".
+	LinkedPanelsAnnotation = "__linkedPanels__"
 
 	// GrafanaReservedLabelPrefix contains the prefix for Grafana reserved labels. These differ from "__<label>__" labels
 	// in that they are not meant for internal-use only and will be passed-through to AMs and available to users in the same
@@ -160,6 +166,9 @@ type AlertRule struct {
 	Annotations map[string]string
 	Labels      map[string]string
 	IsPaused    bool
+	// PausedDimensions are the label selectors of the dimensions of the rule that are paused, for example
+	// {instance="server-1"}. The states of the dimensions matching any selector are kept normal.
+	PausedDimensions []string
 }
 
 // AlertRuleWithOptionals This is to avoid having to pass in additional arguments deep in the call stack. Alert rule
@@ -212,6 +221,87 @@ func (alertRule *AlertRule) GetLabels(opts ...LabelOption) map[string]string {
 	return labels
 }
 
+// PausedDimensionsMatchers returns the matchers of each selector of the paused dimensions of the rule.
+func (alertRule *AlertRule) PausedDimensionsMatchers() ([]labels.Matchers, error) {
+	return ParsePausedDimensions(alertRule.PausedDimensions)
+}
+
+// ParsePausedDimensions parses the label selectors of paused dimensions. A selector must have at least one matcher
+// so that a selector does not pause all the dimensions of a rule.
+func ParsePausedDimensions(selectors []string) ([]labels.Matchers, error) {
+	result := make([]labels.Matchers, 0, len(selectors))
+	for _, selector := range selectors {
+		matchers, err := labels.ParseMatchers(selector)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid paused dimension selector %q: %s", ErrAlertRuleFailedValidation, selector, err)
+		}
+		if len(matchers) == 0 {
+			return nil, fmt.Errorf("%w: paused dimension selector %q has no matchers, pause the rule instead", ErrAlertRuleFailedValidation, selector)
+		}
+		result = append(result, matchers)
+	}
+	return result, nil
+}
+
+// LinkedPanel is a dashboard or a panel of a dashboard the state history annotations of a rule are attached to.
+type LinkedPanel struct {
+	DashboardUID string
+	// PanelID is 0 when the annotations are attached to the whole dashboard.
+	PanelID int64
+}
+
+// LinkedPanels returns the dashboards and panels of the LinkedPanelsAnnotation of the rule.
+func (alertRule *AlertRule) LinkedPanels() ([]LinkedPanel, error) {
+	return ParseLinkedPanels(alertRule.Annotations[LinkedPanelsAnnotation])
+}
+
+// ParseLinkedPanels parses the value of the LinkedPanelsAnnotation. The duplicated dashboards and panels are ignored.
+func ParseLinkedPanels(value string) ([]LinkedPanel, error) {
+	var result []LinkedPanel
+	seen := make(map[LinkedPanel]struct{})
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		dashUID, panel, hasPanel := strings.Cut(entry, "/")
+		if dashUID == "" {
+			return nil, fmt.Errorf("%w: annotation %s has a panel %q without a dashboard UID", ErrAlertRuleFailedValidation, LinkedPanelsAnnotation, entry)
+		}
+		linked := LinkedPanel{DashboardUID: dashUID}
+		if hasPanel {
+			panelID, err := strconv.ParseInt(panel, 10, 64)
+			if err != nil || panelID <= 0 {
+				return nil, fmt.Errorf("%w: annotation %s has an invalid panel ID in %q", ErrAlertRuleFailedValidation, LinkedPanelsAnnotation, entry)
+			}
+			linked.PanelID = panelID
+		}
+		if _, ok := seen[linked]; ok {
+			continue
+		}
+		seen[linked] = struct{}{}
+		result = append(result, linked)
+	}
+	return result, nil
+}
+
+// IsDimensionPaused returns true if the labels of a dimension match all the matchers of any of the selectors.
+func IsDimensionPaused(selectors []labels.Matchers, lbls map[string]string) bool {
+	for _, matchers := range selectors {
+		matched := true
+		for _, m := range matchers {
+			if !m.Matches(lbls[m.Name]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
 func (alertRule *AlertRule) GetEvalCondition() Condition {
 	return Condition{
 		Condition: alertRule.Condition,
@@ -352,10 +442,11 @@ type AlertRuleVersion struct {
 	ExecErrState    ExecutionErrorState
 	// ideally this field should have been apimodels.ApiDuration
 	// but this is currently not possible because of circular dependencies
-	For         time.Duration
-	Annotations map[string]string
-	Labels      map[string]string
-	IsPaused    bool
+	For              time.Duration
+	Annotations      map[string]string
+	Labels           map[string]string
+	IsPaused         bool
+	PausedDimensions []string
 }
 
 // GetAlertRuleByUIDQuery is the query for retrieving/deleting an alert rule by UID and organisation ID.
@@ -162,6 +162,37 @@ func TestSetDashboardAndPanelFromAnnotations(t *testing.T) {
 	}
 }
 
+func TestParseLinkedPanels(t *testing.T) {
+	panels, err := ParseLinkedPanels("deployments/4, services,,deployments/4")
+	require.NoError(t, err)
+	require.Equal(t, []LinkedPanel{{DashboardUID: "deployments", PanelID: 4}, {DashboardUID: "services"}}, panels)
+
+	panels, err = ParseLinkedPanels("")
+	require.NoError(t, err)
+	require.Empty(t, panels)
+
+	for _, invalid := range []string{"/4", "deployments/", "deployments/abc", "deployments/-1"} {
+		_, err = ParseLinkedPanels(invalid)
+		require.ErrorIs(t, err, ErrAlertRuleFailedValidation, invalid)
+	}
+}
+
+func TestPausedDimensions(t *testing.T) {
+	selectors, err := ParsePausedDimensions([]string{`{instance="server-1"}`, `{env="dev", team=~"a|b"}`})
+	require.NoError(t, err)
+
+	require.True(t, IsDimensionPaused(selectors, map[string]string{"instance": "server-1", "env": "prod"}))
+	require.True(t, IsDimensionPaused(selectors, map[string]string{"instance": "server-2", "env": "dev", "team": "b"}))
+	require.False(t, IsDimensionPaused(selectors, map[string]string{"instance": "server-2", "env": "dev"}))
+	require.False(t, IsDimensionPaused(nil, map[string]string{"instance": "server-1"}))
+
+	_, err = ParsePausedDimensions([]string{`{instance=~"["}`})
+	require.ErrorIs(t, err, ErrAlertRuleFailedValidation)
+
+	_, err = ParsePausedDimensions([]string{``})
+	require.ErrorIs(t, err, ErrAlertRuleFailedValidation)
+}
+
 func TestPatchPartialAlertRule(t *testing.T) {
 	t.Run("patches", func(t *testing.T) {
 		testCases := []struct {
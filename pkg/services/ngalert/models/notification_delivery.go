@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotificationDeliveryNotFound is returned when the notification delivery does not exist.
+	ErrNotificationDeliveryNotFound = errors.New("notification delivery not found")
+)
+
+// NotificationDeliveryState is the state of the delivery of a notification to a contact point.
+type NotificationDeliveryState string
+
+const (
+	// NotificationDeliveryPending is the state of the deliveries that failed and wait in the retry queue.
+	NotificationDeliveryPending NotificationDeliveryState = "pending"
+	// NotificationDeliveryDelivered is the state of the deliveries the contact point received.
+	NotificationDeliveryDelivered NotificationDeliveryState = "delivered"
+	// NotificationDeliveryDead is the state of the dead-lettered deliveries, that failed too many times or with an
+	// error that retrying does not fix. They are retried only when replayed.
+	NotificationDeliveryDead NotificationDeliveryState = "dead"
+)
+
+// NotificationDelivery is the receipt of the notification of a group of alerts to a contact point, holding the
+// alerts so the notification can be retried. The attempts of the Alertmanager and of the retry queue for the same
+// group and contact point update the same delivery until it succeeds or is dead-lettered.
+type NotificationDelivery struct {
+	ID              int64                     `xorm:"pk autoincr 'id'"`
+	OrgID           int64                     `xorm:"org_id"`
+	DeliveryKey     string                    `xorm:"delivery_key"`
+	Receiver        string                    `xorm:"receiver"`
+	IntegrationUID  string                    `xorm:"integration_uid"`
+	IntegrationName string                    `xorm:"integration_name"`
+	IntegrationType string                    `xorm:"integration_type"`
+	GroupKey        string                    `xorm:"group_key"`
+	GroupLabels     string                    `xorm:"group_labels"`
+	Alerts          string                    `xorm:"alerts"`
+	State           NotificationDeliveryState `xorm:"state"`
+	Attempts        int64                     `xorm:"attempts"`
+	Retries         int64                     `xorm:"retries"`
+	LastError       string                    `xorm:"last_error"`
+	NextAttemptAt   time.Time                 `xorm:"next_attempt_at"`
+	CreatedAt       time.Time                 `xorm:"created_at"`
+	UpdatedAt       time.Time                 `xorm:"updated_at"`
+}
+
+func (d *NotificationDelivery) TableName() string {
+	return "alert_notification_delivery"
+}
+
+// ListNotificationDeliveriesQuery is the query to list the notification deliveries of an organization, the most
+// recently updated first. The empty fields do not filter the deliveries.
+type ListNotificationDeliveriesQuery struct {
+	OrgID          int64
+	States         []NotificationDeliveryState
+	Receiver       string
+	IntegrationUID string
+	Limit          int
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/state/historian"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/secrets"
@@ -62,6 +63,7 @@ func ProvideService(
 	ac accesscontrol.AccessControl,
 	dashboardService dashboards.DashboardService,
 	renderService rendering.Service,
+	queryService query.Service,
 	bus bus.Bus,
 	accesscontrolService accesscontrol.Service,
 	annotationsRepo annotations.Repository,
@@ -87,6 +89,7 @@ func ProvideService(
 		accesscontrol:        ac,
 		dashboardService:     dashboardService,
 		renderService:        renderService,
+		queryService:         queryService,
 		bus:                  bus,
 		accesscontrolService: accesscontrolService,
 		annotationsRepo:      annotationsRepo,
@@ -122,6 +125,7 @@ type AlertNG struct {
 	NotificationService notifications.Service
 	Log                 log.Logger
 	renderService       rendering.Service
+	queryService        query.Service
 	imageService        image.ImageService
 	schedule            schedule.ScheduleService
 	stateManager        *state.Manager
@@ -166,7 +170,7 @@ func (ng *AlertNG) init() error {
 		return err
 	}
 
-	imageService, err := image.NewScreenshotImageServiceFromCfg(ng.Cfg, store, ng.dashboardService, ng.renderService, ng.Metrics.Registerer)
+	imageService, err := image.NewScreenshotImageServiceFromCfg(ng.Cfg, store, ng.dashboardService, ng.renderService, ng.queryService, ng.Metrics.Registerer)
 	if err != nil {
 		return err
 	}
@@ -201,6 +205,7 @@ func (ng *AlertNG) init() error {
 		C:                    clk,
 		BaseInterval:         ng.Cfg.UnifiedAlerting.BaseInterval,
 		MinRuleInterval:      ng.Cfg.UnifiedAlerting.MinInterval,
+		SkipUnchangedMaxAge:  ng.Cfg.UnifiedAlerting.SkipUnchangedResultsMaxAge,
 		DisableGrafanaFolder: ng.Cfg.UnifiedAlerting.ReservedLabels.IsReservedLabelDisabled(models.FolderTitleLabel),
 		AppURL:               appUrl,
 		EvaluatorFactory:     evalFactory,
@@ -239,9 +244,11 @@ func (ng *AlertNG) init() error {
 	contactPointService := provisioning.NewContactPointService(store, ng.SecretsService, store, store, ng.Log)
 	templateService := provisioning.NewTemplateService(store, store, store, ng.Log)
 	muteTimingService := provisioning.NewMuteTimingService(store, store, store, ng.Log)
+	inhibitionRuleService := provisioning.NewInhibitionRuleService(store, store, store, ng.Log)
 	alertRuleService := provisioning.NewAlertRuleService(store, store, ng.dashboardService, ng.QuotaService, store,
 		int64(ng.Cfg.UnifiedAlerting.DefaultRuleEvaluationInterval.Seconds()),
 		int64(ng.Cfg.UnifiedAlerting.BaseInterval.Seconds()), ng.Log)
+	sloService := provisioning.NewSLOService(ng.KVStore, alertRuleService, store, store, ng.Log)
 
 	api := api.API{
 		Cfg:                  ng.Cfg,
@@ -262,6 +269,8 @@ func (ng *AlertNG) init() error {
 		ContactPointService:  contactPointService,
 		Templates:            templateService,
 		MuteTimings:          muteTimingService,
+		InhibitionRules:      inhibitionRuleService,
+		SLOs:                 sloService,
 		AlertRules:           alertRuleService,
 		AlertsRouter:         alertsRouter,
 		EvaluatorFactory:     evalFactory,
@@ -277,9 +286,10 @@ func (ng *AlertNG) init() error {
 	}
 
 	if err := ng.QuotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
-		TargetSrv:     models.QuotaTargetSrv,
-		DefaultLimits: defaultLimits,
-		Reporter:      api.Usage,
+		TargetSrv:         models.QuotaTargetSrv,
+		DefaultLimits:     defaultLimits,
+		Reporter:          api.Usage,
+		ReadDefaultLimits: readQuotaConfig,
 	}); err != nil {
 		return err
 	}
@@ -357,8 +367,8 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 	var alertGlobalQuota int64
 
 	if cfg.UnifiedAlerting.IsEnabled() {
-		alertOrgQuota = cfg.Quota.Org.AlertRule
-		alertGlobalQuota = cfg.Quota.Global.AlertRule
+		alertOrgQuota = cfg.QuotaSettings().Org.AlertRule
+		alertGlobalQuota = cfg.QuotaSettings().Global.AlertRule
 	}
 
 	globalQuotaTag, err := quota.NewTag(models.QuotaTargetSrv, models.QuotaTarget, quota.GlobalScope)
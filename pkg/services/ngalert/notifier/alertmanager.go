@@ -22,6 +22,7 @@ import (
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/setting"
@@ -43,6 +44,7 @@ var silenceMaintenanceInterval = 15 * time.Minute
 type AlertingStore interface {
 	store.AlertingStore
 	store.ImageStore
+	store.NotificationDeliveryStore
 }
 
 type Alertmanager struct {
@@ -54,8 +56,9 @@ type Alertmanager struct {
 	fileStore           *FileStore
 	NotificationService notifications.Service
 
-	decryptFn receivers.GetDecryptedValueFn
-	orgID     int64
+	decryptFn  receivers.GetDecryptedValueFn
+	orgID      int64
+	deliveries *deliveryTracker
 }
 
 // maintenanceOptions represent the options for components that need maintenance on a frequency within the Alertmanager.
@@ -141,6 +144,7 @@ func newAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store A
 		decryptFn:           decryptFn,
 		fileStore:           fileStore,
 		logger:              l,
+		deliveries:          newDeliveryTracker(orgID, store, l),
 	}
 
 	return am, nil
@@ -319,25 +323,29 @@ func (am *Alertmanager) WorkingDirPath() string {
 // buildIntegrationsMap builds a map of name to the list of Grafana integration notifiers off of a list of receiver config.
 func (am *Alertmanager) buildIntegrationsMap(receivers []*apimodels.PostableApiReceiver, templates *alertingNotify.Template) (map[string][]*alertingNotify.Integration, error) {
 	integrationsMap := make(map[string][]*alertingNotify.Integration, len(receivers))
+	notifiers := make(map[string]*trackedNotifier)
 	for _, receiver := range receivers {
-		integrations, err := am.buildReceiverIntegrations(receiver, templates)
+		integrations, err := am.buildReceiverIntegrations(receiver, templates, notifiers)
 		if err != nil {
 			return nil, err
 		}
 		integrationsMap[receiver.Name] = integrations
 	}
+	am.deliveries.setNotifiers(notifiers)
 
 	return integrationsMap, nil
 }
 
-// buildReceiverIntegrations builds a list of integration notifiers off of a receiver config.
-func (am *Alertmanager) buildReceiverIntegrations(receiver *apimodels.PostableApiReceiver, tmpl *alertingNotify.Template) ([]*alertingNotify.Integration, error) {
+// buildReceiverIntegrations builds a list of integration notifiers off of a receiver config. The delivery attempts of
+// the notifiers are tracked, and the notifiers are added to the notifiers by UID the failed notifications are retried with.
+func (am *Alertmanager) buildReceiverIntegrations(receiver *apimodels.PostableApiReceiver, tmpl *alertingNotify.Template, notifiers map[string]*trackedNotifier) ([]*alertingNotify.Integration, error) {
 	integrations := make([]*alertingNotify.Integration, 0, len(receiver.GrafanaManagedReceivers))
 	for i, r := range receiver.GrafanaManagedReceivers {
 		n, err := am.buildReceiverIntegration(r, tmpl)
 		if err != nil {
 			return nil, err
 		}
+		n = am.deliveries.track(receiver.Name, r, n, notifiers)
 		integrations = append(integrations, alertingNotify.NewIntegration(n, n, r.Type, i))
 	}
 	return integrations, nil
@@ -376,7 +384,7 @@ func (am *Alertmanager) buildReceiverIntegration(r *apimodels.PostableGrafanaRec
 			Err:      err,
 		}
 	}
-	receiverFactory, exists := alertingNotify.Factory(r.Type)
+	receiverFactory, exists := channels.Factory(r.Type)
 	if !exists {
 		return nil, InvalidReceiverError{
 			Receiver: r,
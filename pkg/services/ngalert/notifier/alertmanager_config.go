@@ -165,5 +165,12 @@ func (moa *MultiOrgAlertmanager) mergeProvenance(ctx context.Context, config def
 		config.AlertmanagerConfig.MuteTimeProvenances[key] = definitions.Provenance(provenance)
 	}
 
+	ir := definitions.InhibitionRules{}
+	irProv, err := moa.ProvStore.GetProvenance(ctx, &ir, org)
+	if err != nil {
+		return definitions.GettableUserConfig{}, err
+	}
+	config.AlertmanagerConfig.InhibitRulesProvenance = definitions.Provenance(irProv)
+
 	return config, nil
 }
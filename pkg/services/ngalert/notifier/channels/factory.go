@@ -0,0 +1,20 @@
+package channels
+
+import (
+	"strings"
+
+	alertingNotify "github.com/grafana/alerting/notify"
+	"github.com/grafana/alerting/receivers"
+)
+
+// Factory returns the factory of the notifiers of a type. The notifiers implemented by Grafana are looked up first,
+// then the notifiers of the alerting package.
+func Factory(receiverType string) (func(receivers.FactoryConfig) (alertingNotify.NotificationChannel, error), bool) {
+	switch strings.ToLower(receiverType) {
+	case WebhookSRVType:
+		return func(fc receivers.FactoryConfig) (alertingNotify.NotificationChannel, error) {
+			return NewWebhookSRVNotifier(fc)
+		}, true
+	}
+	return alertingNotify.Factory(receiverType)
+}
@@ -0,0 +1,292 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/webhook"
+)
+
+// WebhookSRVType is the type of the webhook notifiers sending the notifications to a fleet of endpoints
+const WebhookSRVType = "webhook-srv"
+
+const (
+	// webhookSRVPlaceholderHost is the host of the URL given to the webhook notifier, it is replaced by the host of
+	// an endpoint for each request
+	webhookSRVPlaceholderHost = "webhook-srv.invalid"
+	// defaultUnhealthyDuration is how long an endpoint is skipped after a failed request
+	defaultUnhealthyDuration = 30 * time.Second
+	// srvRecordTTL is how long the endpoints resolved from a SRV record are used
+	srvRecordTTL = 30 * time.Second
+)
+
+// WebhookSRVConfig is the configuration of the endpoints of a webhook-srv notifier. The other settings are the
+// settings of the webhook notifier.
+type WebhookSRVConfig struct {
+	// SRVRecord is the SRV record the endpoints are resolved from, such as _alerts._tcp.pipeline.internal
+	SRVRecord string
+	// Endpoints are the host:port of the endpoints when there is no SRV record
+	Endpoints []string
+	Scheme    string
+	Path      string
+	// UnhealthyDuration is how long an endpoint is skipped after a failed request
+	UnhealthyDuration time.Duration
+}
+
+func NewWebhookSRVConfig(jsonData json.RawMessage) (WebhookSRVConfig, error) {
+	rawSettings := struct {
+		SRVRecord         string `json:"srvRecord,omitempty" yaml:"srvRecord,omitempty"`
+		Endpoints         string `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`
+		Scheme            string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+		Path              string `json:"path,omitempty" yaml:"path,omitempty"`
+		UnhealthyDuration string `json:"unhealthyDuration,omitempty" yaml:"unhealthyDuration,omitempty"`
+	}{}
+	if err := json.Unmarshal(jsonData, &rawSettings); err != nil {
+		return WebhookSRVConfig{}, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+
+	settings := WebhookSRVConfig{
+		SRVRecord:         strings.TrimSpace(rawSettings.SRVRecord),
+		Scheme:            rawSettings.Scheme,
+		Path:              rawSettings.Path,
+		UnhealthyDuration: defaultUnhealthyDuration,
+	}
+	for _, endpoint := range strings.FieldsFunc(rawSettings.Endpoints, func(r rune) bool { return r == ',' || r == '\n' || r == ' ' }) {
+		if _, _, err := net.SplitHostPort(endpoint); err != nil {
+			return WebhookSRVConfig{}, fmt.Errorf("invalid endpoint '%s': %w", endpoint, err)
+		}
+		settings.Endpoints = append(settings.Endpoints, endpoint)
+	}
+	if settings.SRVRecord == "" && len(settings.Endpoints) == 0 {
+		return WebhookSRVConfig{}, errors.New("either 'srvRecord' or 'endpoints' must be specified")
+	}
+	if settings.SRVRecord != "" && len(settings.Endpoints) > 0 {
+		return WebhookSRVConfig{}, errors.New("only one of 'srvRecord' or 'endpoints' can be specified")
+	}
+
+	if settings.Scheme == "" {
+		settings.Scheme = "http"
+	}
+	if settings.Scheme != "http" && settings.Scheme != "https" {
+		return WebhookSRVConfig{}, fmt.Errorf("invalid scheme '%s': must be http or https", settings.Scheme)
+	}
+	if settings.Path != "" && !strings.HasPrefix(settings.Path, "/") {
+		settings.Path = "/" + settings.Path
+	}
+
+	if rawSettings.UnhealthyDuration != "" {
+		d, err := time.ParseDuration(rawSettings.UnhealthyDuration)
+		if err != nil || d < 0 {
+			return WebhookSRVConfig{}, fmt.Errorf("invalid unhealthy duration '%s'", rawSettings.UnhealthyDuration)
+		}
+		settings.UnhealthyDuration = d
+	}
+	return settings, nil
+}
+
+// WebhookSRVNotifier sends the notifications of the webhook notifier to the endpoints of a SRV record or of a list.
+// The notifications are load balanced across the healthy endpoints and fail over to the next endpoint when a request
+// fails.
+type WebhookSRVNotifier struct {
+	*webhook.Notifier
+}
+
+// NewWebhookSRVNotifier is the constructor of the webhook-srv notifier.
+func NewWebhookSRVNotifier(fc receivers.FactoryConfig) (*WebhookSRVNotifier, error) {
+	settings, err := NewWebhookSRVConfig(fc.Config.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// the webhook notifier sends the requests to a placeholder host, the sender sends them to the endpoints
+	var webhookSettings map[string]interface{}
+	if err := json.Unmarshal(fc.Config.Settings, &webhookSettings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	webhookSettings["url"] = settings.Scheme + "://" + webhookSRVPlaceholderHost + settings.Path
+	rawWebhookSettings, err := json.Marshal(webhookSettings)
+	if err != nil {
+		return nil, err
+	}
+	cfg := *fc.Config
+	cfg.Settings = rawWebhookSettings
+	fc.Config = &cfg
+	fc.NotificationService = &balancedSender{
+		NotificationSender: fc.NotificationService,
+		balancer:           newEndpointBalancer(settings, net.DefaultResolver.LookupSRV),
+		log:                fc.Logger,
+	}
+
+	n, err := webhook.New(fc)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookSRVNotifier{Notifier: n}, nil
+}
+
+// balancedSender sends the webhooks to the endpoints of a balancer, one endpoint after the other until a request succeeds
+type balancedSender struct {
+	receivers.NotificationSender
+	balancer *endpointBalancer
+	log      logging.Logger
+}
+
+func (s *balancedSender) SendWebhook(ctx context.Context, cmd *receivers.SendWebhookSettings) error {
+	u, err := url.Parse(cmd.URL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	endpoints, err := s.balancer.endpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		u.Host = endpoint
+		endpointCmd := *cmd
+		endpointCmd.URL = u.String()
+		if lastErr = s.NotificationSender.SendWebhook(ctx, &endpointCmd); lastErr != nil {
+			s.log.Warn("Failed to send the webhook to the endpoint, trying the next endpoint", "endpoint", endpoint, "error", lastErr)
+			s.balancer.markUnhealthy(endpoint)
+			continue
+		}
+		s.balancer.markHealthy(endpoint)
+		return nil
+	}
+	return fmt.Errorf("failed to send the webhook to any of the %d endpoints: %w", len(endpoints), lastErr)
+}
+
+type endpoint struct {
+	addr     string
+	priority uint16
+}
+
+type lookupSRVFunc func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+// endpointBalancer orders the endpoints of each request: the endpoints of the lowest SRV priority first, in turn for
+// each request, and the unhealthy endpoints last.
+type endpointBalancer struct {
+	settings  WebhookSRVConfig
+	lookupSRV lookupSRVFunc
+	now       func() time.Time
+
+	mtx            sync.Mutex
+	next           int
+	resolved       []endpoint
+	resolvedAt     time.Time
+	unhealthyUntil map[string]time.Time
+}
+
+func newEndpointBalancer(settings WebhookSRVConfig, lookupSRV lookupSRVFunc) *endpointBalancer {
+	b := &endpointBalancer{
+		settings:       settings,
+		lookupSRV:      lookupSRV,
+		now:            time.Now,
+		unhealthyUntil: map[string]time.Time{},
+	}
+	for _, addr := range settings.Endpoints {
+		b.resolved = append(b.resolved, endpoint{addr: addr})
+	}
+	return b
+}
+
+// resolve returns the endpoints of the SRV record, the endpoints are resolved again after srvRecordTTL. The last
+// resolved endpoints are used if the record can't be resolved.
+func (b *endpointBalancer) resolve(ctx context.Context) ([]endpoint, error) {
+	b.mtx.Lock()
+	resolved, resolvedAt := b.resolved, b.resolvedAt
+	b.mtx.Unlock()
+	if b.settings.SRVRecord == "" || (len(resolved) > 0 && b.now().Sub(resolvedAt) < srvRecordTTL) {
+		return resolved, nil
+	}
+
+	_, records, err := b.lookupSRV(ctx, "", "", b.settings.SRVRecord)
+	if err != nil || len(records) == 0 {
+		if len(resolved) > 0 {
+			return resolved, nil
+		}
+		if err == nil {
+			err = errors.New("no records")
+		}
+		return nil, fmt.Errorf("failed to resolve the SRV record '%s': %w", b.settings.SRVRecord, err)
+	}
+
+	resolved = make([]endpoint, 0, len(records))
+	for _, r := range records {
+		host := strings.TrimSuffix(r.Target, ".")
+		resolved = append(resolved, endpoint{addr: net.JoinHostPort(host, strconv.Itoa(int(r.Port))), priority: r.Priority})
+	}
+	b.mtx.Lock()
+	b.resolved, b.resolvedAt = resolved, b.now()
+	b.mtx.Unlock()
+	return resolved, nil
+}
+
+// endpoints returns the addresses of the endpoints in the order they are tried for a request
+func (b *endpointBalancer) endpoints(ctx context.Context) ([]string, error) {
+	resolved, err := b.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	now := b.now()
+	offset := b.next
+	b.next++
+
+	// the endpoints of the same priority are rotated so that each request starts with the next one
+	byPriority := make([]endpoint, len(resolved))
+	copy(byPriority, resolved)
+	sort.SliceStable(byPriority, func(i, j int) bool {
+		return byPriority[i].priority < byPriority[j].priority
+	})
+	ordered := make([]endpoint, 0, len(byPriority))
+	for start := 0; start < len(byPriority); {
+		end := start
+		for end < len(byPriority) && byPriority[end].priority == byPriority[start].priority {
+			end++
+		}
+		group := byPriority[start:end]
+		for i := range group {
+			ordered = append(ordered, group[(i+offset)%len(group)])
+		}
+		start = end
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return !now.Before(b.unhealthyUntil[ordered[i].addr]) && now.Before(b.unhealthyUntil[ordered[j].addr])
+	})
+
+	addrs := make([]string, 0, len(ordered))
+	for _, e := range ordered {
+		addrs = append(addrs, e.addr)
+	}
+	return addrs, nil
+}
+
+func (b *endpointBalancer) markUnhealthy(addr string) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.unhealthyUntil[addr] = b.now().Add(b.settings.UnhealthyDuration)
+}
+
+func (b *endpointBalancer) markHealthy(addr string) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	delete(b.unhealthyUntil, addr)
+}
@@ -0,0 +1,189 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhookSRVConfig(t *testing.T) {
+	cases := []struct {
+		name        string
+		settings    string
+		expected    WebhookSRVConfig
+		expectedErr string
+	}{
+		{
+			name:     "SRV record with defaults",
+			settings: `{"srvRecord": "_alerts._tcp.pipeline.internal"}`,
+			expected: WebhookSRVConfig{SRVRecord: "_alerts._tcp.pipeline.internal", Scheme: "http", UnhealthyDuration: defaultUnhealthyDuration},
+		},
+		{
+			name:     "list of endpoints",
+			settings: `{"endpoints": "pipeline-1:8080, pipeline-2:8080", "scheme": "https", "path": "alerts", "unhealthyDuration": "1m"}`,
+			expected: WebhookSRVConfig{Endpoints: []string{"pipeline-1:8080", "pipeline-2:8080"}, Scheme: "https", Path: "/alerts", UnhealthyDuration: time.Minute},
+		},
+		{
+			name:        "no endpoints",
+			settings:    `{}`,
+			expectedErr: "either 'srvRecord' or 'endpoints' must be specified",
+		},
+		{
+			name:        "SRV record and endpoints",
+			settings:    `{"srvRecord": "_alerts._tcp.pipeline.internal", "endpoints": "pipeline-1:8080"}`,
+			expectedErr: "only one of 'srvRecord' or 'endpoints' can be specified",
+		},
+		{
+			name:        "endpoint without port",
+			settings:    `{"endpoints": "pipeline-1"}`,
+			expectedErr: "invalid endpoint 'pipeline-1': address pipeline-1: missing port in address",
+		},
+		{
+			name:        "invalid scheme",
+			settings:    `{"endpoints": "pipeline-1:8080", "scheme": "ftp"}`,
+			expectedErr: "invalid scheme 'ftp': must be http or https",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := NewWebhookSRVConfig(json.RawMessage(c.settings))
+			if c.expectedErr != "" {
+				require.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestEndpointBalancer(t *testing.T) {
+	lookups := 0
+	lookupSRV := func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		lookups++
+		if lookups > 1 {
+			return "", nil, errors.New("server misbehaving")
+		}
+		return "", []*net.SRV{
+			{Target: "backup.pipeline.internal.", Port: 8080, Priority: 20},
+			{Target: "pipeline-1.pipeline.internal.", Port: 8080, Priority: 10},
+			{Target: "pipeline-2.pipeline.internal.", Port: 8080, Priority: 10},
+		}, nil
+	}
+
+	now := time.Now()
+	b := newEndpointBalancer(WebhookSRVConfig{SRVRecord: "_alerts._tcp.pipeline.internal", UnhealthyDuration: time.Minute}, lookupSRV)
+	b.now = func() time.Time { return now }
+
+	t.Run("the endpoints of the lowest priority are used in turn", func(t *testing.T) {
+		endpoints, err := b.endpoints(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pipeline-1.pipeline.internal:8080", "pipeline-2.pipeline.internal:8080", "backup.pipeline.internal:8080"}, endpoints)
+
+		endpoints, err = b.endpoints(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pipeline-2.pipeline.internal:8080", "pipeline-1.pipeline.internal:8080", "backup.pipeline.internal:8080"}, endpoints)
+	})
+
+	t.Run("the unhealthy endpoints are tried last until they are healthy again", func(t *testing.T) {
+		b.markUnhealthy("pipeline-1.pipeline.internal:8080")
+		b.markUnhealthy("pipeline-2.pipeline.internal:8080")
+		endpoints, err := b.endpoints(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "backup.pipeline.internal:8080", endpoints[0])
+
+		now = now.Add(time.Minute)
+		endpoints, err = b.endpoints(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "backup.pipeline.internal:8080", endpoints[2])
+	})
+
+	t.Run("the last endpoints are used when the record can't be resolved", func(t *testing.T) {
+		before := lookups
+		now = now.Add(srvRecordTTL)
+		endpoints, err := b.endpoints(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, endpoints, 3)
+		assert.Equal(t, before+1, lookups)
+	})
+}
+
+type failingWebhookSender struct {
+	receivers.NotificationSender
+	failing map[string]bool
+	sent    []string
+}
+
+func (s *failingWebhookSender) SendWebhook(ctx context.Context, cmd *receivers.SendWebhookSettings) error {
+	u, err := url.Parse(cmd.URL)
+	if err != nil {
+		return err
+	}
+	s.sent = append(s.sent, cmd.URL)
+	if s.failing[u.Host] {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestWebhookSRVNotifier(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+	sender := &failingWebhookSender{
+		NotificationSender: receivers.MockNotificationService(),
+		failing:            map[string]bool{"pipeline-1:8080": true},
+	}
+
+	fc, err := receivers.NewFactoryConfig(&receivers.NotificationChannelConfig{
+		Name:     "pipeline",
+		Type:     WebhookSRVType,
+		Settings: json.RawMessage(`{"endpoints": "pipeline-1:8080,pipeline-2:8080", "path": "/alerts"}`),
+	}, sender, func(ctx context.Context, sjd map[string][]byte, key string, fallback string) string {
+		return fallback
+	}, tmpl, &images.UnavailableImageStore{}, func(ctx ...interface{}) logging.Logger {
+		return &logging.FakeLogger{}
+	}, "")
+	require.NoError(t, err)
+
+	factory, ok := Factory(WebhookSRVType)
+	require.True(t, ok)
+	n, err := factory(fc)
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	_, err = n.Notify(ctx, alert)
+	require.NoError(t, err)
+	_, err = n.Notify(ctx, alert)
+	require.NoError(t, err)
+
+	// the first endpoint is skipped once it failed
+	assert.Equal(t, []string{
+		"http://pipeline-1:8080/alerts",
+		"http://pipeline-2:8080/alerts",
+		"http://pipeline-2:8080/alerts",
+	}, sender.sent)
+
+	sender.failing["pipeline-2:8080"] = true
+	_, err = n.Notify(ctx, alert)
+	require.EqualError(t, err, "failed to send the webhook to any of the 2 endpoints: connection refused")
+}
@@ -844,6 +844,127 @@ func GetAvailableNotifiers() []*NotifierPlugin {
 				},
 			},
 		},
+		{
+			Type:        "webhook-srv",
+			Name:        "Webhook fleet",
+			Description: "Sends HTTP POST requests to the endpoints of a SRV record or of a list, with load balancing and failover",
+			Heading:     "Webhook fleet settings",
+			Info:        "The notifications are sent to the healthy endpoints in turn. An endpoint that fails a request is skipped until the unhealthy duration has passed, and the request is sent to the next endpoint.",
+			Options: []NotifierOption{
+				{
+					Label:        "SRV record",
+					Description:  "The SRV record of the endpoints. The endpoints of the lowest priority are used first.",
+					Element:      ElementTypeInput,
+					InputType:    InputTypeText,
+					Placeholder:  "_alerts._tcp.pipeline.internal",
+					PropertyName: "srvRecord",
+				},
+				{
+					Label:        "Endpoints",
+					Description:  "The host:port of the endpoints, separated by commas, when there is no SRV record.",
+					Element:      ElementTypeTextArea,
+					Placeholder:  "pipeline-1:8080,pipeline-2:8080",
+					PropertyName: "endpoints",
+				},
+				{
+					Label:   "Scheme",
+					Element: ElementTypeSelect,
+					SelectOptions: []SelectOption{
+						{
+							Value: "http",
+							Label: "HTTP",
+						},
+						{
+							Value: "https",
+							Label: "HTTPS",
+						},
+					},
+					PropertyName: "scheme",
+				},
+				{
+					Label:        "Path",
+					Description:  "The path of the requests to the endpoints.",
+					Element:      ElementTypeInput,
+					InputType:    InputTypeText,
+					Placeholder:  "/alerts",
+					PropertyName: "path",
+				},
+				{
+					Label:        "Unhealthy duration",
+					Description:  "How long an endpoint is skipped after a failed request. Default is 30s.",
+					Element:      ElementTypeInput,
+					InputType:    InputTypeText,
+					Placeholder:  "30s",
+					PropertyName: "unhealthyDuration",
+				},
+				{
+					Label:   "HTTP Method",
+					Element: ElementTypeSelect,
+					SelectOptions: []SelectOption{
+						{
+							Value: "POST",
+							Label: "POST",
+						},
+						{
+							Value: "PUT",
+							Label: "PUT",
+						},
+					},
+					PropertyName: "httpMethod",
+				},
+				{
+					Label:        "HTTP Basic Authentication - Username",
+					Element:      ElementTypeInput,
+					InputType:    InputTypeText,
+					PropertyName: "username",
+				},
+				{
+					Label:        "HTTP Basic Authentication - Password",
+					Element:      ElementTypeInput,
+					InputType:    InputTypePassword,
+					PropertyName: "password",
+					Secure:       true,
+				},
+				{
+					Label:        "Authorization Header - Scheme",
+					Description:  "Optionally provide a scheme for the Authorization Request Header. Default is Bearer.",
+					Element:      ElementTypeInput,
+					InputType:    InputTypeText,
+					PropertyName: "authorization_scheme",
+					Placeholder:  "Bearer",
+				},
+				{
+					Label:        "Authorization Header - Credentials",
+					Description:  "Credentials for the Authorization Request header. Only one of HTTP Basic Authentication or Authorization Request Header can be set.",
+					Element:      ElementTypeInput,
+					InputType:    InputTypeText,
+					PropertyName: "authorization_credentials",
+					Secure:       true,
+				},
+				{
+					Label:        "Max Alerts",
+					Description:  "Max alerts to include in a notification. Remaining alerts in the same batch will be ignored above this number. 0 means no limit.",
+					Element:      ElementTypeInput,
+					InputType:    InputTypeText,
+					PropertyName: "maxAlerts",
+				},
+				{
+					Label:        "Title",
+					Description:  "Templated title of the message.",
+					Element:      ElementTypeInput,
+					InputType:    InputTypeText,
+					PropertyName: "title",
+					Placeholder:  alertingTemplates.DefaultMessageTitleEmbed,
+				},
+				{
+					Label:        "Message",
+					Description:  "Custom message. You can use template variables.",
+					Element:      ElementTypeTextArea,
+					PropertyName: "message",
+					Placeholder:  alertingTemplates.DefaultMessageEmbed,
+				},
+			},
+		},
 		{
 			Type:        "wecom",
 			Name:        "WeCom",
@@ -0,0 +1,311 @@
+package notifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	alertingNotify "github.com/grafana/alerting/notify"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const (
+	// deliveryRetryInterval is how often the retry queue sends the due notifications.
+	deliveryRetryInterval = 30 * time.Second
+	// deliveryRetryBatchSize is the maximum number of notifications the retry queue sends at once.
+	deliveryRetryBatchSize = 100
+	// deliveryRetryBaseDelay is the delay of the first retry of the queue. It is longer than the maximum interval
+	// between the attempts of the notification pipeline, so the queue retries after the pipeline gave up.
+	deliveryRetryBaseDelay = 2 * time.Minute
+	// deliveryRetryMaxDelay is the maximum delay between the retries of the queue.
+	deliveryRetryMaxDelay = time.Hour
+	// deliveryMaxRetries is the number of retries of the queue before a notification is dead-lettered.
+	deliveryMaxRetries = 8
+	// deliveryRetryTimeout is the timeout of the retries of the queue.
+	deliveryRetryTimeout = 30 * time.Second
+)
+
+var (
+	// ErrNotificationDeliveryNotDead is returned when replaying a notification that is not dead-lettered.
+	ErrNotificationDeliveryNotDead = errors.New("only the dead-lettered notifications can be replayed")
+
+	errContactPointNotFound = errors.New("the contact point no longer exists")
+)
+
+// deliveryTracker records the delivery attempts of the notifications of an organization, and retries the failed
+// notifications with the current contact points.
+type deliveryTracker struct {
+	orgID  int64
+	store  store.NotificationDeliveryStore
+	logger log.Logger
+
+	mtx       sync.RWMutex
+	notifiers map[string]*trackedNotifier
+}
+
+func newDeliveryTracker(orgID int64, store store.NotificationDeliveryStore, logger log.Logger) *deliveryTracker {
+	return &deliveryTracker{
+		orgID:     orgID,
+		store:     store,
+		logger:    logger.New("component", "deliveries"),
+		notifiers: map[string]*trackedNotifier{},
+	}
+}
+
+// trackedNotifier records the attempts of the notifier of a contact point.
+type trackedNotifier struct {
+	alertingNotify.NotificationChannel
+	tracker *deliveryTracker
+
+	receiver        string
+	integrationUID  string
+	integrationName string
+	integrationType string
+}
+
+// track wraps the notifier of the contact point to record its attempts, and adds it to the notifiers by UID. The
+// notifiers without UID are not tracked as they can't be retried.
+func (t *deliveryTracker) track(receiver string, r *apimodels.PostableGrafanaReceiver, n alertingNotify.NotificationChannel, notifiers map[string]*trackedNotifier) alertingNotify.NotificationChannel {
+	if r.UID == "" {
+		return n
+	}
+	tn := &trackedNotifier{
+		NotificationChannel: n,
+		tracker:             t,
+		receiver:            receiver,
+		integrationUID:      r.UID,
+		integrationName:     r.Name,
+		integrationType:     r.Type,
+	}
+	notifiers[r.UID] = tn
+	return tn
+}
+
+// setNotifiers replaces the notifiers the queue retries with, when the configuration is applied.
+func (t *deliveryTracker) setNotifiers(notifiers map[string]*trackedNotifier) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.notifiers = notifiers
+}
+
+func (t *deliveryTracker) notifier(uid string) *trackedNotifier {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	return t.notifiers[uid]
+}
+
+func (n *trackedNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	retry, err := n.NotificationChannel.Notify(ctx, alerts...)
+	n.tracker.recordAttempt(ctx, n, alerts, retry, err)
+	return retry, err
+}
+
+// recordAttempt records an attempt of the notification pipeline. The attempts of the same group and contact point
+// update the pending delivery, and a delivery is created for the first attempt.
+func (t *deliveryTracker) recordAttempt(ctx context.Context, n *trackedNotifier, alerts []*types.Alert, retry bool, err error) {
+	groupKey, _ := notify.GroupKey(ctx)
+	groupLabels, _ := notify.GroupLabels(ctx)
+	// the receipts are saved even though the notification context is done, such as when the last attempt timed out
+	storeCtx := context.Background()
+	key := deliveryKey(groupKey, n.integrationUID)
+
+	delivery, getErr := t.store.GetPendingNotificationDelivery(storeCtx, t.orgID, key)
+	if errors.Is(getErr, ngmodels.ErrNotificationDeliveryNotFound) {
+		delivery = &ngmodels.NotificationDelivery{
+			OrgID:           t.orgID,
+			DeliveryKey:     key,
+			Receiver:        n.receiver,
+			IntegrationUID:  n.integrationUID,
+			IntegrationName: n.integrationName,
+			IntegrationType: n.integrationType,
+			GroupKey:        groupKey,
+		}
+	} else if getErr != nil {
+		t.logger.Error("failed to get the pending notification delivery", "receiver", n.receiver, "integration", n.integrationUID, "error", getErr)
+		return
+	}
+
+	// the pending delivery holds the latest alerts of the group
+	serializedAlerts, marshalErr := json.Marshal(alerts)
+	if marshalErr != nil {
+		t.logger.Error("failed to serialize the alerts of the notification", "receiver", n.receiver, "error", marshalErr)
+		return
+	}
+	serializedLabels, marshalErr := json.Marshal(groupLabels)
+	if marshalErr != nil {
+		t.logger.Error("failed to serialize the group labels of the notification", "receiver", n.receiver, "error", marshalErr)
+		return
+	}
+	delivery.Alerts = string(serializedAlerts)
+	delivery.GroupLabels = string(serializedLabels)
+
+	applyDeliveryAttempt(delivery, store.TimeNow(), retry, err, false)
+	if saveErr := t.store.SaveNotificationDelivery(storeCtx, delivery); saveErr != nil {
+		t.logger.Error("failed to save the notification delivery", "receiver", n.receiver, "integration", n.integrationUID, "error", saveErr)
+	}
+}
+
+// retry sends the notification of the delivery again with the current notifier of its contact point.
+func (t *deliveryTracker) retry(ctx context.Context, delivery *ngmodels.NotificationDelivery) error {
+	now := store.TimeNow()
+	n := t.notifier(delivery.IntegrationUID)
+	if n == nil {
+		applyDeliveryAttempt(delivery, now, false, errContactPointNotFound, true)
+		return t.store.SaveNotificationDelivery(ctx, delivery)
+	}
+
+	var alerts []*types.Alert
+	if err := json.Unmarshal([]byte(delivery.Alerts), &alerts); err != nil {
+		applyDeliveryAttempt(delivery, now, false, fmt.Errorf("failed to decode the alerts: %w", err), true)
+		return t.store.SaveNotificationDelivery(ctx, delivery)
+	}
+	var groupLabels model.LabelSet
+	if err := json.Unmarshal([]byte(delivery.GroupLabels), &groupLabels); err != nil {
+		applyDeliveryAttempt(delivery, now, false, fmt.Errorf("failed to decode the group labels: %w", err), true)
+		return t.store.SaveNotificationDelivery(ctx, delivery)
+	}
+
+	notifyCtx, cancel := context.WithTimeout(ctx, deliveryRetryTimeout)
+	defer cancel()
+	notifyCtx = notify.WithGroupKey(notifyCtx, delivery.GroupKey)
+	notifyCtx = notify.WithReceiverName(notifyCtx, delivery.Receiver)
+	notifyCtx = notify.WithGroupLabels(notifyCtx, groupLabels)
+	notifyCtx = notify.WithNow(notifyCtx, now)
+
+	retry, err := n.NotificationChannel.Notify(notifyCtx, alerts...)
+	if err != nil {
+		t.logger.Warn("failed to retry the notification", "receiver", delivery.Receiver, "integration", delivery.IntegrationUID, "retries", delivery.Retries+1, "error", err)
+	}
+	applyDeliveryAttempt(delivery, now, retry, err, true)
+	return t.store.SaveNotificationDelivery(ctx, delivery)
+}
+
+// replay sends a dead-lettered notification again, and queues it for retries if it fails again.
+func (t *deliveryTracker) replay(ctx context.Context, id int64) (*ngmodels.NotificationDelivery, error) {
+	delivery, err := t.store.GetNotificationDelivery(ctx, t.orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.State != ngmodels.NotificationDeliveryDead {
+		return nil, ErrNotificationDeliveryNotDead
+	}
+	delivery.Retries = 0
+	if err := t.retry(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// GetNotificationDeliveries returns the receipts of the notifications of the organization matching the query.
+func (am *Alertmanager) GetNotificationDeliveries(ctx context.Context, query ngmodels.ListNotificationDeliveriesQuery) ([]*ngmodels.NotificationDelivery, error) {
+	query.OrgID = am.orgID
+	return am.deliveries.store.ListNotificationDeliveries(ctx, &query)
+}
+
+// ReplayNotificationDelivery sends the dead-lettered notification with the ID again. It returns
+// ErrNotificationDeliveryNotDead if the notification is not dead-lettered.
+func (am *Alertmanager) ReplayNotificationDelivery(ctx context.Context, id int64) (*ngmodels.NotificationDelivery, error) {
+	return am.deliveries.replay(ctx, id)
+}
+
+// applyDeliveryAttempt updates the delivery with the result of an attempt. The failed deliveries are retried with an
+// exponential backoff, and dead-lettered when the error is not retryable or the queue retried too many times.
+func applyDeliveryAttempt(delivery *ngmodels.NotificationDelivery, now time.Time, retry bool, err error, fromQueue bool) {
+	delivery.Attempts++
+	if fromQueue {
+		delivery.Retries++
+	}
+	if err == nil {
+		delivery.State = ngmodels.NotificationDeliveryDelivered
+		delivery.LastError = ""
+		delivery.NextAttemptAt = now
+		return
+	}
+	delivery.LastError = err.Error()
+	if !retry || delivery.Retries >= deliveryMaxRetries {
+		delivery.State = ngmodels.NotificationDeliveryDead
+		delivery.NextAttemptAt = now
+		return
+	}
+	delivery.State = ngmodels.NotificationDeliveryPending
+	delivery.NextAttemptAt = now.Add(deliveryRetryDelay(delivery.Retries))
+}
+
+// deliveryRetryDelay returns the delay before the next retry of a delivery retried the number of times.
+func deliveryRetryDelay(retries int64) time.Duration {
+	delay := deliveryRetryBaseDelay
+	for i := int64(0); i < retries && delay < deliveryRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > deliveryRetryMaxDelay {
+		return deliveryRetryMaxDelay
+	}
+	return delay
+}
+
+// deliveryKey identifies the notifications of a group of alerts to a contact point, the group keys are too long to
+// be indexed.
+func deliveryKey(groupKey, integrationUID string) string {
+	sum := sha256.Sum256([]byte(groupKey + "/" + integrationUID))
+	return hex.EncodeToString(sum[:])
+}
+
+// retryNotificationDeliveries retries the due notifications of all the organizations. In high availability mode,
+// only the first peer retries, as every peer would send the notifications again.
+func (moa *MultiOrgAlertmanager) retryNotificationDeliveries(ctx context.Context) {
+	if moa.peer.Position() != 0 {
+		return
+	}
+	deliveries, err := moa.configStore.GetDueNotificationDeliveries(ctx, store.TimeNow(), deliveryRetryBatchSize)
+	if err != nil {
+		moa.logger.Error("failed to get the notifications to retry", "error", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		am, err := moa.AlertmanagerFor(delivery.OrgID)
+		if err != nil {
+			// the notification is retried when the Alertmanager of the organization is ready
+			continue
+		}
+		if err := am.deliveries.retry(ctx, delivery); err != nil {
+			moa.logger.Error("failed to save the retried notification", "org", delivery.OrgID, "id", delivery.ID, "error", err)
+		}
+	}
+}
+
+// runNotificationDeliveries runs the retry queue of the failed notifications, and deletes the receipts of the
+// notifications after the retention of the notification log.
+func (moa *MultiOrgAlertmanager) runNotificationDeliveries(ctx context.Context) {
+	retryTicker := time.NewTicker(deliveryRetryInterval)
+	defer retryTicker.Stop()
+	cleanupTicker := time.NewTicker(notificationLogMaintenanceInterval)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-retryTicker.C:
+			moa.retryNotificationDeliveries(ctx)
+		case <-cleanupTicker.C:
+			n, err := moa.configStore.DeleteNotificationDeliveries(ctx, store.TimeNow().Add(-retentionNotificationsAndSilences))
+			if err != nil {
+				moa.logger.Error("failed to delete the old notification deliveries", "error", err)
+				continue
+			}
+			moa.logger.Debug("deleted the old notification deliveries", "count", n)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type fakeNotificationChannel struct {
+	retry  bool
+	err    error
+	alerts []*types.Alert
+}
+
+func (f *fakeNotificationChannel) Notify(_ context.Context, alerts ...*types.Alert) (bool, error) {
+	f.alerts = alerts
+	return f.retry, f.err
+}
+
+func (f *fakeNotificationChannel) SendResolved() bool {
+	return true
+}
+
+func TestDeliveryTracker(t *testing.T) {
+	ctx := notify.WithGroupKey(context.Background(), `{}:{alertname="test"}`)
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "test"})
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}}}
+
+	setup := func(t *testing.T) (*deliveryTracker, *fakeConfigStore, *fakeNotificationChannel, *trackedNotifier) {
+		configStore := NewFakeConfigStore(t, nil)
+		tracker := newDeliveryTracker(1, configStore, log.NewNopLogger())
+		channel := &fakeNotificationChannel{}
+		notifiers := map[string]*trackedNotifier{}
+		n := tracker.track("pager", &apimodels.PostableGrafanaReceiver{UID: "uid", Name: "pager", Type: "webhook"}, channel, notifiers)
+		tracker.setNotifiers(notifiers)
+		return tracker, configStore, channel, n.(*trackedNotifier)
+	}
+
+	t.Run("the attempts of a group update the pending delivery", func(t *testing.T) {
+		tracker, configStore, channel, n := setup(t)
+		channel.retry, channel.err = true, errors.New("connection refused")
+
+		_, err := n.Notify(ctx, alert)
+		require.Error(t, err)
+		_, err = n.Notify(ctx, alert)
+		require.Error(t, err)
+
+		deliveries, err := configStore.ListNotificationDeliveries(ctx, &ngmodels.ListNotificationDeliveriesQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, ngmodels.NotificationDeliveryPending, deliveries[0].State)
+		require.Equal(t, int64(2), deliveries[0].Attempts)
+		require.Equal(t, int64(0), deliveries[0].Retries)
+		require.Equal(t, "connection refused", deliveries[0].LastError)
+		require.Equal(t, "uid", deliveries[0].IntegrationUID)
+
+		// the retry queue sends the same alerts again
+		channel.err = nil
+		require.NoError(t, tracker.retry(context.Background(), deliveries[0]))
+		require.Len(t, channel.alerts, 1)
+		require.Equal(t, alert.Labels, channel.alerts[0].Labels)
+
+		result, err := configStore.GetNotificationDelivery(ctx, 1, deliveries[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, ngmodels.NotificationDeliveryDelivered, result.State)
+		require.Equal(t, int64(1), result.Retries)
+		require.Empty(t, result.LastError)
+	})
+
+	t.Run("the notifiers without UID are not tracked", func(t *testing.T) {
+		tracker := newDeliveryTracker(1, NewFakeConfigStore(t, nil), log.NewNopLogger())
+		channel := &fakeNotificationChannel{}
+		notifiers := map[string]*trackedNotifier{}
+		n := tracker.track("pager", &apimodels.PostableGrafanaReceiver{Name: "pager"}, channel, notifiers)
+		require.Equal(t, channel, n)
+		require.Empty(t, notifiers)
+	})
+
+	t.Run("the deliveries of removed contact points are dead-lettered", func(t *testing.T) {
+		tracker, configStore, channel, n := setup(t)
+		channel.retry, channel.err = true, errors.New("connection refused")
+		_, _ = n.Notify(ctx, alert)
+		tracker.setNotifiers(map[string]*trackedNotifier{})
+
+		deliveries, err := configStore.ListNotificationDeliveries(ctx, &ngmodels.ListNotificationDeliveriesQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.NoError(t, tracker.retry(context.Background(), deliveries[0]))
+		require.Equal(t, ngmodels.NotificationDeliveryDead, deliveries[0].State)
+		require.Equal(t, errContactPointNotFound.Error(), deliveries[0].LastError)
+	})
+
+	t.Run("only the dead-lettered deliveries are replayed", func(t *testing.T) {
+		tracker, configStore, channel, n := setup(t)
+		channel.retry, channel.err = false, errors.New("invalid token")
+		_, _ = n.Notify(ctx, alert)
+
+		deliveries, err := configStore.ListNotificationDeliveries(ctx, &ngmodels.ListNotificationDeliveriesQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, ngmodels.NotificationDeliveryDead, deliveries[0].State)
+
+		channel.err = nil
+		result, err := tracker.replay(context.Background(), deliveries[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, ngmodels.NotificationDeliveryDelivered, result.State)
+
+		_, err = tracker.replay(context.Background(), deliveries[0].ID)
+		require.ErrorIs(t, err, ErrNotificationDeliveryNotDead)
+		_, err = tracker.replay(context.Background(), 100)
+		require.ErrorIs(t, err, ngmodels.ErrNotificationDeliveryNotFound)
+	})
+}
+
+func TestApplyDeliveryAttempt(t *testing.T) {
+	now := time.Now()
+
+	t.Run("the failed deliveries are retried with a backoff", func(t *testing.T) {
+		delivery := &ngmodels.NotificationDelivery{}
+		applyDeliveryAttempt(delivery, now, true, errors.New("timeout"), false)
+		require.Equal(t, ngmodels.NotificationDeliveryPending, delivery.State)
+		require.Equal(t, now.Add(deliveryRetryBaseDelay), delivery.NextAttemptAt)
+
+		applyDeliveryAttempt(delivery, now, true, errors.New("timeout"), true)
+		require.Equal(t, ngmodels.NotificationDeliveryPending, delivery.State)
+		require.Equal(t, now.Add(2*deliveryRetryBaseDelay), delivery.NextAttemptAt)
+		require.Equal(t, int64(2), delivery.Attempts)
+		require.Equal(t, int64(1), delivery.Retries)
+	})
+
+	t.Run("the deliveries are dead-lettered after the last retry", func(t *testing.T) {
+		delivery := &ngmodels.NotificationDelivery{Retries: deliveryMaxRetries - 1}
+		applyDeliveryAttempt(delivery, now, true, errors.New("timeout"), true)
+		require.Equal(t, ngmodels.NotificationDeliveryDead, delivery.State)
+	})
+
+	t.Run("the deliveries failing with an error that is not retryable are dead-lettered", func(t *testing.T) {
+		delivery := &ngmodels.NotificationDelivery{}
+		applyDeliveryAttempt(delivery, now, false, errors.New("bad request"), false)
+		require.Equal(t, ngmodels.NotificationDeliveryDead, delivery.State)
+		require.Equal(t, "bad request", delivery.LastError)
+	})
+}
+
+func TestDeliveryRetryDelay(t *testing.T) {
+	require.Equal(t, deliveryRetryBaseDelay, deliveryRetryDelay(0))
+	require.Equal(t, 4*deliveryRetryBaseDelay, deliveryRetryDelay(2))
+	require.Equal(t, deliveryRetryMaxDelay, deliveryRetryDelay(deliveryMaxRetries))
+}
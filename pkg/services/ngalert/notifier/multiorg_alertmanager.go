@@ -114,6 +114,7 @@ func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgSto
 
 func (moa *MultiOrgAlertmanager) Run(ctx context.Context) error {
 	moa.logger.Info("starting MultiOrg Alertmanager")
+	go moa.runNotificationDeliveries(ctx)
 
 	for {
 		select {
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -19,6 +20,9 @@ type fakeConfigStore struct {
 
 	// appliedConfigs stores configs by orgID and config hash.
 	appliedConfigs map[int64]map[string]*models.AlertConfiguration
+
+	deliveriesMtx sync.Mutex
+	deliveries    []*models.NotificationDelivery
 }
 
 // Saves the image or returns an error.
@@ -34,6 +38,90 @@ func (f *fakeConfigStore) GetImages(ctx context.Context, tokens []string) ([]mod
 	return nil, nil, models.ErrImageNotFound
 }
 
+func (f *fakeConfigStore) GetNotificationDelivery(_ context.Context, orgID int64, id int64) (*models.NotificationDelivery, error) {
+	f.deliveriesMtx.Lock()
+	defer f.deliveriesMtx.Unlock()
+	for _, d := range f.deliveries {
+		if d.OrgID == orgID && d.ID == id {
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, models.ErrNotificationDeliveryNotFound
+}
+
+func (f *fakeConfigStore) GetPendingNotificationDelivery(_ context.Context, orgID int64, key string) (*models.NotificationDelivery, error) {
+	f.deliveriesMtx.Lock()
+	defer f.deliveriesMtx.Unlock()
+	for _, d := range f.deliveries {
+		if d.OrgID == orgID && d.DeliveryKey == key && d.State == models.NotificationDeliveryPending {
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, models.ErrNotificationDeliveryNotFound
+}
+
+func (f *fakeConfigStore) ListNotificationDeliveries(_ context.Context, query *models.ListNotificationDeliveriesQuery) ([]*models.NotificationDelivery, error) {
+	f.deliveriesMtx.Lock()
+	defer f.deliveriesMtx.Unlock()
+	var result []*models.NotificationDelivery
+	for i := len(f.deliveries) - 1; i >= 0; i-- {
+		d := f.deliveries[i]
+		if d.OrgID != query.OrgID || (query.Receiver != "" && d.Receiver != query.Receiver) ||
+			(query.IntegrationUID != "" && d.IntegrationUID != query.IntegrationUID) {
+			continue
+		}
+		if len(query.States) > 0 {
+			found := false
+			for _, state := range query.States {
+				found = found || d.State == state
+			}
+			if !found {
+				continue
+			}
+		}
+		c := *d
+		result = append(result, &c)
+	}
+	return result, nil
+}
+
+func (f *fakeConfigStore) GetDueNotificationDeliveries(_ context.Context, now time.Time, limit int) ([]*models.NotificationDelivery, error) {
+	f.deliveriesMtx.Lock()
+	defer f.deliveriesMtx.Unlock()
+	var result []*models.NotificationDelivery
+	for _, d := range f.deliveries {
+		if d.State == models.NotificationDeliveryPending && !d.NextAttemptAt.After(now) && len(result) < limit {
+			c := *d
+			result = append(result, &c)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeConfigStore) SaveNotificationDelivery(_ context.Context, delivery *models.NotificationDelivery) error {
+	f.deliveriesMtx.Lock()
+	defer f.deliveriesMtx.Unlock()
+	c := *delivery
+	if delivery.ID == 0 {
+		delivery.ID = int64(len(f.deliveries) + 1)
+		c.ID = delivery.ID
+		f.deliveries = append(f.deliveries, &c)
+		return nil
+	}
+	for i, d := range f.deliveries {
+		if d.ID == delivery.ID {
+			f.deliveries[i] = &c
+		}
+	}
+	return nil
+}
+
+func (f *fakeConfigStore) DeleteNotificationDeliveries(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
 func NewFakeConfigStore(t *testing.T, configs map[int64]*models.AlertConfiguration) *fakeConfigStore {
 	t.Helper()
 
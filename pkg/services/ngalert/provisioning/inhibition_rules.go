@@ -0,0 +1,91 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/config"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type InhibitionRuleService struct {
+	config AMConfigStore
+	prov   ProvisioningStore
+	xact   TransactionManager
+	log    log.Logger
+}
+
+func NewInhibitionRuleService(config AMConfigStore, prov ProvisioningStore, xact TransactionManager, log log.Logger) *InhibitionRuleService {
+	return &InhibitionRuleService{
+		config: config,
+		prov:   prov,
+		xact:   xact,
+		log:    log,
+	}
+}
+
+// GetInhibitionRules returns the inhibition rules within the specified org.
+func (svc *InhibitionRuleService) GetInhibitionRules(ctx context.Context, orgID int64) (definitions.InhibitionRules, error) {
+	rev, err := getLastConfiguration(ctx, orgID, svc.config)
+	if err != nil {
+		return definitions.InhibitionRules{}, err
+	}
+
+	result := definitions.InhibitionRules{Rules: rev.cfg.AlertmanagerConfig.InhibitRules}
+	if result.Rules == nil {
+		result.Rules = []config.InhibitRule{}
+	}
+	provenance, err := svc.prov.GetProvenance(ctx, &result, orgID)
+	if err != nil {
+		return definitions.InhibitionRules{}, err
+	}
+	result.Provenance = definitions.Provenance(provenance)
+	return result, nil
+}
+
+// UpdateInhibitionRules replaces the inhibition rules within the specified org.
+func (svc *InhibitionRuleService) UpdateInhibitionRules(ctx context.Context, orgID int64, rules definitions.InhibitionRules, p models.Provenance) error {
+	if err := rules.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+	return svc.saveInhibitionRules(ctx, orgID, rules, func(ctx context.Context) error {
+		return svc.prov.SetProvenance(ctx, &rules, orgID, p)
+	})
+}
+
+// ResetInhibitionRules deletes all the inhibition rules within the specified org.
+func (svc *InhibitionRuleService) ResetInhibitionRules(ctx context.Context, orgID int64) error {
+	rules := definitions.InhibitionRules{}
+	return svc.saveInhibitionRules(ctx, orgID, rules, func(ctx context.Context) error {
+		return svc.prov.DeleteProvenance(ctx, &rules, orgID)
+	})
+}
+
+func (svc *InhibitionRuleService) saveInhibitionRules(ctx context.Context, orgID int64, rules definitions.InhibitionRules, saveProvenance func(ctx context.Context) error) error {
+	revision, err := getLastConfiguration(ctx, orgID, svc.config)
+	if err != nil {
+		return err
+	}
+	revision.cfg.AlertmanagerConfig.InhibitRules = rules.Rules
+
+	serialized, err := serializeAlertmanagerConfig(*revision.cfg)
+	if err != nil {
+		return err
+	}
+	cmd := models.SaveAlertmanagerConfigurationCmd{
+		AlertmanagerConfiguration: string(serialized),
+		ConfigurationVersion:      revision.version,
+		FetchedConfigurationHash:  revision.concurrencyToken,
+		Default:                   false,
+		OrgID:                     orgID,
+	}
+	return svc.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := PersistConfig(ctx, svc.config, &cmd); err != nil {
+			return err
+		}
+		return saveProvenance(ctx)
+	})
+}
@@ -0,0 +1,201 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/common/model"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestInhibitionRuleService(t *testing.T) {
+	t.Run("service returns rules from config file", func(t *testing.T) {
+		sut := createInhibitionRuleSvcSut()
+		sut.config.(*MockAMConfigStore).EXPECT().
+			GetsConfig(models.AlertConfiguration{
+				AlertmanagerConfiguration: configWithInhibitionRules,
+			})
+		sut.prov.(*MockProvisioningStore).EXPECT().GetReturns(models.ProvenanceAPI)
+
+		result, err := sut.GetInhibitionRules(context.Background(), 1)
+
+		require.NoError(t, err)
+		require.Len(t, result.Rules, 1)
+		require.Equal(t, map[string]string{"alertname": "DatacenterDown"}, result.Rules[0].SourceMatch)
+		require.Equal(t, definitions.Provenance(models.ProvenanceAPI), result.Provenance)
+	})
+
+	t.Run("service returns empty list when config file contains no rules", func(t *testing.T) {
+		sut := createInhibitionRuleSvcSut()
+		sut.config.(*MockAMConfigStore).EXPECT().
+			GetsConfig(models.AlertConfiguration{
+				AlertmanagerConfiguration: defaultConfig,
+			})
+		sut.prov.(*MockProvisioningStore).EXPECT().GetReturns(models.ProvenanceNone)
+
+		result, err := sut.GetInhibitionRules(context.Background(), 1)
+
+		require.NoError(t, err)
+		require.NotNil(t, result.Rules)
+		require.Empty(t, result.Rules)
+	})
+
+	t.Run("updating rules", func(t *testing.T) {
+		t.Run("rejects rules that fail validation", func(t *testing.T) {
+			sut := createInhibitionRuleSvcSut()
+			rules := definitions.InhibitionRules{Rules: []config.InhibitRule{{SourceMatch: map[string]string{"alertname": "DatacenterDown"}}}}
+
+			err := sut.UpdateInhibitionRules(context.Background(), 1, rules, models.ProvenanceAPI)
+
+			require.ErrorIs(t, err, ErrValidation)
+		})
+
+		t.Run("replaces the rules of the config and sets the provenance", func(t *testing.T) {
+			sut := createInhibitionRuleSvcSut()
+			sut.config.(*MockAMConfigStore).EXPECT().
+				GetsConfig(models.AlertConfiguration{
+					AlertmanagerConfiguration: configWithInhibitionRules,
+				})
+			var saved models.SaveAlertmanagerConfigurationCmd
+			sut.config.(*MockAMConfigStore).EXPECT().SaveSucceedsIntercept(&saved)
+			sut.prov.(*MockProvisioningStore).EXPECT().
+				SetProvenance(mock.Anything, mock.Anything, int64(1), models.ProvenanceAPI).
+				Return(nil)
+			rules := createInhibitionRules()
+
+			err := sut.UpdateInhibitionRules(context.Background(), 1, rules, models.ProvenanceAPI)
+
+			require.NoError(t, err)
+			cfg, err := deserializeAlertmanagerConfig([]byte(saved.AlertmanagerConfiguration))
+			require.NoError(t, err)
+			require.Len(t, cfg.AlertmanagerConfig.InhibitRules, 1)
+			require.Equal(t, map[string]string{"severity": "warning"}, cfg.AlertmanagerConfig.InhibitRules[0].TargetMatch)
+		})
+
+		t.Run("propagates errors", func(t *testing.T) {
+			t.Run("when unable to read config", func(t *testing.T) {
+				sut := createInhibitionRuleSvcSut()
+				sut.config.(*MockAMConfigStore).EXPECT().
+					GetLatestAlertmanagerConfiguration(mock.Anything, mock.Anything).
+					Return(fmt.Errorf("failed"))
+
+				err := sut.UpdateInhibitionRules(context.Background(), 1, createInhibitionRules(), models.ProvenanceAPI)
+
+				require.Error(t, err)
+			})
+
+			t.Run("when provenance fails to save", func(t *testing.T) {
+				sut := createInhibitionRuleSvcSut()
+				sut.config.(*MockAMConfigStore).EXPECT().
+					GetsConfig(models.AlertConfiguration{
+						AlertmanagerConfiguration: configWithInhibitionRules,
+					})
+				sut.config.(*MockAMConfigStore).EXPECT().SaveSucceeds()
+				sut.prov.(*MockProvisioningStore).EXPECT().
+					SetProvenance(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(fmt.Errorf("failed to save provenance"))
+
+				err := sut.UpdateInhibitionRules(context.Background(), 1, createInhibitionRules(), models.ProvenanceAPI)
+
+				require.ErrorContains(t, err, "failed to save provenance")
+			})
+
+			t.Run("when AM config fails to save", func(t *testing.T) {
+				sut := createInhibitionRuleSvcSut()
+				sut.config.(*MockAMConfigStore).EXPECT().
+					GetsConfig(models.AlertConfiguration{
+						AlertmanagerConfiguration: configWithInhibitionRules,
+					})
+				sut.config.(*MockAMConfigStore).EXPECT().
+					UpdateAlertmanagerConfiguration(mock.Anything, mock.Anything).
+					Return(fmt.Errorf("failed to save config"))
+				sut.prov.(*MockProvisioningStore).EXPECT().SaveSucceeds()
+
+				err := sut.UpdateInhibitionRules(context.Background(), 1, createInhibitionRules(), models.ProvenanceAPI)
+
+				require.ErrorContains(t, err, "failed to save config")
+			})
+		})
+	})
+
+	t.Run("resetting rules", func(t *testing.T) {
+		t.Run("removes the rules of the config and the provenance", func(t *testing.T) {
+			sut := createInhibitionRuleSvcSut()
+			sut.config.(*MockAMConfigStore).EXPECT().
+				GetsConfig(models.AlertConfiguration{
+					AlertmanagerConfiguration: configWithInhibitionRules,
+				})
+			var saved models.SaveAlertmanagerConfigurationCmd
+			sut.config.(*MockAMConfigStore).EXPECT().SaveSucceedsIntercept(&saved)
+			sut.prov.(*MockProvisioningStore).EXPECT().
+				DeleteProvenance(mock.Anything, mock.Anything, int64(1)).
+				Return(nil)
+
+			err := sut.ResetInhibitionRules(context.Background(), 1)
+
+			require.NoError(t, err)
+			cfg, err := deserializeAlertmanagerConfig([]byte(saved.AlertmanagerConfiguration))
+			require.NoError(t, err)
+			require.Empty(t, cfg.AlertmanagerConfig.InhibitRules)
+		})
+	})
+}
+
+func createInhibitionRuleSvcSut() *InhibitionRuleService {
+	return &InhibitionRuleService{
+		config: &MockAMConfigStore{},
+		prov:   &MockProvisioningStore{},
+		xact:   newNopTransactionManager(),
+		log:    log.NewNopLogger(),
+	}
+}
+
+func createInhibitionRules() definitions.InhibitionRules {
+	return definitions.InhibitionRules{
+		Rules: []config.InhibitRule{
+			{
+				SourceMatch: map[string]string{"alertname": "DatacenterDown"},
+				TargetMatch: map[string]string{"severity": "warning"},
+				Equal:       []model.LabelName{"datacenter"},
+			},
+		},
+	}
+}
+
+var configWithInhibitionRules = `
+{
+	"alertmanager_config": {
+		"route": {
+			"receiver": "grafana-default-email"
+		},
+		"inhibit_rules": [{
+			"source_match": {
+				"alertname": "DatacenterDown"
+			},
+			"target_match": {
+				"severity": "critical"
+			},
+			"equal": ["datacenter"]
+		}],
+		"receivers": [{
+			"name": "grafana-default-email",
+			"grafana_managed_receiver_configs": [{
+				"uid": "",
+				"name": "email receiver",
+				"type": "email",
+				"isDefault": true,
+				"settings": {
+					"addresses": "<example@email.com>"
+				}
+			}]
+		}]
+	}
+}
+`
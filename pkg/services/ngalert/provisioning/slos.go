@@ -0,0 +1,356 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// sloKVNamespace is the namespace of the SLOs in the key-value store, the keys are the UIDs of the SLOs
+	sloKVNamespace = "alerting.slos"
+	// defaultSLOWindow is the window of the SLOs without window
+	defaultSLOWindow = model.Duration(30 * 24 * time.Hour)
+	// sloRangeVariable is the variable of the queries of the SLOs replaced by the window of the burn rate
+	sloRangeVariable = "$__range"
+)
+
+// sloBurnRateWindow is an alert rule of the SLOs, firing when the error budget burns too fast over both windows: the
+// long window catches the significant burns and the short window resets the alert as soon as the burn stops
+type sloBurnRateWindow struct {
+	long     time.Duration
+	short    time.Duration
+	budget   float64
+	severity string
+}
+
+// sloBurnRateWindows are the multi-window, multi-burn-rate alerts of the Google SRE workbook: 2% of the budget of the
+// SLO spent in 1 hour or 5% in 6 hours pages, 10% in 1 or 3 days opens a ticket
+var sloBurnRateWindows = []sloBurnRateWindow{
+	{long: time.Hour, short: 5 * time.Minute, budget: 0.02, severity: "page"},
+	{long: 6 * time.Hour, short: 30 * time.Minute, budget: 0.05, severity: "page"},
+	{long: 24 * time.Hour, short: 2 * time.Hour, budget: 0.1, severity: "ticket"},
+	{long: 72 * time.Hour, short: 6 * time.Hour, budget: 0.1, severity: "ticket"},
+}
+
+// SLOService manages the SLOs and keeps their alert rules in sync with them. An SLO owns the rule group of its folder
+// named after its title.
+type SLOService struct {
+	kv    kvstore.KVStore
+	rules *AlertRuleService
+	prov  ProvisioningStore
+	xact  TransactionManager
+	log   log.Logger
+}
+
+func NewSLOService(kv kvstore.KVStore, rules *AlertRuleService, prov ProvisioningStore, xact TransactionManager, log log.Logger) *SLOService {
+	return &SLOService{
+		kv:    kv,
+		rules: rules,
+		prov:  prov,
+		xact:  xact,
+		log:   log,
+	}
+}
+
+// GetSLOs returns the SLOs within the specified org, sorted by title.
+func (svc *SLOService) GetSLOs(ctx context.Context, orgID int64) (definitions.SLOs, error) {
+	items, err := kvstore.WithNamespace(svc.kv, orgID, sloKVNamespace).GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provenances, err := svc.prov.GetProvenances(ctx, orgID, (&definitions.SLO{}).ResourceType())
+	if err != nil {
+		return nil, err
+	}
+
+	result := definitions.SLOs{}
+	for _, value := range items[orgID] {
+		slo, err := decodeSLO(value)
+		if err != nil {
+			return nil, err
+		}
+		slo.Provenance = definitions.Provenance(provenances[slo.UID])
+		result = append(result, slo)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Title < result[j].Title
+	})
+	return result, nil
+}
+
+// GetSLO returns the SLO with the UID within the specified org.
+func (svc *SLOService) GetSLO(ctx context.Context, orgID int64, uid string) (definitions.SLO, error) {
+	value, ok, err := kvstore.WithNamespace(svc.kv, orgID, sloKVNamespace).Get(ctx, uid)
+	if err != nil {
+		return definitions.SLO{}, err
+	}
+	if !ok {
+		return definitions.SLO{}, fmt.Errorf("%w: SLO %s", ErrNotFound, uid)
+	}
+	slo, err := decodeSLO(value)
+	if err != nil {
+		return definitions.SLO{}, err
+	}
+	provenance, err := svc.prov.GetProvenance(ctx, &slo, orgID)
+	if err != nil {
+		return definitions.SLO{}, err
+	}
+	slo.Provenance = definitions.Provenance(provenance)
+	return slo, nil
+}
+
+// CreateSLO creates the SLO and its alert rules, the rule group of the SLO must not exist.
+func (svc *SLOService) CreateSLO(ctx context.Context, orgID int64, slo definitions.SLO, p models.Provenance, userID int64) (definitions.SLO, error) {
+	if slo.UID == "" {
+		slo.UID = util.GenerateShortUID()
+	}
+	if _, err := svc.GetSLO(ctx, orgID, slo.UID); err == nil {
+		return definitions.SLO{}, fmt.Errorf("%w: SLO %s already exists", ErrValidation, slo.UID)
+	} else if !errors.Is(err, ErrNotFound) {
+		return definitions.SLO{}, err
+	}
+	return svc.saveSLO(ctx, orgID, slo, nil, p, userID)
+}
+
+// UpdateSLO replaces the SLO and updates its alert rules.
+func (svc *SLOService) UpdateSLO(ctx context.Context, orgID int64, slo definitions.SLO, p models.Provenance, userID int64) (definitions.SLO, error) {
+	previous, err := svc.GetSLO(ctx, orgID, slo.UID)
+	if err != nil {
+		return definitions.SLO{}, err
+	}
+	if err := checkSLOProvenance(previous, p); err != nil {
+		return definitions.SLO{}, err
+	}
+	return svc.saveSLO(ctx, orgID, slo, &previous, p, userID)
+}
+
+// DeleteSLO deletes the SLO and its alert rules.
+func (svc *SLOService) DeleteSLO(ctx context.Context, orgID int64, uid string, p models.Provenance, userID int64) error {
+	slo, err := svc.GetSLO(ctx, orgID, uid)
+	if err != nil {
+		return err
+	}
+	if err := checkSLOProvenance(slo, p); err != nil {
+		return err
+	}
+	return svc.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := svc.deleteRuleGroup(ctx, orgID, slo, userID, p); err != nil {
+			return err
+		}
+		if err := kvstore.WithNamespace(svc.kv, orgID, sloKVNamespace).Del(ctx, uid); err != nil {
+			return err
+		}
+		return svc.prov.DeleteProvenance(ctx, &slo, orgID)
+	})
+}
+
+func (svc *SLOService) saveSLO(ctx context.Context, orgID int64, slo definitions.SLO, previous *definitions.SLO, p models.Provenance, userID int64) (definitions.SLO, error) {
+	if slo.Window == 0 {
+		slo.Window = defaultSLOWindow
+	}
+	if err := validateSLO(slo); err != nil {
+		return definitions.SLO{}, fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+
+	// the rules of the previous version of the SLO are updated, they are moved when the rule group changes
+	var ruleUIDs []string
+	moved := previous == nil || previous.FolderUID != slo.FolderUID || previous.Title != slo.Title
+	if previous != nil {
+		group, err := svc.rules.GetRuleGroup(ctx, orgID, previous.FolderUID, previous.Title)
+		if err != nil && !errors.Is(err, store.ErrAlertRuleGroupNotFound) {
+			return definitions.SLO{}, err
+		}
+		sort.Slice(group.Rules, func(i, j int) bool {
+			return group.Rules[i].RuleGroupIndex < group.Rules[j].RuleGroupIndex
+		})
+		for _, rule := range group.Rules {
+			ruleUIDs = append(ruleUIDs, rule.UID)
+		}
+	}
+	if moved {
+		_, err := svc.rules.GetRuleGroup(ctx, orgID, slo.FolderUID, slo.Title)
+		if err == nil {
+			return definitions.SLO{}, fmt.Errorf("%w: the rule group %s already exists in the folder", ErrValidation, slo.Title)
+		}
+		if !errors.Is(err, store.ErrAlertRuleGroupNotFound) {
+			return definitions.SLO{}, err
+		}
+	}
+
+	slo.Provenance = ""
+	serialized, err := json.Marshal(slo)
+	if err != nil {
+		return definitions.SLO{}, err
+	}
+	group := sloRuleGroup(slo, ruleUIDs, svc.rules.defaultIntervalSeconds)
+	err = svc.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := svc.rules.ReplaceRuleGroup(ctx, orgID, group, userID, p); err != nil {
+			return err
+		}
+		// the rules left in the previous rule group are deleted
+		if previous != nil && moved {
+			if err := svc.deleteRuleGroup(ctx, orgID, *previous, userID, p); err != nil {
+				return err
+			}
+		}
+		if err := kvstore.WithNamespace(svc.kv, orgID, sloKVNamespace).Set(ctx, slo.UID, string(serialized)); err != nil {
+			return err
+		}
+		return svc.prov.SetProvenance(ctx, &slo, orgID, p)
+	})
+	if err != nil {
+		return definitions.SLO{}, err
+	}
+	slo.Provenance = definitions.Provenance(p)
+	return slo, nil
+}
+
+func (svc *SLOService) deleteRuleGroup(ctx context.Context, orgID int64, slo definitions.SLO, userID int64, p models.Provenance) error {
+	return svc.rules.ReplaceRuleGroup(ctx, orgID, models.AlertRuleGroup{
+		Title:     slo.Title,
+		FolderUID: slo.FolderUID,
+		Interval:  svc.rules.defaultIntervalSeconds,
+		Rules:     []models.AlertRule{},
+	}, userID, p)
+}
+
+func checkSLOProvenance(stored definitions.SLO, p models.Provenance) error {
+	storedProvenance := models.Provenance(stored.Provenance)
+	if storedProvenance != p && storedProvenance != models.ProvenanceNone {
+		return fmt.Errorf("cannot change provenance from '%s' to '%s'", storedProvenance, p)
+	}
+	return nil
+}
+
+func decodeSLO(value string) (definitions.SLO, error) {
+	var slo definitions.SLO
+	if err := json.Unmarshal([]byte(value), &slo); err != nil {
+		return definitions.SLO{}, fmt.Errorf("failed to decode SLO: %w", err)
+	}
+	return slo, nil
+}
+
+func validateSLO(slo definitions.SLO) error {
+	if !util.IsValidShortUID(slo.UID) {
+		return fmt.Errorf("invalid UID %q", slo.UID)
+	}
+	if strings.TrimSpace(slo.Title) == "" {
+		return errors.New("the title is required")
+	}
+	if slo.FolderUID == "" {
+		return errors.New("the folder is required")
+	}
+	if slo.DatasourceUID == "" {
+		return errors.New("the data source is required")
+	}
+	for name, query := range map[string]string{"error": slo.ErrorQuery, "total": slo.TotalQuery} {
+		if !strings.Contains(query, sloRangeVariable) {
+			return fmt.Errorf("the %s query must use %s as the window of the burn rate", name, sloRangeVariable)
+		}
+	}
+	if slo.Objective <= 0 || slo.Objective >= 100 {
+		return fmt.Errorf("the objective must be between 0 and 100 percent, got %v", slo.Objective)
+	}
+	if time.Duration(slo.Window) < sloBurnRateWindows[0].long {
+		return fmt.Errorf("the window must be at least %s", model.Duration(sloBurnRateWindows[0].long))
+	}
+	return nil
+}
+
+// sloRuleGroup returns the rule group of the SLO, with a rule for each burn rate window shorter than the window of the
+// SLO. The rules reuse the UIDs of the rules of the previous version of the SLO, in order.
+func sloRuleGroup(slo definitions.SLO, ruleUIDs []string, intervalSeconds int64) models.AlertRuleGroup {
+	group := models.AlertRuleGroup{
+		Title:     slo.Title,
+		FolderUID: slo.FolderUID,
+		Interval:  intervalSeconds,
+		Rules:     []models.AlertRule{},
+	}
+	budget := 1 - slo.Objective/100
+	for _, w := range sloBurnRateWindows {
+		if w.long > time.Duration(slo.Window) {
+			continue
+		}
+		burnRate := w.budget * float64(slo.Window) / float64(w.long)
+		threshold := strconv.FormatFloat(burnRate*budget, 'g', 6, 64)
+
+		labels := make(map[string]string, len(slo.Labels)+2)
+		for k, v := range slo.Labels {
+			labels[k] = v
+		}
+		labels["slo"] = slo.Title
+		labels["severity"] = w.severity
+
+		rule := models.AlertRule{
+			Title:     fmt.Sprintf("%s: burn rate over %s", slo.Title, model.Duration(w.long)),
+			Condition: "E",
+			Data: []models.AlertQuery{
+				sloQuery("A", slo.DatasourceUID, slo.ErrorQuery, w.long),
+				sloQuery("B", slo.DatasourceUID, slo.TotalQuery, w.long),
+				sloQuery("C", slo.DatasourceUID, slo.ErrorQuery, w.short),
+				sloQuery("D", slo.DatasourceUID, slo.TotalQuery, w.short),
+				sloMathExpression("E", fmt.Sprintf("$A / $B > %[1]s && $C / $D > %[1]s", threshold)),
+			},
+			RuleGroupIndex: len(group.Rules) + 1,
+			NoDataState:    models.OK,
+			ExecErrState:   models.ErrorErrState,
+			Labels:         labels,
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("The error budget of the %s SLO burns %s times faster than allowed over the last %s",
+					slo.Title, strconv.FormatFloat(burnRate, 'g', 3, 64), model.Duration(w.long)),
+			},
+		}
+		if len(group.Rules) < len(ruleUIDs) {
+			rule.UID = ruleUIDs[len(group.Rules)]
+		}
+		group.Rules = append(group.Rules, rule)
+	}
+	return group
+}
+
+// sloQuery returns the instant query of the data source over the window
+func sloQuery(refID, datasourceUID, query string, window time.Duration) models.AlertQuery {
+	m, _ := json.Marshal(map[string]interface{}{
+		"refId":   refID,
+		"expr":    query,
+		"instant": true,
+		"range":   false,
+	})
+	return models.AlertQuery{
+		RefID:             refID,
+		DatasourceUID:     datasourceUID,
+		RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(window)},
+		Model:             m,
+	}
+}
+
+func sloMathExpression(refID, expression string) models.AlertQuery {
+	m, _ := json.Marshal(map[string]interface{}{
+		"refId":      refID,
+		"type":       "math",
+		"expression": expression,
+		"datasource": map[string]string{"type": expr.DatasourceType, "uid": expr.DatasourceUID},
+	})
+	return models.AlertQuery{
+		RefID:         refID,
+		QueryType:     expr.DatasourceType,
+		DatasourceUID: expr.DatasourceUID,
+		Model:         m,
+	}
+}
@@ -0,0 +1,159 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestSLOService(t *testing.T) {
+	ruleService := createAlertRuleService(t)
+	sut := NewSLOService(kvstore.NewFakeKVStore(), &ruleService, ruleService.provenanceStore, ruleService.xact, log.NewNopLogger())
+	ctx := context.Background()
+	var orgID int64 = 1
+
+	t.Run("creating an SLO generates its burn rate alert rules", func(t *testing.T) {
+		slo, err := sut.CreateSLO(ctx, orgID, createTestSLO("Checkout availability"), models.ProvenanceAPI, 0)
+		require.NoError(t, err)
+		require.NotEmpty(t, slo.UID)
+		require.Equal(t, defaultSLOWindow, slo.Window)
+		require.Equal(t, definitions.Provenance(models.ProvenanceAPI), slo.Provenance)
+
+		group, err := ruleService.GetRuleGroup(ctx, orgID, "my-namespace", "Checkout availability")
+		require.NoError(t, err)
+		require.Len(t, group.Rules, len(sloBurnRateWindows))
+		for _, rule := range group.Rules {
+			require.Equal(t, "E", rule.Condition)
+			require.Equal(t, "Checkout availability", rule.Labels["slo"])
+			require.Equal(t, "checkout", rule.Labels["team"])
+			_, provenance, err := ruleService.GetAlertRule(ctx, orgID, rule.UID)
+			require.NoError(t, err)
+			require.Equal(t, models.ProvenanceAPI, provenance)
+		}
+
+		stored, err := sut.GetSLO(ctx, orgID, slo.UID)
+		require.NoError(t, err)
+		require.Equal(t, slo, stored)
+	})
+
+	t.Run("updating an SLO updates its alert rules", func(t *testing.T) {
+		slo, err := sut.CreateSLO(ctx, orgID, createTestSLO("Search latency"), models.ProvenanceAPI, 0)
+		require.NoError(t, err)
+		before, err := ruleService.GetRuleGroup(ctx, orgID, "my-namespace", "Search latency")
+		require.NoError(t, err)
+
+		slo.Title = "Search latency v2"
+		slo.Objective = 99
+		_, err = sut.UpdateSLO(ctx, orgID, slo, models.ProvenanceAPI, 0)
+		require.NoError(t, err)
+
+		_, err = ruleService.GetRuleGroup(ctx, orgID, "my-namespace", "Search latency")
+		require.Error(t, err)
+		after, err := ruleService.GetRuleGroup(ctx, orgID, "my-namespace", "Search latency v2")
+		require.NoError(t, err)
+		require.Len(t, after.Rules, len(before.Rules))
+		for i := range after.Rules {
+			require.Equal(t, before.Rules[i].UID, after.Rules[i].UID)
+			require.Equal(t, "Search latency v2", after.Rules[i].Labels["slo"])
+		}
+	})
+
+	t.Run("deleting an SLO deletes its alert rules", func(t *testing.T) {
+		slo, err := sut.CreateSLO(ctx, orgID, createTestSLO("Login availability"), models.ProvenanceAPI, 0)
+		require.NoError(t, err)
+
+		err = sut.DeleteSLO(ctx, orgID, slo.UID, models.ProvenanceAPI, 0)
+		require.NoError(t, err)
+
+		_, err = ruleService.GetRuleGroup(ctx, orgID, "my-namespace", "Login availability")
+		require.Error(t, err)
+		_, err = sut.GetSLO(ctx, orgID, slo.UID)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("the SLOs are listed by title", func(t *testing.T) {
+		slos, err := sut.GetSLOs(ctx, orgID)
+		require.NoError(t, err)
+		require.Len(t, slos, 2)
+		require.Equal(t, "Checkout availability", slos[0].Title)
+		require.Equal(t, "Search latency v2", slos[1].Title)
+	})
+
+	t.Run("the provenance of an SLO cannot change", func(t *testing.T) {
+		slos, err := sut.GetSLOs(ctx, orgID)
+		require.NoError(t, err)
+
+		_, err = sut.UpdateSLO(ctx, orgID, slos[0], models.ProvenanceFile, 0)
+		require.Error(t, err)
+		err = sut.DeleteSLO(ctx, orgID, slos[0].UID, models.ProvenanceFile, 0)
+		require.Error(t, err)
+	})
+
+	t.Run("an SLO cannot take over an existing rule group", func(t *testing.T) {
+		_, err := sut.CreateSLO(ctx, orgID, createTestSLO("Checkout availability"), models.ProvenanceAPI, 0)
+		require.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("invalid SLOs are rejected", func(t *testing.T) {
+		cases := map[string]func(*definitions.SLO){
+			"no title":          func(s *definitions.SLO) { s.Title = " " },
+			"no folder":         func(s *definitions.SLO) { s.FolderUID = "" },
+			"no data source":    func(s *definitions.SLO) { s.DatasourceUID = "" },
+			"no range variable": func(s *definitions.SLO) { s.ErrorQuery = "sum(rate(errors_total[5m]))" },
+			"objective of 100%": func(s *definitions.SLO) { s.Objective = 100 },
+			"short window":      func(s *definitions.SLO) { s.Window = model.Duration(30 * time.Minute) },
+			"invalid UID":       func(s *definitions.SLO) { s.UID = "not/valid" },
+		}
+		for name, mutate := range cases {
+			t.Run(name, func(t *testing.T) {
+				slo := createTestSLO("Invalid")
+				mutate(&slo)
+				_, err := sut.CreateSLO(ctx, orgID, slo, models.ProvenanceAPI, 0)
+				require.ErrorIs(t, err, ErrValidation)
+			})
+		}
+	})
+}
+
+func TestSLORuleGroup(t *testing.T) {
+	slo := createTestSLO("Checkout availability")
+	slo.Window = model.Duration(2 * 24 * time.Hour)
+
+	group := sloRuleGroup(slo, []string{"existing"}, 60)
+
+	// the 3 days window is longer than the window of the SLO
+	require.Len(t, group.Rules, 3)
+	require.Equal(t, "existing", group.Rules[0].UID)
+	require.Empty(t, group.Rules[1].UID)
+	require.Equal(t, "Checkout availability: burn rate over 1h", group.Rules[0].Title)
+	require.Equal(t, "page", group.Rules[0].Labels["severity"])
+	require.Equal(t, "ticket", group.Rules[2].Labels["severity"])
+
+	short := group.Rules[0].Data[2]
+	require.Equal(t, models.Duration(5*time.Minute), short.RelativeTimeRange.From)
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(group.Rules[0].Data[4].Model, &m))
+	// 2% of the budget of 0.1% over 1h of 48h is a burn rate of 0.96
+	require.Equal(t, "$A / $B > 0.00096 && $C / $D > 0.00096", m["expression"])
+}
+
+func createTestSLO(title string) definitions.SLO {
+	return definitions.SLO{
+		Title:         title,
+		FolderUID:     "my-namespace",
+		DatasourceUID: "prometheus",
+		ErrorQuery:    `sum(rate(http_requests_total{code=~"5.."}[$__range]))`,
+		TotalQuery:    `sum(rate(http_requests_total[$__range]))`,
+		Objective:     99.9,
+		Labels:        map[string]string{"team": "checkout"},
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	infraMetrics "github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
@@ -84,6 +85,9 @@ type schedule struct {
 
 	alertsSender    AlertsSender
 	minRuleInterval time.Duration
+	// skipUnchangedMaxAge is how long the evaluations with unchanged normal results are skipped, 0 when they are
+	// always processed
+	skipUnchangedMaxAge time.Duration
 
 	// schedulableAlertRules contains the alert rules that are considered for
 	// evaluation in the current tick. The evaluation of an alert rule in the
@@ -100,6 +104,7 @@ type SchedulerCfg struct {
 	BaseInterval         time.Duration
 	C                    clock.Clock
 	MinRuleInterval      time.Duration
+	SkipUnchangedMaxAge  time.Duration
 	DisableGrafanaFolder bool
 	AppURL               *url.URL
 	EvaluatorFactory     eval.EvaluatorFactory
@@ -124,6 +129,7 @@ func NewScheduler(cfg SchedulerCfg, stateManager *state.Manager) *schedule {
 		disableGrafanaFolder:  cfg.DisableGrafanaFolder,
 		stateManager:          stateManager,
 		minRuleInterval:       cfg.MinRuleInterval,
+		skipUnchangedMaxAge:   cfg.SkipUnchangedMaxAge,
 		schedulableAlertRules: alertRulesRegistry{rules: make(map[ngmodels.AlertRuleKey]*ngmodels.AlertRule)},
 		alertsSender:          cfg.AlertSender,
 		tracer:                cfg.Tracer,
@@ -351,6 +357,8 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 	evalTotal := sch.metrics.EvalTotal.WithLabelValues(orgID)
 	evalDuration := sch.metrics.EvalDuration.WithLabelValues(orgID)
 	evalTotalFailures := sch.metrics.EvalFailures.WithLabelValues(orgID)
+	evalSkipped := sch.metrics.EvalSkipped.WithLabelValues(orgID)
+	unchanged := newUnchangedResults(sch.skipUnchangedMaxAge)
 
 	notify := func(states []state.StateTransition) {
 		expiredAlerts := FromAlertsStateToStoppedAlert(states, sch.appURL, sch.clock)
@@ -367,6 +375,7 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 		}
 		states := sch.stateManager.ResetStateByRuleUID(ctx, rule, reason)
 		notify(states)
+		unchanged.reset()
 	}
 
 	evaluate := func(ctx context.Context, attempt int64, e *evaluation, span tracing.Span) {
@@ -404,6 +413,9 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 
 		evalTotal.Inc()
 		evalDuration.Observe(dur.Seconds())
+		if org, ok := infraMetrics.OrgLabel(key.OrgID); ok {
+			infraMetrics.MOrgAlertRuleEvaluations.WithLabelValues(org).Inc()
+		}
 
 		if err != nil || results.HasErrors() {
 			evalTotalFailures.Inc()
@@ -437,7 +449,14 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 			logger.Debug("Skip updating the state because the context has been cancelled")
 			return
 		}
-		processedStates := sch.stateManager.ProcessEvalResults(ctx, e.scheduledAt, e.rule, results, sch.getRuleExtraLabels(e))
+		extraLabels := sch.getRuleExtraLabels(e)
+		if unchanged.skip(e.scheduledAt, results, extraLabels) {
+			evalSkipped.Inc()
+			logger.Debug("Skip processing the results because they are unchanged")
+			span.AddEvents([]string{"message"}, []tracing.EventValue{{Str: "results unchanged"}})
+			return
+		}
+		processedStates := sch.stateManager.ProcessEvalResults(ctx, e.scheduledAt, e.rule, results, extraLabels)
 		alerts := FromStateTransitionToPostableAlerts(processedStates, sch.stateManager, sch.appURL)
 		span.AddEvents(
 			[]string{"message", "state_transitions", "alerts_to_send"},
@@ -741,6 +741,51 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 
 		require.NotEmpty(t, sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
 	})
+
+	t.Run("when the results are unchanged it should not process them", func(t *testing.T) {
+		rule := models.AlertRuleGen(withQueryForState(t, eval.Normal))()
+
+		evalChan := make(chan *evaluation)
+		evalAppliedChan := make(chan time.Time)
+
+		sch, ruleStore, instanceStore, reg := createSchedule(evalAppliedChan, nil)
+		sch.skipUnchangedMaxAge = time.Hour
+		ruleStore.PutRule(context.Background(), rule)
+
+		go func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus))
+		}()
+
+		first := sch.clock.Now()
+		for i := 0; i < 3; i++ {
+			evalChan <- &evaluation{
+				scheduledAt: first.Add(time.Duration(i) * time.Minute),
+				rule:        rule,
+			}
+			waitForTimeChannel(t, evalAppliedChan)
+		}
+
+		saved := 0
+		for _, op := range instanceStore.RecordedOps {
+			if _, ok := op.(models.AlertInstance); ok {
+				saved++
+			}
+		}
+		require.Equal(t, 1, saved)
+
+		states := sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID)
+		require.Len(t, states, 1)
+		require.Equal(t, first, states[0].LastEvaluationTime)
+
+		expectedMetric := fmt.Sprintf(`# HELP grafana_alerting_rule_evaluations_skipped_total The total number of rule evaluations not processed because their results were unchanged.
+			# TYPE grafana_alerting_rule_evaluations_skipped_total counter
+			grafana_alerting_rule_evaluations_skipped_total{org="%d"} 2
+		`, rule.OrgID)
+		err := testutil.GatherAndCompare(reg, bytes.NewBufferString(expectedMetric), "grafana_alerting_rule_evaluations_skipped_total")
+		require.NoError(t, err)
+	})
 }
 
 func TestSchedule_deleteAlertRule(t *testing.T) {
@@ -780,7 +825,7 @@ func setupScheduler(t *testing.T, rs *fakeRulesStore, is *state.FakeInstanceStor
 
 	var evaluator = evalMock
 	if evalMock == nil {
-		evaluator = eval.NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, nil, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil), &plugins.FakePluginStore{})
+		evaluator = eval.NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, nil, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, tracing.InitializeTracerForTest()), &plugins.FakePluginStore{})
 	}
 
 	if registry == nil {
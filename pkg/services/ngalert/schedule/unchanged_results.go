@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+)
+
+// unchangedResults tracks the results of the last evaluation of a rule processed by the state manager, so that the
+// next evaluations returning the same normal results are not processed again: they would not change the states, and
+// processing them would only save the states to the database. The results are still processed at least every
+// maxSkip, so that the last evaluation time of the states stays recent.
+//
+// Only the evaluations whose results are all normal are skipped, the pending and firing states still move forward
+// in time and are sent to the Alertmanager again.
+type unchangedResults struct {
+	maxSkip     time.Duration
+	fingerprint uint64
+	// processedAt is the time of the last evaluation processed, it is zero when the next one must be processed
+	processedAt time.Time
+}
+
+// newUnchangedResults returns nil when the evaluations with unchanged results are processed
+func newUnchangedResults(maxSkip time.Duration) *unchangedResults {
+	if maxSkip <= 0 {
+		return nil
+	}
+	return &unchangedResults{maxSkip: maxSkip}
+}
+
+// skip returns true when the results of the evaluation at evaluatedAt are the results of the last evaluation
+// processed, all normal, and it was processed less than maxSkip ago. Otherwise the results are recorded as processed.
+func (u *unchangedResults) skip(evaluatedAt time.Time, results eval.Results, extraLabels data.Labels) bool {
+	if u == nil {
+		return false
+	}
+	fingerprint, normal := resultsFingerprint(results, extraLabels)
+	if !normal {
+		u.reset()
+		return false
+	}
+	if !u.processedAt.IsZero() && u.fingerprint == fingerprint && evaluatedAt.Sub(u.processedAt) < u.maxSkip {
+		return true
+	}
+	u.fingerprint, u.processedAt = fingerprint, evaluatedAt
+	return false
+}
+
+// reset makes the next evaluation processed, for example when the state of the rule was reset
+func (u *unchangedResults) reset() {
+	if u == nil {
+		return
+	}
+	u.processedAt = time.Time{}
+}
+
+// resultsFingerprint hashes the instances of the results with their values, it returns false when one of the results
+// is not normal
+func resultsFingerprint(results eval.Results, extraLabels data.Labels) (uint64, bool) {
+	instances := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.State != eval.Normal || r.Error != nil {
+			return 0, false
+		}
+
+		values := make([]string, 0, len(r.Values))
+		for name, v := range r.Values {
+			value := "nil"
+			if v.Value != nil {
+				value = strconv.FormatFloat(*v.Value, 'g', -1, 64)
+			}
+			values = append(values, fmt.Sprintf("%s=%s%s:%s", name, v.Var, v.Labels.String(), value))
+		}
+		sort.Strings(values)
+		instances = append(instances, fmt.Sprintf("%s|%s|%v", r.Instance.String(), r.EvaluationString, values))
+	}
+	sort.Strings(instances)
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(extraLabels.String()))
+	for _, i := range instances {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(i))
+	}
+	return h.Sum64(), true
+}
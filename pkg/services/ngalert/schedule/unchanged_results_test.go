@@ -0,0 +1,76 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestUnchangedResults(t *testing.T) {
+	start := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	extraLabels := data.Labels{"grafana_folder": "folder"}
+	normal := func(value float64) eval.Results {
+		return eval.Results{
+			{Instance: data.Labels{"instance": "b"}, State: eval.Normal, EvaluatedAt: start},
+			{Instance: data.Labels{"instance": "a"}, State: eval.Normal, EvaluatedAt: start, Values: map[string]eval.NumberValueCapture{
+				"B": {Var: "B", Labels: data.Labels{"instance": "a"}, Value: util.Pointer(value)},
+			}},
+		}
+	}
+
+	t.Run("should skip the same normal results until the max age", func(t *testing.T) {
+		u := newUnchangedResults(5 * time.Minute)
+		assert.False(t, u.skip(start, normal(1), extraLabels))
+		assert.True(t, u.skip(start.Add(time.Minute), normal(1), extraLabels))
+
+		// the order of the results doesn't matter
+		results := normal(1)
+		results[0], results[1] = results[1], results[0]
+		assert.True(t, u.skip(start.Add(2*time.Minute), results, extraLabels))
+
+		assert.False(t, u.skip(start.Add(5*time.Minute), normal(1), extraLabels))
+		assert.True(t, u.skip(start.Add(6*time.Minute), normal(1), extraLabels))
+	})
+
+	t.Run("should process the changed results", func(t *testing.T) {
+		u := newUnchangedResults(5 * time.Minute)
+		assert.False(t, u.skip(start, normal(1), extraLabels))
+		assert.False(t, u.skip(start.Add(time.Minute), normal(2), extraLabels))
+		assert.False(t, u.skip(start.Add(2*time.Minute), normal(2), data.Labels{"grafana_folder": "renamed"}))
+		assert.True(t, u.skip(start.Add(3*time.Minute), normal(2), data.Labels{"grafana_folder": "renamed"}))
+	})
+
+	t.Run("should always process the results that are not normal", func(t *testing.T) {
+		u := newUnchangedResults(5 * time.Minute)
+		alerting := eval.Results{{Instance: data.Labels{"instance": "a"}, State: eval.Alerting}}
+		assert.False(t, u.skip(start, alerting, extraLabels))
+		assert.False(t, u.skip(start.Add(time.Minute), alerting, extraLabels))
+
+		failed := eval.Results{eval.NewResultFromError(errors.New("timeout"), start, 0)}
+		assert.False(t, u.skip(start.Add(2*time.Minute), failed, extraLabels))
+
+		// the first normal results after them are processed
+		assert.False(t, u.skip(start.Add(3*time.Minute), normal(1), extraLabels))
+	})
+
+	t.Run("should process the results after a reset", func(t *testing.T) {
+		u := newUnchangedResults(5 * time.Minute)
+		assert.False(t, u.skip(start, normal(1), extraLabels))
+		u.reset()
+		assert.False(t, u.skip(start.Add(time.Minute), normal(1), extraLabels))
+	})
+
+	t.Run("should process every result when disabled", func(t *testing.T) {
+		u := newUnchangedResults(0)
+		assert.Nil(t, u)
+		assert.False(t, u.skip(start, normal(1), extraLabels))
+		assert.False(t, u.skip(start, normal(1), extraLabels))
+		u.reset()
+	})
+}
@@ -60,11 +60,12 @@ func (h *AnnotationBackend) Record(ctx context.Context, rule history_model.RuleM
 	// Build annotations before starting goroutine, to make sure all data is copied and won't mutate underneath us.
 	annotations := buildAnnotations(rule, states, logger)
 	panel := parsePanelKey(rule, logger)
+	linked := parseLinkedPanelKeys(rule)
 
 	errCh := make(chan error, 1)
 	go func() {
 		defer close(errCh)
-		errCh <- h.recordAnnotations(ctx, panel, annotations, rule.OrgID, logger)
+		errCh <- h.recordAnnotations(ctx, panel, linked, annotations, rule.OrgID, logger)
 	}()
 	return errCh
 }
@@ -103,6 +104,7 @@ func (h *AnnotationBackend) Query(ctx context.Context, query ngmodels.HistoryQue
 	if err != nil {
 		return nil, fmt.Errorf("failed to query annotations for state history: %w", err)
 	}
+	items = dedupeLinkedAnnotations(items)
 
 	frame := data.NewFrame("states")
 
@@ -179,10 +181,11 @@ func buildAnnotations(rule history_model.RuleMeta, states []state.StateTransitio
 	return items
 }
 
-func (h *AnnotationBackend) recordAnnotations(ctx context.Context, panel *panelKey, annotations []annotations.Item, orgID int64, logger log.Logger) error {
+func (h *AnnotationBackend) recordAnnotations(ctx context.Context, panel *panelKey, linked []panelKey, annotations []annotations.Item, orgID int64, logger log.Logger) error {
 	if len(annotations) == 0 {
 		return nil
 	}
+	transitions := len(annotations)
 
 	if panel != nil {
 		dashID, err := h.dashboards.getID(ctx, panel.orgID, panel.dashUID)
@@ -197,13 +200,27 @@ func (h *AnnotationBackend) recordAnnotations(ctx context.Context, panel *panelK
 		}
 	}
 
+	// The linked panels get a copy of the annotations of each transition.
+	for _, linkedPanel := range linked {
+		dashID, err := h.dashboards.getID(ctx, linkedPanel.orgID, linkedPanel.dashUID)
+		if err != nil {
+			logger.Warn("Error getting linked dashboard for alert annotation, skipping it", "dashboardUID", linkedPanel.dashUID, "error", err)
+			continue
+		}
+		for _, item := range annotations[:transitions] {
+			item.DashboardID = dashID
+			item.PanelID = linkedPanel.panelID
+			annotations = append(annotations, item)
+		}
+	}
+
 	org := fmt.Sprint(orgID)
 	h.metrics.WritesTotal.WithLabelValues(org).Inc()
-	h.metrics.TransitionsTotal.WithLabelValues(org).Add(float64(len(annotations)))
+	h.metrics.TransitionsTotal.WithLabelValues(org).Add(float64(transitions))
 	if err := h.annotations.SaveMany(ctx, annotations); err != nil {
 		logger.Error("Error saving alert annotation batch", "error", err)
 		h.metrics.WritesFailed.WithLabelValues(org).Inc()
-		h.metrics.TransitionsFailed.WithLabelValues(org).Add(float64(len(annotations)))
+		h.metrics.TransitionsFailed.WithLabelValues(org).Add(float64(transitions))
 		return fmt.Errorf("error saving alert annotation batch: %w", err)
 	}
 
@@ -211,6 +228,28 @@ func (h *AnnotationBackend) recordAnnotations(ctx context.Context, panel *panelK
 	return nil
 }
 
+// dedupeLinkedAnnotations removes the copies of the annotations of the transitions attached to the linked panels of
+// the rule, so every transition is returned once.
+func dedupeLinkedAnnotations(items []*annotations.ItemDTO) []*annotations.ItemDTO {
+	type transitionKey struct {
+		time      int64
+		text      string
+		prevState string
+		newState  string
+	}
+	seen := make(map[transitionKey]struct{}, len(items))
+	result := make([]*annotations.ItemDTO, 0, len(items))
+	for _, item := range items {
+		key := transitionKey{time: item.Time, text: item.Text, prevState: item.PrevState, newState: item.NewState}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, item)
+	}
+	return result
+}
+
 func buildAnnotationTextAndData(rule history_model.RuleMeta, currentState *state.State) (string, *simplejson.Json) {
 	jsonData := simplejson.New()
 	var value string
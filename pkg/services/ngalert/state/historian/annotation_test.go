@@ -31,7 +31,7 @@ func TestAnnotationHistorian(t *testing.T) {
 	t.Run("alert annotations are queryable", func(t *testing.T) {
 		anns := createTestAnnotationBackendSut(t)
 		items := []annotations.Item{createAnnotation()}
-		require.NoError(t, anns.recordAnnotations(context.Background(), nil, items, 1, log.NewNopLogger()))
+		require.NoError(t, anns.recordAnnotations(context.Background(), nil, nil, items, 1, log.NewNopLogger()))
 
 		q := models.HistoryQuery{
 			RuleUID: "my-rule",
@@ -100,6 +100,51 @@ grafana_alerting_state_history_writes_total{org="1"} 2
 	})
 }
 
+func TestAnnotationHistorianLinkedPanels(t *testing.T) {
+	t.Run("linked panels get a copy of the annotations", func(t *testing.T) {
+		anns := createTestAnnotationBackendSut(t)
+		rule := createTestRule()
+		rule.LinkedPanels = []models.LinkedPanel{
+			{DashboardUID: "deployments", PanelID: 4},
+			{DashboardUID: "services"},
+			// the panel of the rule is not annotated twice
+			{DashboardUID: rule.DashboardUID, PanelID: rule.PanelID},
+		}
+		states := singleFromNormal(&state.State{
+			State:  eval.Alerting,
+			Labels: data.Labels{"a": "b"},
+		})
+
+		err := <-anns.Record(context.Background(), rule, states)
+
+		require.NoError(t, err)
+		items := anns.annotations.(interface {
+			Items() map[int64]annotations.Item
+		}).Items()
+		require.Len(t, items, 3)
+		panels := make([]int64, 0, len(items))
+		for _, item := range items {
+			require.Equal(t, "Alerting", item.NewState)
+			panels = append(panels, item.PanelID)
+		}
+		require.ElementsMatch(t, []int64{rule.PanelID, 4, 0}, panels)
+	})
+
+	t.Run("the copies of the annotations are queried once", func(t *testing.T) {
+		items := []*annotations.ItemDTO{
+			{ID: 1, Time: 1, Text: "a", PanelID: 1, NewState: "Alerting"},
+			{ID: 2, Time: 1, Text: "a", PanelID: 2, NewState: "Alerting"},
+			{ID: 3, Time: 2, Text: "a", PanelID: 1, NewState: "Normal"},
+		}
+
+		result := dedupeLinkedAnnotations(items)
+
+		require.Len(t, result, 2)
+		require.Equal(t, int64(1), result[0].ID)
+		require.Equal(t, int64(3), result[1].ID)
+	})
+}
+
 func createTestAnnotationBackendSut(t *testing.T) *AnnotationBackend {
 	return createTestAnnotationBackendSutWithMetrics(t, metrics.NewHistorianMetrics(prometheus.NewRegistry()))
 }
@@ -58,6 +58,22 @@ func parsePanelKey(rule history_model.RuleMeta, logger log.Logger) *panelKey {
 	return nil
 }
 
+// parseLinkedPanelKeys returns the keys of the linked panels of the given rule, except the panel the rule is attached to.
+func parseLinkedPanelKeys(rule history_model.RuleMeta) []panelKey {
+	keys := make([]panelKey, 0, len(rule.LinkedPanels))
+	for _, linked := range rule.LinkedPanels {
+		if rule.DashboardUID == linked.DashboardUID && rule.PanelID == linked.PanelID {
+			continue
+		}
+		keys = append(keys, panelKey{
+			orgID:   rule.OrgID,
+			dashUID: linked.DashboardUID,
+			panelID: linked.PanelID,
+		})
+	}
+	return keys
+}
+
 func mergeLabels(base, into data.Labels) data.Labels {
 	for k, v := range into {
 		base[k] = v
@@ -18,6 +18,8 @@ type RuleMeta struct {
 	NamespaceUID string
 	DashboardUID string
 	PanelID      int64
+	// LinkedPanels are the dashboards and panels the annotations are attached to in addition to the rule's panel.
+	LinkedPanels []models.LinkedPanel
 }
 
 func NewRuleMeta(r *models.AlertRule, log log.Logger) RuleMeta {
@@ -33,6 +35,10 @@ func NewRuleMeta(r *models.AlertRule, log log.Logger) RuleMeta {
 		}
 		panelID = pid
 	}
+	linkedPanels, err := r.LinkedPanels()
+	if err != nil {
+		log.Error("Error parsing linked panels for alert annotation", "ruleID", r.ID, "actual", r.Annotations[models.LinkedPanelsAnnotation], "error", err)
+	}
 	return RuleMeta{
 		ID:           r.ID,
 		OrgID:        r.OrgID,
@@ -42,5 +48,6 @@ func NewRuleMeta(r *models.AlertRule, log log.Logger) RuleMeta {
 		NamespaceUID: r.NamespaceUID,
 		DashboardUID: dashUID,
 		PanelID:      panelID,
+		LinkedPanels: linkedPanels,
 	}
 }
@@ -76,3 +76,23 @@ func TestNewRuleMeta(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRuleMetaLinkedPanels(t *testing.T) {
+	logger := log.NewNopLogger()
+
+	res := NewRuleMeta(&models.AlertRule{
+		OrgID: 1,
+		Annotations: map[string]string{
+			models.LinkedPanelsAnnotation: "deployments/4, services",
+		},
+	}, logger)
+	require.Equal(t, []models.LinkedPanel{{DashboardUID: "deployments", PanelID: 4}, {DashboardUID: "services"}}, res.LinkedPanels)
+
+	res = NewRuleMeta(&models.AlertRule{
+		OrgID: 1,
+		Annotations: map[string]string{
+			models.LinkedPanelsAnnotation: "deployments/bad-id",
+		},
+	}, logger)
+	require.Empty(t, res.LinkedPanels)
+}
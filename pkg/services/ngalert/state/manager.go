@@ -7,6 +7,7 @@ import (
 
 	"github.com/benbjohnson/clock"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/pkg/labels"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
@@ -239,8 +240,13 @@ func (st *Manager) ProcessEvalResults(ctx context.Context, evaluatedAt time.Time
 	logger.Debug("State manager processing evaluation results", "resultCount", len(results))
 	states := make([]StateTransition, 0, len(results))
 
+	pausedDimensions, err := alertRule.PausedDimensionsMatchers()
+	if err != nil {
+		logger.Error("Failed to parse the paused dimensions of the rule, all the dimensions are evaluated", "error", err)
+	}
+
 	for _, result := range results {
-		s := st.setNextState(ctx, alertRule, result, extraLabels, logger)
+		s := st.setNextState(ctx, alertRule, result, extraLabels, pausedDimensions, logger)
 		states = append(states, s)
 	}
 	staleStates := st.deleteStaleStatesFromCache(ctx, logger, evaluatedAt, alertRule)
@@ -255,8 +261,9 @@ func (st *Manager) ProcessEvalResults(ctx context.Context, evaluatedAt time.Time
 	return allChanges
 }
 
-// Set the current state based on evaluation results
-func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRule, result eval.Result, extraLabels data.Labels, logger log.Logger) StateTransition {
+// Set the current state based on evaluation results. The states of the paused dimensions are kept normal
+// whatever the result.
+func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRule, result eval.Result, extraLabels data.Labels, pausedDimensions []labels.Matchers, logger log.Logger) StateTransition {
 	currentState := st.cache.getOrCreate(ctx, st.log, alertRule, result, extraLabels, st.externalURL)
 
 	currentState.LastEvaluationTime = result.EvaluatedAt
@@ -275,27 +282,33 @@ func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRu
 	// Add the instance to the log context to help correlate log lines for a state
 	logger = logger.New("instance", result.Instance)
 
-	switch result.State {
-	case eval.Normal:
+	paused := ngModels.IsDimensionPaused(pausedDimensions, currentState.Labels)
+	switch {
+	case paused:
+		logger.Debug("Setting next state", "handler", "resultPaused")
+		resultPaused(currentState, alertRule, result, logger)
+	case result.State == eval.Normal:
 		logger.Debug("Setting next state", "handler", "resultNormal")
 		resultNormal(currentState, alertRule, result, logger)
-	case eval.Alerting:
+	case result.State == eval.Alerting:
 		logger.Debug("Setting next state", "handler", "resultAlerting")
 		resultAlerting(currentState, alertRule, result, logger)
-	case eval.Error:
+	case result.State == eval.Error:
 		logger.Debug("Setting next state", "handler", "resultError")
 		resultError(currentState, alertRule, result, logger)
-	case eval.NoData:
+	case result.State == eval.NoData:
 		logger.Debug("Setting next state", "handler", "resultNoData")
 		resultNoData(currentState, alertRule, result, logger)
-	case eval.Pending: // we do not emit results with this state
+	case result.State == eval.Pending: // we do not emit results with this state
 		logger.Debug("Ignoring set next state as result is pending")
 	}
 
 	// Set reason iff: result and state are different, reason is not Alerting or Normal
 	currentState.StateReason = ""
 
-	if currentState.State != result.State &&
+	if paused {
+		currentState.StateReason = ngModels.StateReasonPaused
+	} else if currentState.State != result.State &&
 		result.State != eval.Normal &&
 		result.State != eval.Alerting {
 		currentState.StateReason = result.State.String()
@@ -2547,6 +2547,66 @@ func TestStaleResults(t *testing.T) {
 	})
 }
 
+func TestPausedDimensions(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+
+	cfg := state.ManagerCfg{
+		Metrics:       testMetrics.GetStateMetrics(),
+		ExternalURL:   nil,
+		InstanceStore: &state.FakeInstanceStore{},
+		Images:        &state.NoopImageService{},
+		Clock:         clk,
+		Historian:     &state.FakeHistorian{},
+	}
+	st := state.NewManager(cfg)
+
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	evaluate := func() map[string]state.StateTransition {
+		t.Helper()
+		clk.Add(time.Duration(rule.IntervalSeconds) * time.Second)
+		results := eval.Results{
+			eval.ResultGen(eval.WithState(eval.Alerting), eval.WithLabels(data.Labels{"instance": "server-1"}), eval.WithEvaluatedAt(clk.Now()))(),
+			eval.ResultGen(eval.WithState(eval.Alerting), eval.WithLabels(data.Labels{"instance": "server-2"}), eval.WithEvaluatedAt(clk.Now()))(),
+		}
+		transitions := map[string]state.StateTransition{}
+		for _, s := range st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil) {
+			transitions[s.Labels["instance"]] = s
+		}
+		require.Len(t, transitions, 2)
+		return transitions
+	}
+
+	transitions := evaluate()
+	require.Equal(t, eval.Alerting, transitions["server-1"].State.State)
+	require.Equal(t, eval.Alerting, transitions["server-2"].State.State)
+
+	rule.PausedDimensions = []string{`{instance="server-1"}`}
+
+	t.Run("the alerting paused dimensions are resolved", func(t *testing.T) {
+		transitions := evaluate()
+		assert.Equal(t, eval.Normal, transitions["server-1"].State.State)
+		assert.Equal(t, models.StateReasonPaused, transitions["server-1"].StateReason)
+		assert.True(t, transitions["server-1"].Resolved)
+		assert.Equal(t, eval.Alerting, transitions["server-2"].State.State)
+	})
+
+	t.Run("the paused dimensions stay normal", func(t *testing.T) {
+		transitions := evaluate()
+		assert.Equal(t, eval.Normal, transitions["server-1"].State.State)
+		assert.Equal(t, models.StateReasonPaused, transitions["server-1"].StateReason)
+		assert.False(t, transitions["server-1"].Resolved)
+		assert.Equal(t, eval.Alerting, transitions["server-2"].State.State)
+	})
+
+	t.Run("the dimensions are evaluated once unpaused", func(t *testing.T) {
+		rule.PausedDimensions = nil
+		transitions := evaluate()
+		assert.Equal(t, eval.Alerting, transitions["server-1"].State.State)
+		assert.Empty(t, transitions["server-1"].StateReason)
+	})
+}
+
 func TestDeleteStateByRuleUID(t *testing.T) {
 	interval := time.Minute
 	ctx := context.Background()
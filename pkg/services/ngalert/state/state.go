@@ -277,6 +277,17 @@ func resultNoData(state *State, rule *models.AlertRule, result eval.Result, _ lo
 	}
 }
 
+// resultPaused keeps the state of a paused dimension normal whatever the result, the dimension is resolved if it was
+// alerting when it was paused.
+func resultPaused(state *State, _ *models.AlertRule, result eval.Result, logger log.Logger) {
+	if state.State == eval.Normal {
+		logger.Debug("Keeping state", "state", state.State)
+	} else {
+		logger.Debug("Changing state", "previous_state", state.State, "next_state", eval.Normal)
+		state.SetNormal(models.StateReasonPaused, result.EvaluatedAt, result.EvaluatedAt)
+	}
+}
+
 func (a *State) NeedsSending(resendDelay time.Duration) bool {
 	switch a.State {
 	case eval.Pending:
@@ -150,6 +150,7 @@ func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRu
 				For:              r.For,
 				Annotations:      r.Annotations,
 				Labels:           r.Labels,
+				PausedDimensions: r.PausedDimensions,
 			})
 		}
 		if len(newRules) > 0 {
@@ -218,6 +219,7 @@ func (st DBstore) UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateR
 				For:              r.New.For,
 				Annotations:      r.New.Annotations,
 				Labels:           r.New.Labels,
+				PausedDimensions: r.New.PausedDimensions,
 			})
 		}
 		if len(ruleVersions) > 0 {
@@ -568,5 +570,13 @@ func (st DBstore) validateAlertRule(alertRule ngmodels.AlertRule) error {
 	if alertRule.For < 0 {
 		return fmt.Errorf("%w: field `for` cannot be negative", ngmodels.ErrAlertRuleFailedValidation)
 	}
+
+	if _, err := alertRule.PausedDimensionsMatchers(); err != nil {
+		return err
+	}
+
+	if _, err := alertRule.LinkedPanels(); err != nil {
+		return err
+	}
 	return nil
 }
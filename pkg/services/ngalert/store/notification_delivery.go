@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// NotificationDeliveryStore is the store of the receipts of the notifications sent by the Grafana Alertmanager.
+type NotificationDeliveryStore interface {
+	// GetNotificationDelivery returns the delivery with the ID in the organization. It returns
+	// ErrNotificationDeliveryNotFound if the delivery does not exist.
+	GetNotificationDelivery(ctx context.Context, orgID int64, id int64) (*models.NotificationDelivery, error)
+
+	// GetPendingNotificationDelivery returns the pending delivery with the key in the organization. It returns
+	// ErrNotificationDeliveryNotFound if there is no pending delivery with the key.
+	GetPendingNotificationDelivery(ctx context.Context, orgID int64, key string) (*models.NotificationDelivery, error)
+
+	// ListNotificationDeliveries returns the deliveries matching the query, the most recently updated first.
+	ListNotificationDeliveries(ctx context.Context, query *models.ListNotificationDeliveriesQuery) ([]*models.NotificationDelivery, error)
+
+	// GetDueNotificationDeliveries returns the pending deliveries of all the organizations to retry before now, the
+	// oldest first.
+	GetDueNotificationDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.NotificationDelivery, error)
+
+	// SaveNotificationDelivery inserts the delivery if it has no ID, or updates it.
+	SaveNotificationDelivery(ctx context.Context, delivery *models.NotificationDelivery) error
+
+	// DeleteNotificationDeliveries deletes the delivered and dead deliveries not updated since the time. It returns
+	// the number of deleted deliveries.
+	DeleteNotificationDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+func (st DBstore) GetNotificationDelivery(ctx context.Context, orgID int64, id int64) (*models.NotificationDelivery, error) {
+	var delivery models.NotificationDelivery
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Where("org_id = ? AND id = ?", orgID, id).Get(&delivery)
+		if err != nil {
+			return fmt.Errorf("failed to get notification delivery: %w", err)
+		}
+		if !exists {
+			return models.ErrNotificationDeliveryNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (st DBstore) GetPendingNotificationDelivery(ctx context.Context, orgID int64, key string) (*models.NotificationDelivery, error) {
+	var delivery models.NotificationDelivery
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Where("org_id = ? AND delivery_key = ? AND state = ?", orgID, key, models.NotificationDeliveryPending).
+			Desc("id").Get(&delivery)
+		if err != nil {
+			return fmt.Errorf("failed to get notification delivery: %w", err)
+		}
+		if !exists {
+			return models.ErrNotificationDeliveryNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (st DBstore) ListNotificationDeliveries(ctx context.Context, query *models.ListNotificationDeliveriesQuery) ([]*models.NotificationDelivery, error) {
+	var deliveries []*models.NotificationDelivery
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Where("org_id = ?", query.OrgID)
+		if len(query.States) > 0 {
+			q = q.In("state", query.States)
+		}
+		if query.Receiver != "" {
+			q = q.And("receiver = ?", query.Receiver)
+		}
+		if query.IntegrationUID != "" {
+			q = q.And("integration_uid = ?", query.IntegrationUID)
+		}
+		if query.Limit > 0 {
+			q = q.Limit(query.Limit)
+		}
+		return q.Desc("updated_at", "id").Find(&deliveries)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (st DBstore) GetDueNotificationDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.NotificationDelivery, error) {
+	var deliveries []*models.NotificationDelivery
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("state = ? AND next_attempt_at <= ?", models.NotificationDeliveryPending, now.UTC()).
+			Asc("next_attempt_at").Limit(limit).Find(&deliveries)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (st DBstore) SaveNotificationDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		delivery.UpdatedAt = TimeNow().UTC()
+		if delivery.ID == 0 {
+			delivery.CreatedAt = delivery.UpdatedAt
+			if _, err := sess.Insert(delivery); err != nil {
+				return fmt.Errorf("failed to insert notification delivery: %w", err)
+			}
+			return nil
+		}
+		// the zero values, such as the empty last error of the delivered notifications, are updated too
+		if _, err := sess.ID(delivery.ID).AllCols().Update(delivery); err != nil {
+			return fmt.Errorf("failed to update notification delivery: %w", err)
+		}
+		return nil
+	})
+}
+
+func (st DBstore) DeleteNotificationDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		rows, err := sess.Where("state <> ? AND updated_at < ?", models.NotificationDeliveryPending, before.UTC()).
+			Delete(&models.NotificationDelivery{})
+		if err != nil {
+			return fmt.Errorf("failed to delete notification deliveries: %w", err)
+		}
+		n = rows
+		return nil
+	})
+	return n, err
+}
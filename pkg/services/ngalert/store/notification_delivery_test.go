@@ -0,0 +1,117 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+)
+
+func TestIntegrationNotificationDeliveries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	// our database schema uses second precision for timestamps
+	now := time.Now().UTC().Truncate(time.Second)
+	store.TimeNow = func() time.Time {
+		return now
+	}
+	t.Cleanup(func() {
+		store.TimeNow = time.Now
+	})
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	pending := models.NotificationDelivery{
+		OrgID:          1,
+		DeliveryKey:    "key",
+		Receiver:       "pager",
+		IntegrationUID: "uid",
+		Alerts:         "[]",
+		State:          models.NotificationDeliveryPending,
+		Attempts:       1,
+		LastError:      "connection refused",
+		NextAttemptAt:  now.Add(-time.Minute),
+	}
+	require.NoError(t, dbstore.SaveNotificationDelivery(ctx, &pending))
+	require.NotZero(t, pending.ID)
+	dead := models.NotificationDelivery{
+		OrgID:          1,
+		DeliveryKey:    "other",
+		Receiver:       "email",
+		IntegrationUID: "other",
+		Alerts:         "[]",
+		State:          models.NotificationDeliveryDead,
+		NextAttemptAt:  now.Add(-time.Minute),
+	}
+	require.NoError(t, dbstore.SaveNotificationDelivery(ctx, &dead))
+
+	t.Run("the pending delivery is found by its key", func(t *testing.T) {
+		result, err := dbstore.GetPendingNotificationDelivery(ctx, 1, "key")
+		require.NoError(t, err)
+		assert.Equal(t, pending.ID, result.ID)
+
+		_, err = dbstore.GetPendingNotificationDelivery(ctx, 1, "other")
+		require.ErrorIs(t, err, models.ErrNotificationDeliveryNotFound)
+		_, err = dbstore.GetPendingNotificationDelivery(ctx, 2, "key")
+		require.ErrorIs(t, err, models.ErrNotificationDeliveryNotFound)
+	})
+
+	t.Run("the deliveries are filtered", func(t *testing.T) {
+		result, err := dbstore.ListNotificationDeliveries(ctx, &models.ListNotificationDeliveriesQuery{OrgID: 1})
+		require.NoError(t, err)
+		assert.Len(t, result, 2)
+
+		result, err = dbstore.ListNotificationDeliveries(ctx, &models.ListNotificationDeliveriesQuery{
+			OrgID:  1,
+			States: []models.NotificationDeliveryState{models.NotificationDeliveryDead},
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, dead.ID, result[0].ID)
+
+		result, err = dbstore.ListNotificationDeliveries(ctx, &models.ListNotificationDeliveriesQuery{OrgID: 1, Receiver: "pager"})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, pending.ID, result[0].ID)
+	})
+
+	t.Run("only the pending deliveries are due", func(t *testing.T) {
+		result, err := dbstore.GetDueNotificationDeliveries(ctx, now, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, pending.ID, result[0].ID)
+
+		result, err = dbstore.GetDueNotificationDeliveries(ctx, now.Add(-time.Hour), 10)
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("updating a delivery clears its error", func(t *testing.T) {
+		pending.State = models.NotificationDeliveryDelivered
+		pending.LastError = ""
+		require.NoError(t, dbstore.SaveNotificationDelivery(ctx, &pending))
+
+		result, err := dbstore.GetNotificationDelivery(ctx, 1, pending.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.NotificationDeliveryDelivered, result.State)
+		assert.Empty(t, result.LastError)
+	})
+
+	t.Run("the old deliveries are deleted", func(t *testing.T) {
+		n, err := dbstore.DeleteNotificationDeliveries(ctx, now.Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		_, err = dbstore.GetNotificationDelivery(ctx, 1, dead.ID)
+		require.ErrorIs(t, err, models.ErrNotificationDeliveryNotFound)
+	})
+}
@@ -54,7 +54,8 @@ func (ns *NotificationService) Send(msg *Message) (int, error) {
 }
 
 func (ns *NotificationService) buildEmailMessage(cmd *SendEmailCommand) (*Message, error) {
-	if !ns.Cfg.Smtp.Enabled {
+	smtp := ns.smtpSettings()
+	if !smtp.Enabled {
 		return nil, ErrSmtpNotEnabled
 	}
 
@@ -66,7 +67,7 @@ func (ns *NotificationService) buildEmailMessage(cmd *SendEmailCommand) (*Messag
 	setDefaultTemplateData(ns.Cfg, data, nil)
 
 	body := make(map[string]string)
-	for _, contentType := range ns.Cfg.Smtp.ContentTypes {
+	for _, contentType := range smtp.ContentTypes {
 		fileExtension, err := getFileExtensionByContentType(contentType)
 		if err != nil {
 			return nil, err
@@ -109,7 +110,7 @@ func (ns *NotificationService) buildEmailMessage(cmd *SendEmailCommand) (*Messag
 		}
 	}
 
-	addr := mail.Address{Name: ns.Cfg.Smtp.FromName, Address: ns.Cfg.Smtp.FromAddress}
+	addr := mail.Address{Name: smtp.FromName, Address: smtp.FromAddress}
 	return &Message{
 		To:            cmd.To,
 		SingleEmail:   cmd.SingleEmail,
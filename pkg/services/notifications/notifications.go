@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/Masterminds/sprig/v3"
 
@@ -47,7 +48,16 @@ func ProvideService(bus bus.Bus, cfg *setting.Cfg, mailer Mailer, store TempUser
 		webhookQueue: make(chan *Webhook, 10),
 		mailer:       mailer,
 		store:        store,
+		smtp:         cfg.Smtp,
 	}
+	reloadSmtp := func() error {
+		ns.smtpMu.Lock()
+		defer ns.smtpMu.Unlock()
+		ns.smtp = cfg.SmtpSettings()
+		return nil
+	}
+	cfg.OnReload("smtp", reloadSmtp)
+	cfg.OnReload("emails", reloadSmtp)
 
 	ns.Bus.AddEventListener(ns.signUpStartedHandler)
 	ns.Bus.AddEventListener(ns.signUpCompletedHandler)
@@ -99,6 +109,16 @@ type NotificationService struct {
 	mailer       Mailer
 	log          log.Logger
 	store        TempUserStore
+
+	// smtp are the SMTP settings applied by the last reload of the settings
+	smtp   setting.SmtpSettings
+	smtpMu sync.RWMutex
+}
+
+func (ns *NotificationService) smtpSettings() setting.SmtpSettings {
+	ns.smtpMu.RLock()
+	defer ns.smtpMu.RUnlock()
+	return ns.smtp
 }
 
 func (ns *NotificationService) Run(ctx context.Context) error {
@@ -276,7 +296,7 @@ func (ns *NotificationService) signUpStartedHandler(ctx context.Context, evt *ev
 }
 
 func (ns *NotificationService) signUpCompletedHandler(ctx context.Context, evt *events.SignUpCompleted) error {
-	if evt.Email == "" || !ns.Cfg.Smtp.SendWelcomeEmailOnSignUp {
+	if evt.Email == "" || !ns.smtpSettings().SendWelcomeEmailOnSignUp {
 		return nil
 	}
 
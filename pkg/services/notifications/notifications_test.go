@@ -2,6 +2,8 @@ package notifications
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
@@ -272,6 +274,54 @@ func TestSendEmailAsync(t *testing.T) {
 	})
 }
 
+func TestReloadSmtpSettings(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "custom.ini")
+	writeConfig := func(content string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(content), 0600))
+	}
+	writeConfig(`
+[smtp]
+enabled = true
+from_name = Grafana
+`)
+	cfg := setting.NewCfg()
+	require.NoError(t, cfg.Load(setting.CommandLineArgs{HomePath: "../../../", Config: configFile}))
+	ns, mailer, err := createSutWithConfig(t, newBus(t), cfg)
+	require.NoError(t, err)
+	cmd := &SendEmailCommandSync{
+		SendEmailCommand: SendEmailCommand{
+			Subject:     "subject",
+			To:          []string{"1@grafana.com"},
+			SingleEmail: true,
+			Template:    "welcome_on_signup",
+		},
+	}
+
+	require.NoError(t, ns.SendEmailCommandHandlerSync(context.Background(), cmd))
+	require.Len(t, mailer.Sent, 1)
+	require.Equal(t, `"Grafana" <admin@grafana.localhost>`, mailer.Sent[0].From)
+
+	writeConfig(`
+[smtp]
+enabled = true
+from_name = Grafana Alerts
+`)
+	_, err = cfg.Reload()
+	require.NoError(t, err)
+	require.NoError(t, ns.SendEmailCommandHandlerSync(context.Background(), cmd))
+	require.Len(t, mailer.Sent, 2)
+	require.Equal(t, `"Grafana Alerts" <admin@grafana.localhost>`, mailer.Sent[1].From)
+
+	writeConfig(`
+[smtp]
+enabled = false
+`)
+	_, err = cfg.Reload()
+	require.NoError(t, err)
+	require.ErrorIs(t, ns.SendEmailCommandHandlerSync(context.Background(), cmd), ErrSmtpNotEnabled)
+	require.Len(t, mailer.Sent, 2)
+}
+
 func createSut(t *testing.T, bus bus.Bus) (*NotificationService, *FakeMailer) {
 	t.Helper()
 
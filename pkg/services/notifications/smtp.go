@@ -7,6 +7,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	gomail "gopkg.in/mail.v2"
 
@@ -18,7 +19,34 @@ type SmtpClient struct {
 }
 
 func ProvideSmtpService(cfg *setting.Cfg) (Mailer, error) {
-	return NewSmtpClient(cfg.Smtp)
+	client, err := NewSmtpClient(cfg.Smtp)
+	if err != nil {
+		return nil, err
+	}
+
+	mailer := &reloadableMailer{}
+	mailer.client.Store(client)
+	reload := func() error {
+		client, err := NewSmtpClient(cfg.SmtpSettings())
+		if err != nil {
+			return err
+		}
+		mailer.client.Store(client)
+		return nil
+	}
+	cfg.OnReload("smtp", reload)
+	cfg.OnReload("emails", reload)
+	return mailer, nil
+}
+
+// reloadableMailer sends the messages with the SMTP client of the settings applied by the last reload
+type reloadableMailer struct {
+	// *SmtpClient
+	client atomic.Value
+}
+
+func (m *reloadableMailer) Send(messages ...*Message) (int, error) {
+	return m.client.Load().(*SmtpClient).Send(messages...)
 }
 
 func NewSmtpClient(cfg setting.SmtpSettings) (*SmtpClient, error) {
@@ -39,9 +39,10 @@ func ProvideService(db db.DB, cfg *setting.Cfg, quotaService quota.Service) (org
 	}
 
 	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
-		TargetSrv:     quota.TargetSrv(org.QuotaTargetSrv),
-		DefaultLimits: defaultLimits,
-		Reporter:      s.Usage,
+		TargetSrv:         quota.TargetSrv(org.QuotaTargetSrv),
+		DefaultLimits:     defaultLimits,
+		Reporter:          s.Usage,
+		ReadDefaultLimits: readQuotaConfig,
 	}); err != nil {
 		return s, nil
 	}
@@ -234,10 +235,10 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 		return limits, err
 	}
 
-	limits.Set(globalQuotaTag, cfg.Quota.Global.Org)
+	limits.Set(globalQuotaTag, cfg.QuotaSettings().Global.Org)
 	// users per org
-	limits.Set(orgQuotaTag, cfg.Quota.Org.User)
+	limits.Set(orgQuotaTag, cfg.QuotaSettings().Org.User)
 	// orgs per user
-	limits.Set(userTag, cfg.Quota.User.Org)
+	limits.Set(userTag, cfg.QuotaSettings().User.Org)
 	return limits, nil
 }
@@ -0,0 +1,111 @@
+package clientmiddleware
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"go.opentelemetry.io/otel/baggage"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/util/proxyutil"
+)
+
+// NewBaggageMiddleware creates a new plugins.ClientMiddleware that will
+// propagate the identifiers of the user, organization, dashboard, panel and
+// data source of outgoing plugins.Client requests as W3C baggage, in the
+// baggage header and in the context. The values of the redacted keys are
+// hashed.
+func NewBaggageMiddleware(redactedKeys map[string]bool) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &BaggageMiddleware{
+			next:         next,
+			redactedKeys: redactedKeys,
+		}
+	})
+}
+
+type BaggageMiddleware struct {
+	next         plugins.Client
+	redactedKeys map[string]bool
+}
+
+func (m *BaggageMiddleware) applyBaggage(ctx context.Context, pCtx backend.PluginContext, h backend.ForwardHTTPHeaders) context.Context {
+	if h == nil {
+		return ctx
+	}
+
+	members := map[string]string{}
+	// the requests without HTTP request context, like the alert rule queries, are identified by their plugin context
+	if pCtx.OrgID != 0 {
+		members[proxyutil.BaggageOrgIDKey] = strconv.FormatInt(pCtx.OrgID, 10)
+	}
+	if pCtx.User != nil {
+		members[proxyutil.BaggageUserLoginKey] = pCtx.User.Login
+	}
+	if pCtx.DataSourceInstanceSettings != nil {
+		members[proxyutil.BaggageDatasourceUIDKey] = pCtx.DataSourceInstanceSettings.UID
+	}
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Req != nil {
+		for k, v := range proxyutil.UserBaggageMembers(reqCtx.SignedInUser) {
+			members[k] = v
+		}
+		if reqCtx.SignedInUser != nil && reqCtx.IsAnonymous {
+			delete(members, proxyutil.BaggageUserLoginKey)
+		}
+		members[proxyutil.BaggageDashboardUIDKey] = reqCtx.Req.Header.Get(query.HeaderDashboardUID)
+		members[proxyutil.BaggagePanelIDKey] = reqCtx.Req.Header.Get(query.HeaderPanelID)
+	}
+
+	b := proxyutil.MergeBaggage(baggage.FromContext(ctx), members, m.redactedKeys)
+	if b.Len() == 0 {
+		return ctx
+	}
+	h.SetHTTPHeader(proxyutil.BaggageHeaderName, b.String())
+	return baggage.ContextWithBaggage(ctx, b)
+}
+
+func (m *BaggageMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	ctx = m.applyBaggage(ctx, req.PluginContext, req)
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *BaggageMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.next.CallResource(ctx, req, sender)
+	}
+
+	ctx = m.applyBaggage(ctx, req.PluginContext, req)
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *BaggageMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	if req == nil {
+		return m.next.CheckHealth(ctx, req)
+	}
+
+	ctx = m.applyBaggage(ctx, req.PluginContext, req)
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *BaggageMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *BaggageMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *BaggageMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *BaggageMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
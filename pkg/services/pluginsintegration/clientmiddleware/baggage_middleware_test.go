@@ -0,0 +1,92 @@
+package clientmiddleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/proxyutil"
+)
+
+func TestBaggageMiddleware(t *testing.T) {
+	pluginCtx := backend.PluginContext{
+		OrgID:                      1,
+		User:                       &backend.User{Login: "admin"},
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds-uid"},
+	}
+
+	t.Run("Should propagate the identifiers of the request as baggage", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+		req.Header.Set(`X-Dashboard-Uid`, "dash-uid")
+		req.Header.Set(`X-Panel-Id`, "2")
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{UserID: 3, OrgID: 1, Login: "admin"}),
+			clienttest.WithMiddlewares(NewBaggageMiddleware(map[string]bool{})),
+		)
+
+		_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+			PluginContext: pluginCtx,
+			Headers:       map[string]string{},
+		})
+		require.NoError(t, err)
+
+		b, err := baggage.Parse(cdt.QueryDataReq.GetHTTPHeader(proxyutil.BaggageHeaderName))
+		require.NoError(t, err)
+		require.Equal(t, "3", b.Member(proxyutil.BaggageUserIDKey).Value())
+		require.Equal(t, "admin", b.Member(proxyutil.BaggageUserLoginKey).Value())
+		require.Equal(t, "1", b.Member(proxyutil.BaggageOrgIDKey).Value())
+		require.Equal(t, "dash-uid", b.Member(proxyutil.BaggageDashboardUIDKey).Value())
+		require.Equal(t, "2", b.Member(proxyutil.BaggagePanelIDKey).Value())
+		require.Equal(t, "ds-uid", b.Member(proxyutil.BaggageDatasourceUIDKey).Value())
+	})
+
+	t.Run("Should redact the values of the redacted keys", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{UserID: 3, OrgID: 1, Login: "admin"}),
+			clienttest.WithMiddlewares(NewBaggageMiddleware(map[string]bool{proxyutil.BaggageUserLoginKey: true})),
+		)
+
+		_, err = cdt.Decorator.CheckHealth(req.Context(), &backend.CheckHealthRequest{
+			PluginContext: pluginCtx,
+			Headers:       map[string]string{},
+		})
+		require.NoError(t, err)
+
+		b, err := baggage.Parse(cdt.CheckHealthReq.GetHTTPHeader(proxyutil.BaggageHeaderName))
+		require.NoError(t, err)
+		login := b.Member(proxyutil.BaggageUserLoginKey).Value()
+		require.NotEmpty(t, login)
+		require.NotEqual(t, "admin", login)
+	})
+
+	t.Run("Should not identify anonymous users", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{OrgID: 1, IsAnonymous: true, Login: "anonymous"}),
+			clienttest.WithMiddlewares(NewBaggageMiddleware(map[string]bool{})),
+		)
+
+		err = cdt.Decorator.CallResource(req.Context(), &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{OrgID: 1, User: &backend.User{Login: "anonymous"}},
+			Headers:       map[string][]string{},
+		}, nopCallResourceSender)
+		require.NoError(t, err)
+
+		b, err := baggage.Parse(cdt.CallResourceReq.GetHTTPHeader(proxyutil.BaggageHeaderName))
+		require.NoError(t, err)
+		require.Equal(t, "1", b.Member(proxyutil.BaggageOrgIDKey).Value())
+		require.Empty(t, b.Member(proxyutil.BaggageUserLoginKey).Value())
+	})
+}
@@ -0,0 +1,292 @@
+package clientmiddleware
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// dataplaneKind is a kind of the dataplane contract, the frames declare their kind with the type of their metadata or,
+// for the kinds without a frame type, with their preferred visualisation.
+type dataplaneKind string
+
+const (
+	dataplaneKindTimeSeriesMulti dataplaneKind = "timeseries-multi"
+	dataplaneKindLogs            dataplaneKind = "logs"
+	dataplaneKindTraces          dataplaneKind = "traces"
+	dataplaneKindNodeGraph       dataplaneKind = "nodegraph"
+)
+
+// traceRequiredFields are the fields the trace view requires in the frames of a trace
+var traceRequiredFields = []string{"traceID", "spanID", "parentSpanID", "operationName", "serviceName", "startTime", "duration"}
+
+// NewDataplaneValidationMiddleware creates a new plugins.ClientMiddleware that will
+// validate the frames of the outgoing plugins.Client QueryData responses against
+// the dataplane contract of their kind, and add a warning notice to the frames
+// that violate it.
+func NewDataplaneValidationMiddleware() plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &DataplaneValidationMiddleware{
+			next: next,
+			log:  log.New("plugins.dataplane"),
+		}
+	})
+}
+
+type DataplaneValidationMiddleware struct {
+	next plugins.Client
+	log  log.Logger
+}
+
+func (m *DataplaneValidationMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp, err := m.next.QueryData(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	for refID, res := range resp.Responses {
+		for frame, violations := range validateDataplaneFrames(res.Frames) {
+			m.log.FromContext(ctx).Debug("Frame violates the dataplane contract", "pluginId", req.PluginContext.PluginID,
+				"refId", refID, "frame", frame.Name, "kind", frameDataplaneKind(frame), "violations", violations)
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text: fmt.Sprintf("The frame does not conform to the %s dataplane contract: %s", frameDataplaneKind(frame),
+					strings.Join(violations, "; ")),
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// frameDataplaneKind returns the kind the frame declares, or an empty kind when the frame declares none of the
+// validated kinds
+func frameDataplaneKind(frame *data.Frame) dataplaneKind {
+	if frame.Meta == nil {
+		return ""
+	}
+	if frame.Meta.Type == data.FrameTypeTimeSeriesMulti {
+		return dataplaneKindTimeSeriesMulti
+	}
+	switch frame.Meta.PreferredVisualization {
+	case data.VisTypeLogs:
+		return dataplaneKindLogs
+	case data.VisTypeTrace:
+		return dataplaneKindTraces
+	case data.VisTypeNodeGraph:
+		return dataplaneKindNodeGraph
+	}
+	return ""
+}
+
+// validateDataplaneFrames returns the violations of the contract of their kind for the frames of a response, the
+// frames without violations are not returned
+func validateDataplaneFrames(frames data.Frames) map[*data.Frame][]string {
+	violations := map[*data.Frame][]string{}
+	add := func(frame *data.Frame, v ...string) {
+		if len(v) > 0 {
+			violations[frame] = append(violations[frame], v...)
+		}
+	}
+
+	var series, nodes, edges []*data.Frame
+	for _, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		switch frameDataplaneKind(frame) {
+		case dataplaneKindTimeSeriesMulti:
+			add(frame, validateTimeSeriesMulti(frame)...)
+			series = append(series, frame)
+		case dataplaneKindLogs:
+			add(frame, validateLogs(frame)...)
+		case dataplaneKindTraces:
+			add(frame, validateTraces(frame)...)
+		case dataplaneKindNodeGraph:
+			// the edges are told apart from the nodes by their source field
+			if _, source := frame.FieldByName("source"); source >= 0 {
+				add(frame, requireFields(frame, "id", "source", "target")...)
+				edges = append(edges, frame)
+			} else {
+				add(frame, requireFields(frame, "id")...)
+				nodes = append(nodes, frame)
+			}
+		}
+	}
+
+	// the series of a response are told apart by the labels of their value field
+	seen := map[string]bool{}
+	for _, frame := range series {
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() {
+				continue
+			}
+			key := field.Name + field.Labels.String()
+			if seen[key] {
+				add(frame, fmt.Sprintf("another frame has a value field named %q with the labels %s", field.Name, field.Labels.String()))
+			}
+			seen[key] = true
+		}
+	}
+
+	if len(nodes) > 0 {
+		for _, frame := range edges {
+			add(frame, validateEdgeTargets(frame, nodes)...)
+		}
+	}
+
+	return violations
+}
+
+func validateTimeSeriesMulti(frame *data.Frame) []string {
+	// a frame without fields is a series without data
+	if len(frame.Fields) == 0 {
+		return nil
+	}
+
+	var violations []string
+	var timeFields, valueFields []*data.Field
+	for _, field := range frame.Fields {
+		switch {
+		case field.Type() == data.FieldTypeTime || field.Type() == data.FieldTypeNullableTime:
+			timeFields = append(timeFields, field)
+		case field.Type().Numeric():
+			valueFields = append(valueFields, field)
+		}
+	}
+	if len(timeFields) != 1 {
+		violations = append(violations, fmt.Sprintf("expected one time field, found %d", len(timeFields)))
+	}
+	if len(valueFields) != 1 {
+		violations = append(violations, fmt.Sprintf("expected one numeric value field, found %d", len(valueFields)))
+	}
+	if len(timeFields) == 1 {
+		if timeFields[0].Type() == data.FieldTypeNullableTime {
+			violations = append(violations, "the time field must not be nullable")
+		} else if !isSortedAscending(timeFields[0]) {
+			violations = append(violations, "the time field is not sorted in ascending order")
+		}
+	}
+	return violations
+}
+
+func validateLogs(frame *data.Frame) []string {
+	var violations []string
+	if !hasFieldOfType(frame, data.FieldTypeTime, data.FieldTypeNullableTime) {
+		violations = append(violations, "expected a time field with the timestamps of the lines")
+	}
+	if !hasFieldOfType(frame, data.FieldTypeString, data.FieldTypeNullableString) {
+		violations = append(violations, "expected a string field with the body of the lines")
+	}
+	return violations
+}
+
+func validateTraces(frame *data.Frame) []string {
+	return requireFields(frame, traceRequiredFields...)
+}
+
+// validateEdgeTargets checks the edges link the nodes of the node frames of the response
+func validateEdgeTargets(edges *data.Frame, nodes []*data.Frame) []string {
+	ids := map[string]bool{}
+	for _, frame := range nodes {
+		field, _ := frame.FieldByName("id")
+		if field == nil {
+			return nil
+		}
+		for i := 0; i < field.Len(); i++ {
+			if id, ok := field.ConcreteAt(i); ok {
+				ids[fmt.Sprint(id)] = true
+			}
+		}
+	}
+
+	unknown := map[string]bool{}
+	for _, name := range []string{"source", "target"} {
+		field, _ := edges.FieldByName(name)
+		if field == nil {
+			continue
+		}
+		for i := 0; i < field.Len(); i++ {
+			id, ok := field.ConcreteAt(i)
+			if ok && !ids[fmt.Sprint(id)] {
+				unknown[fmt.Sprint(id)] = true
+			}
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sorted := make([]string, 0, len(unknown))
+	for id := range unknown {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+	return []string{fmt.Sprintf("the edges link unknown nodes: %s", strings.Join(sorted, ", "))}
+}
+
+func requireFields(frame *data.Frame, names ...string) []string {
+	var missing []string
+	for _, name := range names {
+		if _, idx := frame.FieldByName(name); idx < 0 {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("missing the fields %s", strings.Join(missing, ", "))}
+}
+
+func hasFieldOfType(frame *data.Frame, types ...data.FieldType) bool {
+	for _, field := range frame.Fields {
+		for _, t := range types {
+			if field.Type() == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isSortedAscending(field *data.Field) bool {
+	for i := 1; i < field.Len(); i++ {
+		prev, _ := field.At(i - 1).(time.Time)
+		cur, _ := field.At(i).(time.Time)
+		if cur.Before(prev) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *DataplaneValidationMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *DataplaneValidationMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *DataplaneValidationMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *DataplaneValidationMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *DataplaneValidationMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *DataplaneValidationMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
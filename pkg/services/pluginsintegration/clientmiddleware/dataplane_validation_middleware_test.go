@@ -0,0 +1,110 @@
+package clientmiddleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataplaneValidationMiddleware(t *testing.T) {
+	t0 := time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+
+	series := func(times []time.Time, labels data.Labels) *data.Frame {
+		return data.NewFrame("",
+			data.NewField("time", nil, times),
+			data.NewField("value", labels, make([]float64, len(times))),
+		).SetMeta(&data.FrameMeta{Type: data.FrameTypeTimeSeriesMulti})
+	}
+	nodeGraph := func(fields ...*data.Field) *data.Frame {
+		return data.NewFrame("", fields...).SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeNodeGraph})
+	}
+
+	query := func(t *testing.T, frames ...*data.Frame) data.Frames {
+		t.Helper()
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewDataplaneValidationMiddleware()))
+		cdt.TestClient.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			return &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: frames}}}, nil
+		}
+		resp, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: backend.PluginContext{PluginID: "test"}})
+		require.NoError(t, err)
+		return resp.Responses["A"].Frames
+	}
+	notices := func(frame *data.Frame) []string {
+		if frame.Meta == nil {
+			return nil
+		}
+		texts := make([]string, 0, len(frame.Meta.Notices))
+		for _, n := range frame.Meta.Notices {
+			require.Equal(t, data.NoticeSeverityWarning, n.Severity)
+			texts = append(texts, n.Text)
+		}
+		return texts
+	}
+
+	t.Run("should not add notices to valid frames", func(t *testing.T) {
+		frames := query(t,
+			series([]time.Time{t0, t1}, data.Labels{"job": "a"}),
+			series([]time.Time{t0, t1}, data.Labels{"job": "b"}),
+			data.NewFrame("").SetMeta(&data.FrameMeta{Type: data.FrameTypeTimeSeriesMulti}),
+			data.NewFrame("logs",
+				data.NewField("timestamp", nil, []time.Time{t0}),
+				data.NewField("body", nil, []string{"line"}),
+			).SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeLogs}),
+			nodeGraph(data.NewField("id", nil, []string{"a", "b"})),
+			nodeGraph(
+				data.NewField("id", nil, []string{"a_b"}),
+				data.NewField("source", nil, []string{"a"}),
+				data.NewField("target", nil, []string{"b"}),
+			),
+			data.NewFrame("table", data.NewField("value", nil, []string{"x"})),
+		)
+		for _, frame := range frames {
+			require.Empty(t, notices(frame))
+		}
+	})
+
+	t.Run("should add a notice to the time series violating the contract", func(t *testing.T) {
+		unsorted := series([]time.Time{t1, t0}, data.Labels{"job": "a"})
+		duplicate := series([]time.Time{t0, t1}, data.Labels{"job": "a"})
+		twoValues := series([]time.Time{t0}, data.Labels{"job": "c"})
+		twoValues.Fields = append(twoValues.Fields, data.NewField("other", nil, []float64{1}))
+
+		frames := query(t, unsorted, duplicate, twoValues)
+		require.Equal(t, []string{"The frame does not conform to the timeseries-multi dataplane contract: the time field is not sorted in ascending order"}, notices(frames[0]))
+		require.Equal(t, []string{`The frame does not conform to the timeseries-multi dataplane contract: another frame has a value field named "value" with the labels job=a`}, notices(frames[1]))
+		require.Equal(t, []string{"The frame does not conform to the timeseries-multi dataplane contract: expected one numeric value field, found 2"}, notices(frames[2]))
+	})
+
+	t.Run("should add a notice to the logs and traces missing fields", func(t *testing.T) {
+		frames := query(t,
+			data.NewFrame("logs", data.NewField("body", nil, []string{"line"})).SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeLogs}),
+			data.NewFrame("trace",
+				data.NewField("traceID", nil, []string{"t"}),
+				data.NewField("spanID", nil, []string{"s"}),
+			).SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTrace}),
+		)
+		require.Equal(t, []string{"The frame does not conform to the logs dataplane contract: expected a time field with the timestamps of the lines"}, notices(frames[0]))
+		require.Equal(t, []string{"The frame does not conform to the traces dataplane contract: missing the fields parentSpanID, operationName, serviceName, startTime, duration"}, notices(frames[1]))
+	})
+
+	t.Run("should add a notice to the edges linking unknown nodes", func(t *testing.T) {
+		frames := query(t,
+			nodeGraph(data.NewField("id", nil, []string{"a"})),
+			nodeGraph(
+				data.NewField("id", nil, []string{"a_b", "c_a"}),
+				data.NewField("source", nil, []string{"a", "c"}),
+				data.NewField("target", nil, []string{"b", "a"}),
+			),
+			nodeGraph(data.NewField("source", nil, []string{"a"})),
+		)
+		require.Empty(t, notices(frames[0]))
+		require.Equal(t, []string{"The frame does not conform to the nodegraph dataplane contract: the edges link unknown nodes: b, c"}, notices(frames[1]))
+		require.Equal(t, []string{"The frame does not conform to the nodegraph dataplane contract: missing the fields id, target"}, notices(frames[2]))
+	})
+}
@@ -0,0 +1,118 @@
+package clientmiddleware
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/auth/identitytoken"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+// NewIdentityTokenMiddleware creates a new plugins.ClientMiddleware that will
+// set a signed JWT describing the querying user on outgoing plugins.Client
+// requests if the datasource has enabled forwardIdentityToken.
+func NewIdentityTokenMiddleware(identityTokens *identitytoken.Service) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &IdentityTokenMiddleware{
+			next:           next,
+			identityTokens: identityTokens,
+		}
+	})
+}
+
+type IdentityTokenMiddleware struct {
+	identityTokens *identitytoken.Service
+	next           plugins.Client
+}
+
+func (m *IdentityTokenMiddleware) applyIdentityToken(ctx context.Context, pCtx backend.PluginContext, h backend.ForwardHTTPHeaders) error {
+	reqCtx := contexthandler.FromContext(ctx)
+	// if request not for a datasource or no HTTP request context skip middleware
+	if h == nil || pCtx.DataSourceInstanceSettings == nil || reqCtx == nil || reqCtx.Req == nil || reqCtx.SignedInUser == nil {
+		return nil
+	}
+
+	settings := pCtx.DataSourceInstanceSettings
+	jsonData, err := simplejson.NewJson(settings.JSONData)
+	if err != nil {
+		return err
+	}
+	ds := &datasources.DataSource{
+		ID:       settings.ID,
+		UID:      settings.UID,
+		OrgID:    pCtx.OrgID,
+		JsonData: jsonData,
+	}
+	if !m.identityTokens.IsEnabled(ds) {
+		return nil
+	}
+
+	// the token of another user is never forwarded
+	h.DeleteHTTPHeader(m.identityTokens.HeaderName())
+	token, err := m.identityTokens.Token(reqCtx.SignedInUser, ds)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		h.SetHTTPHeader(m.identityTokens.HeaderName(), token)
+	}
+	return nil
+}
+
+func (m *IdentityTokenMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	err := m.applyIdentityToken(ctx, req.PluginContext, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *IdentityTokenMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.next.CallResource(ctx, req, sender)
+	}
+
+	err := m.applyIdentityToken(ctx, req.PluginContext, req)
+	if err != nil {
+		return err
+	}
+
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *IdentityTokenMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	if req == nil {
+		return m.next.CheckHealth(ctx, req)
+	}
+
+	err := m.applyIdentityToken(ctx, req.PluginContext, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *IdentityTokenMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *IdentityTokenMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *IdentityTokenMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *IdentityTokenMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
@@ -0,0 +1,109 @@
+package clientmiddleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/grafana/grafana/pkg/services/auth/identitytoken"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityTokenMiddleware(t *testing.T) {
+	const headerName = "X-Grafana-Id"
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	cfg := setting.NewCfg()
+	cfg.DataProxyIdentityToken = setting.IdentityTokenSettings{SigningKeyFile: keyFile, HeaderName: headerName}
+	identityTokens, err := identitytoken.ProvideService(cfg)
+	require.NoError(t, err)
+
+	subject := func(t *testing.T, token string) string {
+		t.Helper()
+		parsed, err := jwt.ParseSigned(token)
+		require.NoError(t, err)
+		var claims identitytoken.Claims
+		require.NoError(t, parsed.Claims(publicKey, &claims))
+		return claims.Subject
+	}
+
+	t.Run("When forwardIdentityToken is enabled", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{UserID: 2, OrgID: 1, Login: "alice"}),
+			clienttest.WithMiddlewares(NewIdentityTokenMiddleware(identityTokens)),
+		)
+
+		pluginCtx := backend.PluginContext{
+			OrgID:                      1,
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "tempo", JSONData: []byte(`{"forwardIdentityToken": true}`)},
+		}
+
+		t.Run("Should set the identity token when calling QueryData", func(t *testing.T) {
+			queryReq := &backend.QueryDataRequest{PluginContext: pluginCtx, Headers: map[string]string{}}
+			queryReq.SetHTTPHeader(headerName, "forged")
+			_, err = cdt.Decorator.QueryData(req.Context(), queryReq)
+			require.NoError(t, err)
+			require.NotNil(t, cdt.QueryDataReq)
+			require.Equal(t, "user:2", subject(t, cdt.QueryDataReq.GetHTTPHeader(headerName)))
+		})
+
+		t.Run("Should set the identity token when calling CallResource", func(t *testing.T) {
+			err = cdt.Decorator.CallResource(req.Context(), &backend.CallResourceRequest{
+				PluginContext: pluginCtx,
+				Headers:       map[string][]string{},
+			}, nopCallResourceSender)
+			require.NoError(t, err)
+			require.NotNil(t, cdt.CallResourceReq)
+			require.Len(t, cdt.CallResourceReq.Headers[headerName], 1)
+			require.Equal(t, "user:2", subject(t, cdt.CallResourceReq.Headers[headerName][0]))
+		})
+
+		t.Run("Should set the identity token when calling CheckHealth", func(t *testing.T) {
+			_, err = cdt.Decorator.CheckHealth(req.Context(), &backend.CheckHealthRequest{
+				PluginContext: pluginCtx,
+				Headers:       map[string]string{},
+			})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.CheckHealthReq)
+			require.Equal(t, "user:2", subject(t, cdt.CheckHealthReq.GetHTTPHeader(headerName)))
+		})
+	})
+
+	t.Run("When forwardIdentityToken is not enabled", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{UserID: 2, OrgID: 1, Login: "alice"}),
+			clienttest.WithMiddlewares(NewIdentityTokenMiddleware(identityTokens)),
+		)
+
+		_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "tempo", JSONData: []byte(`{}`)},
+			},
+			Headers: map[string]string{},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+		require.Empty(t, cdt.QueryDataReq.GetHTTPHeader(headerName))
+	})
+}
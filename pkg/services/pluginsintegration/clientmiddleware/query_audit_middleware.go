@@ -0,0 +1,155 @@
+package clientmiddleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
+)
+
+// NewQueryAuditMiddleware creates a new plugins.ClientMiddleware that will
+// record who executed every query of the outgoing plugins.Client QueryData
+// requests and every CallResource request, with the raw query and its
+// result, in the query audit.
+func NewQueryAuditMiddleware(audit queryaudit.Service) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &QueryAuditMiddleware{
+			next:  next,
+			audit: audit,
+			now:   time.Now,
+		}
+	})
+}
+
+type QueryAuditMiddleware struct {
+	next  plugins.Client
+	audit queryaudit.Service
+	now   func() time.Time
+}
+
+func (m *QueryAuditMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	start := m.now()
+	resp, err := m.next.QueryData(ctx, req)
+	base := m.baseEvent(ctx, req.PluginContext, queryaudit.KindQuery, start)
+
+	events := make([]queryaudit.Event, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		e := base
+		e.RefID = q.RefID
+		e.QueryType = q.QueryType
+		e.Query = json.RawMessage(q.JSON)
+		e.From = q.TimeRange.From
+		e.To = q.TimeRange.To
+		e.Status = queryaudit.StatusSuccess
+		if err != nil {
+			e.Status, e.Error = queryaudit.StatusError, err.Error()
+		} else if resp != nil {
+			if r, ok := resp.Responses[q.RefID]; ok && r.Error != nil {
+				e.Status, e.Error = queryaudit.StatusError, r.Error.Error()
+			}
+		}
+		events = append(events, e)
+	}
+	m.audit.Record(events...)
+
+	return resp, err
+}
+
+func (m *QueryAuditMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return m.next.CallResource(ctx, req, sender)
+	}
+
+	// the status of a streamed response is the status of its first message
+	status := 0
+	recordingSender := callResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+		if status == 0 && res != nil {
+			status = res.Status
+		}
+		return sender.Send(res)
+	})
+
+	start := m.now()
+	err := m.next.CallResource(ctx, req, recordingSender)
+
+	e := m.baseEvent(ctx, req.PluginContext, queryaudit.KindResource, start)
+	var params url.Values
+	if u, parseErr := url.Parse(req.URL); parseErr == nil {
+		params = u.Query()
+	}
+	var contentType string
+	if values := req.Headers["Content-Type"]; len(values) > 0 {
+		contentType = values[0]
+	}
+	e.Query = queryaudit.NewHTTPRequestQuery(req.Method, req.Path, params, contentType, req.Body)
+	e.Status = queryaudit.StatusSuccess
+	if err != nil {
+		e.Status, e.Error = queryaudit.StatusError, err.Error()
+	} else if status >= http.StatusBadRequest {
+		e.Status, e.Error = queryaudit.StatusError, fmt.Sprintf("the resource call returned the status %d", status)
+	}
+	m.audit.Record(e)
+
+	return err
+}
+
+// baseEvent returns the event of a request of the plugin context, with the user and the dashboard panel that sent it
+func (m *QueryAuditMiddleware) baseEvent(ctx context.Context, pCtx backend.PluginContext, kind string, start time.Time) queryaudit.Event {
+	e := queryaudit.Event{
+		Timestamp:      start,
+		Kind:           kind,
+		OrgID:          pCtx.OrgID,
+		DatasourceUID:  pCtx.DataSourceInstanceSettings.UID,
+		DatasourceName: pCtx.DataSourceInstanceSettings.Name,
+		DatasourceType: pCtx.PluginID,
+		DurationMs:     m.now().Sub(start).Milliseconds(),
+	}
+	// the requests without a signed in user, like the queries of alerting, only have the user of the plugin context
+	if pCtx.User != nil {
+		e.UserLogin = pCtx.User.Login
+	}
+	if signedInUser, err := appcontext.User(ctx); err == nil && signedInUser != nil {
+		e.UserID = signedInUser.UserID
+		e.UserLogin = signedInUser.Login
+		e.IsServiceAccount = signedInUser.IsServiceAccount
+	}
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Req != nil {
+		e.RemoteAddr = reqCtx.RemoteAddr()
+		e.DashboardUID = reqCtx.Req.Header.Get(query.HeaderDashboardUID)
+		e.PanelID = reqCtx.Req.Header.Get(query.HeaderPanelID)
+	}
+	return e
+}
+
+func (m *QueryAuditMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *QueryAuditMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *QueryAuditMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *QueryAuditMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *QueryAuditMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
@@ -0,0 +1,160 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueryAudit struct {
+	queryaudit.Service
+	events []queryaudit.Event
+}
+
+func (f *fakeQueryAudit) Record(events ...queryaudit.Event) {
+	f.events = append(f.events, events...)
+}
+
+func TestQueryAuditMiddleware(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "/api/ds/query", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Dashboard-Uid", "dash-uid")
+	req.Header.Set("X-Panel-Id", "4")
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	from := time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	queryReq := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			OrgID:    2,
+			PluginID: "mysql",
+			User:     &backend.User{Login: "plugin-user"},
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				UID:  "ds-uid",
+				Name: "MySQL",
+			},
+		},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"rawSql": "SELECT 1"}`), TimeRange: backend.TimeRange{From: from, To: to}},
+			{RefID: "B", QueryType: "table", JSON: []byte(`{"rawSql": "SELECT 2"}`), TimeRange: backend.TimeRange{From: from, To: to}},
+		},
+	}
+
+	t.Run("should record every query with the user that sent it", func(t *testing.T) {
+		audit := &fakeQueryAudit{}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{OrgID: 2, UserID: 5, Login: "auditor", IsServiceAccount: true}),
+			clienttest.WithMiddlewares(NewQueryAuditMiddleware(audit)),
+		)
+
+		_, err = cdt.Decorator.QueryData(req.Context(), queryReq)
+		require.NoError(t, err)
+
+		require.Len(t, audit.events, 2)
+		e := audit.events[0]
+		require.Equal(t, queryaudit.KindQuery, e.Kind)
+		require.Equal(t, int64(2), e.OrgID)
+		require.Equal(t, int64(5), e.UserID)
+		require.Equal(t, "auditor", e.UserLogin)
+		require.True(t, e.IsServiceAccount)
+		require.Equal(t, "10.0.0.1", e.RemoteAddr)
+		require.Equal(t, "ds-uid", e.DatasourceUID)
+		require.Equal(t, "MySQL", e.DatasourceName)
+		require.Equal(t, "mysql", e.DatasourceType)
+		require.Equal(t, "dash-uid", e.DashboardUID)
+		require.Equal(t, "4", e.PanelID)
+		require.Equal(t, "A", e.RefID)
+		require.JSONEq(t, `{"rawSql": "SELECT 1"}`, string(e.Query))
+		require.Equal(t, from, e.From)
+		require.Equal(t, to, e.To)
+		require.Equal(t, queryaudit.StatusSuccess, e.Status)
+		require.Equal(t, "B", audit.events[1].RefID)
+		require.Equal(t, "table", audit.events[1].QueryType)
+	})
+
+	t.Run("should record the error of the failed queries", func(t *testing.T) {
+		audit := &fakeQueryAudit{}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{OrgID: 2}),
+			clienttest.WithMiddlewares(NewQueryAuditMiddleware(audit)),
+		)
+		cdt.TestClient.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			return &backend.QueryDataResponse{Responses: backend.Responses{
+				"A": {},
+				"B": {Error: errors.New("syntax error")},
+			}}, nil
+		}
+
+		_, err = cdt.Decorator.QueryData(req.Context(), queryReq)
+		require.NoError(t, err)
+
+		require.Len(t, audit.events, 2)
+		require.Equal(t, queryaudit.StatusSuccess, audit.events[0].Status)
+		require.Equal(t, queryaudit.StatusError, audit.events[1].Status)
+		require.Equal(t, "syntax error", audit.events[1].Error)
+	})
+
+	t.Run("should record the user of the plugin context without an HTTP request", func(t *testing.T) {
+		audit := &fakeQueryAudit{}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithMiddlewares(NewQueryAuditMiddleware(audit)),
+		)
+
+		_, err = cdt.Decorator.QueryData(context.Background(), queryReq)
+		require.NoError(t, err)
+
+		require.Len(t, audit.events, 2)
+		require.Equal(t, "plugin-user", audit.events[0].UserLogin)
+		require.Zero(t, audit.events[0].UserID)
+		require.Empty(t, audit.events[0].DashboardUID)
+	})
+
+	t.Run("should record the resource calls", func(t *testing.T) {
+		audit := &fakeQueryAudit{}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{OrgID: 2, UserID: 5, Login: "auditor"}),
+			clienttest.WithMiddlewares(NewQueryAuditMiddleware(audit)),
+		)
+		cdt.TestClient.CallResourceFunc = func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+			return sender.Send(&backend.CallResourceResponse{Status: http.StatusBadRequest})
+		}
+
+		var status int
+		err = cdt.Decorator.CallResource(req.Context(), &backend.CallResourceRequest{
+			PluginContext: queryReq.PluginContext,
+			Method:        http.MethodPost,
+			Path:          "api/v1/series",
+			URL:           "api/v1/series?start=10",
+			Headers:       map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}},
+			Body:          []byte("match[]=up"),
+		}, callResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+			status = res.Status
+			return nil
+		}))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		require.Len(t, audit.events, 1)
+		e := audit.events[0]
+		require.Equal(t, queryaudit.KindResource, e.Kind)
+		require.Equal(t, int64(5), e.UserID)
+		require.Equal(t, "ds-uid", e.DatasourceUID)
+		require.Equal(t, "dash-uid", e.DashboardUID)
+		require.JSONEq(t, `{
+			"method": "POST",
+			"path": "api/v1/series",
+			"params": {"start": ["10"]},
+			"body": {"match[]": ["up"]}
+		}`, string(e.Query))
+		require.Equal(t, queryaudit.StatusError, e.Status)
+		require.Equal(t, "the resource call returned the status 400", e.Error)
+	})
+}
@@ -0,0 +1,61 @@
+package clientmiddleware
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/querycapture"
+)
+
+// NewQueryCaptureMiddleware creates a new plugins.ClientMiddleware that will
+// record the outgoing HTTP requests of the datasources, and their responses,
+// in the capture of the query execution when it is captured.
+func NewQueryCaptureMiddleware() plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &QueryCaptureMiddleware{
+			next: next,
+		}
+	})
+}
+
+type QueryCaptureMiddleware struct {
+	next plugins.Client
+}
+
+func (m *QueryCaptureMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	if recorder := querycapture.RecorderFromContext(ctx); recorder != nil {
+		ctx = httpclient.WithContextualMiddleware(ctx, querycapture.Middleware(recorder, req.PluginContext.DataSourceInstanceSettings))
+	}
+
+	return m.next.QueryData(ctx, req)
+}
+
+func (m *QueryCaptureMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *QueryCaptureMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *QueryCaptureMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *QueryCaptureMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *QueryCaptureMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *QueryCaptureMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
@@ -0,0 +1,54 @@
+package clientmiddleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/grafana/grafana/pkg/services/querycapture"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCaptureMiddleware(t *testing.T) {
+	pluginCtx := backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds-uid"},
+	}
+
+	contextualMiddlewareNames := func(ctx context.Context) []string {
+		names := []string{}
+		for _, mw := range httpclient.ContextualMiddlewareFromContext(ctx) {
+			if m, ok := mw.(httpclient.MiddlewareName); ok {
+				names = append(names, m.MiddlewareName())
+			}
+		}
+		return names
+	}
+
+	t.Run("should add the capture middleware when the query execution is captured", func(t *testing.T) {
+		recorder, err := querycapture.ProvideService(routing.NewRouteRegister()).Start(&user.SignedInUser{OrgID: 1, UserID: 1})
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithMiddlewares(NewQueryCaptureMiddleware()),
+		)
+
+		ctx := querycapture.WithRecorder(context.Background(), recorder)
+		_, err = cdt.Decorator.QueryData(ctx, &backend.QueryDataRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+		require.Equal(t, []string{querycapture.MiddlewareName}, contextualMiddlewareNames(cdt.QueryDataCtx))
+	})
+
+	t.Run("should not add the capture middleware when the query execution is not captured", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithMiddlewares(NewQueryCaptureMiddleware()),
+		)
+
+		_, err := cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+		require.Empty(t, contextualMiddlewareNames(cdt.QueryDataCtx))
+	})
+}
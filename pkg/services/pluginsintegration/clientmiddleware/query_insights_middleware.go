@@ -0,0 +1,87 @@
+package clientmiddleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/queryinsights"
+)
+
+// NewQueryInsightsMiddleware creates a new plugins.ClientMiddleware that will
+// record the duration and the result of the datasource queries, with the
+// dashboard that sent them, in the query insights.
+func NewQueryInsightsMiddleware(insights queryinsights.Service) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &QueryInsightsMiddleware{
+			next:     next,
+			insights: insights,
+			now:      time.Now,
+		}
+	})
+}
+
+type QueryInsightsMiddleware struct {
+	next     plugins.Client
+	insights queryinsights.Service
+	now      func() time.Time
+}
+
+func (m *QueryInsightsMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	start := m.now()
+	resp, err := m.next.QueryData(ctx, req)
+
+	q := queryinsights.Query{
+		OrgID:          req.PluginContext.OrgID,
+		DatasourceUID:  req.PluginContext.DataSourceInstanceSettings.UID,
+		DatasourceName: req.PluginContext.DataSourceInstanceSettings.Name,
+		DatasourceType: req.PluginContext.PluginID,
+		Duration:       m.now().Sub(start),
+		Failed:         err != nil,
+	}
+	if resp != nil {
+		for _, r := range resp.Responses {
+			if r.Error != nil {
+				q.Failed = true
+				break
+			}
+		}
+	}
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Req != nil {
+		q.DashboardUID = reqCtx.Req.Header.Get(query.HeaderDashboardUID)
+	}
+	m.insights.Record(q)
+
+	return resp, err
+}
+
+func (m *QueryInsightsMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *QueryInsightsMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *QueryInsightsMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+func (m *QueryInsightsMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *QueryInsightsMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *QueryInsightsMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
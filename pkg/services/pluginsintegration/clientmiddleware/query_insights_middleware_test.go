@@ -0,0 +1,92 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/grafana/grafana/pkg/services/queryinsights"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueryInsights struct {
+	queryinsights.Service
+	queries []queryinsights.Query
+}
+
+func (f *fakeQueryInsights) Record(query queryinsights.Query) {
+	f.queries = append(f.queries, query)
+}
+
+func TestQueryInsightsMiddleware(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "/api/ds/query", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Dashboard-Uid", "dash-uid")
+
+	pluginCtx := backend.PluginContext{
+		OrgID:    2,
+		PluginID: "prometheus",
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+			UID:  "ds-uid",
+			Name: "Prometheus",
+		},
+	}
+
+	t.Run("should record the query with its dashboard", func(t *testing.T) {
+		insights := &fakeQueryInsights{}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{OrgID: 2}),
+			clienttest.WithMiddlewares(NewQueryInsightsMiddleware(insights)),
+		)
+
+		_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+
+		require.Len(t, insights.queries, 1)
+		q := insights.queries[0]
+		require.Equal(t, int64(2), q.OrgID)
+		require.Equal(t, "ds-uid", q.DatasourceUID)
+		require.Equal(t, "Prometheus", q.DatasourceName)
+		require.Equal(t, "prometheus", q.DatasourceType)
+		require.Equal(t, "dash-uid", q.DashboardUID)
+		require.False(t, q.Failed)
+	})
+
+	t.Run("should record the query as failed when one of the responses has an error", func(t *testing.T) {
+		insights := &fakeQueryInsights{}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithMiddlewares(NewQueryInsightsMiddleware(insights)),
+		)
+		cdt.TestClient.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			time.Sleep(time.Millisecond)
+			return &backend.QueryDataResponse{Responses: backend.Responses{
+				"A": {},
+				"B": {Error: errors.New("bad query")},
+			}}, nil
+		}
+
+		_, err = cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+
+		require.Len(t, insights.queries, 1)
+		require.True(t, insights.queries[0].Failed)
+		require.Empty(t, insights.queries[0].DashboardUID)
+		require.GreaterOrEqual(t, insights.queries[0].Duration, time.Millisecond)
+	})
+
+	t.Run("should not record queries without datasource", func(t *testing.T) {
+		insights := &fakeQueryInsights{}
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithMiddlewares(NewQueryInsightsMiddleware(insights)),
+		)
+
+		_, err = cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: backend.PluginContext{PluginID: "app"}})
+		require.NoError(t, err)
+		require.Empty(t, insights.queries)
+	})
+}
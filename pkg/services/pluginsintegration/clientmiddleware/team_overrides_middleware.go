@@ -0,0 +1,165 @@
+package clientmiddleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+)
+
+const teamOverridesMiddlewareName = "team-overrides"
+
+// NewTeamOverridesMiddleware creates a new plugins.ClientMiddleware that will
+// merge the team overrides of the datasource into outgoing plugins.Client requests.
+// The protected headers, set by the next middlewares, are never overridden.
+func NewTeamOverridesMiddleware(protectedHeaders ...string) plugins.ClientMiddleware {
+	return plugins.ClientMiddlewareFunc(func(next plugins.Client) plugins.Client {
+		return &TeamOverridesMiddleware{
+			next:             next,
+			protectedHeaders: protectedHeaders,
+		}
+	})
+}
+
+type TeamOverridesMiddleware struct {
+	next             plugins.Client
+	protectedHeaders []string
+}
+
+// applyTeamOverrides sets the headers of the teams of the user on the plugin request, and adds the query parameters
+// to the HTTP requests of the plugins using the Grafana HTTP client. The label matchers and the TraceQL filters of the
+// teams are added to the queries of the HTTP requests when enforceRequests is set, the backend queries are enforced
+// on their models instead.
+//
+// The user is the user of the HTTP request or the user set in the context by the services querying the datasources
+// without one, such as the asynchronous queries. The requests without a user, such as the alert rule evaluations, are
+// sent without overrides.
+func (m *TeamOverridesMiddleware) applyTeamOverrides(ctx context.Context, pCtx backend.PluginContext, h backend.ForwardHTTPHeaders, enforceRequests bool) (context.Context, datasources.RequestOverrides, error) {
+	if h == nil || pCtx.DataSourceInstanceSettings == nil {
+		return ctx, datasources.RequestOverrides{}, nil
+	}
+	signedInUser, err := appcontext.User(ctx)
+	if err != nil {
+		return ctx, datasources.RequestOverrides{}, nil
+	}
+
+	teamOverrides, err := datasources.TeamOverridesFromJSONData(pCtx.DataSourceInstanceSettings.JSONData)
+	if err != nil {
+		return ctx, datasources.RequestOverrides{}, err
+	}
+	overrides := teamOverrides.ForTeams(signedInUser.Teams).Without(m.protectedHeaders...)
+	if overrides.IsEmpty() {
+		return ctx, overrides, nil
+	}
+
+	for name, value := range overrides.Headers {
+		h.SetHTTPHeader(name, value)
+	}
+
+	dsType := pCtx.PluginID
+	mw := httpclient.NamedMiddlewareFunc(teamOverridesMiddlewareName, func(opts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			overrides.Apply(req)
+			if enforceRequests {
+				if err := overrides.EnforceRequest(dsType, req); err != nil {
+					return nil, err
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	})
+	return httpclient.WithContextualMiddleware(ctx, mw), overrides, nil
+}
+
+func (m *TeamOverridesMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil {
+		return m.next.QueryData(ctx, req)
+	}
+
+	ctx, overrides, err := m.applyTeamOverrides(ctx, req.PluginContext, req, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides.LabelMatchers) == 0 && len(overrides.TraceQLFilters) == 0 {
+		return m.next.QueryData(ctx, req)
+	}
+
+	// the queries of the request are not modified
+	queries := make([]backend.DataQuery, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		q.JSON, err = overrides.EnforceQueryModel(req.PluginContext.PluginID, q.QueryType, q.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add the team overrides to query %s: %w", q.RefID, err)
+		}
+		queries = append(queries, q)
+	}
+	enforced := *req
+	enforced.Queries = queries
+	return m.next.QueryData(ctx, &enforced)
+}
+
+func (m *TeamOverridesMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.next.CallResource(ctx, req, sender)
+	}
+
+	ctx, _, err := m.applyTeamOverrides(ctx, req.PluginContext, req, true)
+	if err != nil {
+		return err
+	}
+
+	return m.next.CallResource(ctx, req, sender)
+}
+
+func (m *TeamOverridesMiddleware) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	if req == nil {
+		return m.next.CheckHealth(ctx, req)
+	}
+
+	ctx, _, err := m.applyTeamOverrides(ctx, req.PluginContext, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.next.CheckHealth(ctx, req)
+}
+
+func (m *TeamOverridesMiddleware) CollectMetrics(ctx context.Context, req *backend.CollectMetricsRequest) (*backend.CollectMetricsResult, error) {
+	return m.next.CollectMetrics(ctx, req)
+}
+
+// SubscribeStream denies the streams of the users of teams with overrides, the streams run without the headers and
+// the queries of the subscriptions are shared by their subscribers
+func (m *TeamOverridesMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return m.next.SubscribeStream(ctx, req)
+	}
+
+	teamOverrides, err := datasources.TeamOverridesFromJSONData(req.PluginContext.DataSourceInstanceSettings.JSONData)
+	if err != nil {
+		return nil, err
+	}
+	if len(teamOverrides) > 0 {
+		// the teams of the users that are not known are assumed to have overrides
+		u, ok := livecontext.GetContextSignedUser(ctx)
+		if !ok || !teamOverrides.ForTeams(u.Teams).IsEmpty() {
+			return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusPermissionDenied}, nil
+		}
+	}
+
+	return m.next.SubscribeStream(ctx, req)
+}
+
+func (m *TeamOverridesMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return m.next.PublishStream(ctx, req)
+}
+
+func (m *TeamOverridesMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return m.next.RunStream(ctx, req, sender)
+}
@@ -0,0 +1,360 @@
+package clientmiddleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager/client/clienttest"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStreamClient struct {
+	plugins.Client
+	subscribed bool
+}
+
+func (c *fakeStreamClient) SubscribeStream(context.Context, *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	c.subscribed = true
+	return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusOK}, nil
+}
+
+func TestTeamOverridesMiddleware(t *testing.T) {
+	const policyHeader = "X-Prom-Label-Policy"
+	jsonData := []byte(`{"teamOverrides": {
+		"1": {"headers": [{"name": "x-prom-label-policy", "value": "team-a:{namespace=\"a\"}"}], "queryParams": [{"name": "namespace", "value": "a"}]},
+		"2": {"headers": [{"name": "X-Prom-Label-Policy", "value": "team-b:{namespace=\"b\"}"}], "queryParams": [{"name": "namespace", "value": "b"}]}
+	}}`)
+
+	t.Run("When the user is member of teams with overrides", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{Teams: []int64{2, 1, 3}}),
+			clienttest.WithMiddlewares(NewTeamOverridesMiddleware()),
+		)
+
+		pluginCtx := backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonData},
+		}
+
+		assertOutgoingRequest := func(t *testing.T, middlewares []httpclient.Middleware) {
+			t.Helper()
+			require.Len(t, middlewares, 1)
+			require.Equal(t, teamOverridesMiddlewareName, middlewares[0].(httpclient.MiddlewareName).MiddlewareName())
+
+			outReq, err := http.NewRequest(http.MethodGet, "http://prometheus/api/v1/query?query=up&namespace=other", nil)
+			require.NoError(t, err)
+			res, err := middlewares[0].CreateMiddleware(httpclient.Options{}, finalRoundTripper).RoundTrip(outReq)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.Equal(t, `team-a:{namespace="a"}, team-b:{namespace="b"}`, outReq.Header.Get(policyHeader))
+			require.Equal(t, []string{"a", "b"}, outReq.URL.Query()["namespace"])
+			require.Equal(t, "up", outReq.URL.Query().Get("query"))
+		}
+
+		t.Run("Should set the team overrides when calling QueryData", func(t *testing.T) {
+			_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+				PluginContext: pluginCtx,
+				Headers:       map[string]string{policyHeader: "forged"},
+			})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.QueryDataReq)
+			require.Equal(t, `team-a:{namespace="a"}, team-b:{namespace="b"}`, cdt.QueryDataReq.GetHTTPHeader(policyHeader))
+			assertOutgoingRequest(t, httpclient.ContextualMiddlewareFromContext(cdt.QueryDataCtx))
+		})
+
+		t.Run("Should set the team overrides when calling CallResource", func(t *testing.T) {
+			err = cdt.Decorator.CallResource(req.Context(), &backend.CallResourceRequest{
+				PluginContext: pluginCtx,
+				Headers:       map[string][]string{policyHeader: {"forged"}},
+			}, nopCallResourceSender)
+			require.NoError(t, err)
+			require.NotNil(t, cdt.CallResourceReq)
+			require.Equal(t, []string{`team-a:{namespace="a"}, team-b:{namespace="b"}`}, cdt.CallResourceReq.Headers[policyHeader])
+			assertOutgoingRequest(t, httpclient.ContextualMiddlewareFromContext(cdt.CallResourceCtx))
+		})
+
+		t.Run("Should set the team overrides when calling CheckHealth", func(t *testing.T) {
+			_, err = cdt.Decorator.CheckHealth(req.Context(), &backend.CheckHealthRequest{
+				PluginContext: pluginCtx,
+				Headers:       map[string]string{},
+			})
+			require.NoError(t, err)
+			require.NotNil(t, cdt.CheckHealthReq)
+			require.Equal(t, `team-a:{namespace="a"}, team-b:{namespace="b"}`, cdt.CheckHealthReq.GetHTTPHeader(policyHeader))
+			assertOutgoingRequest(t, httpclient.ContextualMiddlewareFromContext(cdt.CheckHealthCtx))
+		})
+	})
+
+	t.Run("When the user is not member of teams with overrides", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{Teams: []int64{3}}),
+			clienttest.WithMiddlewares(NewTeamOverridesMiddleware()),
+		)
+
+		_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonData},
+			},
+			Headers: map[string]string{},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+		require.Empty(t, cdt.QueryDataReq.Headers)
+		require.Empty(t, httpclient.ContextualMiddlewareFromContext(cdt.QueryDataCtx))
+	})
+
+	t.Run("Should not override the protected headers", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{Teams: []int64{1}}),
+			clienttest.WithMiddlewares(NewTeamOverridesMiddleware("x-grafana-id")),
+		)
+
+		_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					JSONData: []byte(`{"teamOverrides": {"1": {"headers": [{"name": "X-Grafana-Id", "value": "forged"}, {"name": "X-Scope-OrgID", "value": "a"}]}}}`),
+				},
+			},
+			Headers: map[string]string{},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+		require.Empty(t, cdt.QueryDataReq.GetHTTPHeader("X-Grafana-Id"))
+		require.Equal(t, "a", cdt.QueryDataReq.GetHTTPHeader("X-Scope-OrgID"))
+
+		middlewares := httpclient.ContextualMiddlewareFromContext(cdt.QueryDataCtx)
+		require.Len(t, middlewares, 1)
+		outReq, err := http.NewRequest(http.MethodGet, "http://tempo/api/search", nil)
+		require.NoError(t, err)
+		outReq.Header.Set("X-Grafana-Id", "token")
+		res, err := middlewares[0].CreateMiddleware(httpclient.Options{}, finalRoundTripper).RoundTrip(outReq)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, "token", outReq.Header.Get("X-Grafana-Id"))
+	})
+
+	t.Run("When the team overrides are invalid", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{Teams: []int64{1}}),
+			clienttest.WithMiddlewares(NewTeamOverridesMiddleware()),
+		)
+
+		_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					JSONData: []byte(`{"teamOverrides": {"1": {"headers": [{"value": "no name"}]}}}`),
+				},
+			},
+		})
+		require.Error(t, err)
+		require.Nil(t, cdt.QueryDataReq)
+	})
+	t.Run("Should add the label matchers and the TraceQL filters to the queries", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{Teams: []int64{1}}),
+			clienttest.WithMiddlewares(NewTeamOverridesMiddleware()),
+		)
+		enforcers := []byte(`{"teamOverrides": {"1": {
+			"labelMatchers": ["namespace=\"a\""],
+			"traceQLFilters": [{"scope": "resource", "tag": "namespace", "operator": "=", "value": "a"}]
+		}}}`)
+
+		queries := []backend.DataQuery{{RefID: "A", JSON: []byte(`{"expr": "sum(rate(up[5m]))"}`)}}
+		_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				PluginID:                   "prometheus",
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: enforcers},
+			},
+			Headers: map[string]string{},
+			Queries: queries,
+		})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"expr": "sum(rate(up{namespace=\"a\"}[5m]))"}`, string(cdt.QueryDataReq.Queries[0].JSON))
+		require.JSONEq(t, `{"expr": "sum(rate(up[5m]))"}`, string(queries[0].JSON))
+
+		_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				PluginID:                   "tempo",
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: enforcers},
+			},
+			Headers: map[string]string{},
+			Queries: []backend.DataQuery{
+				{RefID: "A", QueryType: "errorSummary", JSON: []byte(`{"query": "{ .a = 1 }"}`)},
+				{RefID: "B", QueryType: "flameGraph", JSON: []byte(`{"query": "", "filters": [{"id": "1", "tag": "name", "operator": "=", "value": "b"}]}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"query": "{ (.a = 1) && resource.namespace = \"a\" }"}`, string(cdt.QueryDataReq.Queries[0].JSON))
+		require.JSONEq(t, `{"query": "", "filters": [
+			{"id": "1", "tag": "name", "operator": "=", "value": "b"},
+			{"id": "team-override-0", "type": "static", "scope": "resource", "tag": "namespace", "operator": "=", "value": "a", "valueType": "string"}
+		]}`, string(cdt.QueryDataReq.Queries[1].JSON))
+
+		_, err = cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				PluginID:                   "prometheus",
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: enforcers},
+			},
+			Headers: map[string]string{},
+			Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(`{"expr": "sum("}`)}},
+		})
+		require.ErrorContains(t, err, "failed to add the team overrides to query A")
+	})
+
+	t.Run("Should reject the queries the team overrides can't be added to", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{Teams: []int64{1}}),
+			clienttest.WithMiddlewares(NewTeamOverridesMiddleware()),
+		)
+		query := func(pluginID string, jsonData string, q backend.DataQuery) error {
+			_, err := cdt.Decorator.QueryData(req.Context(), &backend.QueryDataRequest{
+				PluginContext: backend.PluginContext{
+					PluginID:                   pluginID,
+					DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)},
+				},
+				Headers: map[string]string{},
+				Queries: []backend.DataQuery{q},
+			})
+			return err
+		}
+
+		// a trace lookup by ID can't be restricted by the TraceQL filters
+		err = query("tempo", `{"teamOverrides": {"1": {"traceQLFilters": [{"tag": "namespace", "operator": "=", "value": "a"}]}}}`,
+			backend.DataQuery{RefID: "A", QueryType: "traceql", JSON: []byte(`{"query": "abc123"}`)})
+		require.ErrorIs(t, err, datasources.ErrTeamOverridesNotEnforced)
+
+		// the label matchers only apply to the PromQL queries
+		err = query("loki", `{"teamOverrides": {"1": {"labelMatchers": ["namespace=\"a\""]}}}`,
+			backend.DataQuery{RefID: "A", JSON: []byte(`{"expr": "{app=\"shop\"}"}`)})
+		require.ErrorIs(t, err, datasources.ErrTeamOverridesNotEnforced)
+
+		err = cdt.Decorator.CallResource(req.Context(), &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{
+				PluginID: "tempo",
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					JSONData: []byte(`{"teamOverrides": {"1": {"traceQLFilters": [{"tag": "namespace", "operator": "=", "value": "a"}]}}}`),
+				},
+			},
+			Headers: map[string][]string{},
+		}, nopCallResourceSender)
+		require.NoError(t, err)
+		middlewares := httpclient.ContextualMiddlewareFromContext(cdt.CallResourceCtx)
+		require.Len(t, middlewares, 1)
+		outReq, err := http.NewRequest(http.MethodGet, "http://tempo/api/traces/abc123", nil)
+		require.NoError(t, err)
+		_, err = middlewares[0].CreateMiddleware(httpclient.Options{}, finalRoundTripper).RoundTrip(outReq)
+		require.ErrorIs(t, err, datasources.ErrTeamOverridesNotEnforced)
+	})
+
+	t.Run("Should apply the team overrides of the user of the context without HTTP request", func(t *testing.T) {
+		cdt := clienttest.NewClientDecoratorTest(t, clienttest.WithMiddlewares(NewTeamOverridesMiddleware()))
+
+		ctx := appcontext.WithUser(context.Background(), &user.SignedInUser{Teams: []int64{1}})
+		_, err := cdt.Decorator.QueryData(ctx, &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonData},
+			},
+			Headers: map[string]string{},
+		})
+		require.NoError(t, err)
+		require.Equal(t, `team-a:{namespace="a"}`, cdt.QueryDataReq.GetHTTPHeader(policyHeader))
+
+		_, err = cdt.Decorator.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonData},
+			},
+			Headers: map[string]string{},
+		})
+		require.NoError(t, err)
+		require.Empty(t, cdt.QueryDataReq.GetHTTPHeader(policyHeader))
+	})
+
+	t.Run("Should add the label matchers to the HTTP requests of the resources", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/some/thing", nil)
+		require.NoError(t, err)
+
+		cdt := clienttest.NewClientDecoratorTest(t,
+			clienttest.WithReqContext(req, &user.SignedInUser{Teams: []int64{1}}),
+			clienttest.WithMiddlewares(NewTeamOverridesMiddleware()),
+		)
+		err = cdt.Decorator.CallResource(req.Context(), &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{
+				PluginID: "prometheus",
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					JSONData: []byte(`{"teamOverrides": {"1": {"labelMatchers": ["namespace=\"a\""]}}}`),
+				},
+			},
+			Headers: map[string][]string{},
+		}, nopCallResourceSender)
+		require.NoError(t, err)
+
+		middlewares := httpclient.ContextualMiddlewareFromContext(cdt.CallResourceCtx)
+		require.Len(t, middlewares, 1)
+		outReq, err := http.NewRequest(http.MethodPost, "http://prometheus/api/v1/series?match[]=up", strings.NewReader(url.Values{"match[]": {"node_load1"}}.Encode()))
+		require.NoError(t, err)
+		outReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		res, err := middlewares[0].CreateMiddleware(httpclient.Options{}, finalRoundTripper).RoundTrip(outReq)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, `up{namespace="a"}`, outReq.URL.Query().Get("match[]"))
+		body, err := io.ReadAll(outReq.Body)
+		require.NoError(t, err)
+		form, err := url.ParseQuery(string(body))
+		require.NoError(t, err)
+		require.Equal(t, `node_load1{namespace="a"}`, form.Get("match[]"))
+	})
+
+	t.Run("Should deny the streams of the members of teams with overrides", func(t *testing.T) {
+		subscribe := func(t *testing.T, ctx context.Context) (*backend.SubscribeStreamResponse, bool) {
+			t.Helper()
+			next := &fakeStreamClient{}
+			res, err := NewTeamOverridesMiddleware().CreateClientMiddleware(next).SubscribeStream(ctx, &backend.SubscribeStreamRequest{
+				PluginContext: backend.PluginContext{
+					DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonData},
+				},
+			})
+			require.NoError(t, err)
+			return res, next.subscribed
+		}
+
+		res, subscribed := subscribe(t, livecontext.SetContextSignedUser(context.Background(), &user.SignedInUser{Teams: []int64{2}}))
+		require.Equal(t, backend.SubscribeStreamStatusPermissionDenied, res.Status)
+		require.False(t, subscribed)
+
+		res, subscribed = subscribe(t, context.Background())
+		require.Equal(t, backend.SubscribeStreamStatusPermissionDenied, res.Status)
+		require.False(t, subscribed)
+
+		res, subscribed = subscribe(t, livecontext.SetContextSignedUser(context.Background(), &user.SignedInUser{Teams: []int64{3}}))
+		require.Equal(t, backend.SubscribeStreamStatusOK, res.Status)
+		require.True(t, subscribed)
+	})
+}
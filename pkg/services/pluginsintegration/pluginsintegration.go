@@ -20,11 +20,14 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/manager/store"
 	"github.com/grafana/grafana/pkg/plugins/pluginscdn"
 	"github.com/grafana/grafana/pkg/plugins/repo"
+	"github.com/grafana/grafana/pkg/services/auth/identitytoken"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/clientmiddleware"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginsettings"
 	pluginSettings "github.com/grafana/grafana/pkg/services/pluginsintegration/pluginsettings/service"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
+	"github.com/grafana/grafana/pkg/services/queryinsights"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -41,6 +44,7 @@ var WireSet = wire.NewSet(
 	process.ProvideService,
 	wire.Bind(new(process.Service), new(*process.Manager)),
 	coreplugin.ProvideCoreRegistry,
+	wire.Bind(new(plugins.QueryHookRegistry), new(*coreplugin.Registry)),
 	pluginscdn.ProvideService,
 	assetpath.ProvideService,
 	loader.ProvideService,
@@ -74,33 +78,65 @@ var WireExtensionSet = wire.NewSet(
 
 func ProvideClientDecorator(cfg *setting.Cfg, pCfg *config.Cfg,
 	pluginRegistry registry.Service,
-	oAuthTokenService oauthtoken.OAuthTokenService) (*client.Decorator, error) {
-	return NewClientDecorator(cfg, pCfg, pluginRegistry, oAuthTokenService)
+	oAuthTokenService oauthtoken.OAuthTokenService, identityTokens *identitytoken.Service,
+	queryInsights queryinsights.Service, queryAudit queryaudit.Service) (*client.Decorator, error) {
+	return NewClientDecorator(cfg, pCfg, pluginRegistry, oAuthTokenService, identityTokens, queryInsights, queryAudit)
 }
 
 func NewClientDecorator(cfg *setting.Cfg, pCfg *config.Cfg,
 	pluginRegistry registry.Service,
-	oAuthTokenService oauthtoken.OAuthTokenService) (*client.Decorator, error) {
+	oAuthTokenService oauthtoken.OAuthTokenService, identityTokens *identitytoken.Service,
+	queryInsights queryinsights.Service, queryAudit queryaudit.Service) (*client.Decorator, error) {
 	c := client.ProvideService(pluginRegistry, pCfg)
-	middlewares := CreateMiddlewares(cfg, oAuthTokenService)
+	middlewares := CreateMiddlewares(cfg, oAuthTokenService, identityTokens, queryInsights, queryAudit)
 
 	return client.NewDecorator(c, middlewares...)
 }
 
-func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthTokenService) []plugins.ClientMiddleware {
+func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthTokenService, identityTokens *identitytoken.Service,
+	queryInsights queryinsights.Service, queryAudit queryaudit.Service) []plugins.ClientMiddleware {
 	skipCookiesNames := []string{cfg.LoginCookieName}
-	middlewares := []plugins.ClientMiddleware{
+	middlewares := []plugins.ClientMiddleware{}
+
+	// recorded first so the durations include the time spent by the other middlewares
+	if queryInsights != nil {
+		middlewares = append(middlewares, clientmiddleware.NewQueryInsightsMiddleware(queryInsights))
+	}
+	middlewares = append(middlewares,
 		clientmiddleware.NewTracingHeaderMiddleware(),
 		clientmiddleware.NewClearAuthHeadersMiddleware(),
 		clientmiddleware.NewOAuthTokenMiddleware(oAuthTokenService),
 		clientmiddleware.NewCookiesMiddleware(skipCookiesNames),
+		// the identity tokens are set by the next middleware, the team overrides cannot replace them
+		clientmiddleware.NewTeamOverridesMiddleware(identityTokens.HeaderName()),
+	)
+
+	// after the team overrides so the queries are recorded as they are sent to the datasources
+	if queryAudit != nil && !queryAudit.IsDisabled() {
+		middlewares = append(middlewares, clientmiddleware.NewQueryAuditMiddleware(queryAudit))
+	}
+
+	if identityTokens != nil {
+		middlewares = append(middlewares, clientmiddleware.NewIdentityTokenMiddleware(identityTokens))
 	}
 
 	if cfg.SendUserHeader {
 		middlewares = append(middlewares, clientmiddleware.NewUserHeaderMiddleware())
 	}
 
+	if cfg.SendBaggage {
+		middlewares = append(middlewares, clientmiddleware.NewBaggageMiddleware(cfg.BaggageRedactedKeys))
+	}
+
 	middlewares = append(middlewares, clientmiddleware.NewHTTPClientMiddleware())
 
+	// after the HTTP client middleware so the requests are captured with the forwarded headers
+	middlewares = append(middlewares, clientmiddleware.NewQueryCaptureMiddleware())
+
+	// last so the frames are validated as they are returned by the plugins
+	if cfg.PluginValidateDataplaneContracts {
+		middlewares = append(middlewares, clientmiddleware.NewDataplaneValidationMiddleware())
+	}
+
 	return middlewares
 }
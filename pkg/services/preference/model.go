@@ -12,6 +12,11 @@ import (
 )
 
 var ErrPrefNotFound = errors.New("preference not found")
+var ErrInvalidQueryDefaults = errutil.NewBase(
+	errutil.StatusBadRequest,
+	"preferences.invalidQueryDefaults",
+	errutil.WithPublicMessage("The query defaults are invalid, the max data points and the timeout can't be negative"),
+)
 var ErrUnknownCookieType = errutil.NewBase(
 	errutil.StatusBadRequest,
 	"preferences.unknownCookieType",
@@ -83,18 +88,42 @@ type PatchPreferenceCommand struct {
 	Language          *string                 `json:"language,omitempty"`
 	QueryHistory      *QueryHistoryPreference `json:"queryHistory,omitempty"`
 	CookiePreferences []CookieType            `json:"cookiePreferences,omitempty"`
+	// QueryDefaults replaces the query defaults of the user when it's set
+	QueryDefaults *QueryDefaultsPreference `json:"queryDefaults,omitempty"`
 }
 
 type PreferenceJSONData struct {
-	Language          string                 `json:"language"`
-	QueryHistory      QueryHistoryPreference `json:"queryHistory"`
-	CookiePreferences map[string]struct{}    `json:"cookiePreferences"`
+	Language          string                   `json:"language"`
+	QueryHistory      QueryHistoryPreference   `json:"queryHistory"`
+	CookiePreferences map[string]struct{}      `json:"cookiePreferences"`
+	QueryDefaults     *QueryDefaultsPreference `json:"queryDefaults,omitempty"`
 }
 
 type QueryHistoryPreference struct {
 	HomeTab string `json:"homeTab"`
 }
 
+// QueryDefaultsPreference are the options applied by the query service to the queries of a user that don't set them.
+// swagger:model
+type QueryDefaultsPreference struct {
+	// MaxDataPoints of the queries without maxDataPoints, 0 to keep the default of the query service
+	MaxDataPoints int64 `json:"maxDataPoints,omitempty"`
+	// TimeoutSeconds after which the queries of the user are cancelled, 0 for no timeout
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
+	// DatasourceUID of the queries without datasource
+	DatasourceUID string `json:"datasourceUid,omitempty"`
+}
+
+// IsEmpty returns true when none of the defaults is set
+func (d QueryDefaultsPreference) IsEmpty() bool {
+	return d == QueryDefaultsPreference{}
+}
+
+// Timeout returns the timeout of the queries, 0 when there is none
+func (d QueryDefaultsPreference) Timeout() time.Duration {
+	return time.Duration(d.TimeoutSeconds) * time.Second
+}
+
 func (j *PreferenceJSONData) FromDB(data []byte) error {
 	dec := json.NewDecoder(bytes.NewBuffer(data))
 	dec.UseNumber()
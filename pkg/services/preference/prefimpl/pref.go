@@ -133,6 +133,10 @@ func (s *Service) Save(ctx context.Context, cmd *pref.SavePreferenceCommand) err
 	preference.Updated = time.Now()
 	preference.Version += 1
 	preference.HomeDashboardID = cmd.HomeDashboardID
+	// the query defaults are only replaced by a patch
+	if preference.JSONData != nil {
+		jsonData.QueryDefaults = preference.JSONData.QueryDefaults
+	}
 	preference.JSONData = jsonData
 
 	return s.store.Update(ctx, preference)
@@ -177,6 +181,19 @@ func (s *Service) Patch(ctx context.Context, cmd *pref.PatchPreferenceCommand) e
 		}
 	}
 
+	if cmd.QueryDefaults != nil {
+		if cmd.QueryDefaults.MaxDataPoints < 0 || cmd.QueryDefaults.TimeoutSeconds < 0 {
+			return pref.ErrInvalidQueryDefaults.Errorf("invalid query defaults %+v", *cmd.QueryDefaults)
+		}
+		if preference.JSONData == nil {
+			preference.JSONData = &pref.PreferenceJSONData{}
+		}
+		preference.JSONData.QueryDefaults = nil
+		if !cmd.QueryDefaults.IsEmpty() {
+			preference.JSONData.QueryDefaults = cmd.QueryDefaults
+		}
+	}
+
 	if cmd.HomeDashboardID != nil {
 		preference.HomeDashboardID = *cmd.HomeDashboardID
 	}
@@ -421,6 +421,57 @@ func TestSave(t *testing.T) {
 	})
 }
 
+func TestPatch_queryDefaults(t *testing.T) {
+	prefService := &Service{
+		store:    newFake(),
+		cfg:      setting.NewCfg(),
+		features: featuremgmt.WithFeatures(),
+	}
+	key := preferenceKey{OrgID: 1, UserID: 2}
+
+	t.Run("patch", func(t *testing.T) {
+		err := prefService.Patch(context.Background(), &pref.PatchPreferenceCommand{
+			OrgID:         1,
+			UserID:        2,
+			QueryDefaults: &pref.QueryDefaultsPreference{MaxDataPoints: 500, TimeoutSeconds: 30, DatasourceUID: "prom"},
+		})
+		require.NoError(t, err)
+
+		stored := prefService.store.(*inmemStore).preference[key]
+		assert.Equal(t, &pref.QueryDefaultsPreference{MaxDataPoints: 500, TimeoutSeconds: 30, DatasourceUID: "prom"}, stored.JSONData.QueryDefaults)
+	})
+
+	t.Run("save keeps the query defaults", func(t *testing.T) {
+		err := prefService.Save(context.Background(), &pref.SavePreferenceCommand{OrgID: 1, UserID: 2, Theme: "dark"})
+		require.NoError(t, err)
+
+		stored := prefService.store.(*inmemStore).preference[key]
+		assert.Equal(t, "dark", stored.Theme)
+		assert.Equal(t, &pref.QueryDefaultsPreference{MaxDataPoints: 500, TimeoutSeconds: 30, DatasourceUID: "prom"}, stored.JSONData.QueryDefaults)
+	})
+
+	t.Run("negative values are invalid", func(t *testing.T) {
+		err := prefService.Patch(context.Background(), &pref.PatchPreferenceCommand{
+			OrgID:         1,
+			UserID:        2,
+			QueryDefaults: &pref.QueryDefaultsPreference{TimeoutSeconds: -1},
+		})
+		require.ErrorIs(t, err, pref.ErrInvalidQueryDefaults)
+	})
+
+	t.Run("empty defaults are removed", func(t *testing.T) {
+		err := prefService.Patch(context.Background(), &pref.PatchPreferenceCommand{
+			OrgID:         1,
+			UserID:        2,
+			QueryDefaults: &pref.QueryDefaultsPreference{},
+		})
+		require.NoError(t, err)
+
+		stored := prefService.store.(*inmemStore).preference[key]
+		assert.Nil(t, stored.JSONData.QueryDefaults)
+	})
+}
+
 func insertPrefs(t testing.TB, store store, preferences ...pref.Preference) {
 	t.Helper()
 	for _, p := range preferences {
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/provisioning/utils"
@@ -23,7 +24,7 @@ type DashboardProvisioner interface {
 }
 
 // DashboardProvisionerFactory creates DashboardProvisioners based on input
-type DashboardProvisionerFactory func(context.Context, string, dashboards.DashboardProvisioningService, org.Service, utils.DashboardStore) (DashboardProvisioner, error)
+type DashboardProvisionerFactory func(context.Context, string, string, dashboards.DashboardProvisioningService, org.Service, utils.DashboardStore, plugins.QueryHookRegistry) (DashboardProvisioner, error)
 
 // Provisioner is responsible for syncing dashboard from disk to Grafana's database.
 type Provisioner struct {
@@ -38,8 +39,9 @@ func (provider *Provisioner) HasDashboardSources() bool {
 	return len(provider.fileReaders) > 0
 }
 
-// New returns a new DashboardProvisioner
-func New(ctx context.Context, configDirectory string, provisioner dashboards.DashboardProvisioningService, orgService org.Service, dashboardStore utils.DashboardStore) (DashboardProvisioner, error) {
+// New returns a new DashboardProvisioner, the local checkouts of the Git repositories are kept in the data path by
+// default
+func New(ctx context.Context, configDirectory string, dataPath string, provisioner dashboards.DashboardProvisioningService, orgService org.Service, dashboardStore utils.DashboardStore, queryHooks plugins.QueryHookRegistry) (DashboardProvisioner, error) {
 	logger := log.New("provisioning.dashboard")
 	cfgReader := &configReader{path: configDirectory, log: logger, orgService: orgService}
 	configs, err := cfgReader.readConfig(ctx)
@@ -47,7 +49,7 @@ func New(ctx context.Context, configDirectory string, provisioner dashboards.Das
 		return nil, fmt.Errorf("%v: %w", "Failed to read dashboards config", err)
 	}
 
-	fileReaders, err := getFileReaders(configs, logger, provisioner, dashboardStore)
+	fileReaders, err := getFileReaders(configs, dataPath, logger, provisioner, dashboardStore, queryHooks)
 	if err != nil {
 		return nil, fmt.Errorf("%v: %w", "Failed to initialize file readers", err)
 	}
@@ -127,7 +129,8 @@ func (provider *Provisioner) GetAllowUIUpdatesFromConfig(name string) bool {
 }
 
 func getFileReaders(
-	configs []*config, logger log.Logger, service dashboards.DashboardProvisioningService, store utils.DashboardStore,
+	configs []*config, dataPath string, logger log.Logger, service dashboards.DashboardProvisioningService, store utils.DashboardStore,
+	queryHooks plugins.QueryHookRegistry,
 ) ([]*FileReader, error) {
 	var readers []*FileReader
 
@@ -138,7 +141,15 @@ func getFileReaders(
 			if err != nil {
 				return nil, fmt.Errorf("failed to create file reader for config %v: %w", config.Name, err)
 			}
+			fileReader.queryHooks = queryHooks
 			readers = append(readers, fileReader)
+		case "git":
+			gitReader, err := NewDashboardGitReader(config, dataPath, logger.New("type", config.Type, "name", config.Name), service, store)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create git reader for config %v: %w", config.Name, err)
+			}
+			gitReader.queryHooks = queryHooks
+			readers = append(readers, gitReader)
 		default:
 			return nil, fmt.Errorf("type %s is not supported", config.Type)
 		}
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/slugify"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/utils"
@@ -39,6 +40,11 @@ type FileReader struct {
 	mux                     sync.RWMutex
 	usageTracker            *usageTracker
 	dbWriteAccessRestricted bool
+	// source updates the files of the reader before every walk, the files are only read from the disk when it's nil
+	source dashboardSource
+	// queryHooks check the queries of the dashboards with the validators of their plugins, they are not checked
+	// when it's nil
+	queryHooks plugins.QueryHookRegistry
 }
 
 // NewDashboardFileReader returns a new filereader based on `config`
@@ -89,6 +95,12 @@ func (fr *FileReader) pollChanges(ctx context.Context) {
 // and applies any change to the database.
 func (fr *FileReader) walkDisk(ctx context.Context) error {
 	fr.log.Debug("Start walking disk", "path", fr.Path)
+	if fr.source != nil {
+		// the dashboards of the last successful sync are provisioned when the sync fails
+		if err := fr.source.sync(ctx); err != nil {
+			fr.log.Error("Failed to sync the dashboards", "error", err)
+		}
+	}
 	resolvedPath := fr.resolvedPath()
 	if _, err := os.Stat(resolvedPath); err != nil {
 		return err
@@ -250,6 +262,14 @@ func (fr *FileReader) saveDashboard(ctx context.Context, path string, folderID i
 		return provisioningMetadata, nil
 	}
 
+	// invalid queries don't prevent the dashboard from being provisioned, they only fail when the panel is rendered
+	if fr.queryHooks != nil {
+		for _, v := range dashboards.ValidateDashboardQueries(dash.Dashboard.Data, fr.queryHooks.QueryValidator, nil) {
+			fr.log.Warn("Provisioned dashboard query has issues", "file", path, "panelId", v.PanelID, "refId", v.RefID,
+				"datasourceType", v.DatasourceType, "issues", strings.Join(v.Issues, "; "))
+		}
+	}
+
 	if dash.Dashboard.ID != 0 {
 		dash.Dashboard.Data.Set("id", nil)
 		dash.Dashboard.ID = 0
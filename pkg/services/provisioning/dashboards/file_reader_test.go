@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -24,6 +25,7 @@ const (
 	containingID              = "testdata/test-dashboards/containing-id"
 	unprovision               = "testdata/test-dashboards/unprovision"
 	foldersFromFilesStructure = "testdata/test-dashboards/folders-from-files-structure"
+	lokiQueries               = "testdata/test-dashboards/loki-queries"
 	configName                = "default"
 )
 
@@ -124,6 +126,24 @@ func TestDashboardFileReader(t *testing.T) {
 			require.NoError(t, err)
 		})
 
+		t.Run("Should validate the queries with the validators of their plugins", func(t *testing.T) {
+			setup()
+			cfg.Options["path"] = lokiQueries
+
+			fakeService.On("GetProvisionedDashboardData", mock.Anything, configName).Return(nil, nil).Once()
+			fakeService.On("SaveProvisionedDashboard", mock.Anything, mock.Anything, mock.Anything).Return(&dashboards.Dashboard{ID: 1}, nil).Once()
+
+			reader, err := NewDashboardFileReader(cfg, logger, nil, fakeStore)
+			require.NoError(t, err)
+			reader.dashboardProvisioningService = fakeService
+			hooks := &fakeQueryHooks{}
+			reader.queryHooks = hooks
+
+			err = reader.walkDisk(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, []string{`app="foo"`, `{app="foo"}`}, hooks.validated)
+		})
+
 		t.Run("Can read default dashboard and replace old version in database", func(t *testing.T) {
 			setup()
 			cfg.Options["path"] = oneDashboard
@@ -510,6 +530,27 @@ func (ffi FakeFileInfo) Sys() interface{} {
 	return nil
 }
 
+type fakeQueryHooks struct {
+	validated []string
+}
+
+func (h *fakeQueryHooks) QueryConverter(string) (plugins.QueryConverter, bool) {
+	return nil, false
+}
+
+func (h *fakeQueryHooks) QueryValidator(pluginID string) (plugins.QueryValidator, bool) {
+	if pluginID != "loki" {
+		return nil, false
+	}
+	return h, true
+}
+
+func (h *fakeQueryHooks) ValidateQuery(query map[string]interface{}) []string {
+	expr, _ := query["expr"].(string)
+	h.validated = append(h.validated, expr)
+	return nil
+}
+
 type fakeDashboardStore struct{}
 
 func (fds *fakeDashboardStore) GetDashboard(_ context.Context, _ *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
@@ -0,0 +1,240 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/slugify"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+const gitRemoteName = "origin"
+
+// dashboardSource updates the files of a reader before they are read.
+type dashboardSource interface {
+	sync(ctx context.Context) error
+}
+
+// NewDashboardGitReader returns a new filereader reading the dashboards of a local checkout of a Git repository. The
+// checkout is updated to the pinned branch or tag before every walk of the files, it is in the data path by default.
+func NewDashboardGitReader(cfg *config, dataPath string, log log.Logger, service dashboards.DashboardProvisioningService, dashboardStore utils.DashboardStore) (*FileReader, error) {
+	source, err := newGitSource(cfg, dataPath, log)
+	if err != nil {
+		return nil, err
+	}
+
+	foldersFromFilesStructure, _ := cfg.Options["foldersFromFilesStructure"].(bool)
+	if foldersFromFilesStructure && cfg.Folder != "" && cfg.FolderUID != "" {
+		return nil, fmt.Errorf("'folder' and 'folderUID' should be empty using 'foldersFromFilesStructure' option")
+	}
+
+	return &FileReader{
+		Cfg:                          cfg,
+		Path:                         source.dashboardsPath(),
+		log:                          log,
+		dashboardProvisioningService: service,
+		dashboardStore:               dashboardStore,
+		FoldersFromFilesStructure:    foldersFromFilesStructure,
+		usageTracker:                 newUsageTracker(),
+		source:                       source,
+	}, nil
+}
+
+// gitSource keeps a local checkout of a Git repository at the head of a branch, or at a tag. When a key ring is
+// configured, the checkout is only updated to the commits, or annotated tags, signed by one of its keys.
+type gitSource struct {
+	url    string
+	branch string
+	tag    string
+	// path is the directory of the dashboards in the repository
+	path    string
+	dir     string
+	auth    transport.AuthMethod
+	keyRing string
+	log     log.Logger
+
+	mu      sync.Mutex
+	current plumbing.Hash
+}
+
+func newGitSource(cfg *config, dataPath string, logger log.Logger) (*gitSource, error) {
+	s := &gitSource{log: logger}
+	s.url, _ = cfg.Options["url"].(string)
+	if s.url == "" {
+		return nil, fmt.Errorf("failed to load dashboards, url param is required for git providers")
+	}
+	s.branch, _ = cfg.Options["branch"].(string)
+	s.tag, _ = cfg.Options["tag"].(string)
+	if s.branch != "" && s.tag != "" {
+		return nil, fmt.Errorf("failed to load dashboards, only one of branch or tag can be set")
+	}
+	s.path, _ = cfg.Options["path"].(string)
+	if filepath.IsAbs(s.path) || strings.HasPrefix(filepath.Clean(s.path), "..") {
+		return nil, fmt.Errorf("failed to load dashboards, path %q must be relative to the root of the repository", s.path)
+	}
+
+	s.dir, _ = cfg.Options["clonePath"].(string)
+	if s.dir == "" {
+		// the checkouts are kept in the data path rather than in the temporary directory shared with the other users,
+		// where they could be replaced before they are provisioned
+		s.dir = filepath.Join(dataPath, "provisioning", "dashboards", slugify.Slugify(cfg.Name))
+	}
+
+	username, _ := cfg.Options["username"].(string)
+	password, _ := cfg.Options["password"].(string)
+	if username != "" || password != "" {
+		s.auth = &githttp.BasicAuth{Username: username, Password: password}
+	}
+
+	if keysPath, _ := cfg.Options["verifyKeys"].(string); keysPath != "" {
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because `keysPath` comes from the provisioning configuration
+		keys, err := os.ReadFile(keysPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the keys to verify the signatures: %w", err)
+		}
+		if _, err := openpgp.ReadArmoredKeyRing(strings.NewReader(string(keys))); err != nil {
+			return nil, fmt.Errorf("failed to parse the keys to verify the signatures: %w", err)
+		}
+		s.keyRing = string(keys)
+	}
+	return s, nil
+}
+
+func (s *gitSource) dashboardsPath() string {
+	return filepath.Join(s.dir, s.path)
+}
+
+// refSpec fetches the pinned branch or tag, or the default branch of the remote
+func (s *gitSource) refSpec() (gitconfig.RefSpec, plumbing.ReferenceName) {
+	switch {
+	case s.tag != "":
+		ref := plumbing.NewTagReferenceName(s.tag)
+		return gitconfig.RefSpec(fmt.Sprintf("+%s:%s", ref, ref)), ref
+	case s.branch != "":
+		ref := plumbing.NewRemoteReferenceName(gitRemoteName, s.branch)
+		return gitconfig.RefSpec(fmt.Sprintf("+%s:%s", plumbing.NewBranchReferenceName(s.branch), ref)), ref
+	default:
+		ref := plumbing.NewRemoteReferenceName(gitRemoteName, plumbing.HEAD.String())
+		return gitconfig.RefSpec(fmt.Sprintf("+%s:%s", plumbing.HEAD, ref)), ref
+	}
+}
+
+// open opens the local repository, or initializes it with the remote of the source
+func (s *gitSource) open() (*git.Repository, error) {
+	repo, err := git.PlainOpen(s.dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		if err := os.MkdirAll(s.dir, 0750); err != nil {
+			return nil, err
+		}
+		repo, err = git.PlainInit(s.dir, false)
+		if err != nil {
+			return nil, err
+		}
+		_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: gitRemoteName, URLs: []string{s.url}})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// sync fetches the pinned branch or tag and checks it out, after verifying its signature. The checkout is left as it
+// is when the fetch or the verification fails.
+func (s *gitSource) sync(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, err := s.open()
+	if err != nil {
+		return fmt.Errorf("failed to open the repository in %s: %w", s.dir, err)
+	}
+
+	refSpec, refName := s.refSpec()
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: gitRemoteName,
+		RefSpecs:   []gitconfig.RefSpec{refSpec},
+		Auth:       s.auth,
+		Tags:       git.NoTags,
+		Force:      true,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch %s: %w", s.url, err)
+	}
+
+	ref, err := repo.Reference(refName, true)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", refName, err)
+	}
+	commit, err := s.verifiedCommit(repo, ref.Hash())
+	if err != nil {
+		return err
+	}
+	if commit.Hash == s.current {
+		return nil
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Hash: commit.Hash, Force: true}); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", commit.Hash, err)
+	}
+	s.log.Info("Checked out the dashboards", "url", s.url, "commit", commit.Hash.String())
+	s.current = commit.Hash
+	return nil
+}
+
+// verifiedCommit returns the commit of the hash, the hash of a commit or of an annotated tag. When there is a key
+// ring, the signature of the annotated tag, or of the commit otherwise, must be from one of its keys.
+func (s *gitSource) verifiedCommit(repo *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	tag, err := repo.TagObject(hash)
+	switch {
+	case err == nil:
+		commit, err := tag.Commit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the commit of tag %s: %w", tag.Name, err)
+		}
+		if s.keyRing != "" {
+			if tag.PGPSignature == "" {
+				return nil, fmt.Errorf("tag %s is not signed", tag.Name)
+			}
+			if _, err := tag.Verify(s.keyRing); err != nil {
+				return nil, fmt.Errorf("failed to verify the signature of tag %s: %w", tag.Name, err)
+			}
+		}
+		return commit, nil
+	case !errors.Is(err, plumbing.ErrObjectNotFound):
+		return nil, err
+	}
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+	if s.keyRing != "" {
+		if commit.PGPSignature == "" {
+			return nil, fmt.Errorf("commit %s is not signed", hash)
+		}
+		if _, err := commit.Verify(s.keyRing); err != nil {
+			return nil, fmt.Errorf("failed to verify the signature of commit %s: %w", hash, err)
+		}
+	}
+	return commit, nil
+}
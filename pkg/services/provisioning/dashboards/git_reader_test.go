@@ -0,0 +1,208 @@
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// testGitRepository is a repository with commits of dashboards, the remote of the readers in the tests
+type testGitRepository struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+}
+
+func newTestGitRepository(t *testing.T) *testGitRepository {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	return &testGitRepository{t: t, dir: dir, repo: repo}
+}
+
+// commit commits the dashboard in dashboards/dashboard.json, signed when the key is not nil
+func (r *testGitRepository) commit(title string, key *openpgp.Entity) plumbing.Hash {
+	r.t.Helper()
+	require.NoError(r.t, os.MkdirAll(filepath.Join(r.dir, "dashboards"), 0750))
+	require.NoError(r.t, os.WriteFile(filepath.Join(r.dir, "dashboards", "dashboard.json"), []byte(`{"title": "`+title+`"}`), 0600))
+	worktree, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+	_, err = worktree.Add("dashboards/dashboard.json")
+	require.NoError(r.t, err)
+	hash, err := worktree.Commit(title, &git.CommitOptions{
+		Author:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		SignKey: key,
+	})
+	require.NoError(r.t, err)
+	return hash
+}
+
+func newTestKey(t *testing.T, dir string) (*openpgp.Entity, string) {
+	t.Helper()
+	key, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, key.Serialize(w))
+	require.NoError(t, w.Close())
+
+	path := filepath.Join(dir, "keys.asc")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+	return key, path
+}
+
+func newTestGitSource(t *testing.T, options map[string]interface{}) *gitSource {
+	t.Helper()
+	options["clonePath"] = filepath.Join(t.TempDir(), "checkout")
+	source, err := newGitSource(&config{Name: configName, Type: "git", Options: options}, t.TempDir(), log.New("test-logger"))
+	require.NoError(t, err)
+	return source
+}
+
+func readTestDashboard(t *testing.T, source *gitSource) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(source.dashboardsPath(), "dashboard.json"))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestCreatingNewDashboardGitReader(t *testing.T) {
+	t.Run("url is required", func(t *testing.T) {
+		_, err := NewDashboardGitReader(&config{Name: configName, Options: map[string]interface{}{}}, "/var/lib/grafana", log.New("test-logger"), nil, nil)
+		require.EqualError(t, err, "failed to load dashboards, url param is required for git providers")
+	})
+
+	t.Run("branch and tag are exclusive", func(t *testing.T) {
+		_, err := NewDashboardGitReader(&config{Name: configName, Options: map[string]interface{}{
+			"url": "https://example.com/dashboards.git", "branch": "main", "tag": "v1",
+		}}, "/var/lib/grafana", log.New("test-logger"), nil, nil)
+		require.EqualError(t, err, "failed to load dashboards, only one of branch or tag can be set")
+	})
+
+	t.Run("path must be in the repository", func(t *testing.T) {
+		_, err := NewDashboardGitReader(&config{Name: configName, Options: map[string]interface{}{
+			"url": "https://example.com/dashboards.git", "path": "../dashboards",
+		}}, "/var/lib/grafana", log.New("test-logger"), nil, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("keys must be valid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.asc")
+		require.NoError(t, os.WriteFile(path, []byte("not a key"), 0600))
+		_, err := NewDashboardGitReader(&config{Name: configName, Options: map[string]interface{}{
+			"url": "https://example.com/dashboards.git", "verifyKeys": path,
+		}}, "/var/lib/grafana", log.New("test-logger"), nil, nil)
+		require.ErrorContains(t, err, "failed to parse the keys to verify the signatures")
+	})
+
+	t.Run("reads the dashboards of the path of the checkout", func(t *testing.T) {
+		reader, err := NewDashboardGitReader(&config{Name: configName, Options: map[string]interface{}{
+			"url": "https://example.com/dashboards.git", "path": "dashboards", "clonePath": "/var/lib/grafana/git",
+		}}, "/var/lib/grafana", log.New("test-logger"), nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("/var/lib/grafana/git", "dashboards"), reader.Path)
+		assert.NotNil(t, reader.source)
+	})
+
+	t.Run("keeps the checkout in the data path by default", func(t *testing.T) {
+		reader, err := NewDashboardGitReader(&config{Name: "Team Dashboards", Options: map[string]interface{}{
+			"url": "https://example.com/dashboards.git",
+		}}, "/var/lib/grafana", log.New("test-logger"), nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("/var/lib/grafana", "provisioning", "dashboards", "team-dashboards"), reader.Path)
+	})
+}
+
+func TestGitSource(t *testing.T) {
+	t.Run("checks out the head of the branch", func(t *testing.T) {
+		origin := newTestGitRepository(t)
+		origin.commit("first", nil)
+		source := newTestGitSource(t, map[string]interface{}{"url": origin.dir, "branch": "master", "path": "dashboards"})
+
+		require.NoError(t, source.sync(context.Background()))
+		assert.Equal(t, `{"title": "first"}`, readTestDashboard(t, source))
+
+		second := origin.commit("second", nil)
+		require.NoError(t, source.sync(context.Background()))
+		assert.Equal(t, `{"title": "second"}`, readTestDashboard(t, source))
+		assert.Equal(t, second, source.current)
+
+		// nothing changed
+		require.NoError(t, source.sync(context.Background()))
+		assert.Equal(t, second, source.current)
+	})
+
+	t.Run("checks out the tag", func(t *testing.T) {
+		origin := newTestGitRepository(t)
+		first := origin.commit("first", nil)
+		_, err := origin.repo.CreateTag("v1", first, nil)
+		require.NoError(t, err)
+		origin.commit("second", nil)
+		source := newTestGitSource(t, map[string]interface{}{"url": origin.dir, "tag": "v1", "path": "dashboards"})
+
+		require.NoError(t, source.sync(context.Background()))
+		assert.Equal(t, `{"title": "first"}`, readTestDashboard(t, source))
+	})
+
+	t.Run("only checks out the signed commits", func(t *testing.T) {
+		origin := newTestGitRepository(t)
+		key, keysPath := newTestKey(t, t.TempDir())
+		signed := origin.commit("signed", key)
+		source := newTestGitSource(t, map[string]interface{}{"url": origin.dir, "branch": "master", "path": "dashboards", "verifyKeys": keysPath})
+
+		require.NoError(t, source.sync(context.Background()))
+		assert.Equal(t, `{"title": "signed"}`, readTestDashboard(t, source))
+
+		// the checkout is kept at the last verified commit
+		origin.commit("unsigned", nil)
+		require.ErrorContains(t, source.sync(context.Background()), "is not signed")
+		assert.Equal(t, `{"title": "signed"}`, readTestDashboard(t, source))
+		assert.Equal(t, signed, source.current)
+
+		other, _ := newTestKey(t, t.TempDir())
+		origin.commit("other key", other)
+		require.ErrorContains(t, source.sync(context.Background()), "failed to verify the signature of commit")
+		assert.Equal(t, `{"title": "signed"}`, readTestDashboard(t, source))
+	})
+
+	t.Run("verifies the signature of the annotated tags", func(t *testing.T) {
+		origin := newTestGitRepository(t)
+		key, keysPath := newTestKey(t, t.TempDir())
+		// the commit is not signed, the tag is
+		commit := origin.commit("tagged", nil)
+		_, err := origin.repo.CreateTag("v1", commit, &git.CreateTagOptions{
+			Tagger:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+			Message: "v1",
+			SignKey: key,
+		})
+		require.NoError(t, err)
+		_, err = origin.repo.CreateTag("v2", commit, &git.CreateTagOptions{
+			Tagger:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+			Message: "v2",
+		})
+		require.NoError(t, err)
+
+		source := newTestGitSource(t, map[string]interface{}{"url": origin.dir, "tag": "v1", "path": "dashboards", "verifyKeys": keysPath})
+		require.NoError(t, source.sync(context.Background()))
+		assert.Equal(t, `{"title": "tagged"}`, readTestDashboard(t, source))
+
+		source = newTestGitSource(t, map[string]interface{}{"url": origin.dir, "tag": "v2", "path": "dashboards", "verifyKeys": keysPath})
+		require.ErrorContains(t, source.sync(context.Background()), "tag v2 is not signed")
+	})
+}
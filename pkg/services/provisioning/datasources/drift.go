@@ -0,0 +1,157 @@
+package datasources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+// ConfigDrift is a datasource of the provisioning files whose stored settings differ from the files, the settings
+// it is set back to when the datasources are provisioned again.
+type ConfigDrift struct {
+	OrgID int64  `json:"orgId"`
+	Name  string `json:"name"`
+	UID   string `json:"uid"`
+	// Missing is set when the datasource is not stored
+	Missing bool         `json:"missing,omitempty"`
+	Fields  []FieldDrift `json:"fields,omitempty"`
+}
+
+// FieldDrift is a setting of a datasource that differs from the provisioning files. The values of the secure
+// settings are not reported.
+type FieldDrift struct {
+	Field       string      `json:"field"`
+	Stored      interface{} `json:"stored,omitempty"`
+	Provisioned interface{} `json:"provisioned,omitempty"`
+}
+
+// DecryptFn returns the decrypted secure settings of a stored datasource
+type DecryptFn func(ctx context.Context, ds *datasources.DataSource) (map[string]string, error)
+
+// Drift reads the provisioning config files of configDirectory and returns the datasources whose stored settings
+// differ from the files. The secure settings of the files are compared with the values returned by decrypt.
+func Drift(ctx context.Context, configDirectory string, store Store, orgService org.Service, decrypt DecryptFn) ([]ConfigDrift, error) {
+	cr := &configReader{log: log.New("provisioning.datasources"), orgService: orgService}
+	configs, err := cr.readConfig(ctx, configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := make([]ConfigDrift, 0)
+	for _, cfg := range configs {
+		for _, ds := range cfg.Datasources {
+			stored, err := store.GetDataSource(ctx, &datasources.GetDataSourceQuery{OrgID: ds.OrgID, Name: ds.Name})
+			if errors.Is(err, datasources.ErrDataSourceNotFound) {
+				drifts = append(drifts, ConfigDrift{OrgID: ds.OrgID, Name: ds.Name, UID: ds.UID, Missing: true})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			fields, err := datasourceDrift(ctx, ds, stored, decrypt)
+			if err != nil {
+				return nil, err
+			}
+			if len(fields) > 0 {
+				drifts = append(drifts, ConfigDrift{OrgID: ds.OrgID, Name: ds.Name, UID: stored.UID, Fields: fields})
+			}
+		}
+	}
+	return drifts, nil
+}
+
+// datasourceDrift compares the settings the datasource is updated with by the provisioning with its stored settings
+func datasourceDrift(ctx context.Context, ds *upsertDataSourceFromConfig, stored *datasources.DataSource, decrypt DecryptFn) ([]FieldDrift, error) {
+	cmd := createUpdateCommand(ds, stored.ID)
+
+	var fields []FieldDrift
+	compare := func(field string, storedValue, provisionedValue interface{}) {
+		if storedValue != provisionedValue {
+			fields = append(fields, FieldDrift{Field: field, Stored: storedValue, Provisioned: provisionedValue})
+		}
+	}
+	// the update doesn't clear these settings when they are empty, the UID of the datasources provisioned without UID
+	// is generated when they are inserted for instance
+	compareSet := func(field string, storedValue, provisionedValue string) {
+		if provisionedValue != "" {
+			compare(field, storedValue, provisionedValue)
+		}
+	}
+	compareSet("uid", stored.UID, cmd.UID)
+	compareSet("type", stored.Type, cmd.Type)
+	compareSet("access", string(stored.Access), string(cmd.Access))
+	compareSet("url", stored.URL, cmd.URL)
+	compareSet("basicAuthUser", stored.BasicAuthUser, cmd.BasicAuthUser)
+	compare("user", stored.User, cmd.User)
+	compare("database", stored.Database, cmd.Database)
+	compare("basicAuth", stored.BasicAuth, cmd.BasicAuth)
+	compare("withCredentials", stored.WithCredentials, cmd.WithCredentials)
+	compare("isDefault", stored.IsDefault, cmd.IsDefault)
+	compare("readOnly", stored.ReadOnly, cmd.ReadOnly)
+
+	jsonFields, err := jsonDataDrift(stored, ds.JSONData)
+	if err != nil {
+		return nil, err
+	}
+	fields = append(fields, jsonFields...)
+
+	if len(ds.SecureJSONData) > 0 {
+		decrypted, err := decrypt(ctx, stored)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(ds.SecureJSONData))
+		for key := range ds.SecureJSONData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if decrypted[key] != ds.SecureJSONData[key] {
+				fields = append(fields, FieldDrift{Field: "secureJsonData." + key})
+			}
+		}
+	}
+	return fields, nil
+}
+
+// jsonDataDrift compares the keys of the stored and of the provisioned jsonData, through their JSON encoding as the
+// numbers of the files and of the database are not decoded to the same types.
+func jsonDataDrift(stored *datasources.DataSource, provisioned map[string]interface{}) ([]FieldDrift, error) {
+	storedData := map[string]interface{}{}
+	if stored.JsonData != nil {
+		storedData = stored.JsonData.MustMap()
+	}
+
+	keys := make([]string, 0, len(storedData)+len(provisioned))
+	for key := range storedData {
+		keys = append(keys, key)
+	}
+	for key := range provisioned {
+		if _, ok := storedData[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var fields []FieldDrift
+	for _, key := range keys {
+		storedValue, err := json.Marshal(storedData[key])
+		if err != nil {
+			return nil, err
+		}
+		provisionedValue, err := json.Marshal(provisioned[key])
+		if err != nil {
+			return nil, err
+		}
+		if string(storedValue) != string(provisionedValue) {
+			fields = append(fields, FieldDrift{Field: "jsonData." + key, Stored: storedData[key], Provisioned: provisioned[key]})
+		}
+	}
+	return fields, nil
+}
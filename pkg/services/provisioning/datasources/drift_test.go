@@ -0,0 +1,47 @@
+package datasources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+)
+
+func TestDrift(t *testing.T) {
+	store := &spyStore{items: []*datasources.DataSource{
+		{
+			ID: 1, OrgID: 1, Name: "Prometheus", UID: "prometheus", Type: "prometheus", Access: datasources.DS_ACCESS_PROXY,
+			URL: "http://prometheus:9091", ReadOnly: true,
+			JsonData: simplejson.NewFromAny(map[string]interface{}{"httpMethod": "GET", "queryTimeout": float64(60), "manualSetting": true}),
+		},
+		{
+			ID: 2, OrgID: 1, Name: "Loki", UID: "loki", Type: "loki", Access: datasources.DS_ACCESS_PROXY,
+			URL: "http://loki:3100", ReadOnly: true,
+		},
+	}}
+	decrypt := func(ctx context.Context, ds *datasources.DataSource) (map[string]string, error) {
+		return map[string]string{"httpHeaderValue1": "rotated"}, nil
+	}
+
+	drifts, err := Drift(context.Background(), "testdata/drift", store, &orgtest.FakeOrgService{ExpectedOrg: &org.Org{ID: 1}}, decrypt)
+	require.NoError(t, err)
+	require.Equal(t, []ConfigDrift{
+		{
+			OrgID: 1,
+			Name:  "Prometheus",
+			UID:   "prometheus",
+			Fields: []FieldDrift{
+				{Field: "url", Stored: "http://prometheus:9091", Provisioned: "http://prometheus:9090"},
+				{Field: "jsonData.httpMethod", Stored: "GET", Provisioned: "POST"},
+				{Field: "jsonData.manualSetting", Stored: true},
+				{Field: "secureJsonData.httpHeaderValue1"},
+			},
+		},
+		{OrgID: 1, Name: "Tempo", Missing: true},
+	}, drifts)
+}
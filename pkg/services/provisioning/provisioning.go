@@ -27,7 +27,11 @@ import (
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
 	"github.com/grafana/grafana/pkg/services/provisioning/notifiers"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
+	"github.com/grafana/grafana/pkg/services/provisioning/queryexports"
+	"github.com/grafana/grafana/pkg/services/provisioning/recordings"
+	"github.com/grafana/grafana/pkg/services/queryexport"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/recording"
 	"github.com/grafana/grafana/pkg/services/searchV2"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/setting"
@@ -51,6 +55,9 @@ func ProvideService(
 	quotaService quota.Service,
 	secrectService secrets.Service,
 	orgService org.Service,
+	recordingService *recording.Service,
+	queryExportService *queryexport.Service,
+	queryHooks plugifaces.QueryHookRegistry,
 ) (*ProvisioningServiceImpl, error) {
 	s := &ProvisioningServiceImpl{
 		Cfg:                          cfg,
@@ -64,6 +71,8 @@ func ProvideService(
 		provisionDatasources:         datasources.Provision,
 		provisionPlugins:             plugins.Provision,
 		provisionAlerting:            prov_alerting.Provision,
+		provisionRecordings:          recordings.Provision,
+		provisionQueryExports:        queryexports.Provision,
 		dashboardProvisioningService: dashboardProvisioningService,
 		dashboardService:             dashboardService,
 		datasourceService:            datasourceService,
@@ -75,6 +84,9 @@ func ProvideService(
 		secretService:                secrectService,
 		log:                          log.New("provisioning"),
 		orgService:                   orgService,
+		recordingService:             recordingService,
+		queryExportService:           queryExportService,
+		queryHooks:                   queryHooks,
 	}
 	return s, nil
 }
@@ -87,6 +99,8 @@ type ProvisioningService interface {
 	ProvisionNotifications(ctx context.Context) error
 	ProvisionDashboards(ctx context.Context) error
 	ProvisionAlerting(ctx context.Context) error
+	ProvisionRecordings(ctx context.Context) error
+	ProvisionQueryExports(ctx context.Context) error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 }
@@ -100,6 +114,8 @@ func NewProvisioningServiceImpl() *ProvisioningServiceImpl {
 		provisionNotifiers:      notifiers.Provision,
 		provisionDatasources:    datasources.Provision,
 		provisionPlugins:        plugins.Provision,
+		provisionRecordings:     recordings.Provision,
+		provisionQueryExports:   queryexports.Provision,
 	}
 }
 
@@ -135,6 +151,8 @@ type ProvisioningServiceImpl struct {
 	provisionDatasources         func(context.Context, string, datasources.Store, datasources.CorrelationsStore, org.Service) error
 	provisionPlugins             func(context.Context, string, plugifaces.Store, pluginsettings.Service, org.Service) error
 	provisionAlerting            func(context.Context, prov_alerting.ProvisionerConfig) error
+	provisionRecordings          func(context.Context, string, recordings.Registry, org.Service) error
+	provisionQueryExports        func(context.Context, string, queryexports.Registry, org.Service) error
 	mutex                        sync.Mutex
 	dashboardProvisioningService dashboardservice.DashboardProvisioningService
 	dashboardService             dashboardservice.DashboardService
@@ -145,6 +163,9 @@ type ProvisioningServiceImpl struct {
 	searchService                searchV2.SearchService
 	quotaService                 quota.Service
 	secretService                secrets.Service
+	recordingService             recordings.Registry
+	queryExportService           queryexports.Registry
+	queryHooks                   plugifaces.QueryHookRegistry
 }
 
 func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) error {
@@ -168,6 +189,16 @@ func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) erro
 		return err
 	}
 
+	err = ps.ProvisionRecordings(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ps.ProvisionQueryExports(ctx)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -235,7 +266,7 @@ func (ps *ProvisioningServiceImpl) ProvisionNotifications(ctx context.Context) e
 
 func (ps *ProvisioningServiceImpl) ProvisionDashboards(ctx context.Context) error {
 	dashboardPath := filepath.Join(ps.Cfg.ProvisioningPath, "dashboards")
-	dashProvisioner, err := ps.newDashboardProvisioner(ctx, dashboardPath, ps.dashboardProvisioningService, ps.orgService, ps.dashboardService)
+	dashProvisioner, err := ps.newDashboardProvisioner(ctx, dashboardPath, ps.Cfg.DataPath, ps.dashboardProvisioningService, ps.orgService, ps.dashboardService, ps.queryHooks)
 	if err != nil {
 		return fmt.Errorf("%v: %w", "Failed to create provisioner", err)
 	}
@@ -294,6 +325,26 @@ func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error
 	return ps.provisionAlerting(ctx, cfg)
 }
 
+func (ps *ProvisioningServiceImpl) ProvisionRecordings(ctx context.Context) error {
+	recordingsPath := filepath.Join(ps.Cfg.ProvisioningPath, "recordings")
+	if err := ps.provisionRecordings(ctx, recordingsPath, ps.recordingService, ps.orgService); err != nil {
+		err = fmt.Errorf("%v: %w", "Recording provisioning error", err)
+		ps.log.Error("Failed to provision recordings", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (ps *ProvisioningServiceImpl) ProvisionQueryExports(ctx context.Context) error {
+	exportsPath := filepath.Join(ps.Cfg.ProvisioningPath, "queryexports")
+	if err := ps.provisionQueryExports(ctx, exportsPath, ps.queryExportService, ps.orgService); err != nil {
+		err = fmt.Errorf("%v: %w", "Query export provisioning error", err)
+		ps.log.Error("Failed to provision query exports", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (ps *ProvisioningServiceImpl) GetDashboardProvisionerResolvedPath(name string) string {
 	return ps.dashboardProvisioner.GetProvisionerResolvedPath(name)
 }
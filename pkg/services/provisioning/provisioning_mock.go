@@ -9,6 +9,8 @@ type Calls struct {
 	ProvisionNotifications              []interface{}
 	ProvisionDashboards                 []interface{}
 	ProvisionAlerting                   []interface{}
+	ProvisionRecordings                 []interface{}
+	ProvisionQueryExports               []interface{}
 	GetDashboardProvisionerResolvedPath []interface{}
 	GetAllowUIUpdatesFromConfig         []interface{}
 	Run                                 []interface{}
@@ -77,6 +79,16 @@ func (mock *ProvisioningServiceMock) ProvisionAlerting(ctx context.Context) erro
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) ProvisionRecordings(ctx context.Context) error {
+	mock.Calls.ProvisionRecordings = append(mock.Calls.ProvisionRecordings, nil)
+	return nil
+}
+
+func (mock *ProvisioningServiceMock) ProvisionQueryExports(ctx context.Context) error {
+	mock.Calls.ProvisionQueryExports = append(mock.Calls.ProvisionQueryExports, nil)
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) GetDashboardProvisionerResolvedPath(name string) string {
 	mock.Calls.GetDashboardProvisionerResolvedPath = append(mock.Calls.GetDashboardProvisionerResolvedPath, name)
 	if mock.GetDashboardProvisionerResolvedPathFunc != nil {
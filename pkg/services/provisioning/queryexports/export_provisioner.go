@@ -0,0 +1,69 @@
+package queryexports
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/provisioning/scheduledqueries"
+	"github.com/grafana/grafana/pkg/services/queryexport"
+)
+
+// Registry schedules the provisioned query exports
+type Registry interface {
+	Register(e queryexport.Export) error
+}
+
+// Provision scans a directory for provisioning config files
+// and registers the query exports in those files.
+func Provision(ctx context.Context, configDirectory string, registry Registry, orgService org.Service) error {
+	logger := log.New("provisioning.queryexports")
+	ep := ExportProvisioner{
+		log:        logger,
+		registry:   registry,
+		orgService: orgService,
+	}
+	return ep.applyChanges(ctx, configDirectory)
+}
+
+// ExportProvisioner is responsible for registering the query exports based on
+// configuration read from the provisioning files
+type ExportProvisioner struct {
+	log        log.Logger
+	registry   Registry
+	orgService org.Service
+}
+
+func (ep *ExportProvisioner) apply(ctx context.Context, cfg *exportsAsConfig) error {
+	for _, export := range cfg.Exports {
+		if export.Export.OrgID == 0 && export.OrgName != "" {
+			res, err := ep.orgService.GetByName(ctx, &org.GetOrgByNameQuery{Name: export.OrgName})
+			if err != nil {
+				return err
+			}
+			export.Export.OrgID = res.ID
+		}
+
+		ep.log.Info("Registering query export from configuration", "uid", export.Export.UID, "schedule", export.Export.Schedule)
+		if err := ep.registry.Register(export.Export); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ep *ExportProvisioner) applyChanges(ctx context.Context, configPath string) error {
+	configs, err := scheduledqueries.ReadConfigs(ep.log, configPath, (*exportsAsConfigV1).mapToExportsFromConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range configs {
+		if err := ep.apply(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
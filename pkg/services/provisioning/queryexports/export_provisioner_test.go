@@ -0,0 +1,86 @@
+package queryexports
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/queryexport"
+)
+
+const (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	brokenYaml        = "./testdata/test-configs/broken-yaml"
+	invalidSchedule   = "./testdata/test-configs/invalid-schedule"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+type fakeRegistry struct {
+	exports []queryexport.Export
+}
+
+func (r *fakeRegistry) Register(e queryexport.Export) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	r.exports = append(r.exports, e)
+	return nil
+}
+
+func TestExportProvisioner(t *testing.T) {
+	t.Run("Should register the exports of the config files", func(t *testing.T) {
+		registry := &fakeRegistry{}
+		orgService := &orgtest.FakeOrgService{ExpectedOrg: &org.Org{ID: 3}}
+		err := Provision(context.Background(), correctProperties, registry, orgService)
+		require.NoError(t, err)
+
+		require.Len(t, registry.exports, 2)
+		e := registry.exports[0]
+		assert.Equal(t, "daily_requests", e.UID)
+		assert.Equal(t, int64(2), e.OrgID)
+		assert.Equal(t, "0 6 * * *", e.Schedule)
+		assert.Equal(t, "csv", e.Format)
+		assert.Equal(t, `requests/{{.Time.Format "2006/01/02"}}/{{.RefID}}.{{.Ext}}`, e.Path)
+		assert.Equal(t, []string{"B"}, e.Targets)
+		require.Len(t, e.Queries, 2)
+		assert.Equal(t, "prometheus", e.Queries[0].DatasourceUID)
+		assert.Equal(t, expr.RelativeTimeRange{From: -24 * time.Hour}, e.Queries[0].RelativeTimeRange)
+		// the macros and variables of the model are not interpolated
+		assert.Equal(t, json.RawMessage(`{"expression":"$A / 3600","type":"math"}`), e.Queries[1].Model)
+
+		e = registry.exports[1]
+		assert.Equal(t, int64(3), e.OrgID)
+		assert.Equal(t, "@hourly", e.Schedule)
+		assert.Empty(t, e.Targets)
+		require.Len(t, e.Variables, 2)
+		assert.Equal(t, queryexport.Variable{Name: "env", Values: []string{"dev", "prod"}}, e.Variables[0])
+		assert.Equal(t, "cluster", e.Variables[1].Label)
+		require.NotNil(t, e.Variables[1].Query)
+		assert.Equal(t, "prometheus", e.Variables[1].Query.DatasourceUID)
+		assert.Contains(t, string(e.Queries[0].Model), `env=\"$env\"`)
+	})
+
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		err := Provision(context.Background(), brokenYaml, &fakeRegistry{}, orgtest.NewOrgServiceFake())
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid schedule should return error", func(t *testing.T) {
+		err := Provision(context.Background(), invalidSchedule, &fakeRegistry{}, orgtest.NewOrgServiceFake())
+		require.ErrorContains(t, err, `export daily_requests: invalid schedule "every day"`)
+	})
+
+	t.Run("Skip invalid directory", func(t *testing.T) {
+		registry := &fakeRegistry{}
+		require.NoError(t, Provision(context.Background(), emptyFolder, registry, orgtest.NewOrgServiceFake()))
+		require.NoError(t, Provision(context.Background(), "./testdata/test-configs/missing", registry, orgtest.NewOrgServiceFake()))
+		assert.Empty(t, registry.exports)
+	})
+}
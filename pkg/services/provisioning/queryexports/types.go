@@ -0,0 +1,103 @@
+package queryexports
+
+import (
+	"github.com/grafana/grafana/pkg/services/provisioning/scheduledqueries"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+	"github.com/grafana/grafana/pkg/services/queryexport"
+)
+
+// exportsAsConfig is a normalized data object for query exports config data. Any config version should be mappable
+// to this type.
+type exportsAsConfig struct {
+	Exports []*exportFromConfig
+}
+
+type exportFromConfig struct {
+	OrgName string
+	Export  queryexport.Export
+}
+
+// exportsAsConfigV1 is a mapping for the first version of the configs. This is mapped to its normalised version.
+type exportsAsConfigV1 struct {
+	APIVersion values.Int64Value     `json:"apiVersion" yaml:"apiVersion"`
+	Exports    []*exportFromConfigV1 `json:"exports" yaml:"exports"`
+}
+
+type exportFromConfigV1 struct {
+	UID       values.StringValue                   `json:"uid" yaml:"uid"`
+	OrgID     values.Int64Value                    `json:"orgId" yaml:"orgId"`
+	OrgName   values.StringValue                   `json:"orgName" yaml:"orgName"`
+	Schedule  values.StringValue                   `json:"schedule" yaml:"schedule"`
+	Format    values.StringValue                   `json:"format" yaml:"format"`
+	Path      values.StringValue                   `json:"path" yaml:"path"`
+	Targets   []values.StringValue                 `json:"targets" yaml:"targets"`
+	Queries   []scheduledqueries.QueryFromConfigV1 `json:"queries" yaml:"queries"`
+	Variables []variableFromConfigV1               `json:"variables" yaml:"variables"`
+}
+
+type variableFromConfigV1 struct {
+	Name   values.StringValue                  `json:"name" yaml:"name"`
+	Values []values.StringValue                `json:"values" yaml:"values"`
+	Query  *scheduledqueries.QueryFromConfigV1 `json:"query" yaml:"query"`
+	Label  values.StringValue                  `json:"label" yaml:"label"`
+}
+
+// mapToExportsFromConfig maps config syntax to a normalized exportsAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *exportsAsConfigV1) mapToExportsFromConfig() (*exportsAsConfig, error) {
+	r := &exportsAsConfig{}
+	if cfg == nil {
+		return r, nil
+	}
+
+	for _, export := range cfg.Exports {
+		queries := make([]queryexport.Query, 0, len(export.Queries))
+		for _, q := range export.Queries {
+			query, err := q.MapToQuery()
+			if err != nil {
+				return nil, err
+			}
+			queries = append(queries, query)
+		}
+
+		var targets []string
+		for _, target := range export.Targets {
+			targets = append(targets, target.Value())
+		}
+
+		var variables []queryexport.Variable
+		for _, v := range export.Variables {
+			variable := queryexport.Variable{
+				Name:  v.Name.Value(),
+				Label: v.Label.Value(),
+			}
+			for _, value := range v.Values {
+				variable.Values = append(variable.Values, value.Value())
+			}
+			if v.Query != nil {
+				query, err := v.Query.MapToQuery()
+				if err != nil {
+					return nil, err
+				}
+				variable.Query = &query
+			}
+			variables = append(variables, variable)
+		}
+
+		r.Exports = append(r.Exports, &exportFromConfig{
+			OrgName: export.OrgName.Value(),
+			Export: queryexport.Export{
+				UID:       export.UID.Value(),
+				OrgID:     scheduledqueries.OrgID(export.OrgID.Value(), export.OrgName.Value()),
+				Schedule:  export.Schedule.Value(),
+				Format:    export.Format.Value(),
+				Path:      export.Path.Value(),
+				Targets:   targets,
+				Queries:   queries,
+				Variables: variables,
+			},
+		})
+	}
+
+	return r, nil
+}
@@ -0,0 +1,69 @@
+package recordings
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/provisioning/scheduledqueries"
+	"github.com/grafana/grafana/pkg/services/recording"
+)
+
+// Registry schedules the provisioned recordings
+type Registry interface {
+	Register(r recording.Recording) error
+}
+
+// Provision scans a directory for provisioning config files
+// and registers the recordings in those files.
+func Provision(ctx context.Context, configDirectory string, registry Registry, orgService org.Service) error {
+	logger := log.New("provisioning.recordings")
+	rp := RecordingProvisioner{
+		log:        logger,
+		registry:   registry,
+		orgService: orgService,
+	}
+	return rp.applyChanges(ctx, configDirectory)
+}
+
+// RecordingProvisioner is responsible for registering the recordings based on
+// configuration read from the provisioning files
+type RecordingProvisioner struct {
+	log        log.Logger
+	registry   Registry
+	orgService org.Service
+}
+
+func (rp *RecordingProvisioner) apply(ctx context.Context, cfg *recordingsAsConfig) error {
+	for _, rec := range cfg.Recordings {
+		if rec.Recording.OrgID == 0 && rec.OrgName != "" {
+			res, err := rp.orgService.GetByName(ctx, &org.GetOrgByNameQuery{Name: rec.OrgName})
+			if err != nil {
+				return err
+			}
+			rec.Recording.OrgID = res.ID
+		}
+
+		rp.log.Info("Registering recording from configuration", "uid", rec.Recording.UID, "metric", rec.Recording.Metric)
+		if err := rp.registry.Register(rec.Recording); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rp *RecordingProvisioner) applyChanges(ctx context.Context, configPath string) error {
+	configs, err := scheduledqueries.ReadConfigs(rp.log, configPath, (*recordingsAsConfigV1).mapToRecordingsFromConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range configs {
+		if err := rp.apply(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
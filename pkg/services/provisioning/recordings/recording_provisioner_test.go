@@ -0,0 +1,84 @@
+package recordings
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/recording"
+)
+
+const (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	brokenYaml        = "./testdata/test-configs/broken-yaml"
+	invalidInterval   = "./testdata/test-configs/invalid-interval"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+type fakeRegistry struct {
+	recordings []recording.Recording
+}
+
+func (r *fakeRegistry) Register(rec recording.Recording) error {
+	if err := rec.Validate(); err != nil {
+		return err
+	}
+	r.recordings = append(r.recordings, rec)
+	return nil
+}
+
+func TestRecordingProvisioner(t *testing.T) {
+	t.Run("Should register the recordings of the config files", func(t *testing.T) {
+		registry := &fakeRegistry{}
+		orgService := &orgtest.FakeOrgService{ExpectedOrg: &org.Org{ID: 3}}
+		err := Provision(context.Background(), correctProperties, registry, orgService)
+		require.NoError(t, err)
+
+		require.Len(t, registry.recordings, 3)
+		rec := registry.recordings[0]
+		assert.Equal(t, "requests_rate", rec.UID)
+		assert.Equal(t, int64(2), rec.OrgID)
+		assert.Equal(t, "job:requests:rate5m", rec.Metric)
+		assert.Equal(t, time.Minute, rec.Interval)
+		assert.Equal(t, map[string]string{"team": "backend"}, rec.Labels)
+		assert.Equal(t, "B", rec.Target)
+		assert.False(t, rec.Count)
+		require.Len(t, rec.Queries, 2)
+		assert.Equal(t, "prometheus", rec.Queries[0].DatasourceUID)
+		assert.Equal(t, expr.RelativeTimeRange{From: -10 * time.Minute}, rec.Queries[0].RelativeTimeRange)
+		assert.JSONEq(t, `{"expr": "sum by (job) (rate(http_requests_total[5m]))", "instant": true}`, string(rec.Queries[0].Model))
+		// the macros and variables of the model are not interpolated
+		assert.Equal(t, json.RawMessage(`{"expression":"$A * 100","type":"math"}`), rec.Queries[1].Model)
+
+		rec = registry.recordings[1]
+		assert.Equal(t, int64(3), rec.OrgID)
+		assert.Equal(t, 30*time.Second, rec.Interval)
+
+		rec = registry.recordings[2]
+		assert.True(t, rec.Count)
+	})
+
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		err := Provision(context.Background(), brokenYaml, &fakeRegistry{}, orgtest.NewOrgServiceFake())
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid interval should return error", func(t *testing.T) {
+		err := Provision(context.Background(), invalidInterval, &fakeRegistry{}, orgtest.NewOrgServiceFake())
+		require.ErrorContains(t, err, "recording requests_rate: invalid interval")
+	})
+
+	t.Run("Skip invalid directory", func(t *testing.T) {
+		registry := &fakeRegistry{}
+		require.NoError(t, Provision(context.Background(), emptyFolder, registry, orgtest.NewOrgServiceFake()))
+		require.NoError(t, Provision(context.Background(), "./testdata/test-configs/missing", registry, orgtest.NewOrgServiceFake()))
+		assert.Empty(t, registry.recordings)
+	})
+}
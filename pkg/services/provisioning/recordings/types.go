@@ -0,0 +1,82 @@
+package recordings
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/services/provisioning/scheduledqueries"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+	"github.com/grafana/grafana/pkg/services/recording"
+)
+
+// recordingsAsConfig is a normalized data object for recordings config data. Any config version should be mappable
+// to this type.
+type recordingsAsConfig struct {
+	Recordings []*recordingFromConfig
+}
+
+type recordingFromConfig struct {
+	OrgName   string
+	Recording recording.Recording
+}
+
+// recordingsAsConfigV1 is a mapping for the first version of the configs. This is mapped to its normalised version.
+type recordingsAsConfigV1 struct {
+	APIVersion values.Int64Value        `json:"apiVersion" yaml:"apiVersion"`
+	Recordings []*recordingFromConfigV1 `json:"recordings" yaml:"recordings"`
+}
+
+type recordingFromConfigV1 struct {
+	UID      values.StringValue                   `json:"uid" yaml:"uid"`
+	OrgID    values.Int64Value                    `json:"orgId" yaml:"orgId"`
+	OrgName  values.StringValue                   `json:"orgName" yaml:"orgName"`
+	Metric   values.StringValue                   `json:"metric" yaml:"metric"`
+	Interval values.StringValue                   `json:"interval" yaml:"interval"`
+	Labels   values.StringMapValue                `json:"labels" yaml:"labels"`
+	Target   values.StringValue                   `json:"target" yaml:"target"`
+	Count    values.BoolValue                     `json:"count" yaml:"count"`
+	Queries  []scheduledqueries.QueryFromConfigV1 `json:"queries" yaml:"queries"`
+}
+
+// mapToRecordingsFromConfig maps config syntax to a normalized recordingsAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *recordingsAsConfigV1) mapToRecordingsFromConfig() (*recordingsAsConfig, error) {
+	r := &recordingsAsConfig{}
+	if cfg == nil {
+		return r, nil
+	}
+
+	for _, rec := range cfg.Recordings {
+		interval, err := model.ParseDuration(rec.Interval.Value())
+		if err != nil {
+			return nil, fmt.Errorf("recording %s: invalid interval: %w", rec.UID.Value(), err)
+		}
+
+		queries := make([]recording.Query, 0, len(rec.Queries))
+		for _, q := range rec.Queries {
+			query, err := q.MapToQuery()
+			if err != nil {
+				return nil, err
+			}
+			queries = append(queries, query)
+		}
+
+		r.Recordings = append(r.Recordings, &recordingFromConfig{
+			OrgName: rec.OrgName.Value(),
+			Recording: recording.Recording{
+				UID:      rec.UID.Value(),
+				OrgID:    scheduledqueries.OrgID(rec.OrgID.Value(), rec.OrgName.Value()),
+				Metric:   rec.Metric.Value(),
+				Interval: time.Duration(interval),
+				Labels:   rec.Labels.Value(),
+				Target:   rec.Target.Value(),
+				Count:    rec.Count.Value(),
+				Queries:  queries,
+			},
+		})
+	}
+
+	return r, nil
+}
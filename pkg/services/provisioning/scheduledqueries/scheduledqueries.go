@@ -0,0 +1,110 @@
+// Package scheduledqueries reads the provisioning files of the queries that the server runs on a schedule, like the
+// recorded queries and the query exports. Each kind reads the YAML files of its own provisioning directory.
+package scheduledqueries
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+	"github.com/grafana/grafana/pkg/services/scheduledquery"
+)
+
+// QueryFromConfigV1 is a datasource query or an expression in the first version of the configs
+type QueryFromConfigV1 struct {
+	RefID             values.StringValue  `json:"refId" yaml:"refId"`
+	DatasourceUID     values.StringValue  `json:"datasourceUid" yaml:"datasourceUid"`
+	RelativeTimeRange RelativeTimeRangeV1 `json:"relativeTimeRange" yaml:"relativeTimeRange"`
+	Model             values.JSONValue    `json:"model" yaml:"model"`
+}
+
+// RelativeTimeRangeV1 is the time range of a query in seconds before the run, like for the alert rules
+type RelativeTimeRangeV1 struct {
+	From values.Int64Value `json:"from" yaml:"from"`
+	To   values.Int64Value `json:"to" yaml:"to"`
+}
+
+// MapToQuery maps the query of the config to the query run by the server
+func (q QueryFromConfigV1) MapToQuery() (scheduledquery.Query, error) {
+	// the raw model is used so that the macros and variables of the queries like $__timeFilter are not interpolated
+	encoded, err := json.Marshal(q.Model.Raw)
+	if err != nil {
+		return scheduledquery.Query{}, err
+	}
+	return scheduledquery.Query{
+		RefID:         q.RefID.Value(),
+		DatasourceUID: q.DatasourceUID.Value(),
+		RelativeTimeRange: expr.RelativeTimeRange{
+			From: -time.Duration(q.RelativeTimeRange.From.Value()) * time.Second,
+			To:   -time.Duration(q.RelativeTimeRange.To.Value()) * time.Second,
+		},
+		Model: encoded,
+	}, nil
+}
+
+// ReadConfigs decodes the YAML files of the directory to the version V of the configs and maps them to their
+// normalized config C, the directories that can't be read have no configs
+func ReadConfigs[V any, C any](logger log.Logger, path string, mapToConfig func(*V) (C, error)) ([]C, error) {
+	var configs []C
+	logger.Debug("Looking for provisioning files", "path", path)
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		logger.Error("Failed to read provisioning files from directory", "path", path, "error", err)
+		return configs, nil
+	}
+
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".yaml") && !strings.HasSuffix(file.Name(), ".yml") {
+			continue
+		}
+		logger.Debug("Parsing provisioning file", "path", path, "file.Name", file.Name())
+		cfg, err := readConfig(filepath.Join(path, file.Name()), mapToConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failure to parse file %s: %w", file.Name(), err)
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+func readConfig[V any, C any](path string, mapToConfig func(*V) (C, error)) (C, error) {
+	var cfg C
+	filename, err := filepath.Abs(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := os.ReadFile(filename)
+	if err != nil {
+		return cfg, err
+	}
+
+	var versioned *V
+	if err := yaml.Unmarshal(yamlFile, &versioned); err != nil {
+		return cfg, err
+	}
+	return mapToConfig(versioned)
+}
+
+// OrgID returns the organization of a provisioned item, the main organization when neither its ID nor its name are
+// set, and 0 when it must be looked up by name
+func OrgID(orgID int64, orgName string) int64 {
+	if orgID >= 1 {
+		return orgID
+	}
+	if orgName == "" {
+		return 1
+	}
+	return 0
+}
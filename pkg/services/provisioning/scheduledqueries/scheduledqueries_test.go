@@ -0,0 +1,93 @@
+package scheduledqueries
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+const (
+	queries       = "./testdata/test-configs/queries"
+	brokenYaml    = "./testdata/test-configs/broken-yaml"
+	invalidConfig = "./testdata/test-configs/invalid-config"
+	emptyFolder   = "./testdata/test-configs/empty_folder"
+)
+
+type testConfigV1 struct {
+	APIVersion values.Int64Value `yaml:"apiVersion"`
+	Items      []struct {
+		UID     values.StringValue  `yaml:"uid"`
+		Queries []QueryFromConfigV1 `yaml:"queries"`
+	} `yaml:"items"`
+}
+
+// mapToConfig maps the config to the list of its queries
+func (cfg *testConfigV1) mapToConfig() ([]QueryFromConfigV1, error) {
+	var queries []QueryFromConfigV1
+	if cfg == nil {
+		return queries, nil
+	}
+	for _, item := range cfg.Items {
+		if item.UID.Value() == "" {
+			return nil, errors.New("uid is required")
+		}
+		queries = append(queries, item.Queries...)
+	}
+	return queries, nil
+}
+
+func TestReadConfigs(t *testing.T) {
+	logger := log.NewNopLogger()
+	read := func(path string) ([][]QueryFromConfigV1, error) {
+		return ReadConfigs(logger, path, (*testConfigV1).mapToConfig)
+	}
+
+	t.Run("Should read the config files", func(t *testing.T) {
+		configs, err := read(queries)
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		require.Len(t, configs[0], 1)
+
+		q, err := configs[0][0].MapToQuery()
+		require.NoError(t, err)
+		assert.Equal(t, "A", q.RefID)
+		assert.Equal(t, "prometheus", q.DatasourceUID)
+		assert.Equal(t, expr.RelativeTimeRange{From: -10 * time.Minute, To: -time.Minute}, q.RelativeTimeRange)
+		// the macros and variables of the model are not interpolated
+		assert.Equal(t, json.RawMessage(`{"expr":"sum(rate(http_requests_total{env=\"$env\"}[5m]))","instant":true}`), q.Model)
+	})
+
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		_, err := read(brokenYaml)
+		require.ErrorContains(t, err, "failure to parse file queries.yaml")
+	})
+
+	t.Run("Invalid config should return error", func(t *testing.T) {
+		_, err := read(invalidConfig)
+		require.EqualError(t, err, "failure to parse file queries.yaml: uid is required")
+	})
+
+	t.Run("Skip invalid directory", func(t *testing.T) {
+		configs, err := read(emptyFolder)
+		require.NoError(t, err)
+		assert.Empty(t, configs)
+
+		configs, err = read("./testdata/test-configs/missing")
+		require.NoError(t, err)
+		assert.Empty(t, configs)
+	})
+}
+
+func TestOrgID(t *testing.T) {
+	assert.Equal(t, int64(2), OrgID(2, "Main Org."))
+	assert.Equal(t, int64(1), OrgID(0, ""))
+	assert.Equal(t, int64(0), OrgID(-1, "Main Org."))
+}
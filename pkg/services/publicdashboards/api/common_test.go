@@ -136,6 +136,10 @@ func buildQueryDataService(t *testing.T, cs datasources.CacheService, fpc *fakeP
 		&fakePluginRequestValidator{},
 		&fakeDatasources.FakeDataSourceService{},
 		fpc,
+		nil,
+		nil,
+		nil,
+		nil,
 	)
 }
 
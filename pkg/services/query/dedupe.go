@@ -0,0 +1,248 @@
+package query
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/querycapture"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+const (
+	// dedupeReasonRequest counts the queries identical to another query of the same request
+	dedupeReasonRequest = "request"
+	// dedupeReasonConcurrent counts the queries of a request identical to a request already running
+	dedupeReasonConcurrent = "concurrent"
+)
+
+var deduplicatedQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Subsystem: "query",
+	Name:      "deduplicated_queries_total",
+	Help:      "Number of data source queries not executed because an identical query was executed for the same user.",
+}, []string{"reason"})
+
+// queryDeduplicator executes the identical queries sent by a user at the same time once, for example the queries of
+// the panels of a dashboard refreshed together, and returns their response to every request sending them.
+//
+// The responses of the deduplicated queries share the fields of their frames, they must not be modified. Every caller
+// gets its own frames and frame metadata.
+type queryDeduplicator struct {
+	group singleflight.Group
+}
+
+type queryDataFunc func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error)
+
+func (d *queryDeduplicator) queryData(ctx context.Context, user *user.SignedInUser, ds *datasources.DataSource, req *backend.QueryDataRequest, next queryDataFunc) (*backend.QueryDataResponse, error) {
+	unique, duplicates, err := uniqueQueries(req.Queries)
+	if err != nil {
+		return next(ctx, req)
+	}
+	if len(duplicates) > 0 {
+		deduplicatedQueries.WithLabelValues(dedupeReasonRequest).Add(float64(len(duplicates)))
+		deduped := *req
+		deduped.Queries = unique
+		req = &deduped
+	}
+
+	// the downstream requests of a captured execution are sent by it, it doesn't wait for the requests of other callers
+	if querycapture.RecorderFromContext(ctx) != nil {
+		resp, err := next(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return fanOut(resp, duplicates), nil
+	}
+
+	key, err := requestKey(user, ds, req)
+	if err != nil {
+		return next(ctx, req)
+	}
+
+	executed := false
+	ch := d.group.DoChan(key, func() (interface{}, error) {
+		executed = true
+		return next(ctx, req)
+	})
+
+	var res singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-ch:
+	}
+
+	if !executed {
+		// the request of another caller was canceled, the queries are sent again for this caller
+		if isContextError(res.Err) && ctx.Err() == nil {
+			return next(ctx, req)
+		}
+		deduplicatedQueries.WithLabelValues(dedupeReasonConcurrent).Add(float64(len(req.Queries)))
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	resp, _ := res.Val.(*backend.QueryDataResponse)
+	return fanOut(resp, duplicates), nil
+}
+
+// uniqueQueries returns the first query of every group of identical queries, and the refIDs of the other queries of
+// the group mapped to the refID of the first query
+func uniqueQueries(queries []backend.DataQuery) ([]backend.DataQuery, map[string]string, error) {
+	unique := make([]backend.DataQuery, 0, len(queries))
+	duplicates := map[string]string{}
+	seen := map[string]string{}
+	for _, q := range queries {
+		key, err := queryKey(q)
+		if err != nil {
+			return nil, nil, err
+		}
+		if refID, ok := seen[key]; ok {
+			duplicates[q.RefID] = refID
+			continue
+		}
+		seen[key] = q.RefID
+		unique = append(unique, q)
+	}
+	return unique, duplicates, nil
+}
+
+// queryKey identifies the queries returning the same data, regardless of their refID
+func queryKey(q backend.DataQuery) (string, error) {
+	model := map[string]interface{}{}
+	if err := json.Unmarshal(q.JSON, &model); err != nil {
+		return "", err
+	}
+	delete(model, "refId")
+	// the keys of the maps are sorted by json.Marshal
+	raw, err := json.Marshal(model)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s|%d|%d|%d|%d|%s", q.QueryType, q.TimeRange.From.UnixNano(), q.TimeRange.To.UnixNano(),
+		q.Interval, q.MaxDataPoints, raw), nil
+}
+
+// requestKey identifies the requests sent by the same user, with the same permissions, to the same version of the
+// datasource, with the same queries
+func requestKey(user *user.SignedInUser, ds *datasources.DataSource, req *backend.QueryDataRequest) (string, error) {
+	queries := make([]string, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		key, err := queryKey(q)
+		if err != nil {
+			return "", err
+		}
+		queries = append(queries, q.RefID+"|"+key)
+	}
+	sort.Strings(queries)
+
+	headers := make([]string, 0, len(req.Headers))
+	for name, value := range req.Headers {
+		headers = append(headers, name+"="+value)
+	}
+	sort.Strings(headers)
+
+	raw, err := json.Marshal(struct {
+		Identity   requestIdentity
+		Datasource string
+		Version    int
+		Updated    int64
+		Headers    []string
+		Queries    []string
+	}{identityOf(user), ds.UID, ds.Version, ds.Updated.UnixNano(), headers, queries})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// requestIdentity is the part of the identity of a user the requests to the datasources depend on
+type requestIdentity struct {
+	OrgID    int64
+	UserID   int64
+	ApiKeyID int64
+	Login    string
+	OrgRole  string
+	Teams    []int64
+}
+
+func identityOf(user *user.SignedInUser) requestIdentity {
+	if user == nil {
+		return requestIdentity{}
+	}
+	return requestIdentity{
+		OrgID:    user.OrgID,
+		UserID:   user.UserID,
+		ApiKeyID: user.ApiKeyID,
+		Login:    user.Login,
+		OrgRole:  string(user.OrgRole),
+		Teams:    user.Teams,
+	}
+}
+
+// fanOut returns a copy of the response, shared by the deduplicated requests, with the responses of the duplicated
+// queries copied from the query they are identical to
+func fanOut(resp *backend.QueryDataResponse, duplicates map[string]string) *backend.QueryDataResponse {
+	if resp == nil {
+		return nil
+	}
+	out := backend.NewQueryDataResponse()
+	for refID, res := range resp.Responses {
+		res.Frames = copyFrames(res.Frames, "")
+		out.Responses[refID] = res
+	}
+	for refID, from := range duplicates {
+		res, ok := resp.Responses[from]
+		if !ok {
+			continue
+		}
+		res.Frames = copyFrames(res.Frames, refID)
+		out.Responses[refID] = res
+	}
+	return out
+}
+
+// copyFrames copies the frames and their metadata, changed by the callers for example to remove the executed queries,
+// the fields are shared. The refID of the frames is replaced when it is not empty.
+func copyFrames(frames data.Frames, refID string) data.Frames {
+	if frames == nil {
+		return nil
+	}
+	copied := make(data.Frames, 0, len(frames))
+	for _, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		f := *frame
+		if refID != "" {
+			f.RefID = refID
+		}
+		if frame.Meta != nil {
+			meta := *frame.Meta
+			meta.Stats = append([]data.QueryStat(nil), frame.Meta.Stats...)
+			meta.Notices = append([]data.Notice(nil), frame.Meta.Notices...)
+			f.Meta = &meta
+		}
+		f.Fields = append([]*data.Field(nil), frame.Fields...)
+		copied = append(copied, &f)
+	}
+	return copied
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,255 @@
+package query
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/querycapture"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestQueryDeduplicator(t *testing.T) {
+	ds := &datasources.DataSource{UID: "ds", Version: 1}
+	signedInUser := &user.SignedInUser{OrgID: 1, UserID: 2, Login: "login"}
+	timeRange := backend.TimeRange{From: time.Unix(1000, 0), To: time.Unix(2000, 0)}
+	newRequest := func(queries ...backend.DataQuery) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{Headers: map[string]string{}, Queries: queries}
+	}
+	echo := func(calls *int64, requests chan<- *backend.QueryDataRequest) queryDataFunc {
+		return func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			atomic.AddInt64(calls, 1)
+			if requests != nil {
+				requests <- req
+			}
+			resp := backend.NewQueryDataResponse()
+			for _, q := range req.Queries {
+				frame := data.NewFrame(q.RefID, data.NewField("value", nil, []string{string(q.JSON)}))
+				frame.RefID = q.RefID
+				resp.Responses[q.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+			}
+			return resp, nil
+		}
+	}
+
+	t.Run("should execute the identical queries of a request once", func(t *testing.T) {
+		d := &queryDeduplicator{}
+		var calls int64
+		requests := make(chan *backend.QueryDataRequest, 1)
+		req := newRequest(
+			backend.DataQuery{RefID: "A", JSON: []byte(`{"refId": "A", "expr": "up"}`), TimeRange: timeRange},
+			backend.DataQuery{RefID: "B", JSON: []byte(`{"expr": "up", "refId": "B"}`), TimeRange: timeRange},
+			backend.DataQuery{RefID: "C", JSON: []byte(`{"refId": "C", "expr": "down"}`), TimeRange: timeRange},
+		)
+
+		resp, err := d.queryData(context.Background(), signedInUser, ds, req, echo(&calls, requests))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), calls)
+
+		sent := <-requests
+		require.Len(t, sent.Queries, 2)
+		assert.Equal(t, "A", sent.Queries[0].RefID)
+		assert.Equal(t, "C", sent.Queries[1].RefID)
+		assert.Len(t, req.Queries, 3)
+
+		require.Len(t, resp.Responses, 3)
+		assert.Equal(t, "B", resp.Responses["B"].Frames[0].RefID)
+		assert.Equal(t, "A", resp.Responses["A"].Frames[0].RefID)
+		assert.Equal(t, resp.Responses["A"].Frames[0].Fields, resp.Responses["B"].Frames[0].Fields)
+	})
+
+	t.Run("should not deduplicate queries with different time ranges", func(t *testing.T) {
+		d := &queryDeduplicator{}
+		var calls int64
+		requests := make(chan *backend.QueryDataRequest, 1)
+		req := newRequest(
+			backend.DataQuery{RefID: "A", JSON: []byte(`{"expr": "up"}`), TimeRange: timeRange},
+			backend.DataQuery{RefID: "B", JSON: []byte(`{"expr": "up"}`), TimeRange: backend.TimeRange{From: time.Unix(1000, 0), To: time.Unix(3000, 0)}},
+		)
+
+		_, err := d.queryData(context.Background(), signedInUser, ds, req, echo(&calls, requests))
+		require.NoError(t, err)
+		assert.Len(t, (<-requests).Queries, 2)
+	})
+
+	t.Run("should execute the identical requests running at the same time once", func(t *testing.T) {
+		d := &queryDeduplicator{}
+		var calls int64
+		release := make(chan struct{})
+		next := func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			<-release
+			return echo(&calls, nil)(ctx, req)
+		}
+
+		var wg sync.WaitGroup
+		responses := make([]*backend.QueryDataResponse, 3)
+		for i := range responses {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := newRequest(backend.DataQuery{RefID: "A", JSON: []byte(`{"expr": "up"}`), TimeRange: timeRange})
+				resp, err := d.queryData(context.Background(), signedInUser, ds, req, next)
+				assert.NoError(t, err)
+				responses[i] = resp
+			}()
+		}
+		// every request waits for the first one before it is released
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int64(1), calls)
+		for _, resp := range responses {
+			require.NotNil(t, resp)
+			assert.Len(t, resp.Responses["A"].Frames, 1)
+		}
+	})
+
+	t.Run("should copy the frame metadata for every request sharing a response", func(t *testing.T) {
+		d := &queryDeduplicator{}
+		release := make(chan struct{})
+		next := func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			<-release
+			frame := data.NewFrame("A", data.NewField("value", nil, []float64{1})).SetMeta(&data.FrameMeta{
+				ExecutedQueryString: "up",
+				Notices:             []data.Notice{{Severity: data.NoticeSeverityInfo, Text: "notice"}},
+			})
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{frame}}
+			return resp, nil
+		}
+
+		var wg sync.WaitGroup
+		responses := make([]*backend.QueryDataResponse, 2)
+		for i := range responses {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := newRequest(backend.DataQuery{RefID: "A", JSON: []byte(`{"expr": "up"}`), TimeRange: timeRange})
+				resp, err := d.queryData(context.Background(), signedInUser, ds, req, next)
+				if !assert.NoError(t, err) {
+					return
+				}
+				// the callers change the metadata of their frames, as the public dashboards remove the executed queries
+				meta := resp.Responses["A"].Frames[0].Meta
+				meta.ExecutedQueryString = ""
+				meta.Notices[0].Text = "changed"
+				meta.Notices = append(meta.Notices, data.Notice{Text: "added"})
+				responses[i] = resp
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.NotNil(t, responses[0])
+		require.NotNil(t, responses[1])
+		first, second := responses[0].Responses["A"].Frames[0], responses[1].Responses["A"].Frames[0]
+		assert.NotSame(t, first, second)
+		assert.NotSame(t, first.Meta, second.Meta)
+		assert.Len(t, first.Meta.Notices, 2)
+		assert.Len(t, second.Meta.Notices, 2)
+	})
+
+	t.Run("should not share the requests of different users or datasource versions", func(t *testing.T) {
+		req := newRequest(backend.DataQuery{RefID: "A", JSON: []byte(`{"expr": "up"}`), TimeRange: timeRange})
+		key, err := requestKey(signedInUser, ds, req)
+		require.NoError(t, err)
+
+		otherUser, err := requestKey(&user.SignedInUser{OrgID: 1, UserID: 3, Login: "other"}, ds, req)
+		require.NoError(t, err)
+		assert.NotEqual(t, key, otherUser)
+
+		otherTeams, err := requestKey(&user.SignedInUser{OrgID: 1, UserID: 2, Login: "login", Teams: []int64{4}}, ds, req)
+		require.NoError(t, err)
+		assert.NotEqual(t, key, otherTeams)
+
+		updated, err := requestKey(signedInUser, &datasources.DataSource{UID: "ds", Version: 2}, req)
+		require.NoError(t, err)
+		assert.NotEqual(t, key, updated)
+
+		same, err := requestKey(&user.SignedInUser{OrgID: 1, UserID: 2, Login: "login"}, &datasources.DataSource{UID: "ds", Version: 1}, newRequest(
+			backend.DataQuery{RefID: "A", JSON: []byte(`{"expr":"up"}`), TimeRange: timeRange},
+		))
+		require.NoError(t, err)
+		assert.Equal(t, key, same)
+	})
+
+	t.Run("should not share the requests of a captured query execution", func(t *testing.T) {
+		d := &queryDeduplicator{}
+		var calls int64
+		release := make(chan struct{})
+		next := func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			if querycapture.RecorderFromContext(ctx) == nil {
+				<-release
+			}
+			return echo(&calls, nil)(ctx, req)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			req := newRequest(backend.DataQuery{RefID: "A", JSON: []byte(`{"expr": "up"}`), TimeRange: timeRange})
+			_, err := d.queryData(context.Background(), signedInUser, ds, req, next)
+			assert.NoError(t, err)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		recorder, err := querycapture.ProvideService(routing.NewRouteRegister()).Start(signedInUser)
+		require.NoError(t, err)
+		req := newRequest(
+			backend.DataQuery{RefID: "A", JSON: []byte(`{"expr": "up"}`), TimeRange: timeRange},
+			backend.DataQuery{RefID: "B", JSON: []byte(`{"expr": "up"}`), TimeRange: timeRange},
+		)
+		resp, err := d.queryData(querycapture.WithRecorder(context.Background(), recorder), signedInUser, ds, req, next)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), atomic.LoadInt64(&calls))
+		assert.Len(t, resp.Responses, 2)
+
+		close(release)
+		<-done
+		assert.Equal(t, int64(2), calls)
+	})
+
+	t.Run("should send the queries again when the shared request is canceled", func(t *testing.T) {
+		d := &queryDeduplicator{}
+		var calls int64
+		started := make(chan struct{})
+		next := func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			if atomic.AddInt64(&calls, 1) == 1 {
+				close(started)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return backend.NewQueryDataResponse(), nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			_, _ = d.queryData(ctx, signedInUser, ds, newRequest(backend.DataQuery{RefID: "A", JSON: []byte(`{}`)}), next)
+		}()
+		<-started
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := d.queryData(context.Background(), signedInUser, ds, newRequest(backend.DataQuery{RefID: "A", JSON: []byte(`{}`)}), next)
+			done <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		require.NoError(t, <-done)
+		assert.Equal(t, int64(2), atomic.LoadInt64(&calls))
+	})
+}
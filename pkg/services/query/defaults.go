@@ -0,0 +1,64 @@
+package query
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// queryDefaults returns the query defaults of the user, nil when the user has none or they can't be read
+func (s *ServiceImpl) queryDefaults(ctx context.Context, user *user.SignedInUser) *pref.QueryDefaultsPreference {
+	if s.preferenceService == nil || user == nil || user.UserID <= 0 {
+		return nil
+	}
+	preference, err := s.preferenceService.Get(ctx, &pref.GetPreferenceQuery{OrgID: user.OrgID, UserID: user.UserID})
+	if err != nil {
+		s.log.Warn("Failed to get the query defaults of the user", "userId", user.UserID, "error", err)
+		return nil
+	}
+	if preference.JSONData == nil || preference.JSONData.QueryDefaults == nil || preference.JSONData.QueryDefaults.IsEmpty() {
+		return nil
+	}
+	return preference.JSONData.QueryDefaults
+}
+
+// applyQueryDefaults returns a copy of the request where the queries without max data points or datasource have the
+// ones of the defaults
+func applyQueryDefaults(reqDTO dtos.MetricRequest, defaults *pref.QueryDefaultsPreference) dtos.MetricRequest {
+	if defaults.MaxDataPoints <= 0 && defaults.DatasourceUID == "" {
+		return reqDTO
+	}
+
+	queries := make([]*simplejson.Json, 0, len(reqDTO.Queries))
+	for _, query := range reqDTO.Queries {
+		model, err := query.Map()
+		if err != nil {
+			queries = append(queries, query)
+			continue
+		}
+
+		// the models of the request are not modified
+		withDefaults := make(map[string]interface{}, len(model)+1)
+		for k, v := range model {
+			withDefaults[k] = v
+		}
+		if _, ok := model["maxDataPoints"]; !ok && defaults.MaxDataPoints > 0 {
+			withDefaults["maxDataPoints"] = defaults.MaxDataPoints
+		}
+		if !hasDatasource(query) && defaults.DatasourceUID != "" {
+			withDefaults["datasource"] = map[string]interface{}{"uid": defaults.DatasourceUID}
+		}
+		queries = append(queries, simplejson.NewFromAny(withDefaults))
+	}
+	return reqDTO.CloneWithQueries(queries)
+}
+
+// hasDatasource returns true when the query has a datasource, see getDataSourceFromQuery
+func hasDatasource(query *simplejson.Json) bool {
+	return query.Get("datasource").Get("uid").MustString() != "" ||
+		query.Get("datasource").MustString() != "" ||
+		query.Get("datasourceId").MustInt64(0) > 0
+}
@@ -0,0 +1,73 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pref "github.com/grafana/grafana/pkg/services/preference"
+	"github.com/grafana/grafana/pkg/services/preference/preftest"
+)
+
+func TestApplyQueryDefaults(t *testing.T) {
+	mr := metricRequestWithQueries(t,
+		`{"refId": "A"}`,
+		`{"refId": "B", "maxDataPoints": 50, "datasource": {"uid": "loki"}}`,
+		`{"refId": "C", "datasource": "__expr__"}`,
+		`{"refId": "D", "datasourceId": 1}`,
+	)
+
+	withDefaults := applyQueryDefaults(mr, &pref.QueryDefaultsPreference{MaxDataPoints: 500, DatasourceUID: "prom"})
+	require.Len(t, withDefaults.Queries, 4)
+	assert.Equal(t, int64(500), withDefaults.Queries[0].Get("maxDataPoints").MustInt64())
+	assert.Equal(t, "prom", withDefaults.Queries[0].Get("datasource").Get("uid").MustString())
+	// the options set by the queries are kept
+	assert.Equal(t, int64(50), withDefaults.Queries[1].Get("maxDataPoints").MustInt64())
+	assert.Equal(t, "loki", withDefaults.Queries[1].Get("datasource").Get("uid").MustString())
+	assert.Equal(t, "__expr__", withDefaults.Queries[2].Get("datasource").MustString())
+	assert.Equal(t, int64(1), withDefaults.Queries[3].Get("datasourceId").MustInt64())
+	assert.Empty(t, withDefaults.Queries[3].Get("datasource").Get("uid").MustString())
+
+	// the queries of the request are not modified
+	_, ok := mr.Queries[0].CheckGet("maxDataPoints")
+	assert.False(t, ok)
+}
+
+func TestQueryDataWithQueryDefaults(t *testing.T) {
+	withDefaults := func(t *testing.T, defaults *pref.QueryDefaultsPreference) *testContext {
+		t.Helper()
+		tc := setup(t)
+		tc.signedInUser.UserID = 2
+		tc.queryService.preferenceService = &preftest.FakePreferenceService{
+			ExpectedPreference: &pref.Preference{JSONData: &pref.PreferenceJSONData{QueryDefaults: defaults}},
+		}
+		return tc
+	}
+
+	t.Run("max data points and datasource of the queries that don't set them", func(t *testing.T) {
+		tc := withDefaults(t, &pref.QueryDefaultsPreference{MaxDataPoints: 500, DatasourceUID: "prom"})
+		_, err := tc.queryService.QueryData(context.Background(), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A"}`))
+		require.NoError(t, err)
+		require.Len(t, tc.pluginContext.req.Queries, 1)
+		assert.Equal(t, int64(500), tc.pluginContext.req.Queries[0].MaxDataPoints)
+		assert.Equal(t, "prom", tc.pluginContext.req.PluginContext.DataSourceInstanceSettings.UID)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		tc := withDefaults(t, &pref.QueryDefaultsPreference{TimeoutSeconds: 1})
+		_, err := tc.queryService.QueryData(context.Background(), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A", "queryType": "SLOW", "datasource": {"uid": "prom"}}`))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("the queries run without defaults when they can't be read", func(t *testing.T) {
+		tc := setup(t)
+		tc.signedInUser.UserID = 2
+		tc.queryService.preferenceService = &preftest.FakePreferenceService{ExpectedError: errors.New("db error")}
+		_, err := tc.queryService.QueryData(context.Background(), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A", "datasource": {"uid": "prom"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), tc.pluginContext.req.Queries[0].MaxDataPoints)
+	})
+}
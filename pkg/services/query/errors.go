@@ -5,9 +5,12 @@ import (
 )
 
 var (
-	ErrNoQueriesFound        = errutil.NewBase(errutil.StatusBadRequest, "query.noQueries", errutil.WithPublicMessage("No queries found")).Errorf("no queries found")
-	ErrInvalidDatasourceID   = errutil.NewBase(errutil.StatusBadRequest, "query.invalidDatasourceId", errutil.WithPublicMessage("Query does not contain a valid data source identifier")).Errorf("invalid data source identifier")
-	ErrMissingDataSourceInfo = errutil.NewBase(errutil.StatusBadRequest, "query.missingDataSourceInfo").MustTemplate("query missing datasource info: {{ .Public.RefId }}", errutil.WithPublic("Query {{ .Public.RefId }} is missing datasource information"))
-	ErrQueryParamMismatch    = errutil.NewBase(errutil.StatusBadRequest, "query.headerMismatch", errutil.WithPublicMessage("The request headers point to a different plugin than is defined in the request body")).Errorf("plugin header/body mismatch")
-	ErrDuplicateRefId        = errutil.NewBase(errutil.StatusBadRequest, "query.duplicateRefId", errutil.WithPublicMessage("Multiple queries using the same RefId is not allowed ")).Errorf("multiple queries using the same RefId is not allowed")
+	ErrNoQueriesFound           = errutil.NewBase(errutil.StatusBadRequest, "query.noQueries", errutil.WithPublicMessage("No queries found")).Errorf("no queries found")
+	ErrInvalidDatasourceID      = errutil.NewBase(errutil.StatusBadRequest, "query.invalidDatasourceId", errutil.WithPublicMessage("Query does not contain a valid data source identifier")).Errorf("invalid data source identifier")
+	ErrMissingDataSourceInfo    = errutil.NewBase(errutil.StatusBadRequest, "query.missingDataSourceInfo").MustTemplate("query missing datasource info: {{ .Public.RefId }}", errutil.WithPublic("Query {{ .Public.RefId }} is missing datasource information"))
+	ErrQueryParamMismatch       = errutil.NewBase(errutil.StatusBadRequest, "query.headerMismatch", errutil.WithPublicMessage("The request headers point to a different plugin than is defined in the request body")).Errorf("plugin header/body mismatch")
+	ErrDuplicateRefId           = errutil.NewBase(errutil.StatusBadRequest, "query.duplicateRefId", errutil.WithPublicMessage("Multiple queries using the same RefId is not allowed ")).Errorf("multiple queries using the same RefId is not allowed")
+	ErrDatasourceTimeout        = errutil.NewBase(errutil.StatusTimeout, "query.datasourceTimeout").MustTemplate("queries of data source {{ .Public.DatasourceUID }} timed out after {{ .Public.Timeout }}", errutil.WithPublic("The queries of data source {{ .Public.DatasourceUID }} timed out after {{ .Public.Timeout }}"))
+	ErrLabelMatchersNotEnforced = errutil.NewBase(errutil.StatusBadRequest, "query.labelMatchersNotEnforced").MustTemplate("the label matchers of the folder can't be added to query {{ .Public.RefId }}: {{ .Error }}", errutil.WithPublic("The label matchers of the folder of the dashboard can't be added to query {{ .Public.RefId }}"))
+	ErrQuotaReached             = errutil.NewBase(errutil.StatusTooManyRequests, "query.quotaReached").MustTemplate("organization reached the {{ .Public.Target }} quota of {{ .Public.Limit }}", errutil.WithPublic("Query quota reached, the {{ .Public.Target }} limit is {{ .Public.Limit }}"))
 )
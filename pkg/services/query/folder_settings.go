@@ -0,0 +1,95 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/datasources/enforcer"
+	"github.com/grafana/grafana/pkg/services/foldersettings"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// dashboardSettings returns the settings inherited from its folder by the dashboard of the request, set in the
+// X-Dashboard-Uid header. It returns nil when the request isn't a dashboard request or the dashboard inherits no
+// settings.
+//
+// The header is set by the client, the settings are conventions shared by the dashboards of a folder and not an
+// access control: a request without the header, or with the header of another dashboard, gets other settings.
+func (s *ServiceImpl) dashboardSettings(ctx context.Context, user *user.SignedInUser) (*foldersettings.Settings, error) {
+	if s.folderSettingsService == nil || user == nil || user.OrgID <= 0 {
+		return nil, nil
+	}
+	reqCtx := contexthandler.FromContext(ctx)
+	if reqCtx == nil || reqCtx.Req == nil {
+		return nil, nil
+	}
+	dashboardUID := reqCtx.Req.Header.Get(HeaderDashboardUID)
+	if dashboardUID == "" {
+		return nil, nil
+	}
+
+	settings, err := s.folderSettingsService.GetDashboardSettings(ctx, user.OrgID, dashboardUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the folder settings of dashboard %s: %w", dashboardUID, err)
+	}
+	if settings.IsEmpty() {
+		return nil, nil
+	}
+	return &settings, nil
+}
+
+// applyLabelMatchers returns a copy of the request where the label matchers are added to the selectors of the
+// Prometheus queries. The queries that can't be parsed fail instead of being sent without the label matchers.
+func (s *ServiceImpl) applyLabelMatchers(ctx context.Context, user *user.SignedInUser, skipCache bool, reqDTO dtos.MetricRequest, labelMatchers []string) (dtos.MetricRequest, error) {
+	matchers, err := enforcer.ParseLabelMatchers(labelMatchers)
+	if err != nil {
+		return reqDTO, err
+	}
+
+	datasourcesByUid := map[string]*datasources.DataSource{}
+	queries := make([]*simplejson.Json, 0, len(reqDTO.Queries))
+	for _, query := range reqDTO.Queries {
+		expr := query.Get("expr").MustString()
+		if expr == "" || s.queryDatasourceType(ctx, user, skipCache, query, datasourcesByUid) != datasources.DS_PROMETHEUS {
+			queries = append(queries, query)
+			continue
+		}
+
+		enforced, err := enforcer.PromQL(expr, matchers)
+		if err != nil {
+			return reqDTO, ErrLabelMatchersNotEnforced.Build(errutil.TemplateData{
+				Public: map[string]interface{}{"RefId": query.Get("refId").MustString("A")},
+				Error:  err,
+			})
+		}
+
+		queryModel, err := query.Map()
+		if err != nil {
+			return reqDTO, err
+		}
+		// the models of the request are not modified
+		withMatchers := make(map[string]interface{}, len(queryModel))
+		for k, v := range queryModel {
+			withMatchers[k] = v
+		}
+		withMatchers["expr"] = enforced
+		queries = append(queries, simplejson.NewFromAny(withMatchers))
+	}
+	return reqDTO.CloneWithQueries(queries), nil
+}
+
+// queryDatasourceType returns the type of the datasource of the query, empty when the datasource can't be found. The
+// type is the one of the datasource found by its UID, not the type set in the query by the client.
+func (s *ServiceImpl) queryDatasourceType(ctx context.Context, user *user.SignedInUser, skipCache bool, query *simplejson.Json, history map[string]*datasources.DataSource) string {
+	ds, err := s.getDataSourceFromQuery(ctx, user, skipCache, query, history)
+	if err != nil || ds == nil {
+		return ""
+	}
+	history[ds.UID] = ds
+	return ds.Type
+}
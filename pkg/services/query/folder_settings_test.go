@@ -0,0 +1,85 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/foldersettings"
+	"github.com/grafana/grafana/pkg/services/foldersettings/foldersettingstest"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestQueryDataWithFolderSettings(t *testing.T) {
+	dashboardContext := func(t *testing.T) context.Context {
+		t.Helper()
+		httpreq, err := http.NewRequest(http.MethodPost, "http://localhost/api/ds/query", bytes.NewReader([]byte{}))
+		require.NoError(t, err)
+		httpreq.Header.Set(HeaderDashboardUID, "dash")
+		return ctxkey.Set(context.Background(), &contextmodel.ReqContext{Context: &web.Context{Req: httpreq}})
+	}
+	withSettings := func(t *testing.T, settings foldersettings.Settings) *testContext {
+		t.Helper()
+		tc := setup(t)
+		tc.queryService.folderSettingsService = &foldersettingstest.FakeService{ExpectedInheritedSettings: settings}
+		return tc
+	}
+
+	t.Run("default datasource of the queries without datasource", func(t *testing.T) {
+		tc := withSettings(t, foldersettings.Settings{DefaultDatasourceUID: "prom"})
+		_, err := tc.queryService.QueryData(dashboardContext(t), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "prom", tc.pluginContext.req.PluginContext.DataSourceInstanceSettings.UID)
+	})
+
+	t.Run("the settings are only applied to the dashboard queries", func(t *testing.T) {
+		tc := withSettings(t, foldersettings.Settings{DefaultDatasourceUID: "prom"})
+		_, err := tc.queryService.QueryData(context.Background(), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A"}`))
+		require.ErrorIs(t, err, ErrInvalidDatasourceID)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		tc := withSettings(t, foldersettings.Settings{QueryTimeoutSeconds: 1})
+		_, err := tc.queryService.QueryData(dashboardContext(t), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A", "queryType": "SLOW", "datasource": {"uid": "prom"}}`))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("label matchers of the Prometheus queries", func(t *testing.T) {
+		tc := withSettings(t, foldersettings.Settings{LabelMatchers: []string{`team="payments"`}})
+		tc.dataSourceCache.types = map[string]string{"prom": datasources.DS_PROMETHEUS, "loki": datasources.DS_LOKI}
+		mr := metricRequestWithQueries(t, `{"refId": "A", "expr": "up", "datasource": {"uid": "prom", "type": "prometheus"}}`)
+		_, err := tc.queryService.QueryData(dashboardContext(t), tc.signedInUser, true, mr)
+		require.NoError(t, err)
+		require.Len(t, tc.pluginContext.req.Queries, 1)
+		assert.JSONEq(t, `{"refId": "A", "expr": "up{team=\"payments\"}", "datasource": {"uid": "prom", "type": "prometheus"}}`, string(tc.pluginContext.req.Queries[0].JSON))
+		// the queries of the request are not modified
+		assert.Equal(t, "up", mr.Queries[0].Get("expr").MustString())
+
+		_, err = tc.queryService.QueryData(dashboardContext(t), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A", "expr": "sum(", "datasource": {"uid": "prom", "type": "prometheus"}}`))
+		require.ErrorIs(t, err, ErrLabelMatchersNotEnforced)
+
+		// the type of the datasource is not the one set in the query
+		_, err = tc.queryService.QueryData(dashboardContext(t), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A", "expr": "up", "datasource": {"uid": "prom", "type": "loki"}}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"refId": "A", "expr": "up{team=\"payments\"}", "datasource": {"uid": "prom", "type": "loki"}}`, string(tc.pluginContext.req.Queries[0].JSON))
+
+		_, err = tc.queryService.QueryData(dashboardContext(t), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A", "expr": "{app=\"shop\"}", "datasource": {"uid": "loki", "type": "prometheus"}}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"refId": "A", "expr": "{app=\"shop\"}", "datasource": {"uid": "loki", "type": "prometheus"}}`, string(tc.pluginContext.req.Queries[0].JSON))
+	})
+
+	t.Run("the queries fail when the settings can't be read", func(t *testing.T) {
+		tc := setup(t)
+		tc.queryService.folderSettingsService = &foldersettingstest.FakeService{ExpectedError: errors.New("db error")}
+		_, err := tc.queryService.QueryData(dashboardContext(t), tc.signedInUser, true, metricRequestWithQueries(t, `{"refId": "A", "datasource": {"uid": "prom"}}`))
+		require.Error(t, err)
+	})
+}
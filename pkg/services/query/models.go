@@ -11,6 +11,34 @@ import (
 	"github.com/grafana/grafana/pkg/services/datasources"
 )
 
+// Priority tells datasources whether a user is waiting for the results of a query. Datasources can queue or shed
+// background queries under load so that interactive queries stay responsive.
+type Priority string
+
+const (
+	PriorityInteractive Priority = "interactive"
+	PriorityBackground  Priority = "background"
+)
+
+// queryPriority returns the priority set in the X-Query-Priority header of the request. Without the header, queries of
+// dashboards and queries without an HTTP request, like the ones of alerting and reporting, are background queries,
+// other queries, like the ones of Explore, are interactive.
+func queryPriority(ctx context.Context) Priority {
+	reqCtx := contexthandler.FromContext(ctx)
+	if reqCtx == nil || reqCtx.Req == nil {
+		return PriorityBackground
+	}
+
+	switch p := Priority(strings.ToLower(reqCtx.Req.Header.Get(HeaderQueryPriority))); p {
+	case PriorityInteractive, PriorityBackground:
+		return p
+	}
+	if reqCtx.Req.Header.Get(HeaderDashboardUID) != "" {
+		return PriorityBackground
+	}
+	return PriorityInteractive
+}
+
 type parsedQuery struct {
 	datasource *datasources.DataSource
 	query      backend.DataQuery
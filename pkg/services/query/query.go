@@ -2,19 +2,29 @@ package query
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/appcontext"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/foldersettings"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/adapters"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/validations"
 	"github.com/grafana/grafana/pkg/setting"
@@ -29,6 +39,7 @@ const (
 	HeaderDashboardUID  = "X-Dashboard-Uid"  // mainly useful for debuging slow queries
 	HeaderPanelID       = "X-Panel-Id"       // mainly useful for debuging slow queries
 	HeaderQueryGroupID  = "X-Query-Group-Id" // mainly useful for finding related queries with query chunking
+	HeaderQueryPriority = "X-Query-Priority" // can be used to queue or shed background queries under load
 )
 
 func ProvideService(
@@ -38,6 +49,10 @@ func ProvideService(
 	pluginRequestValidator validations.PluginRequestValidator,
 	dataSourceService datasources.DataSourceService,
 	pluginClient plugins.Client,
+	quotaService quota.Service,
+	preferenceService pref.Service,
+	folderSettingsService foldersettings.Service,
+	remoteCache remotecache.CacheStorage,
 ) *ServiceImpl {
 	g := &ServiceImpl{
 		cfg:                    cfg,
@@ -46,9 +61,39 @@ func ProvideService(
 		pluginRequestValidator: pluginRequestValidator,
 		dataSourceService:      dataSourceService,
 		pluginClient:           pluginClient,
+		preferenceService:      preferenceService,
+		folderSettingsService:  folderSettingsService,
 		log:                    log.New("query_data"),
 	}
 	g.log.Info("Query Service initialization")
+
+	if cfg.IsFeatureToggleEnabled != nil && cfg.IsFeatureToggleEnabled(featuremgmt.FlagQueryDeduplication) {
+		g.dedupe = &queryDeduplicator{}
+	}
+	g.resultCache = newResultCache(cfg, remoteCache)
+	if g.resultCache != nil {
+		cfg.OnReload("query", func() error {
+			g.resultCache.configure(cfg)
+			return nil
+		})
+	}
+
+	if quotaService != nil {
+		g.quota = newQuotaLimiter(quotaService)
+		defaultLimits, err := readQuotaConfig(cfg)
+		if err == nil {
+			err = quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
+				TargetSrv:         QuotaTargetSrv,
+				DefaultLimits:     defaultLimits,
+				Reporter:          g.quota.Usage,
+				ReadDefaultLimits: readQuotaConfig,
+			})
+		}
+		if err != nil {
+			g.log.Error("Failed to register query quota", "error", err)
+			g.quota = nil
+		}
+	}
 	return g
 }
 
@@ -68,7 +113,18 @@ type ServiceImpl struct {
 	pluginRequestValidator validations.PluginRequestValidator
 	dataSourceService      datasources.DataSourceService
 	pluginClient           plugins.Client
-	log                    log.Logger
+	// preferenceService gets the query defaults of the users, they are not applied when it is nil
+	preferenceService pref.Service
+	// folderSettingsService gets the settings inherited by the dashboards from their folder, they are not applied
+	// when it is nil
+	folderSettingsService foldersettings.Service
+	log                   log.Logger
+	quota                 *quotaLimiter
+	// dedupe executes the identical queries of a user once, it is nil when the deduplication is disabled
+	dedupe *queryDeduplicator
+	// resultCache shares the responses of the datasources between the Grafana instances, it is nil when there is no
+	// remote cache
+	resultCache *resultCache
 }
 
 // Run ServiceImpl.
@@ -78,7 +134,56 @@ func (s *ServiceImpl) Run(ctx context.Context) error {
 }
 
 // QueryData processes queries and returns query responses. It handles queries to single or mixed datasources, as well as expressions.
+// It fails with a QuotaReachedError when the organization of the user reached one of its query quotas.
+// The settings inherited from its folder by the dashboard of the request, then the query defaults of the user, are
+// applied to the queries that don't set them.
 func (s *ServiceImpl) QueryData(ctx context.Context, user *user.SignedInUser, skipCache bool, reqDTO dtos.MetricRequest) (*backend.QueryDataResponse, error) {
+	if s.quota != nil && user != nil && user.OrgID > 0 {
+		release, err := s.quota.acquire(ctx, user.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	if user != nil {
+		// the client middlewares read the user of the requests sent without an HTTP request, such as the queries of
+		// the public dashboards and the asynchronous queries
+		ctx = appcontext.WithUser(ctx, user)
+		if org, ok := metrics.OrgLabel(user.OrgID); ok {
+			metrics.MOrgDataQueries.WithLabelValues(org).Add(float64(len(reqDTO.Queries)))
+		}
+	}
+	settings, err := s.dashboardSettings(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil {
+		reqDTO = applyQueryDefaults(reqDTO, &pref.QueryDefaultsPreference{DatasourceUID: settings.DefaultDatasourceUID})
+		if timeout := settings.Timeout(); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+	}
+	if defaults := s.queryDefaults(ctx, user); defaults != nil {
+		reqDTO = applyQueryDefaults(reqDTO, defaults)
+		if timeout := defaults.Timeout(); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+	}
+	if settings != nil && len(settings.LabelMatchers) > 0 {
+		// the label matchers are added once the datasources of all the queries are known
+		reqDTO, err = s.applyLabelMatchers(ctx, user, skipCache, reqDTO, settings.LabelMatchers)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.queryData(ctx, user, skipCache, reqDTO)
+}
+
+func (s *ServiceImpl) queryData(ctx context.Context, user *user.SignedInUser, skipCache bool, reqDTO dtos.MetricRequest) (*backend.QueryDataResponse, error) {
 	// Parse the request into parsed queries grouped by datasource uid
 	parsedReq, err := s.parseMetricRequest(ctx, user, skipCache, reqDTO)
 	if err != nil {
@@ -91,53 +196,29 @@ func (s *ServiceImpl) QueryData(ctx context.Context, user *user.SignedInUser, sk
 	}
 	// If there is only one datasource, query it and return
 	if len(parsedReq.parsedQueries) == 1 {
-		return s.handleQuerySingleDatasource(ctx, user, parsedReq)
+		return s.handleQuerySingleDatasource(ctx, user, skipCache, parsedReq)
 	}
 	// If there are multiple datasources, handle their queries concurrently and return the aggregate result
 	return s.executeConcurrentQueries(ctx, user, skipCache, reqDTO, parsedReq.parsedQueries)
 }
 
 // executeConcurrentQueries executes queries to multiple datasources concurrently and returns the aggregate result.
+// A failing datasource only fails its own queries, the responses of the other datasources are still returned.
 func (s *ServiceImpl) executeConcurrentQueries(ctx context.Context, user *user.SignedInUser, skipCache bool, reqDTO dtos.MetricRequest, queriesbyDs map[string][]parsedQuery) (*backend.QueryDataResponse, error) {
 	g, ctx := errgroup.WithContext(ctx)
 	g.SetLimit(8) // arbitrary limit to prevent too many concurrent requests
 	rchan := make(chan backend.Responses, len(queriesbyDs))
 
-	// Create panic recovery function for loop below
-	recoveryFn := func(queries []*simplejson.Json) {
-		if r := recover(); r != nil {
-			var err error
-			s.log.Error("query datasource panic", "error", r, "stack", log.Stack(1))
-			if theErr, ok := r.(error); ok {
-				err = theErr
-			} else if theErrString, ok := r.(string); ok {
-				err = fmt.Errorf(theErrString)
-			} else {
-				err = fmt.Errorf("unexpected error, see the server log for details")
-			}
-			// Due to the panic, there is no valid response for any query for this datasource. Append an error for each one.
-			rchan <- buildErrorResponses(err, queries)
-		}
-	}
-
 	// Query each datasource concurrently
-	for _, queries := range queriesbyDs {
+	for dsUID, queries := range queriesbyDs {
+		dsUID := dsUID
 		rawQueries := make([]*simplejson.Json, len(queries))
 		for i := 0; i < len(queries); i++ {
 			rawQueries[i] = queries[i].rawQuery
 		}
 		g.Go(func() error {
 			subDTO := reqDTO.CloneWithQueries(rawQueries)
-			// Handle panics in the datasource qery
-			defer recoveryFn(subDTO.Queries)
-
-			subResp, err := s.QueryData(ctx, user, skipCache, subDTO)
-			if err == nil {
-				rchan <- subResp.Responses
-			} else {
-				// If there was an error, return an error response for each query for this datasource
-				rchan <- buildErrorResponses(err, subDTO.Queries)
-			}
+			rchan <- s.queryDatasourceWithTimeout(ctx, user, skipCache, subDTO, dsUID)
 			return nil
 		})
 	}
@@ -156,12 +237,84 @@ func (s *ServiceImpl) executeConcurrentQueries(ctx context.Context, user *user.S
 	return resp, nil
 }
 
+// queryDatasourceWithTimeout executes the queries of one datasource of a mixed request. When the datasource doesn't
+// answer within the mixed datasource timeout its queries fail with ErrDatasourceTimeout, without waiting for the
+// datasource any longer.
+func (s *ServiceImpl) queryDatasourceWithTimeout(ctx context.Context, user *user.SignedInUser, skipCache bool, subDTO dtos.MetricRequest, dsUID string) backend.Responses {
+	timeout := s.cfg.MixedDatasourceTimeout
+	if timeout <= 0 {
+		return s.queryDatasource(ctx, user, skipCache, subDTO)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// buffered so that the query of a datasource that timed out doesn't block when it completes
+	result := make(chan backend.Responses, 1)
+	go func() {
+		result <- s.queryDatasource(ctx, user, skipCache, subDTO)
+	}()
+
+	select {
+	case responses := <-result:
+		return responses
+	case <-ctx.Done():
+		err := ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			s.log.Warn("Datasource queries timed out", "datasourceUid", dsUID, "timeout", timeout)
+			err = ErrDatasourceTimeout.Build(errutil.TemplateData{
+				Public: map[string]interface{}{
+					"DatasourceUID": dsUID,
+					"Timeout":       timeout.String(),
+				},
+			})
+		}
+		return buildErrorResponses(err, subDTO.Queries)
+	}
+}
+
+// queryDatasource executes the queries of one datasource of a mixed request, an error or a panic of the datasource
+// is returned as the error of each of its queries.
+func (s *ServiceImpl) queryDatasource(ctx context.Context, user *user.SignedInUser, skipCache bool, subDTO dtos.MetricRequest) (responses backend.Responses) {
+	// Handle panics in the datasource query
+	defer func() {
+		if r := recover(); r != nil {
+			var err error
+			s.log.Error("query datasource panic", "error", r, "stack", log.Stack(1))
+			if theErr, ok := r.(error); ok {
+				err = theErr
+			} else if theErrString, ok := r.(string); ok {
+				err = fmt.Errorf(theErrString)
+			} else {
+				err = fmt.Errorf("unexpected error, see the server log for details")
+			}
+			// Due to the panic, there is no valid response for any query for this datasource. Append an error for each one.
+			responses = buildErrorResponses(err, subDTO.Queries)
+		}
+	}()
+
+	subResp, err := s.queryData(ctx, user, skipCache, subDTO)
+	if err != nil {
+		// If there was an error, return an error response for each query for this datasource
+		return buildErrorResponses(err, subDTO.Queries)
+	}
+	return subResp.Responses
+}
+
 // buildErrorResponses applies the provided error to each query response in the list. These queries should all belong to the same datasource.
+// The status of the responses is the status of the error when it has one.
 func buildErrorResponses(err error, queries []*simplejson.Json) backend.Responses {
+	var status backend.Status
+	var grafanaErr errutil.Error
+	if errors.As(err, &grafanaErr) {
+		status = backend.Status(grafanaErr.Reason.Status().HTTPStatus())
+	}
+
 	er := backend.Responses{}
 	for _, query := range queries {
 		er[query.Get("refId").MustString("A")] = backend.DataResponse{
-			Error: err,
+			Error:  err,
+			Status: status,
 		}
 	}
 	return er
@@ -209,7 +362,7 @@ func (s *ServiceImpl) handleExpressions(ctx context.Context, user *user.SignedIn
 }
 
 // handleQuerySingleDatasource handles one or more queries to a single datasource
-func (s *ServiceImpl) handleQuerySingleDatasource(ctx context.Context, user *user.SignedInUser, parsedReq *parsedRequest) (*backend.QueryDataResponse, error) {
+func (s *ServiceImpl) handleQuerySingleDatasource(ctx context.Context, user *user.SignedInUser, skipCache bool, parsedReq *parsedRequest) (*backend.QueryDataResponse, error) {
 	queries := parsedReq.getFlattenedQueries()
 	ds := queries[0].datasource
 	if err := s.pluginRequestValidator.Validate(ds.URL, nil); err != nil {
@@ -242,8 +395,24 @@ func (s *ServiceImpl) handleQuerySingleDatasource(ctx context.Context, user *use
 	for _, q := range queries {
 		req.Queries = append(req.Queries, q.query)
 	}
-
-	return s.pluginClient.QueryData(ctx, req)
+	// the data source is recorded as an event, as a request can query several data sources
+	trace.SpanFromContext(ctx).AddEvent("datasource query", trace.WithAttributes(
+		tracing.DatasourceTypeAttributeKey.String(ds.Type),
+		tracing.DatasourceUIDAttributeKey.String(ds.UID),
+	))
+	req.SetHTTPHeader(HeaderQueryPriority, string(queryPriority(ctx)))
+
+	next := s.pluginClient.QueryData
+	if s.dedupe != nil {
+		next = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			return s.dedupe.queryData(ctx, user, ds, req, s.pluginClient.QueryData)
+		}
+	}
+	// the requests not found in the cache are still deduplicated
+	if s.resultCache != nil {
+		return s.resultCache.queryData(ctx, user, skipCache, ds, req, next)
+	}
+	return next(ctx, req)
 }
 
 // parseRequest parses a request into parsed queries grouped by datasource uid
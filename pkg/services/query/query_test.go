@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/assert"
@@ -17,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/models/roletype"
 	"github.com/grafana/grafana/pkg/plugins"
 	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
@@ -401,6 +403,43 @@ func TestQueryDataMultipleSources(t *testing.T) {
 		require.NotContains(t, res.Responses, "A")
 	})
 
+	t.Run("the queries of a datasource time out without failing the other queries", func(t *testing.T) {
+		tc := setup(t)
+		tc.queryService.cfg.MixedDatasourceTimeout = 500 * time.Millisecond
+
+		reqDTO := metricRequestWithQueries(t, `{
+			"datasource": {
+				"type": "mysql",
+				"uid": "ds1"
+			},
+			"refId": "A",
+			"queryType": "FAIL"
+		}`, `{
+			"datasource": {
+				"type": "prometheus",
+				"uid": "ds2"
+			},
+			"refId": "B",
+			"queryType": "SLOW"
+		}`, `{
+			"datasource": {
+				"type": "prometheus",
+				"uid": "ds2"
+			},
+			"refId": "C"
+		}`)
+
+		res, err := tc.queryService.QueryData(context.Background(), tc.signedInUser, true, reqDTO)
+		require.NoError(t, err)
+
+		require.EqualError(t, res.Responses["A"].Error, "plugin client failed")
+		for _, refID := range []string{"B", "C"} {
+			require.ErrorIs(t, res.Responses[refID].Error, ErrDatasourceTimeout)
+			assert.Equal(t, "[query.datasourceTimeout] queries of data source ds2 timed out after 500ms", res.Responses[refID].Error.Error())
+			assert.Equal(t, backend.StatusTimeout, res.Responses[refID].Status)
+		}
+	})
+
 	t.Run("ignores a deprecated datasourceID", func(t *testing.T) {
 		tc := setup(t)
 		query1, err := simplejson.NewJson([]byte(`
@@ -429,6 +468,45 @@ func TestQueryDataMultipleSources(t *testing.T) {
 	})
 }
 
+func TestQueryDataPriority(t *testing.T) {
+	mr := metricRequestWithQueries(t, `{
+		"refId": "A",
+		"datasource": {
+			"uid": "gIEkMvIVz",
+			"type": "tempo"
+		}
+	}`)
+
+	requestContext := func(t *testing.T, headers map[string]string) context.Context {
+		t.Helper()
+		httpreq, err := http.NewRequest(http.MethodPost, "http://localhost/api/ds/query", bytes.NewReader([]byte{}))
+		require.NoError(t, err)
+		for k, v := range headers {
+			httpreq.Header.Set(k, v)
+		}
+		return ctxkey.Set(context.Background(), &contextmodel.ReqContext{Context: &web.Context{Req: httpreq}})
+	}
+
+	for _, tc := range []struct {
+		name     string
+		ctx      context.Context
+		expected Priority
+	}{
+		{name: "queries without a request are background queries", ctx: context.Background(), expected: PriorityBackground},
+		{name: "explore queries are interactive", ctx: requestContext(t, nil), expected: PriorityInteractive},
+		{name: "dashboard queries are background queries", ctx: requestContext(t, map[string]string{HeaderDashboardUID: "abc"}), expected: PriorityBackground},
+		{name: "priority header is used", ctx: requestContext(t, map[string]string{HeaderDashboardUID: "abc", HeaderQueryPriority: "Interactive"}), expected: PriorityInteractive},
+		{name: "invalid priority header is ignored", ctx: requestContext(t, map[string]string{HeaderQueryPriority: "urgent"}), expected: PriorityInteractive},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			qs := setup(t)
+			_, err := qs.queryService.QueryData(tc.ctx, qs.signedInUser, true, mr)
+			require.NoError(t, err)
+			assert.Equal(t, string(tc.expected), qs.pluginContext.req.GetHTTPHeader(HeaderQueryPriority))
+		})
+	}
+}
+
 func setup(t *testing.T) *testContext {
 	t.Helper()
 	pc := &fakePluginClient{}
@@ -446,8 +524,8 @@ func setup(t *testing.T) *testContext {
 		DataSources:           nil,
 		SimulatePluginFailure: false,
 	}
-	exprService := expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, pc, fakeDatasourceService)
-	queryService := ProvideService(setting.NewCfg(), dc, exprService, rv, ds, pc) // provider belonging to this package
+	exprService := expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, pc, fakeDatasourceService, tracing.InitializeTracerForTest())
+	queryService := ProvideService(setting.NewCfg(), dc, exprService, rv, ds, pc, quotaService, nil, nil, nil) // provider belonging to this package
 	return &testContext{
 		pluginContext:          pc,
 		secretStore:            ss,
@@ -493,6 +571,8 @@ func (rv *fakePluginRequestValidator) Validate(dsURL string, req *http.Request)
 
 type fakeDataSourceCache struct {
 	ds *datasources.DataSource
+	// types are the types of the datasources by UID, the datasources have no type by default
+	types map[string]string
 }
 
 func (c *fakeDataSourceCache) GetDatasource(ctx context.Context, datasourceID int64, user *user.SignedInUser, skipCache bool) (*datasources.DataSource, error) {
@@ -502,7 +582,8 @@ func (c *fakeDataSourceCache) GetDatasource(ctx context.Context, datasourceID in
 
 func (c *fakeDataSourceCache) GetDatasourceByUID(ctx context.Context, datasourceUID string, user *user.SignedInUser, skipCache bool) (*datasources.DataSource, error) {
 	return &datasources.DataSource{
-		UID: datasourceUID,
+		UID:  datasourceUID,
+		Type: c.types[datasourceUID],
 	}, nil
 }
 
@@ -523,5 +604,10 @@ func (c *fakePluginClient) QueryData(ctx context.Context, req *backend.QueryData
 		return nil, errors.New("plugin client failed")
 	}
 
+	if req.Queries[0].QueryType == "SLOW" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
 	return &backend.QueryDataResponse{Responses: make(backend.Responses)}, nil
 }
@@ -0,0 +1,215 @@
+package query
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+const (
+	QuotaTargetSrv               quota.TargetSrv = "query"
+	QuotaTargetQueriesPerMinute  quota.Target    = "queries_per_minute"
+	QuotaTargetConcurrentQueries quota.Target    = "concurrent_queries"
+
+	quotaWindow = time.Minute
+
+	// limits are cached so every query does not read the quota table, updates apply after this delay
+	quotaLimitsCacheTTL = 30 * time.Second
+)
+
+// QuotaReachedError is returned when the organization of the user reached one of its query quotas
+type QuotaReachedError struct {
+	Target     quota.Target
+	Limit      int64
+	RetryAfter time.Duration
+}
+
+func (e QuotaReachedError) Error() string {
+	return e.Unwrap().Error()
+}
+
+func (e QuotaReachedError) Unwrap() error {
+	return ErrQuotaReached.Build(errutil.TemplateData{
+		Public: map[string]interface{}{
+			"Target": e.Target,
+			"Limit":  e.Limit,
+		},
+	})
+}
+
+// Headers returns the rate limit headers sent with the 429 response
+func (e QuotaReachedError) Headers() map[string]string {
+	retryAfter := int64(e.RetryAfter.Round(time.Second) / time.Second)
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	return map[string]string{
+		"Retry-After":           strconv.FormatInt(retryAfter, 10),
+		"X-RateLimit-Limit":     strconv.FormatInt(e.Limit, 10),
+		"X-RateLimit-Remaining": "0",
+	}
+}
+
+type orgQueryUsage struct {
+	// start time of the requests in the last window, oldest first
+	started []time.Time
+	running int64
+}
+
+func (u *orgQueryUsage) trim(now time.Time) {
+	i := 0
+	for i < len(u.started) && now.Sub(u.started[i]) >= quotaWindow {
+		i++
+	}
+	u.started = u.started[i:]
+}
+
+// quotaLimiter counts the query requests of every organization in memory, so limits apply to each Grafana instance
+type quotaLimiter struct {
+	quotaService quota.Service
+	limits       *localcache.CacheService
+	now          func() time.Time
+
+	mutex sync.Mutex
+	orgs  map[int64]*orgQueryUsage
+}
+
+func newQuotaLimiter(quotaService quota.Service) *quotaLimiter {
+	return &quotaLimiter{
+		quotaService: quotaService,
+		limits:       localcache.New(quotaLimitsCacheTTL, 2*quotaLimitsCacheTTL),
+		now:          time.Now,
+		orgs:         make(map[int64]*orgQueryUsage),
+	}
+}
+
+// acquire records a query request for the organization and returns the function to call once it completed. It fails
+// with a QuotaReachedError when the request would exceed one of the organization quotas.
+func (l *quotaLimiter) acquire(ctx context.Context, orgID int64) (func(), error) {
+	perMinute, concurrent, err := l.getLimits(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	usage, ok := l.orgs[orgID]
+	if !ok {
+		usage = &orgQueryUsage{}
+		l.orgs[orgID] = usage
+	}
+	usage.trim(now)
+
+	if concurrent >= 0 && usage.running >= concurrent {
+		return nil, QuotaReachedError{Target: QuotaTargetConcurrentQueries, Limit: concurrent, RetryAfter: time.Second}
+	}
+	if perMinute >= 0 && int64(len(usage.started)) >= perMinute {
+		retryAfter := quotaWindow
+		if len(usage.started) > 0 {
+			retryAfter = usage.started[0].Add(quotaWindow).Sub(now)
+		}
+		return nil, QuotaReachedError{Target: QuotaTargetQueriesPerMinute, Limit: perMinute, RetryAfter: retryAfter}
+	}
+
+	usage.started = append(usage.started, now)
+	usage.running++
+
+	return func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		usage.running--
+	}, nil
+}
+
+type queryLimits struct {
+	perMinute  int64
+	concurrent int64
+}
+
+func (l *quotaLimiter) getLimits(ctx context.Context, orgID int64) (int64, int64, error) {
+	key := strconv.FormatInt(orgID, 10)
+	if cached, ok := l.limits.Get(key); ok {
+		limits := cached.(queryLimits)
+		return limits.perMinute, limits.concurrent, nil
+	}
+
+	m, err := l.quotaService.GetLimits(ctx, QuotaTargetSrv, &quota.ScopeParameters{OrgID: orgID})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	limits := queryLimits{perMinute: -1, concurrent: -1}
+	for item := range m.Iter() {
+		target, err := item.Tag.GetTarget()
+		if err != nil {
+			return 0, 0, err
+		}
+		switch target {
+		case QuotaTargetQueriesPerMinute:
+			limits.perMinute = item.Value
+		case QuotaTargetConcurrentQueries:
+			limits.concurrent = item.Value
+		}
+	}
+	l.limits.SetDefault(key, limits)
+	return limits.perMinute, limits.concurrent, nil
+}
+
+// Usage reports the query requests of the last minute and the running ones
+func (l *quotaLimiter) Usage(ctx context.Context, scopeParams *quota.ScopeParameters) (*quota.Map, error) {
+	u := &quota.Map{}
+	if scopeParams == nil || scopeParams.OrgID == 0 {
+		return u, nil
+	}
+
+	perMinuteTag, err := quota.NewTag(QuotaTargetSrv, QuotaTargetQueriesPerMinute, quota.OrgScope)
+	if err != nil {
+		return nil, err
+	}
+	concurrentTag, err := quota.NewTag(QuotaTargetSrv, QuotaTargetConcurrentQueries, quota.OrgScope)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var started, running int64
+	if usage, ok := l.orgs[scopeParams.OrgID]; ok {
+		usage.trim(l.now())
+		started = int64(len(usage.started))
+		running = usage.running
+	}
+	u.Set(perMinuteTag, started)
+	u.Set(concurrentTag, running)
+	return u, nil
+}
+
+func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
+	limits := &quota.Map{}
+
+	if cfg == nil {
+		return limits, nil
+	}
+
+	perMinuteTag, err := quota.NewTag(QuotaTargetSrv, QuotaTargetQueriesPerMinute, quota.OrgScope)
+	if err != nil {
+		return limits, err
+	}
+	concurrentTag, err := quota.NewTag(QuotaTargetSrv, QuotaTargetConcurrentQueries, quota.OrgScope)
+	if err != nil {
+		return limits, err
+	}
+
+	limits.Set(perMinuteTag, cfg.QuotaSettings().Org.QueriesPerMinute)
+	limits.Set(concurrentTag, cfg.QuotaSettings().Org.ConcurrentQueries)
+	return limits, nil
+}
@@ -0,0 +1,111 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+type fakeQuotaLimits struct {
+	*quotatest.FakeQuotaService
+	perMinute  int64
+	concurrent int64
+	calls      int
+}
+
+func (f *fakeQuotaLimits) GetLimits(ctx context.Context, target quota.TargetSrv, params *quota.ScopeParameters) (*quota.Map, error) {
+	f.calls++
+	limits := &quota.Map{}
+	perMinuteTag, _ := quota.NewTag(QuotaTargetSrv, QuotaTargetQueriesPerMinute, quota.OrgScope)
+	concurrentTag, _ := quota.NewTag(QuotaTargetSrv, QuotaTargetConcurrentQueries, quota.OrgScope)
+	limits.Set(perMinuteTag, f.perMinute)
+	limits.Set(concurrentTag, f.concurrent)
+	return limits, nil
+}
+
+func TestQuotaLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should limit concurrent queries", func(t *testing.T) {
+		limiter := newQuotaLimiter(&fakeQuotaLimits{perMinute: -1, concurrent: 2})
+
+		release1, err := limiter.acquire(ctx, 1)
+		require.NoError(t, err)
+		_, err = limiter.acquire(ctx, 1)
+		require.NoError(t, err)
+
+		_, err = limiter.acquire(ctx, 1)
+		var quotaErr QuotaReachedError
+		require.True(t, errors.As(err, &quotaErr))
+		require.Equal(t, QuotaTargetConcurrentQueries, quotaErr.Target)
+		require.Equal(t, int64(2), quotaErr.Limit)
+
+		// other orgs are not affected
+		_, err = limiter.acquire(ctx, 2)
+		require.NoError(t, err)
+
+		release1()
+		_, err = limiter.acquire(ctx, 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("should limit queries per minute", func(t *testing.T) {
+		now := time.Unix(1000, 0)
+		limiter := newQuotaLimiter(&fakeQuotaLimits{perMinute: 2, concurrent: -1})
+		limiter.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			release, err := limiter.acquire(ctx, 1)
+			require.NoError(t, err)
+			release()
+			now = now.Add(10 * time.Second)
+		}
+
+		_, err := limiter.acquire(ctx, 1)
+		var quotaErr QuotaReachedError
+		require.True(t, errors.As(err, &quotaErr))
+		require.Equal(t, QuotaTargetQueriesPerMinute, quotaErr.Target)
+		require.Equal(t, 40*time.Second, quotaErr.RetryAfter)
+		require.Equal(t, map[string]string{
+			"Retry-After":           "40",
+			"X-RateLimit-Limit":     "2",
+			"X-RateLimit-Remaining": "0",
+		}, quotaErr.Headers())
+
+		var grafanaErr errutil.Error
+		require.True(t, errors.As(err, &grafanaErr))
+		require.Equal(t, "Query quota reached, the queries_per_minute limit is 2", grafanaErr.Public().Message)
+
+		now = now.Add(40 * time.Second)
+		_, err = limiter.acquire(ctx, 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("should report usage and cache limits", func(t *testing.T) {
+		limits := &fakeQuotaLimits{perMinute: -1, concurrent: -1}
+		limiter := newQuotaLimiter(limits)
+
+		release, err := limiter.acquire(ctx, 1)
+		require.NoError(t, err)
+		_, err = limiter.acquire(ctx, 1)
+		require.NoError(t, err)
+		release()
+		require.Equal(t, 1, limits.calls)
+
+		usage, err := limiter.Usage(ctx, &quota.ScopeParameters{OrgID: 1})
+		require.NoError(t, err)
+		perMinuteTag, _ := quota.NewTag(QuotaTargetSrv, QuotaTargetQueriesPerMinute, quota.OrgScope)
+		concurrentTag, _ := quota.NewTag(QuotaTargetSrv, QuotaTargetConcurrentQueries, quota.OrgScope)
+		started, _ := usage.Get(perMinuteTag)
+		running, _ := usage.Get(concurrentTag)
+		require.Equal(t, int64(2), started)
+		require.Equal(t, int64(1), running)
+	})
+}
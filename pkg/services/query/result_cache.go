@@ -0,0 +1,240 @@
+package query
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/querycapture"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	resultCacheKeyPrefix = "query-result:"
+
+	resultCacheHit  = "hit"
+	resultCacheMiss = "miss"
+	// resultCacheSkipped counts the responses not cached, because they failed or are too large
+	resultCacheSkipped = "skipped"
+)
+
+var resultCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Subsystem: "query",
+	Name:      "result_cache_requests_total",
+	Help:      "Number of data source requests looked up in the shared query result cache, by result.",
+}, []string{"result"})
+
+// resultCache shares the responses of the datasource requests between the Grafana instances through the remote cache,
+// Redis or Memcached in HA deployments, so that the same panels refreshed on several instances query the datasource
+// once in every TTL.
+//
+// The time ranges ending now, within a TTL, are truncated to the TTL in the keys: the requests for the relative ranges
+// of a dashboard opened on several instances a few seconds apart get the same response, which is at most a TTL old.
+// The other ranges, such as the fixed ranges in the past, are keyed on their exact bounds. The users of an organization share the responses, the datasource permissions are checked before the cache
+// is read, except for the datasources forwarding the OAuth identity of the user, whose responses are only cached for
+// that user. The same applies to the datasources receiving an identity token of the user, and to every datasource
+// when the user is sent in the X-Grafana-User header or in the baggage. The users whose teams have different team
+// overrides of the datasource don't share the responses either.
+//
+// The TTL and the maximum size are applied without a restart when the query settings are reloaded, the cache is
+// disabled while the TTL is not set.
+type resultCache struct {
+	store remotecache.CacheStorage
+	// ttl is a time.Duration
+	ttl     atomic.Int64
+	maxSize atomic.Int64
+	log     log.Logger
+	// sendUserHeader and sendBaggage are set when the login of the user is sent to every datasource
+	sendUserHeader bool
+	sendBaggage    bool
+	now            func() time.Time
+}
+
+// newResultCache returns nil when there is no remote cache
+func newResultCache(cfg *setting.Cfg, store remotecache.CacheStorage) *resultCache {
+	if store == nil {
+		return nil
+	}
+	c := &resultCache{
+		store:          store,
+		log:            log.New("query_data.result_cache"),
+		sendUserHeader: cfg.SendUserHeader,
+		sendBaggage:    cfg.SendBaggage,
+		now:            time.Now,
+	}
+	c.configure(cfg)
+	return c
+}
+
+// configure applies the TTL and the maximum size of the settings
+func (c *resultCache) configure(cfg *setting.Cfg) {
+	ttl, maxSize := cfg.QueryResultCacheSettings()
+	c.ttl.Store(int64(ttl))
+	c.maxSize.Store(int64(maxSize))
+}
+
+// queryData returns the cached response of the request, or executes it and caches its response when none of its
+// queries failed. The cached response is not read when skipCache is set, it is still replaced.
+func (c *resultCache) queryData(ctx context.Context, user *user.SignedInUser, skipCache bool, ds *datasources.DataSource, req *backend.QueryDataRequest, next queryDataFunc) (*backend.QueryDataResponse, error) {
+	// the downstream requests of a captured execution are sent by it
+	if querycapture.RecorderFromContext(ctx) != nil {
+		return next(ctx, req)
+	}
+
+	ttl := time.Duration(c.ttl.Load())
+	if ttl <= 0 {
+		return next(ctx, req)
+	}
+
+	key, err := c.key(user, ds, req, ttl)
+	if err != nil {
+		return next(ctx, req)
+	}
+
+	if !skipCache {
+		if resp := c.get(ctx, key); resp != nil {
+			resultCacheRequests.WithLabelValues(resultCacheHit).Inc()
+			return resp, nil
+		}
+	}
+	resultCacheRequests.WithLabelValues(resultCacheMiss).Inc()
+
+	resp, err := next(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	c.set(ctx, key, resp, ttl)
+	return resp, nil
+}
+
+func (c *resultCache) get(ctx context.Context, key string) *backend.QueryDataResponse {
+	raw, err := c.store.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			c.log.Warn("Failed to read a query result from the cache", "error", err)
+		}
+		return nil
+	}
+	resp := &backend.QueryDataResponse{}
+	if err := json.Unmarshal(raw, resp); err != nil {
+		c.log.Warn("Failed to decode a cached query result", "error", err)
+		return nil
+	}
+	return resp
+}
+
+func (c *resultCache) set(ctx context.Context, key string, resp *backend.QueryDataResponse, ttl time.Duration) {
+	for _, res := range resp.Responses {
+		if res.Error != nil {
+			resultCacheRequests.WithLabelValues(resultCacheSkipped).Inc()
+			return
+		}
+	}
+
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		c.log.Warn("Failed to encode a query result", "error", err)
+		return
+	}
+	if maxSize := c.maxSize.Load(); maxSize > 0 && int64(len(raw)) > maxSize {
+		resultCacheRequests.WithLabelValues(resultCacheSkipped).Inc()
+		return
+	}
+	if err := c.store.Set(ctx, key, raw, ttl); err != nil {
+		c.log.Warn("Failed to cache a query result", "error", err)
+	}
+}
+
+// key identifies the requests of an organization to the same version of the datasource, with the same queries over
+// the same time ranges, truncated to the TTL for the ranges ending now. It doesn't depend on the Grafana instance
+// computing it.
+//
+// The client middlewares run after the cache, so the key includes what they add to the requests: the team overrides
+// of the user, and the identity of the user for the datasources receiving it.
+func (c *resultCache) key(user *user.SignedInUser, ds *datasources.DataSource, req *backend.QueryDataRequest, ttl time.Duration) (string, error) {
+	now := c.now()
+	queries := make([]string, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		if endsNow(q.TimeRange, now, ttl) {
+			q.TimeRange = backend.TimeRange{From: q.TimeRange.From.Truncate(ttl), To: q.TimeRange.To.Truncate(ttl)}
+		}
+		key, err := queryKey(q)
+		if err != nil {
+			return "", err
+		}
+		queries = append(queries, q.RefID+"|"+key)
+	}
+	sort.Strings(queries)
+
+	headers := make([]string, 0, len(req.Headers))
+	for name, value := range req.Headers {
+		headers = append(headers, name+"="+value)
+	}
+	sort.Strings(headers)
+
+	// the responses of the datasources receiving the identity of the user depend on the user
+	var identity requestIdentity
+	if c.isPerUser(ds) {
+		identity = identityOf(user)
+	}
+
+	teamOverrides, err := ds.TeamOverrides()
+	if err != nil {
+		return "", err
+	}
+	var teams []int64
+	if user != nil {
+		teams = user.Teams
+	}
+
+	raw, err := json.Marshal(struct {
+		OrgID         int64
+		Identity      requestIdentity
+		TeamOverrides datasources.RequestOverrides
+		Datasource    string
+		Version       int
+		Updated       int64
+		Headers       []string
+		Queries       []string
+	}{ds.OrgID, identity, teamOverrides.ForTeams(teams), ds.UID, ds.Version, ds.Updated.UnixNano(), headers, queries})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return resultCacheKeyPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// endsNow returns true when the time range ends less than a TTL away from now, as the relative ranges do, the ranges
+// ending in the past or in the future are fixed ranges
+func endsNow(tr backend.TimeRange, now time.Time, ttl time.Duration) bool {
+	d := now.Sub(tr.To)
+	return d > -ttl && d < ttl
+}
+
+// isPerUser returns true when a client middleware sends the identity of the user to the datasource: its OAuth token,
+// an identity token, the X-Grafana-User header or the baggage
+func (c *resultCache) isPerUser(ds *datasources.DataSource) bool {
+	if c.sendUserHeader || c.sendBaggage {
+		return true
+	}
+	if oauthtoken.IsOAuthPassThruEnabled(ds) {
+		return true
+	}
+	// the tokens are not sent when the signing key is not configured, the cache doesn't depend on it
+	return ds.JsonData != nil && ds.JsonData.Get("forwardIdentityToken").MustBool()
+}
@@ -0,0 +1,258 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeCacheStorage struct {
+	mu    sync.Mutex
+	items map[string][]byte
+	ttls  map[string]time.Duration
+}
+
+func newFakeCacheStorage() *fakeCacheStorage {
+	return &fakeCacheStorage{items: map[string][]byte{}, ttls: map[string]time.Duration{}}
+}
+
+func (s *fakeCacheStorage) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.items[key]
+	if !ok {
+		return nil, remotecache.ErrCacheItemNotFound
+	}
+	return v, nil
+}
+
+func (s *fakeCacheStorage) Set(_ context.Context, key string, value []byte, expire time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = value
+	s.ttls[key] = expire
+	return nil
+}
+
+func (s *fakeCacheStorage) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+func (s *fakeCacheStorage) Count(_ context.Context, _ string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.items)), nil
+}
+
+func TestResultCache(t *testing.T) {
+	ctx := context.Background()
+	ds := &datasources.DataSource{OrgID: 1, UID: "ds", Version: 1}
+	alice := &user.SignedInUser{OrgID: 1, UserID: 1, Login: "alice"}
+	bob := &user.SignedInUser{OrgID: 1, UserID: 2, Login: "bob"}
+	start := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	newRequest := func(offset time.Duration) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{Headers: map[string]string{}, Queries: []backend.DataQuery{{
+			RefID:     "A",
+			JSON:      []byte(`{"refId": "A", "expr": "up"}`),
+			TimeRange: backend.TimeRange{From: start.Add(-time.Hour + offset), To: start.Add(offset)},
+		}}}
+	}
+	counting := func(calls *int, queryErr error) queryDataFunc {
+		return func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			*calls++
+			resp := backend.NewQueryDataResponse()
+			frame := data.NewFrame("up", data.NewField("value", nil, []float64{1}))
+			frame.RefID = "A"
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{frame}, Error: queryErr}
+			return resp, nil
+		}
+	}
+	newCache := func(store remotecache.CacheStorage) *resultCache {
+		c := newResultCache(&setting.Cfg{QueryResultCacheTTL: time.Minute, QueryResultCacheMaxSize: 1 << 20}, store)
+		c.now = func() time.Time { return start.Add(30 * time.Second) }
+		return c
+	}
+
+	t.Run("should share the responses between the instances within the TTL", func(t *testing.T) {
+		store := newFakeCacheStorage()
+		a, b := newCache(store), newCache(store)
+		calls := 0
+
+		resp, err := a.queryData(ctx, alice, false, ds, newRequest(0), counting(&calls, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		require.Len(t, store.items, 1)
+		for _, ttl := range store.ttls {
+			assert.Equal(t, time.Minute, ttl)
+		}
+
+		// another user on another instance, a few seconds later
+		cached, err := b.queryData(ctx, bob, false, ds, newRequest(20*time.Second), counting(&calls, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		require.Len(t, cached.Responses["A"].Frames, 1)
+		assert.Equal(t, resp.Responses["A"].Frames[0].Fields[0].At(0), cached.Responses["A"].Frames[0].Fields[0].At(0))
+		assert.Equal(t, "A", cached.Responses["A"].Frames[0].RefID)
+
+		// the next time window is queried again
+		_, err = b.queryData(ctx, bob, false, ds, newRequest(time.Minute), counting(&calls, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("should not share the responses of different fixed ranges", func(t *testing.T) {
+		c := newCache(newFakeCacheStorage())
+		calls := 0
+		fixed := func(offset time.Duration) *backend.QueryDataRequest {
+			req := newRequest(0)
+			req.Queries[0].TimeRange = backend.TimeRange{From: start.Add(-2*time.Hour + offset), To: start.Add(-time.Hour + offset)}
+			return req
+		}
+
+		_, err := c.queryData(ctx, alice, false, ds, fixed(10*time.Second), counting(&calls, nil))
+		require.NoError(t, err)
+		_, err = c.queryData(ctx, alice, false, ds, fixed(20*time.Second), counting(&calls, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+
+		_, err = c.queryData(ctx, alice, false, ds, fixed(10*time.Second), counting(&calls, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("should not read the cache when skipCache is set", func(t *testing.T) {
+		c := newCache(newFakeCacheStorage())
+		calls := 0
+		_, err := c.queryData(ctx, alice, false, ds, newRequest(0), counting(&calls, nil))
+		require.NoError(t, err)
+		_, err = c.queryData(ctx, alice, true, ds, newRequest(0), counting(&calls, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("should not cache the failed queries", func(t *testing.T) {
+		store := newFakeCacheStorage()
+		c := newCache(store)
+		calls := 0
+		resp, err := c.queryData(ctx, alice, false, ds, newRequest(0), counting(&calls, errors.New("timeout")))
+		require.NoError(t, err)
+		require.Error(t, resp.Responses["A"].Error)
+		assert.Empty(t, store.items)
+	})
+
+	t.Run("should not cache the responses larger than the maximum", func(t *testing.T) {
+		store := newFakeCacheStorage()
+		c := newResultCache(&setting.Cfg{QueryResultCacheTTL: time.Minute, QueryResultCacheMaxSize: 10}, store)
+		calls := 0
+		_, err := c.queryData(ctx, alice, false, ds, newRequest(0), counting(&calls, nil))
+		require.NoError(t, err)
+		assert.Empty(t, store.items)
+	})
+
+	t.Run("should key the responses of the datasources forwarding the OAuth identity by user", func(t *testing.T) {
+		c := newCache(newFakeCacheStorage())
+		passThru := &datasources.DataSource{OrgID: 1, UID: "ds", Version: 1, JsonData: simplejson.NewFromAny(map[string]interface{}{"oauthPassThru": true})}
+
+		aliceKey, err := c.key(alice, passThru, newRequest(0), time.Minute)
+		require.NoError(t, err)
+		bobKey, err := c.key(bob, passThru, newRequest(0), time.Minute)
+		require.NoError(t, err)
+		assert.NotEqual(t, aliceKey, bobKey)
+
+		aliceKey, err = c.key(alice, ds, newRequest(0), time.Minute)
+		require.NoError(t, err)
+		bobKey, err = c.key(bob, ds, newRequest(0), time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, aliceKey, bobKey)
+
+		// a new version of the datasource doesn't use the responses of the previous version
+		updated, err := c.key(alice, &datasources.DataSource{OrgID: 1, UID: "ds", Version: 2}, newRequest(0), time.Minute)
+		require.NoError(t, err)
+		assert.NotEqual(t, aliceKey, updated)
+	})
+
+	t.Run("should key the responses by user when a middleware sends the user", func(t *testing.T) {
+		identityToken := &datasources.DataSource{OrgID: 1, UID: "ds", Version: 1, JsonData: simplejson.NewFromAny(map[string]interface{}{"forwardIdentityToken": true})}
+		for name, tc := range map[string]struct {
+			cfg *setting.Cfg
+			ds  *datasources.DataSource
+		}{
+			"identity token": {cfg: &setting.Cfg{}, ds: identityToken},
+			"user header":    {cfg: &setting.Cfg{SendUserHeader: true}, ds: ds},
+			"baggage":        {cfg: &setting.Cfg{SendBaggage: true}, ds: ds},
+		} {
+			c := newResultCache(tc.cfg, newFakeCacheStorage())
+			aliceKey, err := c.key(alice, tc.ds, newRequest(0), time.Minute)
+			require.NoError(t, err)
+			bobKey, err := c.key(bob, tc.ds, newRequest(0), time.Minute)
+			require.NoError(t, err)
+			assert.NotEqual(t, aliceKey, bobKey, name)
+		}
+	})
+
+	t.Run("should key the responses by team overrides", func(t *testing.T) {
+		c := newCache(newFakeCacheStorage())
+		overrides := &datasources.DataSource{OrgID: 1, UID: "ds", Version: 1, JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"teamOverrides": map[string]interface{}{
+				"1": map[string]interface{}{"headers": []interface{}{map[string]interface{}{"name": "X-Scope-OrgID", "value": "team-a"}}},
+				"2": map[string]interface{}{"headers": []interface{}{map[string]interface{}{"name": "X-Scope-OrgID", "value": "team-b"}}},
+			},
+		})}
+		teamA := &user.SignedInUser{OrgID: 1, UserID: 1, Login: "alice", Teams: []int64{1}}
+		teamB := &user.SignedInUser{OrgID: 1, UserID: 2, Login: "bob", Teams: []int64{2}}
+		otherTeamA := &user.SignedInUser{OrgID: 1, UserID: 3, Login: "carol", Teams: []int64{1, 3}}
+
+		aKey, err := c.key(teamA, overrides, newRequest(0), time.Minute)
+		require.NoError(t, err)
+		bKey, err := c.key(teamB, overrides, newRequest(0), time.Minute)
+		require.NoError(t, err)
+		assert.NotEqual(t, aKey, bKey)
+
+		// the teams without overrides don't change the key
+		otherKey, err := c.key(otherTeamA, overrides, newRequest(0), time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, aKey, otherKey)
+	})
+
+	t.Run("should be disabled without remote cache", func(t *testing.T) {
+		assert.Nil(t, newResultCache(&setting.Cfg{QueryResultCacheTTL: time.Minute}, nil))
+	})
+
+	t.Run("should apply the TTL of the reloaded settings", func(t *testing.T) {
+		store := newFakeCacheStorage()
+		cfg := &setting.Cfg{}
+		c := newResultCache(cfg, store)
+		calls := 0
+
+		// no TTL, the cache is disabled
+		_, err := c.queryData(ctx, alice, false, ds, newRequest(0), counting(&calls, nil))
+		require.NoError(t, err)
+		assert.Empty(t, store.items)
+
+		cfg.QueryResultCacheTTL = 2 * time.Minute
+		c.configure(cfg)
+		_, err = c.queryData(ctx, alice, false, ds, newRequest(0), counting(&calls, nil))
+		require.NoError(t, err)
+		require.Len(t, store.items, 1)
+		for _, ttl := range store.ttls {
+			assert.Equal(t, 2*time.Minute, ttl)
+		}
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,93 @@
+package queryaudit
+
+import (
+	"encoding/json"
+	"mime"
+	"net/url"
+	"time"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+const (
+	// KindQuery is the kind of the events of the queries of the QueryData requests
+	KindQuery = "query"
+	// KindResource is the kind of the events of the resource calls of the backend plugins
+	KindResource = "resource"
+	// KindProxy is the kind of the events of the requests proxied to the datasources
+	KindProxy = "proxy"
+)
+
+// maxRequestBodySize is the size of the bodies of the resource calls and the proxied requests above which they are
+// recorded truncated, as strings
+const maxRequestBodySize = 64 * 1024
+
+// Event is the execution of a query against a datasource
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	Kind      string    `json:"kind"`
+	OrgID     int64     `json:"orgId"`
+	// UserID is 0 when the query was not sent by a signed in user, like the queries of alerting
+	UserID           int64  `json:"userId,omitempty"`
+	UserLogin        string `json:"userLogin,omitempty"`
+	IsServiceAccount bool   `json:"isServiceAccount,omitempty"`
+	RemoteAddr       string `json:"remoteAddr,omitempty"`
+
+	DatasourceUID  string `json:"datasourceUid"`
+	DatasourceName string `json:"datasourceName"`
+	DatasourceType string `json:"datasourceType"`
+	// DashboardUID and PanelID are empty when the query was not sent by a dashboard panel
+	DashboardUID string `json:"dashboardUid,omitempty"`
+	PanelID      string `json:"panelId,omitempty"`
+
+	// RefID, QueryType, From and To are empty for the resource calls and the proxied requests
+	RefID     string `json:"refId"`
+	QueryType string `json:"queryType,omitempty"`
+	// Query is the raw query sent to the datasource, or the HTTPRequest of the resource calls and the proxied
+	// requests, after the redaction rules are applied
+	Query json.RawMessage `json:"query"`
+	From  time.Time       `json:"from"`
+	To    time.Time       `json:"to"`
+
+	DurationMs int64  `json:"durationMs"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// HTTPRequest is the query of the events of the resource calls and the proxied requests
+type HTTPRequest struct {
+	Method string     `json:"method"`
+	Path   string     `json:"path"`
+	Params url.Values `json:"params,omitempty"`
+	// Body is the JSON body, the fields of a form body, or the body as a string
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// NewHTTPRequestQuery returns the query of the event of a request to a datasource, with the params of its query
+// string and its body, so the redaction rules apply to the params and to the fields of the JSON and form bodies
+func NewHTTPRequestQuery(method, path string, params url.Values, contentType string, body []byte) json.RawMessage {
+	r := HTTPRequest{Method: method, Path: path, Params: params}
+	if len(params) == 0 {
+		r.Params = nil
+	}
+	switch mediaType, _, _ := mime.ParseMediaType(contentType); {
+	case len(body) == 0:
+	case len(body) > maxRequestBodySize:
+		r.Body, _ = json.Marshal(string(body[:maxRequestBodySize]))
+	case json.Valid(body):
+		r.Body = body
+	case mediaType == "application/x-www-form-urlencoded":
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			r.Body, _ = json.Marshal(form)
+			break
+		}
+		r.Body, _ = json.Marshal(string(body))
+	default:
+		r.Body, _ = json.Marshal(string(body))
+	}
+	b, _ := json.Marshal(r)
+	return b
+}
@@ -0,0 +1,182 @@
+package queryaudit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	defaultFlushInterval     = time.Second
+	defaultBatchSize         = 100
+	defaultMaxBufferedEvents = 10000
+
+	// shutdownFlushTimeout is the time given to the outputs to write the remaining events when Grafana stops
+	shutdownFlushTimeout = 10 * time.Second
+)
+
+var droppedEvents = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Name:      "query_audit_dropped_events_total",
+	Help:      "Number of query audit events dropped because the buffer of the events was full",
+})
+
+type Service interface {
+	// IsDisabled returns true when the query audit is not enabled, the queries don't need to be recorded
+	IsDisabled() bool
+	// Record applies the redaction rules to the queries of the events and queues them to be written to the outputs
+	Record(events ...Event)
+}
+
+type namedWriter struct {
+	name string
+	Writer
+}
+
+// QueryAuditService writes the audit events of the datasource queries to the outputs of the query_audit section, in
+// batches written every flush interval or when a batch is full
+type QueryAuditService struct {
+	cfg      setting.QueryAuditSettings
+	log      log.Logger
+	redactor *redactor
+	writers  []namedWriter
+
+	mu     sync.Mutex
+	events []Event
+	// dropped is the number of events dropped since the last flush because the buffer was full
+	dropped int
+	flush   chan struct{}
+}
+
+func ProvideService(cfg *setting.Cfg) (*QueryAuditService, error) {
+	s := &QueryAuditService{
+		cfg:   cfg.QueryAudit,
+		log:   log.New("query_audit"),
+		flush: make(chan struct{}, 1),
+	}
+	if !s.cfg.Enabled {
+		return s, nil
+	}
+
+	if s.cfg.FlushInterval <= 0 {
+		s.cfg.FlushInterval = defaultFlushInterval
+	}
+	if s.cfg.BatchSize <= 0 {
+		s.cfg.BatchSize = defaultBatchSize
+	}
+	if s.cfg.MaxBufferedEvents <= 0 {
+		s.cfg.MaxBufferedEvents = defaultMaxBufferedEvents
+	}
+
+	var err error
+	if s.redactor, err = newRedactor(s.cfg.RedactedFields, s.cfg.RedactedPatterns); err != nil {
+		return nil, err
+	}
+
+	for _, output := range s.cfg.Outputs {
+		switch output {
+		case setting.QueryAuditOutputFile:
+			path := s.cfg.FilePath
+			if path == "" {
+				path = filepath.Join(cfg.LogsPath, "query-audit.log")
+			}
+			s.writers = append(s.writers, namedWriter{name: output, Writer: newFileWriter(path)})
+		case setting.QueryAuditOutputLoki:
+			w, err := newLokiWriter(s.cfg)
+			if err != nil {
+				return nil, err
+			}
+			s.writers = append(s.writers, namedWriter{name: output, Writer: w})
+		default:
+			return nil, fmt.Errorf("unknown query audit output %q, the outputs are file and loki", output)
+		}
+	}
+	if len(s.writers) == 0 {
+		return nil, fmt.Errorf("the query audit is enabled without outputs")
+	}
+	return s, nil
+}
+
+// IsDisabled disables the service when the query audit is not enabled
+func (s *QueryAuditService) IsDisabled() bool {
+	return !s.cfg.Enabled
+}
+
+func (s *QueryAuditService) Record(events ...Event) {
+	if s.IsDisabled() || len(events) == 0 {
+		return
+	}
+
+	for i := range events {
+		events[i].Query = s.redactor.redact(events[i].Query)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range events {
+		if len(s.events) >= s.cfg.MaxBufferedEvents {
+			s.dropped++
+			droppedEvents.Inc()
+			continue
+		}
+		s.events = append(s.events, e)
+	}
+	if len(s.events) >= s.cfg.BatchSize {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *QueryAuditService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the context of the service is done, the remaining events are written with a new one
+			flushCtx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+			s.flushEvents(flushCtx)
+			cancel()
+			return nil
+		case <-ticker.C:
+			s.flushEvents(ctx)
+		case <-s.flush:
+			s.flushEvents(ctx)
+		}
+	}
+}
+
+// flushEvents writes the buffered events to every output, a failed output does not prevent writing the others
+func (s *QueryAuditService) flushEvents(ctx context.Context) {
+	s.mu.Lock()
+	events, dropped := s.events, s.dropped
+	s.events, s.dropped = nil, 0
+	s.mu.Unlock()
+
+	if dropped > 0 {
+		s.log.Warn("Dropped query audit events, the outputs are too slow", "dropped", dropped, "maxBufferedEvents", s.cfg.MaxBufferedEvents)
+	}
+
+	for start := 0; start < len(events); start += s.cfg.BatchSize {
+		end := start + s.cfg.BatchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		for _, w := range s.writers {
+			if err := w.Write(ctx, events[start:end]); err != nil {
+				s.log.Error("Failed to write the query audit", "output", w.name, "events", end-start, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package queryaudit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeWriter struct {
+	mu      sync.Mutex
+	batches [][]Event
+	err     error
+}
+
+func (w *fakeWriter) Write(_ context.Context, events []Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batches = append(w.batches, append([]Event{}, events...))
+	return w.err
+}
+
+func (w *fakeWriter) events() []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var events []Event
+	for _, b := range w.batches {
+		events = append(events, b...)
+	}
+	return events
+}
+
+func newTestService(t *testing.T, qa setting.QueryAuditSettings, writers ...namedWriter) *QueryAuditService {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.LogsPath = t.TempDir()
+	cfg.QueryAudit = qa
+	s, err := ProvideService(cfg)
+	require.NoError(t, err)
+	s.writers = writers
+	return s
+}
+
+func TestProvideService(t *testing.T) {
+	t.Run("should be disabled by default", func(t *testing.T) {
+		s, err := ProvideService(setting.NewCfg())
+		require.NoError(t, err)
+		require.True(t, s.IsDisabled())
+		// recording does nothing when disabled
+		s.Record(testEvents()...)
+		require.Empty(t, s.events)
+	})
+
+	for name, tc := range map[string]struct {
+		cfg setting.QueryAuditSettings
+		err string
+	}{
+		"unknown output":        {cfg: setting.QueryAuditSettings{Enabled: true, Outputs: []string{"kafka"}}, err: `unknown query audit output "kafka", the outputs are file and loki`},
+		"no outputs":            {cfg: setting.QueryAuditSettings{Enabled: true}, err: "the query audit is enabled without outputs"},
+		"loki without url":      {cfg: setting.QueryAuditSettings{Enabled: true, Outputs: []string{"loki"}}, err: "loki_remote_url is required to write the query audit to Loki"},
+		"invalid redact regexp": {cfg: setting.QueryAuditSettings{Enabled: true, Outputs: []string{"file"}, RedactedPatterns: []string{"["}}, err: `invalid redacted pattern "["`},
+	} {
+		t.Run("should fail for "+name, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.QueryAudit = tc.cfg
+			_, err := ProvideService(cfg)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestQueryAuditService(t *testing.T) {
+	t.Run("should write the redacted events in batches", func(t *testing.T) {
+		w := &fakeWriter{}
+		s := newTestService(t, setting.QueryAuditSettings{
+			Enabled:        true,
+			Outputs:        []string{"file"},
+			RedactedFields: []string{"password"},
+			BatchSize:      1,
+		}, namedWriter{name: "fake", Writer: w})
+
+		events := testEvents()
+		events[0].Query = json.RawMessage(`{"expr": "up", "password": "hunter2"}`)
+		s.Record(events...)
+		s.flushEvents(context.Background())
+
+		require.Len(t, w.batches, 2)
+		recorded := w.events()
+		require.JSONEq(t, `{"expr": "up", "password": "[REDACTED]"}`, string(recorded[0].Query))
+		require.Equal(t, "editor", recorded[1].UserLogin)
+	})
+
+	t.Run("should write the events to the other outputs when one fails", func(t *testing.T) {
+		failing, w := &fakeWriter{err: errors.New("unavailable")}, &fakeWriter{}
+		s := newTestService(t, setting.QueryAuditSettings{Enabled: true, Outputs: []string{"file"}},
+			namedWriter{name: "failing", Writer: failing}, namedWriter{name: "fake", Writer: w})
+
+		s.Record(testEvents()...)
+		s.flushEvents(context.Background())
+		require.Len(t, w.events(), 2)
+	})
+
+	t.Run("should drop the events above the buffer size", func(t *testing.T) {
+		w := &fakeWriter{}
+		s := newTestService(t, setting.QueryAuditSettings{Enabled: true, Outputs: []string{"file"}, MaxBufferedEvents: 1},
+			namedWriter{name: "fake", Writer: w})
+
+		dropped := testutil.ToFloat64(droppedEvents)
+		s.Record(testEvents()...)
+		require.Equal(t, 1, s.dropped)
+		require.Equal(t, dropped+1, testutil.ToFloat64(droppedEvents))
+		s.flushEvents(context.Background())
+		require.Len(t, w.events(), 1)
+		require.Zero(t, s.dropped)
+	})
+
+	t.Run("should write the remaining events when stopped", func(t *testing.T) {
+		w := &fakeWriter{}
+		s := newTestService(t, setting.QueryAuditSettings{Enabled: true, Outputs: []string{"file"}, FlushInterval: time.Hour},
+			namedWriter{name: "fake", Writer: w})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- s.Run(ctx) }()
+
+		s.Record(testEvents()...)
+		cancel()
+		require.NoError(t, <-done)
+		require.Len(t, w.events(), 2)
+	})
+
+	t.Run("should flush when a batch is full", func(t *testing.T) {
+		w := &fakeWriter{}
+		s := newTestService(t, setting.QueryAuditSettings{Enabled: true, Outputs: []string{"file"}, FlushInterval: time.Hour, BatchSize: 2},
+			namedWriter{name: "fake", Writer: w})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go func() { _ = s.Run(ctx) }()
+
+		s.Record(testEvents()...)
+		require.Eventually(t, func() bool { return len(w.events()) == 2 }, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestNewHTTPRequestQuery(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		contentType string
+		body        string
+		exp         string
+	}{
+		{desc: "without body", exp: `{"method": "POST", "path": "api/v1/query", "params": {"time": ["10"]}}`},
+		{
+			desc: "with a JSON body",
+			body: `{"query": "up"}`,
+			exp:  `{"method": "POST", "path": "api/v1/query", "params": {"time": ["10"]}, "body": {"query": "up"}}`,
+		},
+		{
+			desc:        "with a form body",
+			contentType: "application/x-www-form-urlencoded; charset=UTF-8",
+			body:        "query=up",
+			exp:         `{"method": "POST", "path": "api/v1/query", "params": {"time": ["10"]}, "body": {"query": ["up"]}}`,
+		},
+		{
+			desc: "with a text body",
+			body: "up",
+			exp:  `{"method": "POST", "path": "api/v1/query", "params": {"time": ["10"]}, "body": "up"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			query := NewHTTPRequestQuery("POST", "api/v1/query", url.Values{"time": {"10"}}, tc.contentType, []byte(tc.body))
+			require.JSONEq(t, tc.exp, string(query))
+		})
+	}
+
+	t.Run("should truncate the large bodies", func(t *testing.T) {
+		body := strings.Repeat("a", maxRequestBodySize+1)
+		var r HTTPRequest
+		require.NoError(t, json.Unmarshal(NewHTTPRequestQuery("POST", "api", nil, "", []byte(body)), &r))
+		require.Nil(t, r.Params)
+		require.JSONEq(t, `"`+body[:maxRequestBodySize]+`"`, string(r.Body))
+	})
+}
@@ -0,0 +1,91 @@
+package queryaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces the redacted values and the matches of the redacted patterns
+const redactedValue = "[REDACTED]"
+
+// redactor applies the redaction rules of the query_audit section to the raw queries
+type redactor struct {
+	// fields are lower cased, the field names are compared case insensitively
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+func newRedactor(fields []string, patterns []string) (*redactor, error) {
+	r := &redactor{fields: map[string]bool{}}
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = true
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redacted pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+func (r *redactor) isEmpty() bool {
+	return len(r.fields) == 0 && len(r.patterns) == 0
+}
+
+// redact returns the query with the values of the redacted fields and the matches of the patterns replaced. A query
+// that is not valid JSON is returned as a JSON string, with the matches of the patterns replaced.
+func (r *redactor) redact(query json.RawMessage) json.RawMessage {
+	if len(query) == 0 {
+		return json.RawMessage("null")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(query, &value); err != nil {
+		// the raw text can't be redacted by field, but it is never written without the patterns applied
+		b, _ := json.Marshal(r.redactString(string(query)))
+		return b
+	}
+	if r.isEmpty() {
+		return query
+	}
+
+	b, err := json.Marshal(r.redactValue(value))
+	if err != nil {
+		b, _ = json.Marshal(redactedValue)
+	}
+	return b
+}
+
+func (r *redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = r.redactValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+		return v
+	case string:
+		return r.redactString(v)
+	default:
+		return v
+	}
+}
+
+func (r *redactor) redactString(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, redactedValue)
+	}
+	return s
+}
@@ -0,0 +1,49 @@
+package queryaudit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+	r, err := newRedactor([]string{"password", "Token"}, []string{`\b\d{3}-\d{2}-\d{4}\b`, `(?i)secret-[a-z0-9]+`})
+	require.NoError(t, err)
+
+	t.Run("should redact the fields and the matches of the patterns at any depth", func(t *testing.T) {
+		redacted := r.redact(json.RawMessage(`{
+			"refId": "A",
+			"rawSql": "SELECT * FROM patients WHERE ssn = '123-45-6789' AND key = 'SECRET-abc123'",
+			"connection": {"PASSWORD": "hunter2", "token": {"value": "abc"}},
+			"targets": [{"expr": "up{ssn=\"987-65-4321\"}"}, 42]
+		}`))
+
+		require.JSONEq(t, `{
+			"refId": "A",
+			"rawSql": "SELECT * FROM patients WHERE ssn = '[REDACTED]' AND key = '[REDACTED]'",
+			"connection": {"PASSWORD": "[REDACTED]", "token": "[REDACTED]"},
+			"targets": [{"expr": "up{ssn=\"[REDACTED]\"}"}, 42]
+		}`, string(redacted))
+	})
+
+	t.Run("should apply the patterns to a query that is not valid JSON", func(t *testing.T) {
+		redacted := r.redact(json.RawMessage(`ssn=123-45-6789`))
+		require.JSONEq(t, `"ssn=[REDACTED]"`, string(redacted))
+	})
+
+	t.Run("should return null for an empty query", func(t *testing.T) {
+		require.Equal(t, "null", string(r.redact(nil)))
+	})
+
+	t.Run("should keep the query without redaction rules", func(t *testing.T) {
+		empty, err := newRedactor(nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"expr": "up"}`, string(empty.redact(json.RawMessage(`{"expr": "up"}`))))
+	})
+
+	t.Run("should fail for an invalid pattern", func(t *testing.T) {
+		_, err := newRedactor(nil, []string{"("})
+		require.ErrorContains(t, err, `invalid redacted pattern "("`)
+	})
+}
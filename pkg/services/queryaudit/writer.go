@@ -0,0 +1,153 @@
+package queryaudit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// lokiSource is the source label of the streams of the audit events
+	lokiSource = "grafana-query-audit"
+
+	// maxErrorBodyLength is the number of bytes of the response kept in the error when a push is rejected
+	maxErrorBodyLength = 512
+)
+
+// Writer writes a batch of audit events to one of the outputs
+type Writer interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+// fileWriter appends the events to a file, one JSON object per line
+type fileWriter struct {
+	path string
+}
+
+func newFileWriter(path string) *fileWriter {
+	return &fileWriter{path: path}
+}
+
+// Write opens the file for every batch, so the file can be rotated by an external tool like logrotate
+func (w *fileWriter) Write(_ context.Context, events []Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("failed to serialize the audit event: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.path), 0750); err != nil {
+		return fmt.Errorf("failed to create the query audit folder: %w", err)
+	}
+	// nolint:gosec
+	// the path comes from the configuration of the server
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open the query audit file: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write the query audit file: %w", err)
+	}
+	return f.Close()
+}
+
+// lokiWriter pushes the events to Loki, in one stream for every organization
+type lokiWriter struct {
+	url      *url.URL
+	tenantID string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newLokiWriter(cfg setting.QueryAuditSettings) (*lokiWriter, error) {
+	if cfg.LokiRemoteURL == "" {
+		return nil, fmt.Errorf("loki_remote_url is required to write the query audit to Loki")
+	}
+	u, err := url.Parse(cfg.LokiRemoteURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the Loki remote URL: %w", err)
+	}
+	return &lokiWriter{
+		url:      u,
+		tenantID: cfg.LokiTenantID,
+		username: cfg.LokiBasicAuthUsername,
+		password: cfg.LokiBasicAuthPassword,
+		client:   &http.Client{Timeout: cfg.LokiTimeout},
+	}, nil
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (w *lokiWriter) Write(ctx context.Context, events []Event) error {
+	byOrg := map[int64]*lokiStream{}
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to serialize the audit event: %w", err)
+		}
+		s, ok := byOrg[e.OrgID]
+		if !ok {
+			s = &lokiStream{Stream: map[string]string{
+				"source": lokiSource,
+				"org_id": strconv.FormatInt(e.OrgID, 10),
+			}}
+			byOrg[e.OrgID] = s
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.Timestamp.UnixNano(), 10), string(line)})
+	}
+
+	body := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{Streams: make([]*lokiStream, 0, len(byOrg))}
+	for _, s := range byOrg {
+		body.Streams = append(body.Streams, s)
+	}
+	sort.Slice(body.Streams, func(i, j int) bool {
+		return body.Streams[i].Stream["org_id"] < body.Streams[j].Stream["org_id"]
+	})
+	enc, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to serialize the Loki payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url.JoinPath("/loki/api/v1/push").String(), bytes.NewReader(enc))
+	if err != nil {
+		return fmt.Errorf("failed to create the Loki request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	if w.tenantID != "" {
+		req.Header.Set("X-Scope-OrgID", w.tenantID)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push the query audit to Loki: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		return fmt.Errorf("loki rejected the query audit with status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
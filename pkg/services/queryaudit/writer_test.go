@@ -0,0 +1,110 @@
+package queryaudit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func testEvents() []Event {
+	ts := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []Event{
+		{Timestamp: ts, OrgID: 1, UserID: 2, UserLogin: "admin", DatasourceUID: "prom", RefID: "A", Query: json.RawMessage(`{"expr":"up"}`), Status: StatusSuccess},
+		{Timestamp: ts, OrgID: 2, UserID: 3, UserLogin: "editor", DatasourceUID: "loki", RefID: "A", Query: json.RawMessage(`{"expr":"{job=\"app\"}"}`), Status: StatusError, Error: "timeout"},
+	}
+}
+
+func TestFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "query-audit.log")
+	w := newFileWriter(path)
+
+	require.NoError(t, w.Write(context.Background(), testEvents()[:1]))
+	require.NoError(t, w.Write(context.Background(), testEvents()[1:]))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	var logins []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		logins = append(logins, e.UserLogin)
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []string{"admin", "editor"}, logins)
+}
+
+func TestLokiWriter(t *testing.T) {
+	t.Run("should push the events in one stream for every organization", func(t *testing.T) {
+		var body []byte
+		var req *http.Request
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(srv.Close)
+
+		w, err := newLokiWriter(setting.QueryAuditSettings{
+			LokiRemoteURL:         srv.URL,
+			LokiTenantID:          "tenant-1",
+			LokiBasicAuthUsername: "user",
+			LokiBasicAuthPassword: "pass",
+			LokiTimeout:           time.Second,
+		})
+		require.NoError(t, err)
+		require.NoError(t, w.Write(context.Background(), testEvents()))
+
+		require.Equal(t, "/loki/api/v1/push", req.URL.Path)
+		require.Equal(t, "tenant-1", req.Header.Get("X-Scope-OrgID"))
+		username, password, ok := req.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", username)
+		require.Equal(t, "pass", password)
+
+		var payload struct {
+			Streams []lokiStream `json:"streams"`
+		}
+		require.NoError(t, json.Unmarshal(body, &payload))
+		require.Len(t, payload.Streams, 2)
+		require.Equal(t, map[string]string{"source": lokiSource, "org_id": "1"}, payload.Streams[0].Stream)
+		require.Equal(t, map[string]string{"source": lokiSource, "org_id": "2"}, payload.Streams[1].Stream)
+		require.Len(t, payload.Streams[0].Values, 1)
+		require.Equal(t, "1677672000000000000", payload.Streams[0].Values[0][0])
+
+		var e Event
+		require.NoError(t, json.Unmarshal([]byte(payload.Streams[1].Values[0][1]), &e))
+		require.Equal(t, "editor", e.UserLogin)
+		require.Equal(t, "timeout", e.Error)
+	})
+
+	t.Run("should fail when Loki rejects the events", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "entry too far behind", http.StatusBadRequest)
+		}))
+		t.Cleanup(srv.Close)
+
+		w, err := newLokiWriter(setting.QueryAuditSettings{LokiRemoteURL: srv.URL, LokiTimeout: time.Second})
+		require.NoError(t, err)
+		err = w.Write(context.Background(), testEvents())
+		require.EqualError(t, err, "loki rejected the query audit with status 400: entry too far behind")
+	})
+
+	t.Run("should require the Loki URL", func(t *testing.T) {
+		_, err := newLokiWriter(setting.QueryAuditSettings{})
+		require.Error(t, err)
+	})
+}
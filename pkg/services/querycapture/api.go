@@ -0,0 +1,52 @@
+package querycapture
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *QueryCaptureService) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/query-captures", func(entities routing.RouteRegister) {
+		entities.Get("/:uid", middleware.ReqSignedIn, routing.Wrap(s.getCaptureHandler))
+	})
+}
+
+// swagger:route GET /query-captures/{uid} query_captures getQueryCapture
+//
+// Get the capture of a query execution.
+//
+// Returns the requests sent by the data sources to their downstream services while executing a query request with
+// the `X-Grafana-Capture` header, with the raw responses they received. The secrets of the data sources are redacted.
+// The UID of the capture is returned in the `X-Grafana-Capture-Uid` header of the response of the query request. Only
+// the user who executed the query can get the capture, it is kept in memory by the Grafana instance that executed the
+// query for 15 minutes.
+//
+// Responses:
+// 200: getQueryCaptureResponse
+// 401: unauthorisedError
+// 404: notFoundError
+func (s *QueryCaptureService) getCaptureHandler(c *contextmodel.ReqContext) response.Response {
+	capture := s.Get(c.SignedInUser, web.Params(c.Req)[":uid"])
+	if capture == nil {
+		return response.Error(http.StatusNotFound, "Query capture not found", nil)
+	}
+	return response.JSON(http.StatusOK, capture)
+}
+
+// swagger:parameters getQueryCapture
+type GetQueryCaptureParams struct {
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+}
+
+// swagger:response getQueryCaptureResponse
+type GetQueryCaptureResponse struct {
+	// in: body
+	Body Capture `json:"body"`
+}
@@ -0,0 +1,131 @@
+package querycapture
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+const MiddlewareName = "query-capture"
+
+// Middleware returns the HTTP client middleware recording the requests of a data source and their responses. It has
+// to be the last of the contextual middlewares, so that the requests are recorded as they are sent.
+func Middleware(r *Recorder, settings *backend.DataSourceInstanceSettings) httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(MiddlewareName, func(opts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		redactor := newRedactor(settings, opts)
+
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			exchange := &Exchange{
+				DatasourceUID:  settings.UID,
+				DatasourceType: settings.Type,
+				Started:        r.now(),
+				Request: CapturedRequest{
+					Method:  req.Method,
+					URL:     redactor.redactURL(req.URL),
+					Headers: redactor.redactHeaders(req.Header),
+				},
+			}
+
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := io.ReadAll(req.Body)
+				_ = req.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				// the request is not modified, the data source may retry it
+				req = req.Clone(req.Context())
+				req.Body = io.NopCloser(bytes.NewReader(body))
+				req.GetBody = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(body)), nil
+				}
+				exchange.Request.Body = redactor.redactBody(body, int64(len(body)))
+			}
+
+			resp, err := next.RoundTrip(req)
+			exchange.DurationMs = float64(r.now().Sub(exchange.Started)) / float64(time.Millisecond)
+			if err != nil {
+				exchange.Error = redactor.redactString(err.Error())
+				r.add(exchange)
+				return resp, err
+			}
+
+			exchange.Response = &CapturedResponse{
+				StatusCode: resp.StatusCode,
+				Headers:    redactor.redactHeaders(resp.Header),
+			}
+			if !r.add(exchange) || resp.Body == nil {
+				return resp, nil
+			}
+			resp.Body = &bodyRecorder{
+				ReadCloser: resp.Body,
+				done: func(body []byte, size int64) {
+					r.setResponseBody(exchange, redactor.redactBody(body, size))
+				},
+			}
+			return resp, nil
+		})
+	})
+}
+
+// bodyRecorder records the beginning of the body of a response while the data source reads it
+type bodyRecorder struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	size int64
+	once sync.Once
+	done func(body []byte, size int64)
+}
+
+func (b *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.size += int64(n)
+		if room := maxBodySize - b.buf.Len(); room > 0 {
+			if n < room {
+				room = n
+			}
+			b.buf.Write(p[:room])
+		}
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *bodyRecorder) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *bodyRecorder) finish() {
+	b.once.Do(func() {
+		b.done(b.buf.Bytes(), b.size)
+	})
+}
+
+// newBody returns the body recorded for a request or a response
+func newBody(b []byte, size int64, truncated bool) Body {
+	body := Body{BodySize: size, BodyTruncated: truncated}
+	if truncated {
+		// the body may be cut in the middle of a character
+		for i := 0; i < utf8.UTFMax && len(b) > 0 && !utf8.Valid(b); i++ {
+			b = b[:len(b)-1]
+		}
+	}
+	if utf8.Valid(b) {
+		body.Body = string(b)
+	} else {
+		body.Body = base64.StdEncoding.EncodeToString(b)
+		body.BodyEncoding = "base64"
+	}
+	return body
+}
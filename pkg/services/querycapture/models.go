@@ -0,0 +1,53 @@
+package querycapture
+
+import (
+	"net/http"
+	"time"
+)
+
+// Capture holds the requests sent by the data sources to their downstream services during a query execution, with
+// the raw responses they received
+type Capture struct {
+	UID     string    `json:"uid"`
+	Created time.Time `json:"created"`
+	// Exchanges are ordered by the time their requests were sent
+	Exchanges []Exchange `json:"exchanges"`
+	// Dropped is the number of requests not recorded because the capture was full
+	Dropped int `json:"dropped"`
+}
+
+// Exchange is a request sent by a data source and its response, with the secrets redacted
+type Exchange struct {
+	DatasourceUID  string    `json:"datasourceUid"`
+	DatasourceType string    `json:"datasourceType"`
+	Started        time.Time `json:"started"`
+	// DurationMs is the time until the headers of the response were received
+	DurationMs float64           `json:"durationMs"`
+	Request    CapturedRequest   `json:"request"`
+	Response   *CapturedResponse `json:"response,omitempty"`
+	// Error is the error of the round trip, there is no response when it is set
+	Error string `json:"error,omitempty"`
+}
+
+type CapturedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+	Body
+}
+
+type CapturedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers"`
+	Body
+}
+
+// Body is the beginning of the body of a request or a response. The bodies that are not valid UTF-8 are encoded in
+// base64.
+type Body struct {
+	Body         string `json:"body"`
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+	// BodySize is the size of the body sent or read by the data source, in bytes
+	BodySize      int64 `json:"bodySize"`
+	BodyTruncated bool  `json:"bodyTruncated,omitempty"`
+}
@@ -0,0 +1,196 @@
+package querycapture
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// HeaderCapture opts a query request in the capture of the requests its data sources send downstream
+	HeaderCapture = "X-Grafana-Capture"
+	// HeaderCaptureUID is returned with the response of a captured query request, the capture is fetched with it
+	HeaderCaptureUID = "X-Grafana-Capture-Uid"
+
+	// the captures are kept in memory, they are removed after the retention or when there are too many of them, the
+	// oldest first
+	captureRetention = 15 * time.Minute
+	maxCaptures      = 100
+
+	// maxExchanges is the number of requests recorded by a capture, the next ones are counted as dropped
+	maxExchanges = 100
+	// maxBodySize is the size of the bodies recorded by a capture, in bytes, the longer bodies are truncated
+	maxBodySize = 1 << 20
+)
+
+var ErrNoUniqueID = errors.New("the requests can only be captured for users, API keys and service accounts")
+
+type Service interface {
+	// Start creates a capture for a query execution of the user. The requests of the data sources are recorded when
+	// its recorder is added to the context of the query execution with WithRecorder.
+	Start(user *user.SignedInUser) (*Recorder, error)
+	// Get returns the capture started by the user with the UID, nil when it doesn't exist or has expired
+	Get(user *user.SignedInUser, uid string) *Capture
+}
+
+// QueryCaptureService keeps the captures of the query executions in memory, every Grafana instance only has the
+// captures of the queries it executed
+type QueryCaptureService struct {
+	RouteRegister routing.RouteRegister
+
+	log log.Logger
+	now func() time.Time
+
+	mu sync.Mutex
+	// recorders are ordered by creation time
+	recorders []*Recorder
+}
+
+func ProvideService(routeRegister routing.RouteRegister) *QueryCaptureService {
+	s := newService()
+	s.RouteRegister = routeRegister
+	s.registerAPIEndpoints()
+	return s
+}
+
+func newService() *QueryCaptureService {
+	return &QueryCaptureService{
+		log: log.New("query-capture"),
+		now: time.Now,
+	}
+}
+
+func (s *QueryCaptureService) Start(u *user.SignedInUser) (*Recorder, error) {
+	owner, err := u.GetCacheKey()
+	if err != nil {
+		return nil, ErrNoUniqueID
+	}
+
+	r := &Recorder{
+		owner: owner,
+		now:   s.now,
+		capture: Capture{
+			UID:     util.GenerateShortUID(),
+			Created: s.now(),
+		},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeExpired()
+	if len(s.recorders) >= maxCaptures {
+		s.recorders = s.recorders[len(s.recorders)-maxCaptures+1:]
+	}
+	s.recorders = append(s.recorders, r)
+
+	s.log.Debug("Capturing the requests of a query execution", "uid", r.capture.UID, "owner", owner)
+	return r, nil
+}
+
+func (s *QueryCaptureService) Get(u *user.SignedInUser, uid string) *Capture {
+	owner, err := u.GetCacheKey()
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeExpired()
+	for _, r := range s.recorders {
+		if r.capture.UID == uid && r.owner == owner {
+			return r.snapshot()
+		}
+	}
+	return nil
+}
+
+// removeExpired must be called with the lock held
+func (s *QueryCaptureService) removeExpired() {
+	cutoff := s.now().Add(-captureRetention)
+	i := 0
+	for i < len(s.recorders) && s.recorders[i].capture.Created.Before(cutoff) {
+		i++
+	}
+	s.recorders = s.recorders[i:]
+}
+
+// Recorder records the requests sent during a query execution in its capture
+type Recorder struct {
+	// owner identifies the user who started the capture, only they can get it
+	owner string
+	now   func() time.Time
+
+	mu        sync.Mutex
+	capture   Capture
+	exchanges []*Exchange
+}
+
+func (r *Recorder) UID() string {
+	return r.capture.UID
+}
+
+// add records an exchange, it returns false when the capture is full and the exchange was dropped
+func (r *Recorder) add(e *Exchange) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.exchanges) >= maxExchanges {
+		r.capture.Dropped++
+		return false
+	}
+	r.exchanges = append(r.exchanges, e)
+	return true
+}
+
+// setResponseBody records the body of a response once it has been read by the data source
+func (r *Recorder) setResponseBody(e *Exchange, body Body) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.Response.Body = body
+}
+
+func (r *Recorder) snapshot() *Capture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.capture
+	c.Exchanges = make([]Exchange, 0, len(r.exchanges))
+	for _, e := range r.exchanges {
+		exchange := *e
+		if e.Response != nil {
+			response := *e.Response
+			exchange.Response = &response
+		}
+		c.Exchanges = append(c.Exchanges, exchange)
+	}
+	// the exchanges are recorded when their responses are received, the requests sent concurrently can be recorded
+	// out of order
+	sort.SliceStable(c.Exchanges, func(i, j int) bool {
+		return c.Exchanges[i].Started.Before(c.Exchanges[j].Started)
+	})
+	return &c
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a copy of the context in which the requests of the data sources are recorded by the recorder
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// RecorderFromContext returns the recorder of the context, nil when the requests of the query execution are not
+// captured
+func RecorderFromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
@@ -0,0 +1,137 @@
+package querycapture
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// redactedValue replaces the secrets and the values of the sensitive headers and URL parameters
+const redactedValue = "[REDACTED]"
+
+// sensitiveNames are the names of the headers and of the URL parameters whose values are always redacted
+var sensitiveNames = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"key":                 true,
+	"sig":                 true,
+	"signature":           true,
+}
+
+// sensitiveParts redact the values of the headers and of the URL parameters whose names contain them
+var sensitiveParts = []string{"token", "secret", "password", "passwd", "apikey", "api_key", "api-key", "credential"}
+
+func isSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	if sensitiveNames[name] {
+		return true
+	}
+	for _, part := range sensitiveParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactor removes the secrets of a data source from its requests and responses: the values of its secure JSON data,
+// its basic auth password and the values of the sensitive headers and URL parameters
+type redactor struct {
+	// secrets are ordered by decreasing length, so that a secret containing another one is redacted whole
+	secrets []string
+}
+
+func newRedactor(settings *backend.DataSourceInstanceSettings, opts httpclient.Options) *redactor {
+	r := &redactor{}
+	for _, value := range settings.DecryptedSecureJSONData {
+		if value != "" {
+			r.secrets = append(r.secrets, value)
+		}
+	}
+	if opts.BasicAuth != nil && opts.BasicAuth.Password != "" {
+		r.secrets = append(r.secrets, opts.BasicAuth.Password)
+	}
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+	return r
+}
+
+func (r *redactor) redactString(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	return s
+}
+
+func (r *redactor) redactHeaders(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		redactedValues := make([]string, len(values))
+		for i, value := range values {
+			if isSensitiveName(name) {
+				redactedValues[i] = redactedValue
+			} else {
+				redactedValues[i] = r.redactString(value)
+			}
+		}
+		redacted[name] = redactedValues
+	}
+	return redacted
+}
+
+// redactURL redacts the password of the URL and the values of its sensitive parameters, the parameters keep their
+// order
+func (r *redactor) redactURL(u *url.URL) string {
+	redacted := *u
+	if _, ok := u.User.Password(); ok {
+		redacted.User = url.UserPassword(u.User.Username(), redactedValue)
+	}
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			name, _, _ := strings.Cut(param, "=")
+			if unescaped, err := url.QueryUnescape(name); err == nil && isSensitiveName(unescaped) {
+				params[i] = name + "=" + redactedValue
+			}
+		}
+		redacted.RawQuery = strings.Join(params, "&")
+	}
+	return r.redactString(redacted.String())
+}
+
+// redactBody returns the body with the secrets redacted, truncated to the max size of the bodies
+func (r *redactor) redactBody(b []byte, size int64) Body {
+	truncated := int64(len(b)) < size
+	if len(b) > maxBodySize {
+		b = b[:maxBodySize]
+		truncated = true
+	}
+	for _, secret := range r.secrets {
+		b = bytes.ReplaceAll(b, []byte(secret), []byte(redactedValue))
+	}
+	if truncated {
+		b = r.trimSecretPrefix(b)
+	}
+	return newBody(b, size, truncated)
+}
+
+// trimSecretPrefix removes the end of a truncated body when it is the beginning of a secret, the rest of the secret
+// was cut and it could not be redacted
+func (r *redactor) trimSecretPrefix(b []byte) []byte {
+	for _, secret := range r.secrets {
+		for n := len(secret) - 1; n > 0; n-- {
+			if bytes.HasSuffix(b, []byte(secret[:n])) {
+				return b[:len(b)-n]
+			}
+		}
+	}
+	return b
+}
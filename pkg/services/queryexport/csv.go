@@ -0,0 +1,126 @@
+package queryexport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const csvContentType = "text/csv"
+
+// framesToCSV writes the frames of a target as a single table. The columns are the fields of all the frames followed
+// by the labels of their fields, so the series of a response can be told apart in the rows.
+func framesToCSV(frames data.Frames) ([]byte, error) {
+	var columns []string
+	fieldColumns := map[string]bool{}
+	labelColumns := map[string]bool{}
+	for _, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		for i, field := range frame.Fields {
+			name := fieldName(field, i)
+			if !fieldColumns[name] {
+				fieldColumns[name] = true
+				columns = append(columns, name)
+			}
+			for label := range field.Labels {
+				labelColumns[label] = true
+			}
+		}
+	}
+	labels := make([]string, 0, len(labelColumns))
+	for label := range labelColumns {
+		// the label is dropped rather than mixed with the values of a field of the same name
+		if !fieldColumns[label] {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if len(columns) > 0 {
+		if err := w.Write(append(append([]string{}, columns...), labels...)); err != nil {
+			return nil, err
+		}
+	}
+
+	index := make(map[string]int, len(columns))
+	for i, name := range columns {
+		index[name] = i
+	}
+	for _, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		frameLabels := frameLabels(frame)
+		rows, err := frame.RowLen()
+		if err != nil {
+			return nil, fmt.Errorf("frame %q: %w", frame.Name, err)
+		}
+		for row := 0; row < rows; row++ {
+			record := make([]string, len(columns)+len(labels))
+			for i, field := range frame.Fields {
+				record[index[fieldName(field, i)]] = formatValue(field, row)
+			}
+			for i, label := range labels {
+				record[len(columns)+i] = frameLabels[label]
+			}
+			if err := w.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// frameLabels returns the labels of the fields of the frame, the first field with a label sets its value for the rows
+// of the frame
+func frameLabels(frame *data.Frame) map[string]string {
+	labels := map[string]string{}
+	for _, field := range frame.Fields {
+		for name, value := range field.Labels {
+			if _, ok := labels[name]; !ok {
+				labels[name] = value
+			}
+		}
+	}
+	return labels
+}
+
+func fieldName(field *data.Field, i int) string {
+	if field.Name != "" {
+		return field.Name
+	}
+	return fmt.Sprintf("Field %d", i+1)
+}
+
+func formatValue(field *data.Field, i int) string {
+	v, ok := field.ConcreteAt(i)
+	if !ok {
+		return ""
+	}
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case json.RawMessage:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,46 @@
+package queryexport
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFramesToCSV(t *testing.T) {
+	t0 := time.Date(2023, 3, 4, 12, 0, 0, 0, time.UTC)
+	frames := data.Frames{
+		data.NewFrame("",
+			data.NewField("time", nil, []time.Time{t0, t0.Add(time.Minute)}),
+			data.NewField("value", data.Labels{"job": "api", "instance": "a"}, []*float64{floatPtr(1.5), nil}),
+		),
+		data.NewFrame("",
+			data.NewField("time", nil, []time.Time{t0}),
+			data.NewField("value", data.Labels{"job": "web, \"edge\""}, []float64{2}),
+		),
+		data.NewFrame("",
+			data.NewField("", nil, []string{"text"}),
+			data.NewField("json", nil, []json.RawMessage{json.RawMessage(`{"a":1}`)}),
+		),
+	}
+
+	body, err := framesToCSV(frames)
+	require.NoError(t, err)
+	assert.Equal(t, `time,value,Field 1,json,instance,job
+2023-03-04T12:00:00Z,1.5,,,a,api
+2023-03-04T12:01:00Z,,,,a,api
+2023-03-04T12:00:00Z,2,,,,"web, ""edge"""
+,,text,"{""a"":1}",,
+`, string(body))
+
+	body, err = framesToCSV(nil)
+	require.NoError(t, err)
+	assert.Empty(t, body)
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,158 @@
+package queryexport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/grafana/grafana/pkg/services/scheduledquery"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+
+	// DefaultPath stores the files of every run of an export in their own directory, named after the scheduled time,
+	// and the files of every combination of the values of the variables in a sub directory
+	DefaultPath = `{{.OrgID}}/{{.UID}}/{{.Time.Format "2006-01-02T15-04-05Z"}}/{{.Combination}}/{{.RefID}}.{{.Ext}}`
+)
+
+// Export is a set of queries and expressions run on a cron schedule, the results of its targets are uploaded to the
+// object storage configured in the query_export section
+type Export struct {
+	UID   string
+	OrgID int64
+	// Schedule is a standard cron expression or descriptor like @daily, in UTC unless it starts with CRON_TZ=
+	Schedule string
+	// Format is the format of the uploaded files, csv or parquet, csv when empty
+	Format string
+	// Path is the template of the key of the uploaded objects, DefaultPath when empty
+	Path string
+	// Targets are the RefIDs of the queries and expressions whose results are uploaded, all of them when empty
+	Targets []string
+	Queries []Query
+	// Variables are replaced in the models of the queries, the export runs once for every combination of their values
+	Variables []Variable
+}
+
+// Query is a datasource query or an expression of an export
+type Query = scheduledquery.Query
+
+// pathData are the values available to the path template, for every target of a run
+type pathData struct {
+	OrgID int64
+	UID   string
+	RefID string
+	// Time is the time the run was scheduled at
+	Time time.Time
+	Ext  string
+	// Variables are the values of the variables of the run, by name
+	Variables map[string]string
+	// Combination is name=value for every variable, separated by slashes, empty when there are no variables
+	Combination string
+}
+
+type exportKey struct {
+	orgID int64
+	uid   string
+}
+
+func (e Export) key() exportKey {
+	return exportKey{orgID: e.OrgID, uid: e.UID}
+}
+
+func (e Export) format() string {
+	if e.Format != "" {
+		return e.Format
+	}
+	return FormatCSV
+}
+
+func (e Export) targets() []string {
+	if len(e.Targets) > 0 {
+		return e.Targets
+	}
+	targets := make([]string, 0, len(e.Queries))
+	for _, q := range e.Queries {
+		targets = append(targets, q.RefID)
+	}
+	return targets
+}
+
+// Validate checks that the export can be scheduled, run and its results uploaded
+func (e Export) Validate() error {
+	if e.UID == "" {
+		return errors.New("export uid is required")
+	}
+	if _, err := parseSchedule(e.Schedule); err != nil {
+		return fmt.Errorf("export %s: invalid schedule %q: %w", e.UID, e.Schedule, err)
+	}
+	if e.format() != FormatCSV && e.format() != FormatParquet {
+		return fmt.Errorf("export %s: unsupported format %q, the formats are csv and parquet", e.UID, e.Format)
+	}
+	if _, err := parsePath(e.Path); err != nil {
+		return fmt.Errorf("export %s: invalid path template: %w", e.UID, err)
+	}
+	refIDs, err := scheduledquery.ValidateQueries(e.Queries)
+	if err != nil {
+		return fmt.Errorf("export %s: %w", e.UID, err)
+	}
+	for _, target := range e.Targets {
+		if _, ok := refIDs[target]; !ok {
+			return fmt.Errorf("export %s: target %s is not one of the queries", e.UID, target)
+		}
+	}
+
+	names := make(map[string]struct{}, len(e.Variables))
+	for _, v := range e.Variables {
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("export %s: %w", e.UID, err)
+		}
+		if _, ok := names[v.Name]; ok {
+			return fmt.Errorf("export %s: duplicate variable %s", e.UID, v.Name)
+		}
+		// the refIds are the variables of the expressions
+		if _, ok := refIDs[v.Name]; ok {
+			return fmt.Errorf("export %s: variable %s has the name of a query refId", e.UID, v.Name)
+		}
+		names[v.Name] = struct{}{}
+	}
+	if err := checkCombinations(e.Variables); err != nil {
+		return fmt.Errorf("export %s: %w", e.UID, err)
+	}
+	return nil
+}
+
+func parseSchedule(spec string) (cron.Schedule, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, errors.New("schedule is required")
+	}
+	return cron.ParseStandard(spec)
+}
+
+func parsePath(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultPath
+	}
+	return template.New("path").Parse(text)
+}
+
+// objectKey renders the key of the object of a target, the leading slashes are removed as the keys are relative to
+// the bucket
+func objectKey(tmpl *template.Template, d pathData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	key := strings.TrimLeft(path.Clean("/"+buf.String()), "/")
+	if key == "" {
+		return "", errors.New("the path is empty")
+	}
+	return key, nil
+}
@@ -0,0 +1,97 @@
+package queryexport
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/components/frameparquet"
+)
+
+// framesToParquet writes the frames of a target as a single table, with the columns of framesToCSV. The columns of
+// the fields keep their type, they are nullable as a frame may not have all of them. A target returning a single frame
+// without labels is written as it is, so the file keeps the metadata of the frame.
+func framesToParquet(frames data.Frames) ([]byte, error) {
+	var nonNil data.Frames
+	for _, frame := range frames {
+		if frame != nil {
+			nonNil = append(nonNil, frame)
+		}
+	}
+	if len(nonNil) == 1 && len(frameLabels(nonNil[0])) == 0 {
+		return frameparquet.MarshalFrame(nonNil[0])
+	}
+
+	var columns []string
+	types := map[string]data.FieldType{}
+	labelColumns := map[string]bool{}
+	rows := 0
+	for _, frame := range nonNil {
+		n, err := frame.RowLen()
+		if err != nil {
+			return nil, fmt.Errorf("frame %q: %w", frame.Name, err)
+		}
+		rows += n
+		for i, field := range frame.Fields {
+			name := fieldName(field, i)
+			fieldType := field.Type().NullableType()
+			if t, ok := types[name]; !ok {
+				types[name] = fieldType
+				columns = append(columns, name)
+			} else if t != fieldType {
+				return nil, fmt.Errorf("field %q is of type %s and %s in the frames", name, t.ItemTypeString(), fieldType.ItemTypeString())
+			}
+			for label := range field.Labels {
+				labelColumns[label] = true
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("the frames have no fields")
+	}
+	labels := make([]string, 0, len(labelColumns))
+	for label := range labelColumns {
+		// the label is dropped rather than mixed with the values of a field of the same name
+		if _, ok := types[label]; !ok {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	table := data.NewFrame("")
+	fields := make(map[string]*data.Field, len(columns))
+	for _, name := range columns {
+		field := data.NewFieldFromFieldType(types[name], rows)
+		field.Name = name
+		fields[name] = field
+		table.Fields = append(table.Fields, field)
+	}
+	labelFields := make([]*data.Field, 0, len(labels))
+	for _, label := range labels {
+		field := data.NewFieldFromFieldType(data.FieldTypeNullableString, rows)
+		field.Name = label
+		labelFields = append(labelFields, field)
+		table.Fields = append(table.Fields, field)
+	}
+
+	offset := 0
+	for _, frame := range nonNil {
+		frameLabels := frameLabels(frame)
+		n, _ := frame.RowLen()
+		for row := 0; row < n; row++ {
+			for i, field := range frame.Fields {
+				if v, ok := field.ConcreteAt(row); ok {
+					fields[fieldName(field, i)].SetConcrete(offset+row, v)
+				}
+			}
+			for i, label := range labels {
+				if v, ok := frameLabels[label]; ok {
+					labelFields[i].SetConcrete(offset+row, v)
+				}
+			}
+		}
+		offset += n
+	}
+	return frameparquet.MarshalFrame(table)
+}
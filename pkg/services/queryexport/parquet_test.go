@@ -0,0 +1,74 @@
+package queryexport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/frameparquet"
+)
+
+func TestFramesToParquet(t *testing.T) {
+	t0 := time.Date(2023, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	t.Run("writes the frames as a single table", func(t *testing.T) {
+		frames := data.Frames{
+			data.NewFrame("",
+				data.NewField("time", nil, []time.Time{t0, t0.Add(time.Minute)}),
+				data.NewField("value", data.Labels{"job": "api", "instance": "a"}, []*float64{floatPtr(1.5), nil}),
+			),
+			data.NewFrame("",
+				data.NewField("time", nil, []time.Time{t0}),
+				data.NewField("value", data.Labels{"job": "web"}, []float64{2}),
+			),
+		}
+
+		body, err := framesToParquet(frames)
+		require.NoError(t, err)
+		table, err := frameparquet.UnmarshalFrame(body)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"time", "value", "instance", "job"}, []string{table.Fields[0].Name, table.Fields[1].Name, table.Fields[2].Name, table.Fields[3].Name})
+		require.Equal(t, 3, table.Rows())
+		assert.Equal(t, data.FieldTypeNullableFloat64, table.Fields[1].Type())
+		assert.Equal(t, []interface{}{&t0, floatPtr(1.5), strPtr("a"), strPtr("api")}, table.RowCopy(0))
+		assert.Equal(t, []interface{}{timePtr(t0.Add(time.Minute)), (*float64)(nil), strPtr("a"), strPtr("api")}, table.RowCopy(1))
+		assert.Equal(t, []interface{}{&t0, floatPtr(2), (*string)(nil), strPtr("web")}, table.RowCopy(2))
+	})
+
+	t.Run("writes a single frame as it is", func(t *testing.T) {
+		frame := data.NewFrame("requests",
+			data.NewField("time", nil, []time.Time{t0}),
+			data.NewField("count", nil, []int64{3}),
+		)
+
+		body, err := framesToParquet(data.Frames{frame})
+		require.NoError(t, err)
+		decoded, err := frameparquet.UnmarshalFrame(body)
+		require.NoError(t, err)
+		assert.Equal(t, "requests", decoded.Name)
+		assert.Equal(t, data.FieldTypeInt64, decoded.Fields[1].Type())
+	})
+
+	t.Run("fails when the fields have different types", func(t *testing.T) {
+		_, err := framesToParquet(data.Frames{
+			data.NewFrame("", data.NewField("value", nil, []float64{1})),
+			data.NewFrame("", data.NewField("value", nil, []string{"a"})),
+		})
+		require.EqualError(t, err, `field "value" is of type *float64 and *string in the frames`)
+
+		_, err = framesToParquet(nil)
+		require.Error(t, err)
+	})
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
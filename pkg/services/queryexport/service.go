@@ -0,0 +1,293 @@
+package queryexport
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/robfig/cron/v3"
+
+	"github.com/grafana/grafana/pkg/components/frameparquet"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/scheduledquery"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// tickInterval is how often the service looks for the exports to run
+const tickInterval = time.Second
+
+// evaluator runs the queries of an export
+type evaluator interface {
+	Evaluate(ctx context.Context, e Export, now time.Time) (*backend.QueryDataResponse, error)
+}
+
+type scheduledExport struct {
+	export   Export
+	schedule cron.Schedule
+	path     *template.Template
+	next     time.Time
+	running  bool
+}
+
+// Service runs the registered exports on their schedule and uploads their results to the object storage configured
+// in the query_export section
+type Service struct {
+	cfg       setting.QueryExportSettings
+	log       log.Logger
+	clock     clock.Clock
+	evaluator evaluator
+	storage   ObjectStorage
+
+	mu      sync.Mutex
+	exports map[exportKey]*scheduledExport
+}
+
+func ProvideService(cfg *setting.Cfg, exprService *expr.Service, dsCache datasources.CacheService) (*Service, error) {
+	storage, err := newObjectStorage(cfg.QueryExport)
+	if err != nil {
+		return nil, err
+	}
+	return newService(cfg.QueryExport, clock.New(), &exprEvaluator{evaluator: scheduledquery.NewEvaluator(exprService, dsCache, "grafana_query_export")}, storage,
+		log.New("queryexport")), nil
+}
+
+func newService(cfg setting.QueryExportSettings, clk clock.Clock, evaluator evaluator, storage ObjectStorage, logger log.Logger) *Service {
+	return &Service{
+		cfg:       cfg,
+		log:       logger,
+		clock:     clk,
+		evaluator: evaluator,
+		storage:   storage,
+		exports:   map[exportKey]*scheduledExport{},
+	}
+}
+
+// IsDisabled disables the service when the query exports are disabled or there is no object storage
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.Enabled || s.storage == nil
+}
+
+// Register adds the export to the scheduled exports, or replaces the export with the same org and UID. The export
+// runs at the next time of its schedule.
+func (s *Service) Register(e Export) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	schedule, err := parseSchedule(e.Schedule)
+	if err != nil {
+		return err
+	}
+	tmpl, err := parsePath(e.Path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scheduled, ok := s.exports[e.key()]
+	if !ok {
+		scheduled = &scheduledExport{}
+		s.exports[e.key()] = scheduled
+	}
+	scheduled.export = e
+	scheduled.schedule = schedule
+	scheduled.path = tmpl
+	scheduled.next = schedule.Next(s.clock.Now().UTC())
+	return nil
+}
+
+// Unregister stops scheduling the export, a run in progress is not cancelled
+func (s *Service) Unregister(orgID int64, uid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.exports, exportKey{orgID: orgID, uid: uid})
+}
+
+// Exports returns the registered exports of the organization, sorted by UID
+func (s *Service) Exports(orgID int64) []Export {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exports := make([]Export, 0)
+	for key, scheduled := range s.exports {
+		if key.orgID == orgID {
+			exports = append(exports, scheduled.export)
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].UID < exports[j].UID
+	})
+	return exports
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	ticker := s.clock.Ticker(tickInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			for _, run := range s.due(now) {
+				wg.Add(1)
+				go func(run exportRun) {
+					defer wg.Done()
+					s.run(ctx, run)
+
+					s.mu.Lock()
+					run.scheduled.running = false
+					s.mu.Unlock()
+				}(run)
+			}
+		}
+	}
+}
+
+// exportRun is a run of an export at the time it was scheduled at
+type exportRun struct {
+	scheduled *scheduledExport
+	export    Export
+	path      *template.Template
+	at        time.Time
+}
+
+// due returns the runs of the exports scheduled before now and schedules their next run. The runs missed while the
+// previous run of an export has not finished are skipped, so slow queries do not pile up.
+func (s *Service) due(now time.Time) []exportRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now = now.UTC()
+	var due []exportRun
+	for _, scheduled := range s.exports {
+		if now.Before(scheduled.next) {
+			continue
+		}
+		at := scheduled.next
+		scheduled.next = scheduled.schedule.Next(now)
+		if scheduled.running {
+			s.log.Warn("Skipping export, the previous run has not finished", "org", scheduled.export.OrgID, "uid", scheduled.export.UID)
+			continue
+		}
+		scheduled.running = true
+		due = append(due, exportRun{scheduled: scheduled, export: scheduled.export, path: scheduled.path, at: at})
+	}
+	return due
+}
+
+// run evaluates the queries of the export for every combination of the values of its variables and uploads the
+// result of every target, the combinations and targets that failed are logged and do not prevent the upload of the
+// others
+func (s *Service) run(ctx context.Context, run exportRun) {
+	e := run.export
+	logger := s.log.New("org", e.OrgID, "uid", e.UID, "scheduledAt", run.at)
+
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	values, err := variableValues(ctx, s.evaluator, e, run.at)
+	if err != nil {
+		logger.Error("Failed to get the values of the variables", "error", err)
+		return
+	}
+	combs, err := combinations(e.Variables, values)
+	if err != nil {
+		logger.Error("Failed to run export", "error", err)
+		return
+	}
+
+	keys := map[string]string{}
+	for _, c := range combs {
+		combLogger := logger
+		if len(c) > 0 {
+			combLogger = logger.New("variables", c.String())
+		}
+		interpolated, err := e.interpolate(c)
+		if err != nil {
+			combLogger.Error("Failed to run export", "error", err)
+			continue
+		}
+		resp, err := s.evaluator.Evaluate(ctx, interpolated, run.at)
+		if err != nil {
+			combLogger.Error("Failed to run export", "error", err)
+			continue
+		}
+		s.upload(ctx, combLogger, run, c, resp, keys)
+	}
+}
+
+// upload uploads the result of every target of a combination, keys are the keys already uploaded by the run
+func (s *Service) upload(ctx context.Context, logger log.Logger, run exportRun, c combination, resp *backend.QueryDataResponse, keys map[string]string) {
+	e := run.export
+	for _, target := range e.targets() {
+		res, ok := resp.Responses[target]
+		if !ok {
+			logger.Error("Failed to export target", "refId", target, "error", "no response")
+			continue
+		}
+		if res.Error != nil {
+			logger.Error("Failed to export target", "refId", target, "error", res.Error)
+			continue
+		}
+
+		key, err := objectKey(run.path, pathData{
+			OrgID:       e.OrgID,
+			UID:         e.UID,
+			RefID:       target,
+			Time:        run.at,
+			Ext:         e.format(),
+			Variables:   c.values(),
+			Combination: c.path(),
+		})
+		if err != nil {
+			logger.Error("Failed to render the path of the target", "refId", target, "error", err)
+			continue
+		}
+		if other, ok := keys[key]; ok {
+			logger.Error("Failed to export target, the path is the same as the path of another target", "refId", target, "other", other, "key", key)
+			continue
+		}
+		keys[key] = target
+
+		body, contentType, err := encodeFrames(e.format(), res.Frames)
+		if err != nil {
+			logger.Error("Failed to serialize target", "refId", target, "error", err)
+			continue
+		}
+		if err := s.storage.Upload(ctx, key, body, contentType); err != nil {
+			logger.Error("Failed to upload target", "refId", target, "key", key, "error", err)
+			continue
+		}
+		logger.Debug("Target exported", "refId", target, "key", key, "bytes", len(body))
+	}
+}
+
+// encodeFrames writes the frames of a target in the format of the export, it returns the content type of the file
+func encodeFrames(format string, frames data.Frames) ([]byte, string, error) {
+	if format == FormatParquet {
+		body, err := framesToParquet(frames)
+		return body, frameparquet.ContentType, err
+	}
+	body, err := framesToCSV(frames)
+	return body, csvContentType, err
+}
+
+type exprEvaluator struct {
+	evaluator *scheduledquery.Evaluator
+}
+
+func (e *exprEvaluator) Evaluate(ctx context.Context, export Export, now time.Time) (*backend.QueryDataResponse, error) {
+	return e.evaluator.Evaluate(ctx, export.OrgID, export.Queries, now)
+}
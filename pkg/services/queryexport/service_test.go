@@ -0,0 +1,307 @@
+package queryexport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/frameparquet"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func testExport() Export {
+	return Export{
+		UID:      "exp",
+		OrgID:    1,
+		Schedule: "0 * * * *",
+		Queries: []Query{
+			{RefID: "A", DatasourceUID: "prom", Model: json.RawMessage(`{"expr": "rate(requests[5m])"}`)},
+			{RefID: "B", DatasourceUID: "__expr__", Model: json.RawMessage(`{"type": "reduce", "expression": "A", "reducer": "last"}`)},
+		},
+	}
+}
+
+func TestExportValidate(t *testing.T) {
+	require.NoError(t, testExport().Validate())
+
+	for name, tc := range map[string]struct {
+		update func(e *Export)
+		err    string
+	}{
+		"missing uid":             {update: func(e *Export) { e.UID = "" }, err: "export uid is required"},
+		"missing schedule":        {update: func(e *Export) { e.Schedule = "" }, err: `export exp: invalid schedule "": schedule is required`},
+		"invalid schedule":        {update: func(e *Export) { e.Schedule = "every hour" }, err: `export exp: invalid schedule "every hour"`},
+		"descriptor schedule":     {update: func(e *Export) { e.Schedule = "@daily" }},
+		"unsupported format":      {update: func(e *Export) { e.Format = "json" }, err: `export exp: unsupported format "json", the formats are csv and parquet`},
+		"invalid path":            {update: func(e *Export) { e.Path = "{{.UID" }, err: "export exp: invalid path template"},
+		"no queries":              {update: func(e *Export) { e.Queries = nil }, err: "export exp: at least one query is required"},
+		"duplicate refId":         {update: func(e *Export) { e.Queries[1].RefID = "A" }, err: "export exp: duplicate query refId A"},
+		"missing datasource":      {update: func(e *Export) { e.Queries[0].DatasourceUID = "" }, err: "export exp: query A: datasource uid is required"},
+		"unknown target refId":    {update: func(e *Export) { e.Targets = []string{"B", "C"} }, err: "export exp: target C is not one of the queries"},
+		"variable":                {update: func(e *Export) { e.Variables = []Variable{{Name: "env", Values: []string{"prod"}}} }},
+		"invalid variable name":   {update: func(e *Export) { e.Variables = []Variable{{Name: "${env}", Values: []string{"prod"}}} }, err: `export exp: invalid variable name "${env}"`},
+		"variable without values": {update: func(e *Export) { e.Variables = []Variable{{Name: "env"}} }, err: "export exp: variable env: either values or a query is required"},
+		"variable with values and query": {
+			update: func(e *Export) {
+				e.Variables = []Variable{{Name: "env", Values: []string{"prod"}, Query: &Query{DatasourceUID: "prom"}}}
+			},
+			err: "export exp: variable env: only one of values or query can be set",
+		},
+		"variable query expression": {
+			update: func(e *Export) { e.Variables = []Variable{{Name: "env", Query: &Query{DatasourceUID: "__expr__"}}} },
+			err:    "export exp: variable env: the query can't be an expression",
+		},
+		"duplicate variable": {
+			update: func(e *Export) {
+				e.Variables = []Variable{{Name: "env", Values: []string{"prod"}}, {Name: "env", Values: []string{"dev"}}}
+			},
+			err: "export exp: duplicate variable env",
+		},
+		"variable named after a refId": {update: func(e *Export) { e.Variables = []Variable{{Name: "A", Values: []string{"prod"}}} }, err: "export exp: variable A has the name of a query refId"},
+		"too many combinations": {
+			update: func(e *Export) {
+				e.Variables = []Variable{{Name: "env", Values: make([]string, 20)}, {Name: "region", Values: make([]string, 6)}}
+			},
+			err: "export exp: the variables have more than 100 combinations",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := testExport()
+			tc.update(&e)
+			err := e.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestObjectKey(t *testing.T) {
+	at := time.Date(2023, 3, 4, 12, 0, 0, 0, time.UTC)
+	d := pathData{OrgID: 1, UID: "exp", RefID: "A", Time: at, Ext: "csv"}
+
+	tmpl, err := parsePath("")
+	require.NoError(t, err)
+	key, err := objectKey(tmpl, d)
+	require.NoError(t, err)
+	assert.Equal(t, "1/exp/2023-03-04T12-00-00Z/A.csv", key)
+
+	tmpl, err = parsePath(`/exports//{{.Time.Format "2006/01/02"}}/{{.UID}}-{{.RefID}}.{{.Ext}}`)
+	require.NoError(t, err)
+	key, err = objectKey(tmpl, d)
+	require.NoError(t, err)
+	assert.Equal(t, "exports/2023/03/04/exp-A.csv", key)
+
+	tmpl, err = parsePath(`{{.Unknown}}`)
+	require.NoError(t, err)
+	_, err = objectKey(tmpl, d)
+	require.Error(t, err)
+}
+
+type fakeEvaluator struct {
+	resp *backend.QueryDataResponse
+	err  error
+}
+
+func (e *fakeEvaluator) Evaluate(_ context.Context, _ Export, _ time.Time) (*backend.QueryDataResponse, error) {
+	return e.resp, e.err
+}
+
+type fakeStorage struct {
+	mu           sync.Mutex
+	uploads      map[string]string
+	contentTypes map[string]string
+}
+
+func (s *fakeStorage) Upload(_ context.Context, key string, body []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.uploads == nil {
+		s.uploads = map[string]string{}
+		s.contentTypes = map[string]string{}
+	}
+	s.uploads[key] = string(body)
+	s.contentTypes[key] = contentType
+	return nil
+}
+
+func (s *fakeStorage) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+func TestService(t *testing.T) {
+	resp := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{data.NewFrame("", data.NewField("value", data.Labels{"job": "api"}, []float64{3}))}},
+		"B": {Frames: data.Frames{data.NewFrame("", data.NewField("value", nil, []float64{4}))}},
+	}}
+
+	t.Run("is disabled without object storage", func(t *testing.T) {
+		s := newService(setting.QueryExportSettings{Enabled: true}, clock.NewMock(), &fakeEvaluator{}, nil, log.NewNopLogger())
+		assert.True(t, s.IsDisabled())
+		s = newService(setting.QueryExportSettings{Enabled: true}, clock.NewMock(), &fakeEvaluator{}, &fakeStorage{}, log.NewNopLogger())
+		assert.False(t, s.IsDisabled())
+	})
+
+	t.Run("does not register invalid exports", func(t *testing.T) {
+		s := newService(setting.QueryExportSettings{}, clock.NewMock(), &fakeEvaluator{}, &fakeStorage{}, log.NewNopLogger())
+		e := testExport()
+		e.Schedule = "never"
+		require.Error(t, s.Register(e))
+		assert.Empty(t, s.exports)
+	})
+
+	t.Run("schedules the exports on their schedule", func(t *testing.T) {
+		clk := clock.NewMock()
+		start := time.Date(2023, 3, 4, 11, 30, 0, 0, time.UTC)
+		clk.Set(start)
+		s := newService(setting.QueryExportSettings{}, clk, &fakeEvaluator{}, &fakeStorage{}, log.NewNopLogger())
+		require.NoError(t, s.Register(testExport()))
+
+		assert.Empty(t, s.due(start.Add(time.Minute)))
+		runs := s.due(start.Add(30*time.Minute + time.Second))
+		require.Len(t, runs, 1)
+		// the run has the time it was scheduled at
+		assert.Equal(t, time.Date(2023, 3, 4, 12, 0, 0, 0, time.UTC), runs[0].at)
+		runs[0].scheduled.running = false
+
+		// the runs missed are skipped
+		runs = s.due(start.Add(3*time.Hour + time.Second))
+		require.Len(t, runs, 1)
+		assert.Equal(t, time.Date(2023, 3, 4, 13, 0, 0, 0, time.UTC), runs[0].at)
+		assert.Equal(t, time.Date(2023, 3, 4, 15, 0, 0, 0, time.UTC), s.exports[testExport().key()].next)
+	})
+
+	t.Run("skips the exports still running", func(t *testing.T) {
+		clk := clock.NewMock()
+		s := newService(setting.QueryExportSettings{}, clk, &fakeEvaluator{}, &fakeStorage{}, log.NewNopLogger())
+		require.NoError(t, s.Register(testExport()))
+
+		require.Len(t, s.due(clk.Now().Add(time.Hour)), 1)
+		assert.Empty(t, s.due(clk.Now().Add(2*time.Hour)))
+	})
+
+	t.Run("unregistered exports do not run", func(t *testing.T) {
+		clk := clock.NewMock()
+		s := newService(setting.QueryExportSettings{}, clk, &fakeEvaluator{}, &fakeStorage{}, log.NewNopLogger())
+		require.NoError(t, s.Register(testExport()))
+		s.Unregister(1, "exp")
+		assert.Empty(t, s.due(clk.Now().Add(time.Hour)))
+	})
+
+	t.Run("lists the exports of the organization", func(t *testing.T) {
+		s := newService(setting.QueryExportSettings{}, clock.NewMock(), &fakeEvaluator{}, &fakeStorage{}, log.NewNopLogger())
+		other := testExport()
+		other.OrgID = 2
+		require.NoError(t, s.Register(testExport()))
+		require.NoError(t, s.Register(other))
+
+		exports := s.Exports(1)
+		require.Len(t, exports, 1)
+		assert.Equal(t, "exp", exports[0].UID)
+		assert.Empty(t, s.Exports(3))
+	})
+
+	t.Run("uploads the results of the targets", func(t *testing.T) {
+		clk := clock.NewMock()
+		storage := &fakeStorage{}
+		s := newService(setting.QueryExportSettings{}, clk, &fakeEvaluator{resp: resp}, storage, log.NewNopLogger())
+		e := testExport()
+		e.Schedule = "* * * * *"
+		require.NoError(t, s.Register(e))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- s.Run(ctx) }()
+
+		require.Eventually(t, func() bool {
+			clk.Add(tickInterval)
+			return storage.count() == 2
+		}, time.Second, time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+
+		assert.Equal(t, map[string]string{
+			"1/exp/1970-01-01T00-01-00Z/A.csv": "value,job\n3,api\n",
+			"1/exp/1970-01-01T00-01-00Z/B.csv": "value\n4\n",
+		}, storage.uploads)
+	})
+
+	t.Run("uploads the targets that did not fail", func(t *testing.T) {
+		storage := &fakeStorage{}
+		failed := &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": {Error: errors.New("boom")},
+			"B": resp.Responses["B"],
+		}}
+		s := newService(setting.QueryExportSettings{}, clock.NewMock(), &fakeEvaluator{resp: failed}, storage, log.NewNopLogger())
+		tmpl, err := parsePath("")
+		require.NoError(t, err)
+		s.run(context.Background(), exportRun{export: testExport(), path: tmpl, at: time.Unix(0, 0).UTC()})
+		assert.Equal(t, map[string]string{"1/exp/1970-01-01T00-00-00Z/B.csv": "value\n4\n"}, storage.uploads)
+	})
+
+	t.Run("uploads the results of the targets as parquet files", func(t *testing.T) {
+		storage := &fakeStorage{}
+		s := newService(setting.QueryExportSettings{}, clock.NewMock(), &fakeEvaluator{resp: resp}, storage, log.NewNopLogger())
+		e := testExport()
+		e.Format = FormatParquet
+		tmpl, err := parsePath("")
+		require.NoError(t, err)
+		s.run(context.Background(), exportRun{export: e, path: tmpl, at: time.Unix(0, 0).UTC()})
+
+		require.Equal(t, map[string]string{
+			"1/exp/1970-01-01T00-00-00Z/A.parquet": frameparquet.ContentType,
+			"1/exp/1970-01-01T00-00-00Z/B.parquet": frameparquet.ContentType,
+		}, storage.contentTypes)
+		frame, err := frameparquet.UnmarshalFrame([]byte(storage.uploads["1/exp/1970-01-01T00-00-00Z/A.parquet"]))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{floatPtr(3), strPtr("api")}, frame.RowCopy(0))
+	})
+
+	t.Run("does not upload the targets with the same path", func(t *testing.T) {
+		storage := &fakeStorage{}
+		s := newService(setting.QueryExportSettings{}, clock.NewMock(), &fakeEvaluator{resp: resp}, storage, log.NewNopLogger())
+		tmpl, err := parsePath("{{.UID}}.csv")
+		require.NoError(t, err)
+		s.run(context.Background(), exportRun{export: testExport(), path: tmpl, at: time.Unix(0, 0).UTC()})
+		assert.Equal(t, map[string]string{"exp.csv": "value,job\n3,api\n"}, storage.uploads)
+	})
+
+	t.Run("does not upload when the queries fail", func(t *testing.T) {
+		storage := &fakeStorage{}
+		s := newService(setting.QueryExportSettings{}, clock.NewMock(), &fakeEvaluator{err: errors.New("boom")}, storage, log.NewNopLogger())
+		tmpl, err := parsePath("")
+		require.NoError(t, err)
+		s.run(context.Background(), exportRun{export: testExport(), path: tmpl, at: time.Now()})
+		assert.Equal(t, 0, storage.count())
+	})
+}
+
+func TestNewObjectStorage(t *testing.T) {
+	storage, err := newObjectStorage(setting.QueryExportSettings{})
+	require.NoError(t, err)
+	assert.Nil(t, storage)
+
+	_, err = newObjectStorage(setting.QueryExportSettings{Storage: StorageS3})
+	require.EqualError(t, err, "query export: the s3 bucket is required")
+
+	storage, err = newObjectStorage(setting.QueryExportSettings{Storage: StorageGCS, GCS: setting.QueryExportGCSSettings{Bucket: "exports"}})
+	require.NoError(t, err)
+	assert.IsType(t, &gcsStorage{}, storage)
+
+	_, err = newObjectStorage(setting.QueryExportSettings{Storage: "azure"})
+	require.EqualError(t, err, `query export: unsupported storage "azure"`)
+}
@@ -0,0 +1,99 @@
+package queryexport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"google.golang.org/api/option"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	StorageS3  = "s3"
+	StorageGCS = "gcs"
+)
+
+// ObjectStorage uploads the files of the exports
+type ObjectStorage interface {
+	Upload(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// newObjectStorage returns the storage configured in the query_export section, or nil when there is none
+func newObjectStorage(cfg setting.QueryExportSettings) (ObjectStorage, error) {
+	switch cfg.Storage {
+	case "":
+		return nil, nil
+	case StorageS3:
+		if cfg.S3.Bucket == "" {
+			return nil, fmt.Errorf("query export: the s3 bucket is required")
+		}
+		return &s3Storage{cfg: cfg.S3}, nil
+	case StorageGCS:
+		if cfg.GCS.Bucket == "" {
+			return nil, fmt.Errorf("query export: the gcs bucket is required")
+		}
+		return &gcsStorage{cfg: cfg.GCS}, nil
+	default:
+		return nil, fmt.Errorf("query export: unsupported storage %q", cfg.Storage)
+	}
+}
+
+type s3Storage struct {
+	cfg setting.QueryExportS3Settings
+}
+
+func (s *s3Storage) Upload(ctx context.Context, key string, body []byte, contentType string) error {
+	awsCfg := &aws.Config{
+		Region:           aws.String(s.cfg.Region),
+		Endpoint:         aws.String(s.cfg.Endpoint),
+		S3ForcePathStyle: aws.Bool(s.cfg.PathStyleAccess),
+	}
+	// the default credentials chain of the SDK is used without an access key: environment, shared config and roles
+	if s.cfg.AccessKey != "" {
+		awsCfg.Credentials = credentials.NewStaticCredentials(s.cfg.AccessKey, s.cfg.SecretKey, "")
+	}
+	sess, err := session.NewSession(awsCfg)
+	if err != nil {
+		return err
+	}
+
+	_, err = s3manager.NewUploader(sess).UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(s.cfg.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+type gcsStorage struct {
+	cfg setting.QueryExportGCSSettings
+}
+
+func (s *gcsStorage) Upload(ctx context.Context, key string, body []byte, contentType string) error {
+	// the application default credentials are used without a key file
+	var opts []option.ClientOption
+	if s.cfg.KeyFile != "" {
+		opts = append(opts, option.WithCredentialsFile(s.cfg.KeyFile))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	w := client.Bucket(s.cfg.Bucket).Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := w.Write(body); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
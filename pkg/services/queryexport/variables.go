@@ -0,0 +1,237 @@
+package queryexport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr"
+)
+
+// maxCombinations is the maximum number of combinations of the values of the variables of an export, every
+// combination runs the queries of the export once
+const maxCombinations = 100
+
+var (
+	variableNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// variableRegex matches the $name, ${name} and [[name]] syntaxes of the dashboard variables
+	variableRegex = regexp.MustCompile(`\$(\w+)|\$\{(\w+)\}|\[\[(\w+)\]\]`)
+)
+
+// Variable is a variable of the queries of an export. The export runs once for every combination of the values of
+// its variables, like a dashboard for every value of a repeated variable.
+type Variable struct {
+	Name string
+	// Values are the selected values of the variable
+	Values []string
+	// Query is run before every run of the export to get the values of the variable, when there are no Values. The
+	// values are the distinct values of the first string field of the frames, or of the label when Label is set.
+	Query *Query
+	Label string
+}
+
+func (v Variable) validate() error {
+	if !variableNameRegex.MatchString(v.Name) {
+		return fmt.Errorf("invalid variable name %q", v.Name)
+	}
+	if len(v.Values) > 0 && v.Query != nil {
+		return fmt.Errorf("variable %s: only one of values or query can be set", v.Name)
+	}
+	if len(v.Values) == 0 && v.Query == nil {
+		return fmt.Errorf("variable %s: either values or a query is required", v.Name)
+	}
+	if v.Query != nil {
+		if v.Query.DatasourceUID == "" {
+			return fmt.Errorf("variable %s: query datasource uid is required", v.Name)
+		}
+		if expr.IsDataSource(v.Query.DatasourceUID) {
+			return fmt.Errorf("variable %s: the query can't be an expression", v.Name)
+		}
+	}
+	return nil
+}
+
+// variableValue is the value of a variable in a combination
+type variableValue struct {
+	name  string
+	value string
+}
+
+// combination is a set of values of the variables of an export, in the order of the variables
+type combination []variableValue
+
+// values returns the values of the combination by variable name
+func (c combination) values() map[string]string {
+	values := make(map[string]string, len(c))
+	for _, v := range c {
+		values[v.name] = v.value
+	}
+	return values
+}
+
+// path returns a segment of path for every variable of the combination, so that the files of every combination of a
+// run are stored in their own directory
+func (c combination) path() string {
+	segments := make([]string, 0, len(c))
+	for _, v := range c {
+		segments = append(segments, v.name+"="+url.PathEscape(v.value))
+	}
+	return strings.Join(segments, "/")
+}
+
+func (c combination) String() string {
+	values := make([]string, 0, len(c))
+	for _, v := range c {
+		values = append(values, v.name+"="+v.value)
+	}
+	return strings.Join(values, ",")
+}
+
+// combinations returns every combination of the values of the variables, in the order of the variables and of their
+// values. There is a single empty combination when there are no variables.
+func combinations(variables []Variable, values map[string][]string) ([]combination, error) {
+	result := []combination{{}}
+	for _, v := range variables {
+		if len(values[v.Name]) == 0 {
+			return nil, fmt.Errorf("variable %s has no values", v.Name)
+		}
+		if len(result)*len(values[v.Name]) > maxCombinations {
+			return nil, fmt.Errorf("the variables have more than %d combinations", maxCombinations)
+		}
+		next := make([]combination, 0, len(result)*len(values[v.Name]))
+		for _, c := range result {
+			for _, value := range values[v.Name] {
+				cc := make(combination, len(c), len(c)+1)
+				copy(cc, c)
+				next = append(next, append(cc, variableValue{name: v.Name, value: value}))
+			}
+		}
+		result = next
+	}
+	return result, nil
+}
+
+// interpolate replaces the variables of the models of the queries by their value in the combination. The other
+// variables, like the macros of the datasources or the refIds of the expressions, are left as they are.
+func (e Export) interpolate(c combination) (Export, error) {
+	if len(c) == 0 {
+		return e, nil
+	}
+	values := c.values()
+	queries := make([]Query, 0, len(e.Queries))
+	for _, q := range e.Queries {
+		var err error
+		model := variableRegex.ReplaceAllStringFunc(string(q.Model), func(match string) string {
+			groups := variableRegex.FindStringSubmatch(match)
+			name := groups[1] + groups[2] + groups[3]
+			value, ok := values[name]
+			if !ok {
+				return match
+			}
+			// the value is escaped as the variables are replaced in the JSON of the model
+			encoded, encodeErr := json.Marshal(value)
+			if encodeErr != nil {
+				err = encodeErr
+				return match
+			}
+			return string(encoded[1 : len(encoded)-1])
+		})
+		if err != nil {
+			return Export{}, fmt.Errorf("failed to interpolate the model of query %s: %w", q.RefID, err)
+		}
+		q.Model = json.RawMessage(model)
+		queries = append(queries, q)
+	}
+	e.Queries = queries
+	return e, nil
+}
+
+// variableValues returns the values of the variables of the export, the queries of the variables are run at now
+func variableValues(ctx context.Context, ev evaluator, e Export, now time.Time) (map[string][]string, error) {
+	values := make(map[string][]string, len(e.Variables))
+	for _, v := range e.Variables {
+		if v.Query == nil {
+			values[v.Name] = v.Values
+			continue
+		}
+		q := *v.Query
+		if q.RefID == "" {
+			q.RefID = v.Name
+		}
+		varExport := e
+		varExport.Variables = nil
+		varExport.Queries = []Query{q}
+		resp, err := ev.Evaluate(ctx, varExport, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run the query of variable %s: %w", v.Name, err)
+		}
+		res, ok := resp.Responses[q.RefID]
+		if !ok {
+			return nil, fmt.Errorf("failed to run the query of variable %s: no response", v.Name)
+		}
+		if res.Error != nil {
+			return nil, fmt.Errorf("failed to run the query of variable %s: %w", v.Name, res.Error)
+		}
+		values[v.Name] = valuesFromFrames(res.Frames, v.Label)
+	}
+	return values, nil
+}
+
+// valuesFromFrames returns the sorted distinct values of the label of the fields of the frames, or of their first
+// string field when the label is empty
+func valuesFromFrames(frames data.Frames, label string) []string {
+	distinct := map[string]struct{}{}
+	for _, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		for _, field := range frame.Fields {
+			if label != "" {
+				if value, ok := field.Labels[label]; ok {
+					distinct[value] = struct{}{}
+				}
+				continue
+			}
+			if field.Type() != data.FieldTypeString && field.Type() != data.FieldTypeNullableString {
+				continue
+			}
+			for i := 0; i < field.Len(); i++ {
+				value, ok := field.ConcreteAt(i)
+				if !ok {
+					continue
+				}
+				distinct[value.(string)] = struct{}{}
+			}
+			break
+		}
+	}
+	values := make([]string, 0, len(distinct))
+	for value := range distinct {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// checkCombinations checks that the variables with fixed values do not have too many combinations, the values of the
+// variables with a query are checked at every run
+func checkCombinations(variables []Variable) error {
+	count := 1
+	for _, v := range variables {
+		if v.Query != nil {
+			continue
+		}
+		count *= len(v.Values)
+		if count > maxCombinations {
+			return fmt.Errorf("the variables have more than %d combinations", maxCombinations)
+		}
+	}
+	return nil
+}
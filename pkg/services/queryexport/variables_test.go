@@ -0,0 +1,138 @@
+package queryexport
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestCombinations(t *testing.T) {
+	variables := []Variable{{Name: "env"}, {Name: "region"}}
+
+	combs, err := combinations(variables, map[string][]string{"env": {"dev", "prod"}, "region": {"eu", "us/east"}})
+	require.NoError(t, err)
+	paths := make([]string, 0, len(combs))
+	for _, c := range combs {
+		paths = append(paths, c.path())
+	}
+	assert.Equal(t, []string{"env=dev/region=eu", "env=dev/region=us%2Feast", "env=prod/region=eu", "env=prod/region=us%2Feast"}, paths)
+	assert.Equal(t, map[string]string{"env": "dev", "region": "us/east"}, combs[1].values())
+
+	combs, err = combinations(nil, nil)
+	require.NoError(t, err)
+	require.Len(t, combs, 1)
+	assert.Empty(t, combs[0])
+
+	_, err = combinations(variables, map[string][]string{"env": {"dev"}})
+	require.EqualError(t, err, "variable region has no values")
+
+	many := make([]string, 11)
+	_, err = combinations(variables, map[string][]string{"env": many, "region": many})
+	require.EqualError(t, err, "the variables have more than 100 combinations")
+}
+
+func TestInterpolate(t *testing.T) {
+	e := testExport()
+	e.Queries[0].Model = json.RawMessage(`{"expr": "rate(requests{env=\"$env\", region=~\"${region}\", job=\"[[job]]\"}[$__rate_interval])", "intervalMs": 1000}`)
+	c := combination{{name: "env", value: "prod"}, {name: "region", value: `eu"west`}}
+
+	interpolated, err := e.interpolate(c)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"expr": "rate(requests{env=\"prod\", region=~\"eu\"west\", job=\"[[job]]\"}[$__rate_interval])", "intervalMs": 1000}`, string(interpolated.Queries[0].Model))
+	// the refIds of the expressions are not replaced
+	assert.Equal(t, e.Queries[1].Model, interpolated.Queries[1].Model)
+	// the export is not modified
+	assert.Contains(t, string(e.Queries[0].Model), "$env")
+}
+
+func TestValuesFromFrames(t *testing.T) {
+	frames := data.Frames{
+		data.NewFrame("", data.NewField("time", nil, []time.Time{{}}), data.NewField("env", nil, []string{"prod", "dev"})),
+		data.NewFrame("", data.NewField("env", nil, []*string{nil, stringPtr("prod"), stringPtr("staging")})),
+		data.NewFrame("", data.NewField("value", data.Labels{"env": "qa"}, []float64{1})),
+	}
+	assert.Equal(t, []string{"dev", "prod", "staging"}, valuesFromFrames(frames, ""))
+	assert.Equal(t, []string{"qa"}, valuesFromFrames(frames, "env"))
+	assert.Empty(t, valuesFromFrames(nil, ""))
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// recordingEvaluator returns the responses of the queries by refId and records the models of the queries run
+type recordingEvaluator struct {
+	mu        sync.Mutex
+	responses map[string]backend.DataResponse
+	models    []string
+}
+
+func (e *recordingEvaluator) Evaluate(_ context.Context, export Export, _ time.Time) (*backend.QueryDataResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	resp := backend.NewQueryDataResponse()
+	for _, q := range export.Queries {
+		e.models = append(e.models, string(q.Model))
+		resp.Responses[q.RefID] = e.responses[q.RefID]
+	}
+	return resp, nil
+}
+
+func TestServiceVariables(t *testing.T) {
+	e := testExport()
+	e.Targets = []string{"A"}
+	e.Queries[0].Model = json.RawMessage(`{"expr": "rate(requests{env=\"$env\"}[5m])"}`)
+	e.Variables = []Variable{
+		{Name: "env", Query: &Query{DatasourceUID: "prom", Model: json.RawMessage(`{"expr": "group by (env) (up)"}`)}, Label: "env"},
+		{Name: "tier", Values: []string{"web"}},
+	}
+	require.NoError(t, e.Validate())
+
+	evaluator := &recordingEvaluator{responses: map[string]backend.DataResponse{
+		"env": {Frames: data.Frames{
+			data.NewFrame("", data.NewField("value", data.Labels{"env": "prod"}, []float64{1})),
+			data.NewFrame("", data.NewField("value", data.Labels{"env": "dev"}, []float64{1})),
+		}},
+		"A": {Frames: data.Frames{data.NewFrame("", data.NewField("value", nil, []float64{3}))}},
+	}}
+	storage := &fakeStorage{}
+	s := newService(setting.QueryExportSettings{}, clock.NewMock(), evaluator, storage, log.NewNopLogger())
+
+	t.Run("uploads the results of every combination of the values", func(t *testing.T) {
+		tmpl, err := parsePath("")
+		require.NoError(t, err)
+		s.run(context.Background(), exportRun{export: e, path: tmpl, at: time.Unix(0, 0).UTC()})
+
+		assert.Equal(t, map[string]string{
+			"1/exp/1970-01-01T00-00-00Z/env=dev/tier=web/A.csv":  "value\n3\n",
+			"1/exp/1970-01-01T00-00-00Z/env=prod/tier=web/A.csv": "value\n3\n",
+		}, storage.uploads)
+		// the query of the variable runs first, then the queries of every combination
+		assert.Equal(t, []string{
+			`{"expr": "group by (env) (up)"}`,
+			`{"expr": "rate(requests{env=\"dev\"}[5m])"}`,
+			string(e.Queries[1].Model),
+			`{"expr": "rate(requests{env=\"prod\"}[5m])"}`,
+			string(e.Queries[1].Model),
+		}, evaluator.models)
+	})
+
+	t.Run("the values are available to the path template", func(t *testing.T) {
+		storage.uploads = nil
+		tmpl, err := parsePath(`{{.Variables.env}}/{{.Variables.tier}}-{{.RefID}}.{{.Ext}}`)
+		require.NoError(t, err)
+		s.run(context.Background(), exportRun{export: e, path: tmpl, at: time.Unix(0, 0).UTC()})
+		assert.Equal(t, map[string]string{"dev/web-A.csv": "value\n3\n", "prod/web-A.csv": "value\n3\n"}, storage.uploads)
+	})
+}
@@ -436,3 +436,25 @@ func (s QueryHistoryService) enforceQueryHistoryRowLimit(ctx context.Context, li
 
 	return int(deletedRowsCount), nil
 }
+
+// getRecentQueries lists the recent queries of a data source in an organization with the login of their user
+func (s QueryHistoryService) getRecentQueries(ctx context.Context, query GetRecentQueriesQuery) ([]RecentQuery, error) {
+	if query.Limit <= 0 {
+		query.Limit = 1000
+	}
+
+	var queries []RecentQuery
+	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
+		sql := `SELECT
+			u.login AS created_by_login,
+			query_history.created_at,
+			query_history.queries
+			FROM query_history
+			LEFT JOIN ` + s.store.GetDialect().Quote("user") + ` AS u ON u.id = query_history.created_by
+			WHERE query_history.org_id = ? AND query_history.datasource_uid = ? AND query_history.created_at >= ?
+			ORDER BY query_history.created_at DESC
+			` + s.store.GetDialect().Limit(int64(query.Limit))
+		return session.SQL(sql, query.OrgID, query.DatasourceUID, query.From).Find(&queries)
+	})
+	return queries, err
+}
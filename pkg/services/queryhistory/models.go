@@ -42,6 +42,22 @@ type SearchInQueryHistoryQuery struct {
 	To             int64    `json:"to"`
 }
 
+// GetRecentQueriesQuery lists the queries of a data source run by the users of an organization since From, in unix
+// seconds, the most recent first
+type GetRecentQueriesQuery struct {
+	OrgID         int64
+	DatasourceUID string
+	From          int64
+	Limit         int
+}
+
+// RecentQuery is a query of the query history with the login of the user who ran it
+type RecentQuery struct {
+	CreatedByLogin string           `xorm:"created_by_login"`
+	CreatedAt      int64            `xorm:"created_at"`
+	Queries        *simplejson.Json `xorm:"queries"`
+}
+
 type QueryHistoryDTO struct {
 	UID           string           `json:"uid" xorm:"uid"`
 	DatasourceUID string           `json:"datasourceUid" xorm:"datasource_uid"`
@@ -36,6 +36,7 @@ type Service interface {
 	MigrateQueriesToQueryHistory(ctx context.Context, user *user.SignedInUser, cmd MigrateQueriesToQueryHistoryCommand) (int, int, error)
 	DeleteStaleQueriesInQueryHistory(ctx context.Context, olderThan int64) (int, error)
 	EnforceRowLimitInQueryHistory(ctx context.Context, limit int, starredQueries bool) (int, error)
+	GetRecentQueries(ctx context.Context, query GetRecentQueriesQuery) ([]RecentQuery, error)
 }
 
 type QueryHistoryService struct {
@@ -80,3 +81,10 @@ func (s QueryHistoryService) DeleteStaleQueriesInQueryHistory(ctx context.Contex
 func (s QueryHistoryService) EnforceRowLimitInQueryHistory(ctx context.Context, limit int, starredQueries bool) (int, error) {
 	return s.enforceQueryHistoryRowLimit(ctx, limit, starredQueries)
 }
+
+func (s QueryHistoryService) GetRecentQueries(ctx context.Context, query GetRecentQueriesQuery) ([]RecentQuery, error) {
+	if !s.Cfg.QueryHistoryEnabled {
+		return nil, nil
+	}
+	return s.getRecentQueries(ctx, query)
+}
@@ -0,0 +1,46 @@
+package queryhistory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationGetRecentQueries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	testScenarioWithMultipleQueriesInQueryHistory(t, "When the recent queries of a data source are listed, it should return them with the login of their user",
+		func(t *testing.T, sc scenarioContext) {
+			queries, err := sc.service.GetRecentQueries(context.Background(), GetRecentQueriesQuery{
+				OrgID:         testOrgID,
+				DatasourceUID: testDsUID1,
+			})
+			require.NoError(t, err)
+			require.Len(t, queries, 2)
+			require.Equal(t, "signed_in_user", queries[0].CreatedByLogin)
+			require.Equal(t, "test2", queries[0].Queries.Get("expr").MustString())
+			require.Equal(t, "test", queries[1].Queries.Get("expr").MustString())
+
+			queries, err = sc.service.GetRecentQueries(context.Background(), GetRecentQueriesQuery{
+				OrgID:         testOrgID,
+				DatasourceUID: testDsUID1,
+				From:          time.Now().Add(time.Hour).Unix(),
+			})
+			require.NoError(t, err)
+			require.Empty(t, queries)
+		})
+
+	testScenarioWithQueryInQueryHistory(t, "When query history is disabled, it should return no recent queries",
+		func(t *testing.T, sc scenarioContext) {
+			sc.service.Cfg.QueryHistoryEnabled = false
+			queries, err := sc.service.GetRecentQueries(context.Background(), GetRecentQueriesQuery{
+				OrgID:         testOrgID,
+				DatasourceUID: testDsUID1,
+			})
+			require.NoError(t, err)
+			require.Empty(t, queries)
+		})
+}
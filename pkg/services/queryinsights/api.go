@@ -0,0 +1,51 @@
+package queryinsights
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+)
+
+func (s *QueryInsightsService) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/query-insights", func(entities routing.RouteRegister) {
+		entities.Get("/datasources", middleware.ReqOrgAdmin, routing.Wrap(s.getDatasourceInsightsHandler))
+	})
+}
+
+// swagger:route GET /query-insights/datasources query_insights getDatasourceInsights
+//
+// Get the query statistics of the datasources.
+//
+// Returns the number of queries, the error rate and the latency percentiles of every datasource of the organization,
+// with the dashboards that spent the most time querying it. The statistics are kept in memory by every Grafana instance
+// since it started. Use the `limit` parameter to control the number of dashboards returned for every datasource; the
+// default limit is 10.
+//
+// Responses:
+// 200: getDatasourceInsightsResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+func (s *QueryInsightsService) getDatasourceInsightsHandler(c *contextmodel.ReqContext) response.Response {
+	limit := c.QueryInt("limit")
+	return response.JSON(http.StatusOK, DatasourceInsightsResponse{
+		Since:       s.started,
+		Datasources: s.GetDatasourceInsights(c.OrgID, limit),
+	})
+}
+
+// swagger:parameters getDatasourceInsights
+type GetDatasourceInsightsParams struct {
+	// Limit the number of dashboards returned for every datasource
+	// in:query
+	// required:false
+	Limit int `json:"limit"`
+}
+
+// swagger:response getDatasourceInsightsResponse
+type GetDatasourceInsightsResponse struct {
+	// in: body
+	Body DatasourceInsightsResponse `json:"body"`
+}
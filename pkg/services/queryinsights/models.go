@@ -0,0 +1,40 @@
+package queryinsights
+
+import "time"
+
+// DatasourceInsights are the query statistics of a datasource since the Grafana instance started
+type DatasourceInsights struct {
+	UID           string              `json:"uid"`
+	Name          string              `json:"name"`
+	Type          string              `json:"type"`
+	Queries       int64               `json:"queries"`
+	Errors        int64               `json:"errors"`
+	ErrorRate     float64             `json:"errorRate"`
+	LatencyP50Ms  float64             `json:"latencyP50Ms"`
+	LatencyP90Ms  float64             `json:"latencyP90Ms"`
+	LatencyP99Ms  float64             `json:"latencyP99Ms"`
+	TotalTimeMs   float64             `json:"totalTimeMs"`
+	TopDashboards []DashboardInsights `json:"topDashboards"`
+}
+
+// DashboardInsights are the query statistics of a dashboard for one datasource
+type DashboardInsights struct {
+	UID         string  `json:"uid"`
+	Queries     int64   `json:"queries"`
+	Errors      int64   `json:"errors"`
+	TotalTimeMs float64 `json:"totalTimeMs"`
+}
+
+// DatasourceInsightsResponse is returned by the datasource insights API
+type DatasourceInsightsResponse struct {
+	Since       time.Time            `json:"since"`
+	Datasources []DatasourceInsights `json:"datasources"`
+}
+
+// DatasourceTypeStats are the query statistics of all the datasources of a type
+type DatasourceTypeStats struct {
+	Queries      int64
+	Errors       int64
+	LatencyP50Ms float64
+	LatencyP99Ms float64
+}
@@ -0,0 +1,292 @@
+package queryinsights
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	// DefaultTopDashboards is the number of dashboards returned for every datasource when no limit is requested
+	DefaultTopDashboards = 10
+
+	// dashboards above this number are not tracked for a datasource, so a lot of different dashboard UIDs do not grow
+	// the memory used by the stats
+	maxDashboardsPerDatasource = 1000
+)
+
+// latencyBuckets are the upper bounds in milliseconds of the histogram used to compute the latency percentiles.
+var latencyBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+// Query is a request sent to a datasource
+type Query struct {
+	OrgID          int64
+	DatasourceUID  string
+	DatasourceName string
+	DatasourceType string
+	// DashboardUID is empty when the query was not sent by a dashboard panel
+	DashboardUID string
+	Duration     time.Duration
+	Failed       bool
+}
+
+type Service interface {
+	// Record adds a datasource query to the statistics
+	Record(query Query)
+	// GetDatasourceInsights returns the statistics of the datasources of the organization, with the dashboards that
+	// spent the most time querying every datasource first
+	GetDatasourceInsights(orgID int64, topDashboards int) []DatasourceInsights
+	// GetDatasourceTypeStats returns the statistics of all organizations by datasource type, for the usage stats
+	GetDatasourceTypeStats() map[string]DatasourceTypeStats
+}
+
+type latencyHistogram struct {
+	// counts has one more item than latencyBuckets for the durations above the last bucket
+	counts []int64
+	total  int64
+	max    float64
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{counts: make([]int64, len(latencyBuckets)+1)}
+}
+
+func (h *latencyHistogram) observe(ms float64) {
+	i := sort.SearchFloat64s(latencyBuckets, ms)
+	h.counts[i]++
+	h.total++
+	if ms > h.max {
+		h.max = ms
+	}
+}
+
+func (h *latencyHistogram) merge(other *latencyHistogram) {
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.total += other.total
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// percentile estimates the p quantile by interpolating linearly inside the bucket holding it
+func (h *latencyHistogram) percentile(p float64) float64 {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := p * float64(h.total)
+	var cumulative int64
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		if float64(cumulative+c) >= rank {
+			lower := 0.0
+			if i > 0 {
+				lower = latencyBuckets[i-1]
+			}
+			upper := h.max
+			if i < len(latencyBuckets) && latencyBuckets[i] < upper {
+				upper = latencyBuckets[i]
+			}
+			if upper < lower {
+				return upper
+			}
+			return lower + (upper-lower)*(rank-float64(cumulative))/float64(c)
+		}
+		cumulative += c
+	}
+	return h.max
+}
+
+type queryCounts struct {
+	queries     int64
+	errors      int64
+	totalTimeMs float64
+}
+
+func (c *queryCounts) add(ms float64, failed bool) {
+	c.queries++
+	c.totalTimeMs += ms
+	if failed {
+		c.errors++
+	}
+}
+
+type datasourceStats struct {
+	name       string
+	dsType     string
+	counts     queryCounts
+	latency    *latencyHistogram
+	dashboards map[string]*queryCounts
+}
+
+// QueryInsightsService keeps the query statistics in memory, so every Grafana instance only reports the queries it
+// executed since it started
+type QueryInsightsService struct {
+	RouteRegister routing.RouteRegister
+	log           log.Logger
+	started       time.Time
+
+	mutex sync.Mutex
+	orgs  map[int64]map[string]*datasourceStats
+}
+
+var _ Service = (*QueryInsightsService)(nil)
+
+func ProvideService(routeRegister routing.RouteRegister) *QueryInsightsService {
+	s := newService()
+	s.RouteRegister = routeRegister
+	s.registerAPIEndpoints()
+	return s
+}
+
+func newService() *QueryInsightsService {
+	return &QueryInsightsService{
+		log:     log.New("query-insights"),
+		started: time.Now(),
+		orgs:    make(map[int64]map[string]*datasourceStats),
+	}
+}
+
+func (s *QueryInsightsService) Record(query Query) {
+	if query.DatasourceUID == "" {
+		return
+	}
+	ms := float64(query.Duration) / float64(time.Millisecond)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	datasources, ok := s.orgs[query.OrgID]
+	if !ok {
+		datasources = make(map[string]*datasourceStats)
+		s.orgs[query.OrgID] = datasources
+	}
+	ds, ok := datasources[query.DatasourceUID]
+	if !ok {
+		ds = &datasourceStats{
+			latency:    newLatencyHistogram(),
+			dashboards: make(map[string]*queryCounts),
+		}
+		datasources[query.DatasourceUID] = ds
+	}
+	// the datasource may have been renamed since the previous query
+	ds.name = query.DatasourceName
+	ds.dsType = query.DatasourceType
+	ds.counts.add(ms, query.Failed)
+	ds.latency.observe(ms)
+
+	if query.DashboardUID == "" {
+		return
+	}
+	dash, ok := ds.dashboards[query.DashboardUID]
+	if !ok {
+		if len(ds.dashboards) >= maxDashboardsPerDatasource {
+			return
+		}
+		dash = &queryCounts{}
+		ds.dashboards[query.DashboardUID] = dash
+	}
+	dash.add(ms, query.Failed)
+}
+
+func (s *QueryInsightsService) GetDatasourceInsights(orgID int64, topDashboards int) []DatasourceInsights {
+	if topDashboards <= 0 {
+		topDashboards = DefaultTopDashboards
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := make([]DatasourceInsights, 0, len(s.orgs[orgID]))
+	for uid, ds := range s.orgs[orgID] {
+		insights := DatasourceInsights{
+			UID:           uid,
+			Name:          ds.name,
+			Type:          ds.dsType,
+			Queries:       ds.counts.queries,
+			Errors:        ds.counts.errors,
+			ErrorRate:     errorRate(ds.counts),
+			LatencyP50Ms:  ds.latency.percentile(0.5),
+			LatencyP90Ms:  ds.latency.percentile(0.9),
+			LatencyP99Ms:  ds.latency.percentile(0.99),
+			TotalTimeMs:   ds.counts.totalTimeMs,
+			TopDashboards: make([]DashboardInsights, 0, len(ds.dashboards)),
+		}
+		for dashUID, dash := range ds.dashboards {
+			insights.TopDashboards = append(insights.TopDashboards, DashboardInsights{
+				UID:         dashUID,
+				Queries:     dash.queries,
+				Errors:      dash.errors,
+				TotalTimeMs: dash.totalTimeMs,
+			})
+		}
+		sort.Slice(insights.TopDashboards, func(i, j int) bool {
+			a, b := insights.TopDashboards[i], insights.TopDashboards[j]
+			if a.TotalTimeMs != b.TotalTimeMs {
+				return a.TotalTimeMs > b.TotalTimeMs
+			}
+			return a.UID < b.UID
+		})
+		if len(insights.TopDashboards) > topDashboards {
+			insights.TopDashboards = insights.TopDashboards[:topDashboards]
+		}
+		result = append(result, insights)
+	}
+
+	// the most expensive datasources first
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalTimeMs != result[j].TotalTimeMs {
+			return result[i].TotalTimeMs > result[j].TotalTimeMs
+		}
+		return result[i].UID < result[j].UID
+	})
+	return result
+}
+
+func errorRate(c queryCounts) float64 {
+	if c.queries == 0 {
+		return 0
+	}
+	return float64(c.errors) / float64(c.queries)
+}
+
+func (s *QueryInsightsService) GetDatasourceTypeStats() map[string]DatasourceTypeStats {
+	type typeStats struct {
+		counts  queryCounts
+		latency *latencyHistogram
+	}
+	byType := make(map[string]*typeStats)
+
+	s.mutex.Lock()
+	for _, datasources := range s.orgs {
+		for _, ds := range datasources {
+			stats, ok := byType[ds.dsType]
+			if !ok {
+				stats = &typeStats{latency: newLatencyHistogram()}
+				byType[ds.dsType] = stats
+			}
+			stats.counts.queries += ds.counts.queries
+			stats.counts.errors += ds.counts.errors
+			stats.latency.merge(ds.latency)
+		}
+	}
+	s.mutex.Unlock()
+
+	result := make(map[string]DatasourceTypeStats, len(byType))
+	for dsType, stats := range byType {
+		result[dsType] = DatasourceTypeStats{
+			Queries:      stats.counts.queries,
+			Errors:       stats.counts.errors,
+			LatencyP50Ms: stats.latency.percentile(0.5),
+			LatencyP99Ms: stats.latency.percentile(0.99),
+		}
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package queryinsights
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryInsights(t *testing.T) {
+	t.Run("should compute the statistics of the datasources of the organization", func(t *testing.T) {
+		s := newService()
+		for i := 1; i <= 100; i++ {
+			s.Record(Query{
+				OrgID:          1,
+				DatasourceUID:  "prom",
+				DatasourceName: "Prometheus",
+				DatasourceType: "prometheus",
+				DashboardUID:   "dash-a",
+				Duration:       time.Duration(i) * time.Millisecond,
+				Failed:         i%10 == 0,
+			})
+		}
+		s.Record(Query{OrgID: 1, DatasourceUID: "loki", DatasourceType: "loki", DashboardUID: "dash-b", Duration: time.Millisecond})
+		s.Record(Query{OrgID: 2, DatasourceUID: "other-org", DatasourceType: "loki", Duration: time.Hour})
+
+		insights := s.GetDatasourceInsights(1, 0)
+		require.Len(t, insights, 2)
+
+		prom := insights[0]
+		require.Equal(t, "prom", prom.UID)
+		require.Equal(t, "Prometheus", prom.Name)
+		require.Equal(t, int64(100), prom.Queries)
+		require.Equal(t, int64(10), prom.Errors)
+		require.InDelta(t, 0.1, prom.ErrorRate, 0.0001)
+		require.InDelta(t, 5050, prom.TotalTimeMs, 0.0001)
+		require.InDelta(t, 50, prom.LatencyP50Ms, 0.0001)
+		require.InDelta(t, 90, prom.LatencyP90Ms, 0.0001)
+		require.InDelta(t, 99, prom.LatencyP99Ms, 0.0001)
+		require.Equal(t, []DashboardInsights{{UID: "dash-a", Queries: 100, Errors: 10, TotalTimeMs: prom.TotalTimeMs}}, prom.TopDashboards)
+
+		require.Equal(t, "loki", insights[1].UID)
+	})
+
+	t.Run("should return the dashboards that spent the most time first", func(t *testing.T) {
+		s := newService()
+		s.Record(Query{OrgID: 1, DatasourceUID: "ds", DashboardUID: "cheap", Duration: time.Millisecond})
+		s.Record(Query{OrgID: 1, DatasourceUID: "ds", DashboardUID: "expensive", Duration: time.Second})
+		s.Record(Query{OrgID: 1, DatasourceUID: "ds", DashboardUID: "medium", Duration: 100 * time.Millisecond})
+		s.Record(Query{OrgID: 1, DatasourceUID: "ds"})
+
+		insights := s.GetDatasourceInsights(1, 2)
+		require.Len(t, insights, 1)
+		require.Equal(t, int64(4), insights[0].Queries)
+		require.Len(t, insights[0].TopDashboards, 2)
+		require.Equal(t, "expensive", insights[0].TopDashboards[0].UID)
+		require.Equal(t, "medium", insights[0].TopDashboards[1].UID)
+	})
+
+	t.Run("should not estimate latencies above the slowest query", func(t *testing.T) {
+		s := newService()
+		s.Record(Query{OrgID: 1, DatasourceUID: "ds", Duration: 2 * time.Minute})
+
+		insights := s.GetDatasourceInsights(1, 0)
+		require.Greater(t, insights[0].LatencyP99Ms, 60000.0)
+		require.LessOrEqual(t, insights[0].LatencyP99Ms, 120000.0)
+	})
+
+	t.Run("should aggregate the statistics by datasource type", func(t *testing.T) {
+		s := newService()
+		s.Record(Query{OrgID: 1, DatasourceUID: "a", DatasourceType: "loki", Duration: 10 * time.Millisecond})
+		s.Record(Query{OrgID: 2, DatasourceUID: "b", DatasourceType: "loki", Duration: 30 * time.Millisecond, Failed: true})
+
+		stats := s.GetDatasourceTypeStats()
+		require.Equal(t, int64(2), stats["loki"].Queries)
+		require.Equal(t, int64(1), stats["loki"].Errors)
+		require.InDelta(t, 10, stats["loki"].LatencyP50Ms, 0.0001)
+	})
+}
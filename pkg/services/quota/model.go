@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
@@ -215,4 +216,7 @@ type NewUsageReporter struct {
 	TargetSrv     TargetSrv
 	DefaultLimits *Map
 	Reporter      UsageReporterFunc
+	// ReadDefaultLimits reads the default limits from the settings again when the quota settings are reloaded,
+	// the default limits are not reloaded when it is not set
+	ReadDefaultLimits func(cfg *setting.Cfg) (*Map, error)
 }
@@ -19,6 +19,9 @@ type Service interface {
 	QuotaReached(c *contextmodel.ReqContext, targetSrv TargetSrv) (bool, error)
 	// CheckQuotaReached checks if the quota limitations have been reached for a specific service
 	CheckQuotaReached(ctx context.Context, targetSrv TargetSrv, scopeParams *ScopeParameters) (bool, error)
+	// GetLimits returns the limits of the targets of a specific service, custom limits override the default ones.
+	// Services checking their usage themselves can use it instead of CheckQuotaReached.
+	GetLimits(ctx context.Context, targetSrv TargetSrv, scopeParams *ScopeParameters) (*Map, error)
 	// DeleteQuotaForUser deletes custom quota limitations for the user
 	DeleteQuotaForUser(ctx context.Context, userID int64) error
 	// DeleteByOrg(ctx context.Context, orgID int64) error
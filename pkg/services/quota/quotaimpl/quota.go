@@ -32,6 +32,10 @@ func (s *serviceDisabled) CheckQuotaReached(ctx context.Context, targetSrv quota
 	return false, nil
 }
 
+func (s *serviceDisabled) GetLimits(ctx context.Context, targetSrv quota.TargetSrv, scopeParams *quota.ScopeParameters) (*quota.Map, error) {
+	return &quota.Map{}, nil
+}
+
 func (s *serviceDisabled) DeleteQuotaForUser(ctx context.Context, userID int64) error {
 	return nil
 }
@@ -49,6 +53,8 @@ type service struct {
 	reporters map[quota.TargetSrv]quota.UsageReporterFunc
 
 	defaultLimits *quota.Map
+	// limitReaders read the default limits of the reporters again when the quota settings are reloaded
+	limitReaders []func(cfg *setting.Cfg) (*quota.Map, error)
 
 	targetToSrv *quota.TargetToSrv
 }
@@ -68,6 +74,7 @@ func ProvideService(db db.DB, cfg *setting.Cfg) quota.Service {
 		return &serviceDisabled{}
 	}
 
+	cfg.OnReload("quota", s.reloadDefaultLimits)
 	return &s
 }
 
@@ -232,6 +239,19 @@ func (s *service) CheckQuotaReached(ctx context.Context, targetSrv quota.TargetS
 	return false, nil
 }
 
+func (s *service) GetLimits(ctx context.Context, targetSrv quota.TargetSrv, scopeParams *quota.ScopeParameters) (*quota.Map, error) {
+	targetSrvLimits, err := s.getOverridenLimits(ctx, targetSrv, scopeParams)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := &quota.Map{}
+	for t, limit := range targetSrvLimits {
+		limits.Set(t, limit)
+	}
+	return limits, nil
+}
+
 func (s *service) DeleteQuotaForUser(ctx context.Context, userID int64) error {
 	c, err := s.getContext(ctx)
 	if err != nil {
@@ -264,6 +284,27 @@ func (s *service) RegisterQuotaReporter(e *quota.NewUsageReporter) error {
 		s.defaultLimits.Set(item.Tag, item.Value)
 	}
 
+	if e.ReadDefaultLimits != nil {
+		s.limitReaders = append(s.limitReaders, e.ReadDefaultLimits)
+	}
+
+	return nil
+}
+
+// reloadDefaultLimits applies the default limits of the reloaded quota settings, the custom limits of the
+// organizations and users are kept
+func (s *service) reloadDefaultLimits() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, read := range s.limitReaders {
+		limits, err := read(s.Cfg)
+		if err != nil {
+			return err
+		}
+		s.defaultLimits.Merge(limits)
+	}
+
 	return nil
 }
 
@@ -57,6 +57,35 @@ func TestQuotaService(t *testing.T) {
 	})
 }
 
+func TestReloadDefaultLimits(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.Quota.Enabled = true
+	cfg.Quota.Global.User = 10
+	quotaService := ProvideService(&sqlstore.SQLStore{}, cfg).(*service)
+
+	tag, err := quota.NewTag(quota.TargetSrv(user.QuotaTargetSrv), quota.Target(user.QuotaTarget), quota.GlobalScope)
+	require.NoError(t, err)
+	readLimits := func(cfg *setting.Cfg) (*quota.Map, error) {
+		limits := &quota.Map{}
+		limits.Set(tag, cfg.Quota.Global.User)
+		return limits, nil
+	}
+	limits, err := readLimits(cfg)
+	require.NoError(t, err)
+	require.NoError(t, quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
+		TargetSrv:         quota.TargetSrv(user.QuotaTargetSrv),
+		DefaultLimits:     limits,
+		Reporter:          func(context.Context, *quota.ScopeParameters) (*quota.Map, error) { return &quota.Map{}, nil },
+		ReadDefaultLimits: readLimits,
+	}))
+
+	cfg.Quota.Global.User = 20
+	require.NoError(t, quotaService.reloadDefaultLimits())
+	limit, ok := quotaService.defaultLimits.Get(tag)
+	require.True(t, ok)
+	require.Equal(t, int64(20), limit)
+}
+
 func TestIntegrationQuotaCommandsAndQueries(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -479,7 +508,7 @@ func setupEnv(t *testing.T, sqlStore *sqlstore.SQLStore, b bus.Bus, quotaService
 	m := metrics.NewNGAlert(prometheus.NewRegistry())
 	_, err = ngalert.ProvideService(
 		sqlStore.Cfg, featuremgmt.WithFeatures(), nil, nil, routing.NewRouteRegister(), sqlStore, nil, nil, nil, quotaService,
-		secretsService, nil, m, &foldertest.FakeService{}, &acmock.Mock{}, &dashboards.FakeDashboardService{}, nil, b, &acmock.Mock{}, annotationstest.NewFakeAnnotationsRepo(), &plugins.FakePluginStore{}, tracer,
+		secretsService, nil, m, &foldertest.FakeService{}, &acmock.Mock{}, &dashboards.FakeDashboardService{}, nil, nil, b, &acmock.Mock{}, annotationstest.NewFakeAnnotationsRepo(), &plugins.FakePluginStore{}, tracer,
 	)
 	require.NoError(t, err)
 	_, err = storesrv.ProvideService(sqlStore, featuremgmt.WithFeatures(), sqlStore.Cfg, quotaService, storesrv.ProvideSystemUsersService())
@@ -32,6 +32,10 @@ func (f *FakeQuotaService) CheckQuotaReached(c context.Context, target quota.Tar
 	return f.reached, f.err
 }
 
+func (f *FakeQuotaService) GetLimits(c context.Context, target quota.TargetSrv, params *quota.ScopeParameters) (*quota.Map, error) {
+	return &quota.Map{}, f.err
+}
+
 func (f *FakeQuotaService) DeleteQuotaForUser(c context.Context, userID int64) error {
 	return f.err
 }
@@ -0,0 +1,79 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// graphiteWriter sends the series to Graphite with the plaintext protocol, the labels of the series are written as
+// the tags of the metrics
+type graphiteWriter struct {
+	address string
+	prefix  string
+	timeout time.Duration
+}
+
+func newGraphiteWriter(cfg setting.RecordedQueriesSettings) (*graphiteWriter, error) {
+	if cfg.GraphiteAddress == "" {
+		return nil, errors.New("graphite_address is required to write the recorded queries to Graphite")
+	}
+	return &graphiteWriter{address: cfg.GraphiteAddress, prefix: cfg.GraphitePrefix, timeout: cfg.GraphiteTimeout}, nil
+}
+
+func (w *graphiteWriter) Write(ctx context.Context, series []prompb.TimeSeries) error {
+	if len(series) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, s := range series {
+		path := graphitePath(w.prefix, s.Labels)
+		for _, sample := range s.Samples {
+			buf.WriteString(path + " " + strconv.FormatFloat(sample.Value, 'f', -1, 64) + " " + strconv.FormatInt(sample.Timestamp/1000, 10) + "\n")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: w.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", w.address)
+	if err != nil {
+		return fmt.Errorf("error connecting to Graphite: %w", err)
+	}
+	if w.timeout > 0 {
+		if err := conn.SetWriteDeadline(time.Now().Add(w.timeout)); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("error sending the metrics to Graphite: %w", err)
+	}
+	return conn.Close()
+}
+
+// graphitePath returns the name of the metric followed by its tags, like name;tag1=value1;tag2=value2. The
+// characters the plaintext protocol doesn't accept in the tags are replaced by underscores.
+func graphitePath(prefix string, labels []prompb.Label) string {
+	name, _ := labelValue(labels, model.MetricNameLabel)
+	path := graphiteTagReplacer.Replace(prefix + name)
+	for _, l := range labels {
+		if l.Name == model.MetricNameLabel || l.Value == "" {
+			continue
+		}
+		path += ";" + graphiteTagReplacer.Replace(l.Name) + "=" + strings.TrimLeft(graphiteTagReplacer.Replace(l.Value), "~")
+	}
+	return path
+}
+
+var graphiteTagReplacer = strings.NewReplacer(" ", "_", ";", "_", "=", "_", "!", "_", "^", "_", "\n", "_")
@@ -0,0 +1,48 @@
+package recording
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGraphiteWriter(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	w, err := newGraphiteWriter(setting.RecordedQueriesSettings{GraphiteAddress: listener.Addr().String(), GraphitePrefix: "grafana.", GraphiteTimeout: time.Second})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(context.Background(), []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "requests"}, {Name: "empty", Value: ""}, {Name: "job", Value: "api server;1"}},
+		Samples: []prompb.Sample{{Value: 42.5, Timestamp: 1500}},
+	}}))
+
+	var received []string
+	for line := range lines {
+		received = append(received, line)
+	}
+	assert.Equal(t, []string{"grafana.requests;job=api_server_1 42.5 1"}, received)
+}
@@ -0,0 +1,109 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// influxWriter writes the series to InfluxDB in the line protocol, the name of the metric is the measurement, the
+// labels are the tags and the samples are written in the value field
+type influxWriter struct {
+	url      string
+	token    string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newInfluxWriter(cfg setting.RecordedQueriesSettings) (*influxWriter, error) {
+	if cfg.InfluxURL == "" {
+		return nil, errors.New("influx_url is required to write the recorded queries to InfluxDB")
+	}
+	u, err := url.Parse(cfg.InfluxURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the InfluxDB URL: %w", err)
+	}
+	// the timestamps of the samples are in milliseconds
+	query := u.Query()
+	query.Set("precision", "ms")
+	u.RawQuery = query.Encode()
+
+	return &influxWriter{
+		url:      u.String(),
+		token:    cfg.InfluxToken,
+		username: cfg.InfluxBasicAuthUsername,
+		password: cfg.InfluxBasicAuthPassword,
+		client:   &http.Client{Timeout: cfg.InfluxTimeout},
+	}, nil
+}
+
+func (w *influxWriter) Write(ctx context.Context, series []prompb.TimeSeries) error {
+	if len(series) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, s := range series {
+		key := influxSeriesKey(s.Labels)
+		for _, sample := range s.Samples {
+			buf.WriteString(key + " value=" + strconv.FormatFloat(sample.Value, 'g', -1, 64) + " " + strconv.FormatInt(sample.Timestamp, 10) + "\n")
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, &buf)
+	if err != nil {
+		return fmt.Errorf("error constructing InfluxDB write request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "Grafana")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	} else if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending InfluxDB write request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+	return fmt.Errorf("InfluxDB returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+}
+
+// influxSeriesKey returns the measurement and the tags of the series, escaped for the line protocol. The tags are
+// sorted by name like the labels, as InfluxDB recommends.
+func influxSeriesKey(labels []prompb.Label) string {
+	name, _ := labelValue(labels, model.MetricNameLabel)
+	key := influxMeasurementReplacer.Replace(name)
+	for _, l := range labels {
+		if l.Name == model.MetricNameLabel || l.Value == "" {
+			continue
+		}
+		key += "," + influxTagReplacer.Replace(l.Name) + "=" + influxTagReplacer.Replace(l.Value)
+	}
+	return key
+}
+
+var (
+	influxMeasurementReplacer = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", "_")
+	influxTagReplacer         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", "_")
+)
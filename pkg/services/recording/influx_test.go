@@ -0,0 +1,57 @@
+package recording
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestInfluxWriter(t *testing.T) {
+	series := []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "job:requests:rate5m"}, {Name: "job", Value: "api server"}, {Name: "team", Value: "a=b,c"}},
+		Samples: []prompb.Sample{{Value: 42.5, Timestamp: 1500}},
+	}}
+
+	t.Run("writes the series in the line protocol", func(t *testing.T) {
+		var body string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v2/write", r.URL.Path)
+			assert.Equal(t, "ms", r.URL.Query().Get("precision"))
+			assert.Equal(t, "recordings", r.URL.Query().Get("bucket"))
+			assert.Equal(t, "Token secret", r.Header.Get("Authorization"))
+			b, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			body = string(b)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(srv.Close)
+
+		w, err := newInfluxWriter(setting.RecordedQueriesSettings{InfluxURL: srv.URL + "/api/v2/write?org=main&bucket=recordings", InfluxToken: "secret", InfluxTimeout: time.Second})
+		require.NoError(t, err)
+		require.NoError(t, w.Write(context.Background(), series))
+		assert.Equal(t, "job:requests:rate5m,job=api\\ server,team=a\\=b\\,c value=42.5 1500\n", body)
+	})
+
+	t.Run("returns the errors of InfluxDB", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"not found","message":"database not found: \"recordings\""}`))
+		}))
+		t.Cleanup(srv.Close)
+
+		w, err := newInfluxWriter(setting.RecordedQueriesSettings{InfluxURL: srv.URL + "/write?db=recordings", InfluxBasicAuthUsername: "user", InfluxTimeout: time.Second})
+		require.NoError(t, err)
+		err = w.Write(context.Background(), series)
+		require.ErrorContains(t, err, "InfluxDB returned 404 Not Found")
+		require.ErrorContains(t, err, "database not found")
+	})
+}
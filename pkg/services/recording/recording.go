@@ -0,0 +1,198 @@
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/grafana/grafana/pkg/services/scheduledquery"
+)
+
+// Recording is a set of queries and expressions evaluated on a schedule, the result of the target query is written
+// to the remote write endpoint as the samples of a metric
+type Recording struct {
+	UID   string
+	OrgID int64
+	// Metric is the name of the metric the samples are written to
+	Metric string
+	// Interval is the time between two evaluations
+	Interval time.Duration
+	// Labels are added to every series written, they replace the labels of the series with the same name
+	Labels map[string]string
+	// Target is the RefID of the query or expression whose result is written, the last query when empty
+	Target string
+	// Count writes the number of rows returned for the target instead of their values, like the row count of a
+	// SQL query or the number of logs or traces found
+	Count   bool
+	Queries []Query
+}
+
+// Query is a datasource query or an expression of a recording
+type Query = scheduledquery.Query
+
+type recordingKey struct {
+	orgID int64
+	uid   string
+}
+
+func (r Recording) key() recordingKey {
+	return recordingKey{orgID: r.OrgID, uid: r.UID}
+}
+
+func (r Recording) target() string {
+	if r.Target != "" {
+		return r.Target
+	}
+	return r.Queries[len(r.Queries)-1].RefID
+}
+
+// Validate checks that the recording can be evaluated and its result written
+func (r Recording) Validate() error {
+	if r.UID == "" {
+		return errors.New("recording uid is required")
+	}
+	if !model.IsValidMetricName(model.LabelValue(r.Metric)) {
+		return fmt.Errorf("recording %s: invalid metric name %q", r.UID, r.Metric)
+	}
+	if r.Interval <= 0 {
+		return fmt.Errorf("recording %s: interval must be greater than zero", r.UID)
+	}
+	for name := range r.Labels {
+		if !model.LabelName(name).IsValid() || name == model.MetricNameLabel {
+			return fmt.Errorf("recording %s: invalid label name %q", r.UID, name)
+		}
+	}
+	refIDs, err := scheduledquery.ValidateQueries(r.Queries)
+	if err != nil {
+		return fmt.Errorf("recording %s: %w", r.UID, err)
+	}
+	if _, ok := refIDs[r.target()]; !ok {
+		return fmt.Errorf("recording %s: target %s is not one of the queries", r.UID, r.Target)
+	}
+	return nil
+}
+
+// seriesFromFrames converts the frames returned for the target of the recording to series with a single sample at
+// the time of the evaluation. The series of the time series frames have the last value of every numeric field, the
+// tables without time field have a series for every row, see tableSeries.
+func seriesFromFrames(r Recording, frames data.Frames, now time.Time) []prompb.TimeSeries {
+	if r.Count {
+		rows := 0
+		for _, frame := range frames {
+			rows += frame.Rows()
+		}
+		return []prompb.TimeSeries{{
+			Labels:  seriesLabels(r, nil),
+			Samples: []prompb.Sample{{Value: float64(rows), Timestamp: now.UnixMilli()}},
+		}}
+	}
+
+	var series []prompb.TimeSeries
+	for _, frame := range frames {
+		if len(frame.TypeIndices(data.FieldTypeTime, data.FieldTypeNullableTime)) == 0 {
+			series = append(series, tableSeries(r, frame, now)...)
+			continue
+		}
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() {
+				continue
+			}
+			value, ok := lastValue(field)
+			if !ok {
+				continue
+			}
+			series = append(series, prompb.TimeSeries{
+				Labels:  seriesLabels(r, field.Labels),
+				Samples: []prompb.Sample{{Value: value, Timestamp: now.UnixMilli()}},
+			})
+		}
+	}
+	return series
+}
+
+// tableSeries returns a series for every row and numeric field of a table, like the result of a SQL query grouped
+// by some columns. The string fields of the row are the labels of the series, and the name of the numeric field is
+// added in the field label when the table has several numeric fields.
+func tableSeries(r Recording, frame *data.Frame, now time.Time) []prompb.TimeSeries {
+	var numeric, labels []*data.Field
+	for _, field := range frame.Fields {
+		switch {
+		case field.Type().Numeric():
+			numeric = append(numeric, field)
+		case field.Type() == data.FieldTypeString || field.Type() == data.FieldTypeNullableString:
+			labels = append(labels, field)
+		}
+	}
+
+	var series []prompb.TimeSeries
+	for i := 0; i < frame.Rows(); i++ {
+		for _, field := range numeric {
+			if _, ok := field.ConcreteAt(i); !ok {
+				continue
+			}
+			value, err := field.FloatAt(i)
+			if err != nil || math.IsNaN(value) {
+				continue
+			}
+
+			rowLabels := data.Labels{}
+			for name, value := range field.Labels {
+				rowLabels[name] = value
+			}
+			for _, l := range labels {
+				if v, ok := l.ConcreteAt(i); ok {
+					rowLabels[l.Name] = v.(string)
+				}
+			}
+			if len(numeric) > 1 {
+				rowLabels["field"] = field.Name
+			}
+			series = append(series, prompb.TimeSeries{
+				Labels:  seriesLabels(r, rowLabels),
+				Samples: []prompb.Sample{{Value: value, Timestamp: now.UnixMilli()}},
+			})
+		}
+	}
+	return series
+}
+
+func lastValue(field *data.Field) (float64, bool) {
+	for i := field.Len() - 1; i >= 0; i-- {
+		if _, ok := field.ConcreteAt(i); !ok {
+			continue
+		}
+		v, err := field.FloatAt(i)
+		if err != nil {
+			continue
+		}
+		return v, !math.IsNaN(v)
+	}
+	return 0, false
+}
+
+// seriesLabels returns the labels of a series sorted by name, as the remote write protocol requires
+func seriesLabels(r Recording, fieldLabels data.Labels) []prompb.Label {
+	labels := make(map[string]string, len(fieldLabels)+len(r.Labels)+1)
+	for name, value := range fieldLabels {
+		if model.LabelName(name).IsValid() {
+			labels[name] = value
+		}
+	}
+	for name, value := range r.Labels {
+		labels[name] = value
+	}
+	labels[model.MetricNameLabel] = r.Metric
+
+	result := make([]prompb.Label, 0, len(labels))
+	for name, value := range labels {
+		result = append(result, prompb.Label{Name: name, Value: value})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,136 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/remotewrite"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// minBackoff is the wait before the first retry of a failed write, it is doubled after every retry
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 30 * time.Second
+
+	// maxErrorBodyLength is the number of bytes of the response kept in the error when a write is rejected
+	maxErrorBodyLength = 512
+)
+
+// httpRemoteWriter writes the series to a Prometheus compatible remote write endpoint
+type httpRemoteWriter struct {
+	url        string
+	username   string
+	password   string
+	headers    map[string]string
+	maxRetries int
+	minBackoff time.Duration
+	client     *http.Client
+	log        log.Logger
+}
+
+func newHTTPRemoteWriter(cfg setting.RecordedQueriesSettings, logger log.Logger) *httpRemoteWriter {
+	return &httpRemoteWriter{
+		url:        cfg.RemoteWriteURL,
+		username:   cfg.RemoteWriteBasicAuthUsername,
+		password:   cfg.RemoteWriteBasicAuthPassword,
+		headers:    cfg.RemoteWriteHeaders,
+		maxRetries: cfg.RemoteWriteMaxRetries,
+		minBackoff: minBackoff,
+		client:     &http.Client{Timeout: cfg.RemoteWriteTimeout},
+		log:        logger,
+	}
+}
+
+// retryableError is returned when the write failed because of the network or the endpoint is unavailable or
+// overloaded, as the same request can succeed later
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// Write sends the series to the endpoint, retrying with an exponential backoff when the endpoint is unavailable
+func (w *httpRemoteWriter) Write(ctx context.Context, series []prompb.TimeSeries) error {
+	if len(series) == 0 {
+		return nil
+	}
+	body, err := remotewrite.TimeSeriesToBytes(series)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.minBackoff
+	for attempt := 0; ; attempt++ {
+		err = w.send(ctx, body)
+		var retryable *retryableError
+		if err == nil || !errors.As(err, &retryable) || attempt >= w.maxRetries {
+			return err
+		}
+
+		w.log.Debug("Retrying remote write", "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (w *httpRemoteWriter) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error constructing remote write request: %w", err)
+	}
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("User-Agent", "Grafana")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &retryableError{err: fmt.Errorf("error sending remote write request: %w", err)}
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			w.log.Warn("Failed to close response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+	err = fmt.Errorf("remote write endpoint returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests {
+		return &retryableError{err: err}
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package recording
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func newTestRemoteWriter(url string) *httpRemoteWriter {
+	w := newHTTPRemoteWriter(setting.RecordedQueriesSettings{
+		RemoteWriteURL:               url,
+		RemoteWriteBasicAuthUsername: "user",
+		RemoteWriteBasicAuthPassword: "pass",
+		RemoteWriteHeaders:           map[string]string{"X-Scope-OrgID": "tenant"},
+		RemoteWriteTimeout:           time.Second,
+		RemoteWriteMaxRetries:        2,
+	}, log.NewNopLogger())
+	w.minBackoff = time.Millisecond
+	return w
+}
+
+var testSeries = []prompb.TimeSeries{{
+	Labels:  []prompb.Label{{Name: "__name__", Value: "requests"}, {Name: "job", Value: "api"}},
+	Samples: []prompb.Sample{{Value: 42, Timestamp: 1000}},
+}}
+
+func TestRemoteWriter(t *testing.T) {
+	t.Run("sends the series", func(t *testing.T) {
+		var received prompb.WriteRequest
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+			assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+			assert.Equal(t, "0.1.0", r.Header.Get("X-Prometheus-Remote-Write-Version"))
+			assert.Equal(t, "tenant", r.Header.Get("X-Scope-OrgID"))
+			username, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "user", username)
+			assert.Equal(t, "pass", password)
+
+			compressed, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			body, err := snappy.Decode(nil, compressed)
+			require.NoError(t, err)
+			require.NoError(t, proto.Unmarshal(body, &received))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(srv.Close)
+
+		require.NoError(t, newTestRemoteWriter(srv.URL).Write(context.Background(), testSeries))
+		assert.Equal(t, testSeries, received.Timeseries)
+	})
+
+	t.Run("retries when the endpoint is unavailable", func(t *testing.T) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(srv.Close)
+
+		require.NoError(t, newTestRemoteWriter(srv.URL).Write(context.Background(), testSeries))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("stops retrying after the max retries", func(t *testing.T) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		t.Cleanup(srv.Close)
+
+		err := newTestRemoteWriter(srv.URL).Write(context.Background(), testSeries)
+		require.Error(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("does not retry rejected writes", func(t *testing.T) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			http.Error(w, "out of order sample", http.StatusBadRequest)
+		}))
+		t.Cleanup(srv.Close)
+
+		err := newTestRemoteWriter(srv.URL).Write(context.Background(), testSeries)
+		require.EqualError(t, err, "remote write endpoint returned 400 Bad Request: out of order sample")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("does not send empty writes", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("unexpected request")
+		}))
+		t.Cleanup(srv.Close)
+
+		require.NoError(t, newTestRemoteWriter(srv.URL).Write(context.Background(), nil))
+	})
+}
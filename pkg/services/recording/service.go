@@ -0,0 +1,204 @@
+package recording
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/scheduledquery"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// tickInterval is how often the service looks for the recordings to evaluate
+const tickInterval = time.Second
+
+// evaluator runs the queries of a recording and returns the frames of its target
+type evaluator interface {
+	Evaluate(ctx context.Context, r Recording, now time.Time) (data.Frames, error)
+}
+
+type scheduledRecording struct {
+	recording Recording
+	next      time.Time
+	running   bool
+}
+
+// Service evaluates the registered recordings on their interval and writes their results to the targets configured
+// in the recorded_queries section
+type Service struct {
+	cfg       setting.RecordedQueriesSettings
+	log       log.Logger
+	clock     clock.Clock
+	evaluator evaluator
+	writers   []namedWriter
+
+	mu         sync.Mutex
+	recordings map[recordingKey]*scheduledRecording
+}
+
+func ProvideService(cfg *setting.Cfg, exprService *expr.Service, dsCache datasources.CacheService) (*Service, error) {
+	logger := log.New("recording")
+	var writers []namedWriter
+	if cfg.RecordedQueries.Enabled {
+		var err error
+		if writers, err = newWriters(cfg.RecordedQueries, logger); err != nil {
+			return nil, err
+		}
+	}
+	return newService(cfg.RecordedQueries, clock.New(), &exprEvaluator{evaluator: scheduledquery.NewEvaluator(exprService, dsCache, "grafana_recording")}, writers, logger), nil
+}
+
+func newService(cfg setting.RecordedQueriesSettings, clk clock.Clock, evaluator evaluator, writers []namedWriter, logger log.Logger) *Service {
+	return &Service{
+		cfg:        cfg,
+		log:        logger,
+		clock:      clk,
+		evaluator:  evaluator,
+		writers:    writers,
+		recordings: map[recordingKey]*scheduledRecording{},
+	}
+}
+
+// IsDisabled disables the service when recorded queries are disabled or there is no target to write to
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.Enabled || len(s.writers) == 0
+}
+
+// Register adds the recording to the evaluated recordings, or replaces the recording with the same org and UID.
+// The recording is evaluated on the next tick.
+func (s *Service) Register(r Recording) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scheduled, ok := s.recordings[r.key()]
+	if !ok {
+		scheduled = &scheduledRecording{}
+		s.recordings[r.key()] = scheduled
+	}
+	scheduled.recording = r
+	scheduled.next = s.clock.Now()
+	return nil
+}
+
+// Unregister stops evaluating the recording, an evaluation in progress is not cancelled
+func (s *Service) Unregister(orgID int64, uid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.recordings, recordingKey{orgID: orgID, uid: uid})
+}
+
+// Recordings returns the registered recordings of the organization, sorted by UID
+func (s *Service) Recordings(orgID int64) []Recording {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recordings := make([]Recording, 0)
+	for key, scheduled := range s.recordings {
+		if key.orgID == orgID {
+			recordings = append(recordings, scheduled.recording)
+		}
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].UID < recordings[j].UID
+	})
+	return recordings
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	ticker := s.clock.Ticker(tickInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			for _, scheduled := range s.due(now) {
+				wg.Add(1)
+				go func(scheduled *scheduledRecording, r Recording) {
+					defer wg.Done()
+					s.record(ctx, r, now)
+
+					s.mu.Lock()
+					scheduled.running = false
+					s.mu.Unlock()
+				}(scheduled, scheduled.recording)
+			}
+		}
+	}
+}
+
+// due returns the recordings to evaluate at now and schedules their next evaluation. A recording still evaluated
+// since its previous tick is skipped, so slow queries do not pile up.
+func (s *Service) due(now time.Time) []*scheduledRecording {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*scheduledRecording
+	for _, scheduled := range s.recordings {
+		if now.Before(scheduled.next) {
+			continue
+		}
+		for !now.Before(scheduled.next) {
+			scheduled.next = scheduled.next.Add(scheduled.recording.Interval)
+		}
+		if scheduled.running {
+			s.log.Warn("Skipping recording evaluation, the previous evaluation has not finished", "org", scheduled.recording.OrgID, "uid", scheduled.recording.UID)
+			continue
+		}
+		scheduled.running = true
+		due = append(due, scheduled)
+	}
+	return due
+}
+
+func (s *Service) record(ctx context.Context, r Recording, now time.Time) {
+	logger := s.log.New("org", r.OrgID, "uid", r.UID, "metric", r.Metric)
+
+	frames, err := s.evaluator.Evaluate(ctx, r, now)
+	if err != nil {
+		logger.Error("Failed to evaluate recording", "error", err)
+		return
+	}
+	series := seriesFromFrames(r, frames, now)
+	// a failed target does not prevent writing the others
+	for _, w := range s.writers {
+		if err := w.Write(ctx, series); err != nil {
+			logger.Error("Failed to write recording", "target", w.name, "error", err)
+			continue
+		}
+		logger.Debug("Recording written", "target", w.name, "series", len(series))
+	}
+}
+
+type exprEvaluator struct {
+	evaluator *scheduledquery.Evaluator
+}
+
+func (e *exprEvaluator) Evaluate(ctx context.Context, r Recording, now time.Time) (data.Frames, error) {
+	resp, err := e.evaluator.Evaluate(ctx, r.OrgID, r.Queries, now)
+	if err != nil {
+		return nil, err
+	}
+	target := r.target()
+	res, ok := resp.Responses[target]
+	if !ok {
+		return nil, fmt.Errorf("no response for target %s", target)
+	}
+	if res.Error != nil {
+		return nil, fmt.Errorf("query %s failed: %w", target, res.Error)
+	}
+	return res.Frames, nil
+}
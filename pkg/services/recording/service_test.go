@@ -0,0 +1,317 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func testRecording() Recording {
+	return Recording{
+		UID:      "rec",
+		OrgID:    1,
+		Metric:   "job:requests:rate5m",
+		Interval: time.Minute,
+		Labels:   map[string]string{"team": "backend"},
+		Queries: []Query{
+			{RefID: "A", DatasourceUID: "prom", Model: json.RawMessage(`{"expr": "rate(requests[5m])"}`)},
+			{RefID: "B", DatasourceUID: "__expr__", Model: json.RawMessage(`{"type": "reduce", "expression": "A", "reducer": "last"}`)},
+		},
+	}
+}
+
+func TestRecordingValidate(t *testing.T) {
+	require.NoError(t, testRecording().Validate())
+
+	for name, tc := range map[string]struct {
+		update func(r *Recording)
+		err    string
+	}{
+		"missing uid":           {update: func(r *Recording) { r.UID = "" }, err: "recording uid is required"},
+		"invalid metric name":   {update: func(r *Recording) { r.Metric = "requests-total" }, err: `recording rec: invalid metric name "requests-total"`},
+		"missing interval":      {update: func(r *Recording) { r.Interval = 0 }, err: "recording rec: interval must be greater than zero"},
+		"invalid label name":    {update: func(r *Recording) { r.Labels["__name__"] = "foo" }, err: `recording rec: invalid label name "__name__"`},
+		"no queries":            {update: func(r *Recording) { r.Queries = nil }, err: "recording rec: at least one query is required"},
+		"duplicate refId":       {update: func(r *Recording) { r.Queries[1].RefID = "A" }, err: "recording rec: duplicate query refId A"},
+		"missing datasource":    {update: func(r *Recording) { r.Queries[0].DatasourceUID = "" }, err: "recording rec: query A: datasource uid is required"},
+		"unknown target refId":  {update: func(r *Recording) { r.Target = "C" }, err: "recording rec: target C is not one of the queries"},
+		"target of first query": {update: func(r *Recording) { r.Target = "A" }},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := testRecording()
+			tc.update(&r)
+			err := r.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestSeriesFromFrames(t *testing.T) {
+	now := time.Unix(1000, 0)
+	frames := data.Frames{
+		data.NewFrame("",
+			data.NewField("time", nil, []time.Time{now.Add(-time.Minute), now}),
+			data.NewField("value", data.Labels{"job": "api", "team": "frontend"}, []*float64{floatPtr(1), nil}),
+		),
+		data.NewFrame("",
+			data.NewField("value", data.Labels{"job": "web"}, []float64{2}),
+		),
+		data.NewFrame("",
+			data.NewField("value", data.Labels{"job": "empty"}, []*float64{nil}),
+		),
+	}
+
+	series := seriesFromFrames(testRecording(), frames, now)
+	assert.Equal(t, []prompb.TimeSeries{
+		{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "job:requests:rate5m"},
+				{Name: "job", Value: "api"},
+				{Name: "team", Value: "backend"},
+			},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1000000}},
+		},
+		{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "job:requests:rate5m"},
+				{Name: "job", Value: "web"},
+				{Name: "team", Value: "backend"},
+			},
+			Samples: []prompb.Sample{{Value: 2, Timestamp: 1000000}},
+		},
+	}, series)
+}
+
+func TestSeriesFromTables(t *testing.T) {
+	now := time.Unix(1000, 0)
+	orders := data.NewFrame("",
+		data.NewField("status", nil, []string{"paid", "refunded"}),
+		data.NewField("count", nil, []int64{12, 3}),
+	)
+
+	t.Run("writes a series for every row", func(t *testing.T) {
+		series := seriesFromFrames(testRecording(), data.Frames{orders}, now)
+		require.Len(t, series, 2)
+		assert.Equal(t, []prompb.Label{
+			{Name: "__name__", Value: "job:requests:rate5m"},
+			{Name: "status", Value: "refunded"},
+			{Name: "team", Value: "backend"},
+		}, series[1].Labels)
+		assert.Equal(t, 3.0, series[1].Samples[0].Value)
+	})
+
+	t.Run("adds the name of the field when the table has several numeric fields", func(t *testing.T) {
+		frame := data.NewFrame("",
+			data.NewField("host", nil, []string{"a"}),
+			data.NewField("cpu", nil, []float64{0.5}),
+			data.NewField("memory", nil, []float64{0.25}),
+		)
+		series := seriesFromFrames(testRecording(), data.Frames{frame}, now)
+		require.Len(t, series, 2)
+		value, _ := labelValue(series[1].Labels, "field")
+		assert.Equal(t, "memory", value)
+	})
+
+	t.Run("writes the number of rows of the count recordings", func(t *testing.T) {
+		r := testRecording()
+		r.Count = true
+		series := seriesFromFrames(r, data.Frames{orders, data.NewFrame("", data.NewField("status", nil, []string{"open"}))}, now)
+		assert.Equal(t, []prompb.TimeSeries{{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "job:requests:rate5m"}, {Name: "team", Value: "backend"}},
+			Samples: []prompb.Sample{{Value: 3, Timestamp: 1000000}},
+		}}, series)
+
+		series = seriesFromFrames(r, nil, now)
+		assert.Equal(t, 0.0, series[0].Samples[0].Value)
+	})
+}
+
+func TestNewWriters(t *testing.T) {
+	t.Run("skips the remote write target without url", func(t *testing.T) {
+		w, err := newWriters(setting.RecordedQueriesSettings{Targets: []string{"remote_write"}}, log.NewNopLogger())
+		require.NoError(t, err)
+		assert.Empty(t, w)
+	})
+
+	t.Run("returns a writer for every target", func(t *testing.T) {
+		w, err := newWriters(setting.RecordedQueriesSettings{
+			Targets:         []string{"remote_write", "graphite", "influx"},
+			RemoteWriteURL:  "http://localhost:9090/api/v1/write",
+			GraphiteAddress: "localhost:2003",
+			InfluxURL:       "http://localhost:8086/write?db=recordings",
+		}, log.NewNopLogger())
+		require.NoError(t, err)
+		require.Len(t, w, 3)
+		assert.Equal(t, "influx", w[2].name)
+	})
+
+	t.Run("rejects the targets without address", func(t *testing.T) {
+		_, err := newWriters(setting.RecordedQueriesSettings{Targets: []string{"graphite"}}, log.NewNopLogger())
+		require.EqualError(t, err, "graphite_address is required to write the recorded queries to Graphite")
+		_, err = newWriters(setting.RecordedQueriesSettings{Targets: []string{"influx"}}, log.NewNopLogger())
+		require.EqualError(t, err, "influx_url is required to write the recorded queries to InfluxDB")
+	})
+
+	t.Run("rejects the unknown targets", func(t *testing.T) {
+		_, err := newWriters(setting.RecordedQueriesSettings{Targets: []string{"statsd"}}, log.NewNopLogger())
+		require.EqualError(t, err, `unknown recorded queries target "statsd", the targets are remote_write, graphite and influx`)
+	})
+}
+
+type fakeEvaluator struct {
+	frames data.Frames
+	err    error
+}
+
+func (e *fakeEvaluator) Evaluate(_ context.Context, _ Recording, _ time.Time) (data.Frames, error) {
+	return e.frames, e.err
+}
+
+type fakeWriter struct {
+	mu     sync.Mutex
+	err    error
+	writes [][]prompb.TimeSeries
+}
+
+func (w *fakeWriter) Write(_ context.Context, series []prompb.TimeSeries) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, series)
+	return w.err
+}
+
+func (w *fakeWriter) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.writes)
+}
+
+func TestService(t *testing.T) {
+	frames := data.Frames{data.NewFrame("", data.NewField("value", nil, []float64{3}))}
+
+	t.Run("is disabled without targets", func(t *testing.T) {
+		s := newService(setting.RecordedQueriesSettings{Enabled: true}, clock.NewMock(), &fakeEvaluator{}, nil, log.NewNopLogger())
+		assert.True(t, s.IsDisabled())
+		s = newService(setting.RecordedQueriesSettings{Enabled: true}, clock.NewMock(), &fakeEvaluator{}, writers(&fakeWriter{}), log.NewNopLogger())
+		assert.False(t, s.IsDisabled())
+	})
+
+	t.Run("does not register invalid recordings", func(t *testing.T) {
+		s := newService(setting.RecordedQueriesSettings{}, clock.NewMock(), &fakeEvaluator{}, writers(&fakeWriter{}), log.NewNopLogger())
+		r := testRecording()
+		r.Interval = 0
+		require.Error(t, s.Register(r))
+		assert.Empty(t, s.recordings)
+	})
+
+	t.Run("schedules the recordings on their interval", func(t *testing.T) {
+		clk := clock.NewMock()
+		s := newService(setting.RecordedQueriesSettings{}, clk, &fakeEvaluator{}, writers(&fakeWriter{}), log.NewNopLogger())
+		require.NoError(t, s.Register(testRecording()))
+
+		start := clk.Now()
+		require.Len(t, s.due(start), 1)
+		for _, scheduled := range s.recordings {
+			scheduled.running = false
+		}
+		assert.Empty(t, s.due(start.Add(30*time.Second)))
+		// the evaluations missed are skipped
+		require.Len(t, s.due(start.Add(3*time.Minute+time.Second)), 1)
+		assert.Equal(t, start.Add(4*time.Minute), s.recordings[testRecording().key()].next)
+	})
+
+	t.Run("skips the recordings still running", func(t *testing.T) {
+		clk := clock.NewMock()
+		s := newService(setting.RecordedQueriesSettings{}, clk, &fakeEvaluator{}, writers(&fakeWriter{}), log.NewNopLogger())
+		require.NoError(t, s.Register(testRecording()))
+
+		require.Len(t, s.due(clk.Now()), 1)
+		assert.Empty(t, s.due(clk.Now().Add(time.Minute)))
+	})
+
+	t.Run("unregistered recordings are not evaluated", func(t *testing.T) {
+		clk := clock.NewMock()
+		s := newService(setting.RecordedQueriesSettings{}, clk, &fakeEvaluator{}, writers(&fakeWriter{}), log.NewNopLogger())
+		require.NoError(t, s.Register(testRecording()))
+		s.Unregister(1, "rec")
+		assert.Empty(t, s.due(clk.Now()))
+	})
+
+	t.Run("lists the recordings of the organization", func(t *testing.T) {
+		s := newService(setting.RecordedQueriesSettings{}, clock.NewMock(), &fakeEvaluator{}, writers(&fakeWriter{}), log.NewNopLogger())
+		other := testRecording()
+		other.OrgID = 2
+		require.NoError(t, s.Register(testRecording()))
+		require.NoError(t, s.Register(other))
+
+		recordings := s.Recordings(1)
+		require.Len(t, recordings, 1)
+		assert.Equal(t, "rec", recordings[0].UID)
+		assert.Empty(t, s.Recordings(3))
+	})
+
+	t.Run("writes the result of the evaluations", func(t *testing.T) {
+		clk := clock.NewMock()
+		writer := &fakeWriter{}
+		s := newService(setting.RecordedQueriesSettings{}, clk, &fakeEvaluator{frames: frames}, writers(writer), log.NewNopLogger())
+		require.NoError(t, s.Register(testRecording()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- s.Run(ctx) }()
+
+		require.Eventually(t, func() bool {
+			clk.Add(tickInterval)
+			return writer.count() == 1
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+
+		require.Len(t, writer.writes[0], 1)
+		assert.Equal(t, 3.0, writer.writes[0][0].Samples[0].Value)
+	})
+
+	t.Run("writes to every target", func(t *testing.T) {
+		failing, writer := &fakeWriter{err: errors.New("boom")}, &fakeWriter{}
+		s := newService(setting.RecordedQueriesSettings{}, clock.NewMock(), &fakeEvaluator{frames: frames}, writers(failing, writer), log.NewNopLogger())
+		s.record(context.Background(), testRecording(), time.Now())
+		assert.Equal(t, 1, failing.count())
+		assert.Equal(t, 1, writer.count())
+	})
+
+	t.Run("does not write when the evaluation fails", func(t *testing.T) {
+		writer := &fakeWriter{}
+		s := newService(setting.RecordedQueriesSettings{}, clock.NewMock(), &fakeEvaluator{err: errors.New("boom")}, writers(writer), log.NewNopLogger())
+		s.record(context.Background(), testRecording(), time.Now())
+		assert.Equal(t, 0, writer.count())
+	})
+}
+
+func writers(writers ...*fakeWriter) []namedWriter {
+	named := make([]namedWriter, len(writers))
+	for i, w := range writers {
+		named[i] = namedWriter{name: fmt.Sprintf("fake%d", i), Writer: w}
+	}
+	return named
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
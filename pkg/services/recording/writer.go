@@ -0,0 +1,62 @@
+package recording
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Writer writes the series of a recording to one of the targets. The series are in the representation of the
+// remote write protocol whatever the target, with the name of the metric in the __name__ label.
+type Writer interface {
+	Write(ctx context.Context, series []prompb.TimeSeries) error
+}
+
+type namedWriter struct {
+	name string
+	Writer
+}
+
+// newWriters returns the writers of the targets of the recorded_queries section. The remote write target is skipped
+// without remote write URL, as it is the default target.
+func newWriters(cfg setting.RecordedQueriesSettings, logger log.Logger) ([]namedWriter, error) {
+	var writers []namedWriter
+	for _, target := range cfg.Targets {
+		switch target {
+		case setting.RecordedQueriesTargetRemoteWrite:
+			if cfg.RemoteWriteURL == "" {
+				continue
+			}
+			writers = append(writers, namedWriter{name: target, Writer: newHTTPRemoteWriter(cfg, logger)})
+		case setting.RecordedQueriesTargetGraphite:
+			w, err := newGraphiteWriter(cfg)
+			if err != nil {
+				return nil, err
+			}
+			writers = append(writers, namedWriter{name: target, Writer: w})
+		case setting.RecordedQueriesTargetInflux:
+			w, err := newInfluxWriter(cfg)
+			if err != nil {
+				return nil, err
+			}
+			writers = append(writers, namedWriter{name: target, Writer: w})
+		default:
+			return nil, fmt.Errorf("unknown recorded queries target %q, the targets are remote_write, graphite and influx", target)
+		}
+	}
+	return writers, nil
+}
+
+// labelValue returns the value of the label and if the series has the label
+func labelValue(labels []prompb.Label, name string) (string, bool) {
+	for _, l := range labels {
+		if l.Name == name {
+			return l.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,278 @@
+package native
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	padding   = 8.0
+	titleSize = 14.0
+	labelSize = 11.0
+)
+
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+type point struct {
+	x, y float64
+}
+
+// canvas is implemented for every image format so the panels are drawn the same way for all of them. The y coordinate
+// of a text is its baseline.
+type canvas interface {
+	size() (float64, float64)
+	rect(x, y, w, h float64, c color.NRGBA)
+	polyline(points []point, c color.NRGBA, width float64)
+	text(x, y, size float64, c color.NRGBA, a anchor, s string)
+	textWidth(s string, size float64) float64
+	encode() (*Result, error)
+}
+
+// offsetCanvas draws in an area of its parent canvas
+type offsetCanvas struct {
+	parent canvas
+	x, y   float64
+	w, h   float64
+}
+
+func subCanvas(c canvas, x, y, w, h float64) canvas {
+	return &offsetCanvas{parent: c, x: x, y: y, w: w, h: h}
+}
+
+func (c *offsetCanvas) size() (float64, float64) {
+	return c.w, c.h
+}
+
+func (c *offsetCanvas) rect(x, y, w, h float64, col color.NRGBA) {
+	c.parent.rect(c.x+x, c.y+y, w, h, col)
+}
+
+func (c *offsetCanvas) polyline(points []point, col color.NRGBA, width float64) {
+	moved := make([]point, len(points))
+	for i, p := range points {
+		moved[i] = point{x: c.x + p.x, y: c.y + p.y}
+	}
+	c.parent.polyline(moved, col, width)
+}
+
+func (c *offsetCanvas) text(x, y, size float64, col color.NRGBA, a anchor, s string) {
+	c.parent.text(c.x+x, c.y+y, size, col, a, s)
+}
+
+func (c *offsetCanvas) textWidth(s string, size float64) float64 {
+	return c.parent.textWidth(s, size)
+}
+
+func (c *offsetCanvas) encode() (*Result, error) {
+	return c.parent.encode()
+}
+
+// truncate shortens the text so it fits in the width
+func truncate(c canvas, s string, size float64, width float64) string {
+	if c.textWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && c.textWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	if len(runes) == 0 {
+		return ""
+	}
+	return string(runes) + "..."
+}
+
+// pngCanvas draws with the bitmap font, scaled to the closest size of the text
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func newPNGCanvas(width, height int) *pngCanvas {
+	return &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+func (c *pngCanvas) size() (float64, float64) {
+	b := c.img.Bounds()
+	return float64(b.Dx()), float64(b.Dy())
+}
+
+func (c *pngCanvas) rect(x, y, w, h float64, col color.NRGBA) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	draw.Draw(c.img, r, image.NewUniform(col), image.Point{}, draw.Over)
+}
+
+func (c *pngCanvas) polyline(points []point, col color.NRGBA, width float64) {
+	brush := int(math.Max(1, math.Round(width)))
+	for i := 1; i < len(points); i++ {
+		c.line(points[i-1], points[i], col, brush)
+	}
+}
+
+// line draws the segment with a square brush using the Bresenham algorithm
+func (c *pngCanvas) line(from, to point, col color.NRGBA, brush int) {
+	x0, y0 := int(math.Round(from.x)), int(math.Round(from.y))
+	x1, y1 := int(math.Round(to.x)), int(math.Round(to.y))
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	u := image.NewUniform(col)
+	offset := brush / 2
+	e := dx + dy
+	for {
+		draw.Draw(c.img, image.Rect(x0-offset, y0-offset, x0-offset+brush, y0-offset+brush), u, image.Point{}, draw.Src)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func fontScale(size float64) int {
+	return int(math.Max(1, math.Floor(size/glyphHeight)))
+}
+
+func (c *pngCanvas) textWidth(s string, size float64) float64 {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	scale := fontScale(size)
+	return float64((n*(glyphWidth+1) - 1) * scale)
+}
+
+func (c *pngCanvas) text(x, y, size float64, col color.NRGBA, a anchor, s string) {
+	scale := fontScale(size)
+	switch a {
+	case anchorMiddle:
+		x -= c.textWidth(s, size) / 2
+	case anchorEnd:
+		x -= c.textWidth(s, size)
+	}
+
+	u := image.NewUniform(col)
+	left := int(math.Round(x))
+	top := int(math.Round(y)) - glyphHeight*scale
+	for _, r := range s {
+		g := glyph(r)
+		for gx := 0; gx < glyphWidth; gx++ {
+			for gy := 0; gy < glyphHeight; gy++ {
+				if g[gx]&(1<<uint(gy)) == 0 {
+					continue
+				}
+				px, py := left+gx*scale, top+gy*scale
+				draw.Draw(c.img, image.Rect(px, py, px+scale, py+scale), u, image.Point{}, draw.Over)
+			}
+		}
+		left += (glyphWidth + 1) * scale
+	}
+}
+
+func (c *pngCanvas) encode() (*Result, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return &Result{Data: buf.Bytes(), ContentType: "image/png"}, nil
+}
+
+// svgCanvas writes the SVG elements, the width of the texts is estimated from the average width of sans-serif
+// characters
+type svgCanvas struct {
+	width, height int
+	buf           strings.Builder
+}
+
+func newSVGCanvas(width, height int) *svgCanvas {
+	return &svgCanvas{width: width, height: height}
+}
+
+func (c *svgCanvas) size() (float64, float64) {
+	return float64(c.width), float64(c.height)
+}
+
+func svgColor(col color.NRGBA) string {
+	s := fmt.Sprintf(`"#%02x%02x%02x"`, col.R, col.G, col.B)
+	if col.A != 255 {
+		s += ` fill-opacity="` + formatCoord(float64(col.A)/255) + `"`
+	}
+	return s
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, col color.NRGBA) {
+	fmt.Fprintf(&c.buf, `<rect x="%s" y="%s" width="%s" height="%s" fill=%s/>`,
+		formatCoord(x), formatCoord(y), formatCoord(w), formatCoord(h), svgColor(col))
+}
+
+func (c *svgCanvas) polyline(points []point, col color.NRGBA, width float64) {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = formatCoord(math.Round(p.x*100)/100) + "," + formatCoord(math.Round(p.y*100)/100)
+	}
+	fmt.Fprintf(&c.buf, `<polyline points="%s" fill="none" stroke="#%02x%02x%02x" stroke-width="%s"/>`,
+		strings.Join(coords, " "), col.R, col.G, col.B, formatCoord(width))
+}
+
+func (c *svgCanvas) textWidth(s string, size float64) float64 {
+	return float64(len([]rune(s))) * size * 0.6
+}
+
+func (c *svgCanvas) text(x, y, size float64, col color.NRGBA, a anchor, s string) {
+	textAnchor := "start"
+	switch a {
+	case anchorMiddle:
+		textAnchor = "middle"
+	case anchorEnd:
+		textAnchor = "end"
+	}
+	fmt.Fprintf(&c.buf, `<text x="%s" y="%s" font-family="sans-serif" font-size="%s" text-anchor="%s" fill=%s>`,
+		formatCoord(x), formatCoord(y), formatCoord(size), textAnchor, svgColor(col))
+	_ = xml.EscapeText(&c.buf, []byte(s))
+	c.buf.WriteString("</text>")
+}
+
+func (c *svgCanvas) encode() (*Result, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		c.width, c.height, c.width, c.height)
+	buf.WriteString(c.buf.String())
+	buf.WriteString("</svg>")
+	return &Result{Data: buf.Bytes(), ContentType: "image/svg+xml"}, nil
+}
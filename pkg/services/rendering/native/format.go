@@ -0,0 +1,250 @@
+package native
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+type theme struct {
+	background color.NRGBA
+	text       color.NRGBA
+	textWeak   color.NRGBA
+	grid       color.NRGBA
+}
+
+var (
+	darkTheme = theme{
+		background: hexColor("#181b1f"),
+		text:       hexColor("#ccccdc"),
+		textWeak:   hexColor("#8e8e9e"),
+		grid:       hexColor("#2c3235"),
+	}
+	lightTheme = theme{
+		background: hexColor("#ffffff"),
+		text:       hexColor("#24292e"),
+		textWeak:   hexColor("#6a6e73"),
+		grid:       hexColor("#e4e7e7"),
+	}
+
+	// seriesColors is the classic palette of the graph panels
+	seriesColors = []color.NRGBA{
+		hexColor("#7eb26d"), hexColor("#eab839"), hexColor("#6ed0e0"), hexColor("#ef843c"),
+		hexColor("#e24d42"), hexColor("#1f78c1"), hexColor("#ba43a9"), hexColor("#705da0"),
+	}
+
+	namedColors = map[string]color.NRGBA{
+		"green":  hexColor("#73bf69"),
+		"red":    hexColor("#f2495c"),
+		"yellow": hexColor("#fade2a"),
+		"orange": hexColor("#ff9830"),
+		"blue":   hexColor("#5794f2"),
+		"purple": hexColor("#b877d9"),
+		"white":  hexColor("#ffffff"),
+		"black":  hexColor("#000000"),
+	}
+)
+
+func getTheme(t models.Theme) theme {
+	if t == models.ThemeLight {
+		return lightTheme
+	}
+	return darkTheme
+}
+
+// hexColor parses #rgb and #rrggbb colors, it returns black for invalid values
+func hexColor(s string) color.NRGBA {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || len(s) != 6 {
+		return color.NRGBA{A: 255}
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}
+}
+
+// parseColor parses the named and hex colors of the field config, returning false for the other formats
+func parseColor(s string) (color.NRGBA, bool) {
+	if strings.HasPrefix(s, "#") {
+		return hexColor(s), true
+	}
+	// the palette has shades of the named colors, like dark-green or semi-dark-red
+	name := s
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		name = s[i+1:]
+	}
+	c, ok := namedColors[name]
+	return c, ok
+}
+
+// thresholdColor returns the color of the last threshold step below or equal to the value
+func thresholdColor(t *thresholds, value float64, fallback color.NRGBA) color.NRGBA {
+	if t == nil {
+		return fallback
+	}
+	result := fallback
+	for _, step := range t.Steps {
+		if step.Value != nil && value < *step.Value {
+			break
+		}
+		if c, ok := parseColor(step.Color); ok {
+			result = c
+		}
+	}
+	return result
+}
+
+var siPrefixes = []struct {
+	factor float64
+	suffix string
+}{
+	{1e12, " T"}, {1e9, " B"}, {1e6, " Mil"}, {1e3, " K"},
+}
+
+var bytePrefixes = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+
+// formatValue formats the value with the most common units of the field config
+func formatValue(v float64, unit string, decimals *int) string {
+	if math.IsNaN(v) {
+		return "NaN"
+	}
+
+	// without decimals in the field config, integers are formatted without decimals once the unit is applied
+	d := -1
+	if decimals != nil {
+		d = *decimals
+	}
+	format := func(v float64) string {
+		if d >= 0 {
+			return strconv.FormatFloat(v, 'f', d, 64)
+		}
+		if v == math.Trunc(v) {
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+
+	switch unit {
+	case "percent":
+		return format(v) + "%"
+	case "percentunit":
+		return format(v*100) + "%"
+	case "ms":
+		return formatDuration(v)
+	case "s":
+		return formatDuration(v * 1000)
+	case "bytes", "decbytes":
+		i := 0
+		for math.Abs(v) >= 1024 && i < len(bytePrefixes)-1 {
+			v /= 1024
+			i++
+		}
+		if i > 0 && decimals == nil {
+			d = 1
+		}
+		return format(v) + " " + bytePrefixes[i]
+	case "none", "":
+	case "short":
+		for _, p := range siPrefixes {
+			if math.Abs(v) >= p.factor {
+				if decimals == nil {
+					d = 1
+				}
+				return format(v/p.factor) + p.suffix
+			}
+		}
+	default:
+		// suffix:<unit> and currency units are written as they are
+		if s := strings.TrimPrefix(unit, "suffix:"); s != unit {
+			return format(v) + s
+		}
+		if s := strings.TrimPrefix(unit, "prefix:"); s != unit {
+			return s + format(v)
+		}
+	}
+	return format(v)
+}
+
+func formatDuration(ms float64) string {
+	abs := math.Abs(ms)
+	switch {
+	case abs < 1000:
+		return strconv.FormatFloat(ms, 'f', -1, 64) + " ms"
+	case abs < 60*1000:
+		return strconv.FormatFloat(ms/1000, 'f', 2, 64) + " s"
+	case abs < 3600*1000:
+		return strconv.FormatFloat(ms/60000, 'f', 1, 64) + " min"
+	default:
+		return strconv.FormatFloat(ms/3600000, 'f', 1, 64) + " hour"
+	}
+}
+
+// formatTime formats the time axis labels, with the date when the range is longer than a day
+func formatTime(ms int64, rangeMs int64) string {
+	t := time.UnixMilli(ms).UTC()
+	if rangeMs > 24*time.Hour.Milliseconds() {
+		return t.Format("01/02 15:04")
+	}
+	return t.Format("15:04")
+}
+
+// reduce computes the stat panel calculation, it returns false when there are no values
+func reduce(values []float64, calc string) (float64, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	switch calc {
+	case "last", "lastNotNull", "":
+		return values[len(values)-1], true
+	case "first", "firstNotNull":
+		return values[0], true
+	case "min":
+		m := values[0]
+		for _, v := range values {
+			m = math.Min(m, v)
+		}
+		return m, true
+	case "max":
+		m := values[0]
+		for _, v := range values {
+			m = math.Max(m, v)
+		}
+		return m, true
+	case "sum", "mean":
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		if calc == "mean" {
+			return sum / float64(len(values)), true
+		}
+		return sum, true
+	case "count":
+		return float64(len(values)), true
+	case "range":
+		minValue, _ := reduce(values, "min")
+		maxValue, _ := reduce(values, "max")
+		return maxValue - minValue, true
+	default:
+		return values[len(values)-1], true
+	}
+}
+
+func sortedRefIDs(resp *backend.QueryDataResponse) []string {
+	refIDs := make([]string, 0, len(resp.Responses))
+	for refID := range resp.Responses {
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+	return refIDs
+}
@@ -0,0 +1,207 @@
+// Package native renders the time series, stat and table panels of dashboards without the image renderer plugin. It
+// runs the queries of the panel with the query service and draws the results, so it only supports the basic features
+// of these panels.
+package native
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+)
+
+var (
+	ErrPanelNotFound     = errors.New("panel not found")
+	ErrUnsupportedPanel  = errors.New("panel type is not supported by the native renderer")
+	ErrTemplateVariable  = errors.New("template variables are not supported by the native renderer")
+	ErrNoQueries         = errors.New("panel has no queries")
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrNoIdentity        = errors.New("the native renderer needs the identity of the requester")
+	errNoData            = errors.New("no data")
+
+	panelRenderers = map[string]panelRenderer{
+		"timeseries": renderTimeSeries,
+		"graph":      renderTimeSeries,
+		"stat":       renderStat,
+		"singlestat": renderStat,
+		"table":      renderTable,
+	}
+)
+
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+// Opts are the options to render a panel.
+type Opts struct {
+	OrgID        int64
+	DashboardUID string
+	PanelID      int64
+	From         string
+	To           string
+	Width        int
+	Height       int
+	Theme        models.Theme
+	Format       Format
+	// SignedInUser is the identity the queries of the panel run as, the requester or the alert rule, so that the
+	// permissions of the datasources apply
+	SignedInUser *user.SignedInUser
+}
+
+// Result is the rendered image.
+type Result struct {
+	Data        []byte
+	ContentType string
+}
+
+type panelRenderer func(c canvas, p *panel, frames data.Frames, r renderRange)
+
+// renderRange is the time range and the theme used to draw a panel
+type renderRange struct {
+	fromMs int64
+	toMs   int64
+	theme  theme
+}
+
+// Renderer renders panels from their query results.
+type Renderer struct {
+	dashboards dashboards.DashboardService
+	queries    query.Service
+}
+
+func New(dashboardService dashboards.DashboardService, queryService query.Service) *Renderer {
+	return &Renderer{
+		dashboards: dashboardService,
+		queries:    queryService,
+	}
+}
+
+// Render queries the datasources of the panel and draws the results. It fails with ErrUnsupportedPanel when the type of
+// the panel is not supported, the caller should use the image renderer plugin for these panels.
+func (r *Renderer) Render(ctx context.Context, opts Opts) (*Result, error) {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return nil, fmt.Errorf("invalid image size %dx%d", opts.Width, opts.Height)
+	}
+	if opts.SignedInUser == nil || opts.SignedInUser.OrgID != opts.OrgID {
+		return nil, ErrNoIdentity
+	}
+
+	q := dashboards.GetDashboardQuery{OrgID: opts.OrgID, UID: opts.DashboardUID}
+	dashboard, err := r.dashboards.GetDashboard(ctx, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := getPanel(dashboard.Data, opts.PanelID)
+	if err != nil {
+		return nil, err
+	}
+	render, ok := panelRenderers[p.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPanel, p.Type)
+	}
+
+	timeRange := legacydata.NewDataTimeRange(opts.From, opts.To)
+	rr := renderRange{
+		fromMs: timeRange.GetFromAsMsEpoch(),
+		toMs:   timeRange.GetToAsMsEpoch(),
+		theme:  getTheme(opts.Theme),
+	}
+
+	maxDataPoints := p.MaxDataPoints
+	if maxDataPoints <= 0 {
+		maxDataPoints = int64(opts.Width)
+	}
+	intervalMs := (rr.toMs - rr.fromMs) / maxDataPoints
+	if intervalMs < 1 {
+		intervalMs = 1
+	}
+	queries, err := p.queries(maxDataPoints, intervalMs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.queries.QueryData(ctx, opts.SignedInUser, false, dtos.MetricRequest{
+		From:    opts.From,
+		To:      opts.To,
+		Queries: queries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query panel data: %w", err)
+	}
+
+	frames, err := responseFrames(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var c canvas
+	switch opts.Format {
+	case FormatPNG, "":
+		c = newPNGCanvas(opts.Width, opts.Height)
+	case FormatSVG:
+		c = newSVGCanvas(opts.Width, opts.Height)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, opts.Format)
+	}
+
+	drawPanel(c, p, frames, rr, render)
+	return c.encode()
+}
+
+// responseFrames returns the frames of the responses ordered by query ref ID, it fails when all the queries failed
+func responseFrames(resp *backend.QueryDataResponse) (data.Frames, error) {
+	var frames data.Frames
+	var lastErr error
+	for _, refID := range sortedRefIDs(resp) {
+		r := resp.Responses[refID]
+		if r.Error != nil {
+			lastErr = r.Error
+			continue
+		}
+		frames = append(frames, r.Frames...)
+	}
+	if len(frames) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("failed to query panel data: %w", lastErr)
+	}
+	return frames, nil
+}
+
+func drawPanel(c canvas, p *panel, frames data.Frames, r renderRange, render panelRenderer) {
+	w, h := c.size()
+	c.rect(0, 0, w, h, r.theme.background)
+
+	content := c
+	if p.Title != "" {
+		c.text(padding, padding+titleSize, titleSize, r.theme.text, anchorStart, truncate(c, p.Title, titleSize, w-2*padding))
+		content = subCanvas(c, 0, titleSize+2*padding, w, h-titleSize-2*padding)
+	}
+
+	if !hasData(frames) {
+		cw, ch := content.size()
+		content.text(cw/2, ch/2, labelSize, r.theme.textWeak, anchorMiddle, errNoData.Error())
+		return
+	}
+	render(content, p, frames, r)
+}
+
+func hasData(frames data.Frames) bool {
+	for _, f := range frames {
+		if f != nil && len(f.Fields) > 0 && f.Rows() > 0 {
+			return true
+		}
+	}
+	return false
+}
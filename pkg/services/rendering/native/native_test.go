@@ -0,0 +1,276 @@
+package native
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+var testUser = &user.SignedInUser{UserID: 2, OrgID: 1, Login: "viewer"}
+
+const testDashboard = `{
+	"panels": [
+		{
+			"id": 1,
+			"type": "timeseries",
+			"title": "Requests",
+			"datasource": {"type": "prometheus", "uid": "prom"},
+			"targets": [
+				{"refId": "A", "expr": "rate(requests[5m])"},
+				{"refId": "B", "expr": "rate(errors[5m])", "hide": true}
+			]
+		},
+		{
+			"id": 2,
+			"type": "row",
+			"collapsed": true,
+			"panels": [
+				{
+					"id": 3,
+					"type": "stat",
+					"title": "Errors",
+					"datasource": "prom",
+					"targets": [{"refId": "A", "datasource": {"uid": "loki"}}],
+					"fieldConfig": {"defaults": {"unit": "percent", "thresholds": {"steps": [{"color": "green", "value": null}, {"color": "red", "value": 80}]}}},
+					"options": {"reduceOptions": {"calcs": ["max"]}}
+				}
+			]
+		},
+		{
+			"id": 4,
+			"type": "table",
+			"title": "Hosts",
+			"datasource": {"uid": "prom"},
+			"targets": [{"refId": "A"}]
+		},
+		{
+			"id": 5,
+			"type": "piechart",
+			"datasource": {"uid": "prom"},
+			"targets": [{"refId": "A"}]
+		},
+		{
+			"id": 6,
+			"type": "timeseries",
+			"datasource": {"uid": "$datasource"},
+			"targets": [{"refId": "A"}]
+		}
+	]
+}`
+
+func testPanel(t *testing.T, id int64) *panel {
+	t.Helper()
+	dashboard, err := simplejson.NewJson([]byte(testDashboard))
+	require.NoError(t, err)
+	p, err := getPanel(dashboard, id)
+	require.NoError(t, err)
+	return p
+}
+
+func TestGetPanel(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(testDashboard))
+	require.NoError(t, err)
+
+	p, err := getPanel(dashboard, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "stat", p.Type)
+	assert.Equal(t, "percent", p.FieldConfig.Defaults.Unit)
+	assert.Equal(t, []string{"max"}, p.Options.ReduceOptions.Calcs)
+
+	_, err = getPanel(dashboard, 42)
+	assert.ErrorIs(t, err, ErrPanelNotFound)
+}
+
+func TestPanelQueries(t *testing.T) {
+	t.Run("hidden targets are skipped and the panel datasource is used", func(t *testing.T) {
+		queries, err := testPanel(t, 1).queries(100, 1000)
+		require.NoError(t, err)
+		require.Len(t, queries, 1)
+		assert.Equal(t, "A", queries[0].Get("refId").MustString())
+		assert.Equal(t, "prom", queries[0].GetPath("datasource", "uid").MustString())
+		assert.Equal(t, int64(100), queries[0].Get("maxDataPoints").MustInt64())
+		assert.Equal(t, int64(1000), queries[0].Get("intervalMs").MustInt64())
+	})
+
+	t.Run("target datasource overrides the panel datasource", func(t *testing.T) {
+		queries, err := testPanel(t, 3).queries(100, 1000)
+		require.NoError(t, err)
+		require.Len(t, queries, 1)
+		assert.Equal(t, "loki", queries[0].GetPath("datasource", "uid").MustString())
+	})
+
+	t.Run("template variables are not supported", func(t *testing.T) {
+		_, err := testPanel(t, 6).queries(100, 1000)
+		assert.ErrorIs(t, err, ErrTemplateVariable)
+	})
+}
+
+func timeSeriesFrame(name string, values ...float64) *data.Frame {
+	start := time.Now().Add(-time.Hour)
+	times := make([]time.Time, len(values))
+	for i := range values {
+		times[i] = start.Add(time.Duration(i) * time.Minute)
+	}
+	return data.NewFrame(name,
+		data.NewField("time", nil, times),
+		data.NewField("value", data.Labels{"instance": name}, values),
+	)
+}
+
+func setupRenderer(t *testing.T, frames data.Frames) (*Renderer, *query.FakeQueryService) {
+	t.Helper()
+	dashboard, err := simplejson.NewJson([]byte(testDashboard))
+	require.NoError(t, err)
+
+	ds := &dashboards.FakeDashboardService{}
+	ds.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).
+		Return(&dashboards.Dashboard{UID: "foo", OrgID: 1, Data: dashboard}, nil)
+
+	qs := &query.FakeQueryService{}
+	qs.On("QueryData", mock.Anything, mock.Anything, false, mock.AnythingOfType("dtos.MetricRequest")).
+		Return(&backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: frames}}}, nil)
+
+	return New(ds, qs), qs
+}
+
+func TestRender(t *testing.T) {
+	frames := data.Frames{timeSeriesFrame("a", 1, 2, 3, 4), timeSeriesFrame("b", 4, 3, 2, 1)}
+	table := data.Frames{data.NewFrame("",
+		data.NewField("host", nil, []string{"a", "b"}),
+		data.NewField("load", nil, []float64{0.5, 1.25}),
+	)}
+
+	for _, tc := range []struct {
+		name    string
+		panelID int64
+		frames  data.Frames
+	}{
+		{name: "time series", panelID: 1, frames: frames},
+		{name: "stat", panelID: 3, frames: frames},
+		{name: "table", panelID: 4, frames: table},
+		{name: "no data", panelID: 1, frames: nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, qs := setupRenderer(t, tc.frames)
+			opts := Opts{OrgID: 1, SignedInUser: testUser, DashboardUID: "foo", PanelID: tc.panelID, From: "now-1h", To: "now", Width: 400, Height: 200}
+
+			result, err := r.Render(context.Background(), opts)
+			require.NoError(t, err)
+			assert.Equal(t, "image/png", result.ContentType)
+			img, err := png.Decode(bytes.NewReader(result.Data))
+			require.NoError(t, err)
+			assert.Equal(t, 400, img.Bounds().Dx())
+			assert.Equal(t, 200, img.Bounds().Dy())
+
+			opts.Format = FormatSVG
+			result, err = r.Render(context.Background(), opts)
+			require.NoError(t, err)
+			assert.Equal(t, "image/svg+xml", result.ContentType)
+			assert.True(t, strings.HasPrefix(string(result.Data), "<svg"))
+
+			req := qs.Calls[0].Arguments.Get(3).(dtos.MetricRequest)
+			assert.Equal(t, "now-1h", req.From)
+			require.Len(t, req.Queries, 1)
+		})
+	}
+
+	t.Run("the queries run as the requester", func(t *testing.T) {
+		r, qs := setupRenderer(t, frames)
+		_, err := r.Render(context.Background(), Opts{OrgID: 1, SignedInUser: testUser, DashboardUID: "foo", PanelID: 1, Width: 400, Height: 200})
+		require.NoError(t, err)
+		assert.Same(t, testUser, qs.Calls[0].Arguments.Get(1))
+	})
+
+	t.Run("rendering without the identity of the requester fails", func(t *testing.T) {
+		r, qs := setupRenderer(t, frames)
+		_, err := r.Render(context.Background(), Opts{OrgID: 1, DashboardUID: "foo", PanelID: 1, Width: 400, Height: 200})
+		assert.ErrorIs(t, err, ErrNoIdentity)
+		_, err = r.Render(context.Background(), Opts{OrgID: 2, SignedInUser: testUser, DashboardUID: "foo", PanelID: 1, Width: 400, Height: 200})
+		assert.ErrorIs(t, err, ErrNoIdentity)
+		qs.AssertNotCalled(t, "QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unsupported panels fail", func(t *testing.T) {
+		r, _ := setupRenderer(t, frames)
+		_, err := r.Render(context.Background(), Opts{OrgID: 1, SignedInUser: testUser, DashboardUID: "foo", PanelID: 5, Width: 400, Height: 200})
+		assert.ErrorIs(t, err, ErrUnsupportedPanel)
+	})
+
+	t.Run("failed queries fail", func(t *testing.T) {
+		r, qs := setupRenderer(t, nil)
+		qs.ExpectedCalls = nil
+		qs.On("QueryData", mock.Anything, mock.Anything, false, mock.AnythingOfType("dtos.MetricRequest")).
+			Return(&backend.QueryDataResponse{Responses: backend.Responses{"A": {Error: assert.AnError}}}, nil)
+		_, err := r.Render(context.Background(), Opts{OrgID: 1, SignedInUser: testUser, DashboardUID: "foo", PanelID: 1, Width: 400, Height: 200})
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestFormatValue(t *testing.T) {
+	one := 1
+	for _, tc := range []struct {
+		value    float64
+		unit     string
+		decimals *int
+		expected string
+	}{
+		{value: 42, expected: "42"},
+		{value: 1.2345, expected: "1.23"},
+		{value: 1.2345, decimals: &one, expected: "1.2"},
+		{value: 85, unit: "percent", expected: "85%"},
+		{value: 0.5, unit: "percentunit", expected: "50%"},
+		{value: 250, unit: "ms", expected: "250 ms"},
+		{value: 90, unit: "s", expected: "1.5 min"},
+		{value: 2048, unit: "bytes", expected: "2.0 KiB"},
+		{value: 1500000, unit: "short", expected: "1.5 Mil"},
+		{value: 3, unit: "suffix: req", expected: "3 req"},
+		{value: 3, unit: "prefix:$", expected: "$3"},
+	} {
+		assert.Equal(t, tc.expected, formatValue(tc.value, tc.unit, tc.decimals), "%v %s", tc.value, tc.unit)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	values := []float64{3, 1, 4, 1, 5}
+	for calc, expected := range map[string]float64{
+		"":      5,
+		"last":  5,
+		"first": 3,
+		"min":   1,
+		"max":   5,
+		"sum":   14,
+		"mean":  2.8,
+		"count": 5,
+		"range": 4,
+	} {
+		v, ok := reduce(values, calc)
+		assert.True(t, ok)
+		assert.InDelta(t, expected, v, 1e-9, calc)
+	}
+
+	_, ok := reduce(nil, "max")
+	assert.False(t, ok)
+}
+
+func TestThresholdColor(t *testing.T) {
+	p := testPanel(t, 3)
+	fallback := hexColor("#ccccdc")
+	assert.Equal(t, namedColors["green"], thresholdColor(p.FieldConfig.Defaults.Thresholds, 10, fallback))
+	assert.Equal(t, namedColors["red"], thresholdColor(p.FieldConfig.Defaults.Thresholds, 90, fallback))
+	assert.Equal(t, fallback, thresholdColor(nil, 90, fallback))
+}
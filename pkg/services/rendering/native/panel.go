@@ -0,0 +1,150 @@
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const mixedDatasourceUID = "-- Mixed --"
+
+type panel struct {
+	ID            int64             `json:"id"`
+	Type          string            `json:"type"`
+	Title         string            `json:"title"`
+	Datasource    json.RawMessage   `json:"datasource"`
+	Targets       []json.RawMessage `json:"targets"`
+	MaxDataPoints int64             `json:"maxDataPoints"`
+	FieldConfig   fieldConfig       `json:"fieldConfig"`
+	Options       panelOptions      `json:"options"`
+	Panels        []panel           `json:"panels"`
+}
+
+type fieldConfig struct {
+	Defaults fieldDefaults `json:"defaults"`
+}
+
+type fieldDefaults struct {
+	Unit       string      `json:"unit"`
+	Decimals   *int        `json:"decimals"`
+	Min        *float64    `json:"min"`
+	Max        *float64    `json:"max"`
+	Thresholds *thresholds `json:"thresholds"`
+}
+
+type thresholds struct {
+	Steps []thresholdStep `json:"steps"`
+}
+
+type thresholdStep struct {
+	Color string `json:"color"`
+	// Value is nil for the base step
+	Value *float64 `json:"value"`
+}
+
+type panelOptions struct {
+	ReduceOptions reduceOptions `json:"reduceOptions"`
+}
+
+type reduceOptions struct {
+	Calcs []string `json:"calcs"`
+}
+
+// findPanel returns the panel with the ID, including the panels of collapsed rows
+func findPanel(panels []panel, id int64) *panel {
+	for i := range panels {
+		if panels[i].ID == id {
+			return &panels[i]
+		}
+		if p := findPanel(panels[i].Panels, id); p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+func getPanel(dashboard *simplejson.Json, id int64) (*panel, error) {
+	b, err := dashboard.Get("panels").MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var panels []panel
+	if err := json.Unmarshal(b, &panels); err != nil {
+		return nil, fmt.Errorf("failed to read dashboard panels: %w", err)
+	}
+
+	p := findPanel(panels, id)
+	if p == nil {
+		return nil, ErrPanelNotFound
+	}
+	return p, nil
+}
+
+// datasourceUID returns the UID of a datasource reference, which is an object in current dashboards and the name or
+// the UID of the datasource in older ones
+func datasourceUID(ref json.RawMessage) string {
+	if len(ref) == 0 {
+		return ""
+	}
+
+	var uid string
+	if err := json.Unmarshal(ref, &uid); err == nil {
+		return uid
+	}
+
+	var obj struct {
+		UID string `json:"uid"`
+	}
+	if err := json.Unmarshal(ref, &obj); err == nil {
+		return obj.UID
+	}
+	return ""
+}
+
+// queries returns the queries of the visible targets of the panel, with the panel datasource when they do not have their
+// own. Template variables are not supported as the renderer does not know their values.
+func (p *panel) queries(maxDataPoints int64, intervalMs int64) ([]*simplejson.Json, error) {
+	panelDatasource := datasourceUID(p.Datasource)
+	if strings.HasPrefix(panelDatasource, "$") {
+		return nil, fmt.Errorf("%w: panel datasource %s", ErrTemplateVariable, panelDatasource)
+	}
+
+	queries := make([]*simplejson.Json, 0, len(p.Targets))
+	for _, target := range p.Targets {
+		q, err := simplejson.NewJson(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read panel target: %w", err)
+		}
+		if q.Get("hide").MustBool(false) {
+			continue
+		}
+
+		b, err := q.Get("datasource").MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		uid := datasourceUID(b)
+		if uid == "" || uid == mixedDatasourceUID {
+			if panelDatasource == "" || panelDatasource == mixedDatasourceUID {
+				return nil, fmt.Errorf("panel target %s has no datasource", q.Get("refId").MustString())
+			}
+			uid = panelDatasource
+		}
+		if strings.HasPrefix(uid, "$") {
+			return nil, fmt.Errorf("%w: target datasource %s", ErrTemplateVariable, uid)
+		}
+
+		q.Set("datasource", map[string]interface{}{"uid": uid})
+		q.Set("maxDataPoints", maxDataPoints)
+		q.Set("intervalMs", intervalMs)
+		queries = append(queries, q)
+	}
+
+	if len(queries) == 0 {
+		return nil, ErrNoQueries
+	}
+	return queries, nil
+}
@@ -0,0 +1,80 @@
+package native
+
+import (
+	"math"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const statValueMaxSize = 80.0
+
+type statValue struct {
+	name  string
+	value float64
+}
+
+func statValues(p *panel, frames data.Frames) []statValue {
+	calc := ""
+	if len(p.Options.ReduceOptions.Calcs) > 0 {
+		calc = p.Options.ReduceOptions.Calcs[0]
+	}
+
+	var values []statValue
+	for _, s := range frameSeries(frames) {
+		if v, ok := reduce(s.notNullValues(), calc); ok {
+			values = append(values, statValue{name: s.name, value: v})
+		}
+	}
+	return values
+}
+
+// renderStat draws the reduced value of every numeric field, in a row when the panel is wider than high and in a column
+// otherwise
+func renderStat(c canvas, p *panel, frames data.Frames, r renderRange) {
+	w, h := c.size()
+	values := statValues(p, frames)
+	if len(values) == 0 {
+		c.text(w/2, h/2, labelSize, r.theme.textWeak, anchorMiddle, errNoData.Error())
+		return
+	}
+
+	horizontal := w >= h
+	n := float64(len(values))
+	cellW, cellH := w, h/n
+	if horizontal {
+		cellW, cellH = w/n, h
+	}
+
+	defaults := p.FieldConfig.Defaults
+	for i, v := range values {
+		x, y := 0.0, float64(i)*cellH
+		if horizontal {
+			x, y = float64(i)*cellW, 0
+		}
+		cell := subCanvas(c, x, y, cellW, cellH)
+
+		text := formatValue(v.value, defaults.Unit, defaults.Decimals)
+		showName := len(values) > 1
+		available := cellH - 2*padding
+		if showName {
+			available -= labelSize + padding
+		}
+		size := math.Min(statValueMaxSize, available*0.7)
+		// the value must fit in the width of the cell
+		for size > labelSize && cell.textWidth(text, size) > cellW-2*padding {
+			size--
+		}
+		if size < labelSize {
+			size = labelSize
+		}
+
+		col := thresholdColor(defaults.Thresholds, v.value, r.theme.text)
+		baseline := cellH/2 + size/3
+		if showName {
+			baseline -= (labelSize + padding) / 2
+			cell.text(cellW/2, baseline+padding+labelSize, labelSize, r.theme.textWeak, anchorMiddle,
+				truncate(cell, v.name, labelSize, cellW-2*padding))
+		}
+		cell.text(cellW/2, baseline, size, col, anchorMiddle, truncate(cell, text, size, cellW-2*padding))
+	}
+}
@@ -0,0 +1,85 @@
+package native
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const tableRowHeight = labelSize + padding
+
+// renderTable draws the rows of the first frame with data that fit in the panel
+func renderTable(c canvas, p *panel, frames data.Frames, r renderRange) {
+	w, h := c.size()
+
+	var frame *data.Frame
+	for _, f := range frames {
+		if f != nil && len(f.Fields) > 0 && f.Rows() > 0 {
+			frame = f
+			break
+		}
+	}
+	if frame == nil {
+		c.text(w/2, h/2, labelSize, r.theme.textWeak, anchorMiddle, errNoData.Error())
+		return
+	}
+
+	colWidth := (w - padding) / float64(len(frame.Fields))
+	cellWidth := colWidth - padding
+	textY := func(row int) float64 {
+		return float64(row)*tableRowHeight + padding/2 + labelSize
+	}
+
+	for i, f := range frame.Fields {
+		x := padding + float64(i)*colWidth
+		c.text(x, textY(0), labelSize, r.theme.text, anchorStart, truncate(c, fieldName(frame, f, false), labelSize, cellWidth))
+	}
+	c.polyline([]point{{0, tableRowHeight}, {w, tableRowHeight}}, r.theme.grid, 1)
+
+	rows := int(math.Floor(h/tableRowHeight)) - 1
+	total := frame.Rows()
+	if rows < total && rows > 0 {
+		// the last visible row tells how many rows are missing
+		rows--
+	}
+	for row := 0; row < rows && row < total; row++ {
+		for i, f := range frame.Fields {
+			x := padding + float64(i)*colWidth
+			text := formatCell(f, row, p.FieldConfig.Defaults)
+			c.text(x, textY(row+1), labelSize, r.theme.textWeak, anchorStart, truncate(c, text, labelSize, cellWidth))
+		}
+		c.polyline([]point{{0, float64(row+2) * tableRowHeight}, {w, float64(row+2) * tableRowHeight}}, r.theme.grid, 1)
+	}
+	if rows >= 0 && rows < total {
+		c.text(padding, textY(rows+1), labelSize, r.theme.textWeak, anchorStart, fmt.Sprintf("%d more rows", total-rows))
+	}
+}
+
+func formatCell(f *data.Field, row int, defaults fieldDefaults) string {
+	v, ok := f.ConcreteAt(row)
+	if !ok {
+		return ""
+	}
+
+	switch value := v.(type) {
+	case time.Time:
+		return value.UTC().Format("2006-01-02 15:04:05")
+	case string:
+		return value
+	case bool:
+		return fmt.Sprintf("%t", value)
+	}
+
+	if f.Type().Numeric() {
+		if fv, err := f.FloatAt(row); err == nil {
+			unit, decimals := defaults.Unit, defaults.Decimals
+			if f.Config != nil && f.Config.Unit != "" {
+				unit = f.Config.Unit
+			}
+			return formatValue(fv, unit, decimals)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,301 @@
+package native
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// series are the values of a numeric field, times are nil when the frame has no time field
+type series struct {
+	name   string
+	times  []int64
+	values []*float64
+}
+
+func (s series) notNullValues() []float64 {
+	values := make([]float64, 0, len(s.values))
+	for _, v := range s.values {
+		if v != nil && !math.IsNaN(*v) && !math.IsInf(*v, 0) {
+			values = append(values, *v)
+		}
+	}
+	return values
+}
+
+// frameSeries returns the numeric fields of the frames
+func frameSeries(frames data.Frames) []series {
+	var result []series
+	for _, frame := range frames {
+		if frame == nil {
+			continue
+		}
+
+		var timeField *data.Field
+		for _, f := range frame.Fields {
+			if f.Type().Time() {
+				timeField = f
+				break
+			}
+		}
+
+		for _, f := range frame.Fields {
+			if !f.Type().Numeric() {
+				continue
+			}
+			s := series{name: fieldName(frame, f, len(frames) > 1)}
+			for i := 0; i < f.Len(); i++ {
+				v, err := f.NullableFloatAt(i)
+				if err != nil {
+					v = nil
+				}
+				s.values = append(s.values, v)
+				if timeField != nil {
+					if t, ok := timeField.ConcreteAt(i); ok {
+						s.times = append(s.times, t.(time.Time).UnixMilli())
+					} else {
+						s.times = append(s.times, 0)
+					}
+				}
+			}
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// fieldName returns the display name of the field, with its labels or the name of the frame to tell apart series
+// with the same field name
+func fieldName(frame *data.Frame, f *data.Field, multipleFrames bool) string {
+	if f.Config != nil {
+		if f.Config.DisplayNameFromDS != "" {
+			return f.Config.DisplayNameFromDS
+		}
+		if f.Config.DisplayName != "" {
+			return f.Config.DisplayName
+		}
+	}
+	if len(f.Labels) > 0 {
+		keys := make([]string, 0, len(f.Labels))
+		for k := range f.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = k + "=" + f.Labels[k]
+		}
+		labels := "{" + strings.Join(pairs, ", ") + "}"
+		if f.Name == "" || f.Name == "Value" {
+			return labels
+		}
+		return f.Name + " " + labels
+	}
+	if multipleFrames && frame.Name != "" && (f.Name == "" || f.Name == "Value") {
+		return frame.Name
+	}
+	if f.Name == "" {
+		return "Value"
+	}
+	return f.Name
+}
+
+// niceStep returns a step of 1, 2 or 5 times a power of ten close to the value
+func niceStep(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	exp := math.Pow(10, math.Floor(math.Log10(v)))
+	switch f := v / exp; {
+	case f < 1.5:
+		return exp
+	case f < 3.5:
+		return 2 * exp
+	case f < 7.5:
+		return 5 * exp
+	default:
+		return 10 * exp
+	}
+}
+
+var timeSteps = []time.Duration{
+	time.Second, 5 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour,
+}
+
+func niceTimeStep(rangeMs int64, ticks int) int64 {
+	target := time.Duration(rangeMs/int64(ticks)) * time.Millisecond
+	for _, step := range timeSteps {
+		if step >= target {
+			return step.Milliseconds()
+		}
+	}
+	return timeSteps[len(timeSteps)-1].Milliseconds()
+}
+
+func renderTimeSeries(c canvas, p *panel, frames data.Frames, r renderRange) {
+	w, h := c.size()
+	all := frameSeries(frames)
+	var visible []series
+	for _, s := range all {
+		if s.times != nil {
+			visible = append(visible, s)
+		}
+	}
+	if len(visible) == 0 {
+		c.text(w/2, h/2, labelSize, r.theme.textWeak, anchorMiddle, "data does not have a time field")
+		return
+	}
+
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, s := range visible {
+		for _, v := range s.notNullValues() {
+			minValue = math.Min(minValue, v)
+			maxValue = math.Max(maxValue, v)
+		}
+	}
+	defaults := p.FieldConfig.Defaults
+	if defaults.Min != nil {
+		minValue = *defaults.Min
+	}
+	if defaults.Max != nil {
+		maxValue = *defaults.Max
+	}
+	if math.IsInf(minValue, 0) || math.IsInf(maxValue, 0) {
+		minValue, maxValue = 0, 1
+	}
+	if minValue == maxValue {
+		minValue, maxValue = minValue-1, maxValue+1
+	}
+
+	step := niceStep((maxValue - minValue) / 4)
+	if defaults.Min == nil {
+		minValue = math.Floor(minValue/step) * step
+	}
+	if defaults.Max == nil {
+		maxValue = math.Ceil(maxValue/step) * step
+	}
+
+	var yLabels []string
+	var yTicks []float64
+	for v := minValue; v <= maxValue+step/2; v += step {
+		yTicks = append(yTicks, v)
+		yLabels = append(yLabels, formatValue(v, defaults.Unit, defaults.Decimals))
+	}
+	yLabelWidth := 0.0
+	for _, l := range yLabels {
+		yLabelWidth = math.Max(yLabelWidth, c.textWidth(l, labelSize))
+	}
+
+	legendItems := make([]legendItem, len(visible))
+	for i, s := range visible {
+		legendItems[i] = legendItem{name: s.name, color: seriesColors[i%len(seriesColors)]}
+	}
+	legendHeight := drawLegend(c, legendItems, r, w, h)
+
+	left := yLabelWidth + 2*padding
+	right := w - padding
+	top := padding / 2
+	bottom := h - legendHeight - labelSize - padding
+	if right <= left || bottom <= top {
+		return
+	}
+
+	fromMs, toMs := r.fromMs, r.toMs
+	if toMs <= fromMs {
+		toMs = fromMs + 1
+	}
+	x := func(ms int64) float64 {
+		return left + (right-left)*float64(ms-fromMs)/float64(toMs-fromMs)
+	}
+	y := func(v float64) float64 {
+		return bottom - (bottom-top)*(v-minValue)/(maxValue-minValue)
+	}
+
+	for i, v := range yTicks {
+		c.polyline([]point{{left, y(v)}, {right, y(v)}}, r.theme.grid, 1)
+		c.text(left-padding, y(v)+labelSize/3, labelSize, r.theme.textWeak, anchorEnd, yLabels[i])
+	}
+
+	timeStep := niceTimeStep(toMs-fromMs, int(math.Max(1, (right-left)/100)))
+	for t := (fromMs/timeStep + 1) * timeStep; t < toMs; t += timeStep {
+		c.polyline([]point{{x(t), top}, {x(t), bottom}}, r.theme.grid, 1)
+		c.text(x(t), bottom+labelSize+padding/2, labelSize, r.theme.textWeak, anchorMiddle, formatTime(t, toMs-fromMs))
+	}
+
+	for i, s := range visible {
+		col := seriesColors[i%len(seriesColors)]
+		var segment []point
+		for j, v := range s.values {
+			// null values and values outside of the time range break the line
+			if v == nil || math.IsNaN(*v) || math.IsInf(*v, 0) || s.times[j] < fromMs || s.times[j] > toMs {
+				drawSegment(c, segment, col)
+				segment = nil
+				continue
+			}
+			value := math.Max(minValue, math.Min(maxValue, *v))
+			segment = append(segment, point{x(s.times[j]), y(value)})
+		}
+		drawSegment(c, segment, col)
+	}
+}
+
+func drawSegment(c canvas, points []point, col color.NRGBA) {
+	switch len(points) {
+	case 0:
+	case 1:
+		c.rect(points[0].x-1, points[0].y-1, 3, 3, col)
+	default:
+		c.polyline(points, col, 1)
+	}
+}
+
+type legendItem struct {
+	name  string
+	color color.NRGBA
+}
+
+// drawLegend draws the legend at the bottom of the canvas and returns its height, at most three rows are drawn
+func drawLegend(c canvas, items []legendItem, r renderRange, w, h float64) float64 {
+	const maxRows = 3
+	rowHeight := labelSize + padding
+	type placed struct {
+		item legendItem
+		x    float64
+		row  int
+	}
+
+	var legend []placed
+	x, row := padding, 0
+	for _, item := range items {
+		name := truncate(c, item.name, labelSize, w/2)
+		itemWidth := labelSize + padding/2 + c.textWidth(name, labelSize) + 2*padding
+		if x+itemWidth > w && x > padding {
+			row++
+			x = padding
+		}
+		if row >= maxRows {
+			break
+		}
+		legend = append(legend, placed{item: legendItem{name: name, color: item.color}, x: x, row: row})
+		x += itemWidth
+	}
+
+	height := float64(row+1) * rowHeight
+	if row >= maxRows {
+		height = maxRows * rowHeight
+	}
+	top := h - height
+	for _, l := range legend {
+		rowTop := top + float64(l.row)*rowHeight
+		c.rect(l.x, rowTop+padding/2, labelSize-2, labelSize-2, l.item.color)
+		c.text(l.x+labelSize+padding/2, rowTop+padding/2+labelSize-2, labelSize, r.theme.text, anchorStart, l.item.name)
+	}
+	return height
+}
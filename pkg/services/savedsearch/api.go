@@ -0,0 +1,115 @@
+package savedsearch
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *SavedSearchService) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/saved-searches", func(entities routing.RouteRegister) {
+		entities.Get("/", middleware.ReqSignedInNoAnonymous, routing.Wrap(s.listHandler))
+		entities.Post("/", middleware.ReqSignedInNoAnonymous, routing.Wrap(s.createHandler))
+		entities.Get("/:uid", middleware.ReqSignedInNoAnonymous, routing.Wrap(s.getHandler))
+		entities.Put("/:uid", middleware.ReqSignedInNoAnonymous, routing.Wrap(s.updateHandler))
+		entities.Delete("/:uid", middleware.ReqSignedInNoAnonymous, routing.Wrap(s.deleteHandler))
+		entities.Get("/:uid/matches", middleware.ReqSignedInNoAnonymous, routing.Wrap(s.matchesHandler))
+	})
+}
+
+func (s *SavedSearchService) listHandler(c *contextmodel.ReqContext) response.Response {
+	searches, err := s.GetSavedSearches(c.Req.Context(), c.SignedInUser)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get saved searches", err)
+	}
+	return response.JSON(http.StatusOK, searches)
+}
+
+func (s *SavedSearchService) createHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := SaveSavedSearchCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	search, err := s.CreateSavedSearch(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return savedSearchErrorResponse(err, "Failed to create saved search")
+	}
+	return response.JSON(http.StatusOK, search)
+}
+
+func (s *SavedSearchService) getHandler(c *contextmodel.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+	if !util.IsValidShortUID(uid) {
+		return response.Error(http.StatusNotFound, "Saved search not found", nil)
+	}
+
+	search, err := s.GetSavedSearch(c.Req.Context(), c.SignedInUser, uid)
+	if err != nil {
+		return savedSearchErrorResponse(err, "Failed to get saved search")
+	}
+	return response.JSON(http.StatusOK, search)
+}
+
+func (s *SavedSearchService) updateHandler(c *contextmodel.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+	if !util.IsValidShortUID(uid) {
+		return response.Error(http.StatusNotFound, "Saved search not found", nil)
+	}
+
+	cmd := SaveSavedSearchCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	search, err := s.UpdateSavedSearch(c.Req.Context(), c.SignedInUser, uid, cmd)
+	if err != nil {
+		return savedSearchErrorResponse(err, "Failed to update saved search")
+	}
+	return response.JSON(http.StatusOK, search)
+}
+
+func (s *SavedSearchService) deleteHandler(c *contextmodel.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+	if !util.IsValidShortUID(uid) {
+		return response.Error(http.StatusNotFound, "Saved search not found", nil)
+	}
+
+	if err := s.DeleteSavedSearch(c.Req.Context(), c.SignedInUser, uid); err != nil {
+		return savedSearchErrorResponse(err, "Failed to delete saved search")
+	}
+	return response.Success("Saved search deleted")
+}
+
+func (s *SavedSearchService) matchesHandler(c *contextmodel.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+	if !util.IsValidShortUID(uid) {
+		return response.Error(http.StatusNotFound, "Saved search not found", nil)
+	}
+
+	if !s.search.IsReady(c.Req.Context(), c.OrgID).IsReady {
+		return response.Error(http.StatusServiceUnavailable, "The search index is not ready", nil)
+	}
+
+	matches, err := s.GetSavedSearchMatches(c.Req.Context(), c.SignedInUser, uid)
+	if err != nil {
+		return savedSearchErrorResponse(err, "Failed to run saved search")
+	}
+	return response.JSON(http.StatusOK, matches)
+}
+
+func savedSearchErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrSavedSearchNotFound):
+		return response.Error(http.StatusNotFound, "Saved search not found", err)
+	case errors.Is(err, ErrSavedSearchTitleEmpty):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
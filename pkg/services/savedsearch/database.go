@@ -0,0 +1,71 @@
+package savedsearch
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+func (s *SavedSearchService) insert(ctx context.Context, search *SavedSearch) error {
+	return s.store.WithDbSession(ctx, func(session *db.Session) error {
+		_, err := session.Insert(search)
+		return err
+	})
+}
+
+// get returns the saved search of the user with the UID
+func (s *SavedSearchService) get(ctx context.Context, orgID, userID int64, uid string) (*SavedSearch, error) {
+	search := SavedSearch{}
+	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
+		exists, err := session.Where("org_id = ? AND user_id = ? AND uid = ?", orgID, userID, uid).Get(&search)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrSavedSearchNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
+
+// list returns the saved searches of the user, sorted by title
+func (s *SavedSearchService) list(ctx context.Context, orgID, userID int64) ([]SavedSearch, error) {
+	searches := []SavedSearch{}
+	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
+		return session.Where("org_id = ? AND user_id = ?", orgID, userID).Asc("title").Find(&searches)
+	})
+	return searches, err
+}
+
+// listNotified returns the saved searches notifying their owner of the new matching dashboards
+func (s *SavedSearchService) listNotified(ctx context.Context) ([]SavedSearch, error) {
+	searches := []SavedSearch{}
+	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
+		return session.Where("notify = ?", s.store.GetDialect().BooleanStr(true)).Asc("id").Find(&searches)
+	})
+	return searches, err
+}
+
+func (s *SavedSearchService) update(ctx context.Context, search *SavedSearch, columns ...string) error {
+	return s.store.WithDbSession(ctx, func(session *db.Session) error {
+		_, err := session.ID(search.ID).Cols(columns...).Update(search)
+		return err
+	})
+}
+
+func (s *SavedSearchService) delete(ctx context.Context, orgID, userID int64, uid string) error {
+	return s.store.WithDbSession(ctx, func(session *db.Session) error {
+		affected, err := session.Where("org_id = ? AND user_id = ? AND uid = ?", orgID, userID, uid).Delete(&SavedSearch{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrSavedSearchNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,185 @@
+package savedsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/searchV2"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+const (
+	// maxMatches is the maximum number of dashboards matching a saved search
+	maxMatches = 1000
+	// notificationTemplate is the template of the emails sent when new dashboards match a saved search
+	notificationTemplate = "saved_search_notification"
+)
+
+// matches runs the search as the user and returns the matching dashboards
+func (s *SavedSearchService) matches(ctx context.Context, usr *backend.User, orgID int64, query searchV2.DashboardQuery) ([]Match, error) {
+	if len(query.Kind) == 0 {
+		query.Kind = []string{"dashboard"}
+	}
+	query.Limit = maxMatches
+	query.From = 0
+	query.Facet = nil
+	query.Explain = false
+	query.WithAllowedActions = false
+
+	rsp := s.search.DoDashboardQuery(ctx, usr, orgID, query)
+	if rsp.Error != nil {
+		return nil, rsp.Error
+	}
+	if len(rsp.Frames) == 0 {
+		return nil, errors.New("invalid search response")
+	}
+	return framesMatches(rsp.Frames[0])
+}
+
+// framesMatches reads the dashboards of the query results frame of the search
+func framesMatches(frame *data.Frame) ([]Match, error) {
+	fields := map[string]*data.Field{}
+	for _, f := range frame.Fields {
+		fields[f.Name] = f
+	}
+	uids, names, urls := fields["uid"], fields["name"], fields["url"]
+	if uids == nil || names == nil || urls == nil {
+		return nil, fmt.Errorf("invalid search response frame %q", frame.Name)
+	}
+
+	matches := make([]Match, 0, uids.Len())
+	for i := 0; i < uids.Len(); i++ {
+		uid, _ := uids.At(i).(string)
+		name, _ := names.At(i).(string)
+		url, _ := urls.At(i).(string)
+		matches = append(matches, Match{UID: uid, Name: name, URL: url})
+	}
+	return matches, nil
+}
+
+func matchUIDs(matches []Match) *UIDs {
+	uids := make(UIDs, 0, len(matches))
+	for _, m := range matches {
+		uids = append(uids, m.UID)
+	}
+	return &uids
+}
+
+// Run evaluates the saved searches with notifications at the evaluation interval. When Grafana runs in high
+// availability, the searches are evaluated by a single instance.
+func (s *SavedSearchService) Run(ctx context.Context) error {
+	interval := s.cfg.Search.SavedSearchEvaluationInterval
+	if interval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := s.serverLock.LockAndExecute(ctx, "evaluate saved searches", interval/2, func(ctx context.Context) {
+				s.evaluateAll(ctx)
+			})
+			if err != nil {
+				s.log.Error("Failed to evaluate the saved searches", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *SavedSearchService) evaluateAll(ctx context.Context) {
+	searches, err := s.listNotified(ctx)
+	if err != nil {
+		s.log.Error("Failed to list the saved searches", "error", err)
+		return
+	}
+
+	for i := range searches {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.evaluate(ctx, &searches[i]); err != nil {
+			s.log.Warn("Failed to evaluate the saved search", "uid", searches[i].UID, "orgId", searches[i].OrgID, "error", err)
+		}
+	}
+}
+
+// evaluate runs the saved search as its owner and notifies the owner of the dashboards which didn't match the
+// search at its last evaluation. The first evaluation only records the matching dashboards.
+func (s *SavedSearchService) evaluate(ctx context.Context, search *SavedSearch) error {
+	if !s.search.IsReady(ctx, search.OrgID).IsReady {
+		return nil
+	}
+
+	usr, err := s.users.GetSignedInUser(ctx, &user.GetSignedInUserQuery{UserID: search.UserID, OrgID: search.OrgID})
+	if err != nil {
+		return fmt.Errorf("failed to get the owner of the saved search: %w", err)
+	}
+
+	matches, err := s.matches(ctx, backendUser(usr), search.OrgID, search.dashboardQuery())
+	if err != nil {
+		return err
+	}
+
+	newMatches := []Match{}
+	if search.LastEvaluated != 0 {
+		matched := search.matchedUIDs()
+		for _, m := range matches {
+			if !matched[m.UID] {
+				newMatches = append(newMatches, m)
+			}
+		}
+	}
+
+	search.MatchedUIDs = matchUIDs(matches)
+	search.LastEvaluated = time.Now().Unix()
+	if err := s.update(ctx, search, "matched_uids", "last_evaluated"); err != nil {
+		return err
+	}
+
+	if len(newMatches) == 0 {
+		return nil
+	}
+	return s.notify(ctx, usr, search, newMatches)
+}
+
+func (s *SavedSearchService) notify(ctx context.Context, usr *user.SignedInUser, search *SavedSearch, matches []Match) error {
+	if usr.Email == "" {
+		s.log.Debug("Not notifying the owner of the saved search without email", "uid", search.UID, "userId", usr.UserID)
+		return nil
+	}
+
+	appURL := strings.TrimSuffix(s.cfg.AppURL, "/")
+	dashboards := make([]map[string]string, 0, len(matches))
+	for _, m := range matches {
+		dashboards = append(dashboards, map[string]string{
+			"Name": m.Name,
+			"Url":  appURL + strings.TrimPrefix(m.URL, s.cfg.AppSubURL),
+		})
+	}
+
+	err := s.notifications.SendEmailCommandHandler(ctx, &notifications.SendEmailCommand{
+		To:       []string{usr.Email},
+		Template: notificationTemplate,
+		Data: map[string]interface{}{
+			"Name":       usr.NameOrFallback(),
+			"Title":      search.Title,
+			"Dashboards": dashboards,
+		},
+	})
+	if errors.Is(err, notifications.ErrSmtpNotEnabled) {
+		s.log.Debug("Not notifying the owner of the saved search, SMTP is not enabled", "uid", search.UID)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package savedsearch
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/services/searchV2"
+)
+
+var (
+	ErrSavedSearchNotFound   = errors.New("saved search not found")
+	ErrSavedSearchTitleEmpty = errors.New("saved search title cannot be empty")
+)
+
+// SavedSearch is the model for the saved search definitions. The matched UIDs are the UIDs of the dashboards
+// matching the search at its last evaluation, used to find the new dashboards matching the search.
+type SavedSearch struct {
+	ID            int64  `xorm:"pk autoincr 'id'"`
+	UID           string `xorm:"uid"`
+	OrgID         int64  `xorm:"org_id"`
+	UserID        int64  `xorm:"user_id"`
+	Title         string
+	Query         *Query `xorm:"query"`
+	Notify        bool
+	MatchedUIDs   *UIDs `xorm:"matched_uids"`
+	Created       int64
+	Updated       int64
+	LastEvaluated int64
+}
+
+func (s SavedSearch) TableName() string {
+	return "saved_search"
+}
+
+func (s SavedSearch) dashboardQuery() searchV2.DashboardQuery {
+	if s.Query == nil {
+		return searchV2.DashboardQuery{}
+	}
+	return searchV2.DashboardQuery(*s.Query)
+}
+
+func (s SavedSearch) matchedUIDs() map[string]bool {
+	matched := map[string]bool{}
+	if s.MatchedUIDs != nil {
+		for _, uid := range *s.MatchedUIDs {
+			matched[uid] = true
+		}
+	}
+	return matched
+}
+
+func (s SavedSearch) toDTO() SavedSearchDTO {
+	return SavedSearchDTO{
+		UID:           s.UID,
+		Title:         s.Title,
+		Query:         s.dashboardQuery(),
+		Notify:        s.Notify,
+		Created:       s.Created,
+		Updated:       s.Updated,
+		LastEvaluated: s.LastEvaluated,
+	}
+}
+
+// Query is the query and the filters of a saved search
+type Query searchV2.DashboardQuery
+
+func (q *Query) FromDB(data []byte) error {
+	return json.Unmarshal(data, q)
+}
+
+func (q *Query) ToDB() ([]byte, error) {
+	return json.Marshal(q)
+}
+
+// UIDs is a list of dashboard UIDs
+type UIDs []string
+
+func (u *UIDs) FromDB(data []byte) error {
+	return json.Unmarshal(data, u)
+}
+
+func (u *UIDs) ToDB() ([]byte, error) {
+	return json.Marshal(u)
+}
+
+type SavedSearchDTO struct {
+	UID           string                  `json:"uid"`
+	Title         string                  `json:"title"`
+	Query         searchV2.DashboardQuery `json:"query"`
+	Notify        bool                    `json:"notify"`
+	Created       int64                   `json:"created"`
+	Updated       int64                   `json:"updated"`
+	LastEvaluated int64                   `json:"lastEvaluated"`
+}
+
+// SaveSavedSearchCommand is the command for creating or updating a saved search
+type SaveSavedSearchCommand struct {
+	// The title of the saved search.
+	Title string `json:"title"`
+	// The query and the filters of the search, as sent to the search API.
+	Query searchV2.DashboardQuery `json:"query"`
+	// Notify the owner of the saved search by email when new dashboards match the search.
+	Notify bool `json:"notify"`
+}
+
+// Match is a dashboard matching a saved search
+type Match struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
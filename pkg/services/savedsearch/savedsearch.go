@@ -0,0 +1,185 @@
+package savedsearch
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/searchV2"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// searcher runs the searches of the saved searches on the search index
+type searcher interface {
+	DoDashboardQuery(ctx context.Context, user *backend.User, orgID int64, query searchV2.DashboardQuery) *backend.DataResponse
+	IsReady(ctx context.Context, orgID int64) searchV2.IsSearchReadyResponse
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore db.DB, routeRegister routing.RouteRegister, search searchV2.SearchService,
+	userService user.Service, notificationService notifications.Service, serverLock *serverlock.ServerLockService) *SavedSearchService {
+	s := &SavedSearchService{
+		cfg:           cfg,
+		store:         sqlStore,
+		search:        search,
+		users:         userService,
+		notifications: notificationService,
+		serverLock:    serverLock,
+		RouteRegister: routeRegister,
+		log:           log.New("saved-search"),
+	}
+
+	// Register routes only when the search index is enabled
+	if !s.IsDisabled() {
+		s.registerAPIEndpoints()
+	}
+
+	return s
+}
+
+type Service interface {
+	CreateSavedSearch(ctx context.Context, user *user.SignedInUser, cmd SaveSavedSearchCommand) (SavedSearchDTO, error)
+	UpdateSavedSearch(ctx context.Context, user *user.SignedInUser, uid string, cmd SaveSavedSearchCommand) (SavedSearchDTO, error)
+	GetSavedSearch(ctx context.Context, user *user.SignedInUser, uid string) (SavedSearchDTO, error)
+	GetSavedSearches(ctx context.Context, user *user.SignedInUser) ([]SavedSearchDTO, error)
+	DeleteSavedSearch(ctx context.Context, user *user.SignedInUser, uid string) error
+	GetSavedSearchMatches(ctx context.Context, user *user.SignedInUser, uid string) ([]Match, error)
+}
+
+// SavedSearchService stores the searches of the users, and notifies them by email when new dashboards match their
+// saved searches.
+type SavedSearchService struct {
+	cfg           *setting.Cfg
+	store         db.DB
+	search        searcher
+	users         user.Service
+	notifications notifications.EmailSender
+	serverLock    *serverlock.ServerLockService
+	RouteRegister routing.RouteRegister
+	log           log.Logger
+}
+
+// IsDisabled disables the service when the search index, used to evaluate the saved searches, is disabled.
+func (s *SavedSearchService) IsDisabled() bool {
+	return !s.cfg.IsFeatureToggleEnabled(featuremgmt.FlagPanelTitleSearch)
+}
+
+func (s *SavedSearchService) CreateSavedSearch(ctx context.Context, usr *user.SignedInUser, cmd SaveSavedSearchCommand) (SavedSearchDTO, error) {
+	if cmd.Title == "" {
+		return SavedSearchDTO{}, ErrSavedSearchTitleEmpty
+	}
+
+	now := time.Now().Unix()
+	query := Query(cmd.Query)
+	search := SavedSearch{
+		UID:     util.GenerateShortUID(),
+		OrgID:   usr.OrgID,
+		UserID:  usr.UserID,
+		Title:   cmd.Title,
+		Query:   &query,
+		Notify:  cmd.Notify,
+		Created: now,
+		Updated: now,
+	}
+	if search.Notify {
+		s.resetMatches(ctx, usr, &search)
+	}
+
+	if err := s.insert(ctx, &search); err != nil {
+		return SavedSearchDTO{}, err
+	}
+	return search.toDTO(), nil
+}
+
+func (s *SavedSearchService) UpdateSavedSearch(ctx context.Context, usr *user.SignedInUser, uid string, cmd SaveSavedSearchCommand) (SavedSearchDTO, error) {
+	if cmd.Title == "" {
+		return SavedSearchDTO{}, ErrSavedSearchTitleEmpty
+	}
+
+	search, err := s.get(ctx, usr.OrgID, usr.UserID, uid)
+	if err != nil {
+		return SavedSearchDTO{}, err
+	}
+
+	query := Query(cmd.Query)
+	search.Title = cmd.Title
+	search.Query = &query
+	search.Notify = cmd.Notify
+	search.Updated = time.Now().Unix()
+	// the dashboards matching the updated search are not new dashboards
+	s.resetMatches(ctx, usr, search)
+
+	if err := s.update(ctx, search, "title", "query", "notify", "matched_uids", "updated", "last_evaluated"); err != nil {
+		return SavedSearchDTO{}, err
+	}
+	return search.toDTO(), nil
+}
+
+func (s *SavedSearchService) GetSavedSearch(ctx context.Context, usr *user.SignedInUser, uid string) (SavedSearchDTO, error) {
+	search, err := s.get(ctx, usr.OrgID, usr.UserID, uid)
+	if err != nil {
+		return SavedSearchDTO{}, err
+	}
+	return search.toDTO(), nil
+}
+
+func (s *SavedSearchService) GetSavedSearches(ctx context.Context, usr *user.SignedInUser) ([]SavedSearchDTO, error) {
+	searches, err := s.list(ctx, usr.OrgID, usr.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]SavedSearchDTO, 0, len(searches))
+	for _, search := range searches {
+		dtos = append(dtos, search.toDTO())
+	}
+	return dtos, nil
+}
+
+func (s *SavedSearchService) DeleteSavedSearch(ctx context.Context, usr *user.SignedInUser, uid string) error {
+	return s.delete(ctx, usr.OrgID, usr.UserID, uid)
+}
+
+// GetSavedSearchMatches runs the saved search and returns the dashboards matching it.
+func (s *SavedSearchService) GetSavedSearchMatches(ctx context.Context, usr *user.SignedInUser, uid string) ([]Match, error) {
+	search, err := s.get(ctx, usr.OrgID, usr.UserID, uid)
+	if err != nil {
+		return nil, err
+	}
+	return s.matches(ctx, backendUser(usr), search.OrgID, search.dashboardQuery())
+}
+
+// resetMatches sets the matched UIDs of the search to the dashboards currently matching it. When the search index
+// is not ready, the matches are set at the next evaluation of the search instead.
+func (s *SavedSearchService) resetMatches(ctx context.Context, usr *user.SignedInUser, search *SavedSearch) {
+	search.MatchedUIDs = nil
+	search.LastEvaluated = 0
+	if !search.Notify || !s.search.IsReady(ctx, search.OrgID).IsReady {
+		return
+	}
+
+	matches, err := s.matches(ctx, backendUser(usr), search.OrgID, search.dashboardQuery())
+	if err != nil {
+		s.log.Warn("Failed to run the saved search", "uid", search.UID, "error", err)
+		return
+	}
+	search.MatchedUIDs = matchUIDs(matches)
+	search.LastEvaluated = time.Now().Unix()
+}
+
+func backendUser(usr *user.SignedInUser) *backend.User {
+	return &backend.User{
+		Login: usr.Login,
+		Email: usr.Email,
+		Name:  usr.Name,
+		Role:  string(usr.OrgRole),
+	}
+}
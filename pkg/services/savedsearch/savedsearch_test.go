@@ -0,0 +1,189 @@
+package savedsearch
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/searchV2"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeSearcher struct {
+	mtx        sync.Mutex
+	dashboards []Match
+	notReady   bool
+	queries    []searchV2.DashboardQuery
+}
+
+func (f *fakeSearcher) setDashboards(dashboards ...Match) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.dashboards = dashboards
+}
+
+func (f *fakeSearcher) DoDashboardQuery(_ context.Context, _ *backend.User, _ int64, query searchV2.DashboardQuery) *backend.DataResponse {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.queries = append(f.queries, query)
+
+	uids := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	uids.Name = "uid"
+	names := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	names.Name = "name"
+	urls := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	urls.Name = "url"
+	for _, d := range f.dashboards {
+		uids.Append(d.UID)
+		names.Append(d.Name)
+		urls.Append(d.URL)
+	}
+	return &backend.DataResponse{Frames: data.Frames{data.NewFrame("Query results", uids, names, urls)}}
+}
+
+func (f *fakeSearcher) IsReady(context.Context, int64) searchV2.IsSearchReadyResponse {
+	return searchV2.IsSearchReadyResponse{IsReady: !f.notReady}
+}
+
+func setupTestService(t *testing.T) (*SavedSearchService, *fakeSearcher, *notifications.NotificationServiceMock) {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.AppURL = "http://localhost:3000/grafana/"
+	cfg.AppSubURL = "/grafana"
+	searcher := &fakeSearcher{}
+	ns := &notifications.NotificationServiceMock{}
+	return &SavedSearchService{
+		cfg:    cfg,
+		store:  db.InitTestDB(t),
+		search: searcher,
+		users: &usertest.FakeUserService{ExpectedSignedInUser: &user.SignedInUser{
+			UserID: 1, OrgID: 1, Login: "owner", Email: "owner@example.com",
+		}},
+		notifications: ns,
+		log:           log.New("saved-search.test"),
+	}, searcher, ns
+}
+
+var testUser = &user.SignedInUser{UserID: 1, OrgID: 1, Login: "owner", Email: "owner@example.com"}
+
+func TestIntegrationSavedSearches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	s, _, _ := setupTestService(t)
+
+	created, err := s.CreateSavedSearch(ctx, testUser, SaveSavedSearchCommand{
+		Title: "Production",
+		Query: searchV2.DashboardQuery{Query: "prod", Tags: []string{"production"}},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.UID)
+
+	_, err = s.CreateSavedSearch(ctx, testUser, SaveSavedSearchCommand{})
+	require.ErrorIs(t, err, ErrSavedSearchTitleEmpty)
+
+	search, err := s.GetSavedSearch(ctx, testUser, created.UID)
+	require.NoError(t, err)
+	require.Equal(t, created, search)
+	require.Equal(t, []string{"production"}, search.Query.Tags)
+
+	_, err = s.GetSavedSearch(ctx, &user.SignedInUser{UserID: 2, OrgID: 1}, created.UID)
+	require.ErrorIs(t, err, ErrSavedSearchNotFound, "the saved searches are private")
+
+	updated, err := s.UpdateSavedSearch(ctx, testUser, created.UID, SaveSavedSearchCommand{
+		Title: "Staging",
+		Query: searchV2.DashboardQuery{Query: "staging"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Staging", updated.Title)
+
+	_, err = s.CreateSavedSearch(ctx, testUser, SaveSavedSearchCommand{Title: "Alpha"})
+	require.NoError(t, err)
+	searches, err := s.GetSavedSearches(ctx, testUser)
+	require.NoError(t, err)
+	require.Len(t, searches, 2)
+	require.Equal(t, "Alpha", searches[0].Title)
+	require.Equal(t, "staging", searches[1].Query.Query)
+
+	require.NoError(t, s.DeleteSavedSearch(ctx, testUser, created.UID))
+	require.ErrorIs(t, s.DeleteSavedSearch(ctx, testUser, created.UID), ErrSavedSearchNotFound)
+}
+
+func TestIntegrationSavedSearchNotifications(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	s, searcher, ns := setupTestService(t)
+
+	sent := 0
+	ns.EmailHandler = func(context.Context, *notifications.SendEmailCommand) error {
+		sent++
+		return nil
+	}
+
+	existing := Match{UID: "existing", Name: "Existing", URL: "/grafana/d/existing/existing"}
+	searcher.setDashboards(existing)
+	created, err := s.CreateSavedSearch(ctx, testUser, SaveSavedSearchCommand{
+		Title:  "Production",
+		Query:  searchV2.DashboardQuery{Query: "prod"},
+		Notify: true,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, created.LastEvaluated)
+
+	s.evaluateAll(ctx)
+	require.Equal(t, 0, sent, "the dashboards matching the search when it is saved are not notified")
+
+	added := Match{UID: "added", Name: "Added", URL: "/grafana/d/added/added"}
+	searcher.setDashboards(existing, added)
+	s.evaluateAll(ctx)
+	require.Equal(t, 1, sent)
+	require.Equal(t, []string{"owner@example.com"}, ns.Email.To)
+	require.Equal(t, notificationTemplate, ns.Email.Template)
+	require.Equal(t, "Production", ns.Email.Data["Title"])
+	require.Equal(t, []map[string]string{
+		{"Name": "Added", "Url": "http://localhost:3000/grafana/d/added/added"},
+	}, ns.Email.Data["Dashboards"])
+
+	s.evaluateAll(ctx)
+	require.Equal(t, 1, sent, "the new dashboards are only notified once")
+
+	t.Run("the search is evaluated once the index is ready", func(t *testing.T) {
+		searcher.notReady = true
+		pending, err := s.CreateSavedSearch(ctx, testUser, SaveSavedSearchCommand{Title: "Pending", Notify: true})
+		require.NoError(t, err)
+		require.Zero(t, pending.LastEvaluated)
+
+		searcher.notReady = false
+		sent = 0
+		s.evaluateAll(ctx)
+		require.Equal(t, 0, sent, "the first evaluation only records the matching dashboards")
+
+		pending, err = s.GetSavedSearch(ctx, testUser, pending.UID)
+		require.NoError(t, err)
+		require.NotZero(t, pending.LastEvaluated)
+	})
+
+	t.Run("the search is run with the dashboard kind by default", func(t *testing.T) {
+		matches, err := s.GetSavedSearchMatches(ctx, testUser, created.UID)
+		require.NoError(t, err)
+		require.Equal(t, []Match{existing, added}, matches)
+
+		query := searcher.queries[len(searcher.queries)-1]
+		assert.Equal(t, []string{"dashboard"}, query.Kind)
+		assert.Equal(t, maxMatches, query.Limit)
+	})
+}
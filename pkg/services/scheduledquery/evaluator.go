@@ -0,0 +1,78 @@
+package scheduledquery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+const (
+	// fromAlertHeaderName makes the datasources query the same way as for the alerts, as the queries are run by the
+	// server and no user waits for the result
+	fromAlertHeaderName = "FromAlert"
+	cacheSkipHeaderName = "X-Cache-Skip"
+)
+
+// Evaluator runs the queries with the expression service, as the service account of the server that is allowed to
+// query every datasource of the organization, like the alert rules
+type Evaluator struct {
+	expr    *expr.Service
+	dsCache datasources.CacheService
+	login   string
+}
+
+// NewEvaluator returns an evaluator running the queries as the service account with the login
+func NewEvaluator(exprService *expr.Service, dsCache datasources.CacheService, login string) *Evaluator {
+	return &Evaluator{expr: exprService, dsCache: dsCache, login: login}
+}
+
+// Evaluate runs the queries of the organization at now
+func (e *Evaluator) Evaluate(ctx context.Context, orgID int64, queries []Query, now time.Time) (*backend.QueryDataResponse, error) {
+	signedInUser := e.signedInUser(orgID)
+	req := &expr.Request{
+		OrgId: orgID,
+		Headers: map[string]string{
+			fromAlertHeaderName: "true",
+			cacheSkipHeaderName: "true",
+		},
+	}
+	for _, q := range queries {
+		var ds *datasources.DataSource
+		if expr.IsDataSource(q.DatasourceUID) {
+			ds = expr.DataSourceModel()
+		} else {
+			var err error
+			ds, err = e.dsCache.GetDatasourceByUID(ctx, q.DatasourceUID, signedInUser, false)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the datasource of query %s: %w", q.RefID, err)
+			}
+		}
+		query, err := q.ToExprQuery(ds)
+		if err != nil {
+			return nil, err
+		}
+		req.Queries = append(req.Queries, query)
+	}
+
+	return e.expr.TransformData(ctx, now, req)
+}
+
+func (e *Evaluator) signedInUser(orgID int64) *user.SignedInUser {
+	return &user.SignedInUser{
+		UserID:           -1,
+		IsServiceAccount: true,
+		Login:            e.login,
+		OrgID:            orgID,
+		OrgRole:          org.RoleAdmin,
+		Permissions: map[int64]map[string][]string{
+			orgID: {datasources.ActionQuery: []string{datasources.ScopeAll}},
+		},
+	}
+}
@@ -0,0 +1,84 @@
+// Package scheduledquery runs the queries and expressions that the server evaluates on a schedule, like the recorded
+// queries and the query exports, with no user waiting for their result.
+package scheduledquery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+const (
+	defaultIntervalMS    = 1000
+	defaultMaxDataPoints = 43200
+)
+
+// Query is a datasource query or an expression
+type Query struct {
+	RefID string
+	// DatasourceUID is the UID of the datasource queried, or __expr__ for the expressions
+	DatasourceUID     string
+	RelativeTimeRange expr.RelativeTimeRange
+	// Model is the query sent to the datasource
+	Model json.RawMessage
+}
+
+// ValidateQueries checks that the queries have a datasource and unique RefIDs, it returns the set of their RefIDs
+func ValidateQueries(queries []Query) (map[string]struct{}, error) {
+	if len(queries) == 0 {
+		return nil, errors.New("at least one query is required")
+	}
+
+	refIDs := make(map[string]struct{}, len(queries))
+	for _, q := range queries {
+		if q.RefID == "" {
+			return nil, errors.New("query refId is required")
+		}
+		if _, ok := refIDs[q.RefID]; ok {
+			return nil, fmt.Errorf("duplicate query refId %s", q.RefID)
+		}
+		if q.DatasourceUID == "" {
+			return nil, fmt.Errorf("query %s: datasource uid is required", q.RefID)
+		}
+		refIDs[q.RefID] = struct{}{}
+	}
+	return refIDs, nil
+}
+
+// queryModel are the properties of the model of a query used to build the request
+type queryModel struct {
+	IntervalMs    *float64 `json:"intervalMs"`
+	MaxDataPoints *float64 `json:"maxDataPoints"`
+	QueryType     string   `json:"queryType"`
+}
+
+// ToExprQuery returns the query of the expression request to the datasource
+func (q Query) ToExprQuery(ds *datasources.DataSource) (expr.Query, error) {
+	var m queryModel
+	if len(q.Model) > 0 {
+		if err := json.Unmarshal(q.Model, &m); err != nil {
+			return expr.Query{}, fmt.Errorf("failed to parse the model of query %s: %w", q.RefID, err)
+		}
+	}
+	interval := float64(defaultIntervalMS)
+	if m.IntervalMs != nil && *m.IntervalMs > 0 {
+		interval = *m.IntervalMs
+	}
+	maxDataPoints := float64(defaultMaxDataPoints)
+	if m.MaxDataPoints != nil && *m.MaxDataPoints > 0 {
+		maxDataPoints = *m.MaxDataPoints
+	}
+	return expr.Query{
+		RefID:         q.RefID,
+		TimeRange:     q.RelativeTimeRange,
+		DataSource:    ds,
+		JSON:          q.Model,
+		Interval:      time.Duration(interval) * time.Millisecond,
+		QueryType:     m.QueryType,
+		MaxDataPoints: int64(maxDataPoints),
+	}, nil
+}
@@ -0,0 +1,37 @@
+package scheduledquery
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryToExprQuery(t *testing.T) {
+	q, err := Query{RefID: "A", Model: json.RawMessage(`{"intervalMs": 15000, "queryType": "range"}`)}.ToExprQuery(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Second, q.Interval)
+	assert.Equal(t, int64(defaultMaxDataPoints), q.MaxDataPoints)
+	assert.Equal(t, "range", q.QueryType)
+
+	_, err = Query{RefID: "A", Model: json.RawMessage(`{`)}.ToExprQuery(nil)
+	require.Error(t, err)
+}
+
+func TestValidateQueries(t *testing.T) {
+	refIDs, err := ValidateQueries([]Query{{RefID: "A", DatasourceUID: "prom"}, {RefID: "B", DatasourceUID: "__expr__"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"A": {}, "B": {}}, refIDs)
+
+	for expected, queries := range map[string][]Query{
+		"at least one query is required":      nil,
+		"query refId is required":             {{DatasourceUID: "prom"}},
+		"duplicate query refId A":             {{RefID: "A", DatasourceUID: "prom"}, {RefID: "A", DatasourceUID: "prom"}},
+		"query A: datasource uid is required": {{RefID: "A"}},
+	} {
+		_, err := ValidateQueries(queries)
+		assert.EqualError(t, err, expected)
+	}
+}
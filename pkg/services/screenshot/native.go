@@ -0,0 +1,97 @@
+package screenshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/rendering/native"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// NativeScreenshotService takes screenshots of time series, stat and table panels without
+// a headless browser. It runs the queries of the panel and draws the results.
+type NativeScreenshotService struct {
+	renderer  *native.Renderer
+	imagesDir string
+}
+
+func NewNativeScreenshotService(ds dashboards.DashboardService, qs query.Service, imagesDir string) ScreenshotService {
+	return &NativeScreenshotService{
+		renderer:  native.New(ds, qs),
+		imagesDir: imagesDir,
+	}
+}
+
+// Take returns a PNG screenshot of the panel. It returns an error if the panel does not exist,
+// its type is not supported or its queries failed.
+func (s *NativeScreenshotService) Take(ctx context.Context, opts ScreenshotOptions) (*Screenshot, error) {
+	opts = opts.SetDefaults()
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	result, err := s.renderer.Render(ctx, native.Opts{
+		OrgID:        opts.OrgID,
+		DashboardUID: opts.DashboardUID,
+		PanelID:      opts.PanelID,
+		From:         opts.From,
+		To:           opts.To,
+		Width:        opts.Width,
+		Height:       opts.Height,
+		Theme:        opts.Theme,
+		Format:       native.FormatPNG,
+		SignedInUser: opts.SignedInUser,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to take screenshot: %w", err)
+	}
+
+	name, err := util.GetRandomString(20)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.imagesDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create images directory: %w", err)
+	}
+	filePath, err := filepath.Abs(filepath.Join(s.imagesDir, name+".png"))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filePath, result.Data, 0640); err != nil {
+		return nil, fmt.Errorf("failed to save screenshot: %w", err)
+	}
+
+	return &Screenshot{Path: filePath}, nil
+}
+
+// FallbackScreenshotService takes screenshots with the image renderer when it is available, and
+// with the fallback service, usually the native renderer, otherwise.
+type FallbackScreenshotService struct {
+	rs       rendering.Service
+	headless ScreenshotService
+	fallback ScreenshotService
+}
+
+func NewFallbackScreenshotService(rs rendering.Service, headless ScreenshotService, fallback ScreenshotService) ScreenshotService {
+	return &FallbackScreenshotService{
+		rs:       rs,
+		headless: headless,
+		fallback: fallback,
+	}
+}
+
+func (s *FallbackScreenshotService) Take(ctx context.Context, opts ScreenshotOptions) (*Screenshot, error) {
+	if s.rs.IsAvailable(ctx) {
+		screenshot, err := s.headless.Take(ctx, opts)
+		if !errors.Is(err, rendering.ErrRenderUnavailable) {
+			return screenshot, err
+		}
+	}
+	return s.fallback.Take(ctx, opts)
+}
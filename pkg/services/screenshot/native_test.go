@@ -0,0 +1,92 @@
+package screenshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestNativeScreenshotService(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{"panels": [{"id": 1, "type": "stat", "datasource": {"uid": "prom"}, "targets": [{"refId": "A"}]}]}`))
+	require.NoError(t, err)
+
+	d := dashboards.FakeDashboardService{}
+	d.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).
+		Return(&dashboards.Dashboard{UID: "foo", OrgID: 1, Data: dashboard}, nil)
+
+	frame := data.NewFrame("",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+		data.NewField("value", nil, []float64{42}),
+	)
+	q := query.FakeQueryService{}
+	q.On("QueryData", mock.Anything, mock.Anything, false, mock.Anything).
+		Return(&backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{frame}}}}, nil)
+
+	dir := t.TempDir()
+	s := NewNativeScreenshotService(&d, &q, dir)
+	screenshot, err := s.Take(context.Background(), ScreenshotOptions{OrgID: 1, SignedInUser: &user.SignedInUser{OrgID: 1}, DashboardUID: "foo", PanelID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(screenshot.Path))
+	assert.Equal(t, ".png", filepath.Ext(screenshot.Path))
+	_, err = os.Stat(screenshot.Path)
+	require.NoError(t, err)
+
+	// panels that are not supported should return error
+	_, err = s.Take(context.Background(), ScreenshotOptions{OrgID: 1, SignedInUser: &user.SignedInUser{OrgID: 1}, DashboardUID: "foo", PanelID: 2})
+	assert.Error(t, err)
+}
+
+func TestFallbackScreenshotService(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	r := rendering.NewMockService(c)
+	headless := NewMockScreenshotService(c)
+	fallback := NewMockScreenshotService(c)
+	s := NewFallbackScreenshotService(r, headless, fallback)
+
+	ctx := context.Background()
+	opts := ScreenshotOptions{DashboardUID: "foo", PanelID: 1}
+
+	// should use the image renderer when it is available
+	r.EXPECT().IsAvailable(ctx).Return(true)
+	headless.EXPECT().Take(ctx, opts).Return(&Screenshot{Path: "headless.png"}, nil)
+	screenshot, err := s.Take(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "headless.png", screenshot.Path)
+
+	// should not fallback when the image renderer fails
+	r.EXPECT().IsAvailable(ctx).Return(true)
+	headless.EXPECT().Take(ctx, opts).Return(nil, assert.AnError)
+	_, err = s.Take(ctx, opts)
+	assert.ErrorIs(t, err, assert.AnError)
+
+	// should fallback when the image renderer is not available
+	r.EXPECT().IsAvailable(ctx).Return(false)
+	fallback.EXPECT().Take(ctx, opts).Return(&Screenshot{Path: "native.png"}, nil)
+	screenshot, err = s.Take(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "native.png", screenshot.Path)
+
+	r.EXPECT().IsAvailable(ctx).Return(true)
+	headless.EXPECT().Take(ctx, opts).Return(nil, rendering.ErrRenderUnavailable)
+	fallback.EXPECT().Take(ctx, opts).Return(&Screenshot{Path: "native.png"}, nil)
+	screenshot, err = s.Take(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "native.png", screenshot.Path)
+}
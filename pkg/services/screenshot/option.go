@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/user"
 )
 
 var (
@@ -28,6 +30,9 @@ type ScreenshotOptions struct {
 	Height       int
 	Theme        models.Theme
 	Timeout      time.Duration
+	// SignedInUser is the identity of the requester or of the alert rule, the native renderer runs the queries of the
+	// panel as this user
+	SignedInUser *user.SignedInUser
 }
 
 // SetDefaults sets default values for missing or invalid options.
@@ -65,5 +70,13 @@ func (s ScreenshotOptions) Hash() []byte {
 	_, _ = h.Write([]byte(strconv.FormatInt(int64(s.Width), 10)))
 	_, _ = h.Write([]byte(strconv.FormatInt(int64(s.Height), 10)))
 	_, _ = h.Write([]byte(s.Theme))
+	// the screenshots of users with different permissions on the datasources are not shared
+	if s.SignedInUser != nil {
+		_, _ = h.Write([]byte(strconv.FormatInt(s.SignedInUser.UserID, 10)))
+		_, _ = h.Write([]byte(s.SignedInUser.Login))
+		for _, scope := range s.SignedInUser.Permissions[s.OrgID][datasources.ActionQuery] {
+			_, _ = h.Write([]byte(scope))
+		}
+	}
 	return h.Sum(nil)
 }
@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/user"
 )
 
 func TestScreenshotOptions(t *testing.T) {
@@ -114,4 +117,11 @@ func TestScreenshotOptions_Hash(t *testing.T) {
 	// the timeout should not change the sum
 	o.Timeout = DefaultTimeout + 1
 	assert.Equal(t, []byte{0x3b, 0xd1, 0xfb, 0x3f, 0x3, 0x64, 0xba, 0xad}, o.Hash())
+
+	// the users who can query different datasources should not share the sum
+	o.SignedInUser = &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{1: {datasources.ActionQuery: {"datasources:uid:a"}}}}
+	withA := o.Hash()
+	assert.NotEqual(t, []byte{0x3b, 0xd1, 0xfb, 0x3f, 0x3, 0x64, 0xba, 0xad}, withA)
+	o.SignedInUser = &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{1: {datasources.ActionQuery: {"datasources:uid:b"}}}}
+	assert.NotEqual(t, withA, o.Hash())
 }
@@ -36,6 +36,14 @@ type TokenDTO struct {
 	HasExpired bool `json:"hasExpired"`
 	// example: false
 	IsRevoked *bool `json:"isRevoked"`
+	// The data sources the token can query when it is limited to querying data sources
+	// example: ["PE1C5CBDA0504A6A3"]
+	QueryDatasourceUIDs []string `json:"queryDatasourceUids,omitempty"`
+	// The networks the token can be used from
+	// example: ["10.0.0.0/16"]
+	AllowedCIDRs []string `json:"allowedCidrs,omitempty"`
+	// The SHA-256 fingerprints of the client certificates the token can be used with
+	ClientCertFingerprints []string `json:"clientCertFingerprints,omitempty"`
 }
 
 func hasExpired(expiration *int64) bool {
@@ -103,6 +111,9 @@ func (api *ServiceAccountsAPI) ListTokens(ctx *contextmodel.ReqContext) response
 			HasExpired:             isExpired,
 			LastUsedAt:             token.LastUsedAt,
 			IsRevoked:              token.IsRevoked,
+			QueryDatasourceUIDs:    token.QueryOnlyDatasourceUIDs(),
+			AllowedCIDRs:           token.BoundCIDRs(),
+			ClientCertFingerprints: token.BoundClientCertFingerprints(),
 		}
 	}
 
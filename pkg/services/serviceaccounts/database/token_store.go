@@ -50,12 +50,15 @@ func (s *ServiceAccountsStoreImpl) AddServiceAccountToken(ctx context.Context, s
 		}
 
 		addKeyCmd := &apikey.AddCommand{
-			Name:             cmd.Name,
-			Role:             org.RoleViewer,
-			OrgID:            cmd.OrgId,
-			Key:              cmd.Key,
-			SecondsToLive:    cmd.SecondsToLive,
-			ServiceAccountID: &serviceAccountId,
+			Name:                   cmd.Name,
+			Role:                   org.RoleViewer,
+			OrgID:                  cmd.OrgId,
+			Key:                    cmd.Key,
+			SecondsToLive:          cmd.SecondsToLive,
+			ServiceAccountID:       &serviceAccountId,
+			QueryDatasourceUIDs:    cmd.QueryDatasourceUIDs,
+			AllowedCIDRs:           cmd.AllowedCIDRs,
+			ClientCertFingerprints: cmd.ClientCertFingerprints,
 		}
 
 		if err := s.apiKeyService.AddAPIKey(ctx, addKeyCmd); err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/routing"
@@ -20,6 +21,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 const (
@@ -210,6 +212,12 @@ func (sa *ServiceAccountsService) AddServiceAccountToken(ctx context.Context, se
 	if err := validServiceAccountID(serviceAccountID); err != nil {
 		return nil, err
 	}
+	if err := normalizeTokenDatasourceUIDs(query); err != nil {
+		return nil, err
+	}
+	if err := normalizeTokenBinding(query); err != nil {
+		return nil, err
+	}
 	return sa.store.AddServiceAccountToken(ctx, serviceAccountID, query)
 }
 
@@ -254,6 +262,48 @@ func validServiceAccountID(serviceaccountID int64) error {
 	}
 	return nil
 }
+
+// normalizeTokenDatasourceUIDs trims the data source UIDs of a token limited to querying and drops the empty ones, it
+// fails when none is left as the token would not be limited
+func normalizeTokenDatasourceUIDs(query *serviceaccounts.AddServiceAccountTokenCommand) error {
+	if len(query.QueryDatasourceUIDs) == 0 {
+		return nil
+	}
+	uids := make([]string, 0, len(query.QueryDatasourceUIDs))
+	for _, uid := range query.QueryDatasourceUIDs {
+		if uid = strings.TrimSpace(uid); uid != "" {
+			uids = append(uids, uid)
+		}
+	}
+	if len(uids) == 0 {
+		return serviceaccounts.ErrInvalidTokenDatasourceUID.Errorf("no data source UID has been specified")
+	}
+	for _, uid := range uids {
+		if !util.IsValidShortUID(uid) {
+			return serviceaccounts.ErrInvalidTokenDatasourceUID.Errorf("invalid data source UID %q has been specified", uid)
+		}
+	}
+	query.QueryDatasourceUIDs = uids
+	return nil
+}
+
+func normalizeTokenBinding(query *serviceaccounts.AddServiceAccountTokenCommand) error {
+	for i, cidr := range query.AllowedCIDRs {
+		normalized, ok := apikey.NormalizeCIDR(cidr)
+		if !ok {
+			return serviceaccounts.ErrInvalidTokenBinding.Errorf("invalid network %q has been specified", cidr)
+		}
+		query.AllowedCIDRs[i] = normalized
+	}
+	for i, fingerprint := range query.ClientCertFingerprints {
+		normalized, ok := apikey.NormalizeCertFingerprint(fingerprint)
+		if !ok {
+			return serviceaccounts.ErrInvalidTokenBinding.Errorf("invalid client certificate fingerprint %q has been specified", fingerprint)
+		}
+		query.ClientCertFingerprints[i] = normalized
+	}
+	return nil
+}
 func validServiceAccountTokenID(tokenID int64) error {
 	if tokenID == 0 {
 		return serviceaccounts.ErrServiceAccountInvalidTokenID.Errorf("invalid service account token ID 0 has been specified")
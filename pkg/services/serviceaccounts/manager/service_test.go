@@ -2,6 +2,7 @@ package manager
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -136,3 +137,66 @@ func TestProvideServiceAccount_DeleteServiceAccount(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestProvideServiceAccount_AddServiceAccountToken(t *testing.T) {
+	storeMock := newServiceAccountStoreFake()
+	svc := ServiceAccountsService{storeMock, log.New("test"), log.New("background.test"), &SecretsCheckerFake{}, false, 0}
+
+	t.Run("should add a token limited to querying data sources", func(t *testing.T) {
+		storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 1}
+		cmd := &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:                "query-only",
+			OrgId:               1,
+			QueryDatasourceUIDs: []string{"prom", " loki", ""},
+		}
+		_, err := svc.AddServiceAccountToken(context.Background(), 1, cmd)
+		require.NoError(t, err)
+		require.Equal(t, []string{"prom", "loki"}, cmd.QueryDatasourceUIDs)
+	})
+
+	t.Run("should reject invalid data source UIDs", func(t *testing.T) {
+		_, err := svc.AddServiceAccountToken(context.Background(), 1, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:                "query-only",
+			OrgId:               1,
+			QueryDatasourceUIDs: []string{"prom", "not a uid!"},
+		})
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenDatasourceUID)
+
+		_, err = svc.AddServiceAccountToken(context.Background(), 1, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:                "query-only",
+			OrgId:               1,
+			QueryDatasourceUIDs: []string{" ", ""},
+		})
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenDatasourceUID)
+	})
+
+	t.Run("should normalize the networks and the client certificate fingerprints of a bound token", func(t *testing.T) {
+		storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 1}
+		cmd := &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:                   "bound",
+			OrgId:                  1,
+			AllowedCIDRs:           []string{"10.0.1.7/24", "192.168.1.1", "2001:db8::1"},
+			ClientCertFingerprints: []string{"AB:" + strings.Repeat("cd", 31)},
+		}
+		_, err := svc.AddServiceAccountToken(context.Background(), 1, cmd)
+		require.NoError(t, err)
+		require.Equal(t, []string{"10.0.1.0/24", "192.168.1.1/32", "2001:db8::1/128"}, cmd.AllowedCIDRs)
+		require.Equal(t, []string{"ab" + strings.Repeat("cd", 31)}, cmd.ClientCertFingerprints)
+	})
+
+	t.Run("should reject invalid networks and client certificate fingerprints", func(t *testing.T) {
+		_, err := svc.AddServiceAccountToken(context.Background(), 1, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:         "bound",
+			OrgId:        1,
+			AllowedCIDRs: []string{"10.0.0.0/33"},
+		})
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenBinding)
+
+		_, err = svc.AddServiceAccountToken(context.Background(), 1, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:                   "bound",
+			OrgId:                  1,
+			ClientCertFingerprints: []string{"abcd"},
+		})
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenBinding)
+	})
+}
@@ -35,6 +35,8 @@ var (
 	ErrServiceAccountTokenNotFound       = errutil.NewBase(errutil.StatusNotFound, "serviceaccounts.ErrTokenNotFound", errutil.WithPublicMessage("service account token not found"))
 	ErrInvalidTokenExpiration            = errutil.NewBase(errutil.StatusValidationFailed, "serviceaccounts.ErrInvalidInput", errutil.WithPublicMessage("invalid SecondsToLive value"))
 	ErrDuplicateToken                    = errutil.NewBase(errutil.StatusBadRequest, "serviceaccounts.ErrTokenAlreadyExists", errutil.WithPublicMessage("service account token with given name already exists in the organization"))
+	ErrInvalidTokenDatasourceUID         = errutil.NewBase(errutil.StatusBadRequest, "serviceaccounts.ErrInvalidTokenDatasourceUID", errutil.WithPublicMessage("invalid data source UID for the service account token"))
+	ErrInvalidTokenBinding               = errutil.NewBase(errutil.StatusBadRequest, "serviceaccounts.ErrInvalidTokenBinding", errutil.WithPublicMessage("invalid network or client certificate fingerprint for the service account token"))
 )
 
 type ServiceAccount struct {
@@ -90,6 +92,14 @@ type AddServiceAccountTokenCommand struct {
 	OrgId         int64  `json:"-"`
 	Key           string `json:"-"`
 	SecondsToLive int64  `json:"secondsToLive"`
+	// QueryDatasourceUIDs limits the token to querying these data sources with /api/ds/query, the token can't be
+	// used for the other endpoints
+	QueryDatasourceUIDs []string `json:"queryDatasourceUids,omitempty"`
+	// AllowedCIDRs binds the token to these networks, it is rejected when used from another address
+	AllowedCIDRs []string `json:"allowedCidrs,omitempty"`
+	// ClientCertFingerprints binds the token to the client certificates with these SHA-256 fingerprints, it is
+	// rejected when the request is not made over TLS with one of them
+	ClientCertFingerprints []string `json:"clientCertFingerprints,omitempty"`
 }
 
 type SearchOrgServiceAccountsQuery struct {
@@ -12,6 +12,7 @@ var (
 	ErrShortURLAbsolutePath = errutil.NewBase(errutil.StatusValidationFailed, "shorturl.absolute-path", errutil.WithPublicMessage("Path should be relative"))
 	ErrShortURLInvalidPath  = errutil.NewBase(errutil.StatusValidationFailed, "shorturl.invalid-path", errutil.WithPublicMessage("Invalid short URL path"))
 	ErrShortURLInternal     = errutil.NewBase(errutil.StatusInternal, "shorturl.internal")
+	ErrShortURLInvalidTTL   = errutil.NewBase(errutil.StatusValidationFailed, "shorturl.invalid-ttl", errutil.WithPublicMessage("Invalid short URL time to live"))
 )
 
 type ShortUrl struct {
@@ -22,6 +23,15 @@ type ShortUrl struct {
 	CreatedBy  int64
 	CreatedAt  int64
 	LastSeenAt int64
+	// ExpiresAt is the unix time after which the short URL is not found, 0 when it doesn't expire
+	ExpiresAt int64
+	// HitCount is the number of visits of the short URL
+	HitCount int64
+}
+
+// Expired returns true when the short URL expired at t
+func (s ShortUrl) Expired(t time.Time) bool {
+	return s.ExpiresAt > 0 && s.ExpiresAt <= t.Unix()
 }
 
 type DeleteShortUrlCommand struct {
@@ -29,3 +39,44 @@ type DeleteShortUrlCommand struct {
 
 	NumDeleted int64
 }
+
+// ShortURLWithOwner is a short URL with the login of the user who created it, empty when the user was deleted
+type ShortURLWithOwner struct {
+	ShortUrl       `xorm:"extends"`
+	CreatedByLogin string
+}
+
+type SearchShortURLsQuery struct {
+	OrgID int64
+	// CreatedBy only returns the short URLs of the user when it's set
+	CreatedBy int64
+	// Expired only returns the short URLs expired at Now when it's true
+	Expired bool
+	Now     time.Time
+	Page    int
+	Limit   int
+}
+
+type SearchShortURLsResult struct {
+	TotalCount int64
+	ShortURLs  []*ShortURLWithOwner
+}
+
+// DeleteShortURLsCommand deletes the short URLs of the organization matching all the set filters, at least one
+// filter is required
+type DeleteShortURLsCommand struct {
+	OrgID     int64
+	UID       string
+	CreatedBy int64
+	// ExpiredAt deletes the short URLs expired at this time
+	ExpiredAt time.Time
+	// NotSeenSince deletes the short URLs that were not visited since this time, created before it
+	NotSeenSince time.Time
+
+	NumDeleted int64
+}
+
+// HasFilter returns true when a filter is set, so that all the short URLs of the organization are never deleted
+func (cmd *DeleteShortURLsCommand) HasFilter() bool {
+	return cmd.UID != "" || cmd.CreatedBy != 0 || !cmd.ExpiredAt.IsZero() || !cmd.NotSeenSince.IsZero()
+}
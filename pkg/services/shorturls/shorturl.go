@@ -2,13 +2,20 @@ package shorturls
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/user"
 )
 
 type Service interface {
+	// GetShortURLByUID returns the short URL, it fails with ErrShortURLNotFound when the short URL expired
 	GetShortURLByUID(ctx context.Context, user *user.SignedInUser, uid string) (*ShortUrl, error)
-	CreateShortURL(ctx context.Context, user *user.SignedInUser, path string) (*ShortUrl, error)
+	// CreateShortURL creates a short URL expiring after the ttl, or after the default time to live when it's 0
+	CreateShortURL(ctx context.Context, user *user.SignedInUser, path string, ttl time.Duration) (*ShortUrl, error)
+	// UpdateLastSeenAt updates the last seen at time of the short URL and counts the visit
 	UpdateLastSeenAt(ctx context.Context, shortURL *ShortUrl) error
+	// DeleteStaleShortURLs deletes the short URLs created before OlderThan that were never visited, and the expired ones
 	DeleteStaleShortURLs(ctx context.Context, cmd *DeleteShortUrlCommand) error
+	SearchShortURLs(ctx context.Context, query *SearchShortURLsQuery) (*SearchShortURLsResult, error)
+	DeleteShortURLs(ctx context.Context, cmd *DeleteShortURLsCommand) error
 }
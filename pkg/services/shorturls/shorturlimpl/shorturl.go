@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/teris-io/shortid"
 )
 
@@ -16,25 +17,38 @@ var getTime = time.Now
 
 type ShortURLService struct {
 	SQLStore store
+	// DefaultTTL is the time to live of the short URLs created without one, 0 when they don't expire
+	DefaultTTL time.Duration
+	// MaxTTL is the maximum time to live of the short URLs, 0 when there is no maximum
+	MaxTTL time.Duration
 }
 
-func ProvideService(db db.DB) *ShortURLService {
+func ProvideService(db db.DB, cfg *setting.Cfg) *ShortURLService {
 	return &ShortURLService{
 		SQLStore: &sqlStore{
 			db: db,
 		},
+		DefaultTTL: cfg.ShortLinkDefaultTTL,
+		MaxTTL:     cfg.ShortLinkMaxTTL,
 	}
 }
 
 func (s ShortURLService) GetShortURLByUID(ctx context.Context, user *user.SignedInUser, uid string) (*shorturls.ShortUrl, error) {
-	return s.SQLStore.Get(ctx, user, uid)
+	shortURL, err := s.SQLStore.Get(ctx, user, uid)
+	if err != nil {
+		return nil, err
+	}
+	if shortURL.Expired(getTime()) {
+		return nil, shorturls.ErrShortURLNotFound.Errorf("short URL expired")
+	}
+	return shortURL, nil
 }
 
 func (s ShortURLService) UpdateLastSeenAt(ctx context.Context, shortURL *shorturls.ShortUrl) error {
 	return s.SQLStore.Update(ctx, shortURL)
 }
 
-func (s ShortURLService) CreateShortURL(ctx context.Context, user *user.SignedInUser, relPath string) (*shorturls.ShortUrl, error) {
+func (s ShortURLService) CreateShortURL(ctx context.Context, user *user.SignedInUser, relPath string, ttl time.Duration) (*shorturls.ShortUrl, error) {
 	relPath = strings.TrimSpace(relPath)
 
 	if path.IsAbs(relPath) {
@@ -44,18 +58,31 @@ func (s ShortURLService) CreateShortURL(ctx context.Context, user *user.SignedIn
 		return nil, shorturls.ErrShortURLInvalidPath.Errorf("path cannot contain '../': %s", relPath)
 	}
 
+	if ttl < 0 {
+		return nil, shorturls.ErrShortURLInvalidTTL.Errorf("time to live can't be negative: %s", ttl)
+	}
+	if ttl == 0 {
+		ttl = s.DefaultTTL
+	}
+	if s.MaxTTL > 0 && ttl > s.MaxTTL {
+		return nil, shorturls.ErrShortURLInvalidTTL.Errorf("time to live %s is longer than the maximum %s", ttl, s.MaxTTL)
+	}
+
 	uid, err := shortid.Generate()
 	if err != nil {
 		return nil, shorturls.ErrShortURLInternal.Errorf("failed to generate uid: %w", err)
 	}
 
-	now := time.Now().Unix()
+	now := getTime()
 	shortURL := shorturls.ShortUrl{
 		OrgId:     user.OrgID,
 		Uid:       uid,
 		Path:      relPath,
 		CreatedBy: user.UserID,
-		CreatedAt: now,
+		CreatedAt: now.Unix(),
+	}
+	if ttl > 0 {
+		shortURL.ExpiresAt = now.Add(ttl).Unix()
 	}
 
 	if err := s.SQLStore.Insert(ctx, &shortURL); err != nil {
@@ -68,3 +95,23 @@ func (s ShortURLService) CreateShortURL(ctx context.Context, user *user.SignedIn
 func (s ShortURLService) DeleteStaleShortURLs(ctx context.Context, cmd *shorturls.DeleteShortUrlCommand) error {
 	return s.SQLStore.Delete(ctx, cmd)
 }
+
+func (s ShortURLService) SearchShortURLs(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error) {
+	if query.Now.IsZero() {
+		query.Now = getTime()
+	}
+	if query.Limit <= 0 {
+		query.Limit = 100
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	return s.SQLStore.Search(ctx, query)
+}
+
+func (s ShortURLService) DeleteShortURLs(ctx context.Context, cmd *shorturls.DeleteShortURLsCommand) error {
+	if !cmd.HasFilter() {
+		return shorturls.ErrShortURLBadRequest.Errorf("at least one filter is required to delete short URLs")
+	}
+	return s.SQLStore.DeleteMany(ctx, cmd)
+}
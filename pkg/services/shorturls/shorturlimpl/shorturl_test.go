@@ -21,7 +21,7 @@ func TestShortURLService(t *testing.T) {
 
 		service := ShortURLService{SQLStore: &sqlStore{db: store}}
 
-		newShortURL, err := service.CreateShortURL(context.Background(), user, refPath)
+		newShortURL, err := service.CreateShortURL(context.Background(), user, refPath, 0)
 		require.NoError(t, err)
 		require.NotNil(t, newShortURL)
 		require.NotEmpty(t, newShortURL.Uid)
@@ -48,10 +48,11 @@ func TestShortURLService(t *testing.T) {
 			updatedShortURL, err := service.GetShortURLByUID(context.Background(), user, existingShortURL.Uid)
 			require.NoError(t, err)
 			require.Equal(t, expectedTime.Unix(), updatedShortURL.LastSeenAt)
+			require.Equal(t, int64(1), updatedShortURL.HitCount)
 		})
 
 		t.Run("and stale short urls can be deleted", func(t *testing.T) {
-			staleShortURL, err := service.CreateShortURL(context.Background(), user, refPath)
+			staleShortURL, err := service.CreateShortURL(context.Background(), user, refPath, 0)
 			require.NoError(t, err)
 			require.NotNil(t, staleShortURL)
 			require.NotEmpty(t, staleShortURL.Uid)
@@ -76,6 +77,39 @@ func TestShortURLService(t *testing.T) {
 		})
 	})
 
+	t.Run("Short URLs expire", func(t *testing.T) {
+		service := ShortURLService{SQLStore: &sqlStore{db: store}, DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour}
+
+		_, err := service.CreateShortURL(context.Background(), user, "explore", 48*time.Hour)
+		require.True(t, shorturls.ErrShortURLInvalidTTL.Is(err))
+		_, err = service.CreateShortURL(context.Background(), user, "explore", -time.Hour)
+		require.True(t, shorturls.ErrShortURLInvalidTTL.Is(err))
+
+		shortURL, err := service.CreateShortURL(context.Background(), user, "explore", 0)
+		require.NoError(t, err)
+		require.Equal(t, shortURL.CreatedAt+3600, shortURL.ExpiresAt)
+
+		_, err = service.GetShortURLByUID(context.Background(), user, shortURL.Uid)
+		require.NoError(t, err)
+
+		origGetTime := getTime
+		t.Cleanup(func() {
+			getTime = origGetTime
+		})
+		getTime = func() time.Time {
+			return time.Unix(shortURL.ExpiresAt, 0)
+		}
+
+		_, err = service.GetShortURLByUID(context.Background(), user, shortURL.Uid)
+		require.True(t, shorturls.ErrShortURLNotFound.Is(err))
+
+		t.Run("and the expired short URLs are deleted with the stale ones", func(t *testing.T) {
+			cmd := shorturls.DeleteShortUrlCommand{OlderThan: time.Unix(0, 0)}
+			require.NoError(t, service.DeleteStaleShortURLs(context.Background(), &cmd))
+			require.Equal(t, int64(1), cmd.NumDeleted)
+		})
+	})
+
 	t.Run("User cannot look up nonexistent short URLs", func(t *testing.T) {
 		service := ShortURLService{SQLStore: &sqlStore{db: store}}
 
@@ -85,3 +119,51 @@ func TestShortURLService(t *testing.T) {
 		require.Nil(t, shortURL)
 	})
 }
+
+func TestSearchAndDeleteShortURLs(t *testing.T) {
+	store := db.InitTestDB(t)
+	service := ShortURLService{SQLStore: &sqlStore{db: store}}
+	owner := &user.SignedInUser{OrgID: 1, UserID: 1}
+	other := &user.SignedInUser{OrgID: 1, UserID: 2}
+
+	expiring, err := service.CreateShortURL(context.Background(), owner, "d/1", time.Hour)
+	require.NoError(t, err)
+	_, err = service.CreateShortURL(context.Background(), owner, "d/2", 0)
+	require.NoError(t, err)
+	_, err = service.CreateShortURL(context.Background(), other, "explore", 0)
+	require.NoError(t, err)
+	_, err = service.CreateShortURL(context.Background(), &user.SignedInUser{OrgID: 2, UserID: 1}, "d/3", 0)
+	require.NoError(t, err)
+
+	result, err := service.SearchShortURLs(context.Background(), &shorturls.SearchShortURLsQuery{OrgID: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), result.TotalCount)
+	require.Len(t, result.ShortURLs, 3)
+
+	result, err = service.SearchShortURLs(context.Background(), &shorturls.SearchShortURLsQuery{OrgID: 1, CreatedBy: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.TotalCount)
+	require.Len(t, result.ShortURLs, 1)
+
+	result, err = service.SearchShortURLs(context.Background(), &shorturls.SearchShortURLsQuery{OrgID: 1, Expired: true, Now: time.Unix(expiring.ExpiresAt, 0)})
+	require.NoError(t, err)
+	require.Len(t, result.ShortURLs, 1)
+	require.Equal(t, expiring.Uid, result.ShortURLs[0].Uid)
+
+	err = service.DeleteShortURLs(context.Background(), &shorturls.DeleteShortURLsCommand{OrgID: 1})
+	require.True(t, shorturls.ErrShortURLBadRequest.Is(err))
+	err = service.SQLStore.DeleteMany(context.Background(), &shorturls.DeleteShortURLsCommand{OrgID: 1})
+	require.True(t, shorturls.ErrShortURLBadRequest.Is(err))
+
+	cmd := shorturls.DeleteShortURLsCommand{OrgID: 1, CreatedBy: 1}
+	require.NoError(t, service.DeleteShortURLs(context.Background(), &cmd))
+	require.Equal(t, int64(2), cmd.NumDeleted)
+
+	cmd = shorturls.DeleteShortURLsCommand{OrgID: 1, NotSeenSince: time.Now().Add(time.Hour)}
+	require.NoError(t, service.DeleteShortURLs(context.Background(), &cmd))
+	require.Equal(t, int64(1), cmd.NumDeleted)
+
+	result, err = service.SearchShortURLs(context.Background(), &shorturls.SearchShortURLsQuery{OrgID: 2})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.TotalCount)
+}
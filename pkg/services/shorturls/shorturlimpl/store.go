@@ -13,6 +13,8 @@ type store interface {
 	Update(ctx context.Context, shortURL *shorturls.ShortUrl) error
 	Insert(ctx context.Context, shortURL *shorturls.ShortUrl) error
 	Delete(ctx context.Context, cmd *shorturls.DeleteShortUrlCommand) error
+	Search(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error)
+	DeleteMany(ctx context.Context, cmd *shorturls.DeleteShortURLsCommand) error
 }
 
 type sqlStore struct {
@@ -42,10 +44,12 @@ func (s sqlStore) Get(ctx context.Context, user *user.SignedInUser, uid string)
 func (s sqlStore) Update(ctx context.Context, shortURL *shorturls.ShortUrl) error {
 	shortURL.LastSeenAt = getTime().Unix()
 	return s.db.WithTransactionalDbSession(ctx, func(dbSession *db.Session) error {
-		_, err := dbSession.ID(shortURL.Id).Update(shortURL)
+		// the hit count is incremented in the database so that concurrent visits are all counted
+		_, err := dbSession.ID(shortURL.Id).Cols("last_seen_at").Incr("hit_count").Update(shortURL)
 		if err != nil {
 			return err
 		}
+		shortURL.HitCount++
 		return nil
 	})
 }
@@ -59,9 +63,9 @@ func (s sqlStore) Insert(ctx context.Context, shortURL *shorturls.ShortUrl) erro
 
 func (s sqlStore) Delete(ctx context.Context, cmd *shorturls.DeleteShortUrlCommand) error {
 	return s.db.WithTransactionalDbSession(ctx, func(session *db.Session) error {
-		var rawSql = "DELETE FROM short_url WHERE created_at <= ? AND (last_seen_at IS NULL OR last_seen_at = 0)"
+		var rawSql = "DELETE FROM short_url WHERE (created_at <= ? AND (last_seen_at IS NULL OR last_seen_at = 0)) OR (expires_at > 0 AND expires_at <= ?)"
 
-		if result, err := session.Exec(rawSql, cmd.OlderThan.Unix()); err != nil {
+		if result, err := session.Exec(rawSql, cmd.OlderThan.Unix(), getTime().Unix()); err != nil {
 			return err
 		} else if cmd.NumDeleted, err = result.RowsAffected(); err != nil {
 			return err
@@ -69,3 +73,65 @@ func (s sqlStore) Delete(ctx context.Context, cmd *shorturls.DeleteShortUrlComma
 		return nil
 	})
 }
+
+func (s sqlStore) Search(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error) {
+	result := &shorturls.SearchShortURLsResult{ShortURLs: make([]*shorturls.ShortURLWithOwner, 0)}
+	err := s.db.WithDbSession(ctx, func(session *db.Session) error {
+		filter := db.SQLBuilder{}
+		filter.Write(" WHERE short_url.org_id = ?", query.OrgID)
+		if query.CreatedBy != 0 {
+			filter.Write(" AND short_url.created_by = ?", query.CreatedBy)
+		}
+		if query.Expired {
+			filter.Write(" AND short_url.expires_at > 0 AND short_url.expires_at <= ?", query.Now.Unix())
+		}
+
+		count := db.SQLBuilder{}
+		count.Write("SELECT COUNT(*) FROM short_url")
+		count.Write(filter.GetSQLString(), filter.GetParams()...)
+		if _, err := session.SQL(count.GetSQLString(), count.GetParams()...).Get(&result.TotalCount); err != nil {
+			return err
+		}
+
+		sb := db.SQLBuilder{}
+		sb.Write("SELECT short_url.*, u.login AS created_by_login FROM short_url")
+		sb.Write(" LEFT JOIN " + s.db.GetDialect().Quote("user") + " AS u ON u.id = short_url.created_by")
+		sb.Write(filter.GetSQLString(), filter.GetParams()...)
+		sb.Write(" ORDER BY short_url.created_at DESC, short_url.id DESC")
+		sb.Write(s.db.GetDialect().LimitOffset(int64(query.Limit), int64((query.Page-1)*query.Limit)))
+		return session.SQL(sb.GetSQLString(), sb.GetParams()...).Find(&result.ShortURLs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s sqlStore) DeleteMany(ctx context.Context, cmd *shorturls.DeleteShortURLsCommand) error {
+	if !cmd.HasFilter() {
+		return shorturls.ErrShortURLBadRequest.Errorf("at least one filter is required to delete short URLs")
+	}
+	return s.db.WithTransactionalDbSession(ctx, func(session *db.Session) error {
+		sb := db.SQLBuilder{}
+		sb.Write("DELETE FROM short_url WHERE org_id = ?", cmd.OrgID)
+		if cmd.UID != "" {
+			sb.Write(" AND uid = ?", cmd.UID)
+		}
+		if cmd.CreatedBy != 0 {
+			sb.Write(" AND created_by = ?", cmd.CreatedBy)
+		}
+		if !cmd.ExpiredAt.IsZero() {
+			sb.Write(" AND expires_at > 0 AND expires_at <= ?", cmd.ExpiredAt.Unix())
+		}
+		if !cmd.NotSeenSince.IsZero() {
+			sb.Write(" AND created_at <= ? AND (last_seen_at IS NULL OR last_seen_at <= ?)", cmd.NotSeenSince.Unix(), cmd.NotSeenSince.Unix())
+		}
+
+		result, err := session.Exec(append([]interface{}{sb.GetSQLString()}, sb.GetParams()...)...)
+		if err != nil {
+			return err
+		}
+		cmd.NumDeleted, err = result.RowsAffected()
+		return err
+	})
+}
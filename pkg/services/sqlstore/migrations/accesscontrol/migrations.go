@@ -170,4 +170,12 @@ func AddMigration(mg *migrator.Migrator) {
 	mg.AddMigration("add column hidden to role table", migrator.NewAddColumnMigration(roleV1, &migrator.Column{
 		Name: "hidden", Type: migrator.DB_Bool, Nullable: false, Default: "0",
 	}))
+
+	mg.AddMigration("add column expires to permission table", migrator.NewAddColumnMigration(permissionV1, &migrator.Column{
+		Name: "expires", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("add index permission.expires", migrator.NewAddIndexMigration(permissionV1, &migrator.Index{
+		Cols: []string{"expires"},
+	}))
 }
@@ -100,4 +100,17 @@ func addApiKeyMigrations(mg *Migrator) {
 	mg.AddMigration("Add is_revoked column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
 		Name: "is_revoked", Type: DB_Bool, Nullable: true, Default: "0",
 	}))
+
+	// query_datasource_uids limits a service account token to querying these datasources
+	mg.AddMigration("Add query_datasource_uids column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "query_datasource_uids", Type: DB_Text, Nullable: true,
+	}))
+
+	// allowed_cidrs and client_cert_fingerprints bind a service account token to networks and to client certificates
+	mg.AddMigration("Add allowed_cidrs column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "allowed_cidrs", Type: DB_Text, Nullable: true,
+	}))
+	mg.AddMigration("Add client_cert_fingerprints column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "client_cert_fingerprints", Type: DB_Text, Nullable: true,
+	}))
 }
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addAsyncQueryMigrations(mg *Migrator) {
+	asyncQueryV1 := Table{
+		Name: "async_query",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "owner", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "status", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "error", Type: DB_Text, Nullable: true},
+			{Name: "error_status", Type: DB_Int, Nullable: false, Default: "0"},
+			{Name: "result", Type: DB_LongText, Nullable: true},
+			{Name: "created", Type: DB_BigInt, Nullable: false},
+			{Name: "updated", Type: DB_BigInt, Nullable: false},
+			{Name: "expires", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"expires"}},
+		},
+	}
+
+	mg.AddMigration("create async_query table v1", NewAddTableMigration(asyncQueryV1))
+	mg.AddMigration("add unique index async_query.org_id-uid", NewAddIndexMigration(asyncQueryV1, asyncQueryV1.Indices[0]))
+	mg.AddMigration("add index async_query.expires", NewAddIndexMigration(asyncQueryV1, asyncQueryV1.Indices[1]))
+}
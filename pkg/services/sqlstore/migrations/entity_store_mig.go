@@ -36,7 +36,9 @@ func addEntityStoreMigrations(mg *migrator.Migrator) {
 			{Name: "slug", Type: migrator.DB_NVarchar, Length: 189, Nullable: false}, // from title
 
 			// The raw entity body (any byte array)
-			{Name: "body", Type: migrator.DB_LongBlob, Nullable: true}, // null when nested or remote
+			{Name: "body", Type: migrator.DB_LongBlob, Nullable: true},                       // null when nested, remote or chunked
+			{Name: "body_encoding", Type: migrator.DB_NVarchar, Length: 16, Nullable: false}, // empty or gzip
+			{Name: "body_chunks", Type: migrator.DB_Int, Nullable: false},                    // rows in entity_blob
 			{Name: "size", Type: migrator.DB_BigInt, Nullable: false},
 			{Name: "etag", Type: migrator.DB_NVarchar, Length: 32, Nullable: false, IsLatin: true}, // md5(body)
 			{Name: "version", Type: migrator.DB_NVarchar, Length: 128, Nullable: false},
@@ -137,7 +139,9 @@ func addEntityStoreMigrations(mg *migrator.Migrator) {
 			{Name: "version", Type: migrator.DB_NVarchar, Length: 128, Nullable: false},
 
 			// Raw bytes
-			{Name: "body", Type: migrator.DB_LongBlob, Nullable: false},
+			{Name: "body", Type: migrator.DB_LongBlob, Nullable: true}, // null when chunked
+			{Name: "body_encoding", Type: migrator.DB_NVarchar, Length: 16, Nullable: false},
+			{Name: "body_chunks", Type: migrator.DB_Int, Nullable: false},
 			{Name: "size", Type: migrator.DB_BigInt, Nullable: false},
 			{Name: "etag", Type: migrator.DB_NVarchar, Length: 32, Nullable: false, IsLatin: true}, // md5(body)
 
@@ -154,6 +158,20 @@ func addEntityStoreMigrations(mg *migrator.Migrator) {
 		},
 	})
 
+	// the bodies too large for a single row are split in chunks, for every version
+	tables = append(tables, migrator.Table{
+		Name: "entity_blob",
+		Columns: []*migrator.Column{
+			{Name: "grn", Type: migrator.DB_NVarchar, Length: grnLength, Nullable: false},
+			{Name: "version", Type: migrator.DB_NVarchar, Length: 128, Nullable: false},
+			{Name: "chunk", Type: migrator.DB_Int, Nullable: false},
+			{Name: "data", Type: migrator.DB_LongBlob, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"grn", "version", "chunk"}, Type: migrator.UniqueIndex},
+		},
+	})
+
 	tables = append(tables, migrator.Table{
 		Name: "entity_nested",
 		Columns: []*migrator.Column{
@@ -182,6 +200,40 @@ func addEntityStoreMigrations(mg *migrator.Migrator) {
 		},
 	})
 
+	// every write and delete appends a change with the resource version used by watch
+	tables = append(tables, migrator.Table{
+		Name: "entity_change",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "resource_version", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "grn", Type: migrator.DB_NVarchar, Length: grnLength, Nullable: false},
+
+			// Copied from the entity so deleted entities can still be filtered
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "kind", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "folder", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+
+			{Name: "action", Type: migrator.DB_Int, Nullable: false}, // EntityWatchResponse_Action
+			{Name: "created_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"resource_version"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"tenant_id", "resource_version"}},
+			{Cols: []string{"created_at"}},
+		},
+	})
+
+	// the single row holds the last resource version, it is incremented by the write transactions, which are so
+	// committed in the order of their resource version. It is inserted by the first write.
+	tables = append(tables, migrator.Table{
+		Name: "entity_resource_version",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, Nullable: false, IsPrimaryKey: true},
+			{Name: "resource_version", Type: migrator.DB_BigInt, Nullable: false},
+		},
+	})
+
 	// !!! This should not run in production!
 	// The object store SQL schema is still in active development and this
 	// will only be called when the feature toggle is enabled
@@ -193,7 +245,7 @@ func addEntityStoreMigrations(mg *migrator.Migrator) {
 	// Migration cleanups: given that this is a complex setup
 	// that requires a lot of testing before we are ready to push out of dev
 	// this script lets us easy wipe previous changes and initialize clean tables
-	suffix := " (v010)" // change this when we want to wipe and reset the object tables
+	suffix := " (v012)" // change this when we want to wipe and reset the object tables
 	mg.AddMigration("EntityStore init: cleanup"+suffix, migrator.NewRawSQLMigration(strings.TrimSpace(`
 		DELETE FROM migration_log WHERE migration_id LIKE 'EntityStore init%';
 	`)))
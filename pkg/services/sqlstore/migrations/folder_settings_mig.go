@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addFolderSettingsMigrations(mg *Migrator) {
+	folderSettingsV1 := Table{
+		Name: "folder_settings",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "folder_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "default_datasource_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "query_timeout_seconds", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "label_matchers", Type: DB_Text, Nullable: true},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "folder_uid"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create folder_settings table v1", NewAddTableMigration(folderSettingsV1))
+	mg.AddMigration("add unique index folder_settings.org_id-folder_uid", NewAddIndexMigration(folderSettingsV1, folderSettingsV1.Indices[0]))
+}
@@ -100,6 +100,12 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 	AddExternalAlertmanagerToDatasourceMigration(mg)
 
 	addFolderMigrations(mg)
+
+	addAsyncQueryMigrations(mg)
+
+	addSavedSearchMigrations(mg)
+
+	addFolderSettingsMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addSavedSearchMigrations(mg *Migrator) {
+	savedSearchV1 := Table{
+		Name: "saved_search",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "title", Type: DB_NVarchar, Length: 189, Nullable: false},
+			{Name: "query", Type: DB_Text, Nullable: false},
+			{Name: "notify", Type: DB_Bool, Nullable: false, Default: "0"},
+			{Name: "matched_uids", Type: DB_MediumText, Nullable: true},
+			{Name: "created", Type: DB_BigInt, Nullable: false},
+			{Name: "updated", Type: DB_BigInt, Nullable: false},
+			{Name: "last_evaluated", Type: DB_BigInt, Nullable: false, Default: "0"},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "user_id"}},
+		},
+	}
+
+	mg.AddMigration("create saved_search table v1", NewAddTableMigration(savedSearchV1))
+	mg.AddMigration("add unique index saved_search.org_id-uid", NewAddIndexMigration(savedSearchV1, savedSearchV1.Indices[0]))
+	mg.AddMigration("add index saved_search.org_id-user_id", NewAddIndexMigration(savedSearchV1, savedSearchV1.Indices[1]))
+}
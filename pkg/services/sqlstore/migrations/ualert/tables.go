@@ -24,6 +24,16 @@ func AddTablesMigrations(mg *migrator.Migrator) {
 	mg.AddMigration("add last_applied column to alert_configuration_history", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_configuration_history"}, &migrator.Column{
 		Name: "last_applied", Type: migrator.DB_Int, Nullable: false, Default: "0",
 	}))
+
+	mg.AddMigration("add paused_dimensions column to alert_rule table", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_rule"}, &migrator.Column{
+		Name: "paused_dimensions", Type: migrator.DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("add paused_dimensions column to alert_rule_version table", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_rule_version"}, &migrator.Column{
+		Name: "paused_dimensions", Type: migrator.DB_Text, Nullable: true,
+	}))
+
+	addNotificationDeliveryMigrations(mg)
 }
 
 // historicalTableMigrations contains those migrations that existed prior to creating the improved messaging around migration immutability.
@@ -567,3 +577,38 @@ func (c extractAlertmanagerConfigurationHistory) Exec(sess *xorm.Session, migrat
 	}
 	return nil
 }
+
+func addNotificationDeliveryMigrations(mg *migrator.Migrator) {
+	deliveryTable := migrator.Table{
+		Name: "alert_notification_delivery",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "delivery_key", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "receiver", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "integration_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "integration_name", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "integration_type", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "group_key", Type: migrator.DB_Text, Nullable: false},
+			{Name: "group_labels", Type: migrator.DB_Text, Nullable: false},
+			{Name: "alerts", Type: migrator.DB_MediumText, Nullable: false},
+			{Name: "state", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "attempts", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "retries", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "last_error", Type: migrator.DB_Text, Nullable: false},
+			{Name: "next_attempt_at", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "created_at", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated_at", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "delivery_key", "state"}},
+			{Cols: []string{"state", "next_attempt_at"}},
+			{Cols: []string{"updated_at"}},
+		},
+	}
+
+	mg.AddMigration("create alert_notification_delivery table", migrator.NewAddTableMigration(deliveryTable))
+	mg.AddMigration("add index on org_id, delivery_key and state to alert_notification_delivery table", migrator.NewAddIndexMigration(deliveryTable, deliveryTable.Indices[0]))
+	mg.AddMigration("add index on state and next_attempt_at to alert_notification_delivery table", migrator.NewAddIndexMigration(deliveryTable, deliveryTable.Indices[1]))
+	mg.AddMigration("add index on updated_at to alert_notification_delivery table", migrator.NewAddIndexMigration(deliveryTable, deliveryTable.Indices[2]))
+}
@@ -132,7 +132,9 @@ func (f AccessControlDashboardPermissionFilter) Where() (string, []interface{})
 	folderWildcards := accesscontrol.WildcardsFromPrefix(dashboards.ScopeFoldersPrefix)
 
 	filter, params := accesscontrol.UserRolesFilter(f.user.OrgID, f.user.UserID, f.user.Teams, accesscontrol.GetOrgRoles(f.user))
-	rolesFilter := " AND role_id IN(SELECT id FROM role " + filter + ") "
+	notExpired, notExpiredParams := accesscontrol.PermissionNotExpiredFilter("expires")
+	rolesFilter := " AND role_id IN(SELECT id FROM role " + filter + ") AND " + notExpired + " "
+	params = append(params, notExpiredParams...)
 	var args []interface{}
 	builder := strings.Builder{}
 	builder.WriteRune('(')
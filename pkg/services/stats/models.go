@@ -114,6 +114,15 @@ type GetSystemUserCountStatsQuery struct {
 	Result *SystemUserCountStats
 }
 
+type OrgActiveUsersStats struct {
+	OrgID int64 `xorm:"org_id"`
+	Count int64
+}
+
+type GetOrgActiveUsersStatsQuery struct {
+	Result []*OrgActiveUsersStats
+}
+
 type UserStats struct {
 	Users   int64
 	Admins  int64
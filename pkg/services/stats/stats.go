@@ -11,4 +11,5 @@ type Service interface {
 	GetDataSourceAccessStats(ctx context.Context, query *GetDataSourceAccessStatsQuery) error
 	GetSystemStats(ctx context.Context, query *GetSystemStatsQuery) error
 	GetSystemUserCountStats(ctx context.Context, query *GetSystemUserCountStatsQuery) error
+	GetOrgActiveUsersStats(ctx context.Context, query *GetOrgActiveUsersStatsQuery) error
 }
@@ -265,6 +265,19 @@ func (ss *sqlStatsService) GetSystemUserCountStats(ctx context.Context, query *s
 	})
 }
 
+// GetOrgActiveUsersStats counts the users seen in the last 30 days, by organization
+func (ss *sqlStatsService) GetOrgActiveUsersStats(ctx context.Context, query *stats.GetOrgActiveUsersStatsQuery) error {
+	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		dialect := ss.db.GetDialect()
+		rawSQL := `SELECT org_user.org_id AS org_id, COUNT(*) AS count FROM ` + dialect.Quote("org_user") + ` AS org_user
+			INNER JOIN ` + dialect.Quote("user") + ` AS u ON u.id = org_user.user_id
+			WHERE u.` + notServiceAccount(dialect) + ` AND u.last_seen_at > ?
+			GROUP BY org_user.org_id`
+		query.Result = make([]*stats.OrgActiveUsersStats, 0)
+		return sess.SQL(rawSQL, time.Now().Add(-activeUserTimeLimit)).Find(&query.Result)
+	})
+}
+
 func (ss *sqlStatsService) IsUnifiedAlertingEnabled() bool {
 	return ss.cfg != nil && ss.cfg.UnifiedAlerting.IsEnabled()
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -69,6 +70,30 @@ func TestIntegrationStatsDataAccess(t *testing.T) {
 		err := statsService.GetAdminStats(context.Background(), &query)
 		assert.NoError(t, err)
 	})
+
+	t.Run("Get org active users stats should count the users seen recently", func(t *testing.T) {
+		query := stats.GetOrgActiveUsersStatsQuery{}
+		err := statsService.GetOrgActiveUsersStats(context.Background(), &query)
+		require.NoError(t, err)
+		assert.Empty(t, query.Result)
+
+		err = db.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			_, err := sess.Exec("UPDATE "+db.GetDialect().Quote("user")+" SET last_seen_at = ?", time.Now())
+			return err
+		})
+		require.NoError(t, err)
+
+		err = statsService.GetOrgActiveUsersStats(context.Background(), &query)
+		require.NoError(t, err)
+		require.NotEmpty(t, query.Result)
+		var memberships int64
+		for _, s := range query.Result {
+			assert.NotZero(t, s.OrgID)
+			memberships += s.Count
+		}
+		// the 3 users are members of their organization, and 3 of them were added to another organization
+		assert.Equal(t, int64(6), memberships)
+	})
 }
 
 func populateDB(t *testing.T, sqlStore *sqlstore.SQLStore) {
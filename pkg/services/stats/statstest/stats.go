@@ -11,6 +11,7 @@ type FakeService struct {
 	ExpectedDataSourceStats        []*stats.DataSourceStats
 	ExpectedDataSourcesAccessStats []*stats.DataSourceAccessStats
 	ExpectedNotifierUsageStats     []*stats.NotifierUsageStats
+	ExpectedOrgActiveUsersStats    []*stats.OrgActiveUsersStats
 
 	ExpectedError error
 }
@@ -46,3 +47,8 @@ func (s *FakeService) GetSystemStats(ctx context.Context, query *stats.GetSystem
 func (s *FakeService) GetSystemUserCountStats(ctx context.Context, query *stats.GetSystemUserCountStatsQuery) error {
 	return s.ExpectedError
 }
+
+func (s *FakeService) GetOrgActiveUsersStats(ctx context.Context, query *stats.GetOrgActiveUsersStatsQuery) error {
+	query.Result = s.ExpectedOrgActiveUsersStats
+	return s.ExpectedError
+}
@@ -0,0 +1,70 @@
+package httpentitystore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+// admit runs the validators registered for the kind before the entity is written, like the admission of the
+// Kubernetes API. It returns the response rejecting the write when a field error is not a warning, the field errors
+// are returned as the details of its error info. The warnings are added to the HTTP response.
+func (s *httpEntityStore) admit(ctx context.Context, header http.Header, grn *entity.GRN, folder string, body []byte) (*entity.WriteEntityResponse, error) {
+	validators := s.kinds.GetValidators(grn.Kind)
+	if len(validators) == 0 {
+		return nil, nil
+	}
+
+	existing, err := s.store.Read(ctx, &entity.ReadEntityRequest{GRN: grn})
+	if err != nil {
+		return nil, err
+	}
+	req := &entity.EntityValidationRequest{
+		GRN:      grn,
+		Folder:   folder,
+		Body:     body,
+		IsUpdate: existing != nil && existing.GRN != nil,
+	}
+	if builder := s.kinds.GetSummaryBuilder(grn.Kind); builder != nil {
+		summary, sanitized, err := builder(ctx, grn.UID, body)
+		if err != nil {
+			return nil, err
+		}
+		req.Summary, req.Body = summary, sanitized
+	}
+
+	var fieldErrors []entity.EntityFieldError
+	for _, validator := range validators {
+		errs, err := validator(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range errs {
+			if e.Warning {
+				header.Add("Warning", fmt.Sprintf("299 - %q", e.Field+": "+e.Message))
+				continue
+			}
+			fieldErrors = append(fieldErrors, e)
+		}
+	}
+	if len(fieldErrors) == 0 {
+		return nil, nil
+	}
+
+	details, err := json.Marshal(fieldErrors)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.WriteEntityResponse{
+		GRN:    grn,
+		Status: entity.WriteEntityResponse_ERROR,
+		Error: &entity.EntityErrorInfo{
+			Code:        http.StatusBadRequest,
+			Message:     "entity validation failed",
+			DetailsJson: details,
+		},
+	}, nil
+}
@@ -0,0 +1,74 @@
+package httpentitystore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/kind"
+)
+
+type fakeEntityStore struct {
+	entity.EntityStoreServer
+	existing map[string]bool
+}
+
+func (f *fakeEntityStore) Read(_ context.Context, r *entity.ReadEntityRequest) (*entity.Entity, error) {
+	if !f.existing[r.GRN.UID] {
+		return &entity.Entity{}, nil
+	}
+	return &entity.Entity{GRN: r.GRN}, nil
+}
+
+func TestAdmit(t *testing.T) {
+	store := &fakeEntityStore{existing: map[string]bool{"existing": true}}
+	s := &httpEntityStore{store: store, log: log.New("test"), kinds: kind.NewKindRegistry()}
+	grn := func(uid string) *entity.GRN {
+		return &entity.GRN{TenantId: 1, Kind: entity.StandardKindDashboard, UID: uid}
+	}
+
+	t.Run("should warn about legacy dashboards without rejecting them", func(t *testing.T) {
+		header := http.Header{}
+		rejected, err := s.admit(context.Background(), header, grn("legacy"), "", []byte(`{"title":"","panels":[{"id":1},{"id":1}]}`))
+		require.NoError(t, err)
+		require.Nil(t, rejected)
+		require.Equal(t, []string{
+			`299 - "title: dashboard title is required"`,
+			`299 - "panels[1].id: panel id 1 is already used by panels[0]"`,
+		}, header.Values("Warning"))
+	})
+
+	t.Run("should reject the field errors of the registered validators", func(t *testing.T) {
+		kinds := kind.NewKindRegistry()
+		s := &httpEntityStore{store: store, log: log.New("test"), kinds: kinds}
+		var updates []bool
+		err := kinds.RegisterValidator(entity.StandardKindDashboard, func(ctx context.Context, req *entity.EntityValidationRequest) ([]entity.EntityFieldError, error) {
+			updates = append(updates, req.IsUpdate)
+			if req.Summary.Name == "forbidden" {
+				return []entity.EntityFieldError{{Field: "title", Message: "forbidden title"}}, nil
+			}
+			return nil, nil
+		})
+		require.NoError(t, err)
+
+		rejected, err := s.admit(context.Background(), http.Header{}, grn("new"), "", []byte(`{"title":"hello"}`))
+		require.NoError(t, err)
+		require.Nil(t, rejected)
+
+		rejected, err = s.admit(context.Background(), http.Header{}, grn("existing"), "", []byte(`{"title":"forbidden"}`))
+		require.NoError(t, err)
+		require.NotNil(t, rejected)
+		require.Equal(t, entity.WriteEntityResponse_ERROR, rejected.Status)
+		require.Equal(t, int64(400), rejected.Error.Code)
+		var fieldErrors []entity.EntityFieldError
+		require.NoError(t, json.Unmarshal(rejected.Error.DetailsJson, &fieldErrors))
+		require.Equal(t, []entity.EntityFieldError{{Field: "title", Message: "forbidden title"}}, fieldErrors)
+
+		require.Equal(t, []bool{false, true}, updates)
+	})
+}
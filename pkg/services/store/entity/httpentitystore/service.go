@@ -1,6 +1,8 @@
 package httpentitystore
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 
+	"google.golang.org/grpc"
+
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -51,6 +55,7 @@ func (s *httpEntityStore) RegisterHTTPRoutes(route routing.RouteRegister) {
 	route.Get("/history/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doGetHistory))
 	route.Get("/list/:uid", reqGrafanaAdmin, routing.Wrap(s.doListFolder)) // Simplified version of search -- path is prefix
 	route.Get("/search", reqGrafanaAdmin, routing.Wrap(s.doSearch))
+	route.Get("/watch", reqGrafanaAdmin, routing.Wrap(s.doWatch))
 
 	// File upload
 	route.Post("/upload", reqGrafanaAdmin, routing.Wrap(s.doUpload))
@@ -173,6 +178,14 @@ func (s *httpEntityStore) doWriteEntity(c *contextmodel.ReqContext) response.Res
 		return response.Error(400, "error reading body", err)
 	}
 
+	rejected, err := s.admit(c.Req.Context(), c.Resp.Header(), grn, params["folder"], b)
+	if err != nil {
+		return response.Error(400, "error validating entity", err)
+	}
+	if rejected != nil {
+		return response.JSON(400, rejected)
+	}
+
 	rsp, err := s.store.Write(c.Req.Context(), &entity.WriteEntityRequest{
 		GRN:             grn,
 		Body:            b,
@@ -183,6 +196,9 @@ func (s *httpEntityStore) doWriteEntity(c *contextmodel.ReqContext) response.Res
 	if err != nil {
 		return response.Error(500, "?", err)
 	}
+	if rsp.Error != nil {
+		return response.JSON(400, rsp)
+	}
 	return response.JSON(200, rsp)
 }
 
@@ -283,6 +299,15 @@ func (s *httpEntityStore) doUpload(c *contextmodel.ReqContext) response.Response
 				}
 			}
 
+			rejected, err := s.admit(ctx, c.Resp.Header(), grn, folder, data)
+			if err != nil {
+				return response.Error(400, "error validating entity: "+fileHeader.Filename, err)
+			}
+			if rejected != nil {
+				rsp = append(rsp, rejected)
+				continue
+			}
+
 			result, err := s.store.Write(ctx, &entity.WriteEntityRequest{
 				GRN:     grn,
 				Body:    data,
@@ -332,6 +357,73 @@ func (s *httpEntityStore) doSearch(c *contextmodel.ReqContext) response.Response
 	return response.JSON(200, rsp)
 }
 
+// doWatch streams the changes of the entities, such as the dashboards with ?kind=dashboard, as one JSON encoded
+// response per line. The current entities are sent first, unless the watch resumes from the resource version of the
+// last response received with ?since=.
+func (s *httpEntityStore) doWatch(c *contextmodel.ReqContext) response.Response {
+	vals := c.Req.URL.Query()
+
+	req := &entity.EntityWatchRequest{
+		WithBody:   asBoolean("body", vals, false),
+		WithLabels: asBoolean("labels", vals, false),
+		WithFields: asBoolean("fields", vals, false),
+		Kind:       vals["kind"],
+		Folder:     vals.Get("folder"),
+	}
+	if vals.Has("since") {
+		since, err := strconv.ParseInt(vals.Get("since"), 10, 64)
+		if err != nil {
+			return response.Error(400, "bad since", err)
+		}
+		req.Since = since
+	}
+	for _, label := range vals["label"] {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok {
+			return response.Error(400, "bad label, expected key=value", nil)
+		}
+		if req.Labels == nil {
+			req.Labels = map[string]string{}
+		}
+		req.Labels[key] = value
+	}
+
+	stream := &watchStream{ctx: c.Req.Context(), w: c.Resp}
+	if err := s.store.Watch(req, stream); err != nil {
+		if !stream.started {
+			return response.Error(500, "error watching entities", err)
+		}
+		// the status is already sent, the client sees the stream end before the context is done
+		s.log.Error("Failed to watch entities", "error", err)
+	}
+	return nil
+}
+
+// watchStream writes the watch responses to the HTTP response, flushing every response
+type watchStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	w       web.ResponseWriter
+	started bool
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchStream) Send(rsp *entity.EntityWatchResponse) error {
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+		s.w.WriteHeader(200)
+		s.started = true
+	}
+	if err := json.NewEncoder(s.w).Encode(rsp); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
 func asBoolean(key string, vals url.Values, defaultValue bool) bool {
 	v, ok := vals[key]
 	if !ok {
@@ -135,3 +135,33 @@ type EntityExternalReference struct {
 // EntitySummaryBuilder will read an object, validate it, and return a summary, sanitized payload, or an error
 // This should not include values that depend on system state, only the raw object
 type EntitySummaryBuilder = func(ctx context.Context, uid string, body []byte) (*EntitySummary, []byte, error)
+
+// EntityValidationRequest is passed to the validators of a kind before an entity is created or updated
+type EntityValidationRequest struct {
+	GRN    *GRN
+	Folder string
+
+	// The sanitized body and summary returned by the summary builder
+	Body    []byte
+	Summary *EntitySummary
+
+	// True when an entity with the same GRN already exists
+	IsUpdate bool
+}
+
+// EntityFieldError describes a field of the entity body that failed validation
+type EntityFieldError struct {
+	// Path to the field in the body, eg: panels[0].title
+	Field string `json:"field"`
+
+	// Why the value is not valid
+	Message string `json:"message"`
+
+	// Warning is true when the value is accepted, for example to keep saving the entities written before the
+	// validation. The warnings are returned with the write response instead of rejecting it.
+	Warning bool `json:"warning,omitempty"`
+}
+
+// EntityValidator checks an entity before it is saved by the HTTP API. Any field error which is not a warning rejects
+// the write, while an error means the validation itself could not run
+type EntityValidator = func(ctx context.Context, req *EntityValidationRequest) ([]EntityFieldError, error)
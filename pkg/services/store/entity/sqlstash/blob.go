@@ -0,0 +1,128 @@
+package sqlstash
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+)
+
+const (
+	// bodies larger than this are compressed, most dashboards are smaller and stay readable in the database
+	bodyCompressionThreshold = 64 * 1024
+	// bodies still larger than this once compressed are split in chunks of this size in the entity_blob table, so
+	// that no row exceeds the maximum packet size of the database (4MB by default in MySQL 5.7)
+	bodyChunkSize = 1024 * 1024
+
+	bodyEncodingGzip = "gzip"
+)
+
+// bodyFields are the columns of the entity and entity_history tables scanned by storedBody.scanArgs
+var bodyFields = []string{"body", "body_encoding", "body_chunks"}
+
+// storedBody is the body of an entity version as stored in the database: the body is compressed when its encoding is
+// set, and it is split in the entity_blob table when it has chunks.
+type storedBody struct {
+	data     []byte
+	encoding string
+	chunks   int64
+}
+
+func (b *storedBody) scanArgs() []interface{} {
+	return []interface{}{&b.data, &b.encoding, &b.chunks}
+}
+
+// encodeBody compresses the large bodies, and splits the ones too large for a single row in chunks
+func (s *sqlEntityServer) encodeBody(body []byte) (*storedBody, [][]byte, error) {
+	stored := &storedBody{data: body}
+	if s.bodyCompressionThreshold > 0 && len(body) > s.bodyCompressionThreshold {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return nil, nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, nil, err
+		}
+		// bodies that don't compress are kept as is
+		if buf.Len() < len(body) {
+			stored.data = buf.Bytes()
+			stored.encoding = bodyEncodingGzip
+		}
+	}
+
+	if s.bodyChunkSize <= 0 || len(stored.data) <= s.bodyChunkSize {
+		return stored, nil, nil
+	}
+	var chunks [][]byte
+	for data := stored.data; len(data) > 0; {
+		n := s.bodyChunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	stored.data = nil
+	stored.chunks = int64(len(chunks))
+	return stored, chunks, nil
+}
+
+// writeBodyChunks stores the chunks of the body of a version of the entity
+func writeBodyChunks(ctx context.Context, tx *session.SessionTx, grn string, version string, chunks [][]byte) error {
+	for i, chunk := range chunks {
+		_, err := tx.Exec(ctx, "INSERT INTO entity_blob (grn, version, chunk, data) VALUES (?, ?, ?, ?)", grn, version, i, chunk)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBody returns the original body of a version of the entity. It must not be called while the rows of another
+// query are being read, the chunks are read with a query of their own.
+func (s *sqlEntityServer) readBody(ctx context.Context, grn string, version string, b *storedBody) ([]byte, error) {
+	data := b.data
+	if b.chunks > 0 {
+		rows, err := s.sess.Query(ctx, "SELECT data FROM entity_blob WHERE grn=? AND version=? ORDER BY chunk", grn, version)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = rows.Close() }()
+
+		var buf bytes.Buffer
+		var count int64
+		for rows.Next() {
+			var chunk []byte
+			if err := rows.Scan(&chunk); err != nil {
+				return nil, err
+			}
+			buf.Write(chunk)
+			count++
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if count != b.chunks {
+			return nil, fmt.Errorf("body of %s version %s is incomplete, found %d chunks of %d", grn, version, count, b.chunks)
+		}
+		data = buf.Bytes()
+	}
+
+	switch b.encoding {
+	case "":
+		return data, nil
+	case bodyEncodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = zr.Close() }()
+		return io.ReadAll(zr)
+	default:
+		return nil, fmt.Errorf("unknown body encoding %q", b.encoding)
+	}
+}
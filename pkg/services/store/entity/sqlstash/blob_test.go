@@ -0,0 +1,140 @@
+package sqlstash
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+func TestEncodeBody(t *testing.T) {
+	s := &sqlEntityServer{bodyCompressionThreshold: 100, bodyChunkSize: 50}
+
+	t.Run("small bodies are stored as is", func(t *testing.T) {
+		stored, chunks, err := s.encodeBody([]byte(`{"title":"small"}`))
+		require.NoError(t, err)
+		assert.Empty(t, stored.encoding)
+		assert.Empty(t, chunks)
+		assert.Equal(t, `{"title":"small"}`, string(stored.data))
+	})
+
+	t.Run("large bodies are compressed", func(t *testing.T) {
+		body := []byte(strings.Repeat(`{"title":"panel"}`, 10))
+		stored, chunks, err := s.encodeBody(body)
+		require.NoError(t, err)
+		assert.Equal(t, bodyEncodingGzip, stored.encoding)
+		assert.Empty(t, chunks)
+		assert.Less(t, len(stored.data), 50)
+	})
+
+	t.Run("bodies that don't compress are split in chunks", func(t *testing.T) {
+		body := make([]byte, 120)
+		_, _ = rand.New(rand.NewSource(1)).Read(body)
+		stored, chunks, err := s.encodeBody(body)
+		require.NoError(t, err)
+		assert.Empty(t, stored.encoding)
+		assert.Nil(t, stored.data)
+		assert.Equal(t, int64(3), stored.chunks)
+		require.Len(t, chunks, 3)
+		assert.Equal(t, body, append(append(chunks[0], chunks[1]...), chunks[2]...))
+	})
+}
+
+func TestIntegrationLargeBodies(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	s, ctx := newTestEntityServer(t)
+	s.bodyCompressionThreshold = 1024
+	s.bodyChunkSize = 4096
+
+	// a dashboard with many queries, that compresses to several chunks
+	random := rand.New(rand.NewSource(1))
+	panels := make([]string, 0, 500)
+	for i := 0; i < cap(panels); i++ {
+		panels = append(panels, fmt.Sprintf(`{"id":%d,"targets":[{"refId":"A","expr":"rate(http_requests_total{job=\"%x\"}[5m])"}]}`, i, random.Int63()))
+	}
+	large := `{"title":"large","panels":[` + strings.Join(panels, ",") + `]}`
+	small := `{"title":"small"}`
+
+	grn := &entity.GRN{Kind: entity.StandardKindJSONObj, UID: "large"}
+	first, err := s.Write(ctx, &entity.WriteEntityRequest{GRN: grn, Body: []byte(large)})
+	require.NoError(t, err)
+	require.Nil(t, first.Error)
+	second, err := s.Write(ctx, &entity.WriteEntityRequest{GRN: grn, Body: []byte(small), PreviousVersion: first.Entity.Version})
+	require.NoError(t, err)
+	require.Nil(t, second.Error)
+	third, err := s.Write(ctx, &entity.WriteEntityRequest{GRN: grn, Body: []byte(large), PreviousVersion: second.Entity.Version})
+	require.NoError(t, err)
+	require.Nil(t, third.Error)
+
+	var chunks int64
+	rows, err := s.sess.Query(ctx, "SELECT body_chunks FROM entity WHERE grn=?", grn.ToGRNString())
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&chunks))
+	require.NoError(t, rows.Close())
+	assert.Greater(t, chunks, int64(1))
+
+	t.Run("read", func(t *testing.T) {
+		e, err := s.Read(ctx, &entity.ReadEntityRequest{GRN: grn, WithBody: true})
+		require.NoError(t, err)
+		assert.JSONEq(t, large, string(e.Body))
+		// the size and etag are the ones of the original body
+		assert.Equal(t, third.Entity.Size, e.Size)
+		assert.Equal(t, third.Entity.ETag, e.ETag)
+		assert.Equal(t, int64(len(e.Body)), e.Size)
+	})
+
+	t.Run("read from history", func(t *testing.T) {
+		e, err := s.Read(ctx, &entity.ReadEntityRequest{GRN: grn, Version: first.Entity.Version, WithBody: true, WithSummary: true})
+		require.NoError(t, err)
+		assert.JSONEq(t, large, string(e.Body))
+
+		e, err = s.Read(ctx, &entity.ReadEntityRequest{GRN: grn, Version: second.Entity.Version, WithBody: true})
+		require.NoError(t, err)
+		assert.JSONEq(t, small, string(e.Body))
+	})
+
+	t.Run("batch read and search", func(t *testing.T) {
+		other := &entity.GRN{Kind: entity.StandardKindJSONObj, UID: "small"}
+		_, err := s.Write(ctx, &entity.WriteEntityRequest{GRN: other, Body: []byte(small)})
+		require.NoError(t, err)
+
+		batch, err := s.BatchRead(ctx, &entity.BatchReadEntityRequest{Batch: []*entity.ReadEntityRequest{
+			{GRN: grn, WithBody: true},
+			{GRN: other, WithBody: true},
+		}})
+		require.NoError(t, err)
+		require.Len(t, batch.Results, 2)
+		for _, e := range batch.Results {
+			assert.Equal(t, e.Size, int64(len(e.Body)))
+		}
+
+		search, err := s.Search(ctx, &entity.EntitySearchRequest{Kind: []string{entity.StandardKindJSONObj}, WithBody: true, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, search.Results, 2)
+		for _, r := range search.Results {
+			assert.Equal(t, r.Size, int64(len(r.Body)))
+		}
+	})
+
+	t.Run("delete removes the chunks", func(t *testing.T) {
+		_, err := s.Delete(ctx, &entity.DeleteEntityRequest{GRN: grn})
+		require.NoError(t, err)
+
+		var count int64
+		rows, err := s.sess.Query(ctx, "SELECT COUNT(*) FROM entity_blob WHERE grn=?", grn.ToGRNString())
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&count))
+		require.NoError(t, rows.Close())
+		assert.Equal(t, int64(0), count)
+	})
+}
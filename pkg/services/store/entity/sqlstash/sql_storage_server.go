@@ -32,6 +32,12 @@ func ProvideSQLEntityServer(db db.DB, cfg *setting.Cfg, grpcServerProvider grpcs
 		log:      log.New("sql-entity-server"),
 		kinds:    kinds,
 		resolver: resolver,
+
+		watchPollInterval:     watchPollInterval,
+		watchBookmarkInterval: watchBookmarkInterval,
+
+		bodyCompressionThreshold: bodyCompressionThreshold,
+		bodyChunkSize:            bodyChunkSize,
 	}
 	entity.RegisterEntityStoreServer(grpcServerProvider.GetServer(), entityServer)
 	return entityServer
@@ -42,9 +48,20 @@ type sqlEntityServer struct {
 	sess     *session.SessionDB
 	kinds    kind.KindRegistry
 	resolver resolver.EntityReferenceResolver
+
+	watchPollInterval     time.Duration
+	watchBookmarkInterval time.Duration
+
+	bodyCompressionThreshold int
+	bodyChunkSize            int
 }
 
 func getReadSelect(r *entity.ReadEntityRequest) string {
+	return "SELECT " + strings.Join(getReadFields(r), ",") + " FROM entity WHERE "
+}
+
+// getReadFields returns the columns scanned by rowToReadEntityResponse
+func getReadFields(r *entity.ReadEntityRequest) []string {
 	fields := []string{
 		"tenant_id", "kind", "uid", "folder", // GRN + folder
 		"version", "size", "etag", "errors", // errors are always returned
@@ -53,19 +70,22 @@ func getReadSelect(r *entity.ReadEntityRequest) string {
 		"origin", "origin_key", "origin_ts"}
 
 	if r.WithBody {
-		fields = append(fields, `body`)
+		fields = append(fields, bodyFields...)
 	}
 	if r.WithSummary {
 		fields = append(fields, "name", "slug", "description", "labels", "fields")
 	}
-	return "SELECT " + strings.Join(fields, ",") + " FROM entity WHERE "
+	return fields
 }
 
-func (s *sqlEntityServer) rowToReadEntityResponse(ctx context.Context, rows *sql.Rows, r *entity.ReadEntityRequest) (*entity.Entity, error) {
+// rowToReadEntityResponse returns the stored body of the entity with it when requested, the body of the entity is set
+// by readBody once the rows are closed
+func (s *sqlEntityServer) rowToReadEntityResponse(ctx context.Context, rows *sql.Rows, r *entity.ReadEntityRequest) (*entity.Entity, *storedBody, error) {
 	raw := &entity.Entity{
 		GRN:    &entity.GRN{},
 		Origin: &entity.EntityOriginInfo{},
 	}
+	body := &storedBody{}
 
 	summaryjson := &summarySupport{}
 	args := []interface{}{
@@ -76,7 +96,7 @@ func (s *sqlEntityServer) rowToReadEntityResponse(ctx context.Context, rows *sql
 		&raw.Origin.Source, &raw.Origin.Key, &raw.Origin.Time,
 	}
 	if r.WithBody {
-		args = append(args, &raw.Body)
+		args = append(args, body.scanArgs()...)
 	}
 	if r.WithSummary {
 		args = append(args, &summaryjson.name, &summaryjson.slug, &summaryjson.description, &summaryjson.labels, &summaryjson.fields)
@@ -84,7 +104,7 @@ func (s *sqlEntityServer) rowToReadEntityResponse(ctx context.Context, rows *sql
 
 	err := rows.Scan(args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if raw.Origin.Source == "" {
@@ -94,16 +114,19 @@ func (s *sqlEntityServer) rowToReadEntityResponse(ctx context.Context, rows *sql
 	if r.WithSummary || summaryjson.errors != nil {
 		summary, err := summaryjson.toEntitySummary()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		js, err := json.Marshal(summary)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		raw.SummaryJson = js
 	}
-	return raw, nil
+	if !r.WithBody {
+		body = nil
+	}
+	return raw, body, nil
 }
 
 func (s *sqlEntityServer) validateGRN(ctx context.Context, grn *entity.GRN) (*entity.GRN, error) {
@@ -157,7 +180,17 @@ func (s *sqlEntityServer) Read(ctx context.Context, r *entity.ReadEntityRequest)
 		return &entity.Entity{}, nil
 	}
 
-	return s.rowToReadEntityResponse(ctx, rows, r)
+	raw, body, err := s.rowToReadEntityResponse(ctx, rows, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if body != nil {
+		raw.Body, err = s.readBody(ctx, grn.ToGRNString(), raw.Version, body)
+	}
+	return raw, err
 }
 
 func (s *sqlEntityServer) readFromHistory(ctx context.Context, r *entity.ReadEntityRequest) (*entity.Entity, error) {
@@ -167,10 +200,10 @@ func (s *sqlEntityServer) readFromHistory(ctx context.Context, r *entity.ReadEnt
 	}
 	oid := grn.ToGRNString()
 
-	fields := []string{
-		"body", "size", "etag",
+	fields := append([]string{
+		"size", "etag",
 		"updated_at", "updated_by",
-	}
+	}, bodyFields...)
 
 	rows, err := s.sess.Query(ctx,
 		"SELECT "+strings.Join(fields, ",")+
@@ -188,7 +221,15 @@ func (s *sqlEntityServer) readFromHistory(ctx context.Context, r *entity.ReadEnt
 	raw := &entity.Entity{
 		GRN: r.GRN,
 	}
-	err = rows.Scan(&raw.Body, &raw.Size, &raw.ETag, &raw.UpdatedAt, &raw.UpdatedBy)
+	body := &storedBody{}
+	err = rows.Scan(append([]interface{}{&raw.Size, &raw.ETag, &raw.UpdatedAt, &raw.UpdatedBy}, body.scanArgs()...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	raw.Body, err = s.readBody(ctx, oid, r.Version, body)
 	if err != nil {
 		return nil, err
 	}
@@ -257,12 +298,28 @@ func (s *sqlEntityServer) BatchRead(ctx context.Context, b *entity.BatchReadEnti
 
 	// TODO? make sure the results are in order?
 	rsp := &entity.BatchReadEntityResponse{}
+	var bodies []*storedBody
 	for rows.Next() {
-		r, err := s.rowToReadEntityResponse(ctx, rows, req)
+		r, body, err := s.rowToReadEntityResponse(ctx, rows, req)
 		if err != nil {
 			return nil, err
 		}
 		rsp.Results = append(rsp.Results, r)
+		bodies = append(bodies, body)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	for i, body := range bodies {
+		if body == nil {
+			continue
+		}
+		r := rsp.Results[i]
+		r.Body, err = s.readBody(ctx, r.GRN.ToGRNString(), r.Version, body)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return rsp, nil
 }
@@ -381,18 +438,25 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 			}
 		}
 
-		// 1. Add the `entity_history` values
+		// 1. Add the `entity_history` values, the chunks of the body are shared with the `entity` table
 		versionInfo.Size = int64(len(body))
 		versionInfo.ETag = etag
 		versionInfo.UpdatedAt = updatedAt
 		versionInfo.UpdatedBy = updatedBy
+		stored, chunks, err := s.encodeBody(body)
+		if err != nil {
+			return err
+		}
+		if err := writeBodyChunks(ctx, tx, oid, versionInfo.Version, chunks); err != nil {
+			return err
+		}
 		_, err = tx.Exec(ctx, `INSERT INTO entity_history (`+
 			"grn, version, message, "+
-			"size, body, etag, "+
+			"size, body, body_encoding, body_chunks, etag, "+
 			"updated_at, updated_by) "+
-			"VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 			oid, versionInfo.Version, versionInfo.Comment,
-			versionInfo.Size, body, versionInfo.ETag,
+			versionInfo.Size, stored.data, stored.encoding, stored.chunks, versionInfo.ETag,
 			updatedAt, versionInfo.UpdatedBy,
 		)
 		if err != nil {
@@ -404,13 +468,13 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 		if isUpdate {
 			rsp.Status = entity.WriteEntityResponse_UPDATED
 			_, err = tx.Exec(ctx, "UPDATE entity SET "+
-				"body=?, size=?, etag=?, version=?, "+
+				"body=?, body_encoding=?, body_chunks=?, size=?, etag=?, version=?, "+
 				"updated_at=?, updated_by=?,"+
 				"name=?, description=?,"+
 				"labels=?, fields=?, errors=?, "+
 				"origin=?, origin_key=?, origin_ts=? "+
 				"WHERE grn=?",
-				body, versionInfo.Size, etag, versionInfo.Version,
+				stored.data, stored.encoding, stored.chunks, versionInfo.Size, etag, versionInfo.Version,
 				updatedAt, versionInfo.UpdatedBy,
 				summary.model.Name, summary.model.Description,
 				summary.labels, summary.fields, summary.errors,
@@ -427,19 +491,19 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 
 			_, err = tx.Exec(ctx, "INSERT INTO entity ("+
 				"grn, tenant_id, kind, uid, folder, "+
-				"size, body, etag, version, "+
+				"size, body, body_encoding, body_chunks, etag, version, "+
 				"updated_at, updated_by, created_at, created_by, "+
 				"name, description, slug, "+
 				"labels, fields, errors, "+
 				"origin, origin_key, origin_ts) "+
 				"VALUES (?, ?, ?, ?, ?, "+
-				" ?, ?, ?, ?, "+
+				" ?, ?, ?, ?, ?, ?, "+
 				" ?, ?, ?, ?, "+
 				" ?, ?, ?, "+
 				" ?, ?, ?, "+
 				" ?, ?, ?)",
 				oid, grn.TenantId, grn.Kind, grn.UID, r.Folder,
-				versionInfo.Size, body, etag, versionInfo.Version,
+				versionInfo.Size, stored.data, stored.encoding, stored.chunks, etag, versionInfo.Version,
 				updatedAt, createdBy, createdAt, createdBy,
 				summary.model.Name, summary.model.Description, summary.model.Slug,
 				summary.labels, summary.fields, summary.errors,
@@ -452,7 +516,10 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 		if err == nil {
 			summary.folder = r.Folder
 			summary.parent_grn = grn
-			return s.writeSearchInfo(ctx, tx, oid, summary)
+			err = s.writeSearchInfo(ctx, tx, oid, summary)
+		}
+		if err == nil {
+			err = recordChange(ctx, tx, oid, entity.EntityWatchResponse_UPDATED)
 		}
 		return err
 	})
@@ -634,6 +701,10 @@ func (s *sqlEntityServer) Delete(ctx context.Context, r *entity.DeleteEntityRequ
 
 	rsp := &entity.DeleteEntityResponse{}
 	err = s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		// record the change first, it copies the entity row that is about to be removed
+		if err := recordChange(ctx, tx, grn.ToGRNString(), entity.EntityWatchResponse_DELETED); err != nil {
+			return err
+		}
 		rsp.OK, err = doDelete(ctx, tx, grn)
 		return err
 	})
@@ -656,6 +727,10 @@ func doDelete(ctx context.Context, tx *session.SessionTx, grn *entity.GRN) (bool
 	if err != nil {
 		return false, err
 	}
+	_, err = tx.Exec(ctx, "DELETE FROM entity_blob WHERE grn=?", str)
+	if err != nil {
+		return false, err
+	}
 	_, err = tx.Exec(ctx, "DELETE FROM entity_labels WHERE grn=? OR parent_grn=?", str, str)
 	if err != nil {
 		return false, err
@@ -735,7 +810,7 @@ func (s *sqlEntityServer) Search(ctx context.Context, r *entity.EntitySearchRequ
 	}
 
 	if r.WithBody {
-		fields = append(fields, "body")
+		fields = append(fields, bodyFields...)
 	}
 
 	if r.WithLabels {
@@ -787,11 +862,14 @@ func (s *sqlEntityServer) Search(ctx context.Context, r *entity.EntitySearchRequ
 	defer func() { _ = rows.Close() }()
 	oid := ""
 	rsp := &entity.EntitySearchResponse{}
+	var bodies []*storedBody
+	var oids []string
 	for rows.Next() {
 		result := &entity.EntitySearchResult{
 			GRN: &entity.GRN{},
 		}
 		summaryjson := summarySupport{}
+		body := &storedBody{}
 
 		args := []interface{}{
 			&oid, &result.GRN.TenantId, &result.GRN.Kind, &result.GRN.UID,
@@ -800,7 +878,7 @@ func (s *sqlEntityServer) Search(ctx context.Context, r *entity.EntitySearchRequ
 			&result.Name, &summaryjson.description,
 		}
 		if r.WithBody {
-			args = append(args, &result.Body)
+			args = append(args, body.scanArgs()...)
 		}
 		if r.WithLabels {
 			args = append(args, &summaryjson.labels)
@@ -842,11 +920,21 @@ func (s *sqlEntityServer) Search(ctx context.Context, r *entity.EntitySearchRequ
 		}
 
 		rsp.Results = append(rsp.Results, result)
+		bodies = append(bodies, body)
+		oids = append(oids, oid)
+	}
+	if err := rows.Close(); err != nil {
+		return rsp, err
 	}
 
-	return rsp, err
-}
+	if r.WithBody {
+		for i, result := range rsp.Results {
+			result.Body, err = s.readBody(ctx, oids[i], result.Version, bodies[i])
+			if err != nil {
+				return rsp, err
+			}
+		}
+	}
 
-func (s *sqlEntityServer) Watch(*entity.EntityWatchRequest, entity.EntityStore_WatchServer) error {
-	return fmt.Errorf("unimplemented")
+	return rsp, err
 }
@@ -0,0 +1,403 @@
+package sqlstash
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+const (
+	watchPollInterval     = time.Second
+	watchBookmarkInterval = 30 * time.Second
+
+	// changes older than this are removed by the cleanup service, watches can not resume from before it
+	watchChangeRetention = 24 * time.Hour
+
+	// max number of rows read per query while listing or polling
+	watchBatchSize = 100
+)
+
+// recordChange appends an entry to the change log for the entity. It must run inside the write transaction and,
+// for deletes, before the entity is removed as the row is copied from the entity table. Incrementing the resource
+// version locks its row until the transaction commits, so the changes are committed in the order of their version
+// and the watches polling the versions after the last one they sent can not skip a change committed later.
+func recordChange(ctx context.Context, tx *session.SessionTx, grn string, action entity.EntityWatchResponse_Action) error {
+	res, err := tx.Exec(ctx, "UPDATE entity_resource_version SET resource_version=resource_version+1 WHERE id=1")
+	if err != nil {
+		return err
+	}
+	if updated, err := res.RowsAffected(); err != nil {
+		return err
+	} else if updated == 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO entity_resource_version (id, resource_version) VALUES (1, 1)"); err != nil {
+			return err
+		}
+	}
+	var rv int64
+	if err := tx.Get(ctx, &rv, "SELECT resource_version FROM entity_resource_version WHERE id=1"); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO entity_change "+
+		"(resource_version, grn, tenant_id, kind, uid, folder, action, created_at) "+
+		"SELECT ?, grn, tenant_id, kind, uid, folder, ?, ? FROM entity WHERE grn=?",
+		rv, int64(action), time.Now().UnixMilli(), grn,
+	)
+	return err
+}
+
+// DeleteExpiredChanges removes the changes older than the retention of the change log, the watches can not resume from
+// before them. It returns the number of removed changes.
+func DeleteExpiredChanges(ctx context.Context, sess *session.SessionDB, now time.Time) (int64, error) {
+	res, err := sess.Exec(ctx, "DELETE FROM entity_change WHERE created_at < ?", now.Add(-watchChangeRetention).UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Watch streams the changes of the entities matching the request. The resource version of every response is the
+// version of the change in the entity_change table, so a client can resume the watch by passing the last version it
+// received as `since`. Without a version, the current entities are sent first. Bookmarks with the latest version are sent
+// periodically so idle watches can resume without replaying changes they did not care about.
+func (s *sqlEntityServer) Watch(r *entity.EntityWatchRequest, w entity.EntityStore_WatchServer) error {
+	ctx := w.Context()
+	user, err := appcontext.User(ctx)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("missing user in context")
+	}
+
+	watch := &entityWatch{
+		server:   s,
+		request:  r,
+		tenantID: user.OrgID,
+		stream:   w,
+	}
+	for _, grn := range r.GRN {
+		g := &entity.GRN{TenantId: grn.TenantId, Kind: grn.Kind, UID: grn.UID}
+		if g.TenantId == 0 {
+			g.TenantId = user.OrgID
+		}
+		watch.grns = append(watch.grns, g.ToGRNString())
+	}
+
+	rv := r.Since
+	if rv < 1 {
+		rv, err = watch.sendInitialEntities(ctx)
+		if err != nil {
+			return err
+		}
+	} else if err := watch.checkResourceVersion(ctx, rv); err != nil {
+		return err
+	}
+
+	poll := time.NewTicker(s.watchPollInterval)
+	defer poll.Stop()
+	bookmark := time.NewTicker(s.watchBookmarkInterval)
+	defer bookmark.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-poll.C:
+			rv, err = watch.sendChanges(ctx, rv)
+			if err != nil {
+				return err
+			}
+		case <-bookmark.C:
+			if err := watch.sendBookmark(rv); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type entityWatch struct {
+	server   *sqlEntityServer
+	request  *entity.EntityWatchRequest
+	tenantID int64
+	grns     []string
+	stream   entity.EntityStore_WatchServer
+}
+
+// checkResourceVersion fails when the changes following the version have been removed from the change log
+func (w *entityWatch) checkResourceVersion(ctx context.Context, rv int64) error {
+	rows, err := w.server.sess.Query(ctx, "SELECT MIN(resource_version) FROM entity_change")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var oldest *int64
+	if rows.Next() {
+		if err := rows.Scan(&oldest); err != nil {
+			return err
+		}
+	}
+	if oldest != nil && rv+1 < *oldest {
+		return fmt.Errorf("resource version %d is too old, the oldest available is %d", rv, *oldest-1)
+	}
+	return nil
+}
+
+// sendInitialEntities sends the entities matching the request and returns the resource version to watch from
+func (w *entityWatch) sendInitialEntities(ctx context.Context) (int64, error) {
+	// read the version before the entities, changes made while listing will be sent again by the first poll
+	rv, err := w.latestResourceVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	entityQuery := w.selectEntities()
+	entityQuery.addWhere("tenant_id", w.tenantID)
+	w.addFilters(entityQuery)
+	query, args := entityQuery.toQuery()
+
+	rows, err := w.server.sess.Query(ctx, query+" ORDER BY grn", args...)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	// the bodies are read once the rows are closed
+	var entities []*entity.Entity
+	var bodies []*storedBody
+	for rows.Next() {
+		e, body, err := w.server.rowToReadEntityResponse(ctx, rows, w.readRequest())
+		if err != nil {
+			return 0, err
+		}
+		entities = append(entities, e)
+		bodies = append(bodies, body)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+
+	rsp := &entity.EntityWatchResponse{
+		Action:          entity.EntityWatchResponse_UPDATED,
+		ResourceVersion: rv,
+	}
+	for i, e := range entities {
+		if bodies[i] != nil {
+			e.Body, err = w.server.readBody(ctx, e.GRN.ToGRNString(), e.Version, bodies[i])
+			if err != nil {
+				return 0, err
+			}
+		}
+		rsp.Entity = append(rsp.Entity, e)
+		if len(rsp.Entity) >= watchBatchSize {
+			if err := w.send(rsp); err != nil {
+				return 0, err
+			}
+			rsp.Entity = nil
+		}
+	}
+	if len(rsp.Entity) > 0 {
+		if err := w.send(rsp); err != nil {
+			return 0, err
+		}
+	}
+
+	return rv, w.sendBookmark(rv)
+}
+
+// latestResourceVersion returns the version of the last committed change, it is 0 before the first change
+func (w *entityWatch) latestResourceVersion(ctx context.Context) (int64, error) {
+	var rv int64
+	err := w.server.sess.Get(ctx, &rv, "SELECT resource_version FROM entity_resource_version WHERE id=1")
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return rv, err
+}
+
+type entityChange struct {
+	resourceVersion int64
+	grn             string
+	kind            string
+	uid             string
+	folder          string
+	action          entity.EntityWatchResponse_Action
+}
+
+// sendChanges sends a response for every change after the resource version and returns the last version sent
+func (w *entityWatch) sendChanges(ctx context.Context, rv int64) (int64, error) {
+	for {
+		changes, err := w.readChanges(ctx, rv)
+		if err != nil {
+			return rv, err
+		}
+
+		for _, change := range changes {
+			rsp := &entity.EntityWatchResponse{
+				Action:          change.action,
+				ResourceVersion: change.resourceVersion,
+			}
+
+			switch change.action {
+			case entity.EntityWatchResponse_UPDATED:
+				e, err := w.readEntity(ctx, change.grn)
+				if err != nil {
+					return rv, err
+				}
+				// deleted since, or no longer matching the labels
+				if e == nil {
+					rv = change.resourceVersion
+					continue
+				}
+				rsp.Entity = []*entity.Entity{e}
+			case entity.EntityWatchResponse_DELETED:
+				// labels are removed with the entity, so deletes are sent without checking them
+				rsp.Entity = []*entity.Entity{{
+					GRN:    &entity.GRN{TenantId: w.tenantID, Kind: change.kind, UID: change.uid},
+					Folder: change.folder,
+				}}
+			}
+
+			if err := w.send(rsp); err != nil {
+				return rv, err
+			}
+			rv = change.resourceVersion
+		}
+
+		if len(changes) < watchBatchSize {
+			return rv, nil
+		}
+	}
+}
+
+func (w *entityWatch) readChanges(ctx context.Context, rv int64) ([]entityChange, error) {
+	changeQuery := &selectQuery{
+		fields: []string{"resource_version", "grn", "kind", "uid", "folder", "action"},
+		from:   "entity_change",
+	}
+	changeQuery.addWhere("tenant_id", w.tenantID)
+	changeQuery.args = append(changeQuery.args, rv)
+	changeQuery.where = append(changeQuery.where, "resource_version>?")
+	if len(w.request.Kind) > 0 {
+		changeQuery.addWhereIn("kind", w.request.Kind)
+	}
+	if w.request.Folder != "" {
+		changeQuery.addWhere("folder", w.request.Folder)
+	}
+	if len(w.grns) > 0 {
+		changeQuery.addWhereIn("grn", w.grns)
+	}
+	query, args := changeQuery.toQuery()
+
+	rows, err := w.server.sess.Query(ctx, query+" ORDER BY resource_version LIMIT ?", append(args, watchBatchSize)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var changes []entityChange
+	for rows.Next() {
+		change := entityChange{}
+		var action int64
+		if err := rows.Scan(&change.resourceVersion, &change.grn, &change.kind, &change.uid, &change.folder, &action); err != nil {
+			return nil, err
+		}
+		change.action = entity.EntityWatchResponse_Action(action)
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// readEntity returns nil when the entity does not exist or does not match the request labels
+func (w *entityWatch) readEntity(ctx context.Context, grn string) (*entity.Entity, error) {
+	entityQuery := w.selectEntities()
+	entityQuery.addWhere("grn", grn)
+	w.addLabelFilter(entityQuery)
+	query, args := entityQuery.toQuery()
+
+	rows, err := w.server.sess.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	e, body, err := w.server.rowToReadEntityResponse(ctx, rows, w.readRequest())
+	if err != nil {
+		return nil, err
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if body != nil {
+		e.Body, err = w.server.readBody(ctx, grn, e.Version, body)
+	}
+	return e, err
+}
+
+func (w *entityWatch) readRequest() *entity.ReadEntityRequest {
+	return &entity.ReadEntityRequest{
+		WithBody:    w.request.WithBody,
+		WithSummary: w.request.WithLabels || w.request.WithFields,
+	}
+}
+
+func (w *entityWatch) selectEntities() *selectQuery {
+	return &selectQuery{
+		fields: getReadFields(w.readRequest()),
+		from:   "entity",
+	}
+}
+
+func (w *entityWatch) addFilters(q *selectQuery) {
+	if len(w.request.Kind) > 0 {
+		q.addWhereIn("kind", w.request.Kind)
+	}
+	if w.request.Folder != "" {
+		q.addWhere("folder", w.request.Folder)
+	}
+	if len(w.grns) > 0 {
+		q.addWhereIn("grn", w.grns)
+	}
+	w.addLabelFilter(q)
+}
+
+func (w *entityWatch) addLabelFilter(q *selectQuery) {
+	if len(w.request.Labels) == 0 {
+		return
+	}
+	var args []interface{}
+	var conditions []string
+	for labelKey, labelValue := range w.request.Labels {
+		args = append(args, labelKey, labelValue)
+		conditions = append(conditions, "(label = ? AND value = ?)")
+	}
+	query := "SELECT grn FROM entity_labels WHERE " + strings.Join(conditions, " OR ") + " GROUP BY grn HAVING COUNT(label) = ?"
+	args = append(args, len(w.request.Labels))
+	q.addWhereInSubquery("grn", query, args)
+}
+
+func (w *entityWatch) sendBookmark(rv int64) error {
+	return w.send(&entity.EntityWatchResponse{
+		Action:          entity.EntityWatchResponse_BOOKMARK,
+		ResourceVersion: rv,
+	})
+}
+
+func (w *entityWatch) send(rsp *entity.EntityWatchResponse) error {
+	rsp.Timestamp = time.Now().UnixMilli()
+	return w.stream.Send(rsp)
+}
@@ -0,0 +1,194 @@
+package sqlstash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/kind"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+type fakeWatchServer struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *entity.EntityWatchResponse
+}
+
+func (f *fakeWatchServer) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeWatchServer) Send(rsp *entity.EntityWatchResponse) error {
+	f.events <- rsp
+	return nil
+}
+
+func (f *fakeWatchServer) next(t *testing.T) *entity.EntityWatchResponse {
+	t.Helper()
+	select {
+	case rsp := <-f.events:
+		return rsp
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for watch event")
+		return nil
+	}
+}
+
+func newTestEntityServer(t *testing.T) (*sqlEntityServer, context.Context) {
+	t.Helper()
+
+	sqlStore := db.InitTestDB(t, db.InitTestDBOpt{FeatureFlags: []string{featuremgmt.FlagEntityStore}})
+	s := &sqlEntityServer{
+		sess:                  sqlStore.GetSqlxSession(),
+		log:                   log.New("sql-entity-server-test"),
+		kinds:                 kind.NewKindRegistry(),
+		watchPollInterval:     10 * time.Millisecond,
+		watchBookmarkInterval: time.Hour,
+
+		bodyCompressionThreshold: bodyCompressionThreshold,
+		bodyChunkSize:            bodyChunkSize,
+	}
+	ctx := appcontext.WithUser(context.Background(), &user.SignedInUser{UserID: 1, OrgID: 1, Login: "admin"})
+	return s, ctx
+}
+
+func TestIntegrationWatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	s, ctx := newTestEntityServer(t)
+
+	write := func(t *testing.T, uid string, body string) {
+		t.Helper()
+		_, err := s.Write(ctx, &entity.WriteEntityRequest{
+			GRN:  &entity.GRN{Kind: entity.StandardKindJSONObj, UID: uid},
+			Body: []byte(body),
+		})
+		require.NoError(t, err)
+	}
+
+	watch := func(t *testing.T, since int64) *fakeWatchServer {
+		t.Helper()
+		watchCtx, cancel := context.WithCancel(ctx)
+		srv := &fakeWatchServer{ctx: watchCtx, events: make(chan *entity.EntityWatchResponse, 10)}
+		done := make(chan error)
+		go func() { done <- s.Watch(&entity.EntityWatchRequest{Since: since, WithBody: true}, srv) }()
+		t.Cleanup(func() {
+			cancel()
+			require.NoError(t, <-done)
+		})
+		return srv
+	}
+
+	write(t, "a", `{"hello":"a"}`)
+
+	var bookmark int64
+	t.Run("should send the current entities followed by a bookmark", func(t *testing.T) {
+		srv := watch(t, 0)
+
+		rsp := srv.next(t)
+		require.Equal(t, entity.EntityWatchResponse_UPDATED, rsp.Action)
+		require.Len(t, rsp.Entity, 1)
+		require.Equal(t, "a", rsp.Entity[0].GRN.UID)
+		require.JSONEq(t, `{"hello":"a"}`, string(rsp.Entity[0].Body))
+
+		rsp = srv.next(t)
+		require.Equal(t, entity.EntityWatchResponse_BOOKMARK, rsp.Action)
+		require.Empty(t, rsp.Entity)
+		require.Positive(t, rsp.ResourceVersion)
+		bookmark = rsp.ResourceVersion
+	})
+
+	t.Run("should send the changes after a resource version", func(t *testing.T) {
+		write(t, "b", `{"hello":"b"}`)
+		_, err := s.Delete(ctx, &entity.DeleteEntityRequest{GRN: &entity.GRN{Kind: entity.StandardKindJSONObj, UID: "a"}})
+		require.NoError(t, err)
+
+		srv := watch(t, bookmark)
+
+		updated := srv.next(t)
+		require.Equal(t, entity.EntityWatchResponse_UPDATED, updated.Action)
+		require.Equal(t, "b", updated.Entity[0].GRN.UID)
+		require.Greater(t, updated.ResourceVersion, bookmark)
+
+		deleted := srv.next(t)
+		require.Equal(t, entity.EntityWatchResponse_DELETED, deleted.Action)
+		require.Equal(t, "a", deleted.Entity[0].GRN.UID)
+		require.Equal(t, entity.StandardKindJSONObj, deleted.Entity[0].GRN.Kind)
+		require.Greater(t, deleted.ResourceVersion, updated.ResourceVersion)
+
+		write(t, "b", `{"hello":"again"}`)
+		rsp := srv.next(t)
+		require.Equal(t, entity.EntityWatchResponse_UPDATED, rsp.Action)
+		require.JSONEq(t, `{"hello":"again"}`, string(rsp.Entity[0].Body))
+		require.Greater(t, rsp.ResourceVersion, deleted.ResourceVersion)
+	})
+
+	t.Run("should not send unchanged writes", func(t *testing.T) {
+		srv := watch(t, bookmark)
+		for i := 0; i < 3; i++ {
+			srv.next(t)
+		}
+
+		write(t, "b", `{"hello":"again"}`)
+		write(t, "c", `{"hello":"c"}`)
+		rsp := srv.next(t)
+		require.Equal(t, "c", rsp.Entity[0].GRN.UID)
+	})
+
+	t.Run("should fail when the resource version was removed from the change log", func(t *testing.T) {
+		_, err := s.sess.Exec(ctx, "DELETE FROM entity_change WHERE resource_version<=?", bookmark+1)
+		require.NoError(t, err)
+
+		err = s.Watch(&entity.EntityWatchRequest{Since: bookmark}, &fakeWatchServer{ctx: ctx})
+		require.ErrorContains(t, err, "too old")
+	})
+
+	t.Run("should delete the expired changes", func(t *testing.T) {
+		write(t, "d", `{"hello":"d"}`)
+
+		deleted, err := DeleteExpiredChanges(ctx, s.sess, time.Now())
+		require.NoError(t, err)
+		require.Zero(t, deleted)
+
+		deleted, err = DeleteExpiredChanges(ctx, s.sess, time.Now().Add(watchChangeRetention+time.Minute))
+		require.NoError(t, err)
+		require.Positive(t, deleted)
+	})
+}
+
+func TestIntegrationWatchResourceVersion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	s, ctx := newTestEntityServer(t)
+
+	// the change log ids are allocated when the rows are inserted, the resource versions follow the commits
+	for i, uid := range []string{"a", "b", "c"} {
+		_, err := s.Write(ctx, &entity.WriteEntityRequest{
+			GRN:  &entity.GRN{Kind: entity.StandardKindJSONObj, UID: uid},
+			Body: []byte(`{"hello":"` + uid + `"}`),
+		})
+		require.NoError(t, err)
+
+		var rv int64
+		require.NoError(t, s.sess.Get(ctx, &rv, "SELECT resource_version FROM entity_change WHERE uid=?", uid))
+		require.Equal(t, int64(i+1), rv)
+	}
+
+	watch := &entityWatch{server: s, request: &entity.EntityWatchRequest{}, tenantID: 1}
+	latest, err := watch.latestResourceVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), latest)
+}
@@ -0,0 +1,61 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+type validationPanel struct {
+	ID     *int64            `json:"id"`
+	Panels []validationPanel `json:"panels"`
+}
+
+// GetEntityValidator checks that dashboards have a title and that panel IDs, including the panels of collapsed rows,
+// are unique. Many existing dashboards have no title or duplicate panel IDs, so they are only reported as warnings.
+func GetEntityValidator() entity.EntityValidator {
+	return func(ctx context.Context, req *entity.EntityValidationRequest) ([]entity.EntityFieldError, error) {
+		var dash struct {
+			Title  string            `json:"title"`
+			Panels []validationPanel `json:"panels"`
+		}
+		if err := json.Unmarshal(req.Body, &dash); err != nil {
+			return nil, err
+		}
+
+		var errs []entity.EntityFieldError
+		if strings.TrimSpace(dash.Title) == "" {
+			errs = append(errs, entity.EntityFieldError{
+				Field:   "title",
+				Message: "dashboard title is required",
+				Warning: true,
+			})
+		}
+
+		ids := make(map[int64]string)
+		var checkPanels func(path string, panels []validationPanel)
+		checkPanels = func(path string, panels []validationPanel) {
+			for i, p := range panels {
+				field := fmt.Sprintf("%s[%d]", path, i)
+				if p.ID != nil {
+					if other, ok := ids[*p.ID]; ok {
+						errs = append(errs, entity.EntityFieldError{
+							Field:   field + ".id",
+							Message: fmt.Sprintf("panel id %d is already used by %s", *p.ID, other),
+							Warning: true,
+						})
+					} else {
+						ids[*p.ID] = field
+					}
+				}
+				checkPanels(field+".panels", p.Panels)
+			}
+		}
+		checkPanels("panels", dash.Panels)
+
+		return errs, nil
+	}
+}
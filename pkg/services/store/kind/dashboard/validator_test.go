@@ -0,0 +1,39 @@
+package dashboard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+func TestEntityValidator(t *testing.T) {
+	validate := GetEntityValidator()
+
+	t.Run("valid dashboard", func(t *testing.T) {
+		errs, err := validate(context.Background(), &entity.EntityValidationRequest{
+			Body: []byte(`{"title":"hello","panels":[{"id":1},{"id":2,"type":"row","panels":[{"id":3}]}]}`),
+		})
+		require.NoError(t, err)
+		require.Empty(t, errs)
+	})
+
+	t.Run("missing title and duplicate panel ids are warnings", func(t *testing.T) {
+		errs, err := validate(context.Background(), &entity.EntityValidationRequest{
+			Body: []byte(`{"title":" ","panels":[{"id":1},{"id":2,"type":"row","panels":[{"id":1}]},{"id":2}]}`),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []entity.EntityFieldError{
+			{Field: "title", Message: "dashboard title is required", Warning: true},
+			{Field: "panels[1].panels[0].id", Message: "panel id 1 is already used by panels[0]", Warning: true},
+			{Field: "panels[2].id", Message: "panel id 2 is already used by panels[1]", Warning: true},
+		}, errs)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		_, err := validate(context.Background(), &entity.EntityValidationRequest{Body: []byte(`[`)})
+		require.Error(t, err)
+	})
+}
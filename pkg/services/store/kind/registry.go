@@ -23,6 +23,8 @@ import (
 type KindRegistry interface {
 	Register(info entity.EntityKindInfo, builder entity.EntitySummaryBuilder) error
 	GetSummaryBuilder(kind string) entity.EntitySummaryBuilder
+	RegisterValidator(kind string, validator entity.EntityValidator) error
+	GetValidators(kind string) []entity.EntityValidator
 	GetInfo(kind string) (entity.EntityKindInfo, error)
 	GetFromExtension(suffix string) (entity.EntityKindInfo, error)
 	GetKinds() []entity.EntityKindInfo
@@ -35,8 +37,9 @@ func NewKindRegistry() KindRegistry {
 		builder: playlist.GetEntitySummaryBuilder(),
 	}
 	kinds[entity.StandardKindDashboard] = &kindValues{
-		info:    dashboard.GetEntityKindInfo(),
-		builder: dashboard.GetEntitySummaryBuilder(),
+		info:       dashboard.GetEntityKindInfo(),
+		builder:    dashboard.GetEntitySummaryBuilder(),
+		validators: []entity.EntityValidator{dashboard.GetEntityValidator()},
 	}
 	kinds[entity.StandardKindSnapshot] = &kindValues{
 		info:    snapshot.GetEntityKindInfo(),
@@ -91,8 +94,9 @@ func ProvideService(cfg *setting.Cfg, renderer rendering.Service) KindRegistry {
 }
 
 type kindValues struct {
-	info    entity.EntityKindInfo
-	builder entity.EntitySummaryBuilder
+	info       entity.EntityKindInfo
+	builder    entity.EntitySummaryBuilder
+	validators []entity.EntityValidator
 }
 
 type registry struct {
@@ -150,6 +154,35 @@ func (r *registry) GetSummaryBuilder(kind string) entity.EntitySummaryBuilder {
 	return nil
 }
 
+// RegisterValidator adds a validator to a registered kind, plugins can use it to check the entities of their kinds
+func (r *registry) RegisterValidator(kind string, validator entity.EntityValidator) error {
+	if validator == nil {
+		return fmt.Errorf("invalid validator")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	v, ok := r.kinds[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind: %s", kind)
+	}
+	v.validators = append(v.validators, validator)
+	return nil
+}
+
+// GetValidators returns a copy of the validators of a kind in registration order
+func (r *registry) GetValidators(kind string) []entity.EntityValidator {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	v, ok := r.kinds[kind]
+	if ok {
+		return append([]entity.EntityValidator(nil), v.validators...)
+	}
+	return nil
+}
+
 // GetInfo returns the registered info
 func (r *registry) GetInfo(kind string) (entity.EntityKindInfo, error) {
 	r.mutex.RLock()
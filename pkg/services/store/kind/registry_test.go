@@ -49,3 +49,13 @@ func TestKindRegistry(t *testing.T) {
 	require.Equal(t, "PNG", info.Name)
 	require.True(t, info.IsRaw)
 }
+
+func TestKindRegistryValidators(t *testing.T) {
+	registry := NewKindRegistry()
+	validators := registry.GetValidators(entity.StandardKindDashboard)
+	require.Len(t, validators, 1)
+
+	// the registered validators are not changed through the returned slice
+	validators[0] = nil
+	require.NotNil(t, registry.GetValidators(entity.StandardKindDashboard)[0])
+}
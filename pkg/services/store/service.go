@@ -261,9 +261,10 @@ func ProvideService(
 	}
 
 	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
-		TargetSrv:     QuotaTargetSrv,
-		DefaultLimits: defaultLimits,
-		Reporter:      s.Usage,
+		TargetSrv:         QuotaTargetSrv,
+		DefaultLimits:     defaultLimits,
+		Reporter:          s.Usage,
+		ReadDefaultLimits: readQuotaConfig,
 	}); err != nil {
 		return nil, err
 	}
@@ -283,7 +284,7 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 		return limits, err
 	}
 
-	limits.Set(globalQuotaTag, cfg.Quota.Global.File)
+	limits.Set(globalQuotaTag, cfg.QuotaSettings().Global.File)
 	return limits, nil
 }
 
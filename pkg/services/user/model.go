@@ -218,6 +218,8 @@ type SignedInUser struct {
 	LastSeenAt         time.Time
 	Teams              []int64
 	Analytics          AnalyticsSettings
+	// QueryOnlyDatasourceUIDs limits a service account token to querying these datasources
+	QueryOnlyDatasourceUIDs []string
 	// Permissions grouped by orgID and actions
 	Permissions map[int64]map[string][]string `json:"-"`
 }
@@ -56,9 +56,10 @@ func ProvideService(
 	}
 
 	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
-		TargetSrv:     quota.TargetSrv(user.QuotaTargetSrv),
-		DefaultLimits: defaultLimits,
-		Reporter:      s.Usage,
+		TargetSrv:         quota.TargetSrv(user.QuotaTargetSrv),
+		DefaultLimits:     defaultLimits,
+		Reporter:          s.Usage,
+		ReadDefaultLimits: readQuotaConfig,
 	}); err != nil {
 		return s, err
 	}
@@ -357,7 +358,7 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 		return limits, err
 	}
 
-	limits.Set(globalQuotaTag, cfg.Quota.Global.User)
+	limits.Set(globalQuotaTag, cfg.QuotaSettings().Global.User)
 	return limits, nil
 }
 
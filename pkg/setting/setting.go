@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -19,6 +20,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gobwas/glob"
@@ -167,6 +169,8 @@ type Cfg struct {
 	ReadTimeout      time.Duration
 	EnableGzip       bool
 	EnforceDomain    bool
+	// RequestClientCertificate asks the HTTPS clients for a certificate, the service account tokens can be bound to it
+	RequestClientCertificate bool
 
 	// Security settings
 	SecretKey             string
@@ -237,9 +241,16 @@ type Cfg struct {
 	PluginCatalogHiddenPlugins       []string
 	PluginAdminEnabled               bool
 	PluginAdminExternalManageEnabled bool
+	PluginCatalogMirrorType          string
+	PluginCatalogMirrorURL           string
+	PluginCatalogMirrorToken         string
+	PluginCatalogMirrorSkipTLSVerify bool
+	PluginCatalogVerifySignatures    bool
+	PluginCatalogPinnedVersions      map[string]string
 
-	PluginsCDNURLTemplate    string
-	PluginLogBackendRequests bool
+	PluginsCDNURLTemplate            string
+	PluginLogBackendRequests         bool
+	PluginValidateDataplaneContracts bool
 
 	// Panels
 	DisableSanitizeHtml bool
@@ -249,6 +260,8 @@ type Cfg struct {
 	MetricsEndpointBasicAuthUsername string
 	MetricsEndpointBasicAuthPassword string
 	MetricsEndpointDisableTotalStats bool
+	MetricsPerOrgEnabled             bool
+	MetricsPerOrgMaxOrgs             int
 	MetricsGrafanaEnvironmentInfo    map[string]string
 
 	// Dashboards
@@ -313,6 +326,9 @@ type Cfg struct {
 
 	// Dataproxy
 	SendUserHeader                 bool
+	SendBaggage                    bool
+	BaggageRedactedKeys            map[string]bool
+	DataProxyIdentityToken         IdentityTokenSettings
 	DataProxyLogging               bool
 	DataProxyTimeout               int
 	DataProxyDialTimeout           int
@@ -325,6 +341,11 @@ type Cfg struct {
 	ResponseLimit                  int64
 	DataProxyRowLimit              int64
 	DataProxyUserAgent             string
+	// DataProxyAccessLog logs the calls of the data source proxy, to find the callers to migrate to the backend of
+	// the data sources
+	DataProxyAccessLog bool
+	// DataProxyBackendOnlyTypes are the types of the data sources the data source proxy rejects the calls of
+	DataProxyBackendOnlyTypes map[string]bool
 
 	// DistributedCache
 	RemoteCacheOptions *RemoteCacheOptions
@@ -333,6 +354,9 @@ type Cfg struct {
 	EditorsCanAdmin bool
 
 	ApiKeyMaxSecondsToLive int64
+	// TokenBindingTrustedProxies are the proxies whose X-Forwarded-For header gives the client address checked against
+	// the networks of the bound service account tokens
+	TokenBindingTrustedProxies []*net.IPNet
 
 	// Check if a feature toggle is enabled
 	// @deprecated
@@ -368,6 +392,8 @@ type Cfg struct {
 
 	// Data sources
 	DataSourceLimit int
+	// DataSourceTLSFileDirs are the directories of the TLS files the data sources saved with the API can read
+	DataSourceTLSFileDirs []string
 
 	// Snapshots
 	SnapshotEnabled       bool
@@ -423,6 +449,7 @@ type Cfg struct {
 	LDAPAllowSignup       bool
 	LDAPActiveSyncEnabled bool
 	LDAPSyncCron          string
+	LDAPTeamSyncInterval  time.Duration
 
 	DefaultTheme    string
 	DefaultLanguage string
@@ -477,6 +504,30 @@ type Cfg struct {
 	// Query history
 	QueryHistoryEnabled bool
 
+	// MixedDatasourceTimeout is the maximum duration of the queries of each datasource of a request querying several
+	// datasources, 0 when there is no timeout
+	MixedDatasourceTimeout time.Duration
+	// QueryResultCacheTTL is how long the responses of the datasources are shared in the remote cache, 0 when they
+	// are not cached
+	QueryResultCacheTTL time.Duration
+	// QueryResultCacheMaxSize is the size in bytes of the largest response cached, 0 when there is no maximum
+	QueryResultCacheMaxSize int
+	// AsyncQueryTimeout is the maximum duration of the asynchronous queries
+	AsyncQueryTimeout time.Duration
+	// AsyncQueryResultTTL is how long the results of the asynchronous queries are kept after they finished
+	AsyncQueryResultTTL time.Duration
+	// AsyncQueryMaxConcurrent is the number of asynchronous queries each instance runs at the same time, 0 when there
+	// is no limit
+	AsyncQueryMaxConcurrent int
+	// AsyncQueryResultMaxSize is the size in bytes of the largest result of an asynchronous query saved, 0 when there
+	// is no maximum
+	AsyncQueryResultMaxSize int
+
+	// ShortLinkDefaultTTL is the time to live of the short links created without one, 0 when they don't expire
+	ShortLinkDefaultTTL time.Duration
+	// ShortLinkMaxTTL is the maximum time to live of the short links, 0 when there is no maximum
+	ShortLinkMaxTTL time.Duration
+
 	DashboardPreviews DashboardPreviewsSettings
 
 	Storage StorageSettings
@@ -485,6 +536,12 @@ type Cfg struct {
 
 	SecureSocksDSProxy SecureSocksDSProxySettings
 
+	RecordedQueries RecordedQueriesSettings
+
+	QueryExport QueryExportSettings
+
+	QueryAudit QueryAuditSettings
+
 	// SAML Auth
 	SAMLSkipOrgRoleSync bool
 
@@ -504,6 +561,19 @@ type Cfg struct {
 	GRPCServerTLSConfig *tls.Config
 
 	CustomResponseHeaders map[string]string
+
+	// the command line arguments of Load, the configuration files are read again with them by Reload
+	args CommandLineArgs
+	// the settings of the configuration files applied, Raw also has the defaults set by the reads of the settings
+	fileSettings map[settingKey]string
+	// the configuration with the changes applied by Reload, nil until a setting is reloaded. Raw is not changed by
+	// Reload, since it is read without a lock.
+	reloadedRaw *ini.File
+	// the settings applied by Reload, they are read with SmtpSettings, QuotaSettings and QueryResultCacheSettings
+	// *reloadedSettings
+	reloaded atomic.Value
+	// the functions called by Reload once the settings of a section are applied
+	reloadHandlers map[string][]func() error
 }
 
 // AddChangePasswordLink returns if login form is disabled or not since
@@ -853,6 +923,7 @@ func (cfg *Cfg) loadConfiguration(args CommandLineArgs) (*ini.File, error) {
 	if err != nil {
 		return nil, err
 	}
+	cfg.fileSettings = readFileSettings(parsedFile)
 
 	// update data path and logging config
 	dataPath := valueAsString(parsedFile.Section("paths"), "data", "")
@@ -939,6 +1010,7 @@ func (cfg *Cfg) validateStaticRootPath() error {
 }
 
 func (cfg *Cfg) Load(args CommandLineArgs) error {
+	cfg.args = args
 	cfg.setHomePath(args)
 
 	// Fix for missing IANA db on Windows
@@ -1026,6 +1098,8 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.MetricsEndpointBasicAuthUsername = valueAsString(iniFile.Section("metrics"), "basic_auth_username", "")
 	cfg.MetricsEndpointBasicAuthPassword = valueAsString(iniFile.Section("metrics"), "basic_auth_password", "")
 	cfg.MetricsEndpointDisableTotalStats = iniFile.Section("metrics").Key("disable_total_stats").MustBool(false)
+	cfg.MetricsPerOrgEnabled = iniFile.Section("metrics").Key("per_org_enabled").MustBool(false)
+	cfg.MetricsPerOrgMaxOrgs = iniFile.Section("metrics").Key("per_org_max_orgs").MustInt(100)
 
 	analytics := iniFile.Section("analytics")
 	cfg.CheckForGrafanaUpdates = analytics.Key("check_for_updates").MustBool(true)
@@ -1068,6 +1142,18 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	queryHistory := iniFile.Section("query_history")
 	cfg.QueryHistoryEnabled = queryHistory.Key("enabled").MustBool(true)
 
+	query := iniFile.Section("query")
+	cfg.MixedDatasourceTimeout = query.Key("mixed_datasource_timeout").MustDuration(0)
+	cfg.readQueryResultCacheSettings()
+	cfg.AsyncQueryTimeout = query.Key("async_timeout").MustDuration(time.Hour)
+	cfg.AsyncQueryResultTTL = query.Key("async_result_ttl").MustDuration(time.Hour)
+	cfg.AsyncQueryMaxConcurrent = query.Key("async_max_concurrent_queries").MustInt(10)
+	cfg.AsyncQueryResultMaxSize = query.Key("async_result_max_bytes").MustInt(10 * 1024 * 1024)
+
+	if err := cfg.readShortLinksSettings(iniFile); err != nil {
+		return err
+	}
+
 	panelsSection := iniFile.Section("panels")
 	cfg.DisableSanitizeHtml = panelsSection.Key("disable_sanitize_html").MustBool(false)
 
@@ -1110,6 +1196,9 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.DashboardPreviews = readDashboardPreviewsSettings(iniFile)
 	cfg.Storage = readStorageSettings(iniFile)
 	cfg.Search = readSearchSettings(iniFile)
+	cfg.RecordedQueries = readRecordedQueriesSettings(iniFile)
+	cfg.QueryExport = readQueryExportSettings(iniFile)
+	cfg.QueryAudit = readQueryAuditSettings(iniFile)
 
 	cfg.SecureSocksDSProxy, err = readSecureSocksDSProxySettings(iniFile)
 	if err != nil {
@@ -1201,6 +1290,7 @@ func (cfg *Cfg) readLDAPConfig() {
 	cfg.LDAPSkipOrgRoleSync = ldapSec.Key("skip_org_role_sync").MustBool(false)
 	cfg.LDAPActiveSyncEnabled = ldapSec.Key("active_sync_enabled").MustBool(false)
 	cfg.LDAPAllowSignup = ldapSec.Key("allow_sign_up").MustBool(true)
+	cfg.LDAPTeamSyncInterval = ldapSec.Key("team_sync_interval").MustDuration(time.Hour)
 }
 
 func (cfg *Cfg) handleAWSConfig() {
@@ -1430,6 +1520,15 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 
 	cfg.ApiKeyMaxSecondsToLive = auth.Key("api_key_max_seconds_to_live").MustInt64(-1)
 
+	cfg.TokenBindingTrustedProxies = nil
+	for _, cidr := range util.SplitString(auth.Key("token_binding_trusted_proxies").String()) {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid token_binding_trusted_proxies network %q: %w", cidr, err)
+		}
+		cfg.TokenBindingTrustedProxies = append(cfg.TokenBindingTrustedProxies, network)
+	}
+
 	cfg.TokenRotationIntervalMinutes = auth.Key("token_rotation_interval_minutes").MustInt(10)
 	if cfg.TokenRotationIntervalMinutes < 2 {
 		cfg.TokenRotationIntervalMinutes = 2
@@ -1746,6 +1845,7 @@ func (cfg *Cfg) readServerSettings(iniFile *ini.File) error {
 	cfg.AppSubURL = AppSubUrl
 	cfg.ServeFromSubPath = ServeFromSubPath
 	cfg.Protocol = HTTPScheme
+	cfg.RequestClientCertificate = server.Key("request_client_certificate").MustBool(false)
 
 	protocolStr := valueAsString(server, "protocol", "http")
 
@@ -1818,6 +1918,7 @@ func (cfg *Cfg) GetContentDeliveryURL(prefix string) string {
 func (cfg *Cfg) readDataSourcesSettings() {
 	datasources := cfg.Raw.Section("datasources")
 	cfg.DataSourceLimit = datasources.Key("datasource_limit").MustInt(5000)
+	cfg.DataSourceTLSFileDirs = util.SplitString(datasources.Key("tls_file_dirs").String())
 }
 
 func GetAllowedOriginGlobs(originPatterns []string) ([]glob.Glob, error) {
@@ -1863,3 +1964,20 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 	cfg.LiveAllowedOrigins = originPatterns
 	return nil
 }
+
+func (cfg *Cfg) readShortLinksSettings(iniFile *ini.File) error {
+	shortLinks := iniFile.Section("short_links")
+	var err error
+	cfg.ShortLinkDefaultTTL, err = gtime.ParseDuration(valueAsString(shortLinks, "default_ttl", "0"))
+	if err != nil {
+		return fmt.Errorf("invalid short_links default_ttl: %w", err)
+	}
+	cfg.ShortLinkMaxTTL, err = gtime.ParseDuration(valueAsString(shortLinks, "max_ttl", "0"))
+	if err != nil {
+		return fmt.Errorf("invalid short_links max_ttl: %w", err)
+	}
+	if cfg.ShortLinkMaxTTL > 0 && (cfg.ShortLinkDefaultTTL == 0 || cfg.ShortLinkDefaultTTL > cfg.ShortLinkMaxTTL) {
+		cfg.ShortLinkDefaultTTL = cfg.ShortLinkMaxTTL
+	}
+	return nil
+}
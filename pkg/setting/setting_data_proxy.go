@@ -2,15 +2,39 @@ package setting
 
 import (
 	"fmt"
+	"time"
 
 	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
 )
 
 const defaultDataProxyRowLimit = int64(1000000)
 
+// IdentityTokenSettings configure the JWTs describing the querying user sent to the datasources with forwardIdentityToken
+// enabled, so that the gateways in front of them can enforce per user policies
+type IdentityTokenSettings struct {
+	// SigningKeyFile is the PEM private key signing the tokens, no token is sent without it
+	SigningKeyFile string
+	KeyID          string
+	Expiration     time.Duration
+	HeaderName     string
+}
+
 func readDataProxySettings(iniFile *ini.File, cfg *Cfg) error {
 	dataproxy := iniFile.Section("dataproxy")
 	cfg.SendUserHeader = dataproxy.Key("send_user_header").MustBool(false)
+	cfg.SendBaggage = dataproxy.Key("send_baggage").MustBool(false)
+	cfg.BaggageRedactedKeys = map[string]bool{}
+	for _, key := range util.SplitString(dataproxy.Key("baggage_redacted_keys").MustString("grafana.user.login")) {
+		cfg.BaggageRedactedKeys[key] = true
+	}
+	cfg.DataProxyIdentityToken = IdentityTokenSettings{
+		SigningKeyFile: dataproxy.Key("identity_token_signing_key_file").String(),
+		KeyID:          dataproxy.Key("identity_token_key_id").String(),
+		Expiration:     dataproxy.Key("identity_token_expiration").MustDuration(time.Minute),
+		HeaderName:     dataproxy.Key("identity_token_header_name").MustString("X-Grafana-Id"),
+	}
 	cfg.DataProxyLogging = dataproxy.Key("logging").MustBool(false)
 	cfg.DataProxyTimeout = dataproxy.Key("timeout").MustInt(10)
 	cfg.DataProxyDialTimeout = dataproxy.Key("dialTimeout").MustInt(30)
@@ -23,6 +47,11 @@ func readDataProxySettings(iniFile *ini.File, cfg *Cfg) error {
 	cfg.ResponseLimit = dataproxy.Key("response_limit").MustInt64(0)
 	cfg.DataProxyRowLimit = dataproxy.Key("row_limit").MustInt64(defaultDataProxyRowLimit)
 	cfg.DataProxyUserAgent = dataproxy.Key("user_agent").String()
+	cfg.DataProxyAccessLog = dataproxy.Key("access_log").MustBool(true)
+	cfg.DataProxyBackendOnlyTypes = map[string]bool{}
+	for _, dsType := range util.SplitString(dataproxy.Key("backend_only_types").String()) {
+		cfg.DataProxyBackendOnlyTypes[dsType] = true
+	}
 
 	if cfg.DataProxyUserAgent == "" {
 		cfg.DataProxyUserAgent = fmt.Sprintf("Grafana/%s", BuildVersion)
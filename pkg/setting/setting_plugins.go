@@ -1,6 +1,7 @@
 package setting
 
 import (
+	"fmt"
 	"strings"
 
 	"gopkg.in/ini.v1"
@@ -48,9 +49,48 @@ func (cfg *Cfg) readPluginSettings(iniFile *ini.File) error {
 		cfg.PluginCatalogHiddenPlugins = append(cfg.PluginCatalogHiddenPlugins, plug)
 	}
 
+	// Plugin catalog mirror settings
+	cfg.PluginCatalogMirrorType = pluginsSection.Key("plugin_catalog_mirror_type").MustString("")
+	switch cfg.PluginCatalogMirrorType {
+	case "", "static", "oci":
+	default:
+		return fmt.Errorf("invalid plugin_catalog_mirror_type %q, must be static or oci", cfg.PluginCatalogMirrorType)
+	}
+	cfg.PluginCatalogMirrorURL = strings.TrimRight(pluginsSection.Key("plugin_catalog_mirror_url").MustString(""), "/")
+	if cfg.PluginCatalogMirrorType != "" && cfg.PluginCatalogMirrorURL == "" {
+		return fmt.Errorf("plugin_catalog_mirror_url is required when plugin_catalog_mirror_type is set")
+	}
+	cfg.PluginCatalogMirrorToken = pluginsSection.Key("plugin_catalog_mirror_token").MustString("")
+	cfg.PluginCatalogMirrorSkipTLSVerify = pluginsSection.Key("plugin_catalog_mirror_tls_skip_verify_insecure").MustBool(false)
+	cfg.PluginCatalogVerifySignatures = pluginsSection.Key("plugin_catalog_verify_signatures").MustBool(false)
+	pinnedVersions, err := parsePinnedPluginVersions(pluginsSection.Key("plugin_catalog_pinned_versions").MustString(""))
+	if err != nil {
+		return err
+	}
+	cfg.PluginCatalogPinnedVersions = pinnedVersions
+
 	// Plugins CDN settings
 	cfg.PluginsCDNURLTemplate = strings.TrimRight(pluginsSection.Key("cdn_base_url").MustString(""), "/")
 	cfg.PluginLogBackendRequests = pluginsSection.Key("log_backend_requests").MustBool(false)
+	cfg.PluginValidateDataplaneContracts = pluginsSection.Key("validate_dataplane_contracts").MustBool(false)
 
 	return nil
 }
+
+// parsePinnedPluginVersions parses a comma separated list of plugin IDs with their pinned version, such as
+// "grafana-clock-panel@2.1.3".
+func parsePinnedPluginVersions(value string) (map[string]string, error) {
+	pinned := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pluginID, version, found := strings.Cut(entry, "@")
+		if !found || pluginID == "" || version == "" {
+			return nil, fmt.Errorf("invalid plugin_catalog_pinned_versions entry %q, must be <plugin ID>@<version>", entry)
+		}
+		pinned[pluginID] = version
+	}
+	return pinned, nil
+}
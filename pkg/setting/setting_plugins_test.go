@@ -41,3 +41,14 @@ func TestPluginSettings(t *testing.T) {
 	require.Equal(t, ps["plugin2"]["key3"], "value3")
 	require.Equal(t, ps["plugin2"]["key4"], "value4")
 }
+
+func TestParsePinnedPluginVersions(t *testing.T) {
+	pinned, err := parsePinnedPluginVersions("grafana-clock-panel@2.1.3, grafana-piechart-panel@1.6.4,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"grafana-clock-panel": "2.1.3", "grafana-piechart-panel": "1.6.4"}, pinned)
+
+	for _, invalid := range []string{"grafana-clock-panel", "@2.1.3", "grafana-clock-panel@"} {
+		_, err = parsePinnedPluginVersions(invalid)
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,65 @@
+package setting
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	QueryAuditOutputFile = "file"
+	QueryAuditOutputLoki = "loki"
+)
+
+type QueryAuditSettings struct {
+	Enabled bool
+	// Outputs are the destinations of the audit events, file and/or loki
+	Outputs []string
+
+	// FilePath is the file the events are appended to, the query-audit.log file of the logs folder when it is empty
+	FilePath string
+
+	LokiRemoteURL         string
+	LokiTenantID          string
+	LokiBasicAuthUsername string
+	LokiBasicAuthPassword string
+	LokiTimeout           time.Duration
+
+	// RedactedFields are the names of the fields of the queries whose values are replaced, at any depth
+	RedactedFields []string
+	// RedactedPatterns are regular expressions, the matches in the string values of the queries are replaced
+	RedactedPatterns []string
+
+	FlushInterval     time.Duration
+	BatchSize         int
+	MaxBufferedEvents int
+}
+
+func readQueryAuditSettings(iniFile *ini.File) QueryAuditSettings {
+	sec := iniFile.Section("query_audit")
+	s := QueryAuditSettings{
+		Enabled:               sec.Key("enabled").MustBool(false),
+		Outputs:               util.SplitString(sec.Key("outputs").MustString(QueryAuditOutputFile)),
+		FilePath:              sec.Key("file_path").MustString(""),
+		LokiRemoteURL:         sec.Key("loki_remote_url").MustString(""),
+		LokiTenantID:          sec.Key("loki_tenant_id").MustString(""),
+		LokiBasicAuthUsername: sec.Key("loki_basic_auth_username").MustString(""),
+		LokiBasicAuthPassword: sec.Key("loki_basic_auth_password").MustString(""),
+		LokiTimeout:           sec.Key("loki_timeout").MustDuration(30 * time.Second),
+		RedactedFields:        util.SplitString(sec.Key("redacted_fields").MustString("")),
+		FlushInterval:         sec.Key("flush_interval").MustDuration(time.Second),
+		BatchSize:             sec.Key("batch_size").MustInt(100),
+		MaxBufferedEvents:     sec.Key("max_buffered_events").MustInt(10000),
+	}
+
+	// the patterns are separated by new lines, as a regular expression can contain commas and spaces
+	for _, pattern := range strings.Split(sec.Key("redacted_patterns").MustString(""), "\n") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			s.RedactedPatterns = append(s.RedactedPatterns, pattern)
+		}
+	}
+	return s
+}
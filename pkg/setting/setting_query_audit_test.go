@@ -0,0 +1,42 @@
+package setting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestReadQueryAuditSettings(t *testing.T) {
+	t.Run("should use the defaults", func(t *testing.T) {
+		s := readQueryAuditSettings(ini.Empty())
+		require.False(t, s.Enabled)
+		require.Equal(t, []string{QueryAuditOutputFile}, s.Outputs)
+		require.Equal(t, time.Second, s.FlushInterval)
+		require.Equal(t, 100, s.BatchSize)
+		require.Empty(t, s.RedactedPatterns)
+	})
+
+	t.Run("should read the outputs and the redaction rules", func(t *testing.T) {
+		f, err := ini.Load([]byte(`
+[query_audit]
+enabled = true
+outputs = file, loki
+loki_remote_url = http://localhost:3100
+redacted_fields = password, token
+redacted_patterns = """
+\b\d{3}-\d{2}-\d{4}\b
+(?i)apikey=[a-z0-9, ]+
+"""
+`))
+		require.NoError(t, err)
+
+		s := readQueryAuditSettings(f)
+		require.True(t, s.Enabled)
+		require.Equal(t, []string{QueryAuditOutputFile, QueryAuditOutputLoki}, s.Outputs)
+		require.Equal(t, "http://localhost:3100", s.LokiRemoteURL)
+		require.Equal(t, []string{"password", "token"}, s.RedactedFields)
+		require.Equal(t, []string{`\b\d{3}-\d{2}-\d{4}\b`, `(?i)apikey=[a-z0-9, ]+`}, s.RedactedPatterns)
+	})
+}
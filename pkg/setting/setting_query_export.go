@@ -0,0 +1,55 @@
+package setting
+
+import (
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+type QueryExportSettings struct {
+	Enabled bool
+
+	// Storage is the object storage the exports are uploaded to, s3 or gcs
+	Storage string
+	Timeout time.Duration
+
+	S3  QueryExportS3Settings
+	GCS QueryExportGCSSettings
+}
+
+type QueryExportS3Settings struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKey       string
+	SecretKey       string
+	PathStyleAccess bool
+}
+
+type QueryExportGCSSettings struct {
+	Bucket  string
+	KeyFile string
+}
+
+func readQueryExportSettings(iniFile *ini.File) QueryExportSettings {
+	sec := iniFile.Section("query_export")
+	s3 := iniFile.Section("query_export.s3")
+	gcs := iniFile.Section("query_export.gcs")
+	return QueryExportSettings{
+		Enabled: sec.Key("enabled").MustBool(true),
+		Storage: sec.Key("storage").MustString(""),
+		Timeout: sec.Key("timeout").MustDuration(5 * time.Minute),
+		S3: QueryExportS3Settings{
+			Endpoint:        s3.Key("endpoint").MustString(""),
+			Region:          s3.Key("region").MustString(""),
+			Bucket:          s3.Key("bucket").MustString(""),
+			AccessKey:       s3.Key("access_key").MustString(""),
+			SecretKey:       s3.Key("secret_key").MustString(""),
+			PathStyleAccess: s3.Key("path_style_access").MustBool(false),
+		},
+		GCS: QueryExportGCSSettings{
+			Bucket:  gcs.Key("bucket").MustString(""),
+			KeyFile: gcs.Key("key_file").MustString(""),
+		},
+	}
+}
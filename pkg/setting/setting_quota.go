@@ -6,6 +6,9 @@ type OrgQuota struct {
 	Dashboard  int64 `target:"dashboard"`
 	ApiKey     int64 `target:"api_key"`
 	AlertRule  int64 `target:"alert_rule"`
+
+	QueriesPerMinute  int64 `target:"queries_per_minute"`
+	ConcurrentQueries int64 `target:"concurrent_queries"`
 }
 
 type UserQuota struct {
@@ -48,6 +51,9 @@ func (cfg *Cfg) readQuotaSettings() {
 		Dashboard:  quota.Key("org_dashboard").MustInt64(10),
 		ApiKey:     quota.Key("org_api_key").MustInt64(10),
 		AlertRule:  alertOrgQuota,
+
+		QueriesPerMinute:  quota.Key("org_queries_per_minute").MustInt64(-1),
+		ConcurrentQueries: quota.Key("org_concurrent_queries").MustInt64(-1),
 	}
 
 	// per User limits
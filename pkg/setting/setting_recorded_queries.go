@@ -0,0 +1,72 @@
+package setting
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	RecordedQueriesTargetRemoteWrite = "remote_write"
+	RecordedQueriesTargetGraphite    = "graphite"
+	RecordedQueriesTargetInflux      = "influx"
+)
+
+type RecordedQueriesSettings struct {
+	Enabled bool
+	// Targets are the destinations the recordings are written to, remote_write, graphite and/or influx
+	Targets []string
+
+	RemoteWriteURL               string
+	RemoteWriteBasicAuthUsername string
+	RemoteWriteBasicAuthPassword string
+	RemoteWriteHeaders           map[string]string
+	RemoteWriteTimeout           time.Duration
+	RemoteWriteMaxRetries        int
+
+	// GraphiteAddress is the host:port of the plaintext protocol of Graphite
+	GraphiteAddress string
+	GraphitePrefix  string
+	GraphiteTimeout time.Duration
+
+	// InfluxURL is the write endpoint of InfluxDB, with the database or the organization and bucket in its query
+	InfluxURL               string
+	InfluxToken             string
+	InfluxBasicAuthUsername string
+	InfluxBasicAuthPassword string
+	InfluxTimeout           time.Duration
+}
+
+func readRecordedQueriesSettings(iniFile *ini.File) RecordedQueriesSettings {
+	sec := iniFile.Section("recorded_queries")
+	s := RecordedQueriesSettings{
+		Enabled:                      sec.Key("enabled").MustBool(true),
+		Targets:                      util.SplitString(sec.Key("targets").MustString(RecordedQueriesTargetRemoteWrite)),
+		RemoteWriteURL:               sec.Key("remote_write_url").MustString(""),
+		RemoteWriteBasicAuthUsername: sec.Key("remote_write_basic_auth_username").MustString(""),
+		RemoteWriteBasicAuthPassword: sec.Key("remote_write_basic_auth_password").MustString(""),
+		RemoteWriteHeaders:           map[string]string{},
+		RemoteWriteTimeout:           sec.Key("remote_write_timeout").MustDuration(30 * time.Second),
+		RemoteWriteMaxRetries:        sec.Key("remote_write_max_retries").MustInt(3),
+		GraphiteAddress:              sec.Key("graphite_address").MustString(""),
+		GraphitePrefix:               sec.Key("graphite_prefix").MustString(""),
+		GraphiteTimeout:              sec.Key("graphite_timeout").MustDuration(30 * time.Second),
+		InfluxURL:                    sec.Key("influx_url").MustString(""),
+		InfluxToken:                  sec.Key("influx_token").MustString(""),
+		InfluxBasicAuthUsername:      sec.Key("influx_basic_auth_username").MustString(""),
+		InfluxBasicAuthPassword:      sec.Key("influx_basic_auth_password").MustString(""),
+		InfluxTimeout:                sec.Key("influx_timeout").MustDuration(30 * time.Second),
+	}
+
+	for _, header := range strings.Split(sec.Key("remote_write_headers").MustString(""), ",") {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		s.RemoteWriteHeaders[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return s
+}
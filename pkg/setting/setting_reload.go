@@ -0,0 +1,314 @@
+package setting
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// reloadMu serializes the reloads of the settings and the registration of their handlers
+var reloadMu sync.Mutex
+
+// reloadableSection is a section whose settings are applied without a restart. They are read again in a copy of Cfg,
+// whose settings are then published to the services reading them.
+type reloadableSection struct {
+	// keys are the settings of the section applied without a restart, all of them when empty
+	keys []string
+	read func(cfg *Cfg) error
+}
+
+// reloadedSettings are the settings applied by Reload. They are replaced as a whole, so that the services read them
+// with SmtpSettings, QuotaSettings and QueryResultCacheSettings while they are reloaded.
+type reloadedSettings struct {
+	smtp                    SmtpSettings
+	quota                   QuotaSettings
+	queryResultCacheTTL     time.Duration
+	queryResultCacheMaxSize int
+}
+
+func (s reloadableSection) isReloadable(key string) bool {
+	if len(s.keys) == 0 {
+		return true
+	}
+	for _, k := range s.keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadableSections are the sections applied by Reload, the changes of the other settings require a restart. The
+// log.<mode> sections are applied with the log section.
+var reloadableSections = map[string]reloadableSection{
+	"log": {read: func(cfg *Cfg) error {
+		return cfg.initLogging(cfg.Raw)
+	}},
+	"smtp": {read: func(cfg *Cfg) error {
+		cfg.readSmtpSettings()
+		return nil
+	}},
+	// the email templates are parsed at startup
+	"emails": {keys: []string{"welcome_email_on_sign_up", "content_types"}, read: func(cfg *Cfg) error {
+		cfg.readSmtpSettings()
+		return nil
+	}},
+	"quota": {keys: quotaLimitKeys, read: func(cfg *Cfg) error {
+		cfg.readQuotaSettings()
+		return nil
+	}},
+	"query": {keys: []string{"result_cache_ttl", "result_cache_max_bytes"}, read: func(cfg *Cfg) error {
+		cfg.readQueryResultCacheSettings()
+		return nil
+	}},
+}
+
+// quotaLimitKeys are the default limits of the quotas, enabling or disabling the quotas requires a restart
+var quotaLimitKeys = []string{
+	"org_user", "org_dashboard", "org_data_source", "org_api_key", "org_alert_rule",
+	"org_queries_per_minute", "org_concurrent_queries",
+	"user_org",
+	"global_user", "global_org", "global_data_source", "global_dashboard", "global_api_key", "global_session",
+	"global_alert_rule", "global_file",
+}
+
+func reloadableSectionName(section string) string {
+	if strings.HasPrefix(section, "log.") {
+		return "log"
+	}
+	return section
+}
+
+// ReloadReport lists the settings changed in the configuration files since they were loaded, as section.key
+type ReloadReport struct {
+	// Applied are the settings applied without a restart
+	Applied []string `json:"applied"`
+	// RequiresRestart are the settings applied on the next start of Grafana, they are reported until then
+	RequiresRestart []string `json:"requiresRestart"`
+}
+
+// OnReload registers a function called by Reload once the changed settings of the section are applied, for the
+// services that keep a copy of the settings. The handlers read the applied settings with SmtpSettings,
+// QuotaSettings and QueryResultCacheSettings.
+func (cfg *Cfg) OnReload(section string, handler func() error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if cfg.reloadHandlers == nil {
+		cfg.reloadHandlers = map[string][]func() error{}
+	}
+	cfg.reloadHandlers[section] = append(cfg.reloadHandlers[section], handler)
+}
+
+// Reload reads the configuration files again, with the environment and command line overrides, and applies the
+// changed settings of the reloadable sections: the log levels and outputs, the SMTP settings, the default limits of
+// the quotas and the TTL of the query result cache. The other changed settings are reported as requiring a restart.
+//
+// The fields of cfg are not changed, the applied settings are read with SmtpSettings, QuotaSettings and
+// QueryResultCacheSettings. The changed settings are only recorded as loaded once they are read and all the handlers
+// succeeded, so that a failed reload applies them again on the next one.
+func (cfg *Cfg) Reload() (*ReloadReport, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	file, err := cfg.readConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	raw := cfg.reloadedRaw
+	if raw == nil {
+		raw = cfg.Raw
+	}
+	applied := copyConfiguration(raw)
+	next := readFileSettings(file)
+	report := &ReloadReport{Applied: []string{}, RequiresRestart: []string{}}
+	var reloaded []string
+	var changed []settingKey
+	for _, setting := range changedSettings(cfg.fileSettings, next) {
+		name := reloadableSectionName(setting.section)
+		section, ok := reloadableSections[name]
+		if !ok || !section.isReloadable(setting.key) {
+			report.RequiresRestart = append(report.RequiresRestart, setting.String())
+			continue
+		}
+
+		if value, ok := next[setting]; ok {
+			applied.Section(setting.section).Key(setting.key).SetValue(value)
+		} else {
+			applied.Section(setting.section).DeleteKey(setting.key)
+		}
+		changed = append(changed, setting)
+		report.Applied = append(report.Applied, setting.String())
+		if len(reloaded) == 0 || reloaded[len(reloaded)-1] != name {
+			reloaded = append(reloaded, name)
+		}
+	}
+
+	if len(reloaded) == 0 {
+		return report, nil
+	}
+
+	current := cfg.currentSettings()
+	scratch := &Cfg{
+		Raw:                     applied,
+		LogsPath:                cfg.LogsPath,
+		UnifiedAlerting:         cfg.UnifiedAlerting,
+		Smtp:                    current.smtp,
+		Quota:                   current.quota,
+		QueryResultCacheTTL:     current.queryResultCacheTTL,
+		QueryResultCacheMaxSize: current.queryResultCacheMaxSize,
+	}
+	for _, name := range reloaded {
+		if err := reloadableSections[name].read(scratch); err != nil {
+			return report, fmt.Errorf("failed to apply the %s settings: %w", name, err)
+		}
+	}
+	cfg.reloadedRaw = applied
+	cfg.reloaded.Store(&reloadedSettings{
+		smtp:                    scratch.Smtp,
+		quota:                   scratch.Quota,
+		queryResultCacheTTL:     scratch.QueryResultCacheTTL,
+		queryResultCacheMaxSize: scratch.QueryResultCacheMaxSize,
+	})
+
+	for _, name := range reloaded {
+		for _, handler := range cfg.reloadHandlers[name] {
+			if err := handler(); err != nil {
+				return report, fmt.Errorf("failed to apply the %s settings: %w", name, err)
+			}
+		}
+	}
+
+	for _, setting := range changed {
+		if value, ok := next[setting]; ok {
+			cfg.fileSettings[setting] = value
+		} else {
+			delete(cfg.fileSettings, setting)
+		}
+	}
+	return report, nil
+}
+
+// currentSettings returns the settings applied by the last Reload, or the settings read by Load
+func (cfg *Cfg) currentSettings() *reloadedSettings {
+	if reloaded, ok := cfg.reloaded.Load().(*reloadedSettings); ok {
+		return reloaded
+	}
+	return &reloadedSettings{
+		smtp:                    cfg.Smtp,
+		quota:                   cfg.Quota,
+		queryResultCacheTTL:     cfg.QueryResultCacheTTL,
+		queryResultCacheMaxSize: cfg.QueryResultCacheMaxSize,
+	}
+}
+
+// SmtpSettings returns the SMTP settings, with the changes applied by Reload
+func (cfg *Cfg) SmtpSettings() SmtpSettings {
+	return cfg.currentSettings().smtp
+}
+
+// QuotaSettings returns the quota settings, with the default limits applied by Reload
+func (cfg *Cfg) QuotaSettings() QuotaSettings {
+	return cfg.currentSettings().quota
+}
+
+// QueryResultCacheSettings returns the TTL and the maximum size of the query result cache, with the changes applied
+// by Reload
+func (cfg *Cfg) QueryResultCacheSettings() (time.Duration, int) {
+	current := cfg.currentSettings()
+	return current.queryResultCacheTTL, current.queryResultCacheMaxSize
+}
+
+// copyConfiguration returns a copy of the configuration, to which Reload applies the changed settings
+func copyConfiguration(file *ini.File) *ini.File {
+	c := ini.Empty()
+	c.BlockMode = false
+	for _, section := range file.Sections() {
+		for _, key := range section.Keys() {
+			c.Section(section.Name()).Key(key.Name()).SetValue(key.Value())
+		}
+	}
+	return c
+}
+
+// readConfiguration reads the configuration files like loadConfiguration, without changing the configuration files
+// and overrides logged at startup
+func (cfg *Cfg) readConfiguration() (*ini.File, error) {
+	files, commandLineProperties, envOverrides := configFiles, appliedCommandLineProperties, appliedEnvOverrides
+	defer func() {
+		configFiles, appliedCommandLineProperties, appliedEnvOverrides = files, commandLineProperties, envOverrides
+	}()
+
+	file, err := ini.Load(path.Join(cfg.HomePath, "conf/defaults.ini"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse defaults.ini: %w", err)
+	}
+	file.BlockMode = false
+
+	props := cfg.getCommandLineProperties(cfg.args.Args)
+	applyCommandLineDefaultProperties(props, file)
+	if err := cfg.loadSpecifiedConfigFile(cfg.args.Config, file); err != nil {
+		return nil, err
+	}
+	if err := applyEnvVariableOverrides(file); err != nil {
+		return nil, err
+	}
+	applyCommandLineProperties(props, file)
+	if err := expandConfig(file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+type settingKey struct {
+	section string
+	key     string
+}
+
+func (c settingKey) String() string {
+	return c.section + "." + c.key
+}
+
+func readFileSettings(file *ini.File) map[settingKey]string {
+	settings := map[settingKey]string{}
+	for _, section := range file.Sections() {
+		for _, key := range section.Keys() {
+			settings[settingKey{section: section.Name(), key: key.Name()}] = key.Value()
+		}
+	}
+	return settings
+}
+
+// changedSettings returns the settings added, removed or changed in the next settings, sorted
+func changedSettings(current, next map[settingKey]string) []settingKey {
+	var changes []settingKey
+	for setting, value := range current {
+		if nextValue, ok := next[setting]; !ok || nextValue != value {
+			changes = append(changes, setting)
+		}
+	}
+	for setting := range next {
+		if _, ok := current[setting]; !ok {
+			changes = append(changes, setting)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].section != changes[j].section {
+			return changes[i].section < changes[j].section
+		}
+		return changes[i].key < changes[j].key
+	})
+	return changes
+}
+
+func (cfg *Cfg) readQueryResultCacheSettings() {
+	query := cfg.Raw.Section("query")
+	cfg.QueryResultCacheTTL = query.Key("result_cache_ttl").MustDuration(0)
+	cfg.QueryResultCacheMaxSize = query.Key("result_cache_max_bytes").MustInt(10 * 1024 * 1024)
+}
@@ -0,0 +1,146 @@
+package setting
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "custom.ini")
+	writeConfig := func(content string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(content), 0600))
+	}
+	writeConfig(`
+[smtp]
+host = smtp.example.com:25
+
+[server]
+http_port = 3000
+`)
+
+	cfg := NewCfg()
+	require.NoError(t, cfg.Load(CommandLineArgs{HomePath: "../../", Config: configFile}))
+	require.Equal(t, "smtp.example.com:25", cfg.Smtp.Host)
+	loadedFiles := len(configFiles)
+
+	t.Run("nothing changed", func(t *testing.T) {
+		report, err := cfg.Reload()
+		require.NoError(t, err)
+		assert.Empty(t, report.Applied)
+		assert.Empty(t, report.RequiresRestart)
+	})
+
+	t.Run("the reloadable settings are applied", func(t *testing.T) {
+		var reloaded []string
+		cfg.OnReload("query", func() error {
+			reloaded = append(reloaded, "query")
+			return nil
+		})
+
+		writeConfig(`
+[smtp]
+host = smtp.example.org:587
+from_name = Grafana Alerts
+
+[emails]
+templates_pattern = emails/*.html
+
+[quota]
+enabled = true
+org_dashboard = 50
+
+[query]
+result_cache_ttl = 30s
+
+[log]
+level = debug
+
+[server]
+http_port = 3001
+`)
+		report, err := cfg.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"log.level",
+			"query.result_cache_ttl",
+			"quota.org_dashboard",
+			"smtp.from_name",
+			"smtp.host",
+		}, report.Applied)
+		assert.Equal(t, []string{"emails.templates_pattern", "quota.enabled", "server.http_port"}, report.RequiresRestart)
+
+		assert.Equal(t, "smtp.example.org:587", cfg.SmtpSettings().Host)
+		assert.Equal(t, "Grafana Alerts", cfg.SmtpSettings().FromName)
+		assert.Equal(t, int64(50), cfg.QuotaSettings().Org.Dashboard)
+		assert.False(t, cfg.QuotaSettings().Enabled)
+		ttl, _ := cfg.QueryResultCacheSettings()
+		assert.Equal(t, 30*time.Second, ttl)
+		assert.Equal(t, []string{"query"}, reloaded)
+		// the fields read without a lock are not changed
+		assert.Equal(t, "smtp.example.com:25", cfg.Smtp.Host)
+		assert.Equal(t, "smtp.example.com:25", cfg.Raw.Section("smtp").Key("host").Value())
+		// the settings requiring a restart are not changed
+		assert.Equal(t, "3000", cfg.HTTPPort)
+		// the configuration files logged at startup are kept
+		assert.Len(t, configFiles, loadedFiles)
+	})
+
+	t.Run("the removed settings are applied", func(t *testing.T) {
+		writeConfig(`
+[server]
+http_port = 3001
+`)
+		report, err := cfg.Reload()
+		require.NoError(t, err)
+		assert.Contains(t, report.Applied, "smtp.host")
+		assert.Equal(t, "localhost:25", cfg.SmtpSettings().Host)
+		// the removed limit is reset to its default
+		assert.Equal(t, int64(100), cfg.QuotaSettings().Org.Dashboard)
+		ttl, _ := cfg.QueryResultCacheSettings()
+		assert.Equal(t, time.Duration(0), ttl)
+	})
+
+	t.Run("the settings are applied again after a failed handler", func(t *testing.T) {
+		failing := true
+		cfg.OnReload("query", func() error {
+			if failing {
+				return errors.New("failed to apply")
+			}
+			return nil
+		})
+
+		writeConfig(`
+[query]
+result_cache_ttl = 1m
+
+[server]
+http_port = 3001
+`)
+		_, err := cfg.Reload()
+		require.ErrorContains(t, err, "failed to apply the query settings")
+
+		failing = false
+		report, err := cfg.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"query.result_cache_ttl"}, report.Applied)
+		ttl, _ := cfg.QueryResultCacheSettings()
+		assert.Equal(t, time.Minute, ttl)
+
+		report, err = cfg.Reload()
+		require.NoError(t, err)
+		assert.Empty(t, report.Applied)
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		writeConfig(`[server`)
+		_, err := cfg.Reload()
+		require.Error(t, err)
+		assert.Equal(t, "localhost:25", cfg.SmtpSettings().Host)
+	})
+}
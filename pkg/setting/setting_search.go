@@ -10,6 +10,9 @@ type SearchSettings struct {
 	FullReindexInterval       time.Duration
 	IndexUpdateInterval       time.Duration
 	DashboardLoadingBatchSize int
+	// SavedSearchEvaluationInterval is how often the saved searches are run to notify their owners of the new
+	// matching dashboards
+	SavedSearchEvaluationInterval time.Duration
 }
 
 func readSearchSettings(iniFile *ini.File) SearchSettings {
@@ -19,5 +22,6 @@ func readSearchSettings(iniFile *ini.File) SearchSettings {
 	s.DashboardLoadingBatchSize = searchSection.Key("dashboard_loading_batch_size").MustInt(200)
 	s.FullReindexInterval = searchSection.Key("full_reindex_interval").MustDuration(5 * time.Minute)
 	s.IndexUpdateInterval = searchSection.Key("index_update_interval").MustDuration(10 * time.Second)
+	s.SavedSearchEvaluationInterval = searchSection.Key("saved_search_evaluation_interval").MustDuration(5 * time.Minute)
 	return s
 }
@@ -48,11 +48,13 @@ const (
 	schedulereDefaultExecuteAlerts          = true
 	schedulerDefaultMaxAttempts             = 3
 	schedulerDefaultLegacyMinInterval       = 1
+	schedulerDefaultSkipUnchangedMaxAge     = 5 * time.Minute
 	screenshotsDefaultCapture               = false
 	screenshotsDefaultCaptureTimeout        = 10 * time.Second
 	screenshotsMaxCaptureTimeout            = 30 * time.Second
 	screenshotsDefaultMaxConcurrent         = 5
 	screenshotsDefaultUploadImageStorage    = false
+	screenshotsDefaultNativeFallback        = false
 	// SchedulerBaseInterval base interval of the scheduler. Controls how often the scheduler fetches database for new changes as well as schedules evaluation of a rule
 	// changing this value is discouraged because this could cause existing alert definition
 	// with intervals that are not exactly divided by this number not to be evaluated
@@ -86,6 +88,9 @@ type UnifiedAlertingSettings struct {
 	Screenshots                   UnifiedAlertingScreenshotSettings
 	ReservedLabels                UnifiedAlertingReservedLabelSettings
 	StateHistory                  UnifiedAlertingStateHistorySettings
+	// SkipUnchangedResultsMaxAge is how long the evaluations of a rule returning the same normal results as the last
+	// evaluation processed are not processed again, 0 when they are always processed.
+	SkipUnchangedResultsMaxAge time.Duration
 }
 
 type UnifiedAlertingScreenshotSettings struct {
@@ -93,6 +98,7 @@ type UnifiedAlertingScreenshotSettings struct {
 	CaptureTimeout             time.Duration
 	MaxConcurrentScreenshots   int64
 	UploadExternalImageStorage bool
+	NativeRendererFallback     bool
 }
 
 type UnifiedAlertingReservedLabelSettings struct {
@@ -297,6 +303,13 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		uaCfg.DefaultRuleEvaluationInterval = uaMinInterval
 	}
 
+	if ua.Key("skip_unchanged_results").MustBool(false) {
+		uaCfg.SkipUnchangedResultsMaxAge, err = gtime.ParseDuration(valueAsString(ua, "skip_unchanged_results_max_age", schedulerDefaultSkipUnchangedMaxAge.String()))
+		if err != nil {
+			return fmt.Errorf("value of setting 'skip_unchanged_results_max_age' is not a valid duration: %w", err)
+		}
+	}
+
 	screenshots := iniFile.Section("unified_alerting.screenshots")
 	uaCfgScreenshots := uaCfg.Screenshots
 
@@ -310,6 +323,7 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 
 	uaCfgScreenshots.MaxConcurrentScreenshots = screenshots.Key("max_concurrent_screenshots").MustInt64(screenshotsDefaultMaxConcurrent)
 	uaCfgScreenshots.UploadExternalImageStorage = screenshots.Key("upload_external_image_storage").MustBool(screenshotsDefaultUploadImageStorage)
+	uaCfgScreenshots.NativeRendererFallback = screenshots.Key("native_renderer_fallback").MustBool(screenshotsDefaultNativeFallback)
 	uaCfg.Screenshots = uaCfgScreenshots
 
 	reservedLabels := iniFile.Section("unified_alerting.reserved_labels")
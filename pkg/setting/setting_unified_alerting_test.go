@@ -266,3 +266,34 @@ func TestMinInterval(t *testing.T) {
 		})
 	}
 }
+
+func TestUnifiedAlertingSkipUnchangedResults(t *testing.T) {
+	read := func(t *testing.T, keys map[string]string) (*Cfg, error) {
+		t.Helper()
+		f := ini.Empty()
+		section, err := f.NewSection("unified_alerting")
+		require.NoError(t, err)
+		for k, v := range keys {
+			_, err = section.NewKey(k, v)
+			require.NoError(t, err)
+		}
+		cfg := NewCfg()
+		cfg.IsFeatureToggleEnabled = func(key string) bool { return false }
+		return cfg, cfg.ReadUnifiedAlertingSettings(f)
+	}
+
+	cfg, err := read(t, map[string]string{})
+	require.NoError(t, err)
+	require.Zero(t, cfg.UnifiedAlerting.SkipUnchangedResultsMaxAge)
+
+	cfg, err = read(t, map[string]string{"skip_unchanged_results": "true"})
+	require.NoError(t, err)
+	require.Equal(t, schedulerDefaultSkipUnchangedMaxAge, cfg.UnifiedAlerting.SkipUnchangedResultsMaxAge)
+
+	cfg, err = read(t, map[string]string{"skip_unchanged_results": "true", "skip_unchanged_results_max_age": "15m"})
+	require.NoError(t, err)
+	require.Equal(t, 15*time.Minute, cfg.UnifiedAlerting.SkipUnchangedResultsMaxAge)
+
+	_, err = read(t, map[string]string{"skip_unchanged_results": "true", "skip_unchanged_results_max_age": "invalid"})
+	require.Error(t, err)
+}
@@ -35,6 +35,7 @@ func ProvideService(cfg *setting.Cfg, httpClientProvider *httpclient.Provider, t
 		azureMonitor:       &metrics.AzureMonitorDatasource{Proxy: proxy},
 		azureLogAnalytics:  &loganalytics.AzureLogAnalyticsDatasource{Proxy: proxy},
 		azureResourceGraph: &resourcegraph.AzureResourceGraphDatasource{Proxy: proxy},
+		azureTraces:        &loganalytics.AzureLogAnalyticsDatasource{Proxy: proxy},
 	}
 
 	im := datasource.NewInstanceManager(NewInstanceSettings(cfg, httpClientProvider, executors))
@@ -155,6 +156,12 @@ func getAzureRoutes(cloud string, jsonData json.RawMessage) (map[string]types.Az
 			return nil, fmt.Errorf("unable to instantiate routes, customizedRoutes must be set")
 		}
 		azureRoutes := customizedCloudSettings.CustomizedRoutes
+		// The traces are queried with the Log Analytics API of the cloud
+		if _, ok := azureRoutes[azureTraces]; !ok {
+			if route, ok := azureRoutes[azureLogAnalytics]; ok {
+				azureRoutes[azureTraces] = route
+			}
+		}
 		return azureRoutes, nil
 	} else {
 		return routes[cloud], nil
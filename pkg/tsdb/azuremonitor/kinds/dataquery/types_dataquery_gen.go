@@ -27,12 +27,21 @@ const (
 const (
 	AzureLogsQueryResultFormatTable      AzureLogsQueryResultFormat = "table"
 	AzureLogsQueryResultFormatTimeSeries AzureLogsQueryResultFormat = "time_series"
+	AzureLogsQueryResultFormatTrace      AzureLogsQueryResultFormat = "trace"
 )
 
 // Defines values for AzureMonitorQueryAzureLogAnalyticsResultFormat.
 const (
 	AzureMonitorQueryAzureLogAnalyticsResultFormatTable      AzureMonitorQueryAzureLogAnalyticsResultFormat = "table"
 	AzureMonitorQueryAzureLogAnalyticsResultFormatTimeSeries AzureMonitorQueryAzureLogAnalyticsResultFormat = "time_series"
+	AzureMonitorQueryAzureLogAnalyticsResultFormatTrace      AzureMonitorQueryAzureLogAnalyticsResultFormat = "trace"
+)
+
+// Defines values for AzureMonitorQueryAzureTracesResultFormat.
+const (
+	AzureMonitorQueryAzureTracesResultFormatTable      AzureMonitorQueryAzureTracesResultFormat = "table"
+	AzureMonitorQueryAzureTracesResultFormatTimeSeries AzureMonitorQueryAzureTracesResultFormat = "time_series"
+	AzureMonitorQueryAzureTracesResultFormatTrace      AzureMonitorQueryAzureTracesResultFormat = "trace"
 )
 
 // Defines values for AzureQueryType.
@@ -46,10 +55,18 @@ const (
 	AzureQueryTypeAzureResourceGroups             AzureQueryType = "Azure Resource Groups"
 	AzureQueryTypeAzureResourceNames              AzureQueryType = "Azure Resource Names"
 	AzureQueryTypeAzureSubscriptions              AzureQueryType = "Azure Subscriptions"
+	AzureQueryTypeAzureTraces                     AzureQueryType = "Azure Traces"
 	AzureQueryTypeAzureWorkspaces                 AzureQueryType = "Azure Workspaces"
 	AzureQueryTypeGrafanaTemplateVariableFunction AzureQueryType = "Grafana Template Variable Function"
 )
 
+// Defines values for AzureTracesQueryResultFormat.
+const (
+	AzureTracesQueryResultFormatTable      AzureTracesQueryResultFormat = "table"
+	AzureTracesQueryResultFormatTimeSeries AzureTracesQueryResultFormat = "time_series"
+	AzureTracesQueryResultFormatTrace      AzureTracesQueryResultFormat = "trace"
+)
+
 // Defines values for GrafanaTemplateVariableQueryType.
 const (
 	GrafanaTemplateVariableQueryTypeAppInsightsGroupByQuery    GrafanaTemplateVariableQueryType = "AppInsightsGroupByQuery"
@@ -92,6 +109,7 @@ const (
 const (
 	ResultFormatTable      ResultFormat = "table"
 	ResultFormatTimeSeries ResultFormat = "time_series"
+	ResultFormatTrace      ResultFormat = "trace"
 )
 
 // Defines values for SubscriptionsQueryKind.
@@ -132,6 +150,9 @@ type AppInsightsMetricNameQueryKind string
 
 // Azure Monitor Logs sub-query properties
 type AzureLogsQuery struct {
+	// Query each resource separately and merge the results, instead of a single cross-resource query.
+	FanOutWorkspaces *bool `json:"fanOutWorkspaces,omitempty"`
+
 	// KQL query to be executed.
 	Query *string `json:"query,omitempty"`
 
@@ -352,6 +373,21 @@ type AzureMonitorQuery struct {
 		ResultFormat *string `json:"resultFormat,omitempty"`
 	} `json:"azureResourceGraph,omitempty"`
 
+	// Application Insights Traces sub-query properties.
+	AzureTraces *struct {
+		// Operation ID. Used to query the spans of a single trace.
+		OperationId *string `json:"operationId,omitempty"`
+
+		// Array of resource URIs to be queried.
+		Resources []string `json:"resources,omitempty"`
+
+		// Specifies the format results should be returned as.
+		ResultFormat *AzureMonitorQueryAzureTracesResultFormat `json:"resultFormat,omitempty"`
+
+		// Types of events to query. Defaults to the requests and the dependencies.
+		TraceTypes []string `json:"traceTypes,omitempty"`
+	} `json:"azureTraces,omitempty"`
+
 	// For mixed data sources the selected datasource is on the query level.
 	// For non mixed scenarios this is undefined.
 	// TODO find a better way to do this ^ that's friendly to schema
@@ -394,6 +430,9 @@ type AzureMonitorQuery struct {
 // Specifies the format results should be returned as.
 type AzureMonitorQueryAzureLogAnalyticsResultFormat string
 
+// Specifies the format results should be returned as.
+type AzureMonitorQueryAzureTracesResultFormat string
+
 // @deprecated Legacy template variable support.
 type AzureMonitorQueryGrafanaTemplateVariableFn struct {
 	Kind                 *interface{}           `json:"kind,omitempty"`
@@ -428,6 +467,24 @@ type AzureResourceGraphQuery struct {
 	ResultFormat *string `json:"resultFormat,omitempty"`
 }
 
+// Application Insights Traces sub-query properties
+type AzureTracesQuery struct {
+	// Operation ID. Used to query the spans of a single trace.
+	OperationId *string `json:"operationId,omitempty"`
+
+	// Array of resource URIs to be queried.
+	Resources []string `json:"resources,omitempty"`
+
+	// Specifies the format results should be returned as.
+	ResultFormat *AzureTracesQueryResultFormat `json:"resultFormat,omitempty"`
+
+	// Types of events to query. Defaults to the requests and the dependencies.
+	TraceTypes []string `json:"traceTypes,omitempty"`
+}
+
+// Specifies the format results should be returned as.
+type AzureTracesQueryResultFormat string
+
 // BaseGrafanaTemplateVariableQuery defines model for BaseGrafanaTemplateVariableQuery.
 type BaseGrafanaTemplateVariableQuery struct {
 	RawQuery             *string                `json:"rawQuery,omitempty"`
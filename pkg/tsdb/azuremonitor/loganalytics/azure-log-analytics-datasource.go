@@ -38,6 +38,8 @@ type AzureLogAnalyticsQuery struct {
 	TimeRange    backend.TimeRange
 	Query        string
 	Resources    []string
+	// FanOutWorkspaces queries each of the resources separately and merges the results
+	FanOutWorkspaces bool
 }
 
 func (e *AzureLogAnalyticsDatasource) ResourceRequest(rw http.ResponseWriter, req *http.Request, cli *http.Client) {
@@ -92,6 +94,15 @@ func (e *AzureLogAnalyticsDatasource) buildQueries(logger log.Logger, queries []
 	azureLogAnalyticsQueries := []*AzureLogAnalyticsQuery{}
 
 	for _, query := range queries {
+		if isTracesQuery(query) {
+			tracesQuery, err := buildTracesQuery(logger, query, dsInfo)
+			if err != nil {
+				return nil, err
+			}
+			azureLogAnalyticsQueries = append(azureLogAnalyticsQueries, tracesQuery)
+			continue
+		}
+
 		queryJSONModel := types.LogJSONQuery{}
 		err := json.Unmarshal(query.JSON, &queryJSONModel)
 		if err != nil {
@@ -120,13 +131,14 @@ func (e *AzureLogAnalyticsDatasource) buildQueries(logger log.Logger, queries []
 			resources = []string{azureLogAnalyticsTarget.Resource}
 		}
 		azureLogAnalyticsQueries = append(azureLogAnalyticsQueries, &AzureLogAnalyticsQuery{
-			RefID:        query.RefID,
-			ResultFormat: resultFormat,
-			URL:          apiURL,
-			JSON:         query.JSON,
-			TimeRange:    query.TimeRange,
-			Query:        rawQuery,
-			Resources:    resources,
+			RefID:            query.RefID,
+			ResultFormat:     resultFormat,
+			URL:              apiURL,
+			JSON:             query.JSON,
+			TimeRange:        query.TimeRange,
+			Query:            rawQuery,
+			Resources:        resources,
+			FanOutWorkspaces: azureLogAnalyticsTarget.FanOutWorkspaces && len(resources) > 1,
 		})
 	}
 
@@ -155,10 +167,14 @@ func (e *AzureLogAnalyticsDatasource) executeQuery(ctx context.Context, logger l
 		return dataResponseErrorWithExecuted(fmt.Errorf("credentials for Log Analytics are no longer supported. Go to the data source configuration to update Azure Monitor credentials"))
 	}
 
-	req, err := e.createRequest(ctx, logger, url, query)
-	if err != nil {
-		dataResponse.Error = err
-		return dataResponse
+	var req *http.Request
+	if !query.FanOutWorkspaces {
+		var err error
+		req, err = e.createRequest(ctx, logger, url, query)
+		if err != nil {
+			dataResponse.Error = err
+			return dataResponse
+		}
 	}
 
 	ctx, span := tracer.Start(ctx, "azure log analytics query")
@@ -170,36 +186,36 @@ func (e *AzureLogAnalyticsDatasource) executeQuery(ctx context.Context, logger l
 
 	defer span.End()
 
-	tracer.Inject(ctx, req.Header, span)
-
-	logger.Debug("AzureLogAnalytics", "Request ApiURL", req.URL.String())
-	res, err := client.Do(req)
-	if err != nil {
-		return dataResponseErrorWithExecuted(err)
-	}
-
-	defer func() {
-		err := res.Body.Close()
+	var t *types.AzureResponseTable
+	var notices []data.Notice
+	if query.FanOutWorkspaces {
+		var err error
+		t, notices, err = e.executeFanOutQuery(ctx, logger, query, client, url, tracer, span)
 		if err != nil {
-			logger.Warn("failed to close response body", "error", err)
+			return dataResponseErrorWithExecuted(err)
 		}
-	}()
+	} else {
+		tracer.Inject(ctx, req.Header, span)
 
-	logResponse, err := e.unmarshalResponse(logger, res)
-	if err != nil {
-		return dataResponseErrorWithExecuted(err)
-	}
+		logResponse, err := e.doRequest(logger, client, req)
+		if err != nil {
+			return dataResponseErrorWithExecuted(err)
+		}
 
-	t, err := logResponse.GetPrimaryResultTable()
-	if err != nil {
-		return dataResponseErrorWithExecuted(err)
+		t, err = logResponse.GetPrimaryResultTable()
+		if err != nil {
+			return dataResponseErrorWithExecuted(err)
+		}
+		if logResponse.Error != nil {
+			notices = append(notices, apiErrorToNotice(logResponse.Error))
+		}
 	}
 
 	frame, err := ResponseTableToFrame(t, query.RefID, query.Query)
 	if err != nil {
 		return dataResponseErrorWithExecuted(err)
 	}
-	frame = appendErrorNotice(frame, logResponse.Error)
+	frame = appendNotices(frame, notices)
 	if frame == nil {
 		return dataResponse
 	}
@@ -217,6 +233,14 @@ func (e *AzureLogAnalyticsDatasource) executeQuery(ctx context.Context, logger l
 		logger.Warn("failed to add custom metadata to azure log analytics response", err)
 	}
 
+	if query.ResultFormat == types.Trace && len(frame.Fields) > 0 {
+		traceFrame, err := transformToTraceFrame(frame)
+		if err != nil {
+			return dataResponseErrorWithExecuted(err)
+		}
+		frame = traceFrame
+	}
+
 	if query.ResultFormat == types.TimeSeries {
 		tsSchema := frame.TimeSeriesSchema()
 		if tsSchema.Type == data.TimeSeriesTypeLong {
@@ -237,13 +261,38 @@ func appendErrorNotice(frame *data.Frame, err *AzureLogAnalyticsAPIError) *data.
 	if err == nil {
 		return frame
 	}
+	return appendNotices(frame, []data.Notice{apiErrorToNotice(err)})
+}
+
+func appendNotices(frame *data.Frame, notices []data.Notice) *data.Frame {
+	if len(notices) == 0 {
+		return frame
+	}
 	if frame == nil {
 		frame = &data.Frame{}
 	}
-	frame.AppendNotices(apiErrorToNotice(err))
+	frame.AppendNotices(notices...)
 	return frame
 }
 
+// doRequest executes the query request and decodes the response
+func (e *AzureLogAnalyticsDatasource) doRequest(logger log.Logger, client *http.Client, req *http.Request) (AzureLogAnalyticsResponse, error) {
+	logger.Debug("AzureLogAnalytics", "Request ApiURL", req.URL.String())
+	res, err := client.Do(req)
+	if err != nil {
+		return AzureLogAnalyticsResponse{}, err
+	}
+
+	defer func() {
+		err := res.Body.Close()
+		if err != nil {
+			logger.Warn("failed to close response body", "error", err)
+		}
+	}()
+
+	return e.unmarshalResponse(logger, res)
+}
+
 func (e *AzureLogAnalyticsDatasource) createRequest(ctx context.Context, logger log.Logger, queryURL string, query *AzureLogAnalyticsQuery) (*http.Request, error) {
 	body := map[string]interface{}{
 		"query": query.Query,
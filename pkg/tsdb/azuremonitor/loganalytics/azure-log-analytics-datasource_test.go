@@ -209,6 +209,45 @@ func TestBuildingAzureLogAnalyticsQueries(t *testing.T) {
 			},
 			Err: require.NoError,
 		},
+		{
+			name: "Query with multiple resources queried separately",
+			queryModel: []backend.DataQuery{
+				{
+					JSON: []byte(fmt.Sprintf(`{
+						"queryType": "Azure Log Analytics",
+						"azureLogAnalytics": {
+							"resources":        ["/subscriptions/r1","/subscriptions/r2"],
+							"query":            "Perf",
+							"resultFormat":     "%s",
+							"fanOutWorkspaces": true
+						}
+					}`, types.TimeSeries)),
+					RefID:     "A",
+					TimeRange: timeRange,
+				},
+			},
+			azureLogAnalyticsQueries: []*AzureLogAnalyticsQuery{
+				{
+					RefID:        "A",
+					ResultFormat: types.TimeSeries,
+					URL:          "v1/subscriptions/r1/query",
+					JSON: []byte(fmt.Sprintf(`{
+						"queryType": "Azure Log Analytics",
+						"azureLogAnalytics": {
+							"resources":        ["/subscriptions/r1","/subscriptions/r2"],
+							"query":            "Perf",
+							"resultFormat":     "%s",
+							"fanOutWorkspaces": true
+						}
+					}`, types.TimeSeries)),
+					Query:            "Perf",
+					Resources:        []string{"/subscriptions/r1", "/subscriptions/r2"},
+					TimeRange:        timeRange,
+					FanOutWorkspaces: true,
+				},
+			},
+			Err: require.NoError,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,160 @@
+package loganalytics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/types"
+)
+
+const (
+	// maxFanOutConcurrency is the number of resources of a fan-out query queried at the same time
+	maxFanOutConcurrency = 10
+
+	// resourceIDColumn identifies the resource of the rows of a fan-out query, it's named like the standard column
+	// of the Log Analytics tables
+	resourceIDColumn = "_ResourceId"
+)
+
+type workspaceResult struct {
+	resource string
+	response AzureLogAnalyticsResponse
+	err      error
+}
+
+// executeFanOutQuery runs the query against each resource separately and merges the primary result tables, the
+// failures of some of the resources are returned as notices
+func (e *AzureLogAnalyticsDatasource) executeFanOutQuery(ctx context.Context, logger log.Logger, query *AzureLogAnalyticsQuery, client *http.Client,
+	url string, tracer tracing.Tracer, span tracing.Span) (*types.AzureResponseTable, []data.Notice, error) {
+	results := make([]workspaceResult, len(query.Resources))
+	sem := make(chan struct{}, maxFanOutConcurrency)
+
+	var wg sync.WaitGroup
+	for i, resource := range query.Resources {
+		wg.Add(1)
+		go func(i int, resource string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = workspaceResult{resource: resource}
+			workspaceQuery := *query
+			workspaceQuery.URL = getResourceApiURL(resource)
+			workspaceQuery.Resources = []string{resource}
+			req, err := e.createRequest(ctx, logger, url, &workspaceQuery)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			tracer.Inject(ctx, req.Header, span)
+			results[i].response, results[i].err = e.doRequest(logger, client, req)
+		}(i, resource)
+	}
+	wg.Wait()
+
+	return mergeWorkspaceResults(results)
+}
+
+// getResourceApiURL returns the API URL of a single resource, like getApiURL
+func getResourceApiURL(resource string) string {
+	var model types.LogJSONQuery
+	model.AzureLogAnalytics.Resource = resource
+	return getApiURL(model)
+}
+
+// mergeWorkspaceResults appends the rows of the primary result tables of the resources, with the columns of all the
+// tables and the resource of every row. It fails only when all the resources failed.
+func mergeWorkspaceResults(results []workspaceResult) (*types.AzureResponseTable, []data.Notice, error) {
+	merged := &types.AzureResponseTable{Name: "PrimaryResult"}
+	columnIndexes := map[string]int{}
+	var notices []data.Notice
+	var firstErr error
+	failed := 0
+
+	fail := func(resource string, err error) {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", path.Base(resource), err)
+		}
+		failed++
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Query of %s failed: %s", path.Base(resource), err),
+		})
+	}
+
+	for _, result := range results {
+		if result.err != nil {
+			fail(result.resource, result.err)
+			continue
+		}
+		t, err := result.response.GetPrimaryResultTable()
+		if err != nil {
+			fail(result.resource, err)
+			continue
+		}
+		if err := appendWorkspaceTable(merged, columnIndexes, t, result.resource); err != nil {
+			fail(result.resource, err)
+			continue
+		}
+		if result.response.Error != nil {
+			notice := apiErrorToNotice(result.response.Error)
+			notice.Text = path.Base(result.resource) + ": " + notice.Text
+			notices = append(notices, notice)
+		}
+	}
+
+	if failed == len(results) {
+		return nil, nil, fmt.Errorf("the query failed for all the resources, %w", firstErr)
+	}
+	return merged, notices, nil
+}
+
+func appendWorkspaceTable(merged *types.AzureResponseTable, columnIndexes map[string]int, t *types.AzureResponseTable, resource string) error {
+	for _, col := range t.Columns {
+		if i, ok := columnIndexes[col.Name]; ok && merged.Columns[i].Type != col.Type {
+			return fmt.Errorf("column %s is of type %s, and of type %s for other resources", col.Name, col.Type, merged.Columns[i].Type)
+		}
+	}
+
+	for _, col := range t.Columns {
+		if _, ok := columnIndexes[col.Name]; !ok {
+			addColumn(merged, columnIndexes, col.Name, col.Type)
+		}
+	}
+	// the rows are identified by the resource, unless the query already returns it
+	if _, ok := columnIndexes[resourceIDColumn]; !ok {
+		addColumn(merged, columnIndexes, resourceIDColumn, "string")
+	}
+
+	for _, row := range t.Rows {
+		mergedRow := make([]interface{}, len(merged.Columns))
+		for i, col := range t.Columns {
+			if i < len(row) {
+				mergedRow[columnIndexes[col.Name]] = row[i]
+			}
+		}
+		if i := columnIndexes[resourceIDColumn]; mergedRow[i] == nil {
+			mergedRow[i] = resource
+		}
+		merged.Rows = append(merged.Rows, mergedRow)
+	}
+	return nil
+}
+
+func addColumn(t *types.AzureResponseTable, columnIndexes map[string]int, name, colType string) {
+	columnIndexes[name] = len(t.Columns)
+	t.Columns = append(t.Columns, struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}{Name: name, Type: colType})
+	for i := range t.Rows {
+		t.Rows[i] = append(t.Rows[i], nil)
+	}
+}
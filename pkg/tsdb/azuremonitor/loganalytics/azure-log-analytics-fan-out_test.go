@@ -0,0 +1,165 @@
+package loganalytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/types"
+)
+
+const (
+	fanOutWorkspace1 = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.OperationalInsights/workspaces/ws1"
+	fanOutWorkspace2 = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.OperationalInsights/workspaces/ws2"
+	fanOutWorkspace3 = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.OperationalInsights/workspaces/ws3"
+)
+
+func TestFanOutQuery(t *testing.T) {
+	responses := map[string]string{
+		"/v1" + fanOutWorkspace1 + "/query": `{"tables": [{"name": "PrimaryResult",
+			"columns": [{"name": "Computer", "type": "string"}, {"name": "Count", "type": "long"}],
+			"rows": [["comp1", 1], ["comp2", 2]]}]}`,
+		"/v1" + fanOutWorkspace2 + "/query": `{"tables": [{"name": "PrimaryResult",
+			"columns": [{"name": "Computer", "type": "string"}, {"name": "Count", "type": "long"}, {"name": "Region", "type": "string"}],
+			"rows": [["comp3", 3, "westeurope"]]}]}`,
+	}
+
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		assert.NotContains(t, body, "resources")
+
+		res, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error": {"code": "InsufficientAccessError"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(res))
+	}))
+	t.Cleanup(srv.Close)
+
+	ds := AzureLogAnalyticsDatasource{}
+	dsInfo := types.DatasourceInfo{JSONData: map[string]interface{}{}}
+	tracer := tracing.InitializeTracerForTest()
+
+	t.Run("merges the results of the resources", func(t *testing.T) {
+		requests = nil
+		query := &AzureLogAnalyticsQuery{
+			RefID:            "A",
+			ResultFormat:     "table",
+			Query:            "Perf",
+			Resources:        []string{fanOutWorkspace1, fanOutWorkspace2},
+			FanOutWorkspaces: true,
+			JSON:             []byte(`{}`),
+			TimeRange:        backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+		}
+
+		res := ds.executeQuery(context.Background(), logger, query, dsInfo, srv.Client(), srv.URL, tracer)
+		require.NoError(t, res.Error)
+		require.Len(t, requests, 2)
+
+		frame := res.Frames[0]
+		require.Equal(t, 3, frame.Rows())
+		require.Len(t, frame.Fields, 4)
+		assert.Equal(t, []string{"Computer", "Count", resourceIDColumn, "Region"}, fieldNames(frame))
+		resourceField, _ := frame.FieldByName(resourceIDColumn)
+		assert.Equal(t, fanOutWorkspace1, *resourceField.At(0).(*string))
+		assert.Equal(t, fanOutWorkspace2, *resourceField.At(2).(*string))
+		regionField, _ := frame.FieldByName("Region")
+		assert.Nil(t, regionField.At(0))
+		assert.Equal(t, "westeurope", *regionField.At(2).(*string))
+		assert.Empty(t, frame.Meta.Notices)
+	})
+
+	t.Run("returns the failures of some of the resources as notices", func(t *testing.T) {
+		query := &AzureLogAnalyticsQuery{
+			RefID:            "A",
+			ResultFormat:     "table",
+			Query:            "Perf",
+			Resources:        []string{fanOutWorkspace1, fanOutWorkspace3},
+			FanOutWorkspaces: true,
+			JSON:             []byte(`{}`),
+		}
+
+		res := ds.executeQuery(context.Background(), logger, query, dsInfo, srv.Client(), srv.URL, tracer)
+		require.NoError(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		require.Len(t, frame.Meta.Notices, 1)
+		assert.Equal(t, data.NoticeSeverityWarning, frame.Meta.Notices[0].Severity)
+		assert.True(t, strings.HasPrefix(frame.Meta.Notices[0].Text, "Query of ws3 failed: request failed, status: 403 Forbidden"))
+	})
+
+	t.Run("fails when all the resources failed", func(t *testing.T) {
+		query := &AzureLogAnalyticsQuery{
+			RefID:            "A",
+			ResultFormat:     "table",
+			Query:            "Perf",
+			Resources:        []string{fanOutWorkspace3, fanOutWorkspace3 + "-other"},
+			FanOutWorkspaces: true,
+			JSON:             []byte(`{}`),
+		}
+
+		res := ds.executeQuery(context.Background(), logger, query, dsInfo, srv.Client(), srv.URL, tracer)
+		require.ErrorContains(t, res.Error, "the query failed for all the resources, ws3: request failed")
+		require.Equal(t, "Perf", res.Frames[0].Meta.ExecutedQueryString)
+	})
+}
+
+func TestMergeWorkspaceResults(t *testing.T) {
+	t.Run("keeps the resource column of the query", func(t *testing.T) {
+		var res AzureLogAnalyticsResponse
+		require.NoError(t, json.Unmarshal([]byte(`{"tables": [{"name": "PrimaryResult",
+			"columns": [{"name": "_ResourceId", "type": "string"}, {"name": "Count", "type": "long"}],
+			"rows": [["/subscriptions/sub/vm1", 1]]}]}`), &res))
+
+		merged, notices, err := mergeWorkspaceResults([]workspaceResult{{resource: fanOutWorkspace1, response: res}})
+		require.NoError(t, err)
+		assert.Empty(t, notices)
+		require.Len(t, merged.Columns, 2)
+		assert.Equal(t, [][]interface{}{{"/subscriptions/sub/vm1", float64(1)}}, merged.Rows)
+	})
+
+	t.Run("skips the resources returning columns of other types", func(t *testing.T) {
+		var res1, res2 AzureLogAnalyticsResponse
+		require.NoError(t, json.Unmarshal([]byte(`{"tables": [{"name": "PrimaryResult",
+			"columns": [{"name": "Count", "type": "long"}], "rows": [[1]]}]}`), &res1))
+		require.NoError(t, json.Unmarshal([]byte(`{"tables": [{"name": "PrimaryResult",
+			"columns": [{"name": "Count", "type": "string"}], "rows": [["1"]]}]}`), &res2))
+
+		merged, notices, err := mergeWorkspaceResults([]workspaceResult{
+			{resource: fanOutWorkspace1, response: res1},
+			{resource: fanOutWorkspace2, response: res2},
+		})
+		require.NoError(t, err)
+		require.Len(t, merged.Rows, 1)
+		require.Len(t, notices, 1)
+		assert.Equal(t, "Query of ws2 failed: column Count is of type string, and of type long for other resources", notices[0].Text)
+	})
+}
+
+func fieldNames(frame *data.Frame) []string {
+	names := make([]string, len(frame.Fields))
+	for i, f := range frame.Fields {
+		names[i] = f.Name
+	}
+	return names
+}
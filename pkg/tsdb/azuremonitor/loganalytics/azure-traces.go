@@ -0,0 +1,215 @@
+package loganalytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/kinds/dataquery"
+	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/macros"
+	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/types"
+)
+
+// traceTypes are the Application Insights tables which can be queried by the traces queries
+var traceTypes = map[string]bool{
+	"availabilityResults": true,
+	"customEvents":        true,
+	"dependencies":        true,
+	"exceptions":          true,
+	"pageViews":           true,
+	"requests":            true,
+	"traces":              true,
+}
+
+// defaultTraceTypes are the tables with the spans of the distributed traces, the requests received by the services
+// and the calls of the services to their dependencies
+var defaultTraceTypes = []string{"requests", "dependencies"}
+
+// tracesProjection returns the columns of the trace frames. The root requests have their own operation as parent,
+// they are returned without parent so that the trace view shows them as the roots of the traces.
+const tracesProjection = `| extend duration = coalesce(toreal(column_ifexists("duration", real(null))), toreal(0))
+| extend spanID = iff(isempty(column_ifexists("id", "")), itemId, column_ifexists("id", ""))
+| extend parentSpanID = iff(operation_ParentId == operation_Id, "", operation_ParentId)
+| extend operationName = iff(isempty(column_ifexists("name", "")), itemType, column_ifexists("name", ""))
+| extend serviceTags = bag_pack("cloud_RoleInstance", cloud_RoleInstance, "cloud_RoleName", cloud_RoleName)
+| extend tags = bag_merge(bag_pack("itemType", itemType, "resultCode", column_ifexists("resultCode", ""), "success", column_ifexists("success", "")), customDimensions)
+| project traceID = operation_Id, spanID, parentSpanID, operationName, serviceName = cloud_RoleName, serviceTags, startTime = timestamp, duration, tags
+| order by startTime asc`
+
+func isTracesQuery(query backend.DataQuery) bool {
+	return query.QueryType == string(dataquery.AzureQueryTypeAzureTraces)
+}
+
+// buildTracesQuery builds the Log Analytics query of the spans of the Application Insights resources, the query
+// runs against the first resource with the resource-centric API and the other resources are added to the request
+func buildTracesQuery(logger log.Logger, query backend.DataQuery, dsInfo types.DatasourceInfo) (*AzureLogAnalyticsQuery, error) {
+	queryJSONModel := types.TracesJSONQuery{}
+	err := json.Unmarshal(query.JSON, &queryJSONModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the Azure Traces query object from JSON: %w", err)
+	}
+
+	azureTracesTarget := queryJSONModel.AzureTraces
+	logger.Debug("AzureTraces", "target", azureTracesTarget)
+
+	if len(azureTracesTarget.Resources) == 0 {
+		return nil, fmt.Errorf("query %s is missing the Application Insights resource", query.RefID)
+	}
+
+	resultFormat := azureTracesTarget.ResultFormat
+	if resultFormat == "" {
+		resultFormat = types.Trace
+	}
+
+	tables := azureTracesTarget.TraceTypes
+	if len(tables) == 0 {
+		tables = defaultTraceTypes
+	}
+	for _, table := range tables {
+		if !traceTypes[table] {
+			return nil, fmt.Errorf("query %s has an unknown trace type %q", query.RefID, table)
+		}
+	}
+
+	rawQuery, err := macros.KqlInterpolate(logger, query, dsInfo, buildTracesKQL(tables, azureTracesTarget.OperationId), "timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AzureLogAnalyticsQuery{
+		RefID:        query.RefID,
+		ResultFormat: resultFormat,
+		URL:          getResourceApiURL(azureTracesTarget.Resources[0]),
+		JSON:         query.JSON,
+		TimeRange:    query.TimeRange,
+		Query:        rawQuery,
+		Resources:    azureTracesTarget.Resources,
+	}, nil
+}
+
+func buildTracesKQL(tables []string, operationID string) string {
+	var b strings.Builder
+	b.WriteString("union isfuzzy=true " + strings.Join(tables, ", ") + "\n")
+	b.WriteString("| where $__timeFilter(timestamp)\n")
+	if operationID != "" {
+		b.WriteString("| where operation_Id == " + kqlString(operationID) + "\n")
+	}
+	b.WriteString(tracesProjection)
+	return b.String()
+}
+
+// kqlString quotes the value as a KQL string literal
+func kqlString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// traceKeyValue is a tag of the spans of the trace frames
+type traceKeyValue struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// transformToTraceFrame converts the rows of a traces query to the trace frame of the trace view, with the start
+// times and the durations of the spans in milliseconds and their tags as lists of key value pairs
+func transformToTraceFrame(frame *data.Frame) (*data.Frame, error) {
+	fields := map[string]*data.Field{}
+	for _, field := range frame.Fields {
+		fields[field.Name] = field
+	}
+	for _, name := range []string{"traceID", "spanID", "startTime"} {
+		if fields[name] == nil {
+			return nil, fmt.Errorf("the traces are missing the %s column", name)
+		}
+	}
+
+	traceFrame := data.NewFrame(frame.Name,
+		data.NewField("traceID", nil, []string{}),
+		data.NewField("spanID", nil, []string{}),
+		data.NewField("parentSpanID", nil, []string{}),
+		data.NewField("operationName", nil, []string{}),
+		data.NewField("serviceName", nil, []string{}),
+		data.NewField("serviceTags", nil, []json.RawMessage{}),
+		data.NewField("startTime", nil, []float64{}),
+		data.NewField("duration", nil, []float64{}),
+		data.NewField("tags", nil, []json.RawMessage{}),
+	)
+	traceFrame.RefID = frame.RefID
+
+	for i := 0; i < frame.Rows(); i++ {
+		serviceTags, err := traceTags(fields["serviceTags"], i)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := traceTags(fields["tags"], i)
+		if err != nil {
+			return nil, err
+		}
+
+		var startTime float64
+		if t, ok := concreteAt(fields["startTime"], i).(time.Time); ok {
+			startTime = float64(t.UnixNano()) / float64(time.Millisecond)
+		}
+		duration, _ := concreteAt(fields["duration"], i).(float64)
+
+		traceFrame.AppendRow(
+			stringAt(fields["traceID"], i),
+			stringAt(fields["spanID"], i),
+			stringAt(fields["parentSpanID"], i),
+			stringAt(fields["operationName"], i),
+			stringAt(fields["serviceName"], i),
+			serviceTags,
+			startTime,
+			duration,
+			tags,
+		)
+	}
+
+	traceFrame.Meta = &data.FrameMeta{
+		PreferredVisualization: data.VisTypeTrace,
+	}
+	if frame.Meta != nil {
+		traceFrame.Meta.ExecutedQueryString = frame.Meta.ExecutedQueryString
+		traceFrame.Meta.Notices = frame.Meta.Notices
+	}
+	return traceFrame, nil
+}
+
+func concreteAt(field *data.Field, i int) interface{} {
+	if field == nil {
+		return nil
+	}
+	v, _ := field.ConcreteAt(i)
+	return v
+}
+
+func stringAt(field *data.Field, i int) string {
+	s, _ := concreteAt(field, i).(string)
+	return s
+}
+
+// traceTags converts the JSON object of a dynamic column to the tags of the span, the empty values are left out
+func traceTags(field *data.Field, i int) (json.RawMessage, error) {
+	tags := []traceKeyValue{}
+	if raw := stringAt(field, i); raw != "" {
+		values := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			return nil, fmt.Errorf("failed to decode the tags of the span: %w", err)
+		}
+		for key, value := range values {
+			if value == nil || value == "" {
+				continue
+			}
+			tags = append(tags, traceKeyValue{Key: key, Value: value})
+		}
+		sort.Slice(tags, func(i, j int) bool {
+			return tags[i].Key < tags[j].Key
+		})
+	}
+	return json.Marshal(tags)
+}
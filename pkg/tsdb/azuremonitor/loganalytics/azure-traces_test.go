@@ -0,0 +1,135 @@
+package loganalytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/types"
+)
+
+const (
+	appInsights1 = "/subscriptions/sub/resourceGroups/rg/providers/microsoft.insights/components/app1"
+	appInsights2 = "/subscriptions/sub/resourceGroups/rg/providers/microsoft.insights/components/app2"
+)
+
+func TestBuildingAzureTracesQueries(t *testing.T) {
+	datasource := &AzureLogAnalyticsDatasource{}
+	fromStart := time.Date(2018, 3, 15, 13, 0, 0, 0, time.UTC)
+	timeRange := backend.TimeRange{From: fromStart, To: fromStart.Add(34 * time.Minute)}
+
+	t.Run("queries the requests and the dependencies of the resources", func(t *testing.T) {
+		queries, err := datasource.buildQueries(logger, []backend.DataQuery{{
+			RefID:     "A",
+			QueryType: "Azure Traces",
+			JSON:      []byte(`{"azureTraces": {"resources": ["` + appInsights1 + `", "` + appInsights2 + `"]}}`),
+			TimeRange: timeRange,
+		}}, types.DatasourceInfo{})
+		require.NoError(t, err)
+		require.Len(t, queries, 1)
+
+		query := queries[0]
+		assert.Equal(t, types.Trace, query.ResultFormat)
+		assert.Equal(t, "v1"+appInsights1+"/query", query.URL)
+		assert.Equal(t, []string{appInsights1, appInsights2}, query.Resources)
+		assert.Contains(t, query.Query, "union isfuzzy=true requests, dependencies\n")
+		assert.Contains(t, query.Query, "| where ['timestamp'] >= datetime('2018-03-15T13:00:00Z') and ['timestamp'] <= datetime('2018-03-15T13:34:00Z')\n")
+		assert.NotContains(t, query.Query, "operation_Id ==")
+	})
+
+	t.Run("filters the spans of the operation", func(t *testing.T) {
+		queries, err := datasource.buildQueries(logger, []backend.DataQuery{{
+			RefID:     "A",
+			QueryType: "Azure Traces",
+			JSON:      []byte(`{"azureTraces": {"resources": ["` + appInsights1 + `"], "operationId": "op'1", "traceTypes": ["requests", "exceptions"], "resultFormat": "table"}}`),
+			TimeRange: timeRange,
+		}}, types.DatasourceInfo{})
+		require.NoError(t, err)
+
+		query := queries[0]
+		assert.Equal(t, types.Table, query.ResultFormat)
+		assert.Contains(t, query.Query, "union isfuzzy=true requests, exceptions\n")
+		assert.Contains(t, query.Query, `| where operation_Id == 'op\'1'`)
+	})
+
+	t.Run("rejects the unknown trace types", func(t *testing.T) {
+		_, err := datasource.buildQueries(logger, []backend.DataQuery{{
+			RefID:     "A",
+			QueryType: "Azure Traces",
+			JSON:      []byte(`{"azureTraces": {"resources": ["` + appInsights1 + `"], "traceTypes": ["requests | take 1"]}}`),
+		}}, types.DatasourceInfo{})
+		require.EqualError(t, err, `query A has an unknown trace type "requests | take 1"`)
+	})
+
+	t.Run("rejects the queries without resource", func(t *testing.T) {
+		_, err := datasource.buildQueries(logger, []backend.DataQuery{{
+			RefID:     "A",
+			QueryType: "Azure Traces",
+			JSON:      []byte(`{"azureTraces": {}}`),
+		}}, types.DatasourceInfo{})
+		require.EqualError(t, err, "query A is missing the Application Insights resource")
+	})
+}
+
+func TestAzureTracesQuery(t *testing.T) {
+	response := `{"tables": [{"name": "PrimaryResult",
+		"columns": [
+			{"name": "traceID", "type": "string"}, {"name": "spanID", "type": "string"}, {"name": "parentSpanID", "type": "string"},
+			{"name": "operationName", "type": "string"}, {"name": "serviceName", "type": "string"}, {"name": "serviceTags", "type": "dynamic"},
+			{"name": "startTime", "type": "datetime"}, {"name": "duration", "type": "real"}, {"name": "tags", "type": "dynamic"}
+		],
+		"rows": [
+			["op1", "req1", "", "GET /orders", "frontend", "{\"cloud_RoleInstance\":\"fe-0\",\"cloud_RoleName\":\"frontend\"}",
+				"2018-03-15T13:00:00.5Z", 120.5, "{\"itemType\":\"request\",\"resultCode\":\"200\",\"success\":\"True\"}"],
+			["op1", "dep1", "req1", "SELECT orders", "frontend", "{\"cloud_RoleInstance\":\"fe-0\",\"cloud_RoleName\":\"frontend\"}",
+				"2018-03-15T13:00:00.6Z", 80, "{\"itemType\":\"dependency\",\"resultCode\":\"\",\"host\":\"db\"}"]
+		]}]}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "/v1"+appInsights1+"/query", r.URL.Path)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	ds := AzureLogAnalyticsDatasource{}
+	dsInfo := types.DatasourceInfo{JSONData: map[string]interface{}{}}
+	tracer := tracing.InitializeTracerForTest()
+
+	query := &AzureLogAnalyticsQuery{
+		RefID:        "A",
+		ResultFormat: types.Trace,
+		URL:          "v1" + appInsights1 + "/query",
+		Query:        "union requests, dependencies",
+		Resources:    []string{appInsights1},
+		JSON:         []byte(`{}`),
+	}
+	res := ds.executeQuery(context.Background(), logger, query, dsInfo, srv.Client(), srv.URL, tracer)
+	require.NoError(t, res.Error)
+	require.Len(t, res.Frames, 1)
+
+	frame := res.Frames[0]
+	assert.Equal(t, data.VisTypeTrace, string(frame.Meta.PreferredVisualization))
+	assert.Equal(t, "union requests, dependencies", frame.Meta.ExecutedQueryString)
+	assert.Equal(t, []string{"traceID", "spanID", "parentSpanID", "operationName", "serviceName", "serviceTags", "startTime", "duration", "tags"}, fieldNames(frame))
+	require.Equal(t, 2, frame.Rows())
+
+	assert.Equal(t, "dep1", frame.Fields[1].At(1))
+	assert.Equal(t, "req1", frame.Fields[2].At(1))
+	assert.Equal(t, "", frame.Fields[2].At(0))
+	assert.Equal(t, float64(time.Date(2018, 3, 15, 13, 0, 0, 600000000, time.UTC).UnixMilli()), frame.Fields[6].At(1))
+	assert.Equal(t, 120.5, frame.Fields[7].At(0))
+	assert.Equal(t, 80.0, frame.Fields[7].At(1))
+	assert.JSONEq(t, `[{"key": "cloud_RoleInstance", "value": "fe-0"}, {"key": "cloud_RoleName", "value": "frontend"}]`, string(frame.Fields[5].At(0).(json.RawMessage)))
+	assert.JSONEq(t, `[{"key": "host", "value": "db"}, {"key": "itemType", "value": "dependency"}]`, string(frame.Fields[8].At(1).(json.RawMessage)))
+}
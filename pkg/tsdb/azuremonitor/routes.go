@@ -11,6 +11,7 @@ const (
 	azureMonitor       = "Azure Monitor"
 	azureLogAnalytics  = "Azure Log Analytics"
 	azureResourceGraph = "Azure Resource Graph"
+	azureTraces        = "Azure Traces"
 )
 
 var azManagement = types.AzRoute{
@@ -57,16 +58,19 @@ var (
 			azureMonitor:       azManagement,
 			azureLogAnalytics:  azLogAnalytics,
 			azureResourceGraph: azManagement,
+			azureTraces:        azLogAnalytics,
 		},
 		azsettings.AzureUSGovernment: {
 			azureMonitor:       azUSGovManagement,
 			azureLogAnalytics:  azUSGovLogAnalytics,
 			azureResourceGraph: azUSGovManagement,
+			azureTraces:        azUSGovLogAnalytics,
 		},
 		azsettings.AzureChina: {
 			azureMonitor:       azChinaManagement,
 			azureLogAnalytics:  azChinaLogAnalytics,
 			azureResourceGraph: azChinaManagement,
+			azureTraces:        azChinaLogAnalytics,
 		},
 	}
 )
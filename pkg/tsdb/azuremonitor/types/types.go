@@ -15,6 +15,8 @@ import (
 
 const (
 	TimeSeries = "time_series"
+	Table      = "table"
+	Trace      = "trace"
 )
 
 var (
@@ -183,6 +185,8 @@ type LogJSONQuery struct {
 		Query        string   `json:"query"`
 		ResultFormat string   `json:"resultFormat"`
 		Resources    []string `json:"resources"`
+		// FanOutWorkspaces queries each resource separately instead of running a cross-resource query
+		FanOutWorkspaces bool `json:"fanOutWorkspaces"`
 
 		// Deprecated: Queries should be migrated to use Resource instead
 		Workspace string `json:"workspace"`
@@ -191,6 +195,19 @@ type LogJSONQuery struct {
 	} `json:"azureLogAnalytics"`
 }
 
+// TracesJSONQuery is the frontend JSON query model for an Azure Traces query.
+type TracesJSONQuery struct {
+	AzureTraces struct {
+		// Resources are the Application Insights resources to query
+		Resources []string `json:"resources"`
+		// OperationId filters the spans of a single trace
+		OperationId string `json:"operationId"`
+		// TraceTypes are the Application Insights tables to query, defaults to the requests and the dependencies
+		TraceTypes   []string `json:"traceTypes"`
+		ResultFormat string   `json:"resultFormat"`
+	} `json:"azureTraces"`
+}
+
 // MetricChartDefinition is the JSON model for a metrics chart definition
 type MetricChartDefinition struct {
 	ResourceMetadata    map[string]string   `json:"resourceMetadata"`
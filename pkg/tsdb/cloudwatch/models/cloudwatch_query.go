@@ -92,6 +92,14 @@ func (q *CloudWatchQuery) IsMathExpression() bool {
 	return q.MetricQueryType == MetricQueryTypeSearch && q.MetricEditorMode == MetricEditorModeRaw && !q.IsUserDefinedSearchExpression()
 }
 
+var anomalyDetectionBandExpression = regexp.MustCompile(`(?i)\bANOMALY_DETECTION_BAND\s*\(`)
+
+// IsAnomalyDetectionBandExpression tells whether the query is a math expression returning the upper and the lower
+// bounds of an anomaly detection band
+func (q *CloudWatchQuery) IsAnomalyDetectionBandExpression() bool {
+	return q.IsMathExpression() && anomalyDetectionBandExpression.MatchString(q.Expression)
+}
+
 func (q *CloudWatchQuery) isSearchExpression() bool {
 	return q.MetricQueryType == MetricQueryTypeSearch && (q.IsUserDefinedSearchExpression() || q.IsInferredSearchExpression())
 }
@@ -225,6 +233,7 @@ type metricsDataQuery struct {
 	Period            string                 `json:"period"`
 	Region            string                 `json:"region"`
 	SqlExpression     string                 `json:"sqlExpression"`
+	Sql               *SQLExpression         `json:"sql"`
 	Statistic         *string                `json:"statistic"`
 	Statistics        []*string              `json:"statistics"`
 	TimezoneUTCOffset string                 `json:"timezoneUTCOffset"`
@@ -359,6 +368,16 @@ func (q *CloudWatchQuery) validateAndSetDefaults(refId string, metricsDataQuery
 		q.Region = defaultRegionValue
 	}
 
+	// the queries built with the query builder outside of the query editor, like the provisioned ones, may only have
+	// the structured model of the query
+	if q.MetricQueryType == MetricQueryTypeQuery && q.MetricEditorMode == MetricEditorModeBuilder &&
+		q.SqlExpression == "" && metricsDataQuery.Sql != nil {
+		q.SqlExpression, err = metricsDataQuery.Sql.ToSQL()
+		if err != nil {
+			return fmt.Errorf("invalid Metrics Insights query: %w", err)
+		}
+	}
+
 	return nil
 }
 
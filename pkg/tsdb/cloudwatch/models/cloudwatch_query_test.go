@@ -173,6 +173,24 @@ func TestCloudWatchQuery(t *testing.T) {
 		assert.False(t, query.IsMathExpression(), "Expected not math expression")
 	})
 
+	t.Run("ANOMALY_DETECTION_BAND(m1, 2) was specified in the query editor", func(t *testing.T) {
+		query := &CloudWatchQuery{
+			RefId:            "A",
+			Region:           "us-east-1",
+			Expression:       "ANOMALY_DETECTION_BAND(m1, 2)",
+			Period:           300,
+			Id:               "ad1",
+			MetricQueryType:  MetricQueryTypeSearch,
+			MetricEditorMode: MetricEditorModeRaw,
+		}
+
+		assert.True(t, query.IsMathExpression(), "Expected a math expression")
+		assert.True(t, query.IsAnomalyDetectionBandExpression(), "Expected an anomaly detection band expression")
+
+		query.Expression = "m1 * 2"
+		assert.False(t, query.IsAnomalyDetectionBandExpression(), "Expected not an anomaly detection band expression")
+	})
+
 	t.Run("No expression, no multi dimension key values and no * was used", func(t *testing.T) {
 		query := &CloudWatchQuery{
 			RefId:      "A",
@@ -1114,6 +1132,52 @@ func Test_ParseMetricDataQueries_statistics_and_query_type_validation_and_MatchE
 		assert.Nil(t, actual)
 	})
 
+	t.Run("generates the Metrics Insights query of the builder model when there is no sql expression", func(t *testing.T) {
+		actual, err := ParseMetricDataQueries(
+			[]backend.DataQuery{
+				{
+					RefID: "A",
+					JSON: []byte(`{"statistic":"Average", "metricQueryType":1, "metricEditorMode":0, "sql": {
+						"select": {"type": "function", "name": "AVG", "parameters": [{"type": "functionParameter", "name": "CPUUtilization"}]},
+						"from": {"type": "property", "property": {"type": "string", "name": "AWS/EC2"}},
+						"groupBy": {"type": "and", "expressions": [{"type": "groupBy", "property": {"type": "string", "name": "InstanceId"}}]}
+					}}`),
+				},
+			}, time.Now(), time.Now(), "us-east-2", logger, false, false)
+		require.NoError(t, err)
+
+		require.Len(t, actual, 1)
+		assert.Equal(t, `SELECT AVG(CPUUtilization) FROM "AWS/EC2" GROUP BY InstanceId`, actual[0].SqlExpression)
+	})
+
+	t.Run("keeps the sql expression of the builder queries", func(t *testing.T) {
+		actual, err := ParseMetricDataQueries(
+			[]backend.DataQuery{
+				{
+					RefID: "A",
+					JSON: []byte(`{"statistic":"Average", "metricQueryType":1, "metricEditorMode":0, "sqlExpression":"SELECT MAX(CPUUtilization) FROM SCHEMA(\"AWS/EC2\")", "sql": {
+						"select": {"type": "function", "name": "AVG", "parameters": [{"type": "functionParameter", "name": "CPUUtilization"}]},
+						"from": {"type": "property", "property": {"type": "string", "name": "AWS/EC2"}}
+					}}`),
+				},
+			}, time.Now(), time.Now(), "us-east-2", logger, false, false)
+		require.NoError(t, err)
+
+		require.Len(t, actual, 1)
+		assert.Equal(t, `SELECT MAX(CPUUtilization) FROM SCHEMA("AWS/EC2")`, actual[0].SqlExpression)
+	})
+
+	t.Run("returns an error for an invalid builder model", func(t *testing.T) {
+		_, err := ParseMetricDataQueries(
+			[]backend.DataQuery{
+				{
+					RefID: "A",
+					JSON:  []byte(`{"statistic":"Average", "metricQueryType":1, "metricEditorMode":0, "sql": {"from": {"type": "property", "property": {"type": "string", "name": "AWS/EC2"}}}}`),
+				},
+			}, time.Now(), time.Now(), "us-east-2", logger, false, false)
+		require.EqualError(t, err, `error parsing query "A", invalid Metrics Insights query: select must have a statistic and a metric name`)
+	})
+
 	t.Run("ignores query types which are not timeSeriesQuery", func(t *testing.T) {
 		actual, err := ParseMetricDataQueries(
 			[]backend.DataQuery{
@@ -0,0 +1,293 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/kinds/dataquery"
+)
+
+// SQLExpression is the Metrics Insights query built with the query builder. It is the SQLExpression of the
+// dataquery kind with the expressions the generated types can't represent.
+type SQLExpression struct {
+	Select *QueryEditorFunctionExpression `json:"select,omitempty"`
+	// From is either a namespace property or a SCHEMA function
+	From             *QueryEditorExpression         `json:"from,omitempty"`
+	Where            *QueryEditorExpression         `json:"where,omitempty"`
+	GroupBy          *QueryEditorExpression         `json:"groupBy,omitempty"`
+	OrderBy          *QueryEditorFunctionExpression `json:"orderBy,omitempty"`
+	OrderByDirection string                         `json:"orderByDirection,omitempty"`
+	Limit            int64                          `json:"limit,omitempty"`
+}
+
+type QueryEditorFunctionExpression struct {
+	Type       dataquery.QueryEditorExpressionType      `json:"type"`
+	Name       string                                   `json:"name,omitempty"`
+	Parameters []QueryEditorFunctionParameterExpression `json:"parameters,omitempty"`
+}
+
+type QueryEditorFunctionParameterExpression struct {
+	Type dataquery.QueryEditorExpressionType `json:"type"`
+	Name string                              `json:"name,omitempty"`
+}
+
+type QueryEditorProperty struct {
+	Type dataquery.QueryEditorPropertyType `json:"type"`
+	Name string                            `json:"name,omitempty"`
+}
+
+type QueryEditorOperator struct {
+	Name string `json:"name,omitempty"`
+	// Value is a string, a boolean or a number
+	Value interface{} `json:"value,omitempty"`
+}
+
+// QueryEditorExpression is any of the expressions of the query builder, the fields set depend on its type
+type QueryEditorExpression struct {
+	Type dataquery.QueryEditorExpressionType `json:"type"`
+	// Name and Parameters are set for the function expressions
+	Name       string                                   `json:"name,omitempty"`
+	Parameters []QueryEditorFunctionParameterExpression `json:"parameters,omitempty"`
+	// Property is set for the property, group by and operator expressions
+	Property *QueryEditorProperty `json:"property,omitempty"`
+	// Operator is set for the operator expressions
+	Operator *QueryEditorOperator `json:"operator,omitempty"`
+	// Expressions is set for the and/or expressions
+	Expressions []QueryEditorExpression `json:"expressions,omitempty"`
+}
+
+const schemaFunction = "SCHEMA"
+
+var (
+	sqlStatistics          = []string{"AVG", "COUNT", "MAX", "MIN", "SUM"}
+	sqlComparisonOperators = []string{"=", "!="}
+	sqlOrderByDirections   = []string{"ASC", "DESC"}
+
+	// slash, space, dot or dash
+	sqlSpecialCharacters = regexp.MustCompile(`[/\s.-]`)
+)
+
+var errMissingSQLSelect = errors.New("select must have a statistic and a metric name")
+
+// Validate checks that the query uses the functions and operators supported by Metrics Insights
+func (e *SQLExpression) Validate() error {
+	if e.Select == nil || e.Select.Name == "" || len(e.Select.Parameters) == 0 || e.Select.Parameters[0].Name == "" {
+		return errMissingSQLSelect
+	}
+	if err := validateStatistic(e.Select); err != nil {
+		return fmt.Errorf("invalid select: %w", err)
+	}
+	if len(e.Select.Parameters) > 1 {
+		return errors.New("invalid select: only one metric name is supported")
+	}
+
+	if err := validateFrom(e.From); err != nil {
+		return err
+	}
+
+	if e.Where != nil {
+		if err := validateWhere(*e.Where); err != nil {
+			return fmt.Errorf("invalid where: %w", err)
+		}
+	}
+
+	if e.GroupBy != nil {
+		for _, exp := range e.GroupBy.Expressions {
+			if exp.Type != dataquery.QueryEditorExpressionTypeGroupBy {
+				return fmt.Errorf("invalid group by: unexpected %q expression", exp.Type)
+			}
+		}
+	}
+
+	if e.OrderBy != nil {
+		if err := validateStatistic(e.OrderBy); err != nil {
+			return fmt.Errorf("invalid order by: %w", err)
+		}
+	}
+	if e.OrderByDirection != "" && !containsString(sqlOrderByDirections, e.OrderByDirection) {
+		return fmt.Errorf("invalid order by direction %q", e.OrderByDirection)
+	}
+
+	if e.Limit < 0 {
+		return fmt.Errorf("invalid limit %d", e.Limit)
+	}
+
+	return nil
+}
+
+func validateStatistic(f *QueryEditorFunctionExpression) error {
+	if !containsString(sqlStatistics, f.Name) {
+		return fmt.Errorf("unsupported statistic %q", f.Name)
+	}
+	return nil
+}
+
+func validateFrom(from *QueryEditorExpression) error {
+	if from == nil {
+		return errors.New("from must have a namespace or a schema")
+	}
+	switch from.Type {
+	case dataquery.QueryEditorExpressionTypeProperty:
+		if from.Property == nil || from.Property.Name == "" {
+			return errors.New("from must have a namespace or a schema")
+		}
+	case dataquery.QueryEditorExpressionTypeFunction:
+		if from.Name != schemaFunction {
+			return fmt.Errorf("invalid from: unsupported function %q", from.Name)
+		}
+		if len(from.Parameters) == 0 || from.Parameters[0].Name == "" {
+			return errors.New("invalid from: schema must have a namespace")
+		}
+	default:
+		return fmt.Errorf("invalid from: unexpected %q expression", from.Type)
+	}
+	return nil
+}
+
+func validateWhere(exp QueryEditorExpression) error {
+	switch exp.Type {
+	case dataquery.QueryEditorExpressionTypeAnd, dataquery.QueryEditorExpressionTypeOr:
+		for _, child := range exp.Expressions {
+			if err := validateWhere(child); err != nil {
+				return err
+			}
+		}
+	case dataquery.QueryEditorExpressionTypeOperator:
+		if exp.Operator == nil || exp.Operator.Name == "" {
+			return nil
+		}
+		if !containsString(sqlComparisonOperators, exp.Operator.Name) {
+			return fmt.Errorf("unsupported operator %q", exp.Operator.Name)
+		}
+		value, err := formatOperatorValue(exp.Operator.Value)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(value, "'") {
+			return fmt.Errorf("value %q can't contain a single quote", value)
+		}
+	default:
+		return fmt.Errorf("unexpected %q expression", exp.Type)
+	}
+	return nil
+}
+
+// ToSQL generates the Metrics Insights query like the query builder of the frontend, the incomplete filters and
+// group bys being skipped. The template variables are not interpolated.
+func (e *SQLExpression) ToSQL() (string, error) {
+	if err := e.Validate(); err != nil {
+		return "", err
+	}
+
+	parts := []string{"SELECT", formatFunction(e.Select), "FROM"}
+	if e.From.Type == dataquery.QueryEditorExpressionTypeFunction {
+		parts = append(parts, formatFunction(&QueryEditorFunctionExpression{Name: e.From.Name, Parameters: e.From.Parameters}))
+	} else {
+		parts = append(parts, formatSQLValue(e.From.Property.Name))
+	}
+
+	if e.Where != nil {
+		if where := formatWhere(*e.Where, true, len(e.Where.Expressions)); where != "" {
+			parts = append(parts, "WHERE", where)
+		}
+	}
+
+	if e.GroupBy != nil {
+		var groupBys []string
+		for _, exp := range e.GroupBy.Expressions {
+			if exp.Property == nil || exp.Property.Name == "" {
+				continue
+			}
+			groupBys = append(groupBys, formatSQLValue(exp.Property.Name))
+		}
+		if len(groupBys) > 0 {
+			parts = append(parts, "GROUP BY "+strings.Join(groupBys, ", "))
+		}
+	}
+
+	if e.OrderBy != nil {
+		direction := e.OrderByDirection
+		if direction == "" {
+			direction = "ASC"
+		}
+		parts = append(parts, "ORDER BY", formatFunction(e.OrderBy), direction)
+	}
+
+	if e.Limit > 0 {
+		parts = append(parts, "LIMIT "+strconv.FormatInt(e.Limit, 10))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func formatWhere(exp QueryEditorExpression, topLevel bool, topLevelCount int) string {
+	switch exp.Type {
+	case dataquery.QueryEditorExpressionTypeAnd, dataquery.QueryEditorExpressionTypeOr:
+		var children []string
+		for _, child := range exp.Expressions {
+			if s := formatWhere(child, false, topLevelCount); s != "" {
+				children = append(children, s)
+			}
+		}
+		combined := strings.Join(children, " "+strings.ToUpper(string(exp.Type))+" ")
+		if !topLevel && topLevelCount > 1 && len(children) > 1 {
+			return "(" + combined + ")"
+		}
+		return combined
+	case dataquery.QueryEditorExpressionTypeOperator:
+		if exp.Property == nil || exp.Property.Name == "" || exp.Operator == nil || exp.Operator.Name == "" {
+			return ""
+		}
+		value, err := formatOperatorValue(exp.Operator.Value)
+		if err != nil || value == "" {
+			return ""
+		}
+		return fmt.Sprintf("%s %s '%s'", formatSQLValue(exp.Property.Name), exp.Operator.Name, value)
+	}
+	return ""
+}
+
+func formatFunction(f *QueryEditorFunctionExpression) string {
+	params := make([]string, 0, len(f.Parameters))
+	for _, p := range f.Parameters {
+		if p.Name != "" {
+			params = append(params, formatSQLValue(p.Name))
+		}
+	}
+	return f.Name + "(" + strings.Join(params, ", ") + ")"
+}
+
+func formatOperatorValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// formatSQLValue quotes the names with special characters, like the namespaces
+func formatSQLValue(name string) string {
+	if sqlSpecialCharacters.MatchString(name) {
+		return `"` + name + `"`
+	}
+	return name
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
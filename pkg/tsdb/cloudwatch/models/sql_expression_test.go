@@ -0,0 +1,177 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sqlSelectSum  = `"select": {"type": "function", "name": "SUM", "parameters": [{"type": "functionParameter", "name": "CPUUtilization"}]}`
+	sqlFromSchema = `"from": {"type": "function", "name": "SCHEMA", "parameters": [{"type": "functionParameter", "name": "AWS/EC2"}]}`
+)
+
+func sqlOperator(property, operator, value string) string {
+	return `{"type": "operator", "property": {"type": "string", "name": "` + property + `"}, "operator": {"name": "` + operator + `", "value": "` + value + `"}}`
+}
+
+func parseSQLExpression(t *testing.T, sql string) *SQLExpression {
+	t.Helper()
+	var e SQLExpression
+	require.NoError(t, json.Unmarshal([]byte(sql), &e))
+	return &e
+}
+
+func TestSQLExpression_ToSQL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{
+			name:     "uses the statistic and the metric name of the select",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `}`,
+			expected: `SELECT SUM(CPUUtilization) FROM SCHEMA("AWS/EC2")`,
+		},
+		{
+			name:     "quotes the metric names with special characters",
+			sql:      `{"select": {"type": "function", "name": "AVG", "parameters": [{"type": "functionParameter", "name": "Disk.Read-Bytes"}]}, ` + sqlFromSchema + `}`,
+			expected: `SELECT AVG("Disk.Read-Bytes") FROM SCHEMA("AWS/EC2")`,
+		},
+		{
+			name:     "uses the schema dimensions",
+			sql:      `{` + sqlSelectSum + `, "from": {"type": "function", "name": "SCHEMA", "parameters": [{"type": "functionParameter", "name": "AWS/MQ"}, {"type": "functionParameter", "name": "InstanceId"}, {"type": "functionParameter", "name": "Instance-Group"}]}}`,
+			expected: `SELECT SUM(CPUUtilization) FROM SCHEMA("AWS/MQ", InstanceId, "Instance-Group")`,
+		},
+		{
+			name:     "uses the namespace without schema",
+			sql:      `{` + sqlSelectSum + `, "from": {"type": "property", "property": {"type": "string", "name": "AWS/MQ"}}}`,
+			expected: `SELECT SUM(CPUUtilization) FROM "AWS/MQ"`,
+		},
+		{
+			name:     "skips the where without filters",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "where": {"type": "and", "expressions": []}}`,
+			expected: `SELECT SUM(CPUUtilization) FROM SCHEMA("AWS/EC2")`,
+		},
+		{
+			name:     "skips the incomplete filters",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "where": {"type": "and", "expressions": [` + sqlOperator("InstanceId", "=", "") + `]}}`,
+			expected: `SELECT SUM(CPUUtilization) FROM SCHEMA("AWS/EC2")`,
+		},
+		{
+			name:     "combines the top level filters",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "where": {"type": "or", "expressions": [` + sqlOperator("InstanceId", "=", "I-123") + `, ` + sqlOperator("Instance-Id", "!=", "I-456") + `]}}`,
+			expected: `SELECT SUM(CPUUtilization) FROM SCHEMA("AWS/EC2") WHERE InstanceId = 'I-123' OR "Instance-Id" != 'I-456'`,
+		},
+		{
+			name: "wraps the nested filters in parentheses",
+			sql: `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "where": {"type": "and", "expressions": [
+				{"type": "or", "expressions": [` + sqlOperator("InstanceId", "=", "I-123") + `, ` + sqlOperator("Type", "!=", "some-type") + `]},
+				{"type": "and", "expressions": [` + sqlOperator("InstanceId", "!=", "I-456") + `]}
+			]}}`,
+			expected: `SELECT SUM(CPUUtilization) FROM SCHEMA("AWS/EC2") WHERE (InstanceId = 'I-123' OR Type != 'some-type') AND InstanceId != 'I-456'`,
+		},
+		{
+			name:     "supports the boolean and number values",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "where": {"type": "and", "expressions": [{"type": "operator", "property": {"type": "string", "name": "Enabled"}, "operator": {"name": "=", "value": true}}, {"type": "operator", "property": {"type": "string", "name": "Port"}, "operator": {"name": "=", "value": 8080}}]}}`,
+			expected: `SELECT SUM(CPUUtilization) FROM SCHEMA("AWS/EC2") WHERE Enabled = 'true' AND Port = '8080'`,
+		},
+		{
+			name:     "uses the order by with the default direction",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "orderBy": {"type": "function", "name": "MAX"}}`,
+			expected: `SELECT SUM(CPUUtilization) FROM SCHEMA("AWS/EC2") ORDER BY MAX() ASC`,
+		},
+		{
+			name: "generates the full query",
+			sql: `{
+				"select": {"type": "function", "name": "COUNT", "parameters": [{"type": "functionParameter", "name": "DroppedBytes"}]},
+				"from": {"type": "function", "name": "SCHEMA", "parameters": [{"type": "functionParameter", "name": "AWS/MQ"}, {"type": "functionParameter", "name": "InstanceId"}]},
+				"where": {"type": "and", "expressions": [
+					{"type": "or", "expressions": [` + sqlOperator("InstanceId", "=", "I-123") + `, ` + sqlOperator("Type", "!=", "some-type") + `]},
+					{"type": "or", "expressions": [` + sqlOperator("InstanceId", "!=", "I-456") + `, ` + sqlOperator("Type", "!=", "some-type") + `]}
+				]},
+				"groupBy": {"type": "and", "expressions": [{"type": "groupBy", "property": {"type": "string", "name": "InstanceId"}}, {"type": "groupBy", "property": {"type": "string", "name": "InstanceType"}}, {"type": "groupBy", "property": {"type": "string"}}]},
+				"orderBy": {"type": "function", "name": "COUNT"},
+				"orderByDirection": "DESC",
+				"limit": 100
+			}`,
+			expected: `SELECT COUNT(DroppedBytes) FROM SCHEMA("AWS/MQ", InstanceId) WHERE (InstanceId = 'I-123' OR Type != 'some-type') AND (InstanceId != 'I-456' OR Type != 'some-type') GROUP BY InstanceId, InstanceType ORDER BY COUNT() DESC LIMIT 100`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseSQLExpression(t, tc.sql).ToSQL()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestSQLExpression_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{
+			name:     "requires the select",
+			sql:      `{` + sqlFromSchema + `}`,
+			expected: "select must have a statistic and a metric name",
+		},
+		{
+			name:     "requires the metric name",
+			sql:      `{"select": {"type": "function", "name": "SUM"}, ` + sqlFromSchema + `}`,
+			expected: "select must have a statistic and a metric name",
+		},
+		{
+			name:     "rejects the unsupported statistics",
+			sql:      `{"select": {"type": "function", "name": "p99", "parameters": [{"type": "functionParameter", "name": "Latency"}]}, ` + sqlFromSchema + `}`,
+			expected: `invalid select: unsupported statistic "p99"`,
+		},
+		{
+			name:     "requires the from",
+			sql:      `{` + sqlSelectSum + `}`,
+			expected: "from must have a namespace or a schema",
+		},
+		{
+			name:     "rejects the functions other than schema in the from",
+			sql:      `{` + sqlSelectSum + `, "from": {"type": "function", "name": "SEARCH", "parameters": [{"type": "functionParameter", "name": "AWS/EC2"}]}}`,
+			expected: `invalid from: unsupported function "SEARCH"`,
+		},
+		{
+			name:     "rejects the unsupported operators",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "where": {"type": "and", "expressions": [` + sqlOperator("InstanceId", "LIKE", "I-%") + `]}}`,
+			expected: `invalid where: unsupported operator "LIKE"`,
+		},
+		{
+			name:     "rejects the values with single quotes",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "where": {"type": "and", "expressions": [` + sqlOperator("InstanceId", "=", "I-123' OR 'a") + `]}}`,
+			expected: `invalid where: value "I-123' OR 'a" can't contain a single quote`,
+		},
+		{
+			name:     "rejects the multi-value filters",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "where": {"type": "and", "expressions": [{"type": "operator", "property": {"type": "string", "name": "InstanceId"}, "operator": {"name": "=", "value": ["a", "b"]}}]}}`,
+			expected: "invalid where: unsupported value [a b]",
+		},
+		{
+			name:     "rejects the unsupported order by direction",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "orderBy": {"type": "function", "name": "SUM"}, "orderByDirection": "UP"}`,
+			expected: `invalid order by direction "UP"`,
+		},
+		{
+			name:     "rejects the negative limit",
+			sql:      `{` + sqlSelectSum + `, ` + sqlFromSchema + `, "limit": -1}`,
+			expected: "invalid limit -1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := parseSQLExpression(t, tc.sql).Validate()
+			require.EqualError(t, err, tc.expected)
+		})
+	}
+}
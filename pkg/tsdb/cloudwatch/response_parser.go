@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -111,6 +112,14 @@ func getLabels(cloudwatchLabel string, query *models.CloudWatchQuery) data.Label
 
 func buildDataFrames(startTime time.Time, endTime time.Time, aggregatedResponse models.QueryRowResponse,
 	query *models.CloudWatchQuery, dynamicLabelEnabled bool) (data.Frames, error) {
+	if query.IsAnomalyDetectionBandExpression() && len(aggregatedResponse.Metrics) == 2 {
+		deepLink, err := query.BuildDeepLink(startTime, endTime, dynamicLabelEnabled)
+		if err != nil {
+			return nil, err
+		}
+		return data.Frames{buildAnomalyDetectionBandFrame(aggregatedResponse, query, deepLink, dynamicLabelEnabled)}, nil
+	}
+
 	frames := data.Frames{}
 	for _, metric := range aggregatedResponse.Metrics {
 		label := *metric.Label
@@ -191,26 +200,130 @@ func buildDataFrames(startTime time.Time, endTime time.Time, aggregatedResponse
 			Meta:  createMeta(query),
 		}
 
-		for code := range aggregatedResponse.ErrorCodes {
-			if aggregatedResponse.ErrorCodes[code] {
-				frame.AppendNotices(data.Notice{
-					Severity: data.NoticeSeverityWarning,
-					Text:     "cloudwatch GetMetricData error: " + models.ErrorMessages[code],
-				})
-			}
+		appendResponseNotices(&frame, aggregatedResponse)
+		frames = append(frames, &frame)
+	}
+
+	return frames, nil
+}
+
+// buildAnomalyDetectionBandFrame returns the lower and the upper bounds of an anomaly detection band in one frame.
+// The upper bound is filled down to the lower bound, so that the time series panels show the band.
+func buildAnomalyDetectionBandFrame(aggregatedResponse models.QueryRowResponse, query *models.CloudWatchQuery,
+	deepLink string, dynamicLabelEnabled bool) *data.Frame {
+	lower, upper := aggregatedResponse.Metrics[0], aggregatedResponse.Metrics[1]
+	if isUpperBound(lower, upper) {
+		lower, upper = upper, lower
+	}
+
+	frameName := query.Id
+	if !dynamicLabelEnabled {
+		frameName = formatAlias(query, query.Statistic, nil, "")
+	} else if query.Label != "" {
+		frameName = query.Label
+	}
+
+	// the bounds are joined on their timestamps, a bound can miss the values of some timestamps
+	lowerValues := boundValues(lower)
+	upperValues := boundValues(upper)
+	timestamps := make([]time.Time, 0, len(lowerValues))
+	for t := range lowerValues {
+		timestamps = append(timestamps, t)
+	}
+	for t := range upperValues {
+		if _, ok := lowerValues[t]; !ok {
+			timestamps = append(timestamps, t)
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+
+	times := make([]*time.Time, len(timestamps))
+	lowerPoints := make([]*float64, len(timestamps))
+	upperPoints := make([]*float64, len(timestamps))
+	for i := range timestamps {
+		times[i] = &timestamps[i]
+		lowerPoints[i] = lowerValues[timestamps[i]]
+		upperPoints[i] = upperValues[timestamps[i]]
+	}
+
+	lowerName := frameName + " (lower)"
+	upperName := frameName + " (upper)"
+	lowerField := data.NewField("Lower", nil, lowerPoints)
+	lowerField.SetConfig(&data.FieldConfig{
+		DisplayNameFromDS: lowerName,
+		Links:             createDataLinks(deepLink),
+		Custom:            map[string]interface{}{"lineWidth": 0},
+	})
+	upperField := data.NewField("Upper", nil, upperPoints)
+	upperField.SetConfig(&data.FieldConfig{
+		DisplayNameFromDS: upperName,
+		Links:             createDataLinks(deepLink),
+		Custom:            map[string]interface{}{"lineWidth": 0, "fillBelowTo": lowerName, "fillOpacity": 20},
+	})
+
+	meta := createMeta(query)
+	meta.Type = data.FrameTypeTimeSeriesWide
+	frame := &data.Frame{
+		Name:   frameName,
+		Fields: []*data.Field{data.NewField(data.TimeSeriesTimeFieldName, nil, times), lowerField, upperField},
+		RefID:  query.RefId,
+		Meta:   meta,
+	}
+	appendResponseNotices(frame, aggregatedResponse)
+	return frame
+}
+
+// isUpperBound tells whether the first result of an anomaly detection band is its upper bound, from the labels of
+// the results when they name the bounds, or else from their values
+func isUpperBound(first, second *cloudwatch.MetricDataResult) bool {
+	firstLabel, secondLabel := strings.ToLower(aws.StringValue(first.Label)), strings.ToLower(aws.StringValue(second.Label))
+	if strings.Contains(firstLabel, "upper") || strings.Contains(secondLabel, "lower") {
+		return true
+	}
+	if strings.Contains(firstLabel, "lower") || strings.Contains(secondLabel, "upper") {
+		return false
+	}
+	return mean(first.Values) > mean(second.Values)
+}
+
+func boundValues(metric *cloudwatch.MetricDataResult) map[time.Time]*float64 {
+	values := make(map[time.Time]*float64, len(metric.Timestamps))
+	for j, t := range metric.Timestamps {
+		values[*t] = metric.Values[j]
+	}
+	return values
+}
+
+func mean(values []*float64) float64 {
+	sum, count := 0.0, 0
+	for _, v := range values {
+		if v != nil {
+			sum += *v
+			count++
 		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
 
-		if aggregatedResponse.StatusCode != "Complete" {
+func appendResponseNotices(frame *data.Frame, aggregatedResponse models.QueryRowResponse) {
+	for code := range aggregatedResponse.ErrorCodes {
+		if aggregatedResponse.ErrorCodes[code] {
 			frame.AppendNotices(data.Notice{
 				Severity: data.NoticeSeverityWarning,
-				Text:     "cloudwatch GetMetricData error: Too many datapoints requested - your search has been limited. Please try to reduce the time range",
+				Text:     "cloudwatch GetMetricData error: " + models.ErrorMessages[code],
 			})
 		}
-
-		frames = append(frames, &frame)
 	}
 
-	return frames, nil
+	if aggregatedResponse.StatusCode != "Complete" {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     "cloudwatch GetMetricData error: Too many datapoints requested - your search has been limited. Please try to reduce the time range",
+		})
+	}
 }
 
 func formatAlias(query *models.CloudWatchQuery, stat string, dimensions map[string]string, label string) string {
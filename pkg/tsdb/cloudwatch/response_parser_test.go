@@ -10,6 +10,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -518,4 +519,96 @@ func TestCloudWatchResponseParser(t *testing.T) {
 		require.Len(t, frames, 1)
 		assert.Equal(t, "some response label", frames[0].Name)
 	})
+
+	t.Run("buildDataFrames should return the bounds of an anomaly detection band in one frame", func(t *testing.T) {
+		timestamp := time.Unix(0, 0)
+		response := &models.QueryRowResponse{
+			Metrics: []*cloudwatch.MetricDataResult{
+				{
+					Id:         aws.String("ad1"),
+					Label:      aws.String("ad1"),
+					Timestamps: []*time.Time{aws.Time(timestamp), aws.Time(timestamp.Add(time.Minute)), aws.Time(timestamp.Add(2 * time.Minute))},
+					Values:     []*float64{aws.Float64(12), aws.Float64(14), aws.Float64(13)},
+					StatusCode: aws.String("Complete"),
+				},
+				{
+					Id:         aws.String("ad1"),
+					Label:      aws.String("ad1"),
+					Timestamps: []*time.Time{aws.Time(timestamp), aws.Time(timestamp.Add(2 * time.Minute))},
+					Values:     []*float64{aws.Float64(8), aws.Float64(9)},
+					StatusCode: aws.String("Complete"),
+				},
+			},
+			StatusCode: "Complete",
+		}
+		query := &models.CloudWatchQuery{
+			RefId:            "A",
+			Region:           "us-east-1",
+			Id:               "ad1",
+			Expression:       "ANOMALY_DETECTION_BAND(m1, 2)",
+			Period:           60,
+			MetricQueryType:  models.MetricQueryTypeSearch,
+			MetricEditorMode: models.MetricEditorModeRaw,
+		}
+
+		frames, err := buildDataFrames(startTime, endTime, *response, query, false)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+
+		frame := frames[0]
+		assert.Equal(t, "ad1", frame.Name)
+		assert.Equal(t, "A", frame.RefID)
+		assert.Equal(t, data.FrameTypeTimeSeriesWide, frame.Meta.Type)
+		assert.Empty(t, frame.Meta.Notices)
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, timestamp, *frame.Fields[0].At(0).(*time.Time))
+		assert.Equal(t, timestamp.Add(time.Minute), *frame.Fields[0].At(1).(*time.Time))
+
+		lower, upper := frame.Fields[1], frame.Fields[2]
+		assert.Equal(t, "Lower", lower.Name)
+		assert.Equal(t, "ad1 (lower)", lower.Config.DisplayNameFromDS)
+		assert.Equal(t, 8.0, *lower.At(0).(*float64))
+		assert.Nil(t, lower.At(1))
+		assert.Equal(t, "Upper", upper.Name)
+		assert.Equal(t, "ad1 (upper)", upper.Config.DisplayNameFromDS)
+		assert.Equal(t, 14.0, *upper.At(1).(*float64))
+		assert.Equal(t, "ad1 (lower)", upper.Config.Custom["fillBelowTo"])
+	})
+
+	t.Run("buildDataFrames should find the bounds of an anomaly detection band from their labels", func(t *testing.T) {
+		timestamp := time.Unix(0, 0)
+		response := &models.QueryRowResponse{
+			Metrics: []*cloudwatch.MetricDataResult{
+				{
+					Label:      aws.String("CPUUtilization Upper"),
+					Timestamps: []*time.Time{aws.Time(timestamp)},
+					Values:     []*float64{aws.Float64(1)},
+					StatusCode: aws.String("Complete"),
+				},
+				{
+					Label:      aws.String("CPUUtilization Lower"),
+					Timestamps: []*time.Time{aws.Time(timestamp)},
+					Values:     []*float64{aws.Float64(2)},
+					StatusCode: aws.String("Complete"),
+				},
+			},
+			StatusCode: "Complete",
+		}
+		query := &models.CloudWatchQuery{
+			RefId:            "A",
+			Id:               "ad1",
+			Expression:       "ANOMALY_DETECTION_BAND(m1)",
+			Label:            "CPU band",
+			MetricQueryType:  models.MetricQueryTypeSearch,
+			MetricEditorMode: models.MetricEditorModeRaw,
+		}
+
+		frames, err := buildDataFrames(startTime, endTime, *response, query, true)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, "CPU band", frames[0].Name)
+		assert.Equal(t, 2.0, *frames[0].Fields[1].At(0).(*float64))
+		assert.Equal(t, 1.0, *frames[0].Fields[2].At(0).(*float64))
+	})
 }
@@ -0,0 +1,427 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	asyncSearchChannelPrefix = "async/"
+
+	// asyncSearchPollInterval is how long a poll of a running async search waits for it to complete, the partial
+	// results are streamed after every poll
+	asyncSearchPollInterval = time.Second
+
+	// asyncSearchSubscribeTimeout is how long the results of an async search are kept without subscribers
+	asyncSearchSubscribeTimeout = time.Minute
+
+	// asyncSearchDeleteTimeout is the timeout of the requests deleting the searches nobody waits for anymore
+	asyncSearchDeleteTimeout = 10 * time.Second
+)
+
+// asyncSearchOptions enables the async search API for the searches running longer than the threshold of the
+// datasource
+type asyncSearchOptions struct {
+	store         *asyncSearchStore
+	orgID         int64
+	datasourceUID string
+	dsInfo        *es.DatasourceInfo
+	timeRange     backend.TimeRange
+	// wait is true when the results can't be streamed, like for the alerts, the searches are then polled until they
+	// complete
+	wait bool
+}
+
+// executeAsync runs the searches with the async search API. The results of the searches completing before the
+// threshold are returned like for the multi search API, the partial results of the other searches are returned with
+// a channel streaming the rest of the results.
+func (e *elasticsearchDataQuery) executeAsync(req *es.MultiSearchRequest, queries []*Query) (*backend.QueryDataResponse, error) {
+	threshold := e.async.dsInfo.AsyncSearchThreshold
+	searches := make([]*es.AsyncSearchResponse, len(req.Requests))
+	errs := make([]error, len(req.Requests))
+
+	var wg sync.WaitGroup
+	for i, r := range req.Requests {
+		wg.Add(1)
+		go func(i int, r *es.SearchRequest) {
+			defer wg.Done()
+			searches[i], errs[i] = e.client.SubmitAsyncSearch(r, threshold)
+			for errs[i] == nil && searches[i].IsRunning && e.async.wait {
+				res, err := e.client.GetAsyncSearch(searches[i].ID, asyncSearchPollInterval)
+				if err != nil {
+					errs[i] = err
+					break
+				}
+				searches[i] = res
+			}
+		}(i, r)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			e.deleteRunningSearches(searches)
+			return &backend.QueryDataResponse{}, fmt.Errorf("async search of query %s failed: %w", queries[i].RefID, err)
+		}
+	}
+
+	responses := make([]*es.SearchResponse, len(searches))
+	for i, s := range searches {
+		responses[i] = s.Response
+	}
+	result, err := parseResponse(responses, queries, e.client.GetConfiguredFields())
+	if err != nil {
+		e.deleteRunningSearches(searches)
+		return result, err
+	}
+
+	for i, s := range searches {
+		if !s.IsRunning {
+			continue
+		}
+		search := newAsyncSearch(s.ID, e.async.orgID, e.async.datasourceUID, queries[i], e.async.dsInfo, e.async.timeRange)
+		key := e.async.store.add(search)
+
+		res := result.Responses[queries[i].RefID]
+		if len(res.Frames) == 0 {
+			res.Frames = data.Frames{data.NewFrame(queries[i].RefID)}
+		}
+		for j, frame := range res.Frames {
+			channel := live.Channel{
+				Scope:     live.ScopeDatasource,
+				Namespace: e.async.datasourceUID,
+				Path:      asyncSearchChannelPrefix + key + "/" + strconv.Itoa(j),
+			}
+			setPartialResultMeta(frame, true)
+			frame.Meta.Channel = channel.String()
+		}
+		result.Responses[queries[i].RefID] = res
+	}
+	return result, nil
+}
+
+func (e *elasticsearchDataQuery) deleteRunningSearches(searches []*es.AsyncSearchResponse) {
+	for _, s := range searches {
+		if s != nil && s.IsRunning {
+			deleteAsyncSearch(e.async.dsInfo, e.async.timeRange, s.ID)
+		}
+	}
+}
+
+// deleteAsyncSearch cancels the search in Elasticsearch, it is called once the request that started the search is
+// done, so it uses its own context
+func deleteAsyncSearch(dsInfo *es.DatasourceInfo, timeRange backend.TimeRange, id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), asyncSearchDeleteTimeout)
+	defer cancel()
+
+	client, err := es.NewClient(ctx, dsInfo, timeRange)
+	if err == nil {
+		err = client.DeleteAsyncSearch(id)
+	}
+	if err != nil {
+		eslog.Warn("Failed to delete async search", "id", id, "error", err)
+	}
+}
+
+func setPartialResultMeta(frame *data.Frame, partial bool) {
+	if frame.Meta == nil {
+		frame.Meta = &data.FrameMeta{}
+	}
+	if partial {
+		frame.Meta.Notices = append(frame.Meta.Notices, data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     "Partial results, the search is still running",
+		})
+	}
+}
+
+// asyncSearch is a running async search whose results are streamed to the subscribers of its channels, one channel
+// for every frame of the results
+type asyncSearch struct {
+	id string
+	// orgID and datasourceUID are the datasource that started the search, only its channels can stream the results
+	orgID         int64
+	datasourceUID string
+	query         *Query
+	dsInfo        *es.DatasourceInfo
+	timeRange     backend.TimeRange
+
+	mu          sync.Mutex
+	lastUsed    time.Time
+	subscribers int
+	// cancel stops the polling, it is nil until the first subscriber
+	cancel  context.CancelFunc
+	frames  data.Frames
+	err     error
+	done    bool
+	version int
+	// updated is closed when the results are updated
+	updated chan struct{}
+}
+
+func newAsyncSearch(id string, orgID int64, datasourceUID string, query *Query, dsInfo *es.DatasourceInfo, timeRange backend.TimeRange) *asyncSearch {
+	return &asyncSearch{
+		id:            id,
+		orgID:         orgID,
+		datasourceUID: datasourceUID,
+		query:         query,
+		dsInfo:        dsInfo,
+		timeRange:     timeRange,
+		lastUsed:      time.Now(),
+		updated:       make(chan struct{}),
+	}
+}
+
+// subscribe starts polling the search for the first subscriber
+func (s *asyncSearch) subscribe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers++
+	if s.cancel != nil || s.done {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.poll(ctx)
+}
+
+// unsubscribe cancels the search when there are no subscribers left
+func (s *asyncSearch) unsubscribe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers--
+	s.lastUsed = time.Now()
+	if s.subscribers == 0 && s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
+
+// asyncSearchState is the state of an async search after an update
+type asyncSearchState struct {
+	frames  data.Frames
+	err     error
+	done    bool
+	version int
+	// updated is closed on the next update
+	updated <-chan struct{}
+}
+
+func (s *asyncSearch) state() asyncSearchState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return asyncSearchState{frames: s.frames, err: s.err, done: s.done, version: s.version, updated: s.updated}
+}
+
+func (s *asyncSearch) update(frames data.Frames, err error, done bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frames = frames
+	s.err = err
+	s.done = done
+	s.version++
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+// expired tells whether the search has no subscribers for too long
+func (s *asyncSearch) expired(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscribers == 0 && now.Sub(s.lastUsed) > asyncSearchSubscribeTimeout
+}
+
+func (s *asyncSearch) poll(ctx context.Context) {
+	client, err := es.NewClient(ctx, s.dsInfo, s.timeRange)
+	if err != nil {
+		s.update(nil, err, true)
+		return
+	}
+
+	for {
+		res, err := client.GetAsyncSearch(s.id, asyncSearchPollInterval)
+		if ctx.Err() != nil {
+			// nobody waits for the results anymore
+			deleteAsyncSearch(s.dsInfo, s.timeRange, s.id)
+			return
+		}
+		if err != nil {
+			s.update(nil, err, true)
+			return
+		}
+
+		frames, err := s.parse(res)
+		s.update(frames, err, err != nil || !res.IsRunning)
+		if err != nil || !res.IsRunning {
+			return
+		}
+	}
+}
+
+func (s *asyncSearch) parse(res *es.AsyncSearchResponse) (data.Frames, error) {
+	result, err := parseResponse([]*es.SearchResponse{res.Response}, []*Query{s.query}, s.dsInfo.ConfiguredFields)
+	if err != nil {
+		return nil, err
+	}
+	queryRes := result.Responses[s.query.RefID]
+	if queryRes.Error != nil {
+		return nil, queryRes.Error
+	}
+	for _, frame := range queryRes.Frames {
+		setPartialResultMeta(frame, res.IsRunning)
+	}
+	return queryRes.Frames, nil
+}
+
+// asyncSearchStore keeps the running async searches until their results are streamed
+type asyncSearchStore struct {
+	mu       sync.Mutex
+	searches map[string]*asyncSearch
+	// expiry removes the searches nobody subscribed to, it is only set while the store has searches
+	expiry *time.Timer
+}
+
+func newAsyncSearchStore() *asyncSearchStore {
+	return &asyncSearchStore{searches: map[string]*asyncSearch{}}
+}
+
+// add returns the key of the channels of the search
+func (s *asyncSearchStore) add(search *asyncSearch) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := util.GenerateShortUID()
+	s.searches[key] = search
+	if s.expiry == nil {
+		s.expiry = time.AfterFunc(asyncSearchSubscribeTimeout, s.removeExpired)
+	}
+	return key
+}
+
+// removeExpired removes the searches without subscribers for too long, and cancels the ones still running. It runs
+// every asyncSearchSubscribeTimeout until the store is empty.
+func (s *asyncSearchStore) removeExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, search := range s.searches {
+		if search.expired(now) {
+			delete(s.searches, key)
+			if !search.state().done {
+				go deleteAsyncSearch(search.dsInfo, search.timeRange, search.id)
+			}
+		}
+	}
+
+	if len(s.searches) == 0 {
+		s.expiry = nil
+		return
+	}
+	s.expiry.Reset(asyncSearchSubscribeTimeout)
+}
+
+// get returns the search of the key when it was started by the datasource of the plugin context
+func (s *asyncSearchStore) get(key string, pCtx backend.PluginContext) (*asyncSearch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	search, ok := s.searches[key]
+	if !ok || pCtx.DataSourceInstanceSettings == nil {
+		return nil, false
+	}
+	if search.orgID != pCtx.OrgID || search.datasourceUID != pCtx.DataSourceInstanceSettings.UID {
+		return nil, false
+	}
+	return search, true
+}
+
+// parseAsyncSearchPath returns the search key and the frame index of a channel path, like async/<key>/<index>
+func parseAsyncSearchPath(path string) (string, int, error) {
+	key, index, ok := strings.Cut(strings.TrimPrefix(path, asyncSearchChannelPrefix), "/")
+	if !strings.HasPrefix(path, asyncSearchChannelPrefix) || !ok {
+		return "", 0, fmt.Errorf("invalid async search channel path %q", path)
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 {
+		return "", 0, fmt.Errorf("invalid async search channel path %q", path)
+	}
+	return key, i, nil
+}
+
+func (s *Service) SubscribeStream(_ context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	key, index, err := parseAsyncSearchPath(req.Path)
+	if err != nil {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+	}
+	search, ok := s.asyncSearches.get(key, req.PluginContext)
+	if !ok {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+	}
+
+	frames := search.state().frames
+	if index >= len(frames) {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusOK}, nil
+	}
+	initialData, err := backend.NewInitialFrame(frames[index], data.IncludeAll)
+	return &backend.SubscribeStreamResponse{
+		Status:      backend.SubscribeStreamStatusOK,
+		InitialData: initialData,
+	}, err
+}
+
+// RunStream sends the results of the async search to the channel of one of its frames every time they are updated,
+// until the search completes
+func (s *Service) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	key, index, err := parseAsyncSearchPath(req.Path)
+	if err != nil {
+		return err
+	}
+	search, ok := s.asyncSearches.get(key, req.PluginContext)
+	if !ok {
+		return errors.New("async search not found")
+	}
+
+	search.subscribe()
+	defer search.unsubscribe()
+
+	sent := 0
+	for {
+		state := search.state()
+		if state.err != nil {
+			return state.err
+		}
+		if state.version != sent && index < len(state.frames) {
+			if err := sender.SendFrame(state.frames[index], data.IncludeAll); err != nil {
+				return err
+			}
+			sent = state.version
+		}
+		if state.done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-state.updated:
+		}
+	}
+}
+
+func (s *Service) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{
+		Status: backend.PublishStreamStatusPermissionDenied,
+	}, nil
+}
@@ -0,0 +1,296 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+const asyncSearchTestQuery = `{
+	"bucketAggs": [{ "type": "date_histogram", "field": "@timestamp", "id": "2" }],
+	"metrics": [{"type": "count", "id": "1" }]
+}`
+
+func TestExecuteAsyncSearch(t *testing.T) {
+	from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
+	to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
+
+	t.Run("Should return the results of the searches completing before the threshold", func(t *testing.T) {
+		c := newFakeClient()
+		c.asyncSearchResponses = []*es.AsyncSearchResponse{
+			asyncSearchTestResponse(t, "search-1", false, 10),
+		}
+		opts := newAsyncSearchTestOptions(false)
+
+		res, err := executeAsyncSearchDataQuery(c, asyncSearchTestQuery, from, to, opts)
+		require.NoError(t, err)
+
+		require.Len(t, c.asyncSearchRequests, 1)
+		require.Empty(t, c.multisearchRequests)
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, 10.0, *frames[0].Fields[1].At(0).(*float64))
+		require.Empty(t, frames[0].Meta.Channel)
+		require.Empty(t, opts.store.searches)
+	})
+
+	t.Run("Should return the partial results of the running searches with a channel", func(t *testing.T) {
+		c := newFakeClient()
+		c.asyncSearchResponses = []*es.AsyncSearchResponse{
+			asyncSearchTestResponse(t, "search-1", true, 5),
+		}
+		opts := newAsyncSearchTestOptions(false)
+
+		res, err := executeAsyncSearchDataQuery(c, asyncSearchTestQuery, from, to, opts)
+		require.NoError(t, err)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Len(t, opts.store.searches, 1)
+		for key, search := range opts.store.searches {
+			require.Equal(t, "search-1", search.id)
+			require.Equal(t, "ds/datasource-uid/async/"+key+"/0", frames[0].Meta.Channel)
+		}
+		require.Len(t, frames[0].Meta.Notices, 1)
+		require.Equal(t, data.NoticeSeverityInfo, frames[0].Meta.Notices[0].Severity)
+	})
+
+	t.Run("Should return an empty frame with a channel when the running search has no results yet", func(t *testing.T) {
+		c := newFakeClient()
+		c.asyncSearchResponses = []*es.AsyncSearchResponse{
+			{ID: "search-1", IsPartial: true, IsRunning: true, Response: &es.SearchResponse{}},
+		}
+		opts := newAsyncSearchTestOptions(false)
+
+		res, err := executeAsyncSearchDataQuery(c, asyncSearchTestQuery, from, to, opts)
+		require.NoError(t, err)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.NotEmpty(t, frames[0].Meta.Channel)
+	})
+
+	t.Run("Should poll the running searches until they complete when the results can't be streamed", func(t *testing.T) {
+		c := newFakeClient()
+		c.asyncSearchResponses = []*es.AsyncSearchResponse{
+			asyncSearchTestResponse(t, "search-1", true, 5),
+			asyncSearchTestResponse(t, "search-1", true, 8),
+			asyncSearchTestResponse(t, "search-1", false, 10),
+		}
+		opts := newAsyncSearchTestOptions(true)
+
+		res, err := executeAsyncSearchDataQuery(c, asyncSearchTestQuery, from, to, opts)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"search-1", "search-1"}, c.asyncSearchPolls)
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, 10.0, *frames[0].Fields[1].At(0).(*float64))
+		require.Empty(t, frames[0].Meta.Channel)
+		require.Empty(t, opts.store.searches)
+	})
+
+	t.Run("Should return the error of the searches failing to be submitted", func(t *testing.T) {
+		c := newFakeClient()
+		opts := newAsyncSearchTestOptions(false)
+
+		_, err := executeAsyncSearchDataQuery(c, asyncSearchTestQuery, from, to, opts)
+		require.ErrorContains(t, err, "async search of query A failed")
+	})
+}
+
+func TestAsyncSearchStream(t *testing.T) {
+	from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
+	to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
+
+	c := newFakeClient()
+	c.asyncSearchResponses = []*es.AsyncSearchResponse{
+		asyncSearchTestResponse(t, "search-1", true, 5),
+		asyncSearchTestResponse(t, "search-1", true, 8),
+		asyncSearchTestResponse(t, "search-1", false, 10),
+	}
+	origNewClient := es.NewClient
+	es.NewClient = func(ctx context.Context, ds *es.DatasourceInfo, timeRange backend.TimeRange) (es.Client, error) {
+		return c, nil
+	}
+	t.Cleanup(func() {
+		es.NewClient = origNewClient
+	})
+
+	opts := newAsyncSearchTestOptions(false)
+	res, err := executeAsyncSearchDataQuery(c, asyncSearchTestQuery, from, to, opts)
+	require.NoError(t, err)
+	channel, err := backendChannelPath(res.Responses["A"].Frames[0].Meta.Channel)
+	require.NoError(t, err)
+
+	s := &Service{asyncSearches: opts.store}
+	pCtx := backend.PluginContext{OrgID: 1, DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "datasource-uid"}}
+
+	t.Run("Should subscribe to the channels of the running searches", func(t *testing.T) {
+		subRes, err := s.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pCtx, Path: channel})
+		require.NoError(t, err)
+		require.Equal(t, backend.SubscribeStreamStatusOK, subRes.Status)
+
+		subRes, err = s.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pCtx, Path: "async/unknown/0"})
+		require.NoError(t, err)
+		require.Equal(t, backend.SubscribeStreamStatusNotFound, subRes.Status)
+	})
+
+	t.Run("Should not find the searches of other datasources", func(t *testing.T) {
+		for _, other := range []backend.PluginContext{
+			{OrgID: 2, DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "datasource-uid"}},
+			{OrgID: 1, DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "other-uid"}},
+			{OrgID: 1},
+		} {
+			subRes, err := s.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: other, Path: channel})
+			require.NoError(t, err)
+			require.Equal(t, backend.SubscribeStreamStatusNotFound, subRes.Status)
+
+			err = s.RunStream(context.Background(), &backend.RunStreamRequest{PluginContext: other, Path: channel}, backend.NewStreamSender(&fakeStreamPacketSender{}))
+			require.EqualError(t, err, "async search not found")
+		}
+	})
+
+	t.Run("Should stream the results until the search completes", func(t *testing.T) {
+		sender := &fakeStreamPacketSender{}
+		err := s.RunStream(context.Background(), &backend.RunStreamRequest{PluginContext: pCtx, Path: channel}, backend.NewStreamSender(sender))
+		require.NoError(t, err)
+
+		frames := sender.frames(t)
+		require.NotEmpty(t, frames)
+		last := frames[len(frames)-1]
+		require.Equal(t, 10.0, *last.Fields[1].At(0).(*float64))
+		require.Empty(t, last.Meta.Notices)
+		require.Equal(t, []string{"search-1", "search-1"}, c.asyncSearchPolls)
+	})
+
+	t.Run("Should deny publishing", func(t *testing.T) {
+		pubRes, err := s.PublishStream(context.Background(), &backend.PublishStreamRequest{Path: channel})
+		require.NoError(t, err)
+		require.Equal(t, backend.PublishStreamStatusPermissionDenied, pubRes.Status)
+	})
+}
+
+func TestParseAsyncSearchPath(t *testing.T) {
+	key, index, err := parseAsyncSearchPath("async/abc/2")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", key)
+	assert.Equal(t, 2, index)
+
+	for _, path := range []string{"abc/2", "async/abc", "async/abc/x", "async/abc/-1"} {
+		_, _, err := parseAsyncSearchPath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestAsyncSearchStoreRemovesExpiredSearches(t *testing.T) {
+	pCtx := backend.PluginContext{OrgID: 1, DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "datasource-uid"}}
+	store := newAsyncSearchStore()
+	expired := newAsyncSearch("search-1", 1, "datasource-uid", nil, &es.DatasourceInfo{}, backend.TimeRange{})
+	expired.lastUsed = time.Now().Add(-2 * asyncSearchSubscribeTimeout)
+	expired.done = true
+	expiredKey := store.add(expired)
+	key := store.add(newAsyncSearch("search-2", 1, "datasource-uid", nil, &es.DatasourceInfo{}, backend.TimeRange{}))
+	require.NotNil(t, store.expiry)
+
+	store.removeExpired()
+	_, ok := store.get(expiredKey, pCtx)
+	require.False(t, ok)
+	_, ok = store.get(key, pCtx)
+	require.True(t, ok)
+	require.NotNil(t, store.expiry, "the expiry runs again while the store has searches")
+
+	store.searches[key].lastUsed = time.Now().Add(-2 * asyncSearchSubscribeTimeout)
+	store.searches[key].done = true
+	store.removeExpired()
+	require.Empty(t, store.searches)
+	require.Nil(t, store.expiry, "the expiry stops once the store is empty")
+}
+
+type fakeStreamPacketSender struct {
+	mu      sync.Mutex
+	packets []*backend.StreamPacket
+}
+
+func (s *fakeStreamPacketSender) Send(packet *backend.StreamPacket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.packets = append(s.packets, packet)
+	return nil
+}
+
+func (s *fakeStreamPacketSender) frames(t *testing.T) []*data.Frame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frames := make([]*data.Frame, 0, len(s.packets))
+	for _, packet := range s.packets {
+		var frame data.Frame
+		require.NoError(t, json.Unmarshal(packet.Data, &frame))
+		frames = append(frames, &frame)
+	}
+	return frames
+}
+
+// backendChannelPath returns the path of a channel like the one the plugins receive
+func backendChannelPath(channel string) (string, error) {
+	ch, err := live.ParseChannel(channel)
+	if err != nil {
+		return "", err
+	}
+	return ch.Path, nil
+}
+
+func newAsyncSearchTestOptions(wait bool) *asyncSearchOptions {
+	return &asyncSearchOptions{
+		store:         newAsyncSearchStore(),
+		orgID:         1,
+		datasourceUID: "datasource-uid",
+		dsInfo: &es.DatasourceInfo{
+			AsyncSearchThreshold: time.Second,
+			ConfiguredFields:     es.ConfiguredFields{TimeField: "@timestamp"},
+		},
+		wait: wait,
+	}
+}
+
+func asyncSearchTestResponse(t *testing.T, id string, running bool, count int) *es.AsyncSearchResponse {
+	t.Helper()
+	var res es.SearchResponse
+	err := json.Unmarshal([]byte(`{
+		"aggregations": {
+			"2": {
+				"buckets": [{"doc_count": `+strconv.Itoa(count)+`, "key": 1000}]
+			}
+		}
+	}`), &res)
+	require.NoError(t, err)
+	return &es.AsyncSearchResponse{ID: id, IsPartial: running, IsRunning: running, Response: &res}
+}
+
+func executeAsyncSearchDataQuery(c es.Client, body string, from, to time.Time, opts *asyncSearchOptions) (*backend.QueryDataResponse, error) {
+	timeRange := backend.TimeRange{
+		From: from,
+		To:   to,
+	}
+	opts.timeRange = timeRange
+	query := newElasticsearchDataQuery(c, []backend.DataQuery{
+		{
+			RefID:     "A",
+			JSON:      json.RawMessage(body),
+			TimeRange: timeRange,
+		},
+	})
+	query.async = opts
+	return query.execute()
+}
@@ -0,0 +1,120 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// asyncSearchKeepAlive is how long Elasticsearch keeps an async search after it was submitted or polled, the
+// searches not polled anymore are cancelled and deleted after it
+const asyncSearchKeepAlive = "1m"
+
+// AsyncSearchResponse represents the response of the async search API
+type AsyncSearchResponse struct {
+	ID        string `json:"id"`
+	IsPartial bool   `json:"is_partial"`
+	IsRunning bool   `json:"is_running"`
+	// Response has the partial results of the search while it is running
+	Response *SearchResponse        `json:"response"`
+	Error    map[string]interface{} `json:"error"`
+}
+
+// SubmitAsyncSearch starts the search with the async search API, and waits for it to complete for waitForCompletion.
+// The search is still running when the response is returned if it took longer.
+func (c *baseClientImpl) SubmitAsyncSearch(r *SearchRequest, waitForCompletion time.Duration) (*AsyncSearchResponse, error) {
+	c.logger.Debug("Submitting async search", "waitForCompletion", waitForCompletion)
+
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	qs := url.Values{}
+	qs.Set("wait_for_completion_timeout", formatTimeout(waitForCompletion))
+	qs.Set("keep_alive", asyncSearchKeepAlive)
+	qs.Set("keep_on_completion", "false")
+	qs.Set("ignore_unavailable", "true")
+	maxConcurrentShardRequests := c.ds.MaxConcurrentShardRequests
+	if maxConcurrentShardRequests == 0 {
+		maxConcurrentShardRequests = 5
+	}
+	qs.Set("max_concurrent_shard_requests", fmt.Sprintf("%d", maxConcurrentShardRequests))
+	if c.ds.IncludeFrozen && c.ds.XPack {
+		qs.Set("ignore_throttled", "false")
+	}
+
+	uriPath := "_async_search"
+	if len(c.indices) > 0 {
+		uriPath = strings.Join(c.indices, ",") + "/_async_search"
+	}
+	res, err := c.executeRequest(http.MethodPost, uriPath, qs.Encode(), "application/json", []byte(interpolateInterval(string(body), r.Interval)))
+	if err != nil {
+		return nil, err
+	}
+	return c.decodeAsyncSearchResponse(res)
+}
+
+// GetAsyncSearch returns the results of the async search, and waits for it to complete for waitForCompletion
+func (c *baseClientImpl) GetAsyncSearch(id string, waitForCompletion time.Duration) (*AsyncSearchResponse, error) {
+	qs := url.Values{}
+	qs.Set("wait_for_completion_timeout", formatTimeout(waitForCompletion))
+	qs.Set("keep_alive", asyncSearchKeepAlive)
+
+	res, err := c.executeRequest(http.MethodGet, "_async_search/"+url.PathEscape(id), qs.Encode(), "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.decodeAsyncSearchResponse(res)
+}
+
+// DeleteAsyncSearch cancels the async search if it is still running and deletes its results
+func (c *baseClientImpl) DeleteAsyncSearch(id string) error {
+	res, err := c.executeRequest(http.MethodDelete, "_async_search/"+url.PathEscape(id), "", "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			c.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	// the search was already deleted when it is not found, like when it expired
+	if res.StatusCode/100 != 2 && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete async search: %s", res.Status)
+	}
+	return nil
+}
+
+func (c *baseClientImpl) decodeAsyncSearchResponse(res *http.Response) (*AsyncSearchResponse, error) {
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			c.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	c.logger.Debug("Received async search response", "code", res.StatusCode, "status", res.Status, "content-length", res.ContentLength)
+
+	var asr AsyncSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&asr); err != nil {
+		return nil, err
+	}
+
+	// the errors of the search, or of an expired search, are returned like the errors of the multi search responses
+	if asr.Response == nil && asr.Error != nil {
+		asr.IsRunning = false
+		asr.Response = &SearchResponse{Error: asr.Error}
+	}
+	if asr.Response == nil {
+		asr.Response = &SearchResponse{}
+	}
+	return &asr, nil
+}
+
+func formatTimeout(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
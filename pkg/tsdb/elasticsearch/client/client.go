@@ -31,6 +31,9 @@ type DatasourceInfo struct {
 	MaxConcurrentShardRequests int64
 	IncludeFrozen              bool
 	XPack                      bool
+	// AsyncSearchThreshold is how long a search can run before its results are streamed with the async search API,
+	// the async search API is not used when it is zero
+	AsyncSearchThreshold time.Duration
 }
 
 type ConfiguredFields struct {
@@ -47,6 +50,10 @@ type Client interface {
 	GetMinInterval(queryInterval string) (time.Duration, error)
 	ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearchResponse, error)
 	MultiSearch() *MultiSearchRequestBuilder
+	SubmitAsyncSearch(r *SearchRequest, waitForCompletion time.Duration) (*AsyncSearchResponse, error)
+	GetAsyncSearch(id string, waitForCompletion time.Duration) (*AsyncSearchResponse, error)
+	DeleteAsyncSearch(id string) error
+	ExecuteEQLSearch(r *EQLSearchRequest) (*EQLSearchResponse, error)
 }
 
 // NewClient creates a new elasticsearch client
@@ -103,7 +110,7 @@ func (c *baseClientImpl) executeBatchRequest(uriPath, uriQuery string, requests
 	if err != nil {
 		return nil, err
 	}
-	return c.executeRequest(http.MethodPost, uriPath, uriQuery, bytes)
+	return c.executeRequest(http.MethodPost, uriPath, uriQuery, "application/x-ndjson", bytes)
 }
 
 func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte, error) {
@@ -123,11 +130,7 @@ func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte,
 			return nil, err
 		}
 
-		body := string(reqBody)
-		body = strings.ReplaceAll(body, "$__interval_ms", strconv.FormatInt(r.interval.Milliseconds(), 10))
-		body = strings.ReplaceAll(body, "$__interval", r.interval.String())
-
-		payload.WriteString(body + "\n")
+		payload.WriteString(interpolateInterval(string(reqBody), r.interval) + "\n")
 	}
 
 	elapsed := time.Since(start)
@@ -136,7 +139,12 @@ func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte,
 	return payload.Bytes(), nil
 }
 
-func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body []byte) (*http.Response, error) {
+func interpolateInterval(body string, interval time.Duration) string {
+	body = strings.ReplaceAll(body, "$__interval_ms", strconv.FormatInt(interval.Milliseconds(), 10))
+	return strings.ReplaceAll(body, "$__interval", interval.String())
+}
+
+func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery, contentType string, body []byte) (*http.Response, error) {
 	u, err := url.Parse(c.ds.URL)
 	if err != nil {
 		return nil, err
@@ -148,7 +156,7 @@ func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body [
 	if method == http.MethodPost {
 		req, err = http.NewRequestWithContext(c.ctx, http.MethodPost, u.String(), bytes.NewBuffer(body))
 	} else {
-		req, err = http.NewRequestWithContext(c.ctx, http.MethodGet, u.String(), nil)
+		req, err = http.NewRequestWithContext(c.ctx, method, u.String(), nil)
 	}
 	if err != nil {
 		return nil, err
@@ -156,7 +164,7 @@ func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body [
 
 	c.logger.Debug("Executing request", "url", req.URL.String(), "method", method)
 
-	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Type", contentType)
 
 	start := time.Now()
 	defer func() {
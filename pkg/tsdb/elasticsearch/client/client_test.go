@@ -130,3 +130,179 @@ func createMultisearchForTest(t *testing.T, c Client) (*MultiSearchRequest, erro
 	})
 	return msb.Build()
 }
+
+func TestClient_AsyncSearch(t *testing.T) {
+	var requests []*http.Request
+	var requestBody []byte
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if r.Method == http.MethodPost {
+			buf, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			requestBody = buf
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost:
+			_, err := rw.Write([]byte(`{"id": "search-1", "is_partial": true, "is_running": true, "response": {"aggregations": {"2": {"buckets": []}}}}`))
+			require.NoError(t, err)
+		case r.Method == http.MethodGet && r.URL.Path == "/_async_search/expired":
+			rw.WriteHeader(http.StatusNotFound)
+			_, err := rw.Write([]byte(`{"error": {"type": "resource_not_found_exception", "reason": "expired"}, "status": 404}`))
+			require.NoError(t, err)
+		case r.Method == http.MethodGet:
+			_, err := rw.Write([]byte(`{"id": "search-1", "is_partial": false, "is_running": false, "response": {"hits": {"hits": []}}}`))
+			require.NoError(t, err)
+		default:
+			_, err := rw.Write([]byte(`{"acknowledged": true}`))
+			require.NoError(t, err)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	version, err := semver.NewVersion("8.0.0")
+	require.NoError(t, err)
+	ds := DatasourceInfo{
+		URL:                        ts.URL,
+		HTTPClient:                 ts.Client(),
+		Database:                   "[metrics-]YYYY.MM.DD",
+		ESVersion:                  version,
+		ConfiguredFields:           ConfiguredFields{TimeField: "testtime"},
+		Interval:                   "Daily",
+		MaxConcurrentShardRequests: 6,
+	}
+	timeRange := backend.TimeRange{
+		From: time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC),
+		To:   time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC),
+	}
+	c, err := NewClient(context.Background(), &ds, timeRange)
+	require.NoError(t, err)
+
+	t.Run("submit", func(t *testing.T) {
+		ms, err := createMultisearchForTest(t, c)
+		require.NoError(t, err)
+		res, err := c.SubmitAsyncSearch(ms.Requests[0], 2*time.Second)
+		require.NoError(t, err)
+
+		request := requests[len(requests)-1]
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, "/metrics-2018.05.15/_async_search", request.URL.Path)
+		assert.Equal(t, "2000ms", request.URL.Query().Get("wait_for_completion_timeout"))
+		assert.Equal(t, "1m", request.URL.Query().Get("keep_alive"))
+		assert.Equal(t, "6", request.URL.Query().Get("max_concurrent_shard_requests"))
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+
+		jBody, err := simplejson.NewJson(requestBody)
+		require.NoError(t, err)
+		assert.Equal(t, "15000*@hostname", jBody.GetPath("aggs", "2", "aggs", "1", "avg", "script").MustString())
+		assert.Equal(t, "15s", jBody.GetPath("aggs", "2", "date_histogram", "fixed_interval").MustString())
+
+		assert.Equal(t, "search-1", res.ID)
+		assert.True(t, res.IsRunning)
+		assert.NotNil(t, res.Response.Aggregations)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		res, err := c.GetAsyncSearch("search-1", time.Second)
+		require.NoError(t, err)
+
+		request := requests[len(requests)-1]
+		assert.Equal(t, http.MethodGet, request.Method)
+		assert.Equal(t, "/_async_search/search-1", request.URL.Path)
+		assert.Equal(t, "1000ms", request.URL.Query().Get("wait_for_completion_timeout"))
+		assert.False(t, res.IsRunning)
+		assert.NotNil(t, res.Response.Hits)
+	})
+
+	t.Run("get expired", func(t *testing.T) {
+		res, err := c.GetAsyncSearch("expired", time.Second)
+		require.NoError(t, err)
+		assert.False(t, res.IsRunning)
+		assert.Equal(t, "expired", res.Response.Error["reason"])
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, c.DeleteAsyncSearch("search-1"))
+
+		request := requests[len(requests)-1]
+		assert.Equal(t, http.MethodDelete, request.Method)
+		assert.Equal(t, "/_async_search/search-1", request.URL.Path)
+	})
+}
+
+func TestClient_ExecuteEQLSearch(t *testing.T) {
+	var request *http.Request
+	var requestBody []byte
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		request = r
+		buf, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requestBody = buf
+
+		rw.Header().Set("Content-Type", "application/json")
+		_, err = rw.Write([]byte(`{
+			"hits": {
+				"sequences": [
+					{
+						"join_keys": ["root"],
+						"events": [
+							{"_index": "logs-2018.05.15", "_id": "1", "_source": {"testtime": "2018-05-15T17:51:00Z", "event": {"category": "authentication"}}},
+							{"_index": "logs-2018.05.15", "_id": "2", "_source": {"testtime": "2018-05-15T17:52:00Z", "event": {"category": "process"}}}
+						]
+					}
+				]
+			}
+		}`))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(ts.Close)
+
+	version, err := semver.NewVersion("8.0.0")
+	require.NoError(t, err)
+	ds := DatasourceInfo{
+		URL:              ts.URL,
+		HTTPClient:       ts.Client(),
+		Database:         "[logs-]YYYY.MM.DD",
+		ESVersion:        version,
+		ConfiguredFields: ConfiguredFields{TimeField: "testtime"},
+		Interval:         "Daily",
+	}
+	timeRange := backend.TimeRange{
+		From: time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC),
+		To:   time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC),
+	}
+	c, err := NewClient(context.Background(), &ds, timeRange)
+	require.NoError(t, err)
+
+	res, err := c.ExecuteEQLSearch(&EQLSearchRequest{
+		Query:          `sequence by user.name [authentication where true] [process where true]`,
+		TimestampField: "testtime",
+		Size:           100,
+		From:           1526406600000,
+		To:             1526406900000,
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, request)
+	assert.Equal(t, http.MethodPost, request.Method)
+	assert.Equal(t, "/logs-2018.05.15/_eql/search", request.URL.Path)
+	assert.Equal(t, "ignore_unavailable=true", request.URL.RawQuery)
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+
+	jBody, err := simplejson.NewJson(requestBody)
+	require.NoError(t, err)
+	assert.Equal(t, `sequence by user.name [authentication where true] [process where true]`, jBody.Get("query").MustString())
+	assert.Equal(t, "testtime", jBody.Get("timestamp_field").MustString())
+	assert.Equal(t, 100, jBody.Get("size").MustInt())
+	assert.Equal(t, int64(1526406600000), jBody.GetPath("filter", "range", "testtime", "gte").MustInt64())
+	assert.Equal(t, int64(1526406900000), jBody.GetPath("filter", "range", "testtime", "lte").MustInt64())
+	assert.Equal(t, "epoch_millis", jBody.GetPath("filter", "range", "testtime", "format").MustString())
+
+	require.Len(t, res.Hits.Sequences, 1)
+	assert.Equal(t, []interface{}{"root"}, res.Hits.Sequences[0].JoinKeys)
+	require.Len(t, res.Hits.Sequences[0].Events, 2)
+	assert.Equal(t, "2", res.Hits.Sequences[0].Events[1]["_id"])
+}
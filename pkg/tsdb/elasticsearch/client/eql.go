@@ -0,0 +1,90 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EQLSearchRequest represents a search request of the EQL (Event Query Language) search API
+type EQLSearchRequest struct {
+	Query          string
+	TimestampField string
+	Size           int
+	// From and To are the bounds of the time range of the events, in epoch milliseconds
+	From int64
+	To   int64
+}
+
+// MarshalJSON returns the JSON encoding of the request, the events are filtered by the time range of the query
+func (r *EQLSearchRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"query":           r.Query,
+		"timestamp_field": r.TimestampField,
+		"size":            r.Size,
+		"filter": map[string]interface{}{
+			"range": map[string]interface{}{
+				r.TimestampField: map[string]interface{}{
+					"gte":    r.From,
+					"lte":    r.To,
+					"format": DateFormatEpochMS,
+				},
+			},
+		},
+	})
+}
+
+// EQLSearchResponse represents a response of the EQL search API. The event queries return events, the sequence
+// queries return sequences of events sharing the same join keys.
+type EQLSearchResponse struct {
+	Hits  EQLSearchHits          `json:"hits"`
+	Error map[string]interface{} `json:"error"`
+}
+
+// EQLSearchHits represents the hits of an EQL search response
+type EQLSearchHits struct {
+	Events    []map[string]interface{} `json:"events"`
+	Sequences []*EQLSequence           `json:"sequences"`
+}
+
+// EQLSequence represents a sequence of events matched by an EQL sequence query
+type EQLSequence struct {
+	JoinKeys []interface{}            `json:"join_keys"`
+	Events   []map[string]interface{} `json:"events"`
+}
+
+// ExecuteEQLSearch runs the search with the EQL search API on the indices of the time range
+func (c *baseClientImpl) ExecuteEQLSearch(r *EQLSearchRequest) (*EQLSearchResponse, error) {
+	c.logger.Debug("Executing EQL search")
+
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	uriPath := "_eql/search"
+	if len(c.indices) > 0 {
+		uriPath = strings.Join(c.indices, ",") + "/_eql/search"
+	}
+	res, err := c.executeRequest(http.MethodPost, uriPath, "ignore_unavailable=true", "application/json", body)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			c.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	c.logger.Debug("Received EQL search response", "code", res.StatusCode, "status", res.Status, "content-length", res.ContentLength)
+
+	start := time.Now()
+	var esr EQLSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&esr); err != nil {
+		return nil, err
+	}
+	c.logger.Debug("Decoded EQL search response", "took", time.Since(start))
+
+	return &esr, nil
+}
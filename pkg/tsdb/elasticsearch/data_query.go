@@ -19,6 +19,8 @@ const (
 type elasticsearchDataQuery struct {
 	client      es.Client
 	dataQueries []backend.DataQuery
+	// async is nil when the async search API is not used
+	async *asyncSearchOptions
 }
 
 var newElasticsearchDataQuery = func(client es.Client, dataQuery []backend.DataQuery) *elasticsearchDataQuery {
@@ -34,10 +36,40 @@ func (e *elasticsearchDataQuery) execute() (*backend.QueryDataResponse, error) {
 		return &backend.QueryDataResponse{}, err
 	}
 
-	ms := e.client.MultiSearch()
-
 	from := e.dataQueries[0].TimeRange.From.UnixNano() / int64(time.Millisecond)
 	to := e.dataQueries[0].TimeRange.To.UnixNano() / int64(time.Millisecond)
+
+	// the EQL queries can't be part of the multi search, they are run with the EQL search API
+	var searchQueries, eqlQueries []*Query
+	for _, q := range queries {
+		if isEQLQuery(q) {
+			eqlQueries = append(eqlQueries, q)
+		} else {
+			searchQueries = append(searchQueries, q)
+		}
+	}
+
+	result := &backend.QueryDataResponse{Responses: backend.Responses{}}
+	if len(searchQueries) > 0 {
+		result, err = e.executeSearch(searchQueries, from, to)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for _, q := range eqlQueries {
+		res, err := e.executeEQLQuery(q, from, to)
+		if err != nil {
+			return &backend.QueryDataResponse{}, err
+		}
+		result.Responses[q.RefID] = res
+	}
+	return result, nil
+}
+
+func (e *elasticsearchDataQuery) executeSearch(queries []*Query, from, to int64) (*backend.QueryDataResponse, error) {
+	ms := e.client.MultiSearch()
+
 	for _, q := range queries {
 		if err := e.processQuery(q, ms, from, to); err != nil {
 			return &backend.QueryDataResponse{}, err
@@ -49,6 +81,10 @@ func (e *elasticsearchDataQuery) execute() (*backend.QueryDataResponse, error) {
 		return &backend.QueryDataResponse{}, err
 	}
 
+	if e.async != nil {
+		return e.executeAsync(req, queries)
+	}
+
 	res, err := e.client.ExecuteMultisearch(req)
 	if err != nil {
 		return &backend.QueryDataResponse{}, err
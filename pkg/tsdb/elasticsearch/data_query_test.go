@@ -2,6 +2,8 @@ package elasticsearch
 
 import (
 	"encoding/json"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -1692,6 +1694,15 @@ type fakeClient struct {
 	multiSearchError    error
 	builder             *es.MultiSearchRequestBuilder
 	multisearchRequests []*es.MultiSearchRequest
+
+	mu                   sync.Mutex
+	asyncSearchResponses []*es.AsyncSearchResponse
+	asyncSearchRequests  []*es.SearchRequest
+	asyncSearchPolls     []string
+	deletedAsyncSearches []string
+
+	eqlSearchResponse *es.EQLSearchResponse
+	eqlSearchRequests []*es.EQLSearchRequest
 }
 
 func newFakeClient() *fakeClient {
@@ -1726,6 +1737,44 @@ func (c *fakeClient) MultiSearch() *es.MultiSearchRequestBuilder {
 	return c.builder
 }
 
+func (c *fakeClient) SubmitAsyncSearch(r *es.SearchRequest, waitForCompletion time.Duration) (*es.AsyncSearchResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.asyncSearchRequests = append(c.asyncSearchRequests, r)
+	return c.nextAsyncSearchResponse()
+}
+
+func (c *fakeClient) GetAsyncSearch(id string, waitForCompletion time.Duration) (*es.AsyncSearchResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.asyncSearchPolls = append(c.asyncSearchPolls, id)
+	return c.nextAsyncSearchResponse()
+}
+
+func (c *fakeClient) DeleteAsyncSearch(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deletedAsyncSearches = append(c.deletedAsyncSearches, id)
+	return nil
+}
+
+func (c *fakeClient) ExecuteEQLSearch(r *es.EQLSearchRequest) (*es.EQLSearchResponse, error) {
+	c.eqlSearchRequests = append(c.eqlSearchRequests, r)
+	if c.eqlSearchResponse == nil {
+		return nil, errors.New("unexpected EQL search request")
+	}
+	return c.eqlSearchResponse, nil
+}
+
+func (c *fakeClient) nextAsyncSearchResponse() (*es.AsyncSearchResponse, error) {
+	if len(c.asyncSearchResponses) == 0 {
+		return nil, errors.New("unexpected async search request")
+	}
+	res := c.asyncSearchResponses[0]
+	c.asyncSearchResponses = c.asyncSearchResponses[1:]
+	return res, nil
+}
+
 func newDataQuery(body string) (backend.QueryDataRequest, error) {
 	return backend.QueryDataRequest{
 		Queries: []backend.DataQuery{
@@ -6,10 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 
 	"github.com/grafana/grafana/pkg/infra/httpclient"
@@ -19,9 +21,22 @@ import (
 
 var eslog = log.New("tsdb.elasticsearch")
 
+const (
+	// headerFromAlert is set on the requests of the alerts
+	headerFromAlert = "FromAlert"
+	// headerFromExpression is set on the requests of the queries of the server side expressions
+	headerFromExpression = "FromExpression"
+)
+
+var (
+	_ backend.QueryDataHandler = (*Service)(nil)
+	_ backend.StreamHandler    = (*Service)(nil)
+)
+
 type Service struct {
 	httpClientProvider httpclient.Provider
 	im                 instancemgmt.InstanceManager
+	asyncSearches      *asyncSearchStore
 }
 
 func ProvideService(httpClientProvider httpclient.Provider) *Service {
@@ -30,6 +45,7 @@ func ProvideService(httpClientProvider httpclient.Provider) *Service {
 	return &Service{
 		im:                 datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
 		httpClientProvider: httpClientProvider,
+		asyncSearches:      newAsyncSearchStore(),
 	}
 }
 
@@ -39,11 +55,30 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		return &backend.QueryDataResponse{}, err
 	}
 
-	return queryData(ctx, req.Queries, dsInfo)
+	var async *asyncSearchOptions
+	if dsInfo.AsyncSearchThreshold > 0 && len(req.Queries) > 0 {
+		async = &asyncSearchOptions{
+			store:         s.asyncSearches,
+			orgID:         req.PluginContext.OrgID,
+			datasourceUID: req.PluginContext.DataSourceInstanceSettings.UID,
+			dsInfo:        dsInfo,
+			timeRange:     req.Queries[0].TimeRange,
+			wait:          cannotStream(req),
+		}
+	}
+
+	return queryData(ctx, req.Queries, dsInfo, async)
+}
+
+// cannotStream returns true when nobody can subscribe to the channels streaming the results of the request: the alerts,
+// the expressions, which need the complete results to compute theirs, and the requests without a signed in user
+func cannotStream(req *backend.QueryDataRequest) bool {
+	return req.Headers[headerFromAlert] == "true" || req.Headers[headerFromExpression] == "true" ||
+		req.PluginContext.User == nil
 }
 
 // separate function to allow testing the whole transformation and query flow
-func queryData(ctx context.Context, queries []backend.DataQuery, dsInfo *es.DatasourceInfo) (*backend.QueryDataResponse, error) {
+func queryData(ctx context.Context, queries []backend.DataQuery, dsInfo *es.DatasourceInfo, async *asyncSearchOptions) (*backend.QueryDataResponse, error) {
 	// Support for version after their end-of-life (currently <7.10.0) was removed
 	lastSupportedVersion, _ := semver.NewVersion("7.10.0")
 	if dsInfo.ESVersion.LessThan(lastSupportedVersion) {
@@ -59,6 +94,7 @@ func queryData(ctx context.Context, queries []backend.DataQuery, dsInfo *es.Data
 		return &backend.QueryDataResponse{}, err
 	}
 	query := newElasticsearchDataQuery(client, queries)
+	query.async = async
 	return query.execute()
 }
 
@@ -142,6 +178,14 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			xpack = false
 		}
 
+		var asyncSearchThreshold time.Duration
+		if threshold, ok := jsonData["asyncSearchThreshold"].(string); ok && threshold != "" {
+			asyncSearchThreshold, err = gtime.ParseDuration(threshold)
+			if err != nil {
+				return nil, fmt.Errorf("invalid async search threshold %q: %w", threshold, err)
+			}
+		}
+
 		configuredFields := es.ConfiguredFields{
 			TimeField:       timeField,
 			LogLevelField:   logLevelField,
@@ -160,6 +204,7 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			TimeInterval:               timeInterval,
 			IncludeFrozen:              includeFrozen,
 			XPack:                      xpack,
+			AsyncSearchThreshold:       asyncSearchThreshold,
 		}
 		return model, nil
 	}
@@ -3,11 +3,13 @@ package elasticsearch
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/infra/httpclient"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 )
 
 type datasourceInfo struct {
@@ -16,6 +18,7 @@ type datasourceInfo struct {
 	MaxConcurrentShardRequests int64       `json:"maxConcurrentShardRequests"`
 	Interval                   string      `json:"interval"`
 	TimeInterval               string      `json:"timeInterval"`
+	AsyncSearchThreshold       string      `json:"asyncSearchThreshold,omitempty"`
 }
 
 func TestCoerceVersion(t *testing.T) {
@@ -190,4 +193,71 @@ func TestNewInstanceSettings(t *testing.T) {
 			require.EqualError(t, err, "elasticsearch time field name is required")
 		})
 	})
+	t.Run("asyncSearchThreshold", func(t *testing.T) {
+		t.Run("is parsed", func(t *testing.T) {
+			dsInfo := datasourceInfo{
+				ESVersion:                  "7.10.0",
+				TimeField:                  "@timestamp",
+				MaxConcurrentShardRequests: 5,
+				AsyncSearchThreshold:       "10s",
+			}
+
+			settingsJSON, err := json.Marshal(dsInfo)
+			require.NoError(t, err)
+
+			dsSettings := backend.DataSourceInstanceSettings{
+				JSONData: json.RawMessage(settingsJSON),
+			}
+
+			instance, err := newInstanceSettings(httpclient.NewProvider())(dsSettings)
+			require.NoError(t, err)
+			require.Equal(t, 10*time.Second, instance.(es.DatasourceInfo).AsyncSearchThreshold)
+		})
+
+		t.Run("is invalid", func(t *testing.T) {
+			dsInfo := datasourceInfo{
+				ESVersion:                  "7.10.0",
+				TimeField:                  "@timestamp",
+				MaxConcurrentShardRequests: 5,
+				AsyncSearchThreshold:       "soon",
+			}
+
+			settingsJSON, err := json.Marshal(dsInfo)
+			require.NoError(t, err)
+
+			dsSettings := backend.DataSourceInstanceSettings{
+				JSONData: json.RawMessage(settingsJSON),
+			}
+
+			_, err = newInstanceSettings(httpclient.NewProvider())(dsSettings)
+			require.ErrorContains(t, err, "invalid async search threshold")
+		})
+	})
+}
+
+func TestCannotStream(t *testing.T) {
+	user := &backend.User{Login: "user"}
+	testCases := []struct {
+		desc string
+		req  *backend.QueryDataRequest
+		exp  bool
+	}{
+		{desc: "signed in user", req: &backend.QueryDataRequest{PluginContext: backend.PluginContext{User: user}}, exp: false},
+		{
+			desc: "alert",
+			req:  &backend.QueryDataRequest{PluginContext: backend.PluginContext{User: user}, Headers: map[string]string{headerFromAlert: "true"}},
+			exp:  true,
+		},
+		{
+			desc: "expression",
+			req:  &backend.QueryDataRequest{PluginContext: backend.PluginContext{User: user}, Headers: map[string]string{headerFromExpression: "true"}},
+			exp:  true,
+		},
+		{desc: "without user", req: &backend.QueryDataRequest{}, exp: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.exp, cannotStream(tc.req))
+		})
+	}
 }
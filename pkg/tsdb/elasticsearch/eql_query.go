@@ -0,0 +1,99 @@
+package elasticsearch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+// eqlQueryType is the query type of the EQL (Event Query Language) queries
+const eqlQueryType = "eql"
+
+func isEQLQuery(query *Query) bool {
+	return query.QueryType == eqlQueryType
+}
+
+// executeEQLQuery runs the query with the EQL search API, which doesn't support multi searches. The errors of
+// Elasticsearch, like the syntax errors of the query, are returned in the response of the query.
+func (e *elasticsearchDataQuery) executeEQLQuery(q *Query, from, to int64) (backend.DataResponse, error) {
+	if strings.TrimSpace(q.RawQuery) == "" {
+		return backend.DataResponse{Error: errors.New("invalid query, missing EQL query")}, nil
+	}
+
+	configuredFields := e.client.GetConfiguredFields()
+	res, err := e.client.ExecuteEQLSearch(&es.EQLSearchRequest{
+		Query:          q.RawQuery,
+		TimestampField: configuredFields.TimeField,
+		Size:           defaultSize,
+		From:           from,
+		To:             to,
+	})
+	if err != nil {
+		return backend.DataResponse{}, err
+	}
+	if res.Error != nil {
+		return backend.DataResponse{Error: errors.New(getErrorFromElasticResponse(&es.SearchResponse{Error: res.Error}))}, nil
+	}
+
+	return backend.DataResponse{Frames: processEQLResponse(res, configuredFields)}, nil
+}
+
+// processEQLResponse returns a frame with the events of the event queries, and a frame for every sequence of the
+// sequence queries, named by the join keys that correlate its events
+func processEQLResponse(res *es.EQLSearchResponse, configuredFields es.ConfiguredFields) data.Frames {
+	if len(res.Hits.Sequences) == 0 {
+		return data.Frames{newEQLEventsFrame("", res.Hits.Events, configuredFields)}
+	}
+
+	frames := make(data.Frames, 0, len(res.Hits.Sequences))
+	for i, sequence := range res.Hits.Sequences {
+		name := fmt.Sprintf("Sequence %d", i+1)
+		if len(sequence.JoinKeys) > 0 {
+			keys := make([]string, len(sequence.JoinKeys))
+			for j, key := range sequence.JoinKeys {
+				keys[j] = fmt.Sprint(key)
+			}
+			name += ": " + strings.Join(keys, ", ")
+		}
+
+		frame := newEQLEventsFrame(name, sequence.Events, configuredFields)
+		frame.Meta = &data.FrameMeta{
+			Custom: map[string]interface{}{
+				"joinKeys": sequence.JoinKeys,
+			},
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// newEQLEventsFrame returns a frame with a row for every event, like the frames of the raw data queries
+func newEQLEventsFrame(name string, events []map[string]interface{}, configuredFields es.ConfiguredFields) *data.Frame {
+	propNames := make(map[string]bool)
+	docs := make([]map[string]interface{}, len(events))
+
+	for i, event := range events {
+		doc := map[string]interface{}{
+			"_id":    event["_id"],
+			"_index": event["_index"],
+		}
+		if source, ok := event["_source"].(map[string]interface{}); ok {
+			for k, v := range flatten(source) {
+				doc[k] = v
+			}
+		}
+
+		for key := range doc {
+			propNames[key] = true
+		}
+		docs[i] = doc
+	}
+
+	sortedPropNames := sortPropNames(propNames, configuredFields, false)
+	return data.NewFrame(name, processDocsToDataFrameFields(docs, sortedPropNames, configuredFields)...)
+}
@@ -0,0 +1,141 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+func TestExecuteEQLQuery(t *testing.T) {
+	from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
+	to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
+
+	t.Run("Should run the EQL queries with the EQL search API", func(t *testing.T) {
+		c := newFakeClient()
+		c.eqlSearchResponse = &es.EQLSearchResponse{Hits: es.EQLSearchHits{Events: []map[string]interface{}{
+			{"_id": "1", "_index": "logs", "_source": map[string]interface{}{
+				"@timestamp": "2018-05-15T17:51:00Z",
+				"process":    map[string]interface{}{"name": "cmd.exe"},
+			}},
+			{"_id": "2", "_index": "logs", "_source": map[string]interface{}{
+				"@timestamp": "2018-05-15T17:52:00Z",
+				"process":    map[string]interface{}{"name": "regsvr32.exe"},
+			}},
+		}}}
+
+		res, err := executeEQLDataQuery(c, `process where process.name : ("cmd.exe", "regsvr32.exe")`, from, to)
+		require.NoError(t, err)
+
+		require.Empty(t, c.multisearchRequests)
+		require.Len(t, c.eqlSearchRequests, 1)
+		r := c.eqlSearchRequests[0]
+		assert.Equal(t, `process where process.name : ("cmd.exe", "regsvr32.exe")`, r.Query)
+		assert.Equal(t, "@timestamp", r.TimestampField)
+		assert.Equal(t, defaultSize, r.Size)
+		assert.Equal(t, from.UnixMilli(), r.From)
+		assert.Equal(t, to.UnixMilli(), r.To)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, 2, frames[0].Rows())
+		names := make([]string, len(frames[0].Fields))
+		for i, field := range frames[0].Fields {
+			names[i] = field.Name
+		}
+		assert.Equal(t, []string{"@timestamp", "_id", "_index", "process.name"}, names)
+		assert.Equal(t, "regsvr32.exe", *frames[0].Fields[3].At(1).(*string))
+	})
+
+	t.Run("Should return a frame for every sequence", func(t *testing.T) {
+		c := newFakeClient()
+		c.eqlSearchResponse = &es.EQLSearchResponse{Hits: es.EQLSearchHits{Sequences: []*es.EQLSequence{
+			{
+				JoinKeys: []interface{}{"root", "host-1"},
+				Events: []map[string]interface{}{
+					{"_id": "1", "_source": map[string]interface{}{"@timestamp": "2018-05-15T17:51:00Z", "event": map[string]interface{}{"category": "authentication"}}},
+					{"_id": "2", "_source": map[string]interface{}{"@timestamp": "2018-05-15T17:52:00Z", "event": map[string]interface{}{"category": "process"}}},
+				},
+			},
+			{
+				JoinKeys: []interface{}{"admin", "host-2"},
+				Events: []map[string]interface{}{
+					{"_id": "3", "_source": map[string]interface{}{"@timestamp": "2018-05-15T17:53:00Z", "event": map[string]interface{}{"category": "authentication"}}},
+					{"_id": "4", "_source": map[string]interface{}{"@timestamp": "2018-05-15T17:54:00Z", "event": map[string]interface{}{"category": "process"}}},
+				},
+			},
+		}}}
+
+		res, err := executeEQLDataQuery(c, `sequence by user.name, host.name [authentication where true] [process where true]`, from, to)
+		require.NoError(t, err)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 2)
+		assert.Equal(t, "Sequence 1: root, host-1", frames[0].Name)
+		assert.Equal(t, "Sequence 2: admin, host-2", frames[1].Name)
+		assert.Equal(t, []interface{}{"admin", "host-2"}, frames[1].Meta.Custom.(map[string]interface{})["joinKeys"])
+		require.Equal(t, 2, frames[1].Rows())
+		assert.Equal(t, time.Date(2018, 5, 15, 17, 53, 0, 0, time.UTC), *frames[1].Fields[0].At(0).(*time.Time))
+		assert.Equal(t, "process", *frames[1].Fields[3].At(1).(*string))
+	})
+
+	t.Run("Should return the errors of Elasticsearch in the response of the query", func(t *testing.T) {
+		c := newFakeClient()
+		c.eqlSearchResponse = &es.EQLSearchResponse{Error: map[string]interface{}{
+			"root_cause": []interface{}{map[string]interface{}{"reason": "line 1:9: unknown field [proces.name]"}},
+		}}
+
+		res, err := executeEQLDataQuery(c, `process where proces.name == "cmd.exe"`, from, to)
+		require.NoError(t, err)
+		require.EqualError(t, res.Responses["A"].Error, "line 1:9: unknown field [proces.name]")
+	})
+
+	t.Run("Should reject the EQL queries without query", func(t *testing.T) {
+		c := newFakeClient()
+		res, err := executeEQLDataQuery(c, "", from, to)
+		require.NoError(t, err)
+		require.EqualError(t, res.Responses["A"].Error, "invalid query, missing EQL query")
+		require.Empty(t, c.eqlSearchRequests)
+	})
+
+	t.Run("Should run the other queries with the multi search API", func(t *testing.T) {
+		c := newFakeClient()
+		c.eqlSearchResponse = &es.EQLSearchResponse{}
+		timeRange := backend.TimeRange{From: from, To: to}
+		query := newElasticsearchDataQuery(c, []backend.DataQuery{
+			{RefID: "A", QueryType: eqlQueryType, JSON: json.RawMessage(`{"query": "any where true"}`), TimeRange: timeRange},
+			{RefID: "B", JSON: json.RawMessage(`{"metrics": [{"type": "raw_data", "id": "1"}]}`), TimeRange: timeRange},
+		})
+		c.multiSearchResponse = &es.MultiSearchResponse{Responses: []*es.SearchResponse{{Hits: &es.SearchResponseHits{}}}}
+
+		res, err := query.execute()
+		require.NoError(t, err)
+
+		require.Len(t, c.eqlSearchRequests, 1)
+		require.Len(t, c.multisearchRequests, 1)
+		require.Len(t, c.multisearchRequests[0].Requests, 1)
+		assert.Contains(t, res.Responses, "A")
+		assert.Contains(t, res.Responses, "B")
+	})
+}
+
+func executeEQLDataQuery(c es.Client, eql string, from, to time.Time) (*backend.QueryDataResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{"query": eql})
+	if err != nil {
+		return nil, err
+	}
+	query := newElasticsearchDataQuery(c, []backend.DataQuery{
+		{
+			RefID:     "A",
+			QueryType: eqlQueryType,
+			JSON:      body,
+			TimeRange: backend.TimeRange{From: from, To: to},
+		},
+	})
+	return query.execute()
+}
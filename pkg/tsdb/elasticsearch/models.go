@@ -16,6 +16,8 @@ type Query struct {
 	IntervalMs    int64
 	RefID         string
 	MaxDataPoints int64
+	// QueryType is eql for the EQL queries, their query is an EQL expression instead of a Lucene query
+	QueryType string
 }
 
 // BucketAgg represents a bucket aggregation of the time series query model of the datasource
@@ -39,6 +39,7 @@ func parseQuery(tsdbQuery []backend.DataQuery) ([]*Query, error) {
 			IntervalMs:    intervalMs,
 			RefID:         q.RefID,
 			MaxDataPoints: q.MaxDataPoints,
+			QueryType:     q.QueryType,
 		})
 	}
 
@@ -139,7 +139,7 @@ func queryDataTestWithResponseCode(queriesBytes []byte, responseStatusCode int,
 		return nil
 	})
 
-	result, err := queryData(context.Background(), queries, dsInfo)
+	result, err := queryData(context.Background(), queries, dsInfo, nil)
 	if err != nil {
 		return queryDataTestResult{}, err
 	}
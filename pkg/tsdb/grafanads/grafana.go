@@ -15,6 +15,8 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/queryinsights"
 	"github.com/grafana/grafana/pkg/services/searchV2"
 	"github.com/grafana/grafana/pkg/services/store"
 	"github.com/grafana/grafana/pkg/tsdb/testdatasource"
@@ -51,15 +53,16 @@ var (
 	)
 )
 
-func ProvideService(search searchV2.SearchService, store store.StorageService) *Service {
-	return newService(search, store)
+func ProvideService(search searchV2.SearchService, store store.StorageService, insights queryinsights.Service) *Service {
+	return newService(search, store, insights)
 }
 
-func newService(search searchV2.SearchService, store store.StorageService) *Service {
+func newService(search searchV2.SearchService, store store.StorageService, insights queryinsights.Service) *Service {
 	s := &Service{
-		search: search,
-		store:  store,
-		log:    log.New("grafanads"),
+		search:   search,
+		store:    store,
+		insights: insights,
+		log:      log.New("grafanads"),
 	}
 
 	return s
@@ -67,9 +70,10 @@ func newService(search searchV2.SearchService, store store.StorageService) *Serv
 
 // Service exists regardless of user settings
 type Service struct {
-	search searchV2.SearchService
-	store  store.StorageService
-	log    log.Logger
+	search   searchV2.SearchService
+	store    store.StorageService
+	insights queryinsights.Service
+	log      log.Logger
 }
 
 func DataSourceModel(orgId int64) *datasources.DataSource {
@@ -97,6 +101,8 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 			response.Responses[q.RefID] = s.doReadQuery(ctx, q)
 		case queryTypeSearch:
 			response.Responses[q.RefID] = s.doSearchQuery(ctx, req, q)
+		case queryTypeQueryInsights:
+			response.Responses[q.RefID] = s.doQueryInsightsQuery(req, q)
 		default:
 			response.Responses[q.RefID] = backend.DataResponse{
 				Error: fmt.Errorf("unknown query type"),
@@ -197,6 +203,50 @@ func (s *Service) doSearchQuery(ctx context.Context, req *backend.QueryDataReque
 	return *s.search.DoDashboardQuery(ctx, req.PluginContext.User, req.PluginContext.OrgID, m.Search)
 }
 
+func (s *Service) doQueryInsightsQuery(req *backend.QueryDataRequest, query backend.DataQuery) backend.DataResponse {
+	if s.insights == nil {
+		return backend.DataResponse{Error: fmt.Errorf("query insights are not available")}
+	}
+	if req.PluginContext.User == nil || req.PluginContext.User.Role != string(org.RoleAdmin) {
+		return backend.DataResponse{Error: fmt.Errorf("query insights require the organization admin role")}
+	}
+
+	q := queryInsightsQueryModel{}
+	if err := json.Unmarshal(query.JSON, &q); err != nil {
+		return backend.DataResponse{Error: err}
+	}
+
+	insights := s.insights.GetDatasourceInsights(req.PluginContext.OrgID, q.Limit)
+	dsFrame := data.NewFrame("datasources",
+		data.NewField("uid", nil, []string{}),
+		data.NewField("name", nil, []string{}),
+		data.NewField("type", nil, []string{}),
+		data.NewField("queries", nil, []int64{}),
+		data.NewField("errors", nil, []int64{}),
+		data.NewField("error_rate", nil, []float64{}).SetConfig(&data.FieldConfig{Unit: "percentunit"}),
+		data.NewField("latency_p50", nil, []float64{}).SetConfig(&data.FieldConfig{Unit: "ms"}),
+		data.NewField("latency_p90", nil, []float64{}).SetConfig(&data.FieldConfig{Unit: "ms"}),
+		data.NewField("latency_p99", nil, []float64{}).SetConfig(&data.FieldConfig{Unit: "ms"}),
+		data.NewField("total_time", nil, []float64{}).SetConfig(&data.FieldConfig{Unit: "ms"}),
+	)
+	dashFrame := data.NewFrame("dashboards",
+		data.NewField("datasource_uid", nil, []string{}),
+		data.NewField("dashboard_uid", nil, []string{}),
+		data.NewField("queries", nil, []int64{}),
+		data.NewField("errors", nil, []int64{}),
+		data.NewField("total_time", nil, []float64{}).SetConfig(&data.FieldConfig{Unit: "ms"}),
+	)
+	for _, ds := range insights {
+		dsFrame.AppendRow(ds.UID, ds.Name, ds.Type, ds.Queries, ds.Errors, ds.ErrorRate,
+			ds.LatencyP50Ms, ds.LatencyP90Ms, ds.LatencyP99Ms, ds.TotalTimeMs)
+		for _, dash := range ds.TopDashboards {
+			dashFrame.AppendRow(ds.UID, dash.UID, dash.Queries, dash.Errors, dash.TotalTimeMs)
+		}
+	}
+
+	return backend.DataResponse{Frames: data.Frames{dsFrame, dashFrame}}
+}
+
 type requestModel struct {
 	QueryType string                  `json:"queryType"`
 	Search    searchV2.DashboardQuery `json:"search,omitempty"`
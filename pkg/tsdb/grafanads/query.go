@@ -14,6 +14,10 @@ const (
 	// currently only .csv files are supported,
 	// other file types will eventually be supported (parquet, etc)
 	queryTypeRead = "read"
+
+	// queryTypeQueryInsights returns the query statistics of the datasources of the organization,
+	// only organization admins can run it
+	queryTypeQueryInsights = "queryInsights"
 )
 
 type listQueryModel struct {
@@ -22,3 +26,8 @@ type listQueryModel struct {
 type readQueryModel struct {
 	Path string `json:"path"`
 }
+
+type queryInsightsQueryModel struct {
+	// Limit is the number of dashboards returned for every datasource
+	Limit int `json:"limit"`
+}
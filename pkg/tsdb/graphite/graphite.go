@@ -213,6 +213,13 @@ func (s *Service) processQueries(logger log.Logger, queries []backend.DataQuery)
 		} else {
 			currTarget = model.Get(TargetModelField).MustString()
 		}
+		if currTarget == "" {
+			tagTarget, err := queryTagTarget(query)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			currTarget = tagTarget
+		}
 		if currTarget == "" {
 			logger.Debug("graphite", "empty query target", model)
 			emptyQueries = append(emptyQueries, fmt.Sprintf("Query: %v has no target", model))
@@ -0,0 +1,149 @@
+package graphite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// the resources proxied to the tag autocomplete endpoints of Graphite, with the name of the prefix parameter of each
+var tagAutoCompleteResources = map[string]string{
+	"tags/autoComplete/tags":   "tagPrefix",
+	"tags/autoComplete/values": "valuePrefix",
+}
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return err
+	}
+	return s.callResource(ctx, req, sender, dsInfo)
+}
+
+func (s *Service) callResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender, dsInfo *datasourceInfo) error {
+	endpoint := strings.Trim(req.Path, "/")
+	prefixParam, ok := tagAutoCompleteResources[endpoint]
+	if !ok {
+		return sender.Send(&backend.CallResourceResponse{Status: http.StatusNotFound})
+	}
+	if req.Method != http.MethodGet {
+		return sender.Send(&backend.CallResourceResponse{Status: http.StatusMethodNotAllowed})
+	}
+
+	reqURL, err := url.Parse(req.URL)
+	if err != nil {
+		return err
+	}
+	params, err := tagAutoCompleteParams(reqURL.Query(), prefixParam, endpoint == "tags/autoComplete/values")
+	if err != nil {
+		return sendResourceError(sender, http.StatusBadRequest, err)
+	}
+
+	tags, status, err := s.tagAutoComplete(ctx, dsInfo, endpoint, params)
+	if err != nil {
+		return sendResourceError(sender, status, err)
+	}
+	body, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+// tagAutoCompleteParams returns the parameters of the autocomplete request sent to Graphite. The expressions filter
+// the series whose tags are completed, the tags of the values are required.
+func tagAutoCompleteParams(query url.Values, prefixParam string, values bool) (url.Values, error) {
+	params := url.Values{}
+	for _, expr := range query["expr"] {
+		if expr = strings.TrimSpace(expr); expr != "" {
+			params.Add("expr", expr)
+		}
+	}
+	if values {
+		tag := strings.TrimSpace(query.Get("tag"))
+		if tag == "" {
+			return nil, errors.New("missing tag")
+		}
+		params.Set("tag", tag)
+	}
+	if prefix := query.Get(prefixParam); prefix != "" {
+		params.Set(prefixParam, prefix)
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if n, err := strconv.Atoi(limit); err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid limit %q", limit)
+		}
+		params.Set("limit", limit)
+	}
+	for _, name := range []string{"from", "until"} {
+		if value := query.Get(name); value != "" {
+			params.Set(name, value)
+		}
+	}
+	return params, nil
+}
+
+// tagAutoComplete returns the tags or the tag values completed by Graphite, with the status of the response sent to
+// the client when it fails
+func (s *Service) tagAutoComplete(ctx context.Context, dsInfo *datasourceInfo, endpoint string, params url.Values) ([]string, int, error) {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	u.Path = path.Join(u.Path, endpoint)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	res, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return nil, http.StatusBadGateway, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, http.StatusBadGateway, err
+	}
+	if res.StatusCode/100 != 2 {
+		logger.FromContext(ctx).Info("Tag autocomplete request failed", "status", res.Status, "body", string(body))
+		return nil, res.StatusCode, fmt.Errorf("request failed, status: %s", res.Status)
+	}
+
+	tags := []string{}
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("failed to parse the tags: %w", err)
+	}
+	return tags, http.StatusOK, nil
+}
+
+func sendResourceError(sender backend.CallResourceResponseSender, status int, err error) error {
+	body, marshalErr := json.Marshal(map[string]string{"message": err.Error()})
+	if marshalErr != nil {
+		return marshalErr
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
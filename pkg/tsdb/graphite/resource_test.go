@@ -0,0 +1,72 @@
+package graphite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSender struct {
+	res *backend.CallResourceResponse
+}
+
+func (s *fakeSender) Send(res *backend.CallResourceResponse) error {
+	s.res = res
+	return nil
+}
+
+func TestTagAutoCompleteResources(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/graphite/tags/autoComplete/tags":
+			assert.Equal(t, []string{"name=cpu", "dc=eu"}, r.URL.Query()["expr"])
+			assert.Equal(t, "ho", r.URL.Query().Get("tagPrefix"))
+			assert.Equal(t, "10", r.URL.Query().Get("limit"))
+			_, _ = w.Write([]byte(`["host", "hostname"]`))
+		case "/graphite/tags/autoComplete/values":
+			assert.Equal(t, "host", r.URL.Query().Get("tag"))
+			assert.Equal(t, "web", r.URL.Query().Get("valuePrefix"))
+			assert.Equal(t, "-1h", r.URL.Query().Get("from"))
+			_, _ = w.Write([]byte(`["web-1", "web-2"]`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL + "/graphite"}
+	call := func(path string, query string) *backend.CallResourceResponse {
+		sender := &fakeSender{}
+		err := service.callResource(context.Background(), &backend.CallResourceRequest{
+			Method: http.MethodGet,
+			Path:   path,
+			URL:    path + "?" + query,
+		}, sender, dsInfo)
+		require.NoError(t, err)
+		return sender.res
+	}
+
+	res := call("tags/autoComplete/tags", "expr=name%3Dcpu&expr=&expr=dc%3Deu&tagPrefix=ho&limit=10")
+	require.Equal(t, http.StatusOK, res.Status)
+	assert.JSONEq(t, `["host", "hostname"]`, string(res.Body))
+
+	res = call("tags/autoComplete/values", "tag=host&valuePrefix=web&from=-1h")
+	require.Equal(t, http.StatusOK, res.Status)
+	assert.JSONEq(t, `["web-1", "web-2"]`, string(res.Body))
+
+	res = call("tags/autoComplete/values", "valuePrefix=web")
+	assert.Equal(t, http.StatusBadRequest, res.Status)
+	assert.JSONEq(t, `{"message": "missing tag"}`, string(res.Body))
+
+	res = call("tags/autoComplete/tags", "limit=all")
+	assert.Equal(t, http.StatusBadRequest, res.Status)
+
+	res = call("render", "target=a.b")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+}
@@ -0,0 +1,107 @@
+package graphite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/tsdb/graphite/kinds/dataquery"
+)
+
+var functionNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+var tagOperators = map[dataquery.GraphiteTagOperator]bool{
+	dataquery.GraphiteTagOperatorEqual:    true,
+	dataquery.GraphiteTagOperatorNotEqual: true,
+	dataquery.GraphiteTagOperatorMatch:    true,
+	dataquery.GraphiteTagOperatorNotMatch: true,
+}
+
+// queryTagTarget returns the target generated from the tag query of a query without target, it is empty when the
+// query has no tag query
+func queryTagTarget(query backend.DataQuery) (string, error) {
+	model := &dataquery.GraphiteDataQuery{}
+	if err := json.Unmarshal(query.JSON, model); err != nil {
+		return "", err
+	}
+	if model.TagQuery == nil {
+		return "", nil
+	}
+	target, err := tagQueryTarget(model.TagQuery)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag query of the query %s: %w", query.RefID, err)
+	}
+	return target, nil
+}
+
+// tagQueryTarget generates the target of a tag-based query, the seriesByTag function of its tag expressions wrapped
+// by its functions. Graphite rejects the seriesByTag functions whose expressions all match the empty value, as they
+// would select every series.
+func tagQueryTarget(q *dataquery.GraphiteTagQuery) (string, error) {
+	if len(q.Expressions) == 0 {
+		return "", errors.New("the tag query has no tag expression")
+	}
+
+	expressions := make([]string, 0, len(q.Expressions))
+	selective := false
+	for _, e := range q.Expressions {
+		key := strings.TrimSpace(e.Key)
+		if key == "" {
+			return "", errors.New("a tag expression has no tag")
+		}
+		if !tagOperators[e.Operator] {
+			return "", fmt.Errorf("invalid operator %q of the tag expression of %q", e.Operator, key)
+		}
+		if !matchesEmptyValue(e) {
+			selective = true
+		}
+		expressions = append(expressions, quoteParam(key+string(e.Operator)+e.Value))
+	}
+	if !selective {
+		return "", errors.New("at least one tag expression must match a non-empty value")
+	}
+
+	target := "seriesByTag(" + strings.Join(expressions, ", ") + ")"
+	for _, f := range q.Functions {
+		if !functionNameRegexp.MatchString(f.Name) {
+			return "", fmt.Errorf("invalid function name %q", f.Name)
+		}
+		params := []string{target}
+		for _, p := range f.Params {
+			params = append(params, renderParam(p))
+		}
+		target = f.Name + "(" + strings.Join(params, ", ") + ")"
+	}
+	return target, nil
+}
+
+// matchesEmptyValue returns whether a tag expression matches the series without the tag. The regular expressions Go
+// can't compile are left to Graphite.
+func matchesEmptyValue(e dataquery.GraphiteTagExpression) bool {
+	switch e.Operator {
+	case dataquery.GraphiteTagOperatorEqual:
+		return e.Value == ""
+	case dataquery.GraphiteTagOperatorMatch:
+		re, err := regexp.Compile(e.Value)
+		return err == nil && re.MatchString("")
+	default:
+		return true
+	}
+}
+
+// renderParam quotes the parameters of the functions besides the numbers and the booleans
+func renderParam(p string) string {
+	if _, err := strconv.ParseFloat(p, 64); err == nil || p == "true" || p == "false" {
+		return p
+	}
+	return quoteParam(p)
+}
+
+func quoteParam(p string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(p) + "'"
+}
@@ -0,0 +1,67 @@
+package graphite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/graphite/kinds/dataquery"
+)
+
+func TestTagQueryTarget(t *testing.T) {
+	target, err := tagQueryTarget(&dataquery.GraphiteTagQuery{
+		Expressions: []dataquery.GraphiteTagExpression{
+			{Key: "name", Operator: "=", Value: "cpu.usage"},
+			{Key: "host", Operator: "=~", Value: `web-\d+`},
+			{Key: "dc", Operator: "!=", Value: "o'hare"},
+		},
+		Functions: []dataquery.GraphiteFunction{
+			{Name: "aliasByTags", Params: []string{"host"}},
+			{Name: "movingAverage", Params: []string{"5"}},
+			{Name: "removeEmptySeries", Params: []string{"0.5"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `removeEmptySeries(movingAverage(aliasByTags(seriesByTag('name=cpu.usage', 'host=~web-\\d+', 'dc!=o\'hare'), 'host'), 5), 0.5)`, target)
+
+	for expected, q := range map[string]*dataquery.GraphiteTagQuery{
+		"the tag query has no tag expression":                  {},
+		"a tag expression has no tag":                          {Expressions: []dataquery.GraphiteTagExpression{{Key: " ", Operator: "=", Value: "cpu"}}},
+		`invalid operator "~" of the tag expression of "name"`: {Expressions: []dataquery.GraphiteTagExpression{{Key: "name", Operator: "~", Value: "cpu"}}},
+		"at least one tag expression must match a non-empty value": {Expressions: []dataquery.GraphiteTagExpression{
+			{Key: "name", Operator: "=~", Value: ".*"},
+			{Key: "host", Operator: "!=", Value: "web"},
+		}},
+		`invalid function name "alias(x)"`: {
+			Expressions: []dataquery.GraphiteTagExpression{{Key: "name", Operator: "=", Value: "cpu"}},
+			Functions:   []dataquery.GraphiteFunction{{Name: "alias(x)"}},
+		},
+	} {
+		_, err := tagQueryTarget(q)
+		assert.EqualError(t, err, expected)
+	}
+}
+
+func TestProcessTagQueries(t *testing.T) {
+	service := &Service{}
+	log := logger.FromContext(context.Background())
+
+	targets, invalids, _, err := service.processQueries(log, []backend.DataQuery{
+		{RefID: "A", JSON: []byte(`{"tagQuery": {"expressions": [{"key": "name", "operator": "=", "value": "cpu"}]}}`)},
+		{RefID: "B", JSON: []byte(`{"target": "app.cpu", "tagQuery": {"expressions": [{"key": "name", "operator": "=", "value": "cpu"}]}}`)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, invalids)
+	assert.Equal(t, []string{
+		`aliasSub(seriesByTag('name=cpu'),"(^.*$)","\1 A")`,
+		`aliasSub(app.cpu,"(^.*$)","\1 B")`,
+	}, targets, "the target of the query takes precedence over its tag query")
+
+	_, _, _, err = service.processQueries(log, []backend.DataQuery{
+		{RefID: "A", JSON: []byte(`{"tagQuery": {"expressions": []}}`)},
+	})
+	assert.EqualError(t, err, "invalid tag query of the query A: the tag query has no tag expression")
+}
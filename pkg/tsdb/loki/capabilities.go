@@ -0,0 +1,86 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+var _ backend.CheckHealthHandler = (*Service)(nil)
+
+// healthCheckRange is the time range of the labels fetched by the health check, longer ones take too long
+const healthCheckRange = 10 * time.Minute
+
+// Capabilities are the features of the Loki version of the datasource, which the frontend uses instead of
+// guessing them from the failed requests. A capability is left out when it couldn't be detected.
+type Capabilities struct {
+	LabelVolumes *bool `json:"labelVolumes,omitempty"`
+	IndexStats   *bool `json:"indexStats,omitempty"`
+}
+
+type labelsResponse struct {
+	Data []string `json:"data"`
+}
+
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+	return checkHealth(ctx, dsInfo, logger.FromContext(ctx), time.Now()), nil
+}
+
+func checkHealth(ctx context.Context, dsInfo *datasourceInfo, plog log.Logger, now time.Time) *backend.CheckHealthResult {
+	api := newLokiAPI(dsInfo.HTTPClient, dsInfo.URL, plog)
+	labelsURL := fmt.Sprintf("/loki/api/v1/labels?start=%d&end=%d", now.Add(-healthCheckRange).UnixNano(), now.UnixNano())
+
+	res, err := api.RawQuery(ctx, labelsURL)
+	if err == nil && res.Status/100 != 2 {
+		err = makeLokiError(res.Body)
+	}
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("Unable to fetch labels from Loki (%s), please check the server logs for more details", err),
+		}
+	}
+
+	var labels labelsResponse
+	if err := json.Unmarshal(res.Body, &labels); err != nil || len(labels.Data) == 0 {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: "Data source connected, but no labels received. Verify that Loki and Promtail is configured properly.",
+		}
+	}
+
+	details, err := json.Marshal(map[string]interface{}{"capabilities": detectCapabilities(ctx, api, plog)})
+	if err != nil {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: err.Error()}
+	}
+	return &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "Data source connected and labels found.", JSONDetails: details}
+}
+
+// detectCapabilities checks which index endpoints the Loki version has, the requests without a query are otherwise
+// rejected as invalid
+func detectCapabilities(ctx context.Context, api *LokiAPI, plog log.Logger) Capabilities {
+	detect := func(resourcePath string) *bool {
+		res, err := api.RawQuery(ctx, resourcePath)
+		if err != nil {
+			plog.Warn("Failed to detect the Loki endpoint", "path", resourcePath, "error", err)
+			return nil
+		}
+		supported := res.Status != http.StatusNotFound
+		return &supported
+	}
+
+	return Capabilities{
+		LabelVolumes: detect("/loki/api/v1/index/volume"),
+		IndexStats:   detect("/loki/api/v1/index/stats"),
+	}
+}
@@ -0,0 +1,69 @@
+package loki
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestCheckHealth(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	newServer := func(t *testing.T, labelsStatus int, labelsBody string, indexStatus int) *datasourceInfo {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/loki/api/v1/labels":
+				assert.Equal(t, strconv.FormatInt(now.Add(-10*time.Minute).UnixNano(), 10), r.URL.Query().Get("start"))
+				assert.Equal(t, strconv.FormatInt(now.UnixNano(), 10), r.URL.Query().Get("end"))
+				w.WriteHeader(labelsStatus)
+				_, _ = w.Write([]byte(labelsBody))
+			case "/loki/api/v1/index/volume", "/loki/api/v1/index/stats":
+				w.WriteHeader(indexStatus)
+			default:
+				t.Errorf("unexpected request: %s", r.URL.Path)
+			}
+		}))
+		t.Cleanup(srv.Close)
+		return &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+	}
+
+	t.Run("publishes the capabilities when labels are found", func(t *testing.T) {
+		dsInfo := newServer(t, http.StatusOK, `{"status":"success","data":["app","job"]}`, http.StatusBadRequest)
+		res := checkHealth(context.Background(), dsInfo, log.New("test"), now)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+		assert.Equal(t, "Data source connected and labels found.", res.Message)
+		assert.JSONEq(t, `{"capabilities":{"labelVolumes":true,"indexStats":true}}`, string(res.JSONDetails))
+	})
+
+	t.Run("publishes the capabilities missing from the older versions", func(t *testing.T) {
+		dsInfo := newServer(t, http.StatusOK, `{"status":"success","data":["app"]}`, http.StatusNotFound)
+		res := checkHealth(context.Background(), dsInfo, log.New("test"), now)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+		assert.JSONEq(t, `{"capabilities":{"labelVolumes":false,"indexStats":false}}`, string(res.JSONDetails))
+	})
+
+	t.Run("fails without labels", func(t *testing.T) {
+		dsInfo := newServer(t, http.StatusOK, `{"status":"success","data":[]}`, http.StatusOK)
+		res := checkHealth(context.Background(), dsInfo, log.New("test"), now)
+		require.Equal(t, backend.HealthStatusError, res.Status)
+		assert.Equal(t, "Data source connected, but no labels received. Verify that Loki and Promtail is configured properly.", res.Message)
+		assert.Empty(t, res.JSONDetails)
+	})
+
+	t.Run("fails with the error of loki", func(t *testing.T) {
+		for _, status := range []int{http.StatusUnauthorized, http.StatusBadGateway} {
+			dsInfo := newServer(t, status, `{"message":"no org id"}`, http.StatusOK)
+			res := checkHealth(context.Background(), dsInfo, log.New("test"), now)
+			require.Equal(t, backend.HealthStatusError, res.Status)
+			assert.Equal(t, "Unable to fetch labels from Loki (no org id), please check the server logs for more details", res.Message)
+		}
+	})
+}
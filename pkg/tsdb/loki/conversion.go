@@ -0,0 +1,39 @@
+package loki
+
+import (
+	"github.com/grafana/grafana/pkg/tsdb/loki/kinds/dataquery"
+)
+
+// ConvertQuery implements plugins.QueryConverter, the dashboards migrate their Loki queries with it when they are saved
+func (s *Service) ConvertQuery(query map[string]interface{}) []string {
+	return convertQuery(query)
+}
+
+// convertQuery migrates a stored Loki query to the current query schema and returns the names of the converted fields.
+// Queries created before queryType was introduced use the instant and range flags instead, where any query that is
+// not instant is a range query. The queries with a query type unknown to this version are kept as they are.
+func convertQuery(query map[string]interface{}) []string {
+	var converted []string
+
+	switch queryType, _ := query["queryType"].(string); dataquery.LokiQueryType(queryType) {
+	case dataquery.LokiQueryTypeRange, dataquery.LokiQueryTypeInstant, dataquery.LokiQueryTypeStream:
+	case "":
+		if instant, _ := query["instant"].(bool); instant {
+			query["queryType"] = string(dataquery.LokiQueryTypeInstant)
+		} else {
+			query["queryType"] = string(dataquery.LokiQueryTypeRange)
+		}
+		converted = append(converted, "queryType")
+	default:
+		return nil
+	}
+
+	for _, field := range []string{"instant", "range"} {
+		if _, ok := query[field]; ok {
+			delete(query, field)
+			converted = append(converted, field)
+		}
+	}
+
+	return converted
+}
@@ -0,0 +1,38 @@
+package loki
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertQuery(t *testing.T) {
+	t.Run("instant flag becomes the instant query type", func(t *testing.T) {
+		query := map[string]interface{}{"expr": "{job=\"app\"}", "instant": true, "range": false}
+		require.Equal(t, []string{"queryType", "instant", "range"}, convertQuery(query))
+		require.Equal(t, map[string]interface{}{"expr": "{job=\"app\"}", "queryType": "instant"}, query)
+	})
+
+	t.Run("queries without query type are range queries", func(t *testing.T) {
+		query := map[string]interface{}{"expr": "{job=\"app\"}", "range": true}
+		require.Equal(t, []string{"queryType", "range"}, convertQuery(query))
+		require.Equal(t, map[string]interface{}{"expr": "{job=\"app\"}", "queryType": "range"}, query)
+	})
+
+	t.Run("unknown query types are kept", func(t *testing.T) {
+		query := map[string]interface{}{"expr": "{job=\"app\"}", "queryType": "logsVolume", "instant": true}
+		require.Empty(t, convertQuery(query))
+		require.Equal(t, map[string]interface{}{"expr": "{job=\"app\"}", "queryType": "logsVolume", "instant": true}, query)
+	})
+
+	t.Run("a valid query type is kept and the legacy flags are dropped", func(t *testing.T) {
+		query := map[string]interface{}{"expr": "{job=\"app\"}", "queryType": "range", "instant": true}
+		require.Equal(t, []string{"instant"}, convertQuery(query))
+		require.Equal(t, map[string]interface{}{"expr": "{job=\"app\"}", "queryType": "range"}, query)
+	})
+
+	t.Run("current queries are not converted", func(t *testing.T) {
+		query := map[string]interface{}{"expr": "{job=\"app\"}", "queryType": "stream"}
+		require.Empty(t, convertQuery(query))
+	})
+}
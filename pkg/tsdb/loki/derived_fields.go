@@ -0,0 +1,169 @@
+package loki
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// derivedFieldMatcherRegex extracts the value of the field from the first group of a regular expression
+	// matching the log line
+	derivedFieldMatcherRegex = "regex"
+	// derivedFieldMatcherLabel reads the value of the field from a label of the log line
+	derivedFieldMatcherLabel = "label"
+)
+
+// derivedFieldSettings is a derived field of the data source, read from the derivedFields of jsonData and shared
+// with the frontend
+type derivedFieldSettings struct {
+	Name         string `json:"name"`
+	MatcherRegex string `json:"matcherRegex"`
+	// MatcherType is regex or label, regex by default. With label, MatcherRegex is the name of the label.
+	MatcherType     string `json:"matcherType"`
+	URL             string `json:"url"`
+	URLDisplayLabel string `json:"urlDisplayLabel"`
+	DatasourceUID   string `json:"datasourceUid"`
+}
+
+type derivedField struct {
+	name    string
+	matcher *regexp.Regexp
+	label   string
+	links   []data.DataLink
+}
+
+// derivedFields adds the derived fields of the data source to the logs frames, so that the fields extracted from
+// the log lines, such as the trace IDs, are returned by the queries run without the frontend, such as the alerts.
+// The frontend extracts the fields the data source couldn't.
+type derivedFields struct {
+	fields []derivedField
+}
+
+// newDerivedFields returns nil when the data source has no derived field. The fields with the same name share the
+// matcher of the first one, as in the frontend, and the fields whose regular expression isn't supported by Go, such
+// as the lookbehinds of JavaScript, are left to the frontend.
+func newDerivedFields(settings []derivedFieldSettings) (*derivedFields, []string) {
+	var skipped []string
+	d := &derivedFields{}
+	index := map[string]int{}
+	for _, s := range settings {
+		if s.Name == "" {
+			continue
+		}
+		if i, ok := index[s.Name]; ok {
+			d.fields[i].links = append(d.fields[i].links, derivedFieldLinks(s)...)
+			continue
+		}
+
+		field := derivedField{name: s.Name, links: derivedFieldLinks(s)}
+		switch s.MatcherType {
+		case "", derivedFieldMatcherRegex:
+			matcher, err := regexp.Compile(s.MatcherRegex)
+			if err != nil {
+				skipped = append(skipped, s.Name)
+				continue
+			}
+			field.matcher = matcher
+		case derivedFieldMatcherLabel:
+			field.label = s.MatcherRegex
+		default:
+			skipped = append(skipped, s.Name)
+			continue
+		}
+		index[s.Name] = len(d.fields)
+		d.fields = append(d.fields, field)
+	}
+	if len(d.fields) == 0 {
+		return nil, skipped
+	}
+	return d, skipped
+}
+
+// derivedFieldLinks returns the external link of a derived field, the internal links to the other data sources are
+// built by the frontend
+func derivedFieldLinks(s derivedFieldSettings) []data.DataLink {
+	if s.DatasourceUID != "" || s.URL == "" {
+		return nil
+	}
+	return []data.DataLink{{Title: s.URLDisplayLabel, URL: s.URL}}
+}
+
+// addFields adds a string field to the logs frames for each derived field, with a null value for the lines the field
+// doesn't match. The fields already in the frame are kept.
+func (d *derivedFields) addFields(frames data.Frames) {
+	if d == nil {
+		return
+	}
+	for _, frame := range frames {
+		lineField, labelsField := logsFrameFields(frame)
+		if lineField == nil {
+			continue
+		}
+		var labels []map[string]string
+		if labelsField != nil {
+			labels = parseLineLabels(labelsField)
+		}
+
+		for _, df := range d.fields {
+			if _, found := frame.FieldByName(df.name); found != -1 {
+				continue
+			}
+			values := make([]*string, lineField.Len())
+			for i := range values {
+				values[i] = df.extract(lineField.At(i).(string), labels, i)
+			}
+			field := data.NewField(df.name, nil, values)
+			if len(df.links) > 0 {
+				field.Config = &data.FieldConfig{Links: df.links}
+			}
+			frame.Fields = append(frame.Fields, field)
+		}
+	}
+}
+
+func (df derivedField) extract(line string, labels []map[string]string, i int) *string {
+	if df.matcher == nil {
+		if i >= len(labels) {
+			return nil
+		}
+		if value, ok := labels[i][df.label]; ok {
+			return &value
+		}
+		return nil
+	}
+	match := df.matcher.FindStringSubmatch(line)
+	if len(match) < 2 {
+		return nil
+	}
+	return &match[1]
+}
+
+// logsFrameFields returns the line and the labels fields of a logs frame, the line field is nil for the other frames
+func logsFrameFields(frame *data.Frame) (*data.Field, *data.Field) {
+	var lineField, labelsField *data.Field
+	for _, field := range frame.Fields {
+		switch {
+		case field.Name == "Line" && field.Type() == data.FieldTypeString:
+			lineField = field
+		case field.Name == "labels" && field.Type() == data.FieldTypeJSON:
+			labelsField = field
+		}
+	}
+	return lineField, labelsField
+}
+
+func parseLineLabels(field *data.Field) []map[string]string {
+	labels := make([]map[string]string, field.Len())
+	for i := range labels {
+		raw, ok := field.At(i).(json.RawMessage)
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, &labels[i]); err != nil {
+			labels[i] = nil
+		}
+	}
+	return labels
+}
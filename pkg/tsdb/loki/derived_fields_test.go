@@ -0,0 +1,66 @@
+package loki
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivedFields(t *testing.T) {
+	newFrame := func() *data.Frame {
+		return data.NewFrame("",
+			data.NewField("labels", nil, []json.RawMessage{
+				json.RawMessage(`{"user_id":"42"}`),
+				json.RawMessage(`{}`),
+			}),
+			data.NewField("Line", nil, []string{
+				`level=info traceID=abc123 msg="done"`,
+				`level=info msg="no trace"`,
+			}),
+		)
+	}
+
+	d, skipped := newDerivedFields([]derivedFieldSettings{
+		{Name: "traceID", MatcherRegex: `traceID=(\w+)`, DatasourceUID: "tempo"},
+		{Name: "traceID", MatcherRegex: `ignored=(\w+)`, URL: "https://traces.example.com/${__value.raw}", URLDisplayLabel: "Open"},
+		{Name: "user_id", MatcherType: "label", MatcherRegex: "user_id"},
+		{Name: "lookbehind", MatcherRegex: `(?<=id=)\w+`},
+	})
+	require.NotNil(t, d)
+	assert.Equal(t, []string{"lookbehind"}, skipped, "the regular expressions Go doesn't support are left to the frontend")
+
+	frame := newFrame()
+	d.addFields(data.Frames{frame})
+	require.Len(t, frame.Fields, 4)
+
+	traceID := frame.Fields[2]
+	assert.Equal(t, "traceID", traceID.Name)
+	assert.Equal(t, "abc123", *traceID.At(0).(*string))
+	assert.Nil(t, traceID.At(1))
+	require.NotNil(t, traceID.Config)
+	assert.Equal(t, []data.DataLink{{Title: "Open", URL: "https://traces.example.com/${__value.raw}"}}, traceID.Config.Links)
+
+	userID := frame.Fields[3]
+	assert.Equal(t, "user_id", userID.Name)
+	assert.Equal(t, "42", *userID.At(0).(*string))
+	assert.Nil(t, userID.At(1))
+
+	t.Run("the fields are only added to the logs frames", func(t *testing.T) {
+		metrics := data.NewFrame("", data.NewField("Value", nil, []float64{1}))
+		d.addFields(data.Frames{metrics})
+		assert.Len(t, metrics.Fields, 1)
+	})
+
+	t.Run("without derived fields the frames are not changed", func(t *testing.T) {
+		none, skipped := newDerivedFields(nil)
+		assert.Nil(t, none)
+		assert.Empty(t, skipped)
+
+		frame := newFrame()
+		none.addFields(data.Frames{frame})
+		assert.Len(t, frame.Fields, 2)
+	})
+}
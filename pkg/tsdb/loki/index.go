@@ -0,0 +1,253 @@
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+const (
+	// indexCacheTTL is how long the responses of the index resources are reused. The start and the end of the
+	// requests are truncated to it in the cache key, so that the editor asking for the same relative time range gets
+	// the cached response.
+	indexCacheTTL = time.Minute
+	// indexCacheMaxEntries caps the responses kept by the cache of a data source
+	indexCacheMaxEntries = 1000
+)
+
+// IndexStats is the response of the index/stats resource, the amount of data the streams selected by a query hold
+// in the time range, used for the cost hints of the query editor.
+type IndexStats struct {
+	Streams int64 `json:"streams"`
+	Chunks  int64 `json:"chunks"`
+	Bytes   int64 `json:"bytes"`
+	Entries int64 `json:"entries"`
+}
+
+// IndexVolumes is the response of the index/volume resource, the streams or the labels of a query with the most
+// data in the time range first.
+type IndexVolumes struct {
+	Volumes []IndexVolume `json:"volumes"`
+}
+
+type IndexVolume struct {
+	Labels map[string]string `json:"labels"`
+	Bytes  int64             `json:"bytes"`
+}
+
+// volumeResponse is the body returned by Loki's /index/volume endpoint, a vector of the bytes of each stream or label
+type volumeResponse struct {
+	Data struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+type indexCacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// indexCache keeps the typed responses of the index resources of a data source
+type indexCache struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]indexCacheEntry
+}
+
+func newIndexCache() *indexCache {
+	return &indexCache{now: time.Now, entries: map[string]indexCacheEntry{}}
+}
+
+func (c *indexCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || c.now().After(e.expires) {
+		return nil, false
+	}
+	return e.body, true
+}
+
+func (c *indexCache) set(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= indexCacheMaxEntries {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		// every entry is still valid, the cache starts over rather than growing unbounded
+		if len(c.entries) >= indexCacheMaxEntries {
+			c.entries = map[string]indexCacheEntry{}
+		}
+	}
+	c.entries[key] = indexCacheEntry{body: body, expires: now.Add(indexCacheTTL)}
+}
+
+// indexCacheKey returns the key of a resource call, with the start and the end truncated to the cache TTL when they
+// are unix timestamps in nanoseconds, the format used by the query editor.
+func indexCacheKey(resourcePath string, params url.Values) string {
+	key := url.Values{}
+	for name, values := range params {
+		key[name] = values
+	}
+	for _, name := range []string{"start", "end"} {
+		ns, err := strconv.ParseInt(key.Get(name), 10, 64)
+		if err != nil {
+			continue
+		}
+		key.Set(name, strconv.FormatInt(ns-ns%int64(indexCacheTTL), 10))
+	}
+	return resourcePath + "?" + key.Encode()
+}
+
+// indexResource answers the index/stats?... and index/volume?... resource calls with typed responses, cached for a
+// short time as the query editor asks for them while the query is typed. The errors of Loki are returned as is.
+func indexResource(ctx context.Context, resourceURL string, sender backend.CallResourceResponseSender, dsInfo *datasourceInfo, plog log.Logger, tracer tracing.Tracer) error {
+	u, err := url.Parse(resourceURL)
+	if err != nil {
+		return fmt.Errorf("invalid resource URL: %s", resourceURL)
+	}
+
+	key := indexCacheKey(u.Path, u.Query())
+	if dsInfo.indexCache != nil {
+		if body, ok := dsInfo.indexCache.get(key); ok {
+			return sendIndexResponse(sender, body)
+		}
+	}
+
+	lokiURL := fmt.Sprintf("/loki/api/v1/%s", resourceURL)
+	ctx, span := tracer.Start(ctx, "datasource.loki.CallResource")
+	span.SetAttributes("url", lokiURL, attribute.Key("url").String(lokiURL))
+	defer span.End()
+
+	api := newLokiAPI(dsInfo.HTTPClient, dsInfo.URL, plog)
+	rawLokiResponse, err := api.RawQuery(ctx, lokiURL)
+	if err != nil {
+		return err
+	}
+
+	if rawLokiResponse.Status/100 != 2 {
+		return sender.Send(&backend.CallResourceResponse{
+			Status: rawLokiResponse.Status,
+			Headers: map[string][]string{
+				"content-type": {"application/json"},
+			},
+			Body: rawLokiResponse.Body,
+		})
+	}
+
+	raw, err := decodeRawBody(rawLokiResponse)
+	if err != nil {
+		return err
+	}
+
+	var typed interface{}
+	switch u.Path {
+	case "index/stats":
+		typed, err = parseIndexStats(raw)
+	case "index/volume":
+		typed, err = parseIndexVolumes(raw)
+	default:
+		return fmt.Errorf("invalid resource URL: %s", resourceURL)
+	}
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(typed)
+	if err != nil {
+		return err
+	}
+	if dsInfo.indexCache != nil {
+		dsInfo.indexCache.set(key, body)
+	}
+	return sendIndexResponse(sender, body)
+}
+
+func sendIndexResponse(sender backend.CallResourceResponseSender, body []byte) error {
+	return sender.Send(&backend.CallResourceResponse{
+		Status: http.StatusOK,
+		Headers: map[string][]string{
+			"content-type": {"application/json"},
+		},
+		Body: body,
+	})
+}
+
+// decodeRawBody returns the body of a response of Loki, which is only compressed when the client of the data
+// source doesn't decompress it.
+func decodeRawBody(res RawLokiResponse) ([]byte, error) {
+	switch res.Encoding {
+	case "":
+		return res.Body, nil
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(res.Body))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", res.Encoding)
+	}
+}
+
+func parseIndexStats(body []byte) (*IndexStats, error) {
+	stats := &IndexStats{}
+	if err := json.Unmarshal(body, stats); err != nil {
+		return nil, fmt.Errorf("failed to parse loki index stats: %w", err)
+	}
+	return stats, nil
+}
+
+func parseIndexVolumes(body []byte) (*IndexVolumes, error) {
+	var resp volumeResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse loki index volume: %w", err)
+	}
+
+	volumes := &IndexVolumes{Volumes: make([]IndexVolume, 0, len(resp.Data.Result))}
+	for _, sample := range resp.Data.Result {
+		if len(sample.Value) != 2 {
+			return nil, fmt.Errorf("failed to parse loki index volume: invalid sample value")
+		}
+		value, ok := sample.Value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse loki index volume: invalid sample value")
+		}
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse loki index volume: %w", err)
+		}
+		labels := sample.Metric
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		volumes.Volumes = append(volumes.Volumes, IndexVolume{Labels: labels, Bytes: size})
+	}
+
+	sort.SliceStable(volumes.Volumes, func(i, j int) bool {
+		return volumes.Volumes[i].Bytes > volumes.Volumes[j].Bytes
+	})
+	return volumes, nil
+}
@@ -0,0 +1,93 @@
+package loki
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestIndexResource(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/loki/api/v1/index/stats":
+			assert.Equal(t, `{app="api"}`, r.URL.Query().Get("query"))
+			_, _ = w.Write([]byte(`{"streams": 2, "chunks": 10, "bytes": 4096, "entries": 300}`))
+		case "/loki/api/v1/index/volume":
+			assert.Equal(t, "namespace", r.URL.Query().Get("targetLabels"))
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "vector", "result": [
+				{"metric": {"namespace": "dev"}, "value": [1700000000, "1024"]},
+				{"metric": {"namespace": "prod"}, "value": [1700000000, "8192"]}
+			]}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`parse error at line 1, col 1: syntax error: unexpected IDENTIFIER`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL, indexCache: newIndexCache()}
+	call := func(t *testing.T, resourceURL string) *backend.CallResourceResponse {
+		t.Helper()
+		sender := &recordingSender{}
+		req := &backend.CallResourceRequest{Method: "GET", URL: resourceURL}
+		err := callResource(context.Background(), req, sender, dsInfo, log.New("test"), tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.Len(t, sender.responses, 1)
+		return sender.responses[0]
+	}
+
+	t.Run("index stats are returned typed", func(t *testing.T) {
+		res := call(t, "index/stats?query="+url.QueryEscape(`{app="api"}`)+"&start=1700000000000000000&end=1700003600000000000")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.JSONEq(t, `{"streams": 2, "chunks": 10, "bytes": 4096, "entries": 300}`, string(res.Body))
+	})
+
+	t.Run("index stats are cached for the same time range", func(t *testing.T) {
+		before := requests
+		res := call(t, "index/stats?query="+url.QueryEscape(`{app="api"}`)+"&start=1700000001000000000&end=1700003601000000000")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.Equal(t, before, requests)
+
+		dsInfo.indexCache.now = func() time.Time { return time.Now().Add(2 * indexCacheTTL) }
+		t.Cleanup(func() { dsInfo.indexCache.now = time.Now })
+		call(t, "index/stats?query="+url.QueryEscape(`{app="api"}`)+"&start=1700000001000000000&end=1700003601000000000")
+		assert.Equal(t, before+1, requests)
+	})
+
+	t.Run("index volumes are returned with the largest first", func(t *testing.T) {
+		res := call(t, "index/volume?query="+url.QueryEscape(`{cluster="eu"}`)+"&targetLabels=namespace")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.JSONEq(t, `{"volumes": [{"labels": {"namespace": "prod"}, "bytes": 8192}, {"labels": {"namespace": "dev"}, "bytes": 1024}]}`, string(res.Body))
+	})
+
+	t.Run("loki errors are returned and not cached", func(t *testing.T) {
+		before := requests
+		dsInfo.URL = srv.URL + "/broken"
+		t.Cleanup(func() { dsInfo.URL = srv.URL })
+		for i := 0; i < 2; i++ {
+			res := call(t, "index/stats?query=broken")
+			assert.Equal(t, http.StatusBadRequest, res.Status)
+			assert.JSONEq(t, `{"message": "parse error at line 1, col 1: syntax error: unexpected IDENTIFIER"}`, string(res.Body))
+		}
+		assert.Equal(t, before+2, requests)
+	})
+}
+
+func TestIndexCacheKey(t *testing.T) {
+	params := url.Values{"query": {`{app="api"}`}, "start": {"1700000059000000000"}, "end": {"now"}}
+	assert.Equal(t, `index/stats?end=now&query=%7Bapp%3D%22api%22%7D&start=1700000040000000000`, indexCacheKey("index/stats", params))
+	// the parameters of the call are not changed
+	assert.Equal(t, "1700000059000000000", params.Get("start"))
+}
@@ -0,0 +1,190 @@
+package logql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	// tokenDuration and tokenBytes are numbers with a unit, like 5m or 10MB
+	tokenDuration
+	tokenBytes
+	// tokenVariable is a template variable of Grafana, like $__interval or ${job}
+	tokenVariable
+	tokenOperator
+)
+
+type token struct {
+	typ tokenType
+	val string
+	pos int
+	end int
+}
+
+func (t token) String() string {
+	if t.typ == tokenEOF {
+		return "end of query"
+	}
+	return fmt.Sprintf("%q", t.val)
+}
+
+func (t token) is(typ tokenType, val string) bool {
+	return t.typ == typ && t.val == val
+}
+
+// operators are sorted by length so that the longest operator is matched first
+var operators = []string{
+	"==", "!=", "=~", "!~", "|=", "|~", ">=", "<=",
+	"{", "}", "(", ")", "[", "]", ",", "=", "|", ">", "<", "+", "-", "*", "/", "%", "^",
+}
+
+var (
+	numberRegexp   = regexp.MustCompile(`^(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+	durationRegexp = regexp.MustCompile(`^(\d+(\.\d+)?(ns|us|µs|ms|s|m|h|d|w|y))+$`)
+	bytesRegexp    = regexp.MustCompile(`^\d+(\.\d+)?([kKmMgGtTpPeE]i?)?[bB]$`)
+)
+
+type lexError struct {
+	msg string
+	pos int
+	end int
+}
+
+func (e *lexError) Error() string {
+	return e.msg
+}
+
+// lex splits the query in tokens, comments starting with # are skipped
+func lex(query string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '"' || c == '`':
+			end, err := stringEnd(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{typ: tokenString, val: query[i:end], pos: i, end: end})
+			i = end
+		case c == '$':
+			end := variableEnd(query, i)
+			if end == i+1 {
+				return nil, &lexError{msg: "invalid template variable", pos: i, end: end}
+			}
+			tokens = append(tokens, token{typ: tokenVariable, val: query[i:end], pos: i, end: end})
+			i = end
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			end := i
+			for end < len(query) {
+				switch {
+				case strings.HasPrefix(query[end:], "µ"):
+					end += len("µ")
+				case (query[end] == 'e' || query[end] == 'E') && end+1 < len(query) && (query[end+1] == '+' || query[end+1] == '-'):
+					end += 2
+				case isIdentifierChar(query[end]) || query[end] == '.':
+					end++
+				default:
+					goto number
+				}
+			}
+		number:
+			t := token{val: query[i:end], pos: i, end: end}
+			switch {
+			case numberRegexp.MatchString(t.val):
+				t.typ = tokenNumber
+			case durationRegexp.MatchString(t.val):
+				t.typ = tokenDuration
+			case bytesRegexp.MatchString(t.val):
+				t.typ = tokenBytes
+			default:
+				return nil, &lexError{msg: fmt.Sprintf("invalid number %q", t.val), pos: i, end: end}
+			}
+			tokens = append(tokens, t)
+			i = end
+		case isIdentifierStart(c):
+			end := i
+			for end < len(query) && isIdentifierChar(query[end]) {
+				end++
+			}
+			tokens = append(tokens, token{typ: tokenIdentifier, val: query[i:end], pos: i, end: end})
+			i = end
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(query[i:], op) {
+					tokens = append(tokens, token{typ: tokenOperator, val: op, pos: i, end: i + len(op)})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				r := []rune(query[i:])[0]
+				return nil, &lexError{msg: fmt.Sprintf("unexpected character %q", r), pos: i, end: i + len(string(r))}
+			}
+		}
+	}
+	return append(tokens, token{typ: tokenEOF, pos: len(query), end: len(query)}), nil
+}
+
+// stringEnd returns the position after the quote closing the string starting at start
+func stringEnd(query string, start int) (int, error) {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case '\n':
+			if quote == '"' {
+				return 0, &lexError{msg: "unterminated string", pos: start, end: i}
+			}
+		case quote:
+			return i + 1, nil
+		}
+	}
+	return 0, &lexError{msg: "unterminated string", pos: start, end: len(query)}
+}
+
+// variableEnd returns the position after the template variable starting at start, in the $name or ${name} syntax
+func variableEnd(query string, start int) int {
+	i := start + 1
+	if i < len(query) && query[i] == '{' {
+		if end := strings.IndexByte(query[i:], '}'); end > 0 {
+			return i + end + 1
+		}
+		return i
+	}
+	for i < len(query) && isIdentifierChar(query[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierChar(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c)
+}
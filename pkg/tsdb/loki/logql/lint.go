@@ -0,0 +1,234 @@
+// Package logql checks the syntax of LogQL queries and suggests fixes for the queries that are valid but slow.
+package logql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+const (
+	CodeSyntaxError             = "syntax-error"
+	CodeMissingStreamSelector   = "missing-stream-selector"
+	CodeMissingRange            = "missing-range"
+	CodeMissingRangeAggregation = "missing-range-aggregation"
+	CodeEmptyCompatibleSelector = "empty-compatible-selector"
+	CodeUnboundedRegex          = "unbounded-regex"
+	CodeRegexWithoutMetachars   = "regex-without-metacharacters"
+)
+
+// Issue is a problem found in a query. Start and End are the byte offsets of the problem in the query.
+type Issue struct {
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+	// Fix is the whole query with the issue fixed, when it can be fixed automatically
+	Fix string `json:"fix,omitempty"`
+}
+
+type Result struct {
+	// Valid is false when the query would be rejected by Loki
+	Valid  bool    `json:"valid"`
+	Issues []Issue `json:"issues"`
+}
+
+// Validate checks the syntax of the query and lints it
+func Validate(query string) Result {
+	parsed, issue := parse(query)
+	if issue != nil {
+		return Result{Valid: false, Issues: []Issue{*issue}}
+	}
+
+	l := &linter{query: query, issues: []Issue{}}
+	for _, s := range parsed.selectors {
+		l.lintSelector(s)
+	}
+	for _, f := range parsed.lineFilters {
+		l.lintLineFilter(f)
+	}
+	for _, m := range parsed.labelFilters {
+		l.lintRegexMatcher(m)
+	}
+	sort.SliceStable(l.issues, func(i, j int) bool { return l.issues[i].Start < l.issues[j].Start })
+
+	result := Result{Valid: true, Issues: l.issues}
+	for _, i := range l.issues {
+		if i.Severity == SeverityError {
+			result.Valid = false
+		}
+	}
+	return result
+}
+
+type linter struct {
+	query  string
+	issues []Issue
+}
+
+func (l *linter) add(code string, severity Severity, start, end int, fix string, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{
+		Code:     code,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Start:    start,
+		End:      end,
+		Fix:      fix,
+	})
+}
+
+func (l *linter) replace(start, end int, s string) string {
+	return l.query[:start] + s + l.query[end:]
+}
+
+// remove removes the text between start and end, and the spaces around it
+func (l *linter) remove(start, end int) string {
+	left := strings.TrimRight(l.query[:start], " ")
+	right := strings.TrimLeft(l.query[end:], " ")
+	if left == "" || right == "" {
+		return left + right
+	}
+	return left + " " + right
+}
+
+// matchesEmpty tells whether the matcher matches the streams without the label, Loki rejects the selectors whose
+// matchers all match them
+func matchesEmpty(m matcher) bool {
+	if containsVariable(m.value) {
+		return false
+	}
+	switch m.op {
+	case "=":
+		return m.value == ""
+	case "!=":
+		return m.value != ""
+	default:
+		re, err := regexp.Compile("^(?:" + m.value + ")$")
+		if err != nil {
+			return false
+		}
+		return re.MatchString("") == (m.op == "=~")
+	}
+}
+
+func (l *linter) lintSelector(s *streamSelector) {
+	emptyCompatible := 0
+	for _, m := range s.matchers {
+		if matchesEmpty(m) {
+			emptyCompatible++
+		}
+	}
+	if emptyCompatible == len(s.matchers) {
+		fix := ""
+		if m := s.matchers[0]; len(s.matchers) == 1 && m.op == "=~" && m.value == ".*" {
+			fix = l.replace(m.valuePos, m.valueEnd, `".+"`)
+		}
+		l.add(CodeEmptyCompatibleSelector, SeverityError, s.pos, s.end, fix,
+			`stream selectors need at least one matcher that doesn't match empty values, like {app="foo"}`)
+		return
+	}
+
+	for i, m := range s.matchers {
+		switch {
+		case m.op == "=~" && m.value == ".+":
+			l.add(CodeUnboundedRegex, SeverityWarning, m.pos, m.end, l.replace(m.opPos, m.valueEnd, `!=""`),
+				`%s=~".+" matches every stream with the label, use %s!="" instead`, m.name, m.name)
+		case m.op == "=~" && m.value == ".*":
+			// the selector has other matchers, or it would be empty compatible
+			var fix string
+			if i < len(s.matchers)-1 {
+				fix = l.query[:m.pos] + l.query[s.matchers[i+1].pos:]
+			} else {
+				fix = l.query[:s.matchers[i-1].end] + l.query[m.end:]
+			}
+			l.add(CodeUnboundedRegex, SeverityWarning, m.pos, m.end, fix,
+				`%s=~".*" matches every stream and can be removed`, m.name)
+		default:
+			l.lintRegexMatcher(m)
+		}
+	}
+}
+
+func (l *linter) lintLineFilter(f lineFilter) {
+	if f.chained || !isRegexOp(f.op) || containsVariable(f.value) {
+		return
+	}
+
+	trimmed := trimWildcards(f.value)
+	switch {
+	case f.op == "|~" && trimmed == "":
+		l.add(CodeUnboundedRegex, SeverityWarning, f.pos, f.valueEnd, l.remove(f.pos, f.valueEnd),
+			"line filter %s matches every line and can be removed", l.query[f.valuePos:f.valueEnd])
+	case trimmed != f.value && trimmed != "":
+		op := f.op
+		if isLiteral(trimmed) {
+			op = literalOp(f.op)
+		}
+		fix := l.replace(f.valuePos, f.valueEnd, quote(trimmed, f.quote))
+		fix = fix[:f.pos] + op + fix[f.opEnd:]
+		l.add(CodeUnboundedRegex, SeverityWarning, f.pos, f.valueEnd, fix,
+			"line filters match anywhere in the line, the leading and trailing .* are not needed")
+	case isLiteral(f.value):
+		l.add(CodeRegexWithoutMetachars, SeverityInfo, f.pos, f.valueEnd, l.replace(f.pos, f.opEnd, literalOp(f.op)),
+			"regular expression %s has no special characters, use %s which is faster", l.query[f.valuePos:f.valueEnd], literalOp(f.op))
+	}
+}
+
+// lintRegexMatcher suggests using an equality matcher instead of a regular expression without special characters
+func (l *linter) lintRegexMatcher(m matcher) {
+	if (m.op != "=~" && m.op != "!~") || !isLiteral(m.value) || containsVariable(m.value) {
+		return
+	}
+	op := literalOp(m.op)
+	l.add(CodeRegexWithoutMetachars, SeverityInfo, m.pos, m.end, l.replace(m.opPos, m.opEnd, op),
+		"regular expression %s has no special characters, use %s%s%s which is faster", l.query[m.valuePos:m.valueEnd], m.name, op, l.query[m.valuePos:m.valueEnd])
+}
+
+// trimWildcards removes the .* at the start and at the end of the regular expression of a line filter
+func trimWildcards(value string) string {
+	trimmed := value
+	for strings.HasPrefix(trimmed, ".*") {
+		trimmed = trimmed[2:]
+	}
+	for strings.HasSuffix(trimmed, ".*") && !strings.HasSuffix(trimmed, `\.*`) {
+		trimmed = trimmed[:len(trimmed)-2]
+	}
+	// the wildcards are needed when they are part of an alternation or followed by a quantifier, like .*|foo or .*?foo
+	if _, err := regexp.Compile(trimmed); err != nil || strings.HasPrefix(trimmed, "|") || strings.HasSuffix(trimmed, "|") {
+		return value
+	}
+	return trimmed
+}
+
+func isLiteral(value string) bool {
+	return value != "" && regexp.QuoteMeta(value) == value
+}
+
+func literalOp(op string) string {
+	switch op {
+	case "=~":
+		return "="
+	case "|~":
+		return "|="
+	default:
+		return "!="
+	}
+}
+
+func quote(value string, q byte) string {
+	if q == '`' {
+		return "`" + value + "`"
+	}
+	return strconv.Quote(value)
+}
@@ -0,0 +1,136 @@
+package logql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("valid queries have no issues", func(t *testing.T) {
+		for _, query := range []string{
+			`{app="foo"} |= "error" |~ "err(or)?"`,
+			`sum(rate({app="foo", env=~"prod|dev"}[5m]))`,
+			`{app="foo", pod=~"$pod"} |~ "$search"`,
+			`{app="foo"} |~ ".*|error"`,
+			`{app="foo"} |= "a" or "b"`,
+		} {
+			result := Validate(query)
+			assert.True(t, result.Valid, query)
+			assert.Empty(t, result.Issues, query)
+		}
+	})
+
+	t.Run("syntax errors make the query invalid", func(t *testing.T) {
+		result := Validate(`app="foo"`)
+		assert.False(t, result.Valid)
+		require.Len(t, result.Issues, 1)
+		assert.Equal(t, CodeMissingStreamSelector, result.Issues[0].Code)
+		assert.Equal(t, `{app="foo"}`, result.Issues[0].Fix)
+	})
+
+	for _, tc := range []struct {
+		name     string
+		query    string
+		code     string
+		severity Severity
+		fix      string
+	}{
+		{
+			name:     "selectors with only empty compatible matchers",
+			query:    `{app=~".*"}`,
+			code:     CodeEmptyCompatibleSelector,
+			severity: SeverityError,
+			fix:      `{app=~".+"}`,
+		},
+		{
+			name:     "selectors with only negative matchers",
+			query:    `{app!="foo", env=""}`,
+			code:     CodeEmptyCompatibleSelector,
+			severity: SeverityError,
+		},
+		{
+			name:     "regex matching any value",
+			query:    `{app=~".+"} |= "error"`,
+			code:     CodeUnboundedRegex,
+			severity: SeverityWarning,
+			fix:      `{app!=""} |= "error"`,
+		},
+		{
+			name:     "regex matching every stream",
+			query:    `{app="foo", env=~".*"}`,
+			code:     CodeUnboundedRegex,
+			severity: SeverityWarning,
+			fix:      `{app="foo"}`,
+		},
+		{
+			name:     "regex matching every stream first",
+			query:    `{env=~".*", app="foo"}`,
+			code:     CodeUnboundedRegex,
+			severity: SeverityWarning,
+			fix:      `{app="foo"}`,
+		},
+		{
+			name:     "line filter matching every line",
+			query:    `rate({app="foo"} |~ ".*" [5m])`,
+			code:     CodeUnboundedRegex,
+			severity: SeverityWarning,
+			fix:      `rate({app="foo"} [5m])`,
+		},
+		{
+			name:     "line filter with wildcards at the ends",
+			query:    `{app="foo"} |~ ".*err(or)?.*"`,
+			code:     CodeUnboundedRegex,
+			severity: SeverityWarning,
+			fix:      `{app="foo"} |~ "err(or)?"`,
+		},
+		{
+			name:     "line filter with wildcards around a string",
+			query:    `{app="foo"} !~ ".*debug.*" | json`,
+			code:     CodeUnboundedRegex,
+			severity: SeverityWarning,
+			fix:      `{app="foo"} != "debug" | json`,
+		},
+		{
+			name:     "line filter regex without special characters",
+			query:    "{app=\"foo\"} |~ `error`",
+			code:     CodeRegexWithoutMetachars,
+			severity: SeverityInfo,
+			fix:      "{app=\"foo\"} |= `error`",
+		},
+		{
+			name:     "matcher regex without special characters",
+			query:    `{app=~"foo"}`,
+			code:     CodeRegexWithoutMetachars,
+			severity: SeverityInfo,
+			fix:      `{app="foo"}`,
+		},
+		{
+			name:     "label filter regex without special characters",
+			query:    `{app="foo"} | json | level!~"debug"`,
+			code:     CodeRegexWithoutMetachars,
+			severity: SeverityInfo,
+			fix:      `{app="foo"} | json | level!="debug"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Validate(tc.query)
+			assert.Equal(t, tc.severity != SeverityError, result.Valid)
+			require.Len(t, result.Issues, 1)
+			issue := result.Issues[0]
+			assert.Equal(t, tc.code, issue.Code)
+			assert.Equal(t, tc.severity, issue.Severity)
+			assert.Equal(t, tc.fix, issue.Fix)
+			if tc.fix != "" {
+				assert.True(t, Validate(tc.fix).Valid, tc.fix)
+			}
+		})
+	}
+
+	t.Run("issues are sorted by position", func(t *testing.T) {
+		result := Validate(`{app=~"foo"} |~ "bar" | json | level=~"error"`)
+		require.Len(t, result.Issues, 3)
+		assert.Equal(t, []int{1, 13, 31}, []int{result.Issues[0].Start, result.Issues[1].Start, result.Issues[2].Start})
+	})
+}
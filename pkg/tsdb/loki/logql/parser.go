@@ -0,0 +1,837 @@
+package logql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// exprKind is the type of the result of an expression
+type exprKind int
+
+const (
+	kindLog exprKind = iota
+	kindMetric
+	kindScalar
+	// kindUnknown is the kind of template variables, they can be replaced by any expression
+	kindUnknown
+)
+
+type unwrapRule int
+
+const (
+	unwrapForbidden unwrapRule = iota
+	unwrapOptional
+	unwrapRequired
+)
+
+var vectorAggregations = map[string]bool{
+	"sum": true, "avg": true, "count": true, "max": true, "min": true, "stddev": true, "stdvar": true,
+	"topk": true, "bottomk": true, "sort": true, "sort_desc": true,
+}
+
+// rangeAggregations tells whether the range aggregations need an unwrapped label
+var rangeAggregations = map[string]unwrapRule{
+	"count_over_time":    unwrapForbidden,
+	"bytes_over_time":    unwrapForbidden,
+	"bytes_rate":         unwrapForbidden,
+	"absent_over_time":   unwrapForbidden,
+	"rate":               unwrapOptional,
+	"rate_counter":       unwrapRequired,
+	"avg_over_time":      unwrapRequired,
+	"sum_over_time":      unwrapRequired,
+	"min_over_time":      unwrapRequired,
+	"max_over_time":      unwrapRequired,
+	"stdvar_over_time":   unwrapRequired,
+	"stddev_over_time":   unwrapRequired,
+	"quantile_over_time": unwrapRequired,
+	"first_over_time":    unwrapRequired,
+	"last_over_time":     unwrapRequired,
+}
+
+const comparisonPrecedence = 3
+
+var binaryPrecedence = map[string]int{
+	"or":  1,
+	"and": 2, "unless": 2,
+	"==": comparisonPrecedence, "!=": comparisonPrecedence, ">": comparisonPrecedence, ">=": comparisonPrecedence, "<": comparisonPrecedence, "<=": comparisonPrecedence,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+	"^": 6,
+}
+
+var variableRegexp = regexp.MustCompile(`\$(\w+|\{[^}]+\})`)
+
+// containsVariable tells whether the value has template variables, they can only be checked once interpolated
+func containsVariable(value string) bool {
+	return variableRegexp.MatchString(value)
+}
+
+// matcher is a label matcher of a stream selector or a label filter of a pipeline
+type matcher struct {
+	name  string
+	op    string
+	value string
+	quote byte
+	// the positions of the whole matcher, of its operator and of its quoted value
+	pos, end           int
+	opPos, opEnd       int
+	valuePos, valueEnd int
+}
+
+type streamSelector struct {
+	pos, end int
+	matchers []matcher
+}
+
+type lineFilter struct {
+	op    string
+	value string
+	quote byte
+	// chained is true for the filters joined with or, like |= "a" or "b"
+	chained            bool
+	pos, opEnd         int
+	valuePos, valueEnd int
+}
+
+// parsedQuery holds what the linter needs to know of the query
+type parsedQuery struct {
+	kind         exprKind
+	selectors    []*streamSelector
+	lineFilters  []lineFilter
+	labelFilters []matcher
+}
+
+type parser struct {
+	query  string
+	tokens []token
+	pos    int
+	result *parsedQuery
+	// inRange and unwrapped are set while parsing the log query of a range aggregation
+	inRange   bool
+	unwrapped bool
+}
+
+// parse checks the syntax of the query, the returned issue is the first syntax error
+func parse(query string) (result *parsedQuery, issue *Issue) {
+	tokens, err := lex(query)
+	if err != nil {
+		lerr := err.(*lexError)
+		return nil, &Issue{Code: CodeSyntaxError, Severity: SeverityError, Message: lerr.msg, Start: lerr.pos, End: lerr.end}
+	}
+
+	p := &parser{query: query, tokens: tokens, result: &parsedQuery{}}
+	defer func() {
+		if r := recover(); r != nil {
+			i, ok := r.(*Issue)
+			if !ok {
+				panic(r)
+			}
+			result, issue = nil, i
+		}
+	}()
+
+	if t := p.peek(); t.typ == tokenEOF {
+		p.fail(t, "query is empty")
+	}
+	p.result.kind = p.parseExpr(0)
+	if t := p.peek(); t.typ != tokenEOF {
+		if t.is(tokenOperator, "[") && p.result.kind == kindLog {
+			p.missingRangeAggregation(p.tokens[0], "ranges can only be used in range aggregations")
+		}
+		p.fail(t, "unexpected %s", t)
+	}
+	return p.result, nil
+}
+
+func (p *parser) peek() token {
+	return p.peekAt(0)
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// prevEnd returns the end of the last parsed token
+func (p *parser) prevEnd() int {
+	if p.pos == 0 {
+		return 0
+	}
+	return p.tokens[p.pos-1].end
+}
+
+func (p *parser) fail(t token, format string, args ...interface{}) {
+	panic(&Issue{Code: CodeSyntaxError, Severity: SeverityError, Message: fmt.Sprintf(format, args...), Start: t.pos, End: t.end})
+}
+
+func (p *parser) unexpected(t token, expected string) {
+	p.fail(t, "unexpected %s, expected %s", t, expected)
+}
+
+func (p *parser) expect(typ tokenType, val string) token {
+	t := p.next()
+	if !t.is(typ, val) {
+		p.unexpected(t, fmt.Sprintf("%q", val))
+	}
+	return t
+}
+
+func (p *parser) expectString() (token, string) {
+	t := p.next()
+	if t.typ != tokenString {
+		p.unexpected(t, "a quoted string")
+	}
+	return t, p.unquote(t)
+}
+
+func (p *parser) expectIdentifier(expected string) token {
+	t := p.next()
+	if t.typ != tokenIdentifier {
+		p.unexpected(t, expected)
+	}
+	return t
+}
+
+func (p *parser) unquote(t token) string {
+	if t.val[0] == '`' {
+		return t.val[1 : len(t.val)-1]
+	}
+	s, err := strconv.Unquote(t.val)
+	if err != nil {
+		p.fail(t, "invalid string %s", t.val)
+	}
+	return s
+}
+
+func (p *parser) checkRegex(t token, value string) {
+	if containsVariable(value) {
+		return
+	}
+	if _, err := regexp.Compile(value); err != nil {
+		p.fail(t, "invalid regular expression %s: %s", t.val, strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+	}
+}
+
+func isMatcherOp(t token) bool {
+	return t.typ == tokenOperator && (t.val == "=" || t.val == "!=" || t.val == "=~" || t.val == "!~")
+}
+
+func isLineFilterOp(t token) bool {
+	return t.typ == tokenOperator && (t.val == "|=" || t.val == "|~" || t.val == "!=" || t.val == "!~")
+}
+
+func isComparisonOp(t token) bool {
+	return isMatcherOp(t) || t.typ == tokenOperator && (t.val == "==" || t.val == ">" || t.val == ">=" || t.val == "<" || t.val == "<=")
+}
+
+func isRegexOp(op string) bool {
+	return op == "=~" || op == "!~" || op == "|~"
+}
+
+func binaryOperator(t token) (int, bool) {
+	if t.typ == tokenIdentifier && t.val != "and" && t.val != "or" && t.val != "unless" {
+		return 0, false
+	}
+	if t.typ != tokenIdentifier && t.typ != tokenOperator {
+		return 0, false
+	}
+	prec, ok := binaryPrecedence[t.val]
+	return prec, ok
+}
+
+func (p *parser) parseExpr(minPrec int) exprKind {
+	kind := p.parseUnary()
+	for {
+		op := p.peek()
+		prec, ok := binaryOperator(op)
+		if !ok || prec < minPrec {
+			return kind
+		}
+		p.next()
+		p.parseBinaryModifiers(prec)
+
+		// ^ is right associative
+		nextPrec := prec + 1
+		if op.val == "^" {
+			nextPrec = prec
+		}
+		rhs := p.parseExpr(nextPrec)
+		if kind == kindLog || rhs == kindLog {
+			p.fail(op, "log queries can't be used in binary operations, use a metric query like count_over_time({...}[$__interval])")
+		}
+		switch {
+		case kind == kindMetric || rhs == kindMetric:
+			kind = kindMetric
+		case kind == kindUnknown || rhs == kindUnknown:
+			kind = kindUnknown
+		}
+	}
+}
+
+func (p *parser) parseBinaryModifiers(prec int) {
+	if t := p.peek(); t.is(tokenIdentifier, "bool") {
+		if prec != comparisonPrecedence {
+			p.fail(t, "bool can only be used with comparison operators")
+		}
+		p.next()
+	}
+	if t := p.peek(); t.is(tokenIdentifier, "on") || t.is(tokenIdentifier, "ignoring") {
+		p.next()
+		p.parseLabelList()
+		if t := p.peek(); t.is(tokenIdentifier, "group_left") || t.is(tokenIdentifier, "group_right") {
+			p.next()
+			if p.peek().is(tokenOperator, "(") {
+				p.parseLabelList()
+			}
+		}
+	}
+}
+
+func (p *parser) parseLabelList() {
+	p.expect(tokenOperator, "(")
+	if p.peek().is(tokenOperator, ")") {
+		p.next()
+		return
+	}
+	for {
+		p.expectIdentifier("a label name")
+		if !p.peek().is(tokenOperator, ",") {
+			break
+		}
+		p.next()
+	}
+	p.expect(tokenOperator, ")")
+}
+
+func (p *parser) parseUnary() exprKind {
+	if t := p.peek(); t.is(tokenOperator, "-") || t.is(tokenOperator, "+") {
+		p.next()
+		kind := p.parseUnary()
+		if kind == kindLog {
+			p.fail(t, "log queries can't be used in binary operations, use a metric query like count_over_time({...}[$__interval])")
+		}
+		return kind
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() exprKind {
+	t := p.peek()
+	switch {
+	case t.typ == tokenNumber:
+		p.next()
+		return kindScalar
+	case t.typ == tokenVariable:
+		p.next()
+		return kindUnknown
+	case t.is(tokenOperator, "("):
+		p.next()
+		kind := p.parseExpr(0)
+		p.expect(tokenOperator, ")")
+		return kind
+	case t.is(tokenOperator, "{"):
+		p.parseSelector()
+		p.parsePipeline()
+		return kindLog
+	case t.typ == tokenIdentifier:
+		if vectorAggregations[t.val] {
+			return p.parseVectorAggregation()
+		}
+		if _, ok := rangeAggregations[t.val]; ok {
+			return p.parseRangeAggregation()
+		}
+		switch t.val {
+		case "label_replace":
+			return p.parseLabelReplace()
+		case "vector":
+			p.next()
+			p.expect(tokenOperator, "(")
+			p.parseParameter()
+			p.expect(tokenOperator, ")")
+			return kindMetric
+		}
+	}
+	p.missingSelector(t)
+	p.unexpected(t, "a query")
+	return kindUnknown
+}
+
+// missingSelector fails when the log query at t doesn't start with a stream selector. The query can be fixed when it
+// starts with label matchers without braces.
+func (p *parser) missingSelector(t token) {
+	switch {
+	case t.typ == tokenIdentifier && isMatcherOp(p.peekAt(1)):
+		issue := &Issue{
+			Code:     CodeMissingStreamSelector,
+			Severity: SeverityError,
+			Message:  `log queries must start with a stream selector, label matchers must be in braces like {app="foo"}`,
+			Start:    t.pos,
+			End:      t.end,
+		}
+		if end := p.matchersEnd(); end > 0 {
+			issue.End = end
+			issue.Fix = p.query[:t.pos] + "{" + p.query[t.pos:end] + "}" + p.query[end:]
+		}
+		panic(issue)
+	case isLineFilterOp(t):
+		panic(&Issue{
+			Code:     CodeMissingStreamSelector,
+			Severity: SeverityError,
+			Message:  `log queries must start with a stream selector, like {app="foo"}`,
+			Start:    t.pos,
+			End:      t.end,
+		})
+	}
+}
+
+// matchersEnd returns the end of the comma separated label matchers at the current position, or -1 if they are not
+// valid matchers
+func (p *parser) matchersEnd() int {
+	end := -1
+	for i := p.pos; i+2 < len(p.tokens); i += 4 {
+		if p.tokens[i].typ != tokenIdentifier || !isMatcherOp(p.tokens[i+1]) || p.tokens[i+2].typ != tokenString {
+			return -1
+		}
+		end = p.tokens[i+2].end
+		if !p.tokens[i+3].is(tokenOperator, ",") {
+			break
+		}
+	}
+	return end
+}
+
+// missingRangeAggregation fails for a log query used where a metric query is expected, the fix counts the log lines
+func (p *parser) missingRangeAggregation(start token, message string) {
+	end := p.prevEnd()
+	fix := p.query[:start.pos] + "count_over_time(" + p.query[start.pos:end] + " [$__interval])" + p.query[end:]
+	if p.peek().is(tokenOperator, "[") {
+		// the query already has a range
+		for i := p.pos; i < len(p.tokens) && p.tokens[i].typ != tokenEOF; i++ {
+			if p.tokens[i].is(tokenOperator, "]") {
+				end = p.tokens[i].end
+				fix = p.query[:start.pos] + "count_over_time(" + p.query[start.pos:end] + ")" + p.query[end:]
+				break
+			}
+		}
+	}
+	panic(&Issue{
+		Code:     CodeMissingRangeAggregation,
+		Severity: SeverityError,
+		Message:  message + `, use a range aggregation like count_over_time({app="foo"}[$__interval])`,
+		Start:    start.pos,
+		End:      end,
+		Fix:      fix,
+	})
+}
+
+func (p *parser) parseSelector() {
+	open := p.expect(tokenOperator, "{")
+	if t := p.peek(); t.is(tokenOperator, "}") {
+		p.fail(t, `stream selectors need at least one label matcher, like {app="foo"}`)
+	}
+
+	selector := &streamSelector{pos: open.pos}
+	for {
+		name := p.expectIdentifier("a label name")
+		op := p.next()
+		if !isMatcherOp(op) {
+			p.unexpected(op, "a label matcher like = or =~")
+		}
+		value, unquoted := p.expectString()
+		if isRegexOp(op.val) {
+			p.checkRegex(value, unquoted)
+		}
+		selector.matchers = append(selector.matchers, newMatcher(name, op, value, unquoted))
+		if !p.peek().is(tokenOperator, ",") {
+			break
+		}
+		p.next()
+	}
+	selector.end = p.expect(tokenOperator, "}").end
+	p.result.selectors = append(p.result.selectors, selector)
+}
+
+func newMatcher(name, op, value token, unquoted string) matcher {
+	return matcher{
+		name:     name.val,
+		op:       op.val,
+		value:    unquoted,
+		quote:    value.val[0],
+		pos:      name.pos,
+		end:      value.end,
+		opPos:    op.pos,
+		opEnd:    op.end,
+		valuePos: value.pos,
+		valueEnd: value.end,
+	}
+}
+
+func (p *parser) parsePipeline() {
+	for {
+		t := p.peek()
+		switch {
+		case isLineFilterOp(t):
+			p.parseLineFilter()
+		case t.is(tokenOperator, "|"):
+			p.next()
+			p.parseStage()
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) parseLineFilter() {
+	op := p.next()
+	var filters []lineFilter
+	for {
+		if p.peek().is(tokenIdentifier, "ip") {
+			p.parseIP()
+		} else {
+			value, unquoted := p.expectString()
+			if isRegexOp(op.val) {
+				p.checkRegex(value, unquoted)
+			}
+			filters = append(filters, lineFilter{
+				op:       op.val,
+				value:    unquoted,
+				quote:    value.val[0],
+				pos:      op.pos,
+				opEnd:    op.end,
+				valuePos: value.pos,
+				valueEnd: value.end,
+			})
+		}
+		if !p.peek().is(tokenIdentifier, "or") {
+			break
+		}
+		p.next()
+	}
+	for _, f := range filters {
+		f.chained = len(filters) > 1
+		p.result.lineFilters = append(p.result.lineFilters, f)
+	}
+}
+
+func (p *parser) parseIP() {
+	p.expect(tokenIdentifier, "ip")
+	p.expect(tokenOperator, "(")
+	p.expectString()
+	p.expect(tokenOperator, ")")
+}
+
+func (p *parser) parseStage() {
+	t := p.peek()
+	if t.typ == tokenIdentifier && !isComparisonOp(p.peekAt(1)) {
+		switch t.val {
+		case "json", "logfmt":
+			p.next()
+			p.parseParserFlags()
+			p.parseExtractions()
+			return
+		case "regexp":
+			p.next()
+			value, unquoted := p.expectString()
+			p.checkRegex(value, unquoted)
+			return
+		case "pattern", "line_format":
+			p.next()
+			p.expectString()
+			return
+		case "unpack", "decolorize":
+			p.next()
+			return
+		case "label_format":
+			p.next()
+			p.parseLabelFormat()
+			return
+		case "drop", "keep":
+			p.next()
+			p.parseDropKeep()
+			return
+		case "unwrap":
+			p.parseUnwrap()
+			return
+		}
+	}
+	if t.typ != tokenIdentifier && !t.is(tokenOperator, "(") {
+		p.unexpected(t, "a parser, a formatter or a label filter")
+	}
+	p.parseLabelFilterOr()
+}
+
+// parseParserFlags parses the flags of the parsers, like --strict or --keep-empty
+func (p *parser) parseParserFlags() {
+	for p.peek().is(tokenOperator, "-") && p.peekAt(1).is(tokenOperator, "-") {
+		p.next()
+		p.next()
+		flag := p.expectIdentifier("a parser flag")
+		switch flag.val {
+		case "strict":
+		case "keep":
+			p.expect(tokenOperator, "-")
+			p.expect(tokenIdentifier, "empty")
+		default:
+			p.fail(flag, "unknown parser flag --%s", flag.val)
+		}
+	}
+}
+
+// parseExtractions parses the labels extracted by json or logfmt, like | json status="response.status"
+func (p *parser) parseExtractions() {
+	if p.peek().typ != tokenIdentifier {
+		return
+	}
+	for {
+		p.expectIdentifier("a label name")
+		if p.peek().is(tokenOperator, "=") {
+			p.next()
+			p.expectString()
+		}
+		if !p.peek().is(tokenOperator, ",") {
+			return
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseLabelFormat() {
+	for {
+		p.expectIdentifier("a label name")
+		p.expect(tokenOperator, "=")
+		if t := p.next(); t.typ != tokenString && t.typ != tokenIdentifier {
+			p.unexpected(t, "a label name or a template")
+		}
+		if !p.peek().is(tokenOperator, ",") {
+			return
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseDropKeep() {
+	for {
+		p.expectIdentifier("a label name")
+		if isMatcherOp(p.peek()) {
+			p.next()
+			p.expectString()
+		}
+		if !p.peek().is(tokenOperator, ",") {
+			return
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseUnwrap() {
+	t := p.next()
+	if !p.inRange {
+		p.fail(t, "unwrap can only be used in range aggregations like sum_over_time")
+	}
+	label := p.expectIdentifier("a label name")
+	switch label.val {
+	case "duration", "duration_seconds", "bytes":
+		if p.peek().is(tokenOperator, "(") {
+			p.next()
+			p.expectIdentifier("a label name")
+			p.expect(tokenOperator, ")")
+		}
+	}
+	p.unwrapped = true
+}
+
+func (p *parser) parseLabelFilterOr() {
+	p.parseLabelFilterAnd()
+	for p.peek().is(tokenIdentifier, "or") {
+		p.next()
+		p.parseLabelFilterAnd()
+	}
+}
+
+func (p *parser) parseLabelFilterAnd() {
+	p.parseLabelFilter()
+	for {
+		t := p.peek()
+		switch {
+		case t.is(tokenIdentifier, "and") || t.is(tokenOperator, ","):
+			p.next()
+		case t.typ == tokenIdentifier && isComparisonOp(p.peekAt(1)):
+			// label filters separated by spaces are joined with and
+		default:
+			return
+		}
+		p.parseLabelFilter()
+	}
+}
+
+func (p *parser) parseLabelFilter() {
+	if p.peek().is(tokenOperator, "(") {
+		p.next()
+		p.parseLabelFilterOr()
+		p.expect(tokenOperator, ")")
+		return
+	}
+
+	name := p.expectIdentifier(`a label filter like level="error"`)
+	op := p.next()
+	if !isComparisonOp(op) {
+		p.unexpected(op, "a comparison operator")
+	}
+	value := p.peek()
+	switch {
+	case value.typ == tokenString:
+		p.next()
+		unquoted := p.unquote(value)
+		if isRegexOp(op.val) {
+			p.checkRegex(value, unquoted)
+		}
+		p.result.labelFilters = append(p.result.labelFilters, newMatcher(name, op, value, unquoted))
+	case value.is(tokenIdentifier, "ip"):
+		if op.val != "=" && op.val != "!=" {
+			p.fail(op, "ip() can only be used with = and !=")
+		}
+		p.parseIP()
+	case value.typ == tokenNumber || value.typ == tokenDuration || value.typ == tokenBytes || value.typ == tokenVariable,
+		value.is(tokenOperator, "-") && (p.peekAt(1).typ == tokenNumber || p.peekAt(1).typ == tokenDuration):
+		if isRegexOp(op.val) {
+			p.fail(op, "regular expressions can only be used with quoted strings")
+		}
+		if value.typ == tokenOperator {
+			p.next()
+		}
+		p.next()
+	default:
+		p.unexpected(value, "a value")
+	}
+}
+
+func (p *parser) parseParameter() {
+	if t := p.next(); t.typ != tokenNumber && t.typ != tokenVariable {
+		p.unexpected(t, "a number")
+	}
+}
+
+func (p *parser) parseGrouping() bool {
+	if t := p.peek(); !t.is(tokenIdentifier, "by") && !t.is(tokenIdentifier, "without") {
+		return false
+	}
+	p.next()
+	p.parseLabelList()
+	return true
+}
+
+func (p *parser) parseRange() {
+	p.expect(tokenOperator, "[")
+	if t := p.next(); t.typ != tokenDuration && t.typ != tokenVariable {
+		p.unexpected(t, "a duration like 5m")
+	}
+	p.expect(tokenOperator, "]")
+	if p.peek().is(tokenIdentifier, "offset") {
+		p.next()
+		if t := p.next(); t.typ != tokenDuration && t.typ != tokenVariable {
+			p.unexpected(t, "a duration like 1h")
+		}
+	}
+}
+
+func (p *parser) parseRangeAggregation() exprKind {
+	name := p.next()
+	rule := rangeAggregations[name.val]
+	p.expect(tokenOperator, "(")
+	if name.val == "quantile_over_time" {
+		p.parseParameter()
+		p.expect(tokenOperator, ",")
+	}
+
+	start := p.peek()
+	if !start.is(tokenOperator, "{") {
+		p.missingSelector(start)
+		p.unexpected(start, fmt.Sprintf(`a log query like %s({app="foo"}[5m])`, name.val))
+	}
+	p.parseSelector()
+	// the range can also be written before the pipeline
+	hasRange := p.peek().is(tokenOperator, "[")
+	if hasRange {
+		p.parseRange()
+	}
+	p.inRange, p.unwrapped = true, false
+	p.parsePipeline()
+	p.inRange = false
+
+	if !hasRange {
+		t := p.peek()
+		if t.is(tokenOperator, ")") {
+			end := p.prevEnd()
+			panic(&Issue{
+				Code:     CodeMissingRange,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s needs a range, like [5m] or [$__interval]", name.val),
+				Start:    start.pos,
+				End:      end,
+				Fix:      p.query[:end] + " [$__interval]" + p.query[end:],
+			})
+		}
+		if !t.is(tokenOperator, "[") {
+			p.unexpected(t, "a range like [5m]")
+		}
+		p.parseRange()
+	}
+
+	switch {
+	case rule == unwrapRequired && !p.unwrapped:
+		p.fail(name, "%s needs an unwrapped label, like | unwrap bytes", name.val)
+	case rule == unwrapForbidden && p.unwrapped:
+		p.fail(name, "%s can't be used with unwrap", name.val)
+	}
+	p.expect(tokenOperator, ")")
+	p.parseGrouping()
+	return kindMetric
+}
+
+func (p *parser) parseVectorAggregation() exprKind {
+	name := p.next()
+	grouped := p.parseGrouping()
+	p.expect(tokenOperator, "(")
+	if name.val == "topk" || name.val == "bottomk" {
+		p.parseParameter()
+		p.expect(tokenOperator, ",")
+	}
+
+	start := p.peek()
+	if kind := p.parseExpr(0); kind == kindLog {
+		p.missingRangeAggregation(start, fmt.Sprintf("%s can only aggregate metric queries", name.val))
+	}
+	p.expect(tokenOperator, ")")
+	if !grouped {
+		p.parseGrouping()
+	}
+	return kindMetric
+}
+
+func (p *parser) parseLabelReplace() exprKind {
+	p.next()
+	p.expect(tokenOperator, "(")
+	start := p.peek()
+	kind := p.parseExpr(0)
+	if kind == kindLog {
+		p.missingRangeAggregation(start, "label_replace can only be used with metric queries")
+	}
+	for i := 0; i < 4; i++ {
+		p.expect(tokenOperator, ",")
+		p.expectString()
+	}
+	p.expect(tokenOperator, ")")
+	return kind
+}
@@ -0,0 +1,138 @@
+package logql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLex(t *testing.T) {
+	tokens, err := lex("rate({app=\"foo\"} |~ `a\"b` [5m]) > 1.5e+3 # comment\n or vector($x)")
+	require.NoError(t, err)
+
+	var values []string
+	for _, tok := range tokens[:len(tokens)-1] {
+		values = append(values, tok.val)
+	}
+	assert.Equal(t, []string{"rate", "(", "{", "app", "=", `"foo"`, "}", "|~", "`a\"b`", "[", "5m", "]", ")", ">", "1.5e+3", "or", "vector", "(", "$x", ")"}, values)
+	assert.Equal(t, tokenDuration, tokens[10].typ)
+	assert.Equal(t, tokenNumber, tokens[14].typ)
+	assert.Equal(t, tokenEOF, tokens[len(tokens)-1].typ)
+
+	tokens, err = lex(`500µs 10MB 1h30m ${__interval}`)
+	require.NoError(t, err)
+	assert.Equal(t, []tokenType{tokenDuration, tokenBytes, tokenDuration, tokenVariable, tokenEOF}, []tokenType{tokens[0].typ, tokens[1].typ, tokens[2].typ, tokens[3].typ, tokens[4].typ})
+
+	_, err = lex(`{app="foo}`)
+	assert.EqualError(t, err, "unterminated string")
+	_, err = lex(`{app="foo"} @ 1`)
+	assert.EqualError(t, err, `unexpected character '@'`)
+}
+
+func TestParseValidQueries(t *testing.T) {
+	for _, query := range []string{
+		`{app="foo"}`,
+		`{app="foo", env!="dev", pod=~"api-.*", team!~"a|b"}`,
+		`{app="foo"} |= "error" != "timeout" |~ "(?i)fail" !~ "debug"`,
+		`{app="foo"} |= "a" or "b" |= ip("10.0.0.0/8")`,
+		`{app="foo"} | json | status >= 500 and duration > 1s or level="error"`,
+		`{app="foo"} | json status="response.status", method | logfmt --strict --keep-empty`,
+		`{app="foo"} | logfmt | (level="error" or level="warn"), size > 10MB`,
+		`{app="foo"} | regexp "(?P<method>\\w+)" | pattern "<ip> - <_>" | unpack | decolorize`,
+		"{app=\"foo\"} | line_format `{{.msg}}` | label_format level=lvl, msg=\"{{.message}}\"",
+		`{app="foo"} | drop __error__, level="debug" | keep app | addr = ip("10.0.0.1") | latency < -1.5`,
+		`rate({app="foo"}[5m])`,
+		`count_over_time({app="foo"} |= "error" [$__interval] offset 1h)`,
+		`count_over_time({app="foo"}[5m] | json)`,
+		`sum by (level) (rate({app="foo"} | json [5m]))`,
+		`sum(rate({app="foo"}[5m])) without (pod)`,
+		`topk(10, sum by (pod) (bytes_rate({app="foo"}[1m])))`,
+		`quantile_over_time(0.99, {app="foo"} | json | unwrap duration(latency) | __error__="" [5m]) by (pod)`,
+		`avg_over_time({app="foo"} | logfmt | unwrap bytes [5m])`,
+		`sum(rate({app="foo"}[5m])) / sum(rate({app="bar"}[5m])) * 100`,
+		`sum(rate({app="foo"}[5m])) > bool 1 or vector(0)`,
+		`sum by (pod) (rate({app="foo"}[5m])) / on (pod) group_left (node) sum by (pod, node) (rate({app="bar"}[5m]))`,
+		`label_replace(rate({app="foo"}[5m]), "dst", "$1", "src", "(.*)")`,
+		`2 ^ 3 ^ 2 - -1`,
+		`sum(count_over_time({app="foo"}[$__auto])) by (level) + $offset`,
+		`{app="foo"} |~ "$search"`,
+		`sort_desc(sum(rate({app="foo"}[5m])))`,
+	} {
+		_, issue := parse(query)
+		assert.Nil(t, issue, query)
+	}
+}
+
+func TestParseInvalidQueries(t *testing.T) {
+	for _, tc := range []struct {
+		query   string
+		code    string
+		message string
+		start   int
+		fix     string
+	}{
+		{query: ``, code: CodeSyntaxError, message: "query is empty"},
+		{query: `{}`, code: CodeSyntaxError, message: `stream selectors need at least one label matcher, like {app="foo"}`, start: 1},
+		{query: `{app="foo"`, code: CodeSyntaxError, message: `unexpected end of query, expected "}"`, start: 10},
+		{query: `{app=foo}`, code: CodeSyntaxError, message: `unexpected "foo", expected a quoted string`, start: 5},
+		{query: `{app=~"(foo"}`, code: CodeSyntaxError, message: `invalid regular expression "(foo": missing closing ): ` + "`(foo`", start: 6},
+		{query: `{app="foo"} | json | unwrap bytes`, code: CodeSyntaxError, message: "unwrap can only be used in range aggregations like sum_over_time", start: 21},
+		{query: `sum_over_time({app="foo"}[5m])`, code: CodeSyntaxError, message: "sum_over_time needs an unwrapped label, like | unwrap bytes"},
+		{query: `count_over_time({app="foo"} | unwrap bytes [5m])`, code: CodeSyntaxError, message: "count_over_time can't be used with unwrap"},
+		{query: `{app="foo"} + 1`, code: CodeSyntaxError, message: "log queries can't be used in binary operations, use a metric query like count_over_time({...}[$__interval])", start: 12},
+		{query: `rate({app="foo"}[5m]) + bool 1`, code: CodeSyntaxError, message: "bool can only be used with comparison operators", start: 24},
+		{query: `{app="foo"} | logfmt --fast`, code: CodeSyntaxError, message: "unknown parser flag --fast", start: 23},
+		{query: `rate({app="foo"}[5])`, code: CodeSyntaxError, message: `unexpected "5", expected a duration like 5m`, start: 17},
+		{
+			query:   `app="foo" |= "error"`,
+			code:    CodeMissingStreamSelector,
+			message: `log queries must start with a stream selector, label matchers must be in braces like {app="foo"}`,
+			fix:     `{app="foo"} |= "error"`,
+		},
+		{
+			query:   `rate(app="foo", env="prod" [5m])`,
+			code:    CodeMissingStreamSelector,
+			message: `log queries must start with a stream selector, label matchers must be in braces like {app="foo"}`,
+			start:   5,
+			fix:     `rate({app="foo", env="prod"} [5m])`,
+		},
+		{
+			query:   `|= "error"`,
+			code:    CodeMissingStreamSelector,
+			message: `log queries must start with a stream selector, like {app="foo"}`,
+		},
+		{
+			query:   `sum by (level) (rate({app="foo"} | json))`,
+			code:    CodeMissingRange,
+			message: "rate needs a range, like [5m] or [$__interval]",
+			start:   21,
+			fix:     `sum by (level) (rate({app="foo"} | json [$__interval]))`,
+		},
+		{
+			query:   `sum by (level) ({app="foo"} | json)`,
+			code:    CodeMissingRangeAggregation,
+			message: `sum can only aggregate metric queries, use a range aggregation like count_over_time({app="foo"}[$__interval])`,
+			start:   16,
+			fix:     `sum by (level) (count_over_time({app="foo"} | json [$__interval]))`,
+		},
+		{
+			query:   `{app="foo"}[5m]`,
+			code:    CodeMissingRangeAggregation,
+			message: `ranges can only be used in range aggregations, use a range aggregation like count_over_time({app="foo"}[$__interval])`,
+			fix:     `count_over_time({app="foo"}[5m])`,
+		},
+	} {
+		_, issue := parse(tc.query)
+		require.NotNil(t, issue, tc.query)
+		assert.Equal(t, tc.code, issue.Code, tc.query)
+		assert.Equal(t, SeverityError, issue.Severity, tc.query)
+		assert.Equal(t, tc.message, issue.Message, tc.query)
+		assert.Equal(t, tc.start, issue.Start, tc.query)
+		assert.Equal(t, tc.fix, issue.Fix, tc.query)
+		if tc.fix != "" {
+			_, issue := parse(tc.fix)
+			assert.Nil(t, issue, tc.fix)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
@@ -51,12 +52,29 @@ var (
 type datasourceInfo struct {
 	HTTPClient *http.Client
 	URL        string
+	// ShardParallelism is the number of queries run in parallel for the shards of a metric query
+	ShardParallelism int
+
+	// indexCache keeps the responses of the index resources
+	indexCache *indexCache
+	// scrubber scrubs the log lines before they are returned, it is nil when the data source doesn't scrub them
+	scrubber *lineScrubber
+	// derivedFields adds the derived fields to the logs frames, it is nil when the data source has none
+	derivedFields *derivedFields
+	// deleteRequests caches the delete requests of the data source, noticed on the queries of their time range
+	deleteRequests *deleteRequestsCache
 
 	// open streams
 	streams   map[string]data.FrameJSONCache
 	streamsMu sync.RWMutex
 }
 
+type jsonData struct {
+	ShardParallelism *int                   `json:"shardParallelism"`
+	Scrubbing        scrubbingSettings      `json:"scrubbing"`
+	DerivedFields    []derivedFieldSettings `json:"derivedFields"`
+}
+
 type QueryJSONModel struct {
 	dataquery.LokiDataQuery
 	Direction           *string `json:"direction,omitempty"`
@@ -81,10 +99,36 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		jd := jsonData{}
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jd); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+		shardParallelism := defaultShardParallelism
+		if jd.ShardParallelism != nil {
+			shardParallelism = *jd.ShardParallelism
+		}
+
+		scrubber, err := newLineScrubber(jd.Scrubbing)
+		if err != nil {
+			return nil, fmt.Errorf("error reading scrubbing settings: %w", err)
+		}
+
+		derived, skipped := newDerivedFields(jd.DerivedFields)
+		if len(skipped) > 0 {
+			logger.Info("Derived fields extracted by the frontend", "datasource", settings.UID, "fields", skipped)
+		}
+
 		model := &datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
-			streams:    make(map[string]data.FrameJSONCache),
+			HTTPClient:       client,
+			URL:              settings.URL,
+			ShardParallelism: shardParallelism,
+			indexCache:       newIndexCache(),
+			streams:          make(map[string]data.FrameJSONCache),
+			scrubber:         scrubber,
+			derivedFields:    derived,
+			deleteRequests:   newDeleteRequestsCache(),
 		}
 		return model, nil
 	}
@@ -105,10 +149,15 @@ func callResource(ctx context.Context, req *backend.CallResourceRequest, sender
 	if req.Method != "GET" {
 		return fmt.Errorf("invalid resource method: %s", req.Method)
 	}
+	if strings.HasPrefix(url, "validate?") {
+		return validateQueryResource(url, sender)
+	}
+	if strings.HasPrefix(url, "index/stats?") || strings.HasPrefix(url, "index/volume?") {
+		return indexResource(ctx, url, sender, dsInfo, plog, tracer)
+	}
 	if (!strings.HasPrefix(url, "labels?")) &&
 		(!strings.HasPrefix(url, "label/")) && // the `/label/$label_name/values` form
-		(!strings.HasPrefix(url, "series?")) &&
-		(!strings.HasPrefix(url, "index/stats?")) {
+		(!strings.HasPrefix(url, "series?")) {
 		return fmt.Errorf("invalid resource URL: %s", url)
 	}
 	lokiURL := fmt.Sprintf("/loki/api/v1/%s", url)
@@ -144,10 +193,14 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		return result, err
 	}
 
-	return queryData(ctx, req, dsInfo, s.tracer)
+	shardParallelism := 0
+	if s.features.IsEnabled(featuremgmt.FlagLokiQuerySharding) {
+		shardParallelism = dsInfo.ShardParallelism
+	}
+	return queryData(ctx, req, dsInfo, s.tracer, shardParallelism)
 }
 
-func queryData(ctx context.Context, req *backend.QueryDataRequest, dsInfo *datasourceInfo, tracer tracing.Tracer) (*backend.QueryDataResponse, error) {
+func queryData(ctx context.Context, req *backend.QueryDataRequest, dsInfo *datasourceInfo, tracer tracing.Tracer, shardParallelism int) (*backend.QueryDataResponse, error) {
 	result := backend.NewQueryDataResponse()
 
 	api := newLokiAPI(dsInfo.HTTPClient, dsInfo.URL, logger.FromContext(ctx))
@@ -170,7 +223,12 @@ func queryData(ctx context.Context, req *backend.QueryDataRequest, dsInfo *datas
 		logger := logger.FromContext(ctx) // get logger with trace-id and other contextual info
 		logger.Debug("Sending query", "start", query.Start, "end", query.End, "step", query.Step, "query", query.Expr)
 
-		frames, err := runQuery(ctx, api, query)
+		var frames data.Frames
+		if shardParallelism > 1 && query.SupportingQueryType == SupportingQueryNone {
+			frames, err = runShardedQuery(ctx, api, query, shardParallelism)
+		} else {
+			frames, err = runQuery(ctx, api, query)
+		}
 
 		span.End()
 		queryRes := backend.DataResponse{}
@@ -178,6 +236,12 @@ func queryData(ctx context.Context, req *backend.QueryDataRequest, dsInfo *datas
 		if err != nil {
 			queryRes.Error = err
 		} else {
+			dsInfo.scrubber.scrubFrames(frames)
+			// the fields are extracted from the scrubbed lines, so that they don't leak the scrubbed secrets
+			dsInfo.derivedFields.addFields(frames)
+			if query.SupportingQueryType == SupportingQueryNone {
+				addNotices(frames, query, dsInfo.deleteRequests.get(ctx, api, time.Now()))
+			}
 			queryRes.Frames = frames
 		}
 
@@ -0,0 +1,137 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// deleteRequestsCacheTTL is how long the delete requests of a data source are reused by the queries
+const deleteRequestsCacheTTL = time.Minute
+
+// deleteRequest is a delete request returned by the /loki/api/v1/delete endpoint of the compactor. The start and the
+// end are in seconds.
+type deleteRequest struct {
+	RequestID string  `json:"request_id"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Query     string  `json:"query"`
+	Status    string  `json:"status"`
+}
+
+func (r deleteRequest) overlaps(start time.Time, end time.Time) bool {
+	return r.StartTime <= float64(end.Unix()) && r.EndTime >= float64(start.Unix())
+}
+
+// deleteRequestsCache keeps the delete requests of a data source, so that the queries don't ask the compactor for
+// every query. Loki filters the lines matched by the delete requests out of the results while they are processed.
+type deleteRequestsCache struct {
+	mu        sync.Mutex
+	fetchedAt time.Time
+	requests  []deleteRequest
+}
+
+func newDeleteRequestsCache() *deleteRequestsCache {
+	return &deleteRequestsCache{}
+}
+
+// get returns the delete requests of the data source. Loki without the compactor API, or without the permission to
+// read it, has no delete request: the queries are not failed because of it, and it isn't asked again before the TTL.
+func (c *deleteRequestsCache) get(ctx context.Context, api *LokiAPI, now time.Time) []deleteRequest {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fetchedAt.IsZero() && now.Sub(c.fetchedAt) < deleteRequestsCacheTTL {
+		return c.requests
+	}
+
+	c.fetchedAt = now
+	c.requests = nil
+	resp, err := api.RawQuery(ctx, "/loki/api/v1/delete")
+	if err != nil {
+		api.log.Debug("Failed to read the delete requests", "error", err)
+		return nil
+	}
+	if resp.Status != http.StatusOK {
+		api.log.Debug("Delete requests not available", "status", resp.Status)
+		return nil
+	}
+	if err := json.Unmarshal(resp.Body, &c.requests); err != nil {
+		api.log.Debug("Failed to parse the delete requests", "error", err)
+		return nil
+	}
+	return c.requests
+}
+
+// addNotices attaches to the frames of a query the notices telling the users that the results are truncated or
+// filtered: the log lines reaching the line limit of the query, and the delete requests of the time range of the
+// query. The warnings returned by Loki are already read with the results.
+func addNotices(frames data.Frames, query *lokiQuery, deletes []deleteRequest) {
+	if len(frames) == 0 {
+		return
+	}
+	var notices []data.Notice
+	if notice, ok := lineLimitNotice(frames, query); ok {
+		notices = append(notices, notice)
+	}
+	if notice, ok := deleteRequestsNotice(deletes, query); ok {
+		notices = append(notices, notice)
+	}
+	if len(notices) == 0 {
+		return
+	}
+	if frames[0].Meta == nil {
+		frames[0].Meta = &data.FrameMeta{}
+	}
+	frames[0].Meta.Notices = append(frames[0].Meta.Notices, notices...)
+}
+
+func lineLimitNotice(frames data.Frames, query *lokiQuery) (data.Notice, bool) {
+	if query.MaxLines <= 0 {
+		return data.Notice{}, false
+	}
+	lines := 0
+	for _, frame := range frames {
+		if line, _ := logsFrameFields(frame); line != nil {
+			lines += line.Len()
+		}
+	}
+	if lines < query.MaxLines {
+		return data.Notice{}, false
+	}
+	side := "newest"
+	if query.Direction == DirectionForward {
+		side = "oldest"
+	}
+	return data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text:     fmt.Sprintf("The query reached the line limit of %d, only the %s lines of the time range are returned. Increase the line limit or narrow the time range to see the other lines.", query.MaxLines, side),
+	}, true
+}
+
+func deleteRequestsNotice(deletes []deleteRequest, query *lokiQuery) (data.Notice, bool) {
+	count := 0
+	for _, r := range deletes {
+		if r.overlaps(query.Start, query.End) {
+			count++
+		}
+	}
+	if count == 0 {
+		return data.Notice{}, false
+	}
+	requests := "delete requests overlap"
+	if count == 1 {
+		requests = "delete request overlaps"
+	}
+	return data.Notice{
+		Severity: data.NoticeSeverityInfo,
+		Text:     fmt.Sprintf("%d %s the time range of the query, the lines they match are not returned.", count, requests),
+	}, true
+}
@@ -0,0 +1,87 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestAddNotices(t *testing.T) {
+	start := time.Unix(1000, 0)
+	end := time.Unix(2000, 0)
+	logsFrame := func(lines ...string) *data.Frame {
+		return data.NewFrame("",
+			data.NewField("labels", nil, make([]json.RawMessage, len(lines))),
+			data.NewField("Line", nil, lines),
+		)
+	}
+
+	t.Run("the line limit is noticed when it is reached", func(t *testing.T) {
+		frames := data.Frames{logsFrame("a"), logsFrame("b")}
+		addNotices(frames, &lokiQuery{MaxLines: 2, Direction: DirectionForward, Start: start, End: end}, nil)
+		require.NotNil(t, frames[0].Meta)
+		assert.Equal(t, []data.Notice{{
+			Severity: data.NoticeSeverityWarning,
+			Text:     "The query reached the line limit of 2, only the oldest lines of the time range are returned. Increase the line limit or narrow the time range to see the other lines.",
+		}}, frames[0].Meta.Notices)
+		assert.Nil(t, frames[1].Meta)
+	})
+
+	t.Run("below the line limit there is no notice", func(t *testing.T) {
+		frames := data.Frames{logsFrame("a")}
+		addNotices(frames, &lokiQuery{MaxLines: 2, Start: start, End: end}, nil)
+		assert.Nil(t, frames[0].Meta)
+	})
+
+	t.Run("the delete requests of the time range are noticed", func(t *testing.T) {
+		frames := data.Frames{data.NewFrame("", data.NewField("Value", nil, []float64{1}))}
+		addNotices(frames, &lokiQuery{MaxLines: 1, Start: start, End: end}, []deleteRequest{
+			{RequestID: "before", StartTime: 100, EndTime: 999},
+			{RequestID: "overlapping", StartTime: 500, EndTime: 1500},
+			{RequestID: "inside", StartTime: 1200, EndTime: 1300},
+		})
+		require.NotNil(t, frames[0].Meta)
+		assert.Equal(t, []data.Notice{{
+			Severity: data.NoticeSeverityInfo,
+			Text:     "2 delete requests overlap the time range of the query, the lines they match are not returned.",
+		}}, frames[0].Meta.Notices, "the line limit doesn't apply to the metric frames")
+	})
+}
+
+func TestDeleteRequestsCache(t *testing.T) {
+	calls := 0
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.Equal(t, "/loki/api/v1/delete", r.URL.Path)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`[{"request_id": "r1", "start_time": 1000, "end_time": 2000, "query": "{app=\"web\"}", "status": "received"}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	api := newLokiAPI(srv.Client(), srv.URL, log.New("test"))
+	cache := newDeleteRequestsCache()
+	now := time.Now()
+
+	expected := []deleteRequest{{RequestID: "r1", StartTime: 1000, EndTime: 2000, Query: `{app="web"}`, Status: "received"}}
+	assert.Equal(t, expected, cache.get(context.Background(), api, now))
+	assert.Equal(t, expected, cache.get(context.Background(), api, now.Add(time.Second)))
+	assert.Equal(t, 1, calls, "the delete requests are cached")
+
+	status = http.StatusNotFound
+	assert.Empty(t, cache.get(context.Background(), api, now.Add(deleteRequestsCacheTTL)))
+	assert.Empty(t, cache.get(context.Background(), api, now.Add(deleteRequestsCacheTTL+time.Second)))
+	assert.Equal(t, 2, calls, "the unavailable delete requests are not asked again before the TTL")
+
+	var none *deleteRequestsCache
+	assert.Nil(t, none.get(context.Background(), api, now))
+}
@@ -0,0 +1,178 @@
+package loki
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	detectorCreditCard = "creditCard"
+	detectorToken      = "token"
+	// scrubbedValue replaces the secrets found in the log lines when the pattern has no replacement
+	scrubbedValue = "[REDACTED]"
+)
+
+var scrubbedLinesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Subsystem: "loki",
+	Name:      "scrubbed_lines_total",
+	Help:      "Number of log lines scrubbed before they were returned, by detector",
+}, []string{"detector"})
+
+var (
+	// cardNumberRegexp matches 13 to 19 digits, optionally grouped with spaces or dashes, the matches are checked
+	// with the Luhn algorithm
+	cardNumberRegexp = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+	tokenPatterns = []scrubPattern{
+		{pattern: regexp.MustCompile(`(?i)\b(bearer|basic)\s+[a-z0-9\-._~+/]+=*`), replacement: "$1 " + scrubbedValue},
+		{pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), replacement: scrubbedValue},
+		{pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), replacement: scrubbedValue},
+		{pattern: regexp.MustCompile(`\bgl(?:pat|SA)_[A-Za-z0-9_-]{20,}|\bgh[pousr]_[A-Za-z0-9]{36,}`), replacement: scrubbedValue},
+		{pattern: regexp.MustCompile(`(?i)\b((?:api[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret|secret|password|passwd|token)["']?\s*[:=]\s*["']?)[^\s"'&,;]+`), replacement: "${1}" + scrubbedValue},
+	}
+)
+
+// scrubbingSettings are the scrubbing options of the data source, read from the scrubbing object of jsonData
+type scrubbingSettings struct {
+	// Detectors are the built-in detectors applied to the log lines, creditCard and token
+	Detectors []string `json:"detectors"`
+	// Patterns are the regular expressions of the data source, applied after the detectors
+	Patterns []scrubPatternSettings `json:"patterns"`
+}
+
+type scrubPatternSettings struct {
+	// Name identifies the pattern in the metrics, it defaults to the position of the pattern
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	// Replacement can refer to the groups of the pattern, it defaults to [REDACTED]
+	Replacement *string `json:"replacement"`
+}
+
+type scrubPattern struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+type scrubDetector struct {
+	name  string
+	scrub func(line string) (string, bool)
+}
+
+// lineScrubber removes the secrets and the personal data from the log lines before they are returned by the data
+// source, for the deployments which must not show them in the browsers
+type lineScrubber struct {
+	detectors []scrubDetector
+}
+
+// newLineScrubber returns nil when the data source doesn't scrub the log lines
+func newLineScrubber(settings scrubbingSettings) (*lineScrubber, error) {
+	s := &lineScrubber{}
+	for _, name := range settings.Detectors {
+		switch name {
+		case detectorCreditCard:
+			s.detectors = append(s.detectors, scrubDetector{name: name, scrub: scrubCardNumbers})
+		case detectorToken:
+			s.detectors = append(s.detectors, scrubDetector{name: name, scrub: patternsScrubber(tokenPatterns)})
+		default:
+			return nil, fmt.Errorf("unknown scrubbing detector %q, expected %s or %s", name, detectorCreditCard, detectorToken)
+		}
+	}
+	for i, p := range settings.Patterns {
+		pattern, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrubbing pattern %d: %w", i+1, err)
+		}
+		replacement := scrubbedValue
+		if p.Replacement != nil {
+			replacement = *p.Replacement
+		}
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("pattern%d", i+1)
+		}
+		s.detectors = append(s.detectors, scrubDetector{name: name, scrub: patternsScrubber([]scrubPattern{{pattern: pattern, replacement: replacement}})})
+	}
+	if len(s.detectors) == 0 {
+		return nil, nil
+	}
+	return s, nil
+}
+
+func patternsScrubber(patterns []scrubPattern) func(string) (string, bool) {
+	return func(line string) (string, bool) {
+		scrubbed := false
+		for _, p := range patterns {
+			if p.pattern.MatchString(line) {
+				line = p.pattern.ReplaceAllString(line, p.replacement)
+				scrubbed = true
+			}
+		}
+		return line, scrubbed
+	}
+}
+
+func scrubCardNumbers(line string) (string, bool) {
+	scrubbed := false
+	line = cardNumberRegexp.ReplaceAllStringFunc(line, func(match string) string {
+		if !luhnValid(match) {
+			return match
+		}
+		scrubbed = true
+		return scrubbedValue
+	})
+	return line, scrubbed
+}
+
+// luhnValid checks the digits of the card number with the Luhn algorithm, so that the other numbers of the log lines,
+// such as the timestamps, are kept
+func luhnValid(number string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// scrubLine applies the detectors in order, and counts the line for each detector that changed it
+func (s *lineScrubber) scrubLine(line string) string {
+	for _, detector := range s.detectors {
+		var scrubbed bool
+		if line, scrubbed = detector.scrub(line); scrubbed {
+			scrubbedLinesTotal.WithLabelValues(detector.name).Inc()
+		}
+	}
+	return line
+}
+
+// scrubFrames scrubs the log lines of the frames, in the Line field of the logs frames
+func (s *lineScrubber) scrubFrames(frames data.Frames) {
+	if s == nil {
+		return
+	}
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if field.Name != "Line" || field.Type() != data.FieldTypeString {
+				continue
+			}
+			for i := 0; i < field.Len(); i++ {
+				field.Set(i, s.scrubLine(field.At(i).(string)))
+			}
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// streamShardLabel is the label Loki adds to the streams it splits in shards
+	streamShardLabel        = "__stream_shard__"
+	defaultShardParallelism = 4
+)
+
+// The aggregations grouped with without are not sharded, since their results would keep the shard label
+var (
+	shardableAggregation = regexp.MustCompile(`^(sum|count|max|min)\s*(by\s*\([^()]*\))?\s*\(`)
+	shardableRange       = regexp.MustCompile(`^(count_over_time|rate|bytes_over_time|bytes_rate|sum_over_time|min_over_time|max_over_time)\s*\(`)
+	trailingGrouping     = regexp.MustCompile(`^by\s*\([^()]*\)$`)
+)
+
+// shardMergeOps is how the results of the shards are merged for each aggregation
+var shardMergeOps = map[string]func(a, b float64) float64{
+	"sum":   func(a, b float64) float64 { return a + b },
+	"count": func(a, b float64) float64 { return a + b },
+	"max": func(a, b float64) float64 {
+		if b > a {
+			return b
+		}
+		return a
+	},
+	"min": func(a, b float64) float64 {
+		if b < a {
+			return b
+		}
+		return a
+	},
+}
+
+// shardableExpr is a metric query that can run on every shard of streams on its own. Only the aggregations of range
+// aggregations over a single stream selector are sharded, as their results can be merged.
+type shardableExpr struct {
+	expr  string
+	merge func(a, b float64) float64
+	// selectorStart and selectorEnd are the positions of the braces of the stream selector
+	selectorStart int
+	selectorEnd   int
+}
+
+// matchingClose returns the position of the bracket closing the one at start, skipping quoted strings
+func matchingClose(expr string, start int) int {
+	open := expr[start]
+	closing := map[byte]byte{'(': ')', '{': '}', '[': ']'}[open]
+	depth := 0
+	for i := start; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case '"', '`':
+			end := skipString(expr, i)
+			if end < 0 {
+				return -1
+			}
+			i = end
+		case open:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// skipString returns the position of the quote closing the string starting at start
+func skipString(expr string, start int) int {
+	quote := expr[start]
+	for i := start + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// braces returns the positions of the braces outside of quoted strings
+func braces(expr string) []int {
+	var positions []int
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '"', '`':
+			end := skipString(expr, i)
+			if end < 0 {
+				return nil
+			}
+			i = end
+		case '{', '}':
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+func parseShardableExpr(expr string) (*shardableExpr, bool) {
+	expr = strings.TrimSpace(expr)
+	m := shardableAggregation.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, false
+	}
+
+	open := len(m[0]) - 1
+	closing := matchingClose(expr, open)
+	if closing < 0 {
+		return nil, false
+	}
+	// the grouping can be written after the aggregation, anything else is a binary operation
+	if rest := strings.TrimSpace(expr[closing+1:]); rest != "" && (m[2] != "" || !trailingGrouping.MatchString(rest)) {
+		return nil, false
+	}
+
+	inner := strings.TrimSpace(expr[open+1 : closing])
+	rm := shardableRange.FindStringIndex(inner)
+	if rm == nil || matchingClose(inner, rm[1]-1) != len(inner)-1 {
+		return nil, false
+	}
+
+	b := braces(expr)
+	if len(b) != 2 || expr[b[0]] != '{' || expr[b[1]] != '}' || strings.Contains(expr[b[0]:b[1]], streamShardLabel) {
+		return nil, false
+	}
+
+	return &shardableExpr{
+		expr:          expr,
+		merge:         shardMergeOps[m[1]],
+		selectorStart: b[0],
+		selectorEnd:   b[1],
+	}, true
+}
+
+func (e *shardableExpr) selector() string {
+	return e.expr[e.selectorStart : e.selectorEnd+1]
+}
+
+// withShards returns the query for the streams of the shards
+func (e *shardableExpr) withShards(shards []string) string {
+	values := make([]string, len(shards))
+	for i, s := range shards {
+		values[i] = regexp.QuoteMeta(s)
+	}
+	matcher := fmt.Sprintf(`%s=~"%s", `, streamShardLabel, strings.Join(values, "|"))
+	return e.expr[:e.selectorStart+1] + matcher + e.expr[e.selectorStart+1:]
+}
+
+// shardGroups splits the shards in at most n groups. The first group also matches the streams without shard label,
+// which are the streams Loki did not split.
+func shardGroups(shards []string, n int) [][]string {
+	sort.Slice(shards, func(i, j int) bool {
+		a, errA := strconv.Atoi(shards[i])
+		b, errB := strconv.Atoi(shards[j])
+		if errA != nil || errB != nil {
+			return shards[i] < shards[j]
+		}
+		return a < b
+	})
+	if n > len(shards) {
+		n = len(shards)
+	}
+
+	groups := make([][]string, n)
+	for i := range groups {
+		groups[i] = shards[i*len(shards)/n : (i+1)*len(shards)/n]
+	}
+	groups[0] = append([]string{""}, groups[0]...)
+	return groups
+}
+
+// streamShards returns the shards of the streams of the selector in the time range of the query
+func (api *LokiAPI) streamShards(ctx context.Context, selector string, query lokiQuery) ([]string, error) {
+	qs := url.Values{}
+	qs.Set("query", selector)
+	qs.Set("start", strconv.FormatInt(query.Start.UnixNano(), 10))
+	qs.Set("end", strconv.FormatInt(query.End.UnixNano(), 10))
+
+	rsp, err := api.RawQuery(ctx, fmt.Sprintf("/loki/api/v1/label/%s/values?%s", streamShardLabel, qs.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	if rsp.Status/100 != 2 {
+		return nil, makeLokiError(rsp.Body)
+	}
+
+	var values struct {
+		Data []string `json:"data"`
+	}
+	if err := json.Unmarshal(rsp.Body, &values); err != nil {
+		return nil, fmt.Errorf("failed to read stream shards: %w", err)
+	}
+	return values.Data, nil
+}
+
+// runShardedQuery runs the metric query on groups of stream shards in parallel and merges the results. Queries that
+// can't be sharded, or whose streams are not sharded, run as a single query.
+func runShardedQuery(ctx context.Context, api *LokiAPI, query *lokiQuery, parallelism int) (data.Frames, error) {
+	expr, ok := parseShardableExpr(query.Expr)
+	if !ok || parallelism < 2 {
+		return runQuery(ctx, api, query)
+	}
+
+	shards, err := api.streamShards(ctx, expr.selector(), *query)
+	if err != nil {
+		api.log.Warn("Failed to get stream shards, running query without sharding", "err", err)
+		return runQuery(ctx, api, query)
+	}
+	if len(shards) < 2 {
+		return runQuery(ctx, api, query)
+	}
+
+	groups := shardGroups(shards, parallelism)
+	results := make([]data.Frames, len(groups))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, group := range groups {
+		i, shardQuery := i, *query
+		shardQuery.Expr = expr.withShards(group)
+		g.Go(func() error {
+			frames, err := api.DataQuery(gctx, shardQuery)
+			results[i] = frames
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return data.Frames{}, err
+	}
+
+	frames, err := mergeShardFrames(results, expr.merge)
+	if err != nil {
+		return data.Frames{}, err
+	}
+	for _, frame := range frames {
+		if err := adjustFrame(frame, query); err != nil {
+			return data.Frames{}, err
+		}
+	}
+	return frames, nil
+}
+
+type mergedSeries struct {
+	frame  *data.Frame
+	values map[int64]float64
+}
+
+// mergeShardFrames merges the series with the same labels of the shard results
+func mergeShardFrames(results []data.Frames, merge func(a, b float64) float64) (data.Frames, error) {
+	series := map[string]*mergedSeries{}
+	var keys []string
+	for _, frames := range results {
+		for _, frame := range frames {
+			if len(frame.Fields) != 2 || frame.Fields[0].Type() != data.FieldTypeTime || frame.Fields[1].Type() != data.FieldTypeFloat64 {
+				return nil, fmt.Errorf("unexpected frame in sharded query result")
+			}
+			key := frame.Fields[1].Labels.String()
+			s, ok := series[key]
+			if !ok {
+				s = &mergedSeries{frame: frame, values: map[int64]float64{}}
+				series[key] = s
+				keys = append(keys, key)
+			}
+			for i := 0; i < frame.Rows(); i++ {
+				t := frame.Fields[0].At(i).(time.Time).UnixNano()
+				v := frame.Fields[1].At(i).(float64)
+				if prev, ok := s.values[t]; ok {
+					v = merge(prev, v)
+				}
+				s.values[t] = v
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	frames := make(data.Frames, 0, len(keys))
+	for _, key := range keys {
+		s := series[key]
+		timestamps := make([]int64, 0, len(s.values))
+		for t := range s.values {
+			timestamps = append(timestamps, t)
+		}
+		sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+		times := make([]time.Time, len(timestamps))
+		values := make([]float64, len(timestamps))
+		for i, t := range timestamps {
+			times[i] = time.Unix(0, t).UTC()
+			values[i] = s.values[t]
+		}
+
+		timeField := data.NewField(s.frame.Fields[0].Name, nil, times)
+		valueField := data.NewField(s.frame.Fields[1].Name, s.frame.Fields[1].Labels, values)
+		frame := data.NewFrame(s.frame.Name, timeField, valueField)
+		frame.Meta = s.frame.Meta
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
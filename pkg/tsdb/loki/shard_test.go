@@ -0,0 +1,133 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestParseShardableExpr(t *testing.T) {
+	for _, tc := range []struct {
+		expr      string
+		shardable bool
+	}{
+		{expr: `sum(rate({app="foo"}[5m]))`, shardable: true},
+		{expr: `sum by (level) (count_over_time({app="foo"} |= "error" | json [1m]))`, shardable: true},
+		{expr: `max(bytes_rate({app="foo"}[1m])) by (pod)`, shardable: true},
+		{expr: `sum(rate({app="foo"} | line_format "{{.msg}}" [5m]))`, shardable: true},
+		{expr: `count(sum_over_time({app="foo"} | unwrap bytes [5m]))`, shardable: true},
+		{expr: `rate({app="foo"}[5m])`, shardable: false},
+		{expr: `avg(rate({app="foo"}[5m]))`, shardable: false},
+		{expr: `sum(avg_over_time({app="foo"} | unwrap bytes [5m]))`, shardable: false},
+		{expr: `sum(rate({app="foo"}[5m])) / sum(rate({app="bar"}[5m]))`, shardable: false},
+		{expr: `sum(rate({app="foo"}[5m])) > 1`, shardable: false},
+		{expr: `sum by (pod) (rate({app="foo"}[5m])) by (pod)`, shardable: false},
+		// the results grouped without some labels would have the shard label
+		{expr: `max(bytes_rate({app="foo"}[1m])) without (pod)`, shardable: false},
+		{expr: `sum without (pod) (rate({app="foo"}[5m]))`, shardable: false},
+		{expr: `sum(rate({app="foo", __stream_shard__="1"}[5m]))`, shardable: false},
+		{expr: `{app="foo"}`, shardable: false},
+	} {
+		_, ok := parseShardableExpr(tc.expr)
+		assert.Equal(t, tc.shardable, ok, tc.expr)
+	}
+
+	expr, ok := parseShardableExpr(`sum by (level) (rate({app="foo"} | json [5m]))`)
+	require.True(t, ok)
+	assert.Equal(t, `{app="foo"}`, expr.selector())
+	assert.Equal(t, `sum by (level) (rate({__stream_shard__=~"|0|1", app="foo"} | json [5m]))`, expr.withShards([]string{"", "0", "1"}))
+}
+
+func TestShardGroups(t *testing.T) {
+	assert.Equal(t, [][]string{{"", "0", "1"}, {"2", "3"}, {"10", "11"}}, shardGroups([]string{"10", "2", "0", "11", "3", "1"}, 3))
+	assert.Equal(t, [][]string{{"", "0"}, {"1"}, {"2"}, {"3"}, {"4"}}, shardGroups([]string{"0", "1", "2", "3", "4"}, 8))
+	assert.Equal(t, [][]string{{"", "0", "1"}, {"2", "3", "4"}}, shardGroups([]string{"0", "1", "2", "3", "4"}, 2))
+}
+
+func matrixResponse(series map[string][]float64) string {
+	var results []string
+	for labels, values := range series {
+		var points []string
+		for i, v := range values {
+			points = append(points, fmt.Sprintf(`[%d, "%g"]`, 1000+i*60, v))
+		}
+		results = append(results, fmt.Sprintf(`{"metric": {%s}, "values": [%s]}`, labels, strings.Join(points, ",")))
+	}
+	return fmt.Sprintf(`{"status": "success", "data": {"resultType": "matrix", "result": [%s]}}`, strings.Join(results, ","))
+}
+
+func TestRunShardedQuery(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/loki/api/v1/label/__stream_shard__/values") {
+			assert.Equal(t, `{app="foo"}`, r.URL.Query().Get("query"))
+			_, _ = w.Write([]byte(`{"status": "success", "data": ["0", "1", "2", "3"]}`))
+			return
+		}
+
+		query := r.URL.Query().Get("query")
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+		switch {
+		case strings.Contains(query, `__stream_shard__=~"|0|1"`):
+			_, _ = w.Write([]byte(matrixResponse(map[string][]float64{`"level": "error"`: {1, 2}, `"level": "info"`: {5}})))
+		case strings.Contains(query, `__stream_shard__=~"2|3"`):
+			_, _ = w.Write([]byte(matrixResponse(map[string][]float64{`"level": "error"`: {3, 4, 7}})))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message": "unexpected query"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	api := newLokiAPI(srv.Client(), srv.URL, log.New("test"))
+
+	query := &lokiQuery{
+		Expr:      `sum by (level) (count_over_time({app="foo"}[1m]))`,
+		QueryType: QueryTypeRange,
+		Direction: DirectionBackward,
+		Step:      time.Minute,
+		Start:     time.Unix(1000, 0),
+		End:       time.Unix(1200, 0),
+		RefID:     "A",
+	}
+
+	frames, err := runShardedQuery(context.Background(), api, query, 2)
+	require.NoError(t, err)
+	assert.Len(t, queries, 2)
+
+	require.Len(t, frames, 2)
+	assert.Equal(t, "error", frames[0].Fields[1].Labels["level"])
+	assert.Equal(t, 3, frames[0].Rows())
+	for i, expected := range []float64{4, 6, 7} {
+		assert.Equal(t, expected, frames[0].Fields[1].At(i))
+	}
+	assert.Equal(t, "info", frames[1].Fields[1].Labels["level"])
+	assert.Equal(t, 5.0, frames[1].Fields[1].At(0))
+	assert.Equal(t, "Expr: "+query.Expr+"\nStep: 1m0s", frames[0].Meta.ExecutedQueryString)
+
+	t.Run("a failed shard fails the query", func(t *testing.T) {
+		_, err := runShardedQuery(context.Background(), api, query, 4)
+		assert.Error(t, err)
+	})
+
+	t.Run("queries that can't be sharded are not split", func(t *testing.T) {
+		queries = nil
+		q := *query
+		q.Expr = `count_over_time({app="foo"}[1m])`
+		_, err := runShardedQuery(context.Background(), api, &q, 2)
+		require.Error(t, err)
+		assert.Equal(t, []string{q.Expr}, queries)
+	})
+}
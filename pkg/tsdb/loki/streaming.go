@@ -15,6 +15,15 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/backpressure"
+)
+
+const (
+	// downsampleMaxRowsPerSecond is the number of log lines sent every second when the subscribers are slow
+	downsampleMaxRowsPerSecond = 100
+	// throttleWindow is the window of the lines sent when downsampling, only one message is sent every window when
+	// dropping data
+	throttleWindow = time.Second
 )
 
 func (s *Service) SubscribeStream(_ context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
@@ -120,6 +129,8 @@ func (s *Service) RunStream(ctx context.Context, req *backend.RunStreamRequest,
 	}()
 
 	prev := data.FrameJSONCache{}
+	signals := backpressure.FromContext(ctx)
+	throttle := &tailThrottle{}
 
 	// Read all messages
 	done := make(chan struct{})
@@ -140,18 +151,26 @@ func (s *Service) RunStream(ctx context.Context, req *backend.RunStreamRequest,
 			}
 
 			if err == nil && frame != nil {
-				next, _ := data.FrameToJSONCache(frame)
-				if next.SameSchema(&prev) {
-					err = sender.SendBytes(next.Bytes(data.IncludeDataOnly))
-				} else {
-					err = sender.SendFrame(frame, data.IncludeAll)
-				}
-				prev = next
+				dsInfo.scrubber.scrubFrames(data.Frames{frame})
+				// the lines are read from the websocket even when they are not sent, so Loki does not buffer them
+				if frame = throttle.apply(frame, signals.Load().Hint, time.Now()); frame != nil {
+					next, _ := data.FrameToJSONCache(frame)
+					if next.SameSchema(&prev) {
+						err = sender.SendBytes(next.Bytes(data.IncludeDataOnly))
+					} else {
+						err = sender.SendFrame(frame, data.IncludeAll)
+					}
+					prev = next
+
+					// Cache the initial data
+					dsInfo.streamsMu.Lock()
+					dsInfo.streams[req.Path] = prev
+					dsInfo.streamsMu.Unlock()
 
-				// Cache the initial data
-				dsInfo.streamsMu.Lock()
-				dsInfo.streams[req.Path] = prev
-				dsInfo.streamsMu.Unlock()
+					if dropped := throttle.takeDropped(); dropped > 0 {
+						logger.Debug("Dropped tail lines, the subscribers were too slow", "dropped", dropped)
+					}
+				}
 			}
 
 			if err != nil {
@@ -184,3 +203,65 @@ func (s *Service) PublishStream(_ context.Context, _ *backend.PublishStreamReque
 		Status: backend.PublishStreamStatusPermissionDenied,
 	}, nil
 }
+
+// tailThrottle reduces the lines sent by the tail stream following the backpressure hint of its channel
+type tailThrottle struct {
+	windowStart time.Time
+	// messages and rows are the messages and lines sent in the current window
+	messages int
+	rows     int
+	// dropped is the number of lines dropped since the last call of takeDropped
+	dropped int
+}
+
+// apply returns the frame to send, with part of the lines when downsampling, or nil when the frame is dropped
+func (t *tailThrottle) apply(frame *data.Frame, hint backpressure.Hint, now time.Time) *data.Frame {
+	if now.Sub(t.windowStart) >= throttleWindow {
+		t.windowStart, t.messages, t.rows = now, 0, 0
+	}
+	rows, err := frame.RowLen()
+	if err != nil {
+		return frame
+	}
+
+	switch hint {
+	case backpressure.HintPause:
+		t.dropped += rows
+		return nil
+	case backpressure.HintDrop:
+		if t.messages > 0 {
+			t.dropped += rows
+			return nil
+		}
+	case backpressure.HintDownsample:
+		budget := downsampleMaxRowsPerSecond - t.rows
+		if budget <= 0 {
+			t.dropped += rows
+			return nil
+		}
+		if rows > budget {
+			frame = headRows(frame, budget)
+			t.dropped += rows - budget
+			rows = budget
+		}
+	}
+
+	t.messages++
+	t.rows += rows
+	return frame
+}
+
+func (t *tailThrottle) takeDropped() int {
+	dropped := t.dropped
+	t.dropped = 0
+	return dropped
+}
+
+// headRows returns a copy of the frame with its first n rows
+func headRows(frame *data.Frame, n int) *data.Frame {
+	head := frame.EmptyCopy()
+	for i := 0; i < n; i++ {
+		head.AppendRow(frame.RowCopy(i)...)
+	}
+	return head
+}
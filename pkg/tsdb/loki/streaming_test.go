@@ -0,0 +1,69 @@
+package loki
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/backpressure"
+)
+
+func testTailFrame(rows int) *data.Frame {
+	times := make([]time.Time, rows)
+	lines := make([]string, rows)
+	for i := 0; i < rows; i++ {
+		times[i] = time.Unix(int64(i), 0)
+		lines[i] = "line"
+	}
+	return data.NewFrame("tail", data.NewField("time", nil, times), data.NewField("line", nil, lines))
+}
+
+func rowLen(t *testing.T, frame *data.Frame) int {
+	t.Helper()
+	n, err := frame.RowLen()
+	require.NoError(t, err)
+	return n
+}
+
+func TestTailThrottle(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should send every line without hint", func(t *testing.T) {
+		throttle := &tailThrottle{}
+		for i := 0; i < 3; i++ {
+			require.Equal(t, 200, rowLen(t, throttle.apply(testTailFrame(200), backpressure.HintNone, now)))
+		}
+		require.Zero(t, throttle.takeDropped())
+	})
+
+	t.Run("should limit the lines sent every second when downsampling", func(t *testing.T) {
+		throttle := &tailThrottle{}
+		require.Equal(t, 60, rowLen(t, throttle.apply(testTailFrame(60), backpressure.HintDownsample, now)))
+		frame := throttle.apply(testTailFrame(60), backpressure.HintDownsample, now)
+		require.Equal(t, 40, rowLen(t, frame))
+		require.Equal(t, time.Unix(39, 0), frame.Fields[0].At(39))
+		require.Nil(t, throttle.apply(testTailFrame(10), backpressure.HintDownsample, now))
+		require.Equal(t, 30, throttle.takeDropped())
+
+		// the next window sends lines again
+		require.Equal(t, 10, rowLen(t, throttle.apply(testTailFrame(10), backpressure.HintDownsample, now.Add(time.Second))))
+	})
+
+	t.Run("should send one message every second when dropping", func(t *testing.T) {
+		throttle := &tailThrottle{}
+		require.NotNil(t, throttle.apply(testTailFrame(5), backpressure.HintDrop, now))
+		require.Nil(t, throttle.apply(testTailFrame(5), backpressure.HintDrop, now.Add(500*time.Millisecond)))
+		require.NotNil(t, throttle.apply(testTailFrame(5), backpressure.HintDrop, now.Add(time.Second)))
+		require.Equal(t, 5, throttle.takeDropped())
+	})
+
+	t.Run("should not send anything when paused", func(t *testing.T) {
+		throttle := &tailThrottle{}
+		require.Nil(t, throttle.apply(testTailFrame(5), backpressure.HintPause, now))
+		require.Nil(t, throttle.apply(testTailFrame(5), backpressure.HintPause, now.Add(time.Second)))
+		require.Equal(t, 10, throttle.takeDropped())
+		require.NotNil(t, throttle.apply(testTailFrame(5), backpressure.HintNone, now.Add(2*time.Second)))
+	})
+}
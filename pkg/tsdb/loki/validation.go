@@ -0,0 +1,57 @@
+package loki
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/tsdb/loki/logql"
+)
+
+// validateQueryResource answers the validate?query=... resource call. The query is checked by the plugin, without
+// calling Loki, so that the editor can show the problems while the query is typed.
+func validateQueryResource(resourceURL string, sender backend.CallResourceResponseSender) error {
+	u, err := url.Parse(resourceURL)
+	if err != nil {
+		return fmt.Errorf("invalid resource URL: %s", resourceURL)
+	}
+
+	body, err := json.Marshal(logql.Validate(u.Query().Get("query")))
+	if err != nil {
+		return err
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status: http.StatusOK,
+		Headers: map[string][]string{
+			"content-type": {"application/json"},
+		},
+		Body: body,
+	})
+}
+
+// ValidateQuery implements plugins.QueryValidator, the provisioned dashboards are checked with it
+func (s *Service) ValidateQuery(query map[string]interface{}) []string {
+	return validateQuery(query)
+}
+
+// validateQuery lints the expression of a stored Loki query and returns its errors and warnings, the suggestions are
+// left to the query editor.
+func validateQuery(query map[string]interface{}) []string {
+	expr, _ := query["expr"].(string)
+	if strings.TrimSpace(expr) == "" {
+		return nil
+	}
+
+	var issues []string
+	for _, issue := range logql.Validate(expr).Issues {
+		if issue.Severity == logql.SeverityInfo {
+			continue
+		}
+		issues = append(issues, fmt.Sprintf("%s: %s", issue.Code, issue.Message))
+	}
+	return issues
+}
@@ -0,0 +1,56 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/loki/logql"
+)
+
+type recordingSender struct {
+	responses []*backend.CallResourceResponse
+}
+
+func (s *recordingSender) Send(resp *backend.CallResourceResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func TestValidateQueryResource(t *testing.T) {
+	sender := &recordingSender{}
+	req := &backend.CallResourceRequest{
+		Method: "GET",
+		URL:    "validate?query=" + url.QueryEscape(`sum(rate({app=~".+"}))`),
+	}
+	// the query is validated without calling Loki
+	err := callResource(context.Background(), req, sender, &datasourceInfo{}, log.New("test"), nil)
+	require.NoError(t, err)
+	require.Len(t, sender.responses, 1)
+	assert.Equal(t, 200, sender.responses[0].Status)
+	assert.Equal(t, []string{"application/json"}, sender.responses[0].Headers["content-type"])
+
+	var result logql.Result
+	require.NoError(t, json.Unmarshal(sender.responses[0].Body, &result))
+	assert.False(t, result.Valid)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, logql.CodeMissingRange, result.Issues[0].Code)
+	assert.Equal(t, `sum(rate({app=~".+"} [$__interval]))`, result.Issues[0].Fix)
+}
+
+func TestValidateQuery(t *testing.T) {
+	assert.Empty(t, validateQuery(map[string]interface{}{"expr": `{app="foo"} |~ "error"`}), "suggestions are not reported")
+	assert.Empty(t, validateQuery(map[string]interface{}{"expr": ""}))
+	assert.Equal(t, []string{
+		`unbounded-regex: app=~".+" matches every stream with the label, use app!="" instead`,
+	}, validateQuery(map[string]interface{}{"expr": `{app=~".+"}`}))
+	assert.Equal(t, []string{
+		`missing-stream-selector: log queries must start with a stream selector, label matchers must be in braces like {app="foo"}`,
+	}, validateQuery(map[string]interface{}{"expr": `app="foo"`}))
+}
@@ -127,6 +127,9 @@ func (m *msSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		}
 		return "", err
 	default:
+		if macro, ok := sqleng.LookupMacro(sqleng.DialectMSSQL, name); ok {
+			return macro(sqleng.MacroContext{Query: query, TimeRange: timeRange}, args)
+		}
 		return "", fmt.Errorf("unknown macro %q", name)
 	}
 }
@@ -138,6 +138,12 @@ func TestMacroEngine(t *testing.T) {
 			require.Equal(t, fmt.Sprintf("select %d", to.UnixNano()), sql)
 		})
 
+		t.Run("interpolate the registered __timeBucket macro", func(t *testing.T) {
+			sql, err := engine.Interpolate(query, timeRange, "SELECT $__timeBucket(time_column, '5m')")
+			require.Nil(t, err)
+			require.Equal(t, "SELECT DATEADD(second, FLOOR(DATEDIFF(second, '1970-01-01', time_column)/300)*300, '1970-01-01')", sql)
+		})
+
 		t.Run("interpolate __unixEpochGroup function", func(t *testing.T) {
 			sql, err := engine.Interpolate(query, timeRange, "SELECT $__unixEpochGroup(time_column,'5m')")
 			require.Nil(t, err)
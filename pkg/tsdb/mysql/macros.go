@@ -133,6 +133,9 @@ func (m *mySQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		}
 		return "", err
 	default:
+		if macro, ok := sqleng.LookupMacro(sqleng.DialectMySQL, name); ok {
+			return macro(sqleng.MacroContext{Query: query, TimeRange: timeRange}, args)
+		}
 		return "", fmt.Errorf("unknown macro %v", name)
 	}
 }
@@ -1,6 +1,7 @@
 package opentsdb
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,16 +9,12 @@ import (
 	"net/http"
 	"net/url"
 	"path"
-	"strconv"
-	"strings"
-	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
-	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/setting"
@@ -36,11 +33,16 @@ func ProvideService(httpClientProvider httpclient.Provider) *Service {
 }
 
 type datasourceInfo struct {
-	HTTPClient *http.Client
-	URL        string
+	HTTPClient     *http.Client
+	URL            string
+	TSDBVersion    int
+	TSDBResolution int
 }
 
-type DsAccess string
+type jsonData struct {
+	TSDBVersion    int `json:"tsdbVersion"`
+	TSDBResolution int `json:"tsdbResolution"`
+}
 
 func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
 	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
@@ -54,202 +56,102 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		jsonData := jsonData{}
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jsonData); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
 		model := &datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
+			HTTPClient:     client,
+			URL:            settings.URL,
+			TSDBVersion:    jsonData.TSDBVersion,
+			TSDBResolution: jsonData.TSDBResolution,
 		}
 
 		return model, nil
 	}
 }
 
+// QueryData sends each query to OpenTSDB on its own, the series of the responses don't tell which query they belong to
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
-	var tsdbQuery OpenTsdbQuery
-
 	logger := logger.FromContext(ctx)
 
-	q := req.Queries[0]
-
-	tsdbQuery.Start = q.TimeRange.From.UnixNano() / int64(time.Millisecond)
-	tsdbQuery.End = q.TimeRange.To.UnixNano() / int64(time.Millisecond)
-
-	for _, query := range req.Queries {
-		metric := s.buildMetric(query)
-		tsdbQuery.Queries = append(tsdbQuery.Queries, metric)
-	}
-
-	// TODO: Don't use global variable
-	if setting.Env == setting.Dev {
-		logger.Debug("OpenTsdb request", "params", tsdbQuery)
-	}
-
 	dsInfo, err := s.getDSInfo(req.PluginContext)
 	if err != nil {
 		return nil, err
 	}
 
-	request, err := s.createRequest(ctx, logger, dsInfo, tsdbQuery)
-	if err != nil {
-		return &backend.QueryDataResponse{}, err
-	}
-
-	res, err := dsInfo.HTTPClient.Do(request)
-	if err != nil {
-		return &backend.QueryDataResponse{}, err
-	}
-
-	defer func() {
-		err := res.Body.Close()
+	result := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		frames, err := s.executeQuery(ctx, logger, dsInfo, query)
 		if err != nil {
-			logger.Warn("failed to close response body", "error", err)
+			result.Responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
 		}
-	}()
-
-	result, err := s.parseResponse(logger, res)
-	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		result.Responses[query.RefID] = backend.DataResponse{Frames: frames}
 	}
-
 	return result, nil
 }
 
-func (s *Service) createRequest(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, data OpenTsdbQuery) (*http.Request, error) {
-	u, err := url.Parse(dsInfo.URL)
+func (s *Service) executeQuery(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, query backend.DataQuery) (data.Frames, error) {
+	model, err := parseQueryModel(query)
 	if err != nil {
 		return nil, err
 	}
-	u.Path = path.Join(u.Path, "api/query")
-
-	postData, err := json.Marshal(data)
+	tsdbQuery, err := buildRequest(query, model, dsInfo)
 	if err != nil {
-		logger.Info("Failed marshaling data", "error", err)
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(string(postData)))
+	postData, err := json.Marshal(tsdbQuery)
 	if err != nil {
-		logger.Info("Failed to create request", "error", err)
+		logger.Info("Failed marshaling data", "error", err)
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	return req, nil
-}
+	// TODO: Don't use global variable
+	if setting.Env == setting.Dev {
+		logger.Debug("OpenTsdb request", "params", string(postData))
+	}
 
-func (s *Service) parseResponse(logger log.Logger, res *http.Response) (*backend.QueryDataResponse, error) {
-	resp := backend.NewQueryDataResponse()
+	request, err := s.createRequest(ctx, logger, dsInfo, postData)
+	if err != nil {
+		return nil, err
+	}
 
-	body, err := io.ReadAll(res.Body)
+	res, err := dsInfo.HTTPClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
 	defer func() {
 		if err := res.Body.Close(); err != nil {
-			logger.Warn("Failed to close response body", "err", err)
+			logger.Warn("Failed to close response body", "error", err)
 		}
 	}()
 
-	if res.StatusCode/100 != 2 {
-		logger.Info("Request failed", "status", res.Status, "body", string(body))
-		return nil, fmt.Errorf("request failed, status: %s", res.Status)
-	}
-
-	var responseData []OpenTsdbResponse
-	err = json.Unmarshal(body, &responseData)
+	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		logger.Info("Failed to unmarshal opentsdb response", "error", err, "status", res.Status, "body", string(body))
 		return nil, err
 	}
-
-	frames := data.Frames{}
-	for _, val := range responseData {
-		timeVector := make([]time.Time, 0, len(val.DataPoints))
-		values := make([]float64, 0, len(val.DataPoints))
-		name := val.Metric
-		tags := val.Tags
-
-		for timeString, value := range val.DataPoints {
-			timestamp, err := strconv.ParseInt(timeString, 10, 64)
-			if err != nil {
-				logger.Info("Failed to unmarshal opentsdb timestamp", "timestamp", timeString)
-				return nil, err
-			}
-			timeVector = append(timeVector, time.Unix(timestamp, 0).UTC())
-			values = append(values, value)
-		}
-		frames = append(frames, data.NewFrame(name,
-			data.NewField("time", nil, timeVector),
-			data.NewField("value", tags, values)))
-	}
-	result := resp.Responses["A"]
-	result.Frames = frames
-	resp.Responses["A"] = result
-	return resp, nil
+	return parseResponse(logger, res, body, query.RefID, model, string(postData), tsdbQuery.MsResolution)
 }
 
-func (s *Service) buildMetric(query backend.DataQuery) map[string]interface{} {
-	metric := make(map[string]interface{})
-
-	model, err := simplejson.NewJson(query.JSON)
+func (s *Service) createRequest(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, postData []byte) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.URL)
 	if err != nil {
-		return nil
-	}
-
-	// Setting metric and aggregator
-	metric["metric"] = model.Get("metric").MustString()
-	metric["aggregator"] = model.Get("aggregator").MustString()
-
-	// Setting downsampling options
-	disableDownsampling := model.Get("disableDownsampling").MustBool()
-	if !disableDownsampling {
-		downsampleInterval := model.Get("downsampleInterval").MustString()
-		if downsampleInterval == "" {
-			downsampleInterval = "1m" // default value for blank
-		}
-		downsample := downsampleInterval + "-" + model.Get("downsampleAggregator").MustString()
-		if model.Get("downsampleFillPolicy").MustString() != "none" {
-			metric["downsample"] = downsample + "-" + model.Get("downsampleFillPolicy").MustString()
-		} else {
-			metric["downsample"] = downsample
-		}
-	}
-
-	// Setting rate options
-	if model.Get("shouldComputeRate").MustBool() {
-		metric["rate"] = true
-		rateOptions := make(map[string]interface{})
-		rateOptions["counter"] = model.Get("isCounter").MustBool()
-
-		counterMax, counterMaxCheck := model.CheckGet("counterMax")
-		if counterMaxCheck {
-			rateOptions["counterMax"] = counterMax.MustFloat64()
-		}
-
-		resetValue, resetValueCheck := model.CheckGet("counterResetValue")
-		if resetValueCheck {
-			rateOptions["resetValue"] = resetValue.MustFloat64()
-		}
-
-		if !counterMaxCheck && (!resetValueCheck || resetValue.MustFloat64() == 0) {
-			rateOptions["dropResets"] = true
-		}
-
-		metric["rateOptions"] = rateOptions
-	}
-
-	// Setting tags
-	tags, tagsCheck := model.CheckGet("tags")
-	if tagsCheck && len(tags.MustMap()) > 0 {
-		metric["tags"] = tags.MustMap()
+		return nil, err
 	}
+	u.Path = path.Join(u.Path, "api/query")
 
-	// Setting filters
-	filters, filtersCheck := model.CheckGet("filters")
-	if filtersCheck && len(filters.MustArray()) > 0 {
-		metric["filters"] = filters.MustArray()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(postData))
+	if err != nil {
+		logger.Info("Failed to create request", "error", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return metric
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
 }
 
 func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*datasourceInfo, error) {
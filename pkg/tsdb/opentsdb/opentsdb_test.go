@@ -2,14 +2,17 @@ package opentsdb
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"math"
 	"net/http"
-	"strings"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,32 +22,39 @@ func TestOpenTsdbExecutor(t *testing.T) {
 	service := &Service{}
 
 	t.Run("create request", func(t *testing.T) {
-		req, err := service.createRequest(context.Background(), logger, &datasourceInfo{}, OpenTsdbQuery{})
+		req, err := service.createRequest(context.Background(), logger, &datasourceInfo{URL: "http://localhost:4242/tsdb"}, []byte(`{"start":0}`))
 		require.NoError(t, err)
 
 		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/tsdb/api/query", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
 		body, err := io.ReadAll(req.Body)
 		require.NoError(t, err)
-
-		testBody := "{\"start\":0,\"end\":0,\"queries\":null}"
-		assert.Equal(t, testBody, string(body))
+		assert.Equal(t, `{"start":0}`, string(body))
 	})
 
 	t.Run("Parse response should handle invalid JSON", func(t *testing.T) {
-		response := `{ invalid }`
-
-		result, err := service.parseResponse(logger, &http.Response{Body: io.NopCloser(strings.NewReader(response))})
+		result, err := parseResponse(logger, &http.Response{StatusCode: 200}, []byte(`{ invalid }`), "A", &QueryModel{}, "", false)
 		require.Nil(t, result)
 		require.Error(t, err)
 	})
 
+	t.Run("Parse response should return the error of OpenTSDB", func(t *testing.T) {
+		body := []byte(`{"error": {"code": 400, "message": "No such name for 'metrics': 'cpu'"}}`)
+		_, err := parseResponse(logger, &http.Response{StatusCode: 400, Status: "400 Bad Request"}, body, "A", &QueryModel{}, "", false)
+		require.EqualError(t, err, "request failed, status: 400 Bad Request, error: No such name for 'metrics': 'cpu'")
+	})
+
 	t.Run("Parse response should handle JSON", func(t *testing.T) {
 		response := `
 		[
 			{
 				"metric": "test",
 				"dps": {
-					"1405544146": 50.0
+					"1405544206": 60.0,
+					"1405544146": 50.0,
+					"1405544266": null,
+					"1405544326": "NaN"
 				},
 				"tags" : {
 					"env": "prod",
@@ -53,28 +63,52 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			}
 		]`
 
+		nan := math.NaN()
 		testFrame := data.NewFrame("test",
-			data.NewField("time", nil, []time.Time{
+			data.NewField("Time", nil, []time.Time{
 				time.Date(2014, 7, 16, 20, 55, 46, 0, time.UTC),
+				time.Date(2014, 7, 16, 20, 56, 46, 0, time.UTC),
+				time.Date(2014, 7, 16, 20, 57, 46, 0, time.UTC),
+				time.Date(2014, 7, 16, 20, 58, 46, 0, time.UTC),
 			}),
-			data.NewField("value", map[string]string{"env": "prod", "app": "grafana"}, []float64{
-				50}),
+			data.NewField("Value", map[string]string{"env": "prod", "app": "grafana"}, []*float64{
+				pointer(50), pointer(60), nil, &nan}),
 		)
+		testFrame.RefID = "A"
+		testFrame.Meta = &data.FrameMeta{Type: data.FrameTypeTimeSeriesMulti, ExecutedQueryString: `{"start":0}`}
 
-		resp := http.Response{Body: io.NopCloser(strings.NewReader(response))}
-		resp.StatusCode = 200
-		result, err := service.parseResponse(logger, &resp)
+		frames, err := parseResponse(logger, &http.Response{StatusCode: 200}, []byte(response), "A", &QueryModel{}, `{"start":0}`, false)
 		require.NoError(t, err)
+		require.Len(t, frames, 1)
 
-		frame := result.Responses["A"]
-
-		if diff := cmp.Diff(testFrame, frame.Frames[0], data.FrameTestCompareOptions()...); diff != "" {
+		if diff := cmp.Diff(testFrame, frames[0], data.FrameTestCompareOptions()...); diff != "" {
 			t.Errorf("Result mismatch (-want +got):\n%s", diff)
 		}
 	})
 
+	t.Run("Parse response should handle millisecond timestamps and aliases", func(t *testing.T) {
+		response := `[{"metric": "test", "dps": {"1405544146500": 50.0}, "tags": {"host": "web-1", "hostname": "web"}}]`
+
+		model := &QueryModel{Alias: "$tag_hostname ${tag_host}"}
+		frames, err := parseResponse(logger, &http.Response{StatusCode: 200}, []byte(response), "A", model, "", true)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+
+		assert.Equal(t, time.Date(2014, 7, 16, 20, 55, 46, 500*int(time.Millisecond), time.UTC), frames[0].Fields[0].At(0))
+		assert.Equal(t, "web web-1", frames[0].Fields[1].Config.DisplayNameFromDS)
+	})
+}
+
+func TestBuildSubQuery(t *testing.T) {
+	build := func(t *testing.T, query backend.DataQuery, tsdbVersion int) (OpenTsdbSubQuery, error) {
+		t.Helper()
+		model, err := parseQueryModel(query)
+		require.NoError(t, err)
+		return buildSubQuery(query, model, tsdbVersion)
+	}
+
 	t.Run("Build metric with downsampling enabled", func(t *testing.T) {
-		query := backend.DataQuery{
+		subQuery, err := build(t, backend.DataQuery{
 			JSON: []byte(`
 					{
 						"metric": "cpu.average.percent",
@@ -85,18 +119,28 @@ func TestOpenTsdbExecutor(t *testing.T) {
 						"downsampleFillPolicy": "none"
 					}`,
 			),
-		}
-
-		metric := service.buildMetric(query)
+		}, tsdbVersion22)
+		require.NoError(t, err)
+		require.Equal(t, OpenTsdbSubQuery{Metric: "cpu.average.percent", Aggregator: "avg", Downsample: "1m-avg"}, subQuery)
+	})
 
-		require.Len(t, metric, 3)
-		require.Equal(t, "cpu.average.percent", metric["metric"])
-		require.Equal(t, "avg", metric["aggregator"])
-		require.Equal(t, "1m-avg", metric["downsample"])
+	t.Run("Build metric with downsampling on the interval of the query", func(t *testing.T) {
+		for interval, expected := range map[time.Duration]string{
+			30 * time.Second:        "30s-sum",
+			1500 * time.Millisecond: "1500ms-sum",
+		} {
+			subQuery, err := build(t, backend.DataQuery{
+				Interval: interval,
+				JSON:     []byte(`{"metric": "cpu.average.percent", "downsampleAggregator": "sum"}`),
+			}, tsdbVersion22)
+			require.NoError(t, err)
+			require.Equal(t, expected, subQuery.Downsample)
+			require.Equal(t, defaultAggregator, subQuery.Aggregator)
+		}
 	})
 
 	t.Run("Build metric with downsampling disabled", func(t *testing.T) {
-		query := backend.DataQuery{
+		subQuery, err := build(t, backend.DataQuery{
 			JSON: []byte(`
 					{
 						"metric": "cpu.average.percent",
@@ -107,17 +151,13 @@ func TestOpenTsdbExecutor(t *testing.T) {
 						"downsampleFillPolicy": "none"
 					}`,
 			),
-		}
-
-		metric := service.buildMetric(query)
-
-		require.Len(t, metric, 2)
-		require.Equal(t, "cpu.average.percent", metric["metric"])
-		require.Equal(t, "avg", metric["aggregator"])
+		}, tsdbVersion22)
+		require.NoError(t, err)
+		require.Equal(t, OpenTsdbSubQuery{Metric: "cpu.average.percent", Aggregator: "avg"}, subQuery)
 	})
 
 	t.Run("Build metric with downsampling enabled with params", func(t *testing.T) {
-		query := backend.DataQuery{
+		subQuery, err := build(t, backend.DataQuery{
 			JSON: []byte(`
 					{
 						"metric": "cpu.average.percent",
@@ -128,18 +168,24 @@ func TestOpenTsdbExecutor(t *testing.T) {
 						"downsampleFillPolicy": "null"
 					}`,
 			),
-		}
+		}, tsdbVersion22)
+		require.NoError(t, err)
+		require.Equal(t, "5m-sum-null", subQuery.Downsample)
 
-		metric := service.buildMetric(query)
+		subQuery, err = build(t, backend.DataQuery{
+			JSON: []byte(`{"metric": "cpu.average.percent", "downsampleInterval": "0.5s", "downsampleAggregator": "max"}`),
+		}, tsdbVersion22)
+		require.NoError(t, err)
+		require.Equal(t, "500ms-max", subQuery.Downsample)
 
-		require.Len(t, metric, 3)
-		require.Equal(t, "cpu.average.percent", metric["metric"])
-		require.Equal(t, "avg", metric["aggregator"])
-		require.Equal(t, "5m-sum-null", metric["downsample"])
+		_, err = build(t, backend.DataQuery{
+			JSON: []byte(`{"metric": "cpu.average.percent", "downsampleFillPolicy": "previous"}`),
+		}, tsdbVersion22)
+		require.EqualError(t, err, `invalid fill policy "previous"`)
 	})
 
 	t.Run("Build metric with tags with downsampling disabled", func(t *testing.T) {
-		query := backend.DataQuery{
+		subQuery, err := build(t, backend.DataQuery{
 			JSON: []byte(`
 					{
 						"metric": "cpu.average.percent",
@@ -154,24 +200,32 @@ func TestOpenTsdbExecutor(t *testing.T) {
 						}
 					}`,
 			),
-		}
-
-		metric := service.buildMetric(query)
-
-		require.Len(t, metric, 3)
-		require.Equal(t, "cpu.average.percent", metric["metric"])
-		require.Equal(t, "avg", metric["aggregator"])
-		require.Nil(t, metric["downsample"])
+		}, tsdbVersion22)
+		require.NoError(t, err)
+		require.Empty(t, subQuery.Downsample)
+		require.Equal(t, map[string]string{"env": "prod", "app": "grafana"}, subQuery.Tags)
+	})
 
-		metricTags := metric["tags"].(map[string]interface{})
-		require.Len(t, metricTags, 2)
-		require.Equal(t, "prod", metricTags["env"])
-		require.Equal(t, "grafana", metricTags["app"])
-		require.Nil(t, metricTags["ip"])
+	t.Run("Build metric with filters and tags", func(t *testing.T) {
+		subQuery, err := build(t, backend.DataQuery{
+			JSON: []byte(`
+					{
+						"metric": "cpu.average.percent",
+						"disableDownsampling": true,
+						"filters": [{"type": "wildcard", "tagk": "host", "filter": "web-*", "groupBy": true}],
+						"tags": {"env": "prod"},
+						"explicitTags": true
+					}`,
+			),
+		}, tsdbVersion22)
+		require.NoError(t, err)
+		require.Equal(t, []Filter{{Type: "wildcard", Tagk: "host", Filter: "web-*", GroupBy: true}}, subQuery.Filters)
+		require.Nil(t, subQuery.Tags, "the filters take precedence over the tags")
+		require.True(t, subQuery.ExplicitTags)
 	})
 
 	t.Run("Build metric with rate enabled but counter disabled", func(t *testing.T) {
-		query := backend.DataQuery{
+		subQuery, err := build(t, backend.DataQuery{
 			JSON: []byte(`
 					{
 						"metric": "cpu.average.percent",
@@ -185,60 +239,111 @@ func TestOpenTsdbExecutor(t *testing.T) {
 						}
 					}`,
 			),
-		}
-
-		metric := service.buildMetric(query)
+		}, tsdbVersion22)
+		require.NoError(t, err)
+		require.True(t, subQuery.Rate)
+		require.Equal(t, &RateOptions{Counter: false, DropResets: true}, subQuery.RateOptions)
 
-		require.Len(t, metric, 5)
-		require.Equal(t, "cpu.average.percent", metric["metric"])
-		require.Equal(t, "avg", metric["aggregator"])
+		subQuery, err = build(t, backend.DataQuery{
+			JSON: []byte(`{"metric": "cpu.average.percent", "shouldComputeRate": true}`),
+		}, 1)
+		require.NoError(t, err)
+		require.Equal(t, &RateOptions{}, subQuery.RateOptions, "OpenTSDB 2.1 doesn't drop the resets")
+	})
 
-		metricTags := metric["tags"].(map[string]interface{})
-		require.Len(t, metricTags, 2)
-		require.Equal(t, "prod", metricTags["env"])
-		require.Equal(t, "grafana", metricTags["app"])
-		require.Nil(t, metricTags["ip"])
+	t.Run("Build metric with rate and counter enabled", func(t *testing.T) {
+		for _, counter := range []string{
+			`"counterMax": 45, "counterResetValue": 60`,
+			`"counterMax": "45", "counterResetValue": "60"`,
+		} {
+			subQuery, err := build(t, backend.DataQuery{
+				JSON: []byte(`{"metric": "cpu.average.percent", "shouldComputeRate": true, "isCounter": true, ` + counter + `}`),
+			}, tsdbVersion22)
+			require.NoError(t, err)
+			require.True(t, subQuery.Rate)
+			require.Equal(t, &RateOptions{Counter: true, CounterMax: pointer(45), ResetValue: pointer(60)}, subQuery.RateOptions)
+		}
 
-		require.True(t, metric["rate"].(bool))
-		require.False(t, metric["rateOptions"].(map[string]interface{})["counter"].(bool))
+		subQuery, err := build(t, backend.DataQuery{
+			JSON: []byte(`{"metric": "cpu.average.percent", "shouldComputeRate": true, "isCounter": true, "counterMax": "", "counterResetValue": "0"}`),
+		}, tsdbVersion22)
+		require.NoError(t, err)
+		require.Equal(t, &RateOptions{Counter: true, ResetValue: pointer(0), DropResets: true}, subQuery.RateOptions)
 	})
 
-	t.Run("Build metric with rate and counter enabled", func(t *testing.T) {
+	t.Run("Build metric with rollup usage", func(t *testing.T) {
 		query := backend.DataQuery{
-			JSON: []byte(`
-					{
-						"metric": "cpu.average.percent",
-						"aggregator": "avg",
-						"disableDownsampling": true,
-						"shouldComputeRate": true,
-						"isCounter": true,
-						"counterMax": 45,
-						"counterResetValue": 60,
-						"tags": {
-							"env": "prod",
-							"app": "grafana"
-						}
-					}`,
-			),
+			JSON: []byte(`{"metric": "cpu.average.percent", "downsampleInterval": "1h", "rollupUsage": "ROLLUP_FALLBACK"}`),
 		}
+		subQuery, err := build(t, query, tsdbVersion24)
+		require.NoError(t, err)
+		require.Equal(t, "ROLLUP_FALLBACK", subQuery.RollupUsage)
 
-		metric := service.buildMetric(query)
+		_, err = build(t, query, tsdbVersion22)
+		require.EqualError(t, err, "the rollup tables require OpenTSDB 2.4 or later")
 
-		require.Len(t, metric, 5)
-		require.Equal(t, "cpu.average.percent", metric["metric"])
-		require.Equal(t, "avg", metric["aggregator"])
+		_, err = build(t, backend.DataQuery{
+			JSON: []byte(`{"metric": "cpu.average.percent", "rollupUsage": "ROLLUP_ALWAYS"}`),
+		}, tsdbVersion24)
+		require.EqualError(t, err, `invalid rollup usage "ROLLUP_ALWAYS"`)
+	})
 
-		metricTags := metric["tags"].(map[string]interface{})
-		require.Len(t, metricTags, 2)
-		require.Equal(t, "prod", metricTags["env"])
-		require.Equal(t, "grafana", metricTags["app"])
-		require.Nil(t, metricTags["ip"])
+	t.Run("Parse query without metric", func(t *testing.T) {
+		_, err := parseQueryModel(backend.DataQuery{JSON: []byte(`{"aggregator": "avg"}`)})
+		require.EqualError(t, err, "the query has no metric")
+	})
+}
 
-		require.True(t, metric["rate"].(bool))
-		metricRateOptions := metric["rateOptions"].(map[string]interface{})
-		require.Len(t, metricRateOptions, 3)
-		require.True(t, metricRateOptions["counter"].(bool))
-		require.Equal(t, float64(45), metricRateOptions["counterMax"])
-		require.Equal(t, float64(60), metricRateOptions["resetValue"])
+func TestQueryData(t *testing.T) {
+	var requests []OpenTsdbQuery
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tsdbQuery OpenTsdbQuery
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&tsdbQuery))
+		requests = append(requests, tsdbQuery)
+		_, _ = w.Write([]byte(`[{"metric": "` + tsdbQuery.Queries[0].Metric + `", "dps": {"1405544146000": 1}, "tags": {}}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL, TSDBVersion: tsdbVersion22, TSDBResolution: tsdbResolutionMilliseconds}
+	service := &Service{im: fakeInstanceManager{dsInfo: dsInfo}}
+	timeRange := backend.TimeRange{From: time.Unix(1405544000, 0), To: time.Unix(1405545000, 0)}
+
+	res, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu", "disableDownsampling": true}`)},
+			{RefID: "B", TimeRange: timeRange, JSON: []byte(`{"metric": "memory", "disableDownsampling": true}`)},
+			{RefID: "C", TimeRange: timeRange, JSON: []byte(`{"metric": "disk", "downsampleFillPolicy": "previous"}`)},
+		},
 	})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2, "the invalid queries are not sent")
+	for _, r := range requests {
+		assert.Equal(t, int64(1405544000000), r.Start)
+		assert.Equal(t, int64(1405545000000), r.End)
+		assert.True(t, r.MsResolution)
+	}
+	for refID, metric := range map[string]string{"A": "cpu", "B": "memory"} {
+		require.NoError(t, res.Responses[refID].Error)
+		require.Len(t, res.Responses[refID].Frames, 1)
+		assert.Equal(t, metric, res.Responses[refID].Frames[0].Name)
+		assert.Equal(t, refID, res.Responses[refID].Frames[0].RefID)
+	}
+	require.EqualError(t, res.Responses["C"].Error, `invalid fill policy "previous"`)
+}
+
+type fakeInstanceManager struct {
+	dsInfo *datasourceInfo
+}
+
+func (m fakeInstanceManager) Get(_ backend.PluginContext) (instancemgmt.Instance, error) {
+	return m.dsInfo, nil
+}
+
+func (m fakeInstanceManager) Do(_ backend.PluginContext, _ instancemgmt.InstanceCallbackFunc) error {
+	return nil
+}
+
+func pointer(value float64) *float64 {
+	return &value
 }
@@ -0,0 +1,163 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const (
+	defaultAggregator         = "avg"
+	defaultDownsampleInterval = "1m"
+)
+
+var fillPolicies = map[string]bool{
+	"none": true,
+	"nan":  true,
+	"null": true,
+	"zero": true,
+}
+
+var rollupUsages = map[string]bool{
+	"ROLLUP_RAW":          true,
+	"ROLLUP_NOFALLBACK":   true,
+	"ROLLUP_FALLBACK":     true,
+	"ROLLUP_FALLBACK_RAW": true,
+}
+
+// OpenTSDB doesn't take fractional intervals, they are converted to milliseconds
+var fractionalSecondsRegexp = regexp.MustCompile(`^[0-9]*\.[0-9]+s$`)
+
+func parseQueryModel(query backend.DataQuery) (*QueryModel, error) {
+	model := &QueryModel{}
+	if err := json.Unmarshal(query.JSON, model); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the query: %w", err)
+	}
+	if model.Metric == "" {
+		return nil, fmt.Errorf("the query has no metric")
+	}
+	return model, nil
+}
+
+// buildRequest returns the request of a query to the /api/query endpoint of OpenTSDB
+func buildRequest(query backend.DataQuery, model *QueryModel, dsInfo *datasourceInfo) (OpenTsdbQuery, error) {
+	subQuery, err := buildSubQuery(query, model, dsInfo.TSDBVersion)
+	if err != nil {
+		return OpenTsdbQuery{}, err
+	}
+	return OpenTsdbQuery{
+		Start:        query.TimeRange.From.UnixMilli(),
+		End:          query.TimeRange.To.UnixMilli(),
+		MsResolution: dsInfo.TSDBResolution == tsdbResolutionMilliseconds,
+		Queries:      []OpenTsdbSubQuery{subQuery},
+	}, nil
+}
+
+func buildSubQuery(query backend.DataQuery, model *QueryModel, tsdbVersion int) (OpenTsdbSubQuery, error) {
+	subQuery := OpenTsdbSubQuery{
+		Metric:       model.Metric,
+		Aggregator:   model.Aggregator,
+		ExplicitTags: model.ExplicitTags,
+	}
+	if subQuery.Aggregator == "" {
+		subQuery.Aggregator = defaultAggregator
+	}
+
+	if !model.DisableDownsampling {
+		downsample, err := buildDownsample(query, model)
+		if err != nil {
+			return OpenTsdbSubQuery{}, err
+		}
+		subQuery.Downsample = downsample
+	}
+
+	if model.RollupUsage != "" {
+		if !rollupUsages[model.RollupUsage] {
+			return OpenTsdbSubQuery{}, fmt.Errorf("invalid rollup usage %q", model.RollupUsage)
+		}
+		if tsdbVersion < tsdbVersion24 {
+			return OpenTsdbSubQuery{}, fmt.Errorf("the rollup tables require OpenTSDB 2.4 or later")
+		}
+		subQuery.RollupUsage = model.RollupUsage
+	}
+
+	if model.ShouldComputeRate {
+		subQuery.Rate = true
+		subQuery.RateOptions = buildRateOptions(model, tsdbVersion)
+	}
+
+	if len(model.Filters) > 0 {
+		subQuery.Filters = model.Filters
+	} else if len(model.Tags) > 0 {
+		subQuery.Tags = model.Tags
+	}
+
+	return subQuery, nil
+}
+
+// buildDownsample returns the downsample specifier of a query, its interval defaults to the interval of the query
+func buildDownsample(query backend.DataQuery, model *QueryModel) (string, error) {
+	interval := model.DownsampleInterval
+	if interval == "" {
+		interval = formatInterval(query.Interval)
+	}
+	if fractionalSecondsRegexp.MatchString(interval) {
+		seconds, err := strconv.ParseFloat(interval[:len(interval)-1], 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid downsample interval %q", interval)
+		}
+		interval = strconv.FormatInt(int64(seconds*1000), 10) + "ms"
+	}
+
+	aggregator := model.DownsampleAggregator
+	if aggregator == "" {
+		aggregator = defaultAggregator
+	}
+	downsample := interval + "-" + aggregator
+
+	if fillPolicy := model.DownsampleFillPolicy; fillPolicy != "" {
+		if !fillPolicies[fillPolicy] {
+			return "", fmt.Errorf("invalid fill policy %q", fillPolicy)
+		}
+		if fillPolicy != "none" {
+			downsample += "-" + fillPolicy
+		}
+	}
+	return downsample, nil
+}
+
+// formatInterval formats the interval of a query as an OpenTSDB duration, in whole seconds when possible
+func formatInterval(interval time.Duration) string {
+	if interval <= 0 {
+		return defaultDownsampleInterval
+	}
+	if interval%time.Second == 0 {
+		return strconv.FormatInt(int64(interval/time.Second), 10) + "s"
+	}
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	return strconv.FormatInt(interval.Milliseconds(), 10) + "ms"
+}
+
+// buildRateOptions returns the rate options of a query. The resets of the counters without maximum nor reset value
+// are dropped, OpenTSDB takes that option since 2.2.
+func buildRateOptions(model *QueryModel, tsdbVersion int) *RateOptions {
+	options := &RateOptions{Counter: model.IsCounter}
+	if model.CounterMax.Valid {
+		counterMax := model.CounterMax.Value
+		options.CounterMax = &counterMax
+	}
+	if model.CounterResetValue.Valid {
+		resetValue := model.CounterResetValue.Value
+		options.ResetValue = &resetValue
+	}
+	if tsdbVersion >= tsdbVersion22 && options.CounterMax == nil && (options.ResetValue == nil || *options.ResetValue == 0) {
+		options.DropResets = true
+	}
+	return options
+}
@@ -0,0 +1,106 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// parseResponse returns the frames of the series of a query, each series in a frame named by its metric with its tags
+// as the labels of its values
+func parseResponse(logger log.Logger, res *http.Response, body []byte, refID string, model *QueryModel, executedQuery string, msResolution bool) (data.Frames, error) {
+	if res.StatusCode/100 != 2 {
+		logger.Info("Request failed", "status", res.Status, "body", string(body))
+		var tsdbErr OpenTsdbError
+		if err := json.Unmarshal(body, &tsdbErr); err == nil && tsdbErr.Error.Message != "" {
+			return nil, fmt.Errorf("request failed, status: %s, error: %s", res.Status, tsdbErr.Error.Message)
+		}
+		return nil, fmt.Errorf("request failed, status: %s", res.Status)
+	}
+
+	var responseData []OpenTsdbResponse
+	if err := json.Unmarshal(body, &responseData); err != nil {
+		logger.Info("Failed to unmarshal opentsdb response", "error", err, "status", res.Status, "body", string(body))
+		return nil, fmt.Errorf("failed to unmarshal the response: %w", err)
+	}
+
+	frames := make(data.Frames, 0, len(responseData))
+	for _, series := range responseData {
+		frame, err := seriesFrame(series, model, msResolution)
+		if err != nil {
+			logger.Info("Failed to parse opentsdb series", "error", err, "metric", series.Metric)
+			return nil, err
+		}
+		frame.RefID = refID
+		frame.Meta = &data.FrameMeta{
+			Type:                data.FrameTypeTimeSeriesMulti,
+			ExecutedQueryString: executedQuery,
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+func seriesFrame(series OpenTsdbResponse, model *QueryModel, msResolution bool) (*data.Frame, error) {
+	type point struct {
+		time  time.Time
+		value *float64
+	}
+	points := make([]point, 0, len(series.DataPoints))
+	for timeString, dp := range series.DataPoints {
+		timestamp, err := strconv.ParseInt(timeString, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", timeString)
+		}
+		t := time.Unix(timestamp, 0).UTC()
+		if msResolution {
+			t = time.UnixMilli(timestamp).UTC()
+		}
+		points = append(points, point{time: t, value: dp.Value})
+	}
+	// the datapoints are an object, their order is lost
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].time.Before(points[j].time)
+	})
+
+	times := make([]time.Time, len(points))
+	values := make([]*float64, len(points))
+	for i, p := range points {
+		times[i] = p.time
+		values[i] = p.value
+	}
+
+	valueField := data.NewField(data.TimeSeriesValueFieldName, series.Tags, values)
+	if model.Alias != "" {
+		valueField.Config = &data.FieldConfig{DisplayNameFromDS: formatAlias(model.Alias, series.Tags)}
+	}
+	return data.NewFrame(series.Metric,
+		data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+		valueField,
+	), nil
+}
+
+// formatAlias replaces the $tag_<name> variables of an alias, the longest names first so that a tag doesn't replace
+// the beginning of another
+func formatAlias(alias string, tags map[string]string) string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+	for _, name := range names {
+		alias = strings.ReplaceAll(alias, "$tag_"+name, tags[name])
+		alias = strings.ReplaceAll(alias, "${tag_"+name+"}", tags[name])
+	}
+	return alias
+}
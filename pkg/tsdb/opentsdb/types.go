@@ -1,13 +1,143 @@
 package opentsdb
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// The versions of OpenTSDB selected in the settings of the data source
+const (
+	tsdbVersion22 = 2
+	tsdbVersion24 = 4
+)
+
+// tsdbResolutionMilliseconds is the resolution of the data sources whose datapoints have millisecond timestamps
+const tsdbResolutionMilliseconds = 2
+
+// QueryModel is the model of the queries of the query editor
+type QueryModel struct {
+	Metric     string `json:"metric"`
+	Aggregator string `json:"aggregator"`
+	// Alias is the display name of the series, with the $tag_<name> variables replaced by the tags of each series
+	Alias string `json:"alias"`
+
+	DisableDownsampling  bool   `json:"disableDownsampling"`
+	DownsampleInterval   string `json:"downsampleInterval"`
+	DownsampleAggregator string `json:"downsampleAggregator"`
+	DownsampleFillPolicy string `json:"downsampleFillPolicy"`
+	// RollupUsage is how the query uses the rollup tables, OpenTSDB queries the raw data when it is empty
+	RollupUsage string `json:"rollupUsage"`
+
+	ShouldComputeRate bool          `json:"shouldComputeRate"`
+	IsCounter         bool          `json:"isCounter"`
+	CounterMax        OptionalFloat `json:"counterMax"`
+	CounterResetValue OptionalFloat `json:"counterResetValue"`
+
+	// Tags are used by OpenTSDB 2.1 and earlier, the later versions take either the tags or the filters
+	Tags         map[string]string `json:"tags"`
+	Filters      []Filter          `json:"filters"`
+	ExplicitTags bool              `json:"explicitTags"`
+}
+
+type Filter struct {
+	Type    string `json:"type"`
+	Tagk    string `json:"tagk"`
+	Filter  string `json:"filter"`
+	GroupBy bool   `json:"groupBy"`
+}
+
+// OptionalFloat is a number the query editor saves as a string, empty when it is not set
+type OptionalFloat struct {
+	Value float64
+	Valid bool
+}
+
+func (f *OptionalFloat) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*f = OptionalFloat{}
+	case float64:
+		*f = OptionalFloat{Value: v, Valid: true}
+	case string:
+		if v == "" {
+			*f = OptionalFloat{}
+			return nil
+		}
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*f = OptionalFloat{Value: value, Valid: true}
+	default:
+		return fmt.Errorf("invalid number %s", b)
+	}
+	return nil
+}
+
 type OpenTsdbQuery struct {
-	Start   int64                    `json:"start"`
-	End     int64                    `json:"end"`
-	Queries []map[string]interface{} `json:"queries"`
+	Start        int64              `json:"start"`
+	End          int64              `json:"end"`
+	MsResolution bool               `json:"msResolution,omitempty"`
+	Queries      []OpenTsdbSubQuery `json:"queries"`
+}
+
+type OpenTsdbSubQuery struct {
+	Metric       string            `json:"metric"`
+	Aggregator   string            `json:"aggregator"`
+	Downsample   string            `json:"downsample,omitempty"`
+	RollupUsage  string            `json:"rollupUsage,omitempty"`
+	Rate         bool              `json:"rate,omitempty"`
+	RateOptions  *RateOptions      `json:"rateOptions,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	Filters      []Filter          `json:"filters,omitempty"`
+	ExplicitTags bool              `json:"explicitTags,omitempty"`
+}
+
+type RateOptions struct {
+	Counter    bool     `json:"counter"`
+	CounterMax *float64 `json:"counterMax,omitempty"`
+	ResetValue *float64 `json:"resetValue,omitempty"`
+	DropResets bool     `json:"dropResets,omitempty"`
 }
 
 type OpenTsdbResponse struct {
-	Metric     string             `json:"metric"`
-	Tags       map[string]string  `json:"tags"`
-	DataPoints map[string]float64 `json:"dps"`
+	Metric     string               `json:"metric"`
+	Tags       map[string]string    `json:"tags"`
+	DataPoints map[string]DataPoint `json:"dps"`
+}
+
+type OpenTsdbError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// DataPoint is the value of a datapoint, the fill policies return the missing values as null or as "NaN"
+type DataPoint struct {
+	Value *float64
+}
+
+func (p *DataPoint) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		p.Value = nil
+		return nil
+	}
+	if bytes.Equal(b, []byte(`"NaN"`)) {
+		nan := math.NaN()
+		p.Value = &nan
+		return nil
+	}
+	var value float64
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	p.Value = &value
+	return nil
 }
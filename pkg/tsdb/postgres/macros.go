@@ -155,6 +155,9 @@ func (m *postgresMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *
 		}
 		return "", err
 	default:
+		if macro, ok := sqleng.LookupMacro(sqleng.DialectPostgres, name); ok {
+			return macro(sqleng.MacroContext{Query: query, TimeRange: timeRange}, args)
+		}
 		return "", fmt.Errorf("unknown macro %q", name)
 	}
 }
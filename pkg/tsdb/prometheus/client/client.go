@@ -41,11 +41,13 @@ func (c *Client) QueryRange(ctx context.Context, q *models.Query) (*http.Respons
 		"end":   formatTime(tr.End),
 		"step":  strconv.FormatFloat(tr.Step.Seconds(), 'f', -1, 64),
 	}
+	setMaxSourceResolution(qv, q)
 
 	req, err := c.createQueryRequest(ctx, "api/v1/query_range", qv)
 	if err != nil {
 		return nil, err
 	}
+	setQueryHeaders(req, q)
 
 	return c.doer.Do(req)
 }
@@ -58,10 +60,12 @@ func (c *Client) QueryInstant(ctx context.Context, q *models.Query) (*http.Respo
 	// Instead of aligning we use time point directly.
 	// https://prometheus.io/docs/prometheus/latest/querying/api/#instant-queries
 	qv := map[string]string{"query": q.Expr, "time": formatTime(q.End)}
+	setMaxSourceResolution(qv, q)
 	req, err := c.createQueryRequest(ctx, "api/v1/query", qv)
 	if err != nil {
 		return nil, err
 	}
+	setQueryHeaders(req, q)
 
 	return c.doer.Do(req)
 }
@@ -78,6 +82,7 @@ func (c *Client) QueryExemplars(ctx context.Context, q *models.Query) (*http.Res
 	if err != nil {
 		return nil, err
 	}
+	setQueryHeaders(req, q)
 
 	return c.doer.Do(req)
 }
@@ -105,6 +110,30 @@ func (c *Client) QueryResource(ctx context.Context, req *backend.CallResourceReq
 	return c.doer.Do(httpRequest)
 }
 
+// QueryLabels requests the label names or the values of a label, filtered by the match[] selectors and the time
+// bounds of the params. Prometheus only accepts POST requests for the label names.
+func (c *Client) QueryLabels(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	u, err := c.createUrl(endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.ToUpper(c.method) == http.MethodPost && strings.Trim(endpoint, "/") == "api/v1/labels" {
+		req, err := createRequest(ctx, http.MethodPost, u, strings.NewReader(params.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		return c.doer.Do(req)
+	}
+
+	u.RawQuery = params.Encode()
+	req, err := createRequest(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	return c.doer.Do(req)
+}
+
 func (c *Client) createQueryRequest(ctx context.Context, endpoint string, qv map[string]string) (*http.Request, error) {
 	if strings.ToUpper(c.method) == http.MethodPost {
 		u, err := c.createUrl(endpoint, nil)
@@ -167,6 +196,23 @@ func createRequest(ctx context.Context, method string, u *url.URL, bodyReader io
 	return request, nil
 }
 
+// setMaxSourceResolution selects the downsampled blocks of Thanos read by the query, the other stores ignore it
+func setMaxSourceResolution(qv map[string]string, q *models.Query) {
+	if q.MaxSourceResolution != "" {
+		qv["max_source_resolution"] = q.MaxSourceResolution
+	}
+}
+
+// setQueryHeaders adds the headers of the query to its requests, the headers of the data source are set afterwards by
+// the middlewares of the HTTP client and take precedence
+func setQueryHeaders(req *http.Request, q *models.Query) {
+	for name, values := range q.Headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+}
+
 func formatTime(t time.Time) string {
 	return strconv.FormatFloat(float64(t.Unix())+float64(t.Nanosecond())/1e9, 'f', -1, 64)
 }
@@ -4,10 +4,13 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
@@ -138,4 +141,113 @@ func TestClient(t *testing.T) {
 			require.Equal(t, "http://localhost:9090/api/v1/query_range?end=1234&query=rate%28ALERTS%7Bjob%3D%22test%22+%5B%24__rate_interval%5D%7D%29&start=0&step=1", doer.Req.URL.String())
 		})
 	})
+	t.Run("sends the max source resolution of the downsampled queries", func(t *testing.T) {
+		doer := &MockDoer{}
+		client := NewClient(doer, http.MethodGet, "http://localhost:9090")
+		req := &models.Query{
+			Expr:                "up",
+			Start:               time.Unix(0, 0),
+			End:                 time.Unix(1234, 0),
+			Step:                1 * time.Second,
+			MaxSourceResolution: "5m",
+		}
+
+		t.Run("range query", func(t *testing.T) {
+			res, err := client.QueryRange(context.Background(), req)
+			defer func() {
+				if res != nil && res.Body != nil {
+					if err := res.Body.Close(); err != nil {
+						logger.Warn("Error", "err", err)
+					}
+				}
+			}()
+			require.NoError(t, err)
+			require.Equal(t, "http://localhost:9090/api/v1/query_range?end=1234&max_source_resolution=5m&query=up&start=0&step=1", doer.Req.URL.String())
+		})
+
+		t.Run("instant query", func(t *testing.T) {
+			res, err := client.QueryInstant(context.Background(), req)
+			defer func() {
+				if res != nil && res.Body != nil {
+					if err := res.Body.Close(); err != nil {
+						logger.Warn("Error", "err", err)
+					}
+				}
+			}()
+			require.NoError(t, err)
+			require.Equal(t, "http://localhost:9090/api/v1/query?max_source_resolution=5m&query=up&time=1234", doer.Req.URL.String())
+		})
+	})
+
+	t.Run("sends the headers of the query", func(t *testing.T) {
+		doer := &MockDoer{}
+		client := NewClient(doer, http.MethodPost, "http://localhost:9090")
+		req := &models.Query{
+			Expr:    "up",
+			Start:   time.Unix(0, 0),
+			End:     time.Unix(1234, 0),
+			Step:    1 * time.Second,
+			Headers: http.Header{"X-Read-Consistency": []string{"strong"}},
+		}
+
+		res, err := client.QueryRange(context.Background(), req)
+		defer func() {
+			if res != nil && res.Body != nil {
+				if err := res.Body.Close(); err != nil {
+					logger.Warn("Error", "err", err)
+				}
+			}
+		}()
+		require.NoError(t, err)
+		require.Equal(t, "strong", doer.Req.Header.Get("X-Read-Consistency"))
+		require.Equal(t, "application/x-www-form-urlencoded", doer.Req.Header.Get("Content-Type"))
+	})
+
+	t.Run("the headers of the data source take precedence over the headers of the query", func(t *testing.T) {
+		var received http.Header
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Clone()
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(srv.Close)
+
+		httpClient, err := sdkhttpclient.New(sdkhttpclient.Options{Headers: map[string]string{"X-Read-Consistency": "eventual"}})
+		require.NoError(t, err)
+		client := NewClient(httpClient, http.MethodPost, srv.URL)
+		res, err := client.QueryRange(context.Background(), &models.Query{
+			Expr:    "up",
+			Start:   time.Unix(0, 0),
+			End:     time.Unix(1234, 0),
+			Step:    1 * time.Second,
+			Headers: http.Header{"X-Read-Consistency": []string{"strong"}, "Sharding-Control": []string{"4"}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, []string{"eventual"}, received.Values("X-Read-Consistency"))
+		require.Equal(t, "4", received.Get("Sharding-Control"))
+	})
+
+	t.Run("QueryLabels", func(t *testing.T) {
+		doer := &MockDoer{}
+		params := url.Values{"match[]": {"up", "ALERTS"}, "start": {"1655271408"}}
+
+		t.Run("sends the label names POST request", func(t *testing.T) {
+			client := NewClient(doer, http.MethodPost, "http://localhost:9090")
+			_, err := client.QueryLabels(context.Background(), "/api/v1/labels", params)
+			require.NoError(t, err)
+			require.Equal(t, http.MethodPost, doer.Req.Method)
+			body, err := io.ReadAll(doer.Req.Body)
+			require.NoError(t, err)
+			require.Equal(t, "match%5B%5D=up&match%5B%5D=ALERTS&start=1655271408", string(body))
+			require.Equal(t, "http://localhost:9090/api/v1/labels", doer.Req.URL.String())
+		})
+
+		t.Run("sends the label values GET request", func(t *testing.T) {
+			client := NewClient(doer, http.MethodPost, "http://localhost:9090")
+			_, err := client.QueryLabels(context.Background(), "/api/v1/label/job/values", params)
+			require.NoError(t, err)
+			require.Equal(t, http.MethodGet, doer.Req.Method)
+			require.Equal(t, "http://localhost:9090/api/v1/label/job/values?match%5B%5D=up&match%5B%5D=ALERTS&start=1655271408", doer.Req.URL.String())
+		})
+	})
 }
@@ -9,6 +9,14 @@
 
 package dataquery
 
+// Defines values for PromDownsampling.
+const (
+	PromDownsamplingAuto PromDownsampling = "auto"
+	PromDownsamplingN1h  PromDownsampling = "1h"
+	PromDownsamplingN5m  PromDownsampling = "5m"
+	PromDownsamplingRaw  PromDownsampling = "raw"
+)
+
 // Defines values for PromQueryFormat.
 const (
 	PromQueryFormatHeatmap    PromQueryFormat = "heatmap"
@@ -16,6 +24,14 @@ const (
 	PromQueryFormatTimeSeries PromQueryFormat = "time_series"
 )
 
+// Defines values for Downsampling.
+const (
+	DownsamplingAuto Downsampling = "auto"
+	DownsamplingN1h  Downsampling = "1h"
+	DownsamplingN5m  Downsampling = "5m"
+	DownsamplingRaw  Downsampling = "raw"
+)
+
 // Defines values for EditorMode.
 const (
 	EditorModeBuilder EditorMode = "builder"
@@ -35,9 +51,18 @@ const (
 	QueryEditorModeCode    QueryEditorMode = "code"
 )
 
+// PromDownsampling defines model for PromDownsampling.
+type PromDownsampling string
+
 // PromQueryFormat defines model for PromQueryFormat.
 type PromQueryFormat string
 
+// PromQueryHeader defines model for PromQueryHeader.
+type PromQueryHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
 // PrometheusDataQuery defines model for PrometheusDataQuery.
 type PrometheusDataQuery struct {
 	// For mixed data sources the selected datasource is on the query level.
@@ -46,6 +71,9 @@ type PrometheusDataQuery struct {
 	// TODO this shouldn't be unknown but DataSourceRef | null
 	Datasource *interface{} `json:"datasource,omitempty"`
 
+	// Highest resolution of the downsampled blocks of Thanos read by the query, the raw samples can be skipped on long time ranges. Sent as the max_source_resolution parameter
+	Downsampling *Downsampling `json:"downsampling,omitempty"`
+
 	// Specifies which editor is being used to prepare the query. It can be "code" or "builder"
 	EditorMode *EditorMode `json:"editorMode,omitempty"`
 
@@ -58,6 +86,9 @@ type PrometheusDataQuery struct {
 	// Query format to determine how to display data points in panel. It can be "time_series", "table", "heatmap"
 	Format *Format `json:"format,omitempty"`
 
+	// HTTP headers sent with the requests of the query, such as the read consistency of Mimir
+	Headers []PromQueryHeader `json:"headers,omitempty"`
+
 	// Hide true if query is disabled (ie should not be returned to the dashboard)
 	// Note this does not always imply that the query should not be executed since
 	// the results from a hidden query may be used as the input to other queries (SSE etc)
@@ -79,6 +110,9 @@ type PrometheusDataQuery struct {
 	RefId string `json:"refId"`
 }
 
+// Highest resolution of the downsampled blocks of Thanos read by the query, the raw samples can be skipped on long time ranges. Sent as the max_source_resolution parameter
+type Downsampling string
+
 // Specifies which editor is being used to prepare the query. It can be "code" or "builder"
 type EditorMode string
 
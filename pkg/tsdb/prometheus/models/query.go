@@ -2,12 +2,16 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"golang.org/x/net/http/httpguts"
+
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 	"github.com/grafana/grafana/pkg/tsdb/prometheus/kinds/dataquery"
 )
@@ -68,10 +72,17 @@ type Query struct {
 	Start         time.Time
 	End           time.Time
 	RefId         string
+	MaxDataPoints int64
 	InstantQuery  bool
 	RangeQuery    bool
 	ExemplarQuery bool
 	UtcOffsetSec  int64
+	// MaxSourceResolution is the max_source_resolution parameter of the queries, empty when it isn't sent
+	MaxSourceResolution string
+	// HeatmapFormat is set for the queries with the heatmap format, whose histogram buckets are shown as a heatmap
+	HeatmapFormat bool
+	// Headers are the HTTP headers of the query sent with its requests, such as the read consistency of Mimir
+	Headers http.Header
 }
 
 func Parse(query backend.DataQuery, timeInterval string, intervalCalculator intervalv2.Calculator, fromAlert bool) (*Query, error) {
@@ -86,8 +97,15 @@ func Parse(query backend.DataQuery, timeInterval string, intervalCalculator inte
 		return nil, err
 	}
 
-	// Interpolate variables in expr
+	// Alert rules are evaluated on all the points of the series
+	maxDataPoints := query.MaxDataPoints
+	if fromAlert {
+		maxDataPoints = 0
+	}
 	timeRange := query.TimeRange.To.Sub(query.TimeRange.From)
+	interval = limitDataPoints(interval, timeRange, maxDataPoints)
+
+	// Interpolate variables in expr
 	expr := interpolateVariables(model.Expr, model.Interval, interval, timeRange, intervalCalculator, timeInterval)
 	var rangeQuery, instantQuery bool
 	if model.Instant == nil {
@@ -105,6 +123,11 @@ func Parse(query backend.DataQuery, timeInterval string, intervalCalculator inte
 		rangeQuery = true
 	}
 
+	headers, err := queryHeaders(model.Headers)
+	if err != nil {
+		return nil, err
+	}
+
 	// We never want to run exemplar query for alerting
 	exemplarQuery := false
 	if model.Exemplar != nil {
@@ -115,19 +138,66 @@ func Parse(query backend.DataQuery, timeInterval string, intervalCalculator inte
 	}
 
 	return &Query{
-		Expr:          expr,
-		Step:          interval,
-		LegendFormat:  model.LegendFormat,
-		Start:         query.TimeRange.From,
-		End:           query.TimeRange.To,
-		RefId:         query.RefID,
-		InstantQuery:  instantQuery,
-		RangeQuery:    rangeQuery,
-		ExemplarQuery: exemplarQuery,
-		UtcOffsetSec:  model.UtcOffsetSec,
+		Expr:                expr,
+		Step:                interval,
+		LegendFormat:        model.LegendFormat,
+		Start:               query.TimeRange.From,
+		End:                 query.TimeRange.To,
+		RefId:               query.RefID,
+		MaxDataPoints:       maxDataPoints,
+		InstantQuery:        instantQuery,
+		RangeQuery:          rangeQuery,
+		ExemplarQuery:       exemplarQuery,
+		UtcOffsetSec:        model.UtcOffsetSec,
+		MaxSourceResolution: maxSourceResolution(model.Downsampling),
+		HeatmapFormat:       model.Format != nil && *model.Format == dataquery.FormatHeatmap,
+		Headers:             headers,
 	}, nil
 }
 
+// allowedHeaders are the only headers the queries can set: the read consistency and the query sharding hints of Mimir.
+// The other headers, such as the authentication and the tenant headers, are set by the data source.
+var allowedHeaders = map[string]bool{
+	"X-Read-Consistency":         true,
+	"X-Read-Consistency-Offsets": true,
+	"X-Mimir-Read-Consistency":   true,
+	"Sharding-Control":           true,
+}
+
+// queryHeaders validates the HTTP headers of a query, which are limited to the allowed headers
+func queryHeaders(headers []dataquery.PromQueryHeader) (http.Header, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	h := http.Header{}
+	for _, header := range headers {
+		name := http.CanonicalHeaderKey(strings.TrimSpace(header.Name))
+		if !httpguts.ValidHeaderFieldName(name) {
+			return nil, fmt.Errorf("invalid query header name %q", header.Name)
+		}
+		if !httpguts.ValidHeaderFieldValue(header.Value) {
+			return nil, fmt.Errorf("invalid value of the query header %q", name)
+		}
+		if !allowedHeaders[name] {
+			return nil, fmt.Errorf("the query header %q is not allowed", name)
+		}
+		h.Add(name, header.Value)
+	}
+	return h, nil
+}
+
+// maxSourceResolution returns the max_source_resolution parameter of the downsampling, Thanos reads the raw samples
+// only with a zero resolution and picks the resolution from the step with auto
+func maxSourceResolution(downsampling *dataquery.Downsampling) string {
+	if downsampling == nil {
+		return ""
+	}
+	if *downsampling == dataquery.DownsamplingRaw {
+		return "0s"
+	}
+	return string(*downsampling)
+}
+
 func (query *Query) Type() TimeSeriesQueryType {
 	if query.InstantQuery {
 		return InstantQueryType
@@ -185,6 +255,33 @@ func calculatePrometheusInterval(
 	}
 }
 
+// niceSteps are the steps used when the step is increased to fit in the max data points
+var niceSteps = []time.Duration{
+	time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond,
+	200 * time.Millisecond, 500 * time.Millisecond, time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second,
+	15 * time.Second, 20 * time.Second, 30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute,
+	10 * time.Minute, 15 * time.Minute, 20 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour, 3 * time.Hour,
+	6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// limitDataPoints increases the step when the query would return more points than maxDataPoints. The calculated
+// interval is rounded to the closest nice interval, which can be smaller than the interval fitting the time range in
+// maxDataPoints, so the step is rounded up to the next nice step instead.
+func limitDataPoints(step, timeRange time.Duration, maxDataPoints int64) time.Duration {
+	if maxDataPoints <= 0 || step <= 0 || int64(timeRange/step) <= maxDataPoints {
+		return step
+	}
+
+	minStep := time.Duration(math.Ceil(float64(timeRange) / float64(maxDataPoints)))
+	for _, s := range niceSteps {
+		if s >= minStep {
+			return s
+		}
+	}
+	day := 24 * time.Hour
+	return time.Duration(math.Ceil(float64(minStep)/float64(day))) * day
+}
+
 func calculateRateInterval(
 	interval time.Duration,
 	scrapeInterval string,
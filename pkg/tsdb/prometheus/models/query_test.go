@@ -1,6 +1,7 @@
 package models_test
 
 import (
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
@@ -112,6 +113,99 @@ func TestParse(t *testing.T) {
 		require.Equal(t, time.Minute*2, res.Step)
 	})
 
+	t.Run("parsing query model with max data points", func(t *testing.T) {
+		timeRange := backend.TimeRange{
+			From: now,
+			To:   now.Add(48 * time.Hour),
+		}
+
+		q := queryContext(`{
+			"expr": "rate(go_goroutines[$__interval])",
+			"format": "time_series",
+			"refId": "A"
+		}`, timeRange)
+		q.MaxDataPoints = 1000
+
+		// 48h / 1000 is rounded to 2m, which would return 1440 points
+		res, err := models.Parse(q, "15s", intervalCalculator, false)
+		require.NoError(t, err)
+		require.Equal(t, time.Minute*5, res.Step)
+		require.Equal(t, "rate(go_goroutines[5m])", res.Expr)
+		require.Equal(t, int64(1000), res.MaxDataPoints)
+
+		res, err = models.Parse(q, "15s", intervalCalculator, true)
+		require.NoError(t, err)
+		require.Equal(t, time.Minute*2, res.Step, "alert queries are not limited")
+		require.Equal(t, int64(0), res.MaxDataPoints)
+
+		q.MaxDataPoints = 100000
+		res, err = models.Parse(q, "15s", intervalCalculator, false)
+		require.NoError(t, err)
+		require.Equal(t, time.Second*15, res.Step, "the safe resolution still applies")
+	})
+
+	t.Run("parsing query model with downsampling", func(t *testing.T) {
+		timeRange := backend.TimeRange{
+			From: now,
+			To:   now.Add(30 * 24 * time.Hour),
+		}
+
+		for downsampling, expected := range map[string]string{"raw": "0s", "5m": "5m", "1h": "1h", "auto": "auto"} {
+			q := queryContext(`{
+				"expr": "go_goroutines",
+				"downsampling": "`+downsampling+`",
+				"refId": "A"
+			}`, timeRange)
+			res, err := models.Parse(q, "15s", intervalCalculator, false)
+			require.NoError(t, err)
+			require.Equal(t, expected, res.MaxSourceResolution, downsampling)
+		}
+
+		res, err := models.Parse(queryContext(`{"expr": "go_goroutines", "refId": "A"}`, timeRange), "15s", intervalCalculator, false)
+		require.NoError(t, err)
+		require.Empty(t, res.MaxSourceResolution)
+	})
+
+	t.Run("parsing query model with headers", func(t *testing.T) {
+		timeRange := backend.TimeRange{
+			From: now,
+			To:   now.Add(48 * time.Hour),
+		}
+
+		res, err := models.Parse(queryContext(`{"expr": "go_goroutines", "refId": "A", "headers": [
+			{"name": "x-read-consistency", "value": "strong"},
+			{"name": "Sharding-Control", "value": "4"}
+		]}`, timeRange), "15s", intervalCalculator, false)
+		require.NoError(t, err)
+		require.Equal(t, http.Header{"X-Read-Consistency": {"strong"}, "Sharding-Control": {"4"}}, res.Headers)
+
+		for header, expected := range map[string]string{
+			`{"name": "Authorization", "value": "Bearer secret"}`: `the query header "Authorization" is not allowed`,
+			`{"name": "X-Grafana-User", "value": "admin"}`:        `the query header "X-Grafana-User" is not allowed`,
+			`{"name": "x-scope-orgid", "value": "other-tenant"}`:  `the query header "X-Scope-Orgid" is not allowed`,
+			`{"name": "X Read", "value": "strong"}`:               `invalid query header name "X Read"`,
+			`{"name": "X-Read", "value": "a\nb"}`:                 `invalid value of the query header "X-Read"`,
+		} {
+			_, err := models.Parse(queryContext(`{"expr": "go_goroutines", "refId": "A", "headers": [`+header+`]}`, timeRange), "15s", intervalCalculator, false)
+			require.EqualError(t, err, expected)
+		}
+	})
+
+	t.Run("parsing query model with the heatmap format", func(t *testing.T) {
+		timeRange := backend.TimeRange{
+			From: now,
+			To:   now.Add(48 * time.Hour),
+		}
+
+		res, err := models.Parse(queryContext(`{"expr": "rate(http_request_duration_seconds_bucket[5m])", "format": "heatmap", "refId": "A"}`, timeRange), "15s", intervalCalculator, false)
+		require.NoError(t, err)
+		require.True(t, res.HeatmapFormat)
+
+		res, err = models.Parse(queryContext(`{"expr": "go_goroutines", "format": "time_series", "refId": "A"}`, timeRange), "15s", intervalCalculator, false)
+		require.NoError(t, err)
+		require.False(t, res.HeatmapFormat)
+	})
+
 	t.Run("parsing query model specified scrape-interval in the data source", func(t *testing.T) {
 		timeRange := backend.TimeRange{
 			From: now,
@@ -109,6 +109,14 @@ func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceReq
 		return sender.Send(vResp)
 	}
 
+	if resource.IsLabelsRequest(req) {
+		resp, err := i.resource.Labels(ctx, req)
+		if err != nil {
+			return err
+		}
+		return sender.Send(resp)
+	}
+
 	resp, err := i.resource.Execute(ctx, req)
 	if err != nil {
 		return err
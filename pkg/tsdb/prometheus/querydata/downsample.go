@@ -0,0 +1,107 @@
+package querydata
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// downsampleFrames reduces the series with more than maxPoints points, which happens for instant queries returning
+// range vectors or when the step can't fit the time range in the max data points. Only the frames with a single series
+// are downsampled, the points of wide frames are shared by all their series, and exemplars are sampled on their own.
+func downsampleFrames(frames data.Frames, maxPoints int) {
+	if maxPoints < 3 {
+		return
+	}
+	for _, frame := range frames {
+		if len(frame.Fields) != 2 || frame.Rows() <= maxPoints || isExemplarFrame(frame) ||
+			frame.Fields[0].Type() != data.FieldTypeTime || frame.Fields[1].Type() != data.FieldTypeFloat64 {
+			continue
+		}
+
+		rows := frame.Rows()
+		times := make([]time.Time, rows)
+		values := make([]float64, rows)
+		for i := 0; i < rows; i++ {
+			times[i] = frame.Fields[0].At(i).(time.Time)
+			values[i] = frame.Fields[1].At(i).(float64)
+		}
+
+		indices := largestTriangleThreeBuckets(times, values, maxPoints)
+		sampledTimes := make([]time.Time, len(indices))
+		sampledValues := make([]float64, len(indices))
+		for i, idx := range indices {
+			sampledTimes[i] = times[idx]
+			sampledValues[i] = values[idx]
+		}
+		frame.Fields[0] = copyField(frame.Fields[0], sampledTimes)
+		frame.Fields[1] = copyField(frame.Fields[1], sampledValues)
+
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		frame.Meta.Notices = append(frame.Meta.Notices, data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     fmt.Sprintf("Series downsampled from %d to %d points, the max data points of the query", rows, len(indices)),
+		})
+	}
+}
+
+func copyField(f *data.Field, values interface{}) *data.Field {
+	field := data.NewField(f.Name, f.Labels, values)
+	field.Config = f.Config
+	return field
+}
+
+// largestTriangleThreeBuckets returns the indices of the points to keep so that the shape of the series is kept, it
+// implements the algorithm of Sveinn Steinarsson's thesis "Downsampling Time Series for Visual Representation". The
+// first and the last points are always kept, and for each bucket of points in between the point forming the largest
+// triangle with the previous kept point and the average of the next bucket is kept.
+func largestTriangleThreeBuckets(times []time.Time, values []float64, threshold int) []int {
+	n := len(values)
+	if threshold >= n || threshold < 3 {
+		indices := make([]int, n)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+
+	x := func(i int) float64 {
+		return times[i].Sub(times[0]).Seconds()
+	}
+	bucketSize := float64(n-2) / float64(threshold-2)
+	indices := make([]int, 0, threshold)
+	indices = append(indices, 0)
+	a := 0
+	for i := 0; i < threshold-2; i++ {
+		nextStart := int(math.Floor(float64(i+1)*bucketSize)) + 1
+		nextEnd := int(math.Floor(float64(i+2)*bucketSize)) + 1
+		if nextEnd > n {
+			nextEnd = n
+		}
+		var avgX, avgY float64
+		for j := nextStart; j < nextEnd; j++ {
+			avgX += x(j)
+			avgY += values[j]
+		}
+		count := float64(nextEnd - nextStart)
+		avgX /= count
+		avgY /= count
+
+		start := int(math.Floor(float64(i)*bucketSize)) + 1
+		end := nextStart
+		maxArea, next := -1.0, start
+		for j := start; j < end; j++ {
+			area := math.Abs((x(a)-avgX)*(values[j]-values[a]) - (x(a)-x(j))*(avgY-values[a]))
+			if area > maxArea {
+				maxArea, next = area, j
+			}
+		}
+		indices = append(indices, next)
+		a = next
+	}
+	return append(indices, n-1)
+}
@@ -0,0 +1,155 @@
+package querydata
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// bucketLabel is the label of the upper bound of the buckets of the classic histograms
+	bucketLabel = "le"
+	// frameTypeHeatmapRows is the type of the frames with a field of counts for each bucket, read by the heatmap panel
+	frameTypeHeatmapRows data.FrameType = "heatmap-rows"
+)
+
+type histogramBucket struct {
+	frame *data.Frame
+	le    float64
+}
+
+// heatmapFrames converts the series of the buckets of each histogram into a heatmap rows frame, with a field of the
+// counts of each bucket ordered by their upper bound. The histograms are the series with the same labels besides le,
+// and their buckets are cumulative, so that the counts of each bucket are the difference with the previous bucket.
+// The other frames are kept as is.
+func heatmapFrames(frames data.Frames) data.Frames {
+	result := make(data.Frames, 0, len(frames))
+	histograms := map[string][]histogramBucket{}
+	var keys []string
+	for _, frame := range frames {
+		le, ok := bucketBound(frame)
+		if !ok {
+			result = append(result, frame)
+			continue
+		}
+		key := histogramLabels(frame.Fields[1].Labels).String()
+		if _, found := histograms[key]; !found {
+			keys = append(keys, key)
+		}
+		histograms[key] = append(histograms[key], histogramBucket{frame: frame, le: le})
+	}
+
+	for _, key := range keys {
+		result = append(result, heatmapFrame(histograms[key]))
+	}
+	return result
+}
+
+// bucketBound returns the upper bound of the bucket of a series with a parseable le label
+func bucketBound(frame *data.Frame) (float64, bool) {
+	if isExemplarFrame(frame) || len(frame.Fields) != 2 || frame.Fields[0].Type() != data.FieldTypeTime ||
+		!frame.Fields[1].Type().Numeric() {
+		return 0, false
+	}
+	le, ok := frame.Fields[1].Labels[bucketLabel]
+	if !ok {
+		return 0, false
+	}
+	bound, err := strconv.ParseFloat(le, 64)
+	if err != nil {
+		return 0, false
+	}
+	return bound, true
+}
+
+func histogramLabels(labels data.Labels) data.Labels {
+	histogram := data.Labels{}
+	for name, value := range labels {
+		if name != bucketLabel {
+			histogram[name] = value
+		}
+	}
+	return histogram
+}
+
+func heatmapFrame(buckets []histogramBucket) *data.Frame {
+	sort.SliceStable(buckets, func(i, j int) bool {
+		return buckets[i].le < buckets[j].le
+	})
+
+	// the buckets are aligned on the timestamps of all of them, a bucket without a point at a timestamp has no count
+	index := map[time.Time]int{}
+	var times []time.Time
+	for _, b := range buckets {
+		timeField := b.frame.Fields[0]
+		for i := 0; i < timeField.Len(); i++ {
+			t := timeField.At(i).(time.Time)
+			if _, ok := index[t]; !ok {
+				index[t] = len(times)
+				times = append(times, t)
+			}
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	for i, t := range times {
+		index[t] = i
+	}
+
+	cumulative := make([][]*float64, len(buckets))
+	for i, b := range buckets {
+		cumulative[i] = make([]*float64, len(times))
+		timeField, valueField := b.frame.Fields[0], b.frame.Fields[1]
+		for j := 0; j < valueField.Len(); j++ {
+			value, err := valueField.NullableFloatAt(j)
+			if err != nil {
+				continue
+			}
+			cumulative[i][index[timeField.At(j).(time.Time)]] = value
+		}
+	}
+
+	first := buckets[0].frame
+	timeField := data.NewField(first.Fields[0].Name, nil, times)
+	timeField.Config = first.Fields[0].Config
+	fields := []*data.Field{timeField}
+	labels := histogramLabels(first.Fields[1].Labels)
+	// below is the cumulative count of the highest lower bucket with a point at each timestamp
+	below := make([]float64, len(times))
+	for i, b := range buckets {
+		counts := make([]*float64, len(times))
+		for j, value := range cumulative[i] {
+			if value == nil {
+				continue
+			}
+			count := *value - below[j]
+			below[j] = *value
+			// the buckets are not scraped at once, a bucket can be behind the previous one after a counter reset
+			if count < 0 {
+				count = 0
+			}
+			counts[j] = &count
+		}
+		le := b.frame.Fields[1].Labels[bucketLabel]
+		field := data.NewField(le, labels, counts)
+		field.Config = &data.FieldConfig{DisplayNameFromDS: le}
+		fields = append(fields, field)
+	}
+
+	name := ""
+	if len(labels) > 0 {
+		name = metricNameFromLabels(fields[1])
+	}
+	frame := data.NewFrame(name, fields...)
+	frame.RefID = first.RefID
+	meta := data.FrameMeta{}
+	if first.Meta != nil {
+		meta = *first.Meta
+	}
+	meta.Type = frameTypeHeatmapRows
+	frame.Meta = &meta
+	return frame
+}
@@ -0,0 +1,66 @@
+package querydata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeatmapFrames(t *testing.T) {
+	times := func(seconds ...int64) []time.Time {
+		result := make([]time.Time, len(seconds))
+		for i, s := range seconds {
+			result[i] = time.Unix(s, 0).UTC()
+		}
+		return result
+	}
+	series := func(labels data.Labels, ts []time.Time, values []float64) *data.Frame {
+		frame := data.NewFrame("",
+			data.NewField("Time", nil, ts),
+			data.NewField("Value", labels, values),
+		)
+		frame.Meta = &data.FrameMeta{ExecutedQueryString: "Expr: up"}
+		return frame
+	}
+	ptr := func(v float64) *float64 { return &v }
+
+	frames := heatmapFrames(data.Frames{
+		series(data.Labels{"le": "+Inf", "job": "api"}, times(1, 2, 3), []float64{30, 10, 40}),
+		series(data.Labels{"le": "1", "job": "api"}, times(1, 2, 3), []float64{10, 10, 0}),
+		series(data.Labels{"le": "0.5", "job": "web"}, times(1, 2), []float64{1, 2}),
+		series(data.Labels{"le": "2", "job": "api"}, times(2, 3), []float64{10, 30}),
+		series(data.Labels{"job": "api"}, times(1), []float64{5}),
+	})
+	require.Len(t, frames, 3)
+
+	assert.Len(t, frames[0].Fields, 2, "the series without le are kept")
+
+	api := frames[1]
+	assert.Equal(t, frameTypeHeatmapRows, api.Meta.Type)
+	assert.Equal(t, "Expr: up", api.Meta.ExecutedQueryString)
+	assert.Equal(t, `{job="api"}`, api.Name)
+	require.Len(t, api.Fields, 4)
+	assert.Equal(t, times(1, 2, 3), []time.Time{api.Fields[0].At(0).(time.Time), api.Fields[0].At(1).(time.Time), api.Fields[0].At(2).(time.Time)})
+
+	values := func(f *data.Field) []*float64 {
+		result := make([]*float64, f.Len())
+		for i := range result {
+			result[i] = f.At(i).(*float64)
+		}
+		return result
+	}
+	assert.Equal(t, "1", api.Fields[1].Name)
+	assert.Equal(t, data.Labels{"job": "api"}, api.Fields[1].Labels)
+	assert.Equal(t, []*float64{ptr(10), ptr(10), ptr(0)}, values(api.Fields[1]))
+	assert.Equal(t, "2", api.Fields[2].Name)
+	assert.Equal(t, []*float64{nil, ptr(0), ptr(30)}, values(api.Fields[2]), "a bucket without a point has no count, the next bucket is counted from the previous one")
+	assert.Equal(t, "+Inf", api.Fields[3].Name)
+	assert.Equal(t, []*float64{ptr(20), ptr(0), ptr(10)}, values(api.Fields[3]), "the counts are not negative")
+
+	web := frames[2]
+	require.Len(t, web.Fields, 2)
+	assert.Equal(t, []*float64{ptr(1), ptr(2)}, values(web.Fields[1]))
+}
@@ -43,7 +43,9 @@ type QueryData struct {
 	URL                string
 	TimeInterval       string
 	enableWideSeries   bool
-	exemplarSampler    func() exemplar.Sampler
+	// heatmapServerSide converts the histogram buckets of the queries with the heatmap format into heatmap rows
+	heatmapServerSide bool
+	exemplarSampler   func() exemplar.Sampler
 }
 
 func New(
@@ -64,6 +66,11 @@ func New(
 		return nil, err
 	}
 
+	heatmapServerSide, err := maputil.GetBoolOptional(jsonData, "heatmapServerSide")
+	if err != nil {
+		return nil, err
+	}
+
 	promClient := client.NewClient(httpClient, httpMethod, settings.URL)
 
 	// standard deviation sampler is the default for backwards compatibility
@@ -82,6 +89,7 @@ func New(
 		ID:                 settings.ID,
 		URL:                settings.URL,
 		enableWideSeries:   features.IsEnabled(featuremgmt.FlagPrometheusWideSeries),
+		heatmapServerSide:  heatmapServerSide,
 		exemplarSampler:    exemplarSampler,
 	}, nil
 }
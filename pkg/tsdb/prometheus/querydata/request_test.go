@@ -322,6 +322,54 @@ func TestPrometheus_parseTimeSeriesResponse(t *testing.T) {
 		require.Equal(t, int64(123), testValue.(time.Time).UnixMilli())
 	})
 
+	t.Run("series with more points than max data points should be downsampled", func(t *testing.T) {
+		values := make([]p.SamplePair, 1000)
+		for i := range values {
+			values[i] = p.SamplePair{Value: p.SampleValue(i % 7), Timestamp: p.Time(i * 1000)}
+		}
+		values[500].Value = 100
+		result := queryResult{
+			Type: p.ValMatrix,
+			Result: p.Matrix{
+				&p.SampleStream{Metric: p.Metric{"app": "Application"}, Values: values},
+			},
+		}
+
+		qm := models.QueryModel{
+			PrometheusDataQuery: dataquery.PrometheusDataQuery{
+				Instant: kindsys.Ptr(true),
+			},
+		}
+		b, err := json.Marshal(&qm)
+		require.NoError(t, err)
+		query := backend.DataQuery{
+			TimeRange: backend.TimeRange{
+				From: time.Unix(0, 0).UTC(),
+				To:   time.Unix(1000, 0).UTC(),
+			},
+			MaxDataPoints: 100,
+			JSON:          b,
+		}
+		tctx, err := setup(false)
+		require.NoError(t, err)
+		res, err := execute(tctx, query, result)
+		require.NoError(t, err)
+
+		require.Len(t, res, 1)
+		require.Equal(t, 100, res[0].Rows())
+		require.Equal(t, time.UnixMilli(0).UTC(), res[0].Fields[0].At(0))
+		require.Equal(t, time.UnixMilli(999000).UTC(), res[0].Fields[0].At(99))
+		require.Len(t, res[0].Meta.Notices, 1)
+		require.Equal(t, "Series downsampled from 1000 to 100 points, the max data points of the query", res[0].Meta.Notices[0].Text)
+
+		// the spike is kept
+		peak := 0.0
+		for i := 0; i < res[0].Rows(); i++ {
+			peak = math.Max(peak, res[0].Fields[1].At(i).(float64))
+		}
+		require.Equal(t, 100.0, peak)
+	})
+
 	t.Run("scalar response should be parsed normally", func(t *testing.T) {
 		t.Skip("TODO: implement scalar responses")
 		qr := queryResult{
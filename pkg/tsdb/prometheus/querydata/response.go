@@ -44,6 +44,13 @@ func (s *QueryData) parseResponse(ctx context.Context, q *models.Query, res *htt
 		}
 	}
 
+	if !s.enableWideSeries {
+		if s.heatmapServerSide && q.HeatmapFormat {
+			r.Frames = heatmapFrames(r.Frames)
+		}
+		downsampleFrames(r.Frames, int(q.MaxDataPoints))
+	}
+
 	if r.Error == nil {
 		r = s.processExemplars(q, r)
 	}
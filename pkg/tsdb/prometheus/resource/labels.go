@@ -0,0 +1,195 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/client"
+)
+
+// labelParams are the parameters of the label APIs passed down to Prometheus, the series selectors and the time
+// bounds the labels are read from.
+var labelParams = []string{"match[]", "start", "end"}
+
+var labelValuesPath = regexp.MustCompile(`^api/v1/label/[^/]+/values$`)
+
+// labelsResponse is the body returned by the label names and label values APIs of Prometheus
+type labelsResponse struct {
+	Status    string   `json:"status"`
+	Data      []string `json:"data"`
+	Warnings  []string `json:"warnings,omitempty"`
+	ErrorType string   `json:"errorType,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// federatedMember is a Prometheus server whose labels are merged with the labels of the data source
+type federatedMember struct {
+	url    string
+	client *client.Client
+}
+
+// federatedURLs returns the federatedUrls of jsonData, the Prometheus servers queried with the data source for the
+// label names and values.
+func federatedURLs(jsonData map[string]interface{}) ([]string, error) {
+	raw, ok := jsonData["federatedUrls"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	values, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("federatedUrls must be a list of URLs")
+	}
+
+	urls := make([]string, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("federatedUrls must be a list of URLs")
+		}
+		if _, err := url.ParseRequestURI(s); err != nil {
+			return nil, fmt.Errorf("invalid federated URL %q: %w", s, err)
+		}
+		urls = append(urls, s)
+	}
+	return urls, nil
+}
+
+// IsLabelsRequest returns whether the resource call asks for the label names or the values of a label
+func IsLabelsRequest(req *backend.CallResourceRequest) bool {
+	p := strings.Trim(req.Path, "/")
+	return p == "api/v1/labels" || labelValuesPath.MatchString(p)
+}
+
+// Labels returns the label names or the values of a label. Without federated servers the call is sent to Prometheus
+// as is. Otherwise the match[] selectors and the time bounds of the call are sent to the data source and each
+// federated server, and the labels are merged. The servers that fail are reported as warnings, the call fails when
+// they all fail.
+func (r *Resource) Labels(ctx context.Context, req *backend.CallResourceRequest) (*backend.CallResourceResponse, error) {
+	if len(r.federated) == 0 {
+		return r.Execute(ctx, req)
+	}
+
+	params, err := labelRequestParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	members := append([]federatedMember{{url: r.url, client: r.promClient}}, r.federated...)
+	responses := make([]*labelsResponse, len(members))
+	errs := make([]error, len(members))
+	var wg sync.WaitGroup
+	for i, m := range members {
+		wg.Add(1)
+		go func(i int, m federatedMember) {
+			defer wg.Done()
+			responses[i], errs[i] = r.queryLabels(ctx, m, req.Path, params)
+		}(i, m)
+	}
+	wg.Wait()
+
+	merged := &labelsResponse{Status: "success", Data: []string{}}
+	seen := map[string]struct{}{}
+	succeeded := 0
+	for i, resp := range responses {
+		if errs[i] != nil {
+			r.log.FromContext(ctx).Warn("Failed to get the labels of a federated server", "url", members[i].url, "err", errs[i])
+			merged.Warnings = append(merged.Warnings, fmt.Sprintf("failed to get the labels of %s: %s", members[i].url, errs[i]))
+			continue
+		}
+		succeeded++
+		for _, label := range resp.Data {
+			if _, ok := seen[label]; ok {
+				continue
+			}
+			seen[label] = struct{}{}
+			merged.Data = append(merged.Data, label)
+		}
+		merged.Warnings = append(merged.Warnings, resp.Warnings...)
+	}
+	sort.Strings(merged.Data)
+
+	status := http.StatusOK
+	if succeeded == 0 {
+		status = http.StatusBadGateway
+		merged = &labelsResponse{Status: "error", ErrorType: "unavailable", Error: strings.Join(merged.Warnings, ", ")}
+	}
+
+	body, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.CallResourceResponse{
+		Status: status,
+		Headers: map[string][]string{
+			"Content-Type": {"application/json"},
+		},
+		Body: body,
+	}, nil
+}
+
+// labelRequestParams returns the label parameters of the query string of the call, and of its body for the POST
+// calls.
+func labelRequestParams(req *backend.CallResourceRequest) (url.Values, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	sources := []url.Values{u.Query()}
+	if req.Method == http.MethodPost && len(req.Body) > 0 {
+		form, err := url.ParseQuery(string(req.Body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid label request body: %w", err)
+		}
+		sources = append(sources, form)
+	}
+
+	params := url.Values{}
+	for _, source := range sources {
+		for _, name := range labelParams {
+			for _, v := range source[name] {
+				params.Add(name, v)
+			}
+		}
+	}
+	return params, nil
+}
+
+func (r *Resource) queryLabels(ctx context.Context, m federatedMember, path string, params url.Values) (*labelsResponse, error) {
+	resp, err := m.client.QueryLabels(ctx, path, params)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			r.log.FromContext(ctx).Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var labels labelsResponse
+	if err := json.Unmarshal(body, &labels); err != nil {
+		if resp.StatusCode/100 != 2 {
+			return nil, fmt.Errorf("%s", resp.Status)
+		}
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 || labels.Status != "success" {
+		return nil, fmt.Errorf("%s: %s", resp.Status, labels.Error)
+	}
+	return &labels, nil
+}
@@ -0,0 +1,127 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestLabels(t *testing.T) {
+	newServer := func(t *testing.T, labels string) (*httptest.Server, *http.Request) {
+		var received http.Request
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			received = *r
+			if labels == "" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"status": "error", "errorType": "unavailable", "error": "too many requests"}`))
+				return
+			}
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"status": "success", "data": %s}`, labels)))
+		}))
+		t.Cleanup(srv.Close)
+		return srv, &received
+	}
+
+	newResource := func(t *testing.T, url string, jsonData string) *Resource {
+		r, err := New(http.DefaultClient, backend.DataSourceInstanceSettings{URL: url, JSONData: []byte(jsonData)}, log.New("test"))
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("the labels of the federated servers are merged", func(t *testing.T) {
+		main, mainReq := newServer(t, `["job", "instance"]`)
+		eu, euReq := newServer(t, `["job", "region"]`)
+		r := newResource(t, main.URL, fmt.Sprintf(`{"federatedUrls": [%q]}`, eu.URL))
+
+		res, err := r.Labels(context.Background(), &backend.CallResourceRequest{
+			Method: http.MethodGet,
+			Path:   "/api/v1/labels",
+			URL:    "/api/v1/labels?match%5B%5D=up&start=1655271408&end=1655293008&other=1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.JSONEq(t, `{"status": "success", "data": ["instance", "job", "region"]}`, string(res.Body))
+
+		for _, req := range []*http.Request{mainReq, euReq} {
+			assert.Equal(t, "/api/v1/labels", req.URL.Path)
+			assert.Equal(t, []string{"up"}, req.Form["match[]"])
+			assert.Equal(t, "1655271408", req.Form.Get("start"))
+			assert.Equal(t, "1655293008", req.Form.Get("end"))
+			assert.Empty(t, req.Form.Get("other"))
+		}
+	})
+
+	t.Run("the parameters of the POST calls are read from the body", func(t *testing.T) {
+		main, mainReq := newServer(t, `["api"]`)
+		eu, euReq := newServer(t, `["web"]`)
+		r := newResource(t, main.URL, fmt.Sprintf(`{"httpMethod": "POST", "federatedUrls": [%q]}`, eu.URL))
+
+		res, err := r.Labels(context.Background(), &backend.CallResourceRequest{
+			Method: http.MethodPost,
+			Path:   "/api/v1/label/job/values",
+			URL:    "/api/v1/label/job/values",
+			Body:   []byte("match%5B%5D=up&match%5B%5D=ALERTS&start=1655271408"),
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status": "success", "data": ["api", "web"]}`, string(res.Body))
+
+		// the label values API of Prometheus only accepts GET requests
+		assert.Equal(t, http.MethodGet, euReq.Method)
+		assert.Equal(t, []string{"up", "ALERTS"}, mainReq.Form["match[]"])
+		assert.Equal(t, "1655271408", mainReq.Form.Get("start"))
+	})
+
+	t.Run("the failed servers are reported as warnings", func(t *testing.T) {
+		main, _ := newServer(t, `["job"]`)
+		eu, _ := newServer(t, "")
+		r := newResource(t, main.URL, fmt.Sprintf(`{"federatedUrls": [%q]}`, eu.URL))
+
+		res, err := r.Labels(context.Background(), &backend.CallResourceRequest{Method: http.MethodGet, Path: "api/v1/labels", URL: "api/v1/labels"})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.Status)
+
+		var body labelsResponse
+		require.NoError(t, json.Unmarshal(res.Body, &body))
+		assert.Equal(t, []string{"job"}, body.Data)
+		assert.Equal(t, []string{fmt.Sprintf("failed to get the labels of %s: 503 Service Unavailable: too many requests", eu.URL)}, body.Warnings)
+	})
+
+	t.Run("the call fails when every server fails", func(t *testing.T) {
+		main, _ := newServer(t, "")
+		eu, _ := newServer(t, "")
+		r := newResource(t, main.URL, fmt.Sprintf(`{"federatedUrls": [%q]}`, eu.URL))
+
+		res, err := r.Labels(context.Background(), &backend.CallResourceRequest{Method: http.MethodGet, Path: "api/v1/labels", URL: "api/v1/labels"})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, res.Status)
+
+		var body labelsResponse
+		require.NoError(t, json.Unmarshal(res.Body, &body))
+		assert.Equal(t, "error", body.Status)
+		assert.Contains(t, body.Error, "too many requests")
+	})
+
+	t.Run("invalid federated URLs are rejected", func(t *testing.T) {
+		_, err := New(http.DefaultClient, backend.DataSourceInstanceSettings{JSONData: []byte(`{"federatedUrls": ["eu-prometheus"]}`)}, log.New("test"))
+		require.ErrorContains(t, err, `invalid federated URL "eu-prometheus"`)
+		_, err = New(http.DefaultClient, backend.DataSourceInstanceSettings{JSONData: []byte(`{"federatedUrls": "http://eu-prometheus"}`)}, log.New("test"))
+		require.EqualError(t, err, "federatedUrls must be a list of URLs")
+	})
+}
+
+func TestIsLabelsRequest(t *testing.T) {
+	assert.True(t, IsLabelsRequest(&backend.CallResourceRequest{Path: "/api/v1/labels"}))
+	assert.True(t, IsLabelsRequest(&backend.CallResourceRequest{Path: "api/v1/label/job/values"}))
+	assert.False(t, IsLabelsRequest(&backend.CallResourceRequest{Path: "api/v1/series"}))
+	assert.False(t, IsLabelsRequest(&backend.CallResourceRequest{Path: "api/v1/label/job"}))
+}
@@ -16,7 +16,10 @@ import (
 
 type Resource struct {
 	promClient *client.Client
-	log        log.Logger
+	url        string
+	// federated are the Prometheus servers whose labels are merged with the labels of the data source
+	federated []federatedMember
+	log       log.Logger
 }
 
 func New(
@@ -29,10 +32,21 @@ func New(
 		return nil, err
 	}
 	httpMethod, _ := maputil.GetStringOptional(jsonData, "httpMethod")
+	urls, err := federatedURLs(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	federated := make([]federatedMember, 0, len(urls))
+	for _, u := range urls {
+		federated = append(federated, federatedMember{url: u, client: client.NewClient(httpClient, httpMethod, u)})
+	}
 
 	return &Resource{
 		log:        plog,
 		promClient: client.NewClient(httpClient, httpMethod, settings.URL),
+		url:        settings.URL,
+		federated:  federated,
 	}, nil
 }
 
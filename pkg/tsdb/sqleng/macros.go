@@ -0,0 +1,171 @@
+package sqleng
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// Dialect is the SQL dialect of a data source, the registered macros can have an implementation for every dialect
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+	DialectMSSQL    Dialect = "mssql"
+)
+
+// MacroContext is the query a macro is expanded in
+type MacroContext struct {
+	Query     *backend.DataQuery
+	TimeRange backend.TimeRange
+}
+
+// Interval returns the interval of the query, calculated from the time range and the max data points like $__interval
+func (c MacroContext) Interval() time.Duration {
+	var minInterval time.Duration
+	var maxDataPoints int64
+	if c.Query != nil {
+		minInterval, maxDataPoints = c.Query.Interval, c.Query.MaxDataPoints
+	}
+	return sqlIntervalCalculator.Calculate(c.TimeRange, minInterval, maxDataPoints).Value
+}
+
+// MacroFunc expands a macro, the arguments are the trimmed arguments of the macro call
+type MacroFunc func(ctx MacroContext, args []string) (string, error)
+
+// Macro is a macro added to the built-in macros of the SQL data sources. The name of the macro is its name in the
+// queries without the $, like __timeBucket for $__timeBucket(...).
+type Macro struct {
+	Name string
+	// Dialects are the implementations of the macro for the dialects it supports
+	Dialects map[Dialect]MacroFunc
+	// Default is the implementation for the other dialects, the macro is unknown in the other dialects without it
+	Default MacroFunc
+}
+
+var macroNameRegExp = regexp.MustCompile(`^__[_a-zA-Z0-9]+$`)
+
+// MacroRegistry is a set of macros expanded by the macro engines of the SQL data sources, after their built-in
+// macros. A macro with the name of a built-in macro of a data source is never expanded in that data source.
+type MacroRegistry struct {
+	mu     sync.RWMutex
+	macros map[string]Macro
+}
+
+func NewMacroRegistry() *MacroRegistry {
+	return &MacroRegistry{macros: map[string]Macro{}}
+}
+
+// Register adds the macro to the registry, a macro can only be registered once
+func (r *MacroRegistry) Register(m Macro) error {
+	if !macroNameRegExp.MatchString(m.Name) {
+		return fmt.Errorf("invalid macro name %q, the names of the macros start with __", m.Name)
+	}
+	if m.Default == nil && len(m.Dialects) == 0 {
+		return fmt.Errorf("macro %s has no implementation", m.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.macros[m.Name]; ok {
+		return fmt.Errorf("macro %s is already registered", m.Name)
+	}
+	r.macros[m.Name] = m
+	return nil
+}
+
+// Lookup returns the implementation of the macro for the dialect
+func (r *MacroRegistry) Lookup(dialect Dialect, name string) (MacroFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.macros[name]
+	if !ok {
+		return nil, false
+	}
+	if fn, ok := m.Dialects[dialect]; ok {
+		return fn, true
+	}
+	return m.Default, m.Default != nil
+}
+
+// Macros is the registry of the macros expanded by the SQL data sources, with $__timeBucket and $__intervalRound
+var Macros = newDefaultMacroRegistry()
+
+// RegisterMacro adds the macro to the macros expanded by the SQL data sources
+func RegisterMacro(m Macro) error {
+	return Macros.Register(m)
+}
+
+// LookupMacro returns the implementation of a registered macro for the dialect
+func LookupMacro(dialect Dialect, name string) (MacroFunc, bool) {
+	return Macros.Lookup(dialect, name)
+}
+
+func newDefaultMacroRegistry() *MacroRegistry {
+	r := NewMacroRegistry()
+	for _, m := range []Macro{
+		{
+			Name: "__timeBucket",
+			Dialects: map[Dialect]MacroFunc{
+				DialectPostgres: timeBucketMacro("to_timestamp(floor(extract(epoch from %[1]s)/%[2]d)*%[2]d)"),
+				DialectMySQL:    timeBucketMacro("FROM_UNIXTIME(FLOOR(UNIX_TIMESTAMP(%[1]s)/%[2]d)*%[2]d)"),
+				DialectMSSQL:    timeBucketMacro("DATEADD(second, FLOOR(DATEDIFF(second, '1970-01-01', %[1]s)/%[2]d)*%[2]d, '1970-01-01')"),
+			},
+		},
+		{Name: "__intervalRound", Default: intervalRoundMacro},
+	} {
+		if err := r.Register(m); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// timeBucketMacro returns the $__timeBucket(column[, interval]) macro, which truncates the times of the column to
+// the interval, the interval of the query by default. Unlike $__timeGroup, the buckets are times and not epochs.
+func timeBucketMacro(format string) MacroFunc {
+	return func(ctx MacroContext, args []string) (string, error) {
+		if len(args) == 0 || args[0] == "" {
+			return "", fmt.Errorf("missing time column argument for macro __timeBucket")
+		}
+		interval := ctx.Interval()
+		if len(args) > 1 {
+			var err error
+			if interval, err = gtime.ParseInterval(strings.Trim(args[1], `'`)); err != nil {
+				return "", fmt.Errorf("error parsing interval %v", args[1])
+			}
+		}
+		return fmt.Sprintf(format, args[0], intervalSeconds(interval)), nil
+	}
+}
+
+// intervalRoundMacro is the $__intervalRound(step) macro, the interval of the query in seconds rounded up to a
+// multiple of the step, so the buckets of the queries with different time ranges are aligned
+func intervalRoundMacro(ctx MacroContext, args []string) (string, error) {
+	if len(args) == 0 || args[0] == "" {
+		return "", fmt.Errorf("missing step argument for macro __intervalRound")
+	}
+	step, err := gtime.ParseInterval(strings.Trim(args[0], `'`))
+	if err != nil {
+		return "", fmt.Errorf("error parsing interval %v", args[0])
+	}
+	stepSeconds := intervalSeconds(step)
+	rounded := int64(math.Ceil(float64(intervalSeconds(ctx.Interval()))/float64(stepSeconds))) * stepSeconds
+	return fmt.Sprintf("%d", rounded), nil
+}
+
+// intervalSeconds returns the interval in whole seconds, at least one second
+func intervalSeconds(interval time.Duration) int64 {
+	seconds := int64(interval / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
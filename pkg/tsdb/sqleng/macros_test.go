@@ -0,0 +1,88 @@
+package sqleng
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacroRegistry(t *testing.T) {
+	orgMacro := func(dialect string) MacroFunc {
+		return func(ctx MacroContext, args []string) (string, error) {
+			return fmt.Sprintf("%s(%s)", dialect, args[0]), nil
+		}
+	}
+
+	t.Run("returns the implementation of the dialect", func(t *testing.T) {
+		r := NewMacroRegistry()
+		require.NoError(t, r.Register(Macro{
+			Name:     "__tenant",
+			Dialects: map[Dialect]MacroFunc{DialectPostgres: orgMacro("postgres")},
+			Default:  orgMacro("default"),
+		}))
+
+		fn, ok := r.Lookup(DialectPostgres, "__tenant")
+		require.True(t, ok)
+		sql, err := fn(MacroContext{}, []string{"org_id"})
+		require.NoError(t, err)
+		assert.Equal(t, "postgres(org_id)", sql)
+
+		fn, ok = r.Lookup(DialectMySQL, "__tenant")
+		require.True(t, ok)
+		sql, err = fn(MacroContext{}, []string{"org_id"})
+		require.NoError(t, err)
+		assert.Equal(t, "default(org_id)", sql)
+
+		_, ok = r.Lookup(DialectMySQL, "__unknown")
+		assert.False(t, ok)
+	})
+
+	t.Run("the macros without default are unknown in the other dialects", func(t *testing.T) {
+		r := NewMacroRegistry()
+		require.NoError(t, r.Register(Macro{Name: "__tenant", Dialects: map[Dialect]MacroFunc{DialectMSSQL: orgMacro("mssql")}}))
+		_, ok := r.Lookup(DialectPostgres, "__tenant")
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects the invalid macros", func(t *testing.T) {
+		r := NewMacroRegistry()
+		require.EqualError(t, r.Register(Macro{Name: "tenant", Default: orgMacro("default")}), `invalid macro name "tenant", the names of the macros start with __`)
+		require.EqualError(t, r.Register(Macro{Name: "__tenant"}), "macro __tenant has no implementation")
+		require.NoError(t, r.Register(Macro{Name: "__tenant", Default: orgMacro("default")}))
+		require.EqualError(t, r.Register(Macro{Name: "__tenant", Default: orgMacro("default")}), "macro __tenant is already registered")
+	})
+}
+
+func TestDefaultMacros(t *testing.T) {
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	ctx := MacroContext{
+		Query:     &backend.DataQuery{Interval: time.Minute, MaxDataPoints: 100},
+		TimeRange: backend.TimeRange{From: from, To: from.Add(6 * time.Hour)},
+	}
+
+	t.Run("__timeBucket uses the interval of the query by default", func(t *testing.T) {
+		fn, ok := LookupMacro(DialectMySQL, "__timeBucket")
+		require.True(t, ok)
+		sql, err := fn(ctx, []string{"created_at"})
+		require.NoError(t, err)
+		assert.Equal(t, "FROM_UNIXTIME(FLOOR(UNIX_TIMESTAMP(created_at)/300)*300)", sql)
+
+		_, err = fn(ctx, []string{""})
+		require.EqualError(t, err, "missing time column argument for macro __timeBucket")
+	})
+
+	t.Run("__intervalRound rounds the interval up to the step", func(t *testing.T) {
+		fn, ok := LookupMacro(DialectPostgres, "__intervalRound")
+		require.True(t, ok)
+		sql, err := fn(ctx, []string{"'2m'"})
+		require.NoError(t, err)
+		assert.Equal(t, "360", sql)
+
+		_, err = fn(ctx, []string{"two minutes"})
+		require.EqualError(t, err, "error parsing interval two minutes")
+	})
+}
@@ -0,0 +1,160 @@
+package sqleng
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"xorm.io/xorm"
+)
+
+// poolReleaseGrace is how long the connection pool of a released data source instance is kept open. The instance of
+// a data source is disposed before the instance with the new settings is created, the new instance reuses the pool
+// when only the pool settings changed, so the connections are not dropped on every update of the data source.
+var poolReleaseGrace = 30 * time.Second
+
+// PoolSettings are the settings of the connection pool of a data source, from the JSON data of the data source
+type PoolSettings struct {
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime is the time after which the connections are closed, they are never closed when it is 0
+	ConnMaxLifetime time.Duration
+}
+
+func poolSettingsFromJSONData(jsonData JsonData) PoolSettings {
+	return PoolSettings{
+		MaxOpenConns:    jsonData.MaxOpenConns,
+		MaxIdleConns:    jsonData.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(jsonData.ConnMaxLifetime) * time.Second,
+	}
+}
+
+type poolKey struct {
+	datasourceUID    string
+	driverName       string
+	connectionString string
+}
+
+// pool is the xorm engine of a data source, shared by its instances while they use the same connection string
+type pool struct {
+	key     poolKey
+	engine  *xorm.Engine
+	refs    int
+	closing *time.Timer
+}
+
+func (p *pool) apply(settings PoolSettings) {
+	p.engine.SetMaxOpenConns(settings.MaxOpenConns)
+	p.engine.SetMaxIdleConns(settings.MaxIdleConns)
+	p.engine.SetConnMaxLifetime(settings.ConnMaxLifetime)
+}
+
+// poolRegistry keeps the connection pools of the data sources, and reports their usage as Prometheus metrics
+type poolRegistry struct {
+	mu    sync.Mutex
+	pools map[poolKey]*pool
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+func newPoolRegistry() *poolRegistry {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("grafana", "sql_pool", name), help, []string{"datasource_uid", "driver"}, nil)
+	}
+	return &poolRegistry{
+		pools:             map[poolKey]*pool{},
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections of the pool, 0 is unlimited"),
+		open:              desc("open_connections", "Number of open connections of the pool, in use or idle"),
+		inUse:             desc("in_use_connections", "Number of connections of the pool in use by the queries"),
+		idle:              desc("idle_connections", "Number of idle connections of the pool"),
+		waitCount:         desc("wait_count_total", "Number of queries which waited for a connection of the pool"),
+		waitDuration:      desc("wait_duration_seconds_total", "Time the queries waited for a connection of the pool"),
+		maxIdleClosed:     desc("max_idle_closed_total", "Number of connections closed because of the maximum number of idle connections"),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "Number of connections closed because of the maximum lifetime of the connections"),
+	}
+}
+
+var pools = newPoolRegistry()
+
+func init() {
+	prometheus.MustRegister(pools)
+}
+
+// acquire returns the pool of the data source with the settings, the pool of a previous instance of the data source
+// with the same connection string is reused and reconfigured
+func (r *poolRegistry) acquire(key poolKey, settings PoolSettings) (*pool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.pools[key]; ok {
+		if p.closing != nil {
+			p.closing.Stop()
+			p.closing = nil
+		}
+		p.refs++
+		p.apply(settings)
+		return p, nil
+	}
+
+	engine, err := NewXormEngine(key.driverName, key.connectionString)
+	if err != nil {
+		return nil, err
+	}
+	p := &pool{key: key, engine: engine, refs: 1}
+	p.apply(settings)
+	r.pools[key] = p
+	return p, nil
+}
+
+// release closes the pool when it is no longer used by an instance, after the grace period for the next instance of
+// the data source
+func (r *poolRegistry) release(p *pool, onClose func(error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p.refs--
+	if p.refs > 0 {
+		return
+	}
+	p.closing = time.AfterFunc(poolReleaseGrace, func() {
+		r.mu.Lock()
+		if p.refs > 0 || r.pools[p.key] != p {
+			r.mu.Unlock()
+			return
+		}
+		delete(r.pools, p.key)
+		r.mu.Unlock()
+		onClose(p.engine.Close())
+	})
+}
+
+func (r *poolRegistry) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{r.maxOpen, r.open, r.inUse, r.idle, r.waitCount, r.waitDuration, r.maxIdleClosed, r.maxLifetimeClosed} {
+		ch <- d
+	}
+}
+
+func (r *poolRegistry) Collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, p := range r.pools {
+		stats := p.engine.DB().Stats()
+		labels := []string{key.datasourceUID, key.driverName}
+		ch <- prometheus.MustNewConstMetric(r.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections), labels...)
+		ch <- prometheus.MustNewConstMetric(r.open, prometheus.GaugeValue, float64(stats.OpenConnections), labels...)
+		ch <- prometheus.MustNewConstMetric(r.inUse, prometheus.GaugeValue, float64(stats.InUse), labels...)
+		ch <- prometheus.MustNewConstMetric(r.idle, prometheus.GaugeValue, float64(stats.Idle), labels...)
+		ch <- prometheus.MustNewConstMetric(r.waitCount, prometheus.CounterValue, float64(stats.WaitCount), labels...)
+		ch <- prometheus.MustNewConstMetric(r.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds(), labels...)
+		ch <- prometheus.MustNewConstMetric(r.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed), labels...)
+		ch <- prometheus.MustNewConstMetric(r.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed), labels...)
+	}
+}
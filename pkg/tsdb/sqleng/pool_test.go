@@ -0,0 +1,88 @@
+package sqleng
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolRegistry(t *testing.T) {
+	origGrace := poolReleaseGrace
+	t.Cleanup(func() {
+		poolReleaseGrace = origGrace
+	})
+
+	key := poolKey{datasourceUID: "sql", driverName: "sqlite3", connectionString: "file::memory:"}
+	settings := PoolSettings{MaxOpenConns: 5, MaxIdleConns: 2, ConnMaxLifetime: time.Hour}
+
+	t.Run("the pool of the previous instance is reused and reconfigured", func(t *testing.T) {
+		poolReleaseGrace = time.Hour
+		r := newPoolRegistry()
+
+		p, err := r.acquire(key, settings)
+		require.NoError(t, err)
+		assert.Equal(t, 5, p.engine.DB().Stats().MaxOpenConnections)
+
+		closed := false
+		r.release(p, func(error) { closed = true })
+		next, err := r.acquire(key, PoolSettings{MaxOpenConns: 10, MaxIdleConns: 2})
+		require.NoError(t, err)
+		assert.Same(t, p.engine, next.engine)
+		assert.Equal(t, 10, next.engine.DB().Stats().MaxOpenConnections)
+		assert.Nil(t, next.closing)
+		assert.False(t, closed)
+		require.NoError(t, next.engine.Close())
+	})
+
+	t.Run("the pools of the other connection strings are not shared", func(t *testing.T) {
+		r := newPoolRegistry()
+		p, err := r.acquire(key, settings)
+		require.NoError(t, err)
+		other, err := r.acquire(poolKey{datasourceUID: "sql", driverName: "sqlite3", connectionString: "file:other?mode=memory"}, settings)
+		require.NoError(t, err)
+		assert.NotSame(t, p.engine, other.engine)
+		require.NoError(t, p.engine.Close())
+		require.NoError(t, other.engine.Close())
+	})
+
+	t.Run("the released pool is closed after the grace period", func(t *testing.T) {
+		poolReleaseGrace = time.Millisecond
+		r := newPoolRegistry()
+		p, err := r.acquire(key, settings)
+		require.NoError(t, err)
+
+		closed := make(chan error, 1)
+		r.release(p, func(err error) { closed <- err })
+		select {
+		case err := <-closed:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the pool was not closed")
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		assert.Empty(t, r.pools)
+	})
+
+	t.Run("the usage of the pools is reported", func(t *testing.T) {
+		r := newPoolRegistry()
+		p, err := r.acquire(key, settings)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, p.engine.Close())
+		})
+
+		expected := `
+# HELP grafana_sql_pool_max_open_connections Maximum number of open connections of the pool, 0 is unlimited
+# TYPE grafana_sql_pool_max_open_connections gauge
+grafana_sql_pool_max_open_connections{datasource_uid="sql",driver="sqlite3"} 5
+`
+		require.NoError(t, testutil.CollectAndCompare(r, strings.NewReader(expected), "grafana_sql_pool_max_open_connections"))
+		assert.Equal(t, 8, testutil.CollectAndCount(r))
+	})
+}
@@ -96,6 +96,7 @@ type DataSourceHandler struct {
 	macroEngine            SQLMacroEngine
 	queryResultTransformer SqlQueryResultTransformer
 	engine                 *xorm.Engine
+	pool                   *pool
 	timeColumnNames        []string
 	metricColumnTypes      []string
 	log                    log.Logger
@@ -151,17 +152,19 @@ func NewQueryDataHandler(config DataPluginConfiguration, queryResultTransformer
 		queryDataHandler.metricColumnTypes = config.MetricColumnTypes
 	}
 
-	// Create the xorm engine
-	engine, err := NewXormEngine(config.DriverName, config.ConnectionString)
+	// Get the xorm engine, the engine of the previous instance of the data source is reused and reconfigured
+	pool, err := pools.acquire(poolKey{
+		datasourceUID:    config.DSInfo.UID,
+		driverName:       config.DriverName,
+		connectionString: config.ConnectionString,
+	}, poolSettingsFromJSONData(config.DSInfo.JsonData))
 	if err != nil {
 		return nil, err
 	}
 
-	engine.SetMaxOpenConns(config.DSInfo.JsonData.MaxOpenConns)
-	engine.SetMaxIdleConns(config.DSInfo.JsonData.MaxIdleConns)
-	engine.SetConnMaxLifetime(time.Duration(config.DSInfo.JsonData.ConnMaxLifetime) * time.Second)
-
+	engine := pool.engine
 	queryDataHandler.engine = engine
+	queryDataHandler.pool = pool
 
 	// Create the xorm session
 	session := engine.NewSession()
@@ -177,12 +180,16 @@ type DBDataResponse struct {
 
 func (e *DataSourceHandler) Dispose() {
 	e.log.Debug("Disposing engine...")
-	if e.engine != nil {
-		if err := e.engine.Close(); err != nil {
-			e.log.Error("Failed to dispose engine", "error", err)
-		}
+	if e.pool != nil {
+		logger := e.log
+		pools.release(e.pool, func(err error) {
+			if err != nil {
+				logger.Error("Failed to dispose engine", "error", err)
+				return
+			}
+			logger.Debug("Engine disposed")
+		})
 	}
-	e.log.Debug("Engine disposed")
 }
 
 func (e *DataSourceHandler) Ping() error {
@@ -0,0 +1,163 @@
+package tempo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+const (
+	// attributeStatisticsSearchLimit is the number of traces sampled from Tempo when the query has no limit set.
+	attributeStatisticsSearchLimit = 500
+	// defaultAttributeLimit is the number of attribute values returned when the query has no attribute limit set.
+	defaultAttributeLimit = 10
+)
+
+// spanNameIntrinsic is the only intrinsic returned with the spans of the search results, the other attributes are
+// returned when they are selected.
+const spanNameIntrinsic = "name"
+
+type attributeStatisticsRow struct {
+	value     string
+	spanCount int64
+	traces    map[string]struct{}
+	durations []float64
+}
+
+// attributeStatistics samples the spans matching the TraceQL selection over the query time range and aggregates them
+// by the values of an attribute, listing the most frequent values with their span and trace counts and duration
+// percentiles.
+func (s *Service) attributeStatistics(ctx context.Context, dsInfo *datasourceInfo, model *dataquery.TempoQuery, query backend.DataQuery) backend.DataResponse {
+	queryRes := backend.DataResponse{}
+
+	attribute := ""
+	if model.Attribute != nil {
+		attribute = strings.TrimSpace(*model.Attribute)
+	}
+	if attribute == "" {
+		queryRes.Error = errors.New("attribute statistics query requires an attribute")
+		return queryRes
+	}
+
+	traceQL := strings.TrimSpace(model.Query)
+	if traceQL == "" {
+		traceQL = generateQueryFromFilters(queryFilters(model))
+	}
+	traceQL, notices := dsInfo.rewriteTraceQL(traceQL)
+	// Tempo only returns the attributes used in the selection, unless they are selected
+	if attribute != spanNameIntrinsic {
+		traceQL = fmt.Sprintf("%s | select(%s)", traceQL, attribute)
+	}
+
+	limit := int64(attributeStatisticsSearchLimit)
+	if model.Limit != nil && *model.Limit > 0 {
+		limit = *model.Limit
+	}
+	attributeLimit := defaultAttributeLimit
+	if model.AttributeLimit != nil && *model.AttributeLimit > 0 {
+		attributeLimit = int(*model.AttributeLimit)
+	}
+
+	searchResp, err := s.searchTraces(ctx, dsInfo, traceQL, limit, query.TimeRange.From.Unix(), query.TimeRange.To.Unix())
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	frame := attributeStatisticsToFrame(searchResp, attribute, attributeLimit)
+	frame.RefID = query.RefID
+	frame.SetMeta(&data.FrameMeta{ExecutedQueryString: traceQL, Notices: notices})
+	queryRes.Frames = data.Frames{frame}
+	return queryRes
+}
+
+func attributeStatisticsToFrame(resp *SearchResponse, attribute string, attributeLimit int) *data.Frame {
+	key := attributeKey(attribute)
+	rows := map[string]*attributeStatisticsRow{}
+	for _, trace := range resp.Traces {
+		for _, spanSet := range trace.AllSpanSets() {
+			for _, span := range spanSet.Spans {
+				value, ok := span.Name, span.Name != ""
+				if attribute != spanNameIntrinsic {
+					value, ok = span.Attribute(key)
+				}
+				if !ok {
+					continue
+				}
+
+				row, ok := rows[value]
+				if !ok {
+					row = &attributeStatisticsRow{value: value, traces: map[string]struct{}{}}
+					rows[value] = row
+				}
+				row.spanCount++
+				row.traces[trace.TraceID] = struct{}{}
+				if nanos, err := strconv.ParseInt(span.DurationNanos, 10, 64); err == nil {
+					row.durations = append(row.durations, float64(nanos)/1e6)
+				}
+			}
+		}
+	}
+
+	sorted := make([]*attributeStatisticsRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, row)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].spanCount != sorted[j].spanCount {
+			return sorted[i].spanCount > sorted[j].spanCount
+		}
+		return sorted[i].value < sorted[j].value
+	})
+	if len(sorted) > attributeLimit {
+		sorted = sorted[:attributeLimit]
+	}
+
+	durationConfig := &data.FieldConfig{Unit: "ms"}
+	frame := data.NewFrame("Attribute statistics",
+		data.NewField(attribute, nil, make([]string, 0, len(sorted))),
+		data.NewField("spanCount", nil, make([]int64, 0, len(sorted))),
+		data.NewField("traceCount", nil, make([]int64, 0, len(sorted))),
+		data.NewField("durationP50", nil, make([]*float64, 0, len(sorted))).SetConfig(durationConfig),
+		data.NewField("durationP90", nil, make([]*float64, 0, len(sorted))).SetConfig(durationConfig),
+		data.NewField("durationP99", nil, make([]*float64, 0, len(sorted))).SetConfig(durationConfig),
+	)
+	for _, row := range sorted {
+		sort.Float64s(row.durations)
+		frame.AppendRow(row.value, row.spanCount, int64(len(row.traces)),
+			percentile(row.durations, 50), percentile(row.durations, 90), percentile(row.durations, 99))
+	}
+
+	return frame
+}
+
+// attributeKey returns the key of the attribute in the search results, where the attributes have no scope
+func attributeKey(attribute string) string {
+	for _, prefix := range traceQLScopes {
+		if strings.HasPrefix(attribute, prefix) {
+			return strings.TrimPrefix(attribute, prefix)
+		}
+	}
+	return attribute
+}
+
+// percentile returns the nearest-rank percentile of the sorted values, nil without values
+func percentile(sorted []float64, p float64) *float64 {
+	if len(sorted) == 0 {
+		return nil
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return &sorted[rank-1]
+}
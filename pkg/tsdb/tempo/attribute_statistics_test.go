@@ -0,0 +1,105 @@
+package tempo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+const attributeSearchResponse = `{
+  "traces": [
+    {
+      "traceID": "t1",
+      "spanSet": {
+        "spans": [
+          {"spanID": "s1", "name": "GET", "durationNanos": "10000000", "attributes": [{"key": "http.route", "value": {"stringValue": "/api"}}]},
+          {"spanID": "s2", "name": "GET", "durationNanos": "30000000", "attributes": [{"key": "http.route", "value": {"stringValue": "/api"}}]},
+          {"spanID": "s3", "name": "POST", "durationNanos": "5000000", "attributes": [{"key": "http.route", "value": {"stringValue": "/login"}}]}
+        ]
+      }
+    },
+    {
+      "traceID": "t2",
+      "spanSets": [
+        {"spans": [{"spanID": "s4", "name": "GET", "durationNanos": "20000000", "attributes": [{"key": "http.route", "value": {"stringValue": "/api"}}]}]},
+        {"spans": [{"spanID": "s5", "name": "GET", "durationNanos": "1000000"}]}
+      ]
+    }
+  ]
+}`
+
+func TestAttributeStatistics(t *testing.T) {
+	var requested *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r
+		_, _ = w.Write([]byte(attributeSearchResponse))
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+	query := backend.DataQuery{
+		RefID:     "A",
+		QueryType: string(dataquery.TempoQueryTypeAttributeStatistics),
+		TimeRange: backend.TimeRange{From: time.Unix(1000, 0), To: time.Unix(2000, 0)},
+	}
+
+	t.Run("should aggregate the spans by the values of the attribute", func(t *testing.T) {
+		model := &dataquery.TempoQuery{Query: "{ kind = server }", Attribute: strPtr("span.http.route")}
+		res := service.attributeStatistics(context.Background(), dsInfo, model, query)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+
+		assert.Equal(t, "/api/search", requested.URL.Path)
+		assert.Equal(t, "{ kind = server } | select(span.http.route)", requested.URL.Query().Get("q"))
+		assert.Equal(t, "500", requested.URL.Query().Get("limit"))
+
+		frame := res.Frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		assert.Equal(t, "{ kind = server } | select(span.http.route)", frame.Meta.ExecutedQueryString)
+		assert.Equal(t, "span.http.route", frame.Fields[0].Name)
+		require.Equal(t, 2, frame.Rows())
+
+		assert.Equal(t, []interface{}{"/api", int64(3), int64(2), float64Ptr(20), float64Ptr(30), float64Ptr(30)}, frame.RowCopy(0))
+		assert.Equal(t, []interface{}{"/login", int64(1), int64(1), float64Ptr(5), float64Ptr(5), float64Ptr(5)}, frame.RowCopy(1))
+	})
+
+	t.Run("should use the span name without selecting it", func(t *testing.T) {
+		attributeLimit := int64(1)
+		model := &dataquery.TempoQuery{Attribute: strPtr("name"), AttributeLimit: &attributeLimit}
+		res := service.attributeStatistics(context.Background(), dsInfo, model, query)
+		require.NoError(t, res.Error)
+
+		assert.Equal(t, "{}", requested.URL.Query().Get("q"))
+		frame := res.Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		assert.Equal(t, []interface{}{"GET", int64(4), int64(2), float64Ptr(10), float64Ptr(30), float64Ptr(30)}, frame.RowCopy(0))
+	})
+
+	t.Run("should return an error response without an attribute", func(t *testing.T) {
+		res := service.attributeStatistics(context.Background(), dsInfo, &dataquery.TempoQuery{}, query)
+		require.EqualError(t, res.Error, "attribute statistics query requires an attribute")
+	})
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, 5.0, *percentile(values, 50))
+	assert.Equal(t, 9.0, *percentile(values, 90))
+	assert.Equal(t, 10.0, *percentile(values, 99))
+	assert.Equal(t, 1.0, *percentile(values, 0))
+	assert.Nil(t, percentile(nil, 50))
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,113 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Masterminds/semver"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+var _ backend.CheckHealthHandler = (*Service)(nil)
+
+// streamingMinVersion is the first Tempo version streaming the results of the searches over gRPC
+var streamingMinVersion = semver.MustParse("2.2.0")
+
+// Capabilities are the features of the Tempo version of the datasource, which the frontend uses instead of
+// guessing them from the responses. A capability is left out when it couldn't be detected.
+type Capabilities struct {
+	TagsV2         *bool `json:"tagsV2,omitempty"`
+	MetricsQueries *bool `json:"metricsQueries,omitempty"`
+	Streaming      *bool `json:"streaming,omitempty"`
+}
+
+type buildInfo struct {
+	Version string `json:"version"`
+}
+
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkHealth(ctx, dsInfo), nil
+}
+
+func (s *Service) checkHealth(ctx context.Context, dsInfo *datasourceInfo) *backend.CheckHealthResult {
+	status, _, err := s.get(ctx, dsInfo, "/api/echo")
+	if err != nil {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: err.Error()}
+	}
+	if status != http.StatusOK {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: fmt.Sprintf("Tempo echo endpoint returned status %d", status)}
+	}
+
+	details, err := json.Marshal(map[string]interface{}{"capabilities": s.detectCapabilities(ctx, dsInfo)})
+	if err != nil {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: err.Error()}
+	}
+	return &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "Data source is working", JSONDetails: details}
+}
+
+func (s *Service) detectCapabilities(ctx context.Context, dsInfo *datasourceInfo) Capabilities {
+	logger := s.tlog.FromContext(ctx)
+	var capabilities Capabilities
+
+	// the endpoints are missing from the older versions, the requests without parameters are otherwise rejected
+	// as invalid
+	if status, _, err := s.get(ctx, dsInfo, "/api/v2/search/tags"); err == nil {
+		capabilities.TagsV2 = boolPtr(status != http.StatusNotFound)
+	} else {
+		logger.Warn("Failed to detect the tags v2 API", "error", err)
+	}
+	if status, _, err := s.get(ctx, dsInfo, "/api/metrics/query_range"); err == nil {
+		capabilities.MetricsQueries = boolPtr(status != http.StatusNotFound)
+	} else {
+		logger.Warn("Failed to detect the metrics queries", "error", err)
+	}
+
+	if status, body, err := s.get(ctx, dsInfo, "/api/status/buildinfo"); err == nil && status == http.StatusOK {
+		var info buildInfo
+		if err := json.Unmarshal(body, &info); err == nil {
+			if version, err := semver.NewVersion(info.Version); err == nil {
+				capabilities.Streaming = boolPtr(!version.LessThan(streamingMinVersion))
+			} else {
+				logger.Debug("Unknown Tempo version", "version", info.Version)
+			}
+		}
+	} else if err != nil {
+		logger.Warn("Failed to get the Tempo version", "error", err)
+	}
+	return capabilities
+}
+
+func (s *Service) get(ctx context.Context, dsInfo *datasourceInfo, path string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dsInfo.URL+path, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := dsInfo.httpClient().Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to reach tempo: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.tlog.FromContext(ctx).Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
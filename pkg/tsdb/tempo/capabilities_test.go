@@ -0,0 +1,86 @@
+package tempo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestCheckHealth(t *testing.T) {
+	testCases := []struct {
+		name     string
+		routes   map[string]int
+		version  string
+		expected string
+	}{
+		{
+			name:     "publishes the capabilities of the recent versions",
+			routes:   map[string]int{"/api/v2/search/tags": http.StatusOK, "/api/metrics/query_range": http.StatusBadRequest},
+			version:  "2.4.1",
+			expected: `{"capabilities":{"tagsV2":true,"metricsQueries":true,"streaming":true}}`,
+		},
+		{
+			name:     "publishes the capabilities missing from the older versions",
+			routes:   map[string]int{},
+			version:  "1.5.0",
+			expected: `{"capabilities":{"tagsV2":false,"metricsQueries":false,"streaming":false}}`,
+		},
+		{
+			name:     "leaves out streaming when the version is unknown",
+			routes:   map[string]int{"/api/v2/search/tags": http.StatusOK},
+			version:  "main-5f2c1a9",
+			expected: `{"capabilities":{"tagsV2":true,"metricsQueries":false}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/echo":
+					_, _ = w.Write([]byte("echo"))
+				case "/api/status/buildinfo":
+					_, _ = w.Write([]byte(`{"version":"` + tc.version + `","revision":"abc"}`))
+				default:
+					status, ok := tc.routes[r.URL.Path]
+					if !ok {
+						status = http.StatusNotFound
+					}
+					w.WriteHeader(status)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			res := checkHealth(srv)
+			require.Equal(t, backend.HealthStatusOk, res.Status)
+			assert.Equal(t, "Data source is working", res.Message)
+			assert.JSONEq(t, tc.expected, string(res.JSONDetails))
+		})
+	}
+
+	t.Run("fails when the echo endpoint fails", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		t.Cleanup(srv.Close)
+
+		res := checkHealth(srv)
+		require.Equal(t, backend.HealthStatusError, res.Status)
+		assert.Equal(t, "Tempo echo endpoint returned status 502", res.Message)
+		assert.Empty(t, res.JSONDetails)
+	})
+}
+
+func checkHealth(srv *httptest.Server) *backend.CheckHealthResult {
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL}
+	return service.checkHealth(context.Background(), dsInfo)
+}
@@ -0,0 +1,78 @@
+package tempo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+// intrinsics are the TraceQL tags that are not attributes of a span or resource.
+var intrinsics = map[string]bool{
+	"name":     true,
+	"status":   true,
+	"duration": true,
+	"kind":     true,
+}
+
+// ConvertQuery implements plugins.QueryConverter, the dashboards migrate their Tempo queries with it when they are saved
+func (s *Service) ConvertQuery(query map[string]interface{}) []string {
+	return convertQuery(query)
+}
+
+// convertQuery migrates a stored Tempo query to the current query schema and returns the names of the converted fields:
+//   - queries without queryType are trace ID / TraceQL queries
+//   - filter tags stored with their scope prefix, such as .http.method or resource.service.name, get a scope
+func convertQuery(query map[string]interface{}) []string {
+	var converted []string
+
+	if queryType, ok := query["queryType"].(string); !ok || queryType == "" {
+		query["queryType"] = string(dataquery.TempoQueryTypeTraceql)
+		converted = append(converted, "queryType")
+	}
+
+	filters, _ := query["filters"].([]interface{})
+	for i, filterObj := range filters {
+		filter, ok := filterObj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if scope, ok := filter["scope"].(string); ok && scope != "" {
+			continue
+		}
+		tag, ok := filter["tag"].(string)
+		if !ok || tag == "" {
+			continue
+		}
+
+		scope, name := splitScopedTag(tag)
+		if scope == "" {
+			continue
+		}
+		filter["scope"] = string(scope)
+		filter["tag"] = name
+		converted = append(converted, fmt.Sprintf("filters[%d].scope", i))
+	}
+
+	return converted
+}
+
+func splitScopedTag(tag string) (dataquery.TraceqlFilterScope, string) {
+	if intrinsics[tag] {
+		return dataquery.TraceqlFilterScopeIntrinsic, tag
+	}
+	for _, scope := range []dataquery.TraceqlFilterScope{
+		dataquery.TraceqlFilterScopeResource,
+		dataquery.TraceqlFilterScopeSpan,
+		dataquery.TraceqlFilterScopeEvent,
+		dataquery.TraceqlFilterScopeLink,
+	} {
+		if strings.HasPrefix(tag, traceQLScopes[scope]) {
+			return scope, strings.TrimPrefix(tag, traceQLScopes[scope])
+		}
+	}
+	if strings.HasPrefix(tag, ".") {
+		return dataquery.TraceqlFilterScopeUnscoped, strings.TrimPrefix(tag, ".")
+	}
+	return "", tag
+}
@@ -0,0 +1,62 @@
+package tempo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+func TestConvertQuery(t *testing.T) {
+	t.Run("queries without query type are TraceQL queries", func(t *testing.T) {
+		query := map[string]interface{}{"query": "abcd"}
+		require.Equal(t, []string{"queryType"}, convertQuery(query))
+		require.Equal(t, "traceql", query["queryType"])
+	})
+
+	t.Run("filter tags with a scope prefix get a scope", func(t *testing.T) {
+		query := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"queryType": "traceqlSearch",
+			"filters": [
+				{"id": "a", "type": "static", "tag": ".http.method", "operator": "=", "value": "GET"},
+				{"id": "b", "type": "static", "tag": "resource.service.name", "operator": "=", "value": "db"},
+				{"id": "c", "type": "static", "tag": "duration", "operator": ">", "value": "1s"},
+				{"id": "d", "type": "static", "tag": "span.foo", "scope": "span", "operator": "=", "value": "bar"},
+				{"id": "e", "type": "dynamic", "tag": "foo", "operator": "=", "value": "bar"},
+				{"id": "f", "type": "dynamic"}
+			]
+		}`), &query))
+
+		require.Equal(t, []string{"filters[0].scope", "filters[1].scope", "filters[2].scope"}, convertQuery(query))
+
+		filters := query["filters"].([]interface{})
+		require.Equal(t, map[string]interface{}{"id": "a", "type": "static", "scope": "unscoped", "tag": "http.method", "operator": "=", "value": "GET"}, filters[0])
+		require.Equal(t, map[string]interface{}{"id": "b", "type": "static", "scope": "resource", "tag": "service.name", "operator": "=", "value": "db"}, filters[1])
+		require.Equal(t, map[string]interface{}{"id": "c", "type": "static", "scope": "intrinsic", "tag": "duration", "operator": ">", "value": "1s"}, filters[2])
+		require.Equal(t, "span.foo", filters[3].(map[string]interface{})["tag"])
+		require.NotContains(t, filters[4], "scope")
+	})
+
+	t.Run("converted filters generate the same query", func(t *testing.T) {
+		raw := `{"queryType": "traceqlSearch", "filters": [{"id": "a", "type": "static", "tag": ".http.method", "operator": "=", "value": "GET"}]}`
+		query := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(raw), &query))
+		convertQuery(query)
+
+		convertedJSON, err := json.Marshal(query)
+		require.NoError(t, err)
+
+		require.Equal(t, `{.http.method="GET"}`, traceQLFromJSON(t, []byte(raw)))
+		require.Equal(t, `{.http.method="GET"}`, traceQLFromJSON(t, convertedJSON))
+	})
+}
+
+func traceQLFromJSON(t *testing.T, raw []byte) string {
+	t.Helper()
+	model := &dataquery.TempoQuery{}
+	require.NoError(t, json.Unmarshal(raw, model))
+	return generateQueryFromFilters(queryFilters(model))
+}
@@ -0,0 +1,103 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// costProbeQuery is the query of the searches estimating the cost of a time range. Tempo returns the size of the
+// blocks of the range for any search, this query stops at the first trace of the blocks.
+const costProbeQuery = "{}"
+
+// CostEstimateRequest is the body of the estimate-cost resource, the time range in unix seconds
+type CostEstimateRequest struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// CostEstimate is the most a search of a time range reads from the blocks of Tempo. The queries only read the
+// columns of the blocks they filter on, so they read less. The traces not flushed to blocks yet are not included.
+type CostEstimate struct {
+	Bytes  int64 `json:"bytes"`
+	Blocks int64 `json:"blocks"`
+	// MaxBytes is the highest cost of the searches of the data source, 0 for no limit
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+	// OverBudget is set when the searches of the time range are rejected
+	OverBudget bool `json:"overBudget"`
+}
+
+// estimateCost sums the size of the blocks of the time range reported by the search metrics of Tempo. The time range
+// is split like the searches, so that Tempo doesn't reject the estimates of the long time ranges.
+func (s *Service) estimateCost(ctx context.Context, dsInfo *datasourceInfo, start int64, end int64) (*CostEstimate, error) {
+	if start <= 0 || end <= start {
+		return nil, fmt.Errorf("invalid time range: %d-%d", start, end)
+	}
+
+	partitions := []searchPartition{{start: start, end: end}}
+	concurrency := 1
+	if dsInfo.partitions != nil {
+		partitions = searchPartitions(start, end, s.partitionDuration(ctx, dsInfo))
+		concurrency = dsInfo.partitions.concurrency
+	}
+
+	metrics := make([]*SearchMetrics, len(partitions))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, p := range partitions {
+		i, p := i, p
+		g.Go(func() error {
+			resp, err := s.searchTracesRange(gctx, dsInfo, costProbeQuery, 1, p.start, p.end)
+			if err != nil {
+				return fmt.Errorf("failed to estimate the cost of the search: %w", err)
+			}
+			metrics[i] = resp.Metrics
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	estimate := &CostEstimate{MaxBytes: dsInfo.maxBytesToScan}
+	for _, m := range metrics {
+		if m == nil {
+			continue
+		}
+		estimate.Bytes += int64(m.TotalBlockBytes)
+		estimate.Blocks += int64(m.TotalBlocks)
+	}
+	estimate.OverBudget = estimate.MaxBytes > 0 && estimate.Bytes > estimate.MaxBytes
+	return estimate, nil
+}
+
+// checkCost rejects the searches of the time range when their estimated cost is higher than the maximum of the data
+// source. The searches are not estimated when the data source has no maximum.
+func (s *Service) checkCost(ctx context.Context, dsInfo *datasourceInfo, start int64, end int64) error {
+	if dsInfo.maxBytesToScan <= 0 || start <= 0 || end <= start {
+		return nil
+	}
+	estimate, err := s.estimateCost(ctx, dsInfo, start, end)
+	if err != nil {
+		return err
+	}
+	if estimate.OverBudget {
+		return fmt.Errorf("the search would read up to %s from %d blocks, more than the maximum of %s of the data source: reduce the time range", formatBytes(estimate.Bytes), estimate.Blocks, formatBytes(estimate.MaxBytes))
+	}
+	return nil
+}
+
+// formatBytes returns a size in bytes with the largest binary unit it has at least one of
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit && exp < 5; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
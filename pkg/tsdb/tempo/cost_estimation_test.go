@@ -0,0 +1,88 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestEstimateCost(t *testing.T) {
+	// the blocks of each partition of the time range, the 64 bits counters are encoded as strings by Tempo
+	metrics := map[string]string{
+		"5400-9000": `{"inspectedBytes": "1024", "totalBlocks": 3, "totalBlockBytes": "3000000"}`,
+		"1800-5400": `{"inspectedBytes": "1024", "totalBlocks": 2, "totalBlockBytes": "2000000"}`,
+		"1000-1800": `{"totalBlocks": 1, "totalBlockBytes": 1000000}`,
+		"1000-9000": `{"totalBlocks": 6, "totalBlockBytes": "6000000"}`,
+	}
+
+	var mu sync.Mutex
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := fmt.Sprintf("%s-%s", r.URL.Query().Get("start"), r.URL.Query().Get("end"))
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("q"))
+		mu.Unlock()
+		if r.URL.Query().Get("q") == "{}" {
+			assert.Equal(t, "1", r.URL.Query().Get("limit"))
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"traces": [], "metrics": %s}`, metrics[key])))
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	newDSInfo := func(maxBytes int64, partitions *searchPartitioner) *datasourceInfo {
+		return &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL, maxBytesToScan: maxBytes, partitions: partitions}
+	}
+
+	t.Run("the size of the blocks of the partitions is summed", func(t *testing.T) {
+		estimate, err := service.estimateCost(context.Background(), newDSInfo(0, &searchPartitioner{duration: time.Hour, concurrency: 2}), 1000, 9000)
+		require.NoError(t, err)
+		assert.Equal(t, &CostEstimate{Bytes: 6000000, Blocks: 6}, estimate)
+	})
+
+	t.Run("the estimates over the maximum of the data source are over budget", func(t *testing.T) {
+		estimate, err := service.estimateCost(context.Background(), newDSInfo(5000000, nil), 1000, 9000)
+		require.NoError(t, err)
+		assert.Equal(t, &CostEstimate{Bytes: 6000000, Blocks: 6, MaxBytes: 5000000, OverBudget: true}, estimate)
+	})
+
+	t.Run("the searches over budget are rejected before they run", func(t *testing.T) {
+		mu.Lock()
+		queries = nil
+		mu.Unlock()
+
+		_, err := service.searchTraces(context.Background(), newDSInfo(5000000, nil), `{ status = error }`, 20, 1000, 9000)
+		require.EqualError(t, err, "the search would read up to 5.7 MiB from 6 blocks, more than the maximum of 4.8 MiB of the data source: reduce the time range")
+		assert.Equal(t, []string{"{}"}, queries)
+
+		_, err = service.searchTraces(context.Background(), newDSInfo(10000000, nil), `{ status = error }`, 20, 1000, 9000)
+		require.NoError(t, err)
+	})
+
+	t.Run("the estimate is returned by the estimate-cost resource", func(t *testing.T) {
+		sender := &fakeCallResourceResponseSender{}
+		err := service.callResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodPost, Path: "estimate-cost", Body: []byte(`{"start": 1000, "end": 9000}`)}, sender, newDSInfo(8000000, nil))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, sender.res.Status)
+		assert.JSONEq(t, `{"bytes": 6000000, "blocks": 6, "maxBytes": 8000000, "overBudget": false}`, string(sender.res.Body))
+
+		err = service.callResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodPost, Path: "estimate-cost", Body: []byte(`{"start": 9000, "end": 1000}`)}, sender, newDSInfo(0, nil))
+		require.EqualError(t, err, "invalid time range: 9000-1000")
+	})
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 GiB", formatBytes(2*1024*1024*1024))
+}
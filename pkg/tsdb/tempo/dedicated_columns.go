@@ -0,0 +1,203 @@
+package tempo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// dedicatedColumn is an attribute stored in its own column of the vParquet blocks of Tempo, as configured with the
+// parquet_dedicated_columns option of Tempo. Only the span and resource attributes can have dedicated columns.
+type dedicatedColumn struct {
+	Scope string `json:"scope"`
+	Name  string `json:"name"`
+}
+
+// wellKnownColumns are the attributes the vParquet blocks always store in their own columns
+var wellKnownColumns = []dedicatedColumn{
+	{Scope: "resource", Name: "service.name"},
+	{Scope: "resource", Name: "cluster"},
+	{Scope: "resource", Name: "namespace"},
+	{Scope: "resource", Name: "pod"},
+	{Scope: "resource", Name: "container"},
+	{Scope: "resource", Name: "k8s.cluster.name"},
+	{Scope: "resource", Name: "k8s.namespace.name"},
+	{Scope: "resource", Name: "k8s.pod.name"},
+	{Scope: "resource", Name: "k8s.container.name"},
+	{Scope: "span", Name: "http.method"},
+	{Scope: "span", Name: "http.url"},
+	{Scope: "span", Name: "http.status_code"},
+}
+
+// attributeScopes are the scopes written in front of the attributes in TraceQL, the attributes of the other scopes
+// can't have a dedicated column
+var attributeScopes = map[string]bool{
+	"span":     true,
+	"resource": true,
+	"event":    true,
+	"link":     true,
+}
+
+// dedicatedColumns rewrites the TraceQL queries so that their filters use the dedicated columns of Tempo, and lists
+// the filters on attributes without a dedicated column, which Tempo searches in the generic attribute columns.
+type dedicatedColumns struct {
+	// columns has the scopes of the attributes with a dedicated column
+	columns map[string]map[string]bool
+}
+
+// newDedicatedColumns returns nil when the data source lists no dedicated column, so that the queries are not
+// rewritten and the filters are not reported
+func newDedicatedColumns(columns []dedicatedColumn) (*dedicatedColumns, error) {
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	c := &dedicatedColumns{columns: map[string]map[string]bool{}}
+	all := append(append([]dedicatedColumn{}, wellKnownColumns...), columns...)
+	for _, column := range all {
+		if column.Scope != "span" && column.Scope != "resource" {
+			return nil, fmt.Errorf("invalid scope %q of the dedicated column %q, expected span or resource", column.Scope, column.Name)
+		}
+		if strings.TrimSpace(column.Name) == "" {
+			return nil, fmt.Errorf("a dedicated column of the %s scope has no name", column.Scope)
+		}
+		if c.columns[column.Name] == nil {
+			c.columns[column.Name] = map[string]bool{}
+		}
+		c.columns[column.Name][column.Scope] = true
+	}
+	return c, nil
+}
+
+// rewrite scopes the unscoped attributes of the span selectors of the query which have a dedicated column in a
+// single scope, for example .http.route becomes span.http.route. Tempo searches an unscoped attribute in the columns
+// of both scopes, a scoped one only reads its dedicated column. It returns the attributes of the span selectors
+// without a dedicated column, in the order of the query.
+func (c *dedicatedColumns) rewrite(query string) (string, []string) {
+	var b strings.Builder
+	var missing []string
+	seen := map[string]bool{}
+	depth := 0
+
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch {
+		case ch == '"' || ch == '`':
+			end := quotedEnd(query, i)
+			b.WriteString(query[i:end])
+			i = end
+			continue
+		case ch == '{':
+			depth++
+		case ch == '}':
+			if depth > 0 {
+				depth--
+			}
+		case ch == '.' && (i == 0 || !isWordChar(query[i-1])):
+			end, name := attributeNameEnd(query, i+1)
+			token := query[i:end]
+			if depth > 0 && name != "" {
+				scopes := c.columns[name]
+				switch {
+				case len(scopes) == 1:
+					for scope := range scopes {
+						token = scope + token
+					}
+				case len(scopes) == 0 && !seen[token]:
+					seen[token] = true
+					missing = append(missing, token)
+				}
+			}
+			b.WriteString(token)
+			i = end
+			continue
+		case isWordChar(ch) && (i == 0 || !isWordChar(query[i-1])):
+			wordEnd := i
+			for wordEnd < len(query) && isLetter(query[wordEnd]) {
+				wordEnd++
+			}
+			scope := query[i:wordEnd]
+			if !attributeScopes[scope] || wordEnd >= len(query) || query[wordEnd] != '.' {
+				// keywords, intrinsics such as span:name, numbers and durations
+				for wordEnd < len(query) && isWordChar(query[wordEnd]) {
+					wordEnd++
+				}
+				b.WriteString(query[i:wordEnd])
+				i = wordEnd
+				continue
+			}
+			end, name := attributeNameEnd(query, wordEnd+1)
+			token := query[i:end]
+			if depth > 0 && name != "" && !c.columns[name][scope] && !seen[token] {
+				seen[token] = true
+				missing = append(missing, token)
+			}
+			b.WriteString(token)
+			i = end
+			continue
+		}
+		b.WriteByte(ch)
+		i++
+	}
+	return b.String(), missing
+}
+
+// notice returns the warning about the attributes without a dedicated column
+func (c *dedicatedColumns) notice(attributes []string) data.Notice {
+	return data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text: fmt.Sprintf("The filters on %s don't use a dedicated attribute column of Tempo, they are searched in the generic attribute columns and can be slow. "+
+			"Filter on the attributes with a dedicated column where possible, or add dedicated columns in Tempo and the data source.", strings.Join(attributes, ", ")),
+	}
+}
+
+// rewriteTraceQL rewrites the query for the dedicated columns of the data source, with a warning notice when some
+// filters don't use one. The query is not changed when the data source lists no dedicated column.
+func (d *datasourceInfo) rewriteTraceQL(query string) (string, []data.Notice) {
+	if d.dedicatedColumns == nil {
+		return query, nil
+	}
+	query, missing := d.dedicatedColumns.rewrite(query)
+	if len(missing) == 0 {
+		return query, nil
+	}
+	return query, []data.Notice{d.dedicatedColumns.notice(missing)}
+}
+
+// attributeNameEnd returns the end of the attribute name starting at i and the name, without its quotes when it is
+// quoted, such as span."http route"
+func attributeNameEnd(query string, i int) (int, string) {
+	if i < len(query) && query[i] == '"' {
+		end := quotedEnd(query, i)
+		return end, strings.ReplaceAll(strings.Trim(query[i:end], `"`), `\"`, `"`)
+	}
+	end := i
+	for end < len(query) && !strings.ContainsRune("{}()=~!<>&|^, \t\r\n", rune(query[end])) {
+		end++
+	}
+	return end, query[i:end]
+}
+
+// quotedEnd returns the index after the string starting with the quote at i, or the end of the query when the string
+// isn't closed
+func quotedEnd(query string, i int) int {
+	quote := query[i]
+	for j := i + 1; j < len(query); j++ {
+		if quote == '"' && query[j] == '\\' {
+			j++
+			continue
+		}
+		if query[j] == quote {
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+func isLetter(ch byte) bool {
+	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
+}
+
+func isWordChar(ch byte) bool {
+	return isLetter(ch) || ch == '_' || ch == ':' || ch == '.' || ('0' <= ch && ch <= '9')
+}
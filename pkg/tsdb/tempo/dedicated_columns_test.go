@@ -0,0 +1,94 @@
+package tempo
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDedicatedColumns(t *testing.T) {
+	t.Run("no columns", func(t *testing.T) {
+		columns, err := newDedicatedColumns(nil)
+		require.NoError(t, err)
+		assert.Nil(t, columns)
+	})
+
+	t.Run("invalid scope", func(t *testing.T) {
+		_, err := newDedicatedColumns([]dedicatedColumn{{Scope: "event", Name: "exception.type"}})
+		require.EqualError(t, err, `invalid scope "event" of the dedicated column "exception.type", expected span or resource`)
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		_, err := newDedicatedColumns([]dedicatedColumn{{Scope: "span", Name: " "}})
+		require.EqualError(t, err, "a dedicated column of the span scope has no name")
+	})
+}
+
+func TestDedicatedColumnsRewrite(t *testing.T) {
+	columns, err := newDedicatedColumns([]dedicatedColumn{
+		{Scope: "span", Name: "http.route"},
+		{Scope: "resource", Name: "deployment.environment"},
+		{Scope: "span", Name: "region"},
+		{Scope: "resource", Name: "region"},
+		{Scope: "span", Name: "db statement"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		missing  []string
+	}{
+		{name: "empty selector", query: "{}", expected: "{}"},
+		{name: "unscoped dedicated attribute", query: `{.http.route="/users"}`, expected: `{span.http.route="/users"}`},
+		{name: "unscoped resource attribute", query: `{ .deployment.environment = "prod" && .http.route =~ "/api.*" }`, expected: `{ resource.deployment.environment = "prod" && span.http.route =~ "/api.*" }`},
+		{name: "well-known attribute", query: `{.service.name="db"}`, expected: `{resource.service.name="db"}`},
+		{name: "attribute dedicated in both scopes", query: `{.region="eu"}`, expected: `{.region="eu"}`},
+		{name: "scoped dedicated attribute", query: `{span.http.route="/users" && resource.region="eu"}`, expected: `{span.http.route="/users" && resource.region="eu"}`},
+		{name: "quoted attribute", query: `{."db statement"="SELECT"}`, expected: `{span."db statement"="SELECT"}`},
+		{
+			name:     "attributes without a dedicated column",
+			query:    `{.user.id="1" && span.http.route="/" && resource.http.route="/" && event.exception.type="IO" && .user.id="2"}`,
+			expected: `{.user.id="1" && span.http.route="/" && resource.http.route="/" && event.exception.type="IO" && .user.id="2"}`,
+			missing:  []string{".user.id", "resource.http.route", "event.exception.type"},
+		},
+		{name: "intrinsics", query: `{span:name="GET" && status=error && duration>1.5s && kind=server}`, expected: `{span:name="GET" && status=error && duration>1.5s && kind=server}`},
+		{name: "strings are not rewritten", query: `{name=".http.route span.foo" && .http.route="a\".b"}`, expected: `{name=".http.route span.foo" && span.http.route="a\".b"}`},
+		{name: "pipelines are not rewritten", query: `{.http.route="/"} | select(.http.route, span.user.id) | by(.foo)`, expected: `{span.http.route="/"} | select(.http.route, span.user.id) | by(.foo)`},
+		{name: "several span selectors", query: `{.http.route="/"} >> {.foo=1}`, expected: `{span.http.route="/"} >> {.foo=1}`, missing: []string{".foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, missing := columns.rewrite(tt.query)
+			assert.Equal(t, tt.expected, query)
+			assert.Equal(t, tt.missing, missing)
+		})
+	}
+}
+
+func TestRewriteTraceQL(t *testing.T) {
+	t.Run("the queries are not changed without dedicated columns", func(t *testing.T) {
+		query, notices := (&datasourceInfo{}).rewriteTraceQL(`{.http.route="/" && .user.id="1"}`)
+		assert.Equal(t, `{.http.route="/" && .user.id="1"}`, query)
+		assert.Empty(t, notices)
+	})
+
+	t.Run("warns about the filters without a dedicated column", func(t *testing.T) {
+		columns, err := newDedicatedColumns([]dedicatedColumn{{Scope: "span", Name: "http.route"}})
+		require.NoError(t, err)
+		dsInfo := &datasourceInfo{dedicatedColumns: columns}
+
+		query, notices := dsInfo.rewriteTraceQL(`{.http.route="/"}`)
+		assert.Equal(t, `{span.http.route="/"}`, query)
+		assert.Empty(t, notices)
+
+		query, notices = dsInfo.rewriteTraceQL(`{.http.route="/" && .user.id="1" && span.tenant="a"}`)
+		assert.Equal(t, `{span.http.route="/" && .user.id="1" && span.tenant="a"}`, query)
+		require.Len(t, notices, 1)
+		assert.Equal(t, data.NoticeSeverityWarning, notices[0].Severity)
+		assert.Contains(t, notices[0].Text, "The filters on .user.id, span.tenant don't use a dedicated attribute column of Tempo")
+	})
+}
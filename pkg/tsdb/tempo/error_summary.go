@@ -0,0 +1,132 @@
+package tempo
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+const (
+	// errorSummarySearchLimit is the number of traces sampled from Tempo when the query has no limit set.
+	errorSummarySearchLimit = 100
+	// maxRepresentativeTraceIDs caps the number of example trace IDs reported for each operation.
+	maxRepresentativeTraceIDs = 5
+)
+
+// errorStatusFilter is added to the filters of the query when it does not provide its own TraceQL selection.
+var errorStatusFilter = dataquery.TraceqlFilter{
+	Id:        "status",
+	Type:      dataquery.TraceqlFilterTypeStatic,
+	Scope:     scopePtr(dataquery.TraceqlFilterScopeIntrinsic),
+	Tag:       strPtr("status"),
+	Operator:  strPtr("="),
+	Value:     valuePtr("error"),
+	ValueType: strPtr("keyword"),
+}
+
+type errorSummaryKey struct {
+	serviceName   string
+	operationName string
+}
+
+type errorSummaryRow struct {
+	errorSummaryKey
+	errorCount int64
+	traces     map[string]struct{}
+	// traceIDs holds up to maxRepresentativeTraceIDs traces, in the order they were returned by Tempo.
+	traceIDs []string
+}
+
+// errorSummary samples error spans over the query time range and aggregates them by service and operation, so that
+// the operations failing the most are listed first together with a few traces that can be used as a starting point.
+func (s *Service) errorSummary(ctx context.Context, dsInfo *datasourceInfo, model *dataquery.TempoQuery, query backend.DataQuery) backend.DataResponse {
+	queryRes := backend.DataResponse{}
+
+	traceQL := strings.TrimSpace(model.Query)
+	if traceQL == "" {
+		traceQL = generateQueryFromFilters(append(queryFilters(model), errorStatusFilter))
+	}
+	traceQL, notices := dsInfo.rewriteTraceQL(traceQL)
+
+	limit := int64(errorSummarySearchLimit)
+	if model.Limit != nil && *model.Limit > 0 {
+		limit = *model.Limit
+	}
+
+	searchResp, err := s.searchTraces(ctx, dsInfo, traceQL, limit, query.TimeRange.From.Unix(), query.TimeRange.To.Unix())
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	frame := errorSummaryToFrame(searchResp)
+	frame.RefID = query.RefID
+	frame.SetMeta(&data.FrameMeta{ExecutedQueryString: traceQL, Notices: notices})
+	queryRes.Frames = data.Frames{frame}
+	return queryRes
+}
+
+func errorSummaryToFrame(resp *SearchResponse) *data.Frame {
+	rows := map[errorSummaryKey]*errorSummaryRow{}
+	for _, trace := range resp.Traces {
+		for _, spanSet := range trace.AllSpanSets() {
+			for _, span := range spanSet.Spans {
+				key := errorSummaryKey{
+					serviceName:   trace.RootServiceName,
+					operationName: span.Name,
+				}
+				if serviceName, ok := span.Attribute("service.name"); ok {
+					key.serviceName = serviceName
+				}
+				if key.operationName == "" {
+					key.operationName = trace.RootTraceName
+				}
+
+				row, ok := rows[key]
+				if !ok {
+					row = &errorSummaryRow{errorSummaryKey: key, traces: map[string]struct{}{}}
+					rows[key] = row
+				}
+				row.errorCount++
+				if _, seen := row.traces[trace.TraceID]; !seen {
+					row.traces[trace.TraceID] = struct{}{}
+					if len(row.traceIDs) < maxRepresentativeTraceIDs {
+						row.traceIDs = append(row.traceIDs, trace.TraceID)
+					}
+				}
+			}
+		}
+	}
+
+	sorted := make([]*errorSummaryRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, row)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].errorCount != sorted[j].errorCount {
+			return sorted[i].errorCount > sorted[j].errorCount
+		}
+		if sorted[i].serviceName != sorted[j].serviceName {
+			return sorted[i].serviceName < sorted[j].serviceName
+		}
+		return sorted[i].operationName < sorted[j].operationName
+	})
+
+	frame := data.NewFrame("Error summary",
+		data.NewField("serviceName", nil, make([]string, 0, len(sorted))),
+		data.NewField("operationName", nil, make([]string, 0, len(sorted))),
+		data.NewField("errorCount", nil, make([]int64, 0, len(sorted))),
+		data.NewField("traceCount", nil, make([]int64, 0, len(sorted))),
+		data.NewField("traceIDs", nil, make([]string, 0, len(sorted))),
+	)
+	for _, row := range sorted {
+		frame.AppendRow(row.serviceName, row.operationName, row.errorCount, int64(len(row.traces)), strings.Join(row.traceIDs, ","))
+	}
+
+	return frame
+}
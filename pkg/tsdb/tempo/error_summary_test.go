@@ -0,0 +1,127 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+const errorSearchResponse = `{
+  "traces": [
+    {
+      "traceID": "t1",
+      "rootServiceName": "frontend",
+      "rootTraceName": "GET /",
+      "spanSet": {
+        "spans": [
+          {"spanID": "s1", "name": "SELECT", "attributes": [{"key": "service.name", "value": {"stringValue": "db"}}]},
+          {"spanID": "s2", "name": "SELECT", "attributes": [{"key": "service.name", "value": {"stringValue": "db"}}]}
+        ],
+        "matched": 2
+      }
+    },
+    {
+      "traceID": "t2",
+      "rootServiceName": "frontend",
+      "rootTraceName": "GET /",
+      "spanSets": [
+        {"spans": [{"spanID": "s3", "name": "SELECT", "attributes": [{"key": "service.name", "value": {"stringValue": "db"}}]}], "matched": 1},
+        {"spans": [{"spanID": "s4"}], "matched": 1}
+      ]
+    }
+  ]
+}`
+
+func TestErrorSummary(t *testing.T) {
+	var requested *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r
+		_, _ = w.Write([]byte(errorSearchResponse))
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+	query := backend.DataQuery{
+		RefID:     "A",
+		QueryType: string(dataquery.TempoQueryTypeErrorSummary),
+		TimeRange: backend.TimeRange{From: time.Unix(1000, 0), To: time.Unix(2000, 0)},
+	}
+
+	t.Run("should aggregate error spans by service and operation", func(t *testing.T) {
+		res := service.errorSummary(context.Background(), dsInfo, &dataquery.TempoQuery{}, query)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+
+		assert.Equal(t, "/api/search", requested.URL.Path)
+		assert.Equal(t, "{status=error}", requested.URL.Query().Get("q"))
+		assert.Equal(t, "100", requested.URL.Query().Get("limit"))
+		assert.Equal(t, "1000", requested.URL.Query().Get("start"))
+		assert.Equal(t, "2000", requested.URL.Query().Get("end"))
+
+		frame := res.Frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		require.Equal(t, 2, frame.Rows())
+
+		assert.Equal(t, []interface{}{"db", "SELECT", int64(3), int64(2), "t1,t2"}, frame.RowCopy(0))
+		assert.Equal(t, []interface{}{"frontend", "GET /", int64(1), int64(1), "t2"}, frame.RowCopy(1))
+	})
+
+	t.Run("should use the query and limit from the model", func(t *testing.T) {
+		limit := int64(10)
+		model := &dataquery.TempoQuery{Query: "{ status = error && resource.service.name = \"db\" }", Limit: &limit}
+		res := service.errorSummary(context.Background(), dsInfo, model, query)
+		require.NoError(t, res.Error)
+
+		assert.Equal(t, model.Query, requested.URL.Query().Get("q"))
+		assert.Equal(t, "10", requested.URL.Query().Get("limit"))
+		assert.Equal(t, model.Query, res.Frames[0].Meta.ExecutedQueryString)
+	})
+
+	t.Run("should combine the filters of the query with the error status", func(t *testing.T) {
+		model := &dataquery.TempoQuery{}
+		require.NoError(t, json.Unmarshal([]byte(`{"filters":[{"id":"service","type":"static","scope":"resource","tag":"service.name","operator":"=","value":"db"}]}`), model))
+		res := service.errorSummary(context.Background(), dsInfo, model, query)
+		require.NoError(t, res.Error)
+
+		assert.Equal(t, `{resource.service.name="db" && status=error}`, requested.URL.Query().Get("q"))
+	})
+
+	t.Run("should rewrite the query for the dedicated columns", func(t *testing.T) {
+		columns, err := newDedicatedColumns([]dedicatedColumn{{Scope: "span", Name: "db.system"}})
+		require.NoError(t, err)
+		withColumns := &datasourceInfo{HTTPClient: dsInfo.HTTPClient, URL: dsInfo.URL, dedicatedColumns: columns}
+
+		model := &dataquery.TempoQuery{Query: `{ status = error && .db.system = "postgresql" && .db.name = "users" }`}
+		res := service.errorSummary(context.Background(), withColumns, model, query)
+		require.NoError(t, res.Error)
+
+		expected := `{ status = error && span.db.system = "postgresql" && .db.name = "users" }`
+		assert.Equal(t, expected, requested.URL.Query().Get("q"))
+		assert.Equal(t, expected, res.Frames[0].Meta.ExecutedQueryString)
+		require.Len(t, res.Frames[0].Meta.Notices, 1)
+		assert.Contains(t, res.Frames[0].Meta.Notices[0].Text, "The filters on .db.name")
+	})
+
+	t.Run("should return an error response when tempo fails", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("invalid TraceQL query"))
+		}))
+		t.Cleanup(failing.Close)
+
+		res := service.errorSummary(context.Background(), &datasourceInfo{HTTPClient: failing.Client(), URL: failing.URL}, &dataquery.TempoQuery{}, query)
+		require.Error(t, res.Error)
+		assert.Contains(t, res.Error.Error(), "invalid TraceQL query")
+	})
+}
@@ -0,0 +1,112 @@
+package tempo
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// durationDecimals is the number of decimals of the durations in milliseconds, the spans often last under 1 ms
+const durationDecimals = 2
+
+// spanStatusMappings colors the status of the spans, as returned by the status intrinsic and in the otel.status_code
+// attribute
+var spanStatusMappings = data.ValueMappings{
+	data.ValueMapper{
+		"error": {Color: "red", Index: 0},
+		"ERROR": {Color: "red", Index: 1},
+		"ok":    {Color: "green", Index: 2},
+		"OK":    {Color: "green", Index: 3},
+		"unset": {Color: "text", Index: 4},
+	},
+}
+
+// httpStatusCodeMappings colors the HTTP status codes by class
+var httpStatusCodeMappings = data.ValueMappings{
+	data.RangeValueMapper{From: confFloat64(100), To: confFloat64(399), Result: data.ValueMappingResult{Color: "green", Index: 0}},
+	data.RangeValueMapper{From: confFloat64(400), To: confFloat64(499), Result: data.ValueMappingResult{Color: "orange", Index: 1}},
+	data.RangeValueMapper{From: confFloat64(500), To: confFloat64(599), Result: data.ValueMappingResult{Color: "red", Index: 2}},
+}
+
+func confFloat64(v float64) *data.ConfFloat64 {
+	f := data.ConfFloat64(v)
+	return &f
+}
+
+// inferFieldConfig sets the display of the fields of the frames from their names, so the tables of the spans render
+// without overrides: the units and decimals of the durations and counts, and colored mappings for the status of the
+// spans and the HTTP status codes. The config set by the queries is kept, only the missing settings are inferred.
+func inferFieldConfig(frames data.Frames) {
+	for _, frame := range frames {
+		if frame == nil {
+			continue
+		}
+		for _, field := range frame.Fields {
+			inferred := inferredFieldConfig(field)
+			if inferred == nil {
+				continue
+			}
+			if field.Config == nil {
+				field.Config = &data.FieldConfig{}
+			}
+			mergeFieldConfig(field.Config, inferred)
+		}
+	}
+}
+
+func inferredFieldConfig(field *data.Field) *data.FieldConfig {
+	name := attributeKey(field.Name)
+	lower := strings.ToLower(name)
+	numeric := field.Type().Numeric()
+
+	switch {
+	case lower == "status" || lower == "statuscode" || lower == "status.code" || lower == "otel.status_code":
+		if numeric {
+			return nil
+		}
+		return colorTextConfig(spanStatusMappings)
+	case lower == "http.status_code" || lower == "http.response.status_code" || lower == "httpstatuscode":
+		return colorTextConfig(httpStatusCodeMappings)
+	}
+
+	if !numeric {
+		return nil
+	}
+	switch {
+	case strings.HasSuffix(lower, "nanos") || strings.HasSuffix(lower, "_ns"):
+		return &data.FieldConfig{Unit: "ns"}
+	case strings.HasPrefix(lower, "duration") || strings.HasSuffix(lower, "duration") ||
+		strings.HasSuffix(name, "Ms") || strings.HasSuffix(lower, "_ms") || lower == "selftime":
+		return (&data.FieldConfig{Unit: "ms"}).SetDecimals(durationDecimals)
+	case strings.HasSuffix(name, "Count"):
+		return (&data.FieldConfig{}).SetDecimals(0)
+	case lower == "percent":
+		return (&data.FieldConfig{Unit: "percent"}).SetDecimals(1)
+	}
+	return nil
+}
+
+// colorTextConfig returns the config of the fields colored by the mappings in the tables
+func colorTextConfig(mappings data.ValueMappings) *data.FieldConfig {
+	return &data.FieldConfig{
+		Mappings: mappings,
+		Custom: map[string]interface{}{
+			"cellOptions": map[string]interface{}{"type": "color-text"},
+		},
+	}
+}
+
+func mergeFieldConfig(config *data.FieldConfig, inferred *data.FieldConfig) {
+	if config.Unit == "" {
+		config.Unit = inferred.Unit
+	}
+	if config.Decimals == nil {
+		config.Decimals = inferred.Decimals
+	}
+	if config.Mappings == nil {
+		config.Mappings = inferred.Mappings
+	}
+	if config.Custom == nil {
+		config.Custom = inferred.Custom
+	}
+}
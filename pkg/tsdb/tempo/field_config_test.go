@@ -0,0 +1,49 @@
+package tempo
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferFieldConfig(t *testing.T) {
+	frame := data.NewFrame("Attribute statistics",
+		data.NewField("span.http.status_code", nil, []string{"500"}),
+		data.NewField("status", nil, []string{"error"}),
+		data.NewField("statusCode", nil, []int64{2}),
+		data.NewField("spanCount", nil, []int64{3}),
+		data.NewField("duration", nil, []float64{1.5}),
+		data.NewField("durationNanos", nil, []int64{1500000}),
+		data.NewField("durationP50", nil, []*float64{nil}).SetConfig(&data.FieldConfig{Unit: "s"}),
+		data.NewField("items", nil, []int64{1}),
+		data.NewField("serviceName", nil, []string{"api"}),
+	)
+	inferFieldConfig(data.Frames{frame, nil})
+
+	t.Run("the status codes are colored", func(t *testing.T) {
+		config := frame.Fields[0].Config
+		require.NotNil(t, config)
+		assert.Equal(t, httpStatusCodeMappings, config.Mappings)
+		assert.Equal(t, map[string]interface{}{"type": "color-text"}, config.Custom["cellOptions"])
+		assert.Equal(t, spanStatusMappings, frame.Fields[1].Config.Mappings)
+		// the numeric codes of the OTLP status are not the status names
+		assert.Nil(t, frame.Fields[2].Config)
+	})
+
+	t.Run("the units and decimals are inferred", func(t *testing.T) {
+		assert.Equal(t, uint16(0), *frame.Fields[3].Config.Decimals)
+		assert.Equal(t, "ms", frame.Fields[4].Config.Unit)
+		assert.Equal(t, uint16(durationDecimals), *frame.Fields[4].Config.Decimals)
+		assert.Equal(t, "ns", frame.Fields[5].Config.Unit)
+		assert.Nil(t, frame.Fields[7].Config)
+		assert.Nil(t, frame.Fields[8].Config)
+	})
+
+	t.Run("the config of the queries is kept", func(t *testing.T) {
+		config := frame.Fields[6].Config
+		assert.Equal(t, "s", config.Unit)
+		assert.Equal(t, uint16(durationDecimals), *config.Decimals)
+	})
+}
@@ -0,0 +1,313 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+// tagTypeCacheTTL is how long the type of a tag is reused before it is fetched again from Tempo
+const tagTypeCacheTTL = 5 * time.Minute
+
+// tagType is the type of the values of a tag, as returned by Tempo's /api/v2/search/tag/<tag>/values endpoint
+type tagType string
+
+const (
+	tagTypeString   tagType = "string"
+	tagTypeInt      tagType = "int"
+	tagTypeFloat    tagType = "float"
+	tagTypeBool     tagType = "bool"
+	tagTypeDuration tagType = "duration"
+	tagTypeStatus   tagType = "status"
+	tagTypeKind     tagType = "kind"
+)
+
+// intrinsicTypes are the types of the intrinsics, which are known without asking Tempo
+var intrinsicTypes = map[string]tagType{
+	"duration":        tagTypeDuration,
+	"traceDuration":   tagTypeDuration,
+	"name":            tagTypeString,
+	"rootName":        tagTypeString,
+	"rootServiceName": tagTypeString,
+	"statusMessage":   tagTypeString,
+	"status":          tagTypeStatus,
+	"kind":            tagTypeKind,
+}
+
+// tagTypeOperators are the operators Tempo accepts for the tags of each type
+var tagTypeOperators = map[tagType][]string{
+	tagTypeString:   {"=", "!=", "=~", "!~"},
+	tagTypeInt:      {"=", "!=", ">", ">=", "<", "<="},
+	tagTypeFloat:    {"=", "!=", ">", ">=", "<", "<="},
+	tagTypeDuration: {"=", "!=", ">", ">=", "<", "<="},
+	tagTypeBool:     {"=", "!="},
+	tagTypeStatus:   {"="},
+	tagTypeKind:     {"="},
+}
+
+// enumValues are the values accepted by the tags which can only be compared to a keyword
+var enumValues = map[tagType][]string{
+	tagTypeStatus: {"error", "ok", "unset"},
+	tagTypeKind:   {"unspecified", "internal", "server", "client", "producer", "consumer"},
+}
+
+// FilterValidationRequest is the body of the validate-filters resource
+type FilterValidationRequest struct {
+	Filters []dataquery.TraceqlFilter `json:"filters"`
+}
+
+// FilterValidationResponse lists the errors of the filters, it is empty when all the filters are valid
+type FilterValidationResponse struct {
+	Errors []FilterError `json:"errors"`
+}
+
+// FilterError is an error of a field of a filter, so that the editor can show it next to the field
+type FilterError struct {
+	FilterID string `json:"filterId"`
+	// Field is the field of the filter with the error, operator or value
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type tagTypeEntry struct {
+	typ     tagType
+	expires time.Time
+}
+
+// tagTypeCache keeps the type of the tags fetched from Tempo, so that validating the filters of the editor on every
+// change doesn't query Tempo each time. Unknown tags are cached as well.
+type tagTypeCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]tagTypeEntry
+}
+
+func newTagTypeCache(ttl time.Duration) *tagTypeCache {
+	return &tagTypeCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]tagTypeEntry{},
+	}
+}
+
+func (c *tagTypeCache) get(tag string) (tagType, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[tag]
+	if !ok || c.now().After(e.expires) {
+		return "", false
+	}
+	return e.typ, true
+}
+
+func (c *tagTypeCache) set(tag string, typ tagType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[tag] = tagTypeEntry{typ: typ, expires: c.now().Add(c.ttl)}
+}
+
+// validateFilters checks the operators and values of the filters against the type of their tags. The filters of
+// unknown tags are only checked against the value type set by the editor, if any.
+func (s *Service) validateFilters(ctx context.Context, dsInfo *datasourceInfo, filters []dataquery.TraceqlFilter) *FilterValidationResponse {
+	res := &FilterValidationResponse{Errors: []FilterError{}}
+	for _, f := range filters {
+		if f.Tag == nil || *f.Tag == "" || f.Operator == nil || *f.Operator == "" {
+			continue
+		}
+
+		typ := s.filterTagType(ctx, dsInfo, f)
+		if typ == "" {
+			continue
+		}
+
+		operators, ok := tagTypeOperators[typ]
+		if !ok {
+			continue
+		}
+		if !containsString(operators, *f.Operator) {
+			res.Errors = append(res.Errors, FilterError{
+				FilterID: f.Id,
+				Field:    "operator",
+				Message:  fmt.Sprintf("operator %s is not supported by %s tags, use one of %s", *f.Operator, typ, strings.Join(operators, " ")),
+			})
+			continue
+		}
+
+		for _, value := range filterValues(f) {
+			if err := validateValue(typ, value); err != nil {
+				res.Errors = append(res.Errors, FilterError{FilterID: f.Id, Field: "value", Message: err.Error()})
+				break
+			}
+		}
+	}
+	return res
+}
+
+// filterTagType returns the type of the tag of a filter, an empty type when it isn't known
+func (s *Service) filterTagType(ctx context.Context, dsInfo *datasourceInfo, f dataquery.TraceqlFilter) tagType {
+	if f.Scope == nil || *f.Scope == dataquery.TraceqlFilterScopeIntrinsic {
+		if typ, ok := intrinsicTypes[*f.Tag]; ok {
+			return typ
+		}
+	}
+
+	tag := scopedTag(f)
+	if dsInfo.tagTypes != nil {
+		if typ, ok := dsInfo.tagTypes.get(tag); ok {
+			return typeOrValueType(typ, f)
+		}
+	}
+
+	typ, err := s.getTagType(ctx, dsInfo, tag)
+	if err != nil {
+		// the filter is validated again with the next change of the editor
+		s.tlog.FromContext(ctx).Warn("Failed to get the tag type", "tag", tag, "err", err)
+		return typeOrValueType("", f)
+	}
+	if dsInfo.tagTypes != nil {
+		dsInfo.tagTypes.set(tag, typ)
+	}
+	return typeOrValueType(typ, f)
+}
+
+func typeOrValueType(typ tagType, f dataquery.TraceqlFilter) tagType {
+	if typ == "" && f.ValueType != nil {
+		return tagType(*f.ValueType)
+	}
+	return typ
+}
+
+// tagValuesResponse is the body returned by Tempo's /api/v2/search/tag/<tag>/values endpoint
+type tagValuesResponse struct {
+	TagValues []tagValue `json:"tagValues"`
+}
+
+type tagValue struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// getTagType fetches the values of a tag from Tempo to find its type. The tags without values and the Tempo
+// versions without the v2 API have no type.
+func (s *Service) getTagType(ctx context.Context, dsInfo *datasourceInfo, tag string) (tagType, error) {
+	values, err := s.getTagValues(ctx, dsInfo, tag, "")
+	if err != nil {
+		return "", err
+	}
+	if len(values.TagValues) == 0 {
+		return "", nil
+	}
+	return tagType(values.TagValues[0].Type), nil
+}
+
+// getTagValues fetches the values of a tag from Tempo, of the spans matching the TraceQL query when it is set. The
+// Tempo versions without the v2 API return no value.
+func (s *Service) getTagValues(ctx context.Context, dsInfo *datasourceInfo, tag string, query string) (*tagValuesResponse, error) {
+	u := fmt.Sprintf("%s/api/v2/search/tag/%s/values", dsInfo.URL, url.PathEscape(tag))
+	if query != "" {
+		u += "?" + url.Values{"q": []string{query}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := dsInfo.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tempo tag values: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.tlog.FromContext(ctx).Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	values := &tagValuesResponse{}
+	if resp.StatusCode == http.StatusNotFound {
+		return values, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get tempo tag values, Status: %s Body: %s", resp.Status, string(body))
+	}
+
+	if err := json.Unmarshal(body, values); err != nil {
+		return nil, fmt.Errorf("failed to parse tempo tag values: %w", err)
+	}
+	return values, nil
+}
+
+// filterValues returns the values of a filter as strings, a filter has several values when it matches any of them
+func filterValues(f dataquery.TraceqlFilter) []string {
+	if f.Value == nil {
+		return nil
+	}
+	switch v := (*f.Value).(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+func validateValue(typ tagType, value string) error {
+	switch typ {
+	case tagTypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("%s is not an integer", value)
+		}
+	case tagTypeFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s is not a number", value)
+		}
+	case tagTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s is not true or false", value)
+		}
+	case tagTypeDuration:
+		if _, err := gtime.ParseDuration(value); err != nil {
+			return fmt.Errorf("%s is not a duration, for example 100ms or 1.2s", value)
+		}
+	case tagTypeStatus, tagTypeKind:
+		if !containsString(enumValues[typ], value) {
+			return fmt.Errorf("%s is not a valid %s, use one of %s", value, typ, strings.Join(enumValues[typ], " "))
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+func TestValidateFilters(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/api/v2/search/tag/span.http.status_code/values":
+			_, _ = w.Write([]byte(`{"tagValues": [{"type": "int", "value": "200"}, {"type": "int", "value": "500"}]}`))
+		case "/api/v2/search/tag/resource.service.name/values":
+			_, _ = w.Write([]byte(`{"tagValues": [{"type": "string", "value": "api"}]}`))
+		case "/api/v2/search/tag/.cache.hit/values":
+			_, _ = w.Write([]byte(`{"tagValues": []}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL, tagTypes: newTagTypeCache(time.Minute)}
+
+	testCases := []struct {
+		name     string
+		filter   dataquery.TraceqlFilter
+		expected []FilterError
+	}{
+		{
+			name:   "duration supports the comparisons",
+			filter: dataquery.TraceqlFilter{Id: "min-duration", Tag: strPtr("duration"), Operator: strPtr(">="), Value: valuePtr("100ms")},
+		},
+		{
+			name:   "duration doesn't support the regular expressions",
+			filter: dataquery.TraceqlFilter{Id: "min-duration", Tag: strPtr("duration"), Operator: strPtr("=~"), Value: valuePtr("100ms")},
+			expected: []FilterError{
+				{FilterID: "min-duration", Field: "operator", Message: "operator =~ is not supported by duration tags, use one of = != > >= < <="},
+			},
+		},
+		{
+			name:   "duration values are durations",
+			filter: dataquery.TraceqlFilter{Id: "min-duration", Tag: strPtr("duration"), Operator: strPtr(">"), Value: valuePtr("fast")},
+			expected: []FilterError{
+				{FilterID: "min-duration", Field: "value", Message: "fast is not a duration, for example 100ms or 1.2s"},
+			},
+		},
+		{
+			name:   "status only supports equality",
+			filter: dataquery.TraceqlFilter{Id: "status", Scope: scopePtr(dataquery.TraceqlFilterScopeIntrinsic), Tag: strPtr("status"), Operator: strPtr(">"), Value: valuePtr("error")},
+			expected: []FilterError{
+				{FilterID: "status", Field: "operator", Message: "operator > is not supported by status tags, use one of ="},
+			},
+		},
+		{
+			name:   "status values are the span statuses",
+			filter: dataquery.TraceqlFilter{Id: "status", Tag: strPtr("status"), Operator: strPtr("="), Value: valuePtr([]interface{}{"error", "failed"})},
+			expected: []FilterError{
+				{FilterID: "status", Field: "value", Message: "failed is not a valid status, use one of error ok unset"},
+			},
+		},
+		{
+			name:   "the types of the attributes are fetched from tempo",
+			filter: dataquery.TraceqlFilter{Id: "code", Scope: scopePtr(dataquery.TraceqlFilterScopeSpan), Tag: strPtr("http.status_code"), Operator: strPtr("=~"), Value: valuePtr("5..")},
+			expected: []FilterError{
+				{FilterID: "code", Field: "operator", Message: "operator =~ is not supported by int tags, use one of = != > >= < <="},
+			},
+		},
+		{
+			name:   "string attributes support the regular expressions",
+			filter: dataquery.TraceqlFilter{Id: "service-name", Scope: scopePtr(dataquery.TraceqlFilterScopeResource), Tag: strPtr("service.name"), Operator: strPtr("=~"), Value: valuePtr("api|web")},
+		},
+		{
+			name:   "the value type of the editor is used for the tags without values",
+			filter: dataquery.TraceqlFilter{Id: "cache", Tag: strPtr(".cache.hit"), Operator: strPtr("="), Value: valuePtr("yes"), ValueType: strPtr("bool")},
+			expected: []FilterError{
+				{FilterID: "cache", Field: "value", Message: "yes is not true or false"},
+			},
+		},
+		{
+			name:   "the filters are not validated when tempo fails",
+			filter: dataquery.TraceqlFilter{Id: "db", Scope: scopePtr(dataquery.TraceqlFilterScopeSpan), Tag: strPtr("db.system"), Operator: strPtr(">"), Value: valuePtr("redis")},
+		},
+		{
+			name:   "incomplete filters are not validated",
+			filter: dataquery.TraceqlFilter{Id: "new", Tag: strPtr("duration")},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := service.validateFilters(context.Background(), dsInfo, []dataquery.TraceqlFilter{tc.filter})
+			if tc.expected == nil {
+				tc.expected = []FilterError{}
+			}
+			assert.Equal(t, tc.expected, res.Errors)
+		})
+	}
+
+	t.Run("the tag types are cached", func(t *testing.T) {
+		before := requests
+		filter := dataquery.TraceqlFilter{Id: "code", Scope: scopePtr(dataquery.TraceqlFilterScopeSpan), Tag: strPtr("http.status_code"), Operator: strPtr(">="), Value: valuePtr("500")}
+		res := service.validateFilters(context.Background(), dsInfo, []dataquery.TraceqlFilter{filter, filter})
+		assert.Empty(t, res.Errors)
+		assert.Equal(t, before, requests)
+
+		dsInfo.tagTypes.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+		service.validateFilters(context.Background(), dsInfo, []dataquery.TraceqlFilter{filter})
+		assert.Equal(t, before+1, requests)
+	})
+}
+
+func TestCallResourceValidateFilters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("unexpected request")
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL, tagTypes: newTagTypeCache(time.Minute)}
+
+	body, err := json.Marshal(FilterValidationRequest{Filters: []dataquery.TraceqlFilter{
+		{Id: "max-duration", Tag: strPtr("duration"), Operator: strPtr("<"), Value: valuePtr("1s")},
+		{Id: "kind", Tag: strPtr("kind"), Operator: strPtr("!="), Value: valuePtr("server")},
+	}})
+	require.NoError(t, err)
+
+	sender := &fakeCallResourceResponseSender{}
+	err = service.callResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodPost, Path: "validate-filters", Body: body}, sender, dsInfo)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, sender.res.Status)
+	assert.JSONEq(t, `{"errors": [{"filterId": "kind", "field": "operator", "message": "operator != is not supported by kind tags, use one of ="}]}`, string(sender.res.Body))
+
+	err = service.callResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodGet, Path: "validate-filters"}, sender, dsInfo)
+	require.EqualError(t, err, "invalid resource method: GET")
+}
@@ -0,0 +1,177 @@
+package tempo
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+const (
+	// flameGraphSearchLimit is the number of traces aggregated when the query has no limit set, every trace of the
+	// search is looked up
+	flameGraphSearchLimit = 20
+	// maxFlameGraphTraces caps the limit of the queries, to bound the number of trace lookups
+	maxFlameGraphTraces = 100
+	// flameGraphConcurrency is the number of traces looked up concurrently
+	flameGraphConcurrency = 4
+)
+
+// callTreeNode is an operation of the aggregated call tree, the spans of the operation under the same path of
+// operations in the traces, the times are in milliseconds
+type callTreeNode struct {
+	label    string
+	self     float64
+	value    float64
+	children map[string]*callTreeNode
+}
+
+func newCallTreeNode(label string) *callTreeNode {
+	return &callTreeNode{label: label, children: map[string]*callTreeNode{}}
+}
+
+// add adds the self time of the span and of the spans it started under the node
+func (n *callTreeNode) add(span *breakdownSpan) {
+	label := span.serviceName + ": " + span.operationName
+	child, ok := n.children[label]
+	if !ok {
+		child = newCallTreeNode(label)
+		n.children[label] = child
+	}
+	child.self += span.selfTime
+	for _, c := range span.children {
+		child.add(c)
+	}
+}
+
+// sum sets the values of the node and of its children, the self time of the node and of its children
+func (n *callTreeNode) sum() float64 {
+	n.value = n.self
+	for _, child := range n.children {
+		n.value += child.sum()
+	}
+	return n.value
+}
+
+// sortedChildren returns the children with the most time first
+func (n *callTreeNode) sortedChildren() []*callTreeNode {
+	children := make([]*callTreeNode, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].value != children[j].value {
+			return children[i].value > children[j].value
+		}
+		return children[i].label < children[j].label
+	})
+	return children
+}
+
+// flameGraph searches the traces matching the TraceQL selection over the query time range, looks them up and
+// aggregates their spans in a call tree of the operations, returned as a flame graph of the self time of the
+// operations to show the latency hotspots of the traces
+func (s *Service) flameGraph(ctx context.Context, dsInfo *datasourceInfo, model *dataquery.TempoQuery, query backend.DataQuery) backend.DataResponse {
+	queryRes := backend.DataResponse{}
+
+	traceQL := strings.TrimSpace(model.Query)
+	if traceQL == "" {
+		traceQL = generateQueryFromFilters(queryFilters(model))
+	}
+	traceQL, notices := dsInfo.rewriteTraceQL(traceQL)
+
+	limit := int64(flameGraphSearchLimit)
+	if model.Limit != nil && *model.Limit > 0 {
+		limit = *model.Limit
+	}
+	if limit > maxFlameGraphTraces {
+		limit = maxFlameGraphTraces
+	}
+
+	searchResp, err := s.searchTraces(ctx, dsInfo, traceQL, limit, query.TimeRange.From.Unix(), query.TimeRange.To.Unix())
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	root := newCallTreeNode("total")
+	var failures []partFailure
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(flameGraphConcurrency)
+	for _, trace := range searchResp.Traces {
+		traceID := trace.TraceID
+		g.Go(func() error {
+			roots, err := s.lookupBreakdownSpans(gctx, dsInfo, traceID, query)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures = append(failures, partFailure{part: "trace " + traceID, err: err})
+				return nil
+			}
+			for _, span := range roots {
+				root.add(span)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	root.sum()
+
+	frame := callTreeToFrame(root)
+	frame.RefID = query.RefID
+	frame.SetMeta(&data.FrameMeta{
+		PreferredVisualization: data.VisTypeFlameGraph,
+		ExecutedQueryString:    traceQL,
+		Notices:                notices,
+	})
+	// the traces are looked up concurrently, the failures are sorted so the notices are stable
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].part < failures[j].part
+	})
+	if len(failures) == len(searchResp.Traces) && len(failures) > 0 {
+		return backend.DataResponse{Error: failures[0].err}
+	}
+	return partialResponse(data.Frames{frame}, failures)
+}
+
+// lookupBreakdownSpans looks up the trace and returns its root spans with their self time
+func (s *Service) lookupBreakdownSpans(ctx context.Context, dsInfo *datasourceInfo, traceID string, query backend.DataQuery) ([]*breakdownSpan, error) {
+	res, err := s.getTrace(ctx, dsInfo, &dataquery.TempoQuery{Query: traceID}, query)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if len(res.Frames) == 0 {
+		return nil, nil
+	}
+	return breakdownSpansFromFrame(res.Frames[0])
+}
+
+// callTreeToFrame returns the call tree in the nested set format of the flame graphs, [level, value, self, label] in
+// depth first order
+func callTreeToFrame(root *callTreeNode) *data.Frame {
+	value := data.NewField("value", nil, []float64{})
+	value.Config = &data.FieldConfig{Unit: "ms"}
+	self := data.NewField("self", nil, []float64{})
+	self.Config = &data.FieldConfig{Unit: "ms"}
+	frame := data.NewFrame("Flame graph", data.NewField("level", nil, []int64{}), value, self, data.NewField("label", nil, []string{}))
+
+	var walk func(node *callTreeNode, level int64)
+	walk = func(node *callTreeNode, level int64) {
+		frame.AppendRow(level, node.value, node.self, node.label)
+		for _, child := range node.sortedChildren() {
+			walk(child, level+1)
+		}
+	}
+	walk(root, 0)
+	return frame
+}
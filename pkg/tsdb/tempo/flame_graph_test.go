@@ -0,0 +1,115 @@
+package tempo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/model/otlp"
+	"go.opentelemetry.io/collector/model/pdata"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+// flameGraphTrace returns a trace with a frontend span and an api span called between the offsets, in milliseconds
+func flameGraphTrace(t *testing.T, callStart, callEnd int) []byte {
+	t.Helper()
+	start := time.Unix(1500, 0)
+	td := pdata.NewTraces()
+
+	frontend := td.ResourceSpans().AppendEmpty()
+	frontend.Resource().Attributes().InsertString("service.name", "frontend")
+	root := frontend.InstrumentationLibrarySpans().AppendEmpty().Spans().AppendEmpty()
+	root.SetName("GET /")
+	root.SetTraceID(pdata.NewTraceID([16]byte{1}))
+	root.SetSpanID(pdata.NewSpanID([8]byte{1}))
+	root.SetStartTimestamp(pdata.TimestampFromTime(start))
+	root.SetEndTimestamp(pdata.TimestampFromTime(start.Add(100 * time.Millisecond)))
+
+	api := td.ResourceSpans().AppendEmpty()
+	api.Resource().Attributes().InsertString("service.name", "api")
+	call := api.InstrumentationLibrarySpans().AppendEmpty().Spans().AppendEmpty()
+	call.SetName("GET /orders")
+	call.SetTraceID(pdata.NewTraceID([16]byte{1}))
+	call.SetSpanID(pdata.NewSpanID([8]byte{2}))
+	call.SetParentSpanID(pdata.NewSpanID([8]byte{1}))
+	call.SetStartTimestamp(pdata.TimestampFromTime(start.Add(time.Duration(callStart) * time.Millisecond)))
+	call.SetEndTimestamp(pdata.TimestampFromTime(start.Add(time.Duration(callEnd) * time.Millisecond)))
+
+	body, err := otlp.NewProtobufTracesMarshaler().MarshalTraces(td)
+	require.NoError(t, err)
+	return body
+}
+
+func TestFlameGraph(t *testing.T) {
+	traces := map[string][]byte{
+		"/api/traces/00000000000000000000000000000001": flameGraphTrace(t, 10, 50),
+		"/api/traces/00000000000000000000000000000002": flameGraphTrace(t, 20, 80),
+	}
+	var search *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/search" {
+			search = r
+			_, _ = w.Write([]byte(`{"traces": [{"traceID": "00000000000000000000000000000001"}, {"traceID": "00000000000000000000000000000002"},
+				{"traceID": "00000000000000000000000000000003"}]}`))
+			return
+		}
+		body, ok := traces[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+	query := backend.DataQuery{
+		RefID:     "A",
+		QueryType: string(dataquery.TempoQueryTypeFlameGraph),
+		TimeRange: backend.TimeRange{From: time.Unix(1000, 0), To: time.Unix(2000, 0)},
+	}
+
+	t.Run("aggregates the call trees of the traces of the search", func(t *testing.T) {
+		res := service.flameGraph(context.Background(), dsInfo, &dataquery.TempoQuery{Query: `{ resource.service.name = "api" }`}, query)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+		assert.Equal(t, `{ resource.service.name = "api" }`, search.URL.Query().Get("q"))
+		assert.Equal(t, "20", search.URL.Query().Get("limit"))
+
+		frame := res.Frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		assert.Equal(t, data.VisTypeFlameGraph, string(frame.Meta.PreferredVisualization))
+		rows := make([][]interface{}, 0, frame.Rows())
+		for i := 0; i < frame.Rows(); i++ {
+			rows = append(rows, frame.RowCopy(i))
+		}
+		// the api calls cover 40 and 60 ms of the 100 ms of the frontend spans
+		assert.Equal(t, [][]interface{}{
+			{int64(0), 200.0, 0.0, "total"},
+			{int64(1), 200.0, 100.0, "frontend: GET /"},
+			{int64(2), 100.0, 100.0, "api: GET /orders"},
+		}, rows)
+
+		// the missing trace is reported
+		assert.Equal(t, statusPartial, res.Status)
+		require.Len(t, frame.Meta.Notices, 1)
+		assert.True(t, strings.HasPrefix(frame.Meta.Notices[0].Text, "Failed to get the trace 00000000000000000000000000000003:"))
+	})
+
+	t.Run("caps the number of traces", func(t *testing.T) {
+		limit := int64(1000)
+		res := service.flameGraph(context.Background(), dsInfo, &dataquery.TempoQuery{Query: `{}`, Limit: &limit}, query)
+		require.NoError(t, res.Error)
+		assert.Equal(t, "100", search.URL.Query().Get("limit"))
+	})
+}
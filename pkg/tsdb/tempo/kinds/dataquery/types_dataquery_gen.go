@@ -9,6 +9,16 @@
 
 package dataquery
 
+// Defines values for TempoQueryFiltersScope.
+const (
+	TempoQueryFiltersScopeEvent     TempoQueryFiltersScope = "event"
+	TempoQueryFiltersScopeIntrinsic TempoQueryFiltersScope = "intrinsic"
+	TempoQueryFiltersScopeLink      TempoQueryFiltersScope = "link"
+	TempoQueryFiltersScopeResource  TempoQueryFiltersScope = "resource"
+	TempoQueryFiltersScopeSpan      TempoQueryFiltersScope = "span"
+	TempoQueryFiltersScopeUnscoped  TempoQueryFiltersScope = "unscoped"
+)
+
 // Defines values for TempoQueryFiltersType.
 const (
 	TempoQueryFiltersTypeDynamic TempoQueryFiltersType = "dynamic"
@@ -17,13 +27,28 @@ const (
 
 // Defines values for TempoQueryType.
 const (
-	TempoQueryTypeClear         TempoQueryType = "clear"
-	TempoQueryTypeNativeSearch  TempoQueryType = "nativeSearch"
-	TempoQueryTypeSearch        TempoQueryType = "search"
-	TempoQueryTypeServiceMap    TempoQueryType = "serviceMap"
-	TempoQueryTypeTraceql       TempoQueryType = "traceql"
-	TempoQueryTypeTraceqlSearch TempoQueryType = "traceqlSearch"
-	TempoQueryTypeUpload        TempoQueryType = "upload"
+	TempoQueryTypeAttributeStatistics TempoQueryType = "attributeStatistics"
+	TempoQueryTypeClear               TempoQueryType = "clear"
+	TempoQueryTypeErrorSummary        TempoQueryType = "errorSummary"
+	TempoQueryTypeFlameGraph          TempoQueryType = "flameGraph"
+	TempoQueryTypeNativeSearch        TempoQueryType = "nativeSearch"
+	TempoQueryTypeSearch              TempoQueryType = "search"
+	TempoQueryTypeServiceGraph        TempoQueryType = "serviceGraph"
+	TempoQueryTypeServiceMap          TempoQueryType = "serviceMap"
+	TempoQueryTypeTraceBreakdown      TempoQueryType = "traceBreakdown"
+	TempoQueryTypeTraceql             TempoQueryType = "traceql"
+	TempoQueryTypeTraceqlSearch       TempoQueryType = "traceqlSearch"
+	TempoQueryTypeUpload              TempoQueryType = "upload"
+)
+
+// Defines values for TraceqlFilterScope.
+const (
+	TraceqlFilterScopeEvent     TraceqlFilterScope = "event"
+	TraceqlFilterScopeIntrinsic TraceqlFilterScope = "intrinsic"
+	TraceqlFilterScopeLink      TraceqlFilterScope = "link"
+	TraceqlFilterScopeResource  TraceqlFilterScope = "resource"
+	TraceqlFilterScopeSpan      TraceqlFilterScope = "span"
+	TraceqlFilterScopeUnscoped  TraceqlFilterScope = "unscoped"
 )
 
 // Defines values for TraceqlFilterType.
@@ -38,11 +63,30 @@ const (
 	TraceqlSearchFilterTypeStatic  TraceqlSearchFilterType = "static"
 )
 
+// Defines values for TraceqlSearchScope.
+const (
+	TraceqlSearchScopeEvent     TraceqlSearchScope = "event"
+	TraceqlSearchScopeIntrinsic TraceqlSearchScope = "intrinsic"
+	TraceqlSearchScopeLink      TraceqlSearchScope = "link"
+	TraceqlSearchScopeResource  TraceqlSearchScope = "resource"
+	TraceqlSearchScopeSpan      TraceqlSearchScope = "span"
+	TraceqlSearchScopeUnscoped  TraceqlSearchScope = "unscoped"
+)
+
 // TempoDataQuery defines model for TempoDataQuery.
 type TempoDataQuery = map[string]interface{}
 
 // TempoQuery defines model for TempoQuery.
 type TempoQuery struct {
+	// Attribute whose values are aggregated by the attributeStatistics query, for example: span.http.route, resource.service.name, name
+	Attribute *string `json:"attribute,omitempty"`
+
+	// Defines the maximum number of attribute values returned by the attributeStatistics query
+	AttributeLimit *int64 `json:"attributeLimit,omitempty"`
+
+	// Offset of the previous window the serviceGraph query is compared with, for example: 1h, 1d, 1w
+	CompareWith *string `json:"compareWith,omitempty"`
+
 	// For mixed data sources the selected datasource is on the query level.
 	// For non mixed scenarios this is undefined.
 	// TODO find a better way to do this ^ that's friendly to schema
@@ -55,6 +99,9 @@ type TempoQuery struct {
 		// The operator that connects the tag to the value, for example: =, >, !=, =~
 		Operator *string `json:"operator,omitempty"`
 
+		// The scope of the tag, used as prefix of the tag when generating the query. Tags without scope are used as is
+		Scope *TempoQueryFiltersScope `json:"scope,omitempty"`
+
 		// The tag for the search filter, for example: .http.status_code, .service.name, status
 		Tag *string `json:"tag,omitempty"`
 
@@ -107,10 +154,13 @@ type TempoQuery struct {
 	SpanName *string `json:"spanName,omitempty"`
 }
 
+// The scope of the tag, used as prefix of the tag when generating the query. Tags without scope are used as is
+type TempoQueryFiltersScope string
+
 // The type of the filter, can either be static (pre defined in the UI) or dynamic
 type TempoQueryFiltersType string
 
-// TempoQueryType search = Loki search, nativeSearch = Tempo search for backwards compatibility
+// TempoQueryType search = Loki search, nativeSearch = Tempo search for backwards compatibility, errorSummary = aggregated summary of error spans, attributeStatistics = distribution of the values of a span attribute, serviceGraph = node graph of the services and normalized span names, traceBreakdown = self time of the services and operations of a trace, flameGraph = call tree of the operations of the traces matching a TraceQL query
 type TempoQueryType string
 
 // TraceqlFilter defines model for TraceqlFilter.
@@ -121,6 +171,9 @@ type TraceqlFilter struct {
 	// The operator that connects the tag to the value, for example: =, >, !=, =~
 	Operator *string `json:"operator,omitempty"`
 
+	// The scope of the tag, used as prefix of the tag when generating the query. Tags without scope are used as is
+	Scope *TraceqlFilterScope `json:"scope,omitempty"`
+
 	// The tag for the search filter, for example: .http.status_code, .service.name, status
 	Tag *string `json:"tag,omitempty"`
 
@@ -134,8 +187,14 @@ type TraceqlFilter struct {
 	ValueType *string `json:"valueType,omitempty"`
 }
 
+// The scope of the tag, used as prefix of the tag when generating the query. Tags without scope are used as is
+type TraceqlFilterScope string
+
 // The type of the filter, can either be static (pre defined in the UI) or dynamic
 type TraceqlFilterType string
 
 // TraceqlSearchFilterType static fields are pre-set in the UI, dynamic fields are added by the user
 type TraceqlSearchFilterType string
+
+// The scope of a tag: intrinsics (for example name, status, duration) are used as is, unscoped tags match both span and resource attributes
+type TraceqlSearchScope string
@@ -0,0 +1,135 @@
+package tempo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/collector/model/otlp"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// otlpPushPath is the path of the OTLP HTTP receiver of the Tempo distributors
+const otlpPushPath = "/v1/traces"
+
+type otlpPushSettings struct {
+	// URL is the OTLP HTTP receiver of the Tempo distributor, such as http://localhost:4318
+	URL string `json:"url"`
+}
+
+// PushResult is the response of the push resource
+type PushResult struct {
+	Spans int `json:"spans"`
+}
+
+// otlpJSONTraces is the part of an OTLP JSON export request holding its spans. The spans are grouped by scope since
+// OTLP 0.19, and by instrumentation library before.
+type otlpJSONTraces struct {
+	ResourceSpans []struct {
+		ScopeSpans                  []otlpJSONSpans `json:"scopeSpans"`
+		InstrumentationLibrarySpans []otlpJSONSpans `json:"instrumentationLibrarySpans"`
+	} `json:"resourceSpans"`
+}
+
+type otlpJSONSpans struct {
+	Spans []json.RawMessage `json:"spans"`
+}
+
+// newPushURL returns the URL of the distributor without the path of the receiver, it is empty when not set
+func newPushURL(settings otlpPushSettings) (string, error) {
+	if settings.URL == "" {
+		return "", nil
+	}
+	u, err := url.Parse(settings.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid URL %q, expected an http or https URL", settings.URL)
+	}
+	return strings.TrimSuffix(strings.TrimRight(settings.URL, "/"), otlpPushPath), nil
+}
+
+// pushSpans forwards an OTLP export request to the distributor of the data source, so that the developers of plugins
+// and instrumentations can send test traces through Grafana. It is only available in development mode. The body is
+// forwarded unchanged, it is decoded to reject the invalid requests before they reach Tempo.
+func (s *Service) pushSpans(ctx context.Context, dsInfo *datasourceInfo, contentType string, body []byte) (*PushResult, error) {
+	if setting.Env != setting.Dev {
+		return nil, errors.New("pushing spans is only available when Grafana runs in development mode")
+	}
+	if dsInfo.pushURL == "" {
+		return nil, errors.New("the OTLP push URL of the data source is not set")
+	}
+
+	spans, err := countSpans(contentType, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spans: %w", err)
+	}
+	if spans == 0 {
+		return nil, errors.New("invalid spans: the request has no span")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dsInfo.pushURL+otlpPushPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := dsInfo.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to push the spans to tempo: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.tlog.FromContext(ctx).Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to push the spans to tempo, Status: %s Body: %s", resp.Status, string(respBody))
+	}
+
+	s.tlog.FromContext(ctx).Debug("Pushed spans to Tempo", "url", req.URL.String(), "spans", spans)
+	return &PushResult{Spans: spans}, nil
+}
+
+// countSpans decodes an OTLP export request encoded in protobuf or in JSON and returns its number of spans
+func countSpans(contentType string, body []byte) (int, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return 0, fmt.Errorf("invalid content type %q", contentType)
+	}
+
+	switch mediaType {
+	case "application/x-protobuf", "application/protobuf":
+		traces, err := otlp.NewProtobufTracesUnmarshaler().UnmarshalTraces(body)
+		if err != nil {
+			return 0, err
+		}
+		return traces.SpanCount(), nil
+	case "application/json":
+		var traces otlpJSONTraces
+		if err := json.Unmarshal(body, &traces); err != nil {
+			return 0, err
+		}
+		spans := 0
+		for _, rs := range traces.ResourceSpans {
+			for _, ss := range append(rs.ScopeSpans, rs.InstrumentationLibrarySpans...) {
+				spans += len(ss.Spans)
+			}
+		}
+		return spans, nil
+	default:
+		return 0, fmt.Errorf("unsupported content type %q, expected application/x-protobuf or application/json", mediaType)
+	}
+}
@@ -0,0 +1,140 @@
+package tempo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/model/otlp"
+	"go.opentelemetry.io/collector/model/pdata"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const testJSONSpans = `{"resourceSpans": [{
+	"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "shop"}}]},
+	"scopeSpans": [{"spans": [
+		{"traceId": "5b8efff798038103d269b633813fc60c", "spanId": "eee19b7ec3c1b174", "name": "GET /cart"},
+		{"traceId": "5b8efff798038103d269b633813fc60c", "spanId": "eee19b7ec3c1b175", "name": "SELECT cart"}
+	]}]
+}]}`
+
+func TestPushSpans(t *testing.T) {
+	td := pdata.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().InstrumentationLibrarySpans().AppendEmpty().Spans()
+	for i := byte(1); i <= 3; i++ {
+		span := spans.AppendEmpty()
+		span.SetTraceID(pdata.NewTraceID([16]byte{1}))
+		span.SetSpanID(pdata.NewSpanID([8]byte{i}))
+		span.SetName("span")
+	}
+	protobufSpans, err := otlp.NewProtobufTracesMarshaler().MarshalTraces(td)
+	require.NoError(t, err)
+
+	setup := func(t *testing.T, status int) (*datasourceInfo, *[]string) {
+		t.Helper()
+		var received []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			received = append(received, r.Method+" "+r.URL.Path+" "+r.Header.Get("Content-Type")+" "+string(body))
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("rate limited"))
+		}))
+		t.Cleanup(srv.Close)
+		return &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: "http://tempo:3200", pushURL: srv.URL}, &received
+	}
+	push := func(dsInfo *datasourceInfo, contentType string, body []byte) (*backend.CallResourceResponse, error) {
+		service := &Service{tlog: log.New("tempo-test")}
+		sender := &fakeCallResourceResponseSender{}
+		err := service.callResource(context.Background(), &backend.CallResourceRequest{
+			Method:  http.MethodPost,
+			Path:    "push",
+			Headers: map[string][]string{"Content-Type": {contentType}},
+			Body:    body,
+		}, sender, dsInfo)
+		return sender.res, err
+	}
+
+	t.Run("forwards the protobuf spans to the distributor", func(t *testing.T) {
+		dsInfo, received := setup(t, http.StatusOK)
+
+		res, err := push(dsInfo, "application/x-protobuf", protobufSpans)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"spans": 3}`, string(res.Body))
+		assert.Equal(t, []string{"POST /v1/traces application/x-protobuf " + string(protobufSpans)}, *received)
+	})
+
+	t.Run("forwards the JSON spans to the distributor", func(t *testing.T) {
+		dsInfo, received := setup(t, http.StatusOK)
+
+		res, err := push(dsInfo, "application/json; charset=utf-8", []byte(testJSONSpans))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"spans": 2}`, string(res.Body))
+		assert.Equal(t, []string{"POST /v1/traces application/json; charset=utf-8 " + testJSONSpans}, *received)
+	})
+
+	t.Run("rejects the invalid requests", func(t *testing.T) {
+		dsInfo, received := setup(t, http.StatusOK)
+
+		_, err := push(dsInfo, "application/json", []byte(`{"resourceSpans": [{}]}`))
+		assert.ErrorContains(t, err, "the request has no span")
+		_, err = push(dsInfo, "application/x-protobuf", []byte("not protobuf"))
+		assert.ErrorContains(t, err, "invalid spans")
+		_, err = push(dsInfo, "text/plain", []byte(testJSONSpans))
+		assert.ErrorContains(t, err, `unsupported content type "text/plain"`)
+		assert.Empty(t, *received)
+	})
+
+	t.Run("fails when tempo fails", func(t *testing.T) {
+		dsInfo, _ := setup(t, http.StatusTooManyRequests)
+
+		_, err := push(dsInfo, "application/json", []byte(testJSONSpans))
+		assert.ErrorContains(t, err, "failed to push the spans to tempo, Status: 429 Too Many Requests Body: rate limited")
+	})
+
+	t.Run("fails without push URL", func(t *testing.T) {
+		dsInfo, _ := setup(t, http.StatusOK)
+		dsInfo.pushURL = ""
+
+		_, err := push(dsInfo, "application/json", []byte(testJSONSpans))
+		assert.ErrorContains(t, err, "the OTLP push URL of the data source is not set")
+	})
+
+	t.Run("fails outside of development mode", func(t *testing.T) {
+		env := setting.Env
+		setting.Env = setting.Prod
+		t.Cleanup(func() { setting.Env = env })
+		dsInfo, received := setup(t, http.StatusOK)
+
+		_, err := push(dsInfo, "application/json", []byte(testJSONSpans))
+		assert.ErrorContains(t, err, "only available when Grafana runs in development mode")
+		assert.Empty(t, *received)
+	})
+}
+
+func TestNewPushURL(t *testing.T) {
+	for raw, expected := range map[string]string{
+		"":                                "",
+		"http://localhost:4318":           "http://localhost:4318",
+		"http://localhost:4318/":          "http://localhost:4318",
+		"https://tempo/otlp/v1/traces":    "https://tempo/otlp",
+		"https://tempo/otlp/v1/traces///": "https://tempo/otlp",
+	} {
+		u, err := newPushURL(otlpPushSettings{URL: raw})
+		require.NoError(t, err, raw)
+		assert.Equal(t, expected, u, raw)
+	}
+
+	for _, raw := range []string{"localhost:4318", "grpc://localhost:4317", "http://"} {
+		_, err := newPushURL(otlpPushSettings{URL: raw})
+		assert.Error(t, err, raw)
+	}
+}
@@ -0,0 +1,177 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+var _ backend.CallResourceHandler = (*Service)(nil)
+
+// tempoOverrides is the body returned by Tempo's /api/overrides endpoint. Newer versions group the query limits
+// under read, older versions return them at the top level.
+type tempoOverrides struct {
+	Read *tempoReadOverrides `json:"read"`
+	tempoReadOverrides
+}
+
+type tempoReadOverrides struct {
+	MaxSearchDuration         string `json:"max_search_duration"`
+	MaxBytesPerTagValuesQuery int64  `json:"max_bytes_per_tag_values_query"`
+}
+
+// Limits are the limits of the tenant the query editor warns about before running a query, a zero value means
+// there is no limit.
+type Limits struct {
+	MaxSearchDurationMs  int64 `json:"maxSearchDurationMs,omitempty"`
+	MaxBytesPerTagValues int64 `json:"maxBytesPerTagValues,omitempty"`
+}
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return err
+	}
+	return s.callResource(ctx, req, sender, dsInfo)
+}
+
+func (s *Service) callResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender, dsInfo *datasourceInfo) error {
+	switch strings.Trim(req.Path, "/") {
+	case "overrides":
+		if req.Method != http.MethodGet {
+			return fmt.Errorf("invalid resource method: %s", req.Method)
+		}
+		limits, err := s.getLimits(ctx, dsInfo)
+		if err != nil {
+			return err
+		}
+		return sendJSON(sender, limits)
+	case "validate-filters":
+		if req.Method != http.MethodPost {
+			return fmt.Errorf("invalid resource method: %s", req.Method)
+		}
+		var body FilterValidationRequest
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			return fmt.Errorf("invalid filters: %w", err)
+		}
+		return sendJSON(sender, s.validateFilters(ctx, dsInfo, body.Filters))
+	case "estimate-cost":
+		if req.Method != http.MethodPost {
+			return fmt.Errorf("invalid resource method: %s", req.Method)
+		}
+		var body CostEstimateRequest
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			return fmt.Errorf("invalid cost estimate request: %w", err)
+		}
+		estimate, err := s.estimateCost(ctx, dsInfo, body.Start, body.End)
+		if err != nil {
+			return err
+		}
+		return sendJSON(sender, estimate)
+	case "tag-value-suggestions":
+		if req.Method != http.MethodGet {
+			return fmt.Errorf("invalid resource method: %s", req.Method)
+		}
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return fmt.Errorf("invalid resource URL: %s", req.URL)
+		}
+		suggestions, err := s.tagValueSuggestions(ctx, req.PluginContext, dsInfo, u.Query().Get("tag"), u.Query().Get("q"))
+		if err != nil {
+			return err
+		}
+		return sendJSON(sender, suggestions)
+	case "trace-logs-query":
+		if req.Method != http.MethodGet {
+			return fmt.Errorf("invalid resource method: %s", req.Method)
+		}
+		return s.sendTraceLogsQuery(ctx, req, sender, dsInfo)
+	case "push":
+		if req.Method != http.MethodPost {
+			return fmt.Errorf("invalid resource method: %s", req.Method)
+		}
+		result, err := s.pushSpans(ctx, dsInfo, http.Header(req.Headers).Get("Content-Type"), req.Body)
+		if err != nil {
+			return err
+		}
+		return sendJSON(sender, result)
+	default:
+		return fmt.Errorf("invalid resource URL: %s", req.Path)
+	}
+}
+
+func sendJSON(sender backend.CallResourceResponseSender, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status: http.StatusOK,
+		Headers: map[string][]string{
+			"content-type": {"application/json"},
+		},
+		Body: body,
+	})
+}
+
+// getLimits fetches the overrides of the tenant, the Tempo versions without the overrides API have no limits
+func (s *Service) getLimits(ctx context.Context, dsInfo *datasourceInfo) (*Limits, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dsInfo.URL+"/api/overrides", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := dsInfo.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tempo overrides: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.tlog.FromContext(ctx).Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &Limits{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get tempo overrides, Status: %s Body: %s", resp.Status, string(body))
+	}
+
+	var overrides tempoOverrides
+	if err := json.Unmarshal(body, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse tempo overrides: %w", err)
+	}
+	return overrides.limits()
+}
+
+func (o *tempoOverrides) limits() (*Limits, error) {
+	read := o.tempoReadOverrides
+	if o.Read != nil {
+		read = *o.Read
+	}
+
+	limits := &Limits{MaxBytesPerTagValues: read.MaxBytesPerTagValuesQuery}
+	if read.MaxSearchDuration != "" {
+		d, err := gtime.ParseDuration(read.MaxSearchDuration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max search duration: %w", err)
+		}
+		limits.MaxSearchDurationMs = d.Milliseconds()
+	}
+	return limits, nil
+}
@@ -0,0 +1,110 @@
+package tempo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestCallResourceOverrides(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		expected string
+	}{
+		{
+			name:     "returns the read limits",
+			status:   http.StatusOK,
+			body:     `{"read": {"max_search_duration": "168h0m0s", "max_bytes_per_tag_values_query": 5000000}}`,
+			expected: `{"maxSearchDurationMs":604800000,"maxBytesPerTagValues":5000000}`,
+		},
+		{
+			name:     "returns the limits of the older versions",
+			status:   http.StatusOK,
+			body:     `{"max_search_duration": "1d"}`,
+			expected: `{"maxSearchDurationMs":86400000}`,
+		},
+		{
+			name:     "returns no limits without the overrides API",
+			status:   http.StatusNotFound,
+			body:     `404 page not found`,
+			expected: `{}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/overrides", r.URL.Path)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			res, err := callOverrides(srv, http.MethodGet, "overrides")
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, res.Status)
+			assert.JSONEq(t, tc.expected, string(res.Body))
+		})
+	}
+
+	t.Run("fails when tempo fails", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("no org id"))
+		}))
+		t.Cleanup(srv.Close)
+
+		_, err := callOverrides(srv, http.MethodGet, "overrides")
+		require.ErrorContains(t, err, "failed to get tempo overrides, Status: 500 Internal Server Error Body: no org id")
+	})
+
+	t.Run("fails with an invalid max search duration", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"read": {"max_search_duration": "a week"}}`))
+		}))
+		t.Cleanup(srv.Close)
+
+		_, err := callOverrides(srv, http.MethodGet, "overrides")
+		require.ErrorContains(t, err, "invalid max search duration")
+	})
+
+	t.Run("rejects the other resources", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("unexpected request")
+		}))
+		t.Cleanup(srv.Close)
+
+		_, err := callOverrides(srv, http.MethodPost, "overrides")
+		require.EqualError(t, err, "invalid resource method: POST")
+		_, err = callOverrides(srv, http.MethodGet, "api/search")
+		require.EqualError(t, err, "invalid resource URL: api/search")
+	})
+}
+
+func callOverrides(srv *httptest.Server, method, path string) (*backend.CallResourceResponse, error) {
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL}
+
+	sender := &fakeCallResourceResponseSender{}
+	err := service.callResource(context.Background(), &backend.CallResourceRequest{Method: method, Path: path}, sender, dsInfo)
+	return sender.res, err
+}
+
+type fakeCallResourceResponseSender struct {
+	res *backend.CallResourceResponse
+}
+
+func (s *fakeCallResourceResponseSender) Send(res *backend.CallResourceResponse) error {
+	s.res = res
+	return nil
+}
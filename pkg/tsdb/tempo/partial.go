@@ -0,0 +1,41 @@
+package tempo
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// statusPartial is the status of the responses of the queries making several calls to Tempo, when some calls failed
+// and the response only holds the frames of the calls that succeeded.
+const statusPartial = backend.Status(http.StatusMultiStatus)
+
+// partFailure is a failed call of a query making several calls to Tempo
+type partFailure struct {
+	// part describes the data missing from the response because of the failure, for example "previous window"
+	part string
+	err  error
+}
+
+// partialResponse returns the frames of the calls that succeeded, with a warning notice on the first frame for each
+// failed call. The response fails with the first error when no frame is left.
+func partialResponse(frames data.Frames, failures []partFailure) backend.DataResponse {
+	if len(failures) == 0 {
+		return backend.DataResponse{Frames: frames}
+	}
+	if len(frames) == 0 {
+		return backend.DataResponse{Error: failures[0].err}
+	}
+
+	notices := make([]data.Notice, 0, len(failures))
+	for _, failure := range failures {
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Failed to get the %s: %s", failure.part, failure.err),
+		})
+	}
+	frames[0].AppendNotices(notices...)
+	return backend.DataResponse{Frames: frames, Status: statusPartial}
+}
@@ -0,0 +1,100 @@
+package tempo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// promotedAttribute is an attribute shown in its own column of the search results, read from the promotedAttributes
+// of the search object of jsonData. The attribute is searched in both scopes when it has no scope.
+type promotedAttribute struct {
+	Scope string `json:"scope"`
+	Name  string `json:"name"`
+}
+
+// traceQL returns the attribute as written in TraceQL
+func (a promotedAttribute) traceQL() string {
+	if a.Scope == "" {
+		return "." + a.Name
+	}
+	return a.Scope + "." + a.Name
+}
+
+// matches returns whether the key of an attribute of the search results is the promoted attribute, Tempo returns
+// the selected attributes without their scope
+func (a promotedAttribute) matches(key string) bool {
+	return key == a.Name || key == a.traceQL()
+}
+
+// promotedAttributes adds a column for each promoted attribute to the search results, so that the tables of the
+// searches have the same columns in every dashboard. Tempo only returns the attributes the query filters on or
+// selects, the attributes are selected by the queries.
+type promotedAttributes struct {
+	attributes []promotedAttribute
+}
+
+// newPromotedAttributes returns nil when the data source promotes no attribute, so that the queries and the search
+// results are not changed
+func newPromotedAttributes(attributes []promotedAttribute) (*promotedAttributes, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	p := &promotedAttributes{}
+	seen := map[string]bool{}
+	for _, attribute := range attributes {
+		if attribute.Scope != "" && attribute.Scope != "span" && attribute.Scope != "resource" {
+			return nil, fmt.Errorf("invalid scope %q of the promoted attribute %q, expected span or resource", attribute.Scope, attribute.Name)
+		}
+		if strings.TrimSpace(attribute.Name) == "" {
+			return nil, fmt.Errorf("a promoted attribute has no name")
+		}
+		if seen[attribute.Name] {
+			return nil, fmt.Errorf("the attribute %q is promoted twice", attribute.Name)
+		}
+		seen[attribute.Name] = true
+		p.attributes = append(p.attributes, attribute)
+	}
+	return p, nil
+}
+
+// selectQuery appends the selection of the promoted attributes to the pipeline of the query
+func (p *promotedAttributes) selectQuery(query string) string {
+	if p == nil {
+		return query
+	}
+	selected := make([]string, 0, len(p.attributes))
+	for _, attribute := range p.attributes {
+		selected = append(selected, attribute.traceQL())
+	}
+	return fmt.Sprintf("%s | select(%s)", query, strings.Join(selected, ", "))
+}
+
+// addFields adds a column to the frame of the traces for each promoted attribute, with the value of the first span
+// of the trace having the attribute, or an empty value
+func (p *promotedAttributes) addFields(frame *data.Frame, traces []*TraceSearchMetadata) {
+	if p == nil {
+		return
+	}
+	for _, attribute := range p.attributes {
+		values := make([]string, 0, len(traces))
+		for _, trace := range traces {
+			values = append(values, traceAttribute(trace, attribute))
+		}
+		frame.Fields = append(frame.Fields, data.NewField(attribute.Name, nil, values))
+	}
+}
+
+func traceAttribute(trace *TraceSearchMetadata, attribute promotedAttribute) string {
+	for _, spanSet := range trace.AllSpanSets() {
+		for _, span := range spanSet.Spans {
+			for _, attr := range span.Attributes {
+				if attribute.matches(attr.Key) {
+					return attr.Value.String()
+				}
+			}
+		}
+	}
+	return ""
+}
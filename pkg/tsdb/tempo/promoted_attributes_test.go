@@ -0,0 +1,55 @@
+package tempo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPromotedAttributes(t *testing.T) {
+	p, err := newPromotedAttributes(nil)
+	require.NoError(t, err)
+	assert.Nil(t, p)
+	assert.Equal(t, "{}", p.selectQuery("{}"))
+
+	_, err = newPromotedAttributes([]promotedAttribute{{Scope: "event", Name: "exception.type"}})
+	require.EqualError(t, err, `invalid scope "event" of the promoted attribute "exception.type", expected span or resource`)
+	_, err = newPromotedAttributes([]promotedAttribute{{Scope: "span", Name: " "}})
+	require.EqualError(t, err, "a promoted attribute has no name")
+	_, err = newPromotedAttributes([]promotedAttribute{{Name: "customer.id"}, {Scope: "span", Name: "customer.id"}})
+	require.EqualError(t, err, `the attribute "customer.id" is promoted twice`)
+}
+
+func TestPromotedAttributes(t *testing.T) {
+	p, err := newPromotedAttributes([]promotedAttribute{
+		{Scope: "resource", Name: "k8s.namespace.name"},
+		{Name: "customer.id"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `{span.http.status_code=500} | select(resource.k8s.namespace.name, .customer.id)`, p.selectQuery("{span.http.status_code=500}"))
+
+	resp := &SearchResponse{}
+	require.NoError(t, json.Unmarshal([]byte(`{"traces": [
+		{"traceID": "t1", "spanSets": [
+			{"spans": [{"spanID": "s1", "attributes": [{"key": "http.status_code", "value": {"intValue": "500"}}]}]},
+			{"spans": [{"spanID": "s2", "attributes": [{"key": "k8s.namespace.name", "value": {"stringValue": "payments"}}, {"key": "customer.id", "value": {"intValue": "42"}}]}]}
+		]},
+		{"traceID": "t2", "spanSet": {"spans": [{"spanID": "s3"}]}}
+	]}`), resp))
+
+	frame := searchToFrame(resp)
+	p.addFields(frame, resp.Traces)
+	require.Len(t, frame.Fields, 7)
+
+	namespace := frame.Fields[5]
+	assert.Equal(t, "k8s.namespace.name", namespace.Name)
+	assert.Equal(t, "payments", namespace.At(0))
+	assert.Equal(t, "", namespace.At(1), "the column is empty for the traces without the attribute")
+
+	customer := frame.Fields[6]
+	assert.Equal(t, "customer.id", customer.Name)
+	assert.Equal(t, "42", customer.At(0))
+}
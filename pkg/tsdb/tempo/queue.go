@@ -0,0 +1,121 @@
+package tempo
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// headerQueryPriority is set by the query service, it tells whether a user is waiting for the results of the query
+const headerQueryPriority = "X-Query-Priority"
+
+type queryPriority string
+
+const (
+	priorityInteractive queryPriority = "interactive"
+	priorityBackground  queryPriority = "background"
+)
+
+// errQueueFull is returned for background queries when too many of them are already waiting
+var errQueueFull = errors.New("tempo is overloaded, background query was dropped")
+
+// parsePriority returns the priority of the header, queries without a valid priority are interactive so that they are
+// never dropped
+func parsePriority(header string) queryPriority {
+	if queryPriority(strings.ToLower(header)) == priorityBackground {
+		return priorityBackground
+	}
+	return priorityInteractive
+}
+
+// requestQueue limits the number of concurrent queries to Tempo. Interactive queries can use all the slots and go
+// first when a slot is released. Background queries can't use the slots reserved to interactive queries, and they are
+// dropped when too many of them are waiting.
+type requestQueue struct {
+	mu        sync.Mutex
+	limit     int
+	reserved  int
+	maxQueued int
+	inFlight  int
+	waiting   map[queryPriority]*list.List
+}
+
+func newRequestQueue(limit int) *requestQueue {
+	reserved := limit / 4
+	if reserved < 1 && limit > 1 {
+		reserved = 1
+	}
+	return &requestQueue{
+		limit:     limit,
+		reserved:  reserved,
+		maxQueued: 4 * limit,
+		waiting: map[queryPriority]*list.List{
+			priorityInteractive: list.New(),
+			priorityBackground:  list.New(),
+		},
+	}
+}
+
+func (q *requestQueue) slots(p queryPriority) int {
+	if p == priorityBackground {
+		return q.limit - q.reserved
+	}
+	return q.limit
+}
+
+// acquire waits for a slot and returns the function to release it
+func (q *requestQueue) acquire(ctx context.Context, p queryPriority) (func(), error) {
+	q.mu.Lock()
+	waiting := q.waiting[p]
+	if q.inFlight < q.slots(p) && waiting.Len() == 0 {
+		q.inFlight++
+		q.mu.Unlock()
+		return q.release, nil
+	}
+	if p == priorityBackground && waiting.Len() >= q.maxQueued {
+		q.mu.Unlock()
+		return nil, errQueueFull
+	}
+
+	ready := make(chan struct{})
+	elem := waiting.PushBack(ready)
+	q.mu.Unlock()
+
+	select {
+	case <-ready:
+		return q.release, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		select {
+		case <-ready:
+			// the slot was given to the query while it was canceled
+			q.inFlight--
+			q.next()
+		default:
+			waiting.Remove(elem)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (q *requestQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inFlight--
+	q.next()
+}
+
+// next gives the free slots to the waiting queries, interactive queries first
+func (q *requestQueue) next() {
+	for _, p := range []queryPriority{priorityInteractive, priorityBackground} {
+		waiting := q.waiting[p]
+		for waiting.Len() > 0 && q.inFlight < q.slots(p) {
+			ready := waiting.Remove(waiting.Front()).(chan struct{})
+			q.inFlight++
+			close(ready)
+		}
+	}
+}
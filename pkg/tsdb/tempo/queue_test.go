@@ -0,0 +1,103 @@
+package tempo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePriority(t *testing.T) {
+	assert.Equal(t, priorityBackground, parsePriority("background"))
+	assert.Equal(t, priorityBackground, parsePriority("Background"))
+	assert.Equal(t, priorityInteractive, parsePriority("interactive"))
+	assert.Equal(t, priorityInteractive, parsePriority(""))
+}
+
+func acquireAsync(q *requestQueue, ctx context.Context, p queryPriority) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.acquire(ctx, p)
+		done <- err
+	}()
+	return done
+}
+
+func waitingLen(q *requestQueue, p queryPriority) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiting[p].Len()
+}
+
+func TestRequestQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("background queries can't use the reserved slots", func(t *testing.T) {
+		q := newRequestQueue(4)
+		var releases []func()
+		for i := 0; i < 3; i++ {
+			release, err := q.acquire(ctx, priorityBackground)
+			require.NoError(t, err)
+			releases = append(releases, release)
+		}
+
+		background := acquireAsync(q, ctx, priorityBackground)
+		require.Eventually(t, func() bool { return waitingLen(q, priorityBackground) == 1 }, time.Second, time.Millisecond)
+
+		// the reserved slot is free for interactive queries
+		release, err := q.acquire(ctx, priorityInteractive)
+		require.NoError(t, err)
+
+		// interactive queries go first once a slot is released
+		interactive := acquireAsync(q, ctx, priorityInteractive)
+		require.Eventually(t, func() bool { return waitingLen(q, priorityInteractive) == 1 }, time.Second, time.Millisecond)
+		release()
+		require.NoError(t, <-interactive)
+		assert.Equal(t, 1, waitingLen(q, priorityBackground))
+
+		// the interactive query still uses the reserved slot
+		releases[0]()
+		assert.Equal(t, 1, waitingLen(q, priorityBackground))
+		releases[1]()
+		require.NoError(t, <-background)
+	})
+
+	t.Run("background queries are dropped when the queue is full", func(t *testing.T) {
+		q := newRequestQueue(1)
+		_, err := q.acquire(ctx, priorityBackground)
+		require.NoError(t, err)
+
+		for i := 0; i < q.maxQueued; i++ {
+			acquireAsync(q, ctx, priorityBackground)
+		}
+		require.Eventually(t, func() bool { return waitingLen(q, priorityBackground) == q.maxQueued }, time.Second, time.Millisecond)
+
+		_, err = q.acquire(ctx, priorityBackground)
+		assert.ErrorIs(t, err, errQueueFull)
+
+		// interactive queries are never dropped
+		interactive := acquireAsync(q, ctx, priorityInteractive)
+		require.Eventually(t, func() bool { return waitingLen(q, priorityInteractive) == 1 }, time.Second, time.Millisecond)
+		q.release()
+		require.NoError(t, <-interactive)
+	})
+
+	t.Run("canceled queries leave the queue", func(t *testing.T) {
+		q := newRequestQueue(1)
+		release, err := q.acquire(ctx, priorityInteractive)
+		require.NoError(t, err)
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		canceled := acquireAsync(q, cancelCtx, priorityInteractive)
+		require.Eventually(t, func() bool { return waitingLen(q, priorityInteractive) == 1 }, time.Second, time.Millisecond)
+		cancel()
+		assert.ErrorIs(t, <-canceled, context.Canceled)
+		assert.Equal(t, 0, waitingLen(q, priorityInteractive))
+
+		release()
+		_, err = q.acquire(ctx, priorityInteractive)
+		require.NoError(t, err)
+	})
+}
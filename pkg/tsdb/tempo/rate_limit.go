@@ -0,0 +1,258 @@
+package tempo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// rateLimitNamespace is the namespace of the queries counted in the key-value store, they are not stored by
+	// organization so that the datasources of every organization share the budget of a tenant
+	rateLimitNamespace = "tempo-rate-limit"
+
+	defaultRateLimitInterval = time.Minute
+
+	// headerTenant is the header of the tenant of the requests to a multi-tenant Tempo
+	headerTenant = "X-Scope-OrgID"
+)
+
+type rateLimitSettings struct {
+	// Queries is the number of queries of the tenant in every interval, by all the Grafana instances together
+	Queries  int    `json:"queries"`
+	Interval string `json:"interval"`
+	// Tenant is the tenant whose budget the queries use, it defaults to the X-Scope-OrgID header of the datasource
+	Tenant string `json:"tenant"`
+}
+
+// rateLimitStore counts the queries of every Grafana instance, it is the key-value store of the Grafana database
+type rateLimitStore interface {
+	Set(ctx context.Context, key string, value string) error
+	Del(ctx context.Context, key string) error
+	Keys(ctx context.Context, keyPrefix string) ([]kvstore.Key, error)
+}
+
+func newRateLimitStore(kv kvstore.KVStore) rateLimitStore {
+	if kv == nil {
+		return nil
+	}
+	return kvstore.WithNamespace(kv, 0, rateLimitNamespace)
+}
+
+// rateLimitedError is returned for the queries above the budget of the tenant
+type rateLimitedError struct {
+	tenant     string
+	limit      int
+	interval   time.Duration
+	retryAfter time.Duration
+}
+
+func (e rateLimitedError) Error() string {
+	tenant := "the Tempo tenant"
+	if e.tenant != "" {
+		tenant = fmt.Sprintf("the Tempo tenant %q", e.tenant)
+	}
+	return fmt.Sprintf("%s exceeded its budget of %d queries per %s, retry in %s", tenant, e.limit, e.interval, e.retryAfter.Round(time.Second))
+}
+
+// tenantRateLimiter limits the queries sent to a Tempo tenant in fixed windows. The queries of every Grafana instance
+// are stored in the database, a key for each query, so that the replicas of Grafana share the budget of the tenant and
+// the datasources on the same URL and tenant share it too. The queries are counted by each instance when the database
+// is not available.
+type tenantRateLimiter struct {
+	store rateLimitStore
+	log   log.Logger
+	now   func() time.Time
+
+	// budget identifies the URL and the tenant of the datasource in the keys of the store
+	budget   string
+	tenant   string
+	limit    int
+	interval time.Duration
+
+	mu sync.Mutex
+	// keys are the keys stored by this instance, they are removed once their window is over
+	keys []rateLimitKey
+	// sweptWindow is the last window in which the keys left by the previous windows were removed
+	sweptWindow time.Time
+	// the queries of this instance in the current window, counted when the store is not available
+	localWindow time.Time
+	localCount  int
+}
+
+type rateLimitKey struct {
+	key    string
+	window time.Time
+}
+
+func newTenantRateLimiter(settings backend.DataSourceInstanceSettings, rs rateLimitSettings, store rateLimitStore) (*tenantRateLimiter, error) {
+	if rs.Queries <= 0 {
+		return nil, nil
+	}
+
+	interval := defaultRateLimitInterval
+	if rs.Interval != "" {
+		var err error
+		interval, err = time.ParseDuration(rs.Interval)
+		if err != nil || interval < time.Second {
+			return nil, fmt.Errorf("invalid rate limit interval %q, it must be a duration of a second or more", rs.Interval)
+		}
+	}
+
+	tenant := rs.Tenant
+	if tenant == "" {
+		tenant = tenantHeader(settings)
+	}
+
+	sum := sha256.Sum256([]byte(strings.TrimRight(settings.URL, "/") + "\x00" + tenant))
+	return &tenantRateLimiter{
+		store:    store,
+		log:      log.New("tsdb.tempo").New("datasource", settings.UID),
+		now:      time.Now,
+		budget:   hex.EncodeToString(sum[:8]),
+		tenant:   tenant,
+		limit:    rs.Queries,
+		interval: interval,
+	}, nil
+}
+
+// tenantHeader returns the value of the X-Scope-OrgID header set in the custom headers of the datasource
+func tenantHeader(settings backend.DataSourceInstanceSettings) string {
+	var jd map[string]interface{}
+	if err := json.Unmarshal(settings.JSONData, &jd); err != nil {
+		return ""
+	}
+	for key, value := range jd {
+		name, ok := value.(string)
+		if !ok || !strings.HasPrefix(key, "httpHeaderName") || !strings.EqualFold(name, headerTenant) {
+			continue
+		}
+		return settings.DecryptedSecureJSONData["httpHeaderValue"+strings.TrimPrefix(key, "httpHeaderName")]
+	}
+	return ""
+}
+
+// allow counts a query in the budget of the tenant, it returns a rateLimitedError when the budget of the current
+// window is spent
+func (l *tenantRateLimiter) allow(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	now := l.now()
+	window := now.Truncate(l.interval)
+
+	allowed := false
+	if l.store != nil {
+		var err error
+		allowed, err = l.allowShared(ctx, window)
+		if err != nil {
+			l.log.Warn("Failed to count the queries to Tempo in the database, the queries are limited by instance", "error", err)
+			allowed = l.allowLocal(window)
+		}
+	} else {
+		allowed = l.allowLocal(window)
+	}
+	if allowed {
+		return nil
+	}
+	return rateLimitedError{
+		tenant:     l.tenant,
+		limit:      l.limit,
+		interval:   l.interval,
+		retryAfter: window.Add(l.interval).Sub(now),
+	}
+}
+
+func (l *tenantRateLimiter) windowPrefix(window time.Time) string {
+	return fmt.Sprintf("%s:%d:", l.budget, window.Unix())
+}
+
+// allowShared stores the query before counting the queries of the window, and removes it when the budget is exceeded.
+// The queries racing for the last part of the budget can all be rejected, but the budget is never exceeded.
+func (l *tenantRateLimiter) allowShared(ctx context.Context, window time.Time) (bool, error) {
+	l.removeExpiredKeys(ctx, window)
+
+	prefix := l.windowPrefix(window)
+	key := prefix + util.GenerateShortUID()
+	if err := l.store.Set(ctx, key, "1"); err != nil {
+		return false, err
+	}
+
+	keys, err := l.store.Keys(ctx, prefix)
+	if err == nil && len(keys) <= l.limit {
+		l.mu.Lock()
+		l.keys = append(l.keys, rateLimitKey{key: key, window: window})
+		l.mu.Unlock()
+		return true, nil
+	}
+	if delErr := l.store.Del(ctx, key); delErr != nil {
+		l.log.Debug("Failed to remove a rejected query from the database", "error", delErr)
+	}
+	return false, err
+}
+
+// removeExpiredKeys removes the keys stored by this instance in the previous windows. In the first query of a window
+// it also removes the keys of the previous windows left by the other instances, e.g. by an instance that was stopped.
+func (l *tenantRateLimiter) removeExpiredKeys(ctx context.Context, window time.Time) {
+	l.mu.Lock()
+	i := 0
+	for i < len(l.keys) && l.keys[i].window.Before(window) {
+		i++
+	}
+	expired := make([]string, 0, i)
+	for _, k := range l.keys[:i] {
+		expired = append(expired, k.key)
+	}
+	l.keys = l.keys[i:]
+	sweep := l.sweptWindow.Before(window)
+	if sweep {
+		l.sweptWindow = window
+	}
+	l.mu.Unlock()
+
+	if sweep {
+		keys, err := l.store.Keys(ctx, l.budget+":")
+		if err != nil {
+			l.log.Debug("Failed to list the queries in the database", "error", err)
+		}
+		current := l.windowPrefix(window)
+		for _, k := range keys {
+			if !strings.HasPrefix(k.Key, current) {
+				expired = append(expired, k.Key)
+			}
+		}
+	}
+
+	for _, key := range expired {
+		if err := l.store.Del(ctx, key); err != nil {
+			l.log.Debug("Failed to remove an expired query from the database", "error", err)
+		}
+	}
+}
+
+func (l *tenantRateLimiter) allowLocal(window time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.localWindow.Equal(window) {
+		l.localWindow = window
+		l.localCount = 0
+	}
+	if l.localCount >= l.limit {
+		return false
+	}
+	l.localCount++
+	return true
+}
@@ -0,0 +1,157 @@
+package tempo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+)
+
+type fakeRateLimitStore struct {
+	mu   sync.Mutex
+	keys map[string]string
+	err  error
+}
+
+func newFakeRateLimitStore() *fakeRateLimitStore {
+	return &fakeRateLimitStore{keys: map[string]string{}}
+}
+
+func (s *fakeRateLimitStore) Set(_ context.Context, key string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.keys[key] = value
+	return nil
+}
+
+func (s *fakeRateLimitStore) Del(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, key)
+	return nil
+}
+
+func (s *fakeRateLimitStore) Keys(_ context.Context, keyPrefix string) ([]kvstore.Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	var keys []kvstore.Key
+	for k := range s.keys {
+		if strings.HasPrefix(k, keyPrefix) {
+			keys = append(keys, kvstore.Key{Key: k})
+		}
+	}
+	return keys, nil
+}
+
+func (s *fakeRateLimitStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
+
+func newTestRateLimiter(t *testing.T, url string, store rateLimitStore, now *time.Time) *tenantRateLimiter {
+	t.Helper()
+	l, err := newTenantRateLimiter(backend.DataSourceInstanceSettings{URL: url}, rateLimitSettings{Queries: 2, Tenant: "team-a"}, store)
+	require.NoError(t, err)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestTenantRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("the instances share the budget of the tenant", func(t *testing.T) {
+		now := start
+		store := newFakeRateLimitStore()
+		a := newTestRateLimiter(t, "http://tempo", store, &now)
+		b := newTestRateLimiter(t, "http://tempo/", store, &now)
+		other := newTestRateLimiter(t, "http://other-tempo", store, &now)
+
+		require.NoError(t, a.allow(ctx))
+		require.NoError(t, b.allow(ctx))
+		err := a.allow(ctx)
+		var rateLimited rateLimitedError
+		require.ErrorAs(t, err, &rateLimited)
+		assert.Equal(t, `the Tempo tenant "team-a" exceeded its budget of 2 queries per 1m0s, retry in 1m0s`, err.Error())
+		assert.Error(t, b.allow(ctx))
+		// the rejected queries are not stored
+		assert.Equal(t, 2, store.len())
+
+		// another Tempo has its own budget
+		require.NoError(t, other.allow(ctx))
+
+		now = now.Add(time.Minute)
+		require.NoError(t, a.allow(ctx))
+		require.NoError(t, b.allow(ctx))
+		assert.Error(t, a.allow(ctx))
+		// the queries of the previous window were removed
+		assert.Equal(t, 3, store.len())
+	})
+
+	t.Run("the queries of the stopped instances are removed", func(t *testing.T) {
+		now := start
+		store := newFakeRateLimitStore()
+		stopped := newTestRateLimiter(t, "http://tempo", store, &now)
+		require.NoError(t, stopped.allow(ctx))
+		require.NoError(t, stopped.allow(ctx))
+
+		now = now.Add(time.Minute)
+		l := newTestRateLimiter(t, "http://tempo", store, &now)
+		require.NoError(t, l.allow(ctx))
+		assert.Equal(t, 1, store.len())
+	})
+
+	t.Run("the queries are limited by instance when the store fails", func(t *testing.T) {
+		now := start
+		store := newFakeRateLimitStore()
+		store.err = errors.New("database is locked")
+		l := newTestRateLimiter(t, "http://tempo", store, &now)
+
+		require.NoError(t, l.allow(ctx))
+		require.NoError(t, l.allow(ctx))
+		assert.Error(t, l.allow(ctx))
+
+		now = now.Add(time.Minute)
+		require.NoError(t, l.allow(ctx))
+	})
+
+	t.Run("the limiter is disabled without a budget", func(t *testing.T) {
+		l, err := newTenantRateLimiter(backend.DataSourceInstanceSettings{}, rateLimitSettings{}, nil)
+		require.NoError(t, err)
+		require.Nil(t, l)
+		require.NoError(t, l.allow(ctx))
+	})
+
+	t.Run("the interval must be a second or more", func(t *testing.T) {
+		_, err := newTenantRateLimiter(backend.DataSourceInstanceSettings{}, rateLimitSettings{Queries: 1, Interval: "10ms"}, nil)
+		require.Error(t, err)
+		_, err = newTenantRateLimiter(backend.DataSourceInstanceSettings{}, rateLimitSettings{Queries: 1, Interval: "one minute"}, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestTenantHeader(t *testing.T) {
+	settings := backend.DataSourceInstanceSettings{
+		JSONData:                []byte(`{"httpHeaderName1":"X-Custom","httpHeaderName2":"x-scope-orgid"}`),
+		DecryptedSecureJSONData: map[string]string{"httpHeaderValue1": "custom", "httpHeaderValue2": "team-b"},
+	}
+	assert.Equal(t, "team-b", tenantHeader(settings))
+
+	settings.JSONData = []byte(`{"httpHeaderName1":"X-Custom"}`)
+	assert.Equal(t, "", tenantHeader(settings))
+}
@@ -0,0 +1,140 @@
+package tempo
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.opentelemetry.io/collector/model/pdata"
+)
+
+const (
+	redactionActionMask = "mask"
+	redactionActionDrop = "drop"
+	// redactedValue replaces the values of the masked attributes
+	redactedValue = "[REDACTED]"
+)
+
+// redactionRuleSettings is a redaction rule of the data source, read from the redactionRules list of jsonData. The
+// pattern is a regular expression matched against the attribute names.
+type redactionRuleSettings struct {
+	Pattern string `json:"pattern"`
+	Action  string `json:"action"`
+}
+
+type redactionRule struct {
+	pattern *regexp.Regexp
+	drop    bool
+}
+
+// attributeRedactor masks or drops the span attributes whose name matches a redaction rule of the data source, so
+// that the personal data the attributes hold doesn't leave the Grafana server.
+type attributeRedactor struct {
+	rules []redactionRule
+}
+
+// newAttributeRedactor returns nil when the data source has no redaction rule, so that the traces are not walked
+func newAttributeRedactor(settings []redactionRuleSettings) (*attributeRedactor, error) {
+	if len(settings) == 0 {
+		return nil, nil
+	}
+	r := &attributeRedactor{}
+	for i, rule := range settings {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of redaction rule %d: %w", i+1, err)
+		}
+		switch rule.Action {
+		case redactionActionMask, "":
+			r.rules = append(r.rules, redactionRule{pattern: pattern})
+		case redactionActionDrop:
+			r.rules = append(r.rules, redactionRule{pattern: pattern, drop: true})
+		default:
+			return nil, fmt.Errorf("invalid action %q of redaction rule %d, expected mask or drop", rule.Action, i+1)
+		}
+	}
+	return r, nil
+}
+
+// match returns whether the attribute is redacted and whether it is dropped, the first matching rule applies
+func (r *attributeRedactor) match(key string) (bool, bool) {
+	for _, rule := range r.rules {
+		if rule.pattern.MatchString(key) {
+			return true, rule.drop
+		}
+	}
+	return false, false
+}
+
+// redactTrace redacts the attributes of the resources, the spans, the span events and the span links of the trace
+func (r *attributeRedactor) redactTrace(td pdata.Traces) {
+	if r == nil {
+		return
+	}
+	resourceSpans := td.ResourceSpans()
+	for i := 0; i < resourceSpans.Len(); i++ {
+		rs := resourceSpans.At(i)
+		r.redactAttributes(rs.Resource().Attributes())
+		ilss := rs.InstrumentationLibrarySpans()
+		for j := 0; j < ilss.Len(); j++ {
+			spans := ilss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+				r.redactAttributes(span.Attributes())
+				events := span.Events()
+				for e := 0; e < events.Len(); e++ {
+					r.redactAttributes(events.At(e).Attributes())
+				}
+				links := span.Links()
+				for l := 0; l < links.Len(); l++ {
+					r.redactAttributes(links.At(l).Attributes())
+				}
+			}
+		}
+	}
+}
+
+func (r *attributeRedactor) redactAttributes(attrs pdata.AttributeMap) {
+	var masked, dropped []string
+	attrs.Range(func(key string, _ pdata.AttributeValue) bool {
+		if redacted, drop := r.match(key); drop {
+			dropped = append(dropped, key)
+		} else if redacted {
+			masked = append(masked, key)
+		}
+		return true
+	})
+	// the map can't be changed while it is ranged over
+	for _, key := range masked {
+		attrs.UpsertString(key, redactedValue)
+	}
+	for _, key := range dropped {
+		attrs.Delete(key)
+	}
+}
+
+// redactSearch redacts the attributes of the spans of the search results, which are read by the queries returning
+// the values of the attributes, such as the attribute statistics
+func (r *attributeRedactor) redactSearch(resp *SearchResponse) {
+	if r == nil || resp == nil {
+		return
+	}
+	masked := redactedValue
+	for _, trace := range resp.Traces {
+		for _, spanSet := range trace.AllSpanSets() {
+			for _, span := range spanSet.Spans {
+				attributes := span.Attributes[:0]
+				for _, attr := range span.Attributes {
+					redacted, drop := r.match(attr.Key)
+					if drop {
+						continue
+					}
+					if redacted {
+						attr = &SearchAttribute{Key: attr.Key, Value: SearchAttributeValue{StringValue: &masked}}
+					}
+					attributes = append(attributes, attr)
+				}
+				span.Attributes = attributes
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package tempo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/model/pdata"
+)
+
+func TestAttributeRedactor(t *testing.T) {
+	redactor, err := newAttributeRedactor([]redactionRuleSettings{
+		{Pattern: `^user\.(email|name)$`, Action: "mask"},
+		{Pattern: `password|token`, Action: "drop"},
+		{Pattern: `^client\.address$`},
+	})
+	require.NoError(t, err)
+
+	t.Run("redacts the attributes of the traces before they are transformed", func(t *testing.T) {
+		td := pdata.NewTraces()
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().InsertString("service.name", "checkout")
+		rs.Resource().Attributes().InsertString("user.name", "jane")
+		span := rs.InstrumentationLibrarySpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetName("POST /login")
+		span.SetTraceID(pdata.NewTraceID([16]byte{1}))
+		span.SetSpanID(pdata.NewSpanID([8]byte{1}))
+		span.Attributes().InsertString("user.email", "jane@example.com")
+		span.Attributes().InsertString("db.password", "secret")
+		span.Attributes().InsertInt("client.address", 42)
+		span.Attributes().InsertString("http.method", "POST")
+		event := span.Events().AppendEmpty()
+		event.Attributes().InsertString("auth.token", "abc")
+		event.Attributes().InsertString("user.email", "jane@example.com")
+		link := span.Links().AppendEmpty()
+		link.Attributes().InsertString("user.name", "joe")
+
+		redactor.redactTrace(td)
+		frame, err := TraceToFrame(td)
+		require.NoError(t, err)
+		require.Equal(t, 1, frame.Rows())
+
+		bFrame := &BetterFrame{frame}
+		row := bFrame.GetRow(0)
+		assert.Equal(t, "checkout", row["serviceName"])
+		assert.Equal(t, map[string]interface{}{"service.name": "checkout", "user.name": redactedValue}, keyValues(t, row["serviceTags"]))
+		assert.Equal(t, map[string]interface{}{
+			"user.email":     redactedValue,
+			"client.address": redactedValue,
+			"http.method":    "POST",
+			"status.code":    float64(0),
+		}, keyValues(t, row["tags"]))
+
+		var logs []TraceLog
+		require.NoError(t, json.Unmarshal(row["logs"].(json.RawMessage), &logs))
+		require.Len(t, logs, 1)
+		require.Len(t, logs[0].Fields, 1)
+		assert.Equal(t, KeyValue{Key: "user.email", Value: redactedValue}, *logs[0].Fields[0])
+
+		var references []TraceReference
+		require.NoError(t, json.Unmarshal(row["references"].(json.RawMessage), &references))
+		require.Len(t, references, 1)
+		assert.Equal(t, []*KeyValue{{Key: "user.name", Value: redactedValue}}, references[0].Tags)
+	})
+
+	t.Run("redacts the attributes of the search results", func(t *testing.T) {
+		email, method := "jane@example.com", "GET"
+		resp := &SearchResponse{Traces: []*TraceSearchMetadata{{
+			TraceID: "1",
+			SpanSets: []*SpanSet{{Spans: []*SearchSpan{{Attributes: []*SearchAttribute{
+				{Key: "user.email", Value: SearchAttributeValue{StringValue: &email}},
+				{Key: "api.token", Value: SearchAttributeValue{StringValue: &email}},
+				{Key: "http.method", Value: SearchAttributeValue{StringValue: &method}},
+			}}}}},
+		}}}
+
+		redactor.redactSearch(resp)
+		span := resp.Traces[0].SpanSets[0].Spans[0]
+		require.Len(t, span.Attributes, 2)
+		value, ok := span.Attribute("user.email")
+		assert.True(t, ok)
+		assert.Equal(t, redactedValue, value)
+		_, ok = span.Attribute("api.token")
+		assert.False(t, ok)
+		value, _ = span.Attribute("http.method")
+		assert.Equal(t, "GET", value)
+		// the values of the search response are not changed in place
+		assert.Equal(t, "jane@example.com", email)
+	})
+
+	t.Run("the data sources without rules don't redact", func(t *testing.T) {
+		redactor, err := newAttributeRedactor(nil)
+		require.NoError(t, err)
+		assert.Nil(t, redactor)
+		redactor.redactTrace(pdata.NewTraces())
+		redactor.redactSearch(&SearchResponse{})
+	})
+
+	t.Run("invalid rules are rejected", func(t *testing.T) {
+		_, err := newAttributeRedactor([]redactionRuleSettings{{Pattern: `(`}})
+		require.ErrorContains(t, err, "invalid pattern of redaction rule 1")
+		_, err = newAttributeRedactor([]redactionRuleSettings{{Pattern: `email`, Action: "hash"}})
+		require.EqualError(t, err, `invalid action "hash" of redaction rule 1, expected mask or drop`)
+	})
+}
+
+func keyValues(t *testing.T, raw interface{}) map[string]interface{} {
+	t.Helper()
+	var kvs []KeyValue
+	require.NoError(t, json.Unmarshal(raw.(json.RawMessage), &kvs))
+	m := map[string]interface{}{}
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
@@ -0,0 +1,186 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SearchResponse is the body returned by Tempo's /api/search endpoint.
+type SearchResponse struct {
+	Traces  []*TraceSearchMetadata `json:"traces"`
+	Metrics *SearchMetrics         `json:"metrics,omitempty"`
+}
+
+// SearchMetrics describes the blocks of the time range of a search and what the search read of them
+type SearchMetrics struct {
+	InspectedBytes  searchMetricValue `json:"inspectedBytes"`
+	TotalBlocks     searchMetricValue `json:"totalBlocks"`
+	TotalBlockBytes searchMetricValue `json:"totalBlockBytes"`
+}
+
+// searchMetricValue is a counter of the search metrics, Tempo encodes the 64 bits integers as strings
+type searchMetricValue int64
+
+func (v *searchMetricValue) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "" || raw == "null" {
+		*v = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid search metric: %s", string(data))
+	}
+	*v = searchMetricValue(n)
+	return nil
+}
+
+type TraceSearchMetadata struct {
+	TraceID           string   `json:"traceID"`
+	RootServiceName   string   `json:"rootServiceName"`
+	RootTraceName     string   `json:"rootTraceName"`
+	StartTimeUnixNano string   `json:"startTimeUnixNano"`
+	DurationMs        int64    `json:"durationMs"`
+	SpanSet           *SpanSet `json:"spanSet,omitempty"`
+	// SpanSets is only returned by Tempo versions that support multiple span sets per trace.
+	SpanSets []*SpanSet `json:"spanSets,omitempty"`
+}
+
+// AllSpanSets returns the span sets of the trace regardless of which of the two response shapes Tempo used.
+func (t *TraceSearchMetadata) AllSpanSets() []*SpanSet {
+	if len(t.SpanSets) > 0 {
+		return t.SpanSets
+	}
+	if t.SpanSet != nil {
+		return []*SpanSet{t.SpanSet}
+	}
+	return nil
+}
+
+type SpanSet struct {
+	Spans   []*SearchSpan `json:"spans"`
+	Matched int           `json:"matched"`
+}
+
+type SearchSpan struct {
+	SpanID            string             `json:"spanID"`
+	Name              string             `json:"name"`
+	StartTimeUnixNano string             `json:"startTimeUnixNano"`
+	DurationNanos     string             `json:"durationNanos"`
+	Attributes        []*SearchAttribute `json:"attributes"`
+}
+
+// Attribute returns the string representation of the attribute with the given key, if present on the span.
+func (s *SearchSpan) Attribute(key string) (string, bool) {
+	for _, attr := range s.Attributes {
+		if attr.Key == key {
+			return attr.Value.String(), true
+		}
+	}
+	return "", false
+}
+
+type SearchAttribute struct {
+	Key   string               `json:"key"`
+	Value SearchAttributeValue `json:"value"`
+}
+
+// SearchAttributeValue follows the OTLP JSON encoding of an attribute value, where only one of the fields is set.
+type SearchAttributeValue struct {
+	StringValue *string      `json:"stringValue,omitempty"`
+	IntValue    *json.Number `json:"intValue,omitempty"`
+	DoubleValue *json.Number `json:"doubleValue,omitempty"`
+	BoolValue   *bool        `json:"boolValue,omitempty"`
+}
+
+func (v SearchAttributeValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.IntValue != nil:
+		return v.IntValue.String()
+	case v.DoubleValue != nil:
+		return v.DoubleValue.String()
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	default:
+		return ""
+	}
+}
+
+// searchTraces runs a TraceQL query against Tempo's search API for the given time range in unix seconds. The time
+// ranges longer than the partition duration of the data source are split in several searches.
+func (s *Service) searchTraces(ctx context.Context, dsInfo *datasourceInfo, query string, limit int64, start int64, end int64) (*SearchResponse, error) {
+	if err := s.checkCost(ctx, dsInfo, start, end); err != nil {
+		return nil, err
+	}
+	if dsInfo.partitions == nil || start <= 0 || end <= 0 {
+		return s.searchTracesRange(ctx, dsInfo, query, limit, start, end)
+	}
+	partitions := searchPartitions(start, end, s.partitionDuration(ctx, dsInfo))
+	if len(partitions) == 1 {
+		return s.searchTracesRange(ctx, dsInfo, query, limit, start, end)
+	}
+	s.tlog.FromContext(ctx).Debug("Splitting Tempo search", "partitions", len(partitions))
+	return s.searchTracesPartitioned(ctx, dsInfo, query, limit, partitions)
+}
+
+// searchTracesRange runs a TraceQL query against Tempo's search API with a single request.
+func (s *Service) searchTracesRange(ctx context.Context, dsInfo *datasourceInfo, query string, limit int64, start int64, end int64) (*SearchResponse, error) {
+	params := searchParams(query, limit, start, end)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/search?%s", dsInfo.URL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	s.tlog.FromContext(ctx).Debug("Tempo search request", "url", req.URL.String())
+
+	resp, err := dsInfo.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tempo: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.tlog.FromContext(ctx).Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to search traces with query: %s Status: %s Body: %s", query, resp.Status, string(body))
+	}
+
+	result := &SearchResponse{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("failed to parse tempo search response: %w", err)
+	}
+
+	dsInfo.redactor.redactSearch(result)
+	return result, nil
+}
+
+// searchParams are the parameters of a search of Tempo, the time range in unix seconds is left out when unset
+func searchParams(query string, limit int64, start int64, end int64) url.Values {
+	params := url.Values{}
+	params.Set("q", query)
+	if limit > 0 {
+		params.Set("limit", strconv.FormatInt(limit, 10))
+	}
+	if start > 0 && end > 0 {
+		params.Set("start", strconv.FormatInt(start, 10))
+		params.Set("end", strconv.FormatInt(end, 10))
+	}
+	return params
+}
@@ -0,0 +1,208 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"golang.org/x/sync/errgroup"
+)
+
+// searchSettings are the options of the searches, read from the search object of jsonData.
+type searchSettings struct {
+	// PartitionDuration is the longest time range searched with a single request, the longer searches are split in
+	// several requests. The maximum search duration of the tenant is used when it isn't set.
+	PartitionDuration string `json:"partitionDuration"`
+	// MaxConcurrentPartitions is the number of partitions of a search sent to Tempo at the same time, the partitions
+	// are searched one after the other by default.
+	MaxConcurrentPartitions int `json:"maxConcurrentPartitions"`
+	// MaxBytesToScan rejects the searches whose estimated cost is higher, the searches are not rejected when it
+	// isn't set.
+	MaxBytesToScan int64 `json:"maxBytesToScan"`
+	// DedicatedColumns are the attributes with a dedicated column in the blocks of Tempo, the queries are rewritten
+	// to use them and warn about the filters on the other attributes.
+	DedicatedColumns []dedicatedColumn `json:"dedicatedColumns"`
+	// PromotedAttributes are the attributes shown in their own column of the search results.
+	PromotedAttributes []promotedAttribute `json:"promotedAttributes"`
+}
+
+// searchPartitioner splits the searches over long time ranges, so that Tempo doesn't reject them for exceeding the
+// maximum search duration of the tenant.
+type searchPartitioner struct {
+	duration    time.Duration
+	concurrency int
+
+	// the maximum search duration of the tenant is fetched with the first search needing it
+	mu             sync.Mutex
+	tenantFetched  bool
+	tenantDuration time.Duration
+}
+
+func newSearchPartitioner(settings searchSettings) (*searchPartitioner, error) {
+	p := &searchPartitioner{concurrency: settings.MaxConcurrentPartitions}
+	if p.concurrency < 1 {
+		p.concurrency = 1
+	}
+	if settings.PartitionDuration != "" {
+		d, err := gtime.ParseDuration(settings.PartitionDuration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid partition duration: %w", err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("invalid partition duration: %s is shorter than a second", settings.PartitionDuration)
+		}
+		p.duration = d
+	}
+	return p, nil
+}
+
+// partitionDuration returns the longest time range of a search request, 0 when the searches are not split
+func (s *Service) partitionDuration(ctx context.Context, dsInfo *datasourceInfo) time.Duration {
+	p := dsInfo.partitions
+	if p.duration > 0 {
+		return p.duration
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.tenantFetched {
+		limits, err := s.getLimits(ctx, dsInfo)
+		if err != nil {
+			// the searches are not split until the limits can be fetched
+			s.tlog.FromContext(ctx).Warn("Failed to get the maximum search duration", "err", err)
+			return 0
+		}
+		p.tenantFetched = true
+		p.tenantDuration = time.Duration(limits.MaxSearchDurationMs) * time.Millisecond
+	}
+	return p.tenantDuration
+}
+
+// searchPartition is a time range in unix seconds
+type searchPartition struct {
+	start int64
+	end   int64
+}
+
+// searchPartitions splits a time range in unix seconds in partitions of the given duration, the most recent
+// partition first.
+func searchPartitions(start int64, end int64, duration time.Duration) []searchPartition {
+	size := int64(duration / time.Second)
+	if start <= 0 || end <= start || size <= 0 || end-start <= size {
+		return []searchPartition{{start: start, end: end}}
+	}
+
+	var partitions []searchPartition
+	for e := end; e > start; e -= size {
+		s := e - size
+		if s < start {
+			s = start
+		}
+		partitions = append(partitions, searchPartition{start: s, end: e})
+	}
+	return partitions
+}
+
+// searchTracesPartitioned runs a search over each partition of the time range, one after the other or concurrently
+// when the data source allows it, and merges the results of the partitions as they are searched. The search stops
+// as soon as the limit is reached when the partitions are searched one after the other.
+func (s *Service) searchTracesPartitioned(ctx context.Context, dsInfo *datasourceInfo, query string, limit int64, partitions []searchPartition) (*SearchResponse, error) {
+	merged := newSearchMerger()
+
+	if dsInfo.partitions.concurrency <= 1 {
+		for _, p := range partitions {
+			resp, err := s.searchTracesRange(ctx, dsInfo, query, limit, p.start, p.end)
+			if err != nil {
+				return nil, err
+			}
+			merged.add(resp)
+			if limit > 0 && int64(len(merged.resp.Traces)) >= limit {
+				break
+			}
+		}
+		return merged.result(limit), nil
+	}
+
+	results := make([]*SearchResponse, len(partitions))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(dsInfo.partitions.concurrency)
+	for i, p := range partitions {
+		i, p := i, p
+		g.Go(func() error {
+			resp, err := s.searchTracesRange(gctx, dsInfo, query, limit, p.start, p.end)
+			if err != nil {
+				return err
+			}
+			results[i] = resp
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	// the results are merged in the order of the partitions, so that the most recent traces are kept
+	for _, resp := range results {
+		merged.add(resp)
+	}
+	return merged.result(limit), nil
+}
+
+// searchMerger merges the results of the partitions of a search. The traces crossing the boundary of two partitions
+// are returned by both, their span sets are merged without the spans returned twice.
+type searchMerger struct {
+	resp   *SearchResponse
+	traces map[string]*TraceSearchMetadata
+	spans  map[string]map[string]struct{}
+}
+
+func newSearchMerger() *searchMerger {
+	return &searchMerger{
+		resp:   &SearchResponse{},
+		traces: map[string]*TraceSearchMetadata{},
+		spans:  map[string]map[string]struct{}{},
+	}
+}
+
+func (m *searchMerger) add(resp *SearchResponse) {
+	for _, trace := range resp.Traces {
+		existing, ok := m.traces[trace.TraceID]
+		if !ok {
+			m.traces[trace.TraceID] = trace
+			m.spans[trace.TraceID] = map[string]struct{}{}
+			for _, spanSet := range trace.AllSpanSets() {
+				for _, span := range spanSet.Spans {
+					m.spans[trace.TraceID][span.SpanID] = struct{}{}
+				}
+			}
+			m.resp.Traces = append(m.resp.Traces, trace)
+			continue
+		}
+
+		if trace.DurationMs > existing.DurationMs {
+			existing.DurationMs = trace.DurationMs
+		}
+		for _, spanSet := range trace.AllSpanSets() {
+			var spans []*SearchSpan
+			for _, span := range spanSet.Spans {
+				if _, seen := m.spans[trace.TraceID][span.SpanID]; seen {
+					continue
+				}
+				m.spans[trace.TraceID][span.SpanID] = struct{}{}
+				spans = append(spans, span)
+			}
+			if len(spans) == 0 {
+				continue
+			}
+			existing.SpanSets = append(existing.AllSpanSets(), &SpanSet{Spans: spans, Matched: len(spans)})
+		}
+	}
+}
+
+func (m *searchMerger) result(limit int64) *SearchResponse {
+	if limit > 0 && int64(len(m.resp.Traces)) > limit {
+		m.resp.Traces = m.resp.Traces[:limit]
+	}
+	return m.resp
+}
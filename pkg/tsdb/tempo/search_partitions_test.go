@@ -0,0 +1,178 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestSearchPartitions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		start    int64
+		end      int64
+		duration time.Duration
+		expected []searchPartition
+	}{
+		{
+			name:     "short ranges are not split",
+			start:    1000,
+			end:      4600,
+			duration: time.Hour,
+			expected: []searchPartition{{start: 1000, end: 4600}},
+		},
+		{
+			name:     "ranges are not split without duration",
+			start:    1000,
+			end:      100000,
+			expected: []searchPartition{{start: 1000, end: 100000}},
+		},
+		{
+			name:     "long ranges are split from the most recent partition",
+			start:    1000,
+			end:      9000,
+			duration: time.Hour,
+			expected: []searchPartition{{start: 5400, end: 9000}, {start: 1800, end: 5400}, {start: 1000, end: 1800}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, searchPartitions(tc.start, tc.end, tc.duration))
+		})
+	}
+}
+
+func TestNewSearchPartitioner(t *testing.T) {
+	p, err := newSearchPartitioner(searchSettings{PartitionDuration: "1d", MaxConcurrentPartitions: 4})
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, p.duration)
+	assert.Equal(t, 4, p.concurrency)
+
+	p, err = newSearchPartitioner(searchSettings{})
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), p.duration)
+	assert.Equal(t, 1, p.concurrency)
+
+	_, err = newSearchPartitioner(searchSettings{PartitionDuration: "a day"})
+	require.ErrorContains(t, err, "invalid partition duration")
+	_, err = newSearchPartitioner(searchSettings{PartitionDuration: "10ms"})
+	require.EqualError(t, err, "invalid partition duration: 10ms is shorter than a second")
+}
+
+func TestSearchTracesPartitioned(t *testing.T) {
+	// the trace t2 crosses the boundary of the partitions, its span s2 is returned by both
+	responses := map[string]string{
+		"5400-9000": `{"traces": [{"traceID": "t1", "spanSets": [{"spans": [{"spanID": "s1"}], "matched": 1}]}, {"traceID": "t2", "durationMs": 10, "spanSet": {"spans": [{"spanID": "s2"}], "matched": 1}}]}`,
+		"1800-5400": `{"traces": [{"traceID": "t2", "durationMs": 20, "spanSet": {"spans": [{"spanID": "s2"}, {"spanID": "s3"}], "matched": 2}}, {"traceID": "t3"}]}`,
+		"1000-1800": `{"traces": [{"traceID": "t4"}]}`,
+	}
+
+	newServer := func(t *testing.T) (*httptest.Server, func() []string) {
+		var mu sync.Mutex
+		var ranges []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/overrides":
+				_, _ = w.Write([]byte(`{"read": {"max_search_duration": "1h"}}`))
+			case "/api/search":
+				key := fmt.Sprintf("%s-%s", r.URL.Query().Get("start"), r.URL.Query().Get("end"))
+				mu.Lock()
+				ranges = append(ranges, key)
+				mu.Unlock()
+				_, _ = w.Write([]byte(responses[key]))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(srv.Close)
+		return srv, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return ranges
+		}
+	}
+
+	traceIDs := func(resp *SearchResponse) []string {
+		var ids []string
+		for _, trace := range resp.Traces {
+			ids = append(ids, trace.TraceID)
+		}
+		return ids
+	}
+
+	service := &Service{tlog: log.New("tempo-test")}
+
+	t.Run("the partitions are searched one after the other and merged", func(t *testing.T) {
+		srv, ranges := newServer(t)
+		dsInfo := &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL, partitions: &searchPartitioner{duration: time.Hour, concurrency: 1}}
+
+		resp, err := service.searchTraces(context.Background(), dsInfo, "{}", 20, 1000, 9000)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"5400-9000", "1800-5400", "1000-1800"}, ranges())
+		assert.Equal(t, []string{"t1", "t2", "t3", "t4"}, traceIDs(resp))
+
+		t2 := resp.Traces[1]
+		assert.Equal(t, int64(20), t2.DurationMs)
+		require.Len(t, t2.AllSpanSets(), 2)
+		assert.Equal(t, "s2", t2.AllSpanSets()[0].Spans[0].SpanID)
+		require.Len(t, t2.AllSpanSets()[1].Spans, 1)
+		assert.Equal(t, "s3", t2.AllSpanSets()[1].Spans[0].SpanID)
+	})
+
+	t.Run("the search stops when the limit is reached", func(t *testing.T) {
+		srv, ranges := newServer(t)
+		dsInfo := &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL, partitions: &searchPartitioner{duration: time.Hour, concurrency: 1}}
+
+		resp, err := service.searchTraces(context.Background(), dsInfo, "{}", 3, 1000, 9000)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"5400-9000", "1800-5400"}, ranges())
+		assert.Equal(t, []string{"t1", "t2", "t3"}, traceIDs(resp))
+	})
+
+	t.Run("the partitions are searched concurrently and merged in order", func(t *testing.T) {
+		srv, ranges := newServer(t)
+		dsInfo := &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL, partitions: &searchPartitioner{duration: time.Hour, concurrency: 3}}
+
+		resp, err := service.searchTraces(context.Background(), dsInfo, "{}", 3, 1000, 9000)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"5400-9000", "1800-5400", "1000-1800"}, ranges())
+		assert.Equal(t, []string{"t1", "t2", "t3"}, traceIDs(resp))
+	})
+
+	t.Run("the maximum search duration of the tenant is used without partition duration", func(t *testing.T) {
+		srv, ranges := newServer(t)
+		dsInfo := &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL, partitions: &searchPartitioner{concurrency: 1}}
+
+		_, err := service.searchTraces(context.Background(), dsInfo, "{}", 20, 1000, 9000)
+		require.NoError(t, err)
+		assert.Len(t, ranges(), 3)
+		assert.True(t, dsInfo.partitions.tenantFetched)
+		assert.Equal(t, time.Hour, dsInfo.partitions.tenantDuration)
+	})
+
+	t.Run("the search fails when a partition fails", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("start") == "1800" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte("range specified by start and end exceeds 168h0m0s"))
+				return
+			}
+			_, _ = w.Write([]byte(`{"traces": []}`))
+		}))
+		t.Cleanup(srv.Close)
+		dsInfo := &datasourceInfo{HTTPClient: &http.Client{Timeout: time.Second}, URL: srv.URL, partitions: &searchPartitioner{duration: time.Hour, concurrency: 2}}
+
+		_, err := service.searchTraces(context.Background(), dsInfo, "{}", 20, 1000, 9000)
+		require.ErrorContains(t, err, "exceeds 168h0m0s")
+	})
+}
@@ -0,0 +1,446 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+const (
+	// serviceGraphSearchLimit is the number of traces sampled from Tempo when the query has no limit set.
+	serviceGraphSearchLimit = 500
+	// defaultMaxSpanNamesPerService caps the span names of a service when the data source sets no cap, the other
+	// span names of the service are grouped in a single node.
+	defaultMaxSpanNamesPerService = 50
+	// otherSpanName is the span name of the node grouping the span names above the cap of a service.
+	otherSpanName = "other"
+	// parentSpanIDAttribute is the attribute of the spans selected for the parent span ID intrinsic
+	parentSpanIDAttribute = "span:parentID"
+)
+
+// serviceGraphSettings are the span name normalization options of the data source, read from the serviceGraph object
+// of jsonData.
+type serviceGraphSettings struct {
+	SpanNameRules []spanNameRuleSettings `json:"spanNameRules"`
+	// MaxSpanNamesPerService is the number of span names kept for each service, 0 uses the default and a negative
+	// value disables the cap.
+	MaxSpanNamesPerService int `json:"maxSpanNamesPerService"`
+}
+
+type spanNameRuleSettings struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+type spanNameRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// spanNameNormalizer rewrites the span names of the service graph, so that the IDs embedded in the span names, such
+// as `GET /users/123`, don't create a node for each ID.
+type spanNameNormalizer struct {
+	rules         []spanNameRule
+	maxPerService int
+}
+
+func newSpanNameNormalizer(settings serviceGraphSettings) (*spanNameNormalizer, error) {
+	n := &spanNameNormalizer{maxPerService: settings.MaxSpanNamesPerService}
+	if n.maxPerService == 0 {
+		n.maxPerService = defaultMaxSpanNamesPerService
+	}
+	for i, rule := range settings.SpanNameRules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of span name rule %d: %w", i+1, err)
+		}
+		n.rules = append(n.rules, spanNameRule{pattern: pattern, replacement: rule.Replacement})
+	}
+	return n, nil
+}
+
+// normalize applies the rules in order, each rule getting the span name rewritten by the previous rules
+func (n *spanNameNormalizer) normalize(spanName string) string {
+	for _, rule := range n.rules {
+		spanName = rule.pattern.ReplaceAllString(spanName, rule.replacement)
+	}
+	return spanName
+}
+
+type serviceGraphNodeKey struct {
+	serviceName string
+	spanName    string
+}
+
+func (k serviceGraphNodeKey) id() string {
+	return k.serviceName + "/" + k.spanName
+}
+
+type serviceGraphNode struct {
+	serviceGraphNodeKey
+	spanCount     int64
+	totalDuration float64
+}
+
+type serviceGraphEdgeKey struct {
+	source serviceGraphNodeKey
+	target serviceGraphNodeKey
+}
+
+func (k serviceGraphEdgeKey) id() string {
+	return k.source.id() + "_" + k.target.id()
+}
+
+// serviceGraphData holds the nodes and edges aggregated from the spans of a time window
+type serviceGraphData struct {
+	nodes map[serviceGraphNodeKey]*serviceGraphNode
+	edges map[serviceGraphEdgeKey]int64
+}
+
+// serviceGraph samples the spans matching the TraceQL selection over the query time range and returns them as node
+// graph frames, with a node for each service and normalized span name, linked to the nodes of the spans they started.
+// With compareWith set, the spans of the same window shifted back by this offset are sampled too, and the nodes and
+// edges are annotated with their change from the previous window.
+func (s *Service) serviceGraph(ctx context.Context, dsInfo *datasourceInfo, model *dataquery.TempoQuery, query backend.DataQuery) backend.DataResponse {
+	queryRes := backend.DataResponse{}
+
+	var offset time.Duration
+	if model.CompareWith != nil && strings.TrimSpace(*model.CompareWith) != "" {
+		var err error
+		offset, err = gtime.ParseDuration(strings.TrimSpace(*model.CompareWith))
+		if err != nil || offset <= 0 {
+			queryRes.Error = fmt.Errorf("invalid compareWith offset %q, expected a positive duration such as 1h or 1d", *model.CompareWith)
+			return queryRes
+		}
+	}
+
+	traceQL := strings.TrimSpace(model.Query)
+	if traceQL == "" {
+		traceQL = generateQueryFromFilters(queryFilters(model))
+	}
+	traceQL, notices := dsInfo.rewriteTraceQL(traceQL)
+	traceQL = fmt.Sprintf("%s | select(resource.service.name, %s)", traceQL, parentSpanIDAttribute)
+
+	limit := int64(serviceGraphSearchLimit)
+	if model.Limit != nil && *model.Limit > 0 {
+		limit = *model.Limit
+	}
+
+	searchResp, err := s.searchTraces(ctx, dsInfo, traceQL, limit, query.TimeRange.From.Unix(), query.TimeRange.To.Unix())
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	// the graph of the current window is still returned when the previous window fails, without the comparison
+	var previousResp *SearchResponse
+	var failures []partFailure
+	if offset > 0 {
+		previousResp, err = s.searchTraces(ctx, dsInfo, traceQL, limit, query.TimeRange.From.Add(-offset).Unix(), query.TimeRange.To.Add(-offset).Unix())
+		if err != nil {
+			s.tlog.FromContext(ctx).Warn("Failed to search the previous window of the service graph", "offset", offset, "err", err)
+			failures = append(failures, partFailure{part: "comparison with the previous window", err: err})
+		}
+	}
+
+	// the windows with as many traces as the limit are samples of unknown sizes, their span counts can't be compared
+	withRateChange := true
+	if previousResp != nil && (int64(len(searchResp.Traces)) >= limit || int64(len(previousResp.Traces)) >= limit) {
+		withRateChange = false
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text: fmt.Sprintf("The search of a window returned the maximum of %d traces, the rate changes with the previous window are not computed. "+
+				"Increase the limit or narrow the query to compare the windows.", limit),
+		})
+	}
+
+	normalizer := dsInfo.spanNames
+	if normalizer == nil {
+		normalizer = &spanNameNormalizer{maxPerService: defaultMaxSpanNamesPerService}
+	}
+	nodes, edges := serviceGraphToFrames(searchResp, previousResp, normalizer, withRateChange)
+	nodes.RefID = query.RefID
+	edges.RefID = query.RefID
+	nodes.SetMeta(&data.FrameMeta{ExecutedQueryString: traceQL, Notices: notices, PreferredVisualization: data.VisTypeNodeGraph})
+	edges.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeNodeGraph})
+	return partialResponse(data.Frames{nodes, edges}, failures)
+}
+
+// serviceGraphToFrames returns the nodes and edges frames of the spans, previous is nil when the query is not compared
+// with a previous window. The rate changes are left empty when withRateChange is not set.
+func serviceGraphToFrames(resp *SearchResponse, previousResp *SearchResponse, normalizer *spanNameNormalizer, withRateChange bool) (*data.Frame, *data.Frame) {
+	current := aggregateServiceGraph(resp, normalizer)
+	if previousResp == nil {
+		current = current.merge(spanNameMerges(normalizer.maxPerService, current))
+		return serviceGraphNodesFrame(current, nil, false), serviceGraphEdgesFrame(current, nil, false)
+	}
+
+	// both windows are capped together, so that a span name is not kept in one window and merged in the other
+	previous := aggregateServiceGraph(previousResp, normalizer)
+	merged := spanNameMerges(normalizer.maxPerService, current, previous)
+	current, previous = current.merge(merged), previous.merge(merged)
+	return serviceGraphNodesFrame(current, previous, withRateChange), serviceGraphEdgesFrame(current, previous, withRateChange)
+}
+
+func aggregateServiceGraph(resp *SearchResponse, normalizer *spanNameNormalizer) *serviceGraphData {
+	g := &serviceGraphData{
+		nodes: map[serviceGraphNodeKey]*serviceGraphNode{},
+		edges: map[serviceGraphEdgeKey]int64{},
+	}
+	for _, trace := range resp.Traces {
+		// a span can be in several span sets of its trace, it's only counted once
+		keys := map[string]serviceGraphNodeKey{}
+		var spans []*SearchSpan
+		for _, spanSet := range trace.AllSpanSets() {
+			for _, span := range spanSet.Spans {
+				if _, ok := keys[span.SpanID]; ok {
+					continue
+				}
+				key := serviceGraphNodeKey{serviceName: trace.RootServiceName, spanName: normalizer.normalize(span.Name)}
+				if serviceName, ok := span.Attribute("service.name"); ok {
+					key.serviceName = serviceName
+				}
+				keys[span.SpanID] = key
+				spans = append(spans, span)
+			}
+		}
+
+		for _, span := range spans {
+			key := keys[span.SpanID]
+			node := g.addNode(key)
+			node.spanCount++
+			if nanos, err := strconv.ParseInt(span.DurationNanos, 10, 64); err == nil {
+				node.totalDuration += float64(nanos) / 1e6
+			}
+			// the spans are only linked to their parent when it was sampled too, the root spans have no parent
+			parentSpanID, _ := span.Attribute(parentSpanIDAttribute)
+			if parent, ok := keys[parentSpanID]; ok && parentSpanID != "" && parent != key {
+				g.edges[serviceGraphEdgeKey{source: parent, target: key}]++
+			}
+		}
+	}
+	return g
+}
+
+func (g *serviceGraphData) addNode(key serviceGraphNodeKey) *serviceGraphNode {
+	node, ok := g.nodes[key]
+	if !ok {
+		node = &serviceGraphNode{serviceGraphNodeKey: key}
+		g.nodes[key] = node
+	}
+	return node
+}
+
+// spanNameMerges returns the nodes to merge in the other node of their service, keeping the maxPerService span names
+// of each service with the most spans in the graphs
+func spanNameMerges(maxPerService int, graphs ...*serviceGraphData) map[serviceGraphNodeKey]serviceGraphNodeKey {
+	merged := map[serviceGraphNodeKey]serviceGraphNodeKey{}
+	if maxPerService < 0 {
+		return merged
+	}
+
+	counts := map[serviceGraphNodeKey]*serviceGraphNode{}
+	for _, g := range graphs {
+		for key, node := range g.nodes {
+			count, ok := counts[key]
+			if !ok {
+				count = &serviceGraphNode{serviceGraphNodeKey: key}
+				counts[key] = count
+			}
+			count.spanCount += node.spanCount
+		}
+	}
+
+	byService := map[string][]*serviceGraphNode{}
+	for _, node := range counts {
+		byService[node.serviceName] = append(byService[node.serviceName], node)
+	}
+	for serviceName, serviceNodes := range byService {
+		if len(serviceNodes) <= maxPerService {
+			continue
+		}
+		sortServiceGraphNodes(serviceNodes)
+		other := serviceGraphNodeKey{serviceName: serviceName, spanName: otherSpanName}
+		for _, node := range serviceNodes[maxPerService:] {
+			merged[node.serviceGraphNodeKey] = other
+		}
+	}
+	return merged
+}
+
+// merge returns the graph with the nodes, and their edges, merged in the nodes they are mapped to
+func (g *serviceGraphData) merge(merged map[serviceGraphNodeKey]serviceGraphNodeKey) *serviceGraphData {
+	if len(merged) == 0 {
+		return g
+	}
+
+	resolve := func(key serviceGraphNodeKey) serviceGraphNodeKey {
+		if to, ok := merged[key]; ok {
+			return to
+		}
+		return key
+	}
+
+	capped := &serviceGraphData{
+		nodes: make(map[serviceGraphNodeKey]*serviceGraphNode, len(g.nodes)),
+		edges: make(map[serviceGraphEdgeKey]int64, len(g.edges)),
+	}
+	for key, node := range g.nodes {
+		to := capped.addNode(resolve(key))
+		to.spanCount += node.spanCount
+		to.totalDuration += node.totalDuration
+	}
+	for key, count := range g.edges {
+		to := serviceGraphEdgeKey{source: resolve(key.source), target: resolve(key.target)}
+		if to.source == to.target {
+			continue
+		}
+		capped.edges[to] += count
+	}
+	return capped
+}
+
+// sortServiceGraphNodes sorts the nodes by descending span count, then by service and span name
+func sortServiceGraphNodes(nodes []*serviceGraphNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].spanCount != nodes[j].spanCount {
+			return nodes[i].spanCount > nodes[j].spanCount
+		}
+		if nodes[i].serviceName != nodes[j].serviceName {
+			return nodes[i].serviceName < nodes[j].serviceName
+		}
+		return nodes[i].spanName < nodes[j].spanName
+	})
+}
+
+// serviceGraphNodesFrame returns the nodes of the current window, and the nodes removed since the previous window with
+// no spans, annotated with their change when previous is set
+func serviceGraphNodesFrame(current *serviceGraphData, previous *serviceGraphData, withRateChange bool) *data.Frame {
+	sorted := make([]*serviceGraphNode, 0, len(current.nodes))
+	for _, node := range current.nodes {
+		sorted = append(sorted, node)
+	}
+	if previous != nil {
+		for key := range previous.nodes {
+			if _, ok := current.nodes[key]; !ok {
+				sorted = append(sorted, &serviceGraphNode{serviceGraphNodeKey: key})
+			}
+		}
+	}
+	sortServiceGraphNodes(sorted)
+
+	frame := data.NewFrame("Nodes",
+		data.NewField("id", nil, make([]string, 0, len(sorted))),
+		data.NewField("title", nil, make([]string, 0, len(sorted))),
+		data.NewField("subtitle", nil, make([]string, 0, len(sorted))),
+		data.NewField("mainstat", nil, make([]int64, 0, len(sorted))).SetConfig(&data.FieldConfig{DisplayName: "Spans"}),
+		data.NewField("secondarystat", nil, make([]*float64, 0, len(sorted))).SetConfig(&data.FieldConfig{DisplayName: "Average duration", Unit: "ms"}),
+	)
+	if previous != nil {
+		frame.Fields = append(frame.Fields, comparisonFields(len(sorted))...)
+	}
+	for _, node := range sorted {
+		var avgDuration *float64
+		if node.spanCount > 0 {
+			avg := node.totalDuration / float64(node.spanCount)
+			avgDuration = &avg
+		}
+		row := []interface{}{node.id(), node.serviceName, node.spanName, node.spanCount, avgDuration}
+		if previous != nil {
+			var previousCount int64
+			previousNode, existed := previous.nodes[node.serviceGraphNodeKey]
+			if existed {
+				previousCount = previousNode.spanCount
+			}
+			_, exists := current.nodes[node.serviceGraphNodeKey]
+			row = append(row, comparisonRow(node.spanCount, previousCount, exists, existed, withRateChange)...)
+		}
+		frame.AppendRow(row...)
+	}
+	return frame
+}
+
+// serviceGraphEdgesFrame returns the edges of the current window, and the edges removed since the previous window with
+// no spans, annotated with their change when previous is set
+func serviceGraphEdgesFrame(current *serviceGraphData, previous *serviceGraphData, withRateChange bool) *data.Frame {
+	keys := make([]serviceGraphEdgeKey, 0, len(current.edges))
+	for key := range current.edges {
+		keys = append(keys, key)
+	}
+	if previous != nil {
+		for key := range previous.edges {
+			if _, ok := current.edges[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].source.id() != keys[j].source.id() {
+			return keys[i].source.id() < keys[j].source.id()
+		}
+		return keys[i].target.id() < keys[j].target.id()
+	})
+
+	frame := data.NewFrame("Edges",
+		data.NewField("id", nil, make([]string, 0, len(keys))),
+		data.NewField("source", nil, make([]string, 0, len(keys))),
+		data.NewField("target", nil, make([]string, 0, len(keys))),
+		data.NewField("mainstat", nil, make([]int64, 0, len(keys))).SetConfig(&data.FieldConfig{DisplayName: "Spans"}),
+	)
+	if previous != nil {
+		frame.Fields = append(frame.Fields, comparisonFields(len(keys))...)
+	}
+	for _, key := range keys {
+		row := []interface{}{key.id(), key.source.id(), key.target.id(), current.edges[key]}
+		if previous != nil {
+			_, existed := previous.edges[key]
+			_, exists := current.edges[key]
+			row = append(row, comparisonRow(current.edges[key], previous.edges[key], exists, existed, withRateChange)...)
+		}
+		frame.AppendRow(row...)
+	}
+	return frame
+}
+
+const (
+	comparisonChangeNew     = "new"
+	comparisonChangeRemoved = "removed"
+)
+
+// comparisonFields are the detail fields, shown in the node graph context menu, of the nodes and edges compared with
+// the previous window
+func comparisonFields(capacity int) []*data.Field {
+	return []*data.Field{
+		data.NewField("detail__previousSpans", nil, make([]int64, 0, capacity)).SetConfig(&data.FieldConfig{DisplayName: "Previous spans"}),
+		data.NewField("detail__rateChange", nil, make([]*float64, 0, capacity)).SetConfig(&data.FieldConfig{DisplayName: "Rate change", Unit: "percent"}),
+		data.NewField("detail__change", nil, make([]string, 0, capacity)).SetConfig(&data.FieldConfig{DisplayName: "Change"}),
+	}
+}
+
+// comparisonRow returns the values of the comparison fields, the rate change is nil for the new nodes and edges as
+// both windows have the same duration, and when withRateChange is not set
+func comparisonRow(count int64, previousCount int64, exists bool, existed bool, withRateChange bool) []interface{} {
+	var rateChange *float64
+	if withRateChange && previousCount > 0 {
+		change := float64(count-previousCount) / float64(previousCount) * 100
+		rateChange = &change
+	}
+	change := ""
+	switch {
+	case exists && !existed:
+		change = comparisonChangeNew
+	case !exists && existed:
+		change = comparisonChangeRemoved
+	}
+	return []interface{}{previousCount, rateChange, change}
+}
@@ -0,0 +1,285 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+const serviceGraphSearchResponse = `{
+  "traces": [
+    {
+      "traceID": "t1",
+      "rootServiceName": "frontend",
+      "rootTraceName": "GET /users/12",
+      "spanSet": {
+        "spans": [
+          {"spanID": "s1", "name": "GET /users/12", "durationNanos": "4000000", "attributes": [{"key": "service.name", "value": {"stringValue": "frontend"}}]},
+          {"spanID": "s2", "name": "SELECT users 12", "durationNanos": "1000000", "attributes": [{"key": "service.name", "value": {"stringValue": "db"}}, {"key": "span:parentID", "value": {"stringValue": "s1"}}]},
+          {"spanID": "s3", "name": "SELECT orders", "durationNanos": "3000000", "attributes": [{"key": "service.name", "value": {"stringValue": "db"}}, {"key": "span:parentID", "value": {"stringValue": "s2"}}]}
+        ],
+        "matched": 3
+      }
+    },
+    {
+      "traceID": "t2",
+      "rootServiceName": "frontend",
+      "rootTraceName": "GET /users/34",
+      "spanSet": {
+        "spans": [
+          {"spanID": "s4", "name": "SELECT users 34", "durationNanos": "3000000", "attributes": [{"key": "service.name", "value": {"stringValue": "db"}}, {"key": "span:parentID", "value": {"stringValue": "s5"}}]}
+        ],
+        "matched": 1
+      }
+    }
+  ]
+}`
+
+func TestSpanNameNormalizer(t *testing.T) {
+	t.Run("applies the rules in order", func(t *testing.T) {
+		n, err := newSpanNameNormalizer(serviceGraphSettings{SpanNameRules: []spanNameRuleSettings{
+			{Pattern: `/\d+`, Replacement: "/{id}"},
+			{Pattern: `\{id\}`, Replacement: ":id"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "GET /users/:id/orders/:id", n.normalize("GET /users/12/orders/34"))
+		assert.Equal(t, defaultMaxSpanNamesPerService, n.maxPerService)
+	})
+
+	t.Run("rejects invalid patterns", func(t *testing.T) {
+		_, err := newSpanNameNormalizer(serviceGraphSettings{SpanNameRules: []spanNameRuleSettings{{Pattern: "("}}})
+		require.ErrorContains(t, err, "span name rule 1")
+	})
+}
+
+func TestServiceGraphToFrames(t *testing.T) {
+	resp := &SearchResponse{}
+	require.NoError(t, json.Unmarshal([]byte(serviceGraphSearchResponse), resp))
+
+	t.Run("groups the spans by normalized span name", func(t *testing.T) {
+		n, err := newSpanNameNormalizer(serviceGraphSettings{SpanNameRules: []spanNameRuleSettings{
+			{Pattern: `/\d+`, Replacement: "/{id}"},
+			{Pattern: ` \d+$`, Replacement: ""},
+		}})
+		require.NoError(t, err)
+
+		nodes, edges := serviceGraphToFrames(resp, nil, n, true)
+		require.Equal(t, 3, nodes.Rows())
+		assert.Equal(t, []interface{}{"db/SELECT users", "db", "SELECT users", int64(2), float64Ptr(2)}, nodes.RowCopy(0))
+		assert.Equal(t, "db/SELECT orders", nodes.Fields[0].At(1))
+		assert.Equal(t, "frontend/GET /users/{id}", nodes.Fields[0].At(2))
+
+		// the parent of the span of the second trace wasn't sampled
+		require.Equal(t, 2, edges.Rows())
+		assert.Equal(t, []interface{}{"db/SELECT users_db/SELECT orders", "db/SELECT users", "db/SELECT orders", int64(1)}, edges.RowCopy(0))
+		assert.Equal(t, []interface{}{"frontend/GET /users/{id}_db/SELECT users", "frontend/GET /users/{id}", "db/SELECT users", int64(1)}, edges.RowCopy(1))
+	})
+
+	t.Run("counts the spans of several span sets once", func(t *testing.T) {
+		resp := &SearchResponse{}
+		require.NoError(t, json.Unmarshal([]byte(serviceGraphSearchResponse), resp))
+		trace := resp.Traces[0]
+		trace.SpanSets = []*SpanSet{trace.SpanSet, {Spans: trace.SpanSet.Spans[1:]}}
+
+		nodes, edges := serviceGraphToFrames(resp, nil, &spanNameNormalizer{maxPerService: -1}, true)
+		assert.Equal(t, []interface{}{"db/SELECT orders", "db", "SELECT orders", int64(1), float64Ptr(3)}, nodes.RowCopy(0))
+		require.Equal(t, 2, edges.Rows())
+		assert.Equal(t, int64(1), edges.Fields[3].At(0))
+		assert.Equal(t, int64(1), edges.Fields[3].At(1))
+	})
+
+	t.Run("merges the span names above the cap", func(t *testing.T) {
+		n, err := newSpanNameNormalizer(serviceGraphSettings{MaxSpanNamesPerService: 1})
+		require.NoError(t, err)
+
+		nodes, edges := serviceGraphToFrames(resp, nil, n, true)
+		ids := make([]string, 0, nodes.Rows())
+		for i := 0; i < nodes.Rows(); i++ {
+			ids = append(ids, nodes.Fields[0].At(i).(string))
+		}
+		assert.Equal(t, []string{"db/other", "db/SELECT orders", "frontend/GET /users/12"}, ids)
+		assert.Equal(t, int64(2), nodes.Fields[3].At(0))
+
+		require.Equal(t, 2, edges.Rows())
+		assert.Equal(t, []interface{}{"db/other_db/SELECT orders", "db/other", "db/SELECT orders", int64(1)}, edges.RowCopy(0))
+		assert.Equal(t, []interface{}{"frontend/GET /users/12_db/other", "frontend/GET /users/12", "db/other", int64(1)}, edges.RowCopy(1))
+	})
+
+	t.Run("keeps every span name without a cap", func(t *testing.T) {
+		n, err := newSpanNameNormalizer(serviceGraphSettings{MaxSpanNamesPerService: -1})
+		require.NoError(t, err)
+
+		nodes, _ := serviceGraphToFrames(resp, nil, n, true)
+		assert.Equal(t, 4, nodes.Rows())
+	})
+}
+
+func TestServiceGraph(t *testing.T) {
+	var requested *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r
+		_, _ = w.Write([]byte(serviceGraphSearchResponse))
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+	query := backend.DataQuery{
+		RefID:     "A",
+		QueryType: string(dataquery.TempoQueryTypeServiceGraph),
+		TimeRange: backend.TimeRange{From: time.Unix(1000, 0), To: time.Unix(2000, 0)},
+	}
+
+	res := service.serviceGraph(context.Background(), dsInfo, &dataquery.TempoQuery{Query: `{ span.http.status_code >= 500 }`}, query)
+	require.NoError(t, res.Error)
+	require.Len(t, res.Frames, 2)
+	assert.Equal(t, `{ span.http.status_code >= 500 } | select(resource.service.name, span:parentID)`, requested.URL.Query().Get("q"))
+	assert.Equal(t, "500", requested.URL.Query().Get("limit"))
+	assert.Equal(t, data.VisTypeNodeGraph, string(res.Frames[0].Meta.PreferredVisualization))
+	assert.Equal(t, "A", res.Frames[1].RefID)
+}
+
+const previousServiceGraphSearchResponse = `{
+  "traces": [
+    {
+      "traceID": "t0",
+      "rootServiceName": "frontend",
+      "rootTraceName": "GET /users/56",
+      "spanSet": {
+        "spans": [
+          {"spanID": "s0", "name": "SELECT users 56", "durationNanos": "1000000", "attributes": [{"key": "service.name", "value": {"stringValue": "db"}}, {"key": "span:parentID", "value": {"stringValue": "s8"}}]},
+          {"spanID": "s9", "name": "GET /cache", "durationNanos": "1000000", "attributes": [{"key": "service.name", "value": {"stringValue": "cache"}}, {"key": "span:parentID", "value": {"stringValue": "s0"}}]}
+        ],
+        "matched": 2
+      }
+    }
+  ]
+}`
+
+func TestServiceGraphToFramesComparison(t *testing.T) {
+	resp := &SearchResponse{}
+	require.NoError(t, json.Unmarshal([]byte(serviceGraphSearchResponse), resp))
+	previousResp := &SearchResponse{}
+	require.NoError(t, json.Unmarshal([]byte(previousServiceGraphSearchResponse), previousResp))
+
+	n, err := newSpanNameNormalizer(serviceGraphSettings{SpanNameRules: []spanNameRuleSettings{
+		{Pattern: `/\d+`, Replacement: "/{id}"},
+		{Pattern: ` \d+$`, Replacement: ""},
+	}})
+	require.NoError(t, err)
+
+	nodes, edges := serviceGraphToFrames(resp, previousResp, n, true)
+	require.Len(t, nodes.Fields, 8)
+	assert.Equal(t, "detail__rateChange", nodes.Fields[6].Name)
+
+	require.Equal(t, 4, nodes.Rows())
+	assert.Equal(t, []interface{}{"db/SELECT users", "db", "SELECT users", int64(2), float64Ptr(2), int64(1), float64Ptr(100), ""}, nodes.RowCopy(0))
+	assert.Equal(t, []interface{}{"db/SELECT orders", "db", "SELECT orders", int64(1), float64Ptr(3), int64(0), (*float64)(nil), "new"}, nodes.RowCopy(1))
+	assert.Equal(t, []interface{}{"frontend/GET /users/{id}", "frontend", "GET /users/{id}", int64(1), float64Ptr(4), int64(0), (*float64)(nil), "new"}, nodes.RowCopy(2))
+	assert.Equal(t, []interface{}{"cache/GET /cache", "cache", "GET /cache", int64(0), (*float64)(nil), int64(1), float64Ptr(-100), "removed"}, nodes.RowCopy(3))
+
+	require.Equal(t, 3, edges.Rows())
+	assert.Equal(t, []interface{}{"db/SELECT users_cache/GET /cache", "db/SELECT users", "cache/GET /cache", int64(0), int64(1), float64Ptr(-100), "removed"}, edges.RowCopy(0))
+	assert.Equal(t, []interface{}{"db/SELECT users_db/SELECT orders", "db/SELECT users", "db/SELECT orders", int64(1), int64(0), (*float64)(nil), "new"}, edges.RowCopy(1))
+	assert.Equal(t, []interface{}{"frontend/GET /users/{id}_db/SELECT users", "frontend/GET /users/{id}", "db/SELECT users", int64(1), int64(0), (*float64)(nil), "new"}, edges.RowCopy(2))
+}
+
+func TestServiceGraphCompareWith(t *testing.T) {
+	var requested []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r)
+		_, _ = w.Write([]byte(serviceGraphSearchResponse))
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+	query := backend.DataQuery{
+		RefID:     "A",
+		QueryType: string(dataquery.TempoQueryTypeServiceGraph),
+		TimeRange: backend.TimeRange{From: time.Unix(10000, 0), To: time.Unix(12000, 0)},
+	}
+
+	t.Run("searches the window shifted by the offset", func(t *testing.T) {
+		requested = nil
+		res := service.serviceGraph(context.Background(), dsInfo, &dataquery.TempoQuery{Query: `{}`, CompareWith: strPtr("1h")}, query)
+		require.NoError(t, res.Error)
+		require.Len(t, requested, 2)
+		assert.Equal(t, "6400", requested[1].URL.Query().Get("start"))
+		assert.Equal(t, "8400", requested[1].URL.Query().Get("end"))
+		assert.Equal(t, "detail__change", res.Frames[1].Fields[6].Name)
+	})
+
+	t.Run("leaves the rate changes empty when a window reaches the limit", func(t *testing.T) {
+		requested = nil
+		limit := int64(1)
+		res := service.serviceGraph(context.Background(), dsInfo, &dataquery.TempoQuery{Query: `{}`, CompareWith: strPtr("1h"), Limit: &limit}, query)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames[0].Meta.Notices, 1)
+		assert.Contains(t, res.Frames[0].Meta.Notices[0].Text, "maximum of 1 traces")
+
+		rateChange := res.Frames[0].Fields[6]
+		require.Equal(t, "detail__rateChange", rateChange.Name)
+		for i := 0; i < rateChange.Len(); i++ {
+			assert.Nil(t, rateChange.At(i))
+		}
+	})
+
+	t.Run("rejects invalid offsets", func(t *testing.T) {
+		requested = nil
+		res := service.serviceGraph(context.Background(), dsInfo, &dataquery.TempoQuery{Query: `{}`, CompareWith: strPtr("-1h")}, query)
+		require.ErrorContains(t, res.Error, "invalid compareWith offset")
+		assert.Empty(t, requested)
+	})
+}
+
+func TestServiceGraphPartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start") == "10000" {
+			_, _ = w.Write([]byte(serviceGraphSearchResponse))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("too many requests"))
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+	query := backend.DataQuery{
+		RefID:     "A",
+		QueryType: string(dataquery.TempoQueryTypeServiceGraph),
+		TimeRange: backend.TimeRange{From: time.Unix(10000, 0), To: time.Unix(12000, 0)},
+	}
+
+	t.Run("returns the current window when the previous window fails", func(t *testing.T) {
+		res := service.serviceGraph(context.Background(), dsInfo, &dataquery.TempoQuery{Query: `{}`, CompareWith: strPtr("1h")}, query)
+		require.NoError(t, res.Error)
+		assert.Equal(t, statusPartial, res.Status)
+		require.Len(t, res.Frames, 2)
+		assert.Len(t, res.Frames[0].Fields, 5)
+		require.Len(t, res.Frames[0].Meta.Notices, 1)
+		assert.Equal(t, data.NoticeSeverityWarning, res.Frames[0].Meta.Notices[0].Severity)
+		assert.Contains(t, res.Frames[0].Meta.Notices[0].Text, "Failed to get the comparison with the previous window")
+		assert.Empty(t, res.Frames[1].Meta.Notices)
+	})
+
+	t.Run("fails when the current window fails", func(t *testing.T) {
+		query := query
+		query.TimeRange = backend.TimeRange{From: time.Unix(20000, 0), To: time.Unix(22000, 0)}
+		res := service.serviceGraph(context.Background(), dsInfo, &dataquery.TempoQuery{Query: `{}`, CompareWith: strPtr("1h")}, query)
+		require.ErrorContains(t, res.Error, "too many requests")
+		assert.Empty(t, res.Frames)
+	})
+}
@@ -0,0 +1,404 @@
+package tempo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/live/backpressure"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+var _ backend.StreamHandler = (*Service)(nil)
+
+const (
+	// streamingTransportAuto tries the websocket of Tempo first, and searches the partitions of the time range over
+	// HTTP when the websocket can't be opened
+	streamingTransportAuto = "auto"
+	// streamingTransportWebsocket streams the results from the websocket of Tempo's search endpoint
+	streamingTransportWebsocket = "websocket"
+	// streamingTransportHTTP searches the partitions of the time range one after the other, and sends the results
+	// found so far after each partition
+	streamingTransportHTTP = "http"
+
+	// streamingSearchLimit is the number of traces of a streaming search when the query has no limit set
+	streamingSearchLimit = 20
+	// websocketRetryInterval is how long the auto transport keeps using HTTP after the websocket failed to open,
+	// so that each search doesn't wait for a handshake that is going to fail
+	websocketRetryInterval = 10 * time.Minute
+
+	// streamingDownsampleInterval and streamingDropInterval are the minimum intervals between the frames of a
+	// search sent to the channel when its subscribers are slow
+	streamingDownsampleInterval = time.Second
+	streamingDropInterval       = 10 * time.Second
+
+	streamingStateStreaming = "streaming"
+	streamingStateDone      = "done"
+)
+
+// errWebsocketUnavailable is returned when the websocket of Tempo can't be opened, for example behind a proxy
+// dropping the upgrade requests
+var errWebsocketUnavailable = errors.New("tempo websocket unavailable")
+
+// streamingSettings are the options of the streaming searches, read from the streaming object of jsonData.
+type streamingSettings struct {
+	// Transport is auto, websocket or http, auto by default.
+	Transport string `json:"transport"`
+}
+
+// streamingSearchRequest is the data of the subscriptions to a search/ channel, the query of the search and its
+// time range in unix seconds
+type streamingSearchRequest struct {
+	dataquery.TempoQuery
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// StreamingSearchMeta is the custom metadata of the frames of a streaming search
+type StreamingSearchMeta struct {
+	Transport string `json:"transport"`
+	State     string `json:"state"`
+}
+
+// transportNegotiator picks the transport of the streaming searches of a data source
+type transportNegotiator struct {
+	configured string
+
+	mu sync.Mutex
+	// httpUntil is when the auto transport tries the websocket again after it failed to open
+	httpUntil time.Time
+}
+
+func newTransportNegotiator(settings streamingSettings, hasTLSFiles bool) (*transportNegotiator, error) {
+	switch settings.Transport {
+	case "", streamingTransportAuto:
+		if hasTLSFiles {
+			// the dialer of the websocket doesn't follow the rotation of the certificate files
+			return &transportNegotiator{configured: streamingTransportHTTP}, nil
+		}
+		return &transportNegotiator{configured: streamingTransportAuto}, nil
+	case streamingTransportWebsocket:
+		if hasTLSFiles {
+			return nil, fmt.Errorf("the websocket transport doesn't support TLS certificate files")
+		}
+		return &transportNegotiator{configured: settings.Transport}, nil
+	case streamingTransportHTTP:
+		return &transportNegotiator{configured: settings.Transport}, nil
+	default:
+		return nil, fmt.Errorf("unknown streaming transport %q", settings.Transport)
+	}
+}
+
+// transport returns the transport of the next search
+func (n *transportNegotiator) transport(now time.Time) string {
+	if n.configured != streamingTransportAuto {
+		return n.configured
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if now.Before(n.httpUntil) {
+		return streamingTransportHTTP
+	}
+	return streamingTransportWebsocket
+}
+
+// websocketFailed reports that the websocket failed to open, it returns whether the search can fall back to HTTP
+func (n *transportNegotiator) websocketFailed(now time.Time) bool {
+	if n.configured != streamingTransportAuto {
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.httpUntil = now.Add(websocketRetryInterval)
+	return true
+}
+
+// websocketClient opens the websockets of Tempo with the authentication and TLS settings of the data source
+type websocketClient struct {
+	dialer *websocket.Dialer
+	header http.Header
+}
+
+func newWebsocketClient(opts sdkhttpclient.Options) (*websocketClient, error) {
+	tlsConfig, err := sdkhttpclient.GetTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	for name, value := range opts.Headers {
+		header.Set(name, value)
+	}
+	if opts.BasicAuth != nil {
+		credentials := opts.BasicAuth.User + ":" + opts.BasicAuth.Password
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+	return &websocketClient{
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: 10 * time.Second,
+			TLSClientConfig:  tlsConfig,
+		},
+		header: header,
+	}, nil
+}
+
+func (s *Service) SubscribeStream(_ context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if _, err := s.getDSInfo(req.PluginContext); err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, err
+	}
+
+	// Expect search/${key}
+	if !strings.HasPrefix(req.Path, "search/") {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, fmt.Errorf("expected search in channel path")
+	}
+	if _, _, err := parseStreamingSearch(req.Data); err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, err
+	}
+	return &backend.SubscribeStreamResponse{
+		Status: backend.SubscribeStreamStatusOK,
+	}, nil
+}
+
+func (s *Service) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{
+		Status: backend.PublishStreamStatusPermissionDenied,
+	}, nil
+}
+
+// RunStream runs a TraceQL search and sends the traces found so far each time Tempo returns more of them, the last
+// frame has the done state. The transport is negotiated with Tempo for each search.
+func (s *Service) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return err
+	}
+	search, traceQL, err := parseStreamingSearch(req.Data)
+	if err != nil {
+		return err
+	}
+	traceQL, _ = dsInfo.rewriteTraceQL(traceQL)
+	traceQL = dsInfo.promoted.selectQuery(traceQL)
+
+	limit := int64(streamingSearchLimit)
+	if search.Limit != nil && *search.Limit > 0 {
+		limit = *search.Limit
+	}
+	if err := s.checkCost(ctx, dsInfo, search.Start, search.End); err != nil {
+		return err
+	}
+
+	logger := s.tlog.FromContext(ctx)
+	signals := backpressure.FromContext(ctx)
+	transport := dsInfo.streaming.transport(time.Now())
+	if transport == streamingTransportWebsocket {
+		err := s.streamSearchWebsocket(ctx, dsInfo, traceQL, limit, search.Start, search.End, newStreamingSearchSender(sender, transport, dsInfo.promoted, signals))
+		if !errors.Is(err, errWebsocketUnavailable) || !dsInfo.streaming.websocketFailed(time.Now()) {
+			return err
+		}
+		logger.Info("Tempo websocket unavailable, streaming the search over HTTP", "error", err)
+		transport = streamingTransportHTTP
+	}
+	return s.streamSearchHTTP(ctx, dsInfo, traceQL, limit, search.Start, search.End, newStreamingSearchSender(sender, transport, dsInfo.promoted, signals))
+}
+
+func parseStreamingSearch(raw json.RawMessage) (*streamingSearchRequest, string, error) {
+	search := &streamingSearchRequest{}
+	if err := json.Unmarshal(raw, search); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal streaming search: %w", err)
+	}
+	traceQL := strings.TrimSpace(search.Query)
+	if traceQL == "" && len(search.Filters) > 0 {
+		traceQL = generateQueryFromFilters(queryFilters(&search.TempoQuery))
+	}
+	if traceQL == "" {
+		return nil, "", fmt.Errorf("missing query in channel (subscribe)")
+	}
+	return search, traceQL, nil
+}
+
+// streamSearchWebsocket streams the results of Tempo's websocket search, each message holds the traces Tempo found
+// since the previous message. It returns errWebsocketUnavailable when the websocket can't be opened.
+func (s *Service) streamSearchWebsocket(ctx context.Context, dsInfo *datasourceInfo, query string, limit int64, start int64, end int64, sender *streamingSearchSender) error {
+	logger := s.tlog.FromContext(ctx)
+
+	wsurl, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return err
+	}
+	wsurl.Path = strings.TrimSuffix(wsurl.Path, "/") + "/api/search"
+	if wsurl.Scheme == "https" {
+		wsurl.Scheme = "wss"
+	} else {
+		wsurl.Scheme = "ws"
+	}
+	wsurl.RawQuery = searchParams(query, limit, start, end).Encode()
+
+	logger.Debug("Tempo streaming search", "url", wsurl.String())
+	c, r, err := dsInfo.websocket.dialer.DialContext(ctx, wsurl.String(), dsInfo.websocket.header)
+	if r != nil {
+		_ = r.Body.Close()
+	}
+	if err != nil {
+		if r != nil {
+			return fmt.Errorf("%w: status %s", errWebsocketUnavailable, r.Status)
+		}
+		return fmt.Errorf("%w: %s", errWebsocketUnavailable, err)
+	}
+	defer func() {
+		if err := c.Close(); err != nil {
+			logger.Warn("Failed to close the Tempo websocket", "error", err)
+		}
+	}()
+
+	// the read is unblocked when the subscribers leave
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = c.Close()
+		case <-stop:
+		}
+	}()
+
+	merged := newSearchMerger()
+	for {
+		_, message, err := c.ReadMessage()
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			return sender.send(merged.result(limit), streamingStateDone)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read the tempo streaming search: %w", err)
+		}
+
+		resp := &SearchResponse{}
+		if err := json.Unmarshal(message, resp); err != nil {
+			return fmt.Errorf("failed to parse tempo search response: %w", err)
+		}
+		dsInfo.redactor.redactSearch(resp)
+		merged.add(resp)
+		if err := sender.send(merged.result(limit), streamingStateStreaming); err != nil {
+			return err
+		}
+	}
+}
+
+// streamSearchHTTP searches the partitions of the time range one after the other, the most recent first, and sends
+// the traces found so far after each partition. The search stops as soon as it found enough traces.
+func (s *Service) streamSearchHTTP(ctx context.Context, dsInfo *datasourceInfo, query string, limit int64, start int64, end int64, sender *streamingSearchSender) error {
+	partitions := []searchPartition{{start: start, end: end}}
+	if start > 0 && end > 0 {
+		partitions = searchPartitions(start, end, s.partitionDuration(ctx, dsInfo))
+	}
+
+	merged := newSearchMerger()
+	for i, p := range partitions {
+		resp, err := s.searchTracesRange(ctx, dsInfo, query, limit, p.start, p.end)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		merged.add(resp)
+		if i == len(partitions)-1 || int64(len(merged.resp.Traces)) >= limit {
+			break
+		}
+		if err := sender.send(merged.result(limit), streamingStateStreaming); err != nil {
+			return err
+		}
+	}
+	return sender.send(merged.result(limit), streamingStateDone)
+}
+
+// streamingSearchSender sends the traces of a streaming search, with the transport and the state of the search. Each
+// frame has all the traces found so far, so the frames of a search still streaming are skipped following the
+// backpressure hint of the channel, and the last frame is always sent.
+type streamingSearchSender struct {
+	sender    *backend.StreamSender
+	transport string
+	promoted  *promotedAttributes
+	signals   *backpressure.Signals
+	// lastSent is when the last frame was sent
+	lastSent time.Time
+}
+
+func newStreamingSearchSender(sender *backend.StreamSender, transport string, promoted *promotedAttributes, signals *backpressure.Signals) *streamingSearchSender {
+	return &streamingSearchSender{sender: sender, transport: transport, promoted: promoted, signals: signals}
+}
+
+// skip returns whether the frame of a search still streaming is skipped, following the backpressure hint
+func (s *streamingSearchSender) skip(hint backpressure.Hint, now time.Time) bool {
+	switch hint {
+	case backpressure.HintPause:
+		return true
+	case backpressure.HintDrop:
+		return now.Sub(s.lastSent) < streamingDropInterval
+	case backpressure.HintDownsample:
+		return now.Sub(s.lastSent) < streamingDownsampleInterval
+	default:
+		return false
+	}
+}
+
+func (s *streamingSearchSender) send(resp *SearchResponse, state string) error {
+	now := time.Now()
+	if state == streamingStateStreaming && s.skip(s.signals.Load().Hint, now) {
+		return nil
+	}
+	s.lastSent = now
+
+	frame := searchToFrame(resp)
+	s.promoted.addFields(frame, resp.Traces)
+	frame.SetMeta(&data.FrameMeta{
+		Custom: StreamingSearchMeta{Transport: s.transport, State: state},
+	})
+	return s.sender.SendFrame(frame, data.IncludeAll)
+}
+
+// searchToFrame returns a table of the traces of a search
+func searchToFrame(resp *SearchResponse) *data.Frame {
+	traceIDs := make([]string, 0, len(resp.Traces))
+	startTimes := make([]time.Time, 0, len(resp.Traces))
+	services := make([]string, 0, len(resp.Traces))
+	names := make([]string, 0, len(resp.Traces))
+	durations := make([]float64, 0, len(resp.Traces))
+	for _, trace := range resp.Traces {
+		traceIDs = append(traceIDs, trace.TraceID)
+		startNanos, _ := strconv.ParseInt(trace.StartTimeUnixNano, 10, 64)
+		startTimes = append(startTimes, time.Unix(0, startNanos).UTC())
+		services = append(services, trace.RootServiceName)
+		names = append(names, trace.RootTraceName)
+		durations = append(durations, float64(trace.DurationMs))
+	}
+
+	return data.NewFrame("Traces",
+		data.NewField("traceID", nil, traceIDs).SetConfig(&data.FieldConfig{DisplayName: "Trace ID"}),
+		data.NewField("startTime", nil, startTimes).SetConfig(&data.FieldConfig{DisplayName: "Start time"}),
+		data.NewField("traceService", nil, services).SetConfig(&data.FieldConfig{DisplayName: "Service"}),
+		data.NewField("traceName", nil, names).SetConfig(&data.FieldConfig{DisplayName: "Name"}),
+		data.NewField("traceDuration", nil, durations).SetConfig(&data.FieldConfig{DisplayName: "Duration", Unit: "ms"}),
+	)
+}
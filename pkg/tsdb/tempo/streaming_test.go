@@ -0,0 +1,224 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/backpressure"
+)
+
+type fakePacketSender struct {
+	frames []*data.Frame
+}
+
+func (s *fakePacketSender) Send(packet *backend.StreamPacket) error {
+	frame := &data.Frame{}
+	if err := json.Unmarshal(packet.Data, frame); err != nil {
+		return err
+	}
+	s.frames = append(s.frames, frame)
+	return nil
+}
+
+func TestTransportNegotiator(t *testing.T) {
+	now := time.Now()
+
+	n, err := newTransportNegotiator(streamingSettings{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, streamingTransportWebsocket, n.transport(now))
+	assert.True(t, n.websocketFailed(now))
+	assert.Equal(t, streamingTransportHTTP, n.transport(now.Add(time.Minute)))
+	assert.Equal(t, streamingTransportWebsocket, n.transport(now.Add(websocketRetryInterval)), "the websocket is tried again")
+
+	n, err = newTransportNegotiator(streamingSettings{Transport: streamingTransportWebsocket}, false)
+	require.NoError(t, err)
+	assert.False(t, n.websocketFailed(now), "the configured websocket transport doesn't fall back")
+	assert.Equal(t, streamingTransportWebsocket, n.transport(now))
+
+	n, err = newTransportNegotiator(streamingSettings{}, true)
+	require.NoError(t, err)
+	assert.Equal(t, streamingTransportHTTP, n.transport(now))
+
+	_, err = newTransportNegotiator(streamingSettings{Transport: streamingTransportWebsocket}, true)
+	require.Error(t, err)
+	_, err = newTransportNegotiator(streamingSettings{Transport: "grpc"}, false)
+	require.EqualError(t, err, `unknown streaming transport "grpc"`)
+}
+
+func TestRunStream(t *testing.T) {
+	responses := map[string]string{
+		"5400-9000": `{"traces": [{"traceID": "t1", "rootServiceName": "api", "durationMs": 10}]}`,
+		"1800-5400": `{"traces": [{"traceID": "t2", "rootServiceName": "db"}]}`,
+		"1000-1800": `{"traces": [{"traceID": "t3"}]}`,
+	}
+
+	newService := func(t *testing.T, transport string, handler http.HandlerFunc) (*Service, *datasourceInfo) {
+		srv := httptest.NewServer(handler)
+		t.Cleanup(srv.Close)
+
+		streaming, err := newTransportNegotiator(streamingSettings{Transport: transport}, false)
+		require.NoError(t, err)
+		ws, err := newWebsocketClient(sdkhttpclient.Options{})
+		require.NoError(t, err)
+		dsInfo := &datasourceInfo{
+			HTTPClient: srv.Client(),
+			URL:        srv.URL,
+			partitions: &searchPartitioner{duration: time.Hour, concurrency: 1},
+			streaming:  streaming,
+			websocket:  ws,
+		}
+		return &Service{
+			tlog: log.New("tempo-test"),
+			im: datasource.NewInstanceManager(func(backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+				return dsInfo, nil
+			}),
+		}, dsInfo
+	}
+
+	runWithContext := func(ctx context.Context, t *testing.T, service *Service, limit int64) ([]*data.Frame, error) {
+		search, err := json.Marshal(map[string]interface{}{"query": "{}", "limit": limit, "start": 1000, "end": 9000})
+		require.NoError(t, err)
+		sender := &fakePacketSender{}
+		err = service.RunStream(ctx, &backend.RunStreamRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Path:          "search/abc",
+			Data:          search,
+		}, backend.NewStreamSender(sender))
+		return sender.frames, err
+	}
+	run := func(t *testing.T, service *Service, limit int64) ([]*data.Frame, error) {
+		return runWithContext(context.Background(), t, service, limit)
+	}
+
+	meta := func(t *testing.T, frame *data.Frame) StreamingSearchMeta {
+		raw, err := json.Marshal(frame.Meta.Custom)
+		require.NoError(t, err)
+		var m StreamingSearchMeta
+		require.NoError(t, json.Unmarshal(raw, &m))
+		return m
+	}
+
+	httpHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(responses[r.URL.Query().Get("start")+"-"+r.URL.Query().Get("end")]))
+	}
+
+	t.Run("the results of the websocket are streamed", func(t *testing.T) {
+		upgrader := websocket.Upgrader{}
+		service, _ := newService(t, streamingTransportAuto, func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/search", r.URL.Path)
+			require.Equal(t, "{}", r.URL.Query().Get("q"))
+			c, err := upgrader.Upgrade(w, r, nil)
+			require.NoError(t, err)
+			defer func() { _ = c.Close() }()
+			for _, key := range []string{"5400-9000", "1800-5400"} {
+				require.NoError(t, c.WriteMessage(websocket.TextMessage, []byte(responses[key])))
+			}
+			_ = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		})
+
+		frames, err := run(t, service, 20)
+		require.NoError(t, err)
+		require.Len(t, frames, 3)
+		assert.Equal(t, 1, frames[0].Rows())
+		assert.Equal(t, StreamingSearchMeta{Transport: streamingTransportWebsocket, State: streamingStateStreaming}, meta(t, frames[0]))
+		assert.Equal(t, 2, frames[2].Rows())
+		assert.Equal(t, StreamingSearchMeta{Transport: streamingTransportWebsocket, State: streamingStateDone}, meta(t, frames[2]))
+		assert.Equal(t, "db", frames[2].Fields[2].At(1))
+	})
+
+	t.Run("the search falls back to HTTP when the websocket can't be opened", func(t *testing.T) {
+		service, dsInfo := newService(t, streamingTransportAuto, httpHandler)
+
+		frames, err := run(t, service, 20)
+		require.NoError(t, err)
+		require.Len(t, frames, 3)
+		for i, frame := range frames {
+			assert.Equal(t, i+1, frame.Rows())
+			assert.Equal(t, streamingTransportHTTP, meta(t, frame).Transport)
+		}
+		assert.Equal(t, streamingStateStreaming, meta(t, frames[1]).State)
+		assert.Equal(t, streamingStateDone, meta(t, frames[2]).State)
+		assert.Equal(t, streamingTransportHTTP, dsInfo.streaming.transport(time.Now()), "the next searches use HTTP")
+	})
+
+	t.Run("the HTTP search stops when the limit is reached", func(t *testing.T) {
+		service, _ := newService(t, streamingTransportHTTP, httpHandler)
+
+		frames, err := run(t, service, 2)
+		require.NoError(t, err)
+		require.Len(t, frames, 2)
+		assert.Equal(t, 2, frames[1].Rows())
+		assert.Equal(t, streamingStateDone, meta(t, frames[1]).State)
+	})
+
+	t.Run("only the last frame is sent when the stream is paused", func(t *testing.T) {
+		service, _ := newService(t, streamingTransportHTTP, httpHandler)
+		signals := backpressure.NewSignals()
+		signals.Store(backpressure.Signal{Hint: backpressure.HintPause, SlowSubscribers: 1})
+
+		frames, err := runWithContext(backpressure.WithSignals(context.Background(), signals), t, service, 20)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, 3, frames[0].Rows())
+		assert.Equal(t, streamingStateDone, meta(t, frames[0]).State)
+	})
+
+	t.Run("the configured websocket transport doesn't fall back", func(t *testing.T) {
+		service, _ := newService(t, streamingTransportWebsocket, httpHandler)
+
+		_, err := run(t, service, 20)
+		require.ErrorIs(t, err, errWebsocketUnavailable)
+	})
+}
+
+func TestStreamingSearchSenderSkip(t *testing.T) {
+	now := time.Now()
+	s := &streamingSearchSender{lastSent: now}
+
+	assert.False(t, s.skip(backpressure.HintNone, now))
+	assert.True(t, s.skip(backpressure.HintDownsample, now.Add(streamingDownsampleInterval/2)))
+	assert.False(t, s.skip(backpressure.HintDownsample, now.Add(streamingDownsampleInterval)))
+	assert.True(t, s.skip(backpressure.HintDrop, now.Add(streamingDownsampleInterval)))
+	assert.False(t, s.skip(backpressure.HintDrop, now.Add(streamingDropInterval)))
+	assert.True(t, s.skip(backpressure.HintPause, now.Add(time.Hour)))
+}
+
+func TestSubscribeStream(t *testing.T) {
+	service := &Service{
+		tlog: log.New("tempo-test"),
+		im: datasource.NewInstanceManager(func(backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+			return &datasourceInfo{}, nil
+		}),
+	}
+	pluginCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}}
+
+	resp, err := service.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pluginCtx, Path: "search/abc", Data: []byte(`{"query": "{}"}`)})
+	require.NoError(t, err)
+	assert.Equal(t, backend.SubscribeStreamStatusOK, resp.Status)
+
+	resp, err = service.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pluginCtx, Path: "search/abc", Data: []byte(`{}`)})
+	require.Error(t, err)
+	assert.Equal(t, backend.SubscribeStreamStatusNotFound, resp.Status)
+
+	resp, err = service.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pluginCtx, Path: "tail/abc", Data: []byte(`{"query": "{}"}`)})
+	require.Error(t, err)
+	assert.Equal(t, backend.SubscribeStreamStatusNotFound, resp.Status)
+}
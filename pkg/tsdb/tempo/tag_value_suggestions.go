@@ -0,0 +1,190 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/services/queryhistory"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+const (
+	// suggestionsHistoryPeriod is how far back the query history is read for the suggestions of the tag values
+	suggestionsHistoryPeriod = 30 * 24 * time.Hour
+	// suggestionsHistoryLimit is the most queries of the query history read for the suggestions of the tag values
+	suggestionsHistoryLimit = 1000
+)
+
+// TagValueSuggestion is a value of a tag suggested by the search editor, with the number of recent queries of the
+// data source using it run by the current user, and by all the users of the organization
+type TagValueSuggestion struct {
+	Value    string `json:"value"`
+	Type     string `json:"type,omitempty"`
+	UserUses int    `json:"userUses,omitempty"`
+	OrgUses  int    `json:"orgUses,omitempty"`
+}
+
+type TagValueSuggestionsResponse struct {
+	Suggestions []TagValueSuggestion `json:"suggestions"`
+}
+
+// tagValueSuggestions merges the values of a tag returned by Tempo with the values recently used in the queries of
+// the data source. The values used by the current user come first, then the values used by the organization, the
+// most used first, then the other values returned by Tempo. The values of the high-cardinality tags are often
+// truncated by Tempo, the values used recently are suggested even when Tempo didn't return them.
+func (s *Service) tagValueSuggestions(ctx context.Context, pluginCtx backend.PluginContext, dsInfo *datasourceInfo, tag string, query string) (*TagValueSuggestionsResponse, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("missing tag")
+	}
+	values, err := s.getTagValues(ctx, dsInfo, tag, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var login string
+	if pluginCtx.User != nil {
+		login = pluginCtx.User.Login
+	}
+	used := map[string]*TagValueSuggestion{}
+	for _, q := range s.recentQueries(ctx, pluginCtx) {
+		for _, value := range historyTagValues(q.Queries.MustArray(), tag) {
+			suggestion, ok := used[value]
+			if !ok {
+				suggestion = &TagValueSuggestion{Value: value}
+				used[value] = suggestion
+			}
+			suggestion.OrgUses++
+			if login != "" && q.CreatedByLogin == login {
+				suggestion.UserUses++
+			}
+		}
+	}
+
+	resp := &TagValueSuggestionsResponse{Suggestions: make([]TagValueSuggestion, 0, len(used)+len(values.TagValues))}
+	types := map[string]string{}
+	for _, v := range values.TagValues {
+		types[v.Value] = v.Type
+	}
+	for _, suggestion := range used {
+		suggestion.Type = types[suggestion.Value]
+		resp.Suggestions = append(resp.Suggestions, *suggestion)
+	}
+	sort.Slice(resp.Suggestions, func(i, j int) bool {
+		a, b := resp.Suggestions[i], resp.Suggestions[j]
+		if a.UserUses != b.UserUses {
+			return a.UserUses > b.UserUses
+		}
+		if a.OrgUses != b.OrgUses {
+			return a.OrgUses > b.OrgUses
+		}
+		return a.Value < b.Value
+	})
+	for _, v := range values.TagValues {
+		if _, ok := used[v.Value]; !ok {
+			resp.Suggestions = append(resp.Suggestions, TagValueSuggestion{Value: v.Value, Type: v.Type})
+		}
+	}
+	return resp, nil
+}
+
+// recentQueries reads the recent queries of the data source from the query history, the suggestions only include
+// the values returned by Tempo when the query history can't be read
+func (s *Service) recentQueries(ctx context.Context, pluginCtx backend.PluginContext) []queryhistory.RecentQuery {
+	if s.queryHistory == nil || pluginCtx.DataSourceInstanceSettings == nil {
+		return nil
+	}
+	queries, err := s.queryHistory.GetRecentQueries(ctx, queryhistory.GetRecentQueriesQuery{
+		OrgID:         pluginCtx.OrgID,
+		DatasourceUID: pluginCtx.DataSourceInstanceSettings.UID,
+		From:          time.Now().Add(-suggestionsHistoryPeriod).Unix(),
+		Limit:         suggestionsHistoryLimit,
+	})
+	if err != nil {
+		s.tlog.FromContext(ctx).Warn("Failed to read the query history", "error", err)
+		return nil
+	}
+	return queries
+}
+
+// historyTagValues returns the values of the tag used by the queries of a query history entry, each value once. The
+// values are read from the filters of the search editor and from the equality conditions of the TraceQL queries.
+func historyTagValues(queries []interface{}, tag string) []string {
+	var values []string
+	seen := map[string]bool{}
+	add := func(value string) {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+
+	conditions := tagConditionRegexp(tag)
+	for _, raw := range queries {
+		body, err := json.Marshal(raw)
+		if err != nil {
+			continue
+		}
+		model := &dataquery.TempoQuery{}
+		if err := json.Unmarshal(body, model); err != nil {
+			continue
+		}
+		for _, f := range queryFilters(model) {
+			if f.Tag == nil || f.Operator == nil || *f.Operator != "=" || !sameTag(scopedTag(f), tag) {
+				continue
+			}
+			for _, value := range filterValues(f) {
+				add(value)
+			}
+		}
+		for _, match := range conditions.FindAllStringSubmatch(model.Query, -1) {
+			add(strings.ReplaceAll(match[1], `\"`, `"`))
+		}
+	}
+	return values
+}
+
+// tagSpellings are the ways a tag can be written in TraceQL: an unscoped attribute matches the attribute of any
+// scope, and a scoped attribute matches the unscoped attribute
+func tagSpellings(tag string) []string {
+	name, scoped := attributeName(tag)
+	if name == "" {
+		return []string{tag}
+	}
+	if scoped {
+		return []string{tag, "." + name}
+	}
+	return []string{tag, "span." + name, "resource." + name}
+}
+
+// attributeName returns the name of an attribute without its scope, and whether it is scoped. The name is empty for
+// the intrinsics.
+func attributeName(tag string) (string, bool) {
+	if strings.HasPrefix(tag, ".") {
+		return strings.TrimPrefix(tag, "."), false
+	}
+	if scope, name, ok := strings.Cut(tag, "."); ok && attributeScopes[scope] {
+		return name, true
+	}
+	return "", false
+}
+
+func sameTag(a string, b string) bool {
+	return containsString(tagSpellings(a), b)
+}
+
+// tagConditionRegexp matches the equality conditions of the tag with a quoted value in a TraceQL query
+func tagConditionRegexp(tag string) *regexp.Regexp {
+	spellings := tagSpellings(tag)
+	quoted := make([]string, 0, len(spellings))
+	for _, spelling := range spellings {
+		quoted = append(quoted, regexp.QuoteMeta(spelling))
+	}
+	return regexp.MustCompile(`(?:^|[^\w.])(?:` + strings.Join(quoted, "|") + `)\s*=\s*"((?:[^"\\]|\\.)*)"`)
+}
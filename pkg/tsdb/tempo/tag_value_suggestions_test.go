@@ -0,0 +1,98 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/queryhistory"
+)
+
+type fakeQueryHistory struct {
+	queryhistory.Service
+	queries       []queryhistory.RecentQuery
+	expectedQuery queryhistory.GetRecentQueriesQuery
+}
+
+func (f *fakeQueryHistory) GetRecentQueries(_ context.Context, query queryhistory.GetRecentQueriesQuery) ([]queryhistory.RecentQuery, error) {
+	f.expectedQuery = query
+	return f.queries, nil
+}
+
+func recentQuery(t *testing.T, login string, queries string) queryhistory.RecentQuery {
+	j, err := simplejson.NewJson([]byte(queries))
+	require.NoError(t, err)
+	return queryhistory.RecentQuery{CreatedByLogin: login, Queries: j}
+}
+
+func TestHistoryTagValues(t *testing.T) {
+	queries := []interface{}{
+		map[string]interface{}{"query": `{resource.service.name="checkout" && span.http.route="/cart"} || {.service.name = "cart\"s"}`},
+		map[string]interface{}{"query": `{resource.service.name=~"pay.*" && resource.service.namespace="prod"}`},
+		map[string]interface{}{"filters": []interface{}{
+			map[string]interface{}{"id": "a", "type": "static", "scope": "resource", "tag": "service.name", "operator": "=", "value": []interface{}{"payments", "checkout"}},
+			map[string]interface{}{"id": "b", "type": "static", "scope": "resource", "tag": "service.name", "operator": "!=", "value": "auth"},
+		}},
+	}
+
+	assert.Equal(t, []string{"checkout", `cart"s`, "payments"}, historyTagValues(queries, "resource.service.name"))
+	assert.Equal(t, []string{"/cart"}, historyTagValues(queries, ".http.route"))
+	assert.Empty(t, historyTagValues(queries, "span.service.namespace"))
+}
+
+func TestTagValueSuggestions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v2/search/tag/resource.service.name/values", r.URL.Path)
+		require.Equal(t, `{span.http.status_code=500}`, r.URL.Query().Get("q"))
+		_, _ = w.Write([]byte(`{"tagValues": [{"type": "string", "value": "auth"}, {"type": "string", "value": "checkout"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	history := &fakeQueryHistory{queries: []queryhistory.RecentQuery{
+		recentQuery(t, "alice", `[{"query": "{resource.service.name=\"checkout\"}"}]`),
+		recentQuery(t, "bob", `[{"query": "{resource.service.name=\"payments\"}"}]`),
+		recentQuery(t, "bob", `[{"query": "{resource.service.name=\"payments\"}"}, {"query": "{resource.service.name=\"payments\"}"}]`),
+		recentQuery(t, "bob", `[{"query": "{resource.service.name=\"checkout\"}"}]`),
+		recentQuery(t, "alice", `[{"query": "{resource.service.name=\"cart\"}"}]`),
+	}}
+	service := &Service{tlog: log.New("tempo-test"), queryHistory: history}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+	pluginCtx := backend.PluginContext{
+		OrgID:                      2,
+		User:                       &backend.User{Login: "alice"},
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "tempo"},
+	}
+
+	sender := &fakeCallResourceResponseSender{}
+	err := service.callResource(context.Background(), &backend.CallResourceRequest{
+		PluginContext: pluginCtx,
+		Method:        http.MethodGet,
+		Path:          "tag-value-suggestions",
+		URL:           "tag-value-suggestions?tag=resource.service.name&q=%7Bspan.http.status_code%3D500%7D",
+	}, sender, dsInfo)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, sender.res.Status)
+
+	var resp TagValueSuggestionsResponse
+	require.NoError(t, json.Unmarshal(sender.res.Body, &resp))
+	assert.Equal(t, []TagValueSuggestion{
+		{Value: "checkout", Type: "string", UserUses: 1, OrgUses: 2},
+		{Value: "cart", UserUses: 1, OrgUses: 1},
+		{Value: "payments", OrgUses: 2},
+		{Value: "auth", Type: "string"},
+	}, resp.Suggestions)
+
+	assert.Equal(t, int64(2), history.expectedQuery.OrgID)
+	assert.Equal(t, "tempo", history.expectedQuery.DatasourceUID)
+
+	err = service.callResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodGet, Path: "tag-value-suggestions", URL: "tag-value-suggestions"}, sender, dsInfo)
+	require.EqualError(t, err, "missing tag")
+}
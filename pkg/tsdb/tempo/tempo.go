@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
 
@@ -16,41 +17,166 @@ import (
 	"go.opentelemetry.io/collector/model/otlp"
 
 	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/queryhistory"
 )
 
 type Service struct {
 	im   instancemgmt.InstanceManager
 	tlog log.Logger
+	// queryHistory has the recent queries suggesting the values of the tags
+	queryHistory queryhistory.Service
 }
 
-func ProvideService(httpClientProvider httpclient.Provider) *Service {
+func ProvideService(httpClientProvider httpclient.Provider, queryHistory queryhistory.Service, kvStore kvstore.KVStore) *Service {
 	return &Service{
-		tlog: log.New("tsdb.tempo"),
-		im:   datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
+		tlog:         log.New("tsdb.tempo"),
+		im:           datasource.NewInstanceManager(newInstanceSettings(httpClientProvider, newRateLimitStore(kvStore))),
+		queryHistory: queryHistory,
 	}
 }
 
 type datasourceInfo struct {
 	HTTPClient *http.Client
 	URL        string
+
+	// tls rebuilds the HTTP client when the TLS files of the datasource are rotated, it is nil without TLS files
+	tls *tlsClient
+	// queue limits the concurrent queries when maxConcurrentQueries is set
+	queue *requestQueue
+	// rateLimiter limits the queries of the tenant of the datasource, it is nil when rateLimit.queries is not set
+	rateLimiter *tenantRateLimiter
+	// spanNames normalizes the span names of the service graph queries
+	spanNames *spanNameNormalizer
+	// partitions splits the searches over long time ranges
+	partitions *searchPartitioner
+	// tagTypes caches the types of the tags used to validate the filters of the editor
+	tagTypes *tagTypeCache
+	// maxBytesToScan is the highest estimated cost of the searches, 0 when the searches are not rejected
+	maxBytesToScan int64
+	// dedicatedColumns rewrites the TraceQL queries for the dedicated columns of Tempo, it is nil when none is listed
+	dedicatedColumns *dedicatedColumns
+	// promoted adds the promoted attributes to the search results, it is nil when none is listed
+	promoted *promotedAttributes
+	// traceShards are the time windows of the trace lookups without a time range, shortest first, the lookups are not
+	// split when it is empty
+	traceShards []traceShard
+	// redactor redacts the span attributes before they are returned, it is nil without redaction rules
+	redactor *attributeRedactor
+	// streaming picks the transport of the streaming searches
+	streaming *transportNegotiator
+	// websocket opens the websockets of the streaming searches
+	websocket *websocketClient
+	// pushURL is the distributor the push resource forwards the spans to, it is empty when otlpPush.url is not set
+	pushURL string
+}
+
+type jsonData struct {
+	MaxConcurrentQueries int                     `json:"maxConcurrentQueries"`
+	RateLimit            rateLimitSettings       `json:"rateLimit"`
+	ServiceGraph         serviceGraphSettings    `json:"serviceGraph"`
+	Search               searchSettings          `json:"search"`
+	TraceQuery           traceQuerySettings      `json:"traceQuery"`
+	RedactionRules       []redactionRuleSettings `json:"redactionRules"`
+	Streaming            streamingSettings       `json:"streaming"`
+	OTLPPush             otlpPushSettings        `json:"otlpPush"`
+}
+
+// httpClient returns the client to use for the requests to Tempo
+func (d *datasourceInfo) httpClient() *http.Client {
+	if d.tls != nil {
+		return d.tls.getClient()
+	}
+	return d.HTTPClient
 }
 
-func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+// acquire waits for the queue of the datasource, queries are not queued when the datasource has no limit
+func (d *datasourceInfo) acquire(ctx context.Context, p queryPriority) (func(), error) {
+	if d.queue == nil {
+		return func() {}, nil
+	}
+	return d.queue.acquire(ctx, p)
+}
+
+func newInstanceSettings(httpClientProvider httpclient.Provider, rateLimitStore rateLimitStore) datasource.InstanceFactoryFunc {
 	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		opts, err := settings.HTTPClientOptions()
 		if err != nil {
 			return nil, err
 		}
 
-		client, err := httpClientProvider.New(opts)
+		var jd jsonData
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jd); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
+		model := &datasourceInfo{
+			URL:            settings.URL,
+			tagTypes:       newTagTypeCache(tagTypeCacheTTL),
+			maxBytesToScan: jd.Search.MaxBytesToScan,
+		}
+		if jd.MaxConcurrentQueries > 0 {
+			model.queue = newRequestQueue(jd.MaxConcurrentQueries)
+		}
+		model.rateLimiter, err = newTenantRateLimiter(settings, jd.RateLimit, rateLimitStore)
+		if err != nil {
+			return nil, fmt.Errorf("error reading rate limit settings: %w", err)
+		}
+		model.spanNames, err = newSpanNameNormalizer(jd.ServiceGraph)
+		if err != nil {
+			return nil, fmt.Errorf("error reading service graph settings: %w", err)
+		}
+		model.partitions, err = newSearchPartitioner(jd.Search)
+		if err != nil {
+			return nil, fmt.Errorf("error reading search settings: %w", err)
+		}
+		model.dedicatedColumns, err = newDedicatedColumns(jd.Search.DedicatedColumns)
+		if err != nil {
+			return nil, fmt.Errorf("error reading search settings: %w", err)
+		}
+		model.promoted, err = newPromotedAttributes(jd.Search.PromotedAttributes)
+		if err != nil {
+			return nil, fmt.Errorf("error reading search settings: %w", err)
+		}
+		model.traceShards, err = newTraceShards(jd.TraceQuery)
+		if err != nil {
+			return nil, fmt.Errorf("error reading trace query settings: %w", err)
+		}
+		model.redactor, err = newAttributeRedactor(jd.RedactionRules)
+		if err != nil {
+			return nil, fmt.Errorf("error reading redaction rules: %w", err)
+		}
+		model.pushURL, err = newPushURL(jd.OTLPPush)
+		if err != nil {
+			return nil, fmt.Errorf("error reading OTLP push settings: %w", err)
+		}
+
+		files, err := parseTLSFiles(settings.JSONData)
+		if err != nil {
+			return nil, err
+		}
+		model.streaming, err = newTransportNegotiator(jd.Streaming, !files.empty())
+		if err != nil {
+			return nil, fmt.Errorf("error reading streaming settings: %w", err)
+		}
+		model.websocket, err = newWebsocketClient(opts)
 		if err != nil {
 			return nil, err
 		}
+		if !files.empty() {
+			model.tls, err = newTLSClient(httpClientProvider, opts, files, log.New("tsdb.tempo").New("datasource", settings.UID))
+			if err != nil {
+				return nil, err
+			}
+			return model, nil
+		}
 
-		model := &datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
+		model.HTTPClient, err = httpClientProvider.New(opts)
+		if err != nil {
+			return nil, err
 		}
 		return model, nil
 	}
@@ -58,62 +184,138 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	result := backend.NewQueryDataResponse()
-	queryRes := backend.DataResponse{}
-	refID := req.Queries[0].RefID
-
-	model := &dataquery.TempoQuery{}
-	err := json.Unmarshal(req.Queries[0].JSON, model)
-	if err != nil {
-		return result, err
-	}
 
 	dsInfo, err := s.getDSInfo(req.PluginContext)
 	if err != nil {
 		return nil, err
 	}
 
-	request, err := s.createRequest(ctx, dsInfo, model.Query, req.Queries[0].TimeRange.From.Unix(), req.Queries[0].TimeRange.To.Unix())
-	if err != nil {
-		return result, err
+	priority := parsePriority(req.GetHTTPHeader(headerQueryPriority))
+	for _, q := range req.Queries {
+		model := &dataquery.TempoQuery{}
+		err := json.Unmarshal(q.JSON, model)
+		if err != nil {
+			result.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to unmarshal query: %v", err))
+			continue
+		}
+
+		// the rejected queries don't wait for the queue
+		if err := dsInfo.rateLimiter.allow(ctx); err != nil {
+			result.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusTooManyRequests, err.Error())
+			continue
+		}
+
+		release, err := dsInfo.acquire(ctx, priority)
+		if err != nil {
+			result.Responses[q.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+
+		var res backend.DataResponse
+		switch q.QueryType {
+		case string(dataquery.TempoQueryTypeErrorSummary):
+			res = s.errorSummary(ctx, dsInfo, model, q)
+		case string(dataquery.TempoQueryTypeAttributeStatistics):
+			res = s.attributeStatistics(ctx, dsInfo, model, q)
+		case string(dataquery.TempoQueryTypeServiceGraph):
+			res = s.serviceGraph(ctx, dsInfo, model, q)
+		case string(dataquery.TempoQueryTypeTraceBreakdown):
+			res = s.traceBreakdown(ctx, dsInfo, model, q)
+		case string(dataquery.TempoQueryTypeFlameGraph):
+			res = s.flameGraph(ctx, dsInfo, model, q)
+		default:
+			res, err = s.getTrace(ctx, dsInfo, model, q)
+			if err != nil {
+				// a failed query doesn't fail the other queries of the request
+				res = backend.DataResponse{Error: err}
+			}
+			// the trace frame is only encoded once it's sent, the other query types read its fields
+			for _, frame := range res.Frames {
+				dictionaryEncodeTraceFrame(frame)
+			}
+		}
+		release()
+		inferFieldConfig(res.Frames)
+		result.Responses[q.RefID] = res
 	}
 
-	resp, err := dsInfo.HTTPClient.Do(request)
+	return result, nil
+}
+
+func (s *Service) getTrace(ctx context.Context, dsInfo *datasourceInfo, model *dataquery.TempoQuery, query backend.DataQuery) (backend.DataResponse, error) {
+	queryRes := backend.DataResponse{}
+
+	traceID, format, err := normalizeTraceID(model.Query)
 	if err != nil {
-		return result, fmt.Errorf("failed get to tempo: %w", err)
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error()), nil
 	}
 
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			s.tlog.FromContext(ctx).Warn("failed to close response body", "err", err)
-		}
-	}()
-
-	body, err := io.ReadAll(resp.Body)
+	var resp *traceResponse
+	start, end := query.TimeRange.From.Unix(), query.TimeRange.To.Unix()
+	if (start <= 0 || end <= 0) && len(dsInfo.traceShards) > 0 {
+		resp, err = s.fetchTraceSharded(ctx, dsInfo, traceID, time.Now())
+	} else {
+		resp, err = s.fetchTrace(ctx, dsInfo, traceID, start, end)
+	}
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return queryRes, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		queryRes.Error = fmt.Errorf("failed to get trace with id: %s Status: %s Body: %s", model.Query, resp.Status, string(body))
-		result.Responses[refID] = queryRes
-		return result, nil
+	if resp.statusCode != http.StatusOK {
+		queryRes.Error = fmt.Errorf("failed to get trace with id: %s%s Status: %s Body: %s", describeTraceID(model.Query, traceID, format), resp.window, resp.status, string(resp.body))
+		queryRes.Status = backend.Status(resp.statusCode)
+		return queryRes, nil
 	}
+	body := resp.body
 
 	otTrace, err := otlp.NewProtobufTracesUnmarshaler().UnmarshalTraces(body)
 
 	if err != nil {
-		return &backend.QueryDataResponse{}, fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
+		return queryRes, fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
 	}
 
+	dsInfo.redactor.redactTrace(otTrace)
 	frame, err := TraceToFrame(otTrace)
 	if err != nil {
-		return &backend.QueryDataResponse{}, fmt.Errorf("failed to transform trace %v to data frame: %w", model.Query, err)
+		return queryRes, fmt.Errorf("failed to transform trace %v to data frame: %w", traceID, err)
 	}
-	frame.RefID = refID
-	frames := []*data.Frame{frame}
-	queryRes.Frames = frames
-	result.Responses[refID] = queryRes
-	return result, nil
+	frame.RefID = query.RefID
+	queryRes.Frames = []*data.Frame{frame}
+	return queryRes, nil
+}
+
+// traceResponse is the response of Tempo to a trace lookup
+type traceResponse struct {
+	statusCode int
+	status     string
+	body       []byte
+	// window describes the time range of the lookup in the errors, it is empty for the time range of the query
+	window string
+}
+
+// fetchTrace looks up the trace in the time range, in unix seconds, or without a time range when start or end is 0
+func (s *Service) fetchTrace(ctx context.Context, dsInfo *datasourceInfo, traceID string, start int64, end int64) (*traceResponse, error) {
+	request, err := s.createRequest(ctx, dsInfo, traceID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := dsInfo.httpClient().Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed get to tempo: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.tlog.FromContext(ctx).Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &traceResponse{statusCode: resp.StatusCode, status: resp.Status, body: body}, nil
 }
 
 func (s *Service) createRequest(ctx context.Context, dsInfo *datasourceInfo, traceID string, start int64, end int64) (*http.Request, error) {
@@ -2,9 +2,18 @@ package tempo
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -24,4 +33,67 @@ func TestTempo(t *testing.T) {
 		assert.Equal(t, 1, len(req.Header))
 		assert.Equal(t, "/api/traces/traceID?start=1&end=2", req.URL.String())
 	})
+
+	t.Run("getTrace normalizes the trace ID", func(t *testing.T) {
+		var requested string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = r.URL.Path
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("trace not found"))
+		}))
+		t.Cleanup(srv.Close)
+
+		service := &Service{tlog: log.New("tempo-test")}
+		dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+		res, err := service.getTrace(context.Background(), dsInfo, &dataquery.TempoQuery{Query: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, backend.DataQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, "/api/traces/4bf92f3577b34da6a3ce929d0e0e4736", requested)
+		assert.ErrorContains(t, res.Error, `failed to get trace with id: 4bf92f3577b34da6a3ce929d0e0e4736 (detected format: W3C traceparent, from "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01") Status: 404 Not Found`)
+
+		res, err = service.getTrace(context.Background(), dsInfo, &dataquery.TempoQuery{Query: "not a trace id"}, backend.DataQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, backend.StatusBadRequest, res.Status)
+		assert.ErrorContains(t, res.Error, `invalid trace ID "not a trace id"`)
+	})
+
+	t.Run("QueryData returns the other queries when a query fails", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/api/search") {
+				_, _ = w.Write([]byte(errorSearchResponse))
+				return
+			}
+			// not a protobuf trace
+			_, _ = w.Write([]byte("{"))
+		}))
+		t.Cleanup(srv.Close)
+
+		dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+		service := &Service{
+			tlog: log.New("tempo-test"),
+			im: datasource.NewInstanceManager(func(backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+				return dsInfo, nil
+			}),
+		}
+
+		summary, err := json.Marshal(dataquery.TempoQuery{})
+		require.NoError(t, err)
+		trace, err := json.Marshal(dataquery.TempoQuery{Query: "abc"})
+		require.NoError(t, err)
+		timeRange := backend.TimeRange{From: time.Unix(1000, 0), To: time.Unix(2000, 0)}
+
+		resp, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{
+				{RefID: "A", JSON: []byte("{")},
+				{RefID: "B", JSON: trace, TimeRange: timeRange},
+				{RefID: "C", JSON: summary, QueryType: string(dataquery.TempoQueryTypeErrorSummary), TimeRange: timeRange},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Responses, 3)
+		assert.Equal(t, backend.StatusBadRequest, resp.Responses["A"].Status)
+		assert.ErrorContains(t, resp.Responses["B"].Error, "failed to convert tempo response")
+		require.NoError(t, resp.Responses["C"].Error)
+		assert.Len(t, resp.Responses["C"].Frames, 1)
+	})
 }
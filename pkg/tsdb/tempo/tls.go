@@ -0,0 +1,169 @@
+package tempo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// tlsFilesCheckInterval is how often the TLS files are read again to find out if they were rotated
+const tlsFilesCheckInterval = 10 * time.Second
+
+// tlsFiles are the paths of the PEM files used for TLS, set in the json data of the datasource. They are used instead
+// of the certificates of the secure json data when the certificates are short-lived and rotated by another process,
+// like the sidecar of a service mesh.
+type tlsFiles struct {
+	CACertFile     string `json:"tlsCACertFile"`
+	ClientCertFile string `json:"tlsClientCertFile"`
+	ClientKeyFile  string `json:"tlsClientKeyFile"`
+}
+
+func parseTLSFiles(jsonData json.RawMessage) (tlsFiles, error) {
+	var files tlsFiles
+	if len(jsonData) == 0 {
+		return files, nil
+	}
+	if err := json.Unmarshal(jsonData, &files); err != nil {
+		return files, fmt.Errorf("failed to read TLS files settings: %w", err)
+	}
+	if (files.ClientCertFile == "") != (files.ClientKeyFile == "") {
+		return files, fmt.Errorf("both tlsClientCertFile and tlsClientKeyFile must be set")
+	}
+	// the relative paths would depend on the working directory of the server
+	for _, path := range []string{files.CACertFile, files.ClientCertFile, files.ClientKeyFile} {
+		if path != "" && !filepath.IsAbs(path) {
+			return files, fmt.Errorf("the TLS file %q must be an absolute path", path)
+		}
+	}
+	return files, nil
+}
+
+func (f tlsFiles) empty() bool {
+	return f.CACertFile == "" && f.ClientCertFile == "" && f.ClientKeyFile == ""
+}
+
+// read returns the TLS options with the content of the files, and a checksum of the content to find out if they changed
+func (f tlsFiles) read(opts *sdkhttpclient.TLSOptions) (*sdkhttpclient.TLSOptions, [sha256.Size]byte, error) {
+	var tlsOpts sdkhttpclient.TLSOptions
+	if opts != nil {
+		tlsOpts = *opts
+	}
+
+	var content bytes.Buffer
+	for _, file := range []struct {
+		path  string
+		value *string
+	}{
+		{f.CACertFile, &tlsOpts.CACertificate},
+		{f.ClientCertFile, &tlsOpts.ClientCertificate},
+		{f.ClientKeyFile, &tlsOpts.ClientKey},
+	} {
+		if file.path == "" {
+			continue
+		}
+		b, err := os.ReadFile(file.path)
+		if err != nil {
+			return nil, [sha256.Size]byte{}, fmt.Errorf("failed to read TLS file: %w", err)
+		}
+		*file.value = string(b)
+		content.Write(b)
+	}
+
+	// the certificate and the key are not written at the same time, a mismatch is retried on the next check
+	if f.ClientCertFile != "" {
+		if _, err := tls.X509KeyPair([]byte(tlsOpts.ClientCertificate), []byte(tlsOpts.ClientKey)); err != nil {
+			return nil, [sha256.Size]byte{}, fmt.Errorf("invalid TLS client certificate: %w", err)
+		}
+	}
+	return &tlsOpts, sha256.Sum256(content.Bytes()), nil
+}
+
+// tlsClient is the HTTP client of a datasource with TLS files. It rebuilds the client when the content of the files
+// changes, so that rotated certificates are used without saving the datasource.
+type tlsClient struct {
+	mu             sync.Mutex
+	clientProvider httpclient.Provider
+	opts           sdkhttpclient.Options
+	files          tlsFiles
+	logger         log.Logger
+	checkInterval  time.Duration
+	now            func() time.Time
+
+	client    *http.Client
+	checksum  [sha256.Size]byte
+	lastCheck time.Time
+}
+
+func newTLSClient(clientProvider httpclient.Provider, opts sdkhttpclient.Options, files tlsFiles, logger log.Logger) (*tlsClient, error) {
+	c := &tlsClient{
+		clientProvider: clientProvider,
+		opts:           opts,
+		files:          files,
+		logger:         logger,
+		checkInterval:  tlsFilesCheckInterval,
+		now:            time.Now,
+	}
+
+	tlsOpts, checksum, err := files.read(opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+	client, err := c.newClient(tlsOpts)
+	if err != nil {
+		return nil, err
+	}
+	c.client, c.checksum, c.lastCheck = client, checksum, c.now()
+	return c, nil
+}
+
+func (c *tlsClient) newClient(tlsOpts *sdkhttpclient.TLSOptions) (*http.Client, error) {
+	opts := c.opts
+	opts.TLS = tlsOpts
+	return c.clientProvider.New(opts)
+}
+
+// getClient returns the client with the current certificates. The files are checked at most every checkInterval and
+// the previous client is kept when they cannot be read.
+func (c *tlsClient) getClient() *http.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastCheck) < c.checkInterval {
+		return c.client
+	}
+	c.lastCheck = now
+
+	tlsOpts, checksum, err := c.files.read(c.opts.TLS)
+	if err != nil {
+		c.logger.Warn("Failed to reload TLS files, using the previous certificates", "err", err)
+		return c.client
+	}
+	if checksum == c.checksum {
+		return c.client
+	}
+
+	client, err := c.newClient(tlsOpts)
+	if err != nil {
+		c.logger.Warn("Failed to create HTTP client with the rotated TLS certificates", "err", err)
+		return c.client
+	}
+	c.logger.Info("TLS certificates changed, using a new HTTP client")
+
+	// requests in flight keep their connections, idle connections with the previous certificates are closed
+	c.client.CloseIdleConnections()
+	c.client, c.checksum = client, checksum
+	return c.client
+}
@@ -0,0 +1,123 @@
+package tempo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func writeClientCertificate(t *testing.T, dir string, commonName string) tlsFiles {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	files := tlsFiles{
+		ClientCertFile: filepath.Join(dir, "tls.crt"),
+		ClientKeyFile:  filepath.Join(dir, "tls.key"),
+	}
+	require.NoError(t, os.WriteFile(files.ClientCertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600))
+	require.NoError(t, os.WriteFile(files.ClientKeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600))
+	return files
+}
+
+func TestParseTLSFiles(t *testing.T) {
+	files, err := parseTLSFiles([]byte(`{"tlsCACertFile": "/ca.crt", "tlsClientCertFile": "/tls.crt", "tlsClientKeyFile": "/tls.key"}`))
+	require.NoError(t, err)
+	assert.Equal(t, tlsFiles{CACertFile: "/ca.crt", ClientCertFile: "/tls.crt", ClientKeyFile: "/tls.key"}, files)
+
+	files, err = parseTLSFiles([]byte(`{"tracesToLogs": {}}`))
+	require.NoError(t, err)
+	assert.True(t, files.empty())
+
+	_, err = parseTLSFiles([]byte(`{"tlsCACertFile": "../ca.crt"}`))
+	require.ErrorContains(t, err, "must be an absolute path")
+
+	_, err = parseTLSFiles([]byte(`{"tlsClientCertFile": "/tls.crt"}`))
+	assert.Error(t, err)
+}
+
+func TestTLSClient(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	files := writeClientCertificate(t, dir, "first")
+	files.CACertFile = filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(files.CACertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600))
+
+	c, err := newTLSClient(httpclient.NewProvider(), sdkhttpclient.Options{}, files, log.New("tempo-test"))
+	require.NoError(t, err)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	commonName := func() string {
+		t.Helper()
+		resp, err := c.getClient().Get(srv.URL)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	require.Equal(t, "first", commonName())
+
+	// the files are not checked before the interval
+	writeClientCertificate(t, dir, "second")
+	assert.Equal(t, "first", commonName())
+
+	now = now.Add(tlsFilesCheckInterval)
+	assert.Equal(t, "second", commonName())
+
+	// the previous client is used when the certificate and the key do not match
+	client := c.getClient()
+	require.NoError(t, os.WriteFile(files.ClientKeyFile, []byte("invalid"), 0600))
+	now = now.Add(tlsFilesCheckInterval)
+	assert.Same(t, client, c.getClient())
+	assert.Equal(t, "second", commonName())
+
+	// the files did not change
+	writeClientCertificate(t, dir, "third")
+	now = now.Add(tlsFilesCheckInterval)
+	client = c.getClient()
+	now = now.Add(tlsFilesCheckInterval)
+	assert.Same(t, client, c.getClient())
+}
+
+func TestTLSClientMissingFiles(t *testing.T) {
+	_, err := newTLSClient(httpclient.NewProvider(), sdkhttpclient.Options{}, tlsFiles{CACertFile: "/does/not/exist"}, log.New("tempo-test"))
+	assert.Error(t, err)
+}
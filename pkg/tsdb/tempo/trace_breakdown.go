@@ -0,0 +1,235 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+// breakdownSpan is a span of the trace and the spans it started, the times are in milliseconds
+type breakdownSpan struct {
+	spanID        string
+	parentSpanID  string
+	serviceName   string
+	operationName string
+	start         float64
+	end           float64
+	children      []*breakdownSpan
+	// selfTime is the time of the span not covered by its children
+	selfTime float64
+}
+
+type breakdownKey struct {
+	serviceName   string
+	operationName string
+}
+
+type breakdownRow struct {
+	breakdownKey
+	selfTime  float64
+	spanCount int64
+}
+
+// traceBreakdown looks up the trace of the query and computes the self time of its services and operations, the time
+// their spans didn't wait for the spans they started, to show where the time of the trace went
+func (s *Service) traceBreakdown(ctx context.Context, dsInfo *datasourceInfo, model *dataquery.TempoQuery, query backend.DataQuery) backend.DataResponse {
+	res, err := s.getTrace(ctx, dsInfo, model, query)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	if res.Error != nil || len(res.Frames) == 0 {
+		return res
+	}
+
+	roots, err := breakdownSpansFromFrame(res.Frames[0])
+	if err != nil {
+		return backend.DataResponse{Error: fmt.Errorf("failed to compute the breakdown of trace %v: %w", model.Query, err)}
+	}
+
+	frames := data.Frames{
+		breakdownToFrame("Self time by service", roots, false),
+		breakdownToFrame("Self time by operation", roots, true),
+		breakdownToFlameGraphFrame(roots),
+	}
+	for _, frame := range frames {
+		frame.RefID = query.RefID
+	}
+	return backend.DataResponse{Frames: frames}
+}
+
+// breakdownSpansFromFrame returns the root spans of the trace frame, the spans whose parent is not in the trace, with
+// their self time
+func breakdownSpansFromFrame(frame *data.Frame) ([]*breakdownSpan, error) {
+	fields := map[string]*data.Field{}
+	for _, name := range []string{"spanID", "parentSpanID", "serviceName", "operationName", "startTime", "duration"} {
+		field, _ := frame.FieldByName(name)
+		if field == nil {
+			return nil, fmt.Errorf("the trace frame has no %s field", name)
+		}
+		fields[name] = field
+	}
+
+	spans := make([]*breakdownSpan, 0, frame.Rows())
+	byID := make(map[string]*breakdownSpan, frame.Rows())
+	for i := 0; i < frame.Rows(); i++ {
+		start, _ := fields["startTime"].FloatAt(i)
+		duration, _ := fields["duration"].FloatAt(i)
+		span := &breakdownSpan{
+			spanID:        fmt.Sprint(fields["spanID"].At(i)),
+			parentSpanID:  fmt.Sprint(fields["parentSpanID"].At(i)),
+			serviceName:   fmt.Sprint(fields["serviceName"].At(i)),
+			operationName: fmt.Sprint(fields["operationName"].At(i)),
+			start:         start,
+			end:           start + duration,
+		}
+		spans = append(spans, span)
+		byID[span.spanID] = span
+	}
+
+	var roots []*breakdownSpan
+	for _, span := range spans {
+		if parent, ok := byID[span.parentSpanID]; ok && parent != span {
+			parent.children = append(parent.children, span)
+		} else {
+			roots = append(roots, span)
+		}
+	}
+	for _, span := range spans {
+		sort.SliceStable(span.children, func(i, j int) bool {
+			return span.children[i].start < span.children[j].start
+		})
+		span.selfTime = span.end - span.start - childrenTime(span)
+	}
+	sort.SliceStable(roots, func(i, j int) bool {
+		return roots[i].start < roots[j].start
+	})
+	return roots, nil
+}
+
+// childrenTime returns the time of the span covered by at least one of its children, the children are sorted by start
+// time. The concurrent children are only counted once and the time of the children after the end of the span, like
+// the asynchronous spans, is not counted.
+func childrenTime(span *breakdownSpan) float64 {
+	covered := 0.0
+	// coveredUntil is the end of the covered time up to the current child
+	coveredUntil := span.start
+	for _, child := range span.children {
+		start, end := child.start, child.end
+		if start < coveredUntil {
+			start = coveredUntil
+		}
+		if end > span.end {
+			end = span.end
+		}
+		if end > start {
+			covered += end - start
+			coveredUntil = end
+		}
+	}
+	return covered
+}
+
+// walkBreakdown visits the spans depth first, with the level of the span in the trace
+func walkBreakdown(spans []*breakdownSpan, level int64, visit func(span *breakdownSpan, level int64)) {
+	for _, span := range spans {
+		visit(span, level)
+		walkBreakdown(span.children, level+1, visit)
+	}
+}
+
+// breakdownToFrame returns the self time of the services, or of the operations of the services with byOperation
+func breakdownToFrame(name string, roots []*breakdownSpan, byOperation bool) *data.Frame {
+	rows := map[breakdownKey]*breakdownRow{}
+	total := 0.0
+	walkBreakdown(roots, 0, func(span *breakdownSpan, _ int64) {
+		key := breakdownKey{serviceName: span.serviceName}
+		if byOperation {
+			key.operationName = span.operationName
+		}
+		row, ok := rows[key]
+		if !ok {
+			row = &breakdownRow{breakdownKey: key}
+			rows[key] = row
+		}
+		row.selfTime += span.selfTime
+		row.spanCount++
+		total += span.selfTime
+	})
+
+	sorted := make([]*breakdownRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, row)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].selfTime != sorted[j].selfTime {
+			return sorted[i].selfTime > sorted[j].selfTime
+		}
+		if sorted[i].serviceName != sorted[j].serviceName {
+			return sorted[i].serviceName < sorted[j].serviceName
+		}
+		return sorted[i].operationName < sorted[j].operationName
+	})
+
+	selfTime := data.NewField("selfTime", nil, make([]float64, 0, len(sorted)))
+	selfTime.Config = &data.FieldConfig{Unit: "ms"}
+	percent := data.NewField("percent", nil, make([]float64, 0, len(sorted)))
+	percent.Config = &data.FieldConfig{Unit: "percent"}
+	serviceName := data.NewField("serviceName", nil, make([]string, 0, len(sorted)))
+	operationName := data.NewField("operationName", nil, make([]string, 0, len(sorted)))
+	spanCount := data.NewField("spanCount", nil, make([]int64, 0, len(sorted)))
+	for _, row := range sorted {
+		share := 0.0
+		if total > 0 {
+			share = row.selfTime / total * 100
+		}
+		serviceName.Append(row.serviceName)
+		operationName.Append(row.operationName)
+		selfTime.Append(row.selfTime)
+		spanCount.Append(row.spanCount)
+		percent.Append(share)
+	}
+
+	frame := data.NewFrame(name, serviceName, selfTime, spanCount, percent)
+	if byOperation {
+		frame = data.NewFrame(name, serviceName, operationName, selfTime, spanCount, percent)
+	}
+	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+	return frame
+}
+
+// breakdownToFlameGraphFrame returns the spans in the nested set format of the flame graphs, [level, value, self,
+// label] in depth first order, under a total root. The value of a span is the self time of the span and of the spans
+// it started, not its duration, so the concurrent children of a span fit in the span.
+func breakdownToFlameGraphFrame(roots []*breakdownSpan) *data.Frame {
+	values := map[*breakdownSpan]float64{}
+	var subtreeTime func(span *breakdownSpan) float64
+	subtreeTime = func(span *breakdownSpan) float64 {
+		value := span.selfTime
+		for _, child := range span.children {
+			value += subtreeTime(child)
+		}
+		values[span] = value
+		return value
+	}
+	total := 0.0
+	for _, root := range roots {
+		total += subtreeTime(root)
+	}
+
+	value := data.NewField("value", nil, []float64{})
+	value.Config = &data.FieldConfig{Unit: "ms"}
+	self := data.NewField("self", nil, []float64{})
+	self.Config = &data.FieldConfig{Unit: "ms"}
+	frame := data.NewFrame("Trace breakdown", data.NewField("level", nil, []int64{}), value, self, data.NewField("label", nil, []string{}))
+	frame.AppendRow(int64(0), total, 0.0, "total")
+	walkBreakdown(roots, 1, func(span *breakdownSpan, level int64) {
+		frame.AppendRow(level, values[span], span.selfTime, span.serviceName+": "+span.operationName)
+	})
+	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeFlameGraph})
+	return frame
+}
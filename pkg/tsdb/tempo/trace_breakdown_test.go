@@ -0,0 +1,103 @@
+package tempo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func breakdownTraceFrame(spans ...[]interface{}) *data.Frame {
+	frame := data.NewFrame("Trace",
+		data.NewField("traceID", nil, []string{}),
+		data.NewField("spanID", nil, []string{}),
+		data.NewField("parentSpanID", nil, []string{}),
+		data.NewField("operationName", nil, []string{}),
+		data.NewField("serviceName", nil, []string{}),
+		data.NewField("startTime", nil, []float64{}),
+		data.NewField("duration", nil, []float64{}),
+		data.NewField("tags", nil, []json.RawMessage{}),
+	)
+	for _, span := range spans {
+		frame.AppendRow(append([]interface{}{"trace"}, append(span, json.RawMessage(`[]`))...)...)
+	}
+	return frame
+}
+
+func TestTraceBreakdown(t *testing.T) {
+	// the frontend waits for two concurrent api calls, the second api call waits for the db and finishes late with an
+	// asynchronous span after the end of the call
+	frame := breakdownTraceFrame(
+		[]interface{}{"root", "", "GET /", "frontend", 0.0, 100.0},
+		[]interface{}{"call1", "root", "GET /orders", "api", 10.0, 40.0},
+		[]interface{}{"call2", "root", "GET /users", "api", 30.0, 40.0},
+		[]interface{}{"db", "call2", "SELECT", "db", 35.0, 20.0},
+		[]interface{}{"async", "call2", "publish", "api", 60.0, 50.0},
+		[]interface{}{"orphan", "missing", "cron", "worker", 200.0, 5.0},
+	)
+
+	roots, err := breakdownSpansFromFrame(frame)
+	require.NoError(t, err)
+	require.Len(t, roots, 2)
+	assert.Equal(t, "root", roots[0].spanID)
+	assert.Equal(t, "orphan", roots[1].spanID)
+
+	t.Run("the concurrent and late children are not counted twice", func(t *testing.T) {
+		// the calls cover 10 to 70 ms of the root span
+		assert.Equal(t, 40.0, roots[0].selfTime)
+		// the asynchronous span covers 60 to 70 ms of the second call, the db 35 to 55 ms
+		assert.Equal(t, 10.0, roots[0].children[1].selfTime)
+		assert.Equal(t, 40.0, roots[0].children[0].selfTime)
+	})
+
+	t.Run("the self time is aggregated by service", func(t *testing.T) {
+		frame := breakdownToFrame("Self time by service", roots, false)
+		assert.Equal(t, []string{"serviceName", "selfTime", "spanCount", "percent"}, fieldNames(frame))
+		require.Equal(t, 4, frame.Rows())
+		assert.Equal(t, "api", frame.Fields[0].At(0))
+		// the calls and the asynchronous span
+		assert.Equal(t, 100.0, frame.Fields[1].At(0))
+		assert.Equal(t, int64(3), frame.Fields[2].At(0))
+		assert.InDelta(t, 100.0/165*100, frame.Fields[3].At(0), 0.001)
+		assert.Equal(t, "frontend", frame.Fields[0].At(1))
+		assert.Equal(t, "db", frame.Fields[0].At(2))
+		assert.Equal(t, "worker", frame.Fields[0].At(3))
+	})
+
+	t.Run("the self time is aggregated by operation", func(t *testing.T) {
+		frame := breakdownToFrame("Self time by operation", roots, true)
+		assert.Equal(t, []string{"serviceName", "operationName", "selfTime", "spanCount", "percent"}, fieldNames(frame))
+		require.Equal(t, 6, frame.Rows())
+		assert.Equal(t, "api", frame.Fields[0].At(0))
+		assert.Equal(t, "publish", frame.Fields[1].At(0))
+		assert.Equal(t, 50.0, frame.Fields[2].At(0))
+	})
+
+	t.Run("the spans are returned in the nested set format of the flame graphs", func(t *testing.T) {
+		frame := breakdownToFlameGraphFrame(roots)
+		assert.Equal(t, data.VisTypeFlameGraph, string(frame.Meta.PreferredVisualization))
+		assert.Equal(t, []string{"level", "value", "self", "label"}, fieldNames(frame))
+		require.Equal(t, 7, frame.Rows())
+
+		rows := make([][]interface{}, 0, frame.Rows())
+		for i := 0; i < frame.Rows(); i++ {
+			rows = append(rows, frame.RowCopy(i))
+		}
+		assert.Equal(t, [][]interface{}{
+			{int64(0), 165.0, 0.0, "total"},
+			{int64(1), 160.0, 40.0, "frontend: GET /"},
+			{int64(2), 40.0, 40.0, "api: GET /orders"},
+			{int64(2), 80.0, 10.0, "api: GET /users"},
+			{int64(3), 20.0, 20.0, "db: SELECT"},
+			{int64(3), 50.0, 50.0, "api: publish"},
+			{int64(1), 5.0, 5.0, "worker: cron"},
+		}, rows)
+	})
+
+	t.Run("the frames without the span fields are rejected", func(t *testing.T) {
+		_, err := breakdownSpansFromFrame(data.NewFrame("Trace", data.NewField("spanID", nil, []string{})))
+		require.EqualError(t, err, "the trace frame has no parentSpanID field")
+	})
+}
@@ -0,0 +1,62 @@
+package tempo
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// traceIDFormat is the format a trace ID was given in, reported in the errors of the trace queries
+type traceIDFormat string
+
+const (
+	traceIDFormatHex128      traceIDFormat = "128-bit hex"
+	traceIDFormatHex64       traceIDFormat = "64-bit hex"
+	traceIDFormatTraceparent traceIDFormat = "W3C traceparent"
+	traceIDFormatBase64      traceIDFormat = "base64"
+)
+
+var (
+	hexTraceIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{1,32}$`)
+	// traceparentRegex matches the traceparent header of the W3C trace context, version-traceid-parentid-flags
+	traceparentRegex = regexp.MustCompile(`^[0-9a-fA-F]{2}-([0-9a-fA-F]{32})-[0-9a-fA-F]{16}-[0-9a-fA-F]{2}$`)
+	// base64Encodings are the encodings of the IDs of the OTLP JSON payloads and of the URL-safe variants
+	base64Encodings = []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding}
+)
+
+// normalizeTraceID returns the trace ID as the 32 lowercase hex characters Tempo stores, and the format it was given
+// in. The 64-bit IDs, such as the IDs of Jaeger or Zipkin clients, are padded with zeros like Tempo does.
+func normalizeTraceID(id string) (string, traceIDFormat, error) {
+	id = strings.TrimSpace(id)
+	if hexTraceIDRegex.MatchString(id) {
+		format := traceIDFormatHex128
+		if len(id) <= 16 {
+			format = traceIDFormatHex64
+		}
+		return padTraceID(strings.ToLower(id)), format, nil
+	}
+	if m := traceparentRegex.FindStringSubmatch(id); m != nil {
+		return strings.ToLower(m[1]), traceIDFormatTraceparent, nil
+	}
+	for _, encoding := range base64Encodings {
+		b, err := encoding.DecodeString(id)
+		if err == nil && (len(b) == 16 || len(b) == 8) {
+			return padTraceID(hex.EncodeToString(b)), traceIDFormatBase64, nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid trace ID %q: expected a 128-bit or 64-bit hex ID, a W3C traceparent or a base64-encoded ID", id)
+}
+
+func padTraceID(id string) string {
+	return strings.Repeat("0", 32-len(id)) + id
+}
+
+// describeTraceID returns the trace ID with the format it was given in, for the errors of the trace queries
+func describeTraceID(original string, normalized string, format traceIDFormat) string {
+	if strings.TrimSpace(original) == normalized {
+		return normalized
+	}
+	return fmt.Sprintf("%s (detected format: %s, from %q)", normalized, format, strings.TrimSpace(original))
+}
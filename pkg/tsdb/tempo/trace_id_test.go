@@ -0,0 +1,46 @@
+package tempo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTraceID(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		expected string
+		format   traceIDFormat
+	}{
+		{name: "128-bit hex", id: "4BF92F3577B34DA6A3CE929D0E0E4736", expected: "4bf92f3577b34da6a3ce929d0e0e4736", format: traceIDFormatHex128},
+		{name: "128-bit hex without leading zeros", id: "f92f3577b34da6a3ce929d0e0e4736", expected: "00f92f3577b34da6a3ce929d0e0e4736", format: traceIDFormatHex128},
+		{name: "64-bit hex", id: "a3ce929d0e0e4736", expected: "0000000000000000a3ce929d0e0e4736", format: traceIDFormatHex64},
+		{name: "surrounding spaces", id: " a3ce929d0e0e4736\n", expected: "0000000000000000a3ce929d0e0e4736", format: traceIDFormatHex64},
+		{name: "traceparent", id: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expected: "4bf92f3577b34da6a3ce929d0e0e4736", format: traceIDFormatTraceparent},
+		{name: "base64", id: "S/kvNXezTaajzpKdDg5HNg==", expected: "4bf92f3577b34da6a3ce929d0e0e4736", format: traceIDFormatBase64},
+		{name: "url-safe base64 without padding", id: "S_kvNXezTaajzpKdDg5HNg", expected: "4bf92f3577b34da6a3ce929d0e0e4736", format: traceIDFormatBase64},
+		{name: "64-bit base64", id: "o86SnQ4ORzY=", expected: "0000000000000000a3ce929d0e0e4736", format: traceIDFormatBase64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, format, err := normalizeTraceID(tt.id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, id)
+			assert.Equal(t, tt.format, format)
+		})
+	}
+
+	t.Run("invalid IDs", func(t *testing.T) {
+		for _, id := range []string{"", "not a trace id", "4bf92f3577b34da6a3ce929d0e0e47361", "01-4bf92f3577b34da6a3ce929d0e0e4736-01", "dGVzdA=="} {
+			_, _, err := normalizeTraceID(id)
+			assert.ErrorContains(t, err, "expected a 128-bit or 64-bit hex ID, a W3C traceparent or a base64-encoded ID", id)
+		}
+	})
+}
+
+func TestDescribeTraceID(t *testing.T) {
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", describeTraceID("4bf92f3577b34da6a3ce929d0e0e4736", "4bf92f3577b34da6a3ce929d0e0e4736", traceIDFormatHex128))
+	assert.Equal(t, `4bf92f3577b34da6a3ce929d0e0e4736 (detected format: base64, from "S/kvNXezTaajzpKdDg5HNg==")`, describeTraceID("S/kvNXezTaajzpKdDg5HNg==", "4bf92f3577b34da6a3ce929d0e0e4736", traceIDFormatBase64))
+}
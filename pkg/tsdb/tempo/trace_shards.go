@@ -0,0 +1,95 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// traceQuerySettings are the options of the trace ID queries, read from the traceQuery object of jsonData. The
+// frontend reads the time shift options of the object.
+type traceQuerySettings struct {
+	// TimeShards are the windows, such as 1h or 7d, ending now in which the trace ID queries without a time range look
+	// up the trace concurrently. Tempo looks up the trace in all its blocks when it isn't set.
+	TimeShards []string `json:"timeShards"`
+}
+
+// traceShard is a window ending now of the trace lookups without a time range
+type traceShard struct {
+	duration time.Duration
+	// name is the duration as configured, such as 7d
+	name string
+}
+
+func newTraceShards(settings traceQuerySettings) ([]traceShard, error) {
+	shards := make([]traceShard, 0, len(settings.TimeShards))
+	for _, shard := range settings.TimeShards {
+		shard = strings.TrimSpace(shard)
+		d, err := gtime.ParseDuration(shard)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time shard %q: %w", shard, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid time shard %q: the duration must be positive", shard)
+		}
+		shards = append(shards, traceShard{duration: d, name: shard})
+	}
+	sort.Slice(shards, func(i, j int) bool { return shards[i].duration < shards[j].duration })
+	return shards, nil
+}
+
+// fetchTraceSharded looks up the trace in each time shard of the data source ending at now concurrently, and returns
+// the first response finding it. The lookups still running are canceled. When no lookup finds the trace, the response
+// of the longest shard is returned, or the first error when a lookup failed.
+func (s *Service) fetchTraceSharded(ctx context.Context, dsInfo *datasourceInfo, traceID string, now time.Time) (*traceResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type shardResult struct {
+		shard int
+		resp  *traceResponse
+		err   error
+	}
+	// buffered, so that the canceled lookups don't block
+	results := make(chan shardResult, len(dsInfo.traceShards))
+	for i, shard := range dsInfo.traceShards {
+		i, shard := i, shard
+		go func() {
+			resp, err := s.fetchTrace(ctx, dsInfo, traceID, now.Add(-shard.duration).Unix(), now.Unix())
+			results <- shardResult{shard: i, resp: resp, err: err}
+		}()
+	}
+
+	responses := make([]*traceResponse, len(dsInfo.traceShards))
+	var firstErr error
+	for range dsInfo.traceShards {
+		result := <-results
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			continue
+		}
+		if result.resp.statusCode == http.StatusOK {
+			s.tlog.FromContext(ctx).Debug("Found trace in time shard", "shard", dsInfo.traceShards[result.shard].name)
+			return result.resp, nil
+		}
+		responses[result.shard] = result.resp
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	windows := make([]string, 0, len(dsInfo.traceShards))
+	for _, shard := range dsInfo.traceShards {
+		windows = append(windows, shard.name)
+	}
+	resp := responses[len(responses)-1]
+	resp.window = fmt.Sprintf(" in the last %s", strings.Join(windows, ", "))
+	return resp, nil
+}
@@ -0,0 +1,115 @@
+package tempo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+func TestNewTraceShards(t *testing.T) {
+	shards, err := newTraceShards(traceQuerySettings{TimeShards: []string{"7d", "1h", " 24h "}})
+	require.NoError(t, err)
+	assert.Equal(t, []traceShard{{duration: time.Hour, name: "1h"}, {duration: 24 * time.Hour, name: "24h"}, {duration: 7 * 24 * time.Hour, name: "7d"}}, shards)
+
+	_, err = newTraceShards(traceQuerySettings{TimeShards: []string{"1h", "soon"}})
+	require.ErrorContains(t, err, `invalid time shard "soon"`)
+	_, err = newTraceShards(traceQuerySettings{TimeShards: []string{"0s"}})
+	require.ErrorContains(t, err, "the duration must be positive")
+}
+
+func TestGetTraceWithTimeShards(t *testing.T) {
+	proto, err := os.ReadFile("testData/tempo_proto_response")
+	require.NoError(t, err)
+
+	shards, err := newTraceShards(traceQuerySettings{TimeShards: []string{"1h", "24h", "7d"}})
+	require.NoError(t, err)
+
+	// windows are the durations of the lookups in hours
+	newServer := func(t *testing.T, found func(hours int64) bool) (*datasourceInfo, func() []int64) {
+		t.Helper()
+		var mu sync.Mutex
+		var windows []int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+			end, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+			hours := (end - start) / 3600
+			mu.Lock()
+			windows = append(windows, hours)
+			mu.Unlock()
+			if found(hours) {
+				_, _ = w.Write(proto)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("trace not found"))
+		}))
+		t.Cleanup(srv.Close)
+		return &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL, traceShards: shards}, func() []int64 {
+			mu.Lock()
+			defer mu.Unlock()
+			return windows
+		}
+	}
+
+	service := &Service{tlog: log.New("tempo-test")}
+	model := &dataquery.TempoQuery{Query: "4bf92f3577b34da6a3ce929d0e0e4736"}
+	noTimeRange := backend.DataQuery{TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(0, 0)}}
+
+	t.Run("returns the trace found in a shard", func(t *testing.T) {
+		dsInfo, _ := newServer(t, func(hours int64) bool { return hours == 24*7 })
+		res, err := service.getTrace(context.Background(), dsInfo, model, noTimeRange)
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+	})
+
+	t.Run("lists the shards when the trace isn't found", func(t *testing.T) {
+		dsInfo, windows := newServer(t, func(int64) bool { return false })
+		res, err := service.getTrace(context.Background(), dsInfo, model, noTimeRange)
+		require.NoError(t, err)
+		assert.EqualError(t, res.Error, "failed to get trace with id: 4bf92f3577b34da6a3ce929d0e0e4736 in the last 1h, 24h, 7d Status: 404 Not Found Body: trace not found")
+		assert.ElementsMatch(t, []int64{1, 24, 24 * 7}, windows())
+	})
+
+	t.Run("uses the time range of the query", func(t *testing.T) {
+		dsInfo, windows := newServer(t, func(int64) bool { return true })
+		res, err := service.getTrace(context.Background(), dsInfo, model, backend.DataQuery{TimeRange: backend.TimeRange{From: time.Unix(3600, 0), To: time.Unix(3*3600, 0)}})
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		assert.Equal(t, []int64{2}, windows())
+	})
+
+	t.Run("fails when a lookup fails and no shard has the trace", func(t *testing.T) {
+		dsInfo, _ := newServer(t, func(int64) bool { return false })
+		transport := dsInfo.HTTPClient.Transport
+		dsInfo.HTTPClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+			end, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+			if end-start == 3600 {
+				return nil, errors.New("connection refused")
+			}
+			return transport.RoundTrip(r)
+		})}
+		_, err := service.getTrace(context.Background(), dsInfo, model, noTimeRange)
+		require.ErrorContains(t, err, "connection refused")
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
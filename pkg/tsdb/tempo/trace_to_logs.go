@@ -0,0 +1,402 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+// traceLogsRefID is the refId of the logs query of a trace
+const traceLogsRefID = "logs"
+
+// defaultTraceLogsTags are the tags of the spans used in the logs query when the trace to logs settings have no tags,
+// the same as in the trace view
+var defaultTraceLogsTags = []traceToLogsTag{{Key: "cluster"}, {Key: "hostname"}, {Key: "namespace"}, {Key: "pod"}}
+
+// traceToLogsTag is a tag of the spans used in the logs query, with the name of the label of the logs when it is
+// different from the name of the tag
+type traceToLogsTag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// traceToLogsSettings are the trace to logs settings of a Tempo data source
+type traceToLogsSettings struct {
+	DatasourceUID      string           `json:"datasourceUid"`
+	Tags               []traceToLogsTag `json:"tags"`
+	SpanStartTimeShift string           `json:"spanStartTimeShift"`
+	SpanEndTimeShift   string           `json:"spanEndTimeShift"`
+	FilterByTraceID    bool             `json:"filterByTraceID"`
+	CustomQuery        bool             `json:"customQuery"`
+	Query              string           `json:"query"`
+}
+
+// legacyTraceToLogsSettings are the deprecated trace to logs settings, used when the data source has no tracesToLogsV2
+type legacyTraceToLogsSettings struct {
+	DatasourceUID      string           `json:"datasourceUid"`
+	Tags               []string         `json:"tags"`
+	MappedTags         []traceToLogsTag `json:"mappedTags"`
+	MapTagNamesEnabled bool             `json:"mapTagNamesEnabled"`
+	SpanStartTimeShift string           `json:"spanStartTimeShift"`
+	SpanEndTimeShift   string           `json:"spanEndTimeShift"`
+	FilterByTraceID    bool             `json:"filterByTraceID"`
+}
+
+// TraceLogsQuery is the response of the trace-logs-query resource, the query of the logs of a trace in the logs data
+// source of the trace to logs settings. It has the shape of the body of the query API, so that it can be run as is.
+type TraceLogsQuery struct {
+	From    string                   `json:"from"`
+	To      string                   `json:"to"`
+	Queries []map[string]interface{} `json:"queries"`
+}
+
+// traceLogsQueryError is a failure of the trace-logs-query resource returned with its status
+type traceLogsQueryError struct {
+	status int
+	err    error
+}
+
+func (e *traceLogsQueryError) Error() string {
+	return e.err.Error()
+}
+
+// sendTraceLogsQuery sends the logs query of the trace, the failures caused by the request or by the settings are
+// sent with their status instead of failing the resource call
+func (s *Service) sendTraceLogsQuery(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender, dsInfo *datasourceInfo) error {
+	query, err := s.traceLogsQuery(ctx, req, dsInfo)
+	var queryErr *traceLogsQueryError
+	if errors.As(err, &queryErr) {
+		body, err := json.Marshal(map[string]string{"message": queryErr.Error()})
+		if err != nil {
+			return err
+		}
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  queryErr.status,
+			Headers: map[string][]string{"content-type": {"application/json"}},
+			Body:    body,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return sendJSON(sender, query)
+}
+
+// traceLogsQuery looks up the trace of the traceId parameter and returns the LogQL query of its logs, with the same
+// query as the logs links of the trace view. The from and to parameters are the time range of the lookup in epoch
+// milliseconds, the trace is looked up without time range when they are not set. The limit parameter is the maximum
+// number of log lines.
+func (s *Service) traceLogsQuery(ctx context.Context, req *backend.CallResourceRequest, dsInfo *datasourceInfo) (*TraceLogsQuery, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URL: %s", req.URL)
+	}
+	params := u.Query()
+	traceID := params.Get("traceId")
+	if traceID == "" {
+		return nil, &traceLogsQueryError{http.StatusBadRequest, errors.New("the trace ID is required")}
+	}
+	var from, to, limit int64
+	for _, p := range []struct {
+		name  string
+		value *int64
+	}{{"from", &from}, {"to", &to}, {"limit", &limit}} {
+		if v := params.Get(p.name); v != "" {
+			if *p.value, err = strconv.ParseInt(v, 10, 64); err != nil || *p.value < 0 {
+				return nil, &traceLogsQueryError{http.StatusBadRequest, fmt.Errorf("invalid %s %q, expected a positive number", p.name, v)}
+			}
+		}
+	}
+
+	var jsonData json.RawMessage
+	if req.PluginContext.DataSourceInstanceSettings != nil {
+		jsonData = req.PluginContext.DataSourceInstanceSettings.JSONData
+	}
+	settings, err := getTraceToLogsSettings(jsonData)
+	if err != nil {
+		return nil, &traceLogsQueryError{http.StatusBadRequest, fmt.Errorf("invalid trace to logs settings: %w", err)}
+	}
+	if settings.DatasourceUID == "" {
+		return nil, &traceLogsQueryError{http.StatusBadRequest, errors.New("the data source has no logs data source in its trace to logs settings")}
+	}
+
+	res, err := s.getTrace(ctx, dsInfo, &dataquery.TempoQuery{Query: traceID}, backend.DataQuery{
+		RefID:     "trace",
+		TimeRange: backend.TimeRange{From: time.UnixMilli(from), To: time.UnixMilli(to)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status == backend.StatusNotFound {
+		return nil, &traceLogsQueryError{http.StatusNotFound, errors.New("trace not found")}
+	}
+	if res.Error != nil {
+		return nil, &traceLogsQueryError{http.StatusBadRequest, res.Error}
+	}
+	spans, err := readTraceSpans(res.Frames)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans) == 0 {
+		return nil, &traceLogsQueryError{http.StatusNotFound, errors.New("trace not found")}
+	}
+
+	expr, err := buildTraceLogsQuery(settings, traceID, spans)
+	if err != nil {
+		return nil, &traceLogsQueryError{http.StatusBadRequest, err}
+	}
+	start, end, err := traceLogsTimeRange(settings, spans)
+	if err != nil {
+		return nil, &traceLogsQueryError{http.StatusBadRequest, fmt.Errorf("invalid time shift in the trace to logs settings: %w", err)}
+	}
+
+	logsQuery := map[string]interface{}{
+		"refId":      traceLogsRefID,
+		"datasource": map[string]interface{}{"uid": settings.DatasourceUID},
+		"queryType":  "range",
+		"expr":       expr,
+	}
+	if limit > 0 {
+		logsQuery["maxLines"] = limit
+	}
+	return &TraceLogsQuery{
+		From:    strconv.FormatInt(start.UnixMilli(), 10),
+		To:      strconv.FormatInt(end.UnixMilli(), 10),
+		Queries: []map[string]interface{}{logsQuery},
+	}, nil
+}
+
+// getTraceToLogsSettings returns the trace to logs settings of the data source, the deprecated settings are
+// converted like in the trace view
+func getTraceToLogsSettings(jsonData json.RawMessage) (*traceToLogsSettings, error) {
+	settings := &traceToLogsSettings{}
+	if len(jsonData) == 0 {
+		return settings, nil
+	}
+
+	var raw struct {
+		V2     *traceToLogsSettings       `json:"tracesToLogsV2"`
+		Legacy *legacyTraceToLogsSettings `json:"tracesToLogs"`
+	}
+	if err := json.Unmarshal(jsonData, &raw); err != nil {
+		return nil, err
+	}
+	if raw.V2 != nil {
+		return raw.V2, nil
+	}
+	if raw.Legacy == nil {
+		return settings, nil
+	}
+
+	old := raw.Legacy
+	settings.DatasourceUID = old.DatasourceUID
+	settings.SpanStartTimeShift = old.SpanStartTimeShift
+	settings.SpanEndTimeShift = old.SpanEndTimeShift
+	settings.FilterByTraceID = old.FilterByTraceID
+	if old.MapTagNamesEnabled {
+		settings.Tags = old.MappedTags
+	} else if old.Tags != nil {
+		settings.Tags = make([]traceToLogsTag, len(old.Tags))
+		for i, tag := range old.Tags {
+			settings.Tags[i] = traceToLogsTag{Key: tag}
+		}
+	}
+	return settings, nil
+}
+
+// traceSpan is the time range and the tags of a span of the trace frame
+type traceSpan struct {
+	start time.Time
+	end   time.Time
+	tags  map[string]string
+}
+
+// readTraceSpans returns the spans of the trace frame of Tempo, with their start times and durations in milliseconds
+// and their process and span tags as lists of key value pairs
+func readTraceSpans(frames data.Frames) ([]traceSpan, error) {
+	var spans []traceSpan
+	for _, frame := range frames {
+		fields := map[string]*data.Field{}
+		for _, field := range frame.Fields {
+			fields[field.Name] = field
+		}
+		if fields["startTime"] == nil {
+			continue
+		}
+
+		for i := 0; i < frame.Rows(); i++ {
+			startMs, _ := concreteFieldAt(fields["startTime"], i).(float64)
+			durationMs, _ := concreteFieldAt(fields["duration"], i).(float64)
+			start := time.UnixMicro(int64(startMs * 1000))
+			span := traceSpan{start: start, end: start.Add(time.Duration(durationMs * float64(time.Millisecond))), tags: map[string]string{}}
+
+			for _, name := range []string{"serviceTags", "tags"} {
+				var raw []byte
+				switch v := concreteFieldAt(fields[name], i).(type) {
+				case json.RawMessage:
+					raw = v
+				case string:
+					raw = []byte(v)
+				}
+				if len(raw) == 0 {
+					continue
+				}
+				var tags []struct {
+					Key   string      `json:"key"`
+					Value interface{} `json:"value"`
+				}
+				if err := json.Unmarshal(raw, &tags); err != nil {
+					return nil, fmt.Errorf("failed to decode the %s of the span: %w", name, err)
+				}
+				for _, tag := range tags {
+					if tag.Value != nil {
+						span.tags[tag.Key] = fmt.Sprint(tag.Value)
+					}
+				}
+			}
+			spans = append(spans, span)
+		}
+	}
+	return spans, nil
+}
+
+// concreteFieldAt returns the value of the field at the row, the values of the enum fields are returned as the text
+// of their index
+func concreteFieldAt(field *data.Field, i int) interface{} {
+	if field == nil {
+		return nil
+	}
+	v, _ := field.ConcreteAt(i)
+	if index, ok := v.(data.EnumItemIndex); ok {
+		if field.Config == nil || field.Config.TypeConfig == nil || field.Config.TypeConfig.Enum == nil ||
+			int(index) >= len(field.Config.TypeConfig.Enum.Text) {
+			return nil
+		}
+		return field.Config.TypeConfig.Enum.Text[index]
+	}
+	return v
+}
+
+// unresolvedVariable matches the variables left in the custom queries after the interpolation
+var unresolvedVariable = regexp.MustCompile(`\$\{[^}]*\}`)
+
+// buildTraceLogsQuery returns the LogQL query of the logs of the trace. The label matchers are the values of the tags
+// in the spans, with a regular expression when the spans have different values. The spans are not filtered by span
+// ID, as the logs of the whole trace are returned.
+func buildTraceLogsQuery(settings *traceToLogsSettings, traceID string, spans []traceSpan) (string, error) {
+	tags := settings.Tags
+	if len(tags) == 0 {
+		tags = defaultTraceLogsTags
+	}
+
+	var matchers []string
+	for _, tag := range tags {
+		values := map[string]bool{}
+		for _, span := range spans {
+			if v, ok := span.tags[tag.Key]; ok && v != "" {
+				values[v] = true
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		label := tag.Key
+		if tag.Value != "" {
+			label = tag.Value
+		}
+		sorted := make([]string, 0, len(values))
+		for v := range values {
+			sorted = append(sorted, v)
+		}
+		sort.Strings(sorted)
+
+		if len(sorted) == 1 {
+			matchers = append(matchers, label+"="+strconv.Quote(sorted[0]))
+			continue
+		}
+		for i, v := range sorted {
+			sorted[i] = regexp.QuoteMeta(v)
+		}
+		matchers = append(matchers, label+"=~"+strconv.Quote(strings.Join(sorted, "|")))
+	}
+	selector := strings.Join(matchers, ", ")
+
+	if settings.CustomQuery && settings.Query != "" {
+		expr := strings.NewReplacer(
+			"${__tags}", selector,
+			"${__trace.traceId}", traceID,
+			"${__span.traceId}", traceID,
+		).Replace(settings.Query)
+		if v := unresolvedVariable.FindString(expr); v != "" {
+			return "", fmt.Errorf("the custom query uses the variable %s, which can't be resolved for a trace", v)
+		}
+		return expr, nil
+	}
+
+	if selector == "" {
+		return "", errors.New("the spans of the trace have none of the tags of the trace to logs settings")
+	}
+	expr := "{" + selector + "}"
+	if settings.FilterByTraceID {
+		expr += " |= " + strconv.Quote(traceID)
+	}
+	return expr, nil
+}
+
+// traceLogsTimeRange returns the time range of the spans, shifted by the time shifts of the settings. The end of the
+// time range is after its start, as Loki doesn't query an empty time range.
+func traceLogsTimeRange(settings *traceToLogsSettings, spans []traceSpan) (time.Time, time.Time, error) {
+	start, end := spans[0].start, spans[0].end
+	for _, span := range spans[1:] {
+		if span.start.Before(start) {
+			start = span.start
+		}
+		if span.end.After(end) {
+			end = span.end
+		}
+	}
+
+	for _, shift := range []struct {
+		value string
+		t     *time.Time
+	}{{settings.SpanStartTimeShift, &start}, {settings.SpanEndTimeShift, &end}} {
+		if shift.value == "" {
+			continue
+		}
+		d, err := parseTimeShift(shift.value)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		*shift.t = shift.t.Add(d)
+	}
+
+	start, end = start.Truncate(time.Millisecond), end.Truncate(time.Millisecond)
+	if !end.After(start) {
+		end = start.Add(time.Millisecond)
+	}
+	return start, end, nil
+}
+
+// parseTimeShift parses the time shifts of the settings, which can be negative like "-1h"
+func parseTimeShift(value string) (time.Duration, error) {
+	if strings.HasPrefix(value, "-") {
+		d, err := gtime.ParseDuration(strings.TrimPrefix(value, "-"))
+		return -d, err
+	}
+	return gtime.ParseDuration(value)
+}
@@ -0,0 +1,154 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/model/otlp"
+	"go.opentelemetry.io/collector/model/pdata"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestTraceLogsQuery(t *testing.T) {
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	td := pdata.NewTraces()
+	for i, pod := range []string{"api-0", "db.1"} {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().InsertString("cluster", "eu")
+		rs.Resource().Attributes().InsertString("pod", pod)
+		span := rs.InstrumentationLibrarySpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetName("span")
+		span.SetTraceID(pdata.NewTraceID([16]byte{15: 1}))
+		span.SetSpanID(pdata.NewSpanID([8]byte{byte(i + 1)}))
+		if i == 0 {
+			span.SetStartTimestamp(pdata.TimestampFromTime(start))
+			span.SetEndTimestamp(pdata.TimestampFromTime(start.Add(1500 * time.Millisecond)))
+		} else {
+			span.Attributes().InsertString("namespace", "prod")
+			span.SetStartTimestamp(pdata.TimestampFromTime(start.Add(200 * time.Millisecond)))
+			span.SetEndTimestamp(pdata.TimestampFromTime(start.Add(300 * time.Millisecond)))
+		}
+	}
+	trace, err := otlp.NewProtobufTracesMarshaler().MarshalTraces(td)
+	require.NoError(t, err)
+
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.String())
+		if r.URL.Path != "/api/traces/00000000000000000000000000000001" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(trace)
+	}))
+	t.Cleanup(srv.Close)
+
+	getQuery := func(t *testing.T, jsonData string, params string) *backend.CallResourceResponse {
+		t.Helper()
+		requested = nil
+		service := &Service{tlog: log.New("tempo-test")}
+		sender := &fakeCallResourceResponseSender{}
+		err := service.callResource(context.Background(), &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)}},
+			Method:        http.MethodGet,
+			Path:          "trace-logs-query",
+			URL:           "trace-logs-query?" + params,
+		}, sender, &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL})
+		require.NoError(t, err)
+		return sender.res
+	}
+
+	t.Run("returns the logs query with the tags of the spans", func(t *testing.T) {
+		res := getQuery(t, `{"tracesToLogsV2": {"datasourceUid": "loki", "tags": [{"key": "cluster"}, {"key": "pod", "value": "pod_name"}],
+			"spanStartTimeShift": "-1m", "spanEndTimeShift": "1m", "filterByTraceID": true}}`,
+			"traceId=00000000000000000000000000000001&limit=100")
+		require.Equal(t, http.StatusOK, res.Status)
+		assert.JSONEq(t, `{"from": "1682935140000", "to": "1682935261500", "queries": [{
+			"refId": "logs",
+			"datasource": {"uid": "loki"},
+			"queryType": "range",
+			"expr": "{cluster=\"eu\", pod_name=~\"api-0|db\\\\.1\"} |= \"00000000000000000000000000000001\"",
+			"maxLines": 100
+		}]}`, string(res.Body))
+		assert.Equal(t, []string{"/api/traces/00000000000000000000000000000001"}, requested)
+	})
+
+	t.Run("looks up the trace in the time range", func(t *testing.T) {
+		res := getQuery(t, `{"tracesToLogs": {"datasourceUid": "loki", "tags": ["namespace"]}}`,
+			"traceId=00000000000000000000000000000001&from=1682935000000&to=1682936000000")
+		require.Equal(t, http.StatusOK, res.Status)
+		var query TraceLogsQuery
+		require.NoError(t, json.Unmarshal(res.Body, &query))
+		assert.Equal(t, `{namespace="prod"}`, query.Queries[0]["expr"])
+		assert.Equal(t, []string{"/api/traces/00000000000000000000000000000001?start=1682935000&end=1682936000"}, requested)
+	})
+
+	t.Run("fails with the status of the failure", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			jsonData string
+			params   string
+			status   int
+		}{
+			"without trace ID":          {`{"tracesToLogsV2": {"datasourceUid": "loki"}}`, "", http.StatusBadRequest},
+			"with an invalid limit":     {`{"tracesToLogsV2": {"datasourceUid": "loki"}}`, "traceId=00000000000000000000000000000001&limit=-1", http.StatusBadRequest},
+			"without logs data source":  {`{}`, "traceId=00000000000000000000000000000001", http.StatusBadRequest},
+			"without the tags of spans": {`{"tracesToLogsV2": {"datasourceUid": "loki", "tags": [{"key": "service.name"}]}}`, "traceId=00000000000000000000000000000001", http.StatusBadRequest},
+			"with an unknown trace":     {`{"tracesToLogsV2": {"datasourceUid": "loki"}}`, "traceId=00000000000000000000000000000002", http.StatusNotFound},
+		} {
+			res := getQuery(t, tc.jsonData, tc.params)
+			assert.Equal(t, tc.status, res.Status, name)
+			assert.Contains(t, string(res.Body), `"message"`, name)
+		}
+	})
+}
+
+func TestReadTraceSpans(t *testing.T) {
+	frame := data.NewFrame("trace",
+		data.NewField("serviceTags", nil, []data.EnumItemIndex{0, 1}),
+		data.NewField("startTime", nil, []float64{1000, 1200}),
+		data.NewField("duration", nil, []float64{1500, 100}),
+		data.NewField("tags", nil, []json.RawMessage{json.RawMessage(`[]`), json.RawMessage(`[{"key":"namespace","value":"prod"}]`)}),
+	)
+	frame.Fields[0].Config = &data.FieldConfig{TypeConfig: &data.FieldTypeConfig{Enum: &data.EnumFieldConfig{Text: []string{
+		`[{"key":"cluster","value":"eu"},{"key":"pod","value":"api-0"}]`,
+		`[{"key":"cluster","value":"eu"},{"key":"pod","value":"db.1"}]`,
+	}}}}
+
+	spans, err := readTraceSpans(data.Frames{frame})
+	require.NoError(t, err)
+	assert.Equal(t, []traceSpan{
+		{start: time.UnixMilli(1000), end: time.UnixMilli(2500), tags: map[string]string{"cluster": "eu", "pod": "api-0"}},
+		{start: time.UnixMilli(1200), end: time.UnixMilli(1300), tags: map[string]string{"cluster": "eu", "pod": "db.1", "namespace": "prod"}},
+	}, spans)
+}
+
+func TestBuildTraceLogsQuery(t *testing.T) {
+	spans := []traceSpan{{tags: map[string]string{"cluster": "eu", "pod": "api-0"}}}
+
+	t.Run("the variables of the custom queries are interpolated", func(t *testing.T) {
+		expr, err := buildTraceLogsQuery(&traceToLogsSettings{Tags: []traceToLogsTag{{Key: "cluster"}}, CustomQuery: true,
+			Query: `{${__tags}} | json | trace_id="${__trace.traceId}"`}, "abc", spans)
+		require.NoError(t, err)
+		assert.Equal(t, `{cluster="eu"} | json | trace_id="abc"`, expr)
+	})
+
+	t.Run("the custom queries with the variables of a span are rejected", func(t *testing.T) {
+		_, err := buildTraceLogsQuery(&traceToLogsSettings{CustomQuery: true, Query: `{job=~".+"} |= "${__span.spanId}"`}, "abc", spans)
+		assert.ErrorContains(t, err, "the custom query uses the variable ${__span.spanId}")
+	})
+
+	t.Run("the default tags are used without tags in the settings", func(t *testing.T) {
+		expr, err := buildTraceLogsQuery(&traceToLogsSettings{}, "abc", spans)
+		require.NoError(t, err)
+		assert.Equal(t, `{cluster="eu", pod="api-0"}`, expr)
+	})
+}
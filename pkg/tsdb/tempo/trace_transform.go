@@ -3,6 +3,7 @@ package tempo
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -59,9 +60,10 @@ func TraceToFrame(td pdata.Traces) (*data.Frame, error) {
 		},
 	}
 
+	strs := stringCache{}
 	for i := 0; i < resourceSpans.Len(); i++ {
 		rs := resourceSpans.At(i)
-		rows, err := resourceSpansToRows(rs)
+		rows, err := resourceSpansToRows(rs, strs)
 		if err != nil {
 			return nil, err
 		}
@@ -74,8 +76,80 @@ func TraceToFrame(td pdata.Traces) (*data.Frame, error) {
 	return frame, nil
 }
 
+// dictionaryEncodedFields are the fields of the trace frame with a single value per resource, they are sent as
+// enum fields holding the index of their value in the dictionary of their config
+var dictionaryEncodedFields = []string{"serviceName", "serviceTags"}
+
+// dictionaryEncodeTraceFrame replaces the fields holding the service of the spans with enum fields, so that the name
+// and the tags of a service are sent once instead of with every span. The frontend decodes them back to their values
+// before building the trace view. A field is left unchanged when it has more distinct values than an enum field can
+// index.
+func dictionaryEncodeTraceFrame(frame *data.Frame) {
+	for i, field := range frame.Fields {
+		if !isDictionaryEncodedField(field.Name) {
+			continue
+		}
+		if encoded, ok := dictionaryEncodeField(field); ok {
+			frame.Fields[i] = encoded
+		}
+	}
+}
+
+func isDictionaryEncodedField(name string) bool {
+	for _, n := range dictionaryEncodedFields {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func dictionaryEncodeField(field *data.Field) (*data.Field, bool) {
+	indexes := make([]data.EnumItemIndex, field.Len())
+	positions := map[string]data.EnumItemIndex{}
+	var text []string
+	for i := 0; i < field.Len(); i++ {
+		var value string
+		switch v := field.At(i).(type) {
+		case string:
+			value = v
+		case json.RawMessage:
+			value = string(v)
+		default:
+			return nil, false
+		}
+
+		index, ok := positions[value]
+		if !ok {
+			if len(text) > math.MaxUint16 {
+				return nil, false
+			}
+			index = data.EnumItemIndex(len(text))
+			positions[value] = index
+			text = append(text, value)
+		}
+		indexes[i] = index
+	}
+
+	encoded := data.NewField(field.Name, field.Labels, indexes)
+	encoded.Config = &data.FieldConfig{TypeConfig: &data.FieldTypeConfig{Enum: &data.EnumFieldConfig{Text: text}}}
+	return encoded, true
+}
+
+// stringCache deduplicates strings that repeat across the spans of a trace, such as attribute keys, service names
+// and operation names, so that every row of the frame shares a single allocation per distinct value.
+type stringCache map[string]string
+
+func (c stringCache) intern(s string) string {
+	if v, ok := c[s]; ok {
+		return v
+	}
+	c[s] = s
+	return s
+}
+
 // resourceSpansToRows processes all the spans for a particular resource/service
-func resourceSpansToRows(rs pdata.ResourceSpans) ([][]interface{}, error) {
+func resourceSpansToRows(rs pdata.ResourceSpans, strs stringCache) ([][]interface{}, error) {
 	resource := rs.Resource()
 	ilss := rs.InstrumentationLibrarySpans()
 
@@ -83,19 +157,32 @@ func resourceSpansToRows(rs pdata.ResourceSpans) ([][]interface{}, error) {
 		return [][]interface{}{}, nil
 	}
 
+	// All spans of a resource share the same process, so the service tags are only marshalled once and the
+	// resulting JSON is referenced by every row instead of being encoded again for each span.
+	serviceName, serviceTags := resourceToProcess(resource, strs)
+	serviceTagsJson, err := json.Marshal(serviceTags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal service tags: %w", err)
+	}
+	process := &spanProcess{
+		serviceName: serviceName,
+		serviceTags: json.RawMessage(serviceTagsJson),
+	}
+
 	// Approximate the number of the spans as the number of the spans in the first
 	// instrumentation library info.
 	rows := make([][]interface{}, 0, ilss.At(0).Spans().Len())
 
 	for i := 0; i < ilss.Len(); i++ {
 		ils := ilss.At(i)
+		libraryTags := getTagsFromInstrumentationLibrary(ils.InstrumentationLibrary())
 
 		// These are finally the actual spans
 		spans := ils.Spans()
 
 		for j := 0; j < spans.Len(); j++ {
 			span := spans.At(j)
-			row, err := spanToSpanRow(span, ils.InstrumentationLibrary(), resource)
+			row, err := spanToSpanRow(span, libraryTags, process, strs)
 			if err != nil {
 				return nil, err
 			}
@@ -108,7 +195,13 @@ func resourceSpansToRows(rs pdata.ResourceSpans) ([][]interface{}, error) {
 	return rows, nil
 }
 
-func spanToSpanRow(span pdata.Span, libraryTags pdata.InstrumentationLibrary, resource pdata.Resource) ([]interface{}, error) {
+// spanProcess holds the values shared by all spans originating from the same resource.
+type spanProcess struct {
+	serviceName string
+	serviceTags json.RawMessage
+}
+
+func spanToSpanRow(span pdata.Span, libraryTags []*KeyValue, process *spanProcess, strs stringCache) ([]interface{}, error) {
 	// If the id representation changed from hexstring to something else we need to change the transformBase64IDToHexString in the frontend code
 	traceID := span.TraceID().HexString()
 	traceID = strings.TrimPrefix(traceID, strings.Repeat("0", 16))
@@ -117,24 +210,18 @@ func spanToSpanRow(span pdata.Span, libraryTags pdata.InstrumentationLibrary, re
 
 	parentSpanID := span.ParentSpanID().HexString()
 	startTime := float64(span.StartTimestamp()) / 1_000_000
-	serviceName, serviceTags := resourceToProcess(resource)
-
-	serviceTagsJson, err := json.Marshal(serviceTags)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal service tags: %w", err)
-	}
 
-	spanTags, err := json.Marshal(getSpanTags(span, libraryTags))
+	spanTags, err := json.Marshal(getSpanTags(span, libraryTags, strs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal span tags: %w", err)
 	}
 
-	logs, err := json.Marshal(spanEventsToLogs(span.Events()))
+	logs, err := json.Marshal(spanEventsToLogs(span.Events(), strs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal span logs: %w", err)
 	}
 
-	references, err := json.Marshal(spanLinksToReferences(span.Links()))
+	references, err := json.Marshal(spanLinksToReferences(span.Links(), strs))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal span links: %w", err)
@@ -145,9 +232,9 @@ func spanToSpanRow(span pdata.Span, libraryTags pdata.InstrumentationLibrary, re
 		traceID,
 		spanID,
 		parentSpanID,
-		span.Name(),
-		serviceName,
-		json.RawMessage(serviceTagsJson),
+		strs.intern(span.Name()),
+		process.serviceName,
+		process.serviceTags,
 		startTime,
 		float64(span.EndTimestamp()-span.StartTimestamp()) / 1_000_000,
 		json.RawMessage(logs),
@@ -156,7 +243,7 @@ func spanToSpanRow(span pdata.Span, libraryTags pdata.InstrumentationLibrary, re
 	}, nil
 }
 
-func resourceToProcess(resource pdata.Resource) (string, []*KeyValue) {
+func resourceToProcess(resource pdata.Resource, strs stringCache) (string, []*KeyValue) {
 	attrs := resource.Attributes()
 	serviceName := tracetranslator.ResourceNoServiceName
 	if attrs.Len() == 0 {
@@ -166,9 +253,9 @@ func resourceToProcess(resource pdata.Resource) (string, []*KeyValue) {
 	tags := make([]*KeyValue, 0, attrs.Len()-1)
 	attrs.Range(func(key string, attr pdata.AttributeValue) bool {
 		if key == conventions.AttributeServiceName {
-			serviceName = attr.StringVal()
+			serviceName = strs.intern(attr.StringVal())
 		}
-		tags = append(tags, &KeyValue{Key: key, Value: getAttributeVal(attr)})
+		tags = append(tags, &KeyValue{Key: strs.intern(key), Value: getAttributeVal(attr)})
 		return true
 	})
 
@@ -192,15 +279,14 @@ func getAttributeVal(attr pdata.AttributeValue) interface{} {
 	}
 }
 
-func getSpanTags(span pdata.Span, instrumentationLibrary pdata.InstrumentationLibrary) []*KeyValue {
+func getSpanTags(span pdata.Span, libraryTags []*KeyValue, strs stringCache) []*KeyValue {
 	var tags []*KeyValue
 
-	libraryTags := getTagsFromInstrumentationLibrary(instrumentationLibrary)
 	if libraryTags != nil {
 		tags = append(tags, libraryTags...)
 	}
 	span.Attributes().Range(func(key string, attr pdata.AttributeValue) bool {
-		tags = append(tags, &KeyValue{Key: key, Value: getAttributeVal(attr)})
+		tags = append(tags, &KeyValue{Key: strs.intern(key), Value: getAttributeVal(attr)})
 		return true
 	})
 
@@ -301,7 +387,7 @@ func getTagFromTraceState(traceState pdata.TraceState) *KeyValue {
 	return nil
 }
 
-func spanEventsToLogs(events pdata.SpanEventSlice) []*TraceLog {
+func spanEventsToLogs(events pdata.SpanEventSlice, strs stringCache) []*TraceLog {
 	if events.Len() == 0 {
 		return nil
 	}
@@ -313,11 +399,11 @@ func spanEventsToLogs(events pdata.SpanEventSlice) []*TraceLog {
 		if event.Name() != "" {
 			fields = append(fields, &KeyValue{
 				Key:   tracetranslator.TagMessage,
-				Value: event.Name(),
+				Value: strs.intern(event.Name()),
 			})
 		}
 		event.Attributes().Range(func(key string, attr pdata.AttributeValue) bool {
-			fields = append(fields, &KeyValue{Key: key, Value: getAttributeVal(attr)})
+			fields = append(fields, &KeyValue{Key: strs.intern(key), Value: getAttributeVal(attr)})
 			return true
 		})
 		logs = append(logs, &TraceLog{
@@ -329,7 +415,7 @@ func spanEventsToLogs(events pdata.SpanEventSlice) []*TraceLog {
 	return logs
 }
 
-func spanLinksToReferences(links pdata.SpanLinkSlice) []*TraceReference {
+func spanLinksToReferences(links pdata.SpanLinkSlice, strs stringCache) []*TraceReference {
 	if links.Len() == 0 {
 		return nil
 	}
@@ -345,7 +431,7 @@ func spanLinksToReferences(links pdata.SpanLinkSlice) []*TraceReference {
 
 		tags := make([]*KeyValue, 0, link.Attributes().Len())
 		link.Attributes().Range(func(key string, attr pdata.AttributeValue) bool {
-			tags = append(tags, &KeyValue{Key: key, Value: getAttributeVal(attr)})
+			tags = append(tags, &KeyValue{Key: strs.intern(key), Value: getAttributeVal(attr)})
 			return true
 		})
 
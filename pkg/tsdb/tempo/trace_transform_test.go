@@ -89,6 +89,62 @@ func TestTraceToFrame(t *testing.T) {
 		require.NotNil(t, traceID64Bit)
 		require.Equal(t, "0001020304050607", traceID64Bit["traceID"])
 	})
+
+	t.Run("should share service tags between spans of the same resource", func(t *testing.T) {
+		proto, err := os.ReadFile("testData/tempo_proto_response")
+		require.NoError(t, err)
+
+		otTrace, err := otlp.NewProtobufTracesUnmarshaler().UnmarshalTraces(proto)
+		require.NoError(t, err)
+
+		frame, err := TraceToFrame(otTrace)
+		require.NoError(t, err)
+
+		serviceTags := frame.Fields[5]
+		first := serviceTags.At(0).(json.RawMessage)
+		second := serviceTags.At(1).(json.RawMessage)
+		require.Equal(t, first, second)
+		require.Same(t, &first[0], &second[0])
+	})
+}
+
+func TestDictionaryEncodeTraceFrame(t *testing.T) {
+	proto, err := os.ReadFile("testData/tempo_proto_response")
+	require.NoError(t, err)
+
+	otTrace, err := otlp.NewProtobufTracesUnmarshaler().UnmarshalTraces(proto)
+	require.NoError(t, err)
+
+	frame, err := TraceToFrame(otTrace)
+	require.NoError(t, err)
+	plain, err := json.Marshal(frame)
+	require.NoError(t, err)
+	serviceTags := frame.Fields[5].At(0).(json.RawMessage)
+
+	dictionaryEncodeTraceFrame(frame)
+	encoded, err := json.Marshal(frame)
+	require.NoError(t, err)
+
+	require.ElementsMatch(t, fields, fieldNames(frame))
+	serviceName, _ := frame.FieldByName("serviceName")
+	require.Equal(t, data.FieldTypeEnum, serviceName.Type())
+	require.Equal(t, []string{"loki-all"}, serviceName.Config.TypeConfig.Enum.Text)
+	require.Equal(t, data.EnumItemIndex(0), serviceName.At(29))
+
+	tags, _ := frame.FieldByName("serviceTags")
+	require.Equal(t, data.FieldTypeEnum, tags.Type())
+	require.Equal(t, []string{string(serviceTags)}, tags.Config.TypeConfig.Enum.Text)
+
+	// the tags of the service are only sent once instead of with each of the 30 spans
+	require.Less(t, len(encoded), len(plain)-29*len(serviceTags), "plain frame: %d bytes, encoded frame: %d bytes", len(plain), len(encoded))
+}
+
+func TestStringCache(t *testing.T) {
+	strs := stringCache{}
+	require.Equal(t, "service.name", strs.intern("service.name"))
+	require.Equal(t, "service.name", strs.intern("service.name"))
+	require.Equal(t, "http.method", strs.intern("http.method"))
+	require.Len(t, strs, 2)
 }
 
 type Row map[string]interface{}
@@ -0,0 +1,115 @@
+package tempo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+// traceQLScopes are the prefixes Tempo accepts in front of an attribute name. Intrinsics are not listed as they are
+// used without a prefix.
+var traceQLScopes = map[dataquery.TraceqlFilterScope]string{
+	dataquery.TraceqlFilterScopeUnscoped: ".",
+	dataquery.TraceqlFilterScopeResource: "resource.",
+	dataquery.TraceqlFilterScopeSpan:     "span.",
+	dataquery.TraceqlFilterScopeEvent:    "event.",
+	dataquery.TraceqlFilterScopeLink:     "link.",
+}
+
+// generateQueryFromFilters builds a TraceQL span selector from the filters of a query, mirroring the query the
+// search editor in the frontend generates. Filters missing a tag, an operator or a value are ignored.
+func generateQueryFromFilters(filters []dataquery.TraceqlFilter) string {
+	conditions := make([]string, 0, len(filters))
+	for _, f := range filters {
+		if f.Tag == nil || *f.Tag == "" || f.Operator == nil || *f.Operator == "" {
+			continue
+		}
+		value, ok := filterValue(f)
+		if !ok {
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("%s%s%s", scopedTag(f), *f.Operator, value))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(conditions, " && "))
+}
+
+// queryFilters returns the filters of the query as TraceqlFilter, the type shared with the search editor.
+func queryFilters(model *dataquery.TempoQuery) []dataquery.TraceqlFilter {
+	filters := make([]dataquery.TraceqlFilter, 0, len(model.Filters))
+	for _, f := range model.Filters {
+		filter := dataquery.TraceqlFilter{
+			Id:        f.Id,
+			Operator:  f.Operator,
+			Tag:       f.Tag,
+			Type:      dataquery.TraceqlFilterType(f.Type),
+			Value:     f.Value,
+			ValueType: f.ValueType,
+		}
+		if f.Scope != nil {
+			scope := dataquery.TraceqlFilterScope(*f.Scope)
+			filter.Scope = &scope
+		}
+		filters = append(filters, filter)
+	}
+	return filters
+}
+
+func scopedTag(f dataquery.TraceqlFilter) string {
+	if f.Scope == nil {
+		return *f.Tag
+	}
+	prefix, ok := traceQLScopes[*f.Scope]
+	if !ok {
+		// intrinsics, such as name, status or duration
+		return *f.Tag
+	}
+	// tags could already be stored with their scope, for example from queries created before scopes were introduced
+	if strings.HasPrefix(*f.Tag, prefix) {
+		return *f.Tag
+	}
+	return prefix + strings.TrimPrefix(*f.Tag, ".")
+}
+
+func filterValue(f dataquery.TraceqlFilter) (string, bool) {
+	if f.Value == nil {
+		return "", false
+	}
+
+	var value string
+	switch v := (*f.Value).(type) {
+	case string:
+		value = v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		if len(values) > 1 {
+			return fmt.Sprintf("%q", strings.Join(values, "|")), true
+		}
+		value = strings.Join(values, "")
+	default:
+		value = fmt.Sprint(v)
+	}
+
+	if value == "" {
+		return "", false
+	}
+	if f.ValueType == nil || *f.ValueType == "" || *f.ValueType == "string" {
+		return fmt.Sprintf("%q", value), true
+	}
+	return value, true
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func scopePtr(s dataquery.TraceqlFilterScope) *dataquery.TraceqlFilterScope {
+	return &s
+}
+
+func valuePtr(v interface{}) *interface{} {
+	return &v
+}
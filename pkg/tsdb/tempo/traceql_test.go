@@ -0,0 +1,46 @@
+package tempo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+func TestGenerateQueryFromFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  string
+		expected string
+	}{
+		{name: "no filters", filters: `[]`, expected: "{}"},
+		{name: "filter without value", filters: `[{"id":"a","type":"static","tag":"foo","operator":"="}]`, expected: "{}"},
+		{name: "filter without tag", filters: `[{"id":"a","type":"static","value":"bar","operator":"="}]`, expected: "{}"},
+		{name: "filter without operator", filters: `[{"id":"a","type":"static","tag":"foo","value":"bar"}]`, expected: "{}"},
+		{name: "filter without scope", filters: `[{"id":"a","type":"static","tag":".foo","operator":"=","value":"bar"}]`, expected: `{.foo="bar"}`},
+		{name: "unscoped filter", filters: `[{"id":"a","type":"static","scope":"unscoped","tag":"foo","operator":"=","value":"bar"}]`, expected: `{.foo="bar"}`},
+		{name: "resource filter", filters: `[{"id":"a","type":"static","scope":"resource","tag":"service.name","operator":"=","value":"bar"}]`, expected: `{resource.service.name="bar"}`},
+		{name: "span filter", filters: `[{"id":"a","type":"static","scope":"span","tag":"http.status_code","operator":">=","value":"500","valueType":"int"}]`, expected: `{span.http.status_code>=500}`},
+		{name: "event filter", filters: `[{"id":"a","type":"dynamic","scope":"event","tag":"exception.type","operator":"=~","value":"IO.*"}]`, expected: `{event.exception.type=~"IO.*"}`},
+		{name: "link filter", filters: `[{"id":"a","type":"dynamic","scope":"link","tag":"opentracing.ref_type","operator":"=","value":"follows_from"}]`, expected: `{link.opentracing.ref_type="follows_from"}`},
+		{name: "intrinsic filter", filters: `[{"id":"a","type":"static","scope":"intrinsic","tag":"duration","operator":">","value":"100ms","valueType":"duration"}]`, expected: `{duration>100ms}`},
+		{name: "tag already scoped", filters: `[{"id":"a","type":"static","scope":"span","tag":"span.foo","operator":"=","value":"bar"}]`, expected: `{span.foo="bar"}`},
+		{name: "multiple values", filters: `[{"id":"a","type":"static","scope":"resource","tag":"service.name","operator":"=~","value":["a","b"]}]`, expected: `{resource.service.name=~"a|b"}`},
+		{
+			name:     "multiple filters",
+			filters:  `[{"id":"a","type":"static","scope":"intrinsic","tag":"name","operator":"=","value":"GET"},{"id":"b","type":"dynamic","scope":"span","tag":"foo","operator":"!=","value":"bar"}]`,
+			expected: `{name="GET" && span.foo!="bar"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &dataquery.TempoQuery{}
+			require.NoError(t, json.Unmarshal([]byte(`{"filters":`+tt.filters+`}`), model))
+			assert.Equal(t, tt.expected, generateQueryFromFilters(queryFilters(model)))
+		})
+	}
+}
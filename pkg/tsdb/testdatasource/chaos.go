@@ -0,0 +1,154 @@
+package testdatasource
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// maxChaosLatency caps the injected latency, so that a query can't hold a request forever
+const maxChaosLatency = time.Minute
+
+type chaosOptions struct {
+	latencyDistribution   string
+	latency               time.Duration
+	latencySpread         time.Duration
+	errorProbability      float64
+	corruptionProbability float64
+	corruptionType        string
+	seed                  int64
+}
+
+func newChaosOptions(model *simplejson.Json) (chaosOptions, error) {
+	options := chaosOptions{
+		latencyDistribution:   model.Get("latencyDistribution").MustString("none"),
+		latency:               time.Duration(model.Get("latencyMs").MustInt64()) * time.Millisecond,
+		latencySpread:         time.Duration(model.Get("latencySpreadMs").MustInt64()) * time.Millisecond,
+		errorProbability:      model.Get("errorProbability").MustFloat64(),
+		corruptionProbability: model.Get("corruptionProbability").MustFloat64(),
+		corruptionType:        model.Get("corruptionType").MustString("nulls"),
+		seed:                  model.Get("seed").MustInt64(),
+	}
+
+	switch options.latencyDistribution {
+	case "none", "constant", "uniform", "normal", "exponential":
+	default:
+		return options, fmt.Errorf("invalid latency distribution %q", options.latencyDistribution)
+	}
+	if options.latency < 0 || options.latencySpread < 0 {
+		return options, fmt.Errorf("the latency can't be negative")
+	}
+	for name, p := range map[string]float64{"error": options.errorProbability, "corruption": options.corruptionProbability} {
+		if p < 0 || p > 1 {
+			return options, fmt.Errorf("the %s probability must be between 0 and 1", name)
+		}
+	}
+	switch options.corruptionType {
+	case "nulls", "nan", "truncate", "drop_field":
+	default:
+		return options, fmt.Errorf("invalid corruption type %q", options.corruptionType)
+	}
+	return options, nil
+}
+
+// handleChaosScenario returns random walks like a misbehaving data source would: each query waits for a latency of
+// the configured distribution, then fails with the error probability or returns its frames corrupted with the
+// corruption probability. The queries of a request fail independently of each other.
+func (s *Service) handleChaosScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			continue
+		}
+
+		options, err := newChaosOptions(model.Get("chaos"))
+		if err != nil {
+			resp.Responses[q.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+
+		seed := options.seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		rng := rand.New(rand.NewSource(seed))
+
+		select {
+		case <-ctx.Done():
+			resp.Responses[q.RefID] = backend.DataResponse{Error: ctx.Err()}
+			continue
+		case <-time.After(options.sampleLatency(rng)):
+		}
+
+		if rng.Float64() < options.errorProbability {
+			resp.Responses[q.RefID] = backend.DataResponse{Error: fmt.Errorf("chaos: the query %s failed", q.RefID)}
+			continue
+		}
+
+		respD := resp.Responses[q.RefID]
+		seriesCount := model.Get("seriesCount").MustInt(1)
+		for i := 0; i < seriesCount; i++ {
+			frame := RandomWalk(q, model, i)
+			if rng.Float64() < options.corruptionProbability {
+				corruptFrame(frame, options.corruptionType, rng)
+			}
+			respD.Frames = append(respD.Frames, frame)
+		}
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+func (o chaosOptions) sampleLatency(rng *rand.Rand) time.Duration {
+	var latency float64
+	switch o.latencyDistribution {
+	case "constant":
+		latency = float64(o.latency)
+	case "uniform":
+		latency = float64(o.latency) + rng.Float64()*float64(o.latencySpread)
+	case "normal":
+		latency = float64(o.latency) + rng.NormFloat64()*float64(o.latencySpread)
+	case "exponential":
+		latency = rng.ExpFloat64() * float64(o.latency)
+	}
+	return time.Duration(math.Max(0, math.Min(latency, float64(maxChaosLatency))))
+}
+
+// corruptFrame corrupts the values of a random walk frame: it nulls or sets to NaN about half of its values, drops the
+// second half of its rows, or drops its value field
+func corruptFrame(frame *data.Frame, corruptionType string, rng *rand.Rand) {
+	switch corruptionType {
+	case "nulls", "nan":
+		field := frame.Fields[1]
+		for i := 0; i < field.Len(); i++ {
+			if rng.Float64() >= 0.5 {
+				continue
+			}
+			if corruptionType == "nulls" {
+				field.Set(i, nil)
+			} else {
+				nan := math.NaN()
+				field.Set(i, &nan)
+			}
+		}
+	case "truncate":
+		length := frame.Rows() / 2
+		for _, field := range frame.Fields {
+			for field.Len() > length {
+				field.Delete(field.Len() - 1)
+			}
+		}
+	case "drop_field":
+		frame.Fields = frame.Fields[:1]
+	}
+}
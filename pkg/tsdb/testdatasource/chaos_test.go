@@ -0,0 +1,140 @@
+package testdatasource
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosScenario(t *testing.T) {
+	s := &Service{}
+	now := time.Now()
+	query := func(refID string, model string) backend.DataQuery {
+		return backend.DataQuery{
+			RefID:     refID,
+			TimeRange: backend.TimeRange{From: now.Add(-5 * time.Minute), To: now},
+			Interval:  10 * time.Second,
+			JSON:      []byte(model),
+		}
+	}
+
+	t.Run("should fail the queries with the error probability", func(t *testing.T) {
+		resp, err := s.handleChaosScenario(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				query("A", `{"chaos": {"errorProbability": 1}}`),
+				query("B", `{"chaos": {"errorProbability": 0}, "seriesCount": 2}`),
+			},
+		})
+		require.NoError(t, err)
+		require.EqualError(t, resp.Responses["A"].Error, "chaos: the query A failed")
+		require.NoError(t, resp.Responses["B"].Error)
+		require.Len(t, resp.Responses["B"].Frames, 2)
+	})
+
+	t.Run("should corrupt the frames with the corruption probability", func(t *testing.T) {
+		for corruptionType, check := range map[string]func(t *testing.T, nulls, nans, rows, fields int){
+			"nulls": func(t *testing.T, nulls, nans, rows, fields int) {
+				assert.Greater(t, nulls, 0)
+				assert.Equal(t, 0, nans)
+				assert.Equal(t, 30, rows)
+			},
+			"nan": func(t *testing.T, nulls, nans, rows, fields int) {
+				assert.Equal(t, 0, nulls)
+				assert.Greater(t, nans, 0)
+			},
+			"truncate": func(t *testing.T, nulls, nans, rows, fields int) {
+				assert.Equal(t, 0, nulls+nans)
+				assert.Equal(t, 15, rows)
+				assert.Equal(t, 2, fields)
+			},
+			"drop_field": func(t *testing.T, nulls, nans, rows, fields int) {
+				assert.Equal(t, 1, fields)
+			},
+		} {
+			t.Run(corruptionType, func(t *testing.T) {
+				resp, err := s.handleChaosScenario(context.Background(), &backend.QueryDataRequest{
+					Queries: []backend.DataQuery{
+						query("A", `{"chaos": {"corruptionProbability": 1, "corruptionType": "`+corruptionType+`", "seed": 42}}`),
+					},
+				})
+				require.NoError(t, err)
+				require.NoError(t, resp.Responses["A"].Error)
+				require.Len(t, resp.Responses["A"].Frames, 1)
+
+				frame := resp.Responses["A"].Frames[0]
+				nulls, nans := 0, 0
+				if len(frame.Fields) > 1 {
+					for i := 0; i < frame.Fields[1].Len(); i++ {
+						value := frame.Fields[1].At(i).(*float64)
+						if value == nil {
+							nulls++
+						} else if math.IsNaN(*value) {
+							nans++
+						}
+					}
+				}
+				check(t, nulls, nans, frame.Rows(), len(frame.Fields))
+			})
+		}
+	})
+
+	t.Run("should inject the latency", func(t *testing.T) {
+		start := time.Now()
+		resp, err := s.handleChaosScenario(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				query("A", `{"chaos": {"latencyDistribution": "constant", "latencyMs": 50}}`),
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		resp, err = s.handleChaosScenario(ctx, &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				query("A", `{"chaos": {"latencyDistribution": "constant", "latencyMs": 60000}}`),
+			},
+		})
+		require.NoError(t, err)
+		require.ErrorIs(t, resp.Responses["A"].Error, context.Canceled)
+	})
+
+	t.Run("should sample the latency of the distributions", func(t *testing.T) {
+		for distribution, check := range map[string]func(latency time.Duration) bool{
+			"none":        func(latency time.Duration) bool { return latency == 0 },
+			"uniform":     func(latency time.Duration) bool { return latency >= time.Second && latency <= 3*time.Second },
+			"normal":      func(latency time.Duration) bool { return latency >= 0 },
+			"exponential": func(latency time.Duration) bool { return latency >= 0 && latency <= maxChaosLatency },
+		} {
+			options := chaosOptions{latencyDistribution: distribution, latency: time.Second, latencySpread: 2 * time.Second}
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 100; i++ {
+				latency := options.sampleLatency(rng)
+				require.True(t, check(latency), "%s latency %s", distribution, latency)
+			}
+		}
+	})
+
+	t.Run("should validate the options", func(t *testing.T) {
+		for model, expected := range map[string]string{
+			`{"chaos": {"latencyDistribution": "pareto"}}`: `invalid latency distribution "pareto"`,
+			`{"chaos": {"latencyMs": -1}}`:                 "the latency can't be negative",
+			`{"chaos": {"errorProbability": 2}}`:           "the error probability must be between 0 and 1",
+			`{"chaos": {"corruptionProbability": -0.5}}`:   "the corruption probability must be between 0 and 1",
+			`{"chaos": {"corruptionType": "shuffle"}}`:     `invalid corruption type "shuffle"`,
+		} {
+			resp, err := s.handleChaosScenario(context.Background(), &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{query("A", model)},
+			})
+			require.NoError(t, err)
+			require.EqualError(t, resp.Responses["A"].Error, expected)
+		}
+	})
+}
@@ -9,6 +9,23 @@
 
 package dataquery
 
+// Defines values for ChaosQueryCorruptionType.
+const (
+	ChaosQueryCorruptionTypeDropField ChaosQueryCorruptionType = "drop_field"
+	ChaosQueryCorruptionTypeNan       ChaosQueryCorruptionType = "nan"
+	ChaosQueryCorruptionTypeNulls     ChaosQueryCorruptionType = "nulls"
+	ChaosQueryCorruptionTypeTruncate  ChaosQueryCorruptionType = "truncate"
+)
+
+// Defines values for ChaosQueryLatencyDistribution.
+const (
+	ChaosQueryLatencyDistributionConstant    ChaosQueryLatencyDistribution = "constant"
+	ChaosQueryLatencyDistributionExponential ChaosQueryLatencyDistribution = "exponential"
+	ChaosQueryLatencyDistributionNone        ChaosQueryLatencyDistribution = "none"
+	ChaosQueryLatencyDistributionNormal      ChaosQueryLatencyDistribution = "normal"
+	ChaosQueryLatencyDistributionUniform     ChaosQueryLatencyDistribution = "uniform"
+)
+
 // Defines values for NodesQueryType.
 const (
 	NodesQueryTypeRandom      NodesQueryType = "random"
@@ -23,6 +40,23 @@ const (
 	StreamingQueryTypeSignal StreamingQueryType = "signal"
 )
 
+// Defines values for ChaosCorruptionType.
+const (
+	ChaosCorruptionTypeDropField ChaosCorruptionType = "drop_field"
+	ChaosCorruptionTypeNan       ChaosCorruptionType = "nan"
+	ChaosCorruptionTypeNulls     ChaosCorruptionType = "nulls"
+	ChaosCorruptionTypeTruncate  ChaosCorruptionType = "truncate"
+)
+
+// Defines values for ChaosLatencyDistribution.
+const (
+	ChaosLatencyDistributionConstant    ChaosLatencyDistribution = "constant"
+	ChaosLatencyDistributionExponential ChaosLatencyDistribution = "exponential"
+	ChaosLatencyDistributionNone        ChaosLatencyDistribution = "none"
+	ChaosLatencyDistributionNormal      ChaosLatencyDistribution = "normal"
+	ChaosLatencyDistributionUniform     ChaosLatencyDistribution = "uniform"
+)
+
 // Defines values for ErrorType.
 const (
 	ErrorTypeFrontendException  ErrorType = "frontend_exception"
@@ -41,6 +75,7 @@ const (
 const (
 	ScenarioIdAnnotations                  ScenarioId = "annotations"
 	ScenarioIdArrow                        ScenarioId = "arrow"
+	ScenarioIdChaos                        ScenarioId = "chaos"
 	ScenarioIdCsvContent                   ScenarioId = "csv_content"
 	ScenarioIdCsvFile                      ScenarioId = "csv_file"
 	ScenarioIdCsvMetricValues              ScenarioId = "csv_metric_values"
@@ -81,6 +116,7 @@ const (
 const (
 	TestDataQueryTypeAnnotations                  TestDataQueryType = "annotations"
 	TestDataQueryTypeArrow                        TestDataQueryType = "arrow"
+	TestDataQueryTypeChaos                        TestDataQueryType = "chaos"
 	TestDataQueryTypeCsvContent                   TestDataQueryType = "csv_content"
 	TestDataQueryTypeCsvFile                      TestDataQueryType = "csv_file"
 	TestDataQueryTypeCsvMetricValues              TestDataQueryType = "csv_metric_values"
@@ -118,6 +154,36 @@ type CSVWave struct {
 	ValuesCSV *string `json:"valuesCSV,omitempty"`
 }
 
+// ChaosQuery defines model for ChaosQuery.
+type ChaosQuery struct {
+	// CorruptionProbability The probability that each frame of the query is corrupted, between 0 and 1
+	CorruptionProbability *float64 `json:"corruptionProbability,omitempty"`
+
+	// CorruptionType How the frames are corrupted
+	CorruptionType *ChaosQueryCorruptionType `json:"corruptionType,omitempty"`
+
+	// ErrorProbability The probability that the query fails, between 0 and 1
+	ErrorProbability *float64 `json:"errorProbability,omitempty"`
+
+	// LatencyDistribution The distribution of the latency injected before the response
+	LatencyDistribution *ChaosQueryLatencyDistribution `json:"latencyDistribution,omitempty"`
+
+	// LatencyMs The latency in milliseconds, the minimum of the uniform distribution and the mean of the others
+	LatencyMs *int64 `json:"latencyMs,omitempty"`
+
+	// LatencySpreadMs The spread of the latency in milliseconds, the width of the uniform distribution and the standard deviation of the normal one
+	LatencySpreadMs *int64 `json:"latencySpreadMs,omitempty"`
+
+	// Seed The seed of the random numbers, the query behaves the same on every run with a seed
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// ChaosQueryCorruptionType How the frames are corrupted
+type ChaosQueryCorruptionType string
+
+// ChaosQueryLatencyDistribution The distribution of the latency injected before the response
+type ChaosQueryLatencyDistribution string
+
 // NodesQuery defines model for NodesQuery.
 type NodesQuery struct {
 	Count *int64          `json:"count,omitempty"`
@@ -172,8 +238,30 @@ type StreamingQueryType string
 
 // TestDataDataQuery defines model for TestDataDataQuery.
 type TestDataDataQuery struct {
-	Alias       *string `json:"alias,omitempty"`
-	Channel     *string `json:"channel,omitempty"`
+	Alias   *string `json:"alias,omitempty"`
+	Channel *string `json:"channel,omitempty"`
+	Chaos   *struct {
+		// CorruptionProbability The probability that each frame of the query is corrupted, between 0 and 1
+		CorruptionProbability *float64 `json:"corruptionProbability,omitempty"`
+
+		// CorruptionType How the frames are corrupted
+		CorruptionType *ChaosCorruptionType `json:"corruptionType,omitempty"`
+
+		// ErrorProbability The probability that the query fails, between 0 and 1
+		ErrorProbability *float64 `json:"errorProbability,omitempty"`
+
+		// LatencyDistribution The distribution of the latency injected before the response
+		LatencyDistribution *ChaosLatencyDistribution `json:"latencyDistribution,omitempty"`
+
+		// LatencyMs The latency in milliseconds, the minimum of the uniform distribution and the mean of the others
+		LatencyMs *int64 `json:"latencyMs,omitempty"`
+
+		// LatencySpreadMs The spread of the latency in milliseconds, the width of the uniform distribution and the standard deviation of the normal one
+		LatencySpreadMs *int64 `json:"latencySpreadMs,omitempty"`
+
+		// Seed The seed of the random numbers, the query behaves the same on every run with a seed
+		Seed *int64 `json:"seed,omitempty"`
+	} `json:"chaos,omitempty"`
 	CsvContent  *string `json:"csvContent,omitempty"`
 	CsvFileName *string `json:"csvFileName,omitempty"`
 	CsvWave     []struct {
@@ -249,6 +337,12 @@ type TestDataDataQuery struct {
 	} `json:"usa,omitempty"`
 }
 
+// ChaosCorruptionType How the frames are corrupted
+type ChaosCorruptionType string
+
+// ChaosLatencyDistribution The distribution of the latency injected before the response
+type ChaosLatencyDistribution string
+
 // ErrorType defines model for TestDataDataQuery.ErrorType.
 type ErrorType string
 
@@ -45,6 +45,7 @@ const (
 	csvFileQueryType                  queryType = "csv_file"
 	csvContentQueryType               queryType = "csv_content"
 	traceType                         queryType = "trace"
+	chaosQuery                        queryType = "chaos"
 )
 
 type queryType string
@@ -187,6 +188,13 @@ Timestamps will line up evenly on timeStepSeconds (For example, 60 seconds means
 		Description: "Returns an error when the String Input field is empty",
 	})
 
+	s.registerScenario(&Scenario{
+		ID:          string(chaosQuery),
+		Name:        "Chaos",
+		handler:     s.handleChaosScenario,
+		Description: "Returns random walks with injected latency, errors and corrupted frames",
+	})
+
 	s.registerScenario(&Scenario{
 		ID:      string(logsQuery),
 		Name:    "Logs",
@@ -0,0 +1,104 @@
+package proxyutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// BaggageHeaderName is the name of the W3C baggage header.
+const BaggageHeaderName = "baggage"
+
+// The keys of the baggage members identifying the usage of Grafana causing the outgoing requests.
+const (
+	BaggageUserIDKey        = "grafana.user.id"
+	BaggageUserLoginKey     = "grafana.user.login"
+	BaggageOrgIDKey         = "grafana.org.id"
+	BaggageDashboardUIDKey  = "grafana.dashboard.uid"
+	BaggagePanelIDKey       = "grafana.panel.id"
+	BaggageDatasourceUIDKey = "grafana.datasource.uid"
+)
+
+// redactedBaggageValueLength is the number of hexadecimal characters of the hash of a redacted value.
+const redactedBaggageValueLength = 16
+
+// MergeBaggage sets the members with a value in the baggage, replacing the existing members with the same keys. The
+// values of the members whose keys are redacted are replaced with a hash of the value, so that the downstream
+// systems can still group the requests by value without knowing it.
+func MergeBaggage(b baggage.Baggage, members map[string]string, redactedKeys map[string]bool) baggage.Baggage {
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := members[key]
+		if value == "" {
+			continue
+		}
+		if redactedKeys[key] {
+			value = redactBaggageValue(value)
+		}
+		member, err := baggage.NewMember(key, url.QueryEscape(value))
+		if err != nil {
+			continue
+		}
+		if merged, err := b.SetMember(member); err == nil {
+			b = merged
+		}
+	}
+	return b
+}
+
+// UserBaggageMembers returns the baggage members identifying the user and its organization. The anonymous users are
+// only identified by their organization.
+func UserBaggageMembers(u *user.SignedInUser) map[string]string {
+	members := map[string]string{}
+	if u == nil {
+		return members
+	}
+	if u.OrgID != 0 {
+		members[BaggageOrgIDKey] = strconv.FormatInt(u.OrgID, 10)
+	}
+	if !u.IsAnonymous {
+		if u.UserID != 0 {
+			members[BaggageUserIDKey] = strconv.FormatInt(u.UserID, 10)
+		}
+		members[BaggageUserLoginKey] = u.Login
+	}
+	return members
+}
+
+// ApplyBaggageHeader merges the members in the baggage header of the request. The incoming members are kept as they
+// are, unless they have the key of one of the members.
+func ApplyBaggageHeader(req *http.Request, members map[string]string, redactedKeys map[string]bool) {
+	b := MergeBaggage(baggage.Baggage{}, members, redactedKeys)
+	if b.Len() == 0 {
+		return
+	}
+
+	list := []string{b.String()}
+	for _, member := range strings.Split(req.Header.Get(BaggageHeaderName), ",") {
+		member = strings.TrimSpace(member)
+		key := strings.TrimSpace(strings.SplitN(strings.SplitN(member, ";", 2)[0], "=", 2)[0])
+		if key == "" || b.Member(key).Key() != "" {
+			continue
+		}
+		list = append(list, member)
+	}
+	req.Header.Set(BaggageHeaderName, strings.Join(list, ","))
+}
+
+func redactBaggageValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:redactedBaggageValueLength]
+}
@@ -0,0 +1,56 @@
+package proxyutil
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestApplyBaggageHeader(t *testing.T) {
+	t.Run("Should merge the members in the incoming baggage", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		req.Header.Set(BaggageHeaderName, "tenant=team-a,"+BaggageOrgIDKey+"=5")
+
+		members := UserBaggageMembers(&user.SignedInUser{UserID: 3, OrgID: 1, Login: "john"})
+		members[BaggagePanelIDKey] = ""
+		ApplyBaggageHeader(req, members, map[string]bool{})
+
+		b, err := baggage.Parse(req.Header.Get(BaggageHeaderName))
+		require.NoError(t, err)
+		require.Equal(t, "team-a", b.Member("tenant").Value())
+		require.Equal(t, "1", b.Member(BaggageOrgIDKey).Value())
+		require.Equal(t, "3", b.Member(BaggageUserIDKey).Value())
+		require.Equal(t, "john", b.Member(BaggageUserLoginKey).Value())
+		require.Equal(t, 4, b.Len())
+	})
+
+	t.Run("Should escape the values", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+
+		ApplyBaggageHeader(req, map[string]string{BaggageDashboardUIDKey: "a,b;c d"}, map[string]bool{})
+		require.Equal(t, BaggageDashboardUIDKey+"=a%2Cb%3Bc+d", req.Header.Get(BaggageHeaderName))
+	})
+
+	t.Run("Should hash the values of the redacted keys", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+
+		ApplyBaggageHeader(req, map[string]string{BaggageUserLoginKey: "admin"}, map[string]bool{BaggageUserLoginKey: true})
+
+		b, err := baggage.Parse(req.Header.Get(BaggageHeaderName))
+		require.NoError(t, err)
+		require.Equal(t, redactBaggageValue("admin"), b.Member(BaggageUserLoginKey).Value())
+		require.Len(t, b.Member(BaggageUserLoginKey).Value(), redactedBaggageValueLength)
+	})
+
+	t.Run("Should not identify anonymous users", func(t *testing.T) {
+		members := UserBaggageMembers(&user.SignedInUser{OrgID: 1, IsAnonymous: true, Login: "anonymous"})
+		require.Equal(t, map[string]string{BaggageOrgIDKey: "1"}, members)
+	})
+}
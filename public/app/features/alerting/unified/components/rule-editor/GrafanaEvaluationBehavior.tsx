@@ -4,7 +4,18 @@ import { RegisterOptions, useFormContext } from 'react-hook-form';
 
 import { GrafanaTheme2, SelectableValue } from '@grafana/data';
 import { Stack } from '@grafana/experimental';
-import { Button, Field, InlineLabel, Input, InputControl, useStyles2, Switch, Tooltip, Icon } from '@grafana/ui';
+import {
+  Button,
+  Field,
+  InlineLabel,
+  Input,
+  InputControl,
+  useStyles2,
+  Switch,
+  Tooltip,
+  Icon,
+  TextArea,
+} from '@grafana/ui';
 import { RulerRulesConfigDTO } from 'app/types/unified-alerting-dto';
 
 import { CombinedRuleGroup, CombinedRuleNamespace } from '../../../../../types/unified-alerting';
@@ -123,7 +134,7 @@ function FolderGroupAndEvaluationInterval({
   setEvaluateEvery: (value: string) => void;
 }) {
   const styles = useStyles2(getStyles);
-  const { watch, setValue } = useFormContext<RuleFormValues>();
+  const { watch, setValue, register } = useFormContext<RuleFormValues>();
   const [isEditingGroup, setIsEditingGroup] = useState(false);
 
   const [groupName, folderName] = watch(['group', 'folder.title']);
@@ -300,6 +311,20 @@ export function GrafanaEvaluationBehavior({
             />
           </Field>
         )}
+        {existing && !isPaused && (
+          <Field
+            htmlFor="paused-dimensions"
+            label="Paused dimensions"
+            description='Pause the evaluation of the dimensions matching a label selector, one selector per line, for example {instance="server-1"}. The other dimensions are still evaluated.'
+          >
+            <TextArea
+              id="paused-dimensions"
+              rows={2}
+              placeholder={'{instance="server-1"}'}
+              {...register('pausedDimensions')}
+            />
+          </Field>
+        )}
       </Stack>
       <CollapseToggle
         isCollapsed={!showErrorHandling}
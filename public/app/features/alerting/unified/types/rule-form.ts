@@ -30,6 +30,7 @@ export interface RuleFormValues {
   evaluateEvery: string;
   evaluateFor: string;
   isPaused?: boolean;
+  pausedDimensions?: string; // one label selector per line
 
   // cortex / loki rules
   namespace: string;
@@ -33,4 +33,17 @@ describe('formValuesToRulerGrafanaRuleDTO', () => {
 
     expect(formValuesToRulerGrafanaRuleDTO(values)).toMatchSnapshot();
   });
+
+  it('should save one paused dimension selector per line', () => {
+    const values: RuleFormValues = {
+      ...getDefaultFormValues(),
+      condition: 'A',
+      pausedDimensions: '{instance="server-1"}\n\n  {env="dev", team=~"a|b"}  ',
+    };
+
+    expect(formValuesToRulerGrafanaRuleDTO(values).grafana_alert.paused_dimensions).toEqual([
+      '{instance="server-1"}',
+      '{env="dev", team=~"a|b"}',
+    ]);
+  });
 });
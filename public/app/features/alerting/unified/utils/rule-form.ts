@@ -95,7 +95,7 @@ function listifyLabelsOrAnnotations(item: Labels | Annotations | undefined): Arr
 }
 
 export function formValuesToRulerGrafanaRuleDTO(values: RuleFormValues): PostableRuleGrafanaRuleDTO {
-  const { name, condition, noDataState, execErrState, evaluateFor, queries, isPaused } = values;
+  const { name, condition, noDataState, execErrState, evaluateFor, queries, isPaused, pausedDimensions } = values;
   if (condition) {
     return {
       grafana_alert: {
@@ -105,6 +105,7 @@ export function formValuesToRulerGrafanaRuleDTO(values: RuleFormValues): Postabl
         exec_err_state: execErrState,
         data: queries.map(fixBothInstantAndRangeQuery),
         is_paused: Boolean(isPaused),
+        paused_dimensions: parsePausedDimensions(pausedDimensions),
       },
       for: evaluateFor,
       annotations: arrayToRecord(values.annotations || []),
@@ -114,6 +115,13 @@ export function formValuesToRulerGrafanaRuleDTO(values: RuleFormValues): Postabl
   throw new Error('Cannot create rule without specifying alert condition');
 }
 
+function parsePausedDimensions(pausedDimensions: string | undefined): string[] {
+  return (pausedDimensions ?? '')
+    .split('\n')
+    .map((selector) => selector.trim())
+    .filter((selector) => selector !== '');
+}
+
 export function rulerRuleToFormValues(ruleWithLocation: RuleWithLocation): RuleFormValues {
   const { ruleSourceName, namespace, group, rule } = ruleWithLocation;
 
@@ -136,6 +144,7 @@ export function rulerRuleToFormValues(ruleWithLocation: RuleWithLocation): RuleF
         labels: listifyLabelsOrAnnotations(rule.labels),
         folder: { title: namespace, id: ga.namespace_id },
         isPaused: ga.is_paused,
+        pausedDimensions: (ga.paused_dimensions ?? []).join('\n'),
       };
     } else {
       throw new Error('Unexpected type of rule for grafana rules source');
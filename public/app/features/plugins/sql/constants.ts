@@ -14,4 +14,6 @@ export const MACRO_NAMES = [
   '$__unixEpochNanoTo',
   '$__unixEpochGroup',
   '$__unixEpochGroupAlias',
+  '$__timeBucket',
+  '$__intervalRound',
 ];
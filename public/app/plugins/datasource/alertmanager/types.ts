@@ -119,13 +119,21 @@ export interface RouteWithID extends Route {
 }
 
 export type InhibitRule = {
-  target_match: Record<string, string>;
-  target_match_re: Record<string, string>;
-  source_match: Record<string, string>;
-  source_match_re: Record<string, string>;
+  target_match?: Record<string, string>;
+  target_match_re?: Record<string, string>;
+  target_matchers?: string[];
+  source_match?: Record<string, string>;
+  source_match_re?: Record<string, string>;
+  source_matchers?: string[];
   equal?: string[];
 };
 
+/** the inhibition rules of the provisioning API, they are provisioned together */
+export type InhibitionRules = {
+  rules: InhibitRule[];
+  provenance?: string;
+};
+
 export type AlertmanagerConfig = {
   global?: {
     smtp_from?: string;
@@ -155,6 +163,8 @@ export type AlertmanagerConfig = {
   mute_time_intervals?: MuteTimeInterval[];
   /** { [name]: provenance } */
   muteTimeProvenances?: Record<string, string>;
+  /** the provenance of all the inhibition rules */
+  inhibitRulesProvenance?: string;
 };
 
 export type Matcher = {
@@ -0,0 +1,33 @@
+import { ScopedVars } from '@grafana/data';
+import { getTemplateSrv, DataSourceWithBackend } from '@grafana/runtime';
+
+import { AzureMonitorQuery, AzureDataSourceJsonData, AzureQueryType, ResultFormat } from '../types';
+
+export default class AzureTracesDatasource extends DataSourceWithBackend<AzureMonitorQuery, AzureDataSourceJsonData> {
+  filterQuery(item: AzureMonitorQuery): boolean {
+    return item.hide !== true && !!item.azureTraces?.resources?.length;
+  }
+
+  applyTemplateVariables(target: AzureMonitorQuery, scopedVars: ScopedVars): AzureMonitorQuery {
+    const item = target.azureTraces;
+    if (!item) {
+      return target;
+    }
+
+    const templateSrv = getTemplateSrv();
+    const resources = item.resources?.map((r) => templateSrv.replace(r, scopedVars));
+    const operationId = templateSrv.replace(item.operationId, scopedVars);
+
+    return {
+      ...target,
+      queryType: AzureQueryType.AzureTraces,
+
+      azureTraces: {
+        resultFormat: item.resultFormat ?? ResultFormat.Trace,
+        resources,
+        operationId,
+        traceTypes: item.traceTypes,
+      },
+    };
+  }
+}
@@ -0,0 +1,32 @@
+import React, { useCallback } from 'react';
+
+import { InlineSwitch } from '@grafana/ui';
+
+import { AzureQueryEditorFieldProps } from '../../types';
+import { Field } from '../Field';
+
+import { setFanOutWorkspaces } from './setQueryValue';
+
+const FanOutWorkspacesField = ({ query, onQueryChange }: AzureQueryEditorFieldProps) => {
+  const handleChange = useCallback(
+    (event: React.FormEvent<HTMLInputElement>) => {
+      onQueryChange(setFanOutWorkspaces(query, event.currentTarget.checked));
+    },
+    [onQueryChange, query]
+  );
+
+  return (
+    <Field
+      label="Query each resource"
+      tooltip="Query the resources separately and merge the results. The resources failing to be queried are reported without failing the whole query."
+    >
+      <InlineSwitch
+        id="azure-monitor-logs-fan-out-workspaces-field"
+        value={query.azureLogAnalytics?.fanOutWorkspaces ?? false}
+        onChange={handleChange}
+      />
+    </Field>
+  );
+};
+
+export default FanOutWorkspacesField;
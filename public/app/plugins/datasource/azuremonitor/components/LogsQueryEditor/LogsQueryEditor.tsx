@@ -10,6 +10,7 @@ import { ResourceRow, ResourceRowGroup, ResourceRowType } from '../ResourcePicke
 import { parseResourceDetails } from '../ResourcePicker/utils';
 
 import AdvancedResourcePicker from './AdvancedResourcePicker';
+import FanOutWorkspacesField from './FanOutWorkspacesField';
 import FormatAsField from './FormatAsField';
 import QueryField from './QueryField';
 import useMigrations from './useMigrations';
@@ -102,6 +103,17 @@ const LogsQueryEditor = ({
               />
             )}
 
+            {(query.azureLogAnalytics?.resources?.length ?? 0) > 1 && (
+              <FanOutWorkspacesField
+                query={query}
+                datasource={datasource}
+                subscriptionId={subscriptionId}
+                variableOptionGroup={variableOptionGroup}
+                onQueryChange={onChange}
+                setError={setError}
+              />
+            )}
+
             {migrationError && <Alert title={migrationError.title}>{migrationError.message}</Alert>}
           </EditorFieldGroup>
         </EditorRow>
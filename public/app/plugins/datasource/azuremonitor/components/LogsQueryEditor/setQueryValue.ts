@@ -19,3 +19,13 @@ export function setFormatAs(query: AzureMonitorQuery, formatAs: ResultFormat): A
     },
   };
 }
+
+export function setFanOutWorkspaces(query: AzureMonitorQuery, fanOutWorkspaces: boolean): AzureMonitorQuery {
+  return {
+    ...query,
+    azureLogAnalytics: {
+      ...query.azureLogAnalytics,
+      fanOutWorkspaces,
+    },
+  };
+}
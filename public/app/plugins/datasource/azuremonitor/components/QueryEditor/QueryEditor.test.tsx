@@ -46,6 +46,17 @@ describe('Azure Monitor QueryEditor', () => {
     );
   });
 
+  it('renders the Traces query editor when the query type is Traces', async () => {
+    const mockDatasource = createMockDatasource();
+    const mockQuery = {
+      ...createMockQuery(),
+      queryType: AzureQueryType.AzureTraces,
+    };
+
+    render(<QueryEditor query={mockQuery} datasource={mockDatasource} onChange={() => {}} onRunQuery={() => {}} />);
+    await waitFor(() => expect(screen.queryByTestId('azure-monitor-traces-query-editor')).toBeInTheDocument());
+  });
+
   it('changes the query type when selected', async () => {
     const mockDatasource = createMockDatasource();
     const mockQuery = createMockQuery();
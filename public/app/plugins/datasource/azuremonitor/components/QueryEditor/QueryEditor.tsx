@@ -18,6 +18,7 @@ import LogsQueryEditor from '../LogsQueryEditor';
 import NewMetricsQueryEditor from '../MetricsQueryEditor/MetricsQueryEditor';
 import { QueryHeader } from '../QueryHeader';
 import { Space } from '../Space';
+import TracesQueryEditor from '../TracesQueryEditor';
 
 import usePreparedQuery from './usePreparedQuery';
 
@@ -131,6 +132,18 @@ const EditorForQueryType = ({
         />
       );
 
+    case AzureQueryType.AzureTraces:
+      return (
+        <TracesQueryEditor
+          subscriptionId={subscriptionId}
+          query={query}
+          datasource={datasource}
+          onChange={onChange}
+          variableOptionGroup={variableOptionGroup}
+          setError={setError}
+        />
+      );
+
     default:
       const type = query.queryType as unknown;
       return (
@@ -16,6 +16,7 @@ export const QueryHeader = ({ query, onQueryChange }: QueryTypeFieldProps) => {
     { value: AzureQueryType.AzureMonitor, label: 'Metrics' },
     { value: AzureQueryType.LogAnalytics, label: 'Logs' },
     { value: AzureQueryType.AzureResourceGraph, label: 'Azure Resource Graph' },
+    { value: AzureQueryType.AzureTraces, label: 'Traces' },
   ];
 
   const handleChange = useCallback(
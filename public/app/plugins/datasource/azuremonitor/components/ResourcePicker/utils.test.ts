@@ -1,4 +1,5 @@
 import createMockQuery from '../../__mocks__/query';
+import { AzureQueryType } from '../../types';
 
 import { ResourceRowGroup, ResourceRowType } from './types';
 import {
@@ -198,6 +199,13 @@ describe('AzureMonitor ResourcePicker utils', () => {
       });
     });
 
+    it('updates a resource with a resource URI for the traces', () => {
+      const query = createMockQuery({ queryType: AzureQueryType.AzureTraces });
+      expect(setResources(query, 'logs', ['/subscription/sub/components/app'])).toMatchObject({
+        azureTraces: { resources: ['/subscription/sub/components/app'] },
+      });
+    });
+
     it('ignores an empty resource URI', () => {
       expect(setResources(createMockQuery(), 'logs', ['/subscription/sub', ''])).toMatchObject({
         azureLogAnalytics: { resources: ['/subscription/sub'] },
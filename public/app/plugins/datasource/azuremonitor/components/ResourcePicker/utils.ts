@@ -4,7 +4,7 @@ import { getTemplateSrv } from '@grafana/runtime';
 
 import UrlBuilder from '../../azure_monitor/url_builder';
 import { ResourcePickerQueryType } from '../../resourcePicker/resourcePickerData';
-import { AzureMonitorResource, AzureMonitorQuery } from '../../types';
+import { AzureMonitorResource, AzureMonitorQuery, AzureQueryType } from '../../types';
 
 import { ResourceRow, ResourceRowGroup } from './types';
 
@@ -160,12 +160,22 @@ export function setResources(
   resources: Array<string | AzureMonitorResource>
 ): AzureMonitorQuery {
   if (type === 'logs') {
-    // Resource URI for LogAnalytics
+    // Resource URI for LogAnalytics and for the traces of Application Insights
+    const resourceURIs = resourcesToStrings(resources).filter((resource) => resource !== '');
+    if (query.queryType === AzureQueryType.AzureTraces) {
+      return {
+        ...query,
+        azureTraces: {
+          ...query.azureTraces,
+          resources: resourceURIs,
+        },
+      };
+    }
     return {
       ...query,
       azureLogAnalytics: {
         ...query.azureLogAnalytics,
-        resources: resourcesToStrings(resources).filter((resource) => resource !== ''),
+        resources: resourceURIs,
       },
     };
   }
@@ -0,0 +1,76 @@
+import { render, screen } from '@testing-library/react';
+import userEvent from '@testing-library/user-event';
+import React from 'react';
+import { selectOptionInTest } from 'test/helpers/selectOptionInTest';
+
+import createMockDatasource from '../../__mocks__/datasource';
+import createMockQuery from '../../__mocks__/query';
+import { AzureQueryType, ResultFormat } from '../../types';
+
+import TracesQueryEditor from './TracesQueryEditor';
+
+jest.mock('@grafana/runtime', () => ({
+  ...jest.requireActual('@grafana/runtime'),
+  getTemplateSrv: () => ({
+    replace: (val: string) => {
+      return val;
+    },
+  }),
+}));
+
+const variableOptionGroup = {
+  label: 'Template variables',
+  options: [],
+};
+
+const resource = '/subscriptions/def-456/resourceGroups/dev-3/providers/microsoft.insights/components/app';
+
+describe('TracesQueryEditor', () => {
+  it('should set the operation ID', async () => {
+    const query = createMockQuery({ queryType: AzureQueryType.AzureTraces, azureTraces: { resources: [resource] } });
+    const onChange = jest.fn();
+
+    render(
+      <TracesQueryEditor
+        query={query}
+        datasource={createMockDatasource()}
+        variableOptionGroup={variableOptionGroup}
+        onChange={onChange}
+        setError={() => {}}
+      />
+    );
+
+    const operationId = await screen.findByLabelText('Operation ID');
+    await userEvent.type(operationId, 'op-1');
+    await userEvent.tab();
+
+    expect(onChange).toHaveBeenCalledWith(
+      expect.objectContaining({ azureTraces: { resources: [resource], operationId: 'op-1' } })
+    );
+  });
+
+  it('should select the event types and the format', async () => {
+    const query = createMockQuery({ queryType: AzureQueryType.AzureTraces, azureTraces: { resources: [resource] } });
+    const onChange = jest.fn();
+
+    render(
+      <TracesQueryEditor
+        query={query}
+        datasource={createMockDatasource()}
+        variableOptionGroup={variableOptionGroup}
+        onChange={onChange}
+        setError={() => {}}
+      />
+    );
+
+    await selectOptionInTest(await screen.findByLabelText('Event types'), 'Exceptions');
+    expect(onChange).toHaveBeenCalledWith(
+      expect.objectContaining({ azureTraces: { resources: [resource], traceTypes: ['exceptions'] } })
+    );
+
+    await selectOptionInTest(await screen.findByLabelText('Format as'), 'Table');
+    expect(onChange).toHaveBeenCalledWith(
+      expect.objectContaining({ azureTraces: { resources: [resource], resultFormat: ResultFormat.Table } })
+    );
+  });
+});
@@ -0,0 +1,159 @@
+import React, { useCallback, useEffect, useState } from 'react';
+
+import { SelectableValue } from '@grafana/data';
+import { EditorFieldGroup, EditorRow, EditorRows } from '@grafana/experimental';
+import { Input, MultiSelect, Select } from '@grafana/ui';
+
+import Datasource from '../../datasource';
+import { selectors } from '../../e2e/selectors';
+import { AzureMonitorErrorish, AzureMonitorOption, AzureMonitorQuery, ResultFormat } from '../../types';
+import { Field } from '../Field';
+import AdvancedResourcePicker from '../LogsQueryEditor/AdvancedResourcePicker';
+import ResourceField from '../ResourceField';
+import { ResourceRow, ResourceRowType } from '../ResourcePicker/types';
+import { parseResourceDetails } from '../ResourcePicker/utils';
+
+import { setFormatAs, setOperationId, setTraceTypes } from './setQueryValue';
+
+// The Application Insights tables queried for the spans, the requests and the dependencies are queried by default
+const TRACE_TYPE_OPTIONS: Array<SelectableValue<string>> = [
+  { label: 'Requests', value: 'requests' },
+  { label: 'Dependencies', value: 'dependencies' },
+  { label: 'Traces', value: 'traces' },
+  { label: 'Exceptions', value: 'exceptions' },
+  { label: 'Page views', value: 'pageViews' },
+  { label: 'Custom events', value: 'customEvents' },
+  { label: 'Availability results', value: 'availabilityResults' },
+];
+
+const FORMAT_OPTIONS: Array<SelectableValue<ResultFormat>> = [
+  { label: 'Trace', value: ResultFormat.Trace },
+  { label: 'Table', value: ResultFormat.Table },
+];
+
+interface TracesQueryEditorProps {
+  query: AzureMonitorQuery;
+  datasource: Datasource;
+  subscriptionId?: string;
+  onChange: (newQuery: AzureMonitorQuery) => void;
+  variableOptionGroup: { label: string; options: AzureMonitorOption[] };
+  setError: (source: string, error: AzureMonitorErrorish | undefined) => void;
+}
+
+const TracesQueryEditor = ({
+  query,
+  datasource,
+  subscriptionId,
+  variableOptionGroup,
+  onChange,
+  setError,
+}: TracesQueryEditorProps) => {
+  const [operationId, setOperationIdValue] = useState(query.azureTraces?.operationId ?? '');
+
+  useEffect(() => {
+    setOperationIdValue(query.azureTraces?.operationId ?? '');
+  }, [query.azureTraces?.operationId]);
+
+  // Only the Application Insights resources have traces
+  const disableRow = (row: ResourceRow) => {
+    if (row.type !== ResourceRowType.Resource) {
+      return false;
+    }
+    const metricNamespace = parseResourceDetails(row.uri, row.location).metricNamespace?.toLowerCase();
+    return metricNamespace !== 'microsoft.insights/components';
+  };
+
+  const onOperationIdBlur = useCallback(() => {
+    if (operationId !== (query.azureTraces?.operationId ?? '')) {
+      onChange(setOperationId(query, operationId));
+    }
+  }, [onChange, operationId, query]);
+
+  const onTraceTypesChange = useCallback(
+    (change: Array<SelectableValue<string>>) => {
+      const traceTypes = change.map((option) => option.value ?? '').filter((value) => value !== '');
+      onChange(setTraceTypes(query, traceTypes));
+    },
+    [onChange, query]
+  );
+
+  const onFormatAsChange = useCallback(
+    (change: SelectableValue<ResultFormat>) => {
+      change.value && onChange(setFormatAs(query, change.value));
+    },
+    [onChange, query]
+  );
+
+  return (
+    <span data-testid="azure-monitor-traces-query-editor">
+      <EditorRows>
+        <EditorRow>
+          <EditorFieldGroup>
+            <ResourceField
+              query={query}
+              datasource={datasource}
+              inlineField={true}
+              labelWidth={10}
+              subscriptionId={subscriptionId}
+              variableOptionGroup={variableOptionGroup}
+              onQueryChange={onChange}
+              setError={setError}
+              selectableEntryTypes={[ResourceRowType.Resource, ResourceRowType.Variable]}
+              resources={query.azureTraces?.resources ?? []}
+              queryType="logs"
+              disableRow={disableRow}
+              renderAdvanced={(resources, onChange) => (
+                // It's required to cast resources because the resource picker
+                // specifies the type to string | AzureMonitorResource.
+                // eslint-disable-next-line
+                <AdvancedResourcePicker resources={resources as string[]} onChange={onChange} />
+              )}
+              selectionNotice={() => 'You may only choose Application Insights resources.'}
+            />
+          </EditorFieldGroup>
+        </EditorRow>
+        <EditorRow>
+          <EditorFieldGroup>
+            <Field
+              label="Operation ID"
+              data-testid={selectors.components.queryEditor.tracesQueryEditor.operationId.input}
+            >
+              <Input
+                id="azure-monitor-traces-operation-id-field"
+                value={operationId}
+                placeholder="All operations"
+                onChange={(event) => setOperationIdValue(event.currentTarget.value)}
+                onBlur={onOperationIdBlur}
+                width={38}
+              />
+            </Field>
+            <Field
+              label="Event types"
+              data-testid={selectors.components.queryEditor.tracesQueryEditor.traceTypes.select}
+            >
+              <MultiSelect
+                inputId="azure-monitor-traces-event-types-field"
+                value={query.azureTraces?.traceTypes ?? []}
+                placeholder="Requests, Dependencies"
+                options={TRACE_TYPE_OPTIONS}
+                onChange={onTraceTypesChange}
+                width={38}
+              />
+            </Field>
+            <Field label="Format as">
+              <Select
+                inputId="azure-monitor-traces-format-as-field"
+                value={query.azureTraces?.resultFormat ?? ResultFormat.Trace}
+                options={FORMAT_OPTIONS}
+                onChange={onFormatAsChange}
+                width={20}
+              />
+            </Field>
+          </EditorFieldGroup>
+        </EditorRow>
+      </EditorRows>
+    </span>
+  );
+};
+
+export default TracesQueryEditor;
@@ -0,0 +1,31 @@
+import { AzureMonitorQuery, ResultFormat } from '../../types';
+
+export function setOperationId(query: AzureMonitorQuery, operationId: string): AzureMonitorQuery {
+  return {
+    ...query,
+    azureTraces: {
+      ...query.azureTraces,
+      operationId,
+    },
+  };
+}
+
+export function setTraceTypes(query: AzureMonitorQuery, traceTypes: string[]): AzureMonitorQuery {
+  return {
+    ...query,
+    azureTraces: {
+      ...query.azureTraces,
+      traceTypes,
+    },
+  };
+}
+
+export function setFormatAs(query: AzureMonitorQuery, formatAs: ResultFormat): AzureMonitorQuery {
+  return {
+    ...query,
+    azureTraces: {
+      ...query.azureTraces,
+      resultFormat: formatAs,
+    },
+  };
+}
@@ -43,6 +43,8 @@ composableKinds: DataQuery: {
 							azureLogAnalytics?: #AzureLogsQuery
 							// Azure Resource Graph sub-query properties.
 							azureResourceGraph?: #AzureResourceGraphQuery
+							// Application Insights Traces sub-query properties.
+							azureTraces?: #AzureTracesQuery
 							// @deprecated Legacy template variable support.
 							grafanaTemplateVariableFn?: #GrafanaTemplateVariableQuery
 
@@ -56,7 +58,7 @@ composableKinds: DataQuery: {
 						} @cuetsy(kind="interface") @grafana(TSVeneer="type")
 
 						// Defines the supported queryTypes. GrafanaTemplateVariableFn is deprecated
-						#AzureQueryType: "Azure Monitor" | "Azure Log Analytics" | "Azure Resource Graph" | "Azure Subscriptions" | "Azure Resource Groups" | "Azure Namespaces" | "Azure Resource Names" | "Azure Metric Names" | "Azure Workspaces" | "Azure Regions" | "Grafana Template Variable Function" @cuetsy(kind="enum", memberNames="AzureMonitor|LogAnalytics|AzureResourceGraph|SubscriptionsQuery|ResourceGroupsQuery|NamespacesQuery|ResourceNamesQuery|MetricNamesQuery|WorkspacesQuery|LocationsQuery|GrafanaTemplateVariableFn")
+						#AzureQueryType: "Azure Monitor" | "Azure Log Analytics" | "Azure Resource Graph" | "Azure Traces" | "Azure Subscriptions" | "Azure Resource Groups" | "Azure Namespaces" | "Azure Resource Names" | "Azure Metric Names" | "Azure Workspaces" | "Azure Regions" | "Grafana Template Variable Function" @cuetsy(kind="enum", memberNames="AzureMonitor|LogAnalytics|AzureResourceGraph|AzureTraces|SubscriptionsQuery|ResourceGroupsQuery|NamespacesQuery|ResourceNamesQuery|MetricNamesQuery|WorkspacesQuery|LocationsQuery|GrafanaTemplateVariableFn")
 
 						#AzureMetricQuery: {
 							// Array of resource URIs to be queried.
@@ -114,6 +116,8 @@ composableKinds: DataQuery: {
 							resultFormat?: #ResultFormat
 							// Array of resource URIs to be queried.
 							resources?: [...string]
+							// Query each resource separately and merge the results, instead of a single cross-resource query.
+							fanOutWorkspaces?: bool
 							// Workspace ID. This was removed in Grafana 8, but remains for backwards compat
 							workspace?: string
 
@@ -121,7 +125,19 @@ composableKinds: DataQuery: {
 							resource?: string
 						} @cuetsy(kind="interface")
 
-						#ResultFormat: "table" | "time_series" @cuetsy(kind="enum", memberNames="Table|TimeSeries")
+						#ResultFormat: "table" | "time_series" | "trace" @cuetsy(kind="enum", memberNames="Table|TimeSeries|Trace")
+
+						// Application Insights Traces sub-query properties
+						#AzureTracesQuery: {
+							// Specifies the format results should be returned as.
+							resultFormat?: #ResultFormat
+							// Array of resource URIs to be queried.
+							resources?: [...string]
+							// Operation ID. Used to query the spans of a single trace.
+							operationId?: string
+							// Types of events to query. Defaults to the requests and the dependencies.
+							traceTypes?: [...string]
+						} @cuetsy(kind="interface")
 
 						#AzureResourceGraphQuery: {
 							// Azure Resource Graph KQL query to be executed.
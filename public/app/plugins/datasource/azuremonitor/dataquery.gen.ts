@@ -25,6 +25,10 @@ export interface AzureMonitorQuery extends common.DataQuery {
    * Azure Resource Graph sub-query properties.
    */
   azureResourceGraph?: AzureResourceGraphQuery;
+  /**
+   * Application Insights Traces sub-query properties.
+   */
+  azureTraces?: AzureTracesQuery;
   /**
    * @deprecated Legacy template variable support.
    */
@@ -60,6 +64,7 @@ export const defaultAzureMonitorQuery: Partial<AzureMonitorQuery> = {
 export enum AzureQueryType {
   AzureMonitor = 'Azure Monitor',
   AzureResourceGraph = 'Azure Resource Graph',
+  AzureTraces = 'Azure Traces',
   GrafanaTemplateVariableFn = 'Grafana Template Variable Function',
   LocationsQuery = 'Azure Regions',
   LogAnalytics = 'Azure Log Analytics',
@@ -158,6 +163,10 @@ export const defaultAzureMetricQuery: Partial<AzureMetricQuery> = {
  * Azure Monitor Logs sub-query properties
  */
 export interface AzureLogsQuery {
+  /**
+   * Query each resource separately and merge the results, instead of a single cross-resource query.
+   */
+  fanOutWorkspaces?: boolean;
   /**
    * KQL query to be executed.
    */
@@ -187,8 +196,36 @@ export const defaultAzureLogsQuery: Partial<AzureLogsQuery> = {
 export enum ResultFormat {
   Table = 'table',
   TimeSeries = 'time_series',
+  Trace = 'trace',
 }
 
+/**
+ * Application Insights Traces sub-query properties
+ */
+export interface AzureTracesQuery {
+  /**
+   * Operation ID. Used to query the spans of a single trace.
+   */
+  operationId?: string;
+  /**
+   * Array of resource URIs to be queried.
+   */
+  resources?: Array<string>;
+  /**
+   * Specifies the format results should be returned as.
+   */
+  resultFormat?: ResultFormat;
+  /**
+   * Types of events to query. Defaults to the requests and the dependencies.
+   */
+  traceTypes?: Array<string>;
+}
+
+export const defaultAzureTracesQuery: Partial<AzureTracesQuery> = {
+  resources: [],
+  traceTypes: [],
+};
+
 export interface AzureResourceGraphQuery {
   /**
    * Azure Resource Graph KQL query to be executed.
@@ -16,6 +16,7 @@ import { getTemplateSrv, TemplateSrv } from 'app/features/templating/template_sr
 import AzureLogAnalyticsDatasource from './azure_log_analytics/azure_log_analytics_datasource';
 import AzureMonitorDatasource from './azure_monitor/azure_monitor_datasource';
 import AzureResourceGraphDatasource from './azure_resource_graph/azure_resource_graph_datasource';
+import AzureTracesDatasource from './azure_traces/azure_traces_datasource';
 import ResourcePickerData from './resourcePicker/resourcePickerData';
 import { AzureDataSourceJsonData, AzureMonitorQuery, AzureQueryType } from './types';
 import migrateAnnotation from './utils/migrateAnnotation';
@@ -31,9 +32,14 @@ export default class Datasource extends DataSourceWithBackend<AzureMonitorQuery,
   azureLogAnalyticsDatasource: AzureLogAnalyticsDatasource;
   resourcePickerData: ResourcePickerData;
   azureResourceGraphDatasource: AzureResourceGraphDatasource;
+  azureTracesDatasource: AzureTracesDatasource;
 
   pseudoDatasource: {
-    [key in AzureQueryType]?: AzureMonitorDatasource | AzureLogAnalyticsDatasource | AzureResourceGraphDatasource;
+    [key in AzureQueryType]?:
+      | AzureMonitorDatasource
+      | AzureLogAnalyticsDatasource
+      | AzureResourceGraphDatasource
+      | AzureTracesDatasource;
   } = {};
 
   declare optionsKey: Record<AzureQueryType, string>;
@@ -46,12 +52,14 @@ export default class Datasource extends DataSourceWithBackend<AzureMonitorQuery,
     this.azureMonitorDatasource = new AzureMonitorDatasource(instanceSettings);
     this.azureLogAnalyticsDatasource = new AzureLogAnalyticsDatasource(instanceSettings);
     this.azureResourceGraphDatasource = new AzureResourceGraphDatasource(instanceSettings);
+    this.azureTracesDatasource = new AzureTracesDatasource(instanceSettings);
     this.resourcePickerData = new ResourcePickerData(instanceSettings, this.azureMonitorDatasource);
 
     this.pseudoDatasource = {
       [AzureQueryType.AzureMonitor]: this.azureMonitorDatasource,
       [AzureQueryType.LogAnalytics]: this.azureLogAnalyticsDatasource,
       [AzureQueryType.AzureResourceGraph]: this.azureResourceGraphDatasource,
+      [AzureQueryType.AzureTraces]: this.azureTracesDatasource,
     };
 
     this.variables = new VariableSupport(this);
@@ -133,6 +141,8 @@ export default class Datasource extends DataSourceWithBackend<AzureMonitorQuery,
       subQuery = JSON.stringify(query.azureLogAnalytics);
     } else if (query.queryType === AzureQueryType.AzureResourceGraph) {
       subQuery = JSON.stringify([query.azureResourceGraph, query.subscriptions]);
+    } else if (query.queryType === AzureQueryType.AzureTraces) {
+      subQuery = JSON.stringify(query.azureTraces);
     }
 
     return !!subQuery && this.templateSrv.containsTemplate(subQuery);
@@ -213,6 +223,9 @@ function hasQueryForType(query: AzureMonitorQuery): boolean {
     case AzureQueryType.AzureResourceGraph:
       return !!query.azureResourceGraph;
 
+    case AzureQueryType.AzureTraces:
+      return !!query.azureTraces;
+
     case AzureQueryType.GrafanaTemplateVariableFn:
       return !!query.grafanaTemplateVariableFn;
 
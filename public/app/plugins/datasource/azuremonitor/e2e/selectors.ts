@@ -70,6 +70,14 @@ export const components = {
         input: 'data-testid format-selection',
       },
     },
+    tracesQueryEditor: {
+      operationId: {
+        input: 'data-testid operation-id',
+      },
+      traceTypes: {
+        select: 'data-testid trace-types',
+      },
+    },
     argsQueryEditor: {
       container: {
         input: 'data-testid azure-monitor-arg-query-editor',
@@ -5,6 +5,7 @@ export {
   AzureMetricQuery,
   AzureLogsQuery,
   AzureResourceGraphQuery,
+  AzureTracesQuery,
   AzureMonitorResource,
   AzureMetricDimension,
   ResultFormat,
@@ -124,4 +124,38 @@ describe('QueryEditor', () => {
 
     expect(screen.getByText('Group By')).toBeInTheDocument();
   });
+
+  describe('EQL queries', () => {
+    it('Should only show the query field', () => {
+      const query: ElasticsearchQuery = {
+        refId: 'A',
+        queryType: 'eql',
+        query: 'process where process.name == "cmd.exe"',
+        metrics: [{ id: '1', type: 'count' }],
+        bucketAggs: [{ id: '2', type: 'date_histogram' }],
+      };
+
+      render(<QueryEditor query={query} datasource={datasourceMock} onChange={noop} onRunQuery={noop} />);
+
+      expect(screen.getByLabelText('EQL')).toBeChecked();
+      expect(screen.queryByLabelText('Alias')).not.toBeInTheDocument();
+      expect(screen.queryByText('Group By')).not.toBeInTheDocument();
+    });
+
+    it('Should clear the query when the query type changes', () => {
+      const query: ElasticsearchQuery = {
+        refId: 'A',
+        query: 'status:500',
+        metrics: [{ id: '1', type: 'count' }],
+        bucketAggs: [{ id: '2', type: 'date_histogram' }],
+      };
+      const onChange = jest.fn<void, [ElasticsearchQuery]>();
+
+      render(<QueryEditor query={query} datasource={datasourceMock} onChange={onChange} onRunQuery={noop} />);
+      fireEvent.click(screen.getByLabelText('EQL'));
+
+      expect(onChange).toHaveBeenCalledTimes(1);
+      expect(onChange.mock.calls[0][0]).toMatchObject({ queryType: 'eql', query: '' });
+    });
+  });
 });
@@ -1,20 +1,21 @@
 import { css } from '@emotion/css';
 import React from 'react';
 
-import { getDefaultTimeRange, GrafanaTheme2, QueryEditorProps } from '@grafana/data';
-import { Alert, InlineField, InlineLabel, Input, QueryField, useStyles2 } from '@grafana/ui';
+import { getDefaultTimeRange, GrafanaTheme2, QueryEditorProps, SelectableValue } from '@grafana/data';
+import { Alert, InlineField, InlineLabel, Input, QueryField, RadioButtonGroup, useStyles2 } from '@grafana/ui';
 
 import { ElasticDatasource } from '../../datasource';
 import { useNextId } from '../../hooks/useNextId';
 import { useDispatch } from '../../hooks/useStatelessReducer';
-import { ElasticsearchOptions, ElasticsearchQuery } from '../../types';
+import { isEQLQuery } from '../../queryDef';
+import { ElasticsearchOptions, ElasticsearchQuery, ElasticsearchQueryType } from '../../types';
 import { isSupportedVersion } from '../../utils';
 
 import { BucketAggregationsEditor } from './BucketAggregationsEditor';
 import { ElasticsearchProvider } from './ElasticsearchQueryContext';
 import { MetricAggregationsEditor } from './MetricAggregationsEditor';
 import { metricAggregationConfig } from './MetricAggregationsEditor/utils';
-import { changeAliasPattern, changeQuery } from './state';
+import { changeAliasPattern, changeQuery, changeQueryType } from './state';
 
 export type ElasticQueryEditorProps = QueryEditorProps<ElasticDatasource, ElasticsearchQuery, ElasticsearchOptions>;
 
@@ -53,7 +54,18 @@ interface Props {
   value: ElasticsearchQuery;
 }
 
-export const ElasticSearchQueryField = ({ value, onChange }: { value?: string; onChange: (v: string) => void }) => {
+const queryTypeOptions: Array<SelectableValue<ElasticsearchQueryType>> = [
+  { label: 'Lucene', value: 'lucene' },
+  { label: 'EQL', value: 'eql', description: 'Event Query Language, for the event and sequence queries' },
+];
+
+interface QueryFieldProps {
+  value?: string;
+  onChange: (v: string) => void;
+  placeholder?: string;
+}
+
+export const ElasticSearchQueryField = ({ value, onChange, placeholder = 'Lucene Query' }: QueryFieldProps) => {
   const styles = useStyles2(getStyles);
 
   return (
@@ -64,7 +76,7 @@ export const ElasticSearchQueryField = ({ value, onChange }: { value?: string; o
         // And slate will claim the focus, making it impossible to leave the field.
         onBlur={() => {}}
         onChange={onChange}
-        placeholder="Lucene Query"
+        placeholder={placeholder}
         portalOrigin="elasticsearch"
       />
     </div>
@@ -83,29 +95,47 @@ const QueryEditorForm = ({ value }: Props) => {
     (metric) => !metricAggregationConfig[metric.type].isSingleMetric
   );
 
+  const isEQL = isEQLQuery(value);
+
   return (
     <>
       <div className={styles.root}>
-        <InlineLabel width={17}>Query</InlineLabel>
-        <ElasticSearchQueryField onChange={(query) => dispatch(changeQuery(query))} value={value?.query} />
-
-        <InlineField
-          label="Alias"
-          labelWidth={15}
-          disabled={!isTimeSeriesQuery}
-          tooltip="Aliasing only works for timeseries queries (when the last group is 'Date Histogram'). For all other query types this field is ignored."
-        >
-          <Input
-            id={`ES-query-${value.refId}_alias`}
-            placeholder="Alias Pattern"
-            onBlur={(e) => dispatch(changeAliasPattern(e.currentTarget.value))}
-            defaultValue={value.alias}
+        <InlineField label="Query type" labelWidth={17}>
+          <RadioButtonGroup
+            options={queryTypeOptions}
+            value={isEQL ? 'eql' : 'lucene'}
+            onChange={(queryType) => dispatch(changeQueryType(queryType))}
+            size="sm"
           />
         </InlineField>
       </div>
+      <div className={styles.root}>
+        <InlineLabel width={17}>Query</InlineLabel>
+        <ElasticSearchQueryField
+          onChange={(query) => dispatch(changeQuery(query))}
+          value={value?.query}
+          placeholder={isEQL ? 'EQL Query' : undefined}
+        />
+
+        {!isEQL && (
+          <InlineField
+            label="Alias"
+            labelWidth={15}
+            disabled={!isTimeSeriesQuery}
+            tooltip="Aliasing only works for timeseries queries (when the last group is 'Date Histogram'). For all other query types this field is ignored."
+          >
+            <Input
+              id={`ES-query-${value.refId}_alias`}
+              placeholder="Alias Pattern"
+              onBlur={(e) => dispatch(changeAliasPattern(e.currentTarget.value))}
+              defaultValue={value.alias}
+            />
+          </InlineField>
+        )}
+      </div>
 
-      <MetricAggregationsEditor nextId={nextId} />
-      {showBucketAggregationsEditor && <BucketAggregationsEditor nextId={nextId} />}
+      {!isEQL && <MetricAggregationsEditor nextId={nextId} />}
+      {!isEQL && showBucketAggregationsEditor && <BucketAggregationsEditor nextId={nextId} />}
     </>
   );
 };
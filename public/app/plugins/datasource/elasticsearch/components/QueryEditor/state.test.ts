@@ -2,7 +2,15 @@ import { reducerTester } from 'test/core/redux/reducerTester';
 
 import { ElasticsearchQuery } from '../../types';
 
-import { aliasPatternReducer, changeAliasPattern, changeQuery, initQuery, queryReducer } from './state';
+import {
+  aliasPatternReducer,
+  changeAliasPattern,
+  changeQuery,
+  changeQueryType,
+  initQuery,
+  queryReducer,
+  queryTypeReducer,
+} from './state';
 
 describe('Query Reducer', () => {
   describe('On Init', () => {
@@ -35,6 +43,13 @@ describe('Query Reducer', () => {
       .thenStateShouldEqual(expectedQuery);
   });
 
+  it('Should clear `query` when the query type changes', () => {
+    reducerTester<ElasticsearchQuery['query']>()
+      .givenReducer(queryReducer, 'Some lucene query')
+      .whenActionIsDispatched(changeQueryType('eql'))
+      .thenStateShouldEqual('');
+  });
+
   it('Should not change state with other action types', () => {
     const initialState: ElasticsearchQuery['query'] = 'Some lucene query';
 
@@ -64,3 +79,19 @@ describe('Alias Pattern Reducer', () => {
       .thenStateShouldEqual(initialState);
   });
 });
+
+describe('Query Type Reducer', () => {
+  it('Should correctly set `queryType`', () => {
+    reducerTester<ElasticsearchQuery['queryType']>()
+      .givenReducer(queryTypeReducer, undefined)
+      .whenActionIsDispatched(changeQueryType('eql'))
+      .thenStateShouldEqual('eql');
+  });
+
+  it('Should not change state with other action types', () => {
+    reducerTester<ElasticsearchQuery['queryType']>()
+      .givenReducer(queryTypeReducer, 'eql')
+      .whenActionIsDispatched(initQuery())
+      .thenStateShouldEqual('eql');
+  });
+});
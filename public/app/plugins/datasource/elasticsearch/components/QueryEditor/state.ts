@@ -1,6 +1,6 @@
 import { Action, createAction } from '@reduxjs/toolkit';
 
-import { ElasticsearchQuery } from '../../types';
+import { ElasticsearchQuery, ElasticsearchQueryType } from '../../types';
 
 /**
  * When the `initQuery` Action is dispatched, the query gets populated with default values where values are not present.
@@ -12,11 +12,18 @@ export const changeQuery = createAction<ElasticsearchQuery['query']>('change_que
 
 export const changeAliasPattern = createAction<ElasticsearchQuery['alias']>('change_alias_pattern');
 
+export const changeQueryType = createAction<ElasticsearchQueryType>('change_query_type');
+
 export const queryReducer = (prevQuery: ElasticsearchQuery['query'], action: Action) => {
   if (changeQuery.match(action)) {
     return action.payload;
   }
 
+  // A Lucene query is not a valid EQL query, and the other way around
+  if (changeQueryType.match(action)) {
+    return '';
+  }
+
   if (initQuery.match(action)) {
     return prevQuery || '';
   }
@@ -35,3 +42,11 @@ export const aliasPatternReducer = (prevAliasPattern: ElasticsearchQuery['alias'
 
   return prevAliasPattern;
 };
+
+export const queryTypeReducer = (prevQueryType: ElasticsearchQuery['queryType'], action: Action) => {
+  if (changeQueryType.match(action)) {
+    return action.payload;
+  }
+
+  return prevQueryType;
+};
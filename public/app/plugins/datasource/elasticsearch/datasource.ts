@@ -46,7 +46,7 @@ import {
   isPipelineAggregationWithMultipleBucketPaths,
 } from './components/QueryEditor/MetricAggregationsEditor/aggregations';
 import { metricAggregationConfig } from './components/QueryEditor/MetricAggregationsEditor/utils';
-import { defaultBucketAgg, hasMetricOfType } from './queryDef';
+import { defaultBucketAgg, hasMetricOfType, isEQLQuery } from './queryDef';
 import { trackQuery } from './tracking';
 import { Logs, BucketAggregation, DataLinkConfig, ElasticsearchOptions, ElasticsearchQuery, TermsQuery } from './types';
 import { coerceESVersion, getScriptValue, isSupportedVersion } from './utils';
@@ -673,8 +673,10 @@ export class ElasticDatasource
   }
 
   query(request: DataQueryRequest<ElasticsearchQuery>): Observable<DataQueryResponse> {
+    // The EQL queries are only supported by the backend, which runs the other queries of the request too
     const shouldRunTroughBackend =
-      request.app === CoreApp.Explore && config.featureToggles.elasticsearchBackendMigration;
+      (request.app === CoreApp.Explore && config.featureToggles.elasticsearchBackendMigration) ||
+      request.targets.some((target) => !target.hide && isEQLQuery(target));
     if (shouldRunTroughBackend) {
       const start = new Date();
       return super.query(request).pipe(tap((response) => trackQuery(response, request, start)));
@@ -47,6 +47,10 @@ export function hasMetricOfType(target: ElasticsearchQuery, type: MetricAggregat
   return !!target?.metrics?.some((m) => m.type === type);
 }
 
+export function isEQLQuery(target: ElasticsearchQuery): boolean {
+  return target?.queryType === 'eql';
+}
+
 // Even if we have type guards when building a query, we currently have no way of getting this information from the response.
 // We should try to find a better (type safe) way of doing the following 2.
 export function isPipelineAgg(metricType: MetricAggregationType) {
@@ -35,6 +35,11 @@ export interface MovingAverage<T extends MovingAverageModel = MovingAverageModel
   settings?: MovingAverageModelSettings<T>;
 }
 
+/**
+ * The Lucene queries are the default, the EQL (Event Query Language) queries are only run by the backend.
+ */
+export type ElasticsearchQueryType = 'lucene' | 'eql';
+
 export type Interval = 'Hourly' | 'Daily' | 'Weekly' | 'Monthly' | 'Yearly';
 
 export interface ElasticsearchOptions extends DataSourceJsonData {
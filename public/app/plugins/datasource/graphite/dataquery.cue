@@ -0,0 +1,67 @@
+// Copyright 2023 Grafana Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grafanaplugin
+
+import (
+	"github.com/grafana/grafana/packages/grafana-schema/src/common"
+	"github.com/grafana/grafana/pkg/plugins/pfs"
+)
+
+// This file (with its sibling .cue files) implements pfs.GrafanaPlugin
+pfs.GrafanaPlugin
+
+composableKinds: DataQuery: {
+	maturity: "experimental"
+
+	lineage: {
+		seqs: [
+			{
+				schemas: [
+					// v0.0
+					{
+						common.DataQuery
+
+						// The Graphite target of the query
+						target?: string
+						// The target with the references to the other queries replaced by their targets
+						targetFull?: string
+						// Whether the query is edited in the text editor
+						textEditor?: bool
+						// Tag-based query, the backend generates the target of the query from it
+						tagQuery?: #GraphiteTagQuery
+
+						#GraphiteTagQuery: {
+							// The tag expressions of the seriesByTag function, at least one of them must match a non-empty value
+							expressions: [...#GraphiteTagExpression]
+							// The functions applied to the series, in order
+							functions?: [...#GraphiteFunction]
+						} @cuetsy(kind="interface")
+						#GraphiteTagExpression: {
+							key:      string
+							operator: #GraphiteTagOperator
+							value:    string
+						} @cuetsy(kind="interface")
+						#GraphiteTagOperator: "=" | "!=" | "=~" | "!=~" @cuetsy(kind="type")
+						#GraphiteFunction: {
+							name: string
+							// The parameters of the function after the series, numbers and booleans are not quoted
+							params?: [...string]
+						} @cuetsy(kind="interface")
+					},
+				]
+			},
+		]
+	}
+}
@@ -770,7 +770,7 @@ export class GraphiteDatasource
       httpOptions.params.from = this.translateTime(options.range.from, false, options.timezone);
       httpOptions.params.until = this.translateTime(options.range.to, true, options.timezone);
     }
-    return lastValueFrom(this.doGraphiteRequest(httpOptions).pipe(mapToTags()));
+    return lastValueFrom(this.doResourceRequest(httpOptions).pipe(mapToTags()));
   }
 
   getTagValuesAutoComplete(expressions: any[], tag: any, valuePrefix: any, optionalOptions: any) {
@@ -797,7 +797,7 @@ export class GraphiteDatasource
       httpOptions.params.from = this.translateTime(options.range.from, false, options.timezone);
       httpOptions.params.until = this.translateTime(options.range.to, true, options.timezone);
     }
-    return lastValueFrom(this.doGraphiteRequest(httpOptions).pipe(mapToTags()));
+    return lastValueFrom(this.doResourceRequest(httpOptions).pipe(mapToTags()));
   }
 
   getVersion(optionalOptions: any) {
@@ -928,6 +928,23 @@ export class GraphiteDatasource
       );
   }
 
+  /**
+   * Requests a resource of the backend, which proxies the tag autocomplete endpoints of Graphite with the credentials
+   * of the data source.
+   */
+  doResourceRequest(options: { method?: string; url: string; params?: any; requestId?: any; inspect?: any }) {
+    options.url = `/api/datasources/uid/${this.uid}/resources${options.url}`;
+    options.inspect = { type: 'graphite' };
+
+    return getBackendSrv()
+      .fetch(options)
+      .pipe(
+        catchError((err: any) => {
+          return throwError(reduceError(err));
+        })
+      );
+  }
+
   buildGraphiteParams(options: any, scopedVars?: ScopedVars): string[] {
     const graphiteOptions = ['from', 'until', 'rawData', 'format', 'maxDataPoints', 'cacheTimeout'];
     const cleanOptions = [],
@@ -4,6 +4,7 @@ import { ScopedVars } from '@grafana/data';
 import { TemplateSrv } from '@grafana/runtime';
 import { arrayMove } from 'app/core/utils/arrayMove';
 
+import { GraphiteTagQuery } from './dataquery.gen';
 import { GraphiteDatasource } from './datasource';
 import { FuncInstance } from './gfunc';
 import { Parser } from './parser';
@@ -26,6 +27,10 @@ export type GraphiteTarget = {
   targetFull: string;
   textEditor: boolean;
   paused: boolean;
+  /**
+   * Structured model of the queries built from tags only, the backend generates their target from it
+   */
+  tagQuery?: GraphiteTagQuery;
 };
 
 export default class GraphiteQuery {
@@ -185,6 +190,13 @@ export default class GraphiteQuery {
     if (!this.target.textEditor) {
       const metricPath = this.getSegmentPathUpTo(this.segments.length).replace(/\.?select metric$/, '');
       this.target.target = reduce(this.functions, wrapFunction, metricPath);
+
+      const tagQuery = metricPath === '' ? this.getTagQuery() : undefined;
+      if (tagQuery) {
+        this.target.tagQuery = tagQuery;
+      } else {
+        delete this.target.tagQuery;
+      }
     }
 
     this.updateRenderedTarget(this.target, targets);
@@ -268,6 +280,29 @@ export default class GraphiteQuery {
     );
   }
 
+  /**
+   * Returns the tag query of a query selecting its series with seriesByTag only, the functions referencing other
+   * queries can't be modeled.
+   */
+  getTagQuery(): GraphiteTagQuery | undefined {
+    if (this.tags.length === 0 || this.getSeriesByTagFuncIndex() !== 0) {
+      return undefined;
+    }
+
+    const functions = this.functions.slice(1).map((func) => ({
+      name: func.def.name,
+      params: func.params.map((param) => String(param)),
+    }));
+    if (functions.some((func) => func.params.some((param) => param.startsWith('#')))) {
+      return undefined;
+    }
+
+    return {
+      expressions: this.tags.map((tag) => ({ key: tag.key, operator: tag.operator, value: tag.value })),
+      functions,
+    };
+  }
+
   getSeriesByTagFuncIndex() {
     return findIndex(this.functions, (func) => func.def.name === 'seriesByTag');
   }
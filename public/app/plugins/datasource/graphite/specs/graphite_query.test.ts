@@ -90,6 +90,34 @@ describe('Graphite query model', () => {
     });
   });
 
+  describe('when query selects its series with seriesByTag only', () => {
+    it('should update the tag query of the target', () => {
+      ctx.target = { refId: 'A', target: `aliasByTags(seriesByTag('name=cpu', 'host=~web.*'), 'host')` };
+      ctx.queryModel = new GraphiteQuery(ctx.datasource, ctx.target, ctx.templateSrv);
+      ctx.queryModel.updateModelTarget([ctx.target]);
+
+      expect(ctx.queryModel.target.tagQuery).toEqual({
+        expressions: [
+          { key: 'name', operator: '=', value: 'cpu' },
+          { key: 'host', operator: '=~', value: 'web.*' },
+        ],
+        functions: [{ name: 'aliasByTags', params: ['host'] }],
+      });
+    });
+
+    it('should remove the tag query of the target referencing other queries', () => {
+      ctx.target = {
+        refId: 'A',
+        target: `group(seriesByTag('namespace=asd'), #B)`,
+        tagQuery: { expressions: [{ key: 'namespace', operator: '=', value: 'asd' }] },
+      };
+      ctx.queryModel = new GraphiteQuery(ctx.datasource, ctx.target, ctx.templateSrv);
+      ctx.queryModel.updateModelTarget([ctx.target]);
+
+      expect(ctx.queryModel.target.tagQuery).toBeUndefined();
+    });
+  });
+
   describe('when query is generated from segments', () => {
     beforeEach(() => {
       ctx.target = { refId: 'A', target: '' };
@@ -108,6 +136,7 @@ describe('Graphite query model', () => {
       ctx.queryModel.updateModelTarget(ctx.targets);
 
       expect(ctx.queryModel.target.target).toBe('foo.bar');
+      expect(ctx.queryModel.target.tagQuery).toBeUndefined();
     });
   });
 });
@@ -2,6 +2,7 @@ import { DataQuery, DataQueryRequest, DataSourceJsonData, TimeRange } from '@gra
 
 import { TemplateSrv } from '../../../features/templating/template_srv';
 
+import { GraphiteTagQuery } from './dataquery.gen';
 import { GraphiteDatasource } from './datasource';
 
 export enum GraphiteQueryType {
@@ -16,6 +17,7 @@ export interface GraphiteQuery extends DataQuery {
   target?: string;
   tags?: string[];
   fromAnnotations?: boolean;
+  tagQuery?: GraphiteTagQuery;
 }
 
 export interface GraphiteOptions extends DataSourceJsonData {
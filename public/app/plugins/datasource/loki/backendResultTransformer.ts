@@ -47,7 +47,8 @@ function processStreamFrame(
   const derivedFields = getDerivedFields(newFrame, derivedFieldConfigs);
   return {
     ...newFrame,
-    fields: [...newFrame.fields, ...derivedFields],
+    // the fields extracted by the backend are replaced by the same fields with their links
+    fields: [...newFrame.fields.filter((f) => !derivedFields.some((d) => d.name === f.name)), ...derivedFields],
   };
 }
 
@@ -23,6 +23,7 @@ import {
   config,
   FetchResponse,
   getBackendSrv,
+  HealthStatus,
   reportInteraction,
   setBackendSrv,
 } from '@grafana/runtime';
@@ -331,8 +332,10 @@ describe('LokiDatasource', () => {
       ds = createLokiDatasource(templateSrvStub);
     });
 
-    it('should return successfully when call succeeds with labels', async () => {
-      ds.metadataRequest = () => Promise.resolve(['avalue']);
+    it('should return successfully when the health check of the backend succeeds', async () => {
+      jest
+        .spyOn(ds, 'callHealthCheck')
+        .mockResolvedValue({ status: HealthStatus.OK, message: 'Data source connected and labels found.' });
 
       const result = await ds.testDatasource();
 
@@ -342,42 +345,26 @@ describe('LokiDatasource', () => {
       });
     });
 
-    it('should return error when call succeeds without labels', async () => {
-      ds.metadataRequest = () => Promise.resolve([]);
-
-      const result = await ds.testDatasource();
-
-      expect(result).toStrictEqual({
-        status: 'error',
+    it('should fail with the message of the health check of the backend', async () => {
+      jest.spyOn(ds, 'callHealthCheck').mockResolvedValue({
+        status: HealthStatus.Error,
         message: 'Data source connected, but no labels received. Verify that Loki and Promtail is configured properly.',
       });
-    });
-
-    it('should return error status with no details when call fails with no details', async () => {
-      ds.metadataRequest = () => Promise.reject({});
 
-      const result = await ds.testDatasource();
-
-      expect(result).toStrictEqual({
-        status: 'error',
-        message: 'Unable to fetch labels from Loki, please check the server logs for more details',
-      });
+      await expect(ds.testDatasource()).rejects.toThrow(
+        'Data source connected, but no labels received. Verify that Loki and Promtail is configured properly.'
+      );
     });
+  });
 
-    it('should return error status with details when call fails with details', async () => {
-      ds.metadataRequest = () =>
-        Promise.reject({
-          data: {
-            message: 'error42',
-          },
-        });
-
-      const result = await ds.testDatasource();
+  describe('when the data source has no index API', () => {
+    it('should not request the label volumes and the query stats', async () => {
+      const ds = createLokiDatasource(templateSrvStub, { capabilities: { labelVolumes: false, indexStats: false } });
+      const getResourceMock = jest.spyOn(ds, 'getResource');
 
-      expect(result).toStrictEqual({
-        status: 'error',
-        message: 'Unable to fetch labels from Loki (error42), please check the server logs for more details',
-      });
+      expect(await ds.getLabelVolumes('{job="grafana"}')).toEqual([]);
+      expect(await ds.getQueryStats('{job="grafana"}')).toEqual({ streams: 0, chunks: 0, bytes: 0, entries: 0 });
+      expect(getResourceMock).not.toHaveBeenCalled();
     });
   });
 
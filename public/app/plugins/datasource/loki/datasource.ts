@@ -83,6 +83,7 @@ import { doLokiChannelStream } from './streaming';
 import { trackQuery } from './tracking';
 import {
   ContextFilter,
+  LabelVolume,
   LokiOptions,
   LokiQuery,
   LokiQueryDirection,
@@ -429,6 +430,9 @@ export class LokiDatasource
     const labelMatchers = getStreamSelectorsFromQuery(query);
 
     let statsForAll: QueryStats = { streams: 0, chunks: 0, bytes: 0, entries: 0 };
+    if (this.instanceSettings.capabilities?.indexStats === false) {
+      return statsForAll;
+    }
 
     for (const labelMatcher of labelMatchers) {
       try {
@@ -452,6 +456,27 @@ export class LokiDatasource
     return statsForAll;
   }
 
+  // The volumes are ordered by Grafana with the largest first. Older Loki versions without the index volume API return
+  // no volumes, they are not requested when the health check found that the API is missing.
+  async getLabelVolumes(query: string, targetLabels: string[] = []): Promise<LabelVolume[]> {
+    if (this.instanceSettings.capabilities?.labelVolumes === false) {
+      return [];
+    }
+
+    const { start, end } = this.getTimeRangeParams();
+    const params: Record<string, string | number> = { query, start, end };
+    if (targetLabels.length > 0) {
+      params.targetLabels = targetLabels.join(',');
+    }
+
+    try {
+      const res = await this.getResource('index/volume', params, { showErrorAlert: false });
+      return res.volumes ?? [];
+    } catch (e) {
+      return [];
+    }
+  }
+
   async metricFindQuery(query: LokiVariableQuery | string) {
     if (!query) {
       return Promise.resolve([]);
@@ -847,38 +872,6 @@ export class LokiDatasource
     });
   }
 
-  testDatasource(): Promise<{ status: string; message: string }> {
-    // Consider only last 10 minutes otherwise request takes too long
-    const nowMs = Date.now();
-    const params = {
-      start: (nowMs - 10 * 60 * 1000) * NS_IN_MS,
-      end: nowMs * NS_IN_MS,
-    };
-
-    return this.metadataRequest('labels', params).then(
-      (values) => {
-        return values.length > 0
-          ? { status: 'success', message: 'Data source connected and labels found.' }
-          : {
-              status: 'error',
-              message:
-                'Data source connected, but no labels received. Verify that Loki and Promtail is configured properly.',
-            };
-      },
-      (err) => {
-        // we did a resource-call that failed.
-        // the only info we have, if exists, is err.data.message
-        // (when in development-mode, err.data.error exists too, but not in production-mode)
-        // things like err.status & err.statusText does not help,
-        // because those will only describe how the request between browser<>server failed
-        const info: string = err?.data?.message ?? '';
-        const infoInParentheses = info !== '' ? ` (${info})` : '';
-        const message = `Unable to fetch labels from Loki${infoInParentheses}, please check the server logs for more details`;
-        return { status: 'error', message: message };
-      }
-    );
-  }
-
   async annotationQuery(options: any): Promise<AnnotationEvent[]> {
     const { expr, maxLines, instant, tagKeys = '', titleFormat = '', textFormat = '' } = options.annotation;
 
@@ -59,4 +59,33 @@ describe('getDerivedFields', () => {
       url: '',
     });
   });
+
+  it('adds links to the fields extracted by the backend', () => {
+    const df = new MutableDataFrame({
+      fields: [
+        { name: 'line', values: ['trace1=1234', 'trace2=foo'] },
+        { name: 'trace1', values: ['1234', null] },
+      ],
+    });
+    const newFields = getDerivedFields(df, [
+      {
+        matcherRegex: 'trace1=(\\w+)',
+        name: 'trace1',
+        url: 'http://localhost/${__value.raw}',
+      },
+      {
+        matcherRegex: 'trace2=(\\w+)',
+        name: 'trace2',
+      },
+    ]);
+    expect(newFields.length).toBe(2);
+    const trace1 = newFields.find((f) => f.name === 'trace1');
+    expect(trace1!.values.toArray()).toEqual(['1234', null]);
+    expect(trace1!.config.links![0]).toEqual({
+      url: 'http://localhost/${__value.raw}',
+      title: '',
+    });
+    const trace2 = newFields.find((f) => f.name === 'trace2');
+    expect(trace2!.values.toArray()).toEqual([null, 'foo']);
+  });
 });
@@ -13,6 +13,16 @@ export function getDerivedFields(dataFrame: DataFrame, derivedFieldConfigs: Deri
 
   const newFields = Object.values(derivedFieldsGrouped).map(fieldFromDerivedFieldConfig);
 
+  // the fields extracted by the backend only miss their links, the other fields are extracted from the lines
+  const backendFields = newFields.flatMap((field) => {
+    const existing = dataFrame.fields.find((f) => f.name === field.name);
+    return existing ? [{ ...existing, config: { ...existing.config, links: field.config.links } }] : [];
+  });
+  const extractedFields = newFields.filter((field) => !backendFields.some((f) => f.name === field.name));
+  if (!extractedFields.length) {
+    return backendFields;
+  }
+
   // line-field is the first string-field
   // NOTE: we should create some common log-frame-extra-string-field code somewhere
   const lineField = dataFrame.fields.find((f) => f.type === FieldType.string);
@@ -23,13 +33,15 @@ export function getDerivedFields(dataFrame: DataFrame, derivedFieldConfigs: Deri
   }
 
   lineField.values.toArray().forEach((line) => {
-    for (const field of newFields) {
-      const logMatch = line.match(derivedFieldsGrouped[field.name][0].matcherRegex);
+    for (const field of extractedFields) {
+      const config = derivedFieldsGrouped[field.name][0];
+      // the label matchers are only run by the backend
+      const logMatch = config.matcherType === 'label' ? null : line.match(config.matcherRegex);
       field.values.add(logMatch && logMatch[1]);
     }
   });
 
-  return newFields;
+  return [...backendFields, ...extractedFields];
 }
 
 /**
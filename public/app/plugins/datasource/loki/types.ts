@@ -121,6 +121,8 @@ export interface LokiExpression {
 
 export type DerivedFieldConfig = {
   matcherRegex: string;
+  // label reads the value from the label named matcherRegex, the values are extracted by the backend
+  matcherType?: 'regex' | 'label';
   name: string;
   url?: string;
   urlDisplayLabel?: string;
@@ -153,6 +155,12 @@ export interface QueryStats {
   entries: number;
 }
 
+// The bytes of a stream, or of a value of the target labels, returned by the index/volume resource
+export interface LabelVolume {
+  labels: Record<string, string>;
+  bytes: number;
+}
+
 export interface ContextFilter {
   enabled: boolean;
   label: string;
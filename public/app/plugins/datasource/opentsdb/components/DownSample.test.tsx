@@ -12,6 +12,7 @@ const tsdbVersions = [
   { label: '<=2.1', value: 1 },
   { label: '==2.2', value: 2 },
   { label: '==2.3', value: 3 },
+  { label: '==2.4', value: 4 },
 ];
 
 const setup = (tsdbVersion: number, propOverrides?: Object) => {
@@ -71,4 +72,16 @@ describe('DownSample', () => {
       expect(screen.queryByText('none')).toBeNull();
     });
   });
+
+  describe('rollup usage select', () => {
+    it('should display the rollup usage for versions >= 2.4', () => {
+      setup(tsdbVersions[3].value, { query: { metric: '', refId: 'A', rollupUsage: 'ROLLUP_FALLBACK' } });
+      expect(screen.getByText('fallback')).toBeInTheDocument();
+    });
+
+    it('does not display the rollup usage for versions < 2.4', () => {
+      setup(tsdbVersions[2].value);
+      expect(screen.queryByText('Rollup')).toBeNull();
+    });
+  });
 });
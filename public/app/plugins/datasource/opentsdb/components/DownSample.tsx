@@ -7,6 +7,21 @@ import { OpenTsdbQuery } from '../types';
 
 import { paddingRightClass } from './styles';
 
+const rollupUsageOptions = [
+  { label: 'raw', value: 'ROLLUP_RAW', description: 'Query the raw data only' },
+  { label: 'no fallback', value: 'ROLLUP_NOFALLBACK', description: 'Query the rollup table of the interval only' },
+  {
+    label: 'fallback',
+    value: 'ROLLUP_FALLBACK',
+    description: 'Fall back to the rollup tables of the lower intervals when the interval has none',
+  },
+  {
+    label: 'fallback to raw',
+    value: 'ROLLUP_FALLBACK_RAW',
+    description: 'Fall back to the raw data when the interval has no rollup table',
+  },
+];
+
 export interface DownSampleProps {
   query: OpenTsdbQuery;
   onChange: (query: OpenTsdbQuery) => void;
@@ -79,6 +94,27 @@ export function DownSample({ query, onChange, onRunQuery, aggregators, fillPolic
           />
         </div>
       )}
+      {tsdbVersion >= 4 && (
+        <div className="gf-form">
+          <InlineLabel
+            className="width-6 query-keyword"
+            tooltip="How the query uses the rollup tables of the downsampling interval"
+          >
+            Rollup
+          </InlineLabel>
+          <Select
+            inputId="opentsdb-rollupusage-select"
+            value={query.rollupUsage}
+            options={rollupUsageOptions}
+            placeholder="raw"
+            isClearable
+            onChange={(option) => {
+              onChange({ ...query, rollupUsage: option?.value });
+              onRunQuery();
+            }}
+          />
+        </div>
+      )}
       <div className="gf-form">
         <InlineFormLabel className="query-keyword">Disable downsampling</InlineFormLabel>
         <InlineSwitch
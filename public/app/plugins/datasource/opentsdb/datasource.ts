@@ -223,7 +223,7 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
       msResolution: msResolution,
       globalAnnotations: true,
     };
-    if (this.tsdbVersion === 3) {
+    if (this.tsdbVersion >= 3) {
       reqBody.showQuery = true;
     }
 
@@ -537,6 +537,10 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
       }
     }
 
+    if (tsdbVersion >= 4 && target.rollupUsage) {
+      query.rollupUsage = target.rollupUsage;
+    }
+
     if (target.filters && target.filters.length > 0) {
       query.filters = cloneDeep(target.filters);
 
@@ -573,7 +577,7 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
   mapMetricsToTargets(metrics: any, options: any, tsdbVersion: number) {
     let interpolatedTagValue, arrTagV;
     return _map(metrics, (metricData) => {
-      if (tsdbVersion === 3) {
+      if (tsdbVersion >= 3) {
         return metricData.query.index;
       } else {
         return findIndex(options.targets as any[], (target) => {
@@ -219,4 +219,27 @@ describe('opentsdb', () => {
       expect(templateSrv.replace).toHaveBeenCalledTimes(2);
     });
   });
+
+  describe('When converting targets to queries', () => {
+    const options = { interval: '1h', scopedVars: {} } as unknown as DataQueryRequest<OpenTsdbQuery>;
+    const target: OpenTsdbQuery = {
+      refId: 'A',
+      metric: 'logins.count',
+      downsampleAggregator: 'sum',
+      rollupUsage: 'ROLLUP_FALLBACK',
+    };
+
+    it('should set the rollup usage for versions >= 2.4', () => {
+      const { ds } = getTestcontext();
+      expect(ds.convertTargetToQuery(target, options, 4)).toMatchObject({
+        downsample: '1h-sum',
+        rollupUsage: 'ROLLUP_FALLBACK',
+      });
+    });
+
+    it('should not set the rollup usage for versions < 2.4', () => {
+      const { ds } = getTestcontext();
+      expect(ds.convertTargetToQuery(target, options, 3)).not.toHaveProperty('rollupUsage');
+    });
+  });
 });
@@ -12,6 +12,7 @@ export interface OpenTsdbQuery extends DataQuery {
   downsampleAggregator?: string;
   downsampleFillPolicy?: string;
   disableDownsampling?: boolean;
+  rollupUsage?: string;
 
   //filters
   filters?: OpenTsdbFilter[];
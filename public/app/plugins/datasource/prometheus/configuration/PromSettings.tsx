@@ -299,6 +299,19 @@ export const PromSettings = (props: Props) => {
             />
           </InlineField>
         </div>
+        <div className="gf-form">
+          <InlineField
+            labelWidth={28}
+            label="Build heatmaps on the server"
+            tooltip="Checking this option converts the histogram buckets of the queries with the heatmap format into heatmap rows on the server, so that the alert rules and the clients of the query API get the same heatmaps as the panels."
+            disabled={options.readOnly}
+          >
+            <InlineSwitch
+              value={options.jsonData.heatmapServerSide ?? false}
+              onChange={onUpdateDatasourceJsonDataOptionChecked(props, 'heatmapServerSide')}
+            />
+          </InlineField>
+        </div>
         <div className="gf-form">
           <FormField
             label="Default Editor"
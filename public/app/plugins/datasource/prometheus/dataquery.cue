@@ -44,9 +44,18 @@ composableKinds: DataQuery: {
 						editorMode?: #QueryEditorMode
 						// Query format to determine how to display data points in panel. It can be "time_series", "table", "heatmap"
 						format?: #PromQueryFormat
+						// Highest resolution of the downsampled blocks of Thanos read by the query, the raw samples can be skipped on long time ranges. Sent as the max_source_resolution parameter
+						downsampling?: #PromDownsampling
+						// HTTP headers sent with the requests of the query, such as the read consistency of Mimir
+						headers?: [...#PromQueryHeader]
 
-						#QueryEditorMode: "code" | "builder"                  @cuetsy(kind="enum")
-						#PromQueryFormat: "time_series" | "table" | "heatmap" @cuetsy(kind="type")
+						#QueryEditorMode:  "code" | "builder"                  @cuetsy(kind="enum")
+						#PromQueryFormat:  "time_series" | "table" | "heatmap" @cuetsy(kind="type")
+						#PromDownsampling: "raw" | "5m" | "1h" | "auto"        @cuetsy(kind="type")
+						#PromQueryHeader: {
+							name:  string
+							value: string
+						} @cuetsy(kind="interface")
 					},
 				]
 			},
@@ -19,7 +19,18 @@ export enum QueryEditorMode {
 
 export type PromQueryFormat = ('time_series' | 'table' | 'heatmap');
 
+export type PromDownsampling = ('raw' | '5m' | '1h' | 'auto');
+
+export interface PromQueryHeader {
+  name: string;
+  value: string;
+}
+
 export interface Prometheus extends common.DataQuery {
+  /**
+   * Highest resolution of the downsampled blocks of Thanos read by the query, the raw samples can be skipped on long time ranges. Sent as the max_source_resolution parameter
+   */
+  downsampling?: PromDownsampling;
   /**
    * Specifies which editor is being used to prepare the query. It can be "code" or "builder"
    */
@@ -36,6 +47,10 @@ export interface Prometheus extends common.DataQuery {
    * Query format to determine how to display data points in panel. It can be "time_series", "table", "heatmap"
    */
   format?: PromQueryFormat;
+  /**
+   * HTTP headers sent with the requests of the query, such as the read consistency of Mimir
+   */
+  headers?: Array<PromQueryHeader>;
   /**
    * Returns only the latest value that Prometheus has scraped for the requested time series
    */
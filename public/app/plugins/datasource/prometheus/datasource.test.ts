@@ -677,6 +677,23 @@ describe('PrometheusDatasource', () => {
       expect(interpolatedQuery.interval).toBe(step);
     });
 
+    it('should call replace function for the header values and drop the headers without a name', () => {
+      const query = {
+        expr: 'test{job="bar"}',
+        headers: [
+          { name: 'X-Read-Consistency', value: '$consistency' },
+          { name: '', value: 'edited' },
+        ],
+        refId: 'A',
+      };
+      replaceMock.mockReturnValue('strong');
+
+      const interpolatedQuery = ds.applyTemplateVariables(query, {
+        consistency: { text: 'strong', value: 'strong' },
+      });
+      expect(interpolatedQuery.headers).toEqual([{ name: 'X-Read-Consistency', value: 'strong' }]);
+    });
+
     it('should call replace function for expr', () => {
       const query = {
         expr: 'test{job="$job"}',
@@ -45,6 +45,7 @@ import { PromApiFeatures, PromApplication } from 'app/types/unified-alerting-dto
 
 import { addLabelToQuery } from './add_label_to_query';
 import { AnnotationQueryEditor } from './components/AnnotationQueryEditor';
+import { PromQueryHeader } from './dataquery.gen';
 import PrometheusLanguageProvider from './language_provider';
 import { expandRecordingRules } from './language_utils';
 import { renderLegendFormat } from './legend';
@@ -1077,6 +1078,7 @@ export class PrometheusDatasource
             this.templateSrv.replace(query.expr, scopedVars, this.interpolateQueryExpr)
           ),
           interval: this.templateSrv.replace(query.interval, scopedVars),
+          headers: this.interpolateQueryHeaders(query.headers, scopedVars),
         };
         return expandedQuery;
       });
@@ -1214,6 +1216,18 @@ export class PrometheusDatasource
   }
 
   // Used when running queries trough backend
+  // the headers without a name are still being edited and are not sent
+  interpolateQueryHeaders(
+    headers: PromQueryHeader[] | undefined,
+    scopedVars: ScopedVars
+  ): PromQueryHeader[] | undefined {
+    const named = headers?.filter((header) => header.name);
+    if (!named?.length) {
+      return undefined;
+    }
+    return named.map((header) => ({ name: header.name, value: this.templateSrv.replace(header.value, scopedVars) }));
+  }
+
   applyTemplateVariables(target: PromQuery, scopedVars: ScopedVars): Record<string, any> {
     const variables = cloneDeep(scopedVars);
 
@@ -1229,6 +1243,7 @@ export class PrometheusDatasource
       legendFormat: this.templateSrv.replace(target.legendFormat, variables),
       expr: this.templateSrv.replace(expr, variables, this.interpolateQueryExpr),
       interval: this.templateSrv.replace(target.interval, variables),
+      headers: this.interpolateQueryHeaders(target.headers, variables),
     };
   }
 
@@ -86,6 +86,44 @@ describe('PromQueryBuilderOptions', () => {
     expect(screen.getByText('Type: Instant')).toBeInTheDocument();
   });
 
+  it('Can change downsampling', async () => {
+    const { props } = setup();
+
+    await userEvent.click(screen.getByTitle('Click to edit options'));
+    await selectOptionInTest(screen.getByLabelText('Select downsampling'), '5m');
+
+    expect(props.onChange).toHaveBeenCalledWith({ ...props.query, downsampling: '5m' });
+  });
+
+  it('Should show the downsampling when it is set', () => {
+    setup({ downsampling: '1h' });
+    expect(screen.getByText('Downsampling: 1h')).toBeInTheDocument();
+  });
+
+  it('Can add a header', async () => {
+    const { props } = setup();
+
+    await userEvent.click(screen.getByTitle('Click to edit options'));
+    await userEvent.click(screen.getByLabelText('Add header'));
+
+    expect(props.onChange).toHaveBeenCalledWith({ ...props.query, headers: [{ name: '', value: '' }] });
+  });
+
+  it('Can remove a header', async () => {
+    const { props } = setup({ headers: [{ name: 'X-Read-Consistency', value: 'strong' }] });
+
+    await userEvent.click(screen.getByTitle('Click to edit options'));
+    await userEvent.click(screen.getByLabelText('Remove header'));
+
+    expect(props.onChange).toHaveBeenCalledWith({ ...props.query, headers: undefined });
+    expect(props.onRunQuery).toHaveBeenCalled();
+  });
+
+  it('Should show the number of headers when they are set', () => {
+    setup({ headers: [{ name: 'X-Read-Consistency', value: 'strong' }] });
+    expect(screen.getByText('Headers: 1')).toBeInTheDocument();
+  });
+
   it('Should show "Exemplars: false" by default', () => {
     setup();
     expect(screen.getByText('Exemplars: false')).toBeInTheDocument();
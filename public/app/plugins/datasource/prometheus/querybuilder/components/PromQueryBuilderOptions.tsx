@@ -5,11 +5,12 @@ import { EditorField, EditorRow, EditorSwitch } from '@grafana/experimental';
 import { AutoSizeInput, RadioButtonGroup, Select } from '@grafana/ui';
 
 import { getQueryTypeChangeHandler, getQueryTypeOptions } from '../../components/PromExploreExtraField';
-import { PromQueryFormat } from '../../dataquery.gen';
+import { PromDownsampling, PromQueryFormat } from '../../dataquery.gen';
 import { PromQuery } from '../../types';
 import { QueryOptionGroup } from '../shared/QueryOptionGroup';
 
-import { FORMAT_OPTIONS, INTERVAL_FACTOR_OPTIONS } from './PromQueryEditorSelector';
+import { DOWNSAMPLING_OPTIONS, FORMAT_OPTIONS, INTERVAL_FACTOR_OPTIONS } from './PromQueryEditorSelector';
+import { PromQueryHeadersEditor } from './PromQueryHeadersEditor';
 import { getLegendModeLabel, PromQueryLegendEditor } from './PromQueryLegendEditor';
 
 export interface UIOptions {
@@ -53,6 +54,11 @@ export const PromQueryBuilderOptions = React.memo<Props>(({ query, app, onChange
     onRunQuery();
   };
 
+  const onDownsamplingChange = (value: SelectableValue<PromDownsampling> | null) => {
+    onChange({ ...query, downsampling: value?.value });
+    onRunQuery();
+  };
+
   const formatOption = FORMAT_OPTIONS.find((option) => option.value === query.format) || FORMAT_OPTIONS[0];
   const queryTypeValue = getQueryTypeValue(query);
   const queryTypeLabel = queryTypeOptions.find((x) => x.value === queryTypeValue)!.label;
@@ -97,6 +103,31 @@ export const PromQueryBuilderOptions = React.memo<Props>(({ query, app, onChange
             <EditorSwitch value={query.exemplar || false} onChange={onExemplarChange} />
           </EditorField>
         )}
+        <EditorField
+          label="Downsampling"
+          tooltip={
+            <>
+              Highest resolution of the downsampled blocks read by the query on Thanos, sent as the{' '}
+              <code>max_source_resolution</code> parameter. Lower resolutions make the queries over long time ranges
+              faster.
+            </>
+          }
+        >
+          <Select
+            aria-label="Select downsampling"
+            isSearchable={false}
+            isClearable
+            placeholder="Default"
+            options={DOWNSAMPLING_OPTIONS}
+            onChange={onDownsamplingChange}
+            value={DOWNSAMPLING_OPTIONS.find((option) => option.value === query.downsampling) ?? null}
+          />
+        </EditorField>
+        <PromQueryHeadersEditor
+          headers={query.headers}
+          onChange={(headers) => onChange({ ...query, headers })}
+          onRunQuery={onRunQuery}
+        />
         {query.intervalFactor && query.intervalFactor > 1 && (
           <EditorField label="Resolution">
             <Select
@@ -133,6 +164,14 @@ function getCollapsedInfo(query: PromQuery, formatOption: string, queryType: str
   items.push(`Step: ${query.interval ?? 'auto'}`);
   items.push(`Type: ${queryType}`);
 
+  if (query.downsampling) {
+    items.push(`Downsampling: ${query.downsampling}`);
+  }
+
+  if (query.headers?.length) {
+    items.push(`Headers: ${query.headers.length}`);
+  }
+
   if (shouldShowExemplarSwitch(query, app)) {
     if (query.exemplar) {
       items.push(`Exemplars: true`);
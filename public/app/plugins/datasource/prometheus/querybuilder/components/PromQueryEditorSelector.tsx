@@ -8,7 +8,7 @@ import { reportInteraction } from '@grafana/runtime';
 import { Button, ConfirmModal } from '@grafana/ui';
 
 import { PromQueryEditorProps } from '../../components/types';
-import { PromQueryFormat } from '../../dataquery.gen';
+import { PromDownsampling, PromQueryFormat } from '../../dataquery.gen';
 import { PromQuery } from '../../types';
 import { QueryPatternsModal } from '../QueryPatternsModal';
 import { buildVisualQueryFromString } from '../parsing';
@@ -28,6 +28,13 @@ export const FORMAT_OPTIONS: Array<SelectableValue<PromQueryFormat>> = [
   { label: 'Heatmap', value: 'heatmap' },
 ];
 
+export const DOWNSAMPLING_OPTIONS: Array<SelectableValue<PromDownsampling>> = [
+  { label: 'Raw', value: 'raw' },
+  { label: '5m', value: '5m' },
+  { label: '1h', value: '1h' },
+  { label: 'Auto', value: 'auto' },
+];
+
 export const INTERVAL_FACTOR_OPTIONS: Array<SelectableValue<number>> = map([1, 2, 3, 4, 5, 10], (value: number) => ({
   value,
   label: '1/' + value,
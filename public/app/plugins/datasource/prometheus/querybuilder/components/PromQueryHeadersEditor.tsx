@@ -0,0 +1,77 @@
+import React from 'react';
+
+import { EditorField } from '@grafana/experimental';
+import { AutoSizeInput, Button, HorizontalGroup, IconButton, VerticalGroup } from '@grafana/ui';
+
+import { PromQueryHeader } from '../../dataquery.gen';
+
+export interface Props {
+  headers: PromQueryHeader[] | undefined;
+  onChange: (headers: PromQueryHeader[] | undefined) => void;
+  onRunQuery: () => void;
+}
+
+/**
+ * Tests for this component are on the parent level (PromQueryBuilderOptions).
+ */
+export const PromQueryHeadersEditor = React.memo<Props>(({ headers = [], onChange, onRunQuery }) => {
+  const onHeaderChange = (index: number, header: PromQueryHeader) => {
+    const updated = [...headers];
+    updated[index] = header;
+    onChange(updated);
+    if (header.name) {
+      onRunQuery();
+    }
+  };
+
+  const onRemove = (index: number) => {
+    const updated = headers.filter((_, i) => i !== index);
+    onChange(updated.length > 0 ? updated : undefined);
+    onRunQuery();
+  };
+
+  return (
+    <EditorField
+      label="Headers"
+      tooltip={
+        <>
+          HTTP headers sent with the requests of the query, for example <code>X-Read-Consistency</code> on Mimir. The
+          headers of the data source take precedence.
+        </>
+      }
+    >
+      <VerticalGroup spacing="xs">
+        {headers.map((header, index) => (
+          <HorizontalGroup key={`${index}:${header.name}:${header.value}`} spacing="xs">
+            <AutoSizeInput
+              aria-label="Header name"
+              placeholder="Name"
+              minWidth={12}
+              defaultValue={header.name}
+              onCommitChange={(evt) => onHeaderChange(index, { ...header, name: evt.currentTarget.value.trim() })}
+            />
+            <AutoSizeInput
+              aria-label="Header value"
+              placeholder="Value"
+              minWidth={12}
+              defaultValue={header.value}
+              onCommitChange={(evt) => onHeaderChange(index, { ...header, value: evt.currentTarget.value })}
+            />
+            <IconButton name="times" aria-label="Remove header" onClick={() => onRemove(index)} />
+          </HorizontalGroup>
+        ))}
+        <Button
+          variant="secondary"
+          size="sm"
+          icon="plus"
+          aria-label="Add header"
+          onClick={() => onChange([...headers, { name: '', value: '' }])}
+        >
+          Add header
+        </Button>
+      </VerticalGroup>
+    </EditorField>
+  );
+});
+
+PromQueryHeadersEditor.displayName = 'PromQueryHeadersEditor';
@@ -1,5 +1,6 @@
 import {
   DataFrame,
+  DataFrameType,
   DataQueryRequest,
   DataQueryResponse,
   FieldType,
@@ -301,6 +302,38 @@ describe('Prometheus Result Transformer', () => {
       expect(series.data[0].fields[3].name).toEqual('+Inf');
     });
 
+    it('heatmap rows built by the backend should not be transformed again', () => {
+      const options = {
+        targets: [
+          {
+            format: 'heatmap',
+            refId: 'A',
+          },
+        ],
+      } as unknown as DataQueryRequest<PromQuery>;
+      const response = {
+        state: 'Done',
+        data: [
+          new MutableDataFrame({
+            refId: 'A',
+            meta: { type: DataFrameType.HeatmapRows },
+            fields: [
+              { name: 'Time', type: FieldType.time, values: [4, 5, 6] },
+              { name: '1', type: FieldType.number, values: [0, 10, 10] },
+              { name: '+Inf', type: FieldType.number, values: [40, 0, 20] },
+            ],
+          }),
+        ],
+      } as unknown as DataQueryResponse;
+
+      const series = transformV2(response, options, {});
+      expect(series.data).toHaveLength(1);
+      expect(series.data[0].meta?.type).toEqual(DataFrameType.HeatmapRows);
+      expect(series.data[0].meta?.preferredVisualisationType).toBeUndefined();
+      expect(series.data[0].fields[1].values.toArray()).toEqual([0, 10, 10]);
+      expect(series.data[0].fields[2].values.toArray()).toEqual([40, 0, 20]);
+    });
+
     it('results with heatmap format from multiple queries should be correctly transformed', () => {
       const options = {
         targets: [
@@ -61,6 +61,10 @@ const isTableResult = (dataFrame: DataFrame, options: DataQueryRequest<PromQuery
 };
 
 const isHeatmapResult = (dataFrame: DataFrame, options: DataQueryRequest<PromQuery>): boolean => {
+  // the heatmaps built by the backend are already heatmap rows
+  if (dataFrame.meta?.type === DataFrameType.HeatmapRows) {
+    return false;
+  }
   const target = options.targets.find((target) => target.refId === dataFrame.refId);
   return target?.format === 'heatmap';
 };
@@ -156,6 +160,9 @@ export function transformV2(
 
   // Everything else is processed as time_series result and graph preferredVisualisationType
   const otherFrames = framesWithoutTableHeatmapsAndExemplars.map((dataFrame) => {
+    if (dataFrame.meta?.type === DataFrameType.HeatmapRows) {
+      return dataFrame;
+    }
     const df: DataFrame = {
       ...dataFrame,
       meta: {
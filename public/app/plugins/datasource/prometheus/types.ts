@@ -32,6 +32,8 @@ export interface PromOptions extends DataSourceJsonData {
   prometheusType?: PromApplication;
   prometheusVersion?: string;
   defaultEditor?: QueryEditorMode;
+  federatedUrls?: string[];
+  heatmapServerSide?: boolean;
 }
 
 export type ExemplarTraceIdDestination = {
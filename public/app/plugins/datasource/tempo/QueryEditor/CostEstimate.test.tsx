@@ -0,0 +1,42 @@
+import { render, screen, waitFor } from '@testing-library/react';
+import React from 'react';
+
+import { dateTime, TimeRange } from '@grafana/data';
+
+import { TempoDatasource } from '../datasource';
+import { TempoCostEstimate } from '../types';
+
+import { CostEstimate } from './CostEstimate';
+
+function getRange(): TimeRange {
+  const to = dateTime(1680000000000);
+  const from = dateTime(to.valueOf() - 60 * 60 * 1000);
+  return { from, to, raw: { from, to } };
+}
+
+function getDatasource(estimate?: TempoCostEstimate) {
+  return { estimateCost: jest.fn().mockResolvedValue(estimate) } as unknown as TempoDatasource;
+}
+
+describe('CostEstimate', () => {
+  it('shows the estimated cost of the time range', async () => {
+    const datasource = getDatasource({ bytes: 3 * 1024 * 1024, blocks: 4, overBudget: false });
+    render(<CostEstimate datasource={datasource} range={getRange()} />);
+    expect(await screen.findByText('Searches of this time range read up to 3 MiB from 4 blocks.')).toBeInTheDocument();
+    expect(datasource.estimateCost).toHaveBeenCalledWith(getRange());
+  });
+
+  it('warns when the estimated cost exceeds the maximum of the data source', async () => {
+    const datasource = getDatasource({ bytes: 3 * 1024 * 1024, blocks: 4, maxBytes: 1024 * 1024, overBudget: true });
+    render(<CostEstimate datasource={datasource} range={getRange()} />);
+    expect(await screen.findByText('Time range exceeds the cost limit')).toBeInTheDocument();
+    expect(screen.getByText(/more than the maximum of 1 MiB of the data source/)).toBeInTheDocument();
+  });
+
+  it('shows nothing without estimate', async () => {
+    const datasource = getDatasource(undefined);
+    const { container } = render(<CostEstimate datasource={datasource} range={getRange()} />);
+    await waitFor(() => expect(datasource.estimateCost).toHaveBeenCalled());
+    expect(container).toBeEmptyDOMElement();
+  });
+});
@@ -0,0 +1,46 @@
+import React from 'react';
+import useAsync from 'react-use/lib/useAsync';
+
+import { formattedValueToString, getValueFormat, TimeRange } from '@grafana/data';
+import { Alert } from '@grafana/ui';
+
+import { TempoDatasource } from '../datasource';
+
+interface Props {
+  datasource: TempoDatasource;
+  range?: TimeRange;
+}
+
+const formatBytes = (bytes: number) => formattedValueToString(getValueFormat('bytes')(bytes));
+
+// CostEstimate shows the most the searches of the time range read from the blocks of Tempo, and warns about the
+// searches rejected because they would read more than the maximum of the data source
+export function CostEstimate({ datasource, range }: Props) {
+  const from = range?.from.valueOf();
+  const to = range?.to.valueOf();
+  const { value: estimate } = useAsync(
+    async () => (range ? datasource.estimateCost(range) : undefined),
+    // the range object changes on each render of the editor, the estimate only depends on its bounds
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+    [datasource, from, to]
+  );
+
+  if (!estimate || estimate.blocks === 0) {
+    return null;
+  }
+
+  if (estimate.overBudget && estimate.maxBytes) {
+    return (
+      <Alert title="Time range exceeds the cost limit" severity="warning">
+        Searches of this time range read up to {formatBytes(estimate.bytes)}, more than the maximum of{' '}
+        {formatBytes(estimate.maxBytes)} of the data source. Select a shorter time range to run the search.
+      </Alert>
+    );
+  }
+
+  return (
+    <div>
+      Searches of this time range read up to {formatBytes(estimate.bytes)} from {estimate.blocks} blocks.
+    </div>
+  );
+}
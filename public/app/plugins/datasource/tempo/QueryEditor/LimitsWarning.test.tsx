@@ -0,0 +1,43 @@
+import { render, screen, waitFor } from '@testing-library/react';
+import React from 'react';
+
+import { dateTime, TimeRange } from '@grafana/data';
+
+import { TempoDatasource } from '../datasource';
+import { TempoLimits } from '../types';
+
+import { LimitsWarning } from './LimitsWarning';
+
+const hour = 60 * 60 * 1000;
+
+function getRange(hours: number): TimeRange {
+  const to = dateTime(1680000000000);
+  const from = dateTime(to.valueOf() - hours * hour);
+  return { from, to, raw: { from, to } };
+}
+
+function getDatasource(limits: TempoLimits) {
+  return { getLimits: jest.fn().mockResolvedValue(limits) } as unknown as TempoDatasource;
+}
+
+describe('LimitsWarning', () => {
+  it('warns when the time range exceeds the max search duration', async () => {
+    render(<LimitsWarning datasource={getDatasource({ maxSearchDurationMs: 24 * hour })} range={getRange(48)} />);
+    expect(await screen.findByText('Time range exceeds the search limit')).toBeInTheDocument();
+    expect(screen.getByText(/Tempo only searches 1d of traces/)).toBeInTheDocument();
+  });
+
+  it('does not warn when the time range is within the max search duration', async () => {
+    const datasource = getDatasource({ maxSearchDurationMs: 24 * hour });
+    render(<LimitsWarning datasource={datasource} range={getRange(12)} />);
+    await waitFor(() => expect(datasource.getLimits).toHaveBeenCalled());
+    expect(screen.queryByText('Time range exceeds the search limit')).not.toBeInTheDocument();
+  });
+
+  it('does not warn without limits', async () => {
+    const datasource = getDatasource({});
+    render(<LimitsWarning datasource={datasource} range={getRange(48)} />);
+    await waitFor(() => expect(datasource.getLimits).toHaveBeenCalled());
+    expect(screen.queryByText('Time range exceeds the search limit')).not.toBeInTheDocument();
+  });
+});
@@ -0,0 +1,29 @@
+import React from 'react';
+import useAsync from 'react-use/lib/useAsync';
+
+import { rangeUtil, TimeRange } from '@grafana/data';
+import { Alert } from '@grafana/ui';
+
+import { TempoDatasource } from '../datasource';
+
+interface Props {
+  datasource: TempoDatasource;
+  range?: TimeRange;
+}
+
+// LimitsWarning warns about the searches Tempo rejects because of the limits of the tenant
+export function LimitsWarning({ datasource, range }: Props) {
+  const { value: limits } = useAsync(() => datasource.getLimits(), [datasource]);
+
+  const maxSearchDurationMs = limits?.maxSearchDurationMs;
+  if (!range || !maxSearchDurationMs || range.to.valueOf() - range.from.valueOf() <= maxSearchDurationMs) {
+    return null;
+  }
+
+  return (
+    <Alert title="Time range exceeds the search limit" severity="warning">
+      Tempo only searches {rangeUtil.secondsToHms(maxSearchDurationMs / 1000)} of traces, select a shorter time range
+      to run the search.
+    </Alert>
+  );
+}
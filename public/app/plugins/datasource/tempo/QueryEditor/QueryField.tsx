@@ -23,6 +23,8 @@ import { TempoDatasource } from '../datasource';
 import { QueryEditor } from '../traceql/QueryEditor';
 import { TempoQuery } from '../types';
 
+import { CostEstimate } from './CostEstimate';
+import { LimitsWarning } from './LimitsWarning';
 import NativeSearch from './NativeSearch';
 import { ServiceGraphSection } from './ServiceGraphSection';
 import { getDS } from './utils';
@@ -72,7 +74,7 @@ class TempoQueryFieldComponent extends React.PureComponent<Props> {
   };
 
   render() {
-    const { query, onChange, datasource, app } = this.props;
+    const { query, onChange, datasource, app, range } = this.props;
 
     const logsDatasourceUid = datasource.getLokiSearchDS();
 
@@ -129,6 +131,12 @@ class TempoQueryFieldComponent extends React.PureComponent<Props> {
             />
           </InlineField>
         </InlineFieldRow>
+        {(query.queryType === 'nativeSearch' || query.queryType === 'traceqlSearch' || query.queryType === 'traceql') && (
+          <LimitsWarning datasource={datasource} range={range} />
+        )}
+        {(query.queryType === 'traceqlSearch' || query.queryType === 'traceql') && (
+          <CostEstimate datasource={datasource} range={range} />
+        )}
         {query.queryType === 'search' && (
           <SearchSection
             logsDatasourceUid={logsDatasourceUid}
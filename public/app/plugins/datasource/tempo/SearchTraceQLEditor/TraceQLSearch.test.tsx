@@ -36,6 +36,9 @@ jest.mock('../language_provider', () => {
   });
 });
 
+const validateFilters = jest.fn().mockResolvedValue([]);
+const datasource = { validateFilters } as unknown as TempoDatasource;
+
 describe('TraceQLSearch', () => {
   let user: ReturnType<typeof userEvent.setup>;
 
@@ -63,7 +66,7 @@ describe('TraceQLSearch', () => {
 
   it('should update operator when new value is selected in operator input', async () => {
     const { container } = render(
-      <TraceQLSearch datasource={{} as TempoDatasource} query={query} onChange={onChange} />
+      <TraceQLSearch datasource={datasource} query={query} onChange={onChange} />
     );
 
     const minDurationOperator = container.querySelector(`input[aria-label="select min-duration operator"]`);
@@ -84,7 +87,7 @@ describe('TraceQLSearch', () => {
 
   it('should add new filter when new value is selected in the service name section', async () => {
     const { container } = render(
-      <TraceQLSearch datasource={{} as TempoDatasource} query={query} onChange={onChange} />
+      <TraceQLSearch datasource={datasource} query={query} onChange={onChange} />
     );
     const serviceNameValue = container.querySelector(`input[aria-label="select service-name value"]`);
     expect(serviceNameValue).not.toBeNull();
@@ -106,7 +109,7 @@ describe('TraceQLSearch', () => {
   });
 
   it('should add new filter when new filter button is clicked and remove filter when remove button is clicked', async () => {
-    render(<TraceQLSearch datasource={{} as TempoDatasource} query={query} onChange={onChange} />);
+    render(<TraceQLSearch datasource={datasource} query={query} onChange={onChange} />);
 
     const dynamicFilters = query.filters.filter((f) => f.type === 'dynamic');
     expect(dynamicFilters.length).toBe(1);
@@ -115,7 +118,7 @@ describe('TraceQLSearch', () => {
     jest.advanceTimersByTime(1000);
 
     // We have to rerender here so it picks up the new dynamic field
-    render(<TraceQLSearch datasource={{} as TempoDatasource} query={query} onChange={onChange} />);
+    render(<TraceQLSearch datasource={datasource} query={query} onChange={onChange} />);
 
     const newDynamicFilters = query.filters.filter((f) => f.type === 'dynamic');
     expect(newDynamicFilters.length).toBe(2);
@@ -128,4 +131,15 @@ describe('TraceQLSearch', () => {
       expect(query.filters.filter((f) => f.type === 'dynamic')).toStrictEqual(dynamicFilters);
     }
   });
+
+  it('should show the errors of the filters', async () => {
+    validateFilters.mockResolvedValueOnce([
+      { filterId: 'min-duration', field: 'value', message: 'fast is not a duration, for example 100ms or 1.2s' },
+    ]);
+    render(<TraceQLSearch datasource={datasource} query={query} onChange={onChange} />);
+
+    expect(await screen.findByText('Invalid filters')).toBeInTheDocument();
+    expect(screen.getByText('min-duration: fast is not a duration, for example 100ms or 1.2s')).toBeInTheDocument();
+    expect(validateFilters).toHaveBeenCalledWith(query.filters);
+  });
 });
@@ -15,7 +15,7 @@ import { TempoDatasource } from '../datasource';
 import { TempoQueryBuilderOptions } from '../traceql/TempoQueryBuilderOptions';
 import { CompletionProvider } from '../traceql/autocomplete';
 import { traceqlGrammar } from '../traceql/traceql';
-import { TempoQuery } from '../types';
+import { TempoFilterError, TempoQuery } from '../types';
 
 import DurationInput from './DurationInput';
 import InlineSearchField from './InlineSearchField';
@@ -37,6 +37,7 @@ const TraceQLSearch = ({ datasource, query, onChange }: Props) => {
   const [tags, setTags] = useState<string[]>([]);
   const [isTagsLoading, setIsTagsLoading] = useState(true);
   const [traceQlQuery, setTraceQlQuery] = useState<string>('');
+  const [filterErrors, setFilterErrors] = useState<TempoFilterError[]>([]);
 
   const updateFilter = (s: TraceqlFilter) => {
     const copy = { ...query };
@@ -59,6 +60,18 @@ const TraceQLSearch = ({ datasource, query, onChange }: Props) => {
     setTraceQlQuery(generateQueryFromFilters(query.filters || []));
   }, [query]);
 
+  useEffect(() => {
+    let cancelled = false;
+    datasource.validateFilters(query.filters || []).then((errors) => {
+      if (!cancelled) {
+        setFilterErrors(errors);
+      }
+    });
+    return () => {
+      cancelled = true;
+    };
+  }, [datasource, query.filters]);
+
   const findFilter = (id: string) => query.filters?.find((f) => f.id === id);
 
   useEffect(() => {
@@ -164,6 +177,13 @@ const TraceQLSearch = ({ datasource, query, onChange }: Props) => {
         </EditorRow>
         <TempoQueryBuilderOptions onChange={onChange} query={query} />
       </div>
+      {filterErrors.length > 0 ? (
+        <Alert title="Invalid filters" severity="warning" className={styles.alert}>
+          {filterErrors.map((e) => (
+            <div key={`${e.filterId}-${e.field}`}>{`${e.filterId}: ${e.message}`}</div>
+          ))}
+        </Alert>
+      ) : null}
       {error ? (
         <Alert title="Unable to connect to Tempo search" severity="info" className={styles.alert}>
           Please ensure that Tempo is configured with search enabled. If you would like to hide this tab, you can
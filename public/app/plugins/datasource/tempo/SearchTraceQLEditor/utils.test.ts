@@ -1,3 +1,5 @@
+import { TraceqlSearchScope } from '../dataquery.gen';
+
 import { generateQueryFromFilters } from './utils';
 
 describe('generateQueryFromFilters generates the correct query for', () => {
@@ -54,4 +56,47 @@ describe('generateQueryFromFilters generates the correct query for', () => {
       ])
     ).toBe('{}');
   });
+  it('fields with scopes', () => {
+    expect(
+      generateQueryFromFilters([
+        {
+          id: 'foo',
+          type: 'static',
+          tag: 'duration',
+          value: '100ms',
+          operator: '>',
+          valueType: 'duration',
+          scope: TraceqlSearchScope.Intrinsic,
+        },
+        {
+          id: 'bar',
+          type: 'dynamic',
+          tag: 'bartag',
+          value: 'barvalue',
+          operator: '=',
+          scope: TraceqlSearchScope.Unscoped,
+        },
+        {
+          id: 'baz',
+          type: 'dynamic',
+          tag: 'service.name',
+          value: 'db',
+          operator: '=',
+          scope: TraceqlSearchScope.Resource,
+        },
+        {
+          id: 'qux',
+          type: 'dynamic',
+          tag: 'exception.type',
+          value: 'IO',
+          operator: '=',
+          scope: TraceqlSearchScope.Event,
+        },
+        { id: 'quux', type: 'dynamic', tag: 'link.foo', value: 'x', operator: '=', scope: TraceqlSearchScope.Link },
+      ])
+    ).toBe(
+      '{duration>100ms && .bartag="barvalue" && resource.service.name="db" && ' +
+        'event.exception.type="IO" && link.foo="x"}'
+    );
+  });
 });
@@ -1,14 +1,25 @@
 import { SelectableValue } from '@grafana/data';
 
-import { TraceqlFilter } from '../dataquery.gen';
+import { TraceqlFilter, TraceqlSearchScope } from '../dataquery.gen';
 
 export const generateQueryFromFilters = (filters: TraceqlFilter[]) => {
   return `{${filters
     .filter((f) => f.tag && f.operator && f.value?.length)
-    .map((f) => `${f.tag}${f.operator}${valueHelper(f)}`)
+    .map((f) => `${scopeHelper(f)}${f.operator}${valueHelper(f)}`)
     .join(' && ')}}`;
 };
 
+const scopeHelper = (f: TraceqlFilter) => {
+  if (!f.scope || f.scope === TraceqlSearchScope.Intrinsic) {
+    return f.tag;
+  }
+  const prefix = f.scope === TraceqlSearchScope.Unscoped ? '.' : `${f.scope}.`;
+  if (f.tag!.startsWith(prefix)) {
+    return f.tag;
+  }
+  return `${prefix}${f.tag!.replace(/^\./, '')}`;
+};
+
 const valueHelper = (f: TraceqlFilter) => {
   if (Array.isArray(f.value) && f.value.length > 1) {
     return `"${f.value.join('|')}"`;
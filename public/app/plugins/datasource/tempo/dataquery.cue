@@ -47,14 +47,22 @@ composableKinds: DataQuery: {
 							serviceMapQuery?: string
 							// Defines the maximum number of traces that are returned from Tempo
 							limit?: int64
+							// Attribute whose values are aggregated by the attributeStatistics query, for example: span.http.route, resource.service.name, name
+							attribute?: string
+							// Defines the maximum number of attribute values returned by the attributeStatistics query
+							attributeLimit?: int64
+							// Offset of the previous window the serviceGraph query is compared with, for example: 1h, 1d, 1w
+							compareWith?: string
 							filters: [...#TraceqlFilter]
 						} @cuetsy(kind="interface") @grafana(TSVeneer="type")
 
-						// search = Loki search, nativeSearch = Tempo search for backwards compatibility
-						#TempoQueryType: "traceql" | "traceqlSearch" | "search" | "serviceMap" | "upload" | "nativeSearch" | "clear" @cuetsy(kind="type")
+						// search = Loki search, nativeSearch = Tempo search for backwards compatibility, errorSummary = aggregated summary of error spans, attributeStatistics = distribution of the values of a span attribute, serviceGraph = node graph of the services and normalized span names, traceBreakdown = self time of the services and operations of a trace, flameGraph = call tree of the operations of the traces matching a TraceQL query
+						#TempoQueryType: "traceql" | "traceqlSearch" | "search" | "serviceMap" | "upload" | "nativeSearch" | "clear" | "errorSummary" | "attributeStatistics" | "serviceGraph" | "traceBreakdown" | "flameGraph" @cuetsy(kind="type")
 
 						// static fields are pre-set in the UI, dynamic fields are added by the user
 						#TraceqlSearchFilterType: "static" | "dynamic" @cuetsy(kind="type")
+						// The scope of a tag: intrinsics (for example name, status, duration) are used as is, unscoped tags match both span and resource attributes
+						#TraceqlSearchScope: "intrinsic" | "unscoped" | "resource" | "span" | "event" | "link" @cuetsy(kind="enum")
 						#TraceqlFilter: {
 							// Uniquely identify the filter, will not be used in the query generation
 							id: string
@@ -62,6 +70,8 @@ composableKinds: DataQuery: {
 							type: #TraceqlSearchFilterType
 							// The tag for the search filter, for example: .http.status_code, .service.name, status
 							tag?: string
+							// The scope of the tag, used as prefix of the tag when generating the query. Tags without scope are used as is
+							scope?: #TraceqlSearchScope
 							// The operator that connects the tag to the value, for example: =, >, !=, =~
 							operator?: string
 							// The value for the search filter
@@ -13,6 +13,18 @@ import * as common from '@grafana/schema';
 export const DataQueryModelVersion = Object.freeze([0, 0]);
 
 export interface TempoQuery extends common.DataQuery {
+  /**
+   * Attribute whose values are aggregated by the attributeStatistics query, for example: span.http.route, resource.service.name, name
+   */
+  attribute?: string;
+  /**
+   * Defines the maximum number of attribute values returned by the attributeStatistics query
+   */
+  attributeLimit?: number;
+  /**
+   * Offset of the previous window the serviceGraph query is compared with, for example: 1h, 1d, 1w
+   */
+  compareWith?: string;
   filters: Array<TraceqlFilter>;
   /**
    * Defines the maximum number of traces that are returned from Tempo
@@ -53,15 +65,27 @@ export const defaultTempoQuery: Partial<TempoQuery> = {
 };
 
 /**
- * search = Loki search, nativeSearch = Tempo search for backwards compatibility
+ * search = Loki search, nativeSearch = Tempo search for backwards compatibility, errorSummary = aggregated summary of error spans, attributeStatistics = distribution of the values of a span attribute, serviceGraph = node graph of the services and normalized span names, traceBreakdown = self time of the services and operations of a trace, flameGraph = call tree of the operations of the traces matching a TraceQL query
  */
-export type TempoQueryType = ('traceql' | 'traceqlSearch' | 'search' | 'serviceMap' | 'upload' | 'nativeSearch' | 'clear');
+export type TempoQueryType = ('traceql' | 'traceqlSearch' | 'search' | 'serviceMap' | 'upload' | 'nativeSearch' | 'clear' | 'errorSummary' | 'attributeStatistics' | 'serviceGraph' | 'traceBreakdown' | 'flameGraph');
 
 /**
  * static fields are pre-set in the UI, dynamic fields are added by the user
  */
 export type TraceqlSearchFilterType = ('static' | 'dynamic');
 
+/**
+ * The scope of a tag: intrinsics (for example name, status, duration) are used as is, unscoped tags match both span and resource attributes
+ */
+export enum TraceqlSearchScope {
+  Event = 'event',
+  Intrinsic = 'intrinsic',
+  Link = 'link',
+  Resource = 'resource',
+  Span = 'span',
+  Unscoped = 'unscoped',
+}
+
 export interface TraceqlFilter {
   /**
    * Uniquely identify the filter, will not be used in the query generation
@@ -71,6 +95,10 @@ export interface TraceqlFilter {
    * The operator that connects the tag to the value, for example: =, >, !=, =~
    */
   operator?: string;
+  /**
+   * The scope of the tag, used as prefix of the tag when generating the query. Tags without scope are used as is
+   */
+  scope?: TraceqlSearchScope;
   /**
    * The tag for the search filter, for example: .http.status_code, .service.name, status
    */
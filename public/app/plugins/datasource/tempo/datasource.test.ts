@@ -16,6 +16,7 @@ import {
 import {
   BackendDataSourceResponse,
   FetchResponse,
+  HealthStatus,
   setBackendSrv,
   setDataSourceSrv,
   TemplateSrv,
@@ -360,11 +361,37 @@ describe('Tempo data source', () => {
   });
 
   describe('test the testDatasource function', () => {
-    it('should return a success msg if response.ok is true', async () => {
-      mockObservable = () => of({ ok: true });
+    it('should return a success msg if the health check of the backend succeeds', async () => {
       const ds = new TempoDatasource(defaultSettings);
+      jest
+        .spyOn(ds, 'callHealthCheck')
+        .mockResolvedValue({ status: HealthStatus.OK, message: 'Data source is working' });
       const response = await ds.testDatasource();
-      expect(response.status).toBe('success');
+      expect(response).toEqual({ status: 'success', message: 'Data source is working' });
+    });
+  });
+
+  describe('test the fetchTags function', () => {
+    it('should use the tags v2 API when the data source supports it', async () => {
+      mockObservable = () =>
+        of({
+          data: {
+            scopes: [
+              { name: 'resource', tags: ['service.name'] },
+              { name: 'span', tags: ['http.status_code', 'service.name'] },
+            ],
+          },
+        });
+      const ds = new TempoDatasource({ ...defaultSettings, capabilities: { tagsV2: true } });
+      await ds.languageProvider.fetchTags();
+      expect(ds.languageProvider.getTags()).toEqual(['service.name', 'http.status_code']);
+    });
+
+    it('should use the tags API otherwise', async () => {
+      mockObservable = () => of({ data: { tagNames: ['service.name'] } });
+      const ds = new TempoDatasource(defaultSettings);
+      await ds.languageProvider.fetchTags();
+      expect(ds.languageProvider.getTags()).toEqual(['service.name']);
     });
   });
 
@@ -14,6 +14,7 @@ import {
   LoadingState,
   rangeUtil,
   ScopedVars,
+  TimeRange,
 } from '@grafana/data';
 import {
   config,
@@ -35,6 +36,7 @@ import { PrometheusDatasource } from '../prometheus/datasource';
 import { PromQuery } from '../prometheus/types';
 
 import { generateQueryFromFilters } from './SearchTraceQLEditor/utils';
+import { TraceqlFilter } from './dataquery.gen';
 import {
   failedMetric,
   histogramMetric,
@@ -54,7 +56,14 @@ import {
   createTableFrameFromSearch,
   createTableFrameFromTraceQlQuery,
 } from './resultTransformer';
-import { SearchQueryParams, TempoQuery, TempoJsonData } from './types';
+import {
+  SearchQueryParams,
+  TempoQuery,
+  TempoJsonData,
+  TempoLimits,
+  TempoFilterError,
+  TempoCostEstimate,
+} from './types';
 
 export const DEFAULT_LIMIT = 20;
 
@@ -77,7 +86,9 @@ export class TempoDatasource extends DataSourceWithBackend<TempoQuery, TempoJson
   };
   uploadedJson?: string | ArrayBuffer | null = null;
   spanBar?: SpanBarOptions;
+  capabilities?: Record<string, boolean>;
   languageProvider: TempoLanguageProvider;
+  private limits?: Promise<TempoLimits>;
 
   constructor(
     private instanceSettings: DataSourceInstanceSettings<TempoJsonData>,
@@ -90,6 +101,7 @@ export class TempoDatasource extends DataSourceWithBackend<TempoQuery, TempoJson
     this.nodeGraph = instanceSettings.jsonData.nodeGraph;
     this.lokiSearch = instanceSettings.jsonData.lokiSearch;
     this.traceQuery = instanceSettings.jsonData.traceQuery;
+    this.capabilities = instanceSettings.capabilities;
     this.languageProvider = new TempoLanguageProvider(this);
   }
 
@@ -301,6 +313,26 @@ export class TempoDatasource extends DataSourceWithBackend<TempoQuery, TempoJson
       );
     }
 
+    if (targets.errorSummary?.length) {
+      subQueries.push(super.query({ ...options, targets: targets.errorSummary }));
+    }
+
+    if (targets.attributeStatistics?.length) {
+      subQueries.push(super.query({ ...options, targets: targets.attributeStatistics }));
+    }
+
+    if (targets.serviceGraph?.length) {
+      subQueries.push(super.query({ ...options, targets: targets.serviceGraph }));
+    }
+
+    if (targets.traceBreakdown?.length) {
+      subQueries.push(super.query({ ...options, targets: targets.traceBreakdown }));
+    }
+
+    if (targets.flameGraph?.length) {
+      subQueries.push(super.query({ ...options, targets: targets.flameGraph }));
+    }
+
     return merge(...subQueries);
   }
 
@@ -401,17 +433,27 @@ export class TempoDatasource extends DataSourceWithBackend<TempoQuery, TempoJson
     return getBackendSrv().fetch(req);
   }
 
-  async testDatasource(): Promise<any> {
-    const options: BackendSrvRequest = {
-      headers: {},
-      method: 'GET',
-      url: `${this.instanceSettings.url}/api/echo`,
-    };
-    const response = await lastValueFrom(getBackendSrv().fetch(options));
-
-    if (response?.ok) {
-      return { status: 'success', message: 'Data source is working' };
+  // The limits rarely change, they are fetched once per datasource instance. Failing to fetch them isn't an error,
+  // the queries are then run without a warning.
+  getLimits(): Promise<TempoLimits> {
+    if (!this.limits) {
+      this.limits = this.getResource('overrides').catch(() => ({}));
     }
+    return this.limits;
+  }
+
+  // The filters are validated against the types of their tags before running the query. Failing to validate them
+  // isn't an error, Tempo then rejects the invalid query.
+  validateFilters(filters: TraceqlFilter[]): Promise<TempoFilterError[]> {
+    return this.postResource('validate-filters', { filters })
+      .then((res) => res.errors ?? [])
+      .catch(() => []);
+  }
+
+  // The cost of the searches of the time range is estimated from the size of the blocks of Tempo. Failing to estimate
+  // it isn't an error, the estimate isn't shown.
+  estimateCost(range: TimeRange): Promise<TempoCostEstimate | undefined> {
+    return this.postResource('estimate-cost', { start: range.from.unix(), end: range.to.unix() }).catch(() => undefined);
   }
 
   getQueryDisplayText(query: TempoQuery) {
@@ -31,6 +31,12 @@ export default class TempoLanguageProvider extends LanguageProvider {
   };
 
   async fetchTags() {
+    if (this.datasource.capabilities?.tagsV2) {
+      const response = await this.request('/api/v2/search/tags', []);
+      const tags = (response.scopes ?? []).flatMap((scope: { tags?: string[] }) => scope.tags ?? []);
+      this.tags = Array.from(new Set<string>(tags));
+      return;
+    }
     const response = await this.request('/api/search/tags', []);
     this.tags = response.tagNames;
   }
@@ -16,6 +16,7 @@ import {
   transformFromOTLP,
   createTableFrameFromSearch,
   createTableFrameFromTraceQlQuery,
+  transformTrace,
 } from './resultTransformer';
 import {
   badOTLPResponse,
@@ -45,6 +46,37 @@ const defaultSettings: DataSourceInstanceSettings = {
   jsonData: {},
 };
 
+describe('transformTrace()', () => {
+  test('decodes the dictionary encoded service fields', () => {
+    const frame = new MutableDataFrame({
+      fields: [
+        { name: 'spanID', type: FieldType.string, values: ['1', '2'] },
+        {
+          name: 'serviceName',
+          type: FieldType.enum,
+          config: { type: { enum: { text: ['db', 'api'] } } },
+          values: [1, 0],
+        },
+        {
+          name: 'serviceTags',
+          type: FieldType.enum,
+          config: { type: { enum: { text: ['[{"key":"pod","value":"db-0"}]', '[{"key":"pod","value":"api-0"}]'] } } },
+          values: [1, 0],
+        },
+      ],
+    });
+
+    const decoded = transformTrace({ data: [frame] }).data[0];
+    expect(decoded.fields[1].type).toBe(FieldType.string);
+    expect(decoded.fields[1].values.toArray()).toEqual(['api', 'db']);
+    expect(decoded.fields[2].type).toBe(FieldType.other);
+    expect(decoded.fields[2].values.toArray()).toEqual([
+      [{ key: 'pod', value: 'api-0' }],
+      [{ key: 'pod', value: 'db-0' }],
+    ]);
+  });
+});
+
 describe('transformTraceList()', () => {
   const lokiDataFrame = new MutableDataFrame({
     fields: [
@@ -3,6 +3,7 @@ import { collectorTypes } from '@opentelemetry/exporter-collector';
 import { SemanticResourceAttributes } from '@opentelemetry/semantic-conventions';
 
 import {
+  ArrayVector,
   DataFrame,
   DataQueryResponse,
   DataSourceInstanceSettings,
@@ -531,14 +532,31 @@ function getOTLPReferences(
   return links;
 }
 
-export function transformTrace(response: DataQueryResponse, nodeGraph = false): DataQueryResponse {
-  const frame: DataFrame = response.data[0];
+// The backend sends the service of the spans as enum fields holding the index of their value in the config of the field
+function decodeTraceFrame(frame: DataFrame): DataFrame {
+  return {
+    ...frame,
+    fields: frame.fields.map((field) => {
+      const text = field.config.type?.enum?.text;
+      if (field.type !== FieldType.enum || !text) {
+        return field;
+      }
+      const values = field.values.toArray().map((index: number) => text[index]);
+      if (field.name === 'serviceTags') {
+        return { ...field, type: FieldType.other, values: new ArrayVector(values.map((v) => JSON.parse(v))) };
+      }
+      return { ...field, type: FieldType.string, values: new ArrayVector(values) };
+    }),
+  };
+}
 
-  if (!frame) {
+export function transformTrace(response: DataQueryResponse, nodeGraph = false): DataQueryResponse {
+  if (!response.data[0]) {
     return emptyDataQueryResponse;
   }
+  const frame: DataFrame = decodeTraceFrame(response.data[0]);
 
-  let data = [...response.data];
+  let data = [frame, ...response.data.slice(1)];
   if (nodeGraph) {
     data.push(...createGraphFrames(frame));
   }
@@ -4,7 +4,7 @@ import { TraceToLogsOptions } from 'app/core/components/TraceToLogs/TraceToLogsS
 
 import { LokiQuery } from '../loki/types';
 
-import { TempoQuery as TempoBase, TempoQueryType } from './dataquery.gen';
+import { TempoQuery as TempoBase, TempoQueryType, TraceqlFilter } from './dataquery.gen';
 
 export interface SearchQueryParams {
   minDuration?: string;
@@ -20,8 +20,16 @@ export interface TempoJsonData extends DataSourceJsonData {
   serviceMap?: {
     datasourceUid?: string;
   };
+  serviceGraph?: {
+    spanNameRules?: Array<{ pattern: string; replacement: string }>;
+    maxSpanNamesPerService?: number;
+  };
   search?: {
     hide?: boolean;
+    partitionDuration?: string;
+    maxConcurrentPartitions?: number;
+    maxBytesToScan?: number;
+    dedicatedColumns?: Array<{ scope: 'span' | 'resource'; name: string }>;
   };
   nodeGraph?: NodeGraphOptions;
   lokiSearch?: {
@@ -34,6 +42,11 @@ export interface TempoJsonData extends DataSourceJsonData {
     timeShiftEnabled?: boolean;
     spanStartTimeShift?: string;
     spanEndTimeShift?: string;
+    timeShards?: string[];
+  };
+  redactionRules?: Array<{ pattern: string; action?: 'mask' | 'drop' }>;
+  otlpPush?: {
+    url?: string;
   };
 }
 
@@ -44,6 +57,27 @@ export interface TempoQuery extends TempoBase {
   queryType: TempoQueryType;
 }
 
+// The limits of the tenant returned by the overrides resource, the missing limits aren't enforced by Tempo
+export interface TempoLimits {
+  maxSearchDurationMs?: number;
+  maxBytesPerTagValues?: number;
+}
+
+// An error of the operator or the value of a filter returned by the validate-filters resource
+export interface TempoFilterError {
+  filterId: string;
+  field: 'operator' | 'value';
+  message: string;
+}
+
+// The most a search of a time range reads from the blocks of Tempo, returned by the estimate-cost resource
+export interface TempoCostEstimate {
+  bytes: number;
+  blocks: number;
+  maxBytes?: number;
+  overBudget: boolean;
+}
+
 export interface MyDataSourceOptions extends DataSourceJsonData {}
 
 export const defaultQuery: Partial<TempoQuery> = {};
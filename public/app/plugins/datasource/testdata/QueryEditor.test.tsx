@@ -91,6 +91,23 @@ describe('Test Datasource Query Editor', () => {
     expect(screen.getByLabelText('Bands')).toHaveValue(1);
   });
 
+  it('should display the random walk and chaos options of the chaos scenario', async () => {
+    setup({
+      query: {
+        ...defaultQuery,
+        scenarioId: TestDataQueryType.Chaos,
+        chaos: { latencyDistribution: 'uniform', latencyMs: 100, errorProbability: 0.5 },
+      },
+    });
+
+    expect(await screen.findByText('Chaos')).toBeInTheDocument();
+    expect(screen.getByRole('textbox', { name: 'Labels' })).toBeInTheDocument();
+    expect(screen.getByLabelText('Series count')).toBeInTheDocument();
+    expect(screen.getByText('Uniform')).toBeInTheDocument();
+    expect(screen.getByLabelText('Latency')).toHaveValue(100);
+    expect(screen.getByLabelText('Error probability')).toHaveValue(0.5);
+  });
+
   it('persists the datasource from the query when switching scenario', async () => {
     const mockDatasource = {
       type: 'test',
@@ -9,6 +9,7 @@ import { RandomWalkEditor, StreamingClientEditor } from './components';
 import { CSVContentEditor } from './components/CSVContentEditor';
 import { CSVFileEditor } from './components/CSVFileEditor';
 import { CSVWavesEditor } from './components/CSVWaveEditor';
+import { ChaosEditor } from './components/ChaosEditor';
 import ErrorEditor from './components/ErrorEditor';
 import { GrafanaLiveEditor } from './components/GrafanaLiveEditor';
 import { NodeGraphEditor } from './components/NodeGraphEditor';
@@ -17,11 +18,11 @@ import { RawFrameEditor } from './components/RawFrameEditor';
 import { SimulationQueryEditor } from './components/SimulationQueryEditor';
 import { USAQueryEditor, usaQueryModes } from './components/USAQueryEditor';
 import { defaultCSVWaveQuery, defaultPulseQuery, defaultQuery } from './constants';
-import { CSVWave, NodesQuery, TestData, TestDataQueryType, USAQuery } from './dataquery.gen';
+import { CSVWave, ChaosQuery, NodesQuery, TestData, TestDataQueryType, USAQuery } from './dataquery.gen';
 import { TestDataDataSource } from './datasource';
 import { defaultStreamQuery } from './runStreams';
 
-const showLabelsFor = ['random_walk', 'predictable_pulse'];
+const showLabelsFor = ['random_walk', 'predictable_pulse', 'chaos'];
 const endpoints = [
   { value: 'datasources', label: 'Data Sources' },
   { value: 'search', label: 'Search' },
@@ -243,9 +244,16 @@ export const QueryEditor = ({ query, datasource, onChange, onRunQuery }: Props)
         )}
       </InlineFieldRow>
 
-      {scenarioId === TestDataQueryType.RandomWalk && (
+      {(scenarioId === TestDataQueryType.RandomWalk || scenarioId === TestDataQueryType.Chaos) && (
         <RandomWalkEditor onChange={onInputChange} query={query} ds={datasource} />
       )}
+      {scenarioId === TestDataQueryType.Chaos && (
+        <ChaosEditor
+          onChange={(chaos: ChaosQuery) => onUpdate({ ...query, chaos })}
+          chaos={query.chaos}
+          refId={query.refId}
+        />
+      )}
       {scenarioId === TestDataQueryType.StreamingClient && (
         <StreamingClientEditor onChange={onStreamClientChange} query={query} ds={datasource} />
       )}
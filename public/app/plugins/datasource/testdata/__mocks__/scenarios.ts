@@ -13,6 +13,12 @@ export const scenarios = [
     name: 'Load Apache Arrow Data',
     stringInput: '',
   },
+  {
+    description: 'Returns random walks with injected latency, errors and corrupted frames',
+    id: TestDataQueryType.Chaos,
+    name: 'Chaos',
+    stringInput: '',
+  },
   {
     description: '',
     id: TestDataQueryType.CSVMetricValues,
@@ -0,0 +1,110 @@
+import React, { ChangeEvent } from 'react';
+
+import { InlineField, InlineFieldRow, Input, Select } from '@grafana/ui';
+
+import { ChaosQuery } from '../dataquery.gen';
+
+const latencyDistributions = [
+  { label: 'None', value: 'none' },
+  { label: 'Constant', value: 'constant' },
+  { label: 'Uniform', value: 'uniform' },
+  { label: 'Normal', value: 'normal' },
+  { label: 'Exponential', value: 'exponential' },
+];
+
+const corruptionTypes = [
+  { label: 'Nulls', value: 'nulls', description: 'Replace about half of the values with nulls' },
+  { label: 'NaN', value: 'nan', description: 'Replace about half of the values with NaN' },
+  { label: 'Truncate', value: 'truncate', description: 'Drop the second half of the rows' },
+  { label: 'Drop field', value: 'drop_field', description: 'Drop the value field' },
+];
+
+const numberFields: Array<{
+  label: string;
+  id: keyof ChaosQuery;
+  placeholder: string;
+  tooltip: string;
+}> = [
+  {
+    label: 'Latency',
+    id: 'latencyMs',
+    placeholder: '0',
+    tooltip: 'Milliseconds. The constant latency, the minimum of the uniform distribution or the mean of the others.',
+  },
+  {
+    label: 'Latency spread',
+    id: 'latencySpreadMs',
+    placeholder: '0',
+    tooltip: 'Milliseconds. The width of the uniform distribution or the standard deviation of the normal one.',
+  },
+  {
+    label: 'Error probability',
+    id: 'errorProbability',
+    placeholder: '0',
+    tooltip: 'The probability that the query fails, between 0 and 1.',
+  },
+  {
+    label: 'Corruption probability',
+    id: 'corruptionProbability',
+    placeholder: '0',
+    tooltip: 'The probability that each frame of the query is corrupted, between 0 and 1.',
+  },
+  {
+    label: 'Seed',
+    id: 'seed',
+    placeholder: 'random',
+    tooltip: 'With a seed the query injects the same latency, errors and corruptions on every run.',
+  },
+];
+
+interface Props {
+  onChange: (chaos: ChaosQuery) => void;
+  chaos?: ChaosQuery;
+  refId: string;
+}
+
+export const ChaosEditor = ({ onChange, chaos = {}, refId }: Props) => {
+  const onInputChange = (e: ChangeEvent<HTMLInputElement>) => {
+    const { name, value } = e.target;
+
+    onChange({ ...chaos, [name]: value === '' ? undefined : Number(value) });
+  };
+
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField label="Latency distribution" labelWidth={22}>
+          <Select
+            width={32}
+            options={latencyDistributions}
+            value={chaos.latencyDistribution ?? 'none'}
+            onChange={(v) => onChange({ ...chaos, latencyDistribution: v.value as ChaosQuery['latencyDistribution'] })}
+          />
+        </InlineField>
+        <InlineField label="Corruption type" labelWidth={22}>
+          <Select
+            width={32}
+            options={corruptionTypes}
+            value={chaos.corruptionType ?? 'nulls'}
+            onChange={(v) => onChange({ ...chaos, corruptionType: v.value as ChaosQuery['corruptionType'] })}
+          />
+        </InlineField>
+      </InlineFieldRow>
+      <InlineFieldRow>
+        {numberFields.map(({ label, id, placeholder, tooltip }) => (
+          <InlineField label={label} labelWidth={22} key={id} tooltip={tooltip}>
+            <Input
+              width={32}
+              type="number"
+              name={id}
+              id={`chaos-${id}-${refId}`}
+              value={(chaos[id] as number | undefined) ?? ''}
+              placeholder={placeholder}
+              onChange={onInputChange}
+            />
+          </InlineField>
+        ))}
+      </InlineFieldRow>
+    </>
+  );
+};
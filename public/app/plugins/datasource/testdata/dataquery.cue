@@ -48,11 +48,12 @@ composableKinds: DataQuery: {
 						rawFrameContent?:       string
 						seriesCount?:           int32
 						usa?:                   #USAQuery
+						chaos?:                 #ChaosQuery
 						errorType?:             "server_panic" | "frontend_exception" | "frontend_observable"
 						spanCount?:             int32
 						points?: [...[...string | int64]]
 
-						#TestDataQueryType: "random_walk" | "slow_query" | "random_walk_with_error" | "random_walk_table" | "exponential_heatmap_bucket_data" | "linear_heatmap_bucket_data" | "no_data_points" | "datapoints_outside_range" | "csv_metric_values" | "predictable_pulse" | "predictable_csv_wave" | "streaming_client" | "simulation" | "usa" | "live" | "grafana_api" | "arrow" | "annotations" | "table_static" | "server_error_500" | "logs" | "node_graph" | "flame_graph" | "raw_frame" | "csv_file" | "csv_content" | "trace" | "manual_entry" | "variables-query" @cuetsy(kind="enum", memberNames="RandomWalk|SlowQuery|RandomWalkWithError|RandomWalkTable|ExponentialHeatmapBucketData|LinearHeatmapBucketData|NoDataPoints|DataPointsOutsideRange|CSVMetricValues|PredictablePulse|PredictableCSVWave|StreamingClient|Simulation|USA|Live|GrafanaAPI|Arrow|Annotations|TableStatic|ServerError500|Logs|NodeGraph|FlameGraph|RawFrame|CSVFile|CSVContent|Trace|ManualEntry|VariablesQuery")
+						#TestDataQueryType: "random_walk" | "slow_query" | "random_walk_with_error" | "random_walk_table" | "exponential_heatmap_bucket_data" | "linear_heatmap_bucket_data" | "no_data_points" | "datapoints_outside_range" | "csv_metric_values" | "predictable_pulse" | "predictable_csv_wave" | "streaming_client" | "simulation" | "usa" | "live" | "grafana_api" | "arrow" | "annotations" | "table_static" | "server_error_500" | "logs" | "node_graph" | "flame_graph" | "raw_frame" | "csv_file" | "csv_content" | "trace" | "manual_entry" | "variables-query" | "chaos" @cuetsy(kind="enum", memberNames="RandomWalk|SlowQuery|RandomWalkWithError|RandomWalkTable|ExponentialHeatmapBucketData|LinearHeatmapBucketData|NoDataPoints|DataPointsOutsideRange|CSVMetricValues|PredictablePulse|PredictableCSVWave|StreamingClient|Simulation|USA|Live|GrafanaAPI|Arrow|Annotations|TableStatic|ServerError500|Logs|NodeGraph|FlameGraph|RawFrame|CSVFile|CSVContent|Trace|ManualEntry|VariablesQuery|Chaos")
 
 						#StreamingQuery: {
 							type:   "signal" | "logs" | "fetch"
@@ -101,6 +102,23 @@ composableKinds: DataQuery: {
 							labels?:    string
 						} @cuetsy(kind="interface")
 
+						#ChaosQuery: {
+							// The distribution of the latency injected before the response
+							latencyDistribution?: "none" | "constant" | "uniform" | "normal" | "exponential"
+							// The latency in milliseconds, the minimum of the uniform distribution and the mean of the others
+							latencyMs?: int64
+							// The spread of the latency in milliseconds, the width of the uniform distribution and the standard deviation of the normal one
+							latencySpreadMs?: int64
+							// The probability that the query fails, between 0 and 1
+							errorProbability?: float64
+							// The probability that each frame of the query is corrupted, between 0 and 1
+							corruptionProbability?: float64
+							// How the frames are corrupted
+							corruptionType?: "nulls" | "nan" | "truncate" | "drop_field"
+							// The seed of the random numbers, the query behaves the same on every run with a seed
+							seed?: int64
+						} @cuetsy(kind="interface")
+
 						// TODO: Should this live here given it's not used in the dataquery?
 						#Scenario: {
 							id:              string
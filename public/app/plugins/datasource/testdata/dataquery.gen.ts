@@ -15,6 +15,7 @@ export const DataQueryModelVersion = Object.freeze([0, 0]);
 export enum TestDataQueryType {
   Annotations = 'annotations',
   Arrow = 'arrow',
+  Chaos = 'chaos',
   CSVContent = 'csv_content',
   CSVFile = 'csv_file',
   CSVMetricValues = 'csv_metric_values',
@@ -96,6 +97,37 @@ export interface CSVWave {
   valuesCSV?: string;
 }
 
+export interface ChaosQuery {
+  /**
+   * The probability that each frame of the query is corrupted, between 0 and 1
+   */
+  corruptionProbability?: number;
+  /**
+   * How the frames are corrupted
+   */
+  corruptionType?: ('nulls' | 'nan' | 'truncate' | 'drop_field');
+  /**
+   * The probability that the query fails, between 0 and 1
+   */
+  errorProbability?: number;
+  /**
+   * The distribution of the latency injected before the response
+   */
+  latencyDistribution?: ('none' | 'constant' | 'uniform' | 'normal' | 'exponential');
+  /**
+   * The latency in milliseconds, the minimum of the uniform distribution and the mean of the others
+   */
+  latencyMs?: number;
+  /**
+   * The spread of the latency in milliseconds, the width of the uniform distribution and the standard deviation of the normal one
+   */
+  latencySpreadMs?: number;
+  /**
+   * The seed of the random numbers, the query behaves the same on every run with a seed
+   */
+  seed?: number;
+}
+
 /**
  * TODO: Should this live here given it's not used in the dataquery?
  */
@@ -110,6 +142,7 @@ export interface Scenario {
 export interface TestData extends common.DataQuery {
   alias?: string;
   channel?: string;
+  chaos?: ChaosQuery;
   csvContent?: string;
   csvFileName?: string;
   csvWave?: Array<CSVWave>;
@@ -201,6 +201,8 @@ export interface PostableGrafanaRuleDefinition {
   exec_err_state: GrafanaAlertStateDecision;
   data: AlertQuery[];
   is_paused?: boolean;
+  // label selectors of the dimensions of the rule that are paused, such as {instance="server-1"}
+  paused_dimensions?: string[];
 }
 export interface GrafanaRuleDefinition extends PostableGrafanaRuleDefinition {
   id?: string;